@@ -0,0 +1,147 @@
+// Package adoption reports how widely pill is used in each tenant, so that
+// the people championing it can see where to encourage its use.
+package adoption
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// RecentlyUpdated is how long since their last update profiles are
+// considered to be up to date.
+const RecentlyUpdated = 90 * 24 * time.Hour
+
+// LeaderboardSize is the number of teams in the leaderboard.
+const LeaderboardSize = 10
+
+// A Bucket counts the profiles last updated within a range of ages.
+type Bucket struct {
+	// Days is the oldest age of profiles in the bucket, or 0 for the last
+	// bucket, which holds everything older.
+	Days  int `json:"days"`
+	Count int `json:"count"`
+}
+
+var bucketDays = []int{30, 90, 180, 365, 0}
+
+// A Team is a manager and the people who report directly to them.
+type Team struct {
+	Manager string `json:"manager"`
+	Name    string `json:"name,omitempty"`
+	// Members is the number of people in the team with a profile.
+	Members int `json:"members"`
+	// UpToDate is the number of those profiles which were updated recently.
+	UpToDate int `json:"upToDate"`
+	// Percentage of the team's profiles which are up to date.
+	Percentage int `json:"percentage"`
+}
+
+// A Report describes the adoption of pill in a domain.
+type Report struct {
+	Domain   string `json:"domain"`
+	Profiles int    `json:"profiles"`
+	// Headcount is the number of people in the domain, if it's been set in
+	// the tenant's settings, and Coverage is the percentage of them who have a
+	// profile.
+	Headcount int `json:"headcount,omitempty"`
+	Coverage  int `json:"coverage,omitempty"`
+	// WithSkills is the number of profiles with at least one skill.
+	WithSkills int `json:"withSkills"`
+	// UpToDate is the number of profiles updated recently, and UpToDatePercentage
+	// is the percentage of profiles which are up to date.
+	UpToDate           int      `json:"upToDate"`
+	UpToDatePercentage int      `json:"upToDatePercentage"`
+	Recency            []Bucket `json:"recency"`
+	// BadgesAwarded counts the badges awarded in the last 30 days.
+	BadgesAwarded int `json:"badgesAwarded"`
+	// Leaderboard lists the teams with the highest proportion of up to date
+	// profiles.
+	Leaderboard []Team `json:"leaderboard"`
+}
+
+func percentage(n, of int) int {
+	if of == 0 {
+		return 0
+	}
+	return n * 100 / of
+}
+
+// Compile creates the Report for the profiles in the domain. The headcount
+// is 0 if it isn't known.
+func Compile(domain string, profiles []dataaccess.Profile, headcount int, now time.Time) Report {
+	r := Report{
+		Domain:      domain,
+		Profiles:    len(profiles),
+		Headcount:   headcount,
+		Coverage:    percentage(len(profiles), headcount),
+		Recency:     make([]Bucket, len(bucketDays)),
+		Leaderboard: []Team{},
+	}
+	for i, d := range bucketDays {
+		r.Recency[i].Days = d
+	}
+
+	names := map[string]string{}
+	teams := map[string]*Team{}
+	for _, p := range profiles {
+		names[strings.ToLower(p.EmailAddress)] = p.Name
+
+		age := now.Sub(p.LastUpdated)
+		upToDate := age <= RecentlyUpdated
+		if upToDate {
+			r.UpToDate++
+		}
+		if len(p.Skills) > 0 {
+			r.WithSkills++
+		}
+		for i, d := range bucketDays {
+			if d == 0 || age <= time.Duration(d)*24*time.Hour {
+				r.Recency[i].Count++
+				break
+			}
+		}
+		for _, b := range p.Badges {
+			if now.Sub(b.Awarded) <= 30*24*time.Hour {
+				r.BadgesAwarded++
+			}
+		}
+
+		if p.Manager == "" {
+			continue
+		}
+		manager := strings.ToLower(p.Manager)
+		t, ok := teams[manager]
+		if !ok {
+			t = &Team{Manager: manager}
+			teams[manager] = t
+		}
+		t.Members++
+		if upToDate {
+			t.UpToDate++
+		}
+	}
+	r.UpToDatePercentage = percentage(r.UpToDate, r.Profiles)
+
+	for _, t := range teams {
+		t.Name = names[t.Manager]
+		t.Percentage = percentage(t.UpToDate, t.Members)
+		r.Leaderboard = append(r.Leaderboard, *t)
+	}
+	sort.Slice(r.Leaderboard, func(i, j int) bool {
+		a, b := r.Leaderboard[i], r.Leaderboard[j]
+		if a.Percentage != b.Percentage {
+			return a.Percentage > b.Percentage
+		}
+		if a.Members != b.Members {
+			return a.Members > b.Members
+		}
+		return a.Manager < b.Manager
+	})
+	if len(r.Leaderboard) > LeaderboardSize {
+		r.Leaderboard = r.Leaderboard[:LeaderboardSize]
+	}
+	return r
+}
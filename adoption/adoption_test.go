@@ -0,0 +1,51 @@
+package adoption
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatAdoptionIsReportedForTheDomain(t *testing.T) {
+	now := time.Date(2017, time.June, 1, 9, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) time.Time { return now.Add(-time.Duration(d) * 24 * time.Hour) }
+
+	profiles := []dataaccess.Profile{
+		{EmailAddress: "boss@github.com", Name: "Boss", LastUpdated: daysAgo(10), Skills: []dataaccess.Skill{{Skill: "go"}}},
+		{EmailAddress: "a@github.com", Manager: "boss@github.com", LastUpdated: daysAgo(40)},
+		{EmailAddress: "b@github.com", Manager: "boss@github.com", LastUpdated: daysAgo(200)},
+		{EmailAddress: "c@github.com", Manager: "lead@github.com", LastUpdated: daysAgo(400),
+			Badges: []dataaccess.Badge{{Name: "started", Awarded: daysAgo(5)}}},
+	}
+
+	r := Compile("github.com", profiles, 10, now)
+
+	if r.Profiles != 4 || r.Coverage != 40 {
+		t.Errorf("Expected 4 profiles covering 40%% of the headcount, but got %d and %d%%.", r.Profiles, r.Coverage)
+	}
+	if r.UpToDate != 2 || r.UpToDatePercentage != 50 {
+		t.Errorf("Expected 2 up to date profiles, 50%%, but got %d and %d%%.", r.UpToDate, r.UpToDatePercentage)
+	}
+	if r.WithSkills != 1 || r.BadgesAwarded != 1 {
+		t.Errorf("Expected 1 profile with skills and 1 badge awarded, but got %d and %d.", r.WithSkills, r.BadgesAwarded)
+	}
+
+	expected := []int{1, 1, 0, 1, 1}
+	for i, b := range r.Recency {
+		if b.Count != expected[i] {
+			t.Errorf("Expected %d profiles in the %d day bucket, but got %d.", expected[i], b.Days, b.Count)
+		}
+	}
+
+	if len(r.Leaderboard) != 2 || r.Leaderboard[0].Manager != "boss@github.com" || r.Leaderboard[0].Name != "Boss" || r.Leaderboard[0].Percentage != 50 {
+		t.Errorf("Expected the boss's team to lead with 50%% up to date, but got %v.", r.Leaderboard)
+	}
+}
+
+func TestThatCoverageIsOmittedWhenTheHeadcountIsUnknown(t *testing.T) {
+	r := Compile("github.com", []dataaccess.Profile{{EmailAddress: "a@github.com"}}, 0, time.Now())
+	if r.Coverage != 0 {
+		t.Errorf("Expected no coverage, but got %d%%.", r.Coverage)
+	}
+}
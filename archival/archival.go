@@ -0,0 +1,76 @@
+// Package archival moves the profiles of people who haven't used pill for a
+// while to the archive, in tenants which have switched archival on, so that
+// listings, searches and reports only cover the people who use it.
+package archival
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// Reason is recorded on the profiles which are archived by the Job.
+const Reason = "inactive"
+
+// A Job archives inactive profiles. They're kept in the archive until an
+// administrator restores them.
+type Job struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewJob creates an instance of the Job.
+func NewJob(da dataaccess.DataAccess) *Job {
+	return &Job{da, time.Now}
+}
+
+// Run archives the inactive profiles in each domain which has archival
+// enabled. People who the HR sync says have left are left to offboarding.
+// Failures to archive individual profiles are logged, and the last error is
+// returned.
+func (j *Job) Run(ctx context.Context) error {
+	now := j.now()
+
+	domains, err := j.DataAccess.ListDomains()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		settings, err := j.DataAccess.GetSettings(domain)
+		if err != nil {
+			return err
+		}
+		if !settings.Archival.Enabled {
+			continue
+		}
+
+		profiles, err := j.DataAccess.ListProfiles("@" + domain)
+		if err != nil {
+			return err
+		}
+
+		archived := 0
+		for _, p := range profiles {
+			if !settings.Archival.Inactive(p, now) || (p.Employment != nil && !p.Employment.Terminated.IsZero()) {
+				continue
+			}
+			if _, err := j.DataAccess.ArchiveProfile(p.EmailAddress, Reason, time.Time{}); err != nil {
+				log.Printf("Failed to archive the inactive profile of %s. %v", p.EmailAddress, err)
+				lastErr = err
+				continue
+			}
+			archived++
+		}
+		log.Printf("Archived %d of %d profiles in %s which have been inactive for %d months.", archived, len(profiles), domain, settings.Archival.InactiveMonths)
+	}
+
+	return lastErr
+}
@@ -0,0 +1,71 @@
+package archival
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type testDataAccess struct {
+	dataaccess.DataAccess
+	settings map[string]dataaccess.Settings
+	profiles map[string][]dataaccess.Profile
+	archived []string
+}
+
+func (da *testDataAccess) ListDomains() ([]string, error) {
+	return []string{"github.com", "example.org"}, nil
+}
+
+func (da *testDataAccess) GetSettings(domain string) (dataaccess.Settings, error) {
+	return da.settings[domain], nil
+}
+
+func (da *testDataAccess) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	return da.profiles[emailAddress[1:]], nil
+}
+
+func (da *testDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (bool, error) {
+	if reason != Reason || !purgeAfter.IsZero() {
+		return false, nil
+	}
+	da.archived = append(da.archived, emailAddress)
+	return true, nil
+}
+
+func TestThatInactiveProfilesAreArchived(t *testing.T) {
+	now := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	enabled := dataaccess.DefaultSettings()
+	enabled.Archival = dataaccess.ArchivalSettings{Enabled: true, InactiveMonths: 12}
+
+	da := &testDataAccess{
+		settings: map[string]dataaccess.Settings{
+			"github.com":  enabled,
+			"example.org": dataaccess.DefaultSettings(),
+		},
+		profiles: map[string][]dataaccess.Profile{
+			"github.com": {
+				{EmailAddress: "active@github.com", LastUpdated: now.AddDate(0, -11, 0)},
+				{EmailAddress: "inactive@github.com", LastUpdated: now.AddDate(-2, 0, 0)},
+				{EmailAddress: "restored@github.com", LastUpdated: now.AddDate(-2, 0, 0), Restored: now.AddDate(0, -1, 0)},
+				{EmailAddress: "leaver@github.com", LastUpdated: now.AddDate(-2, 0, 0), Employment: &dataaccess.Employment{Terminated: now.AddDate(0, -1, 0)}},
+			},
+			"example.org": {
+				{EmailAddress: "inactive@example.org", LastUpdated: now.AddDate(-2, 0, 0)},
+			},
+		},
+	}
+
+	j := NewJob(da)
+	j.now = func() time.Time { return now }
+	if err := j.Run(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if expected := []string{"inactive@github.com"}; !reflect.DeepEqual(da.archived, expected) {
+		t.Errorf("Expected %v to be archived, but got %v", expected, da.archived)
+	}
+}
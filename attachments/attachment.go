@@ -0,0 +1,101 @@
+package attachments
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// MaxSize is the largest attachment which can be uploaded, in bytes.
+const MaxSize = 5 << 20
+
+// A fileType is a kind of document which can be attached, recognised by the
+// bytes it starts with.
+type fileType struct {
+	contentType string
+	magic       []byte
+}
+
+// fileTypes are the kinds of document CVs are usually written in, by
+// extension.
+var fileTypes = map[string]fileType{
+	".pdf":  {"application/pdf", []byte("%PDF-")},
+	".doc":  {"application/msword", []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}},
+	".docx": {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", []byte("PK\x03\x04")},
+	".odt":  {"application/vnd.oasis.opendocument.text", []byte("PK\x03\x04")},
+	".rtf":  {"application/rtf", []byte(`{\rtf`)},
+}
+
+// ContentType checks that the file is a PDF, Word, OpenDocument or RTF
+// document no larger than the MaxSize, and returns its content type. The
+// content of the file must match its extension, so that files can't be
+// disguised as documents.
+func ContentType(fileName string, data []byte) (string, error) {
+	var problems []string
+	if len(data) == 0 {
+		problems = append(problems, "the file is empty")
+	}
+	if len(data) > MaxSize {
+		problems = append(problems, fmt.Sprintf("the file must be no larger than %dMB", MaxSize>>20))
+	}
+	ft, ok := fileTypes[strings.ToLower(filepath.Ext(fileName))]
+	if !ok {
+		problems = append(problems, "the file must be a PDF, Word, OpenDocument or RTF document")
+	} else if len(data) > 0 && !bytes.HasPrefix(data, ft.magic) {
+		problems = append(problems, "the content of the file doesn't match its extension")
+	}
+	if len(problems) > 0 {
+		return "", dataaccess.ValidationError{Problems: problems}
+	}
+	return ft.contentType, nil
+}
+
+// A Signer creates download URLs for attachments which expire, so that
+// links can be handed to browsers without the download needing a session.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner creates a Signer which signs URLs with the key.
+func NewSigner(key []byte) Signer {
+	return Signer{key}
+}
+
+func (s Signer) signature(a dataaccess.Attachment, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%d", a.Name, a.FileName, a.ContentType, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// URL returns the query string of a download URL for the attachment which
+// expires at the time.
+func (s Signer) URL(a dataaccess.Attachment, expires time.Time) url.Values {
+	e := expires.Unix()
+	return url.Values{
+		"name":      {a.Name},
+		"fileName":  {a.FileName},
+		"type":      {a.ContentType},
+		"expires":   {strconv.FormatInt(e, 10)},
+		"signature": {s.signature(a, e)},
+	}
+}
+
+// Verify returns the attachment a download URL's query string was signed
+// for, and false if the signature is wrong or the URL has expired.
+func (s Signer) Verify(q url.Values, now time.Time) (dataaccess.Attachment, bool) {
+	a := dataaccess.Attachment{Name: q.Get("name"), FileName: q.Get("fileName"), ContentType: q.Get("type")}
+	expires, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if err != nil || a.Name == "" || now.Unix() > expires {
+		return a, false
+	}
+	return a, hmac.Equal([]byte(s.signature(a, expires)), []byte(q.Get("signature")))
+}
@@ -0,0 +1,104 @@
+package attachments
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatOnlyDocumentsMatchingTheirExtensionCanBeAttached(t *testing.T) {
+	tests := []struct {
+		fileName            string
+		data                []byte
+		expectedContentType string
+	}{
+		{"cv.pdf", []byte("%PDF-1.4 ..."), "application/pdf"},
+		{"CV.PDF", []byte("%PDF-1.7 ..."), "application/pdf"},
+		{"cv.docx", []byte("PK\x03\x04..."), "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"cv.doc", []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1, 0x00}, "application/msword"},
+		{"cv.rtf", []byte(`{\rtf1\ansi ...}`), "application/rtf"},
+		{"cv.pdf", []byte("<html><script>alert(1)</script>"), ""},
+		{"cv.exe", []byte("MZ..."), ""},
+		{"cv.pdf", []byte{}, ""},
+		{"cv.pdf", append([]byte("%PDF-"), bytes.Repeat([]byte{0}, MaxSize)...), ""},
+	}
+
+	for _, test := range tests {
+		contentType, err := ContentType(test.fileName, test.data)
+		if test.expectedContentType == "" {
+			if _, ok := err.(dataaccess.ValidationError); !ok {
+				t.Errorf("For %s, expected a validation error, but got %v.", test.fileName, err)
+			}
+			continue
+		}
+		if err != nil || contentType != test.expectedContentType {
+			t.Errorf("For %s, expected %s, but got %s, %v.", test.fileName, test.expectedContentType, contentType, err)
+		}
+	}
+}
+
+func TestThatSignedURLsCanOnlyBeUsedUntilTheyExpire(t *testing.T) {
+	now := time.Date(2018, time.March, 1, 9, 0, 0, 0, time.UTC)
+	a := dataaccess.Attachment{Name: "cv/github.com/1", FileName: "cv.pdf", ContentType: "application/pdf"}
+	s := NewSigner([]byte("key"))
+	q := s.URL(a, now.Add(time.Minute))
+
+	if v, ok := s.Verify(q, now); !ok || v != a {
+		t.Errorf("Expected the URL to download %v, but got %v, %v.", a, v, ok)
+	}
+	if _, ok := s.Verify(q, now.Add(2*time.Minute)); ok {
+		t.Error("Expected the URL to have expired.")
+	}
+	if _, ok := NewSigner([]byte("other")).Verify(q, now); ok {
+		t.Error("Expected a URL signed with another key to be rejected.")
+	}
+
+	q.Set("name", "cv/github.com/2")
+	if _, ok := s.Verify(q, now); ok {
+		t.Error("Expected a URL for another attachment to be rejected.")
+	}
+}
+
+type memoryStore map[string][]byte
+
+func (s memoryStore) Put(name string, data []byte) error {
+	s[name] = data
+	return nil
+}
+
+func (s memoryStore) Get(name string) ([]byte, error) {
+	return s[name], nil
+}
+
+func (s memoryStore) Delete(name string) error {
+	delete(s, name)
+	return nil
+}
+
+func TestThatFilesAreKeptInTheStoreOfTheTenantsShard(t *testing.T) {
+	main, eu := memoryStore{}, memoryStore{}
+	shardOf := func(tenant string) (string, error) {
+		if tenant == "example.de" {
+			return "eu", nil
+		}
+		return dataaccess.DefaultShard, nil
+	}
+	s := NewShardedStore(map[string]Store{dataaccess.DefaultShard: main, "eu": eu}, shardOf)
+
+	s.Put("cv/example.de/1", []byte("eu"))
+	s.Put("cv/github.com/2", []byte("us"))
+	s.Put("exports/3", []byte("legacy"))
+
+	if len(eu) != 1 || len(main) != 2 {
+		t.Errorf("Expected the tenant's file to be kept in its shard's store, but the stores were %v and %v", eu, main)
+	}
+	if data, _ := s.Get("cv/example.de/1"); string(data) != "eu" {
+		t.Errorf("Expected the file to be read from the tenant's shard, but was '%s'", data)
+	}
+	s.Delete("cv/example.de/1")
+	if len(eu) != 0 {
+		t.Errorf("Expected the file to be deleted from the tenant's shard.")
+	}
+}
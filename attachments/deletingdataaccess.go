@@ -0,0 +1,118 @@
+package attachments
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// DeletingDataAccess wraps a DataAccess and deletes people's CVs from the
+// store when their profiles are deleted, merged into another profile or
+// purged from the archive, so that no file outlives its profile.
+type DeletingDataAccess struct {
+	dataaccess.DataAccess
+	Store Store
+}
+
+// NewDeletingDataAccess creates a DataAccess which deletes the files of
+// deleted profiles from the store.
+func NewDeletingDataAccess(da dataaccess.DataAccess, store Store) *DeletingDataAccess {
+	return &DeletingDataAccess{da, store}
+}
+
+// WithContext passes the context to the wrapped DataAccess.
+func (da DeletingDataAccess) WithContext(ctx context.Context) dataaccess.DataAccess {
+	return &DeletingDataAccess{dataaccess.WithContext(da.DataAccess, ctx), da.Store}
+}
+
+// delete deletes the files. The profiles have already been deleted, so a
+// failure is logged rather than returned.
+func (da DeletingDataAccess) delete(names map[string]bool) {
+	for name := range names {
+		if err := da.Store.Delete(name); err != nil {
+			log.Printf("Failed to delete the file %s. %v", name, err)
+		}
+	}
+}
+
+// files returns the names of the person's files, in their profile and any
+// archived copy of it.
+func (da DeletingDataAccess) files(emailAddress string) (map[string]bool, error) {
+	names := make(map[string]bool)
+	p, found, err := da.DataAccess.GetProfile(emailAddress)
+	if err != nil {
+		return nil, err
+	}
+	if found && p.CV != nil {
+		names[p.CV.Name] = true
+	}
+	archived, err := da.DataAccess.ListArchivedProfiles(dataaccess.GetDomain(emailAddress))
+	if err != nil {
+		return nil, err
+	}
+	for _, ap := range archived {
+		if strings.EqualFold(ap.EmailAddress, emailAddress) && ap.CV != nil {
+			names[ap.CV.Name] = true
+		}
+	}
+	return names, nil
+}
+
+// DeleteProfile deletes the profile, and then the person's files.
+func (da DeletingDataAccess) DeleteProfile(emailAddress string) (bool, error) {
+	names, err := da.files(emailAddress)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := da.DataAccess.DeleteProfile(emailAddress)
+	if err == nil && deleted {
+		da.delete(names)
+	}
+	return deleted, err
+}
+
+// MergeProfiles merges the profiles, and then deletes the duplicate's files
+// which weren't kept by the merged profile.
+func (da DeletingDataAccess) MergeProfiles(primary string, duplicate string) (*dataaccess.Profile, bool, error) {
+	names, err := da.files(duplicate)
+	if err != nil {
+		return nil, false, err
+	}
+	p, found, err := da.DataAccess.MergeProfiles(primary, duplicate)
+	if err != nil || !found {
+		return p, found, err
+	}
+	if p.CV != nil {
+		delete(names, p.CV.Name)
+	}
+	da.delete(names)
+	return p, found, nil
+}
+
+// PurgeArchivedProfiles purges the archived profiles, and then their files.
+func (da DeletingDataAccess) PurgeArchivedProfiles(before time.Time) (int, error) {
+	domains, err := da.DataAccess.ListDomains()
+	if err != nil {
+		return 0, err
+	}
+	names := make(map[string]bool)
+	for _, domain := range domains {
+		archived, err := da.DataAccess.ListArchivedProfiles(domain)
+		if err != nil {
+			return 0, err
+		}
+		for _, ap := range archived {
+			if ap.CV != nil && !ap.PurgeAfter.IsZero() && ap.PurgeAfter.Before(before) {
+				names[ap.CV.Name] = true
+			}
+		}
+	}
+	purged, err := da.DataAccess.PurgeArchivedProfiles(before)
+	if err == nil {
+		da.delete(names)
+	}
+	return purged, err
+}
@@ -0,0 +1,100 @@
+package attachments
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type profileStore struct {
+	dataaccess.DataAccess
+	profiles map[string]dataaccess.Profile
+	archived []dataaccess.ArchivedProfile
+}
+
+func (s *profileStore) GetProfile(emailAddress string) (*dataaccess.Profile, bool, error) {
+	p, ok := s.profiles[emailAddress]
+	return &p, ok, nil
+}
+
+func (s *profileStore) DeleteProfile(emailAddress string) (bool, error) {
+	_, ok := s.profiles[emailAddress]
+	delete(s.profiles, emailAddress)
+	return ok, nil
+}
+
+func (s *profileStore) MergeProfiles(primary string, duplicate string) (*dataaccess.Profile, bool, error) {
+	merged := dataaccess.CombineProfiles(s.profiles[primary], s.profiles[duplicate])
+	s.profiles[primary] = merged
+	delete(s.profiles, duplicate)
+	return &merged, true, nil
+}
+
+func (s *profileStore) ListDomains() ([]string, error) {
+	return []string{"github.com"}, nil
+}
+
+func (s *profileStore) ListArchivedProfiles(domain string) ([]dataaccess.ArchivedProfile, error) {
+	return s.archived, nil
+}
+
+func (s *profileStore) PurgeArchivedProfiles(before time.Time) (int, error) {
+	var kept []dataaccess.ArchivedProfile
+	for _, ap := range s.archived {
+		if ap.PurgeAfter.IsZero() || !ap.PurgeAfter.Before(before) {
+			kept = append(kept, ap)
+		}
+	}
+	purged := len(s.archived) - len(kept)
+	s.archived = kept
+	return purged, nil
+}
+
+func withCV(emailAddress string, name string) dataaccess.Profile {
+	return dataaccess.Profile{EmailAddress: emailAddress, Domain: "github.com", CV: &dataaccess.Attachment{Name: name}}
+}
+
+func TestThatFilesAreDeletedWithTheirProfiles(t *testing.T) {
+	store := memoryStore{"cv/github.com/1": nil, "cv/github.com/2": nil, "cv/github.com/3": nil, "cv/github.com/4": nil, "cv/github.com/5": nil}
+	now := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	ps := &profileStore{
+		profiles: map[string]dataaccess.Profile{
+			"deleted@github.com":   withCV("deleted@github.com", "cv/github.com/1"),
+			"primary@github.com":   withCV("primary@github.com", "cv/github.com/2"),
+			"duplicate@github.com": withCV("duplicate@github.com", "cv/github.com/3"),
+		},
+		archived: []dataaccess.ArchivedProfile{
+			{Profile: withCV("deleted@github.com", "cv/github.com/4")},
+			{Profile: withCV("left@github.com", "cv/github.com/5"), PurgeAfter: now.Add(-time.Hour)},
+		},
+	}
+	da := NewDeletingDataAccess(ps, store)
+
+	if _, err := da.DeleteProfile("deleted@github.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store["cv/github.com/1"]; ok {
+		t.Error("Expected the CV of the deleted profile to be deleted.")
+	}
+	if _, ok := store["cv/github.com/4"]; ok {
+		t.Error("Expected the CV of the archived copy of the deleted profile to be deleted.")
+	}
+
+	if _, _, err := da.MergeProfiles("primary@github.com", "duplicate@github.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store["cv/github.com/3"]; ok {
+		t.Error("Expected the duplicate's CV to be deleted, since the primary has its own.")
+	}
+	if _, ok := store["cv/github.com/2"]; !ok {
+		t.Error("Expected the primary's CV to be kept.")
+	}
+
+	if _, err := da.PurgeArchivedProfiles(now); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store["cv/github.com/5"]; ok {
+		t.Error("Expected the CV of the purged profile to be deleted.")
+	}
+}
@@ -0,0 +1,81 @@
+package attachments
+
+import (
+	"io/ioutil"
+	"log"
+
+	mgo "gopkg.in/mgo.v2"
+)
+
+// A GridFSStore holds attachments in MongoDB's GridFS, so that no other
+// storage is needed.
+type GridFSStore struct {
+	connectionString string
+	databaseName     string
+	prefix           string
+}
+
+// NewGridFSStore creates a GridFSStore. Files are held in the prefix.files
+// and prefix.chunks collections of the database.
+func NewGridFSStore(connectionString string, databaseName string, prefix string) *GridFSStore {
+	return &GridFSStore{connectionString, databaseName, prefix}
+}
+
+func (s GridFSStore) dial() (*mgo.Session, error) {
+	session, err := mgo.Dial(s.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+	}
+	return session, err
+}
+
+// Put writes the attachment, replacing any previous file with the name.
+func (s GridFSStore) Put(name string, data []byte) error {
+	session, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	fs := session.DB(s.databaseName).GridFS(s.prefix)
+	if err := fs.Remove(name); err != nil {
+		return err
+	}
+	f, err := fs.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Abort()
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Get reads the attachment.
+func (s GridFSStore) Get(name string) ([]byte, error) {
+	session, err := s.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	f, err := session.DB(s.databaseName).GridFS(s.prefix).Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// Delete removes the attachment.
+func (s GridFSStore) Delete(name string) error {
+	session, err := s.dial()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(s.databaseName).GridFS(s.prefix).Remove(name)
+}
@@ -0,0 +1,135 @@
+// Package attachments holds the files uploaded to profiles, such as CVs,
+// and checks that they are the kinds of file people expect to download.
+package attachments
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/a-h/pill/backup"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Store holds the contents of attachments by name.
+type Store interface {
+	Put(name string, data []byte) error
+	Get(name string) ([]byte, error)
+	Delete(name string) error
+}
+
+// DefaultPrefix is the GridFS prefix attachments are stored under when the
+// store URL doesn't include one.
+const DefaultPrefix = "attachments"
+
+// OpenStore returns the store at the URL:
+//
+//	gridfs:///attachments
+//	s3://bucket/prefix
+//	gs://bucket/prefix
+//	azure://account/container/prefix
+//
+// GridFS stores attachments in pill's own database, at the connection
+// string. The other stores are opened in the same way as backup stores, and
+// read their credentials from the same environment variables.
+func OpenStore(rawurl string, connectionString string, databaseName string) (Store, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "gridfs" {
+		prefix := strings.Trim(u.Path, "/")
+		if prefix == "" {
+			prefix = DefaultPrefix
+		}
+		return NewGridFSStore(connectionString, databaseName, prefix), nil
+	}
+	return backup.OpenStore(rawurl)
+}
+
+// ShardPlaceholder is replaced by the name of each shard in the URL of an
+// object store, so that each shard's attachments can be kept in a bucket in
+// the shard's region, e.g. s3://pill-{shard}/attachments.
+const ShardPlaceholder = "{shard}"
+
+// OpenShardStores opens a store for each shard, keyed by the shard's name,
+// from the MongoDB connection strings of the shards, which must include the
+// default shard. GridFS stores are held in each shard's own cluster. Object
+// stores are opened from the URL with the ShardPlaceholder replaced by the
+// shard's name, or share one store if it has no placeholder.
+func OpenShardStores(rawurl string, shards map[string]string, databaseName string) (map[string]Store, error) {
+	stores := make(map[string]Store, len(shards))
+	for name, connectionString := range shards {
+		s, err := OpenStore(strings.Replace(rawurl, ShardPlaceholder, name, -1), connectionString, databaseName)
+		if err != nil {
+			return nil, fmt.Errorf("attachments: failed to open the store of shard %s: %v", name, err)
+		}
+		stores[name] = s
+	}
+	return stores, nil
+}
+
+// A ShardedStore keeps each tenant's files in the store of the shard which
+// holds the tenant's data, so that they stay in the same region as the
+// tenant's profiles. Files must be named kind/tenant/id, e.g.
+// cv/example.com/5b1f..., files without a tenant are kept in the default
+// shard's store.
+type ShardedStore struct {
+	stores  map[string]Store
+	shardOf func(tenant string) (string, error)
+}
+
+// NewShardedStore creates a ShardedStore. shardOf returns the name of the
+// shard a tenant is assigned to.
+func NewShardedStore(stores map[string]Store, shardOf func(tenant string) (string, error)) *ShardedStore {
+	return &ShardedStore{stores, shardOf}
+}
+
+func (s *ShardedStore) store(name string) (Store, error) {
+	shard := dataaccess.DefaultShard
+	if parts := strings.SplitN(name, "/", 3); len(parts) == 3 {
+		var err error
+		if shard, err = s.shardOf(parts[1]); err != nil {
+			return nil, err
+		}
+	}
+	st, ok := s.stores[shard]
+	if !ok {
+		return nil, fmt.Errorf("%v: %s", dataaccess.ErrUnknownShard, shard)
+	}
+	return st, nil
+}
+
+// Put writes the file to the store of the tenant's shard.
+func (s *ShardedStore) Put(name string, data []byte) error {
+	st, err := s.store(name)
+	if err != nil {
+		return err
+	}
+	return st.Put(name, data)
+}
+
+// Get reads the file from the store of the tenant's shard.
+func (s *ShardedStore) Get(name string) ([]byte, error) {
+	st, err := s.store(name)
+	if err != nil {
+		return nil, err
+	}
+	return st.Get(name)
+}
+
+// Delete removes the file from the store of the tenant's shard.
+func (s *ShardedStore) Delete(name string) error {
+	st, err := s.store(name)
+	if err != nil {
+		return err
+	}
+	return st.Delete(name)
+}
+
+// Shard returns the store of the shard with the name, e.g. for moving a
+// tenant's files between shards.
+func (s *ShardedStore) Shard(name string) (Store, bool) {
+	st, ok := s.stores[name]
+	return st, ok
+}
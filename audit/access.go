@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxAccessEntries is the most entries of the access log read for an
+// AccessReport.
+const MaxAccessEntries = 10000
+
+// An AccessReport lists who has viewed, listed or exported a person's
+// profile.
+type AccessReport struct {
+	EmailAddress string    `json:"emailAddress"`
+	Since        time.Time `json:"since"`
+	// Accesses are grouped by who accessed the profile and how, most
+	// recent first.
+	Accesses []Access `json:"accesses"`
+}
+
+// An Access is the number of times someone viewed, listed or exported a
+// profile.
+type Access struct {
+	Actor string `json:"actor"`
+	// Impersonator is the administrator who accessed the profile while
+	// impersonating the Actor, if any.
+	Impersonator string    `json:"impersonator,omitempty"`
+	Action       string    `json:"action"`
+	Count        int       `json:"count"`
+	Last         time.Time `json:"last"`
+}
+
+// GetAccessReport returns who has viewed, listed or exported the profile with
+// the email address since the time, from the access log.
+func GetAccessReport(l Log, emailAddress string, since time.Time) (AccessReport, error) {
+	r := AccessReport{EmailAddress: strings.ToLower(emailAddress), Since: since, Accesses: []Access{}}
+	entries, err := l.List(Query{Target: r.EmailAddress, Since: since, Limit: MaxAccessEntries})
+	if err != nil {
+		return r, err
+	}
+
+	type key struct{ actor, impersonator, action string }
+	accesses := make(map[key]*Access)
+	for _, e := range entries {
+		if e.Action != ProfileViewed && e.Action != ProfileListed && e.Action != ProfileExported {
+			continue
+		}
+		k := key{e.Actor, e.Impersonator, e.Action}
+		a, ok := accesses[k]
+		if !ok {
+			a = &Access{Actor: e.Actor, Impersonator: e.Impersonator, Action: e.Action}
+			accesses[k] = a
+		}
+		a.Count++
+		if e.Time.After(a.Last) {
+			a.Last = e.Time.UTC()
+		}
+	}
+	for _, a := range accesses {
+		r.Accesses = append(r.Accesses, *a)
+	}
+	sort.Slice(r.Accesses, func(i, j int) bool {
+		if !r.Accesses[i].Last.Equal(r.Accesses[j].Last) {
+			return r.Accesses[i].Last.After(r.Accesses[j].Last)
+		}
+		return r.Accesses[i].Actor < r.Accesses[j].Actor
+	})
+	return r, nil
+}
@@ -0,0 +1,66 @@
+package audit
+
+// The actions recorded in the audit log.
+const (
+	ProfileUpdated             = "profile.updated"
+	ProfileDeleted             = "profile.deleted"
+	ProfileImported            = "profile.imported"
+	ProfileSynced              = "profile.synced"
+	ProfileArchived            = "profile.archived"
+	ProfileRestored            = "profile.restored"
+	ProfilesPurged             = "profile.purged"
+	SessionsRevoked            = "sessions.revoked"
+	SkillTagsAdded             = "skilltags.added"
+	SkillTagsImported          = "skilltags.imported"
+	SkillTagsMerged            = "skilltags.merged"
+	SkillTagsDeleted           = "skilltags.deleted"
+	SkillTagsRestored          = "skilltags.restored"
+	SkillTagsPurged            = "skilltags.purged"
+	SkillTagDescriptorsSet     = "skilltags.descriptorsset"
+	ConfigurationDeleted       = "configuration.deleted"
+	SessionKeyRotated          = "configuration.sessionkeyrotated"
+	FeatureFlagSet             = "configuration.featureflagset"
+	TenantConfigurationUpdated = "tenantconfiguration.updated"
+	TenantConfigurationDeleted = "tenantconfiguration.deleted"
+	ImpersonationStarted       = "impersonation.started"
+	ImpersonationEnded         = "impersonation.ended"
+	AnomalyDetected            = "anomaly.detected"
+	CalibrationProposed        = "calibration.proposed"
+	CalibrationDecided         = "calibration.decided"
+	QuarantineReleased         = "quarantine.released"
+	QuarantineRejected         = "quarantine.rejected"
+	ApprovalRequested          = "approval.requested"
+	ApprovalGranted            = "approval.granted"
+	ApprovalDenied             = "approval.denied"
+	ClearanceViewed            = "clearance.viewed"
+	ClearanceUpdated           = "clearance.updated"
+	ProfileViewed              = "profile.viewed"
+	ProfileExported            = "profile.exported"
+	ProfileListed              = "profile.listed"
+	ConsentRecorded            = "consent.recorded"
+	ReportDefinitionSaved      = "reportdefinition.saved"
+	ReportDefinitionDeleted    = "reportdefinition.deleted"
+	ExportRequested            = "export.requested"
+	ProfilesMerged             = "profile.merged"
+	ExternalIDsUpdated         = "profile.externalidsupdated"
+	WorkLocationUpdated        = "profile.worklocationupdated"
+	WorkingHoursUpdated        = "profile.workinghoursupdated"
+	AvailabilityWindowsUpdated = "profile.availabilitywindowsupdated"
+	ProjectSaved               = "project.saved"
+	ProjectDeleted             = "project.deleted"
+	CourseSaved                = "course.saved"
+	CourseDeleted              = "course.deleted"
+	CourseCompletionRecorded   = "coursecompletion.recorded"
+	CourseCompletionDecided    = "coursecompletion.decided"
+	LearningGoalsUpdated       = "profile.learninggoalsupdated"
+	SkillsConfirmed            = "profile.skillsconfirmed"
+	TagProposed                = "tagproposal.proposed"
+	TagProposalDecided         = "tagproposal.decided"
+	CVUpdated                  = "profile.cvupdated"
+	ShareLinkCreated           = "sharelink.created"
+	ShareLinkDeleted           = "sharelink.deleted"
+	BookingAdded               = "booking.added"
+	BookingRemoved             = "booking.removed"
+	LanguagesUpdated           = "profile.languagesupdated"
+	CustomFieldsUpdated        = "profile.customfieldsupdated"
+)
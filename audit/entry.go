@@ -0,0 +1,48 @@
+// Package audit records who changed what, and when.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/a-h/pill/caller"
+)
+
+// SystemActor is the actor recorded for actions carried out without a
+// caller, e.g. by scheduled jobs.
+const SystemActor = "system"
+
+// An Entry records a single action.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Actor is the email address of the user who carried out the action.
+	Actor string `json:"actor"`
+	// Impersonator is the email address of the administrator acting as the
+	// Actor, if the action was carried out during impersonation.
+	Impersonator string `json:"impersonator,omitempty"`
+	// Tenant is the domain affected by the action, or empty if the action
+	// affects all domains.
+	Tenant  string `json:"tenant,omitempty"`
+	Action  string `json:"action"`
+	Target  string `json:"target,omitempty"`
+	Details string `json:"details,omitempty"`
+}
+
+// NewEntry creates an Entry for an action carried out by the context's
+// caller.
+func NewEntry(ctx context.Context, action string, tenant string, target string) Entry {
+	e := Entry{
+		Time:   time.Now().UTC(),
+		Actor:  SystemActor,
+		Tenant: tenant,
+		Action: action,
+		Target: target,
+	}
+
+	if c, ok := caller.FromContext(ctx); ok {
+		e.Actor = c.EmailAddress
+		e.Impersonator = c.Impersonator
+	}
+
+	return e
+}
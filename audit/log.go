@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"sync"
+	"time"
+)
+
+// A Query filters the entries returned by a Log. Empty fields match all
+// entries.
+type Query struct {
+	Tenant string
+	Actor  string
+	Target string
+	Since  time.Time
+	// Limit is the maximum number of entries to return.
+	Limit int
+}
+
+// DefaultLimit is the number of entries returned when a Query has no Limit.
+const DefaultLimit = 100
+
+func (q Query) limit() int {
+	if q.Limit <= 0 {
+		return DefaultLimit
+	}
+	return q.Limit
+}
+
+func (q Query) matches(e Entry) bool {
+	return (q.Tenant == "" || q.Tenant == e.Tenant) &&
+		(q.Actor == "" || q.Actor == e.Actor || q.Actor == e.Impersonator) &&
+		(q.Target == "" || q.Target == e.Target) &&
+		!e.Time.Before(q.Since)
+}
+
+// A Log stores audit entries.
+type Log interface {
+	Record(e Entry) error
+	// List returns the entries matching the query, newest first.
+	List(q Query) ([]Entry, error)
+}
+
+// A BatchRecorder adds many entries to a Log at once, e.g. an entry for each
+// of the thousands of people in an export.
+type BatchRecorder interface {
+	RecordAll(entries []Entry) error
+}
+
+// RecordAll adds the entries to the log, at once if it is a BatchRecorder.
+func RecordAll(l Log, entries []Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if b, ok := l.(BatchRecorder); ok {
+		return b.RecordAll(entries)
+	}
+	for _, e := range entries {
+		if err := l.Record(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A Pruner removes old entries from a Log, e.g. to meet a retention policy.
+type Pruner interface {
+	// Prune removes the entries recorded before the time, returning how
+	// many were removed.
+	Prune(before time.Time) (int, error)
+}
+
+// MemoryLog is a Log held in memory.
+type MemoryLog struct {
+	mutex   sync.Mutex
+	entries []Entry
+}
+
+// NewMemoryLog creates an instance of the MemoryLog.
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{}
+}
+
+// Record adds the entry to the log.
+func (l *MemoryLog) Record(e Entry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.entries = append(l.entries, e)
+	return nil
+}
+
+// RecordAll adds the entries to the log.
+func (l *MemoryLog) RecordAll(entries []Entry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.entries = append(l.entries, entries...)
+	return nil
+}
+
+// List returns the entries matching the query, newest first.
+func (l *MemoryLog) List(q Query) ([]Entry, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var entries []Entry
+	for i := len(l.entries) - 1; i >= 0 && len(entries) < q.limit(); i-- {
+		if q.matches(l.entries[i]) {
+			entries = append(entries, l.entries[i])
+		}
+	}
+	return entries, nil
+}
+
+// Prune removes the entries recorded before the time.
+func (l *MemoryLog) Prune(before time.Time) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	var kept []Entry
+	for _, e := range l.entries {
+		if !e.Time.Before(before) {
+			kept = append(kept, e)
+		}
+	}
+	n := len(l.entries) - len(kept)
+	l.entries = kept
+	return n, nil
+}
@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/caller"
+)
+
+func TestThatEntriesRecordTheCallerAndImpersonator(t *testing.T) {
+	ctx := caller.NewContext(context.Background(), caller.Caller{EmailAddress: "a-h@github.com", Impersonator: "admin@github.com"})
+
+	e := NewEntry(ctx, "profile.updated", "github.com", "a-h@github.com")
+
+	if e.Actor != "a-h@github.com" || e.Impersonator != "admin@github.com" {
+		t.Errorf("Expected the actor and impersonator to be recorded, but received %v", e)
+	}
+
+	if e := NewEntry(context.Background(), "skilltags.added", "", "go"); e.Actor != SystemActor {
+		t.Errorf("Expected actions without a caller to be recorded as the system, but was %s", e.Actor)
+	}
+}
+
+func TestThatTheMemoryLogFiltersEntriesNewestFirst(t *testing.T) {
+	now := time.Now()
+	l := NewMemoryLog()
+	l.Record(Entry{Time: now.Add(-2 * time.Hour), Actor: "a-h@github.com", Tenant: "github.com", Action: "one"})
+	l.Record(Entry{Time: now.Add(-1 * time.Hour), Actor: "someone@example.com", Tenant: "example.com", Action: "two"})
+	l.Record(Entry{Time: now, Actor: "a-h@github.com", Impersonator: "admin@github.com", Tenant: "github.com", Action: "three"})
+
+	tests := []struct {
+		query    Query
+		expected []string
+	}{
+		{Query{}, []string{"three", "two", "one"}},
+		{Query{Tenant: "github.com"}, []string{"three", "one"}},
+		{Query{Actor: "admin@github.com"}, []string{"three"}},
+		{Query{Since: now.Add(-90 * time.Minute)}, []string{"three", "two"}},
+		{Query{Limit: 1}, []string{"three"}},
+	}
+
+	for _, test := range tests {
+		entries, _ := l.List(test.query)
+
+		var actual []string
+		for _, e := range entries {
+			actual = append(actual, e.Action)
+		}
+
+		if len(actual) != len(test.expected) {
+			t.Errorf("For query %v, expected %v, but received %v", test.query, test.expected, actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != test.expected[i] {
+				t.Errorf("For query %v, expected %v, but received %v", test.query, test.expected, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestThatPruningRemovesEntriesBeforeTheTime(t *testing.T) {
+	now := time.Now()
+	l := NewMemoryLog()
+	l.Record(Entry{Time: now.Add(-48 * time.Hour), Action: "old"})
+	l.Record(Entry{Time: now, Action: "new"})
+
+	n, err := l.Prune(now.Add(-24 * time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("Expected 1 entry to be pruned, but received %d, %v", n, err)
+	}
+	if entries, _ := l.List(Query{}); len(entries) != 1 || entries[0].Action != "new" {
+		t.Errorf("Expected only the new entry to be kept, but received %v", entries)
+	}
+}
+
+func TestThatTheAccessReportGroupsViewsAndExports(t *testing.T) {
+	now := time.Date(2018, time.September, 3, 9, 0, 0, 0, time.UTC)
+	l := NewMemoryLog()
+	l.Record(Entry{Time: now.AddDate(0, 0, -40), Actor: "old@github.com", Action: ProfileViewed, Target: "dev@github.com"})
+	l.Record(Entry{Time: now.Add(-2 * time.Hour), Actor: "boss@github.com", Action: ProfileViewed, Target: "dev@github.com"})
+	l.Record(Entry{Time: now.Add(-1 * time.Hour), Actor: "boss@github.com", Action: ProfileViewed, Target: "dev@github.com"})
+	l.Record(Entry{Time: now, Actor: "sales@github.com", Action: ProfileExported, Target: "dev@github.com"})
+	l.Record(Entry{Time: now, Actor: "boss@github.com", Action: ProfileViewed, Target: "other@github.com"})
+
+	r, err := GetAccessReport(l, "Dev@github.com", now.AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	expected := []Access{
+		{Actor: "sales@github.com", Action: ProfileExported, Count: 1, Last: now},
+		{Actor: "boss@github.com", Action: ProfileViewed, Count: 2, Last: now.Add(-1 * time.Hour)},
+	}
+	if len(r.Accesses) != len(expected) || r.Accesses[0] != expected[0] || r.Accesses[1] != expected[1] {
+		t.Errorf("Expected %v, but received %v", expected, r.Accesses)
+	}
+}
+
+func TestThatTheShardedLogKeepsEntriesInTheTenantsShard(t *testing.T) {
+	now := time.Now()
+	main, eu := NewMemoryLog(), NewMemoryLog()
+	shardOf := func(tenant string) (string, error) {
+		if tenant == "example.de" {
+			return "eu", nil
+		}
+		return "default", nil
+	}
+	l := NewShardedLog(main, map[string]Log{"eu": eu}, shardOf)
+
+	l.Record(Entry{Time: now.Add(-time.Hour), Tenant: "example.de", Action: "one"})
+	l.Record(Entry{Time: now, Tenant: "github.com", Action: "two"})
+	l.Record(Entry{Time: now.Add(-2 * time.Hour), Action: "three"})
+
+	if len(eu.entries) != 1 || eu.entries[0].Action != "one" {
+		t.Errorf("Expected the tenant's entry to be kept in its shard, but was %v", eu.entries)
+	}
+	if len(main.entries) != 2 {
+		t.Errorf("Expected the other entries to be kept in the main log, but was %v", main.entries)
+	}
+
+	entries, _ := l.List(Query{})
+	if len(entries) != 3 || entries[0].Action != "two" || entries[1].Action != "one" || entries[2].Action != "three" {
+		t.Errorf("Expected the entries of every shard, newest first, but received %v", entries)
+	}
+
+	if n, _ := l.Prune(now.Add(-30 * time.Minute)); n != 2 {
+		t.Errorf("Expected the old entries of every shard to be pruned, but %d were", n)
+	}
+}
@@ -0,0 +1,135 @@
+package audit
+
+import (
+	"log"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MongoLog is a Log which stores entries in a MongoDB collection.
+type MongoLog struct {
+	connectionString string
+	databaseName     string
+	collection       string
+}
+
+// NewMongoLog creates a MongoLog which stores entries in the "auditlog"
+// collection.
+func NewMongoLog(connectionString string, databaseName string) *MongoLog {
+	return &MongoLog{connectionString, databaseName, "auditlog"}
+}
+
+// NewMongoAccessLog creates a MongoLog which stores entries in the
+// "accesslog" collection, keeping the high volume of read access entries
+// apart from the changes in the audit log.
+func NewMongoAccessLog(connectionString string, databaseName string) *MongoLog {
+	return &MongoLog{connectionString, databaseName, "accesslog"}
+}
+
+// Record adds the entry to the log.
+func (l MongoLog) Record(e Entry) error {
+	session, err := mgo.Dial(l.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(l.databaseName).C(l.collection).Insert(e)
+}
+
+// RecordAll adds the entries to the log in one insert.
+func (l MongoLog) RecordAll(entries []Entry) error {
+	session, err := mgo.Dial(l.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return err
+	}
+	defer session.Close()
+
+	docs := make([]interface{}, len(entries))
+	for i, e := range entries {
+		docs[i] = e
+	}
+	return session.DB(l.databaseName).C(l.collection).Insert(docs...)
+}
+
+// List returns the entries matching the query, newest first.
+func (l MongoLog) List(q Query) ([]Entry, error) {
+	session, err := mgo.Dial(l.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	filter := bson.M{}
+	if q.Tenant != "" {
+		filter["tenant"] = q.Tenant
+	}
+	if q.Actor != "" {
+		filter["$or"] = []bson.M{{"actor": q.Actor}, {"impersonator": q.Actor}}
+	}
+	if q.Target != "" {
+		filter["target"] = q.Target
+	}
+	if !q.Since.IsZero() {
+		filter["time"] = bson.M{"$gte": q.Since}
+	}
+
+	var entries []Entry
+	err = session.DB(l.databaseName).C(l.collection).Find(filter).Sort("-time").Limit(q.limit()).All(&entries)
+	return entries, err
+}
+
+// Prune removes the entries recorded before the time.
+func (l MongoLog) Prune(before time.Time) (int, error) {
+	session, err := mgo.Dial(l.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return 0, err
+	}
+	defer session.Close()
+
+	info, err := session.DB(l.databaseName).C(l.collection).RemoveAll(bson.M{"time": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return info.Removed, nil
+}
+
+// MoveTenant moves the tenant's entries to another log, e.g. in the cluster
+// of the shard the tenant has been moved to. The entries are copied before
+// they are removed, so if the move fails, it can be run again.
+func (l MongoLog) MoveTenant(tenant string, to *MongoLog) (int, error) {
+	from, err := mgo.Dial(l.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return 0, err
+	}
+	defer from.Close()
+	dest, err := mgo.Dial(to.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return 0, err
+	}
+	defer dest.Close()
+
+	var entries []bson.M
+	if err := from.DB(l.databaseName).C(l.collection).Find(bson.M{"tenant": tenant}).All(&entries); err != nil {
+		return 0, err
+	}
+	c := dest.DB(to.databaseName).C(to.collection)
+	for _, e := range entries {
+		if _, err := c.UpsertId(e["_id"], e); err != nil {
+			return 0, err
+		}
+	}
+	info, err := from.DB(l.databaseName).C(l.collection).RemoveAll(bson.M{"tenant": tenant})
+	if err != nil {
+		return 0, err
+	}
+	return info.Removed, nil
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A ShardedLog keeps each tenant's entries in the log of the shard which
+// holds the tenant's data, so that they stay in the same region as the
+// tenant's profiles. Entries which affect every tenant are kept in the main
+// log.
+type ShardedLog struct {
+	main    Log
+	shards  map[string]Log
+	shardOf func(tenant string) (string, error)
+}
+
+// NewShardedLog creates a ShardedLog. shardOf returns the name of the shard
+// a tenant is assigned to. Tenants assigned to a shard without a log, such
+// as the default shard, use the main log.
+func NewShardedLog(main Log, shards map[string]Log, shardOf func(tenant string) (string, error)) *ShardedLog {
+	return &ShardedLog{main, shards, shardOf}
+}
+
+func (l *ShardedLog) log(tenant string) (Log, error) {
+	if tenant == "" {
+		return l.main, nil
+	}
+	shard, err := l.shardOf(tenant)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := l.shards[shard]; ok {
+		return s, nil
+	}
+	return l.main, nil
+}
+
+func (l *ShardedLog) all() []Log {
+	logs := []Log{l.main}
+	for _, s := range l.shards {
+		logs = append(logs, s)
+	}
+	return logs
+}
+
+// Record adds the entry to the log of the tenant's shard.
+func (l *ShardedLog) Record(e Entry) error {
+	s, err := l.log(e.Tenant)
+	if err != nil {
+		return err
+	}
+	return s.Record(e)
+}
+
+// RecordAll adds the entries to the logs of their tenants' shards, with one
+// batch for each log.
+func (l *ShardedLog) RecordAll(entries []Entry) error {
+	batches := map[Log][]Entry{}
+	var order []Log
+	for _, e := range entries {
+		s, err := l.log(e.Tenant)
+		if err != nil {
+			return err
+		}
+		if _, ok := batches[s]; !ok {
+			order = append(order, s)
+		}
+		batches[s] = append(batches[s], e)
+	}
+	for _, s := range order {
+		if err := RecordAll(s, batches[s]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the entries matching the query, newest first. Queries for a
+// tenant only read the log of its shard, others read every log.
+func (l *ShardedLog) List(q Query) ([]Entry, error) {
+	if q.Tenant != "" {
+		s, err := l.log(q.Tenant)
+		if err != nil {
+			return nil, err
+		}
+		return s.List(q)
+	}
+
+	var entries []Entry
+	for _, s := range l.all() {
+		e, err := s.List(q)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	if len(entries) > q.limit() {
+		entries = entries[:q.limit()]
+	}
+	return entries, nil
+}
+
+// Prune removes the entries recorded before the time from every log which
+// can be pruned.
+func (l *ShardedLog) Prune(before time.Time) (int, error) {
+	var pruned int
+	for _, s := range l.all() {
+		p, ok := s.(Pruner)
+		if !ok {
+			continue
+		}
+		n, err := p.Prune(before)
+		pruned += n
+		if err != nil {
+			return pruned, fmt.Errorf("audit: failed to prune a shard's log: %v", err)
+		}
+	}
+	return pruned, nil
+}
@@ -0,0 +1,116 @@
+package autocomplete
+
+import (
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+func values(suggestions []Suggestion) []string {
+	op := []string{}
+	for _, s := range suggestions {
+		op = append(op, s.Value)
+	}
+	return op
+}
+
+func TestThatKeysAreCompletedWithTypos(t *testing.T) {
+	trie := NewTrie()
+	for _, tag := range []string{"go", "google cloud", "kubernetes", "kotlin", "node.js"} {
+		addSkill(trie, dataaccess.SkillTag{Name: tag})
+	}
+	addSkill(trie, dataaccess.SkillTag{Name: "go", Aliases: []string{"golang"}})
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{"go", []string{"go", "google cloud"}},
+		{"GOLA", []string{"go"}},
+		{"kuber", []string{"kubernetes"}},
+		{"kubrenetes", []string{"kubernetes"}},
+		{"kx", []string{}},
+		{"nod", []string{"node.js"}},
+		{"", []string{}},
+	}
+	for _, test := range tests {
+		actual := values(trie.Complete(test.prefix, 10))
+		if len(actual) != len(test.expected) {
+			t.Errorf("For %q, expected %v, but received %v", test.prefix, test.expected, actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != test.expected[i] {
+				t.Errorf("For %q, expected %v, but received %v", test.prefix, test.expected, actual)
+				break
+			}
+		}
+	}
+
+	if s := trie.Complete("gola", 10); s[0].Alias != "golang" || s[0].Distance != 0 {
+		t.Errorf("Expected go to be suggested by its alias, but received %+v", s)
+	}
+	if s := trie.Complete("g", 1); len(s) != 1 {
+		t.Errorf("Expected the suggestions to be limited, but received %v", s)
+	}
+	trie.Remove("go")
+	if s := values(trie.Complete("golang", 10)); len(s) != 0 {
+		t.Errorf("Expected the removed tag and its aliases to be gone, but received %v", s)
+	}
+}
+
+type autocompleteStore struct {
+	dataaccess.DataAccess
+}
+
+func (s autocompleteStore) ListSkillTags() ([]dataaccess.SkillTag, error) {
+	return []dataaccess.SkillTag{{Name: "go"}}, nil
+}
+
+func (s autocompleteStore) ListDomains() ([]string, error) {
+	return []string{"github.com", "example.com"}, nil
+}
+
+func (s autocompleteStore) SearchDocuments(domain string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error) {
+	if domain != "github.com" {
+		return nil, nil
+	}
+	return []dataaccess.SearchDocument{{EmailAddress: "ada@github.com", Name: "Ada Lovelace"}}, nil
+}
+
+func TestThatTheIndexIsFilledAndUpdatedByEvents(t *testing.T) {
+	ix := NewIndex(autocompleteStore{})
+	if err := ix.Refresh(); err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	if s := ix.People("github.com", "lovel", 10); len(s) != 1 || s[0].Label != "Ada Lovelace" {
+		t.Errorf("Expected Ada to be found by her surname, but received %v", s)
+	}
+	if s := ix.People("example.com", "ada", 10); len(s) != 0 {
+		t.Errorf("Expected people to be limited to their tenant, but received %v", s)
+	}
+
+	ada := &dataaccess.Profile{EmailAddress: "ada@github.com", Name: "Ada King"}
+	ix.Publish(events.NewEvent(events.ProfileUpdated, "github.com", ada.EmailAddress, ada))
+	ix.Publish(events.NewEvent(events.SkillTagsAdded, "", "", []string{"rust"}))
+	if s := ix.People("github.com", "lovel", 10); len(s) != 0 {
+		t.Errorf("Expected Ada's old name to be removed, but received %v", s)
+	}
+	if s := ix.People("github.com", "king", 10); len(s) != 1 {
+		t.Errorf("Expected Ada's new name to be added, but received %v", s)
+	}
+	if s := ix.Skills("rus", 10); len(s) != 1 {
+		t.Errorf("Expected the new tag to be suggested, but received %v", s)
+	}
+
+	ix.Publish(events.NewEvent(events.ProfileDeleted, "github.com", "ada@github.com", nil))
+	ix.Publish(events.NewEvent(events.SkillTagsDeleted, "", "", []string{"go"}))
+	if s := ix.People("github.com", "ada", 10); len(s) != 0 {
+		t.Errorf("Expected the deleted profile to be removed, but received %v", s)
+	}
+	if s := ix.Skills("go", 10); len(s) != 0 {
+		t.Errorf("Expected the deleted tag to be removed, but received %v", s)
+	}
+}
@@ -0,0 +1,174 @@
+// Package autocomplete completes skill tags and people's names as they're
+// typed, from tries held in memory, so that tag and people pickers can
+// suggest matches on every key press, even with a typo.
+package autocomplete
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+// The Index holds a trie of the skill tags, and one of the people in each
+// tenant, read from the search read model. It is an events.Publisher, so
+// that it's updated by the changes made through a NotifyingDataAccess, and
+// Watch reloads it periodically, to pick up the changes made by other
+// replicas.
+type Index struct {
+	DataAccess dataaccess.DataAccess
+	// RefreshInterval determines how often Watch reloads the index.
+	RefreshInterval time.Duration
+	mutex           sync.RWMutex
+	skills          *Trie
+	people          map[string]*Trie
+}
+
+// NewIndex creates an empty Index, which is filled by Refresh.
+func NewIndex(da dataaccess.DataAccess) *Index {
+	return &Index{
+		DataAccess:      da,
+		RefreshInterval: 5 * time.Minute,
+		skills:          NewTrie(),
+		people:          map[string]*Trie{},
+	}
+}
+
+// Skills completes the skill tag, or one of its aliases.
+func (ix *Index) Skills(prefix string, limit int) []Suggestion {
+	ix.mutex.RLock()
+	defer ix.mutex.RUnlock()
+
+	return ix.skills.Complete(prefix, limit)
+}
+
+// People completes the name or email address of someone in the domain.
+func (ix *Index) People(domain string, prefix string, limit int) []Suggestion {
+	ix.mutex.RLock()
+	defer ix.mutex.RUnlock()
+
+	t, ok := ix.people[strings.ToLower(domain)]
+	if !ok {
+		return []Suggestion{}
+	}
+	return t.Complete(prefix, limit)
+}
+
+// Refresh reloads the skill tags and people.
+func (ix *Index) Refresh() error {
+	tags, err := ix.DataAccess.ListSkillTags()
+	if err != nil {
+		return err
+	}
+	skills := NewTrie()
+	for _, tag := range tags {
+		addSkill(skills, tag)
+	}
+
+	domains, err := ix.DataAccess.ListDomains()
+	if err != nil {
+		return err
+	}
+	people := make(map[string]*Trie)
+	for _, domain := range domains {
+		documents, err := ix.DataAccess.SearchDocuments(domain, dataaccess.SearchQuery{})
+		if err != nil {
+			return err
+		}
+		t := NewTrie()
+		for _, d := range documents {
+			addPerson(t, d.EmailAddress, d.Name)
+		}
+		people[strings.ToLower(domain)] = t
+	}
+
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+
+	ix.skills, ix.people = skills, people
+	return nil
+}
+
+// Watch fills the index, then refreshes it periodically until the context
+// is cancelled.
+func (ix *Index) Watch(ctx context.Context) {
+	if err := ix.Refresh(); err != nil {
+		log.Print("Failed to fill the autocomplete index. ", err)
+	}
+
+	ticker := time.NewTicker(ix.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ix.Refresh(); err != nil {
+				log.Print("Failed to refresh the autocomplete index. ", err)
+			}
+		}
+	}
+}
+
+// Publish updates the index with the change.
+func (ix *Index) Publish(e events.Event) {
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+
+	switch e.Type {
+	case events.ProfileUpdated:
+		p, ok := e.Data.(*dataaccess.Profile)
+		if !ok || p == nil {
+			return
+		}
+		domain := dataaccess.GetDomain(p.EmailAddress)
+		t, ok := ix.people[domain]
+		if !ok {
+			t = NewTrie()
+			ix.people[domain] = t
+		}
+		emailAddress := strings.ToLower(p.EmailAddress)
+		t.Remove(emailAddress)
+		addPerson(t, emailAddress, p.Name)
+	case events.ProfileDeleted:
+		if t, ok := ix.people[dataaccess.GetDomain(e.EmailAddress)]; ok {
+			t.Remove(strings.ToLower(e.EmailAddress))
+		}
+	case events.SkillTagsAdded:
+		tags, _ := e.Data.([]string)
+		for _, tag := range tags {
+			// Tags which already exist keep their aliases.
+			if _, ok := ix.skills.keys[tag]; !ok {
+				addSkill(ix.skills, dataaccess.SkillTag{Name: tag})
+			}
+		}
+	case events.SkillTagsDeleted:
+		tags, _ := e.Data.([]string)
+		for _, tag := range tags {
+			ix.skills.Remove(tag)
+		}
+	}
+}
+
+func addSkill(t *Trie, tag dataaccess.SkillTag) {
+	t.Add(Suggestion{Value: tag.Name, Label: tag.Name}, tag.Name)
+	for _, alias := range tag.Aliases {
+		t.Add(Suggestion{Value: tag.Name, Label: tag.Name, Alias: alias}, alias)
+	}
+}
+
+// addPerson adds the person under their name, each word in it, so that
+// "love" finds "Ada Lovelace", and their email address.
+func addPerson(t *Trie, emailAddress string, name string) {
+	label := name
+	if label == "" {
+		label = emailAddress
+	}
+	keys := append([]string{name, emailAddress}, strings.Fields(name)...)
+	t.Add(Suggestion{Value: emailAddress, Label: label}, keys...)
+}
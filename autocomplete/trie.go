@@ -0,0 +1,185 @@
+package autocomplete
+
+import (
+	"sort"
+	"strings"
+)
+
+// A Suggestion is a skill tag or person which completes what has been
+// typed.
+type Suggestion struct {
+	// Value is the skill tag, or the person's email address.
+	Value string `json:"value"`
+	// Label is what's shown, the tag or the person's name.
+	Label string `json:"label"`
+	// Alias is the other name of the skill which was matched, e.g. "golang"
+	// for "go".
+	Alias string `json:"alias,omitempty"`
+	// Distance is the number of typos between what was typed and the
+	// suggestion.
+	Distance int `json:"distance"`
+}
+
+// A Trie finds suggestions by the start of any of their keys, allowing a
+// few typos. It isn't safe for concurrent use.
+type Trie struct {
+	root *node
+	// keys are the keys each value was added with, so that it can be
+	// removed.
+	keys map[string][]string
+}
+
+type node struct {
+	children    map[rune]*node
+	suggestions []Suggestion
+}
+
+// NewTrie creates an empty Trie.
+func NewTrie() *Trie {
+	return &Trie{&node{}, map[string][]string{}}
+}
+
+// Add adds the suggestion under each key. Suggestions with the same value
+// are only suggested once, so a skill can be added under its name and, with
+// the Alias set, under each of its aliases.
+func (t *Trie) Add(s Suggestion, keys ...string) {
+	for _, key := range keys {
+		key = normalise(key)
+		if key == "" {
+			continue
+		}
+		n := t.root
+		for _, r := range key {
+			if n.children == nil {
+				n.children = map[rune]*node{}
+			}
+			child, ok := n.children[r]
+			if !ok {
+				child = &node{}
+				n.children[r] = child
+			}
+			n = child
+		}
+		n.suggestions = append(n.suggestions, s)
+		t.keys[s.Value] = append(t.keys[s.Value], key)
+	}
+}
+
+// Remove removes the suggestion with the value.
+func (t *Trie) Remove(value string) {
+	for _, key := range t.keys[value] {
+		n := t.root
+		for _, r := range key {
+			if n = n.children[r]; n == nil {
+				break
+			}
+		}
+		if n == nil {
+			continue
+		}
+		kept := n.suggestions[:0]
+		for _, s := range n.suggestions {
+			if s.Value != value {
+				kept = append(kept, s)
+			}
+		}
+		n.suggestions = kept
+	}
+	delete(t.keys, value)
+}
+
+// Complete returns up to limit suggestions with a key starting with the
+// prefix, or with what the prefix would be after fixing a typo or two.
+// Prefixes of 3 to 5 characters allow a typo, and longer prefixes two.
+// Suggestions without typos come first, then the shortest.
+func (t *Trie) Complete(prefix string, limit int) []Suggestion {
+	query := []rune(normalise(prefix))
+	if len(query) == 0 {
+		return []Suggestion{}
+	}
+	maxEdits := 0
+	switch {
+	case len(query) >= 6:
+		maxEdits = 2
+	case len(query) >= 3:
+		maxEdits = 1
+	}
+
+	best := make(map[string]Suggestion)
+	row := make([]int, len(query)+1)
+	for i := range row {
+		row[i] = i
+	}
+	for r, child := range t.root.children {
+		t.search(child, r, query, row, len(query), maxEdits, best)
+	}
+
+	op := []Suggestion{}
+	for _, s := range best {
+		op = append(op, s)
+	}
+	sort.Slice(op, func(i, j int) bool {
+		if op[i].Distance != op[j].Distance {
+			return op[i].Distance < op[j].Distance
+		}
+		if len(op[i].Label) != len(op[j].Label) {
+			return len(op[i].Label) < len(op[j].Label)
+		}
+		return op[i].Label < op[j].Label
+	})
+	if limit > 0 && len(op) > limit {
+		op = op[:limit]
+	}
+	return op
+}
+
+// search walks the trie, keeping the edit distances between each prefix of
+// the query and the path to the node, as in the Levenshtein algorithm. A
+// suggestion's distance is the closest any prefix of its key comes to the
+// whole query, so once that's within maxEdits, everything below the node
+// matches.
+func (t *Trie) search(n *node, r rune, query []rune, previous []int, matched int, maxEdits int, best map[string]Suggestion) {
+	row := make([]int, len(previous))
+	row[0] = previous[0] + 1
+	lowest := row[0]
+	for i := 1; i < len(row); i++ {
+		cost := 1
+		if query[i-1] == r {
+			cost = 0
+		}
+		row[i] = smallest(row[i-1]+1, previous[i]+1, previous[i-1]+cost)
+		if row[i] < lowest {
+			lowest = row[i]
+		}
+	}
+	matched = smallest(matched, row[len(query)])
+	if matched <= maxEdits {
+		for _, s := range n.suggestions {
+			s.Distance = matched
+			b, ok := best[s.Value]
+			// A skill's own name is preferred to its aliases.
+			if !ok || s.Distance < b.Distance || (s.Distance == b.Distance && b.Alias != "" && s.Alias == "") {
+				best[s.Value] = s
+			}
+		}
+	} else if lowest > maxEdits {
+		return
+	}
+	for r, child := range n.children {
+		t.search(child, r, query, row, matched, maxEdits, best)
+	}
+}
+
+func normalise(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func smallest(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
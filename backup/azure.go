@@ -0,0 +1,128 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// An AzureStore holds backups in an Azure Blob Storage container, authorised
+// with a shared access signature (SAS) token for the container.
+type AzureStore struct {
+	containerURL string
+	prefix       string
+	sasToken     string
+	Client       *http.Client
+}
+
+// NewAzureStore creates an AzureStore. The containerURL is e.g.
+// https://account.blob.core.windows.net/backups, and the SAS token must
+// grant read, write, delete and list permissions.
+func NewAzureStore(containerURL, prefix, sasToken string) *AzureStore {
+	return &AzureStore{
+		containerURL: strings.TrimSuffix(containerURL, "/"),
+		prefix:       prefix,
+		sasToken:     strings.TrimPrefix(sasToken, "?"),
+		Client:       &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (s AzureStore) do(method string, u string, body []byte, header http.Header) ([]byte, error) {
+	if s.sasToken != "" {
+		if strings.Contains(u, "?") {
+			u += "&" + s.sasToken
+		} else {
+			u += "?" + s.sasToken
+		}
+	}
+
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("x-ms-version", "2019-12-12")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("backup: %s returned %s: %s", method, resp.Status, data)
+	}
+	return data, nil
+}
+
+func (s AzureStore) blobURL(name string) string {
+	return s.containerURL + "/" + escapePath(path.Join(s.prefix, name))
+}
+
+// Put writes the backup as a block blob.
+func (s AzureStore) Put(name string, data []byte) error {
+	_, err := s.do(http.MethodPut, s.blobURL(name), data, http.Header{"X-Ms-Blob-Type": {"BlockBlob"}})
+	return err
+}
+
+// Get reads the backup.
+func (s AzureStore) Get(name string) ([]byte, error) {
+	return s.do(http.MethodGet, s.blobURL(name), nil, nil)
+}
+
+// Delete removes the backup.
+func (s AzureStore) Delete(name string) error {
+	_, err := s.do(http.MethodDelete, s.blobURL(name), nil, nil)
+	return err
+}
+
+type enumerationResults struct {
+	Blobs struct {
+		Blob []struct {
+			Name string
+		}
+	}
+	NextMarker string
+}
+
+// List returns the names of the blobs under the prefix.
+func (s AzureStore) List() ([]string, error) {
+	prefix := s.prefix
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	var names []string
+	query := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+	for {
+		data, err := s.do(http.MethodGet, s.containerURL+"?"+query.Encode(), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result enumerationResults
+		if err := xml.Unmarshal(data, &result); err != nil {
+			return nil, err
+		}
+		for _, b := range result.Blobs.Blob {
+			names = append(names, strings.TrimPrefix(b.Name, prefix))
+		}
+
+		if result.NextMarker == "" {
+			return names, nil
+		}
+		query.Set("marker", result.NextMarker)
+	}
+}
@@ -0,0 +1,201 @@
+// Package backup writes encrypted, compressed copies of pill's database to
+// object storage, and restores them.
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/encryption"
+)
+
+// DefaultRetention is how long backups are kept for, unless configured.
+const DefaultRetention = 30 * 24 * time.Hour
+
+// ErrNoBackups is returned when restoring the latest backup from a store
+// which doesn't contain any.
+var ErrNoBackups = errors.New("backup: the store doesn't contain any backups")
+
+// A Database can be dumped to, and restored from, a stream.
+type Database interface {
+	Dump(w io.Writer) error
+	Restore(r io.Reader) error
+}
+
+// A Store holds backups, e.g. an S3 bucket.
+type Store interface {
+	Put(name string, data []byte) error
+	Get(name string) ([]byte, error)
+	List() ([]string, error)
+	Delete(name string) error
+}
+
+const (
+	namePrefix = "pill-"
+	nameSuffix = ".backup"
+	nameLayout = "20060102T150405Z"
+)
+
+// Name returns the name of a backup taken at the time. Names sort in the
+// order the backups were taken.
+func Name(t time.Time) string {
+	return namePrefix + t.UTC().Format(nameLayout) + nameSuffix
+}
+
+// TimeFromName returns the time a backup was taken.
+func TimeFromName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, nameSuffix) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(nameLayout, strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), nameSuffix))
+	return t, err == nil
+}
+
+// A Backuper takes and restores backups of a database.
+type Backuper struct {
+	Database    Database
+	Store       Store
+	KeyProvider encryption.KeyProvider
+	// Retention is how long backups are kept. The latest backup is always
+	// kept.
+	Retention time.Duration
+	now       func() time.Time
+}
+
+// NewBackuper creates an instance of the Backuper.
+func NewBackuper(db Database, store Store, kp encryption.KeyProvider, retention time.Duration) *Backuper {
+	return &Backuper{
+		Database:    db,
+		Store:       store,
+		KeyProvider: kp,
+		Retention:   retention,
+		now:         time.Now,
+	}
+}
+
+// Backup dumps the database, compresses and encrypts it, and writes it to the
+// store. Backups older than the retention period are then deleted.
+func (b *Backuper) Backup() (name string, err error) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if err := b.Database.Dump(gz); err != nil {
+		return "", fmt.Errorf("backup: failed to dump the database: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return "", err
+	}
+
+	sealed, err := encryption.Seal(b.KeyProvider, compressed.Bytes())
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(sealed)
+	if err != nil {
+		return "", err
+	}
+
+	name = Name(b.now())
+	if err := b.Store.Put(name, data); err != nil {
+		return "", fmt.Errorf("backup: failed to write %s: %v", name, err)
+	}
+	log.Printf("Wrote backup %s (%d bytes).", name, len(data))
+
+	deleted, err := b.Prune()
+	if err != nil {
+		return name, err
+	}
+	if len(deleted) > 0 {
+		log.Printf("Deleted %d backups older than %v.", len(deleted), b.Retention)
+	}
+	return name, nil
+}
+
+// List returns the names of the backups in the store, oldest first.
+func (b *Backuper) List() ([]string, error) {
+	names, err := b.Store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, n := range names {
+		if _, ok := TimeFromName(n); ok {
+			backups = append(backups, n)
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// Prune deletes backups older than the retention period, except the latest.
+func (b *Backuper) Prune() (deleted []string, err error) {
+	backups, err := b.List()
+	if err != nil || len(backups) == 0 {
+		return nil, err
+	}
+
+	cutoff := b.now().Add(-b.Retention)
+	for _, n := range backups[:len(backups)-1] {
+		if t, _ := TimeFromName(n); !t.Before(cutoff) {
+			continue
+		}
+		if err := b.Store.Delete(n); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, n)
+	}
+	return deleted, nil
+}
+
+// Latest returns the name of the most recent backup.
+func (b *Backuper) Latest() (string, error) {
+	backups, err := b.List()
+	if err != nil {
+		return "", err
+	}
+	if len(backups) == 0 {
+		return "", ErrNoBackups
+	}
+	return backups[len(backups)-1], nil
+}
+
+// Restore replaces the contents of the database with the backup. If the name
+// is empty, the latest backup is restored.
+func (b *Backuper) Restore(name string) (restored string, err error) {
+	if name == "" {
+		if name, err = b.Latest(); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := b.Store.Get(name)
+	if err != nil {
+		return name, fmt.Errorf("backup: failed to read %s: %v", name, err)
+	}
+
+	var sealed encryption.EncryptedValue
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return name, fmt.Errorf("backup: %s is not a backup: %v", name, err)
+	}
+	compressed, err := encryption.Open(b.KeyProvider, sealed)
+	if err != nil {
+		return name, err
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return name, err
+	}
+	if err := b.Database.Restore(gz); err != nil {
+		return name, fmt.Errorf("backup: failed to restore %s: %v", name, err)
+	}
+	return name, nil
+}
@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"strings"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A MongoDatabase dumps each collection of a MongoDB database as a file of
+// BSON documents in a tar archive, in the same format as mongodump, so that
+// backups can also be restored with mongorestore once decrypted.
+type MongoDatabase struct {
+	connectionString string
+	databaseName     string
+}
+
+// NewMongoDatabase creates an instance of the MongoDatabase.
+func NewMongoDatabase(connectionString string, databaseName string) *MongoDatabase {
+	return &MongoDatabase{connectionString, databaseName}
+}
+
+// Dump writes the collections of the database to the writer.
+func (m MongoDatabase) Dump(w io.Writer) error {
+	session, err := mgo.Dial(m.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	db := session.DB(m.databaseName)
+	names, err := db.CollectionNames()
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	for _, name := range names {
+		if strings.HasPrefix(name, "system.") {
+			continue
+		}
+
+		var buf bytes.Buffer
+		iter := db.C(name).Find(nil).Iter()
+		var doc bson.Raw
+		for iter.Next(&doc) {
+			buf.Write(doc.Data)
+		}
+		if err := iter.Close(); err != nil {
+			return fmt.Errorf("failed to read %s: %v", name, err)
+		}
+
+		if err := tw.WriteHeader(&tar.Header{Name: name + ".bson", Mode: 0600, Size: int64(buf.Len())}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// Restore replaces the collections in the archive. Collections which aren't
+// in the archive are left unchanged. Backups of several shards can only be
+// restored by a ShardedDatabase.
+func (m MongoDatabase) Restore(r io.Reader) error {
+	session, err := mgo.Dial(m.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	db := session.DB(m.databaseName)
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if strings.HasSuffix(h.Name, shardSuffix) {
+			return fmt.Errorf("the backup contains several shards, which must be restored together")
+		}
+		name := strings.TrimSuffix(h.Name, ".bson")
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		docs, err := splitDocuments(data)
+		if err != nil {
+			return fmt.Errorf("%s: %v", h.Name, err)
+		}
+
+		c := db.C(name)
+		if err := c.DropCollection(); err != nil && !strings.Contains(err.Error(), "ns not found") {
+			return err
+		}
+		for _, d := range docs {
+			if err := c.Insert(d); err != nil {
+				return fmt.Errorf("failed to restore %s: %v", name, err)
+			}
+		}
+		log.Printf("Restored %d documents to %s.", len(docs), name)
+	}
+}
+
+// splitDocuments splits concatenated BSON documents, each of which starts
+// with its length.
+func splitDocuments(data []byte) ([]bson.Raw, error) {
+	var docs []bson.Raw
+	for len(data) > 0 {
+		if len(data) < 5 {
+			return nil, fmt.Errorf("the file is truncated")
+		}
+		n := int(binary.LittleEndian.Uint32(data))
+		if n < 5 || n > len(data) {
+			return nil, fmt.Errorf("the file is truncated")
+		}
+		docs = append(docs, bson.Raw{Kind: 0x03, Data: data[:n]})
+		data = data[n:]
+	}
+	return docs, nil
+}
@@ -0,0 +1,56 @@
+package backup
+
+import (
+	"bytes"
+	"testing"
+
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestThatMongoDatabasesCanBeDumpedAndRestored(t *testing.T) {
+	session, err := mgo.Dial("mongodb://localhost:27017")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	source := session.DB("pillbackuptest")
+	target := session.DB("pillrestoretest")
+	defer source.DropDatabase()
+	defer target.DropDatabase()
+
+	source.C("profiles").Insert(bson.M{"_id": "a@github.com", "version": 2}, bson.M{"_id": "b@github.com", "version": 1})
+	source.C("skilltags").Insert(bson.M{"_id": "go"})
+	target.C("profiles").Insert(bson.M{"_id": "c@github.com"})
+
+	var dump bytes.Buffer
+	if err := NewMongoDatabase("mongodb://localhost:27017", "pillbackuptest").Dump(&dump); err != nil {
+		t.Fatalf("Failed to dump the database: %v", err)
+	}
+	if err := NewMongoDatabase("mongodb://localhost:27017", "pillrestoretest").Restore(&dump); err != nil {
+		t.Fatalf("Failed to restore the database: %v", err)
+	}
+
+	var profiles []bson.M
+	target.C("profiles").Find(nil).Sort("_id").All(&profiles)
+	if len(profiles) != 2 || profiles[0]["_id"] != "a@github.com" || profiles[0]["version"] != 2 {
+		t.Errorf("Expected the profiles to be replaced by the backup, but got %v.", profiles)
+	}
+	if n, _ := target.C("skilltags").Count(); n != 1 {
+		t.Errorf("Expected 1 skill tag to be restored, but got %d.", n)
+	}
+}
+
+func TestThatTruncatedDumpsAreRejected(t *testing.T) {
+	doc, _ := bson.Marshal(bson.M{"_id": "a@github.com"})
+
+	docs, err := splitDocuments(append(append([]byte{}, doc...), doc...))
+	if err != nil || len(docs) != 2 {
+		t.Errorf("Expected 2 documents, but got %d (%v).", len(docs), err)
+	}
+
+	if _, err := splitDocuments(append(doc, doc[:len(doc)-1]...)); err == nil {
+		t.Errorf("Expected a truncated dump to be rejected.")
+	}
+}
@@ -0,0 +1,138 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// shardSuffix is the extension of each shard's dump in a ShardedDatabase's
+// archive. The dumps of a MongoDatabase only contain .bson files.
+const shardSuffix = ".tar"
+
+// A ShardedDatabase is pill's main database and the databases on the shard
+// clusters that tenants' profiles are spread across. They're backed up
+// together, so that a backup holds every tenant's profiles, and the shard
+// directory which says where they are.
+type ShardedDatabase struct {
+	// Shards are the databases by shard name. The main database is
+	// dataaccess.DefaultShard.
+	Shards map[string]Database
+}
+
+// NewShardedDatabase creates an instance of the ShardedDatabase.
+func NewShardedDatabase(shards map[string]Database) *ShardedDatabase {
+	return &ShardedDatabase{shards}
+}
+
+// NewShardedMongoDatabase creates the Database for the main database and the
+// shards, as parsed by dataaccess.ParseShards. Without shards, it's just the
+// MongoDatabase, so that backups stay in the format of mongodump.
+func NewShardedMongoDatabase(connectionString string, databaseName string, shards map[string]string) Database {
+	main := NewMongoDatabase(connectionString, databaseName)
+	if len(shards) == 0 {
+		return main
+	}
+	databases := map[string]Database{dataaccess.DefaultShard: main}
+	for name, cs := range shards {
+		databases[name] = NewMongoDatabase(cs, databaseName)
+	}
+	return NewShardedDatabase(databases)
+}
+
+// names returns the shards in the order they're dumped and restored, the
+// main database first.
+func (s ShardedDatabase) names() []string {
+	var names []string
+	for name := range s.Shards {
+		if name != dataaccess.DefaultShard {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{dataaccess.DefaultShard}, names...)
+}
+
+// Dump writes a tar archive containing the dump of each shard.
+func (s ShardedDatabase) Dump(w io.Writer) error {
+	tw := tar.NewWriter(w)
+	for _, name := range s.names() {
+		var buf bytes.Buffer
+		if err := s.Shards[name].Dump(&buf); err != nil {
+			return fmt.Errorf("failed to dump the %s shard: %v", name, err)
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: name + shardSuffix, Mode: 0600, Size: int64(buf.Len())}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// Restore restores each shard in the archive to its database. Backups taken
+// before the shards were added only contain the main database, and are
+// restored to it. Nothing is restored if the backup contains a shard which
+// isn't configured, since its tenants' profiles would be lost.
+func (s ShardedDatabase) Restore(r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	dumps, sharded, err := readShards(data)
+	if err != nil {
+		return err
+	}
+	if !sharded {
+		log.Print("The backup doesn't contain any shards, restoring it to the main database.")
+		return s.Shards[dataaccess.DefaultShard].Restore(bytes.NewReader(data))
+	}
+
+	for name := range dumps {
+		if _, ok := s.Shards[name]; !ok {
+			return fmt.Errorf("the backup contains the %s shard, which isn't configured", name)
+		}
+	}
+	for _, name := range s.names() {
+		dump, ok := dumps[name]
+		if !ok {
+			log.Printf("The backup doesn't contain the %s shard, leaving it unchanged.", name)
+			continue
+		}
+		if err := s.Shards[name].Restore(bytes.NewReader(dump)); err != nil {
+			return fmt.Errorf("failed to restore the %s shard: %v", name, err)
+		}
+		log.Printf("Restored the %s shard.", name)
+	}
+	return nil
+}
+
+// readShards reads the dump of each shard from the archive. It returns false
+// if the archive is the dump of a single database.
+func readShards(data []byte) (dumps map[string][]byte, sharded bool, err error) {
+	dumps = map[string][]byte{}
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return dumps, len(dumps) > 0, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if !strings.HasSuffix(h.Name, shardSuffix) {
+			return nil, false, nil
+		}
+		if dumps[strings.TrimSuffix(h.Name, shardSuffix)], err = ioutil.ReadAll(tr); err != nil {
+			return nil, false, err
+		}
+	}
+}
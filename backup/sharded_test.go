@@ -0,0 +1,97 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// dump returns a dump of a database with a single collection, in the format
+// of a MongoDatabase.
+func dump(t *testing.T, collection string, content string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: collection + ".bson", Mode: 0600, Size: int64(len(content))}); err != nil {
+		t.Fatal(err)
+	}
+	tw.Write([]byte(content))
+	tw.Close()
+	return buf.Bytes()
+}
+
+func TestThatEveryShardIsBackedUpAndRestored(t *testing.T) {
+	store, cleanup := newTestFileStore(t)
+	defer cleanup()
+
+	main := &memoryDatabase{data: dump(t, "shards", "directory")}
+	apac := &memoryDatabase{data: dump(t, "profiles", "apac profiles")}
+	eu := &memoryDatabase{data: dump(t, "profiles", "eu profiles")}
+	b := NewBackuper(NewShardedDatabase(map[string]Database{dataaccess.DefaultShard: main, "apac": apac, "eu": eu}), store, newTestKeyProvider(t), DefaultRetention)
+
+	if _, err := b.Backup(); err != nil {
+		t.Fatal("Failed to take the backup.", err)
+	}
+
+	restored := map[string]Database{dataaccess.DefaultShard: &memoryDatabase{}, "apac": &memoryDatabase{}, "eu": &memoryDatabase{}}
+	b.Database = NewShardedDatabase(restored)
+	if _, err := b.Restore(""); err != nil {
+		t.Fatal("Failed to restore the backup.", err)
+	}
+
+	for name, original := range map[string]*memoryDatabase{dataaccess.DefaultShard: main, "apac": apac, "eu": eu} {
+		if !bytes.Equal(restored[name].(*memoryDatabase).data, original.data) {
+			t.Errorf("Expected the %s shard to be restored.", name)
+		}
+	}
+}
+
+func TestThatShardedBackupsAreOnlyRestoredWhenEveryShardIsConfigured(t *testing.T) {
+	store, cleanup := newTestFileStore(t)
+	defer cleanup()
+
+	b := NewBackuper(NewShardedDatabase(map[string]Database{
+		dataaccess.DefaultShard: &memoryDatabase{data: dump(t, "shards", "directory")},
+		"apac":                  &memoryDatabase{data: dump(t, "profiles", "apac profiles")},
+	}), store, newTestKeyProvider(t), DefaultRetention)
+	if _, err := b.Backup(); err != nil {
+		t.Fatal("Failed to take the backup.", err)
+	}
+
+	main := &memoryDatabase{}
+	b.Database = NewShardedDatabase(map[string]Database{dataaccess.DefaultShard: main})
+	if _, err := b.Restore(""); err == nil || !strings.Contains(err.Error(), "apac") {
+		t.Errorf("Expected an error about the apac shard, but was %v.", err)
+	}
+
+	if main.data != nil {
+		t.Error("Nothing should be restored if a shard in the backup isn't configured.")
+	}
+}
+
+func TestThatBackupsTakenBeforeShardingAreRestoredToTheMainDatabase(t *testing.T) {
+	store, cleanup := newTestFileStore(t)
+	defer cleanup()
+
+	original := dump(t, "profiles", "profiles")
+	b := NewBackuper(&memoryDatabase{data: original}, store, newTestKeyProvider(t), DefaultRetention)
+	if _, err := b.Backup(); err != nil {
+		t.Fatal("Failed to take the backup.", err)
+	}
+
+	main, apac := &memoryDatabase{}, &memoryDatabase{}
+	b.Database = NewShardedDatabase(map[string]Database{dataaccess.DefaultShard: main, "apac": apac})
+	if _, err := b.Restore(""); err != nil {
+		t.Fatal("Failed to restore the backup.", err)
+	}
+
+	if !bytes.Equal(main.data, original) {
+		t.Error("Expected the backup to be restored to the main database.")
+	}
+
+	if apac.data != nil {
+		t.Error("Expected the shard to be left unchanged.")
+	}
+}
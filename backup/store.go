@@ -0,0 +1,102 @@
+package backup
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OpenStore returns the store at the URL:
+//
+//	file:///var/backups/pill
+//	s3://bucket/prefix
+//	gs://bucket/prefix
+//	azure://account/container/prefix
+//
+// Credentials are read from the environment. S3 uses AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_REGION. Google Cloud Storage uses HMAC keys
+// in GCS_HMAC_ACCESS_ID and GCS_HMAC_SECRET. Azure Blob Storage uses a
+// shared access signature for the container in AZURE_STORAGE_SAS_TOKEN.
+func OpenStore(rawurl string) (Store, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.Trim(u.Path, "/")
+
+	switch u.Scheme {
+	case "file":
+		return NewFileStore(u.Path), nil
+	case "s3":
+		region := os.Getenv("AWS_REGION")
+		if region == "" {
+			region = "us-east-1"
+		}
+		return NewS3Store("https://s3."+region+".amazonaws.com", region, u.Host, prefix,
+			os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")), nil
+	case "gs":
+		// Google Cloud Storage accepts S3 requests signed with HMAC keys.
+		return NewS3Store("https://storage.googleapis.com", "auto", u.Host, prefix,
+			os.Getenv("GCS_HMAC_ACCESS_ID"), os.Getenv("GCS_HMAC_SECRET")), nil
+	case "azure":
+		parts := strings.SplitN(prefix, "/", 2)
+		if parts[0] == "" {
+			return nil, fmt.Errorf("backup: the Azure store URL must include the container, e.g. azure://account/container")
+		}
+		if len(parts) == 1 {
+			parts = append(parts, "")
+		}
+		return NewAzureStore("https://"+u.Host+".blob.core.windows.net/"+parts[0], parts[1], os.Getenv("AZURE_STORAGE_SAS_TOKEN")), nil
+	}
+	return nil, fmt.Errorf("backup: unsupported store '%s', use file, s3, gs or azure", u.Scheme)
+}
+
+// A FileStore holds backups in a directory, e.g. a mounted volume.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore in the directory.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir}
+}
+
+// Put writes the backup.
+func (s FileStore) Put(name string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(s.dir, name), data, 0600)
+}
+
+// Get reads the backup.
+func (s FileStore) Get(name string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(s.dir, filepath.Base(name)))
+}
+
+// List returns the names of the files in the directory.
+func (s FileStore) List() ([]string, error) {
+	files, err := ioutil.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		if !f.IsDir() {
+			names = append(names, f.Name())
+		}
+	}
+	return names, nil
+}
+
+// Delete removes the backup.
+func (s FileStore) Delete(name string) error {
+	return os.Remove(filepath.Join(s.dir, filepath.Base(name)))
+}
@@ -0,0 +1,212 @@
+package backup
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	mgo "gopkg.in/mgo.v2"
+)
+
+// A Check verifies that a restored database can be used by pill.
+type Check struct {
+	Name string
+	Run  func(da dataaccess.DataAccess) error
+}
+
+// Checks are run against restored backups by Verify.
+var Checks = []Check{
+	{"configuration", checkConfiguration},
+	{"profiles", checkProfiles},
+	{"skilltags", checkSkillTags},
+	{"settings", checkSettings},
+}
+
+func checkConfiguration(da dataaccess.DataAccess) error {
+	c, err := da.GetOrCreateConfiguration()
+	if err != nil {
+		return err
+	}
+	if len(c.SessionEncryptionKey) == 0 {
+		return fmt.Errorf("the configuration has no session encryption key")
+	}
+	return nil
+}
+
+func checkProfiles(da dataaccess.DataAccess) error {
+	domains, err := da.ListDomains()
+	if err != nil {
+		return err
+	}
+
+	var n int
+	var problems []string
+	for _, d := range domains {
+		profiles, err := da.ListProfiles("@" + d)
+		if err != nil {
+			return err
+		}
+		for _, p := range profiles {
+			n++
+			problems = append(problems, profileProblems(p)...)
+		}
+	}
+
+	if n == 0 {
+		return fmt.Errorf("the backup contains no profiles")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found in %d profiles: %s", len(problems), n, strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+func profileProblems(p dataaccess.Profile) []string {
+	var problems []string
+	if !strings.Contains(p.EmailAddress, "@") {
+		return []string{fmt.Sprintf("'%s' is not an email address", p.EmailAddress)}
+	}
+	if p.Domain != dataaccess.GetDomain(p.EmailAddress) {
+		problems = append(problems, fmt.Sprintf("%s is in the domain '%s'", p.EmailAddress, p.Domain))
+	}
+	for _, s := range p.Skills {
+		if s.Level < dataaccess.NoviceLevel || s.Level > dataaccess.MasterLevel {
+			problems = append(problems, fmt.Sprintf("%s has the level %d for %s", p.EmailAddress, s.Level, s.Skill))
+		}
+	}
+	for _, h := range p.SkillsHistory {
+		if h.Date.After(p.LastUpdated) {
+			problems = append(problems, fmt.Sprintf("%s has history after it was last updated", p.EmailAddress))
+			break
+		}
+	}
+	return problems
+}
+
+func checkSkillTags(da dataaccess.DataAccess) error {
+	tags, err := da.ListSkillTags()
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("the backup contains no skill tags")
+	}
+	return nil
+}
+
+func checkSettings(da dataaccess.DataAccess) error {
+	domains, err := da.ListDomains()
+	if err != nil {
+		return err
+	}
+	for _, d := range domains {
+		if _, err := da.GetSettings(d); err != nil {
+			return fmt.Errorf("%s: %v", d, err)
+		}
+	}
+	return nil
+}
+
+// A CheckResult is the outcome of a Check. Err is nil if the check passed.
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+// A Report is the outcome of verifying a backup.
+type Report struct {
+	Backup   string
+	Restored time.Duration
+	Results  []CheckResult
+}
+
+// OK returns true if the backup was restored and every check passed.
+func (r Report) OK() bool {
+	for _, c := range r.Results {
+		if c.Err != nil {
+			return false
+		}
+	}
+	return r.Backup != "" && len(r.Results) > 0
+}
+
+func (r Report) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Restored %s in %v.\n", r.Backup, r.Restored.Round(time.Millisecond))
+	for _, c := range r.Results {
+		if c.Err != nil {
+			fmt.Fprintf(&sb, "FAIL %s: %v\n", c.Name, c.Err)
+		} else {
+			fmt.Fprintf(&sb, "ok   %s\n", c.Name)
+		}
+	}
+	return sb.String()
+}
+
+// RunChecks runs the checks against a restored database.
+func RunChecks(da dataaccess.DataAccess, checks []Check) []CheckResult {
+	results := make([]CheckResult, len(checks))
+	for i, c := range checks {
+		results[i] = CheckResult{c.Name, c.Run(da)}
+	}
+	return results
+}
+
+// Verify restores a backup into a temporary database, runs the checks against
+// it, and then drops the temporary database. If the name is empty, the latest
+// backup is verified. The shards, as parsed by dataaccess.ParseShards, each
+// get a temporary database on their own cluster, and the checks read them
+// through the shard directory in the backup, as the service would.
+func (b *Backuper) Verify(connectionString string, shards map[string]string, name string) (Report, error) {
+	temp := fmt.Sprintf("pillverify%d", b.now().UnixNano())
+	log.Printf("Verifying the backup in the temporary database %s.", temp)
+
+	temps := []*MongoDatabase{NewMongoDatabase(connectionString, temp)}
+	for _, cs := range shards {
+		temps = append(temps, NewMongoDatabase(cs, temp))
+	}
+	defer func() {
+		for _, db := range temps {
+			if err := db.Drop(); err != nil {
+				log.Printf("Failed to drop the temporary database %s. %v", temp, err)
+			}
+		}
+	}()
+
+	verifier := *b
+	verifier.Database = NewShardedMongoDatabase(connectionString, temp, shards)
+
+	var r Report
+	start := time.Now()
+	restored, err := verifier.Restore(name)
+	r.Backup = restored
+	if err != nil {
+		return r, err
+	}
+	r.Restored = time.Since(start)
+
+	var da dataaccess.DataAccess = dataaccess.NewEncryptedMongoDataAccess(connectionString, temp, b.KeyProvider)
+	if len(shards) > 0 {
+		clusters := map[string]dataaccess.DataAccess{}
+		for name, cs := range shards {
+			clusters[name] = dataaccess.NewEncryptedMongoDataAccess(cs, temp, b.KeyProvider)
+		}
+		da = dataaccess.NewShardedDataAccess(da, clusters, dataaccess.NewMongoShardDirectory(connectionString, temp), 0)
+	}
+	r.Results = RunChecks(da, Checks)
+	return r, nil
+}
+
+// Drop deletes the database.
+func (m MongoDatabase) Drop() error {
+	session, err := mgo.Dial(m.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(m.databaseName).DropDatabase()
+}
@@ -0,0 +1,111 @@
+package backup
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type stubDataAccess struct {
+	dataaccess.DataAccess
+	configuration dataaccess.Configuration
+	profiles      []dataaccess.Profile
+	tags          []dataaccess.SkillTag
+}
+
+func (da stubDataAccess) GetOrCreateConfiguration() (dataaccess.Configuration, error) {
+	return da.configuration, nil
+}
+
+func (da stubDataAccess) ListDomains() ([]string, error) {
+	return []string{"github.com"}, nil
+}
+
+func (da stubDataAccess) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	return da.profiles, nil
+}
+
+func (da stubDataAccess) ListSkillTags() ([]dataaccess.SkillTag, error) {
+	return da.tags, nil
+}
+
+func (da stubDataAccess) GetSettings(domain string) (dataaccess.Settings, error) {
+	return dataaccess.Settings{}, nil
+}
+
+func TestThatRestoredDatabasesAreChecked(t *testing.T) {
+	june := time.Date(2017, time.June, 1, 0, 0, 0, 0, time.UTC)
+	valid := dataaccess.Profile{
+		EmailAddress:  "a@github.com",
+		Domain:        "github.com",
+		Skills:        []dataaccess.Skill{{Skill: "go", Level: dataaccess.ExpertLevel}},
+		SkillsHistory: []dataaccess.SkillLevel{{Date: june.AddDate(0, -1, 0)}},
+		LastUpdated:   june,
+	}
+	invalid := dataaccess.Profile{
+		EmailAddress:  "b@github.com",
+		Domain:        "gitlab.com",
+		Skills:        []dataaccess.Skill{{Skill: "go", Level: 9}},
+		SkillsHistory: []dataaccess.SkillLevel{{Date: june.AddDate(0, 1, 0)}},
+		LastUpdated:   june,
+	}
+
+	tests := []struct {
+		name     string
+		da       stubDataAccess
+		expected map[string]string
+	}{
+		{
+			name: "valid",
+			da:   stubDataAccess{configuration: dataaccess.Configuration{SessionEncryptionKey: []byte("key")}, profiles: []dataaccess.Profile{valid}, tags: []dataaccess.SkillTag{{Name: "go"}}},
+		},
+		{
+			name: "empty",
+			da:   stubDataAccess{},
+			expected: map[string]string{
+				"configuration": "the configuration has no session encryption key",
+				"profiles":      "the backup contains no profiles",
+				"skilltags":     "the backup contains no skill tags",
+			},
+		},
+		{
+			name: "invalid profile",
+			da:   stubDataAccess{configuration: dataaccess.Configuration{SessionEncryptionKey: []byte("key")}, profiles: []dataaccess.Profile{valid, invalid}, tags: []dataaccess.SkillTag{{Name: "go"}}},
+			expected: map[string]string{
+				"profiles": "3 problems found in 2 profiles: b@github.com is in the domain 'gitlab.com', b@github.com has the level 9 for go, b@github.com has history after it was last updated",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		results := RunChecks(test.da, Checks)
+		for _, r := range results {
+			expected := test.expected[r.Name]
+			if (expected == "" && r.Err != nil) || (expected != "" && (r.Err == nil || r.Err.Error() != expected)) {
+				t.Errorf("%s: for the %s check, expected '%s', but got %v.", test.name, r.Name, expected, r.Err)
+			}
+		}
+
+		report := Report{Backup: "pill-20170601T020000Z.backup", Results: results}
+		if report.OK() != (len(test.expected) == 0) {
+			t.Errorf("%s: expected OK to be %t.", test.name, len(test.expected) == 0)
+		}
+	}
+}
+
+func TestThatReportsAreOnlyOKIfTheBackupWasRestored(t *testing.T) {
+	if (Report{}).OK() {
+		t.Errorf("Expected a report without a restored backup not to be OK.")
+	}
+
+	r := Report{Backup: "pill-20170601T020000Z.backup", Results: []CheckResult{{"profiles", nil}, {"skilltags", errors.New("no tags")}}}
+	if r.OK() {
+		t.Errorf("Expected a report with a failed check not to be OK.")
+	}
+	if !strings.Contains(r.String(), "FAIL skilltags: no tags") {
+		t.Errorf("Expected the report to list the failed check, but got %s.", r.String())
+	}
+}
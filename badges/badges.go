@@ -0,0 +1,169 @@
+// Package badges awards badges to people for using pill, e.g. for keeping
+// their profile up to date, to encourage them to keep coming back.
+package badges
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+// A Rule awards a badge to people whose profile meets its condition.
+type Rule struct {
+	// Name is stored on the profile, so must not change.
+	Name        string
+	Title       string
+	Description string
+	Earned      func(p dataaccess.Profile) bool
+}
+
+// Rules are the badges which can be awarded.
+var Rules = []Rule{
+	{
+		Name:        "started",
+		Title:       "Getting started",
+		Description: "Added skills to their profile.",
+		Earned:      func(p dataaccess.Profile) bool { return len(p.Skills) > 0 },
+	},
+	{
+		Name:        "wellRounded",
+		Title:       "Well rounded",
+		Description: "Has 10 or more skills on their profile.",
+		Earned:      func(p dataaccess.Profile) bool { return len(p.Skills) >= 10 },
+	},
+	{
+		Name:        "expert",
+		Title:       "Expert",
+		Description: "Is an expert in at least one skill.",
+		Earned: func(p dataaccess.Profile) bool {
+			for _, s := range p.Skills {
+				if s.Level >= dataaccess.ExpertLevel {
+					return true
+				}
+			}
+			return false
+		},
+	},
+	{
+		Name:        "fourQuarterStreak",
+		Title:       "Up to date",
+		Description: "Updated their profile 4 quarters in a row.",
+		Earned:      func(p dataaccess.Profile) bool { return LongestStreak(p) >= 4 },
+	},
+}
+
+// Find returns the rule which awards the named badge.
+func Find(name string) (Rule, bool) {
+	for _, r := range Rules {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Evaluate returns the badges the profile has earned, but hasn't been
+// awarded yet.
+func Evaluate(p dataaccess.Profile, rules []Rule, now time.Time) []dataaccess.Badge {
+	var earned []dataaccess.Badge
+	for _, r := range rules {
+		if !p.HasBadge(r.Name) && r.Earned(p) {
+			earned = append(earned, dataaccess.Badge{Name: r.Name, Awarded: now})
+		}
+	}
+	return earned
+}
+
+// quarters returns the quarters in which the profile was updated, in order,
+// numbered from year 0.
+func quarters(p dataaccess.Profile) []int {
+	seen := map[int]bool{}
+	add := func(t time.Time) {
+		if !t.IsZero() {
+			t = t.UTC()
+			seen[t.Year()*4+(int(t.Month())-1)/3] = true
+		}
+	}
+	for _, h := range p.SkillsHistory {
+		add(h.Date)
+	}
+	add(p.LastUpdated)
+
+	var q []int
+	for k := range seen {
+		q = append(q, k)
+	}
+	sort.Ints(q)
+	return q
+}
+
+// LongestStreak returns the most consecutive quarters in which the profile
+// was updated.
+func LongestStreak(p dataaccess.Profile) int {
+	longest, run, previous := 0, 0, 0
+	for i, q := range quarters(p) {
+		if i > 0 && q == previous+1 {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		previous = q
+	}
+	return longest
+}
+
+// CurrentStreak returns how many quarters in a row the profile has been
+// updated, up to the quarter it was last updated in.
+func CurrentStreak(p dataaccess.Profile) int {
+	q := quarters(p)
+	run := 0
+	for i := len(q) - 1; i >= 0; i-- {
+		if i < len(q)-1 && q[i] != q[i+1]-1 {
+			break
+		}
+		run++
+	}
+	return run
+}
+
+// The Awarder awards badges when profiles are updated. It is an
+// events.Publisher, so it receives the changes made through a
+// NotifyingDataAccess.
+type Awarder struct {
+	DataAccess dataaccess.DataAccess
+	Rules      []Rule
+	now        func() time.Time
+}
+
+// NewAwarder creates an Awarder which saves badges through the DataAccess.
+func NewAwarder(da dataaccess.DataAccess) *Awarder {
+	return &Awarder{da, Rules, time.Now}
+}
+
+// Publish awards any badges earned by the change.
+func (a *Awarder) Publish(e events.Event) {
+	if e.Type != events.ProfileUpdated {
+		return
+	}
+	p, ok := e.Data.(*dataaccess.Profile)
+	if !ok || p == nil {
+		return
+	}
+
+	earned := Evaluate(*p, a.Rules, a.now())
+	if len(earned) == 0 {
+		return
+	}
+	if err := a.DataAccess.AwardBadges(p.EmailAddress, earned); err != nil {
+		log.Printf("Failed to award badges to %s. %v", p.EmailAddress, err)
+		return
+	}
+	p.Badges = append(p.Badges, earned...)
+	log.Printf("Awarded %d badges to %s.", len(earned), p.EmailAddress)
+}
@@ -0,0 +1,82 @@
+package badges
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+func updatedIn(dates ...time.Time) dataaccess.Profile {
+	p := dataaccess.Profile{LastUpdated: dates[len(dates)-1]}
+	for _, d := range dates[:len(dates)-1] {
+		p.SkillsHistory = append(p.SkillsHistory, dataaccess.SkillLevel{Date: d})
+	}
+	return p
+}
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 15, 9, 0, 0, 0, time.UTC)
+}
+
+func TestThatStreaksCountConsecutiveQuarters(t *testing.T) {
+	tests := []struct {
+		name            string
+		profile         dataaccess.Profile
+		longest, recent int
+	}{
+		{"a single update", updatedIn(month(2017, time.June)), 1, 1},
+		{"updates in the same quarter", updatedIn(month(2017, time.April), month(2017, time.June)), 1, 1},
+		{"updates across a year", updatedIn(month(2016, time.November), month(2017, time.February), month(2017, time.May)), 3, 3},
+		{"a gap", updatedIn(month(2016, time.January), month(2016, time.April), month(2016, time.July), month(2016, time.October), month(2017, time.June)), 4, 1},
+	}
+
+	for _, test := range tests {
+		if actual := LongestStreak(test.profile); actual != test.longest {
+			t.Errorf("For %s, expected the longest streak to be %d, but was %d.", test.name, test.longest, actual)
+		}
+		if actual := CurrentStreak(test.profile); actual != test.recent {
+			t.Errorf("For %s, expected the current streak to be %d, but was %d.", test.name, test.recent, actual)
+		}
+	}
+}
+
+func TestThatOnlyNewBadgesAreAwarded(t *testing.T) {
+	p := dataaccess.Profile{
+		Skills: []dataaccess.Skill{{Skill: "go", Level: dataaccess.ExpertLevel}},
+		Badges: []dataaccess.Badge{{Name: "started"}},
+	}
+
+	earned := Evaluate(p, Rules, month(2017, time.June))
+
+	if len(earned) != 1 || earned[0].Name != "expert" {
+		t.Errorf("Expected only the expert badge to be awarded, but got %v.", earned)
+	}
+}
+
+type awardingDataAccess struct {
+	dataaccess.DataAccess
+	awarded map[string][]dataaccess.Badge
+}
+
+func (da awardingDataAccess) AwardBadges(emailAddress string, badges []dataaccess.Badge) error {
+	da.awarded[emailAddress] = append(da.awarded[emailAddress], badges...)
+	return nil
+}
+
+func TestThatBadgesAreAwardedWhenProfilesAreUpdated(t *testing.T) {
+	da := awardingDataAccess{awarded: map[string][]dataaccess.Badge{}}
+	a := NewAwarder(da)
+
+	p := &dataaccess.Profile{EmailAddress: "a@github.com", Skills: []dataaccess.Skill{{Skill: "go"}}}
+	a.Publish(events.NewEvent(events.ProfileUpdated, "github.com", p.EmailAddress, p))
+	a.Publish(events.NewEvent(events.ProfileUpdated, "github.com", p.EmailAddress, p))
+
+	if len(da.awarded["a@github.com"]) != 1 {
+		t.Errorf("Expected the badge to be awarded once, but got %v.", da.awarded["a@github.com"])
+	}
+	if !p.HasBadge("started") {
+		t.Error("Expected the badge to be added to the published profile.")
+	}
+}
@@ -0,0 +1,68 @@
+// Package benchmark averages the skill coverage of the tenants which have
+// opted in to benchmarking, so that each of them can compare its coverage
+// with tenants of a similar size without learning anything about any one of
+// them.
+package benchmark
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Job recomputes the benchmarks.
+type Job struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewJob creates an instance of the Job.
+func NewJob(da dataaccess.DataAccess) *Job {
+	return &Job{da, time.Now}
+}
+
+// Run works out the coverage of each opted in tenant, and replaces the
+// benchmarks with their averages. Only the averages are saved.
+func (j *Job) Run(ctx context.Context) error {
+	domains, err := j.DataAccess.ListDomains()
+	if err != nil {
+		return err
+	}
+
+	var tenants []dataaccess.TenantCoverage
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		settings, err := j.DataAccess.GetSettings(domain)
+		if err != nil {
+			return err
+		}
+		if !settings.Benchmarking.OptIn {
+			continue
+		}
+
+		profiles, err := j.DataAccess.ListProfiles("@" + domain)
+		if err != nil {
+			return err
+		}
+		// People who haven't consented to analytics still count towards the
+		// number of people, but not to the coverage of skills.
+		consenting := dataaccess.Consenting(profiles, dataaccess.ConsentAnalytics, settings.Consent)
+		headcount := settings.Headcount
+		if headcount < len(profiles) {
+			headcount = len(profiles)
+		}
+		tenants = append(tenants, dataaccess.SkillCoverage(consenting, headcount))
+	}
+
+	benchmarks := dataaccess.ComputeBenchmarks(tenants, j.now())
+	if err := j.DataAccess.SaveBenchmarks(benchmarks); err != nil {
+		return err
+	}
+	log.Printf("Computed %d benchmarks from %d of %d tenants.", len(benchmarks), len(tenants), len(domains))
+	return nil
+}
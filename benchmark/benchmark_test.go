@@ -0,0 +1,75 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type testDataAccess struct {
+	dataaccess.DataAccess
+	settings   map[string]dataaccess.Settings
+	profiles   map[string][]dataaccess.Profile
+	benchmarks []dataaccess.Benchmark
+}
+
+func (da *testDataAccess) ListDomains() ([]string, error) {
+	var domains []string
+	for d := range da.settings {
+		domains = append(domains, d)
+	}
+	return domains, nil
+}
+
+func (da *testDataAccess) GetSettings(domain string) (dataaccess.Settings, error) {
+	return da.settings[domain], nil
+}
+
+func (da *testDataAccess) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	return da.profiles[emailAddress[1:]], nil
+}
+
+func (da *testDataAccess) SaveBenchmarks(benchmarks []dataaccess.Benchmark) error {
+	da.benchmarks = benchmarks
+	return nil
+}
+
+func TestThatOnlyOptedInTenantsAreBenchmarked(t *testing.T) {
+	optedIn := dataaccess.DefaultSettings()
+	optedIn.Benchmarking.OptIn = true
+
+	da := &testDataAccess{
+		settings: map[string]dataaccess.Settings{},
+		profiles: map[string][]dataaccess.Profile{},
+	}
+	for i := 0; i < dataaccess.MinBenchmarkTenants; i++ {
+		domain := fmt.Sprintf("tenant%d.com", i)
+		da.settings[domain] = optedIn
+		da.profiles[domain] = []dataaccess.Profile{
+			{EmailAddress: "a@" + domain, Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}},
+			{EmailAddress: "b@" + domain},
+			{EmailAddress: "c@" + domain, Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}, Consents: []dataaccess.Consent{{Purpose: dataaccess.ConsentAnalytics, Given: false}}},
+			{EmailAddress: "d@" + domain, Skills: []dataaccess.Skill{{Skill: "java", Level: 3}}},
+		}
+	}
+	da.settings["private.com"] = dataaccess.DefaultSettings()
+	da.profiles["private.com"] = []dataaccess.Profile{
+		{EmailAddress: "a@private.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}},
+	}
+
+	j := NewJob(da)
+	j.now = func() time.Time { return time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC) }
+	if err := j.Run(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(da.benchmarks) != 1 {
+		t.Fatalf("Expected one benchmark, but got %+v", da.benchmarks)
+	}
+	if b := da.benchmarks[0]; b.Band != "1-49" || b.Tenants != dataaccess.MinBenchmarkTenants || b.Coverage["go"] != 0.25 {
+		t.Errorf("Expected a quarter of the people in %d tenants to have go, but got %+v", dataaccess.MinBenchmarkTenants, b)
+	}
+}
@@ -0,0 +1,41 @@
+// Package caller identifies who is making a request, so that authorization,
+// redaction and auditing can be applied wherever the request is handled.
+package caller
+
+import "context"
+
+// A Caller is the person, or process, making a request.
+type Caller struct {
+	EmailAddress string
+	Roles        []string
+	// Tenant is the domain the caller belongs to.
+	Tenant string
+	// Impersonator is the email address of the administrator acting as the
+	// caller, if the caller is being impersonated.
+	Impersonator string
+}
+
+// HasRole returns true if the caller holds the role.
+func (c Caller) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey int
+
+const callerKey contextKey = iota
+
+// NewContext returns a copy of the context which carries the caller.
+func NewContext(ctx context.Context, c Caller) context.Context {
+	return context.WithValue(ctx, callerKey, c)
+}
+
+// FromContext returns the caller carried by the context, if there is one.
+func FromContext(ctx context.Context) (c Caller, ok bool) {
+	c, ok = ctx.Value(callerKey).(Caller)
+	return
+}
@@ -0,0 +1,32 @@
+package caller
+
+import (
+	"context"
+	"testing"
+)
+
+func TestThatTheCallerCanBeRetrievedFromTheContext(t *testing.T) {
+	expected := Caller{EmailAddress: "a-h@github.com", Roles: []string{"user"}, Tenant: "github.com"}
+
+	actual, ok := FromContext(NewContext(context.Background(), expected))
+
+	if !ok || actual.EmailAddress != expected.EmailAddress || actual.Tenant != expected.Tenant {
+		t.Errorf("Expected caller %v, but received %v", expected, actual)
+	}
+
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("A context without a caller should not return one.")
+	}
+}
+
+func TestThatRolesCanBeChecked(t *testing.T) {
+	c := Caller{Roles: []string{"user", "administrator"}}
+
+	if !c.HasRole("administrator") {
+		t.Error("The caller should hold the administrator role.")
+	}
+
+	if c.HasRole("support") {
+		t.Error("The caller should not hold the support role.")
+	}
+}
@@ -0,0 +1,153 @@
+package confluence
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// A Page is a Confluence page, with its body in the storage format.
+type Page struct {
+	ID      string
+	Title   string
+	Body    string
+	Version int
+}
+
+// Client reads and writes pages through the Confluence REST API.
+type Client struct {
+	// BaseURL is the address of the wiki, e.g.
+	// https://example.atlassian.net/wiki.
+	BaseURL string
+	// User is the email address of an Atlassian account, which is used with
+	// an API token. If empty, the token is a Confluence Data Center personal
+	// access token.
+	User   string
+	Token  string
+	Client *http.Client
+}
+
+// NewClient creates an instance of the Client.
+func NewClient(baseURL string, user string, token string) *Client {
+	return &Client{baseURL, user, token, httpClient}
+}
+
+type content struct {
+	ID        string     `json:"id,omitempty"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Space     *space     `json:"space,omitempty"`
+	Ancestors []ancestor `json:"ancestors,omitempty"`
+	Version   *version   `json:"version,omitempty"`
+	Body      struct {
+		Storage struct {
+			Value          string `json:"value"`
+			Representation string `json:"representation"`
+		} `json:"storage"`
+	} `json:"body"`
+}
+
+type space struct {
+	Key string `json:"key"`
+}
+
+type ancestor struct {
+	ID string `json:"id"`
+}
+
+type version struct {
+	Number int `json:"number"`
+}
+
+func newContent(title string, body string) content {
+	c := content{Type: "page", Title: title}
+	c.Body.Storage.Value = body
+	c.Body.Storage.Representation = "storage"
+	return c
+}
+
+// FindPage returns the page in the space with the title.
+func (c Client) FindPage(ctx context.Context, spaceKey string, title string) (Page, bool, error) {
+	q := url.Values{"spaceKey": {spaceKey}, "title": {title}, "type": {"page"}, "expand": {"body.storage,version"}}
+	var result struct {
+		Results []content `json:"results"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/rest/api/content?"+q.Encode(), nil, &result); err != nil {
+		return Page{}, false, err
+	}
+	if len(result.Results) == 0 {
+		return Page{}, false, nil
+	}
+	r := result.Results[0]
+	p := Page{ID: r.ID, Title: r.Title, Body: r.Body.Storage.Value}
+	if r.Version != nil {
+		p.Version = r.Version.Number
+	}
+	return p, true, nil
+}
+
+// CreatePage adds a page to the space, under the parent page if its ID is
+// set, returning the new page's ID.
+func (c Client) CreatePage(ctx context.Context, spaceKey string, parentID string, title string, body string) (string, error) {
+	page := newContent(title, body)
+	page.Space = &space{spaceKey}
+	if parentID != "" {
+		page.Ancestors = []ancestor{{parentID}}
+	}
+	var created content
+	if err := c.do(ctx, http.MethodPost, "/rest/api/content", page, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// UpdatePage replaces the body of the page, creating the version after
+// the page's.
+func (c Client) UpdatePage(ctx context.Context, p Page) error {
+	page := newContent(p.Title, p.Body)
+	page.ID = p.ID
+	page.Version = &version{p.Version + 1}
+	return c.do(ctx, http.MethodPut, "/rest/api/content/"+url.PathEscape(p.ID), page, nil)
+}
+
+func (c Client) do(ctx context.Context, method string, path string, body interface{}, result interface{}) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, c.BaseURL+path, &buf)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("confluence: %s %s returned status %d", method, path, resp.StatusCode)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
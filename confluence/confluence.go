@@ -0,0 +1,89 @@
+// Package confluence publishes a page of each team's skills to a Confluence
+// space, so that the capability pages on the wiki are kept up to date with
+// people's profiles.
+package confluence
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Publisher keeps a page in a Confluence space for each team in a tenant.
+type Publisher struct {
+	DataAccess dataaccess.DataAccess
+	Client     *Client
+	// SpaceKey is the space the pages are published to.
+	SpaceKey string
+	// ParentID is the page new pages are created under, if set.
+	ParentID string
+	// Domain is the tenant whose teams are published.
+	Domain string
+}
+
+// NewPublisher creates an instance of the Publisher.
+func NewPublisher(da dataaccess.DataAccess, client *Client, spaceKey string, parentID string, domain string) *Publisher {
+	return &Publisher{da, client, spaceKey, parentID, strings.ToLower(domain)}
+}
+
+// A Result summarises a publish.
+type Result struct {
+	Created   int
+	Updated   int
+	Unchanged int
+}
+
+// Publish creates or updates the page of each team. Pages whose content
+// hasn't changed are left alone, so that the page history only shows real
+// changes. The pages of teams which no longer exist are kept, since people
+// may have linked to them.
+func (p Publisher) Publish(ctx context.Context) (Result, error) {
+	var r Result
+
+	profiles, err := dataaccess.WithContext(p.DataAccess, ctx).ListProfiles("@" + p.Domain)
+	if err != nil {
+		return r, err
+	}
+
+	for _, ts := range Summarise(p.Domain, profiles) {
+		if ctx.Err() != nil {
+			return r, ctx.Err()
+		}
+		body, err := ts.Render()
+		if err != nil {
+			return r, err
+		}
+		page, found, err := p.Client.FindPage(ctx, p.SpaceKey, ts.Title())
+		if err != nil {
+			return r, err
+		}
+		if !found {
+			if _, err := p.Client.CreatePage(ctx, p.SpaceKey, p.ParentID, ts.Title(), body); err != nil {
+				return r, err
+			}
+			r.Created++
+			continue
+		}
+		if page.Body == body {
+			r.Unchanged++
+			continue
+		}
+		page.Body = body
+		if err := p.Client.UpdatePage(ctx, page); err != nil {
+			return r, err
+		}
+		r.Updated++
+	}
+	return r, nil
+}
+
+// Run publishes the pages, for use as a scheduled job.
+func (p Publisher) Run(ctx context.Context) error {
+	r, err := p.Publish(ctx)
+	if err == nil {
+		log.Printf("Published the team skills of %s to Confluence, created %d pages, updated %d and left %d unchanged.", p.Domain, r.Created, r.Updated, r.Unchanged)
+	}
+	return err
+}
@@ -0,0 +1,109 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type profileStore struct {
+	dataaccess.DataAccess
+	profiles []dataaccess.Profile
+}
+
+func (s profileStore) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	return s.profiles, nil
+}
+
+var team = []dataaccess.Profile{
+	{EmailAddress: "boss@github.com", Name: "Boss", Skills: []dataaccess.Skill{{Skill: "go", Level: dataaccess.ExpertLevel}}},
+	{EmailAddress: "dev@github.com", Name: "Dev", Manager: "boss@github.com", Skills: []dataaccess.Skill{
+		{Skill: "go", Level: dataaccess.CompetentLevel},
+		{Skill: "<script>", Level: dataaccess.NoviceLevel},
+	}},
+	{EmailAddress: "solo@github.com", Name: "Solo"},
+}
+
+func TestThatEachManagersTeamIsSummarised(t *testing.T) {
+	summaries := Summarise("github.com", team)
+	if len(summaries) != 1 {
+		t.Fatalf("Expected only the boss to have a team, but got %+v", summaries)
+	}
+	ts := summaries[0]
+	if ts.Manager != "boss@github.com" || ts.Size != 2 {
+		t.Errorf("Expected a team of 2 managed by the boss, but got %+v", ts)
+	}
+	if len(ts.Skills) != 2 || ts.Skills[0].Skill != "go" || ts.Skills[0].Levels != [5]int{0, 1, 0, 1, 0} || strings.Join(ts.Skills[0].People, ",") != "Boss,Dev" {
+		t.Errorf("Expected go to be listed first with a competent and an expert, but got %+v", ts.Skills)
+	}
+
+	body, err := ts.Render()
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if strings.Contains(body, "<script>") || !strings.Contains(body, "&lt;script&gt;") {
+		t.Errorf("Expected skill names to be escaped, but got %s", body)
+	}
+}
+
+func TestThatPagesAreCreatedAndOnlyUpdatedWhenTheyChange(t *testing.T) {
+	pages := make(map[string]content)
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "bot@github.com" || token != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		requests = append(requests, r.Method)
+		switch r.Method {
+		case http.MethodGet:
+			var results []content
+			if c, ok := pages[r.URL.Query().Get("title")]; ok && r.URL.Query().Get("spaceKey") == "ENG" {
+				results = append(results, c)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+		case http.MethodPost, http.MethodPut:
+			var c content
+			json.NewDecoder(r.Body).Decode(&c)
+			if r.Method == http.MethodPost {
+				if len(c.Ancestors) != 1 || c.Ancestors[0].ID != "42" {
+					t.Errorf("Expected pages to be created under the parent, but got %+v", c.Ancestors)
+				}
+				c.ID, c.Version = "1", &version{1}
+			}
+			pages[c.Title] = c
+			json.NewEncoder(w).Encode(c)
+		}
+	}))
+	defer server.Close()
+
+	store := profileStore{profiles: team}
+	p := NewPublisher(store, NewClient(server.URL, "bot@github.com", "token"), "ENG", "42", "github.com")
+
+	tests := []struct {
+		change   func()
+		expected Result
+	}{
+		{func() {}, Result{Created: 1}},
+		{func() {}, Result{Unchanged: 1}},
+		{func() { store.profiles[1].Skills[0].Level = dataaccess.ProficientLevel }, Result{Updated: 1}},
+	}
+	for i, test := range tests {
+		test.change()
+		r, err := p.Publish(context.Background())
+		if err != nil {
+			t.Fatalf("%d: unexpected error. %v", i, err)
+		}
+		if r != test.expected {
+			t.Errorf("%d: expected %+v, but got %+v", i, test.expected, r)
+		}
+	}
+	if c := pages["Team skills: Boss (boss@github.com)"]; c.Version == nil || c.Version.Number != 2 {
+		t.Errorf("Expected the update to be version 2, but got %+v", c.Version)
+	}
+}
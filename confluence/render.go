@@ -0,0 +1,108 @@
+package confluence
+
+import (
+	"bytes"
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A TeamSummary counts the people in a manager's team at each level of each
+// of their skills.
+type TeamSummary struct {
+	Manager string
+	Name    string
+	// Size is the number of people in the team, including the manager.
+	Size   int
+	Skills []SkillCount
+}
+
+// A SkillCount is the number of people at each level of a skill, and who
+// they are.
+type SkillCount struct {
+	Skill string
+	// Levels has the number of people at each level, from Novice to Master.
+	Levels [dataaccess.MasterLevel]int
+	People []string
+}
+
+// Summarise returns a summary of each team in the domain, which is everyone
+// who reports to a manager, directly or indirectly, in order of the
+// managers' names. Skills are listed with the most people first.
+func Summarise(domain string, profiles []dataaccess.Profile) []TeamSummary {
+	byID := make(map[string]dataaccess.Profile)
+	for _, p := range profiles {
+		byID[strings.ToLower(p.EmailAddress)] = p
+	}
+
+	var op []TeamSummary
+	var visit func(n *dataaccess.OrgNode)
+	visit = func(n *dataaccess.OrgNode) {
+		for _, c := range n.Children {
+			visit(c)
+		}
+		if n.TeamSize == 0 || n.ID == domain {
+			return
+		}
+		ts := TeamSummary{Manager: n.ID, Name: n.Name, Size: n.TeamSize + 1}
+		counts := make(map[string]*SkillCount)
+		for _, id := range n.Members() {
+			p := byID[id]
+			name := p.Name
+			if name == "" {
+				name = p.EmailAddress
+			}
+			for _, s := range p.Skills {
+				if s.Level < dataaccess.NoviceLevel || s.Level > dataaccess.MasterLevel {
+					continue
+				}
+				sc, ok := counts[s.Skill]
+				if !ok {
+					sc = &SkillCount{Skill: s.Skill}
+					counts[s.Skill] = sc
+				}
+				sc.Levels[s.Level-1]++
+				sc.People = append(sc.People, name)
+			}
+		}
+		for _, sc := range counts {
+			sort.Strings(sc.People)
+			ts.Skills = append(ts.Skills, *sc)
+		}
+		sort.Slice(ts.Skills, func(i, j int) bool {
+			if len(ts.Skills[i].People) != len(ts.Skills[j].People) {
+				return len(ts.Skills[i].People) > len(ts.Skills[j].People)
+			}
+			return ts.Skills[i].Skill < ts.Skills[j].Skill
+		})
+		op = append(op, ts)
+	}
+	visit(dataaccess.NewOrgTree(domain, profiles))
+
+	sort.SliceStable(op, func(i, j int) bool { return op[i].Name < op[j].Name })
+	return op
+}
+
+// Title returns the title of the team's page. Confluence titles are unique
+// within a space, so the manager's email address is included.
+func (ts TeamSummary) Title() string {
+	return "Team skills: " + ts.Name + " (" + ts.Manager + ")"
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<p>The skills of the {{ .Size }} people in {{ .Name }}'s team, from their pill profiles. This page is updated automatically, so changes made here will be lost.</p>
+<table><tbody><tr><th>Skill</th><th>Novice</th><th>Competent</th><th>Proficient</th><th>Expert</th><th>Master</th><th>People</th></tr>
+{{- range .Skills }}
+<tr><td>{{ .Skill }}</td>{{ range .Levels }}<td>{{ if . }}{{ . }}{{ end }}</td>{{ end }}<td>{{ range $i, $p := .People }}{{ if $i }}, {{ end }}{{ $p }}{{ end }}</td></tr>
+{{- end }}
+</tbody></table>`))
+
+// Render returns the team's page body in the Confluence storage format.
+func (ts TeamSummary) Render() (string, error) {
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, ts); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
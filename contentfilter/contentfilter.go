@@ -0,0 +1,151 @@
+// Package contentfilter blocks profanity, and personal information shared by
+// accident, in the free text people write about themselves, such as bios,
+// notes and the names of skill tags.
+package contentfilter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// profanity is blocked when the Profanity setting is on. Words are matched
+// whole, or followed by one of the suffixes, so that names such as
+// "Scunthorpe" aren't blocked.
+var profanity = []string{
+	"arse", "arsehole", "asshole", "bastard", "bitch", "bollocks", "bullshit",
+	"cock", "cunt", "dickhead", "fuck", "motherfucker", "piss", "prick",
+	"shit", "slut", "twat", "wanker", "whore",
+	// German.
+	"arschloch", "fotze", "hurensohn", "scheiße", "scheisse", "wichser",
+}
+
+var suffixes = []string{"", "s", "es", "ed", "er", "ers", "ing", "y"}
+
+var (
+	// Payment card numbers are 13 to 19 digits, optionally in groups.
+	cardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	// US social security numbers, e.g. 078-05-1120.
+	ssnPattern = regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)
+	// UK national insurance numbers, e.g. AB 12 34 56 C.
+	ninoPattern = regexp.MustCompile(`(?i)\b[A-CEGHJ-PR-TW-Z][A-CEGHJ-NPR-TW-Z] ?\d{2} ?\d{2} ?\d{2} ?[A-D]\b`)
+	// Phone numbers have at least 9 digits, optionally starting with a
+	// country code and separated by spaces, dots, dashes or brackets.
+	phonePattern = regexp.MustCompile(`(?:\+|\b)\d[\d ().-]{7,}\d\b`)
+)
+
+// A Field is free text to check, with the name used to describe it in
+// validation errors, e.g. "bio".
+type Field struct {
+	Name string
+	Text string
+}
+
+// A Filter checks free text against a tenant's content filter settings.
+type Filter struct {
+	settings dataaccess.ContentFilterSettings
+	words    map[string]bool
+}
+
+// New creates a Filter which blocks the content the settings ask for.
+func New(s dataaccess.ContentFilterSettings) Filter {
+	f := Filter{settings: s, words: make(map[string]bool)}
+	if s.Profanity {
+		for _, w := range profanity {
+			for _, suffix := range suffixes {
+				f.words[w+suffix] = true
+			}
+		}
+	}
+	for _, w := range s.BlockedWords {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			f.words[w] = true
+		}
+	}
+	return f
+}
+
+// Check returns a ValidationError describing what needs to be removed from
+// each of the fields, or nil if they're all acceptable.
+func (f Filter) Check(fields ...Field) error {
+	var problems []string
+	for _, field := range fields {
+		problems = append(problems, f.problems(field)...)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return dataaccess.ValidationError{Problems: problems}
+}
+
+func (f Filter) problems(field Field) []string {
+	var problems []string
+	reported := make(map[string]bool)
+	for _, w := range words(field.Text) {
+		if f.words[w] && !reported[w] {
+			reported[w] = true
+			problems = append(problems, fmt.Sprintf("remove the word \"%s\" from the %s", w, field.Name))
+		}
+	}
+	if !f.settings.PersonalInformation {
+		return problems
+	}
+
+	text := field.Text
+	found := func(pattern *regexp.Regexp, kind string, valid func(string) bool) {
+		for _, m := range pattern.FindAllString(text, -1) {
+			if valid != nil && !valid(m) {
+				continue
+			}
+			problems = append(problems, fmt.Sprintf("remove what looks like %s (%s) from the %s so that it isn't shared with everyone who can see it", kind, m, field.Name))
+			// Numbers are only reported once, as the most specific kind.
+			text = strings.Replace(text, m, "", 1)
+		}
+	}
+	found(cardPattern, "a payment card number", luhn)
+	found(ssnPattern, "a social security number", nil)
+	found(ninoPattern, "a national insurance number", nil)
+	found(phonePattern, "a phone number", func(m string) bool { return digits(m) >= 9 })
+	return problems
+}
+
+// words splits the text into lowercase words, so that tag names such as
+// "shit-code" are checked word by word.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+func digits(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// luhn returns true if the digits in the string pass the Luhn check used by
+// payment card numbers, so that other long numbers aren't reported as cards.
+func luhn(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] < '0' || s[i] > '9' {
+			continue
+		}
+		d := int(s[i] - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,57 @@
+package contentfilter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatUnsuitableContentIsBlocked(t *testing.T) {
+	f := New(dataaccess.ContentFilterSettings{Profanity: true, PersonalInformation: true, BlockedWords: []string{"Initech"}})
+
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"I've worked on Go services at Scunthorpe council since 2012.", nil},
+		{"Ask me about Kubernetes, room 101, extension 2234.", nil},
+		{"Fucking good at SQL", []string{`remove the word "fucking"`}},
+		{"Ex-Initech engineer", []string{`remove the word "initech"`}},
+		{"Call me on +44 7700 900123", []string{"a phone number (+44 7700 900123)"}},
+		{"Card 4111 1111 1111 1111 for expenses", []string{"a payment card number (4111 1111 1111 1111)"}},
+		{"SSN 078-05-1120", []string{"a social security number (078-05-1120)"}},
+		{"NI number AB 12 34 56 C", []string{"a national insurance number (AB 12 34 56 C)"}},
+	}
+
+	for _, test := range tests {
+		err := f.Check(Field{Name: "bio", Text: test.text})
+		if test.expected == nil {
+			if err != nil {
+				t.Errorf("For '%s', expected no error, but got %v", test.text, err)
+			}
+			continue
+		}
+		ve, ok := err.(dataaccess.ValidationError)
+		if !ok {
+			t.Errorf("For '%s', expected a validation error, but got %v", test.text, err)
+			continue
+		}
+		if len(ve.Problems) != len(test.expected) {
+			t.Errorf("For '%s', expected %d problems, but got %v", test.text, len(test.expected), ve.Problems)
+			continue
+		}
+		for i, p := range ve.Problems {
+			if !strings.Contains(p, test.expected[i]) || !strings.Contains(p, "the bio") {
+				t.Errorf("For '%s', expected the problem to mention '%s' and the bio, but was '%s'", test.text, test.expected[i], p)
+			}
+		}
+	}
+}
+
+func TestThatFiltersCanBeSwitchedOff(t *testing.T) {
+	f := New(dataaccess.ContentFilterSettings{})
+	if err := f.Check(Field{Name: "note", Text: "shit, call +44 7700 900123"}); err != nil {
+		t.Errorf("expected nothing to be blocked, but got %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+// Package crm drafts projects from the opportunities in a sales pipeline, so
+// that the demand for skills can be compared with the people available
+// before the work is won.
+package crm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// DefaultDuration is how long a project drafted from an opportunity without
+// an end date is expected to last.
+const DefaultDuration = 90 * 24 * time.Hour
+
+// DefaultLevel is the level required of skills which are listed on an
+// opportunity without one.
+const DefaultLevel dataaccess.DreyfusLevel = dataaccess.ProficientLevel
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// An Opportunity is a piece of work in the sales pipeline which hasn't been
+// won or lost yet.
+type Opportunity struct {
+	ID     string
+	Name   string
+	Client string
+	// Start is when the work would start, or zero if the CRM doesn't say.
+	Start time.Time
+	// End is when the work would end, or zero if the CRM doesn't say.
+	End          time.Time
+	Requirements []dataaccess.SkillRequirement
+}
+
+// A Source lists the open opportunities in a CRM.
+type Source interface {
+	// Name identifies the CRM, e.g. "salesforce".
+	Name() string
+	Opportunities(ctx context.Context) ([]Opportunity, error)
+}
+
+// OpenSource returns the Source for the URL, e.g.
+// salesforce://example.my.salesforce.com or hubspot://api.hubapi.com. The
+// access token is read from the SALESFORCE_ACCESS_TOKEN or
+// HUBSPOT_ACCESS_TOKEN environment variable.
+func OpenSource(rawurl string) (Source, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "salesforce":
+		if u.Host == "" {
+			return nil, fmt.Errorf("crm: the Salesforce URL must include the instance, e.g. salesforce://example.my.salesforce.com")
+		}
+		return NewSalesforceSource("https://"+u.Host, os.Getenv("SALESFORCE_ACCESS_TOKEN")), nil
+	case "hubspot":
+		host := u.Host
+		if host == "" {
+			host = "api.hubapi.com"
+		}
+		return NewHubSpotSource("https://"+host, os.Getenv("HUBSPOT_ACCESS_TOKEN")), nil
+	}
+	return nil, fmt.Errorf("crm: unsupported source '%s', use salesforce or hubspot", u.Scheme)
+}
+
+// ParseRequirements reads the skills an opportunity needs from a comma
+// separated list of skills with optional levels, e.g. "go:4, sql". Skills
+// without a level need the DefaultLevel.
+func ParseRequirements(s string) ([]dataaccess.SkillRequirement, error) {
+	var op []dataaccess.SkillRequirement
+	for _, item := range strings.Split(s, ",") {
+		parts := strings.SplitN(item, ":", 2)
+		skill := dataaccess.CleanTag(strings.TrimSpace(parts[0]))
+		if skill == "" {
+			continue
+		}
+		level := DefaultLevel
+		if len(parts) == 2 {
+			n, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil || n < dataaccess.NoviceLevel || n > dataaccess.MasterLevel {
+				return nil, fmt.Errorf("crm: the level of %s must be between 1 and 5, but was '%s'", skill, strings.TrimSpace(parts[1]))
+			}
+			level = dataaccess.DreyfusLevel(n)
+		}
+		op = append(op, dataaccess.SkillRequirement{Skill: skill, Level: level})
+	}
+	return op, nil
+}
+
+// parseDate reads a date which may or may not include a time, e.g.
+// "2017-03-01" or "2017-03-01T00:00:00Z". Empty dates are zero.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// A Syncer keeps a draft project for each open opportunity in a CRM.
+type Syncer struct {
+	DataAccess dataaccess.DataAccess
+	Source     Source
+	// Domain is the tenant the projects belong to.
+	Domain string
+	now    func() time.Time
+}
+
+// NewSyncer creates an instance of the Syncer.
+func NewSyncer(da dataaccess.DataAccess, source Source, domain string) *Syncer {
+	return &Syncer{da, source, strings.ToLower(domain), time.Now}
+}
+
+// A Result summarises a sync.
+type Result struct {
+	// Drafted is the number of draft projects created or updated.
+	Drafted int
+	// Skipped is the number of opportunities which weren't drafted, because
+	// they don't list any skills, or their project has been confirmed.
+	Skipped int
+	// Removed is the number of drafts whose opportunities have been won or
+	// lost.
+	Removed int
+}
+
+// Sync creates or updates a draft project for each open opportunity, and
+// removes the drafts of opportunities which have closed. Projects which have
+// been confirmed are left alone, so that changes made in pill are kept.
+func (s Syncer) Sync(ctx context.Context) (Result, error) {
+	var r Result
+	da := dataaccess.WithContext(s.DataAccess, ctx)
+
+	opportunities, err := s.Source.Opportunities(ctx)
+	if err != nil {
+		return r, err
+	}
+
+	open := make(map[string]bool)
+	for _, o := range opportunities {
+		id := s.Source.Name() + ":" + o.ID
+		open[id] = true
+		if len(o.Requirements) == 0 {
+			r.Skipped++
+			continue
+		}
+
+		projectID := "crm-" + s.Source.Name() + "-" + o.ID
+		existing, found, err := da.GetProject(projectID)
+		if err != nil {
+			return r, err
+		}
+		if found && existing.Status != dataaccess.ProjectDraft {
+			r.Skipped++
+			continue
+		}
+
+		now := s.now()
+		p := dataaccess.Project{
+			ID:           projectID,
+			Domain:       s.Domain,
+			Name:         o.Name,
+			Client:       o.Client,
+			Start:        o.Start,
+			End:          o.End,
+			Requirements: o.Requirements,
+			Status:       dataaccess.ProjectDraft,
+			Opportunity:  id,
+			Created:      now,
+			Updated:      now,
+		}
+		if found {
+			p.Created = existing.Created
+		}
+		if p.Start.IsZero() {
+			p.Start = now
+		}
+		if !p.End.After(p.Start) {
+			p.End = p.Start.Add(DefaultDuration)
+		}
+
+		if err := da.SaveProject(&p); err != nil {
+			if _, ok := err.(dataaccess.ValidationError); ok {
+				log.Printf("Skipping the %s opportunity %s. %v", s.Source.Name(), o.ID, err)
+				r.Skipped++
+				continue
+			}
+			return r, err
+		}
+		r.Drafted++
+	}
+
+	projects, err := da.ListProjects(s.Domain)
+	if err != nil {
+		return r, err
+	}
+	for _, p := range projects {
+		if p.Status != dataaccess.ProjectDraft || !strings.HasPrefix(p.Opportunity, s.Source.Name()+":") || open[p.Opportunity] {
+			continue
+		}
+		if err := da.DeleteProject(p.ID); err != nil {
+			return r, err
+		}
+		r.Removed++
+	}
+	return r, nil
+}
+
+// Run syncs the projects, for use as a scheduled job.
+func (s Syncer) Run(ctx context.Context) error {
+	r, err := s.Sync(ctx)
+	if err == nil {
+		log.Printf("Drafted %d projects from %s, skipped %d opportunities and removed %d drafts.", r.Drafted, s.Source.Name(), r.Skipped, r.Removed)
+	}
+	return err
+}
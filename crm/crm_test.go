@@ -0,0 +1,203 @@
+package crm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type projectStore struct {
+	dataaccess.DataAccess
+	projects map[string]dataaccess.Project
+}
+
+func (s *projectStore) GetProject(id string) (*dataaccess.Project, bool, error) {
+	p, ok := s.projects[id]
+	return &p, ok, nil
+}
+
+func (s *projectStore) SaveProject(p *dataaccess.Project) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	s.projects[p.ID] = *p
+	return nil
+}
+
+func (s *projectStore) ListProjects(domain string) ([]dataaccess.Project, error) {
+	var op []dataaccess.Project
+	for _, p := range s.projects {
+		if p.Domain == domain {
+			op = append(op, p)
+		}
+	}
+	return op, nil
+}
+
+func (s *projectStore) DeleteProject(id string) error {
+	delete(s.projects, id)
+	return nil
+}
+
+type memorySource []Opportunity
+
+func (s memorySource) Name() string {
+	return "memory"
+}
+
+func (s memorySource) Opportunities(ctx context.Context) ([]Opportunity, error) {
+	return s, nil
+}
+
+func TestThatRequirementsAreParsed(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []dataaccess.SkillRequirement
+		err      bool
+	}{
+		{"", nil, false},
+		{"Go:4, sql", []dataaccess.SkillRequirement{{Skill: "go", Level: 4}, {Skill: "sql", Level: DefaultLevel}}, false},
+		{"go:expert", nil, true},
+		{"go:6", nil, true},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseRequirements(test.input)
+		if (err != nil) != test.err {
+			t.Errorf("For '%s', expected an error: %v, but received %v", test.input, test.err, err)
+			continue
+		}
+		if len(actual) != len(test.expected) {
+			t.Errorf("For '%s', expected %v, but received %v", test.input, test.expected, actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != test.expected[i] {
+				t.Errorf("For '%s', expected %v, but received %v", test.input, test.expected, actual)
+			}
+		}
+	}
+}
+
+func TestThatOpportunitiesAreDraftedAsProjects(t *testing.T) {
+	now := time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC)
+	go3 := []dataaccess.SkillRequirement{{Skill: "go", Level: 3}}
+	store := &projectStore{projects: map[string]dataaccess.Project{
+		"crm-memory-won":       {ID: "crm-memory-won", Domain: "github.com", Status: dataaccess.ProjectDraft, Opportunity: "memory:won"},
+		"crm-memory-confirmed": {ID: "crm-memory-confirmed", Domain: "github.com", Name: "Kept", Status: dataaccess.ProjectConfirmed, Opportunity: "memory:confirmed"},
+		"manual":               {ID: "manual", Domain: "github.com", Status: dataaccess.ProjectDraft},
+	}}
+	source := memorySource{
+		{ID: "new", Name: "Website", Client: "Acme", Start: now.AddDate(0, 1, 0), Requirements: go3},
+		{ID: "confirmed", Name: "Changed", Requirements: go3},
+		{ID: "noskills", Name: "Unknown"},
+	}
+
+	s := NewSyncer(store, source, "GitHub.com")
+	s.now = func() time.Time { return now }
+	r, err := s.Sync(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	if r.Drafted != 1 || r.Skipped != 2 || r.Removed != 1 {
+		t.Errorf("Expected 1 draft, 2 skipped and 1 removed, but received %+v", r)
+	}
+
+	p, ok := store.projects["crm-memory-new"]
+	if !ok {
+		t.Fatal("Expected a draft project to be created for the new opportunity.")
+	}
+	if p.Domain != "github.com" || p.Status != dataaccess.ProjectDraft || p.Opportunity != "memory:new" || p.Client != "Acme" {
+		t.Errorf("Unexpected draft %+v", p)
+	}
+	if !p.End.Equal(p.Start.Add(DefaultDuration)) {
+		t.Errorf("Expected the draft to last for the default duration, but ended %v", p.End)
+	}
+	if _, ok := store.projects["crm-memory-won"]; ok {
+		t.Error("Expected the draft of the closed opportunity to be removed.")
+	}
+	if store.projects["crm-memory-confirmed"].Name != "Kept" {
+		t.Error("Expected the confirmed project to be left alone.")
+	}
+	if _, ok := store.projects["manual"]; !ok {
+		t.Error("Expected drafts which weren't created from the CRM to be left alone.")
+	}
+}
+
+func TestThatSalesforceOpportunitiesAreRead(t *testing.T) {
+	pages := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		pages++
+		if r.URL.Path == "/next" {
+			w.Write([]byte(`{"done":true,"records":[{"Id":"2","Name":"App","CloseDate":"2017-04-01","Pill_Required_Skills__c":"sql:2"}]}`))
+			return
+		}
+		w.Write([]byte(`{"done":false,"nextRecordsUrl":"/next","records":[{"Id":"1","Name":"Website","Account":{"Name":"Acme"},"CloseDate":"2017-03-01","Pill_Start_Date__c":"2017-03-15","Pill_End_Date__c":"2017-06-01","Pill_Required_Skills__c":"go:4"}]}`))
+	}))
+	defer server.Close()
+
+	opportunities, err := NewSalesforceSource(server.URL, "token").Opportunities(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	if pages != 2 || len(opportunities) != 2 {
+		t.Fatalf("Expected 2 opportunities on 2 pages, but received %d on %d.", len(opportunities), pages)
+	}
+	website := opportunities[0]
+	if website.Client != "Acme" || website.Start.Format("2006-01-02") != "2017-03-15" || website.End.Format("2006-01-02") != "2017-06-01" || website.Requirements[0].Level != 4 {
+		t.Errorf("Unexpected opportunity %+v", website)
+	}
+	if opportunities[1].Start.Format("2006-01-02") != "2017-04-01" {
+		t.Errorf("Expected the work to start when the opportunity closes, but started %v", opportunities[1].Start)
+	}
+}
+
+func TestThatHubSpotDealsAreRead(t *testing.T) {
+	var searches []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var search map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&search)
+		searches = append(searches, search)
+		if search["after"] == "100" {
+			w.Write([]byte(`{"results":[{"id":"2","properties":{"dealname":"App","closedate":"2017-04-01T00:00:00Z","pill_required_skills":"sql"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"results":[{"id":"1","properties":{"dealname":"Website","pill_client":"Acme","closedate":"2017-03-01T00:00:00Z","pill_required_skills":"go:4"}}],"paging":{"next":{"after":"100"}}}`))
+	}))
+	defer server.Close()
+
+	opportunities, err := NewHubSpotSource(server.URL, "token").Opportunities(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	if len(searches) != 2 || len(opportunities) != 2 {
+		t.Fatalf("Expected 2 deals from 2 searches, but received %d from %d.", len(opportunities), len(searches))
+	}
+	if opportunities[0].Name != "Website" || opportunities[0].Client != "Acme" || !opportunities[0].Start.Equal(time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected deal %+v", opportunities[0])
+	}
+	if opportunities[1].Requirements[0].Level != DefaultLevel {
+		t.Errorf("Expected skills without a level to need the default level, but received %v", opportunities[1].Requirements)
+	}
+}
+
+func TestThatUnsupportedSourcesAreRejected(t *testing.T) {
+	if _, err := OpenSource("pipedrive://example"); err == nil {
+		t.Error("Expected an error for an unsupported CRM.")
+	}
+	if s, err := OpenSource("salesforce://example.my.salesforce.com"); err != nil || s.Name() != "salesforce" {
+		t.Errorf("Expected a Salesforce source, but received %v, %v", s, err)
+	}
+}
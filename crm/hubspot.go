@@ -0,0 +1,117 @@
+package crm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// The HubSpotProperties are read from each deal. The skills, client and
+// dates of the work are read from custom properties, which need to be added
+// to deals.
+var HubSpotProperties = []string{"dealname", "closedate", "pill_client", "pill_start_date", "pill_end_date", "pill_required_skills"}
+
+// HubSpotSource reads open deals through the HubSpot CRM API.
+type HubSpotSource struct {
+	// BaseURL is the address of the HubSpot API.
+	BaseURL string
+	// Token is the access token of a private app with the
+	// crm.objects.deals.read scope.
+	Token  string
+	Client *http.Client
+}
+
+// NewHubSpotSource creates an instance of the HubSpotSource.
+func NewHubSpotSource(baseURL string, token string) *HubSpotSource {
+	return &HubSpotSource{baseURL, token, httpClient}
+}
+
+// Name returns "hubspot".
+func (s HubSpotSource) Name() string {
+	return "hubspot"
+}
+
+type hubSpotResponse struct {
+	Results []struct {
+		ID         string            `json:"id"`
+		Properties map[string]string `json:"properties"`
+	} `json:"results"`
+	Paging struct {
+		Next struct {
+			After string `json:"after"`
+		} `json:"next"`
+	} `json:"paging"`
+}
+
+// Opportunities returns the open deals. Work without a start date is expected
+// to start when the deal closes.
+func (s HubSpotSource) Opportunities(ctx context.Context) ([]Opportunity, error) {
+	var op []Opportunity
+
+	after := ""
+	for {
+		search := map[string]interface{}{
+			"filterGroups": []interface{}{
+				map[string]interface{}{
+					"filters": []interface{}{
+						map[string]string{"propertyName": "hs_is_closed", "operator": "EQ", "value": "false"},
+					},
+				},
+			},
+			"properties": HubSpotProperties,
+			"limit":      100,
+		}
+		if after != "" {
+			search["after"] = after
+		}
+		body, _ := json.Marshal(search)
+
+		req, err := http.NewRequest("POST", s.BaseURL+"/crm/v3/objects/deals/search", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+		resp, err := s.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var hr hubSpotResponse
+		err = json.NewDecoder(resp.Body).Decode(&hr)
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("hubspot: the search returned status %d", resp.StatusCode)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range hr.Results {
+			p := r.Properties
+			o := Opportunity{ID: r.ID, Name: p["dealname"], Client: p["pill_client"]}
+			if o.Requirements, err = ParseRequirements(p["pill_required_skills"]); err != nil {
+				return nil, fmt.Errorf("hubspot: deal %s: %v", r.ID, err)
+			}
+			start := p["pill_start_date"]
+			if start == "" {
+				start = p["closedate"]
+			}
+			if o.Start, err = parseDate(start); err != nil {
+				return nil, fmt.Errorf("hubspot: deal %s: %v", r.ID, err)
+			}
+			if o.End, err = parseDate(p["pill_end_date"]); err != nil {
+				return nil, fmt.Errorf("hubspot: deal %s: %v", r.ID, err)
+			}
+			op = append(op, o)
+		}
+
+		if hr.Paging.Next.After == "" {
+			return op, nil
+		}
+		after = hr.Paging.Next.After
+	}
+}
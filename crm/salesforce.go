@@ -0,0 +1,105 @@
+package crm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// The SalesforceQuery finds the open opportunities. The skills and dates of
+// the work are read from custom fields, which need to be added to the
+// Opportunity object.
+const SalesforceQuery = "SELECT Id, Name, Account.Name, CloseDate, Pill_Start_Date__c, Pill_End_Date__c, Pill_Required_Skills__c " +
+	"FROM Opportunity WHERE IsClosed = false"
+
+// SalesforceSource reads opportunities through the Salesforce REST API.
+type SalesforceSource struct {
+	// InstanceURL is the address of the org, e.g.
+	// https://example.my.salesforce.com
+	InstanceURL string
+	// Token is an OAuth access token of a user who can read opportunities.
+	Token  string
+	Client *http.Client
+}
+
+// NewSalesforceSource creates an instance of the SalesforceSource.
+func NewSalesforceSource(instanceURL string, token string) *SalesforceSource {
+	return &SalesforceSource{instanceURL, token, httpClient}
+}
+
+// Name returns "salesforce".
+func (s SalesforceSource) Name() string {
+	return "salesforce"
+}
+
+type salesforceResponse struct {
+	Done           bool   `json:"done"`
+	NextRecordsURL string `json:"nextRecordsUrl"`
+	Records        []struct {
+		ID      string `json:"Id"`
+		Name    string `json:"Name"`
+		Account struct {
+			Name string `json:"Name"`
+		} `json:"Account"`
+		CloseDate string `json:"CloseDate"`
+		Start     string `json:"Pill_Start_Date__c"`
+		End       string `json:"Pill_End_Date__c"`
+		Skills    string `json:"Pill_Required_Skills__c"`
+	} `json:"records"`
+}
+
+// Opportunities returns the open opportunities. Work without a start date is
+// expected to start when the opportunity closes.
+func (s SalesforceSource) Opportunities(ctx context.Context) ([]Opportunity, error) {
+	var op []Opportunity
+
+	next := "/services/data/v59.0/query?q=" + url.QueryEscape(SalesforceQuery)
+	for next != "" {
+		req, err := http.NewRequest("GET", s.InstanceURL+next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+
+		resp, err := s.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var sr salesforceResponse
+		err = json.NewDecoder(resp.Body).Decode(&sr)
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("salesforce: the query returned status %d", resp.StatusCode)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range sr.Records {
+			o := Opportunity{ID: r.ID, Name: r.Name, Client: r.Account.Name}
+			if o.Requirements, err = ParseRequirements(r.Skills); err != nil {
+				return nil, fmt.Errorf("salesforce: opportunity %s: %v", r.ID, err)
+			}
+			start := r.Start
+			if start == "" {
+				start = r.CloseDate
+			}
+			if o.Start, err = parseDate(start); err != nil {
+				return nil, fmt.Errorf("salesforce: opportunity %s: %v", r.ID, err)
+			}
+			if o.End, err = parseDate(r.End); err != nil {
+				return nil, fmt.Errorf("salesforce: opportunity %s: %v", r.ID, err)
+			}
+			op = append(op, o)
+		}
+
+		next = ""
+		if !sr.Done {
+			next = sr.NextRecordsURL
+		}
+	}
+	return op, nil
+}
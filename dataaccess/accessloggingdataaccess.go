@@ -0,0 +1,157 @@
+package dataaccess
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+)
+
+// AccessLoggingDataAccess wraps a DataAccess and records who views, lists or
+// searches for whose profile in an access log. People viewing their own
+// profile, and reads without a caller, e.g. by scheduled jobs, aren't
+// recorded.
+type AccessLoggingDataAccess struct {
+	DataAccess
+	log audit.Log
+	ctx context.Context
+}
+
+// NewAccessLoggingDataAccess creates a DataAccess which records profile
+// views in the log.
+func NewAccessLoggingDataAccess(da DataAccess, l audit.Log) DataAccess {
+	return &AccessLoggingDataAccess{da, l, context.Background()}
+}
+
+// WithContext records views by the context's caller.
+func (da AccessLoggingDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &AccessLoggingDataAccess{WithContext(da.DataAccess, ctx), da.log, ctx}
+}
+
+func (da AccessLoggingDataAccess) record(action string, emailAddress string) {
+	c, ok := caller.FromContext(da.ctx)
+	if !ok || strings.EqualFold(c.EmailAddress, emailAddress) {
+		return
+	}
+	e := audit.NewEntry(da.ctx, action, GetDomain(emailAddress), strings.ToLower(emailAddress))
+	if err := da.log.Record(e); err != nil {
+		log.Printf("Failed to record %s of %s by %s in the access log. %v", e.Action, e.Target, e.Actor, err)
+	}
+}
+
+// recordAll records the caller's access to each of the profiles in one
+// batch, so that listing thousands of people doesn't write to the log
+// thousands of times.
+func (da AccessLoggingDataAccess) recordAll(action string, emailAddresses []string) {
+	c, ok := caller.FromContext(da.ctx)
+	if !ok {
+		return
+	}
+	var entries []audit.Entry
+	for _, emailAddress := range emailAddresses {
+		if strings.EqualFold(c.EmailAddress, emailAddress) {
+			continue
+		}
+		entries = append(entries, audit.NewEntry(da.ctx, action, GetDomain(emailAddress), strings.ToLower(emailAddress)))
+	}
+	if err := audit.RecordAll(da.log, entries); err != nil {
+		log.Printf("Failed to record %s of %d profiles by %s in the access log. %v", action, len(entries), c.EmailAddress, err)
+	}
+}
+
+// An AccessRecorder records access to profiles which doesn't go through the
+// DataAccess, e.g. downloading them as PDFs or in an export.
+type AccessRecorder interface {
+	RecordAccess(action string, emailAddress string)
+	RecordAccesses(action string, emailAddresses []string)
+}
+
+// RecordAccess records the caller's access to the profile, if the data
+// access records access.
+func RecordAccess(da DataAccess, action string, emailAddress string) {
+	if ar, ok := da.(AccessRecorder); ok {
+		ar.RecordAccess(action, emailAddress)
+	}
+}
+
+// RecordAccesses records the caller's access to each of the profiles, if the
+// data access records access.
+func RecordAccesses(da DataAccess, action string, emailAddresses []string) {
+	if ar, ok := da.(AccessRecorder); ok {
+		ar.RecordAccesses(action, emailAddresses)
+	}
+}
+
+// RecordAccess records the caller's access to the profile, e.g.
+// audit.ProfileExported.
+func (da AccessLoggingDataAccess) RecordAccess(action string, emailAddress string) {
+	da.record(action, emailAddress)
+}
+
+// RecordAccesses records the caller's access to each of the profiles.
+func (da AccessLoggingDataAccess) RecordAccesses(action string, emailAddresses []string) {
+	da.recordAll(action, emailAddresses)
+}
+
+// GetProfile returns the profile, recording that the caller viewed it.
+func (da AccessLoggingDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	p, found, err := da.DataAccess.GetProfile(emailAddress)
+	if err == nil && found {
+		da.record(audit.ProfileViewed, p.EmailAddress)
+	}
+	return p, found, err
+}
+
+// EmailAddresses returns the email addresses of the profiles.
+func EmailAddresses(profiles []Profile) []string {
+	emailAddresses := make([]string, len(profiles))
+	for i, p := range profiles {
+		emailAddresses[i] = p.EmailAddress
+	}
+	return emailAddresses
+}
+
+// ListProfiles returns the profiles, recording that the caller listed them.
+func (da AccessLoggingDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
+	profiles, err := da.DataAccess.ListProfiles(emailAddress)
+	if err == nil {
+		da.recordAll(audit.ProfileListed, EmailAddresses(profiles))
+	}
+	return profiles, err
+}
+
+// FindProfiles returns the matching profiles, recording that the caller
+// listed them.
+func (da AccessLoggingDataAccess) FindProfiles(domain string, f ProfileFilter) ([]Profile, error) {
+	profiles, err := da.DataAccess.FindProfiles(domain, f)
+	if err == nil {
+		da.recordAll(audit.ProfileListed, EmailAddresses(profiles))
+	}
+	return profiles, err
+}
+
+// FindProfilesNear returns the nearby profiles, recording that the caller
+// listed them.
+func (da AccessLoggingDataAccess) FindProfilesNear(domain string, near Coordinates, radiusKm float64) ([]Profile, error) {
+	profiles, err := da.DataAccess.FindProfilesNear(domain, near, radiusKm)
+	if err == nil {
+		da.recordAll(audit.ProfileListed, EmailAddresses(profiles))
+	}
+	return profiles, err
+}
+
+// SearchDocuments returns the people found by the search, recording that the
+// caller listed them.
+func (da AccessLoggingDataAccess) SearchDocuments(domain string, q SearchQuery) ([]SearchDocument, error) {
+	documents, err := da.DataAccess.SearchDocuments(domain, q)
+	if err == nil {
+		emailAddresses := make([]string, len(documents))
+		for i, d := range documents {
+			emailAddresses[i] = d.EmailAddress
+		}
+		da.recordAll(audit.ProfileListed, emailAddresses)
+	}
+	return documents, err
+}
@@ -0,0 +1,88 @@
+package dataaccess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+)
+
+type viewedDataAccess struct {
+	DataAccess
+}
+
+func (da viewedDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	if emailAddress == "missing@github.com" {
+		return nil, false, nil
+	}
+	return &Profile{EmailAddress: emailAddress}, true, nil
+}
+
+func TestThatProfileViewsByOtherPeopleAreRecorded(t *testing.T) {
+	dev := caller.NewContext(context.Background(), caller.Caller{EmailAddress: "dev@github.com"})
+	tests := []struct {
+		ctx      context.Context
+		email    string
+		expected bool
+	}{
+		{dev, "other@github.com", true},
+		{dev, "Dev@github.com", false},
+		{dev, "missing@github.com", false},
+		{context.Background(), "other@github.com", false},
+	}
+
+	for _, test := range tests {
+		l := audit.NewMemoryLog()
+		da := WithContext(NewAccessLoggingDataAccess(viewedDataAccess{}, l), test.ctx)
+
+		da.GetProfile(test.email)
+
+		entries, _ := l.List(audit.Query{})
+		if test.expected && (len(entries) != 1 || entries[0].Action != audit.ProfileViewed || entries[0].Actor != "dev@github.com" ||
+			entries[0].Target != test.email || entries[0].Tenant != "github.com") {
+			t.Errorf("For %s, expected the view to be recorded, but received %v", test.email, entries)
+		}
+		if !test.expected && len(entries) != 0 {
+			t.Errorf("For %s, expected nothing to be recorded, but received %v", test.email, entries)
+		}
+	}
+}
+
+func TestThatExportsAreRecordedThroughTheDataAccess(t *testing.T) {
+	l := audit.NewMemoryLog()
+	ctx := caller.NewContext(context.Background(), caller.Caller{EmailAddress: "sales@github.com"})
+
+	RecordAccess(WithContext(NewAccessLoggingDataAccess(viewedDataAccess{}, l), ctx), audit.ProfileExported, "dev@github.com")
+	// Data access which doesn't record access is ignored.
+	RecordAccess(viewedDataAccess{}, audit.ProfileExported, "dev@github.com")
+
+	if entries, _ := l.List(audit.Query{}); len(entries) != 1 || entries[0].Action != audit.ProfileExported || entries[0].Target != "dev@github.com" {
+		t.Errorf("Expected the export to be recorded, but received %v", entries)
+	}
+}
+
+func (da viewedDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
+	return []Profile{{EmailAddress: "dev@github.com"}, {EmailAddress: "other@github.com"}, {EmailAddress: "sales@github.com"}}, nil
+}
+
+func TestThatProfilesListedByOtherPeopleAreRecorded(t *testing.T) {
+	l := audit.NewMemoryLog()
+	ctx := caller.NewContext(context.Background(), caller.Caller{EmailAddress: "dev@github.com"})
+
+	WithContext(NewAccessLoggingDataAccess(viewedDataAccess{}, l), ctx).ListProfiles("dev@github.com")
+	// Listings without a caller, e.g. by scheduled jobs, aren't recorded.
+	NewAccessLoggingDataAccess(viewedDataAccess{}, l).ListProfiles("dev@github.com")
+
+	entries, _ := l.List(audit.Query{})
+	var targets []string
+	for _, e := range entries {
+		if e.Action != audit.ProfileListed || e.Actor != "dev@github.com" {
+			t.Errorf("Expected the listing to be recorded as made by dev@github.com, but received %v", e)
+		}
+		targets = append(targets, e.Target)
+	}
+	if len(targets) != 2 || targets[0] != "sales@github.com" || targets[1] != "other@github.com" {
+		t.Errorf("Expected the other people listed to be recorded, but received %v", targets)
+	}
+}
@@ -0,0 +1,100 @@
+package dataaccess
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AnomalyThresholds determine when a caller's changes are suspicious.
+type AnomalyThresholds struct {
+	// Window is the period changes are counted over.
+	Window time.Duration
+	// ProfileEdits is the number of other people's profiles a caller can
+	// change within the Window.
+	ProfileEdits int
+	// Deletes is the number of profiles a caller can delete within the
+	// Window.
+	Deletes int
+}
+
+// DefaultAnomalyThresholds returns thresholds which allow an administrator to
+// tidy up a handful of profiles, but not to rewrite a whole team.
+func DefaultAnomalyThresholds() AnomalyThresholds {
+	return AnomalyThresholds{
+		Window:       time.Hour,
+		ProfileEdits: 20,
+		Deletes:      10,
+	}
+}
+
+// An Anomaly describes a caller who has made more changes of a kind than the
+// thresholds allow.
+type Anomaly struct {
+	Actor  string
+	Kind   ChangeKind
+	Count  int
+	Window time.Duration
+}
+
+func (a Anomaly) String() string {
+	return fmt.Sprintf("%s made %d %s changes within %v", a.Actor, a.Count, a.Kind, a.Window)
+}
+
+type observation struct {
+	time time.Time
+	kind ChangeKind
+}
+
+// The AnomalyDetector counts the changes made by each caller within the
+// thresholds' window. Counts are held in memory, so each instance of the
+// service counts the changes it receives.
+type AnomalyDetector struct {
+	Thresholds AnomalyThresholds
+	mutex      sync.Mutex
+	changes    map[string][]observation
+	now        func() time.Time
+}
+
+// NewAnomalyDetector creates an AnomalyDetector with the thresholds.
+func NewAnomalyDetector(t AnomalyThresholds) *AnomalyDetector {
+	return &AnomalyDetector{
+		Thresholds: t,
+		changes:    make(map[string][]observation),
+		now:        time.Now,
+	}
+}
+
+func (d *AnomalyDetector) limit(kind ChangeKind) int {
+	if kind == DeleteProfileChange {
+		return d.Thresholds.Deletes
+	}
+	return d.Thresholds.ProfileEdits
+}
+
+// Observe records a change made by the actor, and returns an Anomaly if the
+// actor has made more changes of the kind within the window than allowed.
+func (d *AnomalyDetector) Observe(actor string, kind ChangeKind) (Anomaly, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := d.now()
+	cutoff := now.Add(-d.Thresholds.Window)
+
+	var recent []observation
+	count := 1
+	for _, o := range d.changes[actor] {
+		if o.time.After(cutoff) {
+			recent = append(recent, o)
+			if o.kind == kind {
+				count++
+			}
+		}
+	}
+	d.changes[actor] = append(recent, observation{now, kind})
+
+	if count > d.limit(kind) {
+		return Anomaly{actor, kind, count, d.Thresholds.Window}, true
+	}
+	return Anomaly{}, false
+}
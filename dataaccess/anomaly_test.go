@@ -0,0 +1,37 @@
+package dataaccess
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThatAnomaliesAreDetectedWhenThresholdsAreExceeded(t *testing.T) {
+	now := time.Date(2017, time.March, 6, 12, 0, 0, 0, time.UTC)
+	d := NewAnomalyDetector(AnomalyThresholds{Window: time.Hour, ProfileEdits: 3, Deletes: 1})
+	d.now = func() time.Time { return now }
+
+	tests := []struct {
+		offset   time.Duration
+		actor    string
+		kind     ChangeKind
+		expected bool
+	}{
+		{0, "admin@github.com", UpdateProfileChange, false},
+		{time.Minute, "admin@github.com", UpdateProfileChange, false},
+		{2 * time.Minute, "admin@github.com", DeleteProfileChange, false},
+		{3 * time.Minute, "other@github.com", UpdateProfileChange, false},
+		{4 * time.Minute, "admin@github.com", UpdateProfileChange, false},
+		{5 * time.Minute, "admin@github.com", UpdateProfileChange, true},
+		{6 * time.Minute, "admin@github.com", DeleteProfileChange, true},
+		// The first changes have left the window.
+		{61 * time.Minute, "admin@github.com", UpdateProfileChange, false},
+	}
+
+	for i, test := range tests {
+		d.now = func() time.Time { return now.Add(test.offset) }
+		_, actual := d.Observe(test.actor, test.kind)
+		if actual != test.expected {
+			t.Errorf("Test %d: for a %s by %s after %v, expected an anomaly %t, but was %t.", i, test.kind, test.actor, test.offset, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// DefaultApprovalWindow is how long a second administrator has to approve a
+// destructive action before the request expires.
+const DefaultApprovalWindow = 24 * time.Hour
+
+// ErrApprovalRequired is returned when a destructive action is attempted
+// without the approval of a second administrator.
+var ErrApprovalRequired = errors.New("dataaccess: the action must be approved by a second administrator")
+
+// An ApprovalAction is a destructive action which requires the approval of
+// a second administrator.
+type ApprovalAction string
+
+// The actions which require approval.
+const (
+	// DeleteConfigurationAction deletes the configuration, including the
+	// session encryption keys, ending every session.
+	DeleteConfigurationAction ApprovalAction = "deleteConfiguration"
+	// DeleteTenantAction deletes a tenant's configuration and every profile
+	// in the tenant's domain.
+	DeleteTenantAction ApprovalAction = "deleteTenant"
+	// EraseProfilesAction deletes the profiles of the Targets.
+	EraseProfilesAction ApprovalAction = "eraseProfiles"
+	// DeleteProfilesWhereAction deletes the profiles which match the Query,
+	// as long as they're the ones its dry run found, see
+	// PlanProfileDeletion.
+	DeleteProfilesWhereAction ApprovalAction = "deleteProfilesWhere"
+)
+
+// An ApprovalRequest is a destructive action waiting for the approval of a
+// second administrator.
+type ApprovalRequest struct {
+	ID     string         `bson:"_id" json:"id"`
+	Action ApprovalAction `json:"action"`
+	// Tenant is the domain affected by the action, if any.
+	Tenant string `json:"tenant,omitempty"`
+	// Targets are the email addresses of the profiles to erase.
+	Targets []string `json:"targets,omitempty"`
+	// Query selects the profiles to delete in bulk, and Confirmation is the
+	// one returned by its dry run.
+	Query        *ProfileQuery `json:"query,omitempty"`
+	Confirmation string        `json:"confirmation,omitempty"`
+	RequestedBy  string        `json:"requestedBy"`
+	Requested    time.Time     `json:"requested"`
+	Expires      time.Time     `json:"expires"`
+}
+
+// Validate returns an error if the request doesn't have the fields its action
+// needs.
+func (a ApprovalRequest) Validate() error {
+	switch a.Action {
+	case DeleteConfigurationAction:
+		return nil
+	case DeleteTenantAction:
+		if a.Tenant == "" {
+			return errors.New("dataaccess: a tenant is required to delete a tenant")
+		}
+		return nil
+	case EraseProfilesAction:
+		if len(a.Targets) == 0 {
+			return errors.New("dataaccess: targets are required to erase profiles")
+		}
+		return nil
+	case DeleteProfilesWhereAction:
+		if a.Query == nil || a.Confirmation == "" {
+			return errors.New("dataaccess: a query and the confirmation from its dry run are required to delete profiles")
+		}
+		if !strings.EqualFold(a.Tenant, a.Query.Domain) {
+			return errors.New("dataaccess: the tenant must be the domain of the query")
+		}
+		return a.Query.validate()
+	}
+	return errors.New("dataaccess: unknown approval action " + string(a.Action))
+}
+
+// Execute carries out the action. The context must have been approved, see
+// Approve.
+func (a ApprovalRequest) Execute(ctx context.Context, da DataAccess) error {
+	da = WithContext(da, ctx)
+	switch a.Action {
+	case DeleteConfigurationAction:
+		return da.DeleteConfiguration()
+	case DeleteTenantAction:
+		profiles, err := da.ListProfiles("@" + a.Tenant)
+		if err != nil {
+			return err
+		}
+		for _, p := range profiles {
+			if _, err := da.DeleteProfile(p.EmailAddress); err != nil {
+				return err
+			}
+		}
+		return da.DeleteTenantConfiguration(a.Tenant)
+	case EraseProfilesAction:
+		for _, t := range a.Targets {
+			if _, err := da.DeleteProfile(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	case DeleteProfilesWhereAction:
+		_, err := DeleteProfilesWhere(ctx, da, *a.Query, a.Confirmation)
+		return err
+	}
+	return a.Validate()
+}
+
+type approvedKey struct{}
+
+// Approve returns a context which allows destructive actions to be carried
+// out, once a second administrator has approved them.
+func Approve(ctx context.Context) context.Context {
+	return context.WithValue(ctx, approvedKey{}, true)
+}
+
+func isApproved(ctx context.Context) bool {
+	approved, _ := ctx.Value(approvedKey{}).(bool)
+	return approved
+}
+
+// ApprovingDataAccess wraps a DataAccess and refuses destructive actions
+// unless they've been approved.
+type ApprovingDataAccess struct {
+	DataAccess
+	ctx context.Context
+}
+
+// NewApprovingDataAccess creates a DataAccess which requires destructive
+// actions to be approved.
+func NewApprovingDataAccess(da DataAccess) DataAccess {
+	return &ApprovingDataAccess{da, context.Background()}
+}
+
+// WithContext allows the actions approved in the context.
+func (da ApprovingDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &ApprovingDataAccess{WithContext(da.DataAccess, ctx), ctx}
+}
+
+// DeleteConfiguration deletes the configuration, if approved.
+func (da ApprovingDataAccess) DeleteConfiguration() error {
+	if !isApproved(da.ctx) {
+		return ErrApprovalRequired
+	}
+	return da.DataAccess.DeleteConfiguration()
+}
+
+// DeleteTenantConfiguration deletes the tenant's configuration, if approved.
+func (da ApprovingDataAccess) DeleteTenantConfiguration(domain string) error {
+	if !isApproved(da.ctx) {
+		return ErrApprovalRequired
+	}
+	return da.DataAccess.DeleteTenantConfiguration(domain)
+}
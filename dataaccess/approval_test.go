@@ -0,0 +1,114 @@
+package dataaccess
+
+import (
+	"context"
+	"testing"
+)
+
+type approvalStub struct {
+	stubDataAccess
+	profiles      []Profile
+	deleted       []string
+	deletedTenant string
+}
+
+func (da *approvalStub) ListProfiles(emailAddress string) ([]Profile, error) {
+	var op []Profile
+	for _, p := range da.profiles {
+		if p.Domain == GetDomain(emailAddress) {
+			op = append(op, p)
+		}
+	}
+	return op, nil
+}
+
+func (da *approvalStub) DeleteProfile(emailAddress string) (bool, error) {
+	da.deleted = append(da.deleted, emailAddress)
+	return true, nil
+}
+
+func (da *approvalStub) DeleteTenantConfiguration(domain string) error {
+	da.deletedTenant = domain
+	return nil
+}
+
+func (da *approvalStub) DeleteConfiguration() error {
+	return nil
+}
+
+func TestThatDestructiveActionsRequireApproval(t *testing.T) {
+	da := NewApprovingDataAccess(&approvalStub{})
+
+	if err := da.DeleteConfiguration(); err != ErrApprovalRequired {
+		t.Errorf("Expected deleting the configuration without approval to fail, but received %v", err)
+	}
+
+	if err := da.DeleteTenantConfiguration("github.com"); err != ErrApprovalRequired {
+		t.Errorf("Expected deleting a tenant without approval to fail, but received %v", err)
+	}
+
+	approved := WithContext(da, Approve(context.Background()))
+	if err := approved.DeleteConfiguration(); err != nil {
+		t.Errorf("Expected the approved deletion to succeed, but received %v", err)
+	}
+}
+
+func TestThatDeletingATenantDeletesItsProfiles(t *testing.T) {
+	stub := &approvalStub{profiles: []Profile{
+		{EmailAddress: "a@github.com", Domain: "github.com"},
+		{EmailAddress: "b@example.com", Domain: "example.com"},
+		{EmailAddress: "c@github.com", Domain: "github.com"},
+	}}
+	da := NewApprovingDataAccess(stub)
+
+	a := ApprovalRequest{Action: DeleteTenantAction, Tenant: "github.com"}
+	if err := a.Execute(Approve(context.Background()), da); err != nil {
+		t.Fatal("Failed to delete the tenant.", err)
+	}
+
+	if len(stub.deleted) != 2 || stub.deleted[0] != "a@github.com" || stub.deleted[1] != "c@github.com" || stub.deletedTenant != "github.com" {
+		t.Errorf("Expected the github.com profiles and configuration to be deleted, but %v and '%s' were.", stub.deleted, stub.deletedTenant)
+	}
+}
+
+func TestThatApprovalRequestsAreValidated(t *testing.T) {
+	tests := []struct {
+		request ApprovalRequest
+		valid   bool
+	}{
+		{ApprovalRequest{Action: DeleteConfigurationAction}, true},
+		{ApprovalRequest{Action: DeleteTenantAction}, false},
+		{ApprovalRequest{Action: DeleteTenantAction, Tenant: "github.com"}, true},
+		{ApprovalRequest{Action: EraseProfilesAction}, false},
+		{ApprovalRequest{Action: EraseProfilesAction, Targets: []string{"a@github.com"}}, true},
+		{ApprovalRequest{Action: DeleteProfilesWhereAction, Tenant: "github.com"}, false},
+		{ApprovalRequest{Action: DeleteProfilesWhereAction, Tenant: "github.com", Query: &ProfileQuery{Domain: "github.com"}}, false},
+		{ApprovalRequest{Action: DeleteProfilesWhereAction, Tenant: "example.com", Query: &ProfileQuery{Domain: "github.com"}, Confirmation: "abc"}, false},
+		{ApprovalRequest{Action: DeleteProfilesWhereAction, Tenant: "github.com", Query: &ProfileQuery{Domain: "github.com"}, Confirmation: "abc"}, true},
+		{ApprovalRequest{Action: "dropDatabase"}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.request.Validate(); (err == nil) != test.valid {
+			t.Errorf("For %v, expected valid %t, but received %v", test.request, test.valid, err)
+		}
+	}
+}
+
+func TestThatApprovedBulkDeletionsDeleteTheProfilesFromTheDryRun(t *testing.T) {
+	da := newMemoryProfiles("test-1@example.org", "test-2@example.org", "adrian@example.org")
+	q := ProfileQuery{Domain: "example.org", EmailPattern: "test-*@example.org"}
+	plan, _ := PlanProfileDeletion(da, q)
+
+	a := ApprovalRequest{Action: DeleteProfilesWhereAction, Tenant: "example.org", Query: &q, Confirmation: plan.Confirmation}
+	if err := a.Execute(context.Background(), da); err != ErrApprovalRequired || len(da.profiles) != 3 {
+		t.Errorf("Expected the deletion to need approving, but received %v", err)
+	}
+
+	if err := a.Execute(Approve(context.Background()), da); err != nil {
+		t.Fatal("Failed to delete the profiles.", err)
+	}
+	if _, ok := da.profiles["adrian@example.org"]; !ok || len(da.profiles) != 1 {
+		t.Errorf("Expected only the matching profiles to be deleted, but %d remain", len(da.profiles))
+	}
+}
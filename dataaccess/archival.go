@@ -0,0 +1,35 @@
+package dataaccess
+
+import "time"
+
+// ArchivalSettings move the profiles of people who haven't used pill for a
+// while to the archive, so that they aren't listed, searched or reported on,
+// and the profiles collection stays small. Archived profiles can be restored
+// by the tenant's administrators.
+type ArchivalSettings struct {
+	Enabled bool `json:"enabled"`
+	// InactiveMonths is how long a profile must go without being updated, or
+	// since it was restored, to be archived.
+	InactiveMonths int `json:"inactiveMonths"`
+}
+
+func (a ArchivalSettings) problems() []string {
+	if a.Enabled && a.InactiveMonths < 1 {
+		return []string{"the inactive months must be at least 1"}
+	}
+	return nil
+}
+
+// LastActive returns when the profile was last updated, or restored from the
+// archive, whichever is later.
+func (p Profile) LastActive() time.Time {
+	if p.Restored.After(p.LastUpdated) {
+		return p.Restored
+	}
+	return p.LastUpdated
+}
+
+// Inactive returns true if the profile should be archived at the time.
+func (a ArchivalSettings) Inactive(p Profile, at time.Time) bool {
+	return a.Enabled && p.LastActive().Before(at.AddDate(0, -a.InactiveMonths, 0))
+}
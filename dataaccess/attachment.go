@@ -0,0 +1,36 @@
+package dataaccess
+
+import (
+	"log"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// An Attachment is a file uploaded to a profile, e.g. a CV. The file itself
+// is held in an attachment store, under the Name.
+type Attachment struct {
+	Name        string    `json:"name"`
+	FileName    string    `json:"fileName"`
+	ContentType string    `json:"contentType"`
+	Size        int       `json:"size"`
+	Uploaded    time.Time `json:"uploaded"`
+}
+
+// UpdateCV replaces the person's CV. If the CV is nil, it is removed.
+func (da MongoDataAccess) UpdateCV(emailAddress string, cv *Attachment) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	update := bson.M{"$unset": bson.M{"cv": ""}}
+	if cv != nil {
+		a := *cv
+		a.Uploaded = a.Uploaded.UTC().Truncate(time.Millisecond)
+		update = bson.M{"$set": bson.M{"cv": a}}
+	}
+	return session.DB(da.databaseName).C("profiles").UpdateId(emailAddress, update)
+}
@@ -0,0 +1,640 @@
+package dataaccess
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/audit"
+)
+
+// AuditingDataAccess wraps a DataAccess and records each successful change
+// in the audit log, along with the caller who made it.
+type AuditingDataAccess struct {
+	DataAccess
+	log audit.Log
+	ctx context.Context
+}
+
+// NewAuditingDataAccess creates a DataAccess which records changes in the
+// audit log. Changes are recorded as being made by the system until
+// WithContext is used to provide the caller.
+func NewAuditingDataAccess(da DataAccess, l audit.Log) DataAccess {
+	return &AuditingDataAccess{da, l, context.Background()}
+}
+
+// WithContext records changes as being made by the context's caller.
+func (da AuditingDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &AuditingDataAccess{WithContext(da.DataAccess, ctx), da.log, ctx}
+}
+
+func (da AuditingDataAccess) record(action string, tenant string, target string, details string) {
+	e := audit.NewEntry(da.ctx, action, tenant, target)
+	e.Details = details
+
+	if err := da.log.Record(e); err != nil {
+		log.Printf("Failed to record %s of %s by %s in the audit log. %v", action, target, e.Actor, err)
+	}
+}
+
+// UpdateProfile updates the profile and records the change.
+func (da AuditingDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	profile, err := da.DataAccess.UpdateProfile(update)
+
+	if err == nil {
+		da.record(audit.ProfileUpdated, profile.Domain, profile.EmailAddress, "")
+	}
+
+	return profile, err
+}
+
+// ImportProfile imports the profile and records the change.
+func (da AuditingDataAccess) ImportProfile(p *Profile) error {
+	err := da.DataAccess.ImportProfile(p)
+
+	if err == nil {
+		da.record(audit.ProfileImported, p.Domain, p.EmailAddress, "")
+	}
+
+	return err
+}
+
+// DeleteProfile deletes the profile and records the change.
+func (da AuditingDataAccess) DeleteProfile(emailAddress string) (bool, error) {
+	deleted, err := da.DataAccess.DeleteProfile(emailAddress)
+
+	if err == nil && deleted {
+		da.record(audit.ProfileDeleted, GetDomain(emailAddress), emailAddress, "")
+	}
+
+	return deleted, err
+}
+
+// SyncEmployee syncs the HR record and records the change.
+func (da AuditingDataAccess) SyncEmployee(e EmployeeUpdate) error {
+	err := da.DataAccess.SyncEmployee(e)
+
+	if err == nil {
+		da.record(audit.ProfileSynced, GetDomain(e.EmailAddress), e.EmailAddress, "")
+	}
+
+	return err
+}
+
+// ArchiveProfile archives the profile and records the change.
+func (da AuditingDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (bool, error) {
+	archived, err := da.DataAccess.ArchiveProfile(emailAddress, reason, purgeAfter)
+
+	if err == nil && archived {
+		da.record(audit.ProfileArchived, GetDomain(emailAddress), emailAddress, reason)
+	}
+
+	return archived, err
+}
+
+// RestoreProfile restores the profile and records the change.
+func (da AuditingDataAccess) RestoreProfile(emailAddress string) (bool, error) {
+	restored, err := da.DataAccess.RestoreProfile(emailAddress)
+
+	if err == nil && restored {
+		da.record(audit.ProfileRestored, GetDomain(emailAddress), emailAddress, "")
+	}
+
+	return restored, err
+}
+
+// PurgeArchivedProfiles purges the profiles and records the change.
+func (da AuditingDataAccess) PurgeArchivedProfiles(before time.Time) (int, error) {
+	purged, err := da.DataAccess.PurgeArchivedProfiles(before)
+
+	if err == nil && purged > 0 {
+		da.record(audit.ProfilesPurged, "", "", fmt.Sprintf("%d archived profiles purged", purged))
+	}
+
+	return purged, err
+}
+
+// RevokeSessions revokes the sessions and records the change.
+func (da AuditingDataAccess) RevokeSessions(emailAddress string, at time.Time) error {
+	err := da.DataAccess.RevokeSessions(emailAddress, at)
+
+	if err == nil {
+		da.record(audit.SessionsRevoked, GetDomain(emailAddress), emailAddress, "")
+	}
+
+	return err
+}
+
+// AddSkillTags adds the tags and records the change.
+func (da AuditingDataAccess) AddSkillTags(tags []string) error {
+	err := da.DataAccess.AddSkillTags(tags)
+
+	if err == nil {
+		da.record(audit.SkillTagsAdded, "", strings.Join(tags, ","), "")
+	}
+
+	return err
+}
+
+// ImportSkillTags imports the tags and records the change.
+func (da AuditingDataAccess) ImportSkillTags(tags []SkillTag) (int, error) {
+	added, err := da.DataAccess.ImportSkillTags(tags)
+
+	if err == nil {
+		da.record(audit.SkillTagsImported, "", "", fmt.Sprintf("%d tags imported, %d of them new", len(tags), added))
+	}
+
+	return added, err
+}
+
+// MergeSkillTags merges the tags and records the change.
+func (da AuditingDataAccess) MergeSkillTags(into string, tags []string) (int, error) {
+	merged, err := da.DataAccess.MergeSkillTags(into, tags)
+
+	if err == nil {
+		da.record(audit.SkillTagsMerged, "", into, fmt.Sprintf("%s merged in %d profiles", strings.Join(tags, ","), merged))
+	}
+
+	return merged, err
+}
+
+// SetSkillTagDescriptors sets the descriptors and records the change.
+func (da AuditingDataAccess) SetSkillTagDescriptors(name string, descriptors []LevelDescriptor) error {
+	err := da.DataAccess.SetSkillTagDescriptors(name, descriptors)
+
+	if err == nil {
+		da.record(audit.SkillTagDescriptorsSet, "", name, fmt.Sprintf("%d levels described", len(descriptors)))
+	}
+
+	return err
+}
+
+// DeleteSkillTags deletes the tags and records the change.
+func (da AuditingDataAccess) DeleteSkillTags(tags []string) error {
+	err := da.DataAccess.DeleteSkillTags(tags)
+
+	if err == nil {
+		da.record(audit.SkillTagsDeleted, "", strings.Join(tags, ","), "")
+	}
+
+	return err
+}
+
+// RestoreSkillTags restores the tags and records the change.
+func (da AuditingDataAccess) RestoreSkillTags(tags []string) ([]string, error) {
+	restored, err := da.DataAccess.RestoreSkillTags(tags)
+
+	if len(restored) > 0 {
+		da.record(audit.SkillTagsRestored, "", strings.Join(restored, ","), "")
+	}
+
+	return restored, err
+}
+
+// PurgeDeletedSkillTags purges the tags and records the change.
+func (da AuditingDataAccess) PurgeDeletedSkillTags(before time.Time) (int, error) {
+	n, err := da.DataAccess.PurgeDeletedSkillTags(before)
+
+	if n > 0 {
+		da.record(audit.SkillTagsPurged, "", "", fmt.Sprintf("%d tags deleted before %v", n, before))
+	}
+
+	return n, err
+}
+
+// DeleteConfiguration deletes the configuration and records the change.
+func (da AuditingDataAccess) DeleteConfiguration() error {
+	err := da.DataAccess.DeleteConfiguration()
+
+	if err == nil {
+		da.record(audit.ConfigurationDeleted, "", "", "")
+	}
+
+	return err
+}
+
+// RotateSessionEncryptionKey rotates the key and records the change.
+func (da AuditingDataAccess) RotateSessionEncryptionKey() (Configuration, error) {
+	c, err := da.DataAccess.RotateSessionEncryptionKey()
+
+	if err == nil {
+		da.record(audit.SessionKeyRotated, "", "", "")
+	}
+
+	return c, err
+}
+
+// SetFeatureFlag sets the flag and records the change.
+func (da AuditingDataAccess) SetFeatureFlag(name string, enabled bool) error {
+	err := da.DataAccess.SetFeatureFlag(name, enabled)
+
+	if err == nil {
+		da.record(audit.FeatureFlagSet, "", name, fmt.Sprintf("enabled: %t", enabled))
+	}
+
+	return err
+}
+
+// UpdateTenantConfiguration updates the tenant's configuration and records
+// the change.
+func (da AuditingDataAccess) UpdateTenantConfiguration(tc *TenantConfiguration) error {
+	err := da.DataAccess.UpdateTenantConfiguration(tc)
+
+	if err == nil {
+		da.record(audit.TenantConfigurationUpdated, strings.ToLower(tc.Domain), tc.Domain, "")
+	}
+
+	return err
+}
+
+// DeleteTenantConfiguration deletes the tenant's configuration and records
+// the change.
+func (da AuditingDataAccess) DeleteTenantConfiguration(domain string) error {
+	err := da.DataAccess.DeleteTenantConfiguration(domain)
+
+	if err == nil {
+		da.record(audit.TenantConfigurationDeleted, strings.ToLower(domain), domain, "")
+	}
+
+	return err
+}
+
+// SaveCalibration saves the calibration and records the proposal or
+// decision.
+func (da AuditingDataAccess) SaveCalibration(c *Calibration) error {
+	err := da.DataAccess.SaveCalibration(c)
+
+	if err == nil {
+		action := audit.CalibrationDecided
+		if c.Status == CalibrationPending {
+			action = audit.CalibrationProposed
+		}
+		da.record(action, c.Domain, c.EmailAddress, fmt.Sprintf("%s from %d to %d, %s", c.Skill, c.SelfAssessed, c.Proposed, c.Status))
+	}
+
+	return err
+}
+
+// UpdateClearance updates the clearance and records the change.
+func (da AuditingDataAccess) UpdateClearance(emailAddress string, cl *Clearance) error {
+	err := da.DataAccess.UpdateClearance(emailAddress, cl)
+
+	if err == nil {
+		details := "removed"
+		if cl != nil {
+			details = cl.Level
+		}
+		da.record(audit.ClearanceUpdated, GetDomain(emailAddress), emailAddress, details)
+	}
+
+	return err
+}
+
+// RecordConsents records the consents, and that they were given or
+// withdrawn.
+func (da AuditingDataAccess) RecordConsents(emailAddress string, consents []Consent) error {
+	err := da.DataAccess.RecordConsents(emailAddress, consents)
+
+	if err == nil {
+		var details []string
+		for _, c := range consents {
+			state := "withdrawn"
+			if c.Given {
+				state = "given"
+			}
+			details = append(details, c.Purpose+" "+state+" ("+c.NoticeVersion+")")
+		}
+		da.record(audit.ConsentRecorded, GetDomain(emailAddress), emailAddress, strings.Join(details, ", "))
+	}
+
+	return err
+}
+
+// SaveReportDefinition saves the report definition and records the change,
+// with who it's delivered to.
+func (da AuditingDataAccess) SaveReportDefinition(d *ReportDefinition) error {
+	err := da.DataAccess.SaveReportDefinition(d)
+
+	if err == nil {
+		details := d.Name
+		if d.Delivery != nil {
+			details += fmt.Sprintf(", delivered %s to %s", d.Delivery.Frequency, strings.Join(d.Delivery.Recipients, ", "))
+		}
+		da.record(audit.ReportDefinitionSaved, d.Domain, d.ID, details)
+	}
+
+	return err
+}
+
+// DeleteReportDefinition deletes the report definition and records the
+// change.
+func (da AuditingDataAccess) DeleteReportDefinition(domain string, id string) (bool, error) {
+	deleted, err := da.DataAccess.DeleteReportDefinition(domain, id)
+
+	if err == nil && deleted {
+		da.record(audit.ReportDefinitionDeleted, domain, id, "")
+	}
+
+	return deleted, err
+}
+
+// SaveExportJob saves the export job and records when an export is requested,
+// since it copies the domain's data out of pill.
+func (da AuditingDataAccess) SaveExportJob(j *ExportJob) error {
+	err := da.DataAccess.SaveExportJob(j)
+
+	if err == nil && j.Status == ExportPending {
+		da.record(audit.ExportRequested, j.Domain, j.ID, j.Kind+" as "+j.Format)
+	}
+
+	return err
+}
+
+// MergeProfiles merges the duplicate profile into the primary and records the
+// change against the primary.
+func (da AuditingDataAccess) MergeProfiles(primary string, duplicate string) (*Profile, bool, error) {
+	p, found, err := da.DataAccess.MergeProfiles(primary, duplicate)
+
+	if err == nil && found {
+		da.record(audit.ProfilesMerged, GetDomain(primary), primary, "merged "+duplicate)
+	}
+
+	return p, found, err
+}
+
+// UpdateExternalIDs updates the external IDs and records the change.
+func (da AuditingDataAccess) UpdateExternalIDs(emailAddress string, ids map[string]string) error {
+	err := da.DataAccess.UpdateExternalIDs(emailAddress, ids)
+
+	if err == nil {
+		var systems []string
+		for system := range ids {
+			systems = append(systems, system)
+		}
+		sort.Strings(systems)
+		da.record(audit.ExternalIDsUpdated, GetDomain(emailAddress), emailAddress, strings.Join(systems, ", "))
+	}
+
+	return err
+}
+
+// UpdateWorkLocation updates where the person is based and records the
+// change.
+func (da AuditingDataAccess) UpdateWorkLocation(emailAddress string, l *WorkLocation) error {
+	err := da.DataAccess.UpdateWorkLocation(emailAddress, l)
+
+	if err == nil {
+		details := "removed"
+		if l != nil {
+			details = l.City + ", " + l.Country
+		}
+		da.record(audit.WorkLocationUpdated, GetDomain(emailAddress), emailAddress, details)
+	}
+
+	return err
+}
+
+// UpdateWorkingHours updates the working hours and records the change.
+func (da AuditingDataAccess) UpdateWorkingHours(emailAddress string, h *WorkingHours) error {
+	err := da.DataAccess.UpdateWorkingHours(emailAddress, h)
+
+	if err == nil {
+		details := ""
+		if h == nil {
+			details = "returned to the defaults"
+		}
+		da.record(audit.WorkingHoursUpdated, GetDomain(emailAddress), emailAddress, details)
+	}
+
+	return err
+}
+
+// UpdateAvailabilityWindows updates the availability windows and records
+// the change.
+func (da AuditingDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error {
+	err := da.DataAccess.UpdateAvailabilityWindows(emailAddress, windows)
+
+	if err == nil {
+		da.record(audit.AvailabilityWindowsUpdated, GetDomain(emailAddress), emailAddress, fmt.Sprintf("%d windows", len(windows)))
+	}
+
+	return err
+}
+
+// SaveProject saves the project and records the change.
+func (da AuditingDataAccess) SaveProject(p *Project) error {
+	err := da.DataAccess.SaveProject(p)
+
+	if err == nil {
+		da.record(audit.ProjectSaved, p.Domain, p.ID, p.Name+", "+string(p.Status))
+	}
+
+	return err
+}
+
+// DeleteProject deletes the project and records the change. The project is
+// read first, so that the deletion is recorded against its tenant.
+func (da AuditingDataAccess) DeleteProject(id string) error {
+	p, found, err := da.DataAccess.GetProject(id)
+	if err != nil {
+		return err
+	}
+
+	err = da.DataAccess.DeleteProject(id)
+
+	if err == nil && found {
+		da.record(audit.ProjectDeleted, p.Domain, id, p.Name)
+	}
+
+	return err
+}
+
+// SaveCourse saves the course and records the change.
+func (da AuditingDataAccess) SaveCourse(c *Course) error {
+	err := da.DataAccess.SaveCourse(c)
+
+	if err == nil {
+		da.record(audit.CourseSaved, c.Domain, c.ID, c.Title)
+	}
+
+	return err
+}
+
+// DeleteCourse deletes the course and records the change. The course is
+// read first, so that the deletion is recorded against its tenant.
+func (da AuditingDataAccess) DeleteCourse(id string) error {
+	c, found, err := da.DataAccess.GetCourse(id)
+	if err != nil {
+		return err
+	}
+
+	err = da.DataAccess.DeleteCourse(id)
+
+	if err == nil && found {
+		da.record(audit.CourseDeleted, c.Domain, id, c.Title)
+	}
+
+	return err
+}
+
+// SaveCourseCompletion saves the completion and records it, or the
+// manager's decision on the levels it suggests.
+func (da AuditingDataAccess) SaveCourseCompletion(cc *CourseCompletion) error {
+	err := da.DataAccess.SaveCourseCompletion(cc)
+
+	if err == nil {
+		action := audit.CourseCompletionDecided
+		if cc.Status == CompletionPending {
+			action = audit.CourseCompletionRecorded
+		}
+		var suggestions []string
+		for _, s := range cc.Suggestions {
+			suggestions = append(suggestions, fmt.Sprintf("%s from %d to %d", s.Skill, s.Current, s.Suggested))
+		}
+		da.record(action, cc.Domain, cc.EmailAddress, fmt.Sprintf("%s: %s, %s", cc.Course, strings.Join(suggestions, ", "), cc.Status))
+	}
+
+	return err
+}
+
+// UpdateLearningGoals updates the learning goals and records the change.
+func (da AuditingDataAccess) UpdateLearningGoals(emailAddress string, goals []LearningGoal) error {
+	err := da.DataAccess.UpdateLearningGoals(emailAddress, goals)
+
+	if err == nil {
+		var skills []string
+		for _, g := range goals {
+			skills = append(skills, g.Skill)
+		}
+		da.record(audit.LearningGoalsUpdated, GetDomain(emailAddress), emailAddress, strings.Join(skills, ", "))
+	}
+
+	return err
+}
+
+// ConfirmSkills confirms the skills and records the change.
+func (da AuditingDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	err := da.DataAccess.ConfirmSkills(emailAddress, skills)
+
+	if err == nil {
+		da.record(audit.SkillsConfirmed, GetDomain(emailAddress), emailAddress, strings.Join(skills, ", "))
+	}
+
+	return err
+}
+
+// SaveTagProposal saves the proposal and records it, or the decision on it.
+func (da AuditingDataAccess) SaveTagProposal(p *TagProposal) error {
+	err := da.DataAccess.SaveTagProposal(p)
+
+	if err == nil {
+		action := audit.TagProposalDecided
+		if p.Status == ProposalPending {
+			action = audit.TagProposed
+		}
+		da.record(action, p.Domain, p.Tag, fmt.Sprintf("proposed by %s, %s", p.ProposedBy, p.Status))
+	}
+
+	return err
+}
+
+// UpdateCV updates the CV and records the change.
+func (da AuditingDataAccess) UpdateCV(emailAddress string, cv *Attachment) error {
+	err := da.DataAccess.UpdateCV(emailAddress, cv)
+
+	if err == nil {
+		details := "removed"
+		if cv != nil {
+			details = cv.FileName
+		}
+		da.record(audit.CVUpdated, GetDomain(emailAddress), emailAddress, details)
+	}
+
+	return err
+}
+
+// SaveShareLink saves the share link and records that the person's profile
+// can be read without signing in until it expires.
+func (da AuditingDataAccess) SaveShareLink(l *ShareLink) error {
+	err := da.DataAccess.SaveShareLink(l)
+
+	if err == nil {
+		da.record(audit.ShareLinkCreated, GetDomain(l.EmailAddress), l.EmailAddress, "expires "+l.Expires.UTC().Format(time.RFC3339))
+	}
+
+	return err
+}
+
+// DeleteShareLink deletes the share link and records the change. The link
+// is read first, so that the deletion is recorded against its profile.
+func (da AuditingDataAccess) DeleteShareLink(id string) error {
+	l, found, err := da.DataAccess.GetShareLink(id)
+	if err != nil {
+		return err
+	}
+
+	err = da.DataAccess.DeleteShareLink(id)
+
+	if err == nil && found {
+		da.record(audit.ShareLinkDeleted, GetDomain(l.EmailAddress), l.EmailAddress, "")
+	}
+
+	return err
+}
+
+// AddBooking adds the booking and records the change.
+func (da AuditingDataAccess) AddBooking(emailAddress string, b Booking) error {
+	err := da.DataAccess.AddBooking(emailAddress, b)
+
+	if err == nil {
+		da.record(audit.BookingAdded, GetDomain(emailAddress), emailAddress, fmt.Sprintf("%d%% on %s from %s to %s", b.Percentage, b.Project, b.Start.UTC().Format("2006-01-02"), b.End.UTC().Format("2006-01-02")))
+	}
+
+	return err
+}
+
+// RemoveBooking removes the booking and records the change.
+func (da AuditingDataAccess) RemoveBooking(emailAddress string, id string) error {
+	err := da.DataAccess.RemoveBooking(emailAddress, id)
+
+	if err == nil {
+		da.record(audit.BookingRemoved, GetDomain(emailAddress), emailAddress, id)
+	}
+
+	return err
+}
+
+// UpdateLanguages updates the languages and records the change.
+func (da AuditingDataAccess) UpdateLanguages(emailAddress string, languages []Language) error {
+	err := da.DataAccess.UpdateLanguages(emailAddress, languages)
+
+	if err == nil {
+		var codes []string
+		for _, l := range languages {
+			codes = append(codes, l.Code+" "+string(l.Level))
+		}
+		da.record(audit.LanguagesUpdated, GetDomain(emailAddress), emailAddress, strings.Join(codes, ", "))
+	}
+
+	return err
+}
+
+// UpdateCustomFields updates the custom fields and records the change, with
+// the names of the fields which have values.
+func (da AuditingDataAccess) UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) error {
+	err := da.DataAccess.UpdateCustomFields(emailAddress, values)
+
+	if err == nil {
+		var names []string
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		da.record(audit.CustomFieldsUpdated, GetDomain(emailAddress), emailAddress, strings.Join(names, ", "))
+	}
+
+	return err
+}
@@ -0,0 +1,203 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+)
+
+func TestThatChangesAreAuditedWithTheCaller(t *testing.T) {
+	l := audit.NewMemoryLog()
+	ctx := caller.NewContext(context.Background(), caller.Caller{EmailAddress: "a-h@github.com", Impersonator: "admin@github.com"})
+	da := WithContext(NewAuditingDataAccess(stubDataAccess{}, l), ctx)
+
+	update := NewProfileUpdate()
+	update.EmailAddress = "a-h@github.com"
+	da.UpdateProfile(update)
+
+	entries, _ := l.List(audit.Query{})
+
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry to be recorded, but %d were.", len(entries))
+	}
+
+	e := entries[0]
+	if e.Action != audit.ProfileUpdated || e.Actor != "a-h@github.com" || e.Impersonator != "admin@github.com" || e.Tenant != "github.com" {
+		t.Errorf("Expected the update by a-h@github.com, impersonated by admin@github.com, to be recorded, but received %v", e)
+	}
+}
+
+func TestThatChangesWithoutACallerAreAuditedAsTheSystem(t *testing.T) {
+	l := audit.NewMemoryLog()
+	da := NewAuditingDataAccess(stubDataAccess{}, l)
+
+	da.DeleteProfile("a-h@github.com")
+
+	entries, _ := l.List(audit.Query{})
+	if len(entries) != 1 || entries[0].Actor != audit.SystemActor {
+		t.Errorf("Expected the deletion to be recorded as the system, but received %v", entries)
+	}
+}
+
+func TestThatFailedChangesAreNotAudited(t *testing.T) {
+	l := audit.NewMemoryLog()
+	da := NewAuditingDataAccess(stubDataAccess{err: errors.New("failed")}, l)
+
+	da.DeleteProfile("a-h@github.com")
+
+	if entries, _ := l.List(audit.Query{}); len(entries) != 0 {
+		t.Errorf("Failed changes should not be audited, but %d entries were recorded.", len(entries))
+	}
+}
+
+// acceptingDataAccess accepts every change to a profile's details.
+type acceptingDataAccess struct {
+	DataAccess
+}
+
+func (da acceptingDataAccess) UpdateWorkLocation(emailAddress string, l *WorkLocation) error {
+	return nil
+}
+
+func (da acceptingDataAccess) UpdateWorkingHours(emailAddress string, h *WorkingHours) error {
+	return nil
+}
+
+func (da acceptingDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error {
+	return nil
+}
+
+func (da acceptingDataAccess) SaveProject(p *Project) error {
+	return nil
+}
+
+func (da acceptingDataAccess) GetProject(id string) (*Project, bool, error) {
+	return &Project{ID: id, Domain: "github.com", Name: "pill"}, true, nil
+}
+
+func (da acceptingDataAccess) DeleteProject(id string) error {
+	return nil
+}
+
+func (da acceptingDataAccess) SaveCourse(c *Course) error {
+	return nil
+}
+
+func (da acceptingDataAccess) GetCourse(id string) (*Course, bool, error) {
+	return &Course{ID: id, Domain: "github.com", Title: "Go"}, true, nil
+}
+
+func (da acceptingDataAccess) DeleteCourse(id string) error {
+	return nil
+}
+
+func (da acceptingDataAccess) SaveCourseCompletion(cc *CourseCompletion) error {
+	return nil
+}
+
+func (da acceptingDataAccess) UpdateLearningGoals(emailAddress string, goals []LearningGoal) error {
+	return nil
+}
+
+func (da acceptingDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	return nil
+}
+
+func (da acceptingDataAccess) SaveTagProposal(p *TagProposal) error {
+	return nil
+}
+
+func (da acceptingDataAccess) UpdateCV(emailAddress string, cv *Attachment) error {
+	return nil
+}
+
+func (da acceptingDataAccess) SaveShareLink(l *ShareLink) error {
+	return nil
+}
+
+func (da acceptingDataAccess) GetShareLink(id string) (*ShareLink, bool, error) {
+	return &ShareLink{ID: id, EmailAddress: "a-h@github.com"}, true, nil
+}
+
+func (da acceptingDataAccess) DeleteShareLink(id string) error {
+	return nil
+}
+
+func (da acceptingDataAccess) AddBooking(emailAddress string, b Booking) error {
+	return nil
+}
+
+func (da acceptingDataAccess) RemoveBooking(emailAddress string, id string) error {
+	return nil
+}
+
+func (da acceptingDataAccess) UpdateLanguages(emailAddress string, languages []Language) error {
+	return nil
+}
+
+func (da acceptingDataAccess) UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) error {
+	return nil
+}
+
+func TestThatChangesToProfileDetailsAreAudited(t *testing.T) {
+	tests := []struct {
+		action string
+		change func(da DataAccess) error
+	}{
+		{audit.WorkLocationUpdated, func(da DataAccess) error {
+			return da.UpdateWorkLocation("a-h@github.com", &WorkLocation{City: "London", Country: "GB"})
+		}},
+		{audit.WorkingHoursUpdated, func(da DataAccess) error { return da.UpdateWorkingHours("a-h@github.com", nil) }},
+		{audit.AvailabilityWindowsUpdated, func(da DataAccess) error { return da.UpdateAvailabilityWindows("a-h@github.com", nil) }},
+		{audit.ProjectSaved, func(da DataAccess) error {
+			return da.SaveProject(&Project{ID: "p", Domain: "github.com", Name: "pill"})
+		}},
+		{audit.ProjectDeleted, func(da DataAccess) error { return da.DeleteProject("p") }},
+		{audit.CourseSaved, func(da DataAccess) error { return da.SaveCourse(&Course{ID: "c", Domain: "github.com", Title: "Go"}) }},
+		{audit.CourseDeleted, func(da DataAccess) error { return da.DeleteCourse("c") }},
+		{audit.CourseCompletionRecorded, func(da DataAccess) error {
+			return da.SaveCourseCompletion(&CourseCompletion{EmailAddress: "a-h@github.com", Domain: "github.com", Status: CompletionPending})
+		}},
+		{audit.CourseCompletionDecided, func(da DataAccess) error {
+			return da.SaveCourseCompletion(&CourseCompletion{EmailAddress: "a-h@github.com", Domain: "github.com", Status: CompletionApproved, DecidedBy: "boss@github.com"})
+		}},
+		{audit.LearningGoalsUpdated, func(da DataAccess) error {
+			return da.UpdateLearningGoals("a-h@github.com", []LearningGoal{{Skill: "go"}})
+		}},
+		{audit.SkillsConfirmed, func(da DataAccess) error { return da.ConfirmSkills("a-h@github.com", []string{"go"}) }},
+		{audit.TagProposed, func(da DataAccess) error {
+			return da.SaveTagProposal(&TagProposal{Tag: "rust", ProposedBy: "a-h@github.com", Domain: "github.com", Status: ProposalPending})
+		}},
+		{audit.TagProposalDecided, func(da DataAccess) error {
+			return da.SaveTagProposal(&TagProposal{Tag: "rust", ProposedBy: "a-h@github.com", Domain: "github.com", Status: ProposalApproved})
+		}},
+		{audit.CVUpdated, func(da DataAccess) error { return da.UpdateCV("a-h@github.com", &Attachment{FileName: "cv.pdf"}) }},
+		{audit.ShareLinkCreated, func(da DataAccess) error {
+			return da.SaveShareLink(&ShareLink{ID: "l", EmailAddress: "a-h@github.com"})
+		}},
+		{audit.ShareLinkDeleted, func(da DataAccess) error { return da.DeleteShareLink("l") }},
+		{audit.BookingAdded, func(da DataAccess) error {
+			return da.AddBooking("a-h@github.com", Booking{Project: "pill", Percentage: 50})
+		}},
+		{audit.BookingRemoved, func(da DataAccess) error { return da.RemoveBooking("a-h@github.com", "b") }},
+		{audit.LanguagesUpdated, func(da DataAccess) error {
+			return da.UpdateLanguages("a-h@github.com", []Language{{Code: "de", Level: CEFRB2}})
+		}},
+		{audit.CustomFieldsUpdated, func(da DataAccess) error {
+			return da.UpdateCustomFields("a-h@github.com", map[string]CustomFieldValue{"band": {Type: TextField, Text: "B"}})
+		}},
+	}
+	for _, test := range tests {
+		l := audit.NewMemoryLog()
+		if err := test.change(NewAuditingDataAccess(acceptingDataAccess{}, l)); err != nil {
+			t.Fatalf("%s: unexpected error %v", test.action, err)
+		}
+		entries, _ := l.List(audit.Query{})
+		if len(entries) != 1 || entries[0].Action != test.action || entries[0].Tenant != "github.com" {
+			t.Errorf("Expected %s to be recorded for github.com, but received %v", test.action, entries)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package dataaccess
+
+import (
+	"log"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A Badge is awarded to a person for using pill, e.g. for keeping their
+// profile up to date. The badges package defines the rules which award them.
+type Badge struct {
+	Name    string    `json:"name"`
+	Awarded time.Time `json:"awarded"`
+}
+
+// HasBadge returns true if the named badge has been awarded to the person.
+func (p Profile) HasBadge(name string) bool {
+	for _, b := range p.Badges {
+		if b.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// AwardBadges adds the badges to the person's profile.
+func (da MongoDataAccess) AwardBadges(emailAddress string, badges []Badge) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return err
+	}
+	defer session.Close()
+
+	for i := range badges {
+		badges[i].Awarded = badges[i].Awarded.UTC().Truncate(time.Millisecond)
+	}
+	return session.DB(da.databaseName).C("profiles").UpdateId(emailAddress, bson.M{"$push": bson.M{"badges": bson.M{"$each": badges}}})
+}
@@ -0,0 +1,202 @@
+package dataaccess
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+// BenchmarkSettings control whether a tenant takes part in benchmarking.
+type BenchmarkSettings struct {
+	// OptIn adds the tenant's skill coverage to the anonymous averages, and
+	// lets it compare itself with them. Tenants which don't take part can't
+	// see the averages.
+	OptIn bool `json:"optIn"`
+}
+
+// MinBenchmarkTenants is the fewest tenants an average can be made from, so
+// that no tenant's coverage can be worked out from it. Skills which fewer
+// tenants have are left out of the averages for the same reason.
+const MinBenchmarkTenants = 5
+
+// The sizes of tenants compared with each other, by their number of people.
+var benchmarkBands = []struct {
+	Name string
+	Max  int
+}{
+	{"1-49", 49},
+	{"50-249", 249},
+	{"250-999", 999},
+	{"1000+", 0},
+}
+
+// BenchmarkBand returns the size band tenants with the number of people are
+// compared within, e.g. "50-249".
+func BenchmarkBand(people int) string {
+	for _, b := range benchmarkBands {
+		if people <= b.Max {
+			return b.Name
+		}
+	}
+	return benchmarkBands[len(benchmarkBands)-1].Name
+}
+
+// A Benchmark is the average skill coverage of the tenants of a size which
+// have opted in to benchmarking. It doesn't say which tenants they are.
+type Benchmark struct {
+	Band    string `bson:"_id" json:"band"`
+	Tenants int    `json:"tenants"`
+	// Coverage is the average share of people with each skill, from 0 to 1.
+	// Tenants without the skill count as 0.
+	Coverage map[string]float64 `json:"coverage"`
+	Computed time.Time          `json:"computed"`
+}
+
+// A TenantCoverage is the share of a tenant's people with each skill, which
+// benchmarks are made from.
+type TenantCoverage struct {
+	People   int
+	Coverage map[string]float64
+}
+
+// SkillCoverage returns the share of the people with each of their skills.
+// People is the headcount if it's known, otherwise the number of profiles.
+func SkillCoverage(profiles []Profile, headcount int) TenantCoverage {
+	tc := TenantCoverage{People: headcount, Coverage: make(map[string]float64)}
+	if tc.People < len(profiles) {
+		tc.People = len(profiles)
+	}
+	if tc.People == 0 {
+		return tc
+	}
+	for _, p := range profiles {
+		for _, s := range p.Skills {
+			tc.Coverage[s.Skill]++
+		}
+	}
+	for s := range tc.Coverage {
+		tc.Coverage[s] /= float64(tc.People)
+	}
+	return tc
+}
+
+// ComputeBenchmarks averages the coverage of the tenants in each size band.
+// Bands with fewer than MinBenchmarkTenants tenants have no benchmark.
+func ComputeBenchmarks(tenants []TenantCoverage, now time.Time) []Benchmark {
+	bands := make(map[string][]TenantCoverage)
+	for _, t := range tenants {
+		if t.People > 0 {
+			band := BenchmarkBand(t.People)
+			bands[band] = append(bands[band], t)
+		}
+	}
+	benchmarks := []Benchmark{}
+	for band, tcs := range bands {
+		if len(tcs) < MinBenchmarkTenants {
+			continue
+		}
+		totals, holders := make(map[string]float64), make(map[string]int)
+		for _, tc := range tcs {
+			for s, c := range tc.Coverage {
+				totals[s] += c
+				holders[s]++
+			}
+		}
+		b := Benchmark{Band: band, Tenants: len(tcs), Coverage: make(map[string]float64), Computed: now.UTC().Truncate(time.Millisecond)}
+		for s, total := range totals {
+			if holders[s] >= MinBenchmarkTenants {
+				b.Coverage[s] = total / float64(len(tcs))
+			}
+		}
+		benchmarks = append(benchmarks, b)
+	}
+	sort.Slice(benchmarks, func(i, j int) bool { return benchmarks[i].Band < benchmarks[j].Band })
+	return benchmarks
+}
+
+// A BenchmarkComparison compares a tenant's skill coverage with the average
+// of tenants of its size.
+type BenchmarkComparison struct {
+	Band     string    `json:"band"`
+	Tenants  int       `json:"tenants"`
+	Computed time.Time `json:"computed"`
+	// Skills are the skills in the benchmark, furthest below it first.
+	Skills []BenchmarkedSkill `json:"skills"`
+}
+
+// A BenchmarkedSkill is a tenant's coverage of a skill and the benchmark's.
+type BenchmarkedSkill struct {
+	Skill     string  `json:"skill"`
+	Coverage  float64 `json:"coverage"`
+	Benchmark float64 `json:"benchmark"`
+	// Delta is Coverage minus Benchmark, so it's negative where the tenant
+	// has fewer people with the skill than is usual.
+	Delta float64 `json:"delta"`
+}
+
+// CompareToBenchmark compares the tenant's coverage with the benchmark.
+func CompareToBenchmark(tc TenantCoverage, b Benchmark) BenchmarkComparison {
+	c := BenchmarkComparison{Band: b.Band, Tenants: b.Tenants, Computed: b.Computed, Skills: []BenchmarkedSkill{}}
+	for s, avg := range b.Coverage {
+		c.Skills = append(c.Skills, BenchmarkedSkill{Skill: s, Coverage: tc.Coverage[s], Benchmark: avg, Delta: tc.Coverage[s] - avg})
+	}
+	sort.Slice(c.Skills, func(i, j int) bool {
+		if c.Skills[i].Delta != c.Skills[j].Delta {
+			return c.Skills[i].Delta < c.Skills[j].Delta
+		}
+		return c.Skills[i].Skill < c.Skills[j].Skill
+	})
+	return c
+}
+
+// SaveBenchmarks replaces the benchmarks. Bands which aren't given have their
+// benchmark removed, e.g. because tenants have opted out.
+func (da MongoDataAccess) SaveBenchmarks(benchmarks []Benchmark) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	c := session.DB(da.databaseName).C("benchmarks")
+	saved := make(map[string]bool)
+	for _, b := range benchmarks {
+		if _, err := c.UpsertId(b.Band, b); err != nil {
+			return err
+		}
+		saved[b.Band] = true
+	}
+	for _, b := range benchmarkBands {
+		if saved[b.Name] {
+			continue
+		}
+		if err := c.RemoveId(b.Name); err != nil && err != mgo.ErrNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBenchmark returns the benchmark of the size band, if there's one.
+func (da MongoDataAccess) GetBenchmark(band string) (*Benchmark, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	var b Benchmark
+	err = session.DB(da.databaseName).C("benchmarks").FindId(band).One(&b)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	b.Computed = b.Computed.UTC()
+	return &b, true, nil
+}
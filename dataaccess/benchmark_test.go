@@ -0,0 +1,58 @@
+package dataaccess
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThatTenantsAreBandedBySize(t *testing.T) {
+	tests := map[int]string{1: "1-49", 49: "1-49", 50: "50-249", 999: "250-999", 1000: "1000+", 50000: "1000+"}
+	for people, expected := range tests {
+		if actual := BenchmarkBand(people); actual != expected {
+			t.Errorf("For %d people, expected %s, but got %s", people, expected, actual)
+		}
+	}
+}
+
+func TestThatBenchmarksDontRevealIndividualTenants(t *testing.T) {
+	var tenants []TenantCoverage
+	for i := 0; i < MinBenchmarkTenants; i++ {
+		tenants = append(tenants, TenantCoverage{People: 10, Coverage: map[string]float64{"go": 0.2 * float64(i)}})
+	}
+	// Only one tenant has cobol, and only one is in the largest band.
+	tenants[0].Coverage["cobol"] = 1
+	tenants = append(tenants, TenantCoverage{People: 5000, Coverage: map[string]float64{"go": 1}})
+
+	benchmarks := ComputeBenchmarks(tenants, time.Now())
+
+	if len(benchmarks) != 1 || benchmarks[0].Band != "1-49" {
+		t.Fatalf("Expected only the smallest band to have a benchmark, but got %+v", benchmarks)
+	}
+	if _, ok := benchmarks[0].Coverage["cobol"]; ok {
+		t.Errorf("Expected cobol to be left out, because only one tenant has it")
+	}
+	// The first tenant has 0 coverage of go, but it counts.
+	if c := benchmarks[0].Coverage["go"]; c < 0.399 || c > 0.401 {
+		t.Errorf("Expected an average go coverage of 0.4, but got %f", c)
+	}
+}
+
+func TestThatCoverageIsComparedToTheBenchmark(t *testing.T) {
+	profiles := []Profile{
+		{EmailAddress: "a@github.com", Skills: []Skill{{Skill: "go", Level: 3}}},
+		{EmailAddress: "b@github.com", Skills: []Skill{{Skill: "sql", Level: 3}, {Skill: "go", Level: 1}}},
+	}
+	tc := SkillCoverage(profiles, 4)
+	if tc.People != 4 || tc.Coverage["go"] != 0.5 || tc.Coverage["sql"] != 0.25 {
+		t.Fatalf("Unexpected coverage %+v", tc)
+	}
+
+	c := CompareToBenchmark(tc, Benchmark{Band: "1-49", Tenants: 5, Coverage: map[string]float64{"go": 0.25, "sql": 0.5, "java": 0.1}})
+
+	if len(c.Skills) != 3 || c.Skills[0].Skill != "sql" || c.Skills[1].Skill != "java" || c.Skills[2].Skill != "go" {
+		t.Fatalf("Expected sql, java, then go, but got %+v", c.Skills)
+	}
+	if c.Skills[0].Delta != -0.25 || c.Skills[2].Delta != 0.25 {
+		t.Errorf("Unexpected deltas %+v", c.Skills)
+	}
+}
@@ -0,0 +1,184 @@
+package dataaccess
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrOverbooked is returned when a booking would allocate someone to more
+// than FullAllocation percent of their time.
+var ErrOverbooked = errors.New("dataaccess: the booking would allocate the person to more than 100% of their time")
+
+// ErrBookingNotFound is returned when removing a booking which doesn't exist.
+var ErrBookingNotFound = errors.New("dataaccess: the booking was not found")
+
+// FullAllocation is the percentage of someone's time which can be booked.
+const FullAllocation = 100
+
+// PartialAllocation is the percentage of someone's time which, once booked,
+// makes them amber.
+const PartialAllocation = 50
+
+// A Booking allocates a percentage of a person's time to a project for a
+// period.
+type Booking struct {
+	ID      string `json:"id"`
+	Project string `json:"project"`
+	// ProjectID is the ID of the Project the person is allocated to, if the
+	// booking was made against one.
+	ProjectID  string    `json:"projectId,omitempty"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Percentage int       `json:"percentage"`
+	// BookedBy is the email address of the person who made the booking.
+	BookedBy string    `json:"bookedBy"`
+	Created  time.Time `json:"created"`
+}
+
+// Contains returns true if the time is within the booking.
+func (b Booking) Contains(t time.Time) bool {
+	return !t.Before(b.Start) && t.Before(b.End)
+}
+
+// Overlaps returns true if any of the booking is within the period.
+func (b Booking) Overlaps(start, end time.Time) bool {
+	return b.Start.Before(end) && start.Before(b.End)
+}
+
+// Validate checks that the booking has a project, ends after it starts, and
+// allocates between 1 and 100 percent of someone's time.
+func (b Booking) Validate() error {
+	var problems []string
+	if strings.TrimSpace(b.Project) == "" {
+		problems = append(problems, "the project is required")
+	}
+	if !b.End.After(b.Start) {
+		problems = append(problems, "bookings must end after they start")
+	}
+	if b.Percentage < 1 || b.Percentage > FullAllocation {
+		problems = append(problems, "the percentage must be between 1 and 100")
+	}
+	return newValidationError(problems)
+}
+
+// Allocation returns the percentage of the person's time which is booked at
+// the time.
+func Allocation(bookings []Booking, t time.Time) int {
+	total := 0
+	for _, b := range bookings {
+		if b.Contains(t) {
+			total += b.Percentage
+		}
+	}
+	return total
+}
+
+// PeakAllocation returns the highest percentage of the person's time which
+// is booked at any point during the period.
+func PeakAllocation(bookings []Booking, start, end time.Time) int {
+	// The allocation only rises when a booking starts, so the peak is at the
+	// start of the period or of one of the bookings.
+	peak := Allocation(bookings, start)
+	for _, b := range bookings {
+		if b.Overlaps(start, end) && b.Start.After(start) {
+			if a := Allocation(bookings, b.Start); a > peak {
+				peak = a
+			}
+		}
+	}
+	return peak
+}
+
+// bookedAvailability reduces the availability according to how much of the
+// person's time is booked.
+func bookedAvailability(availability RagStatus, allocation int) RagStatus {
+	if allocation >= FullAllocation && availability > Red {
+		return Red
+	}
+	if allocation >= PartialAllocation && availability > Amber {
+		return Amber
+	}
+	return availability
+}
+
+// bookingAttempts is how many times AddBooking reads the person's bookings
+// and tries to add to them, when they're changed by other bookings at the
+// same time.
+const bookingAttempts = 5
+
+// unchangedBookings matches the person's profile only if it still has
+// exactly the bookings which were read, so that two bookings made at the
+// same time can't both pass the allocation check.
+func unchangedBookings(emailAddress string, bookings []Booking) bson.M {
+	if len(bookings) == 0 {
+		return bson.M{"_id": emailAddress, "$or": []bson.M{
+			{"bookings": bson.M{"$exists": false}},
+			{"bookings": bson.M{"$size": 0}},
+		}}
+	}
+	ids := make([]string, len(bookings))
+	for i, b := range bookings {
+		ids[i] = b.ID
+	}
+	return bson.M{"_id": emailAddress, "bookings": bson.M{"$size": len(bookings)}, "bookings.id": bson.M{"$all": ids}}
+}
+
+// AddBooking adds the booking to the person's profile, returning
+// ErrOverbooked if it conflicts with their other bookings. If the bookings
+// change between being checked and the booking being added, they're read and
+// checked again.
+func (da MongoDataAccess) AddBooking(emailAddress string, b Booking) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return err
+	}
+	defer session.Close()
+
+	profiles := session.DB(da.databaseName).C("profiles")
+
+	b.Start, b.End = b.Start.UTC().Truncate(time.Millisecond), b.End.UTC().Truncate(time.Millisecond)
+	b.Created = b.Created.UTC().Truncate(time.Millisecond)
+	for attempt := 0; attempt < bookingAttempts; attempt++ {
+		var p Profile
+		if err := profiles.FindId(emailAddress).Select(bson.M{"bookings": 1}).One(&p); err != nil {
+			return err
+		}
+		if PeakAllocation(append(p.Bookings, b), b.Start, b.End) > FullAllocation {
+			return ErrOverbooked
+		}
+		err = profiles.Update(unchangedBookings(emailAddress, p.Bookings), bson.M{"$push": bson.M{"bookings": b}})
+		if err != mgo.ErrNotFound {
+			return err
+		}
+		log.Printf("The bookings of %s changed while booking %s, checking them again.", emailAddress, b.Project)
+	}
+	return errors.New("dataaccess: the bookings kept changing while the booking was being made")
+}
+
+// RemoveBooking removes the booking from the person's profile.
+func (da MongoDataAccess) RemoveBooking(emailAddress string, id string) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(da.databaseName).C("profiles").Update(
+		bson.M{"_id": emailAddress, "bookings.id": id},
+		bson.M{"$pull": bson.M{"bookings": bson.M{"id": id}}})
+	if err == mgo.ErrNotFound {
+		return ErrBookingNotFound
+	}
+	return err
+}
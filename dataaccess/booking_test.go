@@ -0,0 +1,102 @@
+package dataaccess
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestThatThePeakAllocationIsFound(t *testing.T) {
+	march := time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	bookings := []Booking{
+		{Project: "a", Start: march, End: march.Add(10 * day), Percentage: 50},
+		{Project: "b", Start: march.Add(5 * day), End: march.Add(20 * day), Percentage: 30},
+		{Project: "c", Start: march.Add(10 * day), End: march.Add(30 * day), Percentage: 60},
+	}
+
+	tests := []struct {
+		start    time.Time
+		end      time.Time
+		expected int
+	}{
+		{march, march.Add(5 * day), 50},
+		{march, march.Add(10 * day), 80},
+		{march.Add(9 * day), march.Add(30 * day), 90},
+		{march.Add(20 * day), march.Add(40 * day), 60},
+		{march.Add(30 * day), march.Add(40 * day), 0},
+	}
+
+	for _, test := range tests {
+		actual := PeakAllocation(bookings, test.start, test.end)
+		if actual != test.expected {
+			t.Errorf("From %v to %v, expected a peak of %d%%, but was %d%%.", test.start, test.end, test.expected, actual)
+		}
+	}
+}
+
+func TestThatBookingsReduceAvailability(t *testing.T) {
+	now := time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)
+	booking := func(percentage int) Booking {
+		return Booking{Project: "website", Start: now.Add(-time.Hour), End: now.Add(time.Hour), Percentage: percentage}
+	}
+
+	tests := []struct {
+		availability RagStatus
+		bookings     []Booking
+		expected     RagStatus
+	}{
+		{Green, nil, Green},
+		{Green, []Booking{booking(40)}, Green},
+		{Green, []Booking{booking(50)}, Amber},
+		{Green, []Booking{booking(60), booking(40)}, Red},
+		{Amber, []Booking{booking(50)}, Amber},
+		{Red, []Booking{booking(10)}, Red},
+	}
+
+	for _, test := range tests {
+		p := Profile{Availability: test.availability, Bookings: test.bookings}
+		if actual := p.AvailabilityAt(now); actual != test.expected {
+			t.Errorf("With availability %d and bookings %v, expected %d, but was %d.", test.availability, test.bookings, test.expected, actual)
+		}
+		if actual := p.AvailabilityAt(now.Add(2 * time.Hour)); actual != test.availability {
+			t.Errorf("After the bookings end, expected availability %d, but was %d.", test.availability, actual)
+		}
+	}
+}
+
+func TestThatBookingsAreValidated(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		booking  Booking
+		expected bool
+	}{
+		{Booking{Project: "website", Start: now, End: now.Add(time.Hour), Percentage: 100}, true},
+		{Booking{Project: " ", Start: now, End: now.Add(time.Hour), Percentage: 50}, false},
+		{Booking{Project: "website", Start: now, End: now, Percentage: 50}, false},
+		{Booking{Project: "website", Start: now, End: now.Add(time.Hour), Percentage: 0}, false},
+		{Booking{Project: "website", Start: now, End: now.Add(time.Hour), Percentage: 101}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.booking.Validate(); (err == nil) != test.expected {
+			t.Errorf("For %v, expected valid: %v, but received %v", test.booking, test.expected, err)
+		}
+	}
+}
+
+func TestThatBookingsAreOnlyAddedToTheBookingsWhichWereChecked(t *testing.T) {
+	none := unchangedBookings("a-h@github.com", nil)
+	if _, ok := none["$or"]; !ok {
+		t.Errorf("Expected a person without bookings to be matched whether or not the field exists, but received %v", none)
+	}
+
+	checked := unchangedBookings("a-h@github.com", []Booking{{ID: "a"}, {ID: "b"}})
+	if size := checked["bookings"].(bson.M)["$size"]; size != 2 {
+		t.Errorf("Expected the number of bookings to be matched, but received %v", checked)
+	}
+	if ids := checked["bookings.id"].(bson.M)["$all"].([]string); len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("Expected the IDs of the checked bookings to be matched, but received %v", checked)
+	}
+}
@@ -0,0 +1,138 @@
+package dataaccess
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultBulkDeleteLimit is the most profiles DeleteProfilesWhere deletes if
+// the query doesn't give a limit.
+const DefaultBulkDeleteLimit = 100
+
+// ErrBulkDeleteNotConfirmed is returned by DeleteProfilesWhere if the
+// confirmation isn't the one returned by the dry run of the query, e.g.
+// because profiles have been added or removed since.
+var ErrBulkDeleteNotConfirmed = errors.New("dataaccess: the deletion must be confirmed with the token from a dry run of the same query")
+
+// A ProfileQuery selects profiles in a domain to delete in bulk.
+type ProfileQuery struct {
+	Domain string        `json:"domain"`
+	Filter ProfileFilter `json:"filter"`
+	// EmailPattern is a shell pattern the email addresses must match, e.g.
+	// "test-*@example.org". Every profile in the domain matches if it's
+	// empty.
+	EmailPattern string `json:"emailPattern,omitempty"`
+	// Limit is the most profiles which can be deleted. If more match, none
+	// are deleted. DefaultBulkDeleteLimit is used if it's zero.
+	Limit int `json:"limit,omitempty"`
+}
+
+func (q ProfileQuery) validate() error {
+	var problems []string
+	if !strings.Contains(q.Domain, ".") {
+		problems = append(problems, "the domain is required")
+	}
+	if _, err := path.Match(q.EmailPattern, ""); err != nil {
+		problems = append(problems, "the email pattern is invalid")
+	}
+	if q.Limit < 0 {
+		problems = append(problems, "the limit must not be negative")
+	}
+	return newValidationError(problems)
+}
+
+func (q ProfileQuery) limit() int {
+	if q.Limit == 0 {
+		return DefaultBulkDeleteLimit
+	}
+	return q.Limit
+}
+
+// A BulkDeletion is the profiles matched by a ProfileQuery.
+type BulkDeletion struct {
+	Query ProfileQuery `json:"query"`
+	// EmailAddresses are the profiles which match, in order.
+	EmailAddresses []string `json:"emailAddresses"`
+	// Confirmation is the token which must be given to DeleteProfilesWhere
+	// to delete the profiles.
+	Confirmation string `json:"confirmation"`
+	// Deleted is true once the profiles have been deleted, and false for dry
+	// runs.
+	Deleted bool `json:"deleted"`
+}
+
+// PlanProfileDeletion is the dry run of DeleteProfilesWhere. It lists the
+// profiles which match the query, without deleting them, and returns the
+// confirmation needed to delete them. It's an error if more profiles match
+// than the query's limit.
+func PlanProfileDeletion(da DataAccess, q ProfileQuery) (BulkDeletion, error) {
+	if err := q.validate(); err != nil {
+		return BulkDeletion{}, err
+	}
+	profiles, err := da.FindProfiles(q.Domain, q.Filter)
+	if err != nil {
+		return BulkDeletion{}, err
+	}
+	plan := BulkDeletion{Query: q, EmailAddresses: []string{}}
+	for _, p := range profiles {
+		if q.EmailPattern != "" {
+			if ok, _ := path.Match(strings.ToLower(q.EmailPattern), strings.ToLower(p.EmailAddress)); !ok {
+				continue
+			}
+		}
+		plan.EmailAddresses = append(plan.EmailAddresses, p.EmailAddress)
+	}
+	if len(plan.EmailAddresses) > q.limit() {
+		return plan, newValidationError([]string{fmt.Sprintf("%d profiles match, which is more than the limit of %d", len(plan.EmailAddresses), q.limit())})
+	}
+	sort.Strings(plan.EmailAddresses)
+	plan.Confirmation = confirmation(q, plan.EmailAddresses)
+	return plan, nil
+}
+
+// confirmation is a hash of the query and the profiles it matches, so that a
+// deletion can only go ahead if it matches the same profiles as its dry run.
+func confirmation(q ProfileQuery, emailAddresses []string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%+v\x00%s\x00%d\x00", strings.ToLower(q.Domain), q.Filter, q.EmailPattern, q.limit())
+	for _, e := range emailAddresses {
+		fmt.Fprintf(h, "%s\x00", e)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// DeleteProfilesWhere deletes the profiles which match the query, e.g. the
+// test accounts in a domain, if the confirmation is the one returned by
+// PlanProfileDeletion for the same profiles, as the token. Like the other
+// mass erasures, it needs a second administrator's approval, so the context
+// must have been approved, see DeleteProfilesWhereAction. Each profile is
+// deleted with DeleteProfile, so the deletions are recorded in the audit log
+// by the AuditingDataAccess. If a deletion fails, the profiles deleted before
+// it are returned with the error.
+func DeleteProfilesWhere(ctx context.Context, da DataAccess, q ProfileQuery, token string) (BulkDeletion, error) {
+	if !isApproved(ctx) {
+		return BulkDeletion{Query: q, EmailAddresses: []string{}}, ErrApprovalRequired
+	}
+	da = WithContext(da, ctx)
+	plan, err := PlanProfileDeletion(da, q)
+	if err != nil {
+		return plan, err
+	}
+	if token == "" || plan.Confirmation != token {
+		return plan, ErrBulkDeleteNotConfirmed
+	}
+	deleted := BulkDeletion{Query: q, EmailAddresses: []string{}, Confirmation: token, Deleted: true}
+	for _, e := range plan.EmailAddresses {
+		if _, err := da.DeleteProfile(e); err != nil {
+			return deleted, fmt.Errorf("dataaccess: failed to delete %s after deleting %d profiles: %v", e, len(deleted.EmailAddresses), err)
+		}
+		deleted.EmailAddresses = append(deleted.EmailAddresses, e)
+	}
+	return deleted, nil
+}
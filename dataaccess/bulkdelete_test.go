@@ -0,0 +1,77 @@
+package dataaccess
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func (m *memoryProfiles) FindProfiles(domain string, f ProfileFilter) ([]Profile, error) {
+	var op []Profile
+	for _, p := range m.profiles {
+		if p.Domain == domain && f.Matches(p) {
+			op = append(op, p)
+		}
+	}
+	return op, nil
+}
+
+func TestThatBulkDeletionsMustBeConfirmedByADryRun(t *testing.T) {
+	da := newMemoryProfiles("test-1@example.org", "test-2@example.org", "adrian@example.org", "test-3@github.com")
+	q := ProfileQuery{Domain: "example.org", EmailPattern: "test-*@example.org"}
+
+	plan, err := PlanProfileDeletion(da, q)
+	if err != nil {
+		t.Fatalf("Unexpected error planning the deletion: %v", err)
+	}
+	expected := []string{"test-1@example.org", "test-2@example.org"}
+	if !reflect.DeepEqual(plan.EmailAddresses, expected) || plan.Deleted || len(da.profiles) != 4 {
+		t.Errorf("Expected the dry run to list %v without deleting them, but got %+v", expected, plan)
+	}
+
+	approved := Approve(context.Background())
+	if _, err := DeleteProfilesWhere(approved, da, q, ""); err != ErrBulkDeleteNotConfirmed {
+		t.Errorf("Expected the deletion to need confirming, but got %v", err)
+	}
+
+	if _, err := DeleteProfilesWhere(context.Background(), da, q, plan.Confirmation); err != ErrApprovalRequired || len(da.profiles) != 4 {
+		t.Errorf("Expected the deletion to need approving, but got %v", err)
+	}
+
+	deleted, err := DeleteProfilesWhere(approved, da, q, plan.Confirmation)
+	if err != nil {
+		t.Fatalf("Unexpected error deleting the profiles: %v", err)
+	}
+	if !reflect.DeepEqual(deleted.EmailAddresses, expected) || !deleted.Deleted {
+		t.Errorf("Expected %v to be deleted, but got %+v", expected, deleted)
+	}
+	if _, ok := da.profiles["adrian@example.org"]; !ok || len(da.profiles) != 2 {
+		t.Errorf("Expected only the matching profiles to be deleted, but %d remain", len(da.profiles))
+	}
+}
+
+func TestThatBulkDeletionsAreRefusedIfTheProfilesChangeAfterTheDryRun(t *testing.T) {
+	da := newMemoryProfiles("test-1@example.org")
+	q := ProfileQuery{Domain: "example.org", EmailPattern: "test-*"}
+
+	plan, _ := PlanProfileDeletion(da, q)
+	da.profiles["test-2@example.org"] = Profile{EmailAddress: "test-2@example.org", Domain: "example.org"}
+
+	if _, err := DeleteProfilesWhere(Approve(context.Background()), da, q, plan.Confirmation); err != ErrBulkDeleteNotConfirmed {
+		t.Errorf("Expected the deletion to be refused, but got %v", err)
+	}
+	if len(da.profiles) != 2 {
+		t.Errorf("Expected no profiles to be deleted, but %d remain", len(da.profiles))
+	}
+}
+
+func TestThatBulkDeletionsAreCapped(t *testing.T) {
+	da := newMemoryProfiles("a@example.org", "b@example.org", "c@example.org")
+
+	if _, err := PlanProfileDeletion(da, ProfileQuery{Domain: "example.org", Limit: 2}); err == nil {
+		t.Error("Expected an error when more profiles match than the limit")
+	}
+	if _, err := PlanProfileDeletion(da, ProfileQuery{EmailPattern: "*"}); err == nil {
+		t.Error("Expected an error without a domain")
+	}
+}
@@ -0,0 +1,275 @@
+package dataaccess
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultProfileCacheTTL is how long a cached profile is used for, so that
+// changes made through other instances of the service are picked up.
+const DefaultProfileCacheTTL = time.Minute
+
+// CachingDataAccess keeps the most recently read profiles in memory, so that
+// browsing other people's profiles doesn't read the same documents over and
+// over. Profiles are removed from the cache when they're changed through
+// this DataAccess, and expire after the TTL.
+type CachingDataAccess struct {
+	DataAccess
+	cache *profileCache
+}
+
+// NewCachingDataAccess creates a DataAccess which caches up to size profiles.
+func NewCachingDataAccess(da DataAccess, size int, ttl time.Duration) *CachingDataAccess {
+	c := &profileCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+		now:     time.Now,
+	}
+	return &CachingDataAccess{da, c}
+}
+
+// WithContext passes the context to the wrapped DataAccess. The cache is
+// shared.
+func (da CachingDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &CachingDataAccess{WithContext(da.DataAccess, ctx), da.cache}
+}
+
+// GetProfile returns the cached profile, or reads it.
+func (da CachingDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	if p, ok := da.cache.get(emailAddress); ok {
+		return p, true, nil
+	}
+
+	p, found, err := da.DataAccess.GetProfile(emailAddress)
+	if err == nil && found {
+		da.cache.put(emailAddress, p)
+	}
+	return p, found, err
+}
+
+// UpdateProfile updates the profile and removes it from the cache.
+func (da CachingDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	defer da.cache.remove(update.EmailAddress)
+	return da.DataAccess.UpdateProfile(update)
+}
+
+// ImportProfile imports the profile and removes it from the cache.
+func (da CachingDataAccess) ImportProfile(p *Profile) error {
+	defer da.cache.remove(p.EmailAddress)
+	return da.DataAccess.ImportProfile(p)
+}
+
+// DeleteProfile deletes the profile and removes it from the cache.
+func (da CachingDataAccess) DeleteProfile(emailAddress string) (bool, error) {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.DeleteProfile(emailAddress)
+}
+
+// UpdateNotificationPreferences updates the preferences and removes the
+// profile from the cache.
+func (da CachingDataAccess) UpdateNotificationPreferences(emailAddress string, p NotificationPreferences) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.UpdateNotificationPreferences(emailAddress, p)
+}
+
+// UpdateAvailabilityWindows updates the windows and removes the profile from
+// the cache.
+func (da CachingDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.UpdateAvailabilityWindows(emailAddress, windows)
+}
+
+// AwardBadges awards the badges and removes the profile from the cache.
+func (da CachingDataAccess) AwardBadges(emailAddress string, badges []Badge) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.AwardBadges(emailAddress, badges)
+}
+
+// AddBooking adds the booking and removes the profile from the cache.
+func (da CachingDataAccess) AddBooking(emailAddress string, b Booking) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.AddBooking(emailAddress, b)
+}
+
+// RemoveBooking removes the booking and removes the profile from the cache.
+func (da CachingDataAccess) RemoveBooking(emailAddress string, id string) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.RemoveBooking(emailAddress, id)
+}
+
+// profileCache is a least recently used cache of profiles.
+type profileCache struct {
+	m       sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type cachedProfileEntry struct {
+	emailAddress string
+	p            Profile
+	expires      time.Time
+}
+
+// get returns a copy of the cached profile, so that callers can't change
+// the cached value.
+func (c *profileCache) get(emailAddress string) (*Profile, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	e, ok := c.entries[emailAddress]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*cachedProfileEntry)
+	if !c.now().Before(entry.expires) {
+		c.order.Remove(e)
+		delete(c.entries, emailAddress)
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	p := entry.p
+	return &p, true
+}
+
+func (c *profileCache) put(emailAddress string, p *Profile) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	entry := &cachedProfileEntry{emailAddress, *p, c.now().Add(c.ttl)}
+	if e, ok := c.entries[emailAddress]; ok {
+		e.Value = entry
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[emailAddress] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedProfileEntry).emailAddress)
+	}
+}
+
+func (c *profileCache) remove(emailAddress string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if e, ok := c.entries[emailAddress]; ok {
+		c.order.Remove(e)
+		delete(c.entries, emailAddress)
+	}
+}
+
+func (c *profileCache) clear() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.order.Init()
+	c.entries = map[string]*list.Element{}
+}
+
+// MergeSkillTags merges the tags and empties the cache, since the merge
+// changes profiles in every tenant.
+func (da CachingDataAccess) MergeSkillTags(into string, tags []string) (int, error) {
+	defer da.cache.clear()
+	return da.DataAccess.MergeSkillTags(into, tags)
+}
+
+// UpdateLearningGoals updates the goals and removes the profile from the
+// cache.
+func (da CachingDataAccess) UpdateLearningGoals(emailAddress string, goals []LearningGoal) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.UpdateLearningGoals(emailAddress, goals)
+}
+
+// UpdateCV updates the CV and removes the profile from the cache.
+func (da CachingDataAccess) UpdateCV(emailAddress string, cv *Attachment) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.UpdateCV(emailAddress, cv)
+}
+
+// ConfirmSkills confirms the skills and removes the profile from the cache.
+func (da CachingDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.ConfirmSkills(emailAddress, skills)
+}
+
+// SyncEmployee syncs the HR record and removes the profile from the cache.
+func (da CachingDataAccess) SyncEmployee(e EmployeeUpdate) error {
+	defer da.cache.remove(e.EmailAddress)
+	return da.DataAccess.SyncEmployee(e)
+}
+
+// ArchiveProfile archives the profile and removes it from the cache.
+func (da CachingDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (bool, error) {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.ArchiveProfile(emailAddress, reason, purgeAfter)
+}
+
+// RestoreProfile restores the profile and removes it from the cache.
+func (da CachingDataAccess) RestoreProfile(emailAddress string) (bool, error) {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.RestoreProfile(emailAddress)
+}
+
+// UpdateCustomFields updates the values and removes the profile from the
+// cache.
+func (da CachingDataAccess) UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.UpdateCustomFields(emailAddress, values)
+}
+
+// UpdateLanguages updates the languages and removes the profile from the
+// cache.
+func (da CachingDataAccess) UpdateLanguages(emailAddress string, languages []Language) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.UpdateLanguages(emailAddress, languages)
+}
+
+// UpdateClearance updates the clearance and removes the profile from the
+// cache.
+func (da CachingDataAccess) UpdateClearance(emailAddress string, cl *Clearance) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.UpdateClearance(emailAddress, cl)
+}
+
+// UpdateWorkLocation updates the location and removes the profile from the
+// cache.
+func (da CachingDataAccess) UpdateWorkLocation(emailAddress string, l *WorkLocation) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.UpdateWorkLocation(emailAddress, l)
+}
+
+// UpdateWorkingHours updates the working hours and removes the profile from
+// the cache.
+func (da CachingDataAccess) UpdateWorkingHours(emailAddress string, h *WorkingHours) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.UpdateWorkingHours(emailAddress, h)
+}
+
+// RecordConsents records the consents and removes the profile from the
+// cache.
+func (da CachingDataAccess) RecordConsents(emailAddress string, consents []Consent) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.RecordConsents(emailAddress, consents)
+}
+
+// MergeProfiles merges the profiles and empties the cache, since the people
+// the duplicate managed are changed too.
+func (da CachingDataAccess) MergeProfiles(primary string, duplicate string) (*Profile, bool, error) {
+	defer da.cache.clear()
+	return da.DataAccess.MergeProfiles(primary, duplicate)
+}
+
+// UpdateExternalIDs updates the external IDs and removes the profile from
+// the cache.
+func (da CachingDataAccess) UpdateExternalIDs(emailAddress string, ids map[string]string) error {
+	defer da.cache.remove(emailAddress)
+	return da.DataAccess.UpdateExternalIDs(emailAddress, ids)
+}
@@ -0,0 +1,64 @@
+package dataaccess
+
+import (
+	"testing"
+	"time"
+)
+
+type countingDataAccess struct {
+	DataAccess
+	reads map[string]int
+}
+
+func (da countingDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	da.reads[emailAddress]++
+	return &Profile{EmailAddress: emailAddress}, true, nil
+}
+
+func (da countingDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	return &Profile{EmailAddress: update.EmailAddress}, nil
+}
+
+func TestThatTheLeastRecentlyUsedProfilesAreEvicted(t *testing.T) {
+	reads := map[string]int{}
+	da := NewCachingDataAccess(countingDataAccess{reads: reads}, 2, time.Minute)
+
+	for _, e := range []string{"a@github.com", "b@github.com", "a@github.com", "c@github.com", "a@github.com", "b@github.com"} {
+		da.GetProfile(e)
+	}
+
+	expected := map[string]int{"a@github.com": 1, "b@github.com": 2, "c@github.com": 1}
+	for e, n := range expected {
+		if reads[e] != n {
+			t.Errorf("Expected %s to be read %d times, but was read %d times.", e, n, reads[e])
+		}
+	}
+}
+
+func TestThatChangedProfilesAreRemovedFromTheCache(t *testing.T) {
+	reads := map[string]int{}
+	da := NewCachingDataAccess(countingDataAccess{reads: reads}, 10, time.Minute)
+
+	da.GetProfile("a@github.com")
+	da.UpdateProfile(&ProfileUpdate{EmailAddress: "a@github.com"})
+	da.GetProfile("a@github.com")
+
+	if reads["a@github.com"] != 2 {
+		t.Errorf("Expected the profile to be read again after it was changed, but it was read %d times.", reads["a@github.com"])
+	}
+}
+
+func TestThatCachedProfilesExpire(t *testing.T) {
+	reads := map[string]int{}
+	now := time.Date(2017, time.June, 1, 9, 0, 0, 0, time.UTC)
+	da := NewCachingDataAccess(countingDataAccess{reads: reads}, 10, time.Minute)
+	da.cache.now = func() time.Time { return now }
+
+	da.GetProfile("a@github.com")
+	now = now.Add(time.Minute)
+	da.GetProfile("a@github.com")
+
+	if reads["a@github.com"] != 2 {
+		t.Errorf("Expected the profile to be read again after it expired, but it was read %d times.", reads["a@github.com"])
+	}
+}
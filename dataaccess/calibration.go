@@ -0,0 +1,131 @@
+package dataaccess
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// CalibrationFeatureFlag is the feature flag which allows managers to
+// propose changes to the levels of the people in their team.
+const CalibrationFeatureFlag = "calibration"
+
+// A CalibrationStatus is the state of a proposed level adjustment.
+type CalibrationStatus string
+
+// The states of a calibration.
+const (
+	CalibrationPending  CalibrationStatus = "pending"
+	CalibrationAccepted CalibrationStatus = "accepted"
+	CalibrationDeclined CalibrationStatus = "declined"
+)
+
+// A Calibration is a change to the level of someone's skill, proposed by a
+// person who manages them. The person must accept it before their level is
+// changed. Calibrations are kept after they're decided, so that they form a
+// history of the adjustments made to people's self-assessments.
+type Calibration struct {
+	ID           string `bson:"_id" json:"id"`
+	EmailAddress string `json:"emailAddress"`
+	Domain       string `json:"domain"`
+	// ProposedBy is the email address of the manager who proposed the change.
+	ProposedBy string `json:"proposedBy"`
+	Skill      string `json:"skill"`
+	// SelfAssessed is the person's level when the change was proposed.
+	SelfAssessed DreyfusLevel      `json:"selfAssessed"`
+	Proposed     DreyfusLevel      `json:"proposed"`
+	Note         string            `json:"note,omitempty"`
+	Status       CalibrationStatus `json:"status"`
+	Created      time.Time         `json:"created"`
+	Decided      time.Time         `json:"decided,omitempty"`
+}
+
+// Validate checks that the proposed level is on the Dreyfus scale, and
+// differs from the self-assessment.
+func (c Calibration) Validate() error {
+	var problems []string
+	if c.EmailAddress == "" || c.Skill == "" {
+		problems = append(problems, "the email address and skill are required")
+	}
+	if c.Proposed < NoviceLevel || c.Proposed > MasterLevel {
+		problems = append(problems, "the proposed level must be between 1 and 5")
+	}
+	if c.Proposed == c.SelfAssessed {
+		problems = append(problems, "the proposed level must differ from the self-assessed level")
+	}
+	return newValidationError(problems)
+}
+
+// ErrCalibrationDecided is returned when deciding a calibration which has
+// already been accepted or declined.
+var ErrCalibrationDecided = errors.New("dataaccess: the calibration has already been decided")
+
+// Decide accepts or declines the calibration at the time.
+func (c *Calibration) Decide(accept bool, at time.Time) error {
+	if c.Status != CalibrationPending {
+		return ErrCalibrationDecided
+	}
+	c.Status = CalibrationDeclined
+	if accept {
+		c.Status = CalibrationAccepted
+	}
+	c.Decided = at.UTC().Truncate(time.Millisecond)
+	return nil
+}
+
+// SaveCalibration creates or updates a calibration.
+func (da MongoDataAccess) SaveCalibration(c *Calibration) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	_, err = session.DB(da.databaseName).C("calibrations").UpsertId(c.ID, c)
+	return err
+}
+
+// ListCalibrations lists the calibrations proposed for, or by, the person,
+// newest first.
+func (da MongoDataAccess) ListCalibrations(emailAddress string) ([]Calibration, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	emailAddress = strings.ToLower(emailAddress)
+	var results []Calibration
+	err = session.DB(da.databaseName).C("calibrations").
+		Find(bson.M{"$or": []bson.M{{"emailaddress": emailAddress}, {"proposedby": emailAddress}}}).
+		Sort("-created").
+		All(&results)
+	return results, err
+}
+
+// GetCalibration returns a calibration.
+func (da MongoDataAccess) GetCalibration(id string) (*Calibration, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	c := &Calibration{}
+	err = session.DB(da.databaseName).C("calibrations").FindId(id).One(c)
+
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return c, true, nil
+}
@@ -0,0 +1,1210 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is returned while the circuit breaker is open, rather than
+// waiting for the database to time out.
+var ErrUnavailable = errors.New("dataaccess: the database is unavailable, try again shortly")
+
+// DefaultBreakerFailures is how many consecutive connection failures open the
+// circuit breaker, unless configured.
+const DefaultBreakerFailures = 5
+
+// DefaultBreakerProbeInterval is how long the circuit breaker stays open
+// before a call is allowed through to check whether the database has
+// recovered, unless configured.
+const DefaultBreakerProbeInterval = 10 * time.Second
+
+// maxCachedReads limits how many read results are kept to serve while the
+// circuit breaker is open.
+const maxCachedReads = 10000
+
+// isConnectionFailure returns true if the error was caused by not being able
+// to reach the database, rather than by the request.
+func isConnectionFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	if err == io.EOF {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "no reachable servers") || strings.Contains(msg, "Closed explicitly")
+}
+
+// A breaker counts consecutive connection failures. Once the threshold is
+// reached it opens, and calls fail fast until the probe interval has passed,
+// when a single call is allowed through. If that call succeeds the breaker
+// closes, otherwise it stays open for another interval.
+type breaker struct {
+	m             sync.Mutex
+	threshold     int
+	probeInterval time.Duration
+	failures      int
+	openedAt      time.Time
+	probing       bool
+	now           func() time.Time
+}
+
+func (b *breaker) open() bool {
+	return b.failures >= b.threshold
+}
+
+// Open returns true if calls are currently failing fast.
+func (b *breaker) Open() bool {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.open()
+}
+
+// allow returns true if the call can go to the database.
+func (b *breaker) allow() bool {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	if !b.open() {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.probeInterval {
+		return false
+	}
+	log.Print("Probing whether the database has recovered.")
+	b.probing = true
+	return true
+}
+
+// record records the outcome of a call which was allowed.
+func (b *breaker) record(err error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	wasOpen := b.open()
+	b.probing = false
+	if !isConnectionFailure(err) {
+		if wasOpen {
+			log.Print("The database has recovered, closing the circuit breaker.")
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.open() {
+		if !wasOpen {
+			log.Printf("Opening the circuit breaker after %d consecutive connection failures. %v", b.failures, err)
+		}
+		b.openedAt = b.now()
+	}
+}
+
+// readCache holds the last successful result of reads, so that they can be
+// returned while the database is unavailable.
+type readCache struct {
+	m       sync.Mutex
+	entries map[string]interface{}
+}
+
+func (c *readCache) get(key string) (interface{}, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	v, ok := c.entries[key]
+	return v, ok
+}
+
+func (c *readCache) put(key string, v interface{}) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCachedReads {
+		return
+	}
+	c.entries[key] = v
+}
+
+func (c *readCache) clear() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.entries = map[string]interface{}{}
+}
+
+func (c *readCache) remove(keys ...string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for _, k := range keys {
+		delete(c.entries, k)
+	}
+}
+
+// CircuitBreakingDataAccess stops calling the database after consecutive
+// connection failures, so that requests fail fast with ErrUnavailable during
+// an outage instead of each waiting for a connection timeout. Reads which
+// have succeeded before are served from the last result while the breaker is
+// open.
+type CircuitBreakingDataAccess struct {
+	DataAccess
+	breaker *breaker
+	cache   *readCache
+}
+
+// NewCircuitBreakingDataAccess creates a DataAccess which opens after the
+// number of consecutive connection failures, and probes the database for
+// recovery every probeInterval.
+func NewCircuitBreakingDataAccess(da DataAccess, failures int, probeInterval time.Duration) *CircuitBreakingDataAccess {
+	b := &breaker{threshold: failures, probeInterval: probeInterval, now: time.Now}
+	return &CircuitBreakingDataAccess{da, b, &readCache{entries: map[string]interface{}{}}}
+}
+
+// WithContext passes the context to the wrapped DataAccess. The breaker and
+// cached reads are shared.
+func (da CircuitBreakingDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &CircuitBreakingDataAccess{WithContext(da.DataAccess, ctx), da.breaker, da.cache}
+}
+
+// Open returns true if the database is unavailable and calls are failing
+// fast.
+func (da CircuitBreakingDataAccess) Open() bool {
+	return da.breaker.Open()
+}
+
+func (da CircuitBreakingDataAccess) do(f func() error) error {
+	if !da.breaker.allow() {
+		return ErrUnavailable
+	}
+	err := f()
+	da.breaker.record(err)
+	return err
+}
+
+// read calls f, caching the result under the key, or returns the cached
+// result if the breaker is open.
+func (da CircuitBreakingDataAccess) read(key string, f func() (interface{}, error)) (interface{}, error) {
+	if !da.breaker.allow() {
+		if v, ok := da.cache.get(key); ok {
+			return v, nil
+		}
+		return nil, ErrUnavailable
+	}
+	v, err := f()
+	da.breaker.record(err)
+	if err != nil {
+		if v, ok := da.cache.get(key); ok && isConnectionFailure(err) {
+			log.Printf("Serving the cached result of %s, because the database is unavailable.", strings.SplitN(key, " ", 2)[0])
+			return v, nil
+		}
+		return nil, err
+	}
+	da.cache.put(key, v)
+	return v, nil
+}
+
+type cachedProfile struct {
+	p     *Profile
+	found bool
+}
+
+type cachedTenantConfiguration struct {
+	tc    *TenantConfiguration
+	found bool
+}
+
+// ListProfiles returns the cached profiles while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
+	v, err := da.read("ListProfiles "+GetDomain(emailAddress), func() (interface{}, error) {
+		return da.DataAccess.ListProfiles(emailAddress)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Profile), nil
+}
+
+// GetOrgTree returns the cached tree while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetOrgTree(d string) (*OrgNode, error) {
+	v, err := da.read("GetOrgTree "+d, func() (interface{}, error) {
+		return da.DataAccess.GetOrgTree(d)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*OrgNode), nil
+}
+
+// ListDomains returns the cached domains while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListDomains() ([]string, error) {
+	v, err := da.read("ListDomains", func() (interface{}, error) {
+		return da.DataAccess.ListDomains()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]string), nil
+}
+
+// UpdateNotificationPreferences fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateNotificationPreferences(emailAddress string, p NotificationPreferences) error {
+	return da.do(func() error {
+		return da.DataAccess.UpdateNotificationPreferences(emailAddress, p)
+	})
+}
+
+// UpdateAvailabilityWindows fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error {
+	return da.do(func() error {
+		return da.DataAccess.UpdateAvailabilityWindows(emailAddress, windows)
+	})
+}
+
+// AwardBadges fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) AwardBadges(emailAddress string, badges []Badge) error {
+	err := da.do(func() error {
+		return da.DataAccess.AwardBadges(emailAddress, badges)
+	})
+	if err == nil {
+		da.cache.remove("GetProfile "+emailAddress, "ListProfiles "+GetDomain(emailAddress))
+	}
+	return err
+}
+
+// AddBooking fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) AddBooking(emailAddress string, b Booking) error {
+	err := da.do(func() error {
+		return da.DataAccess.AddBooking(emailAddress, b)
+	})
+	if err == nil {
+		da.cache.remove("GetProfile "+emailAddress, "ListProfiles "+GetDomain(emailAddress))
+	}
+	return err
+}
+
+// RemoveBooking fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) RemoveBooking(emailAddress string, id string) error {
+	err := da.do(func() error {
+		return da.DataAccess.RemoveBooking(emailAddress, id)
+	})
+	if err == nil {
+		da.cache.remove("GetProfile "+emailAddress, "ListProfiles "+GetDomain(emailAddress))
+	}
+	return err
+}
+
+// GetProfile returns the cached profile while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	v, err := da.read("GetProfile "+emailAddress, func() (interface{}, error) {
+		p, found, err := da.DataAccess.GetProfile(emailAddress)
+		return cachedProfile{p, found}, err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	cp := v.(cachedProfile)
+	return cp.p, cp.found, nil
+}
+
+// UpdateProfile fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateProfile(update *ProfileUpdate) (p *Profile, err error) {
+	err = da.do(func() error {
+		p, err = da.DataAccess.UpdateProfile(update)
+		return err
+	})
+	if err == nil {
+		da.cache.remove("GetProfile "+update.EmailAddress, "ListProfiles "+GetDomain(update.EmailAddress))
+	}
+	return p, err
+}
+
+// ImportProfile fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ImportProfile(p *Profile) error {
+	err := da.do(func() error {
+		return da.DataAccess.ImportProfile(p)
+	})
+	if err == nil {
+		da.cache.remove("GetProfile "+p.EmailAddress, "ListProfiles "+GetDomain(p.EmailAddress))
+	}
+	return err
+}
+
+// DeleteProfile fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) DeleteProfile(emailAddress string) (deleted bool, err error) {
+	err = da.do(func() error {
+		deleted, err = da.DataAccess.DeleteProfile(emailAddress)
+		return err
+	})
+	if err == nil {
+		da.cache.remove("GetProfile "+emailAddress, "ListProfiles "+GetDomain(emailAddress))
+	}
+	return deleted, err
+}
+
+// ListSkillTags returns the cached tags while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListSkillTags() ([]SkillTag, error) {
+	v, err := da.read("ListSkillTags", func() (interface{}, error) {
+		return da.DataAccess.ListSkillTags()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]SkillTag), nil
+}
+
+// SetSkillTagDescriptors fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SetSkillTagDescriptors(name string, descriptors []LevelDescriptor) error {
+	err := da.do(func() error {
+		return da.DataAccess.SetSkillTagDescriptors(name, descriptors)
+	})
+	if err == nil {
+		da.cache.remove("ListSkillTags")
+	}
+	return err
+}
+
+// AddSkillTags fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) AddSkillTags(tags []string) error {
+	return da.do(func() error {
+		return da.DataAccess.AddSkillTags(tags)
+	})
+}
+
+// DeleteSkillTags fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) DeleteSkillTags(tags []string) error {
+	return da.do(func() error {
+		return da.DataAccess.DeleteSkillTags(tags)
+	})
+}
+
+// ListDeletedSkillTags fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListDeletedSkillTags() (tags []DeletedSkillTag, err error) {
+	err = da.do(func() error {
+		tags, err = da.DataAccess.ListDeletedSkillTags()
+		return err
+	})
+	return tags, err
+}
+
+// RestoreSkillTags fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) RestoreSkillTags(tags []string) (restored []string, err error) {
+	err = da.do(func() error {
+		restored, err = da.DataAccess.RestoreSkillTags(tags)
+		return err
+	})
+	return restored, err
+}
+
+// PurgeDeletedSkillTags fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) PurgeDeletedSkillTags(before time.Time) (n int, err error) {
+	err = da.do(func() error {
+		n, err = da.DataAccess.PurgeDeletedSkillTags(before)
+		return err
+	})
+	return n, err
+}
+
+// GetOrCreateConfiguration returns the cached configuration while the
+// database is unavailable.
+func (da CircuitBreakingDataAccess) GetOrCreateConfiguration() (Configuration, error) {
+	v, err := da.read("GetOrCreateConfiguration", func() (interface{}, error) {
+		return da.DataAccess.GetOrCreateConfiguration()
+	})
+	if err != nil {
+		return Configuration{}, err
+	}
+	return v.(Configuration), nil
+}
+
+// DeleteConfiguration fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) DeleteConfiguration() error {
+	err := da.do(func() error {
+		return da.DataAccess.DeleteConfiguration()
+	})
+	if err == nil {
+		da.cache.remove("GetOrCreateConfiguration")
+	}
+	return err
+}
+
+// RotateSessionEncryptionKey fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) RotateSessionEncryptionKey() (c Configuration, err error) {
+	err = da.do(func() error {
+		c, err = da.DataAccess.RotateSessionEncryptionKey()
+		return err
+	})
+	if err == nil {
+		da.cache.remove("GetOrCreateConfiguration")
+	}
+	return c, err
+}
+
+// SetFeatureFlag fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SetFeatureFlag(name string, enabled bool) error {
+	err := da.do(func() error {
+		return da.DataAccess.SetFeatureFlag(name, enabled)
+	})
+	if err == nil {
+		da.cache.remove("GetOrCreateConfiguration")
+	}
+	return err
+}
+
+// GetTenantConfiguration returns the cached configuration while the database
+// is unavailable.
+func (da CircuitBreakingDataAccess) GetTenantConfiguration(d string) (*TenantConfiguration, bool, error) {
+	v, err := da.read("GetTenantConfiguration "+d, func() (interface{}, error) {
+		tc, found, err := da.DataAccess.GetTenantConfiguration(d)
+		return cachedTenantConfiguration{tc, found}, err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	ctc := v.(cachedTenantConfiguration)
+	return ctc.tc, ctc.found, nil
+}
+
+// UpdateTenantConfiguration fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateTenantConfiguration(tc *TenantConfiguration) error {
+	err := da.do(func() error {
+		return da.DataAccess.UpdateTenantConfiguration(tc)
+	})
+	if err == nil {
+		da.cache.remove("GetTenantConfiguration "+tc.Domain, "GetSettings "+tc.Domain)
+	}
+	return err
+}
+
+// DeleteTenantConfiguration fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) DeleteTenantConfiguration(d string) error {
+	err := da.do(func() error {
+		return da.DataAccess.DeleteTenantConfiguration(d)
+	})
+	if err == nil {
+		da.cache.remove("GetTenantConfiguration "+d, "GetSettings "+d)
+	}
+	return err
+}
+
+// GetSettings returns the cached settings while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetSettings(d string) (Settings, error) {
+	v, err := da.read("GetSettings "+d, func() (interface{}, error) {
+		return da.DataAccess.GetSettings(d)
+	})
+	if err != nil {
+		return Settings{}, err
+	}
+	return v.(Settings), nil
+}
+
+// AcquireLock fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) AcquireLock(name string, ttl time.Duration) (l *Lock, acquired bool, err error) {
+	err = da.do(func() error {
+		l, acquired, err = da.DataAccess.AcquireLock(name, ttl)
+		return err
+	})
+	return l, acquired, err
+}
+
+// ReleaseLock fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ReleaseLock(lock *Lock) error {
+	return da.do(func() error {
+		return da.DataAccess.ReleaseLock(lock)
+	})
+}
+
+// QuarantineChange fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) QuarantineChange(c *QuarantinedChange) error {
+	return da.do(func() error {
+		return da.DataAccess.QuarantineChange(c)
+	})
+}
+
+// ListQuarantinedChanges fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListQuarantinedChanges(d string) (changes []QuarantinedChange, err error) {
+	err = da.do(func() error {
+		changes, err = da.DataAccess.ListQuarantinedChanges(d)
+		return err
+	})
+	return changes, err
+}
+
+// GetQuarantinedChange fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetQuarantinedChange(id string) (c *QuarantinedChange, found bool, err error) {
+	err = da.do(func() error {
+		c, found, err = da.DataAccess.GetQuarantinedChange(id)
+		return err
+	})
+	return c, found, err
+}
+
+// DeleteQuarantinedChange fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) DeleteQuarantinedChange(id string) error {
+	return da.do(func() error {
+		return da.DataAccess.DeleteQuarantinedChange(id)
+	})
+}
+
+// RequestApproval fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) RequestApproval(a *ApprovalRequest) error {
+	return da.do(func() error {
+		return da.DataAccess.RequestApproval(a)
+	})
+}
+
+// ListApprovalRequests fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListApprovalRequests() (requests []ApprovalRequest, err error) {
+	err = da.do(func() error {
+		requests, err = da.DataAccess.ListApprovalRequests()
+		return err
+	})
+	return requests, err
+}
+
+// GetApprovalRequest fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetApprovalRequest(id string) (a *ApprovalRequest, found bool, err error) {
+	err = da.do(func() error {
+		a, found, err = da.DataAccess.GetApprovalRequest(id)
+		return err
+	})
+	return a, found, err
+}
+
+// DeleteApprovalRequest fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) DeleteApprovalRequest(id string) error {
+	return da.do(func() error {
+		return da.DataAccess.DeleteApprovalRequest(id)
+	})
+}
+
+// SaveCalibration fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveCalibration(c *Calibration) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveCalibration(c)
+	})
+}
+
+// ListCalibrations fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListCalibrations(emailAddress string) (calibrations []Calibration, err error) {
+	err = da.do(func() error {
+		calibrations, err = da.DataAccess.ListCalibrations(emailAddress)
+		return err
+	})
+	return calibrations, err
+}
+
+// GetCalibration fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetCalibration(id string) (c *Calibration, found bool, err error) {
+	err = da.do(func() error {
+		c, found, err = da.DataAccess.GetCalibration(id)
+		return err
+	})
+	return c, found, err
+}
+
+// SaveProject fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveProject(p *Project) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveProject(p)
+	})
+}
+
+// ListProjects fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListProjects(domain string) (projects []Project, err error) {
+	err = da.do(func() error {
+		projects, err = da.DataAccess.ListProjects(domain)
+		return err
+	})
+	return projects, err
+}
+
+// GetProject fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetProject(id string) (p *Project, found bool, err error) {
+	err = da.do(func() error {
+		p, found, err = da.DataAccess.GetProject(id)
+		return err
+	})
+	return p, found, err
+}
+
+// DeleteProject fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) DeleteProject(id string) error {
+	return da.do(func() error {
+		return da.DataAccess.DeleteProject(id)
+	})
+}
+
+// SaveCourse fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveCourse(c *Course) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveCourse(c)
+	})
+}
+
+// ListCourses fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListCourses(domain string) (courses []Course, err error) {
+	err = da.do(func() error {
+		courses, err = da.DataAccess.ListCourses(domain)
+		return err
+	})
+	return courses, err
+}
+
+// GetCourse fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetCourse(id string) (c *Course, found bool, err error) {
+	err = da.do(func() error {
+		c, found, err = da.DataAccess.GetCourse(id)
+		return err
+	})
+	return c, found, err
+}
+
+// DeleteCourse fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) DeleteCourse(id string) error {
+	return da.do(func() error {
+		return da.DataAccess.DeleteCourse(id)
+	})
+}
+
+// SaveCourseCompletion fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveCourseCompletion(cc *CourseCompletion) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveCourseCompletion(cc)
+	})
+}
+
+// ListCourseCompletions fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListCourseCompletions(domain string) (completions []CourseCompletion, err error) {
+	err = da.do(func() error {
+		completions, err = da.DataAccess.ListCourseCompletions(domain)
+		return err
+	})
+	return completions, err
+}
+
+// GetCourseCompletion fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetCourseCompletion(id string) (cc *CourseCompletion, found bool, err error) {
+	err = da.do(func() error {
+		cc, found, err = da.DataAccess.GetCourseCompletion(id)
+		return err
+	})
+	return cc, found, err
+}
+
+// UpdateLearningGoals fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateLearningGoals(emailAddress string, goals []LearningGoal) error {
+	err := da.do(func() error {
+		return da.DataAccess.UpdateLearningGoals(emailAddress, goals)
+	})
+	if err == nil {
+		da.cache.remove("GetProfile "+emailAddress, "ListProfiles "+GetDomain(emailAddress))
+	}
+	return err
+}
+
+// ConfirmSkills fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	err := da.do(func() error {
+		return da.DataAccess.ConfirmSkills(emailAddress, skills)
+	})
+	if err == nil {
+		da.cache.remove("GetProfile "+emailAddress, "ListProfiles "+GetDomain(emailAddress))
+	}
+	return err
+}
+
+// ImportSkillTags fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ImportSkillTags(tags []SkillTag) (added int, err error) {
+	err = da.do(func() error {
+		added, err = da.DataAccess.ImportSkillTags(tags)
+		return err
+	})
+	if err == nil {
+		da.cache.remove("ListSkillTags")
+	}
+	return added, err
+}
+
+// SaveTagProposal fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveTagProposal(p *TagProposal) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveTagProposal(p)
+	})
+}
+
+// ListTagProposals fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListTagProposals(domain string) (proposals []TagProposal, err error) {
+	err = da.do(func() error {
+		proposals, err = da.DataAccess.ListTagProposals(domain)
+		return err
+	})
+	return proposals, err
+}
+
+// GetTagProposal fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetTagProposal(id string) (p *TagProposal, found bool, err error) {
+	err = da.do(func() error {
+		p, found, err = da.DataAccess.GetTagProposal(id)
+		return err
+	})
+	return p, found, err
+}
+
+// MergeSkillTags fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) MergeSkillTags(into string, tags []string) (merged int, err error) {
+	err = da.do(func() error {
+		merged, err = da.DataAccess.MergeSkillTags(into, tags)
+		return err
+	})
+	if err == nil {
+		// The merge changes profiles in every tenant.
+		da.cache.clear()
+	}
+	return merged, err
+}
+
+// UpdateCV fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateCV(emailAddress string, cv *Attachment) error {
+	return da.do(func() error {
+		return da.DataAccess.UpdateCV(emailAddress, cv)
+	})
+}
+
+// SaveSkillSuggestions fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveSkillSuggestions(ss *SkillSuggestions) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveSkillSuggestions(ss)
+	})
+}
+
+// ListSkillSuggestions fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListSkillSuggestions(emailAddress string) (suggestions []SkillSuggestions, err error) {
+	err = da.do(func() error {
+		suggestions, err = da.DataAccess.ListSkillSuggestions(emailAddress)
+		return err
+	})
+	return suggestions, err
+}
+
+// GetSkillSuggestions fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetSkillSuggestions(id string) (ss *SkillSuggestions, found bool, err error) {
+	err = da.do(func() error {
+		ss, found, err = da.DataAccess.GetSkillSuggestions(id)
+		return err
+	})
+	return ss, found, err
+}
+
+// SaveShareLink fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveShareLink(l *ShareLink) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveShareLink(l)
+	})
+}
+
+// ListShareLinks fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListShareLinks(emailAddress string) (links []ShareLink, err error) {
+	err = da.do(func() error {
+		links, err = da.DataAccess.ListShareLinks(emailAddress)
+		return err
+	})
+	return links, err
+}
+
+// GetShareLink fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetShareLink(id string) (l *ShareLink, found bool, err error) {
+	err = da.do(func() error {
+		l, found, err = da.DataAccess.GetShareLink(id)
+		return err
+	})
+	return l, found, err
+}
+
+// DeleteShareLink fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) DeleteShareLink(id string) error {
+	return da.do(func() error {
+		return da.DataAccess.DeleteShareLink(id)
+	})
+}
+
+// SyncEmployee fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SyncEmployee(e EmployeeUpdate) error {
+	return da.do(func() error {
+		return da.DataAccess.SyncEmployee(e)
+	})
+}
+
+// ArchiveProfile fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (archived bool, err error) {
+	err = da.do(func() error {
+		archived, err = da.DataAccess.ArchiveProfile(emailAddress, reason, purgeAfter)
+		return err
+	})
+	return archived, err
+}
+
+// RestoreProfile fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) RestoreProfile(emailAddress string) (restored bool, err error) {
+	err = da.do(func() error {
+		restored, err = da.DataAccess.RestoreProfile(emailAddress)
+		return err
+	})
+	return restored, err
+}
+
+// ListArchivedProfiles fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListArchivedProfiles(domain string) (profiles []ArchivedProfile, err error) {
+	err = da.do(func() error {
+		profiles, err = da.DataAccess.ListArchivedProfiles(domain)
+		return err
+	})
+	return profiles, err
+}
+
+// PurgeArchivedProfiles fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) PurgeArchivedProfiles(before time.Time) (purged int, err error) {
+	err = da.do(func() error {
+		purged, err = da.DataAccess.PurgeArchivedProfiles(before)
+		return err
+	})
+	return purged, err
+}
+
+// RevokeSessions fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) RevokeSessions(emailAddress string, at time.Time) error {
+	return da.do(func() error {
+		return da.DataAccess.RevokeSessions(emailAddress, at)
+	})
+}
+
+// FindProfiles fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) FindProfiles(domain string, f ProfileFilter) (profiles []Profile, err error) {
+	err = da.do(func() error {
+		profiles, err = da.DataAccess.FindProfiles(domain, f)
+		return err
+	})
+	return profiles, err
+}
+
+// EnsureIndexes fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) EnsureIndexes() error {
+	return da.do(func() error {
+		return da.DataAccess.EnsureIndexes()
+	})
+}
+
+// UpdateCustomFields fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) error {
+	return da.do(func() error {
+		return da.DataAccess.UpdateCustomFields(emailAddress, values)
+	})
+}
+
+// UpdateLanguages fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateLanguages(emailAddress string, languages []Language) error {
+	return da.do(func() error {
+		return da.DataAccess.UpdateLanguages(emailAddress, languages)
+	})
+}
+
+// UpdateClearance fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateClearance(emailAddress string, cl *Clearance) error {
+	return da.do(func() error {
+		return da.DataAccess.UpdateClearance(emailAddress, cl)
+	})
+}
+
+// UpdateWorkLocation fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateWorkLocation(emailAddress string, l *WorkLocation) error {
+	return da.do(func() error {
+		return da.DataAccess.UpdateWorkLocation(emailAddress, l)
+	})
+}
+
+// FindProfilesNear fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) FindProfilesNear(domain string, near Coordinates, radiusKm float64) (profiles []Profile, err error) {
+	err = da.do(func() error {
+		profiles, err = da.DataAccess.FindProfilesNear(domain, near, radiusKm)
+		return err
+	})
+	return profiles, err
+}
+
+// UpdateWorkingHours fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateWorkingHours(emailAddress string, h *WorkingHours) error {
+	return da.do(func() error {
+		return da.DataAccess.UpdateWorkingHours(emailAddress, h)
+	})
+}
+
+// RecordConsents fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) RecordConsents(emailAddress string, consents []Consent) error {
+	return da.do(func() error {
+		return da.DataAccess.RecordConsents(emailAddress, consents)
+	})
+}
+
+// SaveBenchmarks fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveBenchmarks(benchmarks []Benchmark) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveBenchmarks(benchmarks)
+	})
+}
+
+// GetBenchmark fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetBenchmark(band string) (b *Benchmark, found bool, err error) {
+	err = da.do(func() error {
+		b, found, err = da.DataAccess.GetBenchmark(band)
+		return err
+	})
+	return b, found, err
+}
+
+// SaveReportDefinition fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveReportDefinition(d *ReportDefinition) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveReportDefinition(d)
+	})
+}
+
+// ListReportDefinitions fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListReportDefinitions(domain string) (definitions []ReportDefinition, err error) {
+	err = da.do(func() error {
+		definitions, err = da.DataAccess.ListReportDefinitions(domain)
+		return err
+	})
+	return definitions, err
+}
+
+// DeleteReportDefinition fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) DeleteReportDefinition(domain string, id string) (deleted bool, err error) {
+	err = da.do(func() error {
+		deleted, err = da.DataAccess.DeleteReportDefinition(domain, id)
+		return err
+	})
+	return deleted, err
+}
+
+// RunReport fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) RunReport(d ReportDefinition, consent ConsentSettings) (result *ReportResult, err error) {
+	err = da.do(func() error {
+		result, err = da.DataAccess.RunReport(d, consent)
+		return err
+	})
+	return result, err
+}
+
+// SaveReportSnapshot fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveReportSnapshot(s *ReportSnapshot) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveReportSnapshot(s)
+	})
+}
+
+// GetReportSnapshot fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetReportSnapshot(domain string, report string) (s *ReportSnapshot, found bool, err error) {
+	err = da.do(func() error {
+		s, found, err = da.DataAccess.GetReportSnapshot(domain, report)
+		return err
+	})
+	return s, found, err
+}
+
+// SaveExportJob fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveExportJob(j *ExportJob) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveExportJob(j)
+	})
+}
+
+// GetExportJob fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetExportJob(id string) (j *ExportJob, found bool, err error) {
+	err = da.do(func() error {
+		j, found, err = da.DataAccess.GetExportJob(id)
+		return err
+	})
+	return j, found, err
+}
+
+// ClaimExportJob fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ClaimExportJob(now time.Time) (j *ExportJob, found bool, err error) {
+	err = da.do(func() error {
+		j, found, err = da.DataAccess.ClaimExportJob(now)
+		return err
+	})
+	return j, found, err
+}
+
+// PurgeExportJobs fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) PurgeExportJobs(before time.Time) (expired []ExportJob, err error) {
+	err = da.do(func() error {
+		expired, err = da.DataAccess.PurgeExportJobs(before)
+		return err
+	})
+	return expired, err
+}
+
+// SaveImportPreview fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveImportPreview(p *ImportPreview) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveImportPreview(p)
+	})
+}
+
+// TakeImportPreview fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) TakeImportPreview(domain string, id string) (p *ImportPreview, found bool, err error) {
+	err = da.do(func() error {
+		p, found, err = da.DataAccess.TakeImportPreview(domain, id)
+		return err
+	})
+	return p, found, err
+}
+
+// MergeProfiles fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) MergeProfiles(primary string, duplicate string) (p *Profile, found bool, err error) {
+	err = da.do(func() error {
+		p, found, err = da.DataAccess.MergeProfiles(primary, duplicate)
+		return err
+	})
+	return p, found, err
+}
+
+// FindProfileByExternalID fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) FindProfileByExternalID(domain string, system string, id string) (p *Profile, found bool, err error) {
+	err = da.do(func() error {
+		p, found, err = da.DataAccess.FindProfileByExternalID(domain, system, id)
+		return err
+	})
+	return p, found, err
+}
+
+// UpdateExternalIDs fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) UpdateExternalIDs(emailAddress string, ids map[string]string) error {
+	return da.do(func() error {
+		return da.DataAccess.UpdateExternalIDs(emailAddress, ids)
+	})
+}
+
+// AppendProfileEvents fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) AppendProfileEvents(events []ProfileEvent) error {
+	return da.do(func() error {
+		return da.DataAccess.AppendProfileEvents(events)
+	})
+}
+
+// ListProfileEvents fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListProfileEvents(emailAddress string, after int) (events []ProfileEvent, err error) {
+	err = da.do(func() error {
+		events, err = da.DataAccess.ListProfileEvents(emailAddress, after)
+		return err
+	})
+	return events, err
+}
+
+// SaveProfileSnapshot fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveProfileSnapshot(s *ProfileSnapshot) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveProfileSnapshot(s)
+	})
+}
+
+// GetProfileSnapshot fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetProfileSnapshot(emailAddress string, at time.Time) (s *ProfileSnapshot, found bool, err error) {
+	err = da.do(func() error {
+		s, found, err = da.DataAccess.GetProfileSnapshot(emailAddress, at)
+		return err
+	})
+	return s, found, err
+}
+
+// ProjectProfile fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ProjectProfile(s *ProfileSnapshot) error {
+	return da.do(func() error {
+		return da.DataAccess.ProjectProfile(s)
+	})
+}
+
+// SaveSearchDocument fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveSearchDocument(d *SearchDocument) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveSearchDocument(d)
+	})
+}
+
+// GetSearchDocument fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetSearchDocument(emailAddress string) (d *SearchDocument, found bool, err error) {
+	err = da.do(func() error {
+		d, found, err = da.DataAccess.GetSearchDocument(emailAddress)
+		return err
+	})
+	return d, found, err
+}
+
+// RemoveSearchDocument fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) RemoveSearchDocument(emailAddress string) error {
+	return da.do(func() error {
+		return da.DataAccess.RemoveSearchDocument(emailAddress)
+	})
+}
+
+// SearchDocuments fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SearchDocuments(domain string, q SearchQuery) (documents []SearchDocument, err error) {
+	err = da.do(func() error {
+		documents, err = da.DataAccess.SearchDocuments(domain, q)
+		return err
+	})
+	return documents, err
+}
+
+// SaveTeamSummary fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveTeamSummary(s *TeamSummary) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveTeamSummary(s)
+	})
+}
+
+// GetTeamSummary fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetTeamSummary(manager string) (s *TeamSummary, found bool, err error) {
+	err = da.do(func() error {
+		s, found, err = da.DataAccess.GetTeamSummary(manager)
+		return err
+	})
+	return s, found, err
+}
+
+// SaveProfileSummary fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) SaveProfileSummary(s *ProfileSummary) error {
+	return da.do(func() error {
+		return da.DataAccess.SaveProfileSummary(s)
+	})
+}
+
+// GetProfileSummary fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) GetProfileSummary(emailAddress string) (summary *ProfileSummary, found bool, err error) {
+	err = da.do(func() error {
+		summary, found, err = da.DataAccess.GetProfileSummary(emailAddress)
+		return err
+	})
+	return summary, found, err
+}
+
+// ListProfileSummaries fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) ListProfileSummaries(domain string) (summaries []ProfileSummary, err error) {
+	err = da.do(func() error {
+		summaries, err = da.DataAccess.ListProfileSummaries(domain)
+		return err
+	})
+	return summaries, err
+}
+
+// RemoveProfileSummary fails fast while the database is unavailable.
+func (da CircuitBreakingDataAccess) RemoveProfileSummary(emailAddress string) error {
+	return da.do(func() error {
+		return da.DataAccess.RemoveProfileSummary(emailAddress)
+	})
+}
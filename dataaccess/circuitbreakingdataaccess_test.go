@@ -0,0 +1,87 @@
+package dataaccess
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type flakyDataAccess struct {
+	DataAccess
+	down  *bool
+	calls *int
+}
+
+var errNoReachableServers = errors.New("no reachable servers")
+
+func (da flakyDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	*da.calls++
+	if *da.down {
+		return nil, false, errNoReachableServers
+	}
+	return &Profile{EmailAddress: emailAddress}, true, nil
+}
+
+func (da flakyDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	*da.calls++
+	if *da.down {
+		return nil, errNoReachableServers
+	}
+	return &Profile{EmailAddress: update.EmailAddress}, nil
+}
+
+func TestThatTheCircuitBreakerFailsFastAfterConsecutiveConnectionFailures(t *testing.T) {
+	down, calls := false, 0
+	now := time.Date(2017, time.June, 1, 9, 0, 0, 0, time.UTC)
+	da := NewCircuitBreakingDataAccess(flakyDataAccess{down: &down, calls: &calls}, 3, 10*time.Second)
+	da.breaker.now = func() time.Time { return now }
+
+	if _, _, err := da.GetProfile("a@github.com"); err != nil {
+		t.Fatalf("Expected the profile to be read, but got %v.", err)
+	}
+
+	down = true
+	for i := 0; i < 3; i++ {
+		da.UpdateProfile(&ProfileUpdate{EmailAddress: "b@github.com"})
+	}
+	if !da.Open() {
+		t.Fatal("Expected the breaker to open after 3 connection failures.")
+	}
+
+	calls = 0
+	if _, err := da.UpdateProfile(&ProfileUpdate{EmailAddress: "b@github.com"}); err != ErrUnavailable {
+		t.Errorf("Expected changes to fail with ErrUnavailable while open, but got %v.", err)
+	}
+	if p, found, err := da.GetProfile("a@github.com"); err != nil || !found || p.EmailAddress != "a@github.com" {
+		t.Errorf("Expected the cached profile to be returned while open, but got %v, %v, %v.", p, found, err)
+	}
+	if _, _, err := da.GetProfile("c@github.com"); err != ErrUnavailable {
+		t.Errorf("Expected reads which aren't cached to fail with ErrUnavailable while open, but got %v.", err)
+	}
+	if calls != 0 {
+		t.Errorf("Expected no calls to the database while open, but got %d.", calls)
+	}
+
+	now = now.Add(11 * time.Second)
+	da.GetProfile("c@github.com")
+	if calls != 1 || !da.Open() {
+		t.Errorf("Expected a single failed probe to keep the breaker open, but got %d calls.", calls)
+	}
+
+	down = false
+	now = now.Add(11 * time.Second)
+	if _, _, err := da.GetProfile("c@github.com"); err != nil {
+		t.Errorf("Expected the probe to succeed once the database recovers, but got %v.", err)
+	}
+	if da.Open() {
+		t.Error("Expected the breaker to close after a successful probe.")
+	}
+}
+
+func TestThatErrorsOtherThanConnectionFailuresDontOpenTheCircuitBreaker(t *testing.T) {
+	da := NewCircuitBreakingDataAccess(stubDataAccess{err: errors.New("failed")}, 1, time.Second)
+	da.UpdateProfile(&ProfileUpdate{EmailAddress: "a@github.com"})
+	if da.Open() {
+		t.Error("Expected the breaker to stay closed.")
+	}
+}
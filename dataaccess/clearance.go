@@ -0,0 +1,97 @@
+package dataaccess
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A Clearance is a person's security clearance, e.g. for staffing defence
+// engagements.
+type Clearance struct {
+	// Level is the clearance held, e.g. "SC" or "DV".
+	Level string `json:"level"`
+	// Expires is when the clearance lapses, or zero if it isn't known.
+	Expires time.Time `json:"expires,omitempty"`
+	// VerifiedBy is the email address of the person who recorded the
+	// clearance.
+	VerifiedBy string `json:"verifiedBy"`
+}
+
+// ClearanceSettings control who can see security clearances, and which
+// levels can be recorded.
+type ClearanceSettings struct {
+	// Levels are the clearances which can be recorded. Any level can be
+	// recorded if it's empty.
+	Levels []string `json:"levels,omitempty"`
+	// Roles are the roles which can see and change everyone's clearances.
+	// People can always see their own.
+	Roles []string `json:"roles"`
+}
+
+func (s ClearanceSettings) problems() []string {
+	var problems []string
+	for _, r := range s.Roles {
+		if r != AdministratorRole && r != SecurityOfficerRole {
+			problems = append(problems, "the clearance roles must be "+AdministratorRole+" or "+SecurityOfficerRole)
+			break
+		}
+	}
+	for _, l := range s.Levels {
+		if strings.TrimSpace(l) == "" {
+			problems = append(problems, "the clearance levels must not be empty")
+			break
+		}
+	}
+	return problems
+}
+
+// CanChange returns true if the caller holds one of the roles which can see
+// and change everyone's clearances.
+func (s ClearanceSettings) CanChange(c caller.Caller) bool {
+	for _, r := range s.Roles {
+		if c.HasRole(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanView returns true if the caller can see the clearance of the person
+// with the email address.
+func (s ClearanceSettings) CanView(c caller.Caller, emailAddress string) bool {
+	return strings.EqualFold(c.EmailAddress, emailAddress) || s.CanChange(c)
+}
+
+// Check returns an error if the clearance's level can't be recorded.
+func (s ClearanceSettings) Check(cl Clearance) error {
+	if cl.Level == "" {
+		return newValidationError([]string{"the clearance level is required"})
+	}
+	if len(s.Levels) > 0 && !contains(s.Levels, cl.Level) {
+		return newValidationError([]string{"the clearance level must be one of " + strings.Join(s.Levels, ", ")})
+	}
+	return nil
+}
+
+// UpdateClearance records the person's security clearance, or removes it if
+// it's nil.
+func (da MongoDataAccess) UpdateClearance(emailAddress string, cl *Clearance) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	update := bson.M{"$unset": bson.M{"clearance": ""}}
+	if cl != nil {
+		utc := *cl
+		utc.Expires = utc.Expires.UTC()
+		update = bson.M{"$set": bson.M{"clearance": utc}}
+	}
+	return session.DB(da.databaseName).C("profiles").UpdateId(strings.ToLower(emailAddress), update)
+}
@@ -0,0 +1,210 @@
+package dataaccess
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// A Cohort is a group of people in a domain, e.g. a department or an office,
+// to compare with another.
+type Cohort struct {
+	// Field is what the people have in common: department, costCenter, city
+	// or country.
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// The fields cohorts can be made from.
+var cohortFields = map[string]func(p Profile) string{
+	"department": func(p Profile) string { return p.Department },
+	"costCenter": func(p Profile) string { return p.CostCenter },
+	"city": func(p Profile) string {
+		if p.WorkLocation == nil {
+			return ""
+		}
+		return p.WorkLocation.City
+	},
+	"country": func(p Profile) string {
+		if p.WorkLocation == nil {
+			return ""
+		}
+		return p.WorkLocation.Country
+	},
+}
+
+// ParseCohort parses a cohort written as field:value, e.g.
+// "department:Engineering" or "city:London".
+func ParseCohort(s string) (Cohort, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[1]) == "" {
+		return Cohort{}, newValidationError([]string{fmt.Sprintf("the cohort '%s' must be written as field:value, e.g. department:Engineering", s)})
+	}
+	c := Cohort{Field: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1])}
+	if _, ok := cohortFields[c.Field]; !ok {
+		return Cohort{}, newValidationError([]string{fmt.Sprintf("the cohort field '%s' must be department, costCenter, city or country", c.Field)})
+	}
+	return c, nil
+}
+
+func (c Cohort) String() string {
+	return c.Field + ":" + c.Value
+}
+
+// Matches returns true if the person is in the cohort. Values are compared
+// without regard to case.
+func (c Cohort) Matches(p Profile) bool {
+	field, ok := cohortFields[c.Field]
+	return ok && strings.EqualFold(field(p), c.Value)
+}
+
+// A CohortComparison sets the skills of two cohorts side by side, for
+// capability reviews.
+type CohortComparison struct {
+	A CohortSummary `json:"a"`
+	B CohortSummary `json:"b"`
+	// Skills are the skills anyone in either cohort has, largest difference
+	// in coverage first.
+	Skills []CohortSkillComparison `json:"skills"`
+}
+
+// A CohortSummary is the size of a cohort, and how many skills its people
+// have.
+type CohortSummary struct {
+	Cohort Cohort `json:"cohort"`
+	People int    `json:"people"`
+	// SkillsPerPerson summarises the number of skills each person has.
+	SkillsPerPerson Summary `json:"skillsPerPerson"`
+}
+
+// A CohortSkillComparison compares the people who have a skill in each
+// cohort.
+type CohortSkillComparison struct {
+	Skill string `json:"skill"`
+	// CoverageA and CoverageB are the share of each cohort with the skill,
+	// from 0 to 1.
+	CoverageA float64 `json:"coverageA"`
+	CoverageB float64 `json:"coverageB"`
+	// CoverageDelta is CoverageA minus CoverageB.
+	CoverageDelta float64 `json:"coverageDelta"`
+	// LevelA and LevelB summarise the levels of the people with the skill.
+	LevelA Summary `json:"levelA"`
+	LevelB Summary `json:"levelB"`
+	// LevelDelta is the difference in the mean levels, or zero if either
+	// cohort has nobody with the skill.
+	LevelDelta float64 `json:"levelDelta"`
+}
+
+// A Summary gives the statistics of a set of values.
+type Summary struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	// StandardDeviation is the population standard deviation.
+	StandardDeviation float64 `json:"standardDeviation"`
+	Min               float64 `json:"min"`
+	Max               float64 `json:"max"`
+}
+
+// Summarise returns the statistics of the values. All of them are zero if
+// there are no values.
+func Summarise(values []float64) Summary {
+	if len(values) == 0 {
+		return Summary{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	s := Summary{Count: len(sorted), Min: sorted[0], Max: sorted[len(sorted)-1]}
+	for _, v := range sorted {
+		s.Mean += v
+	}
+	s.Mean /= float64(s.Count)
+	if s.Count%2 == 1 {
+		s.Median = sorted[s.Count/2]
+	} else {
+		s.Median = (sorted[s.Count/2-1] + sorted[s.Count/2]) / 2
+	}
+	var squares float64
+	for _, v := range sorted {
+		squares += (v - s.Mean) * (v - s.Mean)
+	}
+	s.StandardDeviation = math.Sqrt(squares / float64(s.Count))
+	return s
+}
+
+// CompareCohorts compares the coverage and levels of the skills of the people
+// in each cohort.
+func CompareCohorts(profiles []Profile, a, b Cohort) CohortComparison {
+	var inA, inB []Profile
+	for _, p := range profiles {
+		if a.Matches(p) {
+			inA = append(inA, p)
+		}
+		if b.Matches(p) {
+			inB = append(inB, p)
+		}
+	}
+	levelsA, levelsB := cohortLevels(inA), cohortLevels(inB)
+
+	c := CohortComparison{
+		A:      summariseCohort(a, inA),
+		B:      summariseCohort(b, inB),
+		Skills: []CohortSkillComparison{},
+	}
+	skills := make(map[string]bool)
+	for s := range levelsA {
+		skills[s] = true
+	}
+	for s := range levelsB {
+		skills[s] = true
+	}
+	for s := range skills {
+		sc := CohortSkillComparison{
+			Skill:     s,
+			CoverageA: coverage(len(levelsA[s]), len(inA)),
+			CoverageB: coverage(len(levelsB[s]), len(inB)),
+			LevelA:    Summarise(levelsA[s]),
+			LevelB:    Summarise(levelsB[s]),
+		}
+		sc.CoverageDelta = sc.CoverageA - sc.CoverageB
+		if sc.LevelA.Count > 0 && sc.LevelB.Count > 0 {
+			sc.LevelDelta = sc.LevelA.Mean - sc.LevelB.Mean
+		}
+		c.Skills = append(c.Skills, sc)
+	}
+	sort.Slice(c.Skills, func(i, j int) bool {
+		di, dj := math.Abs(c.Skills[i].CoverageDelta), math.Abs(c.Skills[j].CoverageDelta)
+		if di != dj {
+			return di > dj
+		}
+		return c.Skills[i].Skill < c.Skills[j].Skill
+	})
+	return c
+}
+
+func summariseCohort(c Cohort, profiles []Profile) CohortSummary {
+	counts := make([]float64, len(profiles))
+	for i, p := range profiles {
+		counts[i] = float64(len(p.Skills))
+	}
+	return CohortSummary{Cohort: c, People: len(profiles), SkillsPerPerson: Summarise(counts)}
+}
+
+// cohortLevels are the levels of each skill held by the people.
+func cohortLevels(profiles []Profile) map[string][]float64 {
+	levels := make(map[string][]float64)
+	for _, p := range profiles {
+		for _, s := range p.Skills {
+			levels[s.Skill] = append(levels[s.Skill], float64(s.Level))
+		}
+	}
+	return levels
+}
+
+func coverage(people, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(people) / float64(total)
+}
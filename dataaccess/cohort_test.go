@@ -0,0 +1,64 @@
+package dataaccess
+
+import (
+	"math"
+	"testing"
+)
+
+func TestThatCohortsAreParsed(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected Cohort
+		valid    bool
+	}{
+		{"department:Engineering", Cohort{"department", "Engineering"}, true},
+		{"city: London ", Cohort{"city", "London"}, true},
+		{"city:", Cohort{}, false},
+		{"Engineering", Cohort{}, false},
+		{"salary:100", Cohort{}, false},
+	}
+	for _, test := range tests {
+		c, err := ParseCohort(test.s)
+		if (err == nil) != test.valid || c != test.expected {
+			t.Errorf("For '%s', expected %v valid %t, but got %v with error %v", test.s, test.expected, test.valid, c, err)
+		}
+	}
+}
+
+func TestThatValuesAreSummarised(t *testing.T) {
+	s := Summarise([]float64{4, 2, 5, 1})
+	if s.Count != 4 || s.Mean != 3 || s.Median != 3 || s.Min != 1 || s.Max != 5 {
+		t.Errorf("Unexpected summary %+v", s)
+	}
+	if math.Abs(s.StandardDeviation-math.Sqrt(2.5)) > 1e-9 {
+		t.Errorf("Expected a standard deviation of %f, but got %f", math.Sqrt(2.5), s.StandardDeviation)
+	}
+	if (Summarise(nil) != Summary{}) {
+		t.Errorf("Expected an empty summary for no values")
+	}
+}
+
+func TestThatCohortsCanBeCompared(t *testing.T) {
+	profiles := []Profile{
+		{EmailAddress: "a@github.com", Department: "Engineering", Skills: []Skill{{Skill: "go", Level: 4}, {Skill: "sql", Level: 2}}},
+		{EmailAddress: "b@github.com", Department: "engineering", Skills: []Skill{{Skill: "go", Level: 2}}},
+		{EmailAddress: "c@github.com", Department: "Product", Skills: []Skill{{Skill: "sql", Level: 5}, {Skill: "go", Level: 1}}},
+		{EmailAddress: "d@github.com", Department: "Product"},
+		{EmailAddress: "e@github.com", Department: "Sales", Skills: []Skill{{Skill: "negotiation", Level: 5}}},
+	}
+
+	c := CompareCohorts(profiles, Cohort{"department", "Engineering"}, Cohort{"department", "Product"})
+
+	if c.A.People != 2 || c.B.People != 2 || c.A.SkillsPerPerson.Mean != 1.5 || c.B.SkillsPerPerson.Mean != 1 {
+		t.Errorf("Unexpected cohort summaries %+v and %+v", c.A, c.B)
+	}
+	if len(c.Skills) != 2 || c.Skills[0].Skill != "go" || c.Skills[1].Skill != "sql" {
+		t.Fatalf("Expected go, then sql, but got %+v", c.Skills)
+	}
+	if g := c.Skills[0]; g.CoverageA != 1 || g.CoverageB != 0.5 || g.CoverageDelta != 0.5 || g.LevelA.Mean != 3 || g.LevelDelta != 2 {
+		t.Errorf("Unexpected comparison of go %+v", g)
+	}
+	if s := c.Skills[1]; s.CoverageDelta != 0 || s.LevelDelta != -3 {
+		t.Errorf("Unexpected comparison of sql %+v", s)
+	}
+}
@@ -0,0 +1,85 @@
+package dataaccess
+
+import "sort"
+
+// A ProfileComparison sets two people's skills side by side, to help choose
+// between candidates when staffing work.
+type ProfileComparison struct {
+	A ComparedProfile `json:"a"`
+	B ComparedProfile `json:"b"`
+	// Shared lists the skills both people have, in alphabetical order.
+	Shared []SkillComparison `json:"shared"`
+}
+
+// A ComparedProfile is one of the people in a comparison.
+type ComparedProfile struct {
+	EmailAddress string    `json:"emailAddress"`
+	Name         string    `json:"name,omitempty"`
+	Availability RagStatus `json:"availability"`
+	// Unique lists the skills only this person has, highest level first.
+	Unique []Skill `json:"unique"`
+}
+
+// A SkillComparison is a skill both people have.
+type SkillComparison struct {
+	Skill  string       `json:"skill"`
+	LevelA DreyfusLevel `json:"levelA"`
+	LevelB DreyfusLevel `json:"levelB"`
+	// Delta is LevelA minus LevelB, so it's positive when A has the higher
+	// level.
+	Delta     int         `json:"delta"`
+	InterestA LikertScale `json:"interestA"`
+	InterestB LikertScale `json:"interestB"`
+}
+
+// CompareProfiles finds the skills the people share, the difference in their
+// levels, and the skills unique to each of them.
+func CompareProfiles(a, b Profile) ProfileComparison {
+	c := ProfileComparison{
+		A:      ComparedProfile{EmailAddress: a.EmailAddress, Name: a.Name, Availability: a.Availability, Unique: []Skill{}},
+		B:      ComparedProfile{EmailAddress: b.EmailAddress, Name: b.Name, Availability: b.Availability, Unique: []Skill{}},
+		Shared: []SkillComparison{},
+	}
+
+	bSkills := make(map[string]Skill, len(b.Skills))
+	for _, s := range b.Skills {
+		bSkills[s.Skill] = s
+	}
+	aSkills := make(map[string]bool, len(a.Skills))
+
+	for _, sa := range a.Skills {
+		aSkills[sa.Skill] = true
+		sb, ok := bSkills[sa.Skill]
+		if !ok {
+			c.A.Unique = append(c.A.Unique, sa)
+			continue
+		}
+		c.Shared = append(c.Shared, SkillComparison{
+			Skill:     sa.Skill,
+			LevelA:    sa.Level,
+			LevelB:    sb.Level,
+			Delta:     int(sa.Level) - int(sb.Level),
+			InterestA: sa.Interest,
+			InterestB: sb.Interest,
+		})
+	}
+	for _, sb := range b.Skills {
+		if !aSkills[sb.Skill] {
+			c.B.Unique = append(c.B.Unique, sb)
+		}
+	}
+
+	sort.Slice(c.Shared, func(i, j int) bool { return c.Shared[i].Skill < c.Shared[j].Skill })
+	sortByLevel(c.A.Unique)
+	sortByLevel(c.B.Unique)
+	return c
+}
+
+func sortByLevel(skills []Skill) {
+	sort.SliceStable(skills, func(i, j int) bool {
+		if skills[i].Level != skills[j].Level {
+			return skills[i].Level > skills[j].Level
+		}
+		return skills[i].Skill < skills[j].Skill
+	})
+}
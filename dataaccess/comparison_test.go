@@ -0,0 +1,23 @@
+package dataaccess
+
+import "testing"
+
+func TestThatProfilesCanBeCompared(t *testing.T) {
+	a := Profile{EmailAddress: "a@github.com", Skills: []Skill{{Skill: "go", Level: 4}, {Skill: "sql", Level: 2}, {Skill: "java", Level: 3}, {Skill: "c", Level: 3}}}
+	b := Profile{EmailAddress: "b@github.com", Skills: []Skill{{Skill: "sql", Level: 5}, {Skill: "go", Level: 1}, {Skill: "rust", Level: 2}}}
+
+	c := CompareProfiles(a, b)
+
+	if len(c.Shared) != 2 || c.Shared[0].Skill != "go" || c.Shared[1].Skill != "sql" {
+		t.Fatalf("Expected go and sql to be shared, but received %v", c.Shared)
+	}
+	if c.Shared[0].Delta != 3 || c.Shared[1].Delta != -3 {
+		t.Errorf("Expected deltas of 3 and -3, but were %d and %d.", c.Shared[0].Delta, c.Shared[1].Delta)
+	}
+	if len(c.A.Unique) != 2 || c.A.Unique[0].Skill != "c" || c.A.Unique[1].Skill != "java" {
+		t.Errorf("Expected c and java to be unique to A, but received %v", c.A.Unique)
+	}
+	if len(c.B.Unique) != 1 || c.B.Unique[0].Skill != "rust" {
+		t.Errorf("Expected rust to be unique to B, but received %v", c.B.Unique)
+	}
+}
@@ -1,23 +1,189 @@
 package dataaccess
 
-import "math/rand"
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"github.com/a-h/pill/encryption"
+)
+
+// ConfigurationSchemaVersion is the version of the configuration document
+// written by this release. Documents with an earlier version are upgraded
+// when they are read.
+const ConfigurationSchemaVersion = 1
 
 // Configuration retrieves the configuration for the application.
 type Configuration struct {
-	ID                   string `bson:"_id" json:"id"`
-	SessionEncryptionKey []byte `json:"sessionEncryptionKey"`
+	ID string `bson:"_id" json:"id"`
+	// SchemaVersion is the version of the document format.
+	SchemaVersion        int    `json:"schemaVersion"`
+	SessionEncryptionKey []byte `bson:"sessionencryptionkey,omitempty" json:"sessionEncryptionKey"`
+	// EncryptedSessionEncryptionKey is stored instead of the plaintext key
+	// when a master key is configured.
+	EncryptedSessionEncryptionKey *encryption.EncryptedValue `bson:",omitempty" json:"-"`
+	// PreviousSessionEncryptionKeys are the keys replaced by rotation, most
+	// recent first. Sessions issued with these keys remain valid.
+	PreviousSessionEncryptionKeys          [][]byte                    `bson:",omitempty" json:"-"`
+	EncryptedPreviousSessionEncryptionKeys []encryption.EncryptedValue `bson:",omitempty" json:"-"`
 	// SetSecureFlag sets whether cookies should be issued with the secure flag set.
 	// When the secure flag is set, cookies cannot be transmitted over HTTP.
 	// SSL must already be in place before this option is set.
 	SetSecureFlag bool `json:"setSecureFlag"`
+	// FeatureFlags turns optional features on and off at runtime.
+	FeatureFlags map[string]bool `json:"featureFlags"`
+	// Administrators lists the email addresses of users who can change the
+	// configuration.
+	Administrators []string `json:"administrators"`
+	// SecurityOfficers lists the email addresses of users who look after
+	// people's security clearances.
+	SecurityOfficers []string `json:"securityOfficers,omitempty"`
+	// Settings override the default settings for all tenants.
+	Settings SettingsOverrides `json:"settings"`
+	// RevokedSessions end the sessions people started before a time.
+	RevokedSessions []RevokedSession `bson:",omitempty" json:"revokedSessions,omitempty"`
 }
 
 // NewConfiguration creates a new configuration file.
 func NewConfiguration(sessionEncryptionKey []byte) *Configuration {
 	return &Configuration{
 		ID:                   "configuration",
+		SchemaVersion:        ConfigurationSchemaVersion,
 		SessionEncryptionKey: sessionEncryptionKey,
+		FeatureFlags:         map[string]bool{},
+	}
+}
+
+// A ValidationError lists the problems found when validating a value.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e ValidationError) Error() string {
+	return "dataaccess: validation failed: " + strings.Join(e.Problems, ", ")
+}
+
+func newValidationError(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return ValidationError{problems}
+}
+
+// Validate checks that the configuration can be used by the application.
+func (c Configuration) Validate() error {
+	var problems []string
+
+	if c.ID != "configuration" {
+		problems = append(problems, "the id must be 'configuration'")
+	}
+
+	if len(c.SessionEncryptionKey) != 32 && len(c.SessionEncryptionKey) != 64 {
+		problems = append(problems, fmt.Sprintf("the session encryption key must be 32 or 64 bytes long, but was %d bytes", len(c.SessionEncryptionKey)))
+	}
+
+	for idx, key := range c.PreviousSessionEncryptionKeys {
+		if len(key) != 32 && len(key) != 64 {
+			problems = append(problems, fmt.Sprintf("the previous session encryption key %d must be 32 or 64 bytes long", idx))
+		}
+	}
+
+	for name := range c.FeatureFlags {
+		if name == "" || strings.ContainsAny(name, ".$") {
+			problems = append(problems, fmt.Sprintf("the feature flag name '%s' is invalid", name))
+		}
 	}
+
+	problems = append(problems, c.Settings.problems()...)
+
+	return newValidationError(problems)
+}
+
+// configurationUpgrades convert a configuration document from the version
+// of its index to the next version.
+var configurationUpgrades = []func(c *Configuration){
+	// 0 to 1: documents created before feature flags were introduced.
+	func(c *Configuration) {
+		if c.FeatureFlags == nil {
+			c.FeatureFlags = map[string]bool{}
+		}
+	},
+}
+
+// UpgradeConfiguration applies the upgrades required to bring the
+// configuration up to the current schema version. It returns true if any
+// upgrades were applied.
+func UpgradeConfiguration(c *Configuration) (upgraded bool, err error) {
+	if c.SchemaVersion > ConfigurationSchemaVersion {
+		return false, fmt.Errorf("dataaccess: the configuration schema version %d is newer than this release supports (%d)", c.SchemaVersion, ConfigurationSchemaVersion)
+	}
+
+	for c.SchemaVersion < ConfigurationSchemaVersion {
+		configurationUpgrades[c.SchemaVersion](c)
+		c.SchemaVersion++
+		upgraded = true
+	}
+
+	return upgraded, nil
+}
+
+// IsEnabled returns true if the named feature flag is switched on.
+func (c Configuration) IsEnabled(feature string) bool {
+	return c.FeatureFlags[feature]
+}
+
+// IsAdministrator returns true if the email address is in the list of
+// administrators.
+func (c Configuration) IsAdministrator(emailAddress string) bool {
+	for _, a := range c.Administrators {
+		if strings.EqualFold(a, emailAddress) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaximumPreviousSessionEncryptionKeys is the number of previous session
+// encryption keys kept after rotation.
+const MaximumPreviousSessionEncryptionKeys = 2
+
+// rotateSessionEncryptionKey replaces the session encryption key with a new
+// key, keeping the replaced key as a previous key.
+func (c *Configuration) rotateSessionEncryptionKey() {
+	previous := append([][]byte{c.SessionEncryptionKey}, c.PreviousSessionEncryptionKeys...)
+	if len(previous) > MaximumPreviousSessionEncryptionKeys {
+		previous = previous[:MaximumPreviousSessionEncryptionKeys]
+	}
+
+	c.PreviousSessionEncryptionKeys = previous
+	c.SessionEncryptionKey = createSessionEncryptionKey()
+}
+
+const (
+	// UserRole is held by every user.
+	UserRole = "user"
+	// AdministratorRole is held by users listed as administrators.
+	AdministratorRole = "administrator"
+	// SecurityOfficerRole is held by users listed as security officers.
+	SecurityOfficerRole = "securityofficer"
+)
+
+// Roles returns the roles held by the user with the email address.
+func (c Configuration) Roles(emailAddress string) []string {
+	roles := []string{UserRole}
+
+	if c.IsAdministrator(emailAddress) {
+		roles = append(roles, AdministratorRole)
+	}
+
+	for _, o := range c.SecurityOfficers {
+		if strings.EqualFold(o, emailAddress) {
+			roles = append(roles, SecurityOfficerRole)
+			break
+		}
+	}
+
+	return roles
 }
 
 func createSessionEncryptionKey() []byte {
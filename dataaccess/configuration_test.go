@@ -0,0 +1,154 @@
+package dataaccess
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/encryption"
+)
+
+func TestThatConfigurationIsValidated(t *testing.T) {
+	invalidScale := DreyfusLevel(7)
+	negative := -1
+
+	cases := []struct {
+		name          string
+		configuration Configuration
+		expectedValid bool
+	}{
+		{"new configuration", *NewConfiguration(createSessionEncryptionKey()), true},
+		{"short key", *NewConfiguration([]byte("short")), false},
+		{"missing key", *NewConfiguration(nil), false},
+		{"wrong id", Configuration{ID: "other", SessionEncryptionKey: createSessionEncryptionKey()}, false},
+		{"invalid scale", Configuration{ID: "configuration", SessionEncryptionKey: createSessionEncryptionKey(),
+			Settings: SettingsOverrides{LevelScale: &invalidScale}}, false},
+		{"negative retention", Configuration{ID: "configuration", SessionEncryptionKey: createSessionEncryptionKey(),
+			Settings: SettingsOverrides{RetentionDays: &negative}}, false},
+		{"invalid feature flag", Configuration{ID: "configuration", SessionEncryptionKey: createSessionEncryptionKey(),
+			FeatureFlags: map[string]bool{"a.b": true}}, false},
+	}
+
+	for _, c := range cases {
+		err := c.configuration.Validate()
+
+		if (err == nil) != c.expectedValid {
+			t.Errorf("For %s, expected valid to be %t, but the error was %v", c.name, c.expectedValid, err)
+		}
+	}
+}
+
+func TestThatLegacyConfigurationIsUpgraded(t *testing.T) {
+	c := &Configuration{ID: "configuration", SessionEncryptionKey: createSessionEncryptionKey()}
+
+	upgraded, err := UpgradeConfiguration(c)
+
+	if err != nil || !upgraded {
+		t.Fatal("The configuration should have been upgraded.", err)
+	}
+
+	if c.SchemaVersion != ConfigurationSchemaVersion {
+		t.Errorf("Expected schema version %d, but was %d", ConfigurationSchemaVersion, c.SchemaVersion)
+	}
+
+	if c.FeatureFlags == nil {
+		t.Error("The upgrade should initialise the feature flags.")
+	}
+
+	upgraded, _ = UpgradeConfiguration(c)
+
+	if upgraded {
+		t.Error("A configuration at the current version should not be upgraded again.")
+	}
+}
+
+func TestThatConfigurationFromANewerReleaseIsRejected(t *testing.T) {
+	c := &Configuration{SchemaVersion: ConfigurationSchemaVersion + 1}
+
+	if _, err := UpgradeConfiguration(c); err == nil {
+		t.Error("A configuration with a newer schema version should not be used.")
+	}
+}
+
+func TestThatThereIsAnUpgradeForEachSchemaVersion(t *testing.T) {
+	if len(configurationUpgrades) != ConfigurationSchemaVersion {
+		t.Errorf("Expected %d configuration upgrades, but found %d.", ConfigurationSchemaVersion, len(configurationUpgrades))
+	}
+}
+
+func TestThatTheSessionKeyIsEncryptedWhenAMasterKeyIsConfigured(t *testing.T) {
+	kp, _ := encryption.NewLocalKeyProvider(createSessionEncryptionKey())
+	da := MongoDataAccess{keyProvider: kp}
+
+	original := *NewConfiguration(createSessionEncryptionKey())
+	original.rotateSessionEncryptionKey()
+
+	stored, err := da.encryptConfiguration(original)
+	if err != nil {
+		t.Fatal("Failed to encrypt the configuration.", err)
+	}
+
+	if stored.SessionEncryptionKey != nil || stored.EncryptedSessionEncryptionKey == nil {
+		t.Fatal("Only the encrypted session key should be stored.")
+	}
+
+	if stored.PreviousSessionEncryptionKeys != nil || len(stored.EncryptedPreviousSessionEncryptionKeys) != 1 {
+		t.Fatal("Only the encrypted previous session keys should be stored.")
+	}
+
+	if err = da.decryptConfiguration(&stored); err != nil {
+		t.Fatal("Failed to decrypt the configuration.", err)
+	}
+
+	if !reflect.DeepEqual(stored.SessionEncryptionKey, original.SessionEncryptionKey) {
+		t.Error("The decrypted session key should match the original.")
+	}
+
+	if !reflect.DeepEqual(stored.PreviousSessionEncryptionKeys, original.PreviousSessionEncryptionKeys) {
+		t.Error("The decrypted previous session keys should match the originals.")
+	}
+
+	if err = (MongoDataAccess{}).decryptConfiguration(&stored); err == nil {
+		t.Error("An encrypted configuration should not be readable without the master key.")
+	}
+}
+
+func TestThatRotationKeepsALimitedNumberOfPreviousKeys(t *testing.T) {
+	c := NewConfiguration(createSessionEncryptionKey())
+	original := c.SessionEncryptionKey
+
+	for i := 0; i < MaximumPreviousSessionEncryptionKeys+1; i++ {
+		c.rotateSessionEncryptionKey()
+	}
+
+	if reflect.DeepEqual(c.SessionEncryptionKey, original) {
+		t.Error("Rotation should create a new session encryption key.")
+	}
+
+	if len(c.PreviousSessionEncryptionKeys) != MaximumPreviousSessionEncryptionKeys {
+		t.Errorf("Expected %d previous keys, but found %d.", MaximumPreviousSessionEncryptionKeys, len(c.PreviousSessionEncryptionKeys))
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Error("The rotated configuration should be valid.", err)
+	}
+}
+
+func TestThatTheLatestRevocationOfEachPersonsSessionsIsUsed(t *testing.T) {
+	earlier := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+	c := Configuration{RevokedSessions: []RevokedSession{
+		{EmailAddress: "a-h@github.com", Revoked: later},
+		{EmailAddress: "a-h@github.com", Revoked: earlier},
+		{EmailAddress: "other@github.com", Revoked: earlier},
+	}}
+
+	times := c.RevocationTimes()
+
+	if !times["a-h@github.com"].Equal(later) {
+		t.Errorf("Expected the latest revocation, %v, but was %v", later, times["a-h@github.com"])
+	}
+	if !times["other@github.com"].Equal(earlier) {
+		t.Errorf("Expected %v, but was %v", earlier, times["other@github.com"])
+	}
+}
@@ -0,0 +1,113 @@
+package dataaccess
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ConsentAnalytics is the purpose of processing people's data in analytics,
+// e.g. exports to analytics tools and the skills heatmap. People who haven't
+// consented are left out.
+const ConsentAnalytics = "analytics"
+
+// A Consent records a person giving or withdrawing their consent to their
+// data being processed for a purpose.
+type Consent struct {
+	Purpose string    `json:"purpose"`
+	Given   bool      `json:"given"`
+	Time    time.Time `json:"time"`
+	// NoticeVersion is the version of the privacy notice the person was
+	// shown.
+	NoticeVersion string `json:"noticeVersion"`
+}
+
+// ConsentSettings set the tenant's privacy notice, and whether people must
+// consent before their data is processed.
+type ConsentSettings struct {
+	// Required leaves people out of processing until they've consented to
+	// the current version of the notice. If it's false, people are included
+	// unless they've withdrawn their consent.
+	Required bool `json:"required"`
+	// NoticeVersion is the current version of the privacy notice. People
+	// are asked to consent again when it changes.
+	NoticeVersion string `json:"noticeVersion,omitempty"`
+	// NoticeURL is the address of the privacy notice, linked to from the
+	// consent banner.
+	NoticeURL string `json:"noticeURL,omitempty"`
+	// Purposes are what people are asked to consent to.
+	Purposes []string `json:"purposes"`
+}
+
+func (s ConsentSettings) problems() []string {
+	var problems []string
+	if s.Required && s.NoticeVersion == "" {
+		problems = append(problems, "the privacy notice version is required when consent is required")
+	}
+	if s.NoticeURL != "" && !strings.HasPrefix(s.NoticeURL, "https://") {
+		problems = append(problems, "the privacy notice URL must start with https://")
+	}
+	for _, p := range s.Purposes {
+		if strings.TrimSpace(p) == "" {
+			problems = append(problems, "the consent purposes must not be empty")
+			break
+		}
+	}
+	return problems
+}
+
+// LatestConsent returns the person's most recent consent for the purpose, or
+// false if they've never been asked.
+func (p Profile) LatestConsent(purpose string) (Consent, bool) {
+	for i := len(p.Consents) - 1; i >= 0; i-- {
+		if p.Consents[i].Purpose == purpose {
+			return p.Consents[i], true
+		}
+	}
+	return Consent{}, false
+}
+
+// Consented returns true if the person's data can be processed for the
+// purpose under the tenant's consent settings. Withdrawn consent is always
+// respected.
+func (p Profile) Consented(purpose string, s ConsentSettings) bool {
+	c, ok := p.LatestConsent(purpose)
+	if ok && !c.Given {
+		return false
+	}
+	if !s.Required {
+		return true
+	}
+	return ok && c.NoticeVersion == s.NoticeVersion
+}
+
+// Consenting returns the profiles which can be processed for the purpose.
+func Consenting(profiles []Profile, purpose string, s ConsentSettings) []Profile {
+	var op []Profile
+	for _, p := range profiles {
+		if p.Consented(purpose, s) {
+			op = append(op, p)
+		}
+	}
+	return op
+}
+
+// RecordConsents adds the consents to the person's consent records.
+func (da MongoDataAccess) RecordConsents(emailAddress string, consents []Consent) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	utc := make([]Consent, len(consents))
+	for i, c := range consents {
+		c.Time = c.Time.UTC()
+		utc[i] = c
+	}
+	update := bson.M{"$push": bson.M{"consents": bson.M{"$each": utc}}}
+	return session.DB(da.databaseName).C("profiles").UpdateId(strings.ToLower(emailAddress), update)
+}
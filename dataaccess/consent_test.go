@@ -0,0 +1,32 @@
+package dataaccess
+
+import "testing"
+
+func TestThatConsentDependsOnTheSettingsAndLatestChoice(t *testing.T) {
+	given := Consent{Purpose: ConsentAnalytics, Given: true, NoticeVersion: "1"}
+	withdrawn := Consent{Purpose: ConsentAnalytics, Given: false, NoticeVersion: "1"}
+	optional := ConsentSettings{NoticeVersion: "1"}
+	required := ConsentSettings{Required: true, NoticeVersion: "1"}
+	changed := ConsentSettings{Required: true, NoticeVersion: "2"}
+
+	tests := []struct {
+		consents []Consent
+		settings ConsentSettings
+		expected bool
+	}{
+		{nil, optional, true},
+		{nil, required, false},
+		{[]Consent{given}, required, true},
+		{[]Consent{given}, changed, false},
+		{[]Consent{given, withdrawn}, optional, false},
+		{[]Consent{withdrawn, given}, required, true},
+		{[]Consent{{Purpose: "marketing", Given: true, NoticeVersion: "1"}}, required, false},
+	}
+
+	for i, test := range tests {
+		p := Profile{Consents: test.consents}
+		if actual := p.Consented(ConsentAnalytics, test.settings); actual != test.expected {
+			t.Errorf("For test %d, expected %v, but got %v", i, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package dataaccess
+
+import (
+	"context"
+
+	"github.com/a-h/pill/caller"
+)
+
+// A ContextualDataAccess is a DataAccess which can act on behalf of the
+// caller carried by a request's context, e.g. to authorize or audit calls.
+type ContextualDataAccess interface {
+	DataAccess
+	WithContext(ctx context.Context) DataAccess
+}
+
+// WithContext returns a DataAccess which acts on behalf of the context's
+// caller. DataAccess implementations which don't use the caller are returned
+// unchanged.
+func WithContext(da DataAccess, ctx context.Context) DataAccess {
+	if cda, ok := da.(ContextualDataAccess); ok {
+		return cda.WithContext(ctx)
+	}
+	return da
+}
+
+// Caller returns the identity of the user with the email address.
+func (c Configuration) Caller(emailAddress string) caller.Caller {
+	return caller.Caller{
+		EmailAddress: emailAddress,
+		Roles:        c.Roles(emailAddress),
+		Tenant:       GetDomain(emailAddress),
+	}
+}
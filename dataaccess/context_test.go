@@ -0,0 +1,38 @@
+package dataaccess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+)
+
+type contextualStubDataAccess struct {
+	stubDataAccess
+	ctx context.Context
+}
+
+func (da contextualStubDataAccess) WithContext(ctx context.Context) DataAccess {
+	return contextualStubDataAccess{da.stubDataAccess, ctx}
+}
+
+func TestThatTheContextIsPassedThroughDecorators(t *testing.T) {
+	ctx := caller.NewContext(context.Background(), Configuration{}.Caller("a-h@github.com"))
+
+	da := WithContext(NewNotifyingDataAccess(contextualStubDataAccess{}, &recordingPublisher{}), ctx)
+
+	inner := da.(*NotifyingDataAccess).DataAccess.(contextualStubDataAccess)
+	c, ok := caller.FromContext(inner.ctx)
+
+	if !ok || c.Tenant != "github.com" {
+		t.Errorf("Expected the wrapped DataAccess to receive the caller, but received %v", c)
+	}
+}
+
+func TestThatDataAccessWhichDoesNotUseTheContextIsUnchanged(t *testing.T) {
+	da := stubDataAccess{}
+
+	if WithContext(da, context.Background()) != da {
+		t.Error("The DataAccess should be returned unchanged.")
+	}
+}
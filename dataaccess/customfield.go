@@ -0,0 +1,271 @@
+package dataaccess
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A CustomFieldType is the kind of value a custom field holds.
+type CustomFieldType string
+
+// The types of custom field.
+const (
+	TextField    CustomFieldType = "text"
+	NumberField  CustomFieldType = "number"
+	DateField    CustomFieldType = "date"
+	BooleanField CustomFieldType = "boolean"
+	// ChoiceField values are one of the field's choices.
+	ChoiceField CustomFieldType = "choice"
+	// ListField values are any number of the field's choices, or of any text
+	// if it doesn't have choices.
+	ListField CustomFieldType = "list"
+)
+
+// A CustomFieldVisibility is who can see a custom field, other than the
+// person whose profile it is.
+type CustomFieldVisibility string
+
+// The visibilities of custom fields, from the widest to the narrowest.
+const (
+	VisibleToEveryone CustomFieldVisibility = "everyone"
+	// VisibleToManagers fields can be seen by the person's managers and
+	// administrators.
+	VisibleToManagers CustomFieldVisibility = "managers"
+	// VisibleToAdministrators fields can only be seen by administrators.
+	VisibleToAdministrators CustomFieldVisibility = "administrators"
+)
+
+var visibilityRanks = map[CustomFieldVisibility]int{
+	VisibleToEveryone:       0,
+	VisibleToManagers:       1,
+	VisibleToAdministrators: 2,
+}
+
+// A CustomField is an extra field on the profiles of a tenant, e.g. security
+// clearance, certifications or visa status.
+type CustomField struct {
+	// Name identifies the field's values on profiles, e.g. "clearance".
+	Name     string          `json:"name"`
+	Label    string          `json:"label"`
+	Type     CustomFieldType `json:"type"`
+	Required bool            `json:"required"`
+	// Choices are the allowed values of choice and list fields.
+	Choices []string `json:"choices,omitempty"`
+	// Pattern is a regular expression text fields must match in full.
+	Pattern string `json:"pattern,omitempty"`
+	// Min and Max limit the values of number fields.
+	Min        *float64              `json:"min,omitempty"`
+	Max        *float64              `json:"max,omitempty"`
+	Visibility CustomFieldVisibility `json:"visibility"`
+	// AdministratorsOnly fields can only be changed by administrators,
+	// e.g. because they're checked by HR.
+	AdministratorsOnly bool `json:"administratorsOnly"`
+}
+
+var customFieldName = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+
+func (f CustomField) problems() []string {
+	var problems []string
+	if !customFieldName.MatchString(f.Name) {
+		problems = append(problems, fmt.Sprintf("the custom field name '%s' must be letters and digits, starting with a lowercase letter", f.Name))
+	}
+	switch f.Type {
+	case TextField, NumberField, DateField, BooleanField, ListField:
+	case ChoiceField:
+		if len(f.Choices) == 0 {
+			problems = append(problems, "the custom field "+f.Name+" must have choices")
+		}
+	default:
+		problems = append(problems, "the type of the custom field "+f.Name+" must be text, number, date, boolean, choice or list")
+	}
+	if _, err := regexp.Compile(f.Pattern); err != nil {
+		problems = append(problems, "the pattern of the custom field "+f.Name+" must be a regular expression")
+	}
+	if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+		problems = append(problems, "the minimum of the custom field "+f.Name+" must not be more than the maximum")
+	}
+	if _, ok := visibilityRanks[f.Visibility]; !ok {
+		problems = append(problems, "the visibility of the custom field "+f.Name+" must be everyone, managers or administrators")
+	}
+	return problems
+}
+
+// VisibleTo returns true if the field can be seen by people with the access,
+// e.g. a manager can see fields which are visible to everyone or managers.
+func (f CustomField) VisibleTo(access CustomFieldVisibility) bool {
+	return visibilityRanks[f.Visibility] <= visibilityRanks[access]
+}
+
+// A CustomFieldValue is the value of a custom field on a profile. Only the
+// member for the field's type is set, so that values are stored with their
+// own types, and can be queried as such.
+type CustomFieldValue struct {
+	Type    CustomFieldType `json:"type"`
+	Text    string          `json:"text,omitempty" bson:",omitempty"`
+	Number  *float64        `json:"number,omitempty" bson:",omitempty"`
+	Date    time.Time       `json:"date,omitempty" bson:",omitempty"`
+	Boolean *bool           `json:"boolean,omitempty" bson:",omitempty"`
+	List    []string        `json:"list,omitempty" bson:",omitempty"`
+}
+
+// Parse reads a value of the field from JSON, e.g. "SC", 3, "2018-09-30",
+// true or ["de","fr"]. Dates are days, in the form 2006-01-02.
+func (f CustomField) Parse(raw json.RawMessage) (CustomFieldValue, error) {
+	v := CustomFieldValue{Type: f.Type}
+	invalid := func(expected string) error {
+		return newValidationError([]string{fmt.Sprintf("the custom field %s must be %s, but was %s", f.Name, expected, raw)})
+	}
+	switch f.Type {
+	case TextField, ChoiceField:
+		if err := json.Unmarshal(raw, &v.Text); err != nil {
+			return v, invalid("text")
+		}
+		v.Text = strings.TrimSpace(v.Text)
+		if f.Type == ChoiceField && !contains(f.Choices, v.Text) {
+			return v, invalid("one of " + strings.Join(f.Choices, ", "))
+		}
+		if f.Pattern != "" && !regexp.MustCompile("^(?:"+f.Pattern+")$").MatchString(v.Text) {
+			return v, invalid("in the form " + f.Pattern)
+		}
+	case NumberField:
+		var n float64
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return v, invalid("a number")
+		}
+		if (f.Min != nil && n < *f.Min) || (f.Max != nil && n > *f.Max) {
+			return v, invalid("within its limits")
+		}
+		v.Number = &n
+	case DateField:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return v, invalid("a date")
+		}
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return v, invalid("a date, such as 2018-09-30")
+		}
+		v.Date = d
+	case BooleanField:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return v, invalid("true or false")
+		}
+		v.Boolean = &b
+	case ListField:
+		var items []string
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return v, invalid("a list")
+		}
+		for _, item := range items {
+			item = strings.TrimSpace(item)
+			if item == "" || contains(v.List, item) {
+				continue
+			}
+			if len(f.Choices) > 0 && !contains(f.Choices, item) {
+				return v, invalid("some of " + strings.Join(f.Choices, ", "))
+			}
+			v.List = append(v.List, item)
+		}
+	}
+	return v, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty returns true if the value hasn't been filled in.
+func (v CustomFieldValue) Empty() bool {
+	return v.Text == "" && v.Number == nil && v.Date.IsZero() && v.Boolean == nil && len(v.List) == 0
+}
+
+// A CustomFieldSchema is the custom fields of a tenant.
+type CustomFieldSchema []CustomField
+
+func (s CustomFieldSchema) problems() []string {
+	var problems []string
+	names := make(map[string]bool)
+	for _, f := range s {
+		problems = append(problems, f.problems()...)
+		if names[f.Name] {
+			problems = append(problems, "the custom field "+f.Name+" is defined more than once")
+		}
+		names[f.Name] = true
+	}
+	return problems
+}
+
+// Field returns the custom field with the name.
+func (s CustomFieldSchema) Field(name string) (CustomField, bool) {
+	for _, f := range s {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return CustomField{}, false
+}
+
+// Check returns an error if the values don't have one for each required
+// field, or are for fields which aren't in the schema, or are of the wrong
+// type.
+func (s CustomFieldSchema) Check(values map[string]CustomFieldValue) error {
+	var problems []string
+	for name, v := range values {
+		f, ok := s.Field(name)
+		if !ok {
+			problems = append(problems, "there isn't a custom field called "+name)
+			continue
+		}
+		if v.Type != f.Type {
+			problems = append(problems, "the custom field "+name+" must be "+string(f.Type))
+		}
+	}
+	for _, f := range s {
+		if v, ok := values[f.Name]; f.Required && (!ok || v.Empty()) {
+			problems = append(problems, "the custom field "+f.Name+" is required")
+		}
+	}
+	return newValidationError(problems)
+}
+
+// Visible returns the values of the fields which can be seen by people with
+// the access. Values of fields which have been removed from the schema are
+// left out.
+func (s CustomFieldSchema) Visible(values map[string]CustomFieldValue, access CustomFieldVisibility) map[string]CustomFieldValue {
+	op := make(map[string]CustomFieldValue)
+	for _, f := range s {
+		if v, ok := values[f.Name]; ok && f.VisibleTo(access) {
+			op[f.Name] = v
+		}
+	}
+	return op
+}
+
+// UpdateCustomFields replaces the values of the person's custom fields.
+func (da MongoDataAccess) UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	utc := make(map[string]CustomFieldValue, len(values))
+	for name, v := range values {
+		v.Date = v.Date.UTC()
+		utc[name] = v
+	}
+	return session.DB(da.databaseName).C("profiles").UpdateId(strings.ToLower(emailAddress), bson.M{"$set": bson.M{"customfields": utc}})
+}
@@ -0,0 +1,108 @@
+package dataaccess
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestThatCustomFieldSchemasAreValidated(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   CustomFieldSchema
+		problems int
+	}{
+		{"valid", CustomFieldSchema{{Name: "clearance", Type: ChoiceField, Choices: []string{"SC", "DV"}, Visibility: VisibleToManagers}}, 0},
+		{"bad name", CustomFieldSchema{{Name: "Visa status", Type: TextField, Visibility: VisibleToEveryone}}, 1},
+		{"unknown type", CustomFieldSchema{{Name: "visa", Type: "colour", Visibility: VisibleToEveryone}}, 1},
+		{"choice without choices", CustomFieldSchema{{Name: "clearance", Type: ChoiceField, Visibility: VisibleToEveryone}}, 1},
+		{"bad pattern", CustomFieldSchema{{Name: "visa", Type: TextField, Pattern: "(", Visibility: VisibleToEveryone}}, 1},
+		{"no visibility", CustomFieldSchema{{Name: "visa", Type: TextField}}, 1},
+		{"duplicate", CustomFieldSchema{{Name: "visa", Type: TextField, Visibility: VisibleToEveryone}, {Name: "visa", Type: DateField, Visibility: VisibleToEveryone}}, 1},
+	}
+
+	for _, test := range tests {
+		if problems := test.schema.problems(); len(problems) != test.problems {
+			t.Errorf("%s: expected %d problems, but got %v", test.name, test.problems, problems)
+		}
+	}
+}
+
+func TestThatCustomFieldValuesAreParsedWithTheirTypes(t *testing.T) {
+	min, max := 0.0, 10.0
+	tests := []struct {
+		f     CustomField
+		raw   string
+		valid bool
+		check func(v CustomFieldValue) bool
+	}{
+		{CustomField{Type: TextField, Pattern: `[A-Z]{2}\d+`}, `"AB123"`, true, func(v CustomFieldValue) bool { return v.Text == "AB123" }},
+		{CustomField{Type: TextField, Pattern: `[A-Z]{2}\d+`}, `"xAB123"`, false, nil},
+		{CustomField{Type: NumberField, Min: &min, Max: &max}, `3.5`, true, func(v CustomFieldValue) bool { return *v.Number == 3.5 }},
+		{CustomField{Type: NumberField, Min: &min, Max: &max}, `11`, false, nil},
+		{CustomField{Type: NumberField}, `"3"`, false, nil},
+		{CustomField{Type: DateField}, `"2018-09-30"`, true, func(v CustomFieldValue) bool {
+			return v.Date.Equal(time.Date(2018, time.September, 30, 0, 0, 0, 0, time.UTC))
+		}},
+		{CustomField{Type: DateField}, `"30/09/2018"`, false, nil},
+		{CustomField{Type: BooleanField}, `true`, true, func(v CustomFieldValue) bool { return *v.Boolean }},
+		{CustomField{Type: ChoiceField, Choices: []string{"SC", "DV"}}, `"DV"`, true, func(v CustomFieldValue) bool { return v.Text == "DV" }},
+		{CustomField{Type: ChoiceField, Choices: []string{"SC", "DV"}}, `"TS"`, false, nil},
+		{CustomField{Type: ListField, Choices: []string{"de", "fr"}}, `["de","fr","de"]`, true, func(v CustomFieldValue) bool { return len(v.List) == 2 }},
+		{CustomField{Type: ListField, Choices: []string{"de", "fr"}}, `["es"]`, false, nil},
+		{CustomField{Type: ListField}, `["anything"]`, true, func(v CustomFieldValue) bool { return v.List[0] == "anything" }},
+	}
+
+	for _, test := range tests {
+		v, err := test.f.Parse(json.RawMessage(test.raw))
+		if (err == nil) != test.valid {
+			t.Errorf("For a %s field, expected %s to be valid: %v, but got %v", test.f.Type, test.raw, test.valid, err)
+			continue
+		}
+		if test.valid && (v.Type != test.f.Type || !test.check(v)) {
+			t.Errorf("For a %s field, %s was parsed as %+v", test.f.Type, test.raw, v)
+		}
+	}
+}
+
+func TestThatRequiredAndUnknownCustomFieldsAreChecked(t *testing.T) {
+	schema := CustomFieldSchema{{Name: "visa", Type: TextField, Required: true, Visibility: VisibleToEveryone}}
+
+	if err := schema.Check(map[string]CustomFieldValue{}); err == nil {
+		t.Error("Expected the missing required field to be an error")
+	}
+	if err := schema.Check(map[string]CustomFieldValue{"visa": {Type: TextField, Text: "Tier 2"}, "other": {Type: TextField, Text: "x"}}); err == nil {
+		t.Error("Expected the unknown field to be an error")
+	}
+	if err := schema.Check(map[string]CustomFieldValue{"visa": {Type: TextField, Text: "Tier 2"}}); err != nil {
+		t.Errorf("Expected the values to be valid, but got %v", err)
+	}
+}
+
+func TestThatOnlyVisibleCustomFieldsAreReturned(t *testing.T) {
+	schema := CustomFieldSchema{
+		{Name: "languages", Type: ListField, Visibility: VisibleToEveryone},
+		{Name: "clearance", Type: TextField, Visibility: VisibleToManagers},
+		{Name: "visa", Type: TextField, Visibility: VisibleToAdministrators},
+	}
+	values := map[string]CustomFieldValue{
+		"languages": {Type: ListField, List: []string{"de"}},
+		"clearance": {Type: TextField, Text: "SC"},
+		"visa":      {Type: TextField, Text: "Tier 2"},
+		"removed":   {Type: TextField, Text: "old"},
+	}
+
+	tests := []struct {
+		access   CustomFieldVisibility
+		expected int
+	}{
+		{VisibleToEveryone, 1},
+		{VisibleToManagers, 2},
+		{VisibleToAdministrators, 3},
+	}
+	for _, test := range tests {
+		if visible := schema.Visible(values, test.access); len(visible) != test.expected {
+			t.Errorf("With %s access, expected %d fields, but got %v", test.access, test.expected, visible)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package dataaccess
 
 import (
+	"errors"
 	"log"
 	"strings"
 	"time"
 
+	"github.com/a-h/pill/encryption"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -12,30 +14,220 @@ import (
 // The DataAccess interface defines how data is written to the data store.
 type DataAccess interface {
 	ListProfiles(emailAddress string) ([]Profile, error)
+	GetOrgTree(domain string) (*OrgNode, error)
+	ListDomains() ([]string, error)
+	UpdateNotificationPreferences(emailAddress string, p NotificationPreferences) error
+	UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error
+	AwardBadges(emailAddress string, badges []Badge) error
+	AddBooking(emailAddress string, b Booking) error
+	RemoveBooking(emailAddress string, id string) error
 	GetProfile(emailAddress string) (*Profile, bool, error)
 	UpdateProfile(update *ProfileUpdate) (*Profile, error)
+	ImportProfile(p *Profile) error
 	DeleteProfile(emailAddress string) (bool, error)
-	ListSkillTags() ([]string, error)
+	ListSkillTags() ([]SkillTag, error)
 	AddSkillTags(tags []string) error
+	SetSkillTagDescriptors(name string, descriptors []LevelDescriptor) error
 	DeleteSkillTags(tags []string) error
+	ListDeletedSkillTags() ([]DeletedSkillTag, error)
+	RestoreSkillTags(tags []string) (restored []string, err error)
+	PurgeDeletedSkillTags(before time.Time) (int, error)
 	GetOrCreateConfiguration() (Configuration, error)
 	DeleteConfiguration() error
+	RotateSessionEncryptionKey() (Configuration, error)
+	SetFeatureFlag(name string, enabled bool) error
+	GetTenantConfiguration(domain string) (*TenantConfiguration, bool, error)
+	UpdateTenantConfiguration(tc *TenantConfiguration) error
+	DeleteTenantConfiguration(domain string) error
+	GetSettings(domain string) (Settings, error)
+	AcquireLock(name string, ttl time.Duration) (*Lock, bool, error)
+	ReleaseLock(lock *Lock) error
+	QuarantineChange(c *QuarantinedChange) error
+	ListQuarantinedChanges(domain string) ([]QuarantinedChange, error)
+	GetQuarantinedChange(id string) (*QuarantinedChange, bool, error)
+	DeleteQuarantinedChange(id string) error
+	RequestApproval(a *ApprovalRequest) error
+	ListApprovalRequests() ([]ApprovalRequest, error)
+	GetApprovalRequest(id string) (*ApprovalRequest, bool, error)
+	DeleteApprovalRequest(id string) error
+	SaveCalibration(c *Calibration) error
+	ListCalibrations(emailAddress string) ([]Calibration, error)
+	GetCalibration(id string) (*Calibration, bool, error)
+	SaveProject(p *Project) error
+	ListProjects(domain string) ([]Project, error)
+	GetProject(id string) (*Project, bool, error)
+	DeleteProject(id string) error
+	SaveCourse(c *Course) error
+	ListCourses(domain string) ([]Course, error)
+	GetCourse(id string) (*Course, bool, error)
+	DeleteCourse(id string) error
+	SaveCourseCompletion(cc *CourseCompletion) error
+	ListCourseCompletions(domain string) ([]CourseCompletion, error)
+	GetCourseCompletion(id string) (*CourseCompletion, bool, error)
+	UpdateLearningGoals(emailAddress string, goals []LearningGoal) error
+	ConfirmSkills(emailAddress string, skills []string) error
+	ImportSkillTags(tags []SkillTag) (int, error)
+	SaveTagProposal(p *TagProposal) error
+	ListTagProposals(domain string) ([]TagProposal, error)
+	GetTagProposal(id string) (*TagProposal, bool, error)
+	MergeSkillTags(into string, tags []string) (int, error)
+	UpdateCV(emailAddress string, cv *Attachment) error
+	SaveSkillSuggestions(ss *SkillSuggestions) error
+	ListSkillSuggestions(emailAddress string) ([]SkillSuggestions, error)
+	GetSkillSuggestions(id string) (*SkillSuggestions, bool, error)
+	SaveShareLink(l *ShareLink) error
+	ListShareLinks(emailAddress string) ([]ShareLink, error)
+	GetShareLink(id string) (*ShareLink, bool, error)
+	DeleteShareLink(id string) error
+	SyncEmployee(e EmployeeUpdate) error
+	ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (bool, error)
+	RestoreProfile(emailAddress string) (bool, error)
+	ListArchivedProfiles(domain string) ([]ArchivedProfile, error)
+	PurgeArchivedProfiles(before time.Time) (int, error)
+	RevokeSessions(emailAddress string, at time.Time) error
+	FindProfiles(domain string, f ProfileFilter) ([]Profile, error)
+	EnsureIndexes() error
+	UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) error
+	UpdateLanguages(emailAddress string, languages []Language) error
+	UpdateClearance(emailAddress string, cl *Clearance) error
+	UpdateWorkLocation(emailAddress string, l *WorkLocation) error
+	FindProfilesNear(domain string, near Coordinates, radiusKm float64) ([]Profile, error)
+	UpdateWorkingHours(emailAddress string, h *WorkingHours) error
+	RecordConsents(emailAddress string, consents []Consent) error
+	SaveBenchmarks(benchmarks []Benchmark) error
+	GetBenchmark(band string) (*Benchmark, bool, error)
+	SaveReportDefinition(d *ReportDefinition) error
+	ListReportDefinitions(domain string) ([]ReportDefinition, error)
+	DeleteReportDefinition(domain string, id string) (bool, error)
+	RunReport(d ReportDefinition, consent ConsentSettings) (*ReportResult, error)
+	SaveReportSnapshot(s *ReportSnapshot) error
+	GetReportSnapshot(domain string, report string) (*ReportSnapshot, bool, error)
+	SaveExportJob(j *ExportJob) error
+	GetExportJob(id string) (*ExportJob, bool, error)
+	ClaimExportJob(now time.Time) (*ExportJob, bool, error)
+	PurgeExportJobs(before time.Time) ([]ExportJob, error)
+	SaveImportPreview(p *ImportPreview) error
+	TakeImportPreview(domain string, id string) (*ImportPreview, bool, error)
+	MergeProfiles(primary string, duplicate string) (*Profile, bool, error)
+	FindProfileByExternalID(domain string, system string, id string) (*Profile, bool, error)
+	UpdateExternalIDs(emailAddress string, ids map[string]string) error
+	AppendProfileEvents(events []ProfileEvent) error
+	ListProfileEvents(emailAddress string, after int) ([]ProfileEvent, error)
+	SaveProfileSnapshot(s *ProfileSnapshot) error
+	GetProfileSnapshot(emailAddress string, at time.Time) (*ProfileSnapshot, bool, error)
+	ProjectProfile(s *ProfileSnapshot) error
+	SaveSearchDocument(d *SearchDocument) error
+	GetSearchDocument(emailAddress string) (*SearchDocument, bool, error)
+	RemoveSearchDocument(emailAddress string) error
+	SearchDocuments(domain string, q SearchQuery) ([]SearchDocument, error)
+	SaveTeamSummary(s *TeamSummary) error
+	GetTeamSummary(manager string) (*TeamSummary, bool, error)
+	SaveProfileSummary(s *ProfileSummary) error
+	GetProfileSummary(emailAddress string) (*ProfileSummary, bool, error)
+	ListProfileSummaries(domain string) ([]ProfileSummary, error)
+	RemoveProfileSummary(emailAddress string) error
 }
 
 // MongoDataAccess provides access to the data structures.
 type MongoDataAccess struct {
 	connectionString string
 	databaseName     string
+	keyProvider      encryption.KeyProvider
+	// nearest reads from the nearest member of the replica set, rather than
+	// the primary.
+	nearest bool
 }
 
 // NewMongoDataAccess creates an instance of the MongoDataAccess type.
 func NewMongoDataAccess(connectionString string, databaseName string) DataAccess {
-	return &MongoDataAccess{connectionString, databaseName}
+	return &MongoDataAccess{connectionString, databaseName, nil, false}
+}
+
+// NewReplicaMongoDataAccess creates an instance of the MongoDataAccess type
+// which reads from the member of the replica set with the lowest latency,
+// which may be a secondary in the same region, so reads may be slightly out
+// of date. Writes are still sent to the primary.
+func NewReplicaMongoDataAccess(connectionString string, databaseName string, kp encryption.KeyProvider) DataAccess {
+	return &MongoDataAccess{connectionString, databaseName, kp, true}
+}
+
+// NewEncryptedMongoDataAccess creates an instance of the MongoDataAccess type
+// which encrypts sensitive configuration values with the KeyProvider's
+// master key.
+func NewEncryptedMongoDataAccess(connectionString string, databaseName string, kp encryption.KeyProvider) DataAccess {
+	return &MongoDataAccess{connectionString, databaseName, kp, false}
+}
+
+func (da MongoDataAccess) dial() (*mgo.Session, error) {
+	session, err := mgo.Dial(da.connectionString)
+	if err == nil && da.nearest {
+		session.SetMode(mgo.Nearest, true)
+	}
+	return session, err
+}
+
+// profileIndexes are the fields profiles are looked up by, other than their
+// email address.
+var profileIndexes = []mgo.Index{
+	{Key: []string{"domain"}, Background: true},
+	{Key: []string{"domain", "department"}, Background: true},
+	{Key: []string{"domain", "costcenter"}, Background: true},
+	{Key: []string{"domain", "languages.code"}, Background: true},
+	{Key: []string{"$2dsphere:worklocation.point"}, Background: true},
+	{Key: []string{"domain", "externalids." + HRISSystem}, Background: true, Sparse: true},
+	{Key: []string{"domain", "externalids." + AzureADSystem}, Background: true, Sparse: true},
+	{Key: []string{"domain", "externalids." + SlackSystem}, Background: true, Sparse: true},
+	{Key: []string{"domain", "externalids." + GitHubSystem}, Background: true, Sparse: true},
+}
+
+// EnsureIndexes creates the indexes which are missing, in the background.
+// Existing indexes are left alone.
+func (da MongoDataAccess) EnsureIndexes() error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	for _, index := range profileIndexes {
+		if err := session.DB(da.databaseName).C("profiles").EnsureIndex(index); err != nil {
+			return err
+		}
+	}
+	// Events and snapshots are read by person, in order.
+	for _, c := range []string{"profileevents", "profilesnapshots"} {
+		index := mgo.Index{Key: []string{"emailaddress", "sequence"}, Background: true}
+		if err := session.DB(da.databaseName).C(c).EnsureIndex(index); err != nil {
+			return err
+		}
+	}
+	for _, index := range searchDocumentIndexes {
+		if err := session.DB(da.databaseName).C("searchdocuments").EnsureIndex(index); err != nil {
+			return err
+		}
+	}
+	summaries := mgo.Index{Key: []string{"domain"}, Background: true}
+	if err := session.DB(da.databaseName).C("profilesummaries").EnsureIndex(summaries); err != nil {
+		return err
+	}
+	// The scheduler keeps the lock for each run of a job until it expires, so
+	// expired locks are removed rather than left for the next run to replace.
+	locks := mgo.Index{Key: []string{"expires"}, ExpireAfter: time.Hour, Background: true}
+	if err := session.DB(da.databaseName).C("locks").EnsureIndex(locks); err != nil {
+		return err
+	}
+	// A run is recorded every time a job runs, so runs are kept for 30 days.
+	jobRuns := mgo.Index{Key: []string{"started"}, ExpireAfter: 30 * 24 * time.Hour, Background: true}
+	if err := session.DB(da.databaseName).C("jobruns").EnsureIndex(jobRuns); err != nil {
+		return err
+	}
+	return nil
 }
 
 // GetProfile returns a Profile by the email address of the person.
 func (da MongoDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
-	session, err := mgo.Dial(da.connectionString)
+	session, err := da.dial()
 	if err != nil {
 		log.Print("Failed to connect to MongoDB.", err)
 		return nil, false, err
@@ -53,6 +245,7 @@ func (da MongoDataAccess) GetProfile(emailAddress string) (*Profile, bool, error
 		return result, false, nil
 	}
 
+	result.inUTC()
 	return result, true, nil
 }
 
@@ -61,7 +254,7 @@ func (da MongoDataAccess) GetProfile(emailAddress string) (*Profile, bool, error
 func (da MongoDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
 	log.Printf("Updating profile for %s", update.EmailAddress)
 
-	session, err := mgo.Dial(da.connectionString)
+	session, err := da.dial()
 	if err != nil {
 		log.Print("Failed to connect to MongoDB.", err)
 		return nil, err
@@ -70,6 +263,12 @@ func (da MongoDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error)
 
 	c := session.DB(da.databaseName).C("profiles")
 
+	if update.TimeZone != nil {
+		if err := ValidateTimeZone(*update.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+
 	profile, found, err := da.GetProfile(update.EmailAddress)
 
 	if err != nil {
@@ -97,11 +296,37 @@ func (da MongoDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error)
 		skill.Skill = strings.ToLower(skill.Skill)
 	}
 
-	profile.Skills = update.Skills
+	// MongoDB stores times to the millisecond, in UTC.
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	profile.Skills = confirmedSkills(profile, update.Skills, now)
+	if !found || profile.Availability != update.Availability {
+		profile.AvailabilityChanged = now
+	}
 	profile.Availability = update.Availability
+	if update.Name != nil {
+		profile.Name = *update.Name
+	}
+	if update.Manager != nil {
+		profile.Manager = strings.ToLower(*update.Manager)
+	}
+	if update.Language != nil {
+		profile.Language = strings.ToLower(*update.Language)
+	}
+	if update.TimeZone != nil {
+		profile.TimeZone = *update.TimeZone
+	}
+	if update.Bio != nil {
+		profile.Bio = *update.Bio
+	}
+	if update.Department != nil {
+		profile.Department = strings.TrimSpace(*update.Department)
+	}
+	if update.CostCenter != nil {
+		profile.CostCenter = strings.TrimSpace(*update.CostCenter)
+	}
 	profile.Version++
-	profile.LastUpdated = time.Unix(time.Now().Unix(), 0)
-	profile.Domain = getDomain(update.EmailAddress)
+	profile.LastUpdated = now
+	profile.Domain = GetDomain(update.EmailAddress)
 
 	_, err = c.UpsertId(profile.EmailAddress, profile)
 
@@ -113,9 +338,28 @@ func (da MongoDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error)
 	return profile, nil
 }
 
+// ImportProfile creates or replaces a whole profile, including its history
+// and timestamps. It's used to import data from other systems, where
+// UpdateProfile would record the import as a change made now.
+func (da MongoDataAccess) ImportProfile(p *Profile) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return err
+	}
+	defer session.Close()
+
+	p.EmailAddress = strings.ToLower(p.EmailAddress)
+	p.Domain = GetDomain(p.EmailAddress)
+	p.inUTC()
+
+	_, err = session.DB(da.databaseName).C("profiles").UpsertId(p.EmailAddress, p)
+	return err
+}
+
 // ListSkillTags lists the skills used before.
-func (da MongoDataAccess) ListSkillTags() ([]string, error) {
-	session, err := mgo.Dial(da.connectionString)
+func (da MongoDataAccess) ListSkillTags() ([]SkillTag, error) {
+	session, err := da.dial()
 	if err != nil {
 		log.Print("Failed to connect to MongoDB. ", err)
 		return nil, err
@@ -132,17 +376,13 @@ func (da MongoDataAccess) ListSkillTags() ([]string, error) {
 		return nil, nil
 	}
 
-	skillTags := make([]string, len(results), len(results))
-	for idx, tag := range results {
-		skillTags[idx] = tag.Name
-	}
-
-	return skillTags, nil
+	return results, nil
 }
 
-// AddSkillTags adds a skill tag to the list.
+// AddSkillTags adds skill tags to the list. Tags which are already in the
+// list are left unchanged.
 func (da MongoDataAccess) AddSkillTags(tags []string) error {
-	session, err := mgo.Dial(da.connectionString)
+	session, err := da.dial()
 	if err != nil {
 		log.Print("Failed to connect to MongoDB.", err)
 		return err
@@ -152,9 +392,9 @@ func (da MongoDataAccess) AddSkillTags(tags []string) error {
 	c := session.DB(da.databaseName).C("skills")
 
 	for _, tag := range tags {
-		_, err = c.UpsertId(tag, SkillTag{CleanTag(tag)})
+		err = c.Insert(SkillTag{Name: CleanTag(tag)})
 
-		if err != nil {
+		if err != nil && !mgo.IsDup(err) {
 			return err
 		}
 	}
@@ -162,9 +402,74 @@ func (da MongoDataAccess) AddSkillTags(tags []string) error {
 	return nil
 }
 
-// DeleteProfile removes a profile specified by email address.
+// ImportSkillTags adds tags from a taxonomy to the list, returning how many
+// of them weren't already in it. Existing tags are moved to the tag's
+// category, if it has one, and gain its aliases.
+func (da MongoDataAccess) ImportSkillTags(tags []SkillTag) (added int, err error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return 0, err
+	}
+	defer session.Close()
+
+	c := session.DB(da.databaseName).C("skills")
+
+	for _, t := range tags {
+		update := bson.M{}
+		if t.Category != "" {
+			update["$set"] = bson.M{"category": t.Category}
+		}
+		aliases := make([]string, 0, len(t.Aliases))
+		for _, a := range t.Aliases {
+			if a = CleanTag(a); a != "" && a != CleanTag(t.Name) {
+				aliases = append(aliases, a)
+			}
+		}
+		if len(aliases) > 0 {
+			update["$addToSet"] = bson.M{"aliases": bson.M{"$each": aliases}}
+		}
+		if len(update) == 0 {
+			// An upsert needs an operator to insert the document.
+			update["$setOnInsert"] = bson.M{"_id": CleanTag(t.Name)}
+		}
+		info, err := c.UpsertId(CleanTag(t.Name), update)
+		if err != nil {
+			return added, err
+		}
+		if info.UpsertedId != nil {
+			added++
+		}
+	}
+
+	return added, nil
+}
+
+// SetSkillTagDescriptors replaces the descriptions of the levels of the
+// skill tag.
+func (da MongoDataAccess) SetSkillTagDescriptors(name string, descriptors []LevelDescriptor) error {
+	if err := ValidateLevelDescriptors(descriptors); err != nil {
+		return err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(da.databaseName).C("skills").UpdateId(name, bson.M{"$set": bson.M{"descriptors": descriptors}})
+	if err == mgo.ErrNotFound {
+		return ErrSkillTagNotFound
+	}
+	return err
+}
+
+// DeleteProfile removes a profile specified by email address, along with
+// everything else kept about the person, apart from their files.
 func (da MongoDataAccess) DeleteProfile(emailAddress string) (bool, error) {
-	session, err := mgo.Dial(da.connectionString)
+	session, err := da.dial()
 	if err != nil {
 		log.Print("Failed to connect to MongoDB.", err)
 		return false, err
@@ -177,12 +482,12 @@ func (da MongoDataAccess) DeleteProfile(emailAddress string) (bool, error) {
 		return false, err
 	}
 
-	return true, nil
+	return true, removeProfileData(session.DB(da.databaseName), emailAddress)
 }
 
 // ListProfiles lists all of the profiles that the user has access to (filtered by domain).
 func (da MongoDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
-	session, err := mgo.Dial(da.connectionString)
+	session, err := da.dial()
 	if err != nil {
 		log.Print("Failed to connect to MongoDB.", err)
 		return nil, err
@@ -190,31 +495,138 @@ func (da MongoDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
 	defer session.Close()
 
 	var results []Profile
-	err = session.DB(da.databaseName).C("profiles").Find(bson.M{"domain": getDomain(emailAddress)}).All(&results)
+	err = session.DB(da.databaseName).C("profiles").Find(bson.M{"domain": GetDomain(emailAddress)}).All(&results)
 
 	if err != nil {
 		log.Print("Failed to list profiles.", err)
 		return nil, err
 	}
 
+	for i := range results {
+		results[i].inUTC()
+	}
 	return results, nil
 }
 
-func getDomain(emailAddress string) string {
+// GetOrgTree returns the management hierarchy of the people in the domain.
+func (da MongoDataAccess) GetOrgTree(domain string) (*OrgNode, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var results []Profile
+	err = session.DB(da.databaseName).C("profiles").
+		Find(bson.M{"domain": strings.ToLower(domain)}).
+		Select(bson.M{"skillshistory": 0}).
+		All(&results)
+
+	if err != nil {
+		log.Print("Failed to list profiles for the org tree.", err)
+		return nil, err
+	}
+
+	return NewOrgTree(domain, results), nil
+}
+
+// ListDomains lists the domains which have profiles, including archived
+// profiles, so that a tenant whose people have all left is still purged.
+func (da MongoDataAccess) ListDomains() ([]string, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var domains, archived []string
+	err = session.DB(da.databaseName).C("profiles").Find(nil).Distinct("domain", &domains)
+	if err == nil {
+		err = session.DB(da.databaseName).C("archivedprofiles").Find(nil).Distinct("domain", &archived)
+	}
+
+	if err != nil {
+		log.Print("Failed to list domains.", err)
+		return nil, err
+	}
+
+	for _, d := range archived {
+		if !contains(domains, d) {
+			domains = append(domains, d)
+		}
+	}
+	return domains, nil
+}
+
+// UpdateNotificationPreferences replaces the person's notification
+// preferences.
+func (da MongoDataAccess) UpdateNotificationPreferences(emailAddress string, p NotificationPreferences) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(da.databaseName).C("profiles").UpdateId(emailAddress, bson.M{"$set": bson.M{"notifications": p}})
+}
+
+// UpdateAvailabilityWindows replaces the person's availability windows.
+func (da MongoDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error {
+	if err := ValidateAvailabilityWindows(windows); err != nil {
+		return err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(da.databaseName).C("profiles").UpdateId(emailAddress, bson.M{"$set": bson.M{"availabilitywindows": utcWindows(windows)}})
+}
+
+// GetDomain returns the lowercased domain part of an email address.
+func GetDomain(emailAddress string) string {
 	return strings.ToLower(strings.Split(emailAddress, "@")[1])
 }
 
-// DeleteSkillTags deletes a set of tags from the database.
+// DeleteSkillTags moves a set of tags to the trash, where they can be
+// restored until they're purged.
 func (da MongoDataAccess) DeleteSkillTags(tags []string) error {
-	session, err := mgo.Dial(da.connectionString)
+	session, err := da.dial()
 	if err != nil {
 		log.Print("Failed to connect to MongoDB.", err)
 		return err
 	}
 	defer session.Close()
 
+	skills := session.DB(da.databaseName).C("skills")
+	trash := session.DB(da.databaseName).C("skilltrash")
+	now := time.Now().UTC()
+
 	for _, tag := range tags {
-		err = session.DB(da.databaseName).C("skills").RemoveId(tag)
+		var st SkillTag
+		err = skills.FindId(tag).One(&st)
+		if err == mgo.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err = trash.UpsertId(tag, DeletedSkillTag{Name: st.Name, Tag: st, Deleted: now}); err != nil {
+			return err
+		}
+
+		err = skills.RemoveId(tag)
 
 		if err != nil && err != mgo.ErrNotFound {
 			return err
@@ -223,27 +635,199 @@ func (da MongoDataAccess) DeleteSkillTags(tags []string) error {
 	return nil
 }
 
+// ListDeletedSkillTags lists the tags in the trash, most recently deleted
+// first.
+func (da MongoDataAccess) ListDeletedSkillTags() ([]DeletedSkillTag, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var results []DeletedSkillTag
+	err = session.DB(da.databaseName).C("skilltrash").
+		Find(bson.M{"deleted": bson.M{"$gt": time.Now().Add(-SkillTagRetention)}}).
+		Sort("-deleted").
+		All(&results)
+	for i := range results {
+		results[i].Deleted = results[i].Deleted.UTC()
+	}
+	return results, err
+}
+
+// RestoreSkillTags moves tags from the trash back to the list of tags. Tags
+// which aren't in the trash, or have been there longer than the retention
+// period, are not restored.
+func (da MongoDataAccess) RestoreSkillTags(tags []string) (restored []string, err error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	skills := session.DB(da.databaseName).C("skills")
+	trash := session.DB(da.databaseName).C("skilltrash")
+	cutoff := time.Now().Add(-SkillTagRetention)
+
+	for _, tag := range tags {
+		var dt DeletedSkillTag
+		err = trash.FindId(tag).One(&dt)
+		if err == mgo.ErrNotFound || (err == nil && !dt.Deleted.After(cutoff)) {
+			continue
+		}
+		if err != nil {
+			return restored, err
+		}
+
+		if _, err = skills.UpsertId(dt.Name, dt.Tag); err != nil {
+			return restored, err
+		}
+		if err = trash.RemoveId(tag); err != nil && err != mgo.ErrNotFound {
+			return restored, err
+		}
+		restored = append(restored, dt.Name)
+	}
+	return restored, nil
+}
+
+// PurgeDeletedSkillTags permanently removes tags deleted before the time, and
+// returns how many were removed.
+func (da MongoDataAccess) PurgeDeletedSkillTags(before time.Time) (int, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return 0, err
+	}
+	defer session.Close()
+
+	info, err := session.DB(da.databaseName).C("skilltrash").RemoveAll(bson.M{"deleted": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return info.Removed, nil
+}
+
 // CleanTag lowercases input tags and replaces spaces with hyphens.
 func CleanTag(tag string) string {
 	return strings.Replace(strings.ToLower(tag), " ", "-", -1)
 }
 
 func (da MongoDataAccess) getConfiguration() (Configuration, error) {
-	session, err := mgo.Dial(da.connectionString)
+	session, err := da.dial()
 	if err != nil {
 		log.Print("Failed to connect to MongoDB. ", err)
 		return Configuration{}, err
 	}
 	defer session.Close()
 
+	c := session.DB(da.databaseName).C("configuration")
+
 	configuration := NewConfiguration(nil)
-	err = session.DB(da.databaseName).C("configuration").FindId("configuration").One(&configuration)
+	err = c.FindId("configuration").One(&configuration)
+
+	if err != nil {
+		return *configuration, err
+	}
+
+	if err = da.decryptConfiguration(configuration); err != nil {
+		log.Print("Failed to decrypt the configuration. ", err)
+		return *configuration, err
+	}
+
+	upgraded, err := UpgradeConfiguration(configuration)
+
+	if err != nil {
+		log.Print("Failed to upgrade the configuration. ", err)
+		return *configuration, err
+	}
+
+	// Plaintext values are encrypted if a master key has been configured
+	// since the configuration was created.
+	unencrypted := da.keyProvider != nil && configuration.EncryptedSessionEncryptionKey == nil
+
+	if upgraded || unencrypted {
+		log.Printf("Saving the configuration at schema version %d, encrypted: %t.", configuration.SchemaVersion, da.keyProvider != nil)
+
+		if err = configuration.Validate(); err != nil {
+			log.Print("The upgraded configuration is invalid. ", err)
+			return *configuration, err
+		}
 
-	return *configuration, err
+		stored, err := da.encryptConfiguration(*configuration)
+		if err != nil {
+			log.Print("Failed to encrypt the configuration. ", err)
+			return *configuration, err
+		}
+
+		if err = c.UpdateId("configuration", stored); err != nil {
+			log.Print("Failed to save the upgraded configuration. ", err)
+			return *configuration, err
+		}
+	}
+
+	return *configuration, nil
+}
+
+// encryptConfiguration returns a copy of the configuration with sensitive
+// values replaced by encrypted values, if a master key is configured.
+func (da MongoDataAccess) encryptConfiguration(configuration Configuration) (Configuration, error) {
+	if da.keyProvider == nil {
+		return configuration, nil
+	}
+
+	ev, err := encryption.Seal(da.keyProvider, configuration.SessionEncryptionKey)
+	if err != nil {
+		return configuration, err
+	}
+
+	configuration.EncryptedSessionEncryptionKey = &ev
+	configuration.SessionEncryptionKey = nil
+
+	previous := make([]encryption.EncryptedValue, len(configuration.PreviousSessionEncryptionKeys))
+	for idx, key := range configuration.PreviousSessionEncryptionKeys {
+		if previous[idx], err = encryption.Seal(da.keyProvider, key); err != nil {
+			return configuration, err
+		}
+	}
+
+	configuration.EncryptedPreviousSessionEncryptionKeys = previous
+	configuration.PreviousSessionEncryptionKeys = nil
+	return configuration, nil
+}
+
+// decryptConfiguration replaces encrypted values with their plaintext.
+func (da MongoDataAccess) decryptConfiguration(configuration *Configuration) error {
+	if configuration.EncryptedSessionEncryptionKey == nil {
+		return nil
+	}
+
+	if da.keyProvider == nil {
+		return errors.New("dataaccess: the configuration is encrypted, but no master key has been provided")
+	}
+
+	key, err := encryption.Open(da.keyProvider, *configuration.EncryptedSessionEncryptionKey)
+	if err != nil {
+		return err
+	}
+
+	configuration.SessionEncryptionKey = key
+
+	configuration.PreviousSessionEncryptionKeys = nil
+	for _, ev := range configuration.EncryptedPreviousSessionEncryptionKeys {
+		key, err := encryption.Open(da.keyProvider, ev)
+		if err != nil {
+			return err
+		}
+		configuration.PreviousSessionEncryptionKeys = append(configuration.PreviousSessionEncryptionKeys, key)
+	}
+
+	return nil
 }
 
 func (da MongoDataAccess) attemptToCreateConfiguration() error {
-	session, err := mgo.Dial(da.connectionString)
+	session, err := da.dial()
 	if err != nil {
 		log.Print("Failed to connect to MongoDB. ", err)
 		return err
@@ -251,7 +835,17 @@ func (da MongoDataAccess) attemptToCreateConfiguration() error {
 	defer session.Close()
 
 	configuration := NewConfiguration(createSessionEncryptionKey())
-	return session.DB(da.databaseName).C("configuration").Insert(configuration)
+
+	if err = configuration.Validate(); err != nil {
+		return err
+	}
+
+	stored, err := da.encryptConfiguration(*configuration)
+	if err != nil {
+		return err
+	}
+
+	return session.DB(da.databaseName).C("configuration").Insert(stored)
 }
 
 // GetOrCreateConfiguration gets configuration from the database, or creates new configuration.
@@ -268,7 +862,7 @@ func (da MongoDataAccess) GetOrCreateConfiguration() (Configuration, error) {
 
 // DeleteConfiguration deletes the configuration record.
 func (da MongoDataAccess) DeleteConfiguration() error {
-	session, err := mgo.Dial(da.connectionString)
+	session, err := da.dial()
 	if err != nil {
 		log.Print("Failed to connect to MongoDB. ", err)
 		return err
@@ -277,3 +871,331 @@ func (da MongoDataAccess) DeleteConfiguration() error {
 
 	return session.DB(da.databaseName).C("configuration").DropCollection()
 }
+
+// ErrLocked is returned when an operation can't run because another
+// instance of the application holds its lock.
+var ErrLocked = errors.New("dataaccess: the operation is locked by another instance")
+
+// RotateSessionEncryptionKey replaces the session encryption key with a new
+// random key. The replaced key is kept as a previous key, so that existing
+// sessions remain valid until they are renewed.
+func (da MongoDataAccess) RotateSessionEncryptionKey() (Configuration, error) {
+	lock, acquired, err := da.AcquireLock("rotate-session-encryption-key", time.Minute)
+	if err != nil {
+		return Configuration{}, err
+	}
+	if !acquired {
+		return Configuration{}, ErrLocked
+	}
+	defer da.ReleaseLock(lock)
+
+	configuration, err := da.GetOrCreateConfiguration()
+	if err != nil {
+		return configuration, err
+	}
+
+	configuration.rotateSessionEncryptionKey()
+
+	if err = configuration.Validate(); err != nil {
+		return configuration, err
+	}
+
+	stored, err := da.encryptConfiguration(configuration)
+	if err != nil {
+		return configuration, err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return configuration, err
+	}
+	defer session.Close()
+
+	log.Print("Rotating the session encryption key.")
+	return configuration, session.DB(da.databaseName).C("configuration").UpdateId("configuration", stored)
+}
+
+// ErrInvalidFeatureFlagName is returned when a feature flag name is empty, or
+// contains characters which can't be stored.
+var ErrInvalidFeatureFlagName = errors.New("dataaccess: feature flag names must not be empty or contain '.' or '$'")
+
+// SetFeatureFlag switches a feature flag on or off in the configuration.
+func (da MongoDataAccess) SetFeatureFlag(name string, enabled bool) error {
+	if name == "" || strings.ContainsAny(name, ".$") {
+		return ErrInvalidFeatureFlagName
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(da.databaseName).C("configuration").UpdateId("configuration",
+		bson.M{"$set": bson.M{"featureflags." + name: enabled}})
+}
+
+// GetTenantConfiguration returns the configuration of the tenant with the
+// domain.
+func (da MongoDataAccess) GetTenantConfiguration(domain string) (*TenantConfiguration, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	domain = strings.ToLower(domain)
+	tc := NewTenantConfiguration(domain)
+	err = session.DB(da.databaseName).C("tenantconfiguration").FindId(domain).One(tc)
+
+	if err == mgo.ErrNotFound {
+		return tc, false, nil
+	}
+
+	if err != nil {
+		log.Printf("Failed to retrieve the configuration for tenant %s. %s", domain, err)
+		return nil, false, err
+	}
+
+	return tc, true, nil
+}
+
+// UpdateTenantConfiguration creates or replaces the configuration of a
+// tenant.
+func (da MongoDataAccess) UpdateTenantConfiguration(tc *TenantConfiguration) error {
+	if err := tc.Overrides.Validate(); err != nil {
+		return err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	tc.Domain = strings.ToLower(tc.Domain)
+	_, err = session.DB(da.databaseName).C("tenantconfiguration").UpsertId(tc.Domain, tc)
+	return err
+}
+
+// DeleteTenantConfiguration removes the configuration of a tenant, so that
+// it inherits all of its settings.
+func (da MongoDataAccess) DeleteTenantConfiguration(domain string) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(da.databaseName).C("tenantconfiguration").RemoveId(strings.ToLower(domain))
+
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// GetSettings returns the settings of a tenant, taking into account the
+// defaults, the configuration and the tenant's own overrides.
+func (da MongoDataAccess) GetSettings(domain string) (Settings, error) {
+	c, err := da.GetOrCreateConfiguration()
+	if err != nil {
+		return Settings{}, err
+	}
+
+	tc, _, err := da.GetTenantConfiguration(domain)
+	if err != nil {
+		return Settings{}, err
+	}
+
+	return EffectiveSettings(c, tc), nil
+}
+
+// AcquireLock takes the named lock for the duration of the ttl, if it is not
+// already held by another owner. Locks are shared by every instance of the
+// application using the same database.
+func (da MongoDataAccess) AcquireLock(name string, ttl time.Duration) (*Lock, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	lock := newLock(name, ttl)
+
+	// If the lock is held and hasn't expired, the query doesn't match, so the
+	// upsert attempts an insert with the same _id and fails.
+	_, err = session.DB(da.databaseName).C("locks").Upsert(bson.M{"_id": name, "expires": bson.M{"$lt": time.Now()}}, lock)
+
+	if mgo.IsDup(err) {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		log.Printf("Failed to acquire the lock %s. %s", name, err)
+		return nil, false, err
+	}
+
+	return lock, true, nil
+}
+
+// ReleaseLock releases a lock taken by AcquireLock.
+func (da MongoDataAccess) ReleaseLock(lock *Lock) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(da.databaseName).C("locks").Remove(bson.M{"_id": lock.Name, "owner": lock.Owner})
+
+	if err == mgo.ErrNotFound {
+		return ErrLockNotHeld
+	}
+
+	return err
+}
+
+// QuarantineChange stores a change for review by an administrator.
+func (da MongoDataAccess) QuarantineChange(c *QuarantinedChange) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(da.databaseName).C("quarantine").Insert(c)
+}
+
+// ListQuarantinedChanges lists the changes awaiting review, oldest first. If
+// the domain is empty, changes in all domains are listed.
+func (da MongoDataAccess) ListQuarantinedChanges(domain string) ([]QuarantinedChange, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	filter := bson.M{}
+	if domain != "" {
+		filter["tenant"] = strings.ToLower(domain)
+	}
+
+	var results []QuarantinedChange
+	err = session.DB(da.databaseName).C("quarantine").Find(filter).Sort("time").All(&results)
+	return results, err
+}
+
+// GetQuarantinedChange returns a change awaiting review.
+func (da MongoDataAccess) GetQuarantinedChange(id string) (*QuarantinedChange, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	c := &QuarantinedChange{}
+	err = session.DB(da.databaseName).C("quarantine").FindId(id).One(c)
+
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return c, true, nil
+}
+
+// DeleteQuarantinedChange removes a change which has been reviewed.
+func (da MongoDataAccess) DeleteQuarantinedChange(id string) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(da.databaseName).C("quarantine").RemoveId(id)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// RequestApproval stores a destructive action until it's approved.
+func (da MongoDataAccess) RequestApproval(a *ApprovalRequest) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(da.databaseName).C("approvals").Insert(a)
+}
+
+// ListApprovalRequests lists the actions waiting for approval which haven't
+// expired, oldest first.
+func (da MongoDataAccess) ListApprovalRequests() ([]ApprovalRequest, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var results []ApprovalRequest
+	err = session.DB(da.databaseName).C("approvals").
+		Find(bson.M{"expires": bson.M{"$gt": time.Now()}}).
+		Sort("requested").
+		All(&results)
+	return results, err
+}
+
+// GetApprovalRequest returns an action waiting for approval.
+func (da MongoDataAccess) GetApprovalRequest(id string) (*ApprovalRequest, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	a := &ApprovalRequest{}
+	err = session.DB(da.databaseName).C("approvals").FindId(id).One(a)
+
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return a, true, nil
+}
+
+// DeleteApprovalRequest removes an action which has been approved, denied or
+// has expired.
+func (da MongoDataAccess) DeleteApprovalRequest(id string) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(da.databaseName).C("approvals").RemoveId(id)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	"gopkg.in/mgo.v2"
 )
@@ -100,7 +101,7 @@ func TestSkillTags(t *testing.T) {
 		t.Error("Failed to retrieve all skill tags. ", err)
 	}
 
-	if !containsAll(allSkillTags, skillTags) {
+	if !containsAll(SkillTagNames(allSkillTags), skillTags) {
 		t.Error("The full list of all skill tags didn't contain the new skill tags.")
 	}
 
@@ -116,11 +117,59 @@ func TestSkillTags(t *testing.T) {
 		t.Error("Failed to retrieve all skill tags (#2). ", err)
 	}
 
-	if containsAny(allSkillTags, skillTags) {
+	if containsAny(SkillTagNames(allSkillTags), skillTags) {
 		t.Error("After deletion, the test skill tags should not be present in the DB.")
 	}
 }
 
+func TestThatDeletedSkillTagsCanBeRestoredUntilPurged(t *testing.T) {
+	da := NewMongoDataAccess("mongodb://localhost:27017", "pilltest")
+
+	restoredTag := "test_tag_" + strconv.Itoa(rand.Int())
+	purgedTag := "test_tag_" + strconv.Itoa(rand.Int())
+
+	if err := da.AddSkillTags([]string{restoredTag, purgedTag}); err != nil {
+		t.Fatal("Failed to add skill tags. ", err)
+	}
+
+	if err := da.DeleteSkillTags([]string{restoredTag, purgedTag}); err != nil {
+		t.Fatal("Failed to delete skill tags. ", err)
+	}
+
+	deleted, err := da.ListDeletedSkillTags()
+	if err != nil {
+		t.Fatal("Failed to list deleted skill tags. ", err)
+	}
+
+	var names []string
+	for _, dt := range deleted {
+		names = append(names, dt.Name)
+	}
+	if !containsAll(names, []string{restoredTag, purgedTag}) {
+		t.Error("Deleted skill tags should be in the trash.")
+	}
+
+	restored, err := da.RestoreSkillTags([]string{restoredTag})
+	if err != nil || len(restored) != 1 {
+		t.Errorf("Expected the tag to be restored, but received %v, %v", restored, err)
+	}
+
+	if _, err := da.PurgeDeletedSkillTags(time.Now().Add(time.Minute)); err != nil {
+		t.Fatal("Failed to purge deleted skill tags. ", err)
+	}
+
+	if restored, _ := da.RestoreSkillTags([]string{purgedTag}); len(restored) != 0 {
+		t.Error("Purged skill tags should not be restorable.")
+	}
+
+	allSkillTags, _ := da.ListSkillTags()
+	if !containsAll(SkillTagNames(allSkillTags), []string{restoredTag}) || containsAny(SkillTagNames(allSkillTags), []string{purgedTag}) {
+		t.Error("Only the restored tag should be in the list of skill tags.")
+	}
+
+	da.DeleteSkillTags([]string{restoredTag})
+}
+
 func TestContainsAllFunction(t *testing.T) {
 	tests := []struct {
 		input          []string
@@ -287,10 +336,147 @@ func TestSplittingEmailAddressesOnDomains(t *testing.T) {
 	}
 
 	for _, c := range cases {
-		actual := getDomain(c.in)
+		actual := GetDomain(c.in)
 
 		if actual != c.expected {
-			t.Errorf("For input %s, the expected domain is %s but getDomain returned %s", c.in, c.expected, actual)
+			t.Errorf("For input %s, the expected domain is %s but GetDomain returned %s", c.in, c.expected, actual)
+		}
+	}
+}
+
+func TestThatLocksCanOnlyBeHeldByOneOwner(t *testing.T) {
+	da := NewMongoDataAccess("mongodb://localhost:27017", "pilltest")
+	name := "test_lock_" + strconv.Itoa(rand.Int())
+
+	l1, acquired, err := da.AcquireLock(name, time.Minute)
+
+	if err != nil || !acquired {
+		t.Fatal("Failed to acquire the lock.", err)
+	}
+
+	_, acquired, err = da.AcquireLock(name, time.Minute)
+
+	if err != nil || acquired {
+		t.Error("The lock should not be acquired while it is held.", err)
+	}
+
+	err = da.ReleaseLock(l1)
+
+	if err != nil {
+		t.Error("Failed to release the lock.", err)
+	}
+
+	l2, acquired, err := da.AcquireLock(name, time.Minute)
+
+	if err != nil || !acquired {
+		t.Error("After release, the lock should be available.", err)
+	}
+
+	if err = da.ReleaseLock(l1); err != ErrLockNotHeld {
+		t.Error("Releasing a lock which is held by another owner should fail.", err)
+	}
+
+	da.ReleaseLock(l2)
+}
+
+func TestThatExpiredLocksCanBeAcquired(t *testing.T) {
+	da := NewMongoDataAccess("mongodb://localhost:27017", "pilltest")
+	name := "test_lock_" + strconv.Itoa(rand.Int())
+
+	_, acquired, err := da.AcquireLock(name, -time.Second)
+
+	if err != nil || !acquired {
+		t.Fatal("Failed to acquire the lock.", err)
+	}
+
+	l, acquired, err := da.AcquireLock(name, time.Minute)
+
+	if err != nil || !acquired {
+		t.Error("An expired lock should be available to another owner.", err)
+	}
+
+	da.ReleaseLock(l)
+}
+
+func TestThatFeatureFlagsCanBeToggled(t *testing.T) {
+	da := NewMongoDataAccess("mongodb://localhost:27017", "pilltest")
+
+	if _, err := da.GetOrCreateConfiguration(); err != nil {
+		t.Fatal("Failed to get or create the configuration.", err)
+	}
+
+	if err := da.SetFeatureFlag("test_feature", true); err != nil {
+		t.Fatal("Failed to set the feature flag.", err)
+	}
+
+	c, _ := da.GetOrCreateConfiguration()
+
+	if !c.IsEnabled("test_feature") {
+		t.Error("The feature flag should be enabled.")
+	}
+
+	da.SetFeatureFlag("test_feature", false)
+	c, _ = da.GetOrCreateConfiguration()
+
+	if c.IsEnabled("test_feature") {
+		t.Error("The feature flag should be disabled.")
+	}
+}
+
+func TestThatInvalidFeatureFlagNamesAreRejected(t *testing.T) {
+	da := NewMongoDataAccess("mongodb://localhost:27017", "pilltest")
+
+	for _, name := range []string{"", "a.b", "$set"} {
+		if err := da.SetFeatureFlag(name, true); err != ErrInvalidFeatureFlagName {
+			t.Errorf("The feature flag name '%s' should be rejected, but the error was %v", name, err)
+		}
+	}
+}
+
+func TestThatAdministratorsAreMatchedCaseInsensitively(t *testing.T) {
+	c := Configuration{Administrators: []string{"a-h@github.com"}}
+
+	cases := []struct {
+		in       string
+		expected bool
+	}{
+		{"a-h@github.com", true},
+		{"A-H@GITHUB.COM", true},
+		{"someone@github.com", false},
+	}
+
+	for _, tc := range cases {
+		if actual := c.IsAdministrator(tc.in); actual != tc.expected {
+			t.Errorf("For %s, expected IsAdministrator to return %t, but was %t", tc.in, tc.expected, actual)
 		}
 	}
 }
+
+func TestThatTenantConfigurationCanBeUpdatedAndDeleted(t *testing.T) {
+	da := NewMongoDataAccess("mongodb://localhost:27017", "pilltest")
+	domain := "tenant" + strconv.Itoa(rand.Int()) + ".example.com"
+
+	strict := true
+	tc := NewTenantConfiguration(domain)
+	tc.Overrides.StrictVocabulary = &strict
+
+	if err := da.UpdateTenantConfiguration(tc); err != nil {
+		t.Fatal("Failed to update the tenant configuration.", err)
+	}
+
+	s, err := da.GetSettings(domain)
+
+	if err != nil || !s.StrictVocabulary {
+		t.Error("The tenant's settings should include its overrides.", err)
+	}
+
+	if err = da.DeleteTenantConfiguration(domain); err != nil {
+		t.Error("Failed to delete the tenant configuration.", err)
+	}
+
+	_, found, _ := da.GetTenantConfiguration(domain)
+
+	if found {
+		t.Error("After deletion, the tenant configuration should not be found.")
+	}
+}
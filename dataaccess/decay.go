@@ -0,0 +1,165 @@
+package dataaccess
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// DecaySettings control how skills which haven't been used for a while are
+// treated.
+type DecaySettings struct {
+	// StaleMonths is how long after it was last confirmed, or exercised on a
+	// project, a skill becomes stale. 0 disables decay.
+	StaleMonths int `json:"staleMonths"`
+	// Discount is the number of levels stale skills are reduced by when
+	// matching people to work. 0 only flags them as stale.
+	Discount int `json:"discount"`
+}
+
+// Enabled returns true if skills can become stale.
+func (d DecaySettings) Enabled() bool {
+	return d.StaleMonths > 0
+}
+
+// LastUsed returns when the skill was last confirmed, or exercised on one of
+// the projects which require it, up to the time. Skills which were confirmed
+// before confirmations were recorded are treated as confirmed when the
+// profile was last updated.
+func LastUsed(p Profile, s Skill, projects []Project, at time.Time) time.Time {
+	last := s.Confirmed
+	if last.IsZero() {
+		last = p.LastUpdated
+	}
+
+	requires := make(map[string]bool)
+	for _, project := range projects {
+		for _, r := range project.Requirements {
+			if r.Skill == s.Skill {
+				requires[project.ID] = true
+			}
+		}
+	}
+	for _, b := range p.Bookings {
+		if b.ProjectID == "" || !requires[b.ProjectID] || !b.Start.Before(at) {
+			continue
+		}
+		exercised := b.End
+		if exercised.After(at) {
+			exercised = at
+		}
+		if exercised.After(last) {
+			last = exercised
+		}
+	}
+	return last
+}
+
+// StaleAt returns when a skill last used at the time becomes stale.
+func (d DecaySettings) StaleAt(lastUsed time.Time) time.Time {
+	return lastUsed.AddDate(0, d.StaleMonths, 0)
+}
+
+// A StaleSkill is a skill which hasn't been confirmed or exercised recently.
+type StaleSkill struct {
+	Skill    string       `json:"skill"`
+	Level    DreyfusLevel `json:"level"`
+	LastUsed time.Time    `json:"lastUsed"`
+	// Discounted is the level used when matching the person to work.
+	Discounted DreyfusLevel `json:"discounted"`
+}
+
+// StaleSkills returns the person's skills which are stale at the time.
+func (d DecaySettings) StaleSkills(p Profile, projects []Project, at time.Time) []StaleSkill {
+	op := []StaleSkill{}
+	if !d.Enabled() {
+		return op
+	}
+	for _, s := range p.Skills {
+		last := LastUsed(p, s, projects, at)
+		if !at.Before(d.StaleAt(last)) {
+			op = append(op, StaleSkill{Skill: s.Skill, Level: s.Level, LastUsed: last, Discounted: d.discount(s.Level)})
+		}
+	}
+	return op
+}
+
+func (d DecaySettings) discount(level DreyfusLevel) DreyfusLevel {
+	level -= DreyfusLevel(d.Discount)
+	if level < NoviceLevel {
+		return NoviceLevel
+	}
+	return level
+}
+
+// Discounted returns copies of the profiles with the levels of their stale
+// skills reduced by the Discount.
+func (d DecaySettings) Discounted(profiles []Profile, projects []Project, at time.Time) []Profile {
+	if !d.Enabled() || d.Discount == 0 {
+		return profiles
+	}
+	op := make([]Profile, len(profiles))
+	for i, p := range profiles {
+		stale := make(map[string]DreyfusLevel)
+		for _, s := range d.StaleSkills(p, projects, at) {
+			stale[s.Skill] = s.Discounted
+		}
+		skills := make([]Skill, len(p.Skills))
+		for j, s := range p.Skills {
+			if level, ok := stale[s.Skill]; ok {
+				s.Level = level
+			}
+			skills[j] = s
+		}
+		p.Skills = skills
+		op[i] = p
+	}
+	return op
+}
+
+// confirmedSkills keeps the confirmation times of the skills whose levels
+// haven't changed, and confirms the rest at the time.
+func confirmedSkills(previous *Profile, skills []Skill, at time.Time) []Skill {
+	op := make([]Skill, len(skills))
+	for i, s := range skills {
+		s.Confirmed = at
+		for _, p := range previous.Skills {
+			if p.Skill == s.Skill && p.Level == s.Level {
+				s.Confirmed = p.Confirmed
+				if s.Confirmed.IsZero() {
+					s.Confirmed = previous.LastUpdated
+				}
+			}
+		}
+		op[i] = s
+	}
+	return op
+}
+
+// ConfirmSkills records that the person's levels of the skills are still
+// correct.
+func (da MongoDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	p, found, err := da.GetProfile(emailAddress)
+	if err != nil || !found {
+		return err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for i, s := range p.Skills {
+		for _, name := range skills {
+			if s.Skill == strings.ToLower(name) {
+				p.Skills[i].Confirmed = now
+			}
+		}
+	}
+	return session.DB(da.databaseName).C("profiles").UpdateId(p.EmailAddress, bson.M{"$set": bson.M{"skills": p.Skills}})
+}
@@ -0,0 +1,101 @@
+package dataaccess
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThatSkillsBecomeStaleWhenTheyHaventBeenUsed(t *testing.T) {
+	at := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	projects := []Project{
+		{ID: "website", Requirements: []SkillRequirement{{Skill: "go", Level: ProficientLevel}}},
+	}
+	p := Profile{
+		LastUpdated: at.AddDate(-2, 0, 0),
+		Skills: []Skill{
+			{Skill: "go", Level: ExpertLevel},
+			{Skill: "sql", Level: CompetentLevel},
+			{Skill: "java", Level: ProficientLevel, Confirmed: at.AddDate(0, -1, 0)},
+			{Skill: "cobol", Level: MasterLevel},
+		},
+		Bookings: []Booking{
+			{ProjectID: "website", Start: at.AddDate(0, -3, 0), End: at.AddDate(0, 3, 0)},
+		},
+	}
+	d := DecaySettings{StaleMonths: 12, Discount: 2}
+
+	stale := d.StaleSkills(p, projects, at)
+	expected := []StaleSkill{
+		{Skill: "sql", Level: CompetentLevel, LastUsed: p.LastUpdated, Discounted: NoviceLevel},
+		{Skill: "cobol", Level: MasterLevel, LastUsed: p.LastUpdated, Discounted: ProficientLevel},
+	}
+	if len(stale) != len(expected) {
+		t.Fatalf("expected %d stale skills, but got %v", len(expected), stale)
+	}
+	for i, s := range stale {
+		if s != expected[i] {
+			t.Errorf("%d: expected %v, but got %v", i, expected[i], s)
+		}
+	}
+
+	if last := LastUsed(p, p.Skills[0], projects, at); !last.Equal(at) {
+		t.Errorf("expected go to have been used up until %v on the current booking, but got %v", at, last)
+	}
+
+	if stale := (DecaySettings{}).StaleSkills(p, projects, at); len(stale) != 0 {
+		t.Errorf("expected no skills to be stale when decay is disabled, but got %v", stale)
+	}
+}
+
+func TestThatStaleSkillsAreDiscounted(t *testing.T) {
+	at := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	profiles := []Profile{
+		{
+			LastUpdated: at.AddDate(-2, 0, 0),
+			Skills: []Skill{
+				{Skill: "go", Level: ExpertLevel, Confirmed: at},
+				{Skill: "sql", Level: ExpertLevel},
+			},
+		},
+	}
+
+	discounted := DecaySettings{StaleMonths: 12, Discount: 1}.Discounted(profiles, nil, at)
+	if discounted[0].Skills[0].Level != ExpertLevel {
+		t.Errorf("expected the confirmed skill to keep its level, but got %v", discounted[0].Skills[0].Level)
+	}
+	if discounted[0].Skills[1].Level != ProficientLevel {
+		t.Errorf("expected the stale skill to be discounted, but got %v", discounted[0].Skills[1].Level)
+	}
+	if profiles[0].Skills[1].Level != ExpertLevel {
+		t.Errorf("expected the original profiles to be unchanged, but got %v", profiles[0].Skills[1].Level)
+	}
+
+	if flagged := (DecaySettings{StaleMonths: 12}).Discounted(profiles, nil, at); flagged[0].Skills[1].Level != ExpertLevel {
+		t.Errorf("expected stale skills to only be flagged without a discount, but got %v", flagged[0].Skills[1].Level)
+	}
+}
+
+func TestThatChangingTheLevelOfASkillConfirmsIt(t *testing.T) {
+	earlier := time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC)
+	at := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	previous := &Profile{
+		LastUpdated: earlier,
+		Skills: []Skill{
+			{Skill: "go", Level: ExpertLevel},
+			{Skill: "sql", Level: CompetentLevel, Confirmed: earlier.AddDate(0, 1, 0)},
+		},
+	}
+
+	skills := confirmedSkills(previous, []Skill{
+		{Skill: "go", Level: ExpertLevel},
+		{Skill: "sql", Level: ProficientLevel},
+		{Skill: "rust", Level: NoviceLevel},
+	}, at)
+
+	expected := []time.Time{earlier, at, at}
+	for i, s := range skills {
+		if !s.Confirmed.Equal(expected[i]) {
+			t.Errorf("expected %s to be confirmed at %v, but got %v", s.Skill, expected[i], s.Confirmed)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package dataaccess
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A ProfileFilter limits a list of profiles to a department or cost center,
+// or to the people who speak a language. Empty fields match everyone.
+type ProfileFilter struct {
+	Department string `json:"department,omitempty"`
+	CostCenter string `json:"costCenter,omitempty"`
+	// Language is the ISO 639-1 code of a language, e.g. "de", spoken at
+	// LanguageLevel or above. An empty level matches any level.
+	Language      string    `json:"language,omitempty"`
+	LanguageLevel CEFRLevel `json:"languageLevel,omitempty"`
+}
+
+// Empty returns true if the filter matches everyone.
+func (f ProfileFilter) Empty() bool {
+	return f.Department == "" && f.CostCenter == "" && f.Language == ""
+}
+
+// Matches returns true if the profile is in the department and cost center,
+// and the person speaks the language.
+func (f ProfileFilter) Matches(p Profile) bool {
+	return (f.Department == "" || p.Department == f.Department) &&
+		(f.CostCenter == "" || p.CostCenter == f.CostCenter) &&
+		(f.Language == "" || p.Speaks(f.Language, f.LanguageLevel))
+}
+
+// Apply returns the profiles which match the filter.
+func (f ProfileFilter) Apply(profiles []Profile) []Profile {
+	if f.Empty() {
+		return profiles
+	}
+	var op []Profile
+	for _, p := range profiles {
+		if f.Matches(p) {
+			op = append(op, p)
+		}
+	}
+	return op
+}
+
+func (f ProfileFilter) query(domain string) bson.M {
+	q := bson.M{"domain": strings.ToLower(domain)}
+	if f.Department != "" {
+		q["department"] = f.Department
+	}
+	if f.CostCenter != "" {
+		q["costcenter"] = f.CostCenter
+	}
+	if f.Language != "" {
+		language := bson.M{"code": f.Language}
+		if f.LanguageLevel != "" {
+			language["level"] = bson.M{"$in": f.LanguageLevel.AtLeast()}
+		}
+		q["languages"] = bson.M{"$elemMatch": language}
+	}
+	return q
+}
+
+// FindProfiles lists the profiles in the domain which match the filter.
+// Departments, cost centers and languages are matched exactly, so that the
+// indexes created by EnsureIndexes are used.
+func (da MongoDataAccess) FindProfiles(domain string, f ProfileFilter) ([]Profile, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var results []Profile
+	if err := session.DB(da.databaseName).C("profiles").Find(f.query(domain)).All(&results); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].inUTC()
+	}
+	return results, nil
+}
+
+// A DepartmentSummary is the headcount and skills of a department.
+type DepartmentSummary struct {
+	// Department is empty for the people who aren't in one.
+	Department  string   `json:"department"`
+	CostCenters []string `json:"costCenters"`
+	People      int      `json:"people"`
+	// Available is the number of people who are green.
+	Available int `json:"available"`
+	// Skills are in order of the number of people who have them.
+	Skills []DepartmentSkill `json:"skills"`
+}
+
+// A DepartmentSkill is the number of people in a department with a skill,
+// and their average level.
+type DepartmentSkill struct {
+	Skill        string  `json:"skill"`
+	People       int     `json:"people"`
+	AverageLevel float64 `json:"averageLevel"`
+}
+
+// Departments sums up the profiles by department, in order of name.
+func Departments(profiles []Profile) []DepartmentSummary {
+	byName := make(map[string]*DepartmentSummary)
+	levels := make(map[string]map[string][]DreyfusLevel)
+	costCenters := make(map[string]map[string]bool)
+	var names []string
+	for _, p := range profiles {
+		d, ok := byName[p.Department]
+		if !ok {
+			d = &DepartmentSummary{Department: p.Department, CostCenters: []string{}}
+			byName[p.Department] = d
+			levels[p.Department] = make(map[string][]DreyfusLevel)
+			costCenters[p.Department] = make(map[string]bool)
+			names = append(names, p.Department)
+		}
+		d.People++
+		if p.Availability == Green {
+			d.Available++
+		}
+		if p.CostCenter != "" && !costCenters[p.Department][p.CostCenter] {
+			costCenters[p.Department][p.CostCenter] = true
+			d.CostCenters = append(d.CostCenters, p.CostCenter)
+		}
+		for _, s := range p.Skills {
+			levels[p.Department][s.Skill] = append(levels[p.Department][s.Skill], s.Level)
+		}
+	}
+	sort.Strings(names)
+
+	op := make([]DepartmentSummary, len(names))
+	for i, name := range names {
+		d := byName[name]
+		sort.Strings(d.CostCenters)
+		d.Skills = []DepartmentSkill{}
+		for skill, ls := range levels[name] {
+			total := 0
+			for _, l := range ls {
+				total += int(l)
+			}
+			d.Skills = append(d.Skills, DepartmentSkill{Skill: skill, People: len(ls), AverageLevel: float64(total) / float64(len(ls))})
+		}
+		sort.Slice(d.Skills, func(i, j int) bool {
+			if d.Skills[i].People != d.Skills[j].People {
+				return d.Skills[i].People > d.Skills[j].People
+			}
+			return d.Skills[i].Skill < d.Skills[j].Skill
+		})
+		op[i] = *d
+	}
+	return op
+}
@@ -0,0 +1,61 @@
+package dataaccess
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThatProfilesAreFilteredByDepartmentAndCostCenter(t *testing.T) {
+	profiles := []Profile{
+		{EmailAddress: "a@github.com", Department: "Engineering", CostCenter: "100"},
+		{EmailAddress: "b@github.com", Department: "Engineering", CostCenter: "200"},
+		{EmailAddress: "c@github.com", Department: "Sales", CostCenter: "100"},
+	}
+
+	tests := []struct {
+		f        ProfileFilter
+		expected []string
+	}{
+		{ProfileFilter{}, []string{"a@github.com", "b@github.com", "c@github.com"}},
+		{ProfileFilter{Department: "Engineering"}, []string{"a@github.com", "b@github.com"}},
+		{ProfileFilter{CostCenter: "100"}, []string{"a@github.com", "c@github.com"}},
+		{ProfileFilter{Department: "Engineering", CostCenter: "100"}, []string{"a@github.com"}},
+		{ProfileFilter{Department: "engineering"}, nil},
+	}
+
+	for _, test := range tests {
+		var actual []string
+		for _, p := range test.f.Apply(profiles) {
+			actual = append(actual, p.EmailAddress)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("For %+v, expected %v, but got %v", test.f, test.expected, actual)
+		}
+	}
+}
+
+func TestThatDepartmentsAreSummedUp(t *testing.T) {
+	profiles := []Profile{
+		{Department: "Sales", Availability: Green},
+		{Department: "Engineering", CostCenter: "200", Availability: Green, Skills: []Skill{{Skill: "go", Level: ExpertLevel}, {Skill: "sql", Level: NoviceLevel}}},
+		{Department: "Engineering", CostCenter: "100", Availability: Red, Skills: []Skill{{Skill: "go", Level: NoviceLevel}}},
+		{},
+	}
+
+	departments := Departments(profiles)
+
+	if len(departments) != 3 || departments[0].Department != "" || departments[1].Department != "Engineering" || departments[2].Department != "Sales" {
+		t.Fatalf("Expected the people without a department, Engineering and Sales, but got %+v", departments)
+	}
+	e := departments[1]
+	if e.People != 2 || e.Available != 1 || !reflect.DeepEqual(e.CostCenters, []string{"100", "200"}) {
+		t.Errorf("Expected 2 people in Engineering, 1 available, in cost centers 100 and 200, but got %+v", e)
+	}
+	expected := []DepartmentSkill{
+		{Skill: "go", People: 2, AverageLevel: float64(ExpertLevel+NoviceLevel) / 2},
+		{Skill: "sql", People: 1, AverageLevel: float64(NoviceLevel)},
+	}
+	if !reflect.DeepEqual(e.Skills, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, e.Skills)
+	}
+}
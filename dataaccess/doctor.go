@@ -0,0 +1,159 @@
+package dataaccess
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/doctor"
+	"github.com/a-h/pill/encryption"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// doctorTimeout is how long the doctor waits to connect to each database.
+const doctorTimeout = 10 * time.Second
+
+// CheckDatabase checks that the database can be reached with the connection
+// string, that the MongoDB user can write to it, and that the profiles'
+// indexes exist. The name describes the database in the results, e.g.
+// "shard eu". Nothing is changed, other than a document which is written to
+// and removed from the doctor collection.
+func CheckDatabase(name, connectionString, databaseName string) []doctor.Result {
+	session, err := mgo.DialWithTimeout(connectionString, doctorTimeout)
+	if err != nil {
+		return []doctor.Result{doctor.Fail(name+" connection", fmt.Sprintf("Failed to connect to MongoDB: %v", err),
+			"Check that MongoDB is running and can be reached from this host, and that the connection string's host, port and credentials are correct.")}
+	}
+	defer session.Close()
+	if err := session.Ping(); err != nil {
+		return []doctor.Result{doctor.Fail(name+" connection", fmt.Sprintf("MongoDB didn't respond: %v", err),
+			"Check the health of the MongoDB servers, e.g. with rs.status() in the mongo shell.")}
+	}
+	results := []doctor.Result{doctor.Pass(name + " connection")}
+
+	c := session.DB(databaseName).C("doctor")
+	id := bson.NewObjectId()
+	if err := c.Insert(bson.M{"_id": id, "checked": time.Now().UTC()}); err != nil {
+		results = append(results, doctor.Fail(name+" permissions", fmt.Sprintf("Failed to write to the %s database: %v", databaseName, err),
+			fmt.Sprintf("Grant the MongoDB user the readWrite role on the %s database, e.g. db.grantRolesToUser(\"pill\", [{role: \"readWrite\", db: \"%s\"}]).", databaseName, databaseName)))
+	} else if err := c.RemoveId(id); err != nil {
+		results = append(results, doctor.Fail(name+" permissions", fmt.Sprintf("Failed to remove from the %s database: %v", databaseName, err),
+			fmt.Sprintf("Grant the MongoDB user the readWrite role on the %s database.", databaseName)))
+	} else {
+		results = append(results, doctor.Pass(name+" permissions"))
+	}
+
+	return append(results, checkIndexes(name, session.DB(databaseName).C("profiles")))
+}
+
+func checkIndexes(name string, c *mgo.Collection) doctor.Result {
+	check := name + " indexes"
+	existing, err := c.Indexes()
+	if err != nil {
+		// The collection doesn't exist until the first profile is saved.
+		if n, countErr := c.Count(); countErr == nil && n == 0 {
+			return doctor.Warn(check, "There are no profiles yet, so the indexes haven't been created.",
+				"Start the service, which creates the indexes, before loading profiles.")
+		}
+		return doctor.Fail(check, fmt.Sprintf("Failed to list the indexes of the profiles: %v", err),
+			"Grant the MongoDB user the listIndexes action on the profiles collection, e.g. with the read role.")
+	}
+	keys := make(map[string]bool)
+	for _, index := range existing {
+		keys[strings.Join(index.Key, ",")] = true
+	}
+	var missing []string
+	for _, index := range profileIndexes {
+		if !keys[strings.Join(index.Key, ",")] {
+			missing = append(missing, strings.Join(index.Key, ", "))
+		}
+	}
+	if len(missing) > 0 {
+		return doctor.Warn(check, "The profiles are missing the indexes on "+strings.Join(missing, "; ")+", so queries by them scan the collection.",
+			"Restart the service, which creates missing indexes in the background, and check its log for errors creating them.")
+	}
+	return doctor.Pass(check)
+}
+
+// CheckConfiguration checks that the configuration can be read, decrypted
+// with the key provider, which is nil if there's no master key, and is
+// valid. Unlike GetConfiguration, it doesn't save upgrades.
+func CheckConfiguration(connectionString, databaseName string, kp encryption.KeyProvider) doctor.Result {
+	const check = "configuration"
+	session, err := mgo.DialWithTimeout(connectionString, doctorTimeout)
+	if err != nil {
+		return doctor.Fail(check, fmt.Sprintf("Failed to connect to MongoDB to read the configuration: %v", err),
+			"Fix the database connection first.")
+	}
+	defer session.Close()
+
+	configuration := NewConfiguration(nil)
+	err = session.DB(databaseName).C("configuration").FindId("configuration").One(configuration)
+	if err == mgo.ErrNotFound {
+		return doctor.Warn(check, "There is no configuration yet.",
+			"Start the service, which creates the configuration with a new session encryption key.")
+	}
+	if err != nil {
+		return doctor.Fail(check, fmt.Sprintf("Failed to read the configuration: %v", err),
+			"Grant the MongoDB user the read role on the configuration collection.")
+	}
+
+	da := MongoDataAccess{connectionString: connectionString, databaseName: databaseName, keyProvider: kp}
+	if err := da.decryptConfiguration(configuration); err != nil {
+		if kp == nil {
+			return doctor.Fail(check, "The configuration is encrypted, but there's no master key to decrypt it.",
+				"Pass the master key file the configuration was encrypted with to -masterKeyFile.")
+		}
+		return doctor.Fail(check, fmt.Sprintf("The configuration can't be decrypted with the master key %s: %v", kp.KeyID(), err),
+			"Pass the master key file the configuration was encrypted with to -masterKeyFile. The key it needs is "+configuration.EncryptedSessionEncryptionKey.KeyID+".")
+	}
+	if _, err := UpgradeConfiguration(configuration); err != nil {
+		return doctor.Fail(check, err.Error(),
+			"Upgrade pill to the release which last saved the configuration, or a later one.")
+	}
+	if err := configuration.Validate(); err != nil {
+		return doctor.Fail(check, err.Error(),
+			"Correct the configuration document in the configuration collection, e.g. with the mongo shell, or restore it from a backup.")
+	}
+	return doctor.Pass(check)
+}
+
+// A Deployment is the databases and master key a pill service is configured
+// to use.
+type Deployment struct {
+	ConnectionString        string
+	ReplicaConnectionString string
+	DatabaseName            string
+	// Shards is the list of shards, as given to ParseShards.
+	Shards        string
+	MasterKeyFile string
+}
+
+// Check runs each of the doctor's checks of the deployment.
+func (d Deployment) Check() []doctor.Result {
+	results := CheckDatabase("database", d.ConnectionString, d.DatabaseName)
+	if d.ReplicaConnectionString != "" {
+		results = append(results, CheckDatabase("replica", d.ReplicaConnectionString, d.DatabaseName)...)
+	}
+	shards, err := ParseShards(d.Shards)
+	if err != nil {
+		results = append(results, doctor.Fail("shards", err.Error(), "Correct the -shards flag, e.g. eu=mongodb://mongo-eu:27017,apac=mongodb://mongo-apac:27017."))
+	}
+	var names []string
+	for name := range shards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		results = append(results, CheckDatabase("shard "+name, shards[name], d.DatabaseName)...)
+	}
+
+	kp, r := doctor.CheckMasterKey(d.MasterKeyFile)
+	results = append(results, r)
+	if r.Status == doctor.Failed {
+		return results
+	}
+	return append(results, CheckConfiguration(d.ConnectionString, d.DatabaseName, kp))
+}
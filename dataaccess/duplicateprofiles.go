@@ -0,0 +1,242 @@
+package dataaccess
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The reasons two profiles are thought to belong to the same person.
+const (
+	// SameName profiles have the same name, ignoring case and spacing.
+	SameName = "sameName"
+	// AliasDomain profiles have the same email address before the @, in the
+	// tenant's domain and one of its alias domains.
+	AliasDomain = "aliasDomain"
+)
+
+// ErrMergeSelf is returned when a profile is merged into itself.
+var ErrMergeSelf = errors.New("dataaccess: a profile can't be merged into itself")
+
+// A DuplicateCandidate is a pair of profiles which are likely to belong to
+// the same person, for an administrator to review and merge.
+type DuplicateCandidate struct {
+	// Primary is the profile suggested to keep, and Duplicate the one to
+	// merge into it.
+	Primary   string   `json:"primary"`
+	Duplicate string   `json:"duplicate"`
+	Reasons   []string `json:"reasons"`
+}
+
+// FindDuplicates finds the pairs of profiles in the tenant's domain and its
+// alias domains which are likely to belong to the same person. The profile
+// in the tenant's own domain is suggested as the primary, then the most
+// recently updated.
+func FindDuplicates(profiles []Profile, domain string) []DuplicateCandidate {
+	byName := make(map[string][]int)
+	byLocalPart := make(map[string][]int)
+	for i, p := range profiles {
+		if name := normaliseName(p.Name); name != "" {
+			byName[name] = append(byName[name], i)
+		}
+		if at := strings.Index(p.EmailAddress, "@"); at > 0 {
+			local := strings.ToLower(p.EmailAddress[:at])
+			byLocalPart[local] = append(byLocalPart[local], i)
+		}
+	}
+
+	reasons := make(map[[2]int][]string)
+	match := func(indices []int, reason string, matches func(a, b Profile) bool) {
+		for x := 0; x < len(indices); x++ {
+			for y := x + 1; y < len(indices); y++ {
+				a, b := profiles[indices[x]], profiles[indices[y]]
+				if strings.EqualFold(a.EmailAddress, b.EmailAddress) || !matches(a, b) {
+					continue
+				}
+				key := [2]int{indices[x], indices[y]}
+				reasons[key] = append(reasons[key], reason)
+			}
+		}
+	}
+	for _, indices := range byName {
+		match(indices, SameName, func(a, b Profile) bool { return true })
+	}
+	for _, indices := range byLocalPart {
+		match(indices, AliasDomain, func(a, b Profile) bool { return !strings.EqualFold(a.Domain, b.Domain) })
+	}
+
+	candidates := []DuplicateCandidate{}
+	for key, r := range reasons {
+		primary, duplicate := profiles[key[0]], profiles[key[1]]
+		if preferPrimary(duplicate, primary, domain) {
+			primary, duplicate = duplicate, primary
+		}
+		candidates = append(candidates, DuplicateCandidate{Primary: primary.EmailAddress, Duplicate: duplicate.EmailAddress, Reasons: r})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Primary != candidates[j].Primary {
+			return candidates[i].Primary < candidates[j].Primary
+		}
+		return candidates[i].Duplicate < candidates[j].Duplicate
+	})
+	return candidates
+}
+
+func normaliseName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// preferPrimary returns true if a should be kept rather than b.
+func preferPrimary(a, b Profile, domain string) bool {
+	aHome, bHome := strings.EqualFold(a.Domain, domain), strings.EqualFold(b.Domain, domain)
+	if aHome != bHome {
+		return aHome
+	}
+	return a.LastUpdated.After(b.LastUpdated)
+}
+
+// CombineProfiles combines the duplicate profile of a person into their
+// primary profile:
+//
+//   - Details such as the name and department are the primary's, unless it
+//     doesn't have them.
+//   - Skills both profiles have keep the higher level and interest, and the
+//     most recent confirmation, as when skill tags are merged.
+//   - Histories, bookings, badges, goals and consents are combined, in date
+//     order, so the most recent consent decision applies to both.
+func CombineProfiles(primary Profile, duplicate Profile) Profile {
+	c := primary
+	fill := func(s *string, v string) {
+		if *s == "" {
+			*s = v
+		}
+	}
+	fill(&c.Name, duplicate.Name)
+	fill(&c.Manager, duplicate.Manager)
+	fill(&c.Department, duplicate.Department)
+	fill(&c.CostCenter, duplicate.CostCenter)
+	fill(&c.Bio, duplicate.Bio)
+	fill(&c.Language, duplicate.Language)
+	fill(&c.TimeZone, duplicate.TimeZone)
+	if c.WorkingHours == nil {
+		c.WorkingHours = duplicate.WorkingHours
+	}
+	if c.WorkLocation == nil {
+		c.WorkLocation = duplicate.WorkLocation
+	}
+	if c.CV == nil {
+		c.CV = duplicate.CV
+	}
+	if c.Employment == nil {
+		c.Employment = duplicate.Employment
+	}
+	if c.Clearance == nil {
+		c.Clearance = duplicate.Clearance
+	}
+	if duplicate.AvailabilityChanged.After(c.AvailabilityChanged) {
+		c.Availability, c.AvailabilityChanged = duplicate.Availability, duplicate.AvailabilityChanged
+	}
+
+	c.Skills = mergeSkills(append(append([]Skill{}, primary.Skills...), duplicate.Skills...), "", nil)
+
+	c.SkillsHistory = append(append([]SkillLevel{}, primary.SkillsHistory...), duplicate.SkillsHistory...)
+	c.SkillsHistory = append(c.SkillsHistory, SkillLevel{Date: duplicate.LastUpdated, Skills: duplicate.Skills})
+	sort.SliceStable(c.SkillsHistory, func(i, j int) bool { return c.SkillsHistory[i].Date.Before(c.SkillsHistory[j].Date) })
+
+	c.Bookings = append(append([]Booking{}, primary.Bookings...), duplicate.Bookings...)
+	sort.SliceStable(c.Bookings, func(i, j int) bool { return c.Bookings[i].Start.Before(c.Bookings[j].Start) })
+
+	c.Badges = append([]Badge{}, primary.Badges...)
+	for _, b := range duplicate.Badges {
+		if !primary.HasBadge(b.Name) {
+			c.Badges = append(c.Badges, b)
+		}
+	}
+
+	goals := make(map[string]bool)
+	c.Goals = append([]LearningGoal{}, primary.Goals...)
+	for _, g := range primary.Goals {
+		goals[g.Skill] = true
+	}
+	for _, g := range duplicate.Goals {
+		if !goals[g.Skill] {
+			c.Goals = append(c.Goals, g)
+		}
+	}
+
+	c.Consents = append(append([]Consent{}, primary.Consents...), duplicate.Consents...)
+	sort.SliceStable(c.Consents, func(i, j int) bool { return c.Consents[i].Time.Before(c.Consents[j].Time) })
+
+	if len(duplicate.CustomFields) > 0 {
+		c.CustomFields = make(map[string]CustomFieldValue)
+		for k, v := range duplicate.CustomFields {
+			c.CustomFields[k] = v
+		}
+		for k, v := range primary.CustomFields {
+			c.CustomFields[k] = v
+		}
+	}
+	if len(duplicate.ExternalIDs) > 0 {
+		c.ExternalIDs = make(map[string]string)
+		for k, v := range duplicate.ExternalIDs {
+			c.ExternalIDs[k] = v
+		}
+		for k, v := range primary.ExternalIDs {
+			c.ExternalIDs[k] = v
+		}
+	}
+
+	if duplicate.Version > c.Version {
+		c.Version = duplicate.Version
+	}
+	c.Version++
+	return c
+}
+
+// MergeProfiles combines the duplicate profile into the primary, see
+// CombineProfiles, and removes the duplicate. The people the duplicate
+// managed, and its course completions, are moved to the primary, and the
+// rest of the duplicate's data is removed. It returns false if either
+// profile doesn't exist.
+func (da MongoDataAccess) MergeProfiles(primary string, duplicate string) (*Profile, bool, error) {
+	primary, duplicate = strings.ToLower(primary), strings.ToLower(duplicate)
+	if primary == duplicate {
+		return nil, false, ErrMergeSelf
+	}
+	p, found, err := da.GetProfile(primary)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	d, found, err := da.GetProfile(duplicate)
+	if err != nil || !found {
+		return nil, found, err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	merged := CombineProfiles(*p, *d)
+	merged.LastUpdated = time.Now().UTC()
+	if err := da.ImportProfile(&merged); err != nil {
+		return nil, false, err
+	}
+	db := session.DB(da.databaseName)
+	if _, err := db.C("profiles").UpdateAll(bson.M{"manager": duplicate}, bson.M{"$set": bson.M{"manager": primary}}); err != nil {
+		return nil, false, err
+	}
+	if _, err := db.C("completions").UpdateAll(bson.M{"emailaddress": duplicate}, bson.M{"$set": bson.M{"emailaddress": primary, "domain": merged.Domain}}); err != nil {
+		return nil, false, err
+	}
+	if err := db.C("profiles").RemoveId(duplicate); err != nil {
+		return nil, false, err
+	}
+	return &merged, true, removeProfileData(db, duplicate)
+}
@@ -0,0 +1,86 @@
+package dataaccess
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestThatLikelyDuplicateProfilesAreFound(t *testing.T) {
+	june := time.Date(2017, time.June, 1, 0, 0, 0, 0, time.UTC)
+	july := time.Date(2017, time.July, 1, 0, 0, 0, 0, time.UTC)
+	profiles := []Profile{
+		{EmailAddress: "adrian@github.com", Domain: "github.com", Name: "Adrian Hesketh", LastUpdated: june},
+		{EmailAddress: "a.hesketh@github.com", Domain: "github.com", Name: "adrian  hesketh", LastUpdated: july},
+		{EmailAddress: "adrian@octocat.com", Domain: "octocat.com", Name: "Adrian H", LastUpdated: july},
+		{EmailAddress: "bob@github.com", Domain: "github.com", Name: "Bob"},
+		{EmailAddress: "bob@octocat.com", Domain: "octocat.com", Name: "Bob"},
+		{EmailAddress: "carol@github.com", Domain: "github.com"},
+		{EmailAddress: "dan@github.com", Domain: "github.com"},
+	}
+
+	actual := FindDuplicates(profiles, "github.com")
+
+	expected := []DuplicateCandidate{
+		{Primary: "a.hesketh@github.com", Duplicate: "adrian@github.com", Reasons: []string{SameName}},
+		{Primary: "adrian@github.com", Duplicate: "adrian@octocat.com", Reasons: []string{AliasDomain}},
+		{Primary: "bob@github.com", Duplicate: "bob@octocat.com", Reasons: []string{SameName, AliasDomain}},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %+v, but got %+v.", expected, actual)
+	}
+}
+
+func TestThatDuplicateProfilesAreCombined(t *testing.T) {
+	june := time.Date(2017, time.June, 1, 0, 0, 0, 0, time.UTC)
+	july := time.Date(2017, time.July, 1, 0, 0, 0, 0, time.UTC)
+	primary := Profile{
+		EmailAddress:  "adrian@github.com",
+		Name:          "Adrian",
+		Version:       2,
+		Skills:        []Skill{{Skill: "go", Level: 3, Confirmed: june}, {Skill: "java", Level: 2}},
+		SkillsHistory: []SkillLevel{{Date: june, Skills: []Skill{{Skill: "go", Level: 2}}}},
+		LastUpdated:   july,
+		Badges:        []Badge{{Name: "first"}},
+		Consents:      []Consent{{Purpose: ConsentAnalytics, Given: true, Time: june}},
+	}
+	duplicate := Profile{
+		EmailAddress: "adrian@octocat.com",
+		Name:         "Adrian H",
+		Department:   "Engineering",
+		Version:      5,
+		Skills:       []Skill{{Skill: "go", Level: 4, Interest: 5, Confirmed: july}, {Skill: "rust", Level: 1}},
+		LastUpdated:  june,
+		Badges:       []Badge{{Name: "first"}, {Name: "expert"}},
+		Consents:     []Consent{{Purpose: ConsentAnalytics, Given: false, Time: july}},
+	}
+
+	c := CombineProfiles(primary, duplicate)
+
+	if c.EmailAddress != primary.EmailAddress || c.Name != "Adrian" || c.Department != "Engineering" {
+		t.Errorf("Expected the primary's details, filled in from the duplicate, but got %+v.", c)
+	}
+	expectedSkills := []Skill{{Skill: "go", Level: 4, Interest: 5, Confirmed: july}, {Skill: "java", Level: 2}, {Skill: "rust", Level: 1}}
+	if !reflect.DeepEqual(c.Skills, expectedSkills) {
+		t.Errorf("Expected skills %v, but got %v.", expectedSkills, c.Skills)
+	}
+	if len(c.SkillsHistory) != 2 || !c.SkillsHistory[1].Date.Equal(june) || len(c.SkillsHistory[1].Skills) != 2 {
+		t.Errorf("Expected the duplicate's skills to be added to the history, but got %v.", c.SkillsHistory)
+	}
+	if len(c.Badges) != 2 {
+		t.Errorf("Expected the badges to be combined, but got %v.", c.Badges)
+	}
+	if len(c.Consents) != 2 || c.Consents[1].Given {
+		t.Errorf("Expected the latest consent decision to be last, but got %v.", c.Consents)
+	}
+	if c.Version != 6 {
+		t.Errorf("Expected the version to follow both profiles', but got %d.", c.Version)
+	}
+}
+
+func TestThatInvalidAliasDomainsAreRejected(t *testing.T) {
+	domains := []string{"octocat.com", "bob@example.com"}
+	if err := (SettingsOverrides{AliasDomains: &domains}).Validate(); err == nil {
+		t.Error("Expected an email address not to be an alias domain.")
+	}
+}
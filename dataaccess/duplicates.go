@@ -0,0 +1,400 @@
+package dataaccess
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The reasons tags are thought to be duplicates.
+const (
+	// SpellingReason is given for tags which are spelled almost the same,
+	// e.g. "nodejs" and "node.js".
+	SpellingReason = "spelling"
+	// AliasReason is given for tags which are aliases of one another, or
+	// share an alias.
+	AliasReason = "alias"
+	// CooccurrenceReason is given for tags which are used alongside the same
+	// other tags, but never by the same person.
+	CooccurrenceReason = "cooccurrence"
+)
+
+// minCooccurrenceUsage is how many people must use a tag before it's
+// compared with others by the tags it's used alongside, since tags used by a
+// handful of people have too few neighbours to compare.
+const minCooccurrenceUsage = 3
+
+// minCooccurrenceSimilarity is the proportion of their neighbouring tags two
+// tags must share to be thought duplicates.
+const minCooccurrenceSimilarity = 0.6
+
+// A TagUsage is a skill tag and the number of people who have it.
+type TagUsage struct {
+	Name     string `json:"name"`
+	Profiles int    `json:"profiles"`
+}
+
+// A DuplicateReason explains why a pair of tags are thought to be
+// duplicates.
+type DuplicateReason struct {
+	Tags   []string `json:"tags"`
+	Reason string   `json:"reason"`
+}
+
+// A DuplicateCluster is a group of tags which are likely to be duplicates.
+type DuplicateCluster struct {
+	// Tags are in order of use, most used first.
+	Tags []TagUsage `json:"tags"`
+	// Into is the suggested tag to merge the others into, the most used.
+	Into    string            `json:"into"`
+	Reasons []DuplicateReason `json:"reasons"`
+}
+
+// DuplicateTags clusters the tags which are likely to be duplicates, by
+// their spelling, aliases and how they're used in the profiles.
+func DuplicateTags(tags []SkillTag, profiles []Profile) []DuplicateCluster {
+	usage := make(map[string]int)
+	together := make(map[[2]string]bool)
+	neighbours := make(map[string]map[string]bool)
+	for _, p := range profiles {
+		for i, a := range p.Skills {
+			usage[a.Skill]++
+			if neighbours[a.Skill] == nil {
+				neighbours[a.Skill] = make(map[string]bool)
+			}
+			for j, b := range p.Skills {
+				if i != j {
+					neighbours[a.Skill][b.Skill] = true
+					together[pair(a.Skill, b.Skill)] = true
+				}
+			}
+		}
+	}
+
+	var names []string
+	aliases := make(map[string][]string)
+	for _, t := range tags {
+		names = append(names, t.Name)
+		aliases[t.Name] = t.Aliases
+	}
+	sort.Strings(names)
+
+	var reasons []DuplicateReason
+	for i, a := range names {
+		for _, b := range names[i+1:] {
+			var reason string
+			switch {
+			case similarSpelling(a, b):
+				reason = SpellingReason
+			case sharesAlias(a, aliases[a], b, aliases[b]):
+				reason = AliasReason
+			case !together[pair(a, b)] && usage[a] >= minCooccurrenceUsage && usage[b] >= minCooccurrenceUsage &&
+				similarity(neighbours[a], neighbours[b], a, b) >= minCooccurrenceSimilarity:
+				reason = CooccurrenceReason
+			default:
+				continue
+			}
+			reasons = append(reasons, DuplicateReason{Tags: []string{a, b}, Reason: reason})
+		}
+	}
+
+	// Pairs which share a tag are in the same cluster.
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(s string) string {
+		if p, ok := parent[s]; ok && p != s {
+			parent[s] = find(p)
+			return parent[s]
+		}
+		parent[s] = s
+		return s
+	}
+	for _, r := range reasons {
+		parent[find(r.Tags[1])] = find(r.Tags[0])
+	}
+
+	clusters := make(map[string]*DuplicateCluster)
+	for _, r := range reasons {
+		root := find(r.Tags[0])
+		c, ok := clusters[root]
+		if !ok {
+			c = &DuplicateCluster{}
+			clusters[root] = c
+		}
+		c.Reasons = append(c.Reasons, r)
+	}
+	for _, name := range names {
+		if c, ok := clusters[find(name)]; ok {
+			c.Tags = append(c.Tags, TagUsage{Name: name, Profiles: usage[name]})
+		}
+	}
+
+	op := []DuplicateCluster{}
+	for _, c := range clusters {
+		sort.SliceStable(c.Tags, func(i, j int) bool { return c.Tags[i].Profiles > c.Tags[j].Profiles })
+		c.Into = c.Tags[0].Name
+		op = append(op, *c)
+	}
+	sort.Slice(op, func(i, j int) bool { return op[i].Into < op[j].Into })
+	return op
+}
+
+func pair(a, b string) [2]string {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
+
+// similarSpelling returns true if the tags are the same apart from
+// punctuation, or are long enough that a single typo is more likely than a
+// different skill.
+func similarSpelling(a, b string) bool {
+	a, b = letters(a), letters(b)
+	if a == b {
+		return true
+	}
+	return len(a) >= 5 && len(b) >= 5 && editDistance(a, b) <= 1
+}
+
+func letters(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '#' || r == '+' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// editDistance is the Levenshtein distance between the strings.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(br)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+func sharesAlias(a string, aAliases []string, b string, bAliases []string) bool {
+	for _, x := range aAliases {
+		if x == b {
+			return true
+		}
+		for _, y := range bAliases {
+			if x == y {
+				return true
+			}
+		}
+	}
+	for _, y := range bAliases {
+		if y == a {
+			return true
+		}
+	}
+	return false
+}
+
+// similarity is the Jaccard index of the tags used alongside a and b.
+func similarity(a, b map[string]bool, aName, bName string) float64 {
+	var shared, total int
+	for t := range a {
+		if t == bName {
+			continue
+		}
+		total++
+		if b[t] {
+			shared++
+		}
+	}
+	for t := range b {
+		if t != aName && !a[t] {
+			total++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(shared) / float64(total)
+}
+
+// mergedTags returns the tags which MergeSkillTags merges into another.
+func mergedTags(into string, tags []string) map[string]bool {
+	into = CleanTag(into)
+	op := make(map[string]bool)
+	for _, t := range tags {
+		if t = CleanTag(t); t != "" && t != into {
+			op[t] = true
+		}
+	}
+	return op
+}
+
+// peopleWithTags lists the email addresses of the people with any of the
+// tags in their skills or learning goals.
+func peopleWithTags(da DataAccess, tags map[string]bool) ([]string, error) {
+	domains, err := da.ListDomains()
+	if err != nil {
+		return nil, err
+	}
+	var op []string
+	for _, domain := range domains {
+		profiles, err := da.ListProfiles("@" + domain)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range profiles {
+			if hasAnyTag(p, tags) {
+				op = append(op, p.EmailAddress)
+			}
+		}
+	}
+	return op, nil
+}
+
+func hasAnyTag(p Profile, tags map[string]bool) bool {
+	for _, s := range p.Skills {
+		if tags[s.Skill] {
+			return true
+		}
+	}
+	for _, g := range p.Goals {
+		if tags[g.Skill] {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSkills renames the skills to the tag they're merged into. If someone
+// has more than one of the skills, the highest level and interest, and the
+// most recent confirmation, are kept.
+func mergeSkills(skills []Skill, into string, merged map[string]bool) []Skill {
+	var op []Skill
+	index := make(map[string]int)
+	for _, s := range skills {
+		if merged[s.Skill] {
+			s.Skill = into
+		}
+		i, ok := index[s.Skill]
+		if !ok {
+			index[s.Skill] = len(op)
+			op = append(op, s)
+			continue
+		}
+		if s.Level > op[i].Level {
+			op[i].Level = s.Level
+		}
+		if s.Interest > op[i].Interest {
+			op[i].Interest = s.Interest
+		}
+		if s.Confirmed.After(op[i].Confirmed) {
+			op[i].Confirmed = s.Confirmed
+		}
+	}
+	return op
+}
+
+// MergeSkillTags replaces the tags with the tag they're merged into, in
+// every profile's skills, history and learning goals, and returns the number
+// of profiles which were changed. The merged tags are removed from the list
+// and become aliases of the tag they were merged into.
+func (da MongoDataAccess) MergeSkillTags(into string, tags []string) (merged int, err error) {
+	into = CleanTag(into)
+	from := make(map[string]bool)
+	var names []string
+	for _, t := range tags {
+		if t = CleanTag(t); t != "" && t != into && !from[t] {
+			from[t] = true
+			names = append(names, t)
+		}
+	}
+	if len(names) == 0 {
+		return 0, nil
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return 0, err
+	}
+	defer session.Close()
+
+	db := session.DB(da.databaseName)
+
+	var profiles []Profile
+	err = db.C("profiles").Find(bson.M{"$or": []bson.M{
+		{"skills.skill": bson.M{"$in": names}},
+		{"skillshistory.skills.skill": bson.M{"$in": names}},
+		{"goals.skill": bson.M{"$in": names}},
+	}}).All(&profiles)
+	if err != nil {
+		return 0, err
+	}
+	for _, p := range profiles {
+		p.Skills = mergeSkills(p.Skills, into, from)
+		for i := range p.SkillsHistory {
+			p.SkillsHistory[i].Skills = mergeSkills(p.SkillsHistory[i].Skills, into, from)
+		}
+		for i := range p.Goals {
+			if from[p.Goals[i].Skill] {
+				p.Goals[i].Skill = into
+			}
+		}
+		update := bson.M{"skills": p.Skills, "skillshistory": p.SkillsHistory, "goals": utcGoals(p.Goals)}
+		if err = db.C("profiles").UpdateId(p.EmailAddress, bson.M{"$set": update}); err != nil {
+			return merged, err
+		}
+		merged++
+	}
+
+	// Each shard has its own profiles, but the tags are only kept in one of
+	// them.
+	skills := db.C("skills")
+	n, err := skills.Find(bson.M{"_id": bson.M{"$in": append(names, into)}}).Count()
+	if err != nil || n == 0 {
+		return merged, err
+	}
+	var mergedTags []SkillTag
+	if err = skills.Find(bson.M{"_id": bson.M{"$in": names}}).All(&mergedTags); err != nil {
+		return merged, err
+	}
+	aliases := names
+	for _, t := range mergedTags {
+		for _, a := range t.Aliases {
+			if a != into {
+				aliases = append(aliases, a)
+			}
+		}
+	}
+	if _, err = skills.UpsertId(into, bson.M{"$addToSet": bson.M{"aliases": bson.M{"$each": aliases}}}); err != nil {
+		return merged, err
+	}
+	_, err = skills.RemoveAll(bson.M{"_id": bson.M{"$in": names}})
+	return merged, err
+}
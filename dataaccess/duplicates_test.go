@@ -0,0 +1,81 @@
+package dataaccess
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThatLikelyDuplicateTagsAreClustered(t *testing.T) {
+	tags := []SkillTag{
+		{Name: "javascript", Aliases: []string{"ecmascript"}},
+		{Name: "js", Aliases: []string{"ecmascript"}},
+		{Name: "node.js"},
+		{Name: "nodejs"},
+		{Name: "postgresql"},
+		{Name: "postgres"},
+		{Name: "psql"},
+		{Name: "kubernetes"},
+		{Name: "go"},
+	}
+	profile := func(skills ...string) Profile {
+		p := Profile{}
+		for _, s := range skills {
+			p.Skills = append(p.Skills, Skill{Skill: s})
+		}
+		return p
+	}
+	profiles := []Profile{
+		profile("postgres", "go", "kubernetes"),
+		profile("postgres", "go", "kubernetes"),
+		profile("postgres", "go", "kubernetes"),
+		profile("psql", "go", "kubernetes"),
+		profile("psql", "go", "kubernetes"),
+		profile("psql", "go", "kubernetes"),
+		profile("node.js", "javascript"),
+	}
+
+	clusters := DuplicateTags(tags, profiles)
+
+	var actual [][]string
+	for _, c := range clusters {
+		var names []string
+		for _, t := range c.Tags {
+			names = append(names, t.Name)
+		}
+		actual = append(actual, names)
+	}
+	expected := [][]string{
+		{"javascript", "js"},
+		{"node.js", "nodejs"},
+		{"postgres", "psql"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected clusters %v, but got %v", expected, actual)
+	}
+	if clusters[1].Into != "node.js" || clusters[1].Reasons[0].Reason != SpellingReason {
+		t.Errorf("expected node.js to be suggested for its spelling, but got %+v", clusters[1])
+	}
+	if clusters[0].Reasons[0].Reason != AliasReason {
+		t.Errorf("expected javascript and js to share an alias, but got %+v", clusters[0])
+	}
+	if clusters[2].Reasons[0].Reason != CooccurrenceReason {
+		t.Errorf("expected postgres and psql to be used alongside the same tags, but got %+v", clusters[2])
+	}
+}
+
+func TestThatMergedSkillsKeepTheHighestLevel(t *testing.T) {
+	skills := []Skill{
+		{Skill: "js", Level: ExpertLevel, Interest: Agree},
+		{Skill: "go", Level: CompetentLevel},
+		{Skill: "javascript", Level: CompetentLevel, Interest: StronglyAgree},
+	}
+
+	actual := mergeSkills(skills, "javascript", map[string]bool{"js": true})
+	expected := []Skill{
+		{Skill: "javascript", Level: ExpertLevel, Interest: StronglyAgree},
+		{Skill: "go", Level: CompetentLevel},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, but got %v", expected, actual)
+	}
+}
@@ -0,0 +1,78 @@
+package dataaccess
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Employment is the person's record in the tenant's HR system.
+type Employment struct {
+	// EmployeeID is the person's ID in the HR system.
+	EmployeeID string `json:"employeeId,omitempty"`
+	// Terminated is when the person left, or zero if they still work for the
+	// tenant.
+	Terminated time.Time `json:"terminated,omitempty"`
+	// Synced is when the record was last read from the HR system.
+	Synced time.Time `json:"synced,omitempty"`
+}
+
+// Active returns true if the person hasn't left.
+func (e Employment) Active() bool {
+	return e.Terminated.IsZero()
+}
+
+// An EmployeeUpdate is the fields of a profile which are owned by the HR
+// system.
+type EmployeeUpdate struct {
+	EmailAddress string
+	Name         string
+	Manager      string
+	// Department and CostCenter are left unchanged if they're nil, e.g.
+	// because the HR system doesn't have them.
+	Department *string
+	CostCenter *string
+	Employment Employment
+}
+
+// SyncEmployee sets the fields of the profile which come from the HR system,
+// creating the profile if the person doesn't have one yet. The person's
+// skills are left alone, and the change isn't recorded in their skills
+// history. The employee ID is also recorded as the person's HRIS ID.
+func (da MongoDataAccess) SyncEmployee(e EmployeeUpdate) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	emailAddress := strings.ToLower(e.EmailAddress)
+	employment := e.Employment
+	employment.Terminated = employment.Terminated.UTC().Truncate(time.Millisecond)
+	employment.Synced = employment.Synced.UTC().Truncate(time.Millisecond)
+	set := bson.M{
+		"name":       e.Name,
+		"manager":    strings.ToLower(e.Manager),
+		"employment": employment,
+	}
+	if employment.EmployeeID != "" {
+		set["externalids."+HRISSystem] = employment.EmployeeID
+	}
+	if e.Department != nil {
+		set["department"] = strings.TrimSpace(*e.Department)
+	}
+	if e.CostCenter != nil {
+		set["costcenter"] = strings.TrimSpace(*e.CostCenter)
+	}
+	_, err = session.DB(da.databaseName).C("profiles").UpsertId(emailAddress, bson.M{
+		"$set": set,
+		"$setOnInsert": bson.M{
+			"domain":      GetDomain(emailAddress),
+			"lastupdated": employment.Synced,
+		},
+	})
+	return err
+}
@@ -0,0 +1,389 @@
+package dataaccess
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+// profileEventAttempts is how many times a change is recorded before giving
+// up, if other changes keep being recorded first.
+const profileEventAttempts = 3
+
+// EventSourcingDataAccess wraps a DataAccess and records every change to a
+// person's skills, availability, bookings and details as events in an
+// append-only log, before the change is made. The log is the source of
+// truth: the profile's copy of that state is rebuilt from it after every
+// change, so that searches and reports read it as before. Every
+// SnapshotInterval events, the state is saved as a snapshot, so that
+// deriving it never replays more than that many events.
+type EventSourcingDataAccess struct {
+	DataAccess
+	SnapshotInterval int
+	now              func() time.Time
+}
+
+// NewEventSourcingDataAccess creates a DataAccess which records changes to
+// profiles as events.
+func NewEventSourcingDataAccess(da DataAccess, snapshotInterval int) *EventSourcingDataAccess {
+	if snapshotInterval <= 0 {
+		snapshotInterval = DefaultSnapshotInterval
+	}
+	return &EventSourcingDataAccess{da, snapshotInterval, time.Now}
+}
+
+// WithContext passes the context to the wrapped DataAccess.
+func (da EventSourcingDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &EventSourcingDataAccess{WithContext(da.DataAccess, ctx), da.SnapshotInterval, da.now}
+}
+
+// change records the events added by f, retrying if other changes are
+// recorded first, and returns the state after them. People whose log
+// doesn't yet hold their whole profile, e.g. because it was started before
+// bookings and details were recorded, have the rest of their profile
+// recorded first. If the person has no profile, f is only called if create
+// is true, otherwise mgo.ErrNotFound is returned.
+func (da EventSourcingDataAccess) change(emailAddress string, create bool, f func(l *profileEventLog) error) (ProfileSnapshot, error) {
+	var err error
+	var state ProfileSnapshot
+	for attempt := 0; attempt < profileEventAttempts; attempt++ {
+		if state, err = da.append(emailAddress, create, f); err != ErrProfileEventConflict {
+			return state, err
+		}
+	}
+	return state, err
+}
+
+func (da EventSourcingDataAccess) append(emailAddress string, create bool, f func(l *profileEventLog) error) (ProfileSnapshot, error) {
+	state, err := ProfileAt(da.DataAccess, emailAddress, time.Time{})
+	if err != nil {
+		return state, err
+	}
+	l := newProfileEventLog(emailAddress, state, da.now().UTC().Truncate(time.Millisecond))
+	if state.Details == nil {
+		p, found, err := da.DataAccess.GetProfile(emailAddress)
+		if err != nil {
+			return state, err
+		}
+		if !found && !create {
+			return state, mgo.ErrNotFound
+		}
+		if found {
+			l.profile(*p)
+		}
+	}
+	if err := f(l); err != nil {
+		return state, err
+	}
+	if len(l.events) == 0 {
+		return state, nil
+	}
+	if err := da.DataAccess.AppendProfileEvents(l.events); err != nil {
+		return state, err
+	}
+
+	latest := l.state
+	if latest.Sequence/da.SnapshotInterval != state.Sequence/da.SnapshotInterval {
+		// The events have been recorded, so a missing snapshot only slows
+		// down reads.
+		if err := da.DataAccess.SaveProfileSnapshot(&latest); err != nil {
+			log.Printf("Failed to save a snapshot of %s. %v", emailAddress, err)
+		}
+	}
+	return latest, nil
+}
+
+// RebuildProfile sets the person's skills, availability, bookings and
+// details to the state derived from their events.
+func (da EventSourcingDataAccess) RebuildProfile(emailAddress string) error {
+	state, err := ProfileAt(da.DataAccess, emailAddress, time.Time{})
+	if err != nil || state.Sequence == 0 {
+		return err
+	}
+	return da.DataAccess.ProjectProfile(&state)
+}
+
+// UpdateProfile records the changes as events, updates the rest of the
+// profile, and rebuilds it from the events.
+func (da EventSourcingDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	if update.TimeZone != nil {
+		if err := ValidateTimeZone(*update.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+	state, err := da.change(update.EmailAddress, true, func(l *profileEventLog) error {
+		previous := &Profile{Skills: l.state.Skills, LastUpdated: l.state.Time}
+		l.skills(confirmedSkills(previous, update.Skills, l.now))
+		l.availability(update.Availability)
+		var d ProfileDetails
+		if l.state.Details != nil {
+			d = *l.state.Details
+		}
+		if update.Name != nil {
+			d.Name = *update.Name
+		}
+		if update.Manager != nil {
+			d.Manager = *update.Manager
+		}
+		if update.Department != nil {
+			d.Department = *update.Department
+		}
+		if update.CostCenter != nil {
+			d.CostCenter = *update.CostCenter
+		}
+		l.details(d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	p, err := da.DataAccess.UpdateProfile(update)
+	if err != nil {
+		return p, err
+	}
+	if err := da.DataAccess.ProjectProfile(&state); err != nil {
+		return p, err
+	}
+	state.ApplyTo(p)
+	return p, nil
+}
+
+// ImportProfile records the differences from the person's events, imports
+// the rest of the profile, and rebuilds it from the events.
+func (da EventSourcingDataAccess) ImportProfile(p *Profile) error {
+	_, err := da.change(p.EmailAddress, true, func(l *profileEventLog) error {
+		l.profile(*p)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := da.DataAccess.ImportProfile(p); err != nil {
+		return err
+	}
+	return da.RebuildProfile(p.EmailAddress)
+}
+
+// RestoreProfile restores the profile and rebuilds it from the events, which
+// were kept while it was archived.
+func (da EventSourcingDataAccess) RestoreProfile(emailAddress string) (bool, error) {
+	restored, err := da.DataAccess.RestoreProfile(emailAddress)
+	if err != nil || !restored {
+		return restored, err
+	}
+	// The restored profile is recorded if the person has no events.
+	if _, err := da.change(emailAddress, false, func(l *profileEventLog) error { return nil }); err != nil {
+		return restored, err
+	}
+	return restored, da.RebuildProfile(emailAddress)
+}
+
+// ConfirmSkills records that the person's levels of the skills are still
+// correct, and rebuilds their profile from the events.
+func (da EventSourcingDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	state, err := da.change(emailAddress, false, func(l *profileEventLog) error {
+		for _, s := range l.state.Skills {
+			for _, name := range skills {
+				if s.Skill == strings.ToLower(name) {
+					confirmed := s
+					confirmed.Confirmed = l.now
+					l.add(ProfileEvent{Type: SkillConfirmed, Skill: &confirmed})
+				}
+			}
+		}
+		return nil
+	})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return da.DataAccess.ProjectProfile(&state)
+}
+
+// MergeSkillTags records the merged tags as changes to the skills of the
+// people who have them, merges them in the rest of their profiles, and
+// rebuilds their profiles from the events.
+func (da EventSourcingDataAccess) MergeSkillTags(into string, tags []string) (int, error) {
+	from := mergedTags(into, tags)
+	if len(from) == 0 {
+		return da.DataAccess.MergeSkillTags(into, tags)
+	}
+	people, err := peopleWithTags(da.DataAccess, from)
+	if err != nil {
+		return 0, err
+	}
+	for _, emailAddress := range people {
+		_, err := da.change(emailAddress, false, func(l *profileEventLog) error {
+			l.skills(mergeSkills(l.state.Skills, CleanTag(into), from))
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	merged, err := da.DataAccess.MergeSkillTags(into, tags)
+	if err != nil {
+		return merged, err
+	}
+	for _, emailAddress := range people {
+		if err := da.RebuildProfile(emailAddress); err != nil {
+			return merged, err
+		}
+	}
+	return merged, nil
+}
+
+// SyncEmployee records the changes to the person's details, sets the rest of
+// the fields which come from the HR system, and rebuilds the profile from
+// the events.
+func (da EventSourcingDataAccess) SyncEmployee(e EmployeeUpdate) error {
+	_, err := da.change(e.EmailAddress, true, func(l *profileEventLog) error {
+		d := ProfileDetails{Name: e.Name, Manager: e.Manager}
+		if l.state.Details != nil {
+			d.Department, d.CostCenter = l.state.Details.Department, l.state.Details.CostCenter
+		}
+		if e.Department != nil {
+			d.Department = *e.Department
+		}
+		if e.CostCenter != nil {
+			d.CostCenter = *e.CostCenter
+		}
+		l.details(d)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := da.DataAccess.SyncEmployee(e); err != nil {
+		return err
+	}
+	return da.RebuildProfile(e.EmailAddress)
+}
+
+// UpdateAvailabilityWindows records the person's new availability windows,
+// and rebuilds their profile from the events.
+func (da EventSourcingDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error {
+	if err := ValidateAvailabilityWindows(windows); err != nil {
+		return err
+	}
+	state, err := da.change(emailAddress, false, func(l *profileEventLog) error {
+		l.windows(windows)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return da.DataAccess.ProjectProfile(&state)
+}
+
+// AddBooking records the booking, returning ErrOverbooked if it conflicts
+// with the person's other bookings, and rebuilds their profile from the
+// events.
+func (da EventSourcingDataAccess) AddBooking(emailAddress string, b Booking) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	b = utcBooking(b)
+	state, err := da.change(emailAddress, false, func(l *profileEventLog) error {
+		if PeakAllocation(append(l.state.Bookings, b), b.Start, b.End) > FullAllocation {
+			return ErrOverbooked
+		}
+		l.add(ProfileEvent{Type: BookingAdded, Booking: &b})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return da.DataAccess.ProjectProfile(&state)
+}
+
+// RemoveBooking records the cancellation of the booking, and rebuilds the
+// person's profile from the events.
+func (da EventSourcingDataAccess) RemoveBooking(emailAddress string, id string) error {
+	state, err := da.change(emailAddress, false, func(l *profileEventLog) error {
+		for _, b := range l.state.Bookings {
+			if b.ID == id {
+				l.add(ProfileEvent{Type: BookingRemoved, Booking: &Booking{ID: id}})
+				return nil
+			}
+		}
+		return ErrBookingNotFound
+	})
+	if err == mgo.ErrNotFound {
+		return ErrBookingNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return da.DataAccess.ProjectProfile(&state)
+}
+
+// MergeProfiles records the combined profile as changes to the primary, and
+// the primary as the new manager of the people the duplicate managed, then
+// merges the profiles and rebuilds them from the events. The duplicate's
+// events are deleted along with it.
+func (da EventSourcingDataAccess) MergeProfiles(primary string, duplicate string) (*Profile, bool, error) {
+	primary, duplicate = strings.ToLower(primary), strings.ToLower(duplicate)
+	if primary == duplicate {
+		return nil, false, ErrMergeSelf
+	}
+	p, found, err := da.DataAccess.GetProfile(primary)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	d, found, err := da.DataAccess.GetProfile(duplicate)
+	if err != nil || !found {
+		return nil, found, err
+	}
+	combined := CombineProfiles(*p, *d)
+	state, err := da.change(primary, false, func(l *profileEventLog) error {
+		l.profile(combined)
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	profiles, err := da.DataAccess.ListProfiles(primary)
+	if err != nil {
+		return nil, false, err
+	}
+	var reports []string
+	for _, r := range profiles {
+		if r.Manager != duplicate || r.EmailAddress == duplicate {
+			continue
+		}
+		_, err := da.change(r.EmailAddress, false, func(l *profileEventLog) error {
+			details := r.Details()
+			if l.state.Details != nil {
+				details = *l.state.Details
+			}
+			details.Manager = primary
+			l.details(details)
+			return nil
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		reports = append(reports, r.EmailAddress)
+	}
+
+	merged, found, err := da.DataAccess.MergeProfiles(primary, duplicate)
+	if err != nil || !found {
+		return merged, found, err
+	}
+	if err := da.DataAccess.ProjectProfile(&state); err != nil {
+		return merged, found, err
+	}
+	state.ApplyTo(merged)
+	for _, r := range reports {
+		if err := da.RebuildProfile(r); err != nil {
+			return merged, found, err
+		}
+	}
+	return merged, found, nil
+}
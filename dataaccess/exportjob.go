@@ -0,0 +1,173 @@
+package dataaccess
+
+import (
+	"log"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// An ExportStatus is the state of an export job.
+type ExportStatus string
+
+// The states of an export job.
+const (
+	ExportPending   ExportStatus = "pending"
+	ExportRunning   ExportStatus = "running"
+	ExportSucceeded ExportStatus = "succeeded"
+	ExportFailed    ExportStatus = "failed"
+)
+
+// ExportTimeout is how long an export job can run before it's assumed that
+// the instance running it has stopped, and it's run again.
+const ExportTimeout = time.Hour
+
+// An ExportJob is a request to export a domain's data, which is written to
+// blob storage in the background, so that large exports don't time out.
+type ExportJob struct {
+	ID     string `bson:"_id" json:"id"`
+	Domain string `json:"domain"`
+	// RequestedBy is the email address of the person who asked for the
+	// export.
+	RequestedBy string `json:"requestedBy"`
+	// Kind is what is exported, e.g. profiles, and Format is the file
+	// format, e.g. xlsx.
+	Kind   string       `json:"kind"`
+	Format string       `json:"format"`
+	Status ExportStatus `json:"status"`
+	// Error is why the export failed.
+	Error    string    `json:"error,omitempty"`
+	Created  time.Time `json:"created"`
+	Started  time.Time `json:"started,omitempty"`
+	Finished time.Time `json:"finished,omitempty"`
+	// Blob is the name of the exported file in blob storage, and Size is its
+	// length in bytes.
+	Blob string `json:"-"`
+	Size int    `json:"size,omitempty"`
+	Rows int    `json:"rows,omitempty"`
+	// Exported are the email addresses of the people in the file, whose
+	// access logs record each download.
+	Exported []string `json:"-"`
+	// Expires is when the job and its file are deleted.
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// NewExportJob creates a pending export job.
+func NewExportJob(domain string, requestedBy string, kind string, format string, now time.Time) *ExportJob {
+	return &ExportJob{
+		ID:          bson.NewObjectId().Hex(),
+		Domain:      domain,
+		RequestedBy: requestedBy,
+		Kind:        kind,
+		Format:      format,
+		Status:      ExportPending,
+		Created:     now.UTC().Truncate(time.Millisecond),
+	}
+}
+
+// Done returns true if the job has succeeded or failed.
+func (j ExportJob) Done() bool {
+	return j.Status == ExportSucceeded || j.Status == ExportFailed
+}
+
+func (j *ExportJob) inUTC() {
+	j.Created = j.Created.UTC()
+	j.Started = j.Started.UTC()
+	j.Finished = j.Finished.UTC()
+	j.Expires = j.Expires.UTC()
+}
+
+// SaveExportJob creates or updates the export job.
+func (da MongoDataAccess) SaveExportJob(j *ExportJob) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	_, err = session.DB(da.databaseName).C("exportjobs").UpsertId(j.ID, j)
+	return err
+}
+
+// GetExportJob returns the export job, if it exists.
+func (da MongoDataAccess) GetExportJob(id string) (*ExportJob, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	var j ExportJob
+	err = session.DB(da.databaseName).C("exportjobs").FindId(id).One(&j)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	j.inUTC()
+	return &j, true, nil
+}
+
+// ClaimExportJob marks the oldest pending export job as running, and returns
+// it, so that each job is run by a single instance. Jobs which have been
+// running for longer than the ExportTimeout are claimed again. It returns
+// false if there are no jobs to run.
+func (da MongoDataAccess) ClaimExportJob(now time.Time) (*ExportJob, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	now = now.UTC().Truncate(time.Millisecond)
+	q := bson.M{"$or": []bson.M{
+		{"status": ExportPending},
+		{"status": ExportRunning, "started": bson.M{"$lt": now.Add(-ExportTimeout)}},
+	}}
+	change := mgo.Change{
+		Update:    bson.M{"$set": bson.M{"status": ExportRunning, "started": now}},
+		ReturnNew: true,
+	}
+	var j ExportJob
+	_, err = session.DB(da.databaseName).C("exportjobs").Find(q).Sort("created").Apply(change, &j)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	j.inUTC()
+	return &j, true, nil
+}
+
+// PurgeExportJobs deletes the finished export jobs which expired before the
+// time, and returns them, so that their files can be deleted.
+func (da MongoDataAccess) PurgeExportJobs(before time.Time) ([]ExportJob, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	c := session.DB(da.databaseName).C("exportjobs")
+	q := bson.M{"status": bson.M{"$in": []ExportStatus{ExportSucceeded, ExportFailed}}, "expires": bson.M{"$lt": before.UTC()}}
+	var expired []ExportJob
+	if err := c.Find(q).All(&expired); err != nil {
+		return nil, err
+	}
+	if len(expired) == 0 {
+		return expired, nil
+	}
+	ids := make([]string, len(expired))
+	for i, j := range expired {
+		ids[i] = j.ID
+	}
+	_, err = c.RemoveAll(bson.M{"_id": bson.M{"$in": ids}})
+	return expired, err
+}
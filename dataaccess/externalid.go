@@ -0,0 +1,130 @@
+package dataaccess
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The systems people's IDs are commonly mapped to. Other systems can be
+// used, as long as their names are valid.
+const (
+	// HRISSystem is the employee ID in the HR system, which is kept in step
+	// with the Employment by the HR sync.
+	HRISSystem = "hris"
+	// AzureADSystem is the objectGUID of the person's Active Directory user.
+	AzureADSystem = "azuread"
+	// SlackSystem is the person's Slack member ID, e.g. "U024BE7LH".
+	SlackSystem = "slack"
+	// GitHubSystem is the person's GitHub handle.
+	GitHubSystem = "github"
+)
+
+// systemName is the form of the names of external systems. They're used as
+// field names in the database, so can't contain dots.
+var systemName = regexp.MustCompile(`^[a-z][a-z0-9]{0,31}$`)
+
+// CheckExternalIDs returns an error if the names of the systems aren't
+// valid. An empty ID removes the person's ID in that system.
+func CheckExternalIDs(ids map[string]string) error {
+	var problems []string
+	for system, id := range ids {
+		if !systemName.MatchString(system) {
+			problems = append(problems, "the system name "+system+" must be lowercase letters and numbers")
+		}
+		if strings.TrimSpace(id) != id {
+			problems = append(problems, "the "+system+" ID must not start or end with spaces")
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return newValidationError(problems)
+	}
+	return nil
+}
+
+// FindProfileByExternalID finds the profile in the domain with the ID in
+// the external system.
+func (da MongoDataAccess) FindProfileByExternalID(domain string, system string, id string) (*Profile, bool, error) {
+	if !systemName.MatchString(system) || id == "" {
+		return nil, false, nil
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	p := NewProfile()
+	err = session.DB(da.databaseName).C("profiles").Find(bson.M{"domain": domain, "externalids." + system: id}).One(p)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	p.inUTC()
+	return p, true, nil
+}
+
+// UpdateExternalIDs sets the person's IDs in the external systems, and
+// removes those which are empty. IDs belonging to other people in the
+// tenant are rejected with a ValidationError, so that lookups by them are
+// never ambiguous.
+func (da MongoDataAccess) UpdateExternalIDs(emailAddress string, ids map[string]string) error {
+	if err := CheckExternalIDs(ids); err != nil {
+		return err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	c := session.DB(da.databaseName).C("profiles")
+	emailAddress = strings.ToLower(emailAddress)
+	set, unset := bson.M{}, bson.M{}
+	var problems []string
+	for system, id := range ids {
+		if id == "" {
+			unset["externalids."+system] = ""
+			continue
+		}
+		set["externalids."+system] = id
+		var other Profile
+		err = c.Find(bson.M{
+			"_id":                   bson.M{"$ne": emailAddress},
+			"domain":                GetDomain(emailAddress),
+			"externalids." + system: id,
+		}).Select(bson.M{"_id": 1}).One(&other)
+		if err == nil {
+			problems = append(problems, "the "+system+" ID "+id+" belongs to "+other.EmailAddress)
+		} else if err != mgo.ErrNotFound {
+			return err
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return newValidationError(problems)
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if len(update) == 0 {
+		return nil
+	}
+	return c.UpdateId(emailAddress, update)
+}
@@ -0,0 +1,24 @@
+package dataaccess
+
+import "testing"
+
+func TestThatExternalSystemsMustHaveValidNames(t *testing.T) {
+	tests := []struct {
+		ids         map[string]string
+		expectedErr bool
+	}{
+		{map[string]string{SlackSystem: "U024BE7LH", GitHubSystem: ""}, false},
+		{map[string]string{"jira2": "adrian"}, false},
+		{map[string]string{"git.hub": "a-h"}, true},
+		{map[string]string{"$where": "1"}, true},
+		{map[string]string{"Slack": "U024BE7LH"}, true},
+		{map[string]string{SlackSystem: " U024BE7LH"}, true},
+	}
+
+	for _, test := range tests {
+		err := CheckExternalIDs(test.ids)
+		if _, ok := err.(ValidationError); ok != test.expectedErr {
+			t.Errorf("For %v, expected a validation error %v, but got %v", test.ids, test.expectedErr, err)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package dataaccess
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A GoalStatus is the progress made towards a learning goal.
+type GoalStatus string
+
+// The states of a learning goal.
+const (
+	GoalPlanned    GoalStatus = "planned"
+	GoalInProgress GoalStatus = "inProgress"
+	GoalAchieved   GoalStatus = "achieved"
+	GoalAbandoned  GoalStatus = "abandoned"
+)
+
+// A LearningGoal is a level someone wants to reach in a skill by a date,
+// e.g. level 3 in Terraform by the end of Q3.
+type LearningGoal struct {
+	ID     string       `json:"id"`
+	Skill  string       `json:"skill"`
+	Target DreyfusLevel `json:"target"`
+	Due    time.Time    `json:"due"`
+	Status GoalStatus   `json:"status"`
+	Note   string       `json:"note,omitempty"`
+	// Reminded is when the person was last reminded about the goal.
+	Reminded time.Time `json:"reminded,omitempty"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+}
+
+// Validate checks that the goal has a skill, a target level on the Dreyfus
+// scale, a due date and a known status.
+func (g LearningGoal) Validate() error {
+	var problems []string
+	if CleanTag(g.Skill) == "" {
+		problems = append(problems, "the skill is required")
+	}
+	if g.Target < NoviceLevel || g.Target > MasterLevel {
+		problems = append(problems, "the target level must be between 1 and 5")
+	}
+	if g.Due.IsZero() {
+		problems = append(problems, "the due date is required")
+	}
+	switch g.Status {
+	case GoalPlanned, GoalInProgress, GoalAchieved, GoalAbandoned:
+	default:
+		problems = append(problems, "the status must be planned, inProgress, achieved or abandoned")
+	}
+	return newValidationError(problems)
+}
+
+// Open returns true if the goal hasn't been achieved or abandoned.
+func (g LearningGoal) Open() bool {
+	return g.Status == GoalPlanned || g.Status == GoalInProgress
+}
+
+// Overdue returns true if the goal is open after its due date.
+func (g LearningGoal) Overdue(t time.Time) bool {
+	return g.Open() && t.After(g.Due)
+}
+
+// NewLearningGoal creates a planned goal.
+func NewLearningGoal(skill string, target DreyfusLevel, due time.Time, at time.Time) LearningGoal {
+	at = at.UTC().Truncate(time.Millisecond)
+	return LearningGoal{
+		ID:      bson.NewObjectId().Hex(),
+		Skill:   CleanTag(strings.TrimSpace(skill)),
+		Target:  target,
+		Due:     due.UTC().Truncate(time.Millisecond),
+		Status:  GoalPlanned,
+		Created: at,
+		Updated: at,
+	}
+}
+
+// ParseDue reads when a goal is due, either a quarter, e.g. "Q3" or
+// "Q3 2018", or a date, e.g. "2018-09-30". Quarters are due at the end of
+// their last day. Quarters without a year are the next one with that
+// number.
+func ParseDue(s string, at time.Time) (time.Time, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if !strings.HasPrefix(s, "Q") {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return d, fmt.Errorf("dataaccess: the due date must be a quarter, such as Q3, or a date, such as 2018-09-30")
+		}
+		return d.Add(24*time.Hour - time.Millisecond), nil
+	}
+
+	parts := strings.Fields(s)
+	q, err := strconv.Atoi(strings.TrimPrefix(parts[0], "Q"))
+	if err != nil || q < 1 || q > 4 || len(parts) > 2 {
+		return time.Time{}, fmt.Errorf("dataaccess: the quarter must be Q1, Q2, Q3 or Q4, but was '%s'", s)
+	}
+	year := at.Year()
+	if len(parts) == 2 {
+		if year, err = strconv.Atoi(parts[1]); err != nil {
+			return time.Time{}, fmt.Errorf("dataaccess: the year of the quarter must be a number, but was '%s'", parts[1])
+		}
+	}
+	end := time.Date(year, time.Month(q*3+1), 1, 0, 0, 0, 0, time.UTC).Add(-time.Millisecond)
+	if len(parts) == 1 && end.Before(at) {
+		end = time.Date(year+1, time.Month(q*3+1), 1, 0, 0, 0, 0, time.UTC).Add(-time.Millisecond)
+	}
+	return end, nil
+}
+
+// ValidateLearningGoals checks each of the goals.
+func ValidateLearningGoals(goals []LearningGoal) error {
+	var problems []string
+	for _, g := range goals {
+		if err := g.Validate(); err != nil {
+			problems = append(problems, err.(ValidationError).Problems...)
+		}
+	}
+	return newValidationError(problems)
+}
+
+// A WantToLearn is a skill someone is interested in using, but hasn't become
+// proficient in yet, with their learning goal for it, if they have one.
+type WantToLearn struct {
+	Skill    string        `json:"skill"`
+	Level    DreyfusLevel  `json:"level"`
+	Interest LikertScale   `json:"interest"`
+	Goal     *LearningGoal `json:"goal,omitempty"`
+}
+
+// WantToLearn returns the skills the person agrees they're interested in
+// using, which they're below the proficient level in, and the skills they
+// have open learning goals for.
+func (p Profile) WantToLearn() []WantToLearn {
+	op := []WantToLearn{}
+	listed := make(map[string]int)
+	for _, s := range p.Skills {
+		if s.Interest >= Agree && s.Level < ProficientLevel {
+			listed[s.Skill] = len(op)
+			op = append(op, WantToLearn{Skill: s.Skill, Level: s.Level, Interest: s.Interest})
+		}
+	}
+	for i := range p.Goals {
+		g := &p.Goals[i]
+		if !g.Open() {
+			continue
+		}
+		if j, ok := listed[g.Skill]; ok {
+			op[j].Goal = g
+			continue
+		}
+		w := WantToLearn{Skill: g.Skill, Goal: g}
+		for _, s := range p.Skills {
+			if s.Skill == g.Skill {
+				w.Level, w.Interest = s.Level, s.Interest
+			}
+		}
+		listed[g.Skill] = len(op)
+		op = append(op, w)
+	}
+	return op
+}
+
+// AchieveLearningGoals marks the open goals whose target levels the person
+// has reached as achieved, returning true if any were.
+func (p *Profile) AchieveLearningGoals(at time.Time) bool {
+	changed := false
+	for i, g := range p.Goals {
+		if !g.Open() {
+			continue
+		}
+		for _, s := range p.Skills {
+			if s.Skill == g.Skill && s.Level >= g.Target {
+				p.Goals[i].Status = GoalAchieved
+				p.Goals[i].Updated = at.UTC().Truncate(time.Millisecond)
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// UpdateLearningGoals replaces the person's learning goals.
+func (da MongoDataAccess) UpdateLearningGoals(emailAddress string, goals []LearningGoal) error {
+	if err := ValidateLearningGoals(goals); err != nil {
+		return err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(da.databaseName).C("profiles").UpdateId(emailAddress, bson.M{"$set": bson.M{"goals": utcGoals(goals)}})
+}
+
+// utcGoals converts the goals' times to UTC.
+func utcGoals(goals []LearningGoal) []LearningGoal {
+	if goals == nil {
+		return nil
+	}
+	op := make([]LearningGoal, len(goals))
+	for i, g := range goals {
+		g.Due, g.Reminded = g.Due.UTC(), g.Reminded.UTC()
+		g.Created, g.Updated = g.Created.UTC(), g.Updated.UTC()
+		op[i] = g
+	}
+	return op
+}
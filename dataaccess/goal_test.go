@@ -0,0 +1,83 @@
+package dataaccess
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThatDueDatesAreParsed(t *testing.T) {
+	at := time.Date(2017, time.August, 14, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		input    string
+		expected time.Time
+		err      bool
+	}{
+		{"Q3", time.Date(2017, time.October, 1, 0, 0, 0, 0, time.UTC).Add(-time.Millisecond), false},
+		{"q1", time.Date(2018, time.April, 1, 0, 0, 0, 0, time.UTC).Add(-time.Millisecond), false},
+		{"Q4 2018", time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC).Add(-time.Millisecond), false},
+		{"2017-09-30", time.Date(2017, time.October, 1, 0, 0, 0, 0, time.UTC).Add(-time.Millisecond), false},
+		{"Q5", time.Time{}, true},
+		{"soon", time.Time{}, true},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseDue(test.input, at)
+		if (err != nil) != test.err {
+			t.Errorf("For '%s', expected an error: %v, but received %v", test.input, test.err, err)
+			continue
+		}
+		if !actual.Equal(test.expected) {
+			t.Errorf("For '%s', expected %v, but received %v", test.input, test.expected, actual)
+		}
+	}
+}
+
+func TestThatTheWantToLearnListIncludesGoals(t *testing.T) {
+	at := time.Date(2017, time.August, 14, 0, 0, 0, 0, time.UTC)
+	terraform := NewLearningGoal("Terraform", ProficientLevel, at.AddDate(0, 2, 0), at)
+	kubernetes := NewLearningGoal("kubernetes", CompetentLevel, at.AddDate(0, 2, 0), at)
+	abandoned := NewLearningGoal("cobol", CompetentLevel, at.AddDate(0, 2, 0), at)
+	abandoned.Status = GoalAbandoned
+	p := Profile{
+		Skills: []Skill{
+			{Skill: "terraform", Level: NoviceLevel, Interest: StronglyAgree},
+			{Skill: "rust", Level: CompetentLevel, Interest: Agree},
+			{Skill: "go", Level: ExpertLevel, Interest: StronglyAgree},
+			{Skill: "cobol", Level: NoviceLevel, Interest: Disagree},
+		},
+		Goals: []LearningGoal{terraform, kubernetes, abandoned},
+	}
+
+	actual := p.WantToLearn()
+
+	if len(actual) != 3 || actual[0].Skill != "terraform" || actual[1].Skill != "rust" || actual[2].Skill != "kubernetes" {
+		t.Fatalf("Expected terraform, rust and kubernetes, but received %v", actual)
+	}
+	if actual[0].Goal == nil || actual[0].Goal.ID != terraform.ID || actual[1].Goal != nil {
+		t.Errorf("Expected only terraform and kubernetes to be linked to goals, but received %v", actual)
+	}
+}
+
+func TestThatGoalsAreAchievedAtTheTargetLevel(t *testing.T) {
+	at := time.Date(2017, time.August, 14, 0, 0, 0, 0, time.UTC)
+	p := Profile{
+		Skills: []Skill{{Skill: "terraform", Level: ProficientLevel}, {Skill: "go", Level: NoviceLevel}},
+		Goals: []LearningGoal{
+			NewLearningGoal("terraform", ProficientLevel, at, at),
+			NewLearningGoal("go", ExpertLevel, at, at),
+		},
+	}
+
+	if !p.AchieveLearningGoals(at) {
+		t.Fatal("Expected a goal to be achieved.")
+	}
+	if p.Goals[0].Status != GoalAchieved || p.Goals[1].Status != GoalPlanned {
+		t.Errorf("Expected only the terraform goal to be achieved, but received %v", p.Goals)
+	}
+	if p.AchieveLearningGoals(at) {
+		t.Error("Expected goals to be achieved once.")
+	}
+	if !p.Goals[1].Overdue(at.Add(time.Hour)) || p.Goals[0].Overdue(at.Add(time.Hour)) {
+		t.Error("Expected only the open goal to be overdue.")
+	}
+}
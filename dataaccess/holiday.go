@@ -0,0 +1,30 @@
+package dataaccess
+
+// HolidaySource is the Source of the availability windows of public
+// holidays.
+const HolidaySource = "holidays"
+
+// HolidaySettings set the public holiday calendar used for people whose work
+// location doesn't give their country.
+type HolidaySettings struct {
+	// Country is the ISO 3166-1 alpha-2 code of the country, e.g. "GB". If
+	// it's empty, only people with a work location are given public
+	// holidays.
+	Country string `json:"country,omitempty"`
+}
+
+func (s HolidaySettings) problems() []string {
+	if s.Country != "" && !countryCode.MatchString(s.Country) {
+		return []string{"the holiday country must be a two letter ISO 3166-1 code, such as GB"}
+	}
+	return nil
+}
+
+// HolidayCountry returns the country whose public holidays the person has,
+// or an empty string if it isn't known.
+func (p Profile) HolidayCountry(s HolidaySettings) string {
+	if p.WorkLocation != nil && p.WorkLocation.Country != "" {
+		return p.WorkLocation.Country
+	}
+	return s.Country
+}
@@ -0,0 +1,95 @@
+package dataaccess
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ImportPreviewExpiry is how long a previewed import can be committed for.
+const ImportPreviewExpiry = time.Hour
+
+// An ImportPreview is an import which has been checked, and can be committed
+// with its token. Only a hash of the token is stored, in the same way as
+// share links.
+type ImportPreview struct {
+	// ID is the hash of the token.
+	ID        string          `bson:"_id" json:"-"`
+	Domain    string          `json:"domain"`
+	Format    string          `json:"format"`
+	Updates   []ProfileUpdate `json:"-"`
+	CreatedBy string          `json:"createdBy"`
+	Created   time.Time       `json:"created"`
+	Expires   time.Time       `json:"expires"`
+}
+
+// NewImportPreview creates a preview of the updates, and returns the token
+// which commits it.
+func NewImportPreview(domain string, format string, createdBy string, updates []ProfileUpdate, at time.Time) (ImportPreview, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return ImportPreview{}, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	at = at.UTC().Truncate(time.Millisecond)
+	return ImportPreview{
+		ID:        ShareLinkID(token),
+		Domain:    strings.ToLower(domain),
+		Format:    format,
+		Updates:   updates,
+		CreatedBy: strings.ToLower(createdBy),
+		Created:   at,
+		Expires:   at.Add(ImportPreviewExpiry),
+	}, token, nil
+}
+
+// Expired returns true if the preview can no longer be committed at the time.
+func (p ImportPreview) Expired(at time.Time) bool {
+	return !at.Before(p.Expires)
+}
+
+// SaveImportPreview stores an import preview. Previews are removed by
+// MongoDB once they've expired.
+func (da MongoDataAccess) SaveImportPreview(p *ImportPreview) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	c := session.DB(da.databaseName).C("importpreviews")
+	if err := c.EnsureIndex(mgo.Index{Key: []string{"expires"}, ExpireAfter: time.Second}); err != nil {
+		return err
+	}
+	_, err = c.UpsertId(p.ID, p)
+	return err
+}
+
+// TakeImportPreview removes the domain's import preview and returns it, so
+// that it can only be committed once.
+func (da MongoDataAccess) TakeImportPreview(domain string, id string) (*ImportPreview, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	var p ImportPreview
+	_, err = session.DB(da.databaseName).C("importpreviews").Find(bson.M{"_id": id, "domain": strings.ToLower(domain)}).Apply(mgo.Change{Remove: true}, &p)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	p.Created = p.Created.UTC()
+	p.Expires = p.Expires.UTC()
+	return &p, true, nil
+}
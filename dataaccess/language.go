@@ -0,0 +1,132 @@
+package dataaccess
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A CEFRLevel is a level of proficiency in a spoken language on the Common
+// European Framework of Reference scale, from A1 (beginner) to C2
+// (mastery).
+type CEFRLevel string
+
+// The levels of the CEFR scale.
+const (
+	CEFRA1 CEFRLevel = "A1"
+	CEFRA2 CEFRLevel = "A2"
+	CEFRB1 CEFRLevel = "B1"
+	CEFRB2 CEFRLevel = "B2"
+	CEFRC1 CEFRLevel = "C1"
+	CEFRC2 CEFRLevel = "C2"
+)
+
+// CEFRLevels are the levels of the scale, in order.
+var CEFRLevels = []CEFRLevel{CEFRA1, CEFRA2, CEFRB1, CEFRB2, CEFRC1, CEFRC2}
+
+// AtLeast returns the levels from the level up.
+func (l CEFRLevel) AtLeast() []CEFRLevel {
+	for i, level := range CEFRLevels {
+		if level == l {
+			return CEFRLevels[i:]
+		}
+	}
+	return nil
+}
+
+// Valid returns true if the level is on the scale.
+func (l CEFRLevel) Valid() bool {
+	return len(l.AtLeast()) > 0
+}
+
+// iso6391 is the two letter ISO 639-1 language codes.
+var iso6391 = strings.Fields(`aa ab ae af ak am an ar as av ay az ba be bg bh bi bm bn bo br bs ca ce ch
+co cr cs cu cv cy da de dv dz ee el en eo es et eu fa ff fi fj fo fr fy ga gd gl gn gu gv ha he hi ho hr
+ht hu hy hz ia id ie ig ii ik io is it iu ja jv ka kg ki kj kk kl km kn ko kr ks ku kv kw ky la lb lg li
+ln lo lt lu lv mg mh mi mk ml mn mr ms mt my na nb nd ne ng nl nn no nr nv ny oc oj om or os pa pi pl ps
+pt qu rm rn ro ru rw sa sc sd se sg si sk sl sm sn so sq sr ss st su sv sw ta te tg th ti tk tl tn to tr
+ts tt tw ty ug uk ur uz ve vi vo wa wo xh yi yo za zh zu`)
+
+// IsLanguageCode returns true if the code is a two letter ISO 639-1 code,
+// e.g. "de".
+func IsLanguageCode(code string) bool {
+	i := sort.SearchStrings(iso6391, code)
+	return i < len(iso6391) && iso6391[i] == code
+}
+
+// A Language is a language someone speaks, and how well. Spoken languages
+// are kept apart from skill tags, since they're rated on a different scale.
+type Language struct {
+	// Code is the ISO 639-1 code of the language, e.g. "de".
+	Code  string    `json:"code"`
+	Level CEFRLevel `json:"level"`
+}
+
+// ValidateLanguages checks that the languages have ISO 639-1 codes and CEFR
+// levels, and that none is listed twice.
+func ValidateLanguages(languages []Language) error {
+	var problems []string
+	seen := make(map[string]bool)
+	for _, l := range languages {
+		if !IsLanguageCode(l.Code) {
+			problems = append(problems, fmt.Sprintf("the language '%s' must be a two letter ISO 639-1 code, such as de", l.Code))
+		}
+		if !l.Level.Valid() {
+			problems = append(problems, fmt.Sprintf("the level of %s must be A1, A2, B1, B2, C1 or C2", l.Code))
+		}
+		if seen[l.Code] {
+			problems = append(problems, "the language "+l.Code+" is listed more than once")
+		}
+		seen[l.Code] = true
+	}
+	return newValidationError(problems)
+}
+
+// Speaks returns true if the person speaks the language at the level or
+// above. An empty level matches any level.
+func (p Profile) Speaks(code string, level CEFRLevel) bool {
+	for _, l := range p.Languages {
+		if l.Code != code {
+			continue
+		}
+		if level == "" {
+			return true
+		}
+		for _, ok := range level.AtLeast() {
+			if l.Level == ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NormaliseLanguages lowercases the codes and uppercases the levels, e.g. so
+// that "DE" and "b2" are accepted.
+func NormaliseLanguages(languages []Language) []Language {
+	op := make([]Language, len(languages))
+	for i, l := range languages {
+		op[i] = Language{Code: strings.ToLower(strings.TrimSpace(l.Code)), Level: CEFRLevel(strings.ToUpper(strings.TrimSpace(string(l.Level))))}
+	}
+	return op
+}
+
+// UpdateLanguages replaces the languages the person speaks.
+func (da MongoDataAccess) UpdateLanguages(emailAddress string, languages []Language) error {
+	languages = NormaliseLanguages(languages)
+	if err := ValidateLanguages(languages); err != nil {
+		return err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(da.databaseName).C("profiles").UpdateId(strings.ToLower(emailAddress), bson.M{"$set": bson.M{"languages": languages}})
+}
@@ -0,0 +1,63 @@
+package dataaccess
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestThatLanguageCodesAreInOrder(t *testing.T) {
+	if !sort.StringsAreSorted(iso6391) {
+		t.Error("The ISO 639-1 codes must be sorted, so that they can be searched.")
+	}
+}
+
+func TestThatLanguagesAreValidated(t *testing.T) {
+	tests := []struct {
+		languages []Language
+		expectErr bool
+	}{
+		{nil, false},
+		{[]Language{{Code: "de", Level: CEFRB2}, {Code: "en", Level: CEFRC2}}, false},
+		{NormaliseLanguages([]Language{{Code: " DE", Level: "b1"}}), false},
+		{[]Language{{Code: "german", Level: CEFRB2}}, true},
+		{[]Language{{Code: "xx", Level: CEFRB2}}, true},
+		{[]Language{{Code: "de", Level: "fluent"}}, true},
+		{[]Language{{Code: "de", Level: CEFRB2}, {Code: "de", Level: CEFRC1}}, true},
+	}
+
+	for _, test := range tests {
+		err := ValidateLanguages(test.languages)
+		if (err != nil) != test.expectErr {
+			t.Errorf("For %v, expected an error %v, but got %v", test.languages, test.expectErr, err)
+		}
+	}
+}
+
+func TestThatProfilesAreFilteredByLanguage(t *testing.T) {
+	profiles := []Profile{
+		{EmailAddress: "a@github.com", Languages: []Language{{Code: "de", Level: CEFRC1}}},
+		{EmailAddress: "b@github.com", Languages: []Language{{Code: "de", Level: CEFRA2}, {Code: "fr", Level: CEFRB2}}},
+		{EmailAddress: "c@github.com", Department: "Sales", Languages: []Language{{Code: "fr", Level: CEFRC2}}},
+	}
+
+	tests := []struct {
+		f        ProfileFilter
+		expected []string
+	}{
+		{ProfileFilter{Language: "de"}, []string{"a@github.com", "b@github.com"}},
+		{ProfileFilter{Language: "de", LanguageLevel: CEFRB2}, []string{"a@github.com"}},
+		{ProfileFilter{Language: "fr", LanguageLevel: CEFRB2, Department: "Sales"}, []string{"c@github.com"}},
+		{ProfileFilter{Language: "es"}, nil},
+	}
+
+	for _, test := range tests {
+		var actual []string
+		for _, p := range test.f.Apply(profiles) {
+			actual = append(actual, p.EmailAddress)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("For %+v, expected %v, but got %v", test.f, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package dataaccess
+
+import (
+	"log"
+	"math"
+	"regexp"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A RemotePreference is how much a person wants to work away from the
+// client's site.
+type RemotePreference string
+
+// The remote working preferences.
+const (
+	OnSite RemotePreference = "onsite"
+	Hybrid RemotePreference = "hybrid"
+	Remote RemotePreference = "remote"
+)
+
+// Coordinates are a point on the Earth, in degrees.
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// earthRadiusKm is the mean radius of the Earth.
+const earthRadiusKm = 6371.0
+
+// DistanceKm returns the great circle distance between the coordinates, in
+// kilometres.
+func (c Coordinates) DistanceKm(to Coordinates) float64 {
+	radians := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := radians(to.Latitude - c.Latitude)
+	dLng := radians(to.Longitude - c.Longitude)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(radians(c.Latitude))*math.Cos(radians(to.Latitude))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// geoPoint is a GeoJSON point, which MongoDB's 2dsphere indexes require.
+type geoPoint struct {
+	Type        string    `bson:"type"`
+	Coordinates []float64 `bson:"coordinates"`
+}
+
+func (c Coordinates) point() *geoPoint {
+	return &geoPoint{Type: "Point", Coordinates: []float64{c.Longitude, c.Latitude}}
+}
+
+// A WorkLocation is where a person is based, and whether they'd rather work
+// remotely.
+type WorkLocation struct {
+	City string `json:"city"`
+	// Country is the ISO 3166-1 alpha-2 code of the country, e.g. "GB".
+	Country string `json:"country"`
+	// Coordinates are where the person is based, if they're known. People
+	// without coordinates aren't found by FindProfilesNear.
+	Coordinates *Coordinates     `json:"coordinates,omitempty"`
+	Remote      RemotePreference `json:"remote,omitempty"`
+	// Point is the coordinates in the form stored for geographic queries.
+	Point *geoPoint `json:"-" bson:",omitempty"`
+}
+
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Validate checks the country code, coordinates and remote preference.
+func (l WorkLocation) Validate() error {
+	var problems []string
+	if l.Country != "" && !countryCode.MatchString(l.Country) {
+		problems = append(problems, "the country must be a two letter ISO 3166-1 code, such as GB")
+	}
+	if c := l.Coordinates; c != nil && (c.Latitude < -90 || c.Latitude > 90 || c.Longitude < -180 || c.Longitude > 180) {
+		problems = append(problems, "the latitude must be between -90 and 90, and the longitude between -180 and 180")
+	}
+	switch l.Remote {
+	case "", OnSite, Hybrid, Remote:
+	default:
+		problems = append(problems, "the remote preference must be onsite, hybrid or remote")
+	}
+	return newValidationError(problems)
+}
+
+// UpdateWorkLocation replaces where the person is based, or removes it if
+// it's nil.
+func (da MongoDataAccess) UpdateWorkLocation(emailAddress string, l *WorkLocation) error {
+	update := bson.M{"$unset": bson.M{"worklocation": ""}}
+	if l != nil {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+		stored := *l
+		stored.Point = nil
+		if l.Coordinates != nil {
+			stored.Point = l.Coordinates.point()
+		}
+		update = bson.M{"$set": bson.M{"worklocation": stored}}
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(da.databaseName).C("profiles").UpdateId(strings.ToLower(emailAddress), update)
+}
+
+// FindProfilesNear lists the profiles in the domain of people based within
+// the radius of the coordinates, nearest first.
+func (da MongoDataAccess) FindProfilesNear(domain string, near Coordinates, radiusKm float64) ([]Profile, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	q := bson.M{
+		"domain": strings.ToLower(domain),
+		"worklocation.point": bson.M{
+			"$nearSphere": bson.M{
+				"$geometry":    near.point(),
+				"$maxDistance": radiusKm * 1000,
+			},
+		},
+	}
+	var results []Profile
+	if err := session.DB(da.databaseName).C("profiles").Find(q).All(&results); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].inUTC()
+	}
+	return results, nil
+}
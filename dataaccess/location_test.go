@@ -0,0 +1,39 @@
+package dataaccess
+
+import (
+	"math"
+	"testing"
+)
+
+func TestThatDistancesAreGreatCircleDistances(t *testing.T) {
+	london := Coordinates{Latitude: 51.5074, Longitude: -0.1278}
+	paris := Coordinates{Latitude: 48.8566, Longitude: 2.3522}
+
+	if d := london.DistanceKm(paris); math.Abs(d-344) > 2 {
+		t.Errorf("Expected London to be about 344km from Paris, but was %.1fkm", d)
+	}
+	if d := london.DistanceKm(london); d != 0 {
+		t.Errorf("Expected London to be 0km from itself, but was %.1fkm", d)
+	}
+}
+
+func TestThatWorkLocationsAreValidated(t *testing.T) {
+	tests := []struct {
+		l         WorkLocation
+		expectErr bool
+	}{
+		{WorkLocation{}, false},
+		{WorkLocation{City: "Leeds", Country: "GB", Coordinates: &Coordinates{53.8, -1.55}, Remote: Hybrid}, false},
+		{WorkLocation{Country: "United Kingdom"}, true},
+		{WorkLocation{Coordinates: &Coordinates{91, 0}}, true},
+		{WorkLocation{Coordinates: &Coordinates{0, -181}}, true},
+		{WorkLocation{Remote: "sometimes"}, true},
+	}
+
+	for _, test := range tests {
+		err := test.l.Validate()
+		if (err != nil) != test.expectErr {
+			t.Errorf("For %+v, expected an error %v, but got %v", test.l, test.expectErr, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package dataaccess
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrLockNotHeld is returned when releasing a lock which has expired or been
+// taken by another owner.
+var ErrLockNotHeld = errors.New("dataaccess: the lock is not held")
+
+// A Lock is held by a single owner until it is released or expires.
+type Lock struct {
+	Name    string    `bson:"_id" json:"name"`
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
+func newLock(name string, ttl time.Duration) *Lock {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return &Lock{
+		Name:    name,
+		Owner:   host + "-" + bson.NewObjectId().Hex(),
+		Expires: time.Now().Add(ttl),
+	}
+}
@@ -0,0 +1,128 @@
+package dataaccess
+
+import "fmt"
+
+// A NotificationChannel is a way of delivering notifications.
+type NotificationChannel string
+
+const (
+	// EmailChannel delivers notifications by email.
+	EmailChannel NotificationChannel = "email"
+	// SlackChannel delivers notifications as Slack direct messages.
+	SlackChannel NotificationChannel = "slack"
+	// TeamsChannel delivers notifications to Microsoft Teams.
+	TeamsChannel NotificationChannel = "teams"
+)
+
+// A NotificationCategory groups notifications so that people can choose which
+// ones they receive.
+type NotificationCategory string
+
+const (
+	// DigestCategory is the summary of changes in a manager's team.
+	DigestCategory NotificationCategory = "digest"
+	// ReminderCategory reminds people to keep their profile up to date.
+	ReminderCategory NotificationCategory = "reminder"
+	// AnnouncementCategory is used for messages from administrators.
+	AnnouncementCategory NotificationCategory = "announcement"
+	// ReportCategory is custom reports, delivered on a schedule.
+	ReportCategory NotificationCategory = "report"
+)
+
+// A NotificationFrequency is how often summary notifications, such as the
+// digest, are sent.
+type NotificationFrequency string
+
+const (
+	// DailyFrequency sends summaries every day.
+	DailyFrequency NotificationFrequency = "daily"
+	// WeeklyFrequency sends summaries every Monday.
+	WeeklyFrequency NotificationFrequency = "weekly"
+	// NeverFrequency doesn't send summaries.
+	NeverFrequency NotificationFrequency = "never"
+)
+
+var (
+	knownChannels    = map[NotificationChannel]bool{EmailChannel: true, SlackChannel: true, TeamsChannel: true}
+	knownCategories  = map[NotificationCategory]bool{DigestCategory: true, ReminderCategory: true, AnnouncementCategory: true, ReportCategory: true}
+	knownFrequencies = map[NotificationFrequency]bool{DailyFrequency: true, WeeklyFrequency: true, NeverFrequency: true}
+)
+
+// NotificationPreferences determine how, and how often, a person is notified.
+// The zero value sends every category of notification by email, with weekly
+// summaries.
+type NotificationPreferences struct {
+	// Channels lists the channels notifications are delivered to. If empty,
+	// notifications are sent by email.
+	Channels []NotificationChannel `json:"channels"`
+	// Frequency is how often summaries are sent. If empty, they're sent
+	// weekly.
+	Frequency NotificationFrequency `json:"frequency"`
+	// MutedCategories lists the categories of notification the person
+	// doesn't want to receive.
+	MutedCategories []NotificationCategory `json:"mutedCategories"`
+}
+
+// EffectiveChannels returns the channels notifications are delivered to.
+func (p NotificationPreferences) EffectiveChannels() []NotificationChannel {
+	if len(p.Channels) == 0 {
+		return []NotificationChannel{EmailChannel}
+	}
+	return p.Channels
+}
+
+// EffectiveFrequency returns how often summaries are sent.
+func (p NotificationPreferences) EffectiveFrequency() NotificationFrequency {
+	if p.Frequency == "" {
+		return WeeklyFrequency
+	}
+	return p.Frequency
+}
+
+// Allows returns true if the person wants to receive notifications in the
+// category.
+func (p NotificationPreferences) Allows(category NotificationCategory) bool {
+	for _, c := range p.MutedCategories {
+		if c == category {
+			return false
+		}
+	}
+	return true
+}
+
+// Mute sets whether the category is muted.
+func (p *NotificationPreferences) Mute(category NotificationCategory, muted bool) {
+	var categories []NotificationCategory
+	for _, c := range p.MutedCategories {
+		if c != category {
+			categories = append(categories, c)
+		}
+	}
+	if muted {
+		categories = append(categories, category)
+	}
+	p.MutedCategories = categories
+}
+
+// Validate checks that the preferences only contain known values.
+func (p NotificationPreferences) Validate() error {
+	var problems []string
+
+	for _, c := range p.Channels {
+		if !knownChannels[c] {
+			problems = append(problems, fmt.Sprintf("the notification channel '%s' is not known", c))
+		}
+	}
+
+	if p.Frequency != "" && !knownFrequencies[p.Frequency] {
+		problems = append(problems, fmt.Sprintf("the notification frequency '%s' is not known", p.Frequency))
+	}
+
+	for _, c := range p.MutedCategories {
+		if !knownCategories[c] {
+			problems = append(problems, fmt.Sprintf("the notification category '%s' is not known", c))
+		}
+	}
+
+	return newValidationError(problems)
+}
@@ -0,0 +1,37 @@
+package dataaccess
+
+import "testing"
+
+func TestThatNotificationPreferencesAreValidated(t *testing.T) {
+	tests := []struct {
+		preferences NotificationPreferences
+		valid       bool
+	}{
+		{NotificationPreferences{}, true},
+		{NotificationPreferences{Channels: []NotificationChannel{SlackChannel}, Frequency: DailyFrequency}, true},
+		{NotificationPreferences{Channels: []NotificationChannel{"pigeon"}}, false},
+		{NotificationPreferences{Frequency: "hourly"}, false},
+		{NotificationPreferences{MutedCategories: []NotificationCategory{"spam"}}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.preferences.Validate(); (err == nil) != test.valid {
+			t.Errorf("For preferences %v, expected valid to be %t, but the error was %v", test.preferences, test.valid, err)
+		}
+	}
+}
+
+func TestThatCategoriesCanBeMutedAndUnmuted(t *testing.T) {
+	var p NotificationPreferences
+
+	p.Mute(DigestCategory, true)
+	p.Mute(DigestCategory, true)
+	if p.Allows(DigestCategory) || len(p.MutedCategories) != 1 {
+		t.Errorf("Expected the digest to be muted once, but the muted categories were %v", p.MutedCategories)
+	}
+
+	p.Mute(DigestCategory, false)
+	if !p.Allows(DigestCategory) {
+		t.Error("Expected the digest to be unmuted.")
+	}
+}
@@ -0,0 +1,313 @@
+package dataaccess
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/a-h/pill/events"
+)
+
+// NotifyingDataAccess wraps a DataAccess and publishes an event after each
+// successful change.
+type NotifyingDataAccess struct {
+	DataAccess
+	publisher events.Publisher
+}
+
+// NewNotifyingDataAccess creates a DataAccess which publishes changes to the
+// publisher.
+func NewNotifyingDataAccess(da DataAccess, publisher events.Publisher) DataAccess {
+	return &NotifyingDataAccess{da, publisher}
+}
+
+// UpdateProfile updates the profile and publishes a ProfileUpdated event.
+func (da NotifyingDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	profile, err := da.DataAccess.UpdateProfile(update)
+
+	if err == nil {
+		da.publisher.Publish(events.NewEvent(events.ProfileUpdated, profile.Domain, profile.EmailAddress, profile))
+	}
+
+	return profile, err
+}
+
+// DeleteProfile deletes the profile and publishes a ProfileDeleted event.
+func (da NotifyingDataAccess) DeleteProfile(emailAddress string) (bool, error) {
+	deleted, err := da.DataAccess.DeleteProfile(emailAddress)
+
+	if err == nil && deleted {
+		da.publisher.Publish(events.NewEvent(events.ProfileDeleted, GetDomain(emailAddress), emailAddress, nil))
+	}
+
+	return deleted, err
+}
+
+// AddSkillTags adds the tags and publishes a SkillTagsAdded event.
+func (da NotifyingDataAccess) AddSkillTags(tags []string) error {
+	err := da.DataAccess.AddSkillTags(tags)
+
+	if err == nil {
+		da.publisher.Publish(events.NewEvent(events.SkillTagsAdded, "", "", tags))
+	}
+
+	return err
+}
+
+// DeleteSkillTags deletes the tags and publishes a SkillTagsDeleted event.
+func (da NotifyingDataAccess) DeleteSkillTags(tags []string) error {
+	err := da.DataAccess.DeleteSkillTags(tags)
+
+	if err == nil {
+		da.publisher.Publish(events.NewEvent(events.SkillTagsDeleted, "", "", tags))
+	}
+
+	return err
+}
+
+// publishUpdated publishes a ProfileUpdated event with the person's profile
+// as it is after a change which doesn't return it. A failure to read the
+// profile is logged, since the change itself has been made.
+func (da NotifyingDataAccess) publishUpdated(emailAddress string) {
+	p, found, err := da.DataAccess.GetProfile(emailAddress)
+	if err != nil {
+		log.Printf("Failed to read the profile of %s to publish its change. %v", emailAddress, err)
+		return
+	}
+	if found {
+		da.publisher.Publish(events.NewEvent(events.ProfileUpdated, p.Domain, p.EmailAddress, p))
+	}
+}
+
+// UpdateLanguages updates the languages and publishes a ProfileUpdated
+// event, since languages are searched for.
+func (da NotifyingDataAccess) UpdateLanguages(emailAddress string, languages []Language) error {
+	err := da.DataAccess.UpdateLanguages(emailAddress, languages)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// SyncEmployee syncs the HR record and publishes a ProfileUpdated event,
+// since people's managers and departments come from it.
+func (da NotifyingDataAccess) SyncEmployee(e EmployeeUpdate) error {
+	err := da.DataAccess.SyncEmployee(e)
+
+	if err == nil {
+		da.publishUpdated(e.EmailAddress)
+	}
+
+	return err
+}
+
+// ConfirmSkills confirms the skills and publishes a ProfileUpdated event.
+func (da NotifyingDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	err := da.DataAccess.ConfirmSkills(emailAddress, skills)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// MergeSkillTags merges the tags and publishes a ProfileUpdated event for
+// each person who had them, since their skills and goals are renamed.
+func (da NotifyingDataAccess) MergeSkillTags(into string, tags []string) (int, error) {
+	people, err := peopleWithTags(da.DataAccess, mergedTags(into, tags))
+	if err != nil {
+		return 0, err
+	}
+	merged, err := da.DataAccess.MergeSkillTags(into, tags)
+
+	if err == nil {
+		for _, emailAddress := range people {
+			da.publishUpdated(emailAddress)
+		}
+	}
+
+	return merged, err
+}
+
+// UpdateAvailabilityWindows updates the windows and publishes a
+// ProfileUpdated event, since they change when people are available.
+func (da NotifyingDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error {
+	err := da.DataAccess.UpdateAvailabilityWindows(emailAddress, windows)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// AddBooking adds the booking and publishes a ProfileUpdated event, since
+// bookings change when people are available.
+func (da NotifyingDataAccess) AddBooking(emailAddress string, b Booking) error {
+	err := da.DataAccess.AddBooking(emailAddress, b)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// RemoveBooking removes the booking and publishes a ProfileUpdated event.
+func (da NotifyingDataAccess) RemoveBooking(emailAddress string, id string) error {
+	err := da.DataAccess.RemoveBooking(emailAddress, id)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// UpdateWorkLocation updates the location and publishes a ProfileUpdated
+// event, since people are searched for by where they're based.
+func (da NotifyingDataAccess) UpdateWorkLocation(emailAddress string, l *WorkLocation) error {
+	err := da.DataAccess.UpdateWorkLocation(emailAddress, l)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// UpdateCustomFields updates the fields and publishes a ProfileUpdated
+// event.
+func (da NotifyingDataAccess) UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) error {
+	err := da.DataAccess.UpdateCustomFields(emailAddress, values)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// RecordConsents records the consents and publishes a ProfileUpdated event,
+// since they decide where the person's data may be used.
+func (da NotifyingDataAccess) RecordConsents(emailAddress string, consents []Consent) error {
+	err := da.DataAccess.RecordConsents(emailAddress, consents)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// UpdateWorkingHours updates the hours and publishes a ProfileUpdated event.
+func (da NotifyingDataAccess) UpdateWorkingHours(emailAddress string, h *WorkingHours) error {
+	err := da.DataAccess.UpdateWorkingHours(emailAddress, h)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// UpdateClearance updates the clearance and publishes a ProfileUpdated event.
+func (da NotifyingDataAccess) UpdateClearance(emailAddress string, cl *Clearance) error {
+	err := da.DataAccess.UpdateClearance(emailAddress, cl)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// UpdateExternalIDs updates the IDs and publishes a ProfileUpdated event.
+func (da NotifyingDataAccess) UpdateExternalIDs(emailAddress string, ids map[string]string) error {
+	err := da.DataAccess.UpdateExternalIDs(emailAddress, ids)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// UpdateLearningGoals updates the goals and publishes a ProfileUpdated event.
+// The goals tracker saves the goals it achieves through here too, but there
+// are none left to achieve by the time it receives the event.
+func (da NotifyingDataAccess) UpdateLearningGoals(emailAddress string, goals []LearningGoal) error {
+	err := da.DataAccess.UpdateLearningGoals(emailAddress, goals)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// UpdateCV updates the CV and publishes a ProfileUpdated event.
+func (da NotifyingDataAccess) UpdateCV(emailAddress string, cv *Attachment) error {
+	err := da.DataAccess.UpdateCV(emailAddress, cv)
+
+	if err == nil {
+		da.publishUpdated(emailAddress)
+	}
+
+	return err
+}
+
+// ImportProfile imports the profile and publishes a ProfileUpdated event.
+func (da NotifyingDataAccess) ImportProfile(p *Profile) error {
+	err := da.DataAccess.ImportProfile(p)
+
+	if err == nil {
+		da.publishUpdated(p.EmailAddress)
+	}
+
+	return err
+}
+
+// ArchiveProfile archives the profile and publishes a ProfileDeleted event,
+// since archived people can't be searched for.
+func (da NotifyingDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (bool, error) {
+	archived, err := da.DataAccess.ArchiveProfile(emailAddress, reason, purgeAfter)
+
+	if err == nil && archived {
+		da.publisher.Publish(events.NewEvent(events.ProfileDeleted, GetDomain(emailAddress), emailAddress, nil))
+	}
+
+	return archived, err
+}
+
+// RestoreProfile restores the profile and publishes a ProfileUpdated event.
+func (da NotifyingDataAccess) RestoreProfile(emailAddress string) (bool, error) {
+	restored, err := da.DataAccess.RestoreProfile(emailAddress)
+
+	if err == nil && restored {
+		da.publishUpdated(emailAddress)
+	}
+
+	return restored, err
+}
+
+// WithContext passes the context to the wrapped DataAccess.
+func (da NotifyingDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &NotifyingDataAccess{WithContext(da.DataAccess, ctx), da.publisher}
+}
+
+// MergeProfiles merges the profiles, and publishes a ProfileUpdated event for
+// the primary and a ProfileDeleted event for the duplicate.
+func (da NotifyingDataAccess) MergeProfiles(primary string, duplicate string) (*Profile, bool, error) {
+	p, found, err := da.DataAccess.MergeProfiles(primary, duplicate)
+
+	if err == nil && found {
+		da.publisher.Publish(events.NewEvent(events.ProfileUpdated, p.Domain, p.EmailAddress, p))
+		da.publisher.Publish(events.NewEvent(events.ProfileDeleted, GetDomain(duplicate), duplicate, nil))
+	}
+
+	return p, found, err
+}
@@ -0,0 +1,201 @@
+package dataaccess
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/a-h/pill/events"
+)
+
+type stubDataAccess struct {
+	DataAccess
+	err error
+}
+
+func (da stubDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	if da.err != nil {
+		return nil, da.err
+	}
+	return &Profile{EmailAddress: update.EmailAddress, Domain: GetDomain(update.EmailAddress)}, nil
+}
+
+func (da stubDataAccess) DeleteProfile(emailAddress string) (bool, error) {
+	return da.err == nil, da.err
+}
+
+func (da stubDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	if da.err != nil {
+		return nil, false, da.err
+	}
+	return &Profile{EmailAddress: emailAddress, Domain: GetDomain(emailAddress)}, true, nil
+}
+
+func (da stubDataAccess) UpdateLanguages(emailAddress string, languages []Language) error {
+	return da.err
+}
+
+func (da stubDataAccess) SyncEmployee(e EmployeeUpdate) error {
+	return da.err
+}
+
+func (da stubDataAccess) ImportProfile(p *Profile) error {
+	return da.err
+}
+
+func (da stubDataAccess) RestoreProfile(emailAddress string) (bool, error) {
+	return da.err == nil, da.err
+}
+
+func (da stubDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	return da.err
+}
+
+func (da stubDataAccess) ListDomains() ([]string, error) {
+	return []string{"github.com"}, da.err
+}
+
+func (da stubDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
+	return []Profile{
+		{EmailAddress: "a-h@github.com", Domain: "github.com", Skills: []Skill{{Skill: "golang"}}},
+		{EmailAddress: "other@github.com", Domain: "github.com", Skills: []Skill{{Skill: "java"}}},
+	}, da.err
+}
+
+func (da stubDataAccess) MergeSkillTags(into string, tags []string) (int, error) {
+	return 1, da.err
+}
+
+func (da stubDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error {
+	return da.err
+}
+
+func (da stubDataAccess) AddBooking(emailAddress string, b Booking) error {
+	return da.err
+}
+
+func (da stubDataAccess) RemoveBooking(emailAddress string, id string) error {
+	return da.err
+}
+
+func (da stubDataAccess) UpdateWorkLocation(emailAddress string, l *WorkLocation) error {
+	return da.err
+}
+
+func (da stubDataAccess) UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) error {
+	return da.err
+}
+
+func (da stubDataAccess) RecordConsents(emailAddress string, consents []Consent) error {
+	return da.err
+}
+
+func (da stubDataAccess) UpdateWorkingHours(emailAddress string, h *WorkingHours) error {
+	return da.err
+}
+
+func (da stubDataAccess) UpdateClearance(emailAddress string, cl *Clearance) error {
+	return da.err
+}
+
+func (da stubDataAccess) UpdateExternalIDs(emailAddress string, ids map[string]string) error {
+	return da.err
+}
+
+func (da stubDataAccess) UpdateLearningGoals(emailAddress string, goals []LearningGoal) error {
+	return da.err
+}
+
+func (da stubDataAccess) UpdateCV(emailAddress string, cv *Attachment) error {
+	return da.err
+}
+
+type recordingPublisher struct {
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(e events.Event) {
+	p.events = append(p.events, e)
+}
+
+func TestThatSuccessfulChangesArePublished(t *testing.T) {
+	p := &recordingPublisher{}
+	da := NewNotifyingDataAccess(stubDataAccess{}, p)
+
+	update := NewProfileUpdate()
+	update.EmailAddress = "a-h@github.com"
+	da.UpdateProfile(update)
+	da.DeleteProfile("a-h@github.com")
+
+	if len(p.events) != 2 {
+		t.Fatalf("Expected 2 events to be published, but %d were.", len(p.events))
+	}
+
+	if p.events[0].Type != events.ProfileUpdated || p.events[0].Domain != "github.com" {
+		t.Errorf("Expected a profileUpdated event for github.com, but received %v", p.events[0])
+	}
+
+	if p.events[1].Type != events.ProfileDeleted || p.events[1].EmailAddress != "a-h@github.com" {
+		t.Errorf("Expected a profileDeleted event for a-h@github.com, but received %v", p.events[1])
+	}
+}
+
+func TestThatFailedChangesAreNotPublished(t *testing.T) {
+	p := &recordingPublisher{}
+	da := NewNotifyingDataAccess(stubDataAccess{err: errors.New("failed")}, p)
+
+	update := NewProfileUpdate()
+	update.EmailAddress = "a-h@github.com"
+	da.UpdateProfile(update)
+	da.DeleteProfile("a-h@github.com")
+
+	if len(p.events) != 0 {
+		t.Errorf("Failed changes should not be published, but %d events were.", len(p.events))
+	}
+}
+
+func TestThatChangesWhichDontReturnTheProfilePublishIt(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(da DataAccess) error
+	}{
+		{"UpdateLanguages", func(da DataAccess) error {
+			return da.UpdateLanguages("a-h@github.com", []Language{{Code: "de", Level: CEFRB2}})
+		}},
+		{"SyncEmployee", func(da DataAccess) error {
+			return da.SyncEmployee(EmployeeUpdate{EmailAddress: "a-h@github.com", Manager: "boss@github.com"})
+		}},
+		{"ImportProfile", func(da DataAccess) error { return da.ImportProfile(&Profile{EmailAddress: "a-h@github.com"}) }},
+		{"RestoreProfile", func(da DataAccess) error {
+			_, err := da.RestoreProfile("a-h@github.com")
+			return err
+		}},
+		{"ConfirmSkills", func(da DataAccess) error { return da.ConfirmSkills("a-h@github.com", []string{"go"}) }},
+		{"MergeSkillTags", func(da DataAccess) error {
+			_, err := da.MergeSkillTags("go", []string{"golang"})
+			return err
+		}},
+		{"UpdateAvailabilityWindows", func(da DataAccess) error { return da.UpdateAvailabilityWindows("a-h@github.com", nil) }},
+		{"AddBooking", func(da DataAccess) error { return da.AddBooking("a-h@github.com", Booking{ID: "b1"}) }},
+		{"RemoveBooking", func(da DataAccess) error { return da.RemoveBooking("a-h@github.com", "b1") }},
+		{"UpdateWorkLocation", func(da DataAccess) error { return da.UpdateWorkLocation("a-h@github.com", &WorkLocation{}) }},
+		{"UpdateCustomFields", func(da DataAccess) error { return da.UpdateCustomFields("a-h@github.com", nil) }},
+		{"RecordConsents", func(da DataAccess) error { return da.RecordConsents("a-h@github.com", nil) }},
+		{"UpdateWorkingHours", func(da DataAccess) error { return da.UpdateWorkingHours("a-h@github.com", &WorkingHours{}) }},
+		{"UpdateClearance", func(da DataAccess) error { return da.UpdateClearance("a-h@github.com", &Clearance{}) }},
+		{"UpdateExternalIDs", func(da DataAccess) error { return da.UpdateExternalIDs("a-h@github.com", nil) }},
+		{"UpdateLearningGoals", func(da DataAccess) error { return da.UpdateLearningGoals("a-h@github.com", nil) }},
+		{"UpdateCV", func(da DataAccess) error { return da.UpdateCV("a-h@github.com", &Attachment{}) }},
+	}
+	for _, test := range tests {
+		p := &recordingPublisher{}
+		if err := test.change(NewNotifyingDataAccess(stubDataAccess{}, p)); err != nil {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		if len(p.events) != 1 || p.events[0].Type != events.ProfileUpdated || p.events[0].EmailAddress != "a-h@github.com" {
+			t.Errorf("%s: expected a profileUpdated event for a-h@github.com, but received %v", test.name, p.events)
+		}
+		if profile, ok := p.events[0].Data.(*Profile); !ok || profile.Domain != "github.com" {
+			t.Errorf("%s: expected the event to carry the profile, but received %v", test.name, p.events[0].Data)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package dataaccess
+
+import (
+	"sort"
+	"strings"
+)
+
+// MaximumTopSkills is the number of skills listed in a SkillSummary.
+const MaximumTopSkills = 5
+
+// An OrgNode is a person in the management hierarchy. The JSON shape
+// (name and children) can be passed directly to d3.hierarchy.
+type OrgNode struct {
+	// ID is the person's email address, or the domain for the root node.
+	ID           string       `json:"id"`
+	Name         string       `json:"name"`
+	Availability RagStatus    `json:"availability,omitempty"`
+	Skills       SkillSummary `json:"skills"`
+	// TeamSize is the number of people who report to the person, directly or
+	// indirectly.
+	TeamSize int        `json:"teamSize"`
+	Children []*OrgNode `json:"children,omitempty"`
+}
+
+// A SkillSummary describes a person's skills.
+type SkillSummary struct {
+	Count int `json:"count"`
+	// Top lists the person's highest level skills, highest first.
+	Top          []string `json:"top"`
+	AverageLevel float64  `json:"averageLevel"`
+}
+
+// NewSkillSummary summarises the skills.
+func NewSkillSummary(skills []Skill) SkillSummary {
+	sorted := make([]Skill, len(skills))
+	copy(sorted, skills)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Level != sorted[j].Level {
+			return sorted[i].Level > sorted[j].Level
+		}
+		return sorted[i].Skill < sorted[j].Skill
+	})
+
+	s := SkillSummary{Count: len(skills), Top: []string{}}
+	total := 0
+	for i, skill := range sorted {
+		if i < MaximumTopSkills {
+			s.Top = append(s.Top, skill.Skill)
+		}
+		total += int(skill.Level)
+	}
+
+	if len(skills) > 0 {
+		s.AverageLevel = float64(total) / float64(len(skills))
+	}
+
+	return s
+}
+
+// NewOrgTree arranges the profiles into a management hierarchy rooted at a
+// node for the domain. People whose manager doesn't have a profile in the
+// domain report directly to the root. Reporting loops are broken, so that
+// everyone appears exactly once.
+func NewOrgTree(domain string, profiles []Profile) *OrgNode {
+	root := &OrgNode{ID: domain, Name: domain, Skills: SkillSummary{Top: []string{}}}
+
+	nodes := make(map[string]*OrgNode)
+	managers := make(map[string]string)
+	var ids []string
+	for _, p := range profiles {
+		id := strings.ToLower(p.EmailAddress)
+		name := p.Name
+		if name == "" {
+			name = p.EmailAddress
+		}
+		nodes[id] = &OrgNode{
+			ID:           id,
+			Name:         name,
+			Availability: p.Availability,
+			Skills:       NewSkillSummary(p.Skills),
+		}
+		managers[id] = strings.ToLower(p.Manager)
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	reports := make(map[string][]string)
+	var tops []string
+	for _, id := range ids {
+		if m := managers[id]; m != id && nodes[m] != nil {
+			reports[m] = append(reports[m], id)
+		} else {
+			tops = append(tops, id)
+		}
+	}
+
+	placed := make(map[string]bool)
+	var place func(id string) *OrgNode
+	place = func(id string) *OrgNode {
+		n := nodes[id]
+		placed[id] = true
+		for _, r := range reports[id] {
+			if placed[r] {
+				continue
+			}
+			child := place(r)
+			n.Children = append(n.Children, child)
+			n.TeamSize += child.TeamSize + 1
+		}
+		return n
+	}
+
+	for _, id := range tops {
+		root.Children = append(root.Children, place(id))
+	}
+
+	// Anyone not yet placed is part of a reporting loop.
+	for _, id := range ids {
+		if !placed[id] {
+			root.Children = append(root.Children, place(id))
+		}
+	}
+
+	for _, c := range root.Children {
+		root.TeamSize += c.TeamSize + 1
+	}
+
+	return root
+}
+
+// Find returns the node with the id, from the node or any of its reports.
+func (n *OrgNode) Find(id string) (*OrgNode, bool) {
+	if strings.EqualFold(n.ID, id) {
+		return n, true
+	}
+
+	for _, c := range n.Children {
+		if found, ok := c.Find(id); ok {
+			return found, true
+		}
+	}
+	return nil, false
+}
+
+// Members returns the ids of the node and everyone who reports to it,
+// directly or indirectly.
+func (n *OrgNode) Members() []string {
+	ids := []string{n.ID}
+	for _, c := range n.Children {
+		ids = append(ids, c.Members()...)
+	}
+	return ids
+}
@@ -0,0 +1,69 @@
+package dataaccess
+
+import "testing"
+
+func TestThatTheOrgTreeFollowsManagers(t *testing.T) {
+	profiles := []Profile{
+		{EmailAddress: "ceo@github.com", Name: "CEO"},
+		{EmailAddress: "cto@github.com", Manager: "ceo@github.com"},
+		{EmailAddress: "dev@github.com", Manager: "CTO@github.com", Skills: []Skill{{Skill: "go", Level: 4}, {Skill: "java", Level: 2}}},
+		{EmailAddress: "contractor@github.com", Manager: "agency@example.com"},
+	}
+
+	root := NewOrgTree("github.com", profiles)
+
+	if root.ID != "github.com" || root.TeamSize != 4 {
+		t.Fatalf("Expected a github.com root node with 4 people, but received %s with %d.", root.ID, root.TeamSize)
+	}
+
+	if len(root.Children) != 2 || root.Children[0].ID != "ceo@github.com" || root.Children[1].ID != "contractor@github.com" {
+		t.Fatalf("Expected the CEO and the contractor to report to the root, but received %v", root.Children)
+	}
+
+	ceo := root.Children[0]
+	if ceo.Name != "CEO" || ceo.TeamSize != 2 {
+		t.Errorf("Expected the CEO to have a team of 2, but was %d.", ceo.TeamSize)
+	}
+
+	dev := ceo.Children[0].Children[0]
+	if dev.ID != "dev@github.com" || dev.Name != "dev@github.com" {
+		t.Errorf("Expected the developer to report to the CTO, but received %v", dev)
+	}
+
+	if dev.Skills.Count != 2 || dev.Skills.Top[0] != "go" || dev.Skills.AverageLevel != 3 {
+		t.Errorf("Unexpected skill summary %v", dev.Skills)
+	}
+}
+
+func TestThatReportingLoopsAreBroken(t *testing.T) {
+	profiles := []Profile{
+		{EmailAddress: "a@github.com", Manager: "b@github.com"},
+		{EmailAddress: "b@github.com", Manager: "a@github.com"},
+		{EmailAddress: "c@github.com", Manager: "c@github.com"},
+	}
+
+	root := NewOrgTree("github.com", profiles)
+
+	if root.TeamSize != 3 {
+		t.Errorf("Everyone should appear exactly once, but the tree contains %d people.", root.TeamSize)
+	}
+}
+
+func TestThatTeamMembersCanBeFound(t *testing.T) {
+	profiles := []Profile{
+		{EmailAddress: "ceo@github.com"},
+		{EmailAddress: "cto@github.com", Manager: "ceo@github.com"},
+		{EmailAddress: "dev@github.com", Manager: "cto@github.com"},
+		{EmailAddress: "sales@github.com", Manager: "ceo@github.com"},
+	}
+
+	cto, ok := NewOrgTree("github.com", profiles).Find("CTO@github.com")
+	if !ok {
+		t.Fatal("Expected to find the CTO.")
+	}
+
+	members := cto.Members()
+	if len(members) != 2 || members[0] != "cto@github.com" || members[1] != "dev@github.com" {
+		t.Errorf("Expected the CTO's team to be the CTO and the developer, but was %v", members)
+	}
+}
@@ -0,0 +1,47 @@
+package dataaccess
+
+import "gopkg.in/mgo.v2"
+
+// PoolStats are counts of the MongoDB driver's connections and operations,
+// across every session the process has opened.
+type PoolStats struct {
+	Clusters     int `json:"clusters"`
+	MasterConns  int `json:"masterConns"`
+	SlaveConns   int `json:"slaveConns"`
+	SentOps      int `json:"sentOps"`
+	ReceivedOps  int `json:"receivedOps"`
+	ReceivedDocs int `json:"receivedDocs"`
+	SocketsAlive int `json:"socketsAlive"`
+	SocketsInUse int `json:"socketsInUse"`
+	SocketRefs   int `json:"socketRefs"`
+}
+
+// EnablePoolStats starts counting the driver's connections and operations.
+// Counting takes a lock on each operation, so it's off unless diagnostics are
+// needed.
+func EnablePoolStats() {
+	mgo.SetStats(true)
+	poolStatsEnabled = true
+}
+
+var poolStatsEnabled bool
+
+// GetPoolStats returns the counts since EnablePoolStats was called, or zeros
+// if it hasn't been.
+func GetPoolStats() PoolStats {
+	if !poolStatsEnabled {
+		return PoolStats{}
+	}
+	s := mgo.GetStats()
+	return PoolStats{
+		Clusters:     s.Clusters,
+		MasterConns:  s.MasterConns,
+		SlaveConns:   s.SlaveConns,
+		SentOps:      s.SentOps,
+		ReceivedOps:  s.ReceivedOps,
+		ReceivedDocs: s.ReceivedDocs,
+		SocketsAlive: s.SocketsAlive,
+		SocketsInUse: s.SocketsInUse,
+		SocketRefs:   s.SocketRefs,
+	}
+}
@@ -7,6 +7,24 @@ type ProfileUpdate struct {
 	EmailAddress string    `json:"emailAddress"`
 	Skills       []Skill   `json:"skills"`
 	Availability RagStatus `json:"availability"`
+	// Name is the person's display name. If nil, the name is unchanged.
+	Name *string `json:"name,omitempty"`
+	// Manager is the email address of the person's manager. If nil, the
+	// manager is unchanged.
+	Manager *string `json:"manager,omitempty"`
+	// Language is the language generated content, such as emails, is written
+	// in. If nil, the language is unchanged.
+	Language *string `json:"language,omitempty"`
+	// TimeZone is the IANA name of the person's time zone, e.g.
+	// "Europe/London". If nil, the time zone is unchanged.
+	TimeZone *string `json:"timeZone,omitempty"`
+	// Bio is the person's description of themselves. If nil, the bio is
+	// unchanged.
+	Bio *string `json:"bio,omitempty"`
+	// Department and CostCenter are where the person sits in the
+	// organisation. If nil, they're unchanged.
+	Department *string `json:"department,omitempty"`
+	CostCenter *string `json:"costCenter,omitempty"`
 }
 
 // NewProfileUpdate creates an empty profile update.
@@ -22,12 +40,68 @@ type Profile struct {
 	SkillsHistory []SkillLevel `json:"skillsHistory"`
 	Version       int          `json:"version"`
 	LastUpdated   time.Time    `json:"lastUpdated"`
-	Domain        string       `json:"domain"`
+	// Restored is when the profile was last restored from the archive.
+	Restored time.Time `json:"restored,omitempty"`
+	Domain   string    `json:"domain"`
+	Name     string    `json:"name,omitempty"`
+	Manager  string    `json:"manager,omitempty"`
+	// Department and CostCenter are where the person sits in the
+	// organisation, entered by them or synced from the HR system.
+	Department string `json:"department,omitempty"`
+	CostCenter string `json:"costCenter,omitempty"`
+	// Bio is a short description of the person, written by them.
+	Bio string `json:"bio,omitempty"`
+	// Language is the language generated content is written in, e.g. "de". If
+	// empty, the default language is used.
+	Language string `json:"language,omitempty"`
+	// TimeZone is the IANA name of the person's time zone. If empty, UTC is
+	// used.
+	TimeZone string `json:"timeZone,omitempty"`
+	// WorkingHours are when the person usually works, in their TimeZone. If
+	// nil, DefaultWorkingHours are used.
+	WorkingHours *WorkingHours `json:"workingHours,omitempty"`
+	// AvailabilityWindows are periods when the person's availability differs
+	// from Availability. Times are stored in UTC.
+	AvailabilityWindows []AvailabilityWindow `json:"availabilityWindows,omitempty"`
+	// AvailabilityChanged is when the Availability was last changed.
+	AvailabilityChanged time.Time `json:"availabilityChanged"`
+	// Notifications determine how the person is notified.
+	Notifications NotificationPreferences `json:"notifications"`
+	// Badges have been awarded for using pill.
+	Badges []Badge `json:"badges,omitempty"`
+	// Bookings allocate the person's time to projects, which reduces their
+	// availability while they're booked.
+	Bookings []Booking `json:"bookings,omitempty"`
+	// Goals are the levels the person wants to reach in skills.
+	Goals []LearningGoal `json:"goals,omitempty"`
+	// CV is the person's uploaded CV, if they have one.
+	CV *Attachment `json:"cv,omitempty"`
+	// Employment is the person's record in the HR system, if it is synced.
+	Employment *Employment `json:"employment,omitempty"`
+	// WorkLocation is where the person is based. Their time zone is kept in
+	// TimeZone.
+	WorkLocation *WorkLocation `json:"workLocation,omitempty"`
+	// Languages are the languages the person speaks. Language, above, is
+	// what generated content is written in.
+	Languages []Language `json:"languages,omitempty"`
+	// Clearance is the person's security clearance. It's removed from the
+	// profile by the RedactingDataAccess for callers who can't see it.
+	Clearance *Clearance `json:"clearance,omitempty"`
+	// Consents record each time the person gave or withdrew their consent to
+	// their data being processed, oldest first.
+	Consents []Consent `json:"consents,omitempty"`
+	// CustomFields are the values of the tenant's custom fields, keyed by
+	// the name of the field.
+	CustomFields map[string]CustomFieldValue `json:"customFields,omitempty"`
+	// ExternalIDs are the person's IDs in other systems, keyed by the name
+	// of the system, e.g. "slack". Each ID belongs to one profile in the
+	// tenant.
+	ExternalIDs map[string]string `json:"externalIds,omitempty"`
 }
 
 // NewProfile creates an empty profile.
 func NewProfile() *Profile {
 	return &Profile{
-		LastUpdated: time.Now(),
+		LastUpdated: time.Now().UTC(),
 	}
 }
@@ -0,0 +1,159 @@
+package dataaccess
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// An ArchivedProfile is a profile which has been taken out of use, e.g.
+// because the person has left. Archived profiles aren't listed, searched or
+// reported on, but can be restored.
+type ArchivedProfile struct {
+	Profile  `bson:",inline"`
+	Archived time.Time `json:"archived"`
+	// Reason says why the profile was archived, e.g. "left".
+	Reason string `json:"reason"`
+	// PurgeAfter is when the profile is permanently deleted, or zero if it's
+	// kept until it is restored.
+	PurgeAfter time.Time `json:"purgeAfter,omitempty"`
+}
+
+// ArchiveProfile moves the profile to the archive, returning false if the
+// person doesn't have a profile. If purgeAfter isn't zero, the profile is
+// soft deleted, and is permanently deleted by PurgeArchivedProfiles after
+// that time.
+func (da MongoDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return false, err
+	}
+	defer session.Close()
+
+	profiles := session.DB(da.databaseName).C("profiles")
+	archive := session.DB(da.databaseName).C("archivedprofiles")
+
+	var p Profile
+	err = profiles.FindId(strings.ToLower(emailAddress)).One(&p)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// The profile is copied before it's removed, so that a failure leaves it
+	// in both collections rather than neither.
+	ap := ArchivedProfile{
+		Profile:    p,
+		Archived:   time.Now().UTC().Truncate(time.Millisecond),
+		Reason:     reason,
+		PurgeAfter: purgeAfter.UTC().Truncate(time.Millisecond),
+	}
+	if _, err = archive.UpsertId(p.EmailAddress, ap); err != nil {
+		return false, err
+	}
+	if err = profiles.RemoveId(p.EmailAddress); err != nil && err != mgo.ErrNotFound {
+		return false, err
+	}
+	// Archived people can't be searched for. Their manager's team summary
+	// is updated when the read models are rebuilt.
+	if err = session.DB(da.databaseName).C("searchdocuments").RemoveId(p.EmailAddress); err != nil && err != mgo.ErrNotFound {
+		return false, err
+	}
+	if err = session.DB(da.databaseName).C("profilesummaries").RemoveId(p.EmailAddress); err != nil && err != mgo.ErrNotFound {
+		return false, err
+	}
+	return true, nil
+}
+
+// RestoreProfile moves the profile back from the archive, returning false if
+// it isn't archived.
+func (da MongoDataAccess) RestoreProfile(emailAddress string) (bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return false, err
+	}
+	defer session.Close()
+
+	profiles := session.DB(da.databaseName).C("profiles")
+	archive := session.DB(da.databaseName).C("archivedprofiles")
+
+	var ap ArchivedProfile
+	err = archive.FindId(strings.ToLower(emailAddress)).One(&ap)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// The profile counts as active from when it's restored, so that it isn't
+	// archived again for being inactive.
+	ap.Profile.Restored = time.Now().UTC().Truncate(time.Millisecond)
+	if _, err = profiles.UpsertId(ap.EmailAddress, ap.Profile); err != nil {
+		return false, err
+	}
+	if err = archive.RemoveId(ap.EmailAddress); err != nil && err != mgo.ErrNotFound {
+		return false, err
+	}
+	return true, nil
+}
+
+// ListArchivedProfiles lists the archived profiles in the domain, most
+// recently archived first.
+func (da MongoDataAccess) ListArchivedProfiles(domain string) ([]ArchivedProfile, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var results []ArchivedProfile
+	err = session.DB(da.databaseName).C("archivedprofiles").
+		Find(bson.M{"domain": strings.ToLower(domain)}).
+		Select(bson.M{"skillshistory": 0}).
+		Sort("-archived").
+		All(&results)
+	for i := range results {
+		results[i].inUTC()
+		results[i].Archived = results[i].Archived.UTC()
+		results[i].PurgeAfter = results[i].PurgeAfter.UTC()
+	}
+	return results, err
+}
+
+// PurgeArchivedProfiles permanently deletes the soft deleted profiles whose
+// purge time is before the time, and returns how many were deleted.
+func (da MongoDataAccess) PurgeArchivedProfiles(before time.Time) (int, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return 0, err
+	}
+	defer session.Close()
+
+	db := session.DB(da.databaseName)
+	q := bson.M{"purgeafter": bson.M{"$gt": time.Time{}, "$lt": before}}
+	var purged []struct {
+		EmailAddress string `bson:"_id"`
+	}
+	if err := db.C("archivedprofiles").Find(q).Select(bson.M{"_id": 1}).All(&purged); err != nil {
+		return 0, err
+	}
+	info, err := db.C("archivedprofiles").RemoveAll(q)
+	if err != nil {
+		return 0, err
+	}
+	emailAddresses := make([]string, len(purged))
+	for i, p := range purged {
+		emailAddresses[i] = p.EmailAddress
+	}
+	return info.Removed, removeProfileData(db, emailAddresses...)
+}
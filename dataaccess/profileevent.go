@@ -0,0 +1,520 @@
+package dataaccess
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ProfileEventType names the kind of change described by a ProfileEvent.
+type ProfileEventType string
+
+const (
+	// SkillAdded is recorded when a person adds a skill to their profile.
+	SkillAdded ProfileEventType = "skillAdded"
+	// LevelChanged is recorded when a person changes their level of, or
+	// interest in, a skill.
+	LevelChanged ProfileEventType = "levelChanged"
+	// SkillRemoved is recorded when a person removes a skill.
+	SkillRemoved ProfileEventType = "skillRemoved"
+	// SkillConfirmed is recorded when a person confirms that their level
+	// of a skill is still correct.
+	SkillConfirmed ProfileEventType = "skillConfirmed"
+	// AvailabilitySet is recorded when a person's availability changes.
+	AvailabilitySet ProfileEventType = "availabilitySet"
+	// AvailabilityWindowsSet is recorded when a person's availability
+	// windows are replaced.
+	AvailabilityWindowsSet ProfileEventType = "availabilityWindowsSet"
+	// BookingAdded and BookingRemoved are recorded when a person is booked
+	// on a project, and when the booking is cancelled.
+	BookingAdded   ProfileEventType = "bookingAdded"
+	BookingRemoved ProfileEventType = "bookingRemoved"
+	// DetailsChanged is recorded when a person's name, manager, department
+	// or cost center changes, by them or the HR system.
+	DetailsChanged ProfileEventType = "detailsChanged"
+)
+
+// ProfileDetails are who a person is and where they sit in the
+// organisation.
+type ProfileDetails struct {
+	Name       string `json:"name,omitempty"`
+	Manager    string `json:"manager,omitempty"`
+	Department string `json:"department,omitempty"`
+	CostCenter string `json:"costCenter,omitempty"`
+}
+
+// DefaultSnapshotInterval is the number of events between snapshots of a
+// profile, unless configured.
+const DefaultSnapshotInterval = 50
+
+// ErrProfileEventConflict is returned when events are appended after an
+// event which is no longer the latest, because another change was recorded
+// first.
+var ErrProfileEventConflict = errors.New("dataaccess: the profile has changed since its events were read")
+
+// A ProfileEvent is a change to a person's skills, availability, bookings or
+// details. Events are only ever appended, so they're a complete history of
+// the profile, from which its state is rebuilt.
+type ProfileEvent struct {
+	// ID is the email address and sequence, so that no two events can have
+	// the same place in a profile's history.
+	ID           string           `bson:"_id" json:"id"`
+	EmailAddress string           `json:"emailAddress"`
+	Domain       string           `json:"domain"`
+	Sequence     int              `json:"sequence"`
+	Type         ProfileEventType `json:"type"`
+	// Skill is the skill as it was after the change, or only its name if it
+	// was removed.
+	Skill        *Skill    `json:"skill,omitempty"`
+	Availability RagStatus `json:"availability,omitempty"`
+	// Windows are all of the person's availability windows after the
+	// change.
+	Windows []AvailabilityWindow `json:"windows,omitempty"`
+	// Booking is the booking added, or only its ID if it was removed.
+	Booking *Booking `json:"booking,omitempty"`
+	// Details are the person's details after the change.
+	Details *ProfileDetails `json:"details,omitempty"`
+	Time    time.Time       `json:"time"`
+}
+
+func profileEventID(emailAddress string, sequence int) string {
+	return fmt.Sprintf("%s/%010d", strings.ToLower(emailAddress), sequence)
+}
+
+// A ProfileSnapshot is the state of a profile's skills, availability,
+// bookings and details after an event, so that the state can be read
+// without replaying every event before it.
+type ProfileSnapshot struct {
+	ID                  string               `bson:"_id" json:"-"`
+	EmailAddress        string               `json:"emailAddress"`
+	Domain              string               `json:"domain"`
+	Sequence            int                  `json:"sequence"`
+	Time                time.Time            `json:"time"`
+	Skills              []Skill              `json:"skills"`
+	Availability        RagStatus            `json:"availability"`
+	AvailabilityChanged time.Time            `json:"availabilityChanged"`
+	AvailabilityWindows []AvailabilityWindow `json:"availabilityWindows,omitempty"`
+	Bookings            []Booking            `json:"bookings,omitempty"`
+	// Details are nil until the first DetailsChanged event, which is
+	// recorded with the rest of the profile when the log starts.
+	Details *ProfileDetails `json:"details,omitempty"`
+}
+
+// Apply returns the state after the events, which must follow the
+// snapshot in order.
+func (s ProfileSnapshot) Apply(events []ProfileEvent) ProfileSnapshot {
+	s.Skills = append([]Skill(nil), s.Skills...)
+	s.Bookings = append([]Booking(nil), s.Bookings...)
+	for _, e := range events {
+		index := -1
+		if e.Skill != nil {
+			for i, sk := range s.Skills {
+				if sk.Skill == e.Skill.Skill {
+					index = i
+				}
+			}
+		}
+		switch e.Type {
+		case SkillAdded, LevelChanged, SkillConfirmed:
+			if index < 0 {
+				s.Skills = append(s.Skills, *e.Skill)
+			} else {
+				s.Skills[index] = *e.Skill
+			}
+		case SkillRemoved:
+			if index >= 0 {
+				s.Skills = append(s.Skills[:index], s.Skills[index+1:]...)
+			}
+		case AvailabilitySet:
+			s.Availability = e.Availability
+			s.AvailabilityChanged = e.Time
+		case AvailabilityWindowsSet:
+			s.AvailabilityWindows = e.Windows
+		case BookingAdded:
+			s.Bookings = append(s.Bookings, *e.Booking)
+		case BookingRemoved:
+			var kept []Booking
+			for _, b := range s.Bookings {
+				if b.ID != e.Booking.ID {
+					kept = append(kept, b)
+				}
+			}
+			s.Bookings = kept
+		case DetailsChanged:
+			d := *e.Details
+			s.Details = &d
+		}
+		s.EmailAddress, s.Domain = e.EmailAddress, e.Domain
+		s.Sequence, s.Time = e.Sequence, e.Time
+	}
+	s.ID = profileEventID(s.EmailAddress, s.Sequence)
+	return s
+}
+
+// ProfileEvents returns the events which change the state's skills and
+// availability into the profile's, numbered from after the state's
+// sequence. A person's first events always set their availability.
+func ProfileEvents(from ProfileSnapshot, to Profile, now time.Time) []ProfileEvent {
+	e := newProfileEventLog(to.EmailAddress, from, now)
+	e.skills(to.Skills)
+	e.availability(to.Availability)
+	return e.events
+}
+
+// A profileEventLog builds the events of a change to a profile, keeping the
+// state after them, so that each event is worked out from the ones before
+// it.
+type profileEventLog struct {
+	emailAddress string
+	state        ProfileSnapshot
+	// first is true if these are the person's first events.
+	first  bool
+	now    time.Time
+	events []ProfileEvent
+}
+
+func newProfileEventLog(emailAddress string, from ProfileSnapshot, now time.Time) *profileEventLog {
+	return &profileEventLog{emailAddress: strings.ToLower(emailAddress), state: from, first: from.Sequence == 0, now: now}
+}
+
+// add numbers the event after the state, and applies it.
+func (l *profileEventLog) add(e ProfileEvent) {
+	e.Sequence = l.state.Sequence + 1
+	e.ID = profileEventID(l.emailAddress, e.Sequence)
+	e.EmailAddress, e.Domain = l.emailAddress, GetDomain(l.emailAddress)
+	e.Time = l.now
+	l.events = append(l.events, e)
+	l.state = l.state.Apply([]ProfileEvent{e})
+}
+
+// skills adds the events which change the skills to the new ones.
+func (l *profileEventLog) skills(skills []Skill) {
+	previous := make(map[string]Skill)
+	for _, s := range l.state.Skills {
+		previous[s.Skill] = s
+	}
+	removed := l.state.Skills
+	current := make(map[string]bool)
+	for _, s := range skills {
+		s := s
+		current[s.Skill] = true
+		p, ok := previous[s.Skill]
+		switch {
+		case !ok:
+			l.add(ProfileEvent{Type: SkillAdded, Skill: &s})
+		case p.Level != s.Level || p.Interest != s.Interest:
+			l.add(ProfileEvent{Type: LevelChanged, Skill: &s})
+		case !p.Confirmed.Equal(s.Confirmed):
+			l.add(ProfileEvent{Type: SkillConfirmed, Skill: &s})
+		}
+	}
+	for _, s := range removed {
+		if !current[s.Skill] {
+			l.add(ProfileEvent{Type: SkillRemoved, Skill: &Skill{Skill: s.Skill}})
+		}
+	}
+}
+
+// availability sets the availability, if it has changed or these are the
+// person's first events.
+func (l *profileEventLog) availability(a RagStatus) {
+	if l.first && !l.availabilitySet() || l.state.Availability != a {
+		l.add(ProfileEvent{Type: AvailabilitySet, Availability: a})
+	}
+}
+
+func (l *profileEventLog) availabilitySet() bool {
+	for _, e := range l.events {
+		if e.Type == AvailabilitySet {
+			return true
+		}
+	}
+	return false
+}
+
+// windows replaces the availability windows, if they have changed.
+func (l *profileEventLog) windows(windows []AvailabilityWindow) {
+	windows = utcWindows(windows)
+	if sameWindows(l.state.AvailabilityWindows, windows) {
+		return
+	}
+	l.add(ProfileEvent{Type: AvailabilityWindowsSet, Windows: windows})
+}
+
+func sameWindows(a, b []AvailabilityWindow) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Start.Equal(b[i].Start) || !a[i].End.Equal(b[i].End) || a[i].Availability != b[i].Availability ||
+			a[i].Note != b[i].Note || a[i].Source != b[i].Source {
+			return false
+		}
+	}
+	return true
+}
+
+// bookings adds and removes bookings, by their IDs, to match the new ones.
+func (l *profileEventLog) bookings(bookings []Booking) {
+	current := make(map[string]bool)
+	for _, b := range bookings {
+		current[b.ID] = true
+	}
+	previous := make(map[string]bool)
+	for _, b := range l.state.Bookings {
+		previous[b.ID] = true
+		if !current[b.ID] {
+			l.add(ProfileEvent{Type: BookingRemoved, Booking: &Booking{ID: b.ID}})
+		}
+	}
+	for _, b := range bookings {
+		if !previous[b.ID] {
+			b := utcBooking(b)
+			l.add(ProfileEvent{Type: BookingAdded, Booking: &b})
+		}
+	}
+}
+
+// details sets the details, if they have changed or haven't been set.
+func (l *profileEventLog) details(d ProfileDetails) {
+	d.Manager = strings.ToLower(d.Manager)
+	d.Department, d.CostCenter = strings.TrimSpace(d.Department), strings.TrimSpace(d.CostCenter)
+	if l.state.Details == nil || *l.state.Details != d {
+		l.add(ProfileEvent{Type: DetailsChanged, Details: &d})
+	}
+}
+
+// profile adds the events which change the state into the profile's.
+func (l *profileEventLog) profile(p Profile) {
+	l.skills(p.Skills)
+	l.availability(p.Availability)
+	l.windows(p.AvailabilityWindows)
+	l.bookings(p.Bookings)
+	l.details(p.Details())
+}
+
+// Details returns who the person is and where they sit in the organisation.
+func (p Profile) Details() ProfileDetails {
+	return ProfileDetails{Name: p.Name, Manager: p.Manager, Department: p.Department, CostCenter: p.CostCenter}
+}
+
+func utcBooking(b Booking) Booking {
+	b.Start, b.End = b.Start.UTC().Truncate(time.Millisecond), b.End.UTC().Truncate(time.Millisecond)
+	b.Created = b.Created.UTC().Truncate(time.Millisecond)
+	return b
+}
+
+// ApplyTo sets the profile's skills, availability, bookings and details to
+// the state.
+func (s ProfileSnapshot) ApplyTo(p *Profile) {
+	p.Skills = s.Skills
+	p.Availability = s.Availability
+	p.AvailabilityWindows = s.AvailabilityWindows
+	p.Bookings = s.Bookings
+	if d := s.Details; d != nil {
+		p.Name, p.Manager, p.Department, p.CostCenter = d.Name, d.Manager, d.Department, d.CostCenter
+	}
+}
+
+// ProfileAt returns the state of the person's skills and availability at
+// the time, from the latest snapshot before it and the events since. If at
+// is zero, the latest state is returned. The sequence is zero if the person
+// had no events by then.
+func ProfileAt(da DataAccess, emailAddress string, at time.Time) (ProfileSnapshot, error) {
+	emailAddress = strings.ToLower(emailAddress)
+	s := ProfileSnapshot{EmailAddress: emailAddress, Domain: GetDomain(emailAddress)}
+	snapshot, found, err := da.GetProfileSnapshot(emailAddress, at)
+	if err != nil {
+		return s, err
+	}
+	if found {
+		s = *snapshot
+	}
+	events, err := da.ListProfileEvents(emailAddress, s.Sequence)
+	if err != nil {
+		return s, err
+	}
+	for i, e := range events {
+		if !at.IsZero() && e.Time.After(at) {
+			events = events[:i]
+			break
+		}
+	}
+	return s.Apply(events), nil
+}
+
+// AppendProfileEvents adds the events to the end of the profiles' history.
+// If an event with the same sequence has already been recorded, none of the
+// later events are added and ErrProfileEventConflict is returned.
+func (da MongoDataAccess) AppendProfileEvents(events []ProfileEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	c := session.DB(da.databaseName).C("profileevents")
+	for _, e := range events {
+		e.Time = e.Time.UTC().Truncate(time.Millisecond)
+		if e.Skill != nil {
+			s := *e.Skill
+			s.Confirmed = s.Confirmed.UTC()
+			e.Skill = &s
+		}
+		e.Windows = utcWindows(e.Windows)
+		if e.Booking != nil {
+			b := utcBooking(*e.Booking)
+			e.Booking = &b
+		}
+		err = c.Insert(e)
+		if mgo.IsDup(err) {
+			return ErrProfileEventConflict
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListProfileEvents lists the person's events after the sequence, oldest
+// first.
+func (da MongoDataAccess) ListProfileEvents(emailAddress string, after int) ([]ProfileEvent, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	events := []ProfileEvent{}
+	err = session.DB(da.databaseName).C("profileevents").
+		Find(bson.M{"emailaddress": strings.ToLower(emailAddress), "sequence": bson.M{"$gt": after}}).
+		Sort("sequence").All(&events)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		events[i].Time = events[i].Time.UTC()
+		if s := events[i].Skill; s != nil {
+			s.Confirmed = s.Confirmed.UTC()
+		}
+		events[i].Windows = utcWindows(events[i].Windows)
+		if b := events[i].Booking; b != nil {
+			*b = utcBooking(*b)
+		}
+	}
+	return events, nil
+}
+
+// SaveProfileSnapshot saves the state of the profile after an event.
+func (da MongoDataAccess) SaveProfileSnapshot(s *ProfileSnapshot) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	utc := *s
+	utc.Time = utc.Time.UTC()
+	utc.AvailabilityChanged = utc.AvailabilityChanged.UTC()
+	_, err = session.DB(da.databaseName).C("profilesnapshots").UpsertId(utc.ID, utc)
+	return err
+}
+
+// GetProfileSnapshot gets the person's latest snapshot taken at or before
+// the time, or their latest snapshot if the time is zero.
+func (da MongoDataAccess) GetProfileSnapshot(emailAddress string, at time.Time) (*ProfileSnapshot, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	q := bson.M{"emailaddress": strings.ToLower(emailAddress)}
+	if !at.IsZero() {
+		q["time"] = bson.M{"$lte": at.UTC()}
+	}
+	var s ProfileSnapshot
+	err = session.DB(da.databaseName).C("profilesnapshots").Find(q).Sort("-sequence").One(&s)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	s.Time = s.Time.UTC()
+	s.AvailabilityChanged = s.AvailabilityChanged.UTC()
+	for i := range s.Skills {
+		s.Skills[i].Confirmed = s.Skills[i].Confirmed.UTC()
+	}
+	s.AvailabilityWindows = utcWindows(s.AvailabilityWindows)
+	for i := range s.Bookings {
+		s.Bookings[i] = utcBooking(s.Bookings[i])
+	}
+	return &s, true, nil
+}
+
+// ProjectProfile sets the person's skills, availability, bookings and
+// details to the state derived from their events. The rest of the profile
+// is left alone.
+func (da MongoDataAccess) ProjectProfile(s *ProfileSnapshot) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	set := bson.M{
+		"skills":              s.Skills,
+		"availability":        s.Availability,
+		"availabilitywindows": utcWindows(s.AvailabilityWindows),
+		"bookings":            s.Bookings,
+	}
+	if d := s.Details; d != nil {
+		set["name"], set["manager"] = d.Name, d.Manager
+		set["department"], set["costcenter"] = d.Department, d.CostCenter
+	}
+	return session.DB(da.databaseName).C("profiles").UpdateId(strings.ToLower(s.EmailAddress), bson.M{"$set": set})
+}
+
+// removeProfileEvents removes the person's history.
+func removeProfileEvents(db *mgo.Database, emailAddresses ...string) error {
+	q := bson.M{"emailaddress": bson.M{"$in": emailAddresses}}
+	if _, err := db.C("profileevents").RemoveAll(q); err != nil {
+		return err
+	}
+	_, err := db.C("profilesnapshots").RemoveAll(q)
+	return err
+}
+
+// removeProfileData removes everything kept about the people other than
+// their profiles and files, when their profiles are deleted, merged into
+// another or purged: their history, calibrations, course completions, skill
+// suggestions, share links and archived profiles.
+func removeProfileData(db *mgo.Database, emailAddresses ...string) error {
+	if err := removeProfileEvents(db, emailAddresses...); err != nil {
+		return err
+	}
+	q := bson.M{"emailaddress": bson.M{"$in": emailAddresses}}
+	for _, c := range []string{"calibrations", "completions", "skillsuggestions", "sharelinks"} {
+		if _, err := db.C(c).RemoveAll(q); err != nil {
+			return err
+		}
+	}
+	_, err := db.C("archivedprofiles").RemoveAll(bson.M{"_id": bson.M{"$in": emailAddresses}})
+	return err
+}
@@ -0,0 +1,256 @@
+package dataaccess
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+func (m *memoryProfiles) AppendProfileEvents(events []ProfileEvent) error {
+	for _, e := range events {
+		for _, existing := range m.events {
+			if existing.ID == e.ID {
+				return ErrProfileEventConflict
+			}
+		}
+		m.events = append(m.events, e)
+	}
+	return nil
+}
+
+func (m *memoryProfiles) ListProfileEvents(emailAddress string, after int) ([]ProfileEvent, error) {
+	op := []ProfileEvent{}
+	for _, e := range m.events {
+		if e.EmailAddress == emailAddress && e.Sequence > after {
+			op = append(op, e)
+		}
+	}
+	return op, nil
+}
+
+func (m *memoryProfiles) SaveProfileSnapshot(s *ProfileSnapshot) error {
+	m.snapshots = append(m.snapshots, *s)
+	return nil
+}
+
+func (m *memoryProfiles) GetProfileSnapshot(emailAddress string, at time.Time) (*ProfileSnapshot, bool, error) {
+	for i := len(m.snapshots) - 1; i >= 0; i-- {
+		if s := m.snapshots[i]; s.EmailAddress == emailAddress && (at.IsZero() || !s.Time.After(at)) {
+			return &s, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (m *memoryProfiles) ConfirmSkills(emailAddress string, skills []string) error {
+	return nil
+}
+
+func (m *memoryProfiles) ProjectProfile(s *ProfileSnapshot) error {
+	p, ok := m.profiles[s.EmailAddress]
+	if !ok {
+		return mgo.ErrNotFound
+	}
+	s.ApplyTo(&p)
+	m.profiles[s.EmailAddress] = p
+	return nil
+}
+
+func (m *memoryProfiles) SyncEmployee(e EmployeeUpdate) error {
+	p, ok := m.profiles[e.EmailAddress]
+	if !ok {
+		p = Profile{EmailAddress: e.EmailAddress, Domain: GetDomain(e.EmailAddress)}
+	}
+	p.Name, p.Manager = e.Name, e.Manager
+	p.Employment = &e.Employment
+	m.profiles[e.EmailAddress] = p
+	return nil
+}
+
+func TestThatChangesToProfilesAreEvents(t *testing.T) {
+	now := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	from := ProfileSnapshot{
+		EmailAddress: "adrian@github.com",
+		Sequence:     4,
+		Skills:       []Skill{{Skill: "go", Level: 3}, {Skill: "java", Level: 2}},
+		Availability: Green,
+	}
+	to := Profile{
+		EmailAddress: "adrian@github.com",
+		Skills:       []Skill{{Skill: "go", Level: 4}, {Skill: "rust", Level: 1}},
+		Availability: Red,
+	}
+
+	events := ProfileEvents(from, to, now)
+
+	var types []ProfileEventType
+	for i, e := range events {
+		types = append(types, e.Type)
+		if e.Sequence != 5+i || e.ID != profileEventID("adrian@github.com", 5+i) || e.Domain != "github.com" {
+			t.Errorf("Expected the events to follow the state, but got %+v", e)
+		}
+	}
+	expected := []ProfileEventType{LevelChanged, SkillAdded, SkillRemoved, AvailabilitySet}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("Expected %v, but got %v", expected, types)
+	}
+
+	state := from.Apply(events)
+	if !reflect.DeepEqual(state.Skills, to.Skills) || state.Availability != Red || state.Sequence != 8 || !state.AvailabilityChanged.Equal(now) {
+		t.Errorf("Expected the events to bring the state up to date, but got %+v", state)
+	}
+	if again := ProfileEvents(state, to, now); len(again) != 0 {
+		t.Errorf("Expected no events once the state is up to date, but got %+v", again)
+	}
+}
+
+func TestThatProfileStateIsDerivedFromEventsAndSnapshots(t *testing.T) {
+	mp := newMemoryProfiles()
+	da := NewEventSourcingDataAccess(mp, 3)
+	day := 0
+	da.now = func() time.Time { return time.Date(2018, time.March, 1+day, 0, 0, 0, 0, time.UTC) }
+
+	levels := []DreyfusLevel{1, 2, 3, 4, 5}
+	for i, l := range levels {
+		day = i
+		_, err := da.UpdateProfile(&ProfileUpdate{EmailAddress: "adrian@github.com", Skills: []Skill{{Skill: "go", Level: l}}, Availability: Green})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first update adds the skill, sets the availability and records
+	// the person's details, and each one after changes the level.
+	if len(mp.events) != 7 || len(mp.snapshots) != 2 || mp.snapshots[1].Sequence != 6 {
+		t.Errorf("Expected 7 events with a snapshot after every 3, but got %d events and %+v", len(mp.events), mp.snapshots)
+	}
+	at, err := ProfileAt(mp, "adrian@github.com", time.Date(2018, time.March, 3, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(at.Skills) != 1 || at.Skills[0].Level != 3 || at.Sequence != 5 {
+		t.Errorf("Expected level 3 on the 3rd of March, but got %+v", at)
+	}
+	latest, err := ProfileAt(mp, "adrian@github.com", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Skills[0].Level != 5 || latest.Sequence != 7 {
+		t.Errorf("Expected the latest state to be level 5, but got %+v", latest)
+	}
+}
+
+func TestThatProfilesAreRebuiltFromTheEventLog(t *testing.T) {
+	mp := newMemoryProfiles()
+	// The profile was created before its changes were recorded as events.
+	mp.profiles["adrian@github.com"] = Profile{
+		EmailAddress: "adrian@github.com",
+		Domain:       "github.com",
+		Name:         "Adrian",
+		Skills:       []Skill{{Skill: "go", Level: 3}},
+		Availability: Green,
+	}
+	da := NewEventSourcingDataAccess(mp, DefaultSnapshotInterval)
+
+	if err := da.ConfirmSkills("adrian@github.com", []string{"go"}); err != nil {
+		t.Fatal(err)
+	}
+	var types []ProfileEventType
+	for _, e := range mp.events {
+		types = append(types, e.Type)
+	}
+	expected := []ProfileEventType{SkillAdded, AvailabilitySet, DetailsChanged, SkillConfirmed}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("Expected the profile to be recorded before the change, but got %v", types)
+	}
+
+	// e.g. the profile was restored from an old backup.
+	p := mp.profiles["adrian@github.com"]
+	p.Skills, p.Name = []Skill{{Skill: "java", Level: 1}}, "Someone else"
+	mp.profiles["adrian@github.com"] = p
+	if err := da.RebuildProfile("adrian@github.com"); err != nil {
+		t.Fatal(err)
+	}
+	p = mp.profiles["adrian@github.com"]
+	if len(p.Skills) != 1 || p.Skills[0].Skill != "go" || p.Skills[0].Confirmed.IsZero() || p.Name != "Adrian" {
+		t.Errorf("Expected the profile to be rebuilt from the events, but got %+v", p)
+	}
+
+	if err := da.ConfirmSkills("nobody@github.com", []string{"go"}); err != nil {
+		t.Errorf("Expected confirming a missing profile's skills to do nothing, but got %v", err)
+	}
+}
+
+func TestThatBookingsAndAvailabilityWindowsAreEvents(t *testing.T) {
+	mp := newMemoryProfiles("adrian@github.com")
+	da := NewEventSourcingDataAccess(mp, DefaultSnapshotInterval)
+	start := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	b := Booking{ID: "b1", Project: "pill", Start: start, End: start.AddDate(0, 1, 0), Percentage: 60}
+	if err := da.AddBooking("adrian@github.com", b); err != nil {
+		t.Fatal(err)
+	}
+	over := Booking{ID: "b2", Project: "other", Start: start, End: start.AddDate(0, 0, 7), Percentage: 50}
+	if err := da.AddBooking("adrian@github.com", over); err != ErrOverbooked {
+		t.Errorf("Expected ErrOverbooked, but got %v", err)
+	}
+	if bookings := mp.profiles["adrian@github.com"].Bookings; len(bookings) != 1 || bookings[0].ID != "b1" {
+		t.Errorf("Expected the booking to be added to the profile, but got %+v", bookings)
+	}
+
+	windows := []AvailabilityWindow{{Start: start, End: start.AddDate(0, 0, 14), Availability: Red}}
+	if err := da.UpdateAvailabilityWindows("adrian@github.com", windows); err != nil {
+		t.Fatal(err)
+	}
+	if err := da.RemoveBooking("adrian@github.com", "b2"); err != ErrBookingNotFound {
+		t.Errorf("Expected ErrBookingNotFound, but got %v", err)
+	}
+	if err := da.RemoveBooking("adrian@github.com", "b1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var types []ProfileEventType
+	for _, e := range mp.events {
+		types = append(types, e.Type)
+	}
+	expected := []ProfileEventType{AvailabilitySet, DetailsChanged, BookingAdded, AvailabilityWindowsSet, BookingRemoved}
+	if !reflect.DeepEqual(types, expected) {
+		t.Errorf("Expected %v, but got %v", expected, types)
+	}
+	p := mp.profiles["adrian@github.com"]
+	if len(p.Bookings) != 0 || !reflect.DeepEqual(p.AvailabilityWindows, windows) {
+		t.Errorf("Expected the profile to match the events, but got %+v", p)
+	}
+	if err := da.AddBooking("nobody@github.com", b); err != mgo.ErrNotFound {
+		t.Errorf("Expected a missing profile not to be booked, but got %v", err)
+	}
+}
+
+func TestThatChangesFromTheHRSystemAreEvents(t *testing.T) {
+	mp := newMemoryProfiles()
+	da := NewEventSourcingDataAccess(mp, DefaultSnapshotInterval)
+
+	department := "Engineering"
+	e := EmployeeUpdate{EmailAddress: "adrian@github.com", Name: "Adrian", Manager: "Boss@github.com", Department: &department}
+	if err := da.SyncEmployee(e); err != nil {
+		t.Fatal(err)
+	}
+	e.Name, e.Department = "Adrian H", nil
+	if err := da.SyncEmployee(e); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := ProfileAt(mp, "adrian@github.com", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ProfileDetails{Name: "Adrian H", Manager: "boss@github.com", Department: "Engineering"}
+	if state.Sequence != 2 || state.Details == nil || *state.Details != expected {
+		t.Errorf("Expected 2 changes to the details, keeping the department, but got %+v", state)
+	}
+	if p := mp.profiles["adrian@github.com"]; p.Details() != expected || p.Employment == nil {
+		t.Errorf("Expected the profile to be rebuilt from the events, but got %+v", p)
+	}
+}
@@ -0,0 +1,79 @@
+package dataaccess
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/a-h/pill/script"
+)
+
+// MaxProfileRules is the most rules a tenant can have.
+const MaxProfileRules = 50
+
+// A ProfileRule is a tenant's own check on the profiles people save, or a
+// change it makes to them, written as script expressions, e.g. a Require of
+// `department != "" || startsWith(emailAddress, "contractor.")`.
+type ProfileRule struct {
+	Name string `json:"name"`
+	// When, if not empty, limits the rule to the profiles it's true for.
+	When string `json:"when,omitempty"`
+	// Require, if not empty, must be true, or the update is rejected with
+	// the Message.
+	Require string `json:"require,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Set fills in fields of the profile from the strings the expressions
+	// evaluate to, by the name of the field, e.g. "department".
+	Set map[string]string `json:"set,omitempty"`
+}
+
+// ProfileRuleFields are the fields of a profile which a rule can set.
+var ProfileRuleFields = []string{"name", "manager", "department", "costCenter", "bio", "language", "timeZone"}
+
+// profileRuleProblems checks that the rules are named, and their expressions parse.
+func profileRuleProblems(rules []ProfileRule) []string {
+	var problems []string
+	if len(rules) > MaxProfileRules {
+		problems = append(problems, fmt.Sprintf("there must be no more than %d profile rules", MaxProfileRules))
+	}
+	names := make(map[string]bool)
+	for _, r := range rules {
+		if strings.TrimSpace(r.Name) == "" {
+			problems = append(problems, "profile rules must have a name")
+		} else if names[r.Name] {
+			problems = append(problems, fmt.Sprintf("there is more than one profile rule named '%s'", r.Name))
+		}
+		names[r.Name] = true
+		if r.Require == "" && len(r.Set) == 0 {
+			problems = append(problems, fmt.Sprintf("the profile rule '%s' must require something or set a field", r.Name))
+		}
+		if r.Require != "" && strings.TrimSpace(r.Message) == "" {
+			problems = append(problems, fmt.Sprintf("the profile rule '%s' must have a message to show when it isn't met", r.Name))
+		}
+		check := func(part string, expression string) {
+			if expression == "" {
+				return
+			}
+			if _, err := script.Parse(expression); err != nil {
+				problems = append(problems, fmt.Sprintf("the %s of the profile rule '%s' is invalid: %v", part, r.Name, err))
+			}
+		}
+		check("condition", r.When)
+		check("requirement", r.Require)
+		for field, expression := range r.Set {
+			if !isProfileRuleField(field) {
+				problems = append(problems, fmt.Sprintf("the profile rule '%s' can't set '%s', only %s", r.Name, field, strings.Join(ProfileRuleFields, ", ")))
+			}
+			check(field, expression)
+		}
+	}
+	return problems
+}
+
+func isProfileRuleField(field string) bool {
+	for _, f := range ProfileRuleFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
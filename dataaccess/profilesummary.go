@@ -0,0 +1,113 @@
+package dataaccess
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ConsentSummaries is the purpose of sending people's skills, projects and
+// certifications to a language model to summarise them. People who haven't
+// consented don't have a summary.
+const ConsentSummaries = "summaries"
+
+// SummarySettings control whether the tenant's profiles are summarised.
+type SummarySettings struct {
+	// Enabled sends the skills, projects and certifications on each profile
+	// to the language model, to write a summary of what the person can do.
+	// Nothing is sent unless it's enabled.
+	Enabled bool `json:"enabled"`
+}
+
+// A ProfileSummary is a short description of what a person can do, written
+// by a language model from their profile.
+type ProfileSummary struct {
+	EmailAddress string `bson:"_id" json:"emailAddress"`
+	Domain       string `json:"domain"`
+	Summary      string `json:"summary"`
+	// Source identifies the details the summary was written from, so that
+	// it's written again when they change.
+	Source    string    `json:"-"`
+	Generated time.Time `json:"generated"`
+	// Regenerate is set when the person asks for the summary to be written
+	// again, even though their profile hasn't changed.
+	Regenerate bool `json:"regenerate"`
+}
+
+// SaveProfileSummary creates or replaces the person's summary.
+func (da MongoDataAccess) SaveProfileSummary(s *ProfileSummary) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	utc := *s
+	utc.EmailAddress = strings.ToLower(utc.EmailAddress)
+	utc.Domain = GetDomain(utc.EmailAddress)
+	utc.Generated = utc.Generated.UTC().Truncate(time.Millisecond)
+	_, err = session.DB(da.databaseName).C("profilesummaries").UpsertId(utc.EmailAddress, utc)
+	return err
+}
+
+// GetProfileSummary gets the person's summary.
+func (da MongoDataAccess) GetProfileSummary(emailAddress string) (*ProfileSummary, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	var s ProfileSummary
+	err = session.DB(da.databaseName).C("profilesummaries").FindId(strings.ToLower(emailAddress)).One(&s)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	s.Generated = s.Generated.UTC()
+	return &s, true, nil
+}
+
+// ListProfileSummaries lists the summaries in the domain, in order of email
+// address.
+func (da MongoDataAccess) ListProfileSummaries(domain string) ([]ProfileSummary, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	summaries := []ProfileSummary{}
+	err = session.DB(da.databaseName).C("profilesummaries").Find(bson.M{"domain": strings.ToLower(domain)}).Sort("_id").All(&summaries)
+	if err != nil {
+		return nil, err
+	}
+	for i := range summaries {
+		summaries[i].Generated = summaries[i].Generated.UTC()
+	}
+	return summaries, nil
+}
+
+// RemoveProfileSummary removes the person's summary, if they have one.
+func (da MongoDataAccess) RemoveProfileSummary(emailAddress string) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(da.databaseName).C("profilesummaries").RemoveId(strings.ToLower(emailAddress))
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+package dataaccess
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A SkillRequirement is a skill some work needs, at a minimum level.
+type SkillRequirement struct {
+	Skill string       `json:"skill"`
+	Level DreyfusLevel `json:"level"`
+}
+
+// A ProjectStatus is whether a project is going ahead.
+type ProjectStatus string
+
+// The states of a project.
+const (
+	// ProjectDraft projects have been drafted from opportunities in a CRM, and
+	// may not go ahead.
+	ProjectDraft ProjectStatus = "draft"
+	// ProjectConfirmed projects are going ahead.
+	ProjectConfirmed ProjectStatus = "confirmed"
+)
+
+// A Project is a piece of work for a client, which needs people with the
+// required skills between the start and end dates. People are allocated to
+// a project by booking them onto it.
+type Project struct {
+	ID           string             `bson:"_id" json:"id"`
+	Domain       string             `json:"domain"`
+	Name         string             `json:"name"`
+	Client       string             `json:"client,omitempty"`
+	Start        time.Time          `json:"start"`
+	End          time.Time          `json:"end"`
+	Requirements []SkillRequirement `json:"requirements"`
+	Status       ProjectStatus      `json:"status"`
+	// Opportunity identifies the CRM opportunity a draft project was created
+	// from, e.g. "salesforce:0061U00000abcdE".
+	Opportunity string    `json:"opportunity,omitempty"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+// Validate checks that the project has a name and a status, ends after it
+// starts, and that the required levels are on the Dreyfus scale.
+func (p Project) Validate() error {
+	var problems []string
+	if strings.TrimSpace(p.Name) == "" {
+		problems = append(problems, "the name is required")
+	}
+	if !p.End.After(p.Start) {
+		problems = append(problems, "projects must end after they start")
+	}
+	if p.Status != ProjectDraft && p.Status != ProjectConfirmed {
+		problems = append(problems, "the status must be draft or confirmed")
+	}
+	for _, r := range p.Requirements {
+		if CleanTag(r.Skill) == "" {
+			problems = append(problems, "required skills must have a name")
+		}
+		if r.Level < NoviceLevel || r.Level > MasterLevel {
+			problems = append(problems, "the level of "+r.Skill+" must be between 1 and 5")
+		}
+	}
+	return newValidationError(problems)
+}
+
+// SaveProject creates or updates a project.
+func (da MongoDataAccess) SaveProject(p *Project) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	p.Start, p.End = p.Start.UTC().Truncate(time.Millisecond), p.End.UTC().Truncate(time.Millisecond)
+	p.Created, p.Updated = p.Created.UTC().Truncate(time.Millisecond), p.Updated.UTC().Truncate(time.Millisecond)
+	_, err = session.DB(da.databaseName).C("projects").UpsertId(p.ID, p)
+	return err
+}
+
+// ListProjects lists the projects of the domain, in order of their start
+// dates.
+func (da MongoDataAccess) ListProjects(domain string) ([]Project, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var results []Project
+	err = session.DB(da.databaseName).C("projects").
+		Find(bson.M{"domain": strings.ToLower(domain)}).
+		Sort("start").
+		All(&results)
+	for i := range results {
+		results[i].inUTC()
+	}
+	return results, err
+}
+
+// GetProject returns a project.
+func (da MongoDataAccess) GetProject(id string) (*Project, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	p := &Project{}
+	err = session.DB(da.databaseName).C("projects").FindId(id).One(p)
+
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	p.inUTC()
+	return p, true, nil
+}
+
+// DeleteProject deletes a project. Bookings onto the project are kept.
+func (da MongoDataAccess) DeleteProject(id string) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(da.databaseName).C("projects").RemoveId(id)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// inUTC converts the project's timestamps to UTC.
+func (p *Project) inUTC() {
+	p.Start, p.End = p.Start.UTC(), p.End.UTC()
+	p.Created, p.Updated = p.Created.UTC(), p.Updated.UTC()
+}
@@ -0,0 +1,66 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrQuarantined is returned when a change is held for review by an
+// administrator, instead of being made.
+var ErrQuarantined = errors.New("dataaccess: the change has been quarantined pending review by an administrator")
+
+// A ChangeKind is a kind of change which can be quarantined.
+type ChangeKind string
+
+// The kinds of change which are checked for anomalies.
+const (
+	UpdateProfileChange ChangeKind = "updateProfile"
+	DeleteProfileChange ChangeKind = "deleteProfile"
+)
+
+// A QuarantinedChange is a change which was held for review because it was
+// part of an anomaly.
+type QuarantinedChange struct {
+	ID   string    `bson:"_id" json:"id"`
+	Time time.Time `json:"time"`
+	// Actor is the email address of the user who made the change.
+	Actor string `json:"actor"`
+	// Impersonator is the email address of the administrator acting as the
+	// Actor, if the change was made during impersonation.
+	Impersonator string     `json:"impersonator,omitempty"`
+	Tenant       string     `json:"tenant"`
+	Kind         ChangeKind `json:"kind"`
+	// Target is the email address of the profile being changed.
+	Target string `json:"target"`
+	// Update is the profile update, if the Kind is UpdateProfileChange.
+	Update *ProfileUpdate `json:"update,omitempty"`
+	// Reason describes the anomaly the change was part of.
+	Reason string `json:"reason"`
+}
+
+// Apply makes the change.
+func (c QuarantinedChange) Apply(da DataAccess) error {
+	switch c.Kind {
+	case UpdateProfileChange:
+		_, err := da.UpdateProfile(c.Update)
+		return err
+	case DeleteProfileChange:
+		_, err := da.DeleteProfile(c.Target)
+		return err
+	}
+	return errors.New("dataaccess: unknown change kind " + string(c.Kind))
+}
+
+type releaseKey struct{}
+
+// Release returns a context which allows changes to bypass the quarantine,
+// so that an administrator can apply changes they've reviewed.
+func Release(ctx context.Context) context.Context {
+	return context.WithValue(ctx, releaseKey{}, true)
+}
+
+func isReleased(ctx context.Context) bool {
+	released, _ := ctx.Value(releaseKey{}).(bool)
+	return released
+}
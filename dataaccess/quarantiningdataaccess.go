@@ -0,0 +1,107 @@
+package dataaccess
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// QuarantiningDataAccess wraps a DataAccess and watches for callers who
+// change other people's profiles, or delete profiles, faster than the
+// detector's thresholds allow. Anomalies are recorded in the audit log and,
+// if Quarantine is set, the changes are held for review by an administrator
+// instead of being made.
+type QuarantiningDataAccess struct {
+	DataAccess
+	detector   *AnomalyDetector
+	log        audit.Log
+	quarantine bool
+	ctx        context.Context
+}
+
+// NewQuarantiningDataAccess creates a DataAccess which checks changes for
+// anomalies. Changes made without a caller, e.g. by scheduled jobs, are not
+// checked.
+func NewQuarantiningDataAccess(da DataAccess, d *AnomalyDetector, l audit.Log, quarantine bool) DataAccess {
+	return &QuarantiningDataAccess{da, d, l, quarantine, context.Background()}
+}
+
+// WithContext checks changes made by the context's caller.
+func (da QuarantiningDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &QuarantiningDataAccess{WithContext(da.DataAccess, ctx), da.detector, da.log, da.quarantine, ctx}
+}
+
+// check returns ErrQuarantined if the change should be held for review. The
+// change is recorded against the impersonator, if there is one, so that an
+// administrator can't avoid the thresholds by impersonating other users.
+func (da QuarantiningDataAccess) check(kind ChangeKind, target string, update *ProfileUpdate) error {
+	c, ok := caller.FromContext(da.ctx)
+	if !ok || isReleased(da.ctx) {
+		return nil
+	}
+
+	actor := c.EmailAddress
+	if c.Impersonator != "" {
+		actor = c.Impersonator
+	}
+
+	if kind == UpdateProfileChange && strings.EqualFold(actor, target) {
+		return nil
+	}
+
+	anomaly, suspicious := da.detector.Observe(actor, kind)
+	if !suspicious {
+		return nil
+	}
+
+	e := audit.NewEntry(da.ctx, audit.AnomalyDetected, GetDomain(target), target)
+	e.Details = anomaly.String()
+	if err := da.log.Record(e); err != nil {
+		log.Printf("Failed to record an anomaly in the audit log. %v", err)
+	}
+	log.Printf("Anomaly detected: %s.", anomaly)
+
+	if !da.quarantine {
+		return nil
+	}
+
+	qc := &QuarantinedChange{
+		ID:           bson.NewObjectId().Hex(),
+		Time:         time.Now().UTC(),
+		Actor:        c.EmailAddress,
+		Impersonator: c.Impersonator,
+		Tenant:       GetDomain(target),
+		Kind:         kind,
+		Target:       target,
+		Update:       update,
+		Reason:       anomaly.String(),
+	}
+	if err := da.DataAccess.QuarantineChange(qc); err != nil {
+		// If the change can't be held, it mustn't be made either.
+		log.Printf("Failed to quarantine the change to %s. %v", target, err)
+		return err
+	}
+
+	return ErrQuarantined
+}
+
+// UpdateProfile updates the profile, unless the change is quarantined.
+func (da QuarantiningDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	if err := da.check(UpdateProfileChange, update.EmailAddress, update); err != nil {
+		return nil, err
+	}
+	return da.DataAccess.UpdateProfile(update)
+}
+
+// DeleteProfile deletes the profile, unless the change is quarantined.
+func (da QuarantiningDataAccess) DeleteProfile(emailAddress string) (bool, error) {
+	if err := da.check(DeleteProfileChange, emailAddress, nil); err != nil {
+		return false, err
+	}
+	return da.DataAccess.DeleteProfile(emailAddress)
+}
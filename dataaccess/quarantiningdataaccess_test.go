@@ -0,0 +1,118 @@
+package dataaccess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+)
+
+type quarantineStub struct {
+	stubDataAccess
+	updated     int
+	quarantined []*QuarantinedChange
+}
+
+func (da *quarantineStub) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	da.updated++
+	return da.stubDataAccess.UpdateProfile(update)
+}
+
+func (da *quarantineStub) QuarantineChange(c *QuarantinedChange) error {
+	da.quarantined = append(da.quarantined, c)
+	return nil
+}
+
+func newQuarantineTest(c caller.Caller, quarantine bool) (*quarantineStub, audit.Log, DataAccess) {
+	stub := &quarantineStub{}
+	l := audit.NewMemoryLog()
+	d := NewAnomalyDetector(AnomalyThresholds{Window: DefaultAnomalyThresholds().Window, ProfileEdits: 2, Deletes: 2})
+	ctx := caller.NewContext(context.Background(), c)
+	return stub, l, WithContext(NewQuarantiningDataAccess(stub, d, l, quarantine), ctx)
+}
+
+func updateOf(emailAddress string) *ProfileUpdate {
+	update := NewProfileUpdate()
+	update.EmailAddress = emailAddress
+	return update
+}
+
+func TestThatChangesToOtherProfilesAreQuarantinedWhenAnomalous(t *testing.T) {
+	stub, l, da := newQuarantineTest(caller.Caller{EmailAddress: "admin@github.com"}, true)
+
+	for _, target := range []string{"a@github.com", "b@github.com"} {
+		if _, err := da.UpdateProfile(updateOf(target)); err != nil {
+			t.Errorf("Changes within the threshold should be made, but received %v", err)
+		}
+	}
+
+	if _, err := da.UpdateProfile(updateOf("c@github.com")); err != ErrQuarantined {
+		t.Errorf("Expected the third change to be quarantined, but received %v", err)
+	}
+
+	if stub.updated != 2 || len(stub.quarantined) != 1 || stub.quarantined[0].Target != "c@github.com" || stub.quarantined[0].Actor != "admin@github.com" {
+		t.Errorf("Expected 2 changes to be made and the change to c@github.com to be quarantined, but %d were made and %v quarantined.", stub.updated, stub.quarantined)
+	}
+
+	entries, _ := l.List(audit.Query{})
+	if len(entries) != 1 || entries[0].Action != audit.AnomalyDetected || entries[0].Target != "c@github.com" {
+		t.Errorf("Expected the anomaly to be recorded in the audit log, but received %v", entries)
+	}
+}
+
+func TestThatAnomaliesAreOnlyRecordedWhenQuarantineIsDisabled(t *testing.T) {
+	stub, l, da := newQuarantineTest(caller.Caller{EmailAddress: "admin@github.com"}, false)
+
+	for _, target := range []string{"a@github.com", "b@github.com", "c@github.com"} {
+		if _, err := da.UpdateProfile(updateOf(target)); err != nil {
+			t.Errorf("Changes should not be quarantined, but received %v", err)
+		}
+	}
+
+	if entries, _ := l.List(audit.Query{}); stub.updated != 3 || len(entries) != 1 {
+		t.Errorf("Expected all changes to be made and the anomaly to be recorded, but %d were made and %d entries recorded.", stub.updated, len(entries))
+	}
+}
+
+func TestThatUsersCanChangeTheirOwnProfileFreely(t *testing.T) {
+	stub, _, da := newQuarantineTest(caller.Caller{EmailAddress: "a-h@github.com"}, true)
+
+	for i := 0; i < 5; i++ {
+		da.UpdateProfile(updateOf("a-h@github.com"))
+	}
+
+	if stub.updated != 5 {
+		t.Errorf("Expected every change to the user's own profile to be made, but %d were.", stub.updated)
+	}
+}
+
+func TestThatImpersonatorsAreCountedAsTheActor(t *testing.T) {
+	stub := &quarantineStub{}
+	d := NewAnomalyDetector(AnomalyThresholds{Window: DefaultAnomalyThresholds().Window, ProfileEdits: 1, Deletes: 1})
+	qda := NewQuarantiningDataAccess(stub, d, audit.NewMemoryLog(), true)
+
+	var err error
+	for _, target := range []string{"a@github.com", "b@github.com"} {
+		ctx := caller.NewContext(context.Background(), caller.Caller{EmailAddress: target, Impersonator: "admin@github.com"})
+		_, err = WithContext(qda, ctx).UpdateProfile(updateOf(target))
+	}
+
+	if err != ErrQuarantined || len(stub.quarantined) != 1 || stub.quarantined[0].Impersonator != "admin@github.com" {
+		t.Errorf("Expected the administrator's second change to be quarantined, but received %v and %v", err, stub.quarantined)
+	}
+}
+
+func TestThatReleasedAndSystemChangesAreNotChecked(t *testing.T) {
+	stub := &quarantineStub{}
+	d := NewAnomalyDetector(AnomalyThresholds{Window: DefaultAnomalyThresholds().Window})
+	qda := NewQuarantiningDataAccess(stub, d, audit.NewMemoryLog(), true)
+
+	qda.DeleteProfile("a@github.com")
+	released := Release(caller.NewContext(context.Background(), caller.Caller{EmailAddress: "admin@github.com"}))
+	WithContext(qda, released).DeleteProfile("b@github.com")
+
+	if len(stub.quarantined) != 0 {
+		t.Errorf("Expected no changes to be quarantined, but received %v", stub.quarantined)
+	}
+}
@@ -0,0 +1,305 @@
+package dataaccess
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A SearchDocument is the read model of a profile used to search for
+// people. It's updated from the changes to the profile, and holds only what
+// searches and listings need, so that they never read the profiles
+// themselves.
+type SearchDocument struct {
+	EmailAddress string        `bson:"_id" json:"emailAddress"`
+	Domain       string        `json:"domain"`
+	Name         string        `json:"name,omitempty"`
+	Manager      string        `json:"manager,omitempty"`
+	Department   string        `json:"department,omitempty"`
+	CostCenter   string        `json:"costCenter,omitempty"`
+	Availability RagStatus     `json:"availability"`
+	Skills       []SearchSkill `json:"skills"`
+	// Languages are the ISO 639-1 codes of the languages the person speaks.
+	Languages []string `json:"languages,omitempty"`
+	// Terms are the words in the name, skills and department, in lower case,
+	// which free text searches match.
+	Terms   []string  `json:"-"`
+	Updated time.Time `json:"updated"`
+}
+
+// A SearchSkill is a skill in a SearchDocument.
+type SearchSkill struct {
+	Skill    string       `json:"skill"`
+	Level    DreyfusLevel `json:"level"`
+	Interest LikertScale  `json:"interest"`
+}
+
+// NewSearchDocument creates the search document of the profile.
+func NewSearchDocument(p Profile, now time.Time) SearchDocument {
+	d := SearchDocument{
+		EmailAddress: strings.ToLower(p.EmailAddress),
+		Domain:       GetDomain(p.EmailAddress),
+		Name:         p.Name,
+		Manager:      strings.ToLower(p.Manager),
+		Department:   p.Department,
+		CostCenter:   p.CostCenter,
+		Availability: p.Availability,
+		Skills:       []SearchSkill{},
+		Updated:      now.UTC().Truncate(time.Millisecond),
+	}
+	text := []string{p.Name, p.Department}
+	for _, s := range p.Skills {
+		d.Skills = append(d.Skills, SearchSkill{Skill: s.Skill, Level: s.Level, Interest: s.Interest})
+		text = append(text, s.Skill)
+	}
+	for _, l := range p.Languages {
+		d.Languages = append(d.Languages, l.Code)
+	}
+	d.Terms = searchTerms(strings.Join(text, " "))
+	return d
+}
+
+// Level returns the person's level of the skill, or zero if they don't have
+// it.
+func (d SearchDocument) Level(skill string) DreyfusLevel {
+	for _, s := range d.Skills {
+		if s.Skill == skill {
+			return s.Level
+		}
+	}
+	return 0
+}
+
+// searchTerms splits the text into its distinct lower case words. Skills
+// such as "c#" and "node.js" keep their punctuation.
+func searchTerms(text string) []string {
+	seen := make(map[string]bool)
+	var op []string
+	for _, t := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == '(' || r == ')'
+	}) {
+		if !seen[t] {
+			seen[t] = true
+			op = append(op, t)
+		}
+	}
+	sort.Strings(op)
+	return op
+}
+
+// A SearchQuery finds people in a tenant. Empty fields match everyone.
+type SearchQuery struct {
+	// Skill and Level find the people with the skill at the level or above.
+	Skill string       `json:"skill,omitempty"`
+	Level DreyfusLevel `json:"level,omitempty"`
+	// Text finds the people with every word in their name, skills or
+	// department.
+	Text       string `json:"text,omitempty"`
+	Manager    string `json:"manager,omitempty"`
+	Department string `json:"department,omitempty"`
+	// Availability finds the people who are at least as available.
+	Availability RagStatus `json:"availability,omitempty"`
+}
+
+func (q SearchQuery) query(domain string) bson.M {
+	m := bson.M{"domain": strings.ToLower(domain)}
+	if q.Skill != "" {
+		m["skills"] = bson.M{"$elemMatch": bson.M{"skill": strings.ToLower(q.Skill), "level": bson.M{"$gte": q.Level}}}
+	}
+	if terms := searchTerms(q.Text); len(terms) > 0 {
+		m["terms"] = bson.M{"$all": terms}
+	}
+	if q.Manager != "" {
+		m["manager"] = strings.ToLower(q.Manager)
+	}
+	if q.Department != "" {
+		m["department"] = q.Department
+	}
+	if q.Availability > 0 {
+		m["availability"] = bson.M{"$gte": q.Availability}
+	}
+	return m
+}
+
+// A TeamSummary is the read model of a manager's direct reports: how many
+// there are, how many are available and the skills they have.
+type TeamSummary struct {
+	Manager   string            `bson:"_id" json:"manager"`
+	Domain    string            `json:"domain"`
+	Members   []string          `json:"members"`
+	Available int               `json:"available"`
+	Skills    []DepartmentSkill `json:"skills"`
+	Updated   time.Time         `json:"updated"`
+}
+
+// SummariseTeam summarises the search documents of the manager's direct
+// reports.
+func SummariseTeam(manager string, reports []SearchDocument, now time.Time) TeamSummary {
+	s := TeamSummary{
+		Manager: strings.ToLower(manager),
+		Domain:  GetDomain(manager),
+		Members: []string{},
+		Skills:  []DepartmentSkill{},
+		Updated: now.UTC().Truncate(time.Millisecond),
+	}
+	index := make(map[string]int)
+	total := make(map[string]int)
+	for _, d := range reports {
+		s.Members = append(s.Members, d.EmailAddress)
+		if d.Availability == Green {
+			s.Available++
+		}
+		for _, sk := range d.Skills {
+			i, ok := index[sk.Skill]
+			if !ok {
+				i = len(s.Skills)
+				index[sk.Skill] = i
+				s.Skills = append(s.Skills, DepartmentSkill{Skill: sk.Skill})
+			}
+			s.Skills[i].People++
+			total[sk.Skill] += int(sk.Level)
+		}
+	}
+	for i, sk := range s.Skills {
+		s.Skills[i].AverageLevel = float64(total[sk.Skill]) / float64(sk.People)
+	}
+	sort.Strings(s.Members)
+	sort.SliceStable(s.Skills, func(i, j int) bool {
+		if s.Skills[i].People != s.Skills[j].People {
+			return s.Skills[i].People > s.Skills[j].People
+		}
+		return s.Skills[i].Skill < s.Skills[j].Skill
+	})
+	return s
+}
+
+// searchDocumentIndexes are the fields search documents are looked up by.
+var searchDocumentIndexes = []mgo.Index{
+	{Key: []string{"domain", "skills.skill", "skills.level"}, Background: true},
+	{Key: []string{"domain", "terms"}, Background: true},
+	{Key: []string{"domain", "manager"}, Background: true},
+}
+
+// SaveSearchDocument creates or replaces the search document.
+func (da MongoDataAccess) SaveSearchDocument(d *SearchDocument) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	utc := *d
+	utc.Updated = utc.Updated.UTC()
+	_, err = session.DB(da.databaseName).C("searchdocuments").UpsertId(utc.EmailAddress, utc)
+	return err
+}
+
+// GetSearchDocument gets the search document of the person.
+func (da MongoDataAccess) GetSearchDocument(emailAddress string) (*SearchDocument, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	var d SearchDocument
+	err = session.DB(da.databaseName).C("searchdocuments").FindId(strings.ToLower(emailAddress)).One(&d)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	d.Updated = d.Updated.UTC()
+	return &d, true, nil
+}
+
+// RemoveSearchDocument removes the person's search document, if they have
+// one.
+func (da MongoDataAccess) RemoveSearchDocument(emailAddress string) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(da.databaseName).C("searchdocuments").RemoveId(strings.ToLower(emailAddress))
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// SearchDocuments finds the search documents in the domain which match the
+// query, in order of email address.
+func (da MongoDataAccess) SearchDocuments(domain string, q SearchQuery) ([]SearchDocument, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	documents := []SearchDocument{}
+	err = session.DB(da.databaseName).C("searchdocuments").Find(q.query(domain)).Sort("_id").All(&documents)
+	if err != nil {
+		return nil, err
+	}
+	for i := range documents {
+		documents[i].Updated = documents[i].Updated.UTC()
+	}
+	return documents, nil
+}
+
+// SaveTeamSummary creates or replaces the summary of the manager's team, or
+// removes it if the team has no members.
+func (da MongoDataAccess) SaveTeamSummary(s *TeamSummary) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	c := session.DB(da.databaseName).C("teamsummaries")
+	if len(s.Members) == 0 {
+		err = c.RemoveId(s.Manager)
+		if err == mgo.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	utc := *s
+	utc.Updated = utc.Updated.UTC()
+	_, err = c.UpsertId(utc.Manager, utc)
+	return err
+}
+
+// GetTeamSummary gets the summary of the manager's team.
+func (da MongoDataAccess) GetTeamSummary(manager string) (*TeamSummary, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	var s TeamSummary
+	err = session.DB(da.databaseName).C("teamsummaries").FindId(strings.ToLower(manager)).One(&s)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	s.Updated = s.Updated.UTC()
+	return &s, true, nil
+}
@@ -0,0 +1,50 @@
+package dataaccess
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestThatSearchDocumentsMatchTheWordsInNamesSkillsAndDepartments(t *testing.T) {
+	p := Profile{
+		EmailAddress: "A@github.com",
+		Name:         "Ada Lovelace",
+		Manager:      "Boss@github.com",
+		Department:   "Platform",
+		Skills:       []Skill{{Skill: "c#", Level: 3}, {Skill: "node.js", Level: 2}},
+		Languages:    []Language{{Code: "en", Level: CEFRC2}},
+	}
+
+	d := NewSearchDocument(p, time.Date(2017, time.August, 14, 0, 0, 0, 0, time.UTC))
+
+	if d.EmailAddress != "a@github.com" || d.Domain != "github.com" || d.Manager != "boss@github.com" || d.Level("c#") != 3 {
+		t.Errorf("Unexpected search document %+v", d)
+	}
+	if expected := []string{"ada", "c#", "lovelace", "node.js", "platform"}; !reflect.DeepEqual(d.Terms, expected) {
+		t.Errorf("Expected terms %v, but received %v", expected, d.Terms)
+	}
+	q := SearchQuery{Skill: "Go", Level: 3, Text: "Lovelace  ada"}.query("GitHub.com")
+	if q["domain"] != "github.com" || !reflect.DeepEqual(q["terms"], bson.M{"$all": []string{"ada", "lovelace"}}) {
+		t.Errorf("Unexpected query %v", q)
+	}
+}
+
+func TestThatTeamsAreSummarisedFromTheirSearchDocuments(t *testing.T) {
+	reports := []SearchDocument{
+		{EmailAddress: "b@github.com", Availability: Green, Skills: []SearchSkill{{Skill: "go", Level: 4}, {Skill: "sql", Level: 1}}},
+		{EmailAddress: "a@github.com", Availability: Red, Skills: []SearchSkill{{Skill: "go", Level: 2}}},
+	}
+
+	s := SummariseTeam("Boss@github.com", reports, time.Now())
+
+	if s.Manager != "boss@github.com" || !reflect.DeepEqual(s.Members, []string{"a@github.com", "b@github.com"}) || s.Available != 1 {
+		t.Errorf("Unexpected summary %+v", s)
+	}
+	expected := []DepartmentSkill{{Skill: "go", People: 2, AverageLevel: 3}, {Skill: "sql", People: 1, AverageLevel: 1}}
+	if !reflect.DeepEqual(s.Skills, expected) {
+		t.Errorf("Expected skills %v, but received %v", expected, s.Skills)
+	}
+}
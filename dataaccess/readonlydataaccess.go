@@ -0,0 +1,586 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrReadOnly is returned when a change is made while the service is read
+// only, e.g. during database maintenance.
+var ErrReadOnly = errors.New("dataaccess: pill is read only during maintenance, changes can't be saved at the moment")
+
+// ReadOnlyFeatureFlag is the feature flag administrators switch on to make
+// the service read only.
+const ReadOnlyFeatureFlag = "readOnly"
+
+// ReadOnlyDataAccess rejects changes with ErrReadOnly while readOnly returns
+// true, and passes reads through. Feature flags can still be set, so that
+// administrators can switch read only mode off again, and locks can still be
+// taken so that scheduled jobs don't run twice when it ends.
+type ReadOnlyDataAccess struct {
+	DataAccess
+	readOnly func() bool
+}
+
+// NewReadOnlyDataAccess creates a DataAccess which rejects changes while
+// readOnly returns true.
+func NewReadOnlyDataAccess(da DataAccess, readOnly func() bool) *ReadOnlyDataAccess {
+	return &ReadOnlyDataAccess{da, readOnly}
+}
+
+// WithContext passes the context to the wrapped DataAccess.
+func (da ReadOnlyDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &ReadOnlyDataAccess{WithContext(da.DataAccess, ctx), da.readOnly}
+}
+
+func (da ReadOnlyDataAccess) check() error {
+	if da.readOnly() {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// UpdateNotificationPreferences is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateNotificationPreferences(emailAddress string, p NotificationPreferences) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.UpdateNotificationPreferences(emailAddress, p)
+}
+
+// UpdateAvailabilityWindows is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.UpdateAvailabilityWindows(emailAddress, windows)
+}
+
+// AwardBadges is rejected while read only.
+func (da ReadOnlyDataAccess) AwardBadges(emailAddress string, badges []Badge) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.AwardBadges(emailAddress, badges)
+}
+
+// AddBooking is rejected while read only.
+func (da ReadOnlyDataAccess) AddBooking(emailAddress string, b Booking) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.AddBooking(emailAddress, b)
+}
+
+// RemoveBooking is rejected while read only.
+func (da ReadOnlyDataAccess) RemoveBooking(emailAddress string, id string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.RemoveBooking(emailAddress, id)
+}
+
+// UpdateProfile is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	if err := da.check(); err != nil {
+		return nil, err
+	}
+	return da.DataAccess.UpdateProfile(update)
+}
+
+// ImportProfile is rejected while read only.
+func (da ReadOnlyDataAccess) ImportProfile(p *Profile) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.ImportProfile(p)
+}
+
+// DeleteProfile is rejected while read only.
+func (da ReadOnlyDataAccess) DeleteProfile(emailAddress string) (bool, error) {
+	if err := da.check(); err != nil {
+		return false, err
+	}
+	return da.DataAccess.DeleteProfile(emailAddress)
+}
+
+// AddSkillTags is rejected while read only.
+func (da ReadOnlyDataAccess) AddSkillTags(tags []string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.AddSkillTags(tags)
+}
+
+// SetSkillTagDescriptors is rejected while read only.
+func (da ReadOnlyDataAccess) SetSkillTagDescriptors(name string, descriptors []LevelDescriptor) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SetSkillTagDescriptors(name, descriptors)
+}
+
+// DeleteSkillTags is rejected while read only.
+func (da ReadOnlyDataAccess) DeleteSkillTags(tags []string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.DeleteSkillTags(tags)
+}
+
+// RestoreSkillTags is rejected while read only.
+func (da ReadOnlyDataAccess) RestoreSkillTags(tags []string) ([]string, error) {
+	if err := da.check(); err != nil {
+		return nil, err
+	}
+	return da.DataAccess.RestoreSkillTags(tags)
+}
+
+// PurgeDeletedSkillTags is rejected while read only.
+func (da ReadOnlyDataAccess) PurgeDeletedSkillTags(before time.Time) (int, error) {
+	if err := da.check(); err != nil {
+		return 0, err
+	}
+	return da.DataAccess.PurgeDeletedSkillTags(before)
+}
+
+// DeleteConfiguration is rejected while read only.
+func (da ReadOnlyDataAccess) DeleteConfiguration() error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.DeleteConfiguration()
+}
+
+// SetFeatureFlag is rejected while read only.
+func (da ReadOnlyDataAccess) SetFeatureFlag(name string, enabled bool) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SetFeatureFlag(name, enabled)
+}
+
+// RotateSessionEncryptionKey is rejected while read only.
+func (da ReadOnlyDataAccess) RotateSessionEncryptionKey() (Configuration, error) {
+	if err := da.check(); err != nil {
+		return Configuration{}, err
+	}
+	return da.DataAccess.RotateSessionEncryptionKey()
+}
+
+// UpdateTenantConfiguration is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateTenantConfiguration(tc *TenantConfiguration) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.UpdateTenantConfiguration(tc)
+}
+
+// DeleteTenantConfiguration is rejected while read only.
+func (da ReadOnlyDataAccess) DeleteTenantConfiguration(domain string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.DeleteTenantConfiguration(domain)
+}
+
+// QuarantineChange is rejected while read only.
+func (da ReadOnlyDataAccess) QuarantineChange(c *QuarantinedChange) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.QuarantineChange(c)
+}
+
+// DeleteQuarantinedChange is rejected while read only.
+func (da ReadOnlyDataAccess) DeleteQuarantinedChange(id string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.DeleteQuarantinedChange(id)
+}
+
+// RequestApproval is rejected while read only.
+func (da ReadOnlyDataAccess) RequestApproval(a *ApprovalRequest) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.RequestApproval(a)
+}
+
+// DeleteApprovalRequest is rejected while read only.
+func (da ReadOnlyDataAccess) DeleteApprovalRequest(id string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.DeleteApprovalRequest(id)
+}
+
+// SaveCalibration is rejected while read only.
+func (da ReadOnlyDataAccess) SaveCalibration(c *Calibration) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveCalibration(c)
+}
+
+// SaveProject is rejected while read only.
+func (da ReadOnlyDataAccess) SaveProject(p *Project) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveProject(p)
+}
+
+// DeleteProject is rejected while read only.
+func (da ReadOnlyDataAccess) DeleteProject(id string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.DeleteProject(id)
+}
+
+// SaveCourse is rejected while read only.
+func (da ReadOnlyDataAccess) SaveCourse(c *Course) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveCourse(c)
+}
+
+// DeleteCourse is rejected while read only.
+func (da ReadOnlyDataAccess) DeleteCourse(id string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.DeleteCourse(id)
+}
+
+// SaveCourseCompletion is rejected while read only.
+func (da ReadOnlyDataAccess) SaveCourseCompletion(cc *CourseCompletion) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveCourseCompletion(cc)
+}
+
+// UpdateLearningGoals is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateLearningGoals(emailAddress string, goals []LearningGoal) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.UpdateLearningGoals(emailAddress, goals)
+}
+
+// ConfirmSkills is rejected while read only.
+func (da ReadOnlyDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.ConfirmSkills(emailAddress, skills)
+}
+
+// ImportSkillTags is rejected while read only.
+func (da ReadOnlyDataAccess) ImportSkillTags(tags []SkillTag) (int, error) {
+	if err := da.check(); err != nil {
+		return 0, err
+	}
+	return da.DataAccess.ImportSkillTags(tags)
+}
+
+// SaveTagProposal is rejected while read only.
+func (da ReadOnlyDataAccess) SaveTagProposal(p *TagProposal) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveTagProposal(p)
+}
+
+// MergeSkillTags is rejected while read only.
+func (da ReadOnlyDataAccess) MergeSkillTags(into string, tags []string) (int, error) {
+	if err := da.check(); err != nil {
+		return 0, err
+	}
+	return da.DataAccess.MergeSkillTags(into, tags)
+}
+
+// UpdateCV is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateCV(emailAddress string, cv *Attachment) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.UpdateCV(emailAddress, cv)
+}
+
+// SaveSkillSuggestions is rejected while read only.
+func (da ReadOnlyDataAccess) SaveSkillSuggestions(ss *SkillSuggestions) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveSkillSuggestions(ss)
+}
+
+// SaveShareLink is rejected while read only.
+func (da ReadOnlyDataAccess) SaveShareLink(l *ShareLink) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveShareLink(l)
+}
+
+// DeleteShareLink is rejected while read only.
+func (da ReadOnlyDataAccess) DeleteShareLink(id string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.DeleteShareLink(id)
+}
+
+// SyncEmployee is rejected while read only.
+func (da ReadOnlyDataAccess) SyncEmployee(e EmployeeUpdate) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SyncEmployee(e)
+}
+
+// ArchiveProfile is rejected while read only.
+func (da ReadOnlyDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (bool, error) {
+	if err := da.check(); err != nil {
+		return false, err
+	}
+	return da.DataAccess.ArchiveProfile(emailAddress, reason, purgeAfter)
+}
+
+// RestoreProfile is rejected while read only.
+func (da ReadOnlyDataAccess) RestoreProfile(emailAddress string) (bool, error) {
+	if err := da.check(); err != nil {
+		return false, err
+	}
+	return da.DataAccess.RestoreProfile(emailAddress)
+}
+
+// PurgeArchivedProfiles is rejected while read only.
+func (da ReadOnlyDataAccess) PurgeArchivedProfiles(before time.Time) (int, error) {
+	if err := da.check(); err != nil {
+		return 0, err
+	}
+	return da.DataAccess.PurgeArchivedProfiles(before)
+}
+
+// RevokeSessions is rejected while read only.
+func (da ReadOnlyDataAccess) RevokeSessions(emailAddress string, at time.Time) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.RevokeSessions(emailAddress, at)
+}
+
+// EnsureIndexes is rejected while read only.
+func (da ReadOnlyDataAccess) EnsureIndexes() error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.EnsureIndexes()
+}
+
+// UpdateCustomFields is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.UpdateCustomFields(emailAddress, values)
+}
+
+// UpdateLanguages is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateLanguages(emailAddress string, languages []Language) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.UpdateLanguages(emailAddress, languages)
+}
+
+// UpdateClearance is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateClearance(emailAddress string, cl *Clearance) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.UpdateClearance(emailAddress, cl)
+}
+
+// UpdateWorkLocation is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateWorkLocation(emailAddress string, l *WorkLocation) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.UpdateWorkLocation(emailAddress, l)
+}
+
+// UpdateWorkingHours is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateWorkingHours(emailAddress string, h *WorkingHours) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.UpdateWorkingHours(emailAddress, h)
+}
+
+// RecordConsents is rejected while read only.
+func (da ReadOnlyDataAccess) RecordConsents(emailAddress string, consents []Consent) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.RecordConsents(emailAddress, consents)
+}
+
+// SaveBenchmarks is rejected while read only.
+func (da ReadOnlyDataAccess) SaveBenchmarks(benchmarks []Benchmark) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveBenchmarks(benchmarks)
+}
+
+// SaveReportDefinition is rejected while read only.
+func (da ReadOnlyDataAccess) SaveReportDefinition(d *ReportDefinition) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveReportDefinition(d)
+}
+
+// DeleteReportDefinition is rejected while read only.
+func (da ReadOnlyDataAccess) DeleteReportDefinition(domain string, id string) (bool, error) {
+	if err := da.check(); err != nil {
+		return false, err
+	}
+	return da.DataAccess.DeleteReportDefinition(domain, id)
+}
+
+// SaveReportSnapshot is rejected while read only.
+func (da ReadOnlyDataAccess) SaveReportSnapshot(s *ReportSnapshot) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveReportSnapshot(s)
+}
+
+// SaveExportJob is rejected while read only.
+func (da ReadOnlyDataAccess) SaveExportJob(j *ExportJob) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveExportJob(j)
+}
+
+// ClaimExportJob is rejected while read only.
+func (da ReadOnlyDataAccess) ClaimExportJob(now time.Time) (*ExportJob, bool, error) {
+	if err := da.check(); err != nil {
+		return nil, false, err
+	}
+	return da.DataAccess.ClaimExportJob(now)
+}
+
+// PurgeExportJobs is rejected while read only.
+func (da ReadOnlyDataAccess) PurgeExportJobs(before time.Time) ([]ExportJob, error) {
+	if err := da.check(); err != nil {
+		return nil, err
+	}
+	return da.DataAccess.PurgeExportJobs(before)
+}
+
+// SaveImportPreview is rejected while read only.
+func (da ReadOnlyDataAccess) SaveImportPreview(p *ImportPreview) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveImportPreview(p)
+}
+
+// TakeImportPreview is rejected while read only.
+func (da ReadOnlyDataAccess) TakeImportPreview(domain string, id string) (*ImportPreview, bool, error) {
+	if err := da.check(); err != nil {
+		return nil, false, err
+	}
+	return da.DataAccess.TakeImportPreview(domain, id)
+}
+
+// MergeProfiles is rejected while read only.
+func (da ReadOnlyDataAccess) MergeProfiles(primary string, duplicate string) (*Profile, bool, error) {
+	if err := da.check(); err != nil {
+		return nil, false, err
+	}
+	return da.DataAccess.MergeProfiles(primary, duplicate)
+}
+
+// UpdateExternalIDs is rejected while read only.
+func (da ReadOnlyDataAccess) UpdateExternalIDs(emailAddress string, ids map[string]string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.UpdateExternalIDs(emailAddress, ids)
+}
+
+// AppendProfileEvents is rejected while read only.
+func (da ReadOnlyDataAccess) AppendProfileEvents(events []ProfileEvent) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.AppendProfileEvents(events)
+}
+
+// SaveProfileSnapshot is rejected while read only.
+func (da ReadOnlyDataAccess) SaveProfileSnapshot(s *ProfileSnapshot) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveProfileSnapshot(s)
+}
+
+// ProjectProfile is rejected while read only.
+func (da ReadOnlyDataAccess) ProjectProfile(s *ProfileSnapshot) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.ProjectProfile(s)
+}
+
+// SaveSearchDocument is rejected while read only.
+func (da ReadOnlyDataAccess) SaveSearchDocument(d *SearchDocument) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveSearchDocument(d)
+}
+
+// RemoveSearchDocument is rejected while read only.
+func (da ReadOnlyDataAccess) RemoveSearchDocument(emailAddress string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.RemoveSearchDocument(emailAddress)
+}
+
+// SaveTeamSummary is rejected while read only.
+func (da ReadOnlyDataAccess) SaveTeamSummary(s *TeamSummary) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveTeamSummary(s)
+}
+
+// SaveProfileSummary is rejected while read only.
+func (da ReadOnlyDataAccess) SaveProfileSummary(s *ProfileSummary) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.SaveProfileSummary(s)
+}
+
+// RemoveProfileSummary is rejected while read only.
+func (da ReadOnlyDataAccess) RemoveProfileSummary(emailAddress string) error {
+	if err := da.check(); err != nil {
+		return err
+	}
+	return da.DataAccess.RemoveProfileSummary(emailAddress)
+}
@@ -0,0 +1,23 @@
+package dataaccess
+
+import "testing"
+
+func TestThatChangesAreRejectedWhileReadOnly(t *testing.T) {
+	readOnly := true
+	da := NewReadOnlyDataAccess(stubDataAccess{}, func() bool { return readOnly })
+
+	if _, err := da.UpdateProfile(&ProfileUpdate{EmailAddress: "a@github.com"}); err != ErrReadOnly {
+		t.Errorf("Expected changes to fail with ErrReadOnly, but got %v.", err)
+	}
+	if _, err := da.DeleteProfile("a@github.com"); err != ErrReadOnly {
+		t.Errorf("Expected deletes to fail with ErrReadOnly, but got %v.", err)
+	}
+	if err := da.SetFeatureFlag("newSearch", true); err != ErrReadOnly {
+		t.Errorf("Expected feature flags not to be changed, but got %v.", err)
+	}
+
+	readOnly = false
+	if _, err := da.UpdateProfile(&ProfileUpdate{EmailAddress: "a@github.com"}); err != nil {
+		t.Errorf("Expected changes to be saved once read only mode ends, but got %v.", err)
+	}
+}
@@ -0,0 +1,229 @@
+package dataaccess
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+)
+
+// RedactingDataAccess wraps a DataAccess and removes the security clearances
+// from the profiles it returns, unless the caller can see them under their
+// tenant's clearance settings, and the custom fields the caller can't see
+// under the tenant's custom field schema. Each clearance which is seen is
+// recorded in the audit log.
+type RedactingDataAccess struct {
+	DataAccess
+	log audit.Log
+	ctx context.Context
+}
+
+// NewRedactingDataAccess creates a DataAccess which redacts profiles.
+// Profiles read without a caller, e.g. by scheduled jobs, have their
+// clearances removed until WithContext is used to provide the caller.
+func NewRedactingDataAccess(da DataAccess, l audit.Log) DataAccess {
+	return &RedactingDataAccess{da, l, context.Background()}
+}
+
+// WithContext redacts profiles for the context's caller.
+func (da RedactingDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &RedactingDataAccess{WithContext(da.DataAccess, ctx), da.log, ctx}
+}
+
+// redact removes the clearances and custom fields the caller can't see. If
+// there is no caller, the clearances and the custom fields which aren't
+// visible to everyone are removed. If a tenant's settings can't be read, its
+// clearances and custom fields are removed.
+func (da RedactingDataAccess) redact(profiles ...*Profile) {
+	c, hasCaller := caller.FromContext(da.ctx)
+
+	settings := make(map[string]*Settings)
+	settingsOf := func(domain string) *Settings {
+		s, ok := settings[domain]
+		if !ok {
+			if all, err := da.DataAccess.GetSettings(domain); err != nil {
+				log.Printf("Failed to get the settings of %s, so clearances and custom fields have been redacted. %v", domain, err)
+			} else {
+				s = &all
+			}
+			settings[domain] = s
+		}
+		return s
+	}
+	// The managers of the people being redacted are known, so only the
+	// managers above them need to be read.
+	managers := make(map[string]string)
+	for _, p := range profiles {
+		if p != nil {
+			managers[strings.ToLower(p.EmailAddress)] = strings.ToLower(p.Manager)
+		}
+	}
+	manages := func(emailAddress string) bool {
+		return da.manages(c.EmailAddress, emailAddress, managers)
+	}
+
+	for _, p := range profiles {
+		if p == nil {
+			continue
+		}
+		domain := GetDomain(p.EmailAddress)
+		if len(p.CustomFields) > 0 {
+			if s := settingsOf(domain); s == nil {
+				p.CustomFields = nil
+			} else {
+				access := VisibleToEveryone
+				if hasCaller {
+					access = customFieldAccess(c, p.EmailAddress, manages)
+				}
+				p.CustomFields = s.CustomFields.Visible(p.CustomFields, access)
+			}
+		}
+
+		if p.Clearance == nil {
+			continue
+		}
+		if !hasCaller {
+			p.Clearance = nil
+			continue
+		}
+		if s := settingsOf(domain); s == nil || !s.Clearance.CanView(c, p.EmailAddress) {
+			p.Clearance = nil
+			continue
+		}
+
+		e := audit.NewEntry(da.ctx, audit.ClearanceViewed, domain, p.EmailAddress)
+		if err := da.log.Record(e); err != nil {
+			log.Printf("Failed to record %s of %s by %s in the audit log. %v", e.Action, e.Target, e.Actor, err)
+		}
+	}
+}
+
+// customFieldAccess returns the custom fields the caller can see on the
+// person's profile. People see all of their own fields, as do
+// administrators, and managers see the fields for managers of the people in
+// their part of the organisation.
+func customFieldAccess(c caller.Caller, emailAddress string, manages func(emailAddress string) bool) CustomFieldVisibility {
+	if strings.EqualFold(c.EmailAddress, emailAddress) || c.HasRole(AdministratorRole) {
+		return VisibleToAdministrators
+	}
+	if strings.EqualFold(GetDomain(c.EmailAddress), GetDomain(emailAddress)) && manages(emailAddress) {
+		return VisibleToManagers
+	}
+	return VisibleToEveryone
+}
+
+// manages returns true if the person is in the manager's part of the
+// organisation, by following the person's managers up the organisation.
+// Managers which are read are added to the known managers. If a profile
+// can't be read, false is returned, so the manager only sees the fields for
+// everyone.
+func (da RedactingDataAccess) manages(manager string, emailAddress string, managers map[string]string) bool {
+	manager = strings.ToLower(manager)
+	seen := make(map[string]bool)
+	for e := strings.ToLower(emailAddress); e != "" && !seen[e]; {
+		seen[e] = true
+		m, ok := managers[e]
+		if !ok {
+			p, found, err := da.DataAccess.GetProfile(e)
+			if err != nil {
+				log.Printf("Failed to get the manager of %s, so custom fields for managers have been redacted. %v", e, err)
+				return false
+			}
+			if found {
+				m = strings.ToLower(p.Manager)
+			}
+			managers[e] = m
+		}
+		if m == manager {
+			return true
+		}
+		e = m
+	}
+	return false
+}
+
+func (da RedactingDataAccess) redactAll(profiles []Profile) {
+	ps := make([]*Profile, len(profiles))
+	for i := range profiles {
+		ps[i] = &profiles[i]
+	}
+	da.redact(ps...)
+}
+
+// GetProfile returns the redacted profile.
+func (da RedactingDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	p, found, err := da.DataAccess.GetProfile(emailAddress)
+	if err == nil && found {
+		da.redact(p)
+	}
+	return p, found, err
+}
+
+// UpdateProfile updates the profile and returns it redacted.
+func (da RedactingDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	p, err := da.DataAccess.UpdateProfile(update)
+	if err == nil {
+		da.redact(p)
+	}
+	return p, err
+}
+
+// ListProfiles returns the redacted profiles.
+func (da RedactingDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
+	profiles, err := da.DataAccess.ListProfiles(emailAddress)
+	if err == nil {
+		da.redactAll(profiles)
+	}
+	return profiles, err
+}
+
+// FindProfiles returns the redacted profiles.
+func (da RedactingDataAccess) FindProfiles(domain string, f ProfileFilter) ([]Profile, error) {
+	profiles, err := da.DataAccess.FindProfiles(domain, f)
+	if err == nil {
+		da.redactAll(profiles)
+	}
+	return profiles, err
+}
+
+// FindProfilesNear returns the redacted profiles.
+func (da RedactingDataAccess) FindProfilesNear(domain string, near Coordinates, radiusKm float64) ([]Profile, error) {
+	profiles, err := da.DataAccess.FindProfilesNear(domain, near, radiusKm)
+	if err == nil {
+		da.redactAll(profiles)
+	}
+	return profiles, err
+}
+
+// ListArchivedProfiles returns the redacted archived profiles.
+func (da RedactingDataAccess) ListArchivedProfiles(domain string) ([]ArchivedProfile, error) {
+	archived, err := da.DataAccess.ListArchivedProfiles(domain)
+	if err == nil {
+		ps := make([]*Profile, len(archived))
+		for i := range archived {
+			ps[i] = &archived[i].Profile
+		}
+		da.redact(ps...)
+	}
+	return archived, err
+}
+
+// FindProfileByExternalID finds the profile and returns it redacted.
+func (da RedactingDataAccess) FindProfileByExternalID(domain string, system string, id string) (*Profile, bool, error) {
+	p, found, err := da.DataAccess.FindProfileByExternalID(domain, system, id)
+	if err == nil && found {
+		da.redact(p)
+	}
+	return p, found, err
+}
+
+// MergeProfiles merges the profiles and returns the merged profile redacted.
+func (da RedactingDataAccess) MergeProfiles(primary string, duplicate string) (*Profile, bool, error) {
+	p, found, err := da.DataAccess.MergeProfiles(primary, duplicate)
+	if err == nil && found {
+		da.redact(p)
+	}
+	return p, found, err
+}
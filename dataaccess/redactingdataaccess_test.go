@@ -0,0 +1,179 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+)
+
+type clearedDataAccess struct {
+	DataAccess
+}
+
+func (da clearedDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
+	return []Profile{
+		{EmailAddress: "dev@github.com", Clearance: &Clearance{Level: "SC"}},
+		{EmailAddress: "other@github.com"},
+	}, nil
+}
+
+func (da clearedDataAccess) MergeProfiles(primary string, duplicate string) (*Profile, bool, error) {
+	return &Profile{EmailAddress: primary, Clearance: &Clearance{Level: "SC"}}, true, nil
+}
+
+func (da clearedDataAccess) GetSettings(domain string) (Settings, error) {
+	return DefaultSettings(), nil
+}
+
+func TestThatClearancesAreRedactedUnlessTheCallerCanSeeThem(t *testing.T) {
+	tests := []struct {
+		c        caller.Caller
+		expected bool
+	}{
+		{caller.Caller{EmailAddress: "dev@github.com", Roles: []string{UserRole}}, true},
+		{caller.Caller{EmailAddress: "other@github.com", Roles: []string{UserRole}}, false},
+		{caller.Caller{EmailAddress: "admin@github.com", Roles: []string{UserRole, AdministratorRole}}, true},
+		{caller.Caller{EmailAddress: "officer@github.com", Roles: []string{UserRole, SecurityOfficerRole}}, true},
+	}
+
+	for _, test := range tests {
+		l := audit.NewMemoryLog()
+		da := WithContext(NewRedactingDataAccess(clearedDataAccess{}, l), caller.NewContext(context.Background(), test.c))
+
+		profiles, _ := da.ListProfiles("@github.com")
+		if seen := profiles[0].Clearance != nil; seen != test.expected {
+			t.Errorf("For %s, expected the clearance to be seen %v, but was %v", test.c.EmailAddress, test.expected, seen)
+		}
+
+		entries, _ := l.List(audit.Query{})
+		if test.expected && (len(entries) != 1 || entries[0].Action != audit.ClearanceViewed || entries[0].Actor != test.c.EmailAddress || entries[0].Target != "dev@github.com") {
+			t.Errorf("For %s, expected the access to be recorded, but received %v", test.c.EmailAddress, entries)
+		}
+		if !test.expected && len(entries) != 0 {
+			t.Errorf("For %s, expected nothing to be recorded, but received %v", test.c.EmailAddress, entries)
+		}
+	}
+}
+
+func TestThatClearancesAreRedactedWithoutACaller(t *testing.T) {
+	da := NewRedactingDataAccess(clearedDataAccess{}, audit.NewMemoryLog())
+
+	profiles, _ := da.ListProfiles("@github.com")
+	if profiles[0].Clearance != nil {
+		t.Error("Expected the clearance to be redacted when nobody is known to be reading it.")
+	}
+}
+
+func TestThatMergedProfilesAreRedacted(t *testing.T) {
+	l := audit.NewMemoryLog()
+	c := caller.Caller{EmailAddress: "other@github.com", Roles: []string{UserRole}}
+	da := WithContext(NewRedactingDataAccess(clearedDataAccess{}, l), caller.NewContext(context.Background(), c))
+
+	p, _, _ := da.MergeProfiles("dev@github.com", "dev@example.com")
+	if p.Clearance != nil {
+		t.Error("Expected the merged clearance to be redacted.")
+	}
+}
+
+type fieldedDataAccess struct {
+	DataAccess
+}
+
+func (da fieldedDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
+	values := func() map[string]CustomFieldValue {
+		return map[string]CustomFieldValue{
+			"nickname": {Type: TextField, Text: "dev"},
+			"band":     {Type: TextField, Text: "B"},
+			"salary":   {Type: TextField, Text: "100"},
+			"removed":  {Type: TextField, Text: "old"},
+		}
+	}
+	return []Profile{
+		{EmailAddress: "boss@github.com"},
+		{EmailAddress: "dev@github.com", Manager: "boss@github.com", CustomFields: values()},
+	}, nil
+}
+
+func (da fieldedDataAccess) GetSettings(domain string) (Settings, error) {
+	s := DefaultSettings()
+	s.CustomFields = CustomFieldSchema{
+		{Name: "nickname", Type: TextField, Visibility: VisibleToEveryone},
+		{Name: "band", Type: TextField, Visibility: VisibleToManagers},
+		{Name: "salary", Type: TextField, Visibility: VisibleToAdministrators},
+	}
+	return s, nil
+}
+
+func TestThatCustomFieldsAreRedactedUnlessTheCallerCanSeeThem(t *testing.T) {
+	tests := []struct {
+		c        *caller.Caller
+		expected []string
+	}{
+		{&caller.Caller{EmailAddress: "dev@github.com", Roles: []string{UserRole}}, []string{"band", "nickname", "salary"}},
+		{&caller.Caller{EmailAddress: "boss@github.com", Roles: []string{UserRole}}, []string{"band", "nickname"}},
+		{&caller.Caller{EmailAddress: "other@github.com", Roles: []string{UserRole}}, []string{"nickname"}},
+		{&caller.Caller{EmailAddress: "admin@github.com", Roles: []string{UserRole, AdministratorRole}}, []string{"band", "nickname", "salary"}},
+		{nil, []string{"nickname"}},
+	}
+
+	for _, test := range tests {
+		da := NewRedactingDataAccess(fieldedDataAccess{}, audit.NewMemoryLog())
+		name := "nobody"
+		if test.c != nil {
+			da, name = WithContext(da, caller.NewContext(context.Background(), *test.c)), test.c.EmailAddress
+		}
+
+		profiles, _ := da.ListProfiles("@github.com")
+		var actual []string
+		for field := range profiles[1].CustomFields {
+			actual = append(actual, field)
+		}
+		sort.Strings(actual)
+		if strings.Join(actual, ",") != strings.Join(test.expected, ",") {
+			t.Errorf("For %s, expected to see %v, but saw %v", name, test.expected, actual)
+		}
+	}
+}
+
+// reportingDataAccess holds a dev who reports to the boss through a lead,
+// and can't list profiles, so that managers are found without listing them.
+type reportingDataAccess struct {
+	fieldedDataAccess
+}
+
+func (da reportingDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
+	return nil, errors.New("profiles can't be listed")
+}
+
+func (da reportingDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	switch emailAddress {
+	case "dev@github.com":
+		return &Profile{EmailAddress: emailAddress, Manager: "Lead@github.com", CustomFields: map[string]CustomFieldValue{"band": {Type: TextField, Text: "B"}}}, true, nil
+	case "lead@github.com":
+		return &Profile{EmailAddress: emailAddress, Manager: "boss@github.com"}, true, nil
+	}
+	return &Profile{}, false, nil
+}
+
+func TestThatIndirectReportsCustomFieldsAreVisibleToTheirManager(t *testing.T) {
+	for _, manager := range []string{"lead@github.com", "boss@github.com"} {
+		c := caller.Caller{EmailAddress: manager, Roles: []string{UserRole}}
+		da := WithContext(NewRedactingDataAccess(reportingDataAccess{}, audit.NewMemoryLog()), caller.NewContext(context.Background(), c))
+		p, _, _ := da.GetProfile("dev@github.com")
+		if _, ok := p.CustomFields["band"]; !ok {
+			t.Errorf("Expected %s to see the band of dev, but saw %v", manager, p.CustomFields)
+		}
+	}
+
+	c := caller.Caller{EmailAddress: "other@github.com", Roles: []string{UserRole}}
+	da := WithContext(NewRedactingDataAccess(reportingDataAccess{}, audit.NewMemoryLog()), caller.NewContext(context.Background(), c))
+	p, _, _ := da.GetProfile("dev@github.com")
+	if _, ok := p.CustomFields["band"]; ok {
+		t.Error("Expected other people not to see the band of dev.")
+	}
+}
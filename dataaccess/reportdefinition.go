@@ -0,0 +1,389 @@
+package dataaccess
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MaxReportRows is the most rows a custom report returns.
+const MaxReportRows = 1000
+
+// A ReportDefinition is a custom report saved by a tenant's administrators.
+// It counts, or summarises the levels of, the people in the domain, grouped
+// by its dimensions, e.g. the number of people with each skill in each
+// department.
+type ReportDefinition struct {
+	ID     string `bson:"_id" json:"id"`
+	Domain string `json:"domain"`
+	Name   string `json:"name"`
+	// Dimensions are the fields the rows are grouped by: department,
+	// costCenter, manager, city, country, availability, skill, level or
+	// interest. If there are none, the report has a single row.
+	Dimensions []string `json:"dimensions"`
+	// Measures are what is reported for each row: people, averageLevel,
+	// minLevel or maxLevel.
+	Measures []string `json:"measures"`
+	// Filters limit the people and skills which are reported on.
+	Filters []ReportFilter `json:"filters,omitempty"`
+	// Delivery emails the report to people on a schedule, if it's set.
+	Delivery *ReportDelivery `json:"delivery,omitempty"`
+	// CreatedBy is the email address of the administrator who saved the
+	// report.
+	CreatedBy string    `json:"createdBy"`
+	Updated   time.Time `json:"updated"`
+}
+
+// A ReportFilter limits a custom report to the people or skills whose field
+// matches the values.
+type ReportFilter struct {
+	Field string `json:"field"`
+	// Operator is eq, ne, in, gte or lte. in matches any of the values; the
+	// others use the first.
+	Operator string   `json:"operator"`
+	Values   []string `json:"values"`
+}
+
+// A ReportDelivery emails a custom report to people in the domain.
+type ReportDelivery struct {
+	// Frequency is daily, or weekly, which sends it on Mondays.
+	Frequency NotificationFrequency `json:"frequency"`
+	// Recipients are the email addresses of the people the report is sent
+	// to, who must be in the report's domain.
+	Recipients []string `json:"recipients"`
+}
+
+// A reportField is a field which reports can be grouped and filtered by.
+type reportField struct {
+	path string
+	// skill fields need a row per skill, rather than per person.
+	skill   bool
+	numeric bool
+}
+
+// reportFields are the fields which custom reports can be grouped and
+// filtered by.
+var reportFields = map[string]reportField{
+	"department":   {path: "department"},
+	"costCenter":   {path: "costcenter"},
+	"manager":      {path: "manager"},
+	"city":         {path: "worklocation.city"},
+	"country":      {path: "worklocation.country"},
+	"availability": {path: "availability", numeric: true},
+	"skill":        {path: "skills.skill", skill: true},
+	"level":        {path: "skills.level", skill: true, numeric: true},
+	"interest":     {path: "skills.interest", skill: true, numeric: true},
+}
+
+// reportMeasures are what custom reports can report for each row, and
+// whether they summarise skills.
+var reportMeasures = map[string]struct {
+	accumulator bson.M
+	skill       bool
+}{
+	"people":       {bson.M{"$addToSet": "$_id"}, false},
+	"averageLevel": {bson.M{"$avg": "$skills.level"}, true},
+	"minLevel":     {bson.M{"$min": "$skills.level"}, true},
+	"maxLevel":     {bson.M{"$max": "$skills.level"}, true},
+}
+
+var reportOperators = map[string]string{"eq": "$eq", "ne": "$ne", "in": "$in", "gte": "$gte", "lte": "$lte"}
+
+// Validate checks the report's fields, measures and filters.
+func (d ReportDefinition) Validate() error {
+	var problems []string
+	if strings.TrimSpace(d.Name) == "" {
+		problems = append(problems, "the name is required")
+	}
+	for _, dim := range d.Dimensions {
+		if _, ok := reportFields[dim]; !ok {
+			problems = append(problems, fmt.Sprintf("the dimension '%s' is not a report field", dim))
+		}
+	}
+	if len(d.Measures) == 0 {
+		problems = append(problems, "at least one measure is required")
+	}
+	for _, m := range d.Measures {
+		if _, ok := reportMeasures[m]; !ok {
+			problems = append(problems, fmt.Sprintf("the measure '%s' must be people, averageLevel, minLevel or maxLevel", m))
+		}
+		if _, ok := reportFields[m]; ok {
+			problems = append(problems, fmt.Sprintf("'%s' can't be both a measure and a dimension", m))
+		}
+	}
+	for _, f := range d.Filters {
+		field, ok := reportFields[f.Field]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("the filter field '%s' is not a report field", f.Field))
+			continue
+		}
+		if _, ok := reportOperators[f.Operator]; !ok {
+			problems = append(problems, fmt.Sprintf("the filter operator '%s' must be eq, ne, in, gte or lte", f.Operator))
+		}
+		if len(f.Values) == 0 {
+			problems = append(problems, fmt.Sprintf("the filter on %s needs a value", f.Field))
+		}
+		if field.numeric {
+			for _, v := range f.Values {
+				if _, err := strconv.Atoi(v); err != nil {
+					problems = append(problems, fmt.Sprintf("the filter on %s must be a number", f.Field))
+					break
+				}
+			}
+		}
+	}
+	if d.Delivery != nil {
+		if d.Delivery.Frequency != DailyFrequency && d.Delivery.Frequency != WeeklyFrequency {
+			problems = append(problems, "the delivery frequency must be daily or weekly")
+		}
+		if len(d.Delivery.Recipients) == 0 {
+			problems = append(problems, "the delivery needs at least one recipient")
+		}
+		for _, r := range d.Delivery.Recipients {
+			if GetDomain(r) != strings.ToLower(d.Domain) {
+				problems = append(problems, fmt.Sprintf("the recipient %s must be in %s", r, d.Domain))
+			}
+		}
+	}
+	return newValidationError(problems)
+}
+
+// perSkill returns true if the report needs a row per skill, because it's
+// grouped, filtered or measured by skills.
+func (d ReportDefinition) perSkill() bool {
+	for _, dim := range d.Dimensions {
+		if reportFields[dim].skill {
+			return true
+		}
+	}
+	for _, f := range d.Filters {
+		if reportFields[f.Field].skill {
+			return true
+		}
+	}
+	for _, m := range d.Measures {
+		if reportMeasures[m].skill {
+			return true
+		}
+	}
+	return false
+}
+
+// Pipeline compiles the report to a MongoDB aggregation pipeline over the
+// profiles collection. Like the heatmap, it leaves out the people who haven't
+// consented to analytics under the tenant's consent settings. The definition
+// must be valid.
+func (d ReportDefinition) Pipeline(consent ConsentSettings) []bson.M {
+	pipeline := []bson.M{{"$match": bson.M{"domain": strings.ToLower(d.Domain)}}}
+	pipeline = append(pipeline, consentStages(consent)...)
+	if d.perSkill() {
+		pipeline = append(pipeline, bson.M{"$unwind": "$skills"})
+	}
+	if len(d.Filters) > 0 {
+		match := bson.M{}
+		var and []bson.M
+		for _, f := range d.Filters {
+			and = append(and, bson.M{reportFields[f.Field].path: bson.M{reportOperators[f.Operator]: f.value()}})
+		}
+		match["$and"] = and
+		pipeline = append(pipeline, bson.M{"$match": match})
+	}
+
+	id := bson.M{}
+	for _, dim := range d.Dimensions {
+		id[dim] = "$" + reportFields[dim].path
+	}
+	group := bson.M{"_id": id}
+	project := bson.M{"_id": 0}
+	for _, dim := range d.Dimensions {
+		project[dim] = "$_id." + dim
+	}
+	for _, m := range d.Measures {
+		group[m] = reportMeasures[m].accumulator
+		project[m] = 1
+	}
+	if _, ok := group["people"]; ok {
+		project["people"] = bson.M{"$size": "$people"}
+	}
+
+	sortBy := bson.D{}
+	for _, dim := range d.Dimensions {
+		sortBy = append(sortBy, bson.DocElem{Name: dim, Value: 1})
+	}
+	pipeline = append(pipeline, bson.M{"$group": group}, bson.M{"$project": project})
+	if len(sortBy) > 0 {
+		pipeline = append(pipeline, bson.M{"$sort": sortBy})
+	}
+	return append(pipeline, bson.M{"$limit": MaxReportRows})
+}
+
+// consentStages match the people whose latest analytics consent allows them
+// to be reported on, as Profile.Consented does.
+func consentStages(s ConsentSettings) []bson.M {
+	latest := bson.M{"$arrayElemAt": []interface{}{
+		bson.M{"$filter": bson.M{
+			"input": bson.M{"$ifNull": []interface{}{"$consents", []interface{}{}}},
+			"as":    "c",
+			"cond":  bson.M{"$eq": []interface{}{"$$c.purpose", ConsentAnalytics}},
+		}},
+		-1,
+	}}
+	match := bson.M{"reportconsent.given": bson.M{"$ne": false}}
+	if s.Required {
+		match = bson.M{"reportconsent.given": true, "reportconsent.noticeversion": s.NoticeVersion}
+	}
+	return []bson.M{{"$addFields": bson.M{"reportconsent": latest}}, {"$match": match}}
+}
+
+// value is what the filter's field is compared with.
+func (f ReportFilter) value() interface{} {
+	values := make([]interface{}, len(f.Values))
+	for i, v := range f.Values {
+		values[i] = v
+		if reportFields[f.Field].numeric {
+			values[i], _ = strconv.Atoi(v)
+		}
+	}
+	if f.Operator == "in" {
+		return values
+	}
+	return values[0]
+}
+
+// A ReportResult is the output of a custom report.
+type ReportResult struct {
+	Definition ReportDefinition `json:"definition"`
+	// Columns are the report's dimensions, then its measures.
+	Columns []string `json:"columns"`
+	// Rows are the values of the columns, keyed by their name.
+	Rows []map[string]interface{} `json:"rows"`
+	Ran  time.Time                `json:"ran"`
+}
+
+// Columns returns the names of the columns of the report's results.
+func (d ReportDefinition) Columns() []string {
+	return append(append([]string{}, d.Dimensions...), d.Measures...)
+}
+
+// Table returns the rows of the result as strings, in the order of the
+// columns, e.g. to be written as CSV.
+func (r ReportResult) Table() [][]string {
+	table := make([][]string, len(r.Rows))
+	for i, row := range r.Rows {
+		table[i] = make([]string, len(r.Columns))
+		for j, c := range r.Columns {
+			switch v := row[c].(type) {
+			case nil:
+			case float64:
+				table[i][j] = strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+			default:
+				table[i][j] = fmt.Sprint(v)
+			}
+		}
+	}
+	return table
+}
+
+// SaveReportDefinition creates or updates the report definition. A new ID is
+// given to definitions which don't have one.
+func (da MongoDataAccess) SaveReportDefinition(d *ReportDefinition) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	if d.ID == "" {
+		d.ID = bson.NewObjectId().Hex()
+	}
+	d.Domain = strings.ToLower(d.Domain)
+	_, err = session.DB(da.databaseName).C("reportdefinitions").UpsertId(d.ID, d)
+	return err
+}
+
+// ListReportDefinitions lists the domain's report definitions by name.
+func (da MongoDataAccess) ListReportDefinitions(domain string) ([]ReportDefinition, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var results []ReportDefinition
+	err = session.DB(da.databaseName).C("reportdefinitions").
+		Find(bson.M{"domain": strings.ToLower(domain)}).
+		Sort("name").
+		All(&results)
+	for i := range results {
+		results[i].Updated = results[i].Updated.UTC()
+	}
+	return results, err
+}
+
+// DeleteReportDefinition deletes the domain's report definition, and returns
+// false if it doesn't exist.
+func (da MongoDataAccess) DeleteReportDefinition(domain string, id string) (bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return false, err
+	}
+	defer session.Close()
+
+	info, err := session.DB(da.databaseName).C("reportdefinitions").
+		RemoveAll(bson.M{"_id": id, "domain": strings.ToLower(domain)})
+	if err != nil {
+		return false, err
+	}
+	return info.Removed > 0, nil
+}
+
+// RunReport runs the report's pipeline over the profiles in its domain, with
+// the tenant's consent settings.
+func (da MongoDataAccess) RunReport(d ReportDefinition, consent ConsentSettings) (*ReportResult, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var rows []bson.M
+	err = session.DB(da.databaseName).C("profiles").Pipe(d.Pipeline(consent)).All(&rows)
+	if err != nil {
+		return nil, err
+	}
+	result := &ReportResult{Definition: d, Columns: d.Columns(), Rows: []map[string]interface{}{}, Ran: time.Now().UTC()}
+	for _, row := range rows {
+		result.Rows = append(result.Rows, reportRow(row))
+	}
+	return result, nil
+}
+
+// reportRow converts the numbers in the row to float64, as they would be if
+// the row were read from JSON, so that results are the same however they're
+// read.
+func reportRow(row bson.M) map[string]interface{} {
+	r := make(map[string]interface{}, len(row))
+	for k, v := range row {
+		switch n := v.(type) {
+		case int:
+			r[k] = float64(n)
+		case int64:
+			r[k] = float64(n)
+		default:
+			r[k] = v
+		}
+	}
+	return r
+}
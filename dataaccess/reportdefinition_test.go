@@ -0,0 +1,115 @@
+package dataaccess
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestThatReportDefinitionsAreValidated(t *testing.T) {
+	valid := ReportDefinition{
+		Domain:     "github.com",
+		Name:       "Skills by department",
+		Dimensions: []string{"department", "skill"},
+		Measures:   []string{"people", "averageLevel"},
+		Filters:    []ReportFilter{{Field: "level", Operator: "gte", Values: []string{"3"}}},
+		Delivery:   &ReportDelivery{Frequency: WeeklyFrequency, Recipients: []string{"boss@github.com"}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Expected the definition to be valid, but got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		change   func(d *ReportDefinition)
+		expected string
+	}{
+		{"no name", func(d *ReportDefinition) { d.Name = " " }, "the name is required"},
+		{"unknown dimension", func(d *ReportDefinition) { d.Dimensions = []string{"salary"} }, "'salary' is not a report field"},
+		{"no measures", func(d *ReportDefinition) { d.Measures = nil }, "at least one measure"},
+		{"unknown measure", func(d *ReportDefinition) { d.Measures = []string{"sum"} }, "the measure 'sum'"},
+		{"unknown operator", func(d *ReportDefinition) { d.Filters[0].Operator = "like" }, "operator 'like'"},
+		{"non-numeric level", func(d *ReportDefinition) { d.Filters[0].Values = []string{"expert"} }, "must be a number"},
+		{"recipient outside the domain", func(d *ReportDefinition) { d.Delivery.Recipients = []string{"someone@example.com"} }, "must be in github.com"},
+		{"unknown frequency", func(d *ReportDefinition) { d.Delivery.Frequency = NeverFrequency }, "daily or weekly"},
+	}
+	for _, test := range tests {
+		d := valid
+		d.Filters = append([]ReportFilter{}, valid.Filters...)
+		delivery := *valid.Delivery
+		d.Delivery = &delivery
+		test.change(&d)
+		if err := d.Validate(); err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("For %s, expected an error containing '%s', but got %v", test.name, test.expected, err)
+		}
+	}
+}
+
+func TestThatReportDefinitionsCompileToPipelines(t *testing.T) {
+	d := ReportDefinition{
+		Domain:     "GitHub.com",
+		Name:       "Senior engineers by skill",
+		Dimensions: []string{"skill"},
+		Measures:   []string{"people", "averageLevel"},
+		Filters: []ReportFilter{
+			{Field: "department", Operator: "in", Values: []string{"Engineering", "Platform"}},
+			{Field: "level", Operator: "gte", Values: []string{"4"}},
+		},
+	}
+
+	pipeline := d.Pipeline(ConsentSettings{Required: true, NoticeVersion: "2"})
+
+	stages := make([]string, len(pipeline))
+	for i, stage := range pipeline {
+		for k := range stage {
+			stages[i] = k
+		}
+	}
+	if expected := []string{"$match", "$addFields", "$match", "$unwind", "$match", "$group", "$project", "$sort", "$limit"}; !reflect.DeepEqual(stages, expected) {
+		t.Fatalf("Expected stages %v, but got %v", expected, stages)
+	}
+	if expected := `{"$match":{"domain":"github.com"}}`; toJSON(pipeline[0]) != expected {
+		t.Errorf("Expected %s, but got %s", expected, toJSON(pipeline[0]))
+	}
+	if expected := `{"$match":{"reportconsent.given":true,"reportconsent.noticeversion":"2"}}`; toJSON(pipeline[2]) != expected {
+		t.Errorf("Expected %s, but got %s", expected, toJSON(pipeline[2]))
+	}
+	if expected := `{"$match":{"$and":[{"department":{"$in":["Engineering","Platform"]}},{"skills.level":{"$gte":4}}]}}`; toJSON(pipeline[4]) != expected {
+		t.Errorf("Expected %s, but got %s", expected, toJSON(pipeline[4]))
+	}
+	if expected := `{"$group":{"_id":{"skill":"$skills.skill"},"averageLevel":{"$avg":"$skills.level"},"people":{"$addToSet":"$_id"}}}`; toJSON(pipeline[5]) != expected {
+		t.Errorf("Expected %s, but got %s", expected, toJSON(pipeline[5]))
+	}
+	if expected := `{"$project":{"_id":0,"averageLevel":1,"people":{"$size":"$people"},"skill":"$_id.skill"}}`; toJSON(pipeline[6]) != expected {
+		t.Errorf("Expected %s, but got %s", expected, toJSON(pipeline[6]))
+	}
+}
+
+func TestThatReportsWhichDontNeedSkillsArentUnwound(t *testing.T) {
+	d := ReportDefinition{Domain: "github.com", Name: "Headcount", Dimensions: []string{"city"}, Measures: []string{"people"}}
+	for _, stage := range d.Pipeline(ConsentSettings{}) {
+		if _, ok := stage["$unwind"]; ok {
+			t.Errorf("Expected people to be counted without unwinding their skills")
+		}
+	}
+}
+
+func TestThatReportResultsAreTabulated(t *testing.T) {
+	r := ReportResult{
+		Columns: []string{"skill", "people", "averageLevel"},
+		Rows: []map[string]interface{}{
+			{"skill": "go", "people": float64(3), "averageLevel": 10.0 / 3},
+			{"people": float64(1)},
+		},
+	}
+	expected := [][]string{{"go", "3", "3.33"}, {"", "1", ""}}
+	if actual := r.Table(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+	}
+}
+
+func toJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
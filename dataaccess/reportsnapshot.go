@@ -0,0 +1,81 @@
+package dataaccess
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+)
+
+// The reports which are materialised as snapshots.
+const (
+	DepartmentsSnapshot = "departments"
+	HeatmapSnapshot     = "heatmap"
+)
+
+// A ReportSnapshot is a report of a whole domain which has been computed
+// ahead of time, so that it can be served without reading every profile.
+type ReportSnapshot struct {
+	ID     string `bson:"_id" json:"-"`
+	Domain string `json:"domain"`
+	Report string `json:"report"`
+	// Data is the report, as JSON.
+	Data []byte `json:"-"`
+	// Profiles is the number of profiles the report was computed from.
+	Profiles int       `json:"profiles"`
+	Computed time.Time `json:"computed"`
+}
+
+// NewReportSnapshot creates a snapshot of the domain's report, computed now.
+func NewReportSnapshot(domain string, report string, data []byte, profiles int, now time.Time) *ReportSnapshot {
+	domain = strings.ToLower(domain)
+	return &ReportSnapshot{
+		ID:       domain + "/" + report,
+		Domain:   domain,
+		Report:   report,
+		Data:     data,
+		Profiles: profiles,
+		Computed: now.UTC().Truncate(time.Millisecond),
+	}
+}
+
+// Fresh returns true if the snapshot was computed less than maxAge ago.
+func (s ReportSnapshot) Fresh(maxAge time.Duration, now time.Time) bool {
+	return now.Sub(s.Computed) < maxAge
+}
+
+// SaveReportSnapshot replaces the snapshot of the domain's report.
+func (da MongoDataAccess) SaveReportSnapshot(s *ReportSnapshot) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	_, err = session.DB(da.databaseName).C("reports").UpsertId(s.ID, s)
+	return err
+}
+
+// GetReportSnapshot returns the latest snapshot of the domain's report, if
+// there is one.
+func (da MongoDataAccess) GetReportSnapshot(domain string, report string) (*ReportSnapshot, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	var s ReportSnapshot
+	err = session.DB(da.databaseName).C("reports").FindId(strings.ToLower(domain) + "/" + report).One(&s)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	s.Computed = s.Computed.UTC()
+	return &s, true, nil
+}
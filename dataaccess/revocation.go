@@ -0,0 +1,60 @@
+package dataaccess
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SessionRevocationRetention is how long session revocations are kept. It
+// must be at least as long as sessions last, since older sessions have
+// expired anyway.
+const SessionRevocationRetention = 30 * 24 * time.Hour
+
+// A RevokedSession records that the sessions a person started before a time
+// have been ended, e.g. because they have left.
+type RevokedSession struct {
+	EmailAddress string    `json:"emailAddress"`
+	Revoked      time.Time `json:"revoked"`
+}
+
+// RevocationTimes returns when each person's sessions were last revoked,
+// keyed by their email address.
+func (c Configuration) RevocationTimes() map[string]time.Time {
+	op := make(map[string]time.Time, len(c.RevokedSessions))
+	for _, r := range c.RevokedSessions {
+		if latest, ok := op[r.EmailAddress]; !ok || r.Revoked.After(latest) {
+			op[r.EmailAddress] = r.Revoked
+		}
+	}
+	return op
+}
+
+// RevokeSessions ends the sessions the person started before the time.
+// Revocations which are older than the SessionRevocationRetention are
+// removed.
+func (da MongoDataAccess) RevokeSessions(emailAddress string, at time.Time) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	c := session.DB(da.databaseName).C("configuration")
+
+	emailAddress = strings.ToLower(emailAddress)
+	at = at.UTC().Truncate(time.Millisecond)
+	// Each update is atomic, so revocations made at the same time aren't
+	// lost. The revocation is added before the ones it replaces are removed,
+	// so that the person's sessions are never accepted in between.
+	if err := c.UpdateId("configuration", bson.M{"$push": bson.M{"revokedsessions": RevokedSession{EmailAddress: emailAddress, Revoked: at}}}); err != nil {
+		return err
+	}
+	return c.UpdateId("configuration", bson.M{"$pull": bson.M{"revokedsessions": bson.M{"$or": []bson.M{
+		{"emailaddress": emailAddress, "revoked": bson.M{"$lt": at}},
+		{"revoked": bson.M{"$lte": at.Add(-SessionRevocationRetention)}},
+	}}}})
+}
@@ -0,0 +1,639 @@
+package dataaccess
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/a-h/pill/caller"
+)
+
+// DefaultReplicaLag is how long reads are sent to the primary after a caller
+// makes a change, unless configured.
+const DefaultReplicaLag = 10 * time.Second
+
+// RoutingDataAccess sends reads to a replica, e.g. in the same region as the
+// service, and everything else to the primary. Callers read from the primary
+// for a short time after they make a change, so that they see their own
+// changes while the replica catches up.
+type RoutingDataAccess struct {
+	DataAccess
+	replica DataAccess
+	writes  *recentWrites
+	ctx     context.Context
+}
+
+// NewRoutingDataAccess creates a DataAccess which reads from the replica,
+// except within lag of the caller's last change.
+func NewRoutingDataAccess(primary DataAccess, replica DataAccess, lag time.Duration) DataAccess {
+	w := &recentWrites{lag: lag, by: map[string]time.Time{}, now: time.Now}
+	return &RoutingDataAccess{primary, replica, w, context.Background()}
+}
+
+// WithContext routes the reads of the context's caller.
+func (da RoutingDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &RoutingDataAccess{WithContext(da.DataAccess, ctx), WithContext(da.replica, ctx), da.writes, ctx}
+}
+
+// recentWrites records when each caller last made a change. Changes made
+// without a caller, e.g. by scheduled jobs, are recorded against "".
+type recentWrites struct {
+	m   sync.Mutex
+	lag time.Duration
+	by  map[string]time.Time
+	now func() time.Time
+}
+
+func (w *recentWrites) record(who string) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	now := w.now()
+	w.by[who] = now
+	for k, t := range w.by {
+		if now.Sub(t) > w.lag {
+			delete(w.by, k)
+		}
+	}
+}
+
+func (w *recentWrites) recent(who string) bool {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	t, ok := w.by[who]
+	return ok && w.now().Sub(t) <= w.lag
+}
+
+func (da RoutingDataAccess) caller() string {
+	c, _ := caller.FromContext(da.ctx)
+	return c.EmailAddress
+}
+
+func (da RoutingDataAccess) reader() DataAccess {
+	if da.writes.recent(da.caller()) {
+		return da.DataAccess
+	}
+	return da.replica
+}
+
+func (da RoutingDataAccess) wrote() {
+	da.writes.record(da.caller())
+}
+
+// ListProfiles reads from the replica.
+func (da RoutingDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
+	return da.reader().ListProfiles(emailAddress)
+}
+
+// GetOrgTree reads from the replica.
+func (da RoutingDataAccess) GetOrgTree(domain string) (*OrgNode, error) {
+	return da.reader().GetOrgTree(domain)
+}
+
+// ListDomains reads from the replica.
+func (da RoutingDataAccess) ListDomains() ([]string, error) {
+	return da.reader().ListDomains()
+}
+
+// GetProfile reads from the replica.
+func (da RoutingDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	return da.reader().GetProfile(emailAddress)
+}
+
+// ListSkillTags reads from the replica.
+func (da RoutingDataAccess) ListSkillTags() ([]SkillTag, error) {
+	return da.reader().ListSkillTags()
+}
+
+// ListDeletedSkillTags reads from the replica.
+func (da RoutingDataAccess) ListDeletedSkillTags() ([]DeletedSkillTag, error) {
+	return da.reader().ListDeletedSkillTags()
+}
+
+// GetTenantConfiguration reads from the replica.
+func (da RoutingDataAccess) GetTenantConfiguration(domain string) (*TenantConfiguration, bool, error) {
+	return da.reader().GetTenantConfiguration(domain)
+}
+
+// GetSettings reads from the replica.
+func (da RoutingDataAccess) GetSettings(domain string) (Settings, error) {
+	return da.reader().GetSettings(domain)
+}
+
+// UpdateNotificationPreferences writes to the primary.
+func (da RoutingDataAccess) UpdateNotificationPreferences(emailAddress string, p NotificationPreferences) error {
+	defer da.wrote()
+	return da.DataAccess.UpdateNotificationPreferences(emailAddress, p)
+}
+
+// UpdateAvailabilityWindows writes to the primary.
+func (da RoutingDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error {
+	defer da.wrote()
+	return da.DataAccess.UpdateAvailabilityWindows(emailAddress, windows)
+}
+
+// AwardBadges writes to the primary.
+func (da RoutingDataAccess) AwardBadges(emailAddress string, badges []Badge) error {
+	defer da.wrote()
+	return da.DataAccess.AwardBadges(emailAddress, badges)
+}
+
+// AddBooking writes to the primary.
+func (da RoutingDataAccess) AddBooking(emailAddress string, b Booking) error {
+	defer da.wrote()
+	return da.DataAccess.AddBooking(emailAddress, b)
+}
+
+// RemoveBooking writes to the primary.
+func (da RoutingDataAccess) RemoveBooking(emailAddress string, id string) error {
+	defer da.wrote()
+	return da.DataAccess.RemoveBooking(emailAddress, id)
+}
+
+// UpdateProfile writes to the primary.
+func (da RoutingDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	defer da.wrote()
+	return da.DataAccess.UpdateProfile(update)
+}
+
+// ImportProfile writes to the primary.
+func (da RoutingDataAccess) ImportProfile(p *Profile) error {
+	defer da.wrote()
+	return da.DataAccess.ImportProfile(p)
+}
+
+// DeleteProfile writes to the primary.
+func (da RoutingDataAccess) DeleteProfile(emailAddress string) (bool, error) {
+	defer da.wrote()
+	return da.DataAccess.DeleteProfile(emailAddress)
+}
+
+// AddSkillTags writes to the primary.
+func (da RoutingDataAccess) AddSkillTags(tags []string) error {
+	defer da.wrote()
+	return da.DataAccess.AddSkillTags(tags)
+}
+
+// SetSkillTagDescriptors writes to the primary.
+func (da RoutingDataAccess) SetSkillTagDescriptors(name string, descriptors []LevelDescriptor) error {
+	defer da.wrote()
+	return da.DataAccess.SetSkillTagDescriptors(name, descriptors)
+}
+
+// DeleteSkillTags writes to the primary.
+func (da RoutingDataAccess) DeleteSkillTags(tags []string) error {
+	defer da.wrote()
+	return da.DataAccess.DeleteSkillTags(tags)
+}
+
+// RestoreSkillTags writes to the primary.
+func (da RoutingDataAccess) RestoreSkillTags(tags []string) ([]string, error) {
+	defer da.wrote()
+	return da.DataAccess.RestoreSkillTags(tags)
+}
+
+// PurgeDeletedSkillTags writes to the primary.
+func (da RoutingDataAccess) PurgeDeletedSkillTags(before time.Time) (int, error) {
+	defer da.wrote()
+	return da.DataAccess.PurgeDeletedSkillTags(before)
+}
+
+// SetFeatureFlag writes to the primary.
+func (da RoutingDataAccess) SetFeatureFlag(name string, enabled bool) error {
+	defer da.wrote()
+	return da.DataAccess.SetFeatureFlag(name, enabled)
+}
+
+// UpdateTenantConfiguration writes to the primary.
+func (da RoutingDataAccess) UpdateTenantConfiguration(tc *TenantConfiguration) error {
+	defer da.wrote()
+	return da.DataAccess.UpdateTenantConfiguration(tc)
+}
+
+// DeleteTenantConfiguration writes to the primary.
+func (da RoutingDataAccess) DeleteTenantConfiguration(domain string) error {
+	defer da.wrote()
+	return da.DataAccess.DeleteTenantConfiguration(domain)
+}
+
+// SaveCalibration writes to the primary.
+func (da RoutingDataAccess) SaveCalibration(c *Calibration) error {
+	defer da.wrote()
+	return da.DataAccess.SaveCalibration(c)
+}
+
+// ListCalibrations reads from the replica.
+func (da RoutingDataAccess) ListCalibrations(emailAddress string) ([]Calibration, error) {
+	return da.reader().ListCalibrations(emailAddress)
+}
+
+// SaveProject writes to the primary.
+func (da RoutingDataAccess) SaveProject(p *Project) error {
+	defer da.wrote()
+	return da.DataAccess.SaveProject(p)
+}
+
+// ListProjects reads from the replica.
+func (da RoutingDataAccess) ListProjects(domain string) ([]Project, error) {
+	return da.reader().ListProjects(domain)
+}
+
+// GetProject reads from the replica.
+func (da RoutingDataAccess) GetProject(id string) (*Project, bool, error) {
+	return da.reader().GetProject(id)
+}
+
+// DeleteProject writes to the primary.
+func (da RoutingDataAccess) DeleteProject(id string) error {
+	defer da.wrote()
+	return da.DataAccess.DeleteProject(id)
+}
+
+// SaveCourse writes to the primary.
+func (da RoutingDataAccess) SaveCourse(c *Course) error {
+	defer da.wrote()
+	return da.DataAccess.SaveCourse(c)
+}
+
+// ListCourses reads from the replica.
+func (da RoutingDataAccess) ListCourses(domain string) ([]Course, error) {
+	return da.reader().ListCourses(domain)
+}
+
+// GetCourse reads from the replica.
+func (da RoutingDataAccess) GetCourse(id string) (*Course, bool, error) {
+	return da.reader().GetCourse(id)
+}
+
+// DeleteCourse writes to the primary.
+func (da RoutingDataAccess) DeleteCourse(id string) error {
+	defer da.wrote()
+	return da.DataAccess.DeleteCourse(id)
+}
+
+// SaveCourseCompletion writes to the primary.
+func (da RoutingDataAccess) SaveCourseCompletion(cc *CourseCompletion) error {
+	defer da.wrote()
+	return da.DataAccess.SaveCourseCompletion(cc)
+}
+
+// ListCourseCompletions reads from the replica.
+func (da RoutingDataAccess) ListCourseCompletions(domain string) ([]CourseCompletion, error) {
+	return da.reader().ListCourseCompletions(domain)
+}
+
+// GetCourseCompletion reads from the replica.
+func (da RoutingDataAccess) GetCourseCompletion(id string) (*CourseCompletion, bool, error) {
+	return da.reader().GetCourseCompletion(id)
+}
+
+// UpdateLearningGoals writes to the primary.
+func (da RoutingDataAccess) UpdateLearningGoals(emailAddress string, goals []LearningGoal) error {
+	defer da.wrote()
+	return da.DataAccess.UpdateLearningGoals(emailAddress, goals)
+}
+
+// ConfirmSkills writes to the primary.
+func (da RoutingDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	defer da.wrote()
+	return da.DataAccess.ConfirmSkills(emailAddress, skills)
+}
+
+// ImportSkillTags writes to the primary.
+func (da RoutingDataAccess) ImportSkillTags(tags []SkillTag) (int, error) {
+	defer da.wrote()
+	return da.DataAccess.ImportSkillTags(tags)
+}
+
+// SaveTagProposal writes to the primary.
+func (da RoutingDataAccess) SaveTagProposal(p *TagProposal) error {
+	defer da.wrote()
+	return da.DataAccess.SaveTagProposal(p)
+}
+
+// ListTagProposals reads from the replica.
+func (da RoutingDataAccess) ListTagProposals(domain string) ([]TagProposal, error) {
+	return da.reader().ListTagProposals(domain)
+}
+
+// GetTagProposal reads from the replica.
+func (da RoutingDataAccess) GetTagProposal(id string) (*TagProposal, bool, error) {
+	return da.reader().GetTagProposal(id)
+}
+
+// MergeSkillTags writes to the primary.
+func (da RoutingDataAccess) MergeSkillTags(into string, tags []string) (int, error) {
+	defer da.wrote()
+	return da.DataAccess.MergeSkillTags(into, tags)
+}
+
+// UpdateCV writes to the primary.
+func (da RoutingDataAccess) UpdateCV(emailAddress string, cv *Attachment) error {
+	defer da.wrote()
+	return da.DataAccess.UpdateCV(emailAddress, cv)
+}
+
+// SaveSkillSuggestions writes to the primary.
+func (da RoutingDataAccess) SaveSkillSuggestions(ss *SkillSuggestions) error {
+	defer da.wrote()
+	return da.DataAccess.SaveSkillSuggestions(ss)
+}
+
+// ListSkillSuggestions reads from the replica.
+func (da RoutingDataAccess) ListSkillSuggestions(emailAddress string) ([]SkillSuggestions, error) {
+	return da.reader().ListSkillSuggestions(emailAddress)
+}
+
+// GetSkillSuggestions reads from the replica.
+func (da RoutingDataAccess) GetSkillSuggestions(id string) (*SkillSuggestions, bool, error) {
+	return da.reader().GetSkillSuggestions(id)
+}
+
+// SaveShareLink writes to the primary.
+func (da RoutingDataAccess) SaveShareLink(l *ShareLink) error {
+	defer da.wrote()
+	return da.DataAccess.SaveShareLink(l)
+}
+
+// ListShareLinks reads from the replica.
+func (da RoutingDataAccess) ListShareLinks(emailAddress string) ([]ShareLink, error) {
+	return da.reader().ListShareLinks(emailAddress)
+}
+
+// GetShareLink reads from the replica.
+func (da RoutingDataAccess) GetShareLink(id string) (*ShareLink, bool, error) {
+	return da.reader().GetShareLink(id)
+}
+
+// DeleteShareLink writes to the primary.
+func (da RoutingDataAccess) DeleteShareLink(id string) error {
+	defer da.wrote()
+	return da.DataAccess.DeleteShareLink(id)
+}
+
+// SyncEmployee writes to the primary.
+func (da RoutingDataAccess) SyncEmployee(e EmployeeUpdate) error {
+	defer da.wrote()
+	return da.DataAccess.SyncEmployee(e)
+}
+
+// ArchiveProfile writes to the primary.
+func (da RoutingDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (bool, error) {
+	defer da.wrote()
+	return da.DataAccess.ArchiveProfile(emailAddress, reason, purgeAfter)
+}
+
+// RestoreProfile writes to the primary.
+func (da RoutingDataAccess) RestoreProfile(emailAddress string) (bool, error) {
+	defer da.wrote()
+	return da.DataAccess.RestoreProfile(emailAddress)
+}
+
+// ListArchivedProfiles reads from the replica.
+func (da RoutingDataAccess) ListArchivedProfiles(domain string) ([]ArchivedProfile, error) {
+	return da.reader().ListArchivedProfiles(domain)
+}
+
+// PurgeArchivedProfiles writes to the primary.
+func (da RoutingDataAccess) PurgeArchivedProfiles(before time.Time) (int, error) {
+	defer da.wrote()
+	return da.DataAccess.PurgeArchivedProfiles(before)
+}
+
+// RevokeSessions writes to the primary.
+func (da RoutingDataAccess) RevokeSessions(emailAddress string, at time.Time) error {
+	defer da.wrote()
+	return da.DataAccess.RevokeSessions(emailAddress, at)
+}
+
+// FindProfiles reads from the replica.
+func (da RoutingDataAccess) FindProfiles(domain string, f ProfileFilter) ([]Profile, error) {
+	return da.reader().FindProfiles(domain, f)
+}
+
+// EnsureIndexes writes to the primary.
+func (da RoutingDataAccess) EnsureIndexes() error {
+	defer da.wrote()
+	return da.DataAccess.EnsureIndexes()
+}
+
+// UpdateCustomFields writes to the primary.
+func (da RoutingDataAccess) UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) error {
+	defer da.wrote()
+	return da.DataAccess.UpdateCustomFields(emailAddress, values)
+}
+
+// UpdateLanguages writes to the primary.
+func (da RoutingDataAccess) UpdateLanguages(emailAddress string, languages []Language) error {
+	defer da.wrote()
+	return da.DataAccess.UpdateLanguages(emailAddress, languages)
+}
+
+// UpdateClearance writes to the primary.
+func (da RoutingDataAccess) UpdateClearance(emailAddress string, cl *Clearance) error {
+	defer da.wrote()
+	return da.DataAccess.UpdateClearance(emailAddress, cl)
+}
+
+// UpdateWorkLocation writes to the primary.
+func (da RoutingDataAccess) UpdateWorkLocation(emailAddress string, l *WorkLocation) error {
+	defer da.wrote()
+	return da.DataAccess.UpdateWorkLocation(emailAddress, l)
+}
+
+// FindProfilesNear reads from the replica.
+func (da RoutingDataAccess) FindProfilesNear(domain string, near Coordinates, radiusKm float64) ([]Profile, error) {
+	return da.reader().FindProfilesNear(domain, near, radiusKm)
+}
+
+// UpdateWorkingHours writes to the primary.
+func (da RoutingDataAccess) UpdateWorkingHours(emailAddress string, h *WorkingHours) error {
+	defer da.wrote()
+	return da.DataAccess.UpdateWorkingHours(emailAddress, h)
+}
+
+// RecordConsents writes to the primary.
+func (da RoutingDataAccess) RecordConsents(emailAddress string, consents []Consent) error {
+	defer da.wrote()
+	return da.DataAccess.RecordConsents(emailAddress, consents)
+}
+
+// SaveBenchmarks writes to the primary.
+func (da RoutingDataAccess) SaveBenchmarks(benchmarks []Benchmark) error {
+	defer da.wrote()
+	return da.DataAccess.SaveBenchmarks(benchmarks)
+}
+
+// GetBenchmark reads from the replica.
+func (da RoutingDataAccess) GetBenchmark(band string) (*Benchmark, bool, error) {
+	return da.reader().GetBenchmark(band)
+}
+
+// SaveReportDefinition writes to the primary.
+func (da RoutingDataAccess) SaveReportDefinition(d *ReportDefinition) error {
+	defer da.wrote()
+	return da.DataAccess.SaveReportDefinition(d)
+}
+
+// ListReportDefinitions reads from the replica.
+func (da RoutingDataAccess) ListReportDefinitions(domain string) ([]ReportDefinition, error) {
+	return da.reader().ListReportDefinitions(domain)
+}
+
+// DeleteReportDefinition writes to the primary.
+func (da RoutingDataAccess) DeleteReportDefinition(domain string, id string) (bool, error) {
+	defer da.wrote()
+	return da.DataAccess.DeleteReportDefinition(domain, id)
+}
+
+// RunReport reads from the replica.
+func (da RoutingDataAccess) RunReport(d ReportDefinition, consent ConsentSettings) (*ReportResult, error) {
+	return da.reader().RunReport(d, consent)
+}
+
+// SaveReportSnapshot writes to the primary.
+func (da RoutingDataAccess) SaveReportSnapshot(s *ReportSnapshot) error {
+	defer da.wrote()
+	return da.DataAccess.SaveReportSnapshot(s)
+}
+
+// GetReportSnapshot reads from the replica.
+func (da RoutingDataAccess) GetReportSnapshot(domain string, report string) (*ReportSnapshot, bool, error) {
+	return da.reader().GetReportSnapshot(domain, report)
+}
+
+// SaveExportJob writes to the primary.
+func (da RoutingDataAccess) SaveExportJob(j *ExportJob) error {
+	defer da.wrote()
+	return da.DataAccess.SaveExportJob(j)
+}
+
+// GetExportJob reads from the replica.
+func (da RoutingDataAccess) GetExportJob(id string) (*ExportJob, bool, error) {
+	return da.reader().GetExportJob(id)
+}
+
+// ClaimExportJob writes to the primary.
+func (da RoutingDataAccess) ClaimExportJob(now time.Time) (*ExportJob, bool, error) {
+	defer da.wrote()
+	return da.DataAccess.ClaimExportJob(now)
+}
+
+// PurgeExportJobs writes to the primary.
+func (da RoutingDataAccess) PurgeExportJobs(before time.Time) ([]ExportJob, error) {
+	defer da.wrote()
+	return da.DataAccess.PurgeExportJobs(before)
+}
+
+// SaveImportPreview writes to the primary.
+func (da RoutingDataAccess) SaveImportPreview(p *ImportPreview) error {
+	defer da.wrote()
+	return da.DataAccess.SaveImportPreview(p)
+}
+
+// TakeImportPreview writes to the primary.
+func (da RoutingDataAccess) TakeImportPreview(domain string, id string) (*ImportPreview, bool, error) {
+	defer da.wrote()
+	return da.DataAccess.TakeImportPreview(domain, id)
+}
+
+// MergeProfiles writes to the primary.
+func (da RoutingDataAccess) MergeProfiles(primary string, duplicate string) (*Profile, bool, error) {
+	defer da.wrote()
+	return da.DataAccess.MergeProfiles(primary, duplicate)
+}
+
+// FindProfileByExternalID reads from the replica.
+func (da RoutingDataAccess) FindProfileByExternalID(domain string, system string, id string) (*Profile, bool, error) {
+	return da.reader().FindProfileByExternalID(domain, system, id)
+}
+
+// UpdateExternalIDs writes to the primary.
+func (da RoutingDataAccess) UpdateExternalIDs(emailAddress string, ids map[string]string) error {
+	defer da.wrote()
+	return da.DataAccess.UpdateExternalIDs(emailAddress, ids)
+}
+
+// AppendProfileEvents writes to the primary.
+func (da RoutingDataAccess) AppendProfileEvents(events []ProfileEvent) error {
+	defer da.wrote()
+	return da.DataAccess.AppendProfileEvents(events)
+}
+
+// ListProfileEvents reads from the replica.
+func (da RoutingDataAccess) ListProfileEvents(emailAddress string, after int) ([]ProfileEvent, error) {
+	return da.reader().ListProfileEvents(emailAddress, after)
+}
+
+// SaveProfileSnapshot writes to the primary.
+func (da RoutingDataAccess) SaveProfileSnapshot(s *ProfileSnapshot) error {
+	defer da.wrote()
+	return da.DataAccess.SaveProfileSnapshot(s)
+}
+
+// GetProfileSnapshot reads from the replica.
+func (da RoutingDataAccess) GetProfileSnapshot(emailAddress string, at time.Time) (*ProfileSnapshot, bool, error) {
+	return da.reader().GetProfileSnapshot(emailAddress, at)
+}
+
+// ProjectProfile writes to the primary.
+func (da RoutingDataAccess) ProjectProfile(s *ProfileSnapshot) error {
+	defer da.wrote()
+	return da.DataAccess.ProjectProfile(s)
+}
+
+// SaveSearchDocument writes to the primary.
+func (da RoutingDataAccess) SaveSearchDocument(d *SearchDocument) error {
+	defer da.wrote()
+	return da.DataAccess.SaveSearchDocument(d)
+}
+
+// GetSearchDocument reads from the replica.
+func (da RoutingDataAccess) GetSearchDocument(emailAddress string) (*SearchDocument, bool, error) {
+	return da.reader().GetSearchDocument(emailAddress)
+}
+
+// RemoveSearchDocument writes to the primary.
+func (da RoutingDataAccess) RemoveSearchDocument(emailAddress string) error {
+	defer da.wrote()
+	return da.DataAccess.RemoveSearchDocument(emailAddress)
+}
+
+// SearchDocuments reads from the replica.
+func (da RoutingDataAccess) SearchDocuments(domain string, q SearchQuery) ([]SearchDocument, error) {
+	return da.reader().SearchDocuments(domain, q)
+}
+
+// SaveTeamSummary writes to the primary.
+func (da RoutingDataAccess) SaveTeamSummary(s *TeamSummary) error {
+	defer da.wrote()
+	return da.DataAccess.SaveTeamSummary(s)
+}
+
+// GetTeamSummary reads from the replica.
+func (da RoutingDataAccess) GetTeamSummary(manager string) (*TeamSummary, bool, error) {
+	return da.reader().GetTeamSummary(manager)
+}
+
+// SaveProfileSummary writes to the primary.
+func (da RoutingDataAccess) SaveProfileSummary(s *ProfileSummary) error {
+	defer da.wrote()
+	return da.DataAccess.SaveProfileSummary(s)
+}
+
+// GetProfileSummary reads from the replica.
+func (da RoutingDataAccess) GetProfileSummary(emailAddress string) (*ProfileSummary, bool, error) {
+	return da.reader().GetProfileSummary(emailAddress)
+}
+
+// ListProfileSummaries reads from the replica.
+func (da RoutingDataAccess) ListProfileSummaries(domain string) ([]ProfileSummary, error) {
+	return da.reader().ListProfileSummaries(domain)
+}
+
+// RemoveProfileSummary writes to the primary.
+func (da RoutingDataAccess) RemoveProfileSummary(emailAddress string) error {
+	defer da.wrote()
+	return da.DataAccess.RemoveProfileSummary(emailAddress)
+}
@@ -0,0 +1,56 @@
+package dataaccess
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/caller"
+)
+
+type namedDataAccess struct {
+	DataAccess
+	name string
+}
+
+func (da namedDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	return &Profile{EmailAddress: emailAddress, Name: da.name}, true, nil
+}
+
+func (da namedDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	return &Profile{EmailAddress: update.EmailAddress, Name: da.name}, nil
+}
+
+func TestThatReadsAreSentToTheReplicaUnlessTheCallerRecentlyMadeAChange(t *testing.T) {
+	now := time.Date(2017, time.June, 1, 9, 0, 0, 0, time.UTC)
+	da := NewRoutingDataAccess(namedDataAccess{name: "primary"}, namedDataAccess{name: "replica"}, 10*time.Second).(*RoutingDataAccess)
+	da.writes.now = func() time.Time { return now }
+
+	a := WithContext(da, caller.NewContext(context.Background(), caller.Caller{EmailAddress: "a@github.com"}))
+	b := WithContext(da, caller.NewContext(context.Background(), caller.Caller{EmailAddress: "b@github.com"}))
+
+	read := func(da DataAccess) string {
+		p, _, _ := da.GetProfile("a@github.com")
+		return p.Name
+	}
+
+	if actual := read(a); actual != "replica" {
+		t.Errorf("Expected reads to be sent to the replica, but were sent to the %s.", actual)
+	}
+
+	if p, _ := a.UpdateProfile(&ProfileUpdate{EmailAddress: "a@github.com"}); p.Name != "primary" {
+		t.Errorf("Expected changes to be sent to the primary, but were sent to the %s.", p.Name)
+	}
+
+	if actual := read(a); actual != "primary" {
+		t.Errorf("Expected the caller's reads to be sent to the primary after a change, but were sent to the %s.", actual)
+	}
+	if actual := read(b); actual != "replica" {
+		t.Errorf("Expected other callers' reads to be sent to the replica, but were sent to the %s.", actual)
+	}
+
+	now = now.Add(11 * time.Second)
+	if actual := read(a); actual != "replica" {
+		t.Errorf("Expected reads to be sent to the replica once it has caught up, but were sent to the %s.", actual)
+	}
+}
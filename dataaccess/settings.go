@@ -0,0 +1,365 @@
+package dataaccess
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// Settings control behaviour which can differ between tenants. A tenant is
+// identified by the domain of its users' email addresses.
+type Settings struct {
+	// LevelScale is the highest skill level which can be selected.
+	LevelScale DreyfusLevel `json:"levelScale"`
+	// StrictVocabulary restricts skills to the existing list of skill tags.
+	StrictVocabulary bool `json:"strictVocabulary"`
+	// RetentionDays is how long skills history is kept, 0 keeps it forever.
+	RetentionDays int `json:"retentionDays"`
+	// Notifications controls the messages sent to users.
+	Notifications NotificationSettings `json:"notifications"`
+	// Headcount is the number of people in the tenant, used to report what
+	// proportion of them have a profile. 0 if it isn't known.
+	Headcount int `json:"headcount"`
+	// Decay flags skills which haven't been used for a while as stale.
+	Decay DecaySettings `json:"decay"`
+	// ContentFilter blocks unsuitable free text, such as bios and notes.
+	ContentFilter ContentFilterSettings `json:"contentFilter"`
+	// OnePagerTemplate lays out the PDF profiles people include in client
+	// proposals. If empty, the default template is used.
+	OnePagerTemplate string `json:"onePagerTemplate,omitempty"`
+	// Branding white-labels the user interface and PDF profiles.
+	Branding BrandingSettings `json:"branding"`
+	// Offboarding controls what happens to the profiles of people who leave.
+	Offboarding OffboardingSettings `json:"offboarding"`
+	// CustomFields are the extra fields on the tenant's profiles.
+	CustomFields CustomFieldSchema `json:"customFields"`
+	// Clearance restricts who can see people's security clearances.
+	Clearance ClearanceSettings `json:"clearance"`
+	// Holidays sets whose public holidays people are given.
+	Holidays HolidaySettings `json:"holidays"`
+	// Consent sets the privacy notice people consent to.
+	Consent ConsentSettings `json:"consent"`
+	// Archival moves inactive profiles to the archive.
+	Archival ArchivalSettings `json:"archival"`
+	// Benchmarking compares the tenant's skills with other tenants of its
+	// size.
+	Benchmarking BenchmarkSettings `json:"benchmarking"`
+	// AliasDomains are other domains the tenant's people have email
+	// addresses in, e.g. after a rebrand, which are checked for duplicate
+	// profiles.
+	AliasDomains []string `json:"aliasDomains,omitempty"`
+	// Summaries has a language model summarise what each person can do.
+	Summaries SummarySettings `json:"summaries"`
+	// ProfileRules check and fill in the profiles people save, in order.
+	ProfileRules []ProfileRule `json:"profileRules,omitempty"`
+}
+
+// An OffboardingAction is what happens to a leaver's profile.
+type OffboardingAction string
+
+// The actions taken on leavers' profiles.
+const (
+	// ArchiveLeavers moves the profile to the archive, where it is kept until
+	// it's restored.
+	ArchiveLeavers OffboardingAction = "archive"
+	// DeleteLeavers soft deletes the profile, so that it can be restored
+	// until it's purged.
+	DeleteLeavers OffboardingAction = "delete"
+)
+
+// OffboardingSettings control what happens when the HR system says that
+// someone has left.
+type OffboardingSettings struct {
+	Enabled bool `json:"enabled"`
+	// GraceDays is how long after someone leaves their profile is archived
+	// or deleted. Their sessions are revoked straight away.
+	GraceDays int               `json:"graceDays"`
+	Action    OffboardingAction `json:"action"`
+	// PurgeDays is how long deleted profiles can be restored for.
+	PurgeDays int `json:"purgeDays"`
+	// NotifyManager emails the leaver's manager when their profile is
+	// archived or deleted.
+	NotifyManager bool `json:"notifyManager"`
+}
+
+func (o OffboardingSettings) problems() []string {
+	var problems []string
+	if o.GraceDays < 0 || o.PurgeDays < 0 {
+		problems = append(problems, "the offboarding grace and purge days must not be negative")
+	}
+	if o.Action != ArchiveLeavers && o.Action != DeleteLeavers {
+		problems = append(problems, "the offboarding action must be archive or delete")
+	}
+	return problems
+}
+
+// BrandingSettings replace pill's branding with a partner's. Empty fields
+// keep pill's own.
+type BrandingSettings struct {
+	// LogoURL is the address of a PNG, JPEG or GIF logo.
+	LogoURL string `json:"logoUrl,omitempty"`
+	// PrimaryColor is used for the navigation bar and headings, e.g. "#1f6feb".
+	PrimaryColor string `json:"primaryColor,omitempty"`
+	// AccentColor is used for links and rules.
+	AccentColor string `json:"accentColor,omitempty"`
+	// FooterText is shown at the bottom of each page.
+	FooterText string `json:"footerText,omitempty"`
+}
+
+// MaxFooterLength is the longest footer text, in characters.
+const MaxFooterLength = 200
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func (b BrandingSettings) problems() []string {
+	var problems []string
+	if b.LogoURL != "" {
+		if u, err := url.Parse(b.LogoURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			problems = append(problems, "the logo URL must be an absolute https URL")
+		}
+	}
+	for _, c := range []string{b.PrimaryColor, b.AccentColor} {
+		if c != "" && !hexColor.MatchString(c) {
+			problems = append(problems, fmt.Sprintf("the colour '%s' must be a hex colour such as #1f6feb", c))
+		}
+	}
+	if len([]rune(b.FooterText)) > MaxFooterLength {
+		problems = append(problems, fmt.Sprintf("the footer text must be no longer than %d characters", MaxFooterLength))
+	}
+	return problems
+}
+
+// ContentFilterSettings control what's blocked in free text.
+type ContentFilterSettings struct {
+	// Profanity blocks swearing, in English and German.
+	Profanity bool `json:"profanity"`
+	// PersonalInformation blocks what looks like phone numbers, payment card
+	// numbers and national identity numbers.
+	PersonalInformation bool `json:"personalInformation"`
+	// BlockedWords are blocked in addition to the built in list of profanity.
+	BlockedWords []string `json:"blockedWords,omitempty" bson:",omitempty"`
+}
+
+// NotificationSettings control the messages sent to users.
+type NotificationSettings struct {
+	EmailEnabled bool `json:"emailEnabled"`
+	// ReminderDays is how long after their last update users are reminded to
+	// update their profile, 0 disables reminders.
+	ReminderDays int `json:"reminderDays"`
+}
+
+// DefaultSettings returns the settings used when neither the configuration
+// or the tenant override them.
+func DefaultSettings() Settings {
+	return Settings{
+		LevelScale:       MasterLevel,
+		StrictVocabulary: false,
+		RetentionDays:    0,
+		Notifications: NotificationSettings{
+			EmailEnabled: true,
+			ReminderDays: 90,
+		},
+		ContentFilter: ContentFilterSettings{
+			Profanity:           true,
+			PersonalInformation: true,
+		},
+		Offboarding: OffboardingSettings{
+			GraceDays:     30,
+			Action:        ArchiveLeavers,
+			PurgeDays:     30,
+			NotifyManager: true,
+		},
+		Clearance: ClearanceSettings{
+			Roles: []string{AdministratorRole, SecurityOfficerRole},
+		},
+		Consent: ConsentSettings{
+			Purposes: []string{ConsentAnalytics},
+		},
+		Archival: ArchivalSettings{
+			InactiveMonths: 24,
+		},
+	}
+}
+
+// SettingsOverrides replace individual settings. Nil fields are inherited.
+type SettingsOverrides struct {
+	LevelScale       *DreyfusLevel          `json:"levelScale,omitempty" bson:",omitempty"`
+	StrictVocabulary *bool                  `json:"strictVocabulary,omitempty" bson:",omitempty"`
+	RetentionDays    *int                   `json:"retentionDays,omitempty" bson:",omitempty"`
+	Notifications    *NotificationSettings  `json:"notifications,omitempty" bson:",omitempty"`
+	Headcount        *int                   `json:"headcount,omitempty" bson:",omitempty"`
+	Decay            *DecaySettings         `json:"decay,omitempty" bson:",omitempty"`
+	ContentFilter    *ContentFilterSettings `json:"contentFilter,omitempty" bson:",omitempty"`
+	OnePagerTemplate *string                `json:"onePagerTemplate,omitempty" bson:",omitempty"`
+	Branding         *BrandingSettings      `json:"branding,omitempty" bson:",omitempty"`
+	Offboarding      *OffboardingSettings   `json:"offboarding,omitempty" bson:",omitempty"`
+	CustomFields     *CustomFieldSchema     `json:"customFields,omitempty" bson:",omitempty"`
+	Clearance        *ClearanceSettings     `json:"clearance,omitempty" bson:",omitempty"`
+	Holidays         *HolidaySettings       `json:"holidays,omitempty" bson:",omitempty"`
+	Consent          *ConsentSettings       `json:"consent,omitempty" bson:",omitempty"`
+	Archival         *ArchivalSettings      `json:"archival,omitempty" bson:",omitempty"`
+	Benchmarking     *BenchmarkSettings     `json:"benchmarking,omitempty" bson:",omitempty"`
+	AliasDomains     *[]string              `json:"aliasDomains,omitempty" bson:",omitempty"`
+	Summaries        *SummarySettings       `json:"summaries,omitempty" bson:",omitempty"`
+	ProfileRules     *[]ProfileRule         `json:"profileRules,omitempty" bson:",omitempty"`
+}
+
+// Validate checks that the overrides are within sensible ranges.
+func (o SettingsOverrides) Validate() error {
+	return newValidationError(o.problems())
+}
+
+func (o SettingsOverrides) problems() []string {
+	var problems []string
+
+	if o.LevelScale != nil && (*o.LevelScale < NoviceLevel || *o.LevelScale > MasterLevel) {
+		problems = append(problems, fmt.Sprintf("the level scale must be between %d and %d", NoviceLevel, MasterLevel))
+	}
+
+	if o.RetentionDays != nil && *o.RetentionDays < 0 {
+		problems = append(problems, "the retention days must not be negative")
+	}
+
+	if o.Notifications != nil && o.Notifications.ReminderDays < 0 {
+		problems = append(problems, "the reminder days must not be negative")
+	}
+
+	if o.Headcount != nil && *o.Headcount < 0 {
+		problems = append(problems, "the headcount must not be negative")
+	}
+
+	if o.Decay != nil && (o.Decay.StaleMonths < 0 || o.Decay.Discount < 0 || o.Decay.Discount >= MasterLevel) {
+		problems = append(problems, fmt.Sprintf("the stale months must not be negative, and the discount must be between 0 and %d", MasterLevel-1))
+	}
+
+	if o.OnePagerTemplate != nil {
+		if _, err := template.New("onepager").Parse(*o.OnePagerTemplate); err != nil {
+			problems = append(problems, "the one pager template must be a valid text template")
+		}
+	}
+
+	if o.Branding != nil {
+		problems = append(problems, o.Branding.problems()...)
+	}
+
+	if o.Offboarding != nil {
+		problems = append(problems, o.Offboarding.problems()...)
+	}
+	if o.CustomFields != nil {
+		problems = append(problems, o.CustomFields.problems()...)
+	}
+	if o.Clearance != nil {
+		problems = append(problems, o.Clearance.problems()...)
+	}
+	if o.Holidays != nil {
+		problems = append(problems, o.Holidays.problems()...)
+	}
+	if o.Consent != nil {
+		problems = append(problems, o.Consent.problems()...)
+	}
+	if o.Archival != nil {
+		problems = append(problems, o.Archival.problems()...)
+	}
+	if o.ProfileRules != nil {
+		problems = append(problems, profileRuleProblems(*o.ProfileRules)...)
+	}
+	if o.AliasDomains != nil {
+		for _, d := range *o.AliasDomains {
+			if strings.TrimSpace(d) == "" || strings.ContainsAny(d, "@/ ") {
+				problems = append(problems, fmt.Sprintf("the alias domain '%s' must be a domain, e.g. example.com", d))
+			}
+		}
+	}
+
+	return problems
+}
+
+// Apply returns a copy of the settings with the overrides applied.
+func (s Settings) Apply(o SettingsOverrides) Settings {
+	if o.LevelScale != nil {
+		s.LevelScale = *o.LevelScale
+	}
+	if o.StrictVocabulary != nil {
+		s.StrictVocabulary = *o.StrictVocabulary
+	}
+	if o.RetentionDays != nil {
+		s.RetentionDays = *o.RetentionDays
+	}
+	if o.Notifications != nil {
+		s.Notifications = *o.Notifications
+	}
+	if o.Headcount != nil {
+		s.Headcount = *o.Headcount
+	}
+	if o.Decay != nil {
+		s.Decay = *o.Decay
+	}
+	if o.ContentFilter != nil {
+		s.ContentFilter = *o.ContentFilter
+	}
+	if o.OnePagerTemplate != nil {
+		s.OnePagerTemplate = *o.OnePagerTemplate
+	}
+	if o.Branding != nil {
+		s.Branding = *o.Branding
+	}
+	if o.Offboarding != nil {
+		s.Offboarding = *o.Offboarding
+	}
+	if o.CustomFields != nil {
+		s.CustomFields = *o.CustomFields
+	}
+	if o.Clearance != nil {
+		s.Clearance = *o.Clearance
+	}
+	if o.Holidays != nil {
+		s.Holidays = *o.Holidays
+	}
+	if o.Consent != nil {
+		s.Consent = *o.Consent
+	}
+	if o.Archival != nil {
+		s.Archival = *o.Archival
+	}
+	if o.Benchmarking != nil {
+		s.Benchmarking = *o.Benchmarking
+	}
+	if o.AliasDomains != nil {
+		s.AliasDomains = *o.AliasDomains
+	}
+	if o.Summaries != nil {
+		s.Summaries = *o.Summaries
+	}
+	if o.ProfileRules != nil {
+		s.ProfileRules = *o.ProfileRules
+	}
+	return s
+}
+
+// TenantConfiguration holds the settings overridden by a tenant.
+type TenantConfiguration struct {
+	Domain    string            `bson:"_id" json:"domain"`
+	Overrides SettingsOverrides `json:"overrides"`
+}
+
+// NewTenantConfiguration creates a TenantConfiguration which inherits all of
+// its settings.
+func NewTenantConfiguration(domain string) *TenantConfiguration {
+	return &TenantConfiguration{
+		Domain: domain,
+	}
+}
+
+// EffectiveSettings applies the configuration's overrides, then the tenant's
+// overrides to the default settings.
+func EffectiveSettings(c Configuration, tc *TenantConfiguration) Settings {
+	s := DefaultSettings().Apply(c.Settings)
+
+	if tc != nil {
+		s = s.Apply(tc.Overrides)
+	}
+
+	return s
+}
@@ -0,0 +1,68 @@
+package dataaccess
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThatTenantSettingsInheritFromTheConfiguration(t *testing.T) {
+	globalScale := DreyfusLevel(ExpertLevel)
+	globalRetention := 365
+	tenantStrict := true
+	tenantRetention := 30
+
+	c := Configuration{
+		Settings: SettingsOverrides{
+			LevelScale:    &globalScale,
+			RetentionDays: &globalRetention,
+		},
+	}
+
+	tc := NewTenantConfiguration("github.com")
+	tc.Overrides.StrictVocabulary = &tenantStrict
+	tc.Overrides.RetentionDays = &tenantRetention
+
+	actual := EffectiveSettings(c, tc)
+
+	if actual.LevelScale != ExpertLevel {
+		t.Errorf("The level scale should be inherited from the configuration, but was %d.", actual.LevelScale)
+	}
+
+	if !actual.StrictVocabulary {
+		t.Error("The tenant's strict vocabulary setting should be applied.")
+	}
+
+	if actual.RetentionDays != 30 {
+		t.Errorf("The tenant's retention should override the configuration, but was %d.", actual.RetentionDays)
+	}
+
+	if actual.Notifications != DefaultSettings().Notifications {
+		t.Errorf("Notifications should be inherited from the defaults, but were %v.", actual.Notifications)
+	}
+}
+
+func TestThatSettingsDefaultWithoutATenantConfiguration(t *testing.T) {
+	actual := EffectiveSettings(Configuration{}, nil)
+
+	if !reflect.DeepEqual(actual, DefaultSettings()) {
+		t.Errorf("Without overrides, the default settings should be used, but were %v.", actual)
+	}
+}
+
+func TestThatProfileRulesMustParse(t *testing.T) {
+	valid := []ProfileRule{{Name: "department", Require: `department != ""`, Message: "The department is required."}}
+	if err := (SettingsOverrides{ProfileRules: &valid}).Validate(); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	invalid := []ProfileRule{
+		{Name: "syntax", Require: `department ==`, Message: "Invalid."},
+		{Name: "field", Set: map[string]string{"skills": `"go"`}},
+		{Name: "message", Require: `true`},
+		{Name: "message"},
+	}
+	err := (SettingsOverrides{ProfileRules: &invalid}).Validate()
+	if verr, ok := err.(ValidationError); !ok || len(verr.Problems) != 5 {
+		t.Errorf("Expected five problems, but received %v", err)
+	}
+}
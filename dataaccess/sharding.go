@@ -0,0 +1,1388 @@
+package dataaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mgo "gopkg.in/mgo.v2"
+)
+
+// DefaultShard is the name of the shard which holds the tenants that haven't
+// been assigned to a shard. It is the database which holds the configuration.
+const DefaultShard = "default"
+
+// DefaultShardDirectoryTTL is how long shard assignments are cached for.
+const DefaultShardDirectoryTTL = 30 * time.Second
+
+// ErrTenantMoving is returned when changing the profiles of a tenant which is
+// being moved between shards.
+var ErrTenantMoving = errors.New("dataaccess: the tenant is being moved to another shard, try again shortly")
+
+// ErrUnknownShard is returned when a tenant is assigned to a shard which
+// isn't configured.
+var ErrUnknownShard = errors.New("dataaccess: the shard is not configured")
+
+// ErrResidencyViolation is returned when a tenant's profiles are held by a
+// shard outside the region its data must be stored in.
+var ErrResidencyViolation = errors.New("dataaccess: the tenant's data must be stored in another region")
+
+// ParseShards parses a comma separated list of shard names and MongoDB
+// connection strings, e.g. "apac=mongodb://mongo-apac:27017".
+func ParseShards(spec string) (map[string]string, error) {
+	shards := map[string]string{}
+	for _, s := range strings.Split(spec, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		parts := strings.SplitN(s, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("dataaccess: '%s' is not a shard, use name=connectionString", s)
+		}
+		if name == DefaultShard {
+			return nil, fmt.Errorf("dataaccess: the %s shard is the main database, and can't be configured", DefaultShard)
+		}
+		shards[name] = strings.TrimSpace(parts[1])
+	}
+	return shards, nil
+}
+
+// ParseShardRegions parses a comma separated list of shard names and the
+// regions their clusters are in, e.g. "default=US,eu=EU".
+func ParseShardRegions(spec string) (map[string]string, error) {
+	regions := map[string]string{}
+	for _, s := range strings.Split(spec, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		parts := strings.SplitN(s, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf("dataaccess: '%s' is not a shard region, use name=region", s)
+		}
+		regions[strings.TrimSpace(parts[0])] = strings.ToUpper(strings.TrimSpace(parts[1]))
+	}
+	return regions, nil
+}
+
+// A ShardAssignment records which shard holds a tenant's profiles.
+type ShardAssignment struct {
+	Tenant string `bson:"_id" json:"tenant"`
+	Shard  string `json:"shard"`
+	// Moving is set while the tenant is being moved to another shard. Its
+	// profiles can be read, but not changed.
+	Moving bool `json:"moving"`
+	// Region is where the tenant's data must be stored, e.g. "EU", or empty
+	// if it can be stored anywhere. The tenant's profiles can't be read or
+	// changed while they're in a shard outside the region.
+	Region string `json:"region,omitempty" bson:",omitempty"`
+}
+
+// A ShardDirectory stores the shard assignments of tenants.
+type ShardDirectory interface {
+	GetShardAssignment(tenant string) (*ShardAssignment, bool, error)
+	AssignShard(a *ShardAssignment) error
+	ListShardAssignments() ([]ShardAssignment, error)
+}
+
+// NewMongoShardDirectory creates a ShardDirectory stored in the database.
+func NewMongoShardDirectory(connectionString string, databaseName string) ShardDirectory {
+	return &MongoDataAccess{connectionString, databaseName, nil, false}
+}
+
+// GetShardAssignment returns the shard assignment of a tenant.
+func (da MongoDataAccess) GetShardAssignment(tenant string) (*ShardAssignment, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	var a ShardAssignment
+	err = session.DB(da.databaseName).C("shards").FindId(tenant).One(&a)
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &a, true, nil
+}
+
+// AssignShard stores the shard assignment of a tenant.
+func (da MongoDataAccess) AssignShard(a *ShardAssignment) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	_, err = session.DB(da.databaseName).C("shards").UpsertId(a.Tenant, a)
+	return err
+}
+
+// ListShardAssignments lists the tenants which have been assigned to a shard.
+func (da MongoDataAccess) ListShardAssignments() ([]ShardAssignment, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var assignments []ShardAssignment
+	err = session.DB(da.databaseName).C("shards").Find(nil).Sort("_id").All(&assignments)
+	return assignments, err
+}
+
+// ShardedDataAccess stores each tenant's profiles, and the other data about
+// its people, such as calibrations, share links and export jobs, in the shard
+// it is assigned to. Everything else, including tenant configuration, skill
+// tags and the shard directory itself, is stored by the wrapped DataAccess,
+// which is also the default shard.
+type ShardedDataAccess struct {
+	DataAccess
+	// Regions are the regions of the shards' clusters, keyed by the name of
+	// the shard, for enforcing data residency.
+	Regions   map[string]string
+	shards    map[string]DataAccess
+	directory ShardDirectory
+	cache     *shardCache
+	ctx       context.Context
+}
+
+// NewShardedDataAccess creates a DataAccess which routes each tenant's
+// profiles to one of the shards. Assignments are cached for the ttl.
+func NewShardedDataAccess(da DataAccess, shards map[string]DataAccess, directory ShardDirectory, ttl time.Duration) *ShardedDataAccess {
+	c := &shardCache{ttl: ttl, assignments: map[string]cachedAssignment{}, now: time.Now}
+	return &ShardedDataAccess{da, map[string]string{}, shards, directory, c, context.Background()}
+}
+
+// WithContext passes the context to the shards.
+func (da ShardedDataAccess) WithContext(ctx context.Context) DataAccess {
+	shards := make(map[string]DataAccess, len(da.shards))
+	for name, s := range da.shards {
+		shards[name] = WithContext(s, ctx)
+	}
+	return &ShardedDataAccess{WithContext(da.DataAccess, ctx), da.Regions, shards, da.directory, da.cache, ctx}
+}
+
+type cachedAssignment struct {
+	ShardAssignment
+	expires time.Time
+}
+
+type shardCache struct {
+	m           sync.Mutex
+	ttl         time.Duration
+	assignments map[string]cachedAssignment
+	now         func() time.Time
+}
+
+func (c *shardCache) get(tenant string) (ShardAssignment, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	a, ok := c.assignments[tenant]
+	if !ok || c.now().After(a.expires) {
+		return ShardAssignment{}, false
+	}
+	return a.ShardAssignment, true
+}
+
+func (c *shardCache) put(a ShardAssignment) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.assignments[a.Tenant] = cachedAssignment{a, c.now().Add(c.ttl)}
+}
+
+func (da ShardedDataAccess) assignment(tenant string) (ShardAssignment, error) {
+	if a, ok := da.cache.get(tenant); ok {
+		return a, nil
+	}
+
+	a, found, err := da.directory.GetShardAssignment(tenant)
+	if err != nil {
+		return ShardAssignment{}, err
+	}
+	if !found {
+		a = &ShardAssignment{Tenant: tenant, Shard: DefaultShard}
+	}
+	da.cache.put(*a)
+	return *a, nil
+}
+
+// Shard returns the DataAccess of the shard with the name.
+func (da ShardedDataAccess) Shard(name string) (DataAccess, error) {
+	if name == DefaultShard {
+		return da.DataAccess, nil
+	}
+	s, ok := da.shards[name]
+	if !ok {
+		return nil, fmt.Errorf("%v: %s", ErrUnknownShard, name)
+	}
+	return s, nil
+}
+
+// Shards returns the names of the configured shards, including the default.
+func (da ShardedDataAccess) Shards() []string {
+	names := []string{DefaultShard}
+	for name := range da.shards {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// inRegion returns an error if the shard's cluster isn't in the region.
+// Shards without a region are assumed to be outside every region.
+func (da ShardedDataAccess) inRegion(shard string, region string) error {
+	if region != "" && !strings.EqualFold(da.Regions[shard], region) {
+		return fmt.Errorf("%v: %s, but shard %s is not", ErrResidencyViolation, region, shard)
+	}
+	return nil
+}
+
+func (da ShardedDataAccess) reader(emailAddress string) (DataAccess, error) {
+	a, err := da.assignment(GetDomain(emailAddress))
+	if err != nil {
+		return nil, err
+	}
+	if err := da.inRegion(a.Shard, a.Region); err != nil {
+		return nil, err
+	}
+	return da.Shard(a.Shard)
+}
+
+func (da ShardedDataAccess) writer(emailAddress string) (DataAccess, error) {
+	a, err := da.assignment(GetDomain(emailAddress))
+	if err != nil {
+		return nil, err
+	}
+	if a.Moving {
+		return nil, ErrTenantMoving
+	}
+	if err := da.inRegion(a.Shard, a.Region); err != nil {
+		return nil, err
+	}
+	return da.Shard(a.Shard)
+}
+
+// ListProfiles reads from the tenant's shard.
+func (da ShardedDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
+	s, err := da.reader(emailAddress)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListProfiles(emailAddress)
+}
+
+// GetOrgTree reads from the tenant's shard.
+func (da ShardedDataAccess) GetOrgTree(domain string) (*OrgNode, error) {
+	s, err := da.reader("@" + domain)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOrgTree(domain)
+}
+
+// ListDomains lists the domains in every shard.
+func (da ShardedDataAccess) ListDomains() ([]string, error) {
+	seen := map[string]bool{}
+	var domains []string
+	for _, name := range da.Shards() {
+		s, _ := da.Shard(name)
+		d, err := s.ListDomains()
+		if err != nil {
+			return nil, fmt.Errorf("shard %s: %v", name, err)
+		}
+		for _, domain := range d {
+			if !seen[domain] {
+				seen[domain] = true
+				domains = append(domains, domain)
+			}
+		}
+	}
+	return domains, nil
+}
+
+// GetProfile reads from the tenant's shard.
+func (da ShardedDataAccess) GetProfile(emailAddress string) (*Profile, bool, error) {
+	s, err := da.reader(emailAddress)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.GetProfile(emailAddress)
+}
+
+// UpdateProfile writes to the tenant's shard.
+func (da ShardedDataAccess) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	s, err := da.writer(update.EmailAddress)
+	if err != nil {
+		return nil, err
+	}
+	return s.UpdateProfile(update)
+}
+
+// ImportProfile writes to the tenant's shard.
+func (da ShardedDataAccess) ImportProfile(p *Profile) error {
+	s, err := da.writer(p.EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.ImportProfile(p)
+}
+
+// DeleteProfile writes to the tenant's shard.
+func (da ShardedDataAccess) DeleteProfile(emailAddress string) (bool, error) {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return false, err
+	}
+	return s.DeleteProfile(emailAddress)
+}
+
+// UpdateNotificationPreferences writes to the tenant's shard.
+func (da ShardedDataAccess) UpdateNotificationPreferences(emailAddress string, p NotificationPreferences) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.UpdateNotificationPreferences(emailAddress, p)
+}
+
+// UpdateAvailabilityWindows writes to the tenant's shard.
+func (da ShardedDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.UpdateAvailabilityWindows(emailAddress, windows)
+}
+
+// AwardBadges writes to the tenant's shard.
+func (da ShardedDataAccess) AwardBadges(emailAddress string, badges []Badge) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.AwardBadges(emailAddress, badges)
+}
+
+// AddBooking writes to the tenant's shard.
+func (da ShardedDataAccess) AddBooking(emailAddress string, b Booking) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.AddBooking(emailAddress, b)
+}
+
+// RemoveBooking writes to the tenant's shard.
+func (da ShardedDataAccess) RemoveBooking(emailAddress string, id string) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.RemoveBooking(emailAddress, id)
+}
+
+// MoveTenant copies a tenant's profiles to another shard, updates the
+// directory, and then deletes the profiles from the old shard. While the
+// tenant is moving, its profiles can be read but not changed. The move
+// waits for settle after marking the tenant as moving, which must be at
+// least the directory cache ttl of every running instance, so that no
+// instance is still writing to the old shard when the profiles are copied.
+// If the move fails, the tenant stays read only until it is moved again.
+// The tenant's projects, calibrations, course completions, skill
+// suggestions, share links, tag proposals, quarantined changes and approval
+// requests are moved with its profiles.
+func (da ShardedDataAccess) MoveTenant(tenant string, to string, settle time.Duration) (moved int, err error) {
+	a, err := da.assignment(tenant)
+	if err != nil {
+		return 0, err
+	}
+	if a.Shard == to {
+		return 0, nil
+	}
+	if err := da.inRegion(to, a.Region); err != nil {
+		return 0, err
+	}
+	from, err := da.Shard(a.Shard)
+	if err != nil {
+		return 0, err
+	}
+	target, err := da.Shard(to)
+	if err != nil {
+		return 0, err
+	}
+
+	a.Moving = true
+	if err := da.directory.AssignShard(&a); err != nil {
+		return 0, err
+	}
+	log.Printf("Moving %s from shard %s to %s, waiting %v for other instances to stop writing.", tenant, a.Shard, to, settle)
+	time.Sleep(settle)
+
+	profiles, err := from.ListProfiles("@" + tenant)
+	if err != nil {
+		return 0, err
+	}
+	for i := range profiles {
+		if err := target.ImportProfile(&profiles[i]); err != nil {
+			return 0, fmt.Errorf("failed to copy %s: %v", profiles[i].EmailAddress, err)
+		}
+		// Snapshots can be taken again from the events, so only the events
+		// are copied. They're already there if an earlier move failed.
+		events, err := from.ListProfileEvents(profiles[i].EmailAddress, 0)
+		if err != nil {
+			return 0, err
+		}
+		if err := target.AppendProfileEvents(events); err != nil && err != ErrProfileEventConflict {
+			return 0, fmt.Errorf("failed to copy the events of %s: %v", profiles[i].EmailAddress, err)
+		}
+	}
+
+	if err := copyTenantData(from, target, tenant, profiles); err != nil {
+		return 0, err
+	}
+
+	moved = len(profiles)
+	done := ShardAssignment{Tenant: tenant, Shard: to, Region: a.Region}
+	if err := da.directory.AssignShard(&done); err != nil {
+		return moved, err
+	}
+	da.cache.put(done)
+
+	for _, p := range profiles {
+		if _, err := from.DeleteProfile(p.EmailAddress); err != nil {
+			return moved, fmt.Errorf("the tenant has moved, but %s couldn't be deleted from the old shard: %v", p.EmailAddress, err)
+		}
+	}
+	if err := deleteTenantData(from, tenant); err != nil {
+		return moved, fmt.Errorf("the tenant has moved, but its data couldn't be deleted from the old shard: %v", err)
+	}
+	return moved, nil
+}
+
+// copyTenantData copies the tenant's data, other than its profiles, to the
+// target shard. Export jobs aren't copied, since they expire shortly after
+// they finish. Data which is already there, because an earlier move failed,
+// is replaced.
+func copyTenantData(from DataAccess, target DataAccess, tenant string, profiles []Profile) error {
+	for _, p := range profiles {
+		calibrations, err := from.ListCalibrations(p.EmailAddress)
+		if err != nil {
+			return err
+		}
+		for i := range calibrations {
+			if err := target.SaveCalibration(&calibrations[i]); err != nil {
+				return err
+			}
+		}
+		suggestions, err := from.ListSkillSuggestions(p.EmailAddress)
+		if err != nil {
+			return err
+		}
+		for i := range suggestions {
+			if err := target.SaveSkillSuggestions(&suggestions[i]); err != nil {
+				return err
+			}
+		}
+		links, err := from.ListShareLinks(p.EmailAddress)
+		if err != nil {
+			return err
+		}
+		for i := range links {
+			if err := target.SaveShareLink(&links[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	completions, err := from.ListCourseCompletions(tenant)
+	if err != nil {
+		return err
+	}
+	for i := range completions {
+		if err := target.SaveCourseCompletion(&completions[i]); err != nil {
+			return err
+		}
+	}
+	projects, err := from.ListProjects(tenant)
+	if err != nil {
+		return err
+	}
+	for i := range projects {
+		if err := target.SaveProject(&projects[i]); err != nil {
+			return err
+		}
+	}
+	proposals, err := from.ListTagProposals(tenant)
+	if err != nil {
+		return err
+	}
+	for i := range proposals {
+		if err := target.SaveTagProposal(&proposals[i]); err != nil {
+			return err
+		}
+	}
+	changes, err := from.ListQuarantinedChanges(tenant)
+	if err != nil {
+		return err
+	}
+	for i := range changes {
+		if err := target.QuarantineChange(&changes[i]); err != nil && !mgo.IsDup(err) {
+			return err
+		}
+	}
+	approvals, err := from.ListApprovalRequests()
+	if err != nil {
+		return err
+	}
+	for i := range approvals {
+		if approvals[i].Tenant != tenant {
+			continue
+		}
+		if err := target.RequestApproval(&approvals[i]); err != nil && !mgo.IsDup(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTenantData deletes the tenant's data which isn't deleted with its
+// profiles from the old shard, once it has been moved. Tag proposals are
+// kept, since they can't be deleted, but are no longer read.
+func deleteTenantData(from DataAccess, tenant string) error {
+	projects, err := from.ListProjects(tenant)
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		if err := from.DeleteProject(p.ID); err != nil {
+			return err
+		}
+	}
+	changes, err := from.ListQuarantinedChanges(tenant)
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		if err := from.DeleteQuarantinedChange(c.ID); err != nil {
+			return err
+		}
+	}
+	approvals, err := from.ListApprovalRequests()
+	if err != nil {
+		return err
+	}
+	for _, a := range approvals {
+		if a.Tenant == tenant {
+			if err := from.DeleteApprovalRequest(a.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetResidency requires the tenant's data to be stored in the region, or
+// lifts the requirement if the region is empty. The tenant must already be
+// in a shard in the region, so it must be moved first if it isn't.
+func (da ShardedDataAccess) SetResidency(tenant string, region string) error {
+	a, found, err := da.directory.GetShardAssignment(tenant)
+	if err != nil {
+		return err
+	}
+	if !found {
+		a = &ShardAssignment{Tenant: tenant, Shard: DefaultShard}
+	}
+	region = strings.ToUpper(strings.TrimSpace(region))
+	if err := da.inRegion(a.Shard, region); err != nil {
+		return err
+	}
+	a.Region = region
+	if err := da.directory.AssignShard(a); err != nil {
+		return err
+	}
+	da.cache.put(*a)
+	return nil
+}
+
+// UpdateLearningGoals writes to the tenant's shard.
+func (da ShardedDataAccess) UpdateLearningGoals(emailAddress string, goals []LearningGoal) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.UpdateLearningGoals(emailAddress, goals)
+}
+
+// UpdateCV writes to the tenant's shard.
+func (da ShardedDataAccess) UpdateCV(emailAddress string, cv *Attachment) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.UpdateCV(emailAddress, cv)
+}
+
+// ConfirmSkills writes to the tenant's shard.
+func (da ShardedDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.ConfirmSkills(emailAddress, skills)
+}
+
+// MergeSkillTags merges the tags in every shard. The tags themselves are
+// kept in the default shard.
+func (da ShardedDataAccess) MergeSkillTags(into string, tags []string) (int, error) {
+	var merged int
+	for _, name := range da.Shards() {
+		s, _ := da.Shard(name)
+		n, err := s.MergeSkillTags(into, tags)
+		merged += n
+		if err != nil {
+			return merged, fmt.Errorf("shard %s: %v", name, err)
+		}
+	}
+	return merged, nil
+}
+
+// SyncEmployee writes to the tenant's shard.
+func (da ShardedDataAccess) SyncEmployee(e EmployeeUpdate) error {
+	s, err := da.writer(e.EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.SyncEmployee(e)
+}
+
+// ArchiveProfile writes to the tenant's shard.
+func (da ShardedDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (bool, error) {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return false, err
+	}
+	return s.ArchiveProfile(emailAddress, reason, purgeAfter)
+}
+
+// RestoreProfile writes to the tenant's shard.
+func (da ShardedDataAccess) RestoreProfile(emailAddress string) (bool, error) {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return false, err
+	}
+	return s.RestoreProfile(emailAddress)
+}
+
+// ListArchivedProfiles reads from the tenant's shard.
+func (da ShardedDataAccess) ListArchivedProfiles(domain string) ([]ArchivedProfile, error) {
+	s, err := da.reader("@" + domain)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListArchivedProfiles(domain)
+}
+
+// PurgeArchivedProfiles purges the archive of every shard.
+func (da ShardedDataAccess) PurgeArchivedProfiles(before time.Time) (int, error) {
+	var purged int
+	for _, name := range da.Shards() {
+		s, _ := da.Shard(name)
+		n, err := s.PurgeArchivedProfiles(before)
+		purged += n
+		if err != nil {
+			return purged, fmt.Errorf("shard %s: %v", name, err)
+		}
+	}
+	return purged, nil
+}
+
+// FindProfiles reads from the tenant's shard.
+func (da ShardedDataAccess) FindProfiles(domain string, f ProfileFilter) ([]Profile, error) {
+	s, err := da.reader("@" + domain)
+	if err != nil {
+		return nil, err
+	}
+	return s.FindProfiles(domain, f)
+}
+
+// EnsureIndexes creates the indexes on every shard.
+func (da ShardedDataAccess) EnsureIndexes() error {
+	for _, name := range da.Shards() {
+		s, _ := da.Shard(name)
+		if err := s.EnsureIndexes(); err != nil {
+			return fmt.Errorf("shard %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// UpdateCustomFields writes to the tenant's shard.
+func (da ShardedDataAccess) UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.UpdateCustomFields(emailAddress, values)
+}
+
+// UpdateLanguages writes to the tenant's shard.
+func (da ShardedDataAccess) UpdateLanguages(emailAddress string, languages []Language) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.UpdateLanguages(emailAddress, languages)
+}
+
+// UpdateClearance writes to the tenant's shard.
+func (da ShardedDataAccess) UpdateClearance(emailAddress string, cl *Clearance) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.UpdateClearance(emailAddress, cl)
+}
+
+// UpdateWorkLocation writes to the tenant's shard.
+func (da ShardedDataAccess) UpdateWorkLocation(emailAddress string, l *WorkLocation) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.UpdateWorkLocation(emailAddress, l)
+}
+
+// FindProfilesNear reads from the tenant's shard.
+func (da ShardedDataAccess) FindProfilesNear(domain string, near Coordinates, radiusKm float64) ([]Profile, error) {
+	s, err := da.reader("@" + domain)
+	if err != nil {
+		return nil, err
+	}
+	return s.FindProfilesNear(domain, near, radiusKm)
+}
+
+// UpdateWorkingHours writes to the tenant's shard.
+func (da ShardedDataAccess) UpdateWorkingHours(emailAddress string, h *WorkingHours) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.UpdateWorkingHours(emailAddress, h)
+}
+
+// RecordConsents writes to the tenant's shard.
+func (da ShardedDataAccess) RecordConsents(emailAddress string, consents []Consent) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.RecordConsents(emailAddress, consents)
+}
+
+// RunReport reads from the tenant's shard.
+func (da ShardedDataAccess) RunReport(d ReportDefinition, consent ConsentSettings) (*ReportResult, error) {
+	s, err := da.reader("@" + d.Domain)
+	if err != nil {
+		return nil, err
+	}
+	return s.RunReport(d, consent)
+}
+
+// SaveReportSnapshot writes to the tenant's shard, so that reports stay in
+// the tenant's region.
+func (da ShardedDataAccess) SaveReportSnapshot(snapshot *ReportSnapshot) error {
+	s, err := da.writer("@" + snapshot.Domain)
+	if err != nil {
+		return err
+	}
+	return s.SaveReportSnapshot(snapshot)
+}
+
+// GetReportSnapshot reads from the tenant's shard.
+func (da ShardedDataAccess) GetReportSnapshot(domain string, report string) (*ReportSnapshot, bool, error) {
+	s, err := da.reader("@" + domain)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.GetReportSnapshot(domain, report)
+}
+
+// SaveImportPreview writes to the tenant's shard, since previews hold the
+// imported skills.
+func (da ShardedDataAccess) SaveImportPreview(p *ImportPreview) error {
+	s, err := da.writer("@" + p.Domain)
+	if err != nil {
+		return err
+	}
+	return s.SaveImportPreview(p)
+}
+
+// TakeImportPreview removes the preview from the tenant's shard.
+func (da ShardedDataAccess) TakeImportPreview(domain string, id string) (*ImportPreview, bool, error) {
+	s, err := da.writer("@" + domain)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.TakeImportPreview(domain, id)
+}
+
+// ErrCrossShardMerge is returned when merging profiles held by different
+// shards, since the duplicate's data would leave its shard.
+var ErrCrossShardMerge = errors.New("dataaccess: profiles on different shards can't be merged")
+
+// MergeProfiles merges the profiles on their tenants' shard.
+func (da ShardedDataAccess) MergeProfiles(primary string, duplicate string) (*Profile, bool, error) {
+	a, err := da.assignment(GetDomain(primary))
+	if err != nil {
+		return nil, false, err
+	}
+	b, err := da.assignment(GetDomain(duplicate))
+	if err != nil {
+		return nil, false, err
+	}
+	if a.Shard != b.Shard {
+		return nil, false, ErrCrossShardMerge
+	}
+	if _, err := da.writer(duplicate); err != nil {
+		return nil, false, err
+	}
+	s, err := da.writer(primary)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.MergeProfiles(primary, duplicate)
+}
+
+// FindProfileByExternalID reads from the tenant's shard.
+func (da ShardedDataAccess) FindProfileByExternalID(domain string, system string, id string) (*Profile, bool, error) {
+	s, err := da.reader("@" + domain)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.FindProfileByExternalID(domain, system, id)
+}
+
+// UpdateExternalIDs writes to the tenant's shard.
+func (da ShardedDataAccess) UpdateExternalIDs(emailAddress string, ids map[string]string) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.UpdateExternalIDs(emailAddress, ids)
+}
+
+// AppendProfileEvents writes to the tenant's shard. The events must all be
+// for people in the same tenant.
+func (da ShardedDataAccess) AppendProfileEvents(events []ProfileEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	s, err := da.writer(events[0].EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.AppendProfileEvents(events)
+}
+
+// ListProfileEvents reads from the tenant's shard.
+func (da ShardedDataAccess) ListProfileEvents(emailAddress string, after int) ([]ProfileEvent, error) {
+	s, err := da.reader(emailAddress)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListProfileEvents(emailAddress, after)
+}
+
+// SaveProfileSnapshot writes to the tenant's shard.
+func (da ShardedDataAccess) SaveProfileSnapshot(snapshot *ProfileSnapshot) error {
+	s, err := da.writer(snapshot.EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.SaveProfileSnapshot(snapshot)
+}
+
+// GetProfileSnapshot reads from the tenant's shard.
+func (da ShardedDataAccess) GetProfileSnapshot(emailAddress string, at time.Time) (*ProfileSnapshot, bool, error) {
+	s, err := da.reader(emailAddress)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.GetProfileSnapshot(emailAddress, at)
+}
+
+// ProjectProfile writes to the tenant's shard.
+func (da ShardedDataAccess) ProjectProfile(snapshot *ProfileSnapshot) error {
+	s, err := da.writer(snapshot.EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.ProjectProfile(snapshot)
+}
+
+// SaveSearchDocument writes to the tenant's shard.
+func (da ShardedDataAccess) SaveSearchDocument(d *SearchDocument) error {
+	s, err := da.writer(d.EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.SaveSearchDocument(d)
+}
+
+// GetSearchDocument reads from the tenant's shard.
+func (da ShardedDataAccess) GetSearchDocument(emailAddress string) (*SearchDocument, bool, error) {
+	s, err := da.reader(emailAddress)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.GetSearchDocument(emailAddress)
+}
+
+// RemoveSearchDocument writes to the tenant's shard.
+func (da ShardedDataAccess) RemoveSearchDocument(emailAddress string) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.RemoveSearchDocument(emailAddress)
+}
+
+// SearchDocuments reads from the tenant's shard.
+func (da ShardedDataAccess) SearchDocuments(domain string, q SearchQuery) ([]SearchDocument, error) {
+	s, err := da.reader("@" + domain)
+	if err != nil {
+		return nil, err
+	}
+	return s.SearchDocuments(domain, q)
+}
+
+// SaveTeamSummary writes to the tenant's shard.
+func (da ShardedDataAccess) SaveTeamSummary(summary *TeamSummary) error {
+	s, err := da.writer(summary.Manager)
+	if err != nil {
+		return err
+	}
+	return s.SaveTeamSummary(summary)
+}
+
+// GetTeamSummary reads from the tenant's shard.
+func (da ShardedDataAccess) GetTeamSummary(manager string) (*TeamSummary, bool, error) {
+	s, err := da.reader(manager)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.GetTeamSummary(manager)
+}
+
+// SaveProfileSummary writes to the tenant's shard.
+func (da ShardedDataAccess) SaveProfileSummary(summary *ProfileSummary) error {
+	s, err := da.writer(summary.EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.SaveProfileSummary(summary)
+}
+
+// GetProfileSummary reads from the tenant's shard.
+func (da ShardedDataAccess) GetProfileSummary(emailAddress string) (*ProfileSummary, bool, error) {
+	s, err := da.reader(emailAddress)
+	if err != nil {
+		return nil, false, err
+	}
+	return s.GetProfileSummary(emailAddress)
+}
+
+// ListProfileSummaries reads from the tenant's shard.
+func (da ShardedDataAccess) ListProfileSummaries(domain string) ([]ProfileSummary, error) {
+	s, err := da.reader("@" + domain)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListProfileSummaries(domain)
+}
+
+// RemoveProfileSummary writes to the tenant's shard.
+func (da ShardedDataAccess) RemoveProfileSummary(emailAddress string) error {
+	s, err := da.writer(emailAddress)
+	if err != nil {
+		return err
+	}
+	return s.RemoveProfileSummary(emailAddress)
+}
+
+// ShardOf returns the name of the shard which holds the tenant's data, or
+// an error if the shard is outside the region the tenant's data must be
+// stored in. It is used to keep the tenant's files, and its audit entries,
+// in the same cluster as its profiles.
+func (da ShardedDataAccess) ShardOf(tenant string) (string, error) {
+	a, err := da.assignment(strings.ToLower(tenant))
+	if err != nil {
+		return "", err
+	}
+	if err := da.inRegion(a.Shard, a.Region); err != nil {
+		return "", err
+	}
+	return a.Shard, nil
+}
+
+// eachShard calls f with each shard until it returns true, for finding the
+// documents which are only identified by an ID, and so could be in any
+// shard.
+func (da ShardedDataAccess) eachShard(f func(s DataAccess) (bool, error)) error {
+	for _, name := range da.Shards() {
+		s, _ := da.Shard(name)
+		done, err := f(s)
+		if err != nil {
+			return fmt.Errorf("shard %s: %v", name, err)
+		}
+		if done {
+			return nil
+		}
+	}
+	return nil
+}
+
+// QuarantineChange writes to the tenant's shard.
+func (da ShardedDataAccess) QuarantineChange(c *QuarantinedChange) error {
+	s, err := da.writer("@" + c.Tenant)
+	if err != nil {
+		return err
+	}
+	return s.QuarantineChange(c)
+}
+
+// ListQuarantinedChanges reads from the tenant's shard, or from every shard
+// if the domain is empty.
+func (da ShardedDataAccess) ListQuarantinedChanges(domain string) ([]QuarantinedChange, error) {
+	if domain != "" {
+		s, err := da.reader("@" + domain)
+		if err != nil {
+			return nil, err
+		}
+		return s.ListQuarantinedChanges(domain)
+	}
+	var changes []QuarantinedChange
+	err := da.eachShard(func(s DataAccess) (bool, error) {
+		c, err := s.ListQuarantinedChanges(domain)
+		changes = append(changes, c...)
+		return false, err
+	})
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Time.Before(changes[j].Time) })
+	return changes, err
+}
+
+// GetQuarantinedChange reads from the shard which holds the change.
+func (da ShardedDataAccess) GetQuarantinedChange(id string) (c *QuarantinedChange, found bool, err error) {
+	err = da.eachShard(func(s DataAccess) (bool, error) {
+		c, found, err = s.GetQuarantinedChange(id)
+		return found, err
+	})
+	return
+}
+
+// DeleteQuarantinedChange writes to the shard which holds the change.
+func (da ShardedDataAccess) DeleteQuarantinedChange(id string) error {
+	c, found, err := da.GetQuarantinedChange(id)
+	if err != nil || !found {
+		return err
+	}
+	s, err := da.writer("@" + c.Tenant)
+	if err != nil {
+		return err
+	}
+	return s.DeleteQuarantinedChange(id)
+}
+
+// approvalShard returns the shard of the tenant the action affects, or the
+// default shard if it affects every tenant.
+func (da ShardedDataAccess) approvalShard(a *ApprovalRequest) (DataAccess, error) {
+	if a.Tenant == "" {
+		return da.DataAccess, nil
+	}
+	return da.writer("@" + a.Tenant)
+}
+
+// RequestApproval writes to the shard of the tenant the action affects.
+func (da ShardedDataAccess) RequestApproval(a *ApprovalRequest) error {
+	s, err := da.approvalShard(a)
+	if err != nil {
+		return err
+	}
+	return s.RequestApproval(a)
+}
+
+// ListApprovalRequests reads from every shard.
+func (da ShardedDataAccess) ListApprovalRequests() ([]ApprovalRequest, error) {
+	var requests []ApprovalRequest
+	err := da.eachShard(func(s DataAccess) (bool, error) {
+		r, err := s.ListApprovalRequests()
+		requests = append(requests, r...)
+		return false, err
+	})
+	sort.SliceStable(requests, func(i, j int) bool { return requests[i].Requested.Before(requests[j].Requested) })
+	return requests, err
+}
+
+// GetApprovalRequest reads from the shard which holds the request.
+func (da ShardedDataAccess) GetApprovalRequest(id string) (a *ApprovalRequest, found bool, err error) {
+	err = da.eachShard(func(s DataAccess) (bool, error) {
+		a, found, err = s.GetApprovalRequest(id)
+		return found, err
+	})
+	return
+}
+
+// DeleteApprovalRequest writes to the shard which holds the request.
+func (da ShardedDataAccess) DeleteApprovalRequest(id string) error {
+	a, found, err := da.GetApprovalRequest(id)
+	if err != nil || !found {
+		return err
+	}
+	s, err := da.approvalShard(a)
+	if err != nil {
+		return err
+	}
+	return s.DeleteApprovalRequest(id)
+}
+
+// SaveCalibration writes to the tenant's shard.
+func (da ShardedDataAccess) SaveCalibration(c *Calibration) error {
+	s, err := da.writer(c.EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.SaveCalibration(c)
+}
+
+// ListCalibrations reads from the tenant's shard.
+func (da ShardedDataAccess) ListCalibrations(emailAddress string) ([]Calibration, error) {
+	s, err := da.reader(emailAddress)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListCalibrations(emailAddress)
+}
+
+// GetCalibration reads from the shard which holds the calibration.
+func (da ShardedDataAccess) GetCalibration(id string) (c *Calibration, found bool, err error) {
+	err = da.eachShard(func(s DataAccess) (bool, error) {
+		c, found, err = s.GetCalibration(id)
+		return found, err
+	})
+	return
+}
+
+// SaveProject writes to the tenant's shard.
+func (da ShardedDataAccess) SaveProject(p *Project) error {
+	s, err := da.writer("@" + p.Domain)
+	if err != nil {
+		return err
+	}
+	return s.SaveProject(p)
+}
+
+// ListProjects reads from the tenant's shard.
+func (da ShardedDataAccess) ListProjects(domain string) ([]Project, error) {
+	s, err := da.reader("@" + domain)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListProjects(domain)
+}
+
+// GetProject reads from the shard which holds the project.
+func (da ShardedDataAccess) GetProject(id string) (p *Project, found bool, err error) {
+	err = da.eachShard(func(s DataAccess) (bool, error) {
+		p, found, err = s.GetProject(id)
+		return found, err
+	})
+	return
+}
+
+// DeleteProject writes to the shard which holds the project.
+func (da ShardedDataAccess) DeleteProject(id string) error {
+	p, found, err := da.GetProject(id)
+	if err != nil || !found {
+		return err
+	}
+	s, err := da.writer("@" + p.Domain)
+	if err != nil {
+		return err
+	}
+	return s.DeleteProject(id)
+}
+
+// SaveCourseCompletion writes to the tenant's shard.
+func (da ShardedDataAccess) SaveCourseCompletion(cc *CourseCompletion) error {
+	s, err := da.writer(cc.EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.SaveCourseCompletion(cc)
+}
+
+// ListCourseCompletions reads from the tenant's shard.
+func (da ShardedDataAccess) ListCourseCompletions(domain string) ([]CourseCompletion, error) {
+	s, err := da.reader("@" + domain)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListCourseCompletions(domain)
+}
+
+// GetCourseCompletion reads from the shard which holds the completion.
+func (da ShardedDataAccess) GetCourseCompletion(id string) (cc *CourseCompletion, found bool, err error) {
+	err = da.eachShard(func(s DataAccess) (bool, error) {
+		cc, found, err = s.GetCourseCompletion(id)
+		return found, err
+	})
+	return
+}
+
+// SaveTagProposal writes to the tenant's shard.
+func (da ShardedDataAccess) SaveTagProposal(p *TagProposal) error {
+	s, err := da.writer("@" + p.Domain)
+	if err != nil {
+		return err
+	}
+	return s.SaveTagProposal(p)
+}
+
+// ListTagProposals reads from the tenant's shard.
+func (da ShardedDataAccess) ListTagProposals(domain string) ([]TagProposal, error) {
+	s, err := da.reader("@" + domain)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListTagProposals(domain)
+}
+
+// GetTagProposal reads from the shard which holds the proposal.
+func (da ShardedDataAccess) GetTagProposal(id string) (p *TagProposal, found bool, err error) {
+	err = da.eachShard(func(s DataAccess) (bool, error) {
+		p, found, err = s.GetTagProposal(id)
+		return found, err
+	})
+	return
+}
+
+// SaveSkillSuggestions writes to the tenant's shard.
+func (da ShardedDataAccess) SaveSkillSuggestions(ss *SkillSuggestions) error {
+	s, err := da.writer(ss.EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.SaveSkillSuggestions(ss)
+}
+
+// ListSkillSuggestions reads from the tenant's shard.
+func (da ShardedDataAccess) ListSkillSuggestions(emailAddress string) ([]SkillSuggestions, error) {
+	s, err := da.reader(emailAddress)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListSkillSuggestions(emailAddress)
+}
+
+// GetSkillSuggestions reads from the shard which holds the suggestions.
+func (da ShardedDataAccess) GetSkillSuggestions(id string) (ss *SkillSuggestions, found bool, err error) {
+	err = da.eachShard(func(s DataAccess) (bool, error) {
+		ss, found, err = s.GetSkillSuggestions(id)
+		return found, err
+	})
+	return
+}
+
+// SaveShareLink writes to the tenant's shard.
+func (da ShardedDataAccess) SaveShareLink(l *ShareLink) error {
+	s, err := da.writer(l.EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.SaveShareLink(l)
+}
+
+// ListShareLinks reads from the tenant's shard.
+func (da ShardedDataAccess) ListShareLinks(emailAddress string) ([]ShareLink, error) {
+	s, err := da.reader(emailAddress)
+	if err != nil {
+		return nil, err
+	}
+	return s.ListShareLinks(emailAddress)
+}
+
+// GetShareLink reads from the shard which holds the link.
+func (da ShardedDataAccess) GetShareLink(id string) (l *ShareLink, found bool, err error) {
+	err = da.eachShard(func(s DataAccess) (bool, error) {
+		l, found, err = s.GetShareLink(id)
+		return found, err
+	})
+	return
+}
+
+// DeleteShareLink writes to the shard which holds the link.
+func (da ShardedDataAccess) DeleteShareLink(id string) error {
+	l, found, err := da.GetShareLink(id)
+	if err != nil || !found {
+		return err
+	}
+	s, err := da.writer(l.EmailAddress)
+	if err != nil {
+		return err
+	}
+	return s.DeleteShareLink(id)
+}
+
+// SaveExportJob writes to the tenant's shard, since the job holds the
+// exported file's location and the query which selected the profiles.
+func (da ShardedDataAccess) SaveExportJob(j *ExportJob) error {
+	s, err := da.writer("@" + j.Domain)
+	if err != nil {
+		return err
+	}
+	return s.SaveExportJob(j)
+}
+
+// GetExportJob reads from the shard which holds the job.
+func (da ShardedDataAccess) GetExportJob(id string) (j *ExportJob, found bool, err error) {
+	err = da.eachShard(func(s DataAccess) (bool, error) {
+		j, found, err = s.GetExportJob(id)
+		return found, err
+	})
+	return
+}
+
+// ClaimExportJob claims the oldest waiting job of the first shard which has
+// one.
+func (da ShardedDataAccess) ClaimExportJob(now time.Time) (j *ExportJob, found bool, err error) {
+	err = da.eachShard(func(s DataAccess) (bool, error) {
+		j, found, err = s.ClaimExportJob(now)
+		return found, err
+	})
+	return
+}
+
+// PurgeExportJobs purges the jobs of every shard.
+func (da ShardedDataAccess) PurgeExportJobs(before time.Time) ([]ExportJob, error) {
+	var purged []ExportJob
+	err := da.eachShard(func(s DataAccess) (bool, error) {
+		j, err := s.PurgeExportJobs(before)
+		purged = append(purged, j...)
+		return false, err
+	})
+	return purged, err
+}
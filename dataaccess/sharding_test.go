@@ -0,0 +1,317 @@
+package dataaccess
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+type memoryShardDirectory map[string]ShardAssignment
+
+func (d memoryShardDirectory) GetShardAssignment(tenant string) (*ShardAssignment, bool, error) {
+	a, ok := d[tenant]
+	return &a, ok, nil
+}
+
+func (d memoryShardDirectory) AssignShard(a *ShardAssignment) error {
+	d[a.Tenant] = *a
+	return nil
+}
+
+func (d memoryShardDirectory) ListShardAssignments() ([]ShardAssignment, error) {
+	var op []ShardAssignment
+	for _, a := range d {
+		op = append(op, a)
+	}
+	return op, nil
+}
+
+// memoryProfiles stores profiles in memory.
+type memoryProfiles struct {
+	DataAccess
+	profiles  map[string]Profile
+	events    []ProfileEvent
+	snapshots []ProfileSnapshot
+	projects  map[string]Project
+	links     map[string]ShareLink
+}
+
+func newMemoryProfiles(emailAddresses ...string) *memoryProfiles {
+	m := &memoryProfiles{profiles: map[string]Profile{}, projects: map[string]Project{}, links: map[string]ShareLink{}}
+	for _, e := range emailAddresses {
+		m.profiles[e] = Profile{EmailAddress: e, Domain: GetDomain(e)}
+	}
+	return m
+}
+
+func (m *memoryProfiles) ListProfiles(emailAddress string) ([]Profile, error) {
+	var op []Profile
+	for _, p := range m.profiles {
+		if p.Domain == GetDomain(emailAddress) {
+			op = append(op, p)
+		}
+	}
+	return op, nil
+}
+
+func (m *memoryProfiles) ListDomains() ([]string, error) {
+	seen := map[string]bool{}
+	var op []string
+	for _, p := range m.profiles {
+		if !seen[p.Domain] {
+			seen[p.Domain] = true
+			op = append(op, p.Domain)
+		}
+	}
+	return op, nil
+}
+
+func (m *memoryProfiles) GetProfile(emailAddress string) (*Profile, bool, error) {
+	p, ok := m.profiles[emailAddress]
+	return &p, ok, nil
+}
+
+func (m *memoryProfiles) UpdateProfile(update *ProfileUpdate) (*Profile, error) {
+	p := Profile{EmailAddress: update.EmailAddress, Domain: GetDomain(update.EmailAddress), Skills: update.Skills, Availability: update.Availability}
+	m.profiles[p.EmailAddress] = p
+	return &p, nil
+}
+
+func (m *memoryProfiles) ImportProfile(p *Profile) error {
+	m.profiles[p.EmailAddress] = *p
+	return nil
+}
+
+func (m *memoryProfiles) DeleteProfile(emailAddress string) (bool, error) {
+	_, ok := m.profiles[emailAddress]
+	delete(m.profiles, emailAddress)
+	return ok, nil
+}
+
+func (m *memoryProfiles) SaveProject(p *Project) error {
+	m.projects[p.ID] = *p
+	return nil
+}
+
+func (m *memoryProfiles) ListProjects(domain string) ([]Project, error) {
+	var op []Project
+	for _, p := range m.projects {
+		if p.Domain == domain {
+			op = append(op, p)
+		}
+	}
+	return op, nil
+}
+
+func (m *memoryProfiles) GetProject(id string) (*Project, bool, error) {
+	p, ok := m.projects[id]
+	return &p, ok, nil
+}
+
+func (m *memoryProfiles) DeleteProject(id string) error {
+	delete(m.projects, id)
+	return nil
+}
+
+func (m *memoryProfiles) SaveShareLink(l *ShareLink) error {
+	m.links[l.ID] = *l
+	return nil
+}
+
+func (m *memoryProfiles) ListShareLinks(emailAddress string) ([]ShareLink, error) {
+	var op []ShareLink
+	for _, l := range m.links {
+		if l.EmailAddress == emailAddress {
+			op = append(op, l)
+		}
+	}
+	return op, nil
+}
+
+func (m *memoryProfiles) GetShareLink(id string) (*ShareLink, bool, error) {
+	l, ok := m.links[id]
+	return &l, ok, nil
+}
+
+func (m *memoryProfiles) ListCalibrations(emailAddress string) ([]Calibration, error) {
+	return nil, nil
+}
+
+func (m *memoryProfiles) ListSkillSuggestions(emailAddress string) ([]SkillSuggestions, error) {
+	return nil, nil
+}
+
+func (m *memoryProfiles) ListCourseCompletions(domain string) ([]CourseCompletion, error) {
+	return nil, nil
+}
+
+func (m *memoryProfiles) ListTagProposals(domain string) ([]TagProposal, error) {
+	return nil, nil
+}
+
+func (m *memoryProfiles) ListQuarantinedChanges(domain string) ([]QuarantinedChange, error) {
+	return nil, nil
+}
+
+func (m *memoryProfiles) ListApprovalRequests() ([]ApprovalRequest, error) {
+	return nil, nil
+}
+
+func TestThatProfilesAreRoutedToTheTenantsShard(t *testing.T) {
+	global := newMemoryProfiles("a@github.com")
+	apac := newMemoryProfiles("b@example.com.au")
+	directory := memoryShardDirectory{"example.com.au": {Tenant: "example.com.au", Shard: "apac"}}
+	da := NewShardedDataAccess(global, map[string]DataAccess{"apac": apac}, directory, time.Minute)
+
+	if _, found, _ := da.GetProfile("b@example.com.au"); !found {
+		t.Errorf("Expected the profile to be read from the tenant's shard.")
+	}
+	if _, found, _ := da.GetProfile("a@github.com"); !found {
+		t.Errorf("Expected profiles of unassigned tenants to be read from the default shard.")
+	}
+
+	da.UpdateProfile(&ProfileUpdate{EmailAddress: "c@example.com.au"})
+	if _, ok := apac.profiles["c@example.com.au"]; !ok {
+		t.Errorf("Expected the new profile to be written to the tenant's shard.")
+	}
+
+	domains, _ := da.ListDomains()
+	sort.Strings(domains)
+	if len(domains) != 2 || domains[0] != "example.com.au" || domains[1] != "github.com" {
+		t.Errorf("Expected the domains of every shard, but got %v.", domains)
+	}
+}
+
+func TestThatTheDataAboutATenantsPeopleIsRoutedToItsShard(t *testing.T) {
+	global := newMemoryProfiles("a@github.com")
+	eu := newMemoryProfiles("b@example.de")
+	directory := memoryShardDirectory{"example.de": {Tenant: "example.de", Shard: "eu"}}
+	da := NewShardedDataAccess(global, map[string]DataAccess{"eu": eu}, directory, time.Minute)
+
+	da.SaveShareLink(&ShareLink{ID: "link", EmailAddress: "b@example.de"})
+	da.SaveProject(&Project{ID: "project", Domain: "example.de"})
+
+	if _, ok := eu.links["link"]; !ok || len(global.links) != 0 {
+		t.Errorf("Expected the share link to be written to the tenant's shard.")
+	}
+	if _, found, _ := da.GetShareLink("link"); !found {
+		t.Errorf("Expected the share link to be found in the tenant's shard by its ID.")
+	}
+	if err := da.DeleteProject("project"); err != nil || len(eu.projects) != 0 {
+		t.Errorf("Expected the project to be deleted from the tenant's shard, but got %v.", err)
+	}
+}
+
+func TestThatTenantsCanBeMovedBetweenShards(t *testing.T) {
+	global := newMemoryProfiles("a@github.com", "b@github.com", "c@example.com")
+	global.projects["project"] = Project{ID: "project", Domain: "github.com"}
+	global.links["link"] = ShareLink{ID: "link", EmailAddress: "a@github.com"}
+	apac := newMemoryProfiles()
+	directory := memoryShardDirectory{}
+	da := NewShardedDataAccess(global, map[string]DataAccess{"apac": apac}, directory, time.Minute)
+
+	moved, err := da.MoveTenant("github.com", "apac", 0)
+	if err != nil || moved != 2 {
+		t.Fatalf("Expected 2 profiles to be moved, but got %d (%v).", moved, err)
+	}
+
+	if len(apac.profiles) != 2 || len(global.profiles) != 1 {
+		t.Errorf("Expected the profiles to be moved to the new shard, and deleted from the old one, but there were %d and %d.", len(apac.profiles), len(global.profiles))
+	}
+	if a := directory["github.com"]; a.Shard != "apac" || a.Moving {
+		t.Errorf("Expected the directory to assign the tenant to the new shard, but got %+v.", a)
+	}
+	if _, found, _ := da.GetProfile("a@github.com"); !found {
+		t.Errorf("Expected the moved profile to be read from the new shard.")
+	}
+	if len(apac.projects) != 1 || len(global.projects) != 0 {
+		t.Errorf("Expected the tenant's projects to be moved to the new shard.")
+	}
+	if _, ok := apac.links["link"]; !ok {
+		t.Errorf("Expected the share links of the tenant's people to be copied to the new shard.")
+	}
+}
+
+func TestThatTenantsCantBeChangedWhileMoving(t *testing.T) {
+	global := newMemoryProfiles("a@github.com")
+	directory := memoryShardDirectory{"github.com": {Tenant: "github.com", Shard: DefaultShard, Moving: true}}
+	da := NewShardedDataAccess(global, nil, directory, time.Minute)
+
+	if _, err := da.UpdateProfile(&ProfileUpdate{EmailAddress: "a@github.com"}); err != ErrTenantMoving {
+		t.Errorf("Expected ErrTenantMoving, but got %v.", err)
+	}
+	if _, found, err := da.GetProfile("a@github.com"); !found || err != nil {
+		t.Errorf("Expected profiles to be readable while the tenant is moving, but got %v.", err)
+	}
+}
+
+func TestThatTenantsCantBeMovedToUnknownShards(t *testing.T) {
+	da := NewShardedDataAccess(newMemoryProfiles("a@github.com"), nil, memoryShardDirectory{}, time.Minute)
+
+	if _, err := da.MoveTenant("github.com", "mars", 0); err == nil {
+		t.Errorf("Expected an error moving to a shard which isn't configured.")
+	}
+}
+
+func TestThatShardsAreParsed(t *testing.T) {
+	shards, err := ParseShards("apac=mongodb://mongo-apac:27017, eu=mongodb://mongo-eu:27017/?replicaSet=eu")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+	if len(shards) != 2 || shards["apac"] != "mongodb://mongo-apac:27017" || shards["eu"] != "mongodb://mongo-eu:27017/?replicaSet=eu" {
+		t.Errorf("Expected the apac and eu shards, but got %v.", shards)
+	}
+
+	for _, spec := range []string{"apac", "=mongodb://mongo", "default=mongodb://mongo"} {
+		if _, err := ParseShards(spec); err == nil {
+			t.Errorf("For '%s', expected an error.", spec)
+		}
+	}
+}
+
+func TestThatDataResidencyIsEnforced(t *testing.T) {
+	global := newMemoryProfiles("a@github.com")
+	eu := newMemoryProfiles()
+	directory := memoryShardDirectory{}
+	da := NewShardedDataAccess(global, map[string]DataAccess{"eu": eu, "eu-old": newMemoryProfiles()}, directory, time.Minute)
+	da.Regions = map[string]string{DefaultShard: "US", "eu": "EU"}
+
+	if err := da.SetResidency("github.com", "eu"); err == nil {
+		t.Errorf("Expected the residency to be refused while the tenant is in the US.")
+	}
+	if _, err := da.MoveTenant("github.com", "eu", 0); err != nil {
+		t.Fatalf("Expected the tenant to be moved, but got %v.", err)
+	}
+	if err := da.SetResidency("github.com", "eu"); err != nil || directory["github.com"].Region != "EU" {
+		t.Fatalf("Expected the residency to be set, but got %+v (%v).", directory["github.com"], err)
+	}
+	if _, err := da.MoveTenant("github.com", DefaultShard, 0); err == nil {
+		t.Errorf("Expected the tenant not to be moved out of the EU.")
+	}
+	if _, err := da.MoveTenant("github.com", "eu-old", 0); err == nil {
+		t.Errorf("Expected the tenant not to be moved to a shard without a region.")
+	}
+	if _, found, err := da.GetProfile("a@github.com"); !found || err != nil {
+		t.Errorf("Expected the profile to be read from the EU, but got %v.", err)
+	}
+
+	// A shard whose region is changed by misconfiguration is refused,
+	// rather than serving the tenant from the wrong region.
+	da.Regions["eu"] = "US"
+	if _, _, err := da.GetProfile("a@github.com"); err == nil {
+		t.Errorf("Expected reads outside the region to be refused.")
+	}
+	if _, err := da.UpdateProfile(&ProfileUpdate{EmailAddress: "a@github.com"}); err == nil {
+		t.Errorf("Expected writes outside the region to be refused.")
+	}
+}
+
+func TestThatShardRegionsAreParsed(t *testing.T) {
+	regions, err := ParseShardRegions("default=us, eu=EU")
+	if err != nil || len(regions) != 2 || regions[DefaultShard] != "US" || regions["eu"] != "EU" {
+		t.Errorf("Expected the regions of both shards, but got %v (%v).", regions, err)
+	}
+	if _, err := ParseShardRegions("eu"); err == nil {
+		t.Errorf("Expected an error for a shard without a region.")
+	}
+}
@@ -0,0 +1,124 @@
+package dataaccess
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MaxShareLinkDays is the longest a share link can be used for.
+const MaxShareLinkDays = 90
+
+// A ShareLink lets people without an account see the skills and
+// certifications on a profile until it expires. Only a hash of the link's
+// token is stored, so the links can't be recovered from the database.
+type ShareLink struct {
+	// ID is the hash of the token.
+	ID           string    `bson:"_id" json:"id"`
+	EmailAddress string    `json:"emailAddress"`
+	Created      time.Time `json:"created"`
+	Expires      time.Time `json:"expires"`
+}
+
+// NewShareLink creates a link to the person's profile which expires after
+// the number of days, and returns the token which identifies it.
+func NewShareLink(emailAddress string, days int, at time.Time) (ShareLink, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return ShareLink{}, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	at = at.UTC().Truncate(time.Millisecond)
+	return ShareLink{
+		ID:           ShareLinkID(token),
+		EmailAddress: strings.ToLower(emailAddress),
+		Created:      at,
+		Expires:      at.AddDate(0, 0, days),
+	}, token, nil
+}
+
+// ShareLinkID returns the ID of the link with the token.
+func ShareLinkID(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// Expired returns true if the link can no longer be used at the time.
+func (l ShareLink) Expired(at time.Time) bool {
+	return !at.Before(l.Expires)
+}
+
+// SaveShareLink stores a share link.
+func (da MongoDataAccess) SaveShareLink(l *ShareLink) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	_, err = session.DB(da.databaseName).C("sharelinks").UpsertId(l.ID, l)
+	return err
+}
+
+// ListShareLinks lists the person's share links which haven't expired,
+// newest first.
+func (da MongoDataAccess) ListShareLinks(emailAddress string) ([]ShareLink, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var results []ShareLink
+	err = session.DB(da.databaseName).C("sharelinks").
+		Find(bson.M{"emailaddress": strings.ToLower(emailAddress), "expires": bson.M{"$gt": time.Now().UTC()}}).
+		Sort("-created").
+		All(&results)
+	return results, err
+}
+
+// GetShareLink returns a share link.
+func (da MongoDataAccess) GetShareLink(id string) (*ShareLink, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	l := &ShareLink{}
+	err = session.DB(da.databaseName).C("sharelinks").FindId(id).One(l)
+
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return l, true, nil
+}
+
+// DeleteShareLink revokes a share link.
+func (da MongoDataAccess) DeleteShareLink(id string) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(da.databaseName).C("sharelinks").RemoveId(id)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
@@ -1,5 +1,7 @@
 package dataaccess
 
+import "time"
+
 // Skill stores information about skills.
 type Skill struct {
 	Skill string `json:"skill"`
@@ -7,4 +9,6 @@ type Skill struct {
 	Level DreyfusLevel `json:"level"`
 	// Interest represents the answer to the question "You are interested in using this skill for work."
 	Interest LikertScale `json:"interest"`
+	// Confirmed is when the person last set or re-confirmed the level.
+	Confirmed time.Time `json:"confirmed,omitempty"`
 }
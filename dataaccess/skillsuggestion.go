@@ -0,0 +1,132 @@
+package dataaccess
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A SuggestionStatus is the state of skills suggested from a CV.
+type SuggestionStatus string
+
+// The states of skill suggestions.
+const (
+	SuggestionPending   SuggestionStatus = "pending"
+	SuggestionAccepted  SuggestionStatus = "accepted"
+	SuggestionDismissed SuggestionStatus = "dismissed"
+)
+
+// SkillSuggestions are the skills found in a person's CV which aren't on
+// their profile yet, or are at a lower level. They're added to the profile
+// once the person accepts them.
+type SkillSuggestions struct {
+	ID           string `bson:"_id" json:"id"`
+	EmailAddress string `json:"emailAddress"`
+	Domain       string `json:"domain"`
+	// Source is the file name of the CV the skills were found in.
+	Source      string            `json:"source"`
+	Suggestions []LevelSuggestion `json:"suggestions"`
+	Status      SuggestionStatus  `json:"status"`
+	Suggested   time.Time         `json:"suggested"`
+	Decided     time.Time         `json:"decided,omitempty"`
+}
+
+// NewSkillSuggestions queues the suggestions found in the CV for the person
+// to review.
+func NewSkillSuggestions(emailAddress string, source string, suggestions []LevelSuggestion, at time.Time) SkillSuggestions {
+	return SkillSuggestions{
+		ID:           bson.NewObjectId().Hex(),
+		EmailAddress: strings.ToLower(emailAddress),
+		Domain:       GetDomain(emailAddress),
+		Source:       source,
+		Suggestions:  suggestions,
+		Status:       SuggestionPending,
+		Suggested:    at.UTC().Truncate(time.Millisecond),
+	}
+}
+
+// ErrSuggestionsDecided is returned when deciding skill suggestions which
+// have already been accepted or dismissed.
+var ErrSuggestionsDecided = errors.New("dataaccess: the skill suggestions have already been decided")
+
+// Decide accepts or dismisses the suggestions. If skills are given, only the
+// suggestions for those skills are accepted.
+func (ss *SkillSuggestions) Decide(accept bool, skills []string, at time.Time) error {
+	if ss.Status != SuggestionPending {
+		return ErrSuggestionsDecided
+	}
+	ss.Status = SuggestionDismissed
+	if accept {
+		ss.Status = SuggestionAccepted
+		if len(skills) > 0 {
+			var accepted []LevelSuggestion
+			for _, s := range ss.Suggestions {
+				for _, name := range skills {
+					if s.Skill == CleanTag(name) {
+						accepted = append(accepted, s)
+						break
+					}
+				}
+			}
+			ss.Suggestions = accepted
+		}
+	}
+	ss.Decided = at.UTC().Truncate(time.Millisecond)
+	return nil
+}
+
+// SaveSkillSuggestions queues skill suggestions, or records a decision.
+func (da MongoDataAccess) SaveSkillSuggestions(ss *SkillSuggestions) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	_, err = session.DB(da.databaseName).C("skillsuggestions").UpsertId(ss.ID, ss)
+	return err
+}
+
+// ListSkillSuggestions lists the person's pending skill suggestions, newest
+// first.
+func (da MongoDataAccess) ListSkillSuggestions(emailAddress string) ([]SkillSuggestions, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var results []SkillSuggestions
+	err = session.DB(da.databaseName).C("skillsuggestions").
+		Find(bson.M{"emailaddress": strings.ToLower(emailAddress), "status": SuggestionPending}).
+		Sort("-suggested").
+		All(&results)
+	return results, err
+}
+
+// GetSkillSuggestions returns skill suggestions.
+func (da MongoDataAccess) GetSkillSuggestions(id string) (*SkillSuggestions, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	ss := &SkillSuggestions{}
+	err = session.DB(da.databaseName).C("skillsuggestions").FindId(id).One(ss)
+
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return ss, true, nil
+}
@@ -1,6 +1,69 @@
 package dataaccess
 
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSkillTagNotFound is returned when changing a skill tag which doesn't
+// exist.
+var ErrSkillTagNotFound = errors.New("dataaccess: the skill tag was not found")
+
 // SkillTag names a skill, e.g. "c#", "java"
 type SkillTag struct {
 	Name string `bson:"_id" json:"name"`
+	// Category groups related tags, e.g. "programming languages".
+	Category string `json:"category,omitempty" bson:",omitempty"`
+	// Aliases are other names for the skill, e.g. "golang" for "go".
+	Aliases []string `json:"aliases,omitempty" bson:",omitempty"`
+	// Descriptors explain what each level of the skill means, so that people
+	// assess themselves consistently.
+	Descriptors []LevelDescriptor `json:"descriptors,omitempty" bson:",omitempty"`
+}
+
+// A LevelDescriptor describes what a level of a skill means, e.g. that level
+// 3 in "kubernetes" means having run a production cluster.
+type LevelDescriptor struct {
+	Level       DreyfusLevel `json:"level"`
+	Description string       `json:"description"`
+}
+
+// Descriptor returns the description of the level, or an empty string if
+// the level hasn't been described.
+func (t SkillTag) Descriptor(level DreyfusLevel) string {
+	for _, d := range t.Descriptors {
+		if d.Level == level {
+			return d.Description
+		}
+	}
+	return ""
+}
+
+// SkillTagNames returns the names of the tags.
+func SkillTagNames(tags []SkillTag) []string {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// ValidateLevelDescriptors checks that each level is described at most once,
+// and is on the Dreyfus scale.
+func ValidateLevelDescriptors(descriptors []LevelDescriptor) error {
+	var problems []string
+	seen := map[DreyfusLevel]bool{}
+	for _, d := range descriptors {
+		if d.Level < NoviceLevel || d.Level > MasterLevel {
+			problems = append(problems, fmt.Sprintf("level %d must be between %d and %d", d.Level, NoviceLevel, MasterLevel))
+		}
+		if d.Description == "" {
+			problems = append(problems, fmt.Sprintf("level %d must have a description", d.Level))
+		}
+		if seen[d.Level] {
+			problems = append(problems, fmt.Sprintf("level %d is described more than once", d.Level))
+		}
+		seen[d.Level] = true
+	}
+	return newValidationError(problems)
 }
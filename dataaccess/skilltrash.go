@@ -0,0 +1,20 @@
+package dataaccess
+
+import "time"
+
+// SkillTagRetention is how long deleted skill tags are kept in the trash,
+// where they can be restored, before they're purged.
+const SkillTagRetention = 30 * 24 * time.Hour
+
+// A DeletedSkillTag is a skill tag in the trash. The whole tag is kept, so
+// that restoring it doesn't lose anything stored alongside the name.
+type DeletedSkillTag struct {
+	Name    string    `bson:"_id" json:"name"`
+	Tag     SkillTag  `json:"tag"`
+	Deleted time.Time `json:"deleted"`
+}
+
+// Expires returns when the tag will be purged from the trash.
+func (t DeletedSkillTag) Expires() time.Time {
+	return t.Deleted.Add(SkillTagRetention)
+}
@@ -0,0 +1,964 @@
+package dataaccess
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// DefaultSlowOperationThreshold is how long a call must take to be logged,
+// unless configured.
+const DefaultSlowOperationThreshold = 250 * time.Millisecond
+
+// SlowLoggingDataAccess logs calls which take longer than the threshold,
+// with the collection, the shape of the query filter, and the number of
+// documents read or written, so that operators can spot missing indexes.
+// The filter's values are never logged, since they contain email addresses.
+type SlowLoggingDataAccess struct {
+	DataAccess
+	threshold time.Duration
+	logf      func(format string, v ...interface{})
+}
+
+// NewSlowLoggingDataAccess creates a DataAccess which logs calls slower than
+// the threshold.
+func NewSlowLoggingDataAccess(da DataAccess, threshold time.Duration) *SlowLoggingDataAccess {
+	return &SlowLoggingDataAccess{da, threshold, log.Printf}
+}
+
+// WithContext passes the context to the wrapped DataAccess.
+func (da SlowLoggingDataAccess) WithContext(ctx context.Context) DataAccess {
+	return &SlowLoggingDataAccess{WithContext(da.DataAccess, ctx), da.threshold, da.logf}
+}
+
+func (da SlowLoggingDataAccess) observe(operation string, collection string, filter string, start time.Time, documents int, err error) {
+	d := time.Since(start)
+	if d < da.threshold {
+		return
+	}
+
+	status := "ok"
+	if err != nil {
+		status = "failed"
+	}
+	da.logf("Slow operation: %s on %s %s took %v, %d documents, %s.", operation, collection, filter, d.Round(time.Millisecond), documents, status)
+}
+
+func documentCount(found bool) int {
+	if found {
+		return 1
+	}
+	return 0
+}
+
+func orgTreeSize(tree *OrgNode) int {
+	if tree == nil {
+		return 0
+	}
+	// The root node is the domain, not a person.
+	return len(tree.Members()) - 1
+}
+
+// ListProfiles logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListProfiles(emailAddress string) (profiles []Profile, err error) {
+	defer func(start time.Time) {
+		da.observe("ListProfiles", "profiles", "{domain: ?}", start, len(profiles), err)
+	}(time.Now())
+	return da.DataAccess.ListProfiles(emailAddress)
+}
+
+// GetOrgTree logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetOrgTree(domain string) (tree *OrgNode, err error) {
+	defer func(start time.Time) {
+		da.observe("GetOrgTree", "profiles", "{domain: ?}", start, orgTreeSize(tree), err)
+	}(time.Now())
+	return da.DataAccess.GetOrgTree(domain)
+}
+
+// ListDomains logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListDomains() (domains []string, err error) {
+	defer func(start time.Time) {
+		da.observe("ListDomains", "profiles", "distinct(domain)", start, len(domains), err)
+	}(time.Now())
+	return da.DataAccess.ListDomains()
+}
+
+// UpdateNotificationPreferences logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateNotificationPreferences(emailAddress string, p NotificationPreferences) (err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateNotificationPreferences", "profiles", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateNotificationPreferences(emailAddress, p)
+}
+
+// UpdateAvailabilityWindows logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []AvailabilityWindow) (err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateAvailabilityWindows", "profiles", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateAvailabilityWindows(emailAddress, windows)
+}
+
+// AwardBadges logs the call if it is slow.
+func (da SlowLoggingDataAccess) AwardBadges(emailAddress string, badges []Badge) (err error) {
+	defer func(start time.Time) {
+		da.observe("AwardBadges", "profiles", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.AwardBadges(emailAddress, badges)
+}
+
+// AddBooking logs the call if it is slow.
+func (da SlowLoggingDataAccess) AddBooking(emailAddress string, b Booking) (err error) {
+	defer func(start time.Time) {
+		da.observe("AddBooking", "profiles", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.AddBooking(emailAddress, b)
+}
+
+// RemoveBooking logs the call if it is slow.
+func (da SlowLoggingDataAccess) RemoveBooking(emailAddress string, id string) (err error) {
+	defer func(start time.Time) {
+		da.observe("RemoveBooking", "profiles", "{_id: ?, bookings.id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.RemoveBooking(emailAddress, id)
+}
+
+// GetProfile logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetProfile(emailAddress string) (p *Profile, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetProfile", "profiles", "{_id: ?}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetProfile(emailAddress)
+}
+
+// UpdateProfile logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateProfile(update *ProfileUpdate) (p *Profile, err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateProfile", "profiles", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateProfile(update)
+}
+
+// ImportProfile logs the call if it is slow.
+func (da SlowLoggingDataAccess) ImportProfile(p *Profile) (err error) {
+	defer func(start time.Time) {
+		da.observe("ImportProfile", "profiles", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.ImportProfile(p)
+}
+
+// DeleteProfile logs the call if it is slow.
+func (da SlowLoggingDataAccess) DeleteProfile(emailAddress string) (deleted bool, err error) {
+	defer func(start time.Time) {
+		da.observe("DeleteProfile", "profiles", "{_id: ?}", start, documentCount(deleted), err)
+	}(time.Now())
+	return da.DataAccess.DeleteProfile(emailAddress)
+}
+
+// ListSkillTags logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListSkillTags() (tags []SkillTag, err error) {
+	defer func(start time.Time) {
+		da.observe("ListSkillTags", "skills", "{}", start, len(tags), err)
+	}(time.Now())
+	return da.DataAccess.ListSkillTags()
+}
+
+// SetSkillTagDescriptors logs the call if it is slow.
+func (da SlowLoggingDataAccess) SetSkillTagDescriptors(name string, descriptors []LevelDescriptor) (err error) {
+	defer func(start time.Time) {
+		da.observe("SetSkillTagDescriptors", "skills", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SetSkillTagDescriptors(name, descriptors)
+}
+
+// AddSkillTags logs the call if it is slow.
+func (da SlowLoggingDataAccess) AddSkillTags(tags []string) (err error) {
+	defer func(start time.Time) {
+		da.observe("AddSkillTags", "skills", "{_id: ?}", start, len(tags), err)
+	}(time.Now())
+	return da.DataAccess.AddSkillTags(tags)
+}
+
+// DeleteSkillTags logs the call if it is slow.
+func (da SlowLoggingDataAccess) DeleteSkillTags(tags []string) (err error) {
+	defer func(start time.Time) {
+		da.observe("DeleteSkillTags", "skills", "{_id: {$in: ?}}", start, len(tags), err)
+	}(time.Now())
+	return da.DataAccess.DeleteSkillTags(tags)
+}
+
+// ListDeletedSkillTags logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListDeletedSkillTags() (tags []DeletedSkillTag, err error) {
+	defer func(start time.Time) {
+		da.observe("ListDeletedSkillTags", "skilltrash", "{}", start, len(tags), err)
+	}(time.Now())
+	return da.DataAccess.ListDeletedSkillTags()
+}
+
+// RestoreSkillTags logs the call if it is slow.
+func (da SlowLoggingDataAccess) RestoreSkillTags(tags []string) (restored []string, err error) {
+	defer func(start time.Time) {
+		da.observe("RestoreSkillTags", "skilltrash", "{_id: {$in: ?}}", start, len(restored), err)
+	}(time.Now())
+	return da.DataAccess.RestoreSkillTags(tags)
+}
+
+// PurgeDeletedSkillTags logs the call if it is slow.
+func (da SlowLoggingDataAccess) PurgeDeletedSkillTags(before time.Time) (n int, err error) {
+	defer func(start time.Time) {
+		da.observe("PurgeDeletedSkillTags", "skilltrash", "{deleted: {$lt: ?}}", start, n, err)
+	}(time.Now())
+	return da.DataAccess.PurgeDeletedSkillTags(before)
+}
+
+// GetOrCreateConfiguration logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetOrCreateConfiguration() (c Configuration, err error) {
+	defer func(start time.Time) {
+		da.observe("GetOrCreateConfiguration", "configuration", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.GetOrCreateConfiguration()
+}
+
+// DeleteConfiguration logs the call if it is slow.
+func (da SlowLoggingDataAccess) DeleteConfiguration() (err error) {
+	defer func(start time.Time) {
+		da.observe("DeleteConfiguration", "configuration", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.DeleteConfiguration()
+}
+
+// RotateSessionEncryptionKey logs the call if it is slow.
+func (da SlowLoggingDataAccess) RotateSessionEncryptionKey() (c Configuration, err error) {
+	defer func(start time.Time) {
+		da.observe("RotateSessionEncryptionKey", "configuration", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.RotateSessionEncryptionKey()
+}
+
+// SetFeatureFlag logs the call if it is slow.
+func (da SlowLoggingDataAccess) SetFeatureFlag(name string, enabled bool) (err error) {
+	defer func(start time.Time) {
+		da.observe("SetFeatureFlag", "configuration", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SetFeatureFlag(name, enabled)
+}
+
+// GetTenantConfiguration logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetTenantConfiguration(domain string) (tc *TenantConfiguration, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetTenantConfiguration", "tenantconfiguration", "{_id: ?}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetTenantConfiguration(domain)
+}
+
+// UpdateTenantConfiguration logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateTenantConfiguration(tc *TenantConfiguration) (err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateTenantConfiguration", "tenantconfiguration", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateTenantConfiguration(tc)
+}
+
+// DeleteTenantConfiguration logs the call if it is slow.
+func (da SlowLoggingDataAccess) DeleteTenantConfiguration(domain string) (err error) {
+	defer func(start time.Time) {
+		da.observe("DeleteTenantConfiguration", "tenantconfiguration", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.DeleteTenantConfiguration(domain)
+}
+
+// GetSettings logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetSettings(domain string) (s Settings, err error) {
+	defer func(start time.Time) {
+		da.observe("GetSettings", "tenantconfiguration", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.GetSettings(domain)
+}
+
+// AcquireLock logs the call if it is slow.
+func (da SlowLoggingDataAccess) AcquireLock(name string, ttl time.Duration) (lock *Lock, acquired bool, err error) {
+	defer func(start time.Time) {
+		da.observe("AcquireLock", "locks", "{_id: ?, expires: {$lt: ?}}", start, documentCount(acquired), err)
+	}(time.Now())
+	return da.DataAccess.AcquireLock(name, ttl)
+}
+
+// ReleaseLock logs the call if it is slow.
+func (da SlowLoggingDataAccess) ReleaseLock(lock *Lock) (err error) {
+	defer func(start time.Time) {
+		da.observe("ReleaseLock", "locks", "{_id: ?, owner: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.ReleaseLock(lock)
+}
+
+// QuarantineChange logs the call if it is slow.
+func (da SlowLoggingDataAccess) QuarantineChange(c *QuarantinedChange) (err error) {
+	defer func(start time.Time) {
+		da.observe("QuarantineChange", "quarantine", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.QuarantineChange(c)
+}
+
+// ListQuarantinedChanges logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListQuarantinedChanges(domain string) (changes []QuarantinedChange, err error) {
+	defer func(start time.Time) {
+		da.observe("ListQuarantinedChanges", "quarantine", "{tenant: ?}", start, len(changes), err)
+	}(time.Now())
+	return da.DataAccess.ListQuarantinedChanges(domain)
+}
+
+// GetQuarantinedChange logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetQuarantinedChange(id string) (c *QuarantinedChange, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetQuarantinedChange", "quarantine", "{_id: ?}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetQuarantinedChange(id)
+}
+
+// DeleteQuarantinedChange logs the call if it is slow.
+func (da SlowLoggingDataAccess) DeleteQuarantinedChange(id string) (err error) {
+	defer func(start time.Time) {
+		da.observe("DeleteQuarantinedChange", "quarantine", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.DeleteQuarantinedChange(id)
+}
+
+// RequestApproval logs the call if it is slow.
+func (da SlowLoggingDataAccess) RequestApproval(a *ApprovalRequest) (err error) {
+	defer func(start time.Time) {
+		da.observe("RequestApproval", "approvals", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.RequestApproval(a)
+}
+
+// ListApprovalRequests logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListApprovalRequests() (requests []ApprovalRequest, err error) {
+	defer func(start time.Time) {
+		da.observe("ListApprovalRequests", "approvals", "{}", start, len(requests), err)
+	}(time.Now())
+	return da.DataAccess.ListApprovalRequests()
+}
+
+// GetApprovalRequest logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetApprovalRequest(id string) (a *ApprovalRequest, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetApprovalRequest", "approvals", "{_id: ?}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetApprovalRequest(id)
+}
+
+// DeleteApprovalRequest logs the call if it is slow.
+func (da SlowLoggingDataAccess) DeleteApprovalRequest(id string) (err error) {
+	defer func(start time.Time) {
+		da.observe("DeleteApprovalRequest", "approvals", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.DeleteApprovalRequest(id)
+}
+
+// SaveCalibration logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveCalibration(c *Calibration) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveCalibration", "calibrations", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveCalibration(c)
+}
+
+// ListCalibrations logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListCalibrations(emailAddress string) (calibrations []Calibration, err error) {
+	defer func(start time.Time) {
+		da.observe("ListCalibrations", "calibrations", "{$or: [{emailaddress: ?}, {proposedby: ?}]}", start, len(calibrations), err)
+	}(time.Now())
+	return da.DataAccess.ListCalibrations(emailAddress)
+}
+
+// GetCalibration logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetCalibration(id string) (c *Calibration, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetCalibration", "calibrations", "{_id: ?}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetCalibration(id)
+}
+
+// SaveProject logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveProject(p *Project) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveProject", "projects", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveProject(p)
+}
+
+// ListProjects logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListProjects(domain string) (projects []Project, err error) {
+	defer func(start time.Time) {
+		da.observe("ListProjects", "projects", "{domain: ?}", start, len(projects), err)
+	}(time.Now())
+	return da.DataAccess.ListProjects(domain)
+}
+
+// GetProject logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetProject(id string) (p *Project, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetProject", "projects", "{_id: ?}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetProject(id)
+}
+
+// DeleteProject logs the call if it is slow.
+func (da SlowLoggingDataAccess) DeleteProject(id string) (err error) {
+	defer func(start time.Time) {
+		da.observe("DeleteProject", "projects", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.DeleteProject(id)
+}
+
+// SaveCourse logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveCourse(c *Course) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveCourse", "courses", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveCourse(c)
+}
+
+// ListCourses logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListCourses(domain string) (courses []Course, err error) {
+	defer func(start time.Time) {
+		da.observe("ListCourses", "courses", "{domain: ?}", start, len(courses), err)
+	}(time.Now())
+	return da.DataAccess.ListCourses(domain)
+}
+
+// GetCourse logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetCourse(id string) (c *Course, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetCourse", "courses", "{_id: ?}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetCourse(id)
+}
+
+// DeleteCourse logs the call if it is slow.
+func (da SlowLoggingDataAccess) DeleteCourse(id string) (err error) {
+	defer func(start time.Time) {
+		da.observe("DeleteCourse", "courses", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.DeleteCourse(id)
+}
+
+// SaveCourseCompletion logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveCourseCompletion(cc *CourseCompletion) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveCourseCompletion", "completions", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveCourseCompletion(cc)
+}
+
+// ListCourseCompletions logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListCourseCompletions(domain string) (completions []CourseCompletion, err error) {
+	defer func(start time.Time) {
+		da.observe("ListCourseCompletions", "completions", "{domain: ?}", start, len(completions), err)
+	}(time.Now())
+	return da.DataAccess.ListCourseCompletions(domain)
+}
+
+// GetCourseCompletion logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetCourseCompletion(id string) (cc *CourseCompletion, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetCourseCompletion", "completions", "{_id: ?}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetCourseCompletion(id)
+}
+
+// UpdateLearningGoals logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateLearningGoals(emailAddress string, goals []LearningGoal) (err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateLearningGoals", "profiles", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateLearningGoals(emailAddress, goals)
+}
+
+// ConfirmSkills logs the call if it is slow.
+func (da SlowLoggingDataAccess) ConfirmSkills(emailAddress string, skills []string) (err error) {
+	defer func(start time.Time) {
+		da.observe("ConfirmSkills", "profiles", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.ConfirmSkills(emailAddress, skills)
+}
+
+// ImportSkillTags logs the call if it is slow.
+func (da SlowLoggingDataAccess) ImportSkillTags(tags []SkillTag) (added int, err error) {
+	defer func(start time.Time) {
+		da.observe("ImportSkillTags", "skills", "{_id: ?}", start, len(tags), err)
+	}(time.Now())
+	return da.DataAccess.ImportSkillTags(tags)
+}
+
+// SaveTagProposal logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveTagProposal(p *TagProposal) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveTagProposal", "tagproposals", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveTagProposal(p)
+}
+
+// ListTagProposals logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListTagProposals(domain string) (proposals []TagProposal, err error) {
+	defer func(start time.Time) {
+		da.observe("ListTagProposals", "tagproposals", "{status: ?, domain: ?}", start, len(proposals), err)
+	}(time.Now())
+	return da.DataAccess.ListTagProposals(domain)
+}
+
+// GetTagProposal logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetTagProposal(id string) (p *TagProposal, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetTagProposal", "tagproposals", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.GetTagProposal(id)
+}
+
+// MergeSkillTags logs the call if it is slow.
+func (da SlowLoggingDataAccess) MergeSkillTags(into string, tags []string) (merged int, err error) {
+	defer func(start time.Time) {
+		da.observe("MergeSkillTags", "profiles", "{skills.skill: {$in: ?}}", start, merged, err)
+	}(time.Now())
+	return da.DataAccess.MergeSkillTags(into, tags)
+}
+
+// UpdateCV logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateCV(emailAddress string, cv *Attachment) (err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateCV", "profiles", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateCV(emailAddress, cv)
+}
+
+// SaveSkillSuggestions logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveSkillSuggestions(ss *SkillSuggestions) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveSkillSuggestions", "skillsuggestions", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveSkillSuggestions(ss)
+}
+
+// ListSkillSuggestions logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListSkillSuggestions(emailAddress string) (suggestions []SkillSuggestions, err error) {
+	defer func(start time.Time) {
+		da.observe("ListSkillSuggestions", "skillsuggestions", "{emailaddress: ?, status: ?}", start, len(suggestions), err)
+	}(time.Now())
+	return da.DataAccess.ListSkillSuggestions(emailAddress)
+}
+
+// GetSkillSuggestions logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetSkillSuggestions(id string) (ss *SkillSuggestions, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetSkillSuggestions", "skillsuggestions", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.GetSkillSuggestions(id)
+}
+
+// SaveShareLink logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveShareLink(l *ShareLink) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveShareLink", "sharelinks", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveShareLink(l)
+}
+
+// ListShareLinks logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListShareLinks(emailAddress string) (links []ShareLink, err error) {
+	defer func(start time.Time) {
+		da.observe("ListShareLinks", "sharelinks", "{emailaddress: ?, expires: {$gt: ?}}", start, len(links), err)
+	}(time.Now())
+	return da.DataAccess.ListShareLinks(emailAddress)
+}
+
+// GetShareLink logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetShareLink(id string) (l *ShareLink, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetShareLink", "sharelinks", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.GetShareLink(id)
+}
+
+// DeleteShareLink logs the call if it is slow.
+func (da SlowLoggingDataAccess) DeleteShareLink(id string) (err error) {
+	defer func(start time.Time) {
+		da.observe("DeleteShareLink", "sharelinks", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.DeleteShareLink(id)
+}
+
+// SyncEmployee logs the call if it is slow.
+func (da SlowLoggingDataAccess) SyncEmployee(e EmployeeUpdate) (err error) {
+	defer func(start time.Time) {
+		da.observe("SyncEmployee", "profiles", "sync", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SyncEmployee(e)
+}
+
+// ArchiveProfile logs the call if it is slow.
+func (da SlowLoggingDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (archived bool, err error) {
+	defer func(start time.Time) {
+		da.observe("ArchiveProfile", "archivedprofiles", "archive", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.ArchiveProfile(emailAddress, reason, purgeAfter)
+}
+
+// RestoreProfile logs the call if it is slow.
+func (da SlowLoggingDataAccess) RestoreProfile(emailAddress string) (restored bool, err error) {
+	defer func(start time.Time) {
+		da.observe("RestoreProfile", "archivedprofiles", "restore", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.RestoreProfile(emailAddress)
+}
+
+// ListArchivedProfiles logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListArchivedProfiles(domain string) (profiles []ArchivedProfile, err error) {
+	defer func(start time.Time) {
+		da.observe("ListArchivedProfiles", "archivedprofiles", "domain", start, len(profiles), err)
+	}(time.Now())
+	return da.DataAccess.ListArchivedProfiles(domain)
+}
+
+// PurgeArchivedProfiles logs the call if it is slow.
+func (da SlowLoggingDataAccess) PurgeArchivedProfiles(before time.Time) (purged int, err error) {
+	defer func(start time.Time) {
+		da.observe("PurgeArchivedProfiles", "archivedprofiles", "purge", start, purged, err)
+	}(time.Now())
+	return da.DataAccess.PurgeArchivedProfiles(before)
+}
+
+// RevokeSessions logs the call if it is slow.
+func (da SlowLoggingDataAccess) RevokeSessions(emailAddress string, at time.Time) (err error) {
+	defer func(start time.Time) {
+		da.observe("RevokeSessions", "configuration", "revokesessions", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.RevokeSessions(emailAddress, at)
+}
+
+// FindProfiles logs the call if it is slow.
+func (da SlowLoggingDataAccess) FindProfiles(domain string, f ProfileFilter) (profiles []Profile, err error) {
+	defer func(start time.Time) {
+		da.observe("FindProfiles", "profiles", "domain", start, len(profiles), err)
+	}(time.Now())
+	return da.DataAccess.FindProfiles(domain, f)
+}
+
+// EnsureIndexes logs the call if it is slow.
+func (da SlowLoggingDataAccess) EnsureIndexes() (err error) {
+	defer func(start time.Time) {
+		da.observe("EnsureIndexes", "profiles", "indexes", start, 0, err)
+	}(time.Now())
+	return da.DataAccess.EnsureIndexes()
+}
+
+// UpdateCustomFields logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateCustomFields(emailAddress string, values map[string]CustomFieldValue) (err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateCustomFields", "profiles", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateCustomFields(emailAddress, values)
+}
+
+// UpdateLanguages logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateLanguages(emailAddress string, languages []Language) (err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateLanguages", "profiles", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateLanguages(emailAddress, languages)
+}
+
+// UpdateClearance logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateClearance(emailAddress string, cl *Clearance) (err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateClearance", "profiles", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateClearance(emailAddress, cl)
+}
+
+// UpdateWorkLocation logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateWorkLocation(emailAddress string, l *WorkLocation) (err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateWorkLocation", "profiles", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateWorkLocation(emailAddress, l)
+}
+
+// FindProfilesNear logs the call if it is slow.
+func (da SlowLoggingDataAccess) FindProfilesNear(domain string, near Coordinates, radiusKm float64) (profiles []Profile, err error) {
+	defer func(start time.Time) {
+		da.observe("FindProfilesNear", "profiles", "domain", start, len(profiles), err)
+	}(time.Now())
+	return da.DataAccess.FindProfilesNear(domain, near, radiusKm)
+}
+
+// UpdateWorkingHours logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateWorkingHours(emailAddress string, h *WorkingHours) (err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateWorkingHours", "profiles", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateWorkingHours(emailAddress, h)
+}
+
+// RecordConsents logs the call if it is slow.
+func (da SlowLoggingDataAccess) RecordConsents(emailAddress string, consents []Consent) (err error) {
+	defer func(start time.Time) {
+		da.observe("RecordConsents", "profiles", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.RecordConsents(emailAddress, consents)
+}
+
+// SaveBenchmarks logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveBenchmarks(benchmarks []Benchmark) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveBenchmarks", "benchmarks", "band", start, len(benchmarks), err)
+	}(time.Now())
+	return da.DataAccess.SaveBenchmarks(benchmarks)
+}
+
+// GetBenchmark logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetBenchmark(band string) (b *Benchmark, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetBenchmark", "benchmarks", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.GetBenchmark(band)
+}
+
+// SaveReportDefinition logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveReportDefinition(d *ReportDefinition) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveReportDefinition", "reportdefinitions", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveReportDefinition(d)
+}
+
+// ListReportDefinitions logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListReportDefinitions(domain string) (definitions []ReportDefinition, err error) {
+	defer func(start time.Time) {
+		da.observe("ListReportDefinitions", "reportdefinitions", "domain", start, len(definitions), err)
+	}(time.Now())
+	return da.DataAccess.ListReportDefinitions(domain)
+}
+
+// DeleteReportDefinition logs the call if it is slow.
+func (da SlowLoggingDataAccess) DeleteReportDefinition(domain string, id string) (deleted bool, err error) {
+	defer func(start time.Time) {
+		da.observe("DeleteReportDefinition", "reportdefinitions", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.DeleteReportDefinition(domain, id)
+}
+
+// RunReport logs the call if it is slow.
+func (da SlowLoggingDataAccess) RunReport(d ReportDefinition, consent ConsentSettings) (result *ReportResult, err error) {
+	defer func(start time.Time) {
+		da.observe("RunReport", "profiles", "pipeline", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.RunReport(d, consent)
+}
+
+// SaveReportSnapshot logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveReportSnapshot(s *ReportSnapshot) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveReportSnapshot", "reports", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveReportSnapshot(s)
+}
+
+// GetReportSnapshot logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetReportSnapshot(domain string, report string) (s *ReportSnapshot, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetReportSnapshot", "reports", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.GetReportSnapshot(domain, report)
+}
+
+// SaveExportJob logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveExportJob(j *ExportJob) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveExportJob", "exportjobs", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveExportJob(j)
+}
+
+// GetExportJob logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetExportJob(id string) (j *ExportJob, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetExportJob", "exportjobs", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.GetExportJob(id)
+}
+
+// ClaimExportJob logs the call if it is slow.
+func (da SlowLoggingDataAccess) ClaimExportJob(now time.Time) (j *ExportJob, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("ClaimExportJob", "exportjobs", "status", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.ClaimExportJob(now)
+}
+
+// PurgeExportJobs logs the call if it is slow.
+func (da SlowLoggingDataAccess) PurgeExportJobs(before time.Time) (expired []ExportJob, err error) {
+	defer func(start time.Time) {
+		da.observe("PurgeExportJobs", "exportjobs", "expires", start, len(expired), err)
+	}(time.Now())
+	return da.DataAccess.PurgeExportJobs(before)
+}
+
+// SaveImportPreview logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveImportPreview(p *ImportPreview) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveImportPreview", "importpreviews", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveImportPreview(p)
+}
+
+// TakeImportPreview logs the call if it is slow.
+func (da SlowLoggingDataAccess) TakeImportPreview(domain string, id string) (p *ImportPreview, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("TakeImportPreview", "importpreviews", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.TakeImportPreview(domain, id)
+}
+
+// MergeProfiles logs the call if it is slow.
+func (da SlowLoggingDataAccess) MergeProfiles(primary string, duplicate string) (p *Profile, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("MergeProfiles", "profiles", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.MergeProfiles(primary, duplicate)
+}
+
+// FindProfileByExternalID logs the call if it is slow.
+func (da SlowLoggingDataAccess) FindProfileByExternalID(domain string, system string, id string) (p *Profile, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("FindProfileByExternalID", "profiles", "externalids", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.FindProfileByExternalID(domain, system, id)
+}
+
+// UpdateExternalIDs logs the call if it is slow.
+func (da SlowLoggingDataAccess) UpdateExternalIDs(emailAddress string, ids map[string]string) (err error) {
+	defer func(start time.Time) {
+		da.observe("UpdateExternalIDs", "profiles", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.UpdateExternalIDs(emailAddress, ids)
+}
+
+// AppendProfileEvents logs the call if it is slow.
+func (da SlowLoggingDataAccess) AppendProfileEvents(events []ProfileEvent) (err error) {
+	defer func(start time.Time) {
+		da.observe("AppendProfileEvents", "profileevents", "{_id: ?}", start, len(events), err)
+	}(time.Now())
+	return da.DataAccess.AppendProfileEvents(events)
+}
+
+// ListProfileEvents logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListProfileEvents(emailAddress string, after int) (events []ProfileEvent, err error) {
+	defer func(start time.Time) {
+		da.observe("ListProfileEvents", "profileevents", "{emailaddress: ?, sequence: {$gt: ?}}", start, len(events), err)
+	}(time.Now())
+	return da.DataAccess.ListProfileEvents(emailAddress, after)
+}
+
+// SaveProfileSnapshot logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveProfileSnapshot(s *ProfileSnapshot) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveProfileSnapshot", "profilesnapshots", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveProfileSnapshot(s)
+}
+
+// GetProfileSnapshot logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetProfileSnapshot(emailAddress string, at time.Time) (s *ProfileSnapshot, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetProfileSnapshot", "profilesnapshots", "{emailaddress: ?, time: {$lte: ?}}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetProfileSnapshot(emailAddress, at)
+}
+
+// ProjectProfile logs the call if it is slow.
+func (da SlowLoggingDataAccess) ProjectProfile(s *ProfileSnapshot) (err error) {
+	defer func(start time.Time) {
+		da.observe("ProjectProfile", "profiles", "_id", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.ProjectProfile(s)
+}
+
+// SaveSearchDocument logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveSearchDocument(d *SearchDocument) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveSearchDocument", "searchdocuments", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveSearchDocument(d)
+}
+
+// GetSearchDocument logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetSearchDocument(emailAddress string) (d *SearchDocument, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetSearchDocument", "searchdocuments", "{_id: ?}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetSearchDocument(emailAddress)
+}
+
+// RemoveSearchDocument logs the call if it is slow.
+func (da SlowLoggingDataAccess) RemoveSearchDocument(emailAddress string) (err error) {
+	defer func(start time.Time) {
+		da.observe("RemoveSearchDocument", "searchdocuments", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.RemoveSearchDocument(emailAddress)
+}
+
+// SearchDocuments logs the call if it is slow.
+func (da SlowLoggingDataAccess) SearchDocuments(domain string, q SearchQuery) (documents []SearchDocument, err error) {
+	defer func(start time.Time) {
+		da.observe("SearchDocuments", "searchdocuments", "{domain: ?, ...}", start, len(documents), err)
+	}(time.Now())
+	return da.DataAccess.SearchDocuments(domain, q)
+}
+
+// SaveTeamSummary logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveTeamSummary(s *TeamSummary) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveTeamSummary", "teamsummaries", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveTeamSummary(s)
+}
+
+// GetTeamSummary logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetTeamSummary(manager string) (s *TeamSummary, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetTeamSummary", "teamsummaries", "{_id: ?}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetTeamSummary(manager)
+}
+
+// SaveProfileSummary logs the call if it is slow.
+func (da SlowLoggingDataAccess) SaveProfileSummary(s *ProfileSummary) (err error) {
+	defer func(start time.Time) {
+		da.observe("SaveProfileSummary", "profilesummaries", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.SaveProfileSummary(s)
+}
+
+// GetProfileSummary logs the call if it is slow.
+func (da SlowLoggingDataAccess) GetProfileSummary(emailAddress string) (summary *ProfileSummary, found bool, err error) {
+	defer func(start time.Time) {
+		da.observe("GetProfileSummary", "profilesummaries", "{_id: ?}", start, documentCount(found), err)
+	}(time.Now())
+	return da.DataAccess.GetProfileSummary(emailAddress)
+}
+
+// ListProfileSummaries logs the call if it is slow.
+func (da SlowLoggingDataAccess) ListProfileSummaries(domain string) (summaries []ProfileSummary, err error) {
+	defer func(start time.Time) {
+		da.observe("ListProfileSummaries", "profilesummaries", "{domain: ?}", start, len(summaries), err)
+	}(time.Now())
+	return da.DataAccess.ListProfileSummaries(domain)
+}
+
+// RemoveProfileSummary logs the call if it is slow.
+func (da SlowLoggingDataAccess) RemoveProfileSummary(emailAddress string) (err error) {
+	defer func(start time.Time) {
+		da.observe("RemoveProfileSummary", "profilesummaries", "{_id: ?}", start, 1, err)
+	}(time.Now())
+	return da.DataAccess.RemoveProfileSummary(emailAddress)
+}
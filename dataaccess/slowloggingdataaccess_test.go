@@ -0,0 +1,49 @@
+package dataaccess
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type slowDataAccess struct {
+	DataAccess
+	delay time.Duration
+}
+
+func (da slowDataAccess) ListProfiles(emailAddress string) ([]Profile, error) {
+	time.Sleep(da.delay)
+	return []Profile{{EmailAddress: "a@github.com"}, {EmailAddress: "b@github.com"}}, nil
+}
+
+func TestThatSlowOperationsAreLogged(t *testing.T) {
+	tests := []struct {
+		delay    time.Duration
+		expected string
+	}{
+		{0, ""},
+		{20 * time.Millisecond, "Slow operation: ListProfiles on profiles {domain: ?} took"},
+	}
+
+	for _, test := range tests {
+		var logged []string
+		da := NewSlowLoggingDataAccess(slowDataAccess{delay: test.delay}, 10*time.Millisecond)
+		da.logf = func(format string, v ...interface{}) { logged = append(logged, fmt.Sprintf(format, v...)) }
+
+		da.ListProfiles("a@github.com")
+
+		if test.expected == "" {
+			if len(logged) > 0 {
+				t.Errorf("For a delay of %v, expected nothing to be logged, but got %v.", test.delay, logged)
+			}
+			continue
+		}
+		if len(logged) != 1 || !strings.HasPrefix(logged[0], test.expected) || !strings.HasSuffix(logged[0], "2 documents, ok.") {
+			t.Errorf("For a delay of %v, expected '%s...2 documents, ok.', but got %v.", test.delay, test.expected, logged)
+		}
+		if strings.Contains(logged[0], "a@github.com") {
+			t.Errorf("Expected the filter's values not to be logged, but got %s.", logged[0])
+		}
+	}
+}
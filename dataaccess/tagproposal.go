@@ -0,0 +1,187 @@
+package dataaccess
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// A Vocabulary is the skill tags people can use when the vocabulary is
+// strict, with their aliases.
+type Vocabulary map[string]string
+
+// NewVocabulary maps the names and aliases of the tags to the names.
+func NewVocabulary(tags []SkillTag) Vocabulary {
+	v := make(Vocabulary)
+	for _, t := range tags {
+		for _, a := range t.Aliases {
+			v[CleanTag(a)] = t.Name
+		}
+	}
+	// Names take precedence over aliases of other tags.
+	for _, t := range tags {
+		v[t.Name] = t.Name
+	}
+	return v
+}
+
+// Resolve returns the skills with aliases replaced by the names of their
+// tags, and the skills which aren't in the vocabulary, in order.
+func (v Vocabulary) Resolve(skills []Skill) (resolved []Skill, unknown []string) {
+	resolved = make([]Skill, len(skills))
+	seen := make(map[string]bool)
+	for i, s := range skills {
+		if name, ok := v[CleanTag(s.Skill)]; ok {
+			s.Skill = name
+		} else if !seen[s.Skill] {
+			seen[s.Skill] = true
+			unknown = append(unknown, s.Skill)
+		}
+		resolved[i] = s
+	}
+	sort.Strings(unknown)
+	return resolved, unknown
+}
+
+// A ProposalStatus is the state of a proposed skill tag.
+type ProposalStatus string
+
+// The states of a tag proposal.
+const (
+	ProposalPending  ProposalStatus = "pending"
+	ProposalApproved ProposalStatus = "approved"
+	ProposalRejected ProposalStatus = "rejected"
+)
+
+// A TagProposal asks for a new skill tag to be added to a strict
+// vocabulary. The profile update which used the tag is held until every tag
+// it proposed has been decided.
+type TagProposal struct {
+	ID            string `bson:"_id" json:"id"`
+	Tag           string `json:"tag"`
+	Justification string `json:"justification"`
+	ProposedBy    string `json:"proposedBy"`
+	Domain        string `json:"domain"`
+	// UpdateID is shared by the proposals made by the same profile update.
+	UpdateID string         `json:"updateId"`
+	Update   *ProfileUpdate `json:"update"`
+	// Version is the version of the proposer's profile when the update was
+	// made. The update is discarded if the profile has changed since.
+	Version   int            `json:"version"`
+	Status    ProposalStatus `json:"status"`
+	Proposed  time.Time      `json:"proposed"`
+	DecidedBy string         `json:"decidedBy,omitempty"`
+	Decided   time.Time      `json:"decided,omitempty"`
+}
+
+// NewTagProposals proposes each of the tags, holding the update made to the
+// profile at the version.
+func NewTagProposals(update *ProfileUpdate, version int, tags []string, justification string, at time.Time) []TagProposal {
+	updateID := bson.NewObjectId().Hex()
+	at = at.UTC().Truncate(time.Millisecond)
+	op := make([]TagProposal, len(tags))
+	for i, t := range tags {
+		op[i] = TagProposal{
+			ID:            bson.NewObjectId().Hex(),
+			Tag:           CleanTag(t),
+			Justification: strings.TrimSpace(justification),
+			ProposedBy:    strings.ToLower(update.EmailAddress),
+			Domain:        GetDomain(update.EmailAddress),
+			UpdateID:      updateID,
+			Update:        update,
+			Version:       version,
+			Status:        ProposalPending,
+			Proposed:      at,
+		}
+	}
+	return op
+}
+
+// ErrProposalDecided is returned when deciding a tag proposal which has
+// already been approved or rejected.
+var ErrProposalDecided = errors.New("dataaccess: the tag proposal has already been decided")
+
+// Decide approves or rejects the proposal on behalf of the administrator.
+func (p *TagProposal) Decide(approve bool, administrator string, at time.Time) error {
+	if p.Status != ProposalPending {
+		return ErrProposalDecided
+	}
+	p.Status = ProposalRejected
+	if approve {
+		p.Status = ProposalApproved
+	}
+	p.DecidedBy = strings.ToLower(administrator)
+	p.Decided = at.UTC().Truncate(time.Millisecond)
+	return nil
+}
+
+// WithoutSkill removes a rejected tag from the held update.
+func (p *TagProposal) WithoutSkill(tag string) {
+	u := *p.Update
+	u.Skills = nil
+	for _, s := range p.Update.Skills {
+		if s.Skill != tag {
+			u.Skills = append(u.Skills, s)
+		}
+	}
+	p.Update = &u
+}
+
+// SaveTagProposal records a tag proposal, or its decision.
+func (da MongoDataAccess) SaveTagProposal(p *TagProposal) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	_, err = session.DB(da.databaseName).C("tagproposals").UpsertId(p.ID, p)
+	return err
+}
+
+// ListTagProposals lists the proposals waiting for a decision, oldest first.
+// If the domain is empty, the proposals in every domain are listed.
+func (da MongoDataAccess) ListTagProposals(domain string) ([]TagProposal, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	filter := bson.M{"status": ProposalPending}
+	if domain != "" {
+		filter["domain"] = strings.ToLower(domain)
+	}
+
+	var results []TagProposal
+	err = session.DB(da.databaseName).C("tagproposals").Find(filter).Sort("proposed").All(&results)
+	return results, err
+}
+
+// GetTagProposal returns a tag proposal.
+func (da MongoDataAccess) GetTagProposal(id string) (*TagProposal, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	p := &TagProposal{}
+	err = session.DB(da.databaseName).C("tagproposals").FindId(id).One(p)
+
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return p, true, nil
+}
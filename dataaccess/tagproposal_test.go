@@ -0,0 +1,62 @@
+package dataaccess
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestThatAliasesAreResolvedToTheirTags(t *testing.T) {
+	v := NewVocabulary([]SkillTag{
+		{Name: "go", Aliases: []string{"golang"}},
+		{Name: "javascript", Aliases: []string{"js", "go"}},
+	})
+
+	resolved, unknown := v.Resolve([]Skill{
+		{Skill: "golang", Level: ExpertLevel},
+		{Skill: "go", Level: ExpertLevel},
+		{Skill: "js", Level: NoviceLevel},
+		{Skill: "rust", Level: NoviceLevel},
+		{Skill: "cobol", Level: NoviceLevel},
+	})
+
+	var names []string
+	for _, s := range resolved {
+		names = append(names, s.Skill)
+	}
+	if expected := []string{"go", "go", "javascript", "rust", "cobol"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected the skills to be resolved to %v, but got %v", expected, names)
+	}
+	if expected := []string{"cobol", "rust"}; !reflect.DeepEqual(unknown, expected) {
+		t.Errorf("expected %v to be unknown, but got %v", expected, unknown)
+	}
+}
+
+func TestThatTagProposalsCanOnlyBeDecidedOnce(t *testing.T) {
+	at := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	u := &ProfileUpdate{EmailAddress: "Dev@github.com", Skills: []Skill{{Skill: "rust"}, {Skill: "zig"}}}
+
+	proposals := NewTagProposals(u, 3, []string{"rust", "zig"}, " Needed for the new project ", at)
+	if len(proposals) != 2 || proposals[0].UpdateID != proposals[1].UpdateID {
+		t.Fatalf("expected two proposals sharing an update, but got %v", proposals)
+	}
+	p := proposals[0]
+	if p.ProposedBy != "dev@github.com" || p.Domain != "github.com" || p.Version != 3 || p.Justification != "Needed for the new project" {
+		t.Errorf("unexpected proposal %+v", p)
+	}
+
+	if err := p.Decide(false, "Admin@github.com", at); err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != ProposalRejected || p.DecidedBy != "admin@github.com" {
+		t.Errorf("expected the proposal to be rejected by admin@github.com, but got %+v", p)
+	}
+	if err := p.Decide(true, "admin@github.com", at); err != ErrProposalDecided {
+		t.Errorf("expected ErrProposalDecided, but got %v", err)
+	}
+
+	p.WithoutSkill("rust")
+	if len(p.Update.Skills) != 1 || p.Update.Skills[0].Skill != "zig" || len(u.Skills) != 2 {
+		t.Errorf("expected the rejected skill to be removed from a copy of the update, but got %v and %v", p.Update.Skills, u.Skills)
+	}
+}
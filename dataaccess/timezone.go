@@ -0,0 +1,184 @@
+package dataaccess
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// ErrInvalidTimeZone is returned when a profile's time zone isn't an IANA
+// time zone name, e.g. "Europe/London".
+var ErrInvalidTimeZone = errors.New("dataaccess: the time zone must be an IANA time zone name, e.g. Europe/London")
+
+// ErrInvalidAvailabilityWindow is returned when an availability window ends
+// before it starts.
+var ErrInvalidAvailabilityWindow = errors.New("dataaccess: availability windows must end after they start")
+
+// An AvailabilityWindow is a period when a person's availability differs
+// from their usual availability, e.g. a holiday.
+type AvailabilityWindow struct {
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	Availability RagStatus `json:"availability"`
+	Note         string    `json:"note,omitempty"`
+	// Source is the system the window was imported from, e.g. "bamboohr",
+	// or empty if it was entered by hand.
+	Source string `json:"source,omitempty"`
+}
+
+// Imported returns true if the window was imported, rather than entered by
+// hand.
+func (w AvailabilityWindow) Imported() bool {
+	return w.Source != ""
+}
+
+// In returns the window with its times in the location.
+func (w AvailabilityWindow) In(loc *time.Location) AvailabilityWindow {
+	w.Start, w.End = w.Start.In(loc), w.End.In(loc)
+	return w
+}
+
+// Contains returns true if the time is within the window.
+func (w AvailabilityWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// ValidateTimeZone returns ErrInvalidTimeZone if the name isn't empty, and
+// isn't an IANA time zone name.
+func ValidateTimeZone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil || name == "Local" {
+		return ErrInvalidTimeZone
+	}
+	return nil
+}
+
+// ValidateAvailabilityWindows returns ErrInvalidAvailabilityWindow if any of
+// the windows end before they start.
+func ValidateAvailabilityWindows(windows []AvailabilityWindow) error {
+	for _, w := range windows {
+		if !w.End.After(w.Start) {
+			return ErrInvalidAvailabilityWindow
+		}
+	}
+	return nil
+}
+
+// utcWindows returns a copy of the windows in UTC, so that they're stored
+// the same way regardless of the server's time zone.
+func utcWindows(windows []AvailabilityWindow) []AvailabilityWindow {
+	if windows == nil {
+		return nil
+	}
+	op := make([]AvailabilityWindow, len(windows))
+	for i, w := range windows {
+		op[i] = w.In(time.UTC)
+	}
+	return op
+}
+
+// Location returns the profile's time zone, or UTC if it isn't set.
+func (p Profile) Location() *time.Location {
+	if p.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// AvailabilityAt returns the person's availability at the time, taking
+// their availability windows and bookings into account.
+func (p Profile) AvailabilityAt(t time.Time) RagStatus {
+	return bookedAvailability(p.scheduledAvailabilityAt(t), Allocation(p.Bookings, t))
+}
+
+// scheduledAvailabilityAt returns the person's availability at the time,
+// taking their availability windows, but not their bookings, into account.
+func (p Profile) scheduledAvailabilityAt(t time.Time) RagStatus {
+	for _, w := range p.AvailabilityWindows {
+		if w.Contains(t) {
+			return w.Availability
+		}
+	}
+	return p.Availability
+}
+
+// ReplaceImportedWindows replaces the windows imported from the source which
+// haven't ended by the time with the next ones. Windows entered by hand, and
+// those imported from other sources, are kept. It returns false if the
+// imported windows haven't changed.
+func ReplaceImportedWindows(windows []AvailabilityWindow, source string, t time.Time, next []AvailabilityWindow) ([]AvailabilityWindow, bool) {
+	var current, kept []AvailabilityWindow
+	for _, w := range windows {
+		if w.Source == source && w.End.After(t) {
+			current = append(current, w)
+			continue
+		}
+		kept = append(kept, w)
+	}
+	next = append([]AvailabilityWindow(nil), next...)
+	sortWindows(current)
+	sortWindows(next)
+	if len(current) == 0 && len(next) == 0 || reflect.DeepEqual(current, next) {
+		return windows, false
+	}
+	return append(kept, next...), true
+}
+
+func sortWindows(windows []AvailabilityWindow) {
+	sort.Slice(windows, func(i, j int) bool {
+		if !windows[i].Start.Equal(windows[j].Start) {
+			return windows[i].Start.Before(windows[j].Start)
+		}
+		return windows[i].End.Before(windows[j].End)
+	})
+}
+
+// UpcomingAvailabilityWindows returns the windows which haven't ended by the
+// time, in the location.
+func (p Profile) UpcomingAvailabilityWindows(t time.Time, loc *time.Location) []AvailabilityWindow {
+	var op []AvailabilityWindow
+	for _, w := range p.AvailabilityWindows {
+		if w.End.After(t) {
+			op = append(op, w.In(loc))
+		}
+	}
+	return op
+}
+
+// inUTC converts the profile's timestamps to UTC. MongoDB stores times in
+// UTC, but the driver returns them in the server's time zone.
+func (p *Profile) inUTC() {
+	p.LastUpdated = p.LastUpdated.UTC()
+	p.Restored = p.Restored.UTC()
+	for i := range p.Skills {
+		p.Skills[i].Confirmed = p.Skills[i].Confirmed.UTC()
+	}
+	p.AvailabilityChanged = p.AvailabilityChanged.UTC()
+	for i := range p.SkillsHistory {
+		p.SkillsHistory[i].Date = p.SkillsHistory[i].Date.UTC()
+	}
+	p.AvailabilityWindows = utcWindows(p.AvailabilityWindows)
+	for i := range p.Bookings {
+		p.Bookings[i].Start = p.Bookings[i].Start.UTC()
+		p.Bookings[i].End = p.Bookings[i].End.UTC()
+		p.Bookings[i].Created = p.Bookings[i].Created.UTC()
+	}
+	p.Goals = utcGoals(p.Goals)
+	if p.Employment != nil {
+		p.Employment.Terminated = p.Employment.Terminated.UTC()
+		p.Employment.Synced = p.Employment.Synced.UTC()
+	}
+	for i := range p.Consents {
+		p.Consents[i].Time = p.Consents[i].Time.UTC()
+	}
+	if p.Clearance != nil {
+		p.Clearance.Expires = p.Clearance.Expires.UTC()
+	}
+}
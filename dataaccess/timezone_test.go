@@ -0,0 +1,75 @@
+package dataaccess
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThatTimeZonesAreValidated(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected error
+	}{
+		{"", nil},
+		{"UTC", nil},
+		{"Europe/London", nil},
+		{"Local", ErrInvalidTimeZone},
+		{"Middle/Earth", ErrInvalidTimeZone},
+	}
+
+	for _, test := range tests {
+		if actual := ValidateTimeZone(test.name); actual != test.expected {
+			t.Errorf("For '%s', expected %v, but was %v.", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestThatProfileTimestampsAreConvertedToUTC(t *testing.T) {
+	london, _ := time.LoadLocation("Europe/London")
+	p := &Profile{
+		LastUpdated:         time.Date(2017, time.July, 1, 9, 0, 0, 0, london),
+		SkillsHistory:       []SkillLevel{{Date: time.Date(2017, time.June, 1, 9, 0, 0, 0, london)}},
+		AvailabilityWindows: []AvailabilityWindow{{Start: time.Date(2017, time.July, 3, 9, 0, 0, 0, london), End: time.Date(2017, time.July, 3, 17, 0, 0, 0, london)}},
+	}
+
+	p.inUTC()
+
+	if p.LastUpdated.Location() != time.UTC || p.LastUpdated.Hour() != 8 {
+		t.Errorf("Expected LastUpdated to be 08:00 UTC, but was %v.", p.LastUpdated)
+	}
+
+	if p.SkillsHistory[0].Date.Location() != time.UTC || p.AvailabilityWindows[0].Start.Location() != time.UTC {
+		t.Errorf("Expected the history and windows to be in UTC, but were %v and %v.", p.SkillsHistory[0].Date, p.AvailabilityWindows[0].Start)
+	}
+}
+
+func TestThatAvailabilityWindowsOverrideTheAvailability(t *testing.T) {
+	now := time.Date(2017, time.July, 3, 12, 0, 0, 0, time.UTC)
+	p := Profile{
+		Availability: Green,
+		AvailabilityWindows: []AvailabilityWindow{
+			{Start: now.Add(-time.Hour), End: now, Availability: Amber},
+			{Start: now, End: now.Add(time.Hour), Availability: Red},
+		},
+	}
+
+	tests := []struct {
+		at       time.Time
+		expected RagStatus
+	}{
+		{now.Add(-2 * time.Hour), Green},
+		{now.Add(-time.Minute), Amber},
+		{now, Red},
+		{now.Add(time.Hour), Green},
+	}
+
+	for _, test := range tests {
+		if actual := p.AvailabilityAt(test.at); actual != test.expected {
+			t.Errorf("At %v, expected %v, but was %v.", test.at, test.expected, actual)
+		}
+	}
+
+	if upcoming := p.UpcomingAvailabilityWindows(now, time.UTC); len(upcoming) != 1 || upcoming[0].Availability != Red {
+		t.Errorf("Expected the window which ended at %v not to be upcoming, but received %v", now, upcoming)
+	}
+}
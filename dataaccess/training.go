@@ -0,0 +1,332 @@
+package dataaccess
+
+import (
+	"errors"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The course providers which are recognised from a course's URL. Courses
+// hosted anywhere else are assumed to be on the tenant's own learning
+// management system.
+const (
+	UdemyProvider    = "udemy"
+	CourseraProvider = "coursera"
+	LMSProvider      = "lms"
+)
+
+// CourseProvider returns the provider of the course at the URL.
+func CourseProvider(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return LMSProvider
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "udemy.com" || strings.HasSuffix(host, ".udemy.com"):
+		return UdemyProvider
+	case host == "coursera.org" || strings.HasSuffix(host, ".coursera.org"):
+		return CourseraProvider
+	}
+	return LMSProvider
+}
+
+// A Course is training which teaches skills, up to a level.
+type Course struct {
+	ID       string `bson:"_id" json:"id"`
+	Domain   string `json:"domain"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	Provider string `json:"provider"`
+	// Skills are the skill tags taught by the course, and the level people
+	// who complete it should reach.
+	Skills  []SkillRequirement `json:"skills"`
+	Created time.Time          `json:"created"`
+}
+
+// Validate checks that the course has a title and an absolute URL, and
+// teaches at least one skill to a level on the Dreyfus scale.
+func (c Course) Validate() error {
+	var problems []string
+	if strings.TrimSpace(c.Title) == "" {
+		problems = append(problems, "the title is required")
+	}
+	if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems = append(problems, "the URL must be an absolute http or https URL")
+	}
+	if len(c.Skills) == 0 {
+		problems = append(problems, "the course must teach at least one skill")
+	}
+	for _, s := range c.Skills {
+		if CleanTag(s.Skill) == "" {
+			problems = append(problems, "skills must have a name")
+		}
+		if s.Level < NoviceLevel || s.Level > MasterLevel {
+			problems = append(problems, "the level of "+s.Skill+" must be between 1 and 5")
+		}
+	}
+	return newValidationError(problems)
+}
+
+// Teaches returns the level the course teaches the skill to, and whether it
+// teaches it at all.
+func (c Course) Teaches(skill string) (DreyfusLevel, bool) {
+	for _, s := range c.Skills {
+		if s.Skill == skill {
+			return s.Level, true
+		}
+	}
+	return 0, false
+}
+
+// A LearningPath is the courses which take someone from their current level
+// of a skill to a target level.
+type LearningPath struct {
+	Skill   string         `json:"skill"`
+	Current DreyfusLevel   `json:"current"`
+	Target  DreyfusLevel   `json:"target"`
+	Steps   []LearningStep `json:"steps"`
+}
+
+// A LearningStep is the courses which teach a skill to a level.
+type LearningStep struct {
+	Level   DreyfusLevel `json:"level"`
+	Courses []Course     `json:"courses"`
+}
+
+// NewLearningPath returns a step for each level above the current level, up
+// to the target, which is taught by one of the courses. Levels which no
+// course teaches are left out.
+func NewLearningPath(skill string, current DreyfusLevel, target DreyfusLevel, courses []Course) LearningPath {
+	lp := LearningPath{Skill: skill, Current: current, Target: target, Steps: []LearningStep{}}
+
+	byLevel := make(map[DreyfusLevel][]Course)
+	for _, c := range courses {
+		if level, ok := c.Teaches(skill); ok && level > current && level <= target {
+			byLevel[level] = append(byLevel[level], c)
+		}
+	}
+	for level := range byLevel {
+		sort.Slice(byLevel[level], func(i, j int) bool { return byLevel[level][i].Title < byLevel[level][j].Title })
+		lp.Steps = append(lp.Steps, LearningStep{Level: level, Courses: byLevel[level]})
+	}
+	sort.Slice(lp.Steps, func(i, j int) bool { return lp.Steps[i].Level < lp.Steps[j].Level })
+	return lp
+}
+
+// A CompletionStatus is the state of the level changes suggested by a
+// course completion.
+type CompletionStatus string
+
+// The states of a course completion.
+const (
+	CompletionPending  CompletionStatus = "pending"
+	CompletionApproved CompletionStatus = "approved"
+	CompletionDeclined CompletionStatus = "declined"
+)
+
+// A LevelSuggestion is a change to the level of a skill suggested by
+// completing a course.
+type LevelSuggestion struct {
+	Skill     string       `json:"skill"`
+	Current   DreyfusLevel `json:"current"`
+	Suggested DreyfusLevel `json:"suggested"`
+}
+
+// A CourseCompletion records that someone has completed a course. If the
+// course teaches skills beyond their current levels, the new levels are
+// suggested, and applied once someone who manages them approves.
+type CourseCompletion struct {
+	ID           string            `bson:"_id" json:"id"`
+	EmailAddress string            `json:"emailAddress"`
+	Domain       string            `json:"domain"`
+	CourseID     string            `json:"courseId"`
+	Course       string            `json:"course"`
+	Suggestions  []LevelSuggestion `json:"suggestions"`
+	Status       CompletionStatus  `json:"status"`
+	Completed    time.Time         `json:"completed"`
+	// DecidedBy is the email address of the manager who approved or declined
+	// the suggestions.
+	DecidedBy string    `json:"decidedBy,omitempty"`
+	Decided   time.Time `json:"decided,omitempty"`
+}
+
+// NewCourseCompletion records the completion of the course by the person
+// with the profile. Completions which don't suggest any changes don't need
+// to be approved.
+func NewCourseCompletion(p *Profile, c Course, at time.Time) CourseCompletion {
+	cc := CourseCompletion{
+		ID:           bson.NewObjectId().Hex(),
+		EmailAddress: strings.ToLower(p.EmailAddress),
+		Domain:       GetDomain(p.EmailAddress),
+		CourseID:     c.ID,
+		Course:       c.Title,
+		Suggestions:  []LevelSuggestion{},
+		Status:       CompletionApproved,
+		Completed:    at.UTC().Truncate(time.Millisecond),
+	}
+	for _, s := range c.Skills {
+		var current DreyfusLevel
+		for _, own := range p.Skills {
+			if own.Skill == s.Skill {
+				current = own.Level
+			}
+		}
+		if s.Level > current {
+			cc.Suggestions = append(cc.Suggestions, LevelSuggestion{Skill: s.Skill, Current: current, Suggested: s.Level})
+		}
+	}
+	if len(cc.Suggestions) > 0 {
+		cc.Status = CompletionPending
+	}
+	return cc
+}
+
+// ErrCompletionDecided is returned when deciding a course completion which
+// has already been approved or declined.
+var ErrCompletionDecided = errors.New("dataaccess: the course completion has already been decided")
+
+// Decide approves or declines the suggestions on behalf of the manager.
+func (cc *CourseCompletion) Decide(approve bool, manager string, at time.Time) error {
+	if cc.Status != CompletionPending {
+		return ErrCompletionDecided
+	}
+	cc.Status = CompletionDeclined
+	if approve {
+		cc.Status = CompletionApproved
+	}
+	cc.DecidedBy = strings.ToLower(manager)
+	cc.Decided = at.UTC().Truncate(time.Millisecond)
+	return nil
+}
+
+// SaveCourse adds a course to the catalog, or updates it.
+func (da MongoDataAccess) SaveCourse(c *Course) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	c.Created = c.Created.UTC().Truncate(time.Millisecond)
+	_, err = session.DB(da.databaseName).C("courses").UpsertId(c.ID, c)
+	return err
+}
+
+// ListCourses lists the domain's catalog of courses, in order of title.
+func (da MongoDataAccess) ListCourses(domain string) ([]Course, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var results []Course
+	err = session.DB(da.databaseName).C("courses").
+		Find(bson.M{"domain": strings.ToLower(domain)}).
+		Sort("title").
+		All(&results)
+	return results, err
+}
+
+// GetCourse returns a course.
+func (da MongoDataAccess) GetCourse(id string) (*Course, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	c := &Course{}
+	err = session.DB(da.databaseName).C("courses").FindId(id).One(c)
+
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return c, true, nil
+}
+
+// DeleteCourse removes a course from the catalog. Completions of the course
+// are kept.
+func (da MongoDataAccess) DeleteCourse(id string) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	err = session.DB(da.databaseName).C("courses").RemoveId(id)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// SaveCourseCompletion records a course completion, or its approval.
+func (da MongoDataAccess) SaveCourseCompletion(cc *CourseCompletion) error {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	_, err = session.DB(da.databaseName).C("completions").UpsertId(cc.ID, cc)
+	return err
+}
+
+// ListCourseCompletions lists the course completions in the domain, newest
+// first.
+func (da MongoDataAccess) ListCourseCompletions(domain string) ([]CourseCompletion, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var results []CourseCompletion
+	err = session.DB(da.databaseName).C("completions").
+		Find(bson.M{"domain": strings.ToLower(domain)}).
+		Sort("-completed").
+		All(&results)
+	return results, err
+}
+
+// GetCourseCompletion returns a course completion.
+func (da MongoDataAccess) GetCourseCompletion(id string) (*CourseCompletion, bool, error) {
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return nil, false, err
+	}
+	defer session.Close()
+
+	cc := &CourseCompletion{}
+	err = session.DB(da.databaseName).C("completions").FindId(id).One(cc)
+
+	if err == mgo.ErrNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return cc, true, nil
+}
@@ -0,0 +1,70 @@
+package dataaccess
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThatCourseProvidersAreRecognisedFromTheURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://www.udemy.com/course/terraform/", UdemyProvider},
+		{"https://www.coursera.org/learn/golang", CourseraProvider},
+		{"https://learning.example.com/courses/42", LMSProvider},
+		{"https://udemy.com.example.com/course", LMSProvider},
+	}
+
+	for _, test := range tests {
+		if actual := CourseProvider(test.url); actual != test.expected {
+			t.Errorf("For %s, expected provider %s, but received %s", test.url, test.expected, actual)
+		}
+	}
+}
+
+func TestThatLearningPathsStartAboveTheCurrentLevel(t *testing.T) {
+	courses := []Course{
+		{ID: "basics", Title: "Terraform Basics", Skills: []SkillRequirement{{Skill: "terraform", Level: NoviceLevel}}},
+		{ID: "modules", Title: "Terraform Modules", Skills: []SkillRequirement{{Skill: "terraform", Level: CompetentLevel}}},
+		{ID: "aws", Title: "AWS with Terraform", Skills: []SkillRequirement{{Skill: "aws", Level: ProficientLevel}, {Skill: "terraform", Level: CompetentLevel}}},
+		{ID: "advanced", Title: "Advanced Terraform", Skills: []SkillRequirement{{Skill: "terraform", Level: ProficientLevel}}},
+		{ID: "expert", Title: "Terraform at Scale", Skills: []SkillRequirement{{Skill: "terraform", Level: ExpertLevel}}},
+		{ID: "go", Title: "Go", Skills: []SkillRequirement{{Skill: "go", Level: ProficientLevel}}},
+	}
+
+	lp := NewLearningPath("terraform", NoviceLevel, ProficientLevel, courses)
+
+	if len(lp.Steps) != 2 || lp.Steps[0].Level != CompetentLevel || lp.Steps[1].Level != ProficientLevel {
+		t.Fatalf("Expected steps to levels 2 and 3, but received %v", lp.Steps)
+	}
+	if len(lp.Steps[0].Courses) != 2 || lp.Steps[0].Courses[0].ID != "aws" || lp.Steps[0].Courses[1].ID != "modules" {
+		t.Errorf("Expected the courses to be in order of title, but received %v", lp.Steps[0].Courses)
+	}
+}
+
+func TestThatCompletionsSuggestHigherLevels(t *testing.T) {
+	p := &Profile{EmailAddress: "Dev@github.com", Skills: []Skill{{Skill: "terraform", Level: NoviceLevel}, {Skill: "aws", Level: ExpertLevel}}}
+	c := Course{ID: "aws", Title: "AWS with Terraform", Skills: []SkillRequirement{{Skill: "aws", Level: ProficientLevel}, {Skill: "terraform", Level: CompetentLevel}, {Skill: "packer", Level: NoviceLevel}}}
+
+	cc := NewCourseCompletion(p, c, time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC))
+
+	if cc.Status != CompletionPending || cc.EmailAddress != "dev@github.com" || cc.Domain != "github.com" {
+		t.Errorf("Expected a pending completion for dev@github.com, but received %+v", cc)
+	}
+	expected := []LevelSuggestion{{Skill: "terraform", Current: NoviceLevel, Suggested: CompetentLevel}, {Skill: "packer", Current: 0, Suggested: NoviceLevel}}
+	if len(cc.Suggestions) != len(expected) || cc.Suggestions[0] != expected[0] || cc.Suggestions[1] != expected[1] {
+		t.Errorf("Expected suggestions %v, but received %v", expected, cc.Suggestions)
+	}
+
+	if err := cc.Decide(true, "boss@github.com", time.Now()); err != nil || cc.Status != CompletionApproved {
+		t.Errorf("Expected the completion to be approved, but received %v, %s", err, cc.Status)
+	}
+	if err := cc.Decide(false, "boss@github.com", time.Now()); err != ErrCompletionDecided {
+		t.Errorf("Expected deciding twice to return ErrCompletionDecided, but received %v", err)
+	}
+
+	if done := NewCourseCompletion(p, Course{Skills: []SkillRequirement{{Skill: "aws", Level: NoviceLevel}}}, time.Now()); done.Status != CompletionApproved {
+		t.Errorf("Expected completions which suggest nothing not to need approval, but the status was %s", done.Status)
+	}
+}
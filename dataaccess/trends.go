@@ -0,0 +1,120 @@
+package dataaccess
+
+import (
+	"sort"
+	"time"
+)
+
+// A TagTrend is how many people in a domain have had a skill tag over time,
+// from their skills history.
+type TagTrend struct {
+	Tag string `json:"tag"`
+	// Points are at the start of each month in the window, and now, oldest
+	// first.
+	Points []TrendPoint `json:"points"`
+	// Change is the number of people who have the tag now, less the number
+	// who had it at the start of the window.
+	Change int `json:"change"`
+}
+
+// A TrendPoint is the number of people with a tag at a time, and their
+// average level.
+type TrendPoint struct {
+	Date         time.Time `json:"date"`
+	People       int       `json:"people"`
+	AverageLevel float64   `json:"averageLevel"`
+}
+
+// SkillsAt returns the skills the person had at the time, or nil if they
+// hadn't given any by then.
+func (p Profile) SkillsAt(t time.Time) []Skill {
+	if !p.LastUpdated.After(t) {
+		return p.Skills
+	}
+	// Each history entry is the skills which were saved at its date, before
+	// they were replaced.
+	var latest *SkillLevel
+	for i, h := range p.SkillsHistory {
+		if !h.Date.After(t) && (latest == nil || h.Date.After(latest.Date)) {
+			latest = &p.SkillsHistory[i]
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+	return latest.Skills
+}
+
+// trendDates are the start of each of the months before now, and now.
+func trendDates(months int, now time.Time) []time.Time {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -months+1, 0)
+	var dates []time.Time
+	for d := start; d.Before(now); d = d.AddDate(0, 1, 0) {
+		dates = append(dates, d)
+	}
+	return append(dates, now)
+}
+
+// GetTagTrends returns how many of the people had the tag at the start of
+// each of the last months, and now.
+func GetTagTrends(profiles []Profile, tag string, months int, now time.Time) TagTrend {
+	tag = CleanTag(tag)
+	trend := TagTrend{Tag: tag, Points: []TrendPoint{}}
+	for _, d := range trendDates(months, now) {
+		point := TrendPoint{Date: d}
+		var total int
+		for _, p := range profiles {
+			for _, s := range p.SkillsAt(d) {
+				if s.Skill == tag {
+					point.People++
+					total += int(s.Level)
+					break
+				}
+			}
+		}
+		if point.People > 0 {
+			point.AverageLevel = float64(total) / float64(point.People)
+		}
+		trend.Points = append(trend.Points, point)
+	}
+	trend.Change = trend.Points[len(trend.Points)-1].People - trend.Points[0].People
+	return trend
+}
+
+// TrendingTags returns the trends of the tags which the most people have
+// added over the last months, up to the limit, most added first.
+func TrendingTags(profiles []Profile, months int, now time.Time, limit int) []TagTrend {
+	dates := trendDates(months, now)
+	// The change is worked out from the first and last points, so that only
+	// the trends which are returned are worked out in full.
+	change := make(map[string]int)
+	for _, p := range profiles {
+		for _, s := range p.SkillsAt(dates[len(dates)-1]) {
+			change[s.Skill]++
+		}
+		for _, s := range p.SkillsAt(dates[0]) {
+			change[s.Skill]--
+		}
+	}
+	var tags []string
+	for tag, c := range change {
+		if c > 0 {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if change[tags[i]] != change[tags[j]] {
+			return change[tags[i]] > change[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	if len(tags) > limit {
+		tags = tags[:limit]
+	}
+	trends := []TagTrend{}
+	for _, tag := range tags {
+		trends = append(trends, GetTagTrends(profiles, tag, months, now))
+	}
+	return trends
+}
@@ -0,0 +1,58 @@
+package dataaccess
+
+import (
+	"testing"
+	"time"
+)
+
+func trendTestProfiles() []Profile {
+	jan := time.Date(2018, time.January, 15, 0, 0, 0, 0, time.UTC)
+	return []Profile{
+		{
+			EmailAddress:  "early@github.com",
+			Skills:        []Skill{{Skill: "go", Level: 4}, {Skill: "kubernetes", Level: 2}},
+			LastUpdated:   jan.AddDate(0, 2, 0),
+			SkillsHistory: []SkillLevel{{Date: jan, Skills: []Skill{{Skill: "go", Level: 2}, {Skill: "java", Level: 3}}}},
+		},
+		{
+			EmailAddress: "late@github.com",
+			Skills:       []Skill{{Skill: "kubernetes", Level: 4}},
+			LastUpdated:  jan.AddDate(0, 1, 0),
+		},
+	}
+}
+
+func TestThatTagTrendsCountThePeopleWithTheTagEachMonth(t *testing.T) {
+	now := time.Date(2018, time.April, 10, 0, 0, 0, 0, time.UTC)
+
+	trend := GetTagTrends(trendTestProfiles(), "Kubernetes", 4, now)
+
+	expected := []int{0, 0, 1, 2, 2}
+	if len(trend.Points) != len(expected) {
+		t.Fatalf("Expected %d points, but got %+v", len(expected), trend.Points)
+	}
+	for i, p := range trend.Points {
+		if p.People != expected[i] {
+			t.Errorf("At %v, expected %d people, but got %d", p.Date, expected[i], p.People)
+		}
+	}
+	if !trend.Points[0].Date.Equal(time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)) || !trend.Points[4].Date.Equal(now) {
+		t.Errorf("Expected the points to run from the 1st of January to now, but got %v to %v", trend.Points[0].Date, trend.Points[4].Date)
+	}
+	if trend.Change != 2 || trend.Points[4].AverageLevel != 3 {
+		t.Errorf("Expected a change of 2 at an average level of 3, but got %d at %v", trend.Change, trend.Points[4].AverageLevel)
+	}
+}
+
+func TestThatTrendingTagsAreTheMostAdded(t *testing.T) {
+	now := time.Date(2018, time.April, 10, 0, 0, 0, 0, time.UTC)
+
+	trends := TrendingTags(trendTestProfiles(), 4, now, 10)
+
+	if len(trends) != 2 || trends[0].Tag != "kubernetes" || trends[1].Tag != "go" {
+		t.Fatalf("Expected kubernetes then go to be trending, but got %+v", trends)
+	}
+	if trends[1].Change != 1 {
+		t.Errorf("Expected go to have been added by 1 person, but got %d", trends[1].Change)
+	}
+}
@@ -0,0 +1,159 @@
+package dataaccess
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// WorkingHours are the hours a person usually works, in their time zone.
+type WorkingHours struct {
+	// Start and End are times of day, e.g. "09:00". Hours which end before
+	// they start run past midnight.
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Days are the days the hours start on, where 0 is Sunday.
+	Days []time.Weekday `json:"days"`
+}
+
+// DefaultWorkingHours are used for people who haven't given theirs.
+var DefaultWorkingHours = WorkingHours{
+	Start: "09:00",
+	End:   "17:00",
+	Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+}
+
+const clockFormat = "15:04"
+
+// Validate checks that the hours are times of day, and the days are days of
+// the week.
+func (h WorkingHours) Validate() error {
+	var problems []string
+	start, startErr := time.Parse(clockFormat, h.Start)
+	end, endErr := time.Parse(clockFormat, h.End)
+	if startErr != nil || endErr != nil {
+		problems = append(problems, "the working hours must start and end at a time of day, such as 09:00")
+	} else if start.Equal(end) {
+		problems = append(problems, "the working hours must not start and end at the same time")
+	}
+	seen := make(map[time.Weekday]bool)
+	for _, d := range h.Days {
+		if d < time.Sunday || d > time.Saturday || seen[d] {
+			problems = append(problems, "the working days must be different days of the week, from 0 (Sunday) to 6 (Saturday)")
+			break
+		}
+		seen[d] = true
+	}
+	return newValidationError(problems)
+}
+
+// A WorkingPeriod is a time when someone is at work.
+type WorkingPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Duration returns the length of the period.
+func (wp WorkingPeriod) Duration() time.Duration {
+	return wp.End.Sub(wp.Start)
+}
+
+// Hours returns the person's working hours, or the defaults if they haven't
+// given theirs.
+func (p Profile) Hours() WorkingHours {
+	if p.WorkingHours == nil {
+		return DefaultWorkingHours
+	}
+	return *p.WorkingHours
+}
+
+// WorkingPeriods returns the parts of the time window the person is at work,
+// in UTC, taking their time zone and its daylight saving changes into
+// account.
+func (p Profile) WorkingPeriods(from, to time.Time) []WorkingPeriod {
+	h := p.Hours()
+	start, err := time.Parse(clockFormat, h.Start)
+	if err != nil {
+		return nil
+	}
+	end, err := time.Parse(clockFormat, h.End)
+	if err != nil {
+		return nil
+	}
+	days := make(map[time.Weekday]bool)
+	for _, d := range h.Days {
+		days[d] = true
+	}
+
+	loc := p.Location()
+	local := from.In(loc)
+	// Start from the day before, in case the hours run past midnight.
+	day := time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, loc)
+	var op []WorkingPeriod
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !days[day.Weekday()] {
+			continue
+		}
+		s := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		e := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+		if !e.After(s) {
+			e = time.Date(day.Year(), day.Month(), day.Day()+1, end.Hour(), end.Minute(), 0, 0, loc)
+		}
+		if s.Before(from) {
+			s = from
+		}
+		if e.After(to) {
+			e = to
+		}
+		if e.After(s) {
+			op = append(op, WorkingPeriod{Start: s.UTC(), End: e.UTC()})
+		}
+	}
+	return op
+}
+
+// WorkingOverlap returns how much of the time window the person is at work.
+func (p Profile) WorkingOverlap(from, to time.Time) time.Duration {
+	var total time.Duration
+	for _, wp := range p.WorkingPeriods(from, to) {
+		total += wp.Duration()
+	}
+	return total
+}
+
+// AvailableDays returns the number of the person's working days in the time
+// window, and how many of those they're not red on at the start of their
+// working hours, e.g. because of leave or a public holiday. Bookings aren't
+// taken into account.
+func (p Profile) AvailableDays(from, to time.Time) (working, available int) {
+	for _, wp := range p.WorkingPeriods(from, to) {
+		working++
+		if p.scheduledAvailabilityAt(wp.Start) != Red {
+			available++
+		}
+	}
+	return working, available
+}
+
+// UpdateWorkingHours replaces the person's working hours, or returns them to
+// the defaults if they're nil.
+func (da MongoDataAccess) UpdateWorkingHours(emailAddress string, h *WorkingHours) error {
+	update := bson.M{"$unset": bson.M{"workinghours": ""}}
+	if h != nil {
+		if err := h.Validate(); err != nil {
+			return err
+		}
+		update = bson.M{"$set": bson.M{"workinghours": h}}
+	}
+
+	session, err := da.dial()
+	if err != nil {
+		log.Print("Failed to connect to MongoDB. ", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(da.databaseName).C("profiles").UpdateId(strings.ToLower(emailAddress), update)
+}
@@ -0,0 +1,56 @@
+package dataaccess
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThatWorkingHoursAreValidated(t *testing.T) {
+	tests := []struct {
+		h         WorkingHours
+		expectErr bool
+	}{
+		{DefaultWorkingHours, false},
+		{WorkingHours{Start: "22:00", End: "06:00", Days: []time.Weekday{time.Sunday}}, false},
+		{WorkingHours{Start: "9am", End: "17:00"}, true},
+		{WorkingHours{Start: "09:00", End: "09:00"}, true},
+		{WorkingHours{Start: "09:00", End: "17:00", Days: []time.Weekday{7}}, true},
+		{WorkingHours{Start: "09:00", End: "17:00", Days: []time.Weekday{1, 1}}, true},
+	}
+
+	for _, test := range tests {
+		err := test.h.Validate()
+		if (err != nil) != test.expectErr {
+			t.Errorf("For %+v, expected an error %v, but got %v", test.h, test.expectErr, err)
+		}
+	}
+}
+
+func TestThatWorkingOverlapUsesTheTimeZone(t *testing.T) {
+	// Monday 3rd September 2018, 06:00 to 14:00 UTC.
+	from := time.Date(2018, time.September, 3, 6, 0, 0, 0, time.UTC)
+	to := from.Add(8 * time.Hour)
+
+	tests := []struct {
+		p        Profile
+		expected time.Duration
+	}{
+		// 09:00 to 17:00 BST is 08:00 to 16:00 UTC.
+		{Profile{TimeZone: "Europe/London"}, 6 * time.Hour},
+		// 09:00 to 17:00 AEST is 23:00 to 07:00 UTC.
+		{Profile{TimeZone: "Australia/Sydney"}, 1 * time.Hour},
+		// 09:00 to 17:00 EDT is 13:00 to 21:00 UTC.
+		{Profile{TimeZone: "America/New_York"}, 1 * time.Hour},
+		{Profile{}, 5 * time.Hour},
+		// A night shift which started on Sunday evening.
+		{Profile{WorkingHours: &WorkingHours{Start: "22:00", End: "07:00", Days: []time.Weekday{time.Sunday}}}, 1 * time.Hour},
+		// Nobody works at the weekend by default.
+		{Profile{TimeZone: "Europe/London", WorkingHours: &WorkingHours{Start: "09:00", End: "17:00", Days: []time.Weekday{time.Saturday}}}, 0},
+	}
+
+	for _, test := range tests {
+		if actual := test.p.WorkingOverlap(from, to); actual != test.expected {
+			t.Errorf("For %s with %+v, expected %v of overlap, but got %v", test.p.TimeZone, test.p.WorkingHours, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Package decay prompts people to re-confirm their skills when they become
+// stale, in tenants which have switched decay on.
+package decay
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/i18n"
+	"github.com/a-h/pill/notifications"
+)
+
+// Period is how often the Job should run. People are prompted about the
+// skills which became stale during the period before the job runs.
+const Period = 24 * time.Hour
+
+// A Job prompts people to re-confirm the skills which have become stale, in
+// every domain which has decay and email notifications enabled.
+type Job struct {
+	DataAccess dataaccess.DataAccess
+	Notifier   *notifications.Notifier
+	// BaseURL is the address of the pill website.
+	BaseURL string
+	now     func() time.Time
+}
+
+// NewJob creates an instance of the Job.
+func NewJob(da dataaccess.DataAccess, notifier *notifications.Notifier, baseURL string) *Job {
+	return &Job{da, notifier, baseURL, time.Now}
+}
+
+// NewlyStale returns the skills which became stale during the period up to
+// the time.
+func NewlyStale(d dataaccess.DecaySettings, p dataaccess.Profile, projects []dataaccess.Project, at time.Time) []dataaccess.StaleSkill {
+	var op []dataaccess.StaleSkill
+	for _, s := range d.StaleSkills(p, projects, at) {
+		if d.StaleAt(s.LastUsed).After(at.Add(-Period)) {
+			op = append(op, s)
+		}
+	}
+	return op
+}
+
+// Notification prompts the person to re-confirm the skills.
+func Notification(p dataaccess.Profile, stale []dataaccess.StaleSkill, baseURL string) notifications.Notification {
+	t := i18n.For(p.Language)
+	names := make([]string, len(stale))
+	for i, s := range stale {
+		names[i] = s.Skill
+	}
+	return notifications.Notification{
+		Category: dataaccess.ReminderCategory,
+		To:       p.EmailAddress,
+		Subject:  t("decay.subject"),
+		Text:     t("decay.text", strings.Join(names, ", "), strings.TrimSuffix(baseURL, "/")+"/profile/"),
+	}
+}
+
+// Run sends the prompts. Failures to notify individual people are logged,
+// so that one bad address doesn't prevent the rest of the prompts being
+// sent, and the last error is returned.
+func (j *Job) Run(ctx context.Context) error {
+	now := j.now()
+
+	domains, err := j.DataAccess.ListDomains()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		settings, err := j.DataAccess.GetSettings(domain)
+		if err != nil {
+			return err
+		}
+		if !settings.Decay.Enabled() || !settings.Notifications.EmailEnabled {
+			continue
+		}
+
+		profiles, err := j.DataAccess.ListProfiles("@" + domain)
+		if err != nil {
+			return err
+		}
+		projects, err := j.DataAccess.ListProjects(domain)
+		if err != nil {
+			return err
+		}
+
+		sent := 0
+		for _, p := range profiles {
+			stale := NewlyStale(settings.Decay, p, projects, now)
+			if len(stale) == 0 {
+				continue
+			}
+			if err := j.Notifier.Notify(p.Notifications, Notification(p, stale, j.BaseURL)); err != nil {
+				log.Printf("Failed to prompt %s to re-confirm their skills. %v", p.EmailAddress, err)
+				lastErr = err
+				continue
+			}
+			sent++
+		}
+		log.Printf("Prompted %d people in %s to re-confirm stale skills.", sent, domain)
+	}
+
+	return lastErr
+}
@@ -0,0 +1,43 @@
+package decay
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatOnlyNewlyStaleSkillsArePrompted(t *testing.T) {
+	at := time.Date(2018, time.March, 1, 9, 0, 0, 0, time.UTC)
+	d := dataaccess.DecaySettings{StaleMonths: 6}
+	p := dataaccess.Profile{
+		Skills: []dataaccess.Skill{
+			{Skill: "go", Level: dataaccess.ExpertLevel, Confirmed: at.AddDate(0, -6, 0).Add(-time.Hour)},
+			{Skill: "sql", Level: dataaccess.ExpertLevel, Confirmed: at.AddDate(0, -8, 0)},
+			{Skill: "java", Level: dataaccess.ExpertLevel, Confirmed: at.AddDate(0, -1, 0)},
+		},
+	}
+
+	stale := NewlyStale(d, p, nil, at)
+	if len(stale) != 1 || stale[0].Skill != "go" {
+		t.Errorf("expected only go to have become stale since the last run, but got %v", stale)
+	}
+}
+
+func TestThatThePromptListsTheStaleSkills(t *testing.T) {
+	p := dataaccess.Profile{EmailAddress: "dev@github.com"}
+	n := Notification(p, []dataaccess.StaleSkill{{Skill: "go"}, {Skill: "sql"}}, "https://pill.example.com/")
+
+	if n.To != p.EmailAddress {
+		t.Errorf("expected the prompt to be sent to %s, but was %s", p.EmailAddress, n.To)
+	}
+	if n.Category != dataaccess.ReminderCategory {
+		t.Errorf("expected the prompt to be a reminder, but was %v", n.Category)
+	}
+	for _, s := range []string{"go, sql", "https://pill.example.com/profile/"} {
+		if !strings.Contains(n.Text, s) {
+			t.Errorf("expected the prompt to contain '%s', but was '%s'", s, n.Text)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Package digest compiles weekly summaries of changes in each manager's team.
+package digest
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/adoption"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Change describes how a person's profile changed during the digest's
+// period.
+type Change struct {
+	EmailAddress string
+	Name         string
+	// Updated is true if the person updated their profile.
+	Updated bool
+	// AvailabilityChanged is true if the person's availability changed, in
+	// which case Availability is the new value.
+	AvailabilityChanged bool
+	Availability        dataaccess.RagStatus
+	// NewSkills lists the skills added to the profile.
+	NewSkills []string
+}
+
+// A Summary is the digest sent to a single manager.
+type Summary struct {
+	Manager   dataaccess.Profile
+	Frequency dataaccess.NotificationFrequency
+	From      time.Time
+	To        time.Time
+	Changes   []Change
+	// NewSkills lists every skill added by someone in the team.
+	NewSkills []string
+	// Adoption describes the use of pill across the domain. It is only
+	// included in weekly digests.
+	Adoption *adoption.Report
+}
+
+// Compile creates a Summary for each manager in the domain who receives
+// digests at the frequency, and whose team changed between from and to.
+// Managers who have muted the digest are skipped.
+func Compile(domain string, profiles []dataaccess.Profile, frequency dataaccess.NotificationFrequency, from time.Time, to time.Time) []Summary {
+	tree := dataaccess.NewOrgTree(domain, profiles)
+
+	byID := make(map[string]dataaccess.Profile)
+	changes := make(map[string]Change)
+	for _, p := range profiles {
+		id := strings.ToLower(p.EmailAddress)
+		byID[id] = p
+		if c, ok := changeOf(p, from, to); ok {
+			changes[id] = c
+		}
+	}
+
+	var summaries []Summary
+	for _, p := range profiles {
+		if !p.Notifications.Allows(dataaccess.DigestCategory) || p.Notifications.EffectiveFrequency() != frequency {
+			continue
+		}
+
+		node, ok := tree.Find(p.EmailAddress)
+		if !ok || len(node.Children) == 0 {
+			continue
+		}
+
+		s := Summary{Manager: p, Frequency: frequency, From: from, To: to}
+		skills := make(map[string]bool)
+		for _, id := range node.Members()[1:] {
+			c, ok := changes[id]
+			if !ok {
+				continue
+			}
+			s.Changes = append(s.Changes, c)
+			for _, skill := range c.NewSkills {
+				skills[skill] = true
+			}
+		}
+
+		if len(s.Changes) == 0 {
+			continue
+		}
+
+		for skill := range skills {
+			s.NewSkills = append(s.NewSkills, skill)
+		}
+		sort.Strings(s.NewSkills)
+
+		summaries = append(summaries, s)
+	}
+
+	return summaries
+}
+
+func within(t time.Time, from time.Time, to time.Time) bool {
+	return !t.Before(from) && t.Before(to)
+}
+
+func changeOf(p dataaccess.Profile, from time.Time, to time.Time) (Change, bool) {
+	c := Change{
+		EmailAddress:        p.EmailAddress,
+		Name:                p.Name,
+		Updated:             within(p.LastUpdated, from, to),
+		AvailabilityChanged: within(p.AvailabilityChanged, from, to),
+		Availability:        p.Availability,
+	}
+
+	if c.Updated {
+		c.NewSkills = newSkills(p, from)
+	}
+
+	return c, c.Updated || c.AvailabilityChanged
+}
+
+// newSkills returns the skills which weren't in the profile before from.
+func newSkills(p dataaccess.Profile, from time.Time) []string {
+	previous := make(map[string]bool)
+	for _, h := range p.SkillsHistory {
+		if !h.Date.Before(from) {
+			continue
+		}
+		previous = make(map[string]bool)
+		for _, s := range h.Skills {
+			previous[dataaccess.CleanTag(s.Skill)] = true
+		}
+	}
+
+	var added []string
+	for _, s := range p.Skills {
+		if tag := dataaccess.CleanTag(s.Skill); !previous[tag] {
+			added = append(added, tag)
+		}
+	}
+	sort.Strings(added)
+	return added
+}
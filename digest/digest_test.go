@@ -0,0 +1,210 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/adoption"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/notifications"
+)
+
+var (
+	to     = time.Date(2024, time.March, 11, 8, 0, 0, 0, time.UTC)
+	from   = to.Add(-Week)
+	before = from.Add(-time.Hour)
+	during = from.Add(time.Hour)
+)
+
+func testProfiles() []dataaccess.Profile {
+	return []dataaccess.Profile{
+		{EmailAddress: "boss@github.com", Name: "Boss", LastUpdated: before},
+		{
+			EmailAddress:        "dev@github.com",
+			Manager:             "boss@github.com",
+			LastUpdated:         during,
+			AvailabilityChanged: during,
+			Availability:        dataaccess.Red,
+			Skills:              []dataaccess.Skill{{Skill: "go"}, {Skill: "rust"}},
+			SkillsHistory:       []dataaccess.SkillLevel{{Date: before, Skills: []dataaccess.Skill{{Skill: "go"}}}},
+		},
+		{EmailAddress: "quiet@github.com", Manager: "boss@github.com", LastUpdated: before},
+		{EmailAddress: "lead@github.com", Manager: "boss@github.com", LastUpdated: before, Notifications: dataaccess.NotificationPreferences{MutedCategories: []dataaccess.NotificationCategory{dataaccess.DigestCategory}}},
+		{EmailAddress: "junior@github.com", Manager: "lead@github.com", LastUpdated: during},
+	}
+}
+
+func TestThatManagersReceiveTheChangesInTheirTeam(t *testing.T) {
+	summaries := Compile("github.com", testProfiles(), dataaccess.WeeklyFrequency, from, to)
+
+	if len(summaries) != 1 {
+		t.Fatalf("Only the boss should receive a digest, because the lead has muted digests, but %d were compiled.", len(summaries))
+	}
+
+	s := summaries[0]
+	if s.Manager.EmailAddress != "boss@github.com" || len(s.Changes) != 2 {
+		t.Fatalf("Expected the boss to receive 2 changes, but received %v", s.Changes)
+	}
+
+	dev := s.Changes[0]
+	if dev.EmailAddress != "dev@github.com" || !dev.Updated || !dev.AvailabilityChanged {
+		t.Errorf("Expected the developer's update and availability change, but received %v", dev)
+	}
+
+	if len(s.NewSkills) != 1 || s.NewSkills[0] != "rust" {
+		t.Errorf("Expected rust to be the only new skill, but received %v", s.NewSkills)
+	}
+
+	if s.Changes[1].EmailAddress != "junior@github.com" || s.Changes[1].NewSkills != nil {
+		t.Errorf("Expected indirect reports to be included, but received %v", s.Changes[1])
+	}
+}
+
+func TestThatTheDigestIsRenderedAsAnEmail(t *testing.T) {
+	m, err := Compile("github.com", testProfiles(), dataaccess.WeeklyFrequency, from, to)[0].Notification("https://pill.example.com/")
+	if err != nil {
+		t.Fatal("Failed to render the digest.", err)
+	}
+
+	if m.To != "boss@github.com" || m.Category != dataaccess.DigestCategory {
+		t.Errorf("Expected a digest to be sent to the boss, but received %v", m)
+	}
+
+	for _, expected := range []string{"dev@github.com updated their profile and is now red.", "New skills in your team: rust.", "https://pill.example.com/profile/"} {
+		if !strings.Contains(m.Text, expected) {
+			t.Errorf("Expected the text to contain '%s', but was:\n%s", expected, m.Text)
+		}
+	}
+
+	if !strings.Contains(m.HTML, "<strong>red</strong>") {
+		t.Errorf("Expected the HTML to contain the availability, but was:\n%s", m.HTML)
+	}
+}
+
+func TestThatWeeklyDigestsIncludeAdoption(t *testing.T) {
+	s := Compile("github.com", testProfiles(), dataaccess.WeeklyFrequency, from, to)[0]
+	r := adoption.Compile("github.com", testProfiles(), 10, to)
+	s.Adoption = &r
+
+	m, err := s.Notification("https://pill.example.com/")
+	if err != nil {
+		t.Fatal("Failed to render the digest.", err)
+	}
+
+	expected := fmt.Sprintf("%d%% of people at github.com have a pill profile", r.Coverage)
+	if !strings.Contains(m.Text, expected) || !strings.Contains(m.HTML, expected) {
+		t.Errorf("Expected the digest to contain '%s', but was:\n%s\n%s", expected, m.Text, m.HTML)
+	}
+}
+
+func TestThatTheDigestIsRenderedInTheManagersLanguage(t *testing.T) {
+	profiles := testProfiles()
+	profiles[0].Language = "de"
+
+	m, err := Compile("github.com", profiles, dataaccess.WeeklyFrequency, from, to)[0].Notification("https://pill.example.com/")
+	if err != nil {
+		t.Fatal("Failed to render the digest.", err)
+	}
+
+	if m.Subject != "Die Woche deines Teams in pill" {
+		t.Errorf("Expected a German subject, but was '%s'.", m.Subject)
+	}
+
+	if expected := "dev@github.com hat das Profil aktualisiert und ist jetzt rot."; !strings.Contains(m.Text, expected) {
+		t.Errorf("Expected the text to contain '%s', but was:\n%s", expected, m.Text)
+	}
+
+	if !strings.Contains(m.HTML, "<strong>rot</strong>") {
+		t.Errorf("Expected the HTML to contain the availability in German, but was:\n%s", m.HTML)
+	}
+}
+
+type stubDataAccess struct {
+	dataaccess.DataAccess
+	emailEnabled bool
+}
+
+func (da stubDataAccess) ListDomains() ([]string, error) {
+	return []string{"github.com"}, nil
+}
+
+func (da stubDataAccess) GetSettings(domain string) (dataaccess.Settings, error) {
+	s := dataaccess.DefaultSettings()
+	s.Notifications.EmailEnabled = da.emailEnabled
+	return s, nil
+}
+
+func (da stubDataAccess) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	return testProfiles(), nil
+}
+
+type recordingChannel struct {
+	delivered []notifications.Notification
+	err       error
+}
+
+func (c *recordingChannel) Deliver(n notifications.Notification) error {
+	c.delivered = append(c.delivered, n)
+	return c.err
+}
+
+func newTestNotifier(c *recordingChannel) *notifications.Notifier {
+	return notifications.NewNotifier(map[dataaccess.NotificationChannel]notifications.Channel{dataaccess.EmailChannel: c})
+}
+
+func TestThatTheJobRespectsTheDomainsEmailSettings(t *testing.T) {
+	tests := []struct {
+		emailEnabled bool
+		expected     int
+	}{
+		{true, 1},
+		{false, 0},
+	}
+
+	for _, test := range tests {
+		c := &recordingChannel{}
+		j := NewJob(stubDataAccess{emailEnabled: test.emailEnabled}, newTestNotifier(c), "https://pill.example.com")
+		j.now = func() time.Time { return to }
+
+		if err := j.Run(context.Background()); err != nil {
+			t.Error("The job should not fail.", err)
+		}
+
+		if len(c.delivered) != test.expected {
+			t.Errorf("With email enabled %t, expected %d notifications, but %d were sent.", test.emailEnabled, test.expected, len(c.delivered))
+		}
+	}
+}
+
+func TestThatSendFailuresAreReturned(t *testing.T) {
+	c := &recordingChannel{err: errors.New("failed")}
+	j := NewJob(stubDataAccess{emailEnabled: true}, newTestNotifier(c), "https://pill.example.com")
+	j.now = func() time.Time { return to }
+
+	if err := j.Run(context.Background()); err == nil {
+		t.Error("The job should report that sending failed.")
+	}
+}
+
+func TestThatManagersReceiveDigestsAtTheirChosenFrequency(t *testing.T) {
+	profiles := testProfiles()
+	profiles[0].Notifications.Frequency = dataaccess.DailyFrequency
+	profiles[1].LastUpdated = to.Add(-time.Hour)
+
+	if summaries := Compile("github.com", profiles, dataaccess.WeeklyFrequency, from, to); len(summaries) != 0 {
+		t.Errorf("A manager who receives daily digests should not receive a weekly digest, but %d were compiled.", len(summaries))
+	}
+
+	summaries := Compile("github.com", profiles, dataaccess.DailyFrequency, to.Add(-Day), to)
+	if len(summaries) != 1 {
+		t.Fatalf("Expected a daily digest for the boss, but %d were compiled.", len(summaries))
+	}
+
+	if n, _ := summaries[0].Notification(""); n.Subject != "Your team's day in pill" {
+		t.Errorf("Expected the daily subject, but was '%s'.", n.Subject)
+	}
+}
@@ -0,0 +1,95 @@
+package digest
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/a-h/pill/adoption"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/notifications"
+)
+
+// The periods covered by daily and weekly digests.
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+// A Job sends the digest to managers, in every domain which has email
+// notifications enabled. It should run once a day. Managers who receive
+// weekly digests are sent them on Mondays.
+type Job struct {
+	DataAccess dataaccess.DataAccess
+	Notifier   *notifications.Notifier
+	// BaseURL is the address of the pill website.
+	BaseURL string
+	now     func() time.Time
+}
+
+// NewJob creates an instance of the Job.
+func NewJob(da dataaccess.DataAccess, notifier *notifications.Notifier, baseURL string) *Job {
+	return &Job{da, notifier, baseURL, time.Now}
+}
+
+// Run sends the digests. Failures to notify individual managers are logged,
+// so that one bad address doesn't prevent the rest of the digests being sent,
+// and the last error is returned.
+func (j *Job) Run(ctx context.Context) error {
+	to := j.now()
+
+	periods := map[dataaccess.NotificationFrequency]time.Duration{dataaccess.DailyFrequency: Day}
+	if to.Weekday() == time.Monday {
+		periods[dataaccess.WeeklyFrequency] = Week
+	}
+
+	domains, err := j.DataAccess.ListDomains()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		settings, err := j.DataAccess.GetSettings(domain)
+		if err != nil {
+			return err
+		}
+		if !settings.Notifications.EmailEnabled {
+			log.Printf("Not sending digests for %s because notifications are disabled.", domain)
+			continue
+		}
+
+		profiles, err := j.DataAccess.ListProfiles("@" + domain)
+		if err != nil {
+			return err
+		}
+
+		for frequency, period := range periods {
+			summaries := Compile(domain, profiles, frequency, to.Add(-period), to)
+			if frequency == dataaccess.WeeklyFrequency {
+				a := adoption.Compile(domain, profiles, settings.Headcount, to)
+				for i := range summaries {
+					summaries[i].Adoption = &a
+				}
+			}
+			log.Printf("Sending %d %s digests for %s.", len(summaries), frequency, domain)
+
+			for _, s := range summaries {
+				n, err := s.Notification(j.BaseURL)
+				if err == nil {
+					err = j.Notifier.Notify(s.Manager.Notifications, n)
+				}
+				if err != nil {
+					log.Printf("Failed to send the digest to %s. %v", s.Manager.EmailAddress, err)
+					lastErr = err
+				}
+			}
+		}
+	}
+
+	return lastErr
+}
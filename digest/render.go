@@ -0,0 +1,140 @@
+package digest
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/i18n"
+	"github.com/a-h/pill/notifications"
+)
+
+func availabilityKey(s dataaccess.RagStatus) string {
+	switch s {
+	case dataaccess.Red:
+		return "availability.red"
+	case dataaccess.Amber:
+		return "availability.amber"
+	case dataaccess.Green:
+		return "availability.green"
+	}
+	return "availability.unknown"
+}
+
+// dateFunction formats times with the layout for the key in the language.
+func dateFunction(language string) func(t time.Time, key string) string {
+	return func(t time.Time, key string) string {
+		return t.Format(i18n.Message(language, key))
+	}
+}
+
+// textFunctions returns the text template functions for the language. The
+// placeholder functions used at parse time are replaced when rendering.
+func textFunctions(language string) texttemplate.FuncMap {
+	t := i18n.For(language)
+	return texttemplate.FuncMap{
+		"t":            t,
+		"availability": func(s dataaccess.RagStatus) string { return t(availabilityKey(s)) },
+		"date":         dateFunction(language),
+		"join":         strings.Join,
+	}
+}
+
+// htmlFunctions returns the HTML template functions for the language. The
+// arguments to t are escaped, unless they're already HTML, so that they can
+// be marked up inside a translated sentence.
+func htmlFunctions(language string) htmltemplate.FuncMap {
+	t := func(key string, args ...interface{}) htmltemplate.HTML {
+		escaped := make([]interface{}, len(args))
+		for i, a := range args {
+			if h, ok := a.(htmltemplate.HTML); ok {
+				escaped[i] = h
+				continue
+			}
+			escaped[i] = htmltemplate.HTMLEscapeString(fmt.Sprint(a))
+		}
+		message := htmltemplate.HTMLEscapeString(i18n.Message(language, key))
+		if len(args) == 0 {
+			return htmltemplate.HTML(message)
+		}
+		return htmltemplate.HTML(fmt.Sprintf(message, escaped...))
+	}
+	return htmltemplate.FuncMap{
+		"t": t,
+		"availability": func(s dataaccess.RagStatus) htmltemplate.HTML {
+			return "<strong>" + htmltemplate.HTML(htmltemplate.HTMLEscapeString(i18n.Translate(language, availabilityKey(s)))) + "</strong>"
+		},
+		"date": dateFunction(language),
+		"join": strings.Join,
+	}
+}
+
+var textTemplate = texttemplate.Must(texttemplate.New("digest").Funcs(textFunctions(i18n.DefaultLanguage)).Parse(
+	`{{ t "digest.intro" (date .From "date.short") (date .To "date.long") }}
+{{ range .Changes }}
+* {{ template "change" . }}{{ if .NewSkills }} {{ t "digest.personNewSkills" (join .NewSkills ", ") }}{{ end }}{{ end }}
+{{ if .NewSkills }}
+{{ t "digest.teamNewSkills" (join .NewSkills ", ") }}
+{{ end }}{{ with .Adoption }}
+{{ template "adoption" . }}
+{{ end }}
+{{ t "digest.preferences" (print .BaseURL "/profile/") }}
+{{ define "change" }}{{ $name := or .Name .EmailAddress }}{{ if and .Updated .AvailabilityChanged }}{{ t "digest.updatedAndAvailabilityChanged" $name (availability .Availability) }}{{ else if .AvailabilityChanged }}{{ t "digest.availabilityChanged" $name (availability .Availability) }}{{ else }}{{ t "digest.updated" $name }}{{ end }}{{ end }}
+{{ define "adoption" }}{{ if .Headcount }}{{ t "digest.adoption" .Coverage .Domain .UpToDatePercentage }}{{ else }}{{ t "digest.adoptionProfiles" .Profiles .Domain .UpToDatePercentage }}{{ end }}{{ end }}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("digest").Funcs(htmlFunctions(i18n.DefaultLanguage)).Parse(
+	`<p>{{ t "digest.intro" (date .From "date.short") (date .To "date.long") }}</p>
+<ul>
+{{ range .Changes }}  <li>{{ template "change" . }}{{ if .NewSkills }} {{ t "digest.personNewSkills" (join .NewSkills ", ") }}{{ end }}</li>
+{{ end }}</ul>
+{{ if .NewSkills }}<p>{{ t "digest.teamNewSkills" (join .NewSkills ", ") }}</p>
+{{ end }}{{ with .Adoption }}<p>{{ template "adoption" . }}</p>
+{{ end }}<p><a href="{{ .BaseURL }}/profile/">{{ t "digest.preferencesLink" }}</a></p>
+{{ define "change" }}{{ $name := or .Name .EmailAddress }}{{ if and .Updated .AvailabilityChanged }}{{ t "digest.updatedAndAvailabilityChanged" $name (availability .Availability) }}{{ else if .AvailabilityChanged }}{{ t "digest.availabilityChanged" $name (availability .Availability) }}{{ else }}{{ t "digest.updated" $name }}{{ end }}{{ end }}
+{{ define "adoption" }}{{ if .Headcount }}{{ t "digest.adoption" .Coverage .Domain .UpToDatePercentage }}{{ else }}{{ t "digest.adoptionProfiles" .Profiles .Domain .UpToDatePercentage }}{{ end }}{{ end }}`))
+
+type templateModel struct {
+	Summary
+	BaseURL string
+}
+
+// Notification renders the summary as a notification to the manager, in the
+// manager's language. The baseURL is used to link to the profile page, where
+// preferences are set.
+func (s Summary) Notification(baseURL string) (notifications.Notification, error) {
+	model := templateModel{s, strings.TrimSuffix(baseURL, "/")}
+	language := s.Manager.Language
+
+	tt, err := textTemplate.Clone()
+	if err != nil {
+		return notifications.Notification{}, err
+	}
+	ht, err := htmlTemplate.Clone()
+	if err != nil {
+		return notifications.Notification{}, err
+	}
+
+	var text, html strings.Builder
+	if err := tt.Funcs(textFunctions(language)).Execute(&text, model); err != nil {
+		return notifications.Notification{}, err
+	}
+	if err := ht.Funcs(htmlFunctions(language)).Execute(&html, model); err != nil {
+		return notifications.Notification{}, err
+	}
+
+	subject := i18n.Translate(language, "digest.subject.weekly")
+	if s.Frequency == dataaccess.DailyFrequency {
+		subject = i18n.Translate(language, "digest.subject.daily")
+	}
+
+	return notifications.Notification{
+		Category: dataaccess.DigestCategory,
+		To:       s.Manager.EmailAddress,
+		Subject:  subject,
+		Text:     text.String(),
+		HTML:     html.String(),
+	}, nil
+}
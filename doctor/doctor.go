@@ -0,0 +1,92 @@
+// Package doctor describes the results of checking a pill deployment, e.g.
+// that its database can be reached and its master key loaded, with what to
+// do about each problem found.
+package doctor
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/a-h/pill/encryption"
+)
+
+// A Status is the outcome of a check.
+type Status string
+
+// The outcomes of checks.
+const (
+	// OK checks found nothing wrong.
+	OK Status = "ok"
+	// Warning checks found something which doesn't stop the service from
+	// working, but should be put right.
+	Warning Status = "warning"
+	// Failed checks found something which stops the service from working.
+	Failed Status = "failed"
+)
+
+// A Result is the outcome of one check.
+type Result struct {
+	// Check is what was checked, e.g. "database connection".
+	Check  string `json:"check"`
+	Status Status `json:"status"`
+	// Problem is what was found, if the check didn't pass.
+	Problem string `json:"problem,omitempty"`
+	// Remedy is what to do about the problem.
+	Remedy string `json:"remedy,omitempty"`
+}
+
+// Pass returns the result of a check which found nothing wrong.
+func Pass(check string) Result {
+	return Result{Check: check, Status: OK}
+}
+
+// Warn returns the result of a check which found a problem which should be
+// put right.
+func Warn(check, problem, remedy string) Result {
+	return Result{Check: check, Status: Warning, Problem: problem, Remedy: remedy}
+}
+
+// Fail returns the result of a check which found a problem that stops the
+// service from working.
+func Fail(check, problem, remedy string) Result {
+	return Result{Check: check, Status: Failed, Problem: problem, Remedy: remedy}
+}
+
+// Healthy returns true if none of the checks failed.
+func Healthy(results []Result) bool {
+	for _, r := range results {
+		if r.Status == Failed {
+			return false
+		}
+	}
+	return true
+}
+
+// Print writes the results, with the remedy of each problem.
+func Print(w io.Writer, results []Result) {
+	for _, r := range results {
+		fmt.Fprintf(w, "[%s] %s\n", r.Status, r.Check)
+		if r.Problem != "" {
+			fmt.Fprintf(w, "    %s\n", r.Problem)
+		}
+		if r.Remedy != "" {
+			fmt.Fprintf(w, "    To fix: %s\n", r.Remedy)
+		}
+	}
+}
+
+// CheckMasterKey loads the master key file, and returns its KeyProvider,
+// which is nil if there's no file or it can't be loaded.
+func CheckMasterKey(path string) (encryption.KeyProvider, Result) {
+	const check = "master key"
+	if path == "" {
+		return nil, Warn(check, "No master key file has been provided, so configuration values aren't encrypted.",
+			"Create a key with `head -c 32 /dev/urandom | base64 > master.key`, store it outside the database, and pass it with -masterKeyFile.")
+	}
+	kp, err := encryption.LoadKeyFile(path)
+	if err != nil {
+		return nil, Fail(check, fmt.Sprintf("The master key file %s can't be loaded: %v", path, err),
+			"Check that the file exists, can be read by the user the service runs as, and contains a base64 encoded 32 byte key.")
+	}
+	return kp, Pass(check)
+}
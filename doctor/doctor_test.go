@@ -0,0 +1,67 @@
+package doctor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestThatResultsArePrintedWithTheirRemedies(t *testing.T) {
+	buf := new(bytes.Buffer)
+	Print(buf, []Result{
+		Pass("database connection"),
+		Fail("indexes", "The profiles collection has no domain index.", "Start the service."),
+	})
+
+	expected := "[ok] database connection\n[failed] indexes\n    The profiles collection has no domain index.\n    To fix: Start the service.\n"
+	if buf.String() != expected {
+		t.Errorf("Expected '%s', but got '%s'", expected, buf.String())
+	}
+}
+
+func TestThatWarningsAreHealthy(t *testing.T) {
+	if !Healthy([]Result{Pass("a"), Warn("b", "", "")}) {
+		t.Error("Expected warnings to be healthy")
+	}
+	if Healthy([]Result{Pass("a"), Fail("b", "", "")}) {
+		t.Error("Expected failures to be unhealthy")
+	}
+}
+
+func TestThatMasterKeysAreChecked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	valid := filepath.Join(dir, "valid.key")
+	ioutil.WriteFile(valid, []byte("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=\n"), 0600)
+	short := filepath.Join(dir, "short.key")
+	ioutil.WriteFile(short, []byte("c2hvcnQ="), 0600)
+
+	tests := []struct {
+		path     string
+		expected Status
+	}{
+		{"", Warning},
+		{valid, OK},
+		{short, Failed},
+		{filepath.Join(dir, "missing.key"), Failed},
+	}
+	for _, test := range tests {
+		kp, r := CheckMasterKey(test.path)
+		if r.Status != test.expected {
+			t.Errorf("For '%s', expected %s, but got %s: %s", test.path, test.expected, r.Status, r.Problem)
+		}
+		if (kp != nil) != (test.expected == OK) {
+			t.Errorf("For '%s', expected a key provider only if the key loaded", test.path)
+		}
+		if r.Status != OK && !strings.Contains(r.Remedy, "key") {
+			t.Errorf("For '%s', expected a remedy, but got '%s'", test.path, r.Remedy)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Package elasticsearch mirrors the search read model into an Elasticsearch
+// or OpenSearch index, whose analyzers rank people by how well their names
+// and skills match a search, and tolerate typos.
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// MaxResults is the number of people returned by a search, best match
+// first.
+const MaxResults = 100
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Client reads and writes the search documents in an index through the
+// Elasticsearch REST API, which OpenSearch also implements.
+type Client struct {
+	// BaseURL is the address of the cluster, e.g. http://elasticsearch:9200.
+	BaseURL string
+	// Index is the name of the index the documents are kept in.
+	Index string
+	// User and Password are used for basic authentication, if set.
+	User     string
+	Password string
+	Client   *http.Client
+}
+
+// NewClient creates an instance of the Client.
+func NewClient(baseURL string, index string, user string, password string) *Client {
+	return &Client{strings.TrimSuffix(baseURL, "/"), index, user, password, httpClient}
+}
+
+// m is a JSON object in a request.
+type m map[string]interface{}
+
+// mapping is the definition of the index. Names are folded to ASCII, so
+// that "Zoe" finds "Zoë", and skills are split on punctuation as well as
+// kept whole, so that "node" finds "node.js" while "c#" still finds "c#".
+// Skills are nested, so that a skill filter matches the level of the same
+// skill.
+var mapping = m{
+	"settings": m{
+		"analysis": m{
+			"filter": m{
+				"skill_parts": m{
+					"type":              "word_delimiter_graph",
+					"preserve_original": true,
+				},
+			},
+			"analyzer": m{
+				"name": m{
+					"type":      "custom",
+					"tokenizer": "standard",
+					"filter":    []string{"lowercase", "asciifolding"},
+				},
+				"skill": m{
+					"type":      "custom",
+					"tokenizer": "whitespace",
+					"filter":    []string{"skill_parts", "lowercase", "asciifolding"},
+				},
+			},
+		},
+	},
+	"mappings": m{
+		"properties": m{
+			"emailAddress": m{"type": "keyword"},
+			"domain":       m{"type": "keyword"},
+			"name":         m{"type": "text", "analyzer": "name"},
+			"manager":      m{"type": "keyword"},
+			"department": m{"type": "text", "analyzer": "name",
+				"fields": m{"raw": m{"type": "keyword"}}},
+			"costCenter":   m{"type": "keyword"},
+			"availability": m{"type": "integer"},
+			"skills": m{
+				"type": "nested",
+				"properties": m{
+					"skill": m{"type": "text", "analyzer": "skill",
+						"fields": m{"raw": m{"type": "keyword"}}},
+					"level":    m{"type": "integer"},
+					"interest": m{"type": "integer"},
+				},
+			},
+			"languages": m{"type": "keyword"},
+			"updated":   m{"type": "date"},
+		},
+	},
+}
+
+// EnsureIndex creates the index if it doesn't exist, returning true if it
+// was created and so needs to be filled. An existing index is left alone, so
+// changes to the mapping need the index to be deleted and rebuilt.
+func (c Client) EnsureIndex(ctx context.Context) (bool, error) {
+	status, err := c.do(ctx, http.MethodHead, "/"+url.PathEscape(c.Index), nil, nil)
+	if err != nil {
+		return false, err
+	}
+	if status == http.StatusOK {
+		return false, nil
+	}
+	if err := c.check(c.do(ctx, http.MethodPut, "/"+url.PathEscape(c.Index), mapping, nil)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Save creates or replaces the search document.
+func (c Client) Save(ctx context.Context, d dataaccess.SearchDocument) error {
+	return c.check(c.do(ctx, http.MethodPut, c.documentPath(d.EmailAddress), d, nil))
+}
+
+// Remove removes the person's search document, if it's in the index.
+func (c Client) Remove(ctx context.Context, emailAddress string) error {
+	status, err := c.do(ctx, http.MethodDelete, c.documentPath(emailAddress), nil, nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return c.check(status, err)
+}
+
+func (c Client) documentPath(emailAddress string) string {
+	return "/" + url.PathEscape(c.Index) + "/_doc/" + url.PathEscape(strings.ToLower(emailAddress))
+}
+
+// Search finds the people in the domain who match the query, best match
+// first. The skill and free text may be misspelled, and the text matches
+// people with any of its words, ranking those with more of them, and with
+// them in their name or skills, higher.
+func (c Client) Search(ctx context.Context, domain string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error) {
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source dataaccess.SearchDocument `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := c.check(c.do(ctx, http.MethodPost, "/"+url.PathEscape(c.Index)+"/_search", searchBody(domain, q), &result)); err != nil {
+		return nil, err
+	}
+	documents := []dataaccess.SearchDocument{}
+	for _, h := range result.Hits.Hits {
+		documents = append(documents, h.Source)
+	}
+	return documents, nil
+}
+
+func searchBody(domain string, q dataaccess.SearchQuery) m {
+	filter := []m{{"term": m{"domain": strings.ToLower(domain)}}}
+	if q.Manager != "" {
+		filter = append(filter, m{"term": m{"manager": strings.ToLower(q.Manager)}})
+	}
+	if q.Department != "" {
+		filter = append(filter, m{"term": m{"department.raw": q.Department}})
+	}
+	if q.Availability > 0 {
+		filter = append(filter, m{"range": m{"availability": m{"gte": q.Availability}}})
+	}
+
+	var must []m
+	if q.Skill != "" {
+		must = append(must, m{"nested": m{
+			"path": "skills",
+			"query": m{"bool": m{
+				"must":   m{"match": m{"skills.skill": m{"query": q.Skill, "fuzziness": "AUTO"}}},
+				"filter": m{"range": m{"skills.level": m{"gte": q.Level}}},
+			}},
+		}})
+	}
+	if strings.TrimSpace(q.Text) != "" {
+		must = append(must, m{"bool": m{
+			"should": []m{
+				{"match": m{"name": m{"query": q.Text, "fuzziness": "AUTO", "boost": 3}}},
+				{"match": m{"department": m{"query": q.Text, "fuzziness": "AUTO"}}},
+				{"nested": m{
+					"path":       "skills",
+					"score_mode": "max",
+					"query":      m{"match": m{"skills.skill": m{"query": q.Text, "fuzziness": "AUTO", "boost": 2}}},
+				}},
+			},
+			"minimum_should_match": 1,
+		}})
+	}
+	query := m{"filter": filter}
+	if len(must) > 0 {
+		query["must"] = must
+	}
+	return m{"size": MaxResults, "query": m{"bool": query}}
+}
+
+// check returns an error if the status isn't a success.
+func (c Client) check(status int, err error) error {
+	if err != nil {
+		return err
+	}
+	if status >= 300 {
+		return fmt.Errorf("elasticsearch: the index %s returned status %d", c.Index, status)
+	}
+	return nil
+}
+
+func (c Client) do(ctx context.Context, method string, path string, body interface{}, result interface{}) (int, error) {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequest(method, c.BaseURL+path, &buf)
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Password)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 || result == nil {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,94 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type readModelStore struct {
+	dataaccess.DataAccess
+	saved    []string
+	searched int
+}
+
+func (s *readModelStore) SaveSearchDocument(d *dataaccess.SearchDocument) error {
+	s.saved = append(s.saved, d.EmailAddress)
+	return nil
+}
+
+func (s *readModelStore) SearchDocuments(domain string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error) {
+	s.searched++
+	return []dataaccess.SearchDocument{{EmailAddress: "mongo@github.com"}}, nil
+}
+
+func TestThatSearchDocumentsAreIndexedAndSearched(t *testing.T) {
+	indexed := make(map[string]dataaccess.SearchDocument)
+	var created bool
+	var search map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "pill" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/people":
+			if !created {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodPut && r.URL.Path == "/people":
+			created = true
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/people/_doc/"):
+			var d dataaccess.SearchDocument
+			json.NewDecoder(r.Body).Decode(&d)
+			indexed[strings.TrimPrefix(r.URL.Path, "/people/_doc/")] = d
+		case r.Method == http.MethodPost && r.URL.Path == "/people/_search":
+			json.NewDecoder(r.Body).Decode(&search)
+			w.Write([]byte(`{"hits":{"hits":[{"_source":{"emailAddress":"a@github.com","name":"Ada"}}]}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", "people", "pill", "secret")
+	for _, expected := range []bool{true, false} {
+		if c, err := client.EnsureIndex(context.Background()); err != nil || c != expected {
+			t.Fatalf("Expected the index to be created only the first time, but received %v, %v", c, err)
+		}
+	}
+
+	store := &readModelStore{}
+	da := NewIndexingDataAccess(store, client)
+	if err := da.SaveSearchDocument(&dataaccess.SearchDocument{EmailAddress: "a@github.com", Name: "Ada"}); err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if len(store.saved) != 1 || indexed["a@github.com"].Name != "Ada" {
+		t.Errorf("Expected the document to be saved and indexed, but saved %v and indexed %v", store.saved, indexed)
+	}
+
+	documents, err := da.SearchDocuments("github.com", dataaccess.SearchQuery{Skill: "kubernets", Level: 3})
+	if err != nil || len(documents) != 1 || documents[0].EmailAddress != "a@github.com" || store.searched != 0 {
+		t.Fatalf("Expected the index to be searched, but received %v, %v", documents, err)
+	}
+	body, _ := json.Marshal(search)
+	for _, s := range []string{`"domain":"github.com"`, `"path":"skills"`, `"fuzziness":"AUTO"`, `"gte":3`} {
+		if !strings.Contains(string(body), s) {
+			t.Errorf("Expected the search to contain %s, but received %s", s, body)
+		}
+	}
+
+	if _, err := da.SearchDocuments("github.com", dataaccess.SearchQuery{Manager: "boss@github.com"}); err != nil || store.searched != 1 {
+		t.Errorf("Expected a team listing to read MongoDB, but received %v", err)
+	}
+	server.Close()
+	documents, err = da.SearchDocuments("github.com", dataaccess.SearchQuery{Text: "ada"})
+	if err != nil || len(documents) != 1 || documents[0].EmailAddress != "mongo@github.com" {
+		t.Errorf("Expected searches to read MongoDB while the index is unavailable, but received %v, %v", documents, err)
+	}
+}
@@ -0,0 +1,86 @@
+package elasticsearch
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// IndexingDataAccess wraps a DataAccess and mirrors the search read model
+// into the index, and searches the index instead of MongoDB. The read model
+// in MongoDB is still kept, so that listings which don't rank people, such
+// as the members of a team, read every match rather than the best, and so
+// that searches keep working, less relevantly, if the index is unavailable.
+type IndexingDataAccess struct {
+	dataaccess.DataAccess
+	Client *Client
+	ctx    context.Context
+}
+
+// NewIndexingDataAccess creates a DataAccess which mirrors search documents
+// into the index.
+func NewIndexingDataAccess(da dataaccess.DataAccess, client *Client) *IndexingDataAccess {
+	return &IndexingDataAccess{da, client, context.Background()}
+}
+
+// WithContext passes the context to the wrapped DataAccess, and uses it for
+// requests to the index.
+func (da IndexingDataAccess) WithContext(ctx context.Context) dataaccess.DataAccess {
+	return &IndexingDataAccess{dataaccess.WithContext(da.DataAccess, ctx), da.Client, ctx}
+}
+
+// SaveSearchDocument saves the search document and indexes it. A failure to
+// index it is logged, since the read models are rebuilt every night.
+func (da IndexingDataAccess) SaveSearchDocument(d *dataaccess.SearchDocument) error {
+	if err := da.DataAccess.SaveSearchDocument(d); err != nil {
+		return err
+	}
+	if err := da.Client.Save(da.ctx, *d); err != nil {
+		log.Printf("Failed to index the search document of %s. %v", d.EmailAddress, err)
+	}
+	return nil
+}
+
+// RemoveSearchDocument removes the search document from MongoDB and the
+// index.
+func (da IndexingDataAccess) RemoveSearchDocument(emailAddress string) error {
+	if err := da.DataAccess.RemoveSearchDocument(emailAddress); err != nil {
+		return err
+	}
+	da.unindex(emailAddress)
+	return nil
+}
+
+// ArchiveProfile archives the profile and removes it from the index, as
+// archiving removes it from the read model in MongoDB.
+func (da IndexingDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (bool, error) {
+	archived, err := da.DataAccess.ArchiveProfile(emailAddress, reason, purgeAfter)
+	if err != nil || !archived {
+		return archived, err
+	}
+	da.unindex(emailAddress)
+	return archived, nil
+}
+
+func (da IndexingDataAccess) unindex(emailAddress string) {
+	if err := da.Client.Remove(da.ctx, emailAddress); err != nil {
+		log.Printf("Failed to remove the search document of %s from the index. %v", emailAddress, err)
+	}
+}
+
+// SearchDocuments searches the index for the people with a skill or words,
+// best match first, up to MaxResults. Other queries, and searches while the
+// index is unavailable, read MongoDB.
+func (da IndexingDataAccess) SearchDocuments(domain string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error) {
+	if q.Skill == "" && q.Text == "" {
+		return da.DataAccess.SearchDocuments(domain, q)
+	}
+	documents, err := da.Client.Search(da.ctx, domain, q)
+	if err != nil {
+		log.Printf("Failed to search the index, searching MongoDB instead. %v", err)
+		return da.DataAccess.SearchDocuments(domain, q)
+	}
+	return documents, nil
+}
@@ -0,0 +1,104 @@
+// Package email sends email messages.
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+)
+
+// A Message is an email with plain text and HTML versions of the body.
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// A Sender sends messages.
+type Sender interface {
+	Send(m Message) error
+}
+
+// SMTPSender sends messages via an SMTP server.
+type SMTPSender struct {
+	// Address is the host:port of the SMTP server.
+	Address string
+	From    string
+	Auth    smtp.Auth
+}
+
+// NewSMTPSender creates an instance of the SMTPSender.
+func NewSMTPSender(address string, from string) *SMTPSender {
+	return &SMTPSender{Address: address, From: from}
+}
+
+// Send sends the message.
+func (s SMTPSender) Send(m Message) error {
+	body, err := Encode(s.From, m)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.Address, s.Auth, s.From, m.To, body)
+}
+
+// Encode creates a MIME encoded email containing the text and HTML bodies.
+func Encode(from string, m Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", m.Subject))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", mw.Boundary())
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", m.Text},
+		{"text/html; charset=UTF-8", m.HTML},
+	}
+
+	for _, p := range parts {
+		if p.content == "" {
+			continue
+		}
+
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		qw.Close()
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// LogSender writes messages to the log instead of sending them, for use when
+// no SMTP server is configured.
+type LogSender struct{}
+
+// Send logs the message.
+func (LogSender) Send(m Message) error {
+	log.Printf("Not sending email '%s' to %s, because no SMTP server is configured.", m.Subject, strings.Join(m.To, ", "))
+	return nil
+}
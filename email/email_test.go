@@ -0,0 +1,51 @@
+package email
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+)
+
+func TestThatMessagesAreEncodedWithTextAndHTMLParts(t *testing.T) {
+	data, err := Encode("pill@example.com", Message{
+		To:      []string{"a-h@github.com"},
+		Subject: "Weekly digest",
+		Text:    "Hello",
+		HTML:    "<p>Hello</p>",
+	})
+	if err != nil {
+		t.Fatal("Failed to encode the message.", err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal("Failed to parse the encoded message.", err)
+	}
+
+	if msg.Header.Get("Subject") != "Weekly digest" || msg.Header.Get("To") != "a-h@github.com" {
+		t.Errorf("Unexpected headers %v", msg.Header)
+	}
+
+	_, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+
+	var bodies []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal("Failed to read a part.", err)
+		}
+		b, _ := io.ReadAll(p)
+		bodies = append(bodies, string(b))
+	}
+
+	if len(bodies) != 2 || bodies[0] != "Hello" || bodies[1] != "<p>Hello</p>" {
+		t.Errorf("Expected text and HTML parts, but received %v", bodies)
+	}
+}
@@ -0,0 +1,103 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// An EncryptedValue is encrypted with a data key, which is itself encrypted
+// by the master key of a KeyProvider.
+type EncryptedValue struct {
+	KeyID      string `json:"keyId"`
+	WrappedKey []byte `json:"wrappedKey"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// ErrInvalidCiphertext is returned when a value can't be decrypted because
+// it has been truncated or modified.
+var ErrInvalidCiphertext = errors.New("encryption: the ciphertext is invalid")
+
+// Seal encrypts the plaintext with a new data key, and wraps the data key
+// with the KeyProvider.
+func Seal(kp KeyProvider, plaintext []byte) (EncryptedValue, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return EncryptedValue{}, err
+	}
+
+	ciphertext, err := encrypt(dataKey, plaintext)
+	if err != nil {
+		return EncryptedValue{}, err
+	}
+
+	wrappedKey, err := kp.WrapKey(dataKey)
+	if err != nil {
+		return EncryptedValue{}, err
+	}
+
+	return EncryptedValue{
+		KeyID:      kp.KeyID(),
+		WrappedKey: wrappedKey,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// Open decrypts a value created by Seal. The KeyProvider must have the same
+// master key as was used to seal the value.
+func Open(kp KeyProvider, v EncryptedValue) ([]byte, error) {
+	if v.KeyID != kp.KeyID() {
+		return nil, fmt.Errorf("encryption: the value was encrypted with the key '%s', but the key '%s' was provided", v.KeyID, kp.KeyID())
+	}
+
+	dataKey, err := kp.UnwrapKey(v.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return decrypt(dataKey, v.Ciphertext)
+}
+
+// encrypt uses AES-GCM, prepending the nonce to the ciphertext.
+func encrypt(key []byte, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decrypt(key []byte, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
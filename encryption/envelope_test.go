@@ -0,0 +1,89 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestKeyProvider(t *testing.T) *LocalKeyProvider {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	kp, err := NewLocalKeyProvider(key)
+	if err != nil {
+		t.Fatal("Failed to create the key provider.", err)
+	}
+	return kp
+}
+
+func TestThatSealedValuesCanBeOpened(t *testing.T) {
+	kp := newTestKeyProvider(t)
+	plaintext := []byte("session encryption key")
+
+	v, err := Seal(kp, plaintext)
+	if err != nil {
+		t.Fatal("Failed to seal the value.", err)
+	}
+
+	if bytes.Contains(v.Ciphertext, plaintext) {
+		t.Error("The ciphertext should not contain the plaintext.")
+	}
+
+	actual, err := Open(kp, v)
+	if err != nil {
+		t.Fatal("Failed to open the value.", err)
+	}
+
+	if !bytes.Equal(actual, plaintext) {
+		t.Errorf("Expected '%s', but was '%s'", plaintext, actual)
+	}
+}
+
+func TestThatValuesCanOnlyBeOpenedWithTheSameMasterKey(t *testing.T) {
+	v, _ := Seal(newTestKeyProvider(t), []byte("secret"))
+
+	if _, err := Open(newTestKeyProvider(t), v); err == nil {
+		t.Error("A value sealed with one master key should not be opened with another.")
+	}
+}
+
+func TestThatModifiedValuesAreRejected(t *testing.T) {
+	kp := newTestKeyProvider(t)
+	v, _ := Seal(kp, []byte("secret"))
+	v.Ciphertext[len(v.Ciphertext)-1] ^= 1
+
+	if _, err := Open(kp, v); err != ErrInvalidCiphertext {
+		t.Errorf("Expected a modified ciphertext to be rejected, but the error was %v", err)
+	}
+}
+
+func TestThatKeyFilesCanBeLoaded(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pillkeys")
+	defer os.RemoveAll(dir)
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	path := filepath.Join(dir, "master.key")
+	ioutil.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600)
+
+	kp, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatal("Failed to load the key file.", err)
+	}
+
+	expected, _ := NewLocalKeyProvider(key)
+	if kp.KeyID() != expected.KeyID() {
+		t.Errorf("Expected the key ID %s, but was %s", expected.KeyID(), kp.KeyID())
+	}
+}
+
+func TestThatInvalidMasterKeysAreRejected(t *testing.T) {
+	if _, err := NewLocalKeyProvider([]byte("too short")); err == nil {
+		t.Error("A master key which isn't 32 bytes long should be rejected.")
+	}
+}
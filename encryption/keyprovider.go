@@ -0,0 +1,11 @@
+package encryption
+
+// A KeyProvider encrypts and decrypts data keys using a master key which is
+// never stored alongside the data.
+type KeyProvider interface {
+	// KeyID identifies the master key, so that values can be matched to the
+	// key which encrypted them.
+	KeyID() string
+	WrapKey(dataKey []byte) ([]byte, error)
+	UnwrapKey(wrappedKey []byte) ([]byte, error)
+}
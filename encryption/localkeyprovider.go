@@ -0,0 +1,64 @@
+package encryption
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// A LocalKeyProvider wraps data keys with a master key held by the
+// application, e.g. one loaded from a file mounted as a secret.
+type LocalKeyProvider struct {
+	id  string
+	key []byte
+}
+
+// NewLocalKeyProvider creates a LocalKeyProvider from a 32 byte master key.
+// The key ID is derived from the key, so that the correct key can be
+// identified without storing it.
+func NewLocalKeyProvider(key []byte) (*LocalKeyProvider, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption: the master key must be 32 bytes long, but was %d bytes", len(key))
+	}
+
+	fingerprint := sha256.Sum256(key)
+
+	return &LocalKeyProvider{
+		id:  "local:" + hex.EncodeToString(fingerprint[:8]),
+		key: key,
+	}, nil
+}
+
+// LoadKeyFile creates a LocalKeyProvider from a file containing a base64
+// encoded 32 byte master key, e.g. the output of `head -c 32 /dev/urandom | base64`.
+func LoadKeyFile(path string) (*LocalKeyProvider, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(contents)))
+	if err != nil {
+		return nil, fmt.Errorf("encryption: the key file %s must contain a base64 encoded key: %v", path, err)
+	}
+
+	return NewLocalKeyProvider(key)
+}
+
+// KeyID identifies the master key.
+func (kp LocalKeyProvider) KeyID() string {
+	return kp.id
+}
+
+// WrapKey encrypts the data key with the master key.
+func (kp LocalKeyProvider) WrapKey(dataKey []byte) ([]byte, error) {
+	return encrypt(kp.key, dataKey)
+}
+
+// UnwrapKey decrypts a data key encrypted by WrapKey.
+func (kp LocalKeyProvider) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	return decrypt(kp.key, wrappedKey)
+}
@@ -0,0 +1,39 @@
+package events
+
+import "time"
+
+// EventType names the kind of change described by an Event.
+type EventType string
+
+const (
+	// ProfileUpdated is raised when a profile is created or updated.
+	ProfileUpdated EventType = "profileUpdated"
+	// ProfileDeleted is raised when a profile is removed.
+	ProfileDeleted EventType = "profileDeleted"
+	// SkillTagsAdded is raised when skill tags are added to the list.
+	SkillTagsAdded EventType = "skillTagsAdded"
+	// SkillTagsDeleted is raised when skill tags are removed from the list.
+	SkillTagsDeleted EventType = "skillTagsDeleted"
+)
+
+// An Event describes a change to the data held by pill.
+type Event struct {
+	Type EventType `json:"type"`
+	// Domain is the email domain the change applies to. Changes which apply
+	// to every domain (e.g. skill tags) have an empty domain.
+	Domain       string      `json:"domain"`
+	EmailAddress string      `json:"emailAddress,omitempty"`
+	Time         time.Time   `json:"time"`
+	Data         interface{} `json:"data,omitempty"`
+}
+
+// NewEvent creates an Event of the given type, timestamped now.
+func NewEvent(eventType EventType, domain string, emailAddress string, data interface{}) Event {
+	return Event{
+		Type:         eventType,
+		Domain:       domain,
+		EmailAddress: emailAddress,
+		Time:         time.Now(),
+		Data:         data,
+	}
+}
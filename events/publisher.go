@@ -0,0 +1,16 @@
+package events
+
+// A Publisher receives events as they happen.
+type Publisher interface {
+	Publish(e Event)
+}
+
+// Publishers sends each event to every publisher, in order.
+type Publishers []Publisher
+
+// Publish sends the event to every publisher.
+func (ps Publishers) Publish(e Event) {
+	for _, p := range ps {
+		p.Publish(e)
+	}
+}
@@ -0,0 +1,237 @@
+// Package export writes pill's data in formats used by analytics tools.
+package export
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/parquet"
+)
+
+var profileColumns = []parquet.Column{
+	{Name: "emailAddress", Type: parquet.String},
+	{Name: "domain", Type: parquet.String},
+	{Name: "name", Type: parquet.String, Optional: true},
+	{Name: "manager", Type: parquet.String, Optional: true},
+	{Name: "availability", Type: parquet.Int32},
+	{Name: "availabilityChanged", Type: parquet.Timestamp, Optional: true},
+	{Name: "version", Type: parquet.Int32},
+	{Name: "lastUpdated", Type: parquet.Timestamp},
+	{Name: "skills", Type: parquet.Int32},
+}
+
+var historyColumns = []parquet.Column{
+	{Name: "emailAddress", Type: parquet.String},
+	{Name: "domain", Type: parquet.String},
+	{Name: "date", Type: parquet.Timestamp},
+	{Name: "skill", Type: parquet.String},
+	{Name: "level", Type: parquet.Int32},
+	{Name: "interest", Type: parquet.Int32, Optional: true},
+	// current is true for the profile's current skills, and false for its
+	// history.
+	{Name: "current", Type: parquet.Bool},
+}
+
+// A ParquetExporter writes profiles and their skills history as Parquet files,
+// in the directory layout used by Hive, Spark and Athena to partition tables:
+//
+//	profiles/domain=github.com/month=2017-06/part-0.parquet
+//	history/domain=github.com/month=2017-06/part-0.parquet
+//
+// Profiles are partitioned by the month they were last updated, and history
+// by the month of each entry.
+type ParquetExporter struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewParquetExporter creates an instance of the ParquetExporter.
+func NewParquetExporter(da dataaccess.DataAccess) *ParquetExporter {
+	return &ParquetExporter{da}
+}
+
+// A Result summarises an export.
+type Result struct {
+	Files   int `json:"files"`
+	Rows    int `json:"rows"`
+	Domains int `json:"domains"`
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%d rows written to %d files for %d domains", r.Rows, r.Files, r.Domains)
+}
+
+type partition struct {
+	table, domain, month string
+}
+
+func (p partition) path(dir string) string {
+	return filepath.Join(dir, p.table, "domain="+p.domain, "month="+p.month, "part-0.parquet")
+}
+
+func month(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// stagingDir is where each domain's partitions are written before they
+// replace the last export's. Analytics tools ignore directories which start
+// with a dot.
+const stagingDir = ".staging"
+
+// Export writes the files to the directory. Each domain's partitions replace
+// all of those from the last export, so that rows which have since moved to
+// another month, or whose people have withdrawn their consent, are removed.
+func (e ParquetExporter) Export(dir string) (Result, error) {
+	var r Result
+
+	domains, err := e.DataAccess.ListDomains()
+	if err != nil {
+		return r, err
+	}
+	r.Domains = len(domains)
+
+	for _, domain := range domains {
+		profiles, err := e.DataAccess.ListProfiles("@" + domain)
+		if err != nil {
+			return r, err
+		}
+		settings, err := e.DataAccess.GetSettings(domain)
+		if err != nil {
+			return r, err
+		}
+		// People who haven't consented to analytics aren't exported.
+		profiles = dataaccess.Consenting(profiles, dataaccess.ConsentAnalytics, settings.Consent)
+		dataaccess.RecordAccesses(e.DataAccess, audit.ProfileExported, dataaccess.EmailAddresses(profiles))
+
+		rows := map[partition][][]interface{}{}
+		for _, p := range profiles {
+			pp := partition{"profiles", domain, month(p.LastUpdated)}
+			rows[pp] = append(rows[pp], profileRow(p))
+
+			for _, h := range p.SkillsHistory {
+				hp := partition{"history", domain, month(h.Date)}
+				rows[hp] = append(rows[hp], historyRows(p, h.Date, h.Skills, false)...)
+			}
+			cp := partition{"history", domain, month(p.LastUpdated)}
+			rows[cp] = append(rows[cp], historyRows(p, p.LastUpdated, p.Skills, true)...)
+		}
+
+		var partitions []partition
+		for p := range rows {
+			partitions = append(partitions, p)
+		}
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i].path(dir) < partitions[j].path(dir) })
+
+		staging := filepath.Join(dir, stagingDir)
+		if err := os.RemoveAll(staging); err != nil {
+			return r, err
+		}
+		for _, p := range partitions {
+			columns := profileColumns
+			if p.table == "history" {
+				columns = historyColumns
+			}
+			if err := writeFile(p.path(staging), columns, rows[p]); err != nil {
+				return r, err
+			}
+			r.Files++
+			r.Rows += len(rows[p])
+		}
+		if err := replaceDomain(dir, staging, domain); err != nil {
+			return r, err
+		}
+		log.Printf("Exported %d profiles for %s.", len(profiles), domain)
+	}
+
+	return r, os.RemoveAll(filepath.Join(dir, stagingDir))
+}
+
+// replaceDomain moves the domain's partitions from the staging directory into
+// the export, in place of those already there.
+func replaceDomain(dir, staging, domain string) error {
+	for _, table := range []string{"profiles", "history"} {
+		current := filepath.Join(dir, table, "domain="+domain)
+		if err := os.RemoveAll(current); err != nil {
+			return err
+		}
+		staged := filepath.Join(staging, table, "domain="+domain)
+		if _, err := os.Stat(staged); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(current), 0755); err != nil {
+			return err
+		}
+		if err := os.Rename(staged, current); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func profileRow(p dataaccess.Profile) []interface{} {
+	return []interface{}{
+		p.EmailAddress,
+		p.Domain,
+		optionalString(p.Name),
+		optionalString(p.Manager),
+		int(p.Availability),
+		optionalTime(p.AvailabilityChanged),
+		p.Version,
+		p.LastUpdated,
+		len(p.Skills),
+	}
+}
+
+func historyRows(p dataaccess.Profile, date time.Time, skills []dataaccess.Skill, current bool) [][]interface{} {
+	var op [][]interface{}
+	for _, s := range skills {
+		var interest interface{}
+		if s.Interest != 0 {
+			interest = int(s.Interest)
+		}
+		op = append(op, []interface{}{p.EmailAddress, p.Domain, date, s.Skill, int(s.Level), interest, current})
+	}
+	return op
+}
+
+func optionalString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func optionalTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+func writeFile(name string, columns []parquet.Column, rows [][]interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := parquet.NewWriter(f, columns)
+	for _, row := range rows {
+		if err := w.Write(row...); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
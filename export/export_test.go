@@ -0,0 +1,158 @@
+package export
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type stubDataAccess struct {
+	dataaccess.DataAccess
+	profiles []dataaccess.Profile
+	settings dataaccess.Settings
+}
+
+func (da stubDataAccess) ListDomains() ([]string, error) {
+	return []string{"github.com"}, nil
+}
+
+func (da stubDataAccess) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	return da.profiles, nil
+}
+
+func (da stubDataAccess) GetSettings(domain string) (dataaccess.Settings, error) {
+	return da.settings, nil
+}
+
+func TestThatProfilesAreExportedInPartitionsByDomainAndMonth(t *testing.T) {
+	june := time.Date(2017, time.June, 1, 0, 0, 0, 0, time.UTC)
+	july := time.Date(2017, time.July, 1, 0, 0, 0, 0, time.UTC)
+	da := stubDataAccess{profiles: []dataaccess.Profile{
+		{
+			EmailAddress:  "a@github.com",
+			Domain:        "github.com",
+			Skills:        []dataaccess.Skill{{Skill: "go", Level: 3}, {Skill: "rust", Level: 1}},
+			SkillsHistory: []dataaccess.SkillLevel{{Date: june, Skills: []dataaccess.Skill{{Skill: "go", Level: 2}}}},
+			LastUpdated:   july,
+		},
+		{
+			EmailAddress: "b@github.com",
+			Domain:       "github.com",
+			Skills:       []dataaccess.Skill{{Skill: "java", Level: 4, Interest: 5}},
+			LastUpdated:  july,
+		},
+	}}
+
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := NewParquetExporter(da).Export(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+
+	expected := Result{Files: 3, Rows: 6, Domains: 1}
+	if r != expected {
+		t.Errorf("Expected %v, but got %v.", expected, r)
+	}
+
+	files := listFiles(dir)
+	expectedFiles := []string{
+		"history/domain=github.com/month=2017-06/part-0.parquet",
+		"history/domain=github.com/month=2017-07/part-0.parquet",
+		"profiles/domain=github.com/month=2017-07/part-0.parquet",
+	}
+	if !reflect.DeepEqual(files, expectedFiles) {
+		t.Errorf("Expected files %v, but got %v.", expectedFiles, files)
+	}
+}
+
+func listFiles(dir string) []string {
+	var files []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files
+}
+
+func TestThatPartitionsFromTheLastExportAreReplaced(t *testing.T) {
+	june := time.Date(2017, time.June, 1, 0, 0, 0, 0, time.UTC)
+	july := time.Date(2017, time.July, 1, 0, 0, 0, 0, time.UTC)
+	da := &stubDataAccess{profiles: []dataaccess.Profile{
+		{EmailAddress: "a@github.com", Domain: "github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}, LastUpdated: june},
+	}}
+
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := NewParquetExporter(da).Export(dir); err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+
+	// The profile was updated in July, so it's no longer in June's partition.
+	da.profiles[0].LastUpdated = july
+	if _, err := NewParquetExporter(da).Export(dir); err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+
+	expected := []string{
+		"history/domain=github.com/month=2017-07/part-0.parquet",
+		"profiles/domain=github.com/month=2017-07/part-0.parquet",
+	}
+	if files := listFiles(dir); !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected files %v, but got %v.", expected, files)
+	}
+
+	// Nobody in the domain is exported once their consent is required.
+	da.settings.Consent = dataaccess.ConsentSettings{Required: true, NoticeVersion: "2"}
+	if _, err := NewParquetExporter(da).Export(dir); err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+
+	if files := listFiles(dir); len(files) != 0 {
+		t.Errorf("Expected the domain's partitions to be removed, but got %v.", files)
+	}
+}
+
+func TestThatPeopleWhoHaventConsentedAreNotExported(t *testing.T) {
+	july := time.Date(2017, time.July, 1, 0, 0, 0, 0, time.UTC)
+	consented := []dataaccess.Consent{{Purpose: dataaccess.ConsentAnalytics, Given: true, Time: july, NoticeVersion: "2"}}
+	da := stubDataAccess{
+		profiles: []dataaccess.Profile{
+			{EmailAddress: "a@github.com", Domain: "github.com", LastUpdated: july, Consents: consented},
+			{EmailAddress: "b@github.com", Domain: "github.com", LastUpdated: july},
+		},
+		settings: dataaccess.Settings{Consent: dataaccess.ConsentSettings{Required: true, NoticeVersion: "2"}},
+	}
+
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r, err := NewParquetExporter(da).Export(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+	if r.Rows != 1 {
+		t.Errorf("Expected only the profile with consent to be exported, but got %v.", r)
+	}
+}
@@ -0,0 +1,291 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/a-h/pill/attachments"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/onepager"
+	"github.com/a-h/pill/parquet"
+)
+
+// The data export jobs can export.
+const (
+	// Profiles is a row for each person.
+	Profiles = "profiles"
+	// Skills is a row for each of the skills people have, and each entry in
+	// their skills history.
+	Skills = "skills"
+	// OnePagers is a one pager for each person.
+	OnePagers = "onepagers"
+)
+
+// The formats exports are written in.
+const (
+	CSV     = "csv"
+	XLSX    = "xlsx"
+	Parquet = "parquet"
+	// PDF is a zip file of PDFs, one for each person.
+	PDF = "pdf"
+)
+
+var kindFormats = map[string][]string{
+	Profiles:  {CSV, XLSX, Parquet},
+	Skills:    {CSV, XLSX, Parquet},
+	OnePagers: {PDF},
+}
+
+// Supports returns true if the kind of data can be exported in the format.
+func Supports(kind string, format string) bool {
+	for _, f := range kindFormats[kind] {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentType returns the media type of files in the format.
+func ContentType(format string) string {
+	switch format {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case XLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case PDF:
+		return "application/zip"
+	}
+	return "application/octet-stream"
+}
+
+// FileName returns the name the job's file is downloaded as, e.g.
+// github.com-profiles-2017-07-01.xlsx.
+func FileName(j dataaccess.ExportJob) string {
+	ext := j.Format
+	if j.Format == PDF {
+		ext = "zip"
+	}
+	return fmt.Sprintf("%s-%s-%s.%s", j.Domain, j.Kind, j.Created.Format("2006-01-02"), ext)
+}
+
+// DefaultRetention is how long exported files are kept for.
+const DefaultRetention = 24 * time.Hour
+
+// A Runner runs export jobs in the background, and writes their files to
+// blob storage.
+type Runner struct {
+	DataAccess dataaccess.DataAccess
+	Store      attachments.Store
+	// Retention is how long files are kept after the job finishes.
+	Retention time.Duration
+	now       func() time.Time
+}
+
+// NewRunner creates an instance of the Runner.
+func NewRunner(da dataaccess.DataAccess, store attachments.Store, retention time.Duration) *Runner {
+	return &Runner{
+		DataAccess: da,
+		Store:      store,
+		Retention:  retention,
+		now:        time.Now,
+	}
+}
+
+// Run runs the pending export jobs, one at a time, until there are none left.
+// A job which fails is marked as failed, and doesn't stop the others.
+func (r Runner) Run(ctx context.Context) error {
+	for ctx.Err() == nil {
+		j, ok, err := r.DataAccess.ClaimExportJob(r.now())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if err := r.run(j); err != nil {
+			log.Printf("Export %s of the %s of %s failed. %v", j.ID, j.Kind, j.Domain, err)
+			j.Status, j.Error = dataaccess.ExportFailed, err.Error()
+		}
+		j.Finished = r.now().UTC().Truncate(time.Millisecond)
+		j.Expires = j.Finished.Add(r.Retention)
+		if err := r.DataAccess.SaveExportJob(j); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (r Runner) run(j *dataaccess.ExportJob) error {
+	var buf bytes.Buffer
+	rows, exported, err := r.write(&buf, j.Domain, j.Kind, j.Format)
+	if err != nil {
+		return err
+	}
+	// The tenant is part of the name, so that a sharded store keeps the
+	// file in the tenant's region.
+	blob := "exports/" + j.Domain + "/" + j.ID
+	if err := r.Store.Put(blob, buf.Bytes()); err != nil {
+		return err
+	}
+	j.Status, j.Blob, j.Size, j.Rows = dataaccess.ExportSucceeded, blob, buf.Len(), rows
+	j.Exported = dataaccess.EmailAddresses(exported)
+	log.Printf("Exported %d %s of %s as %s.", rows, j.Kind, j.Domain, j.Format)
+	return nil
+}
+
+// Purge deletes the jobs which have expired, and their files.
+func (r Runner) Purge(ctx context.Context) error {
+	expired, err := r.DataAccess.PurgeExportJobs(r.now())
+	if err != nil {
+		return err
+	}
+	for _, j := range expired {
+		if j.Blob == "" {
+			continue
+		}
+		if err := r.Store.Delete(j.Blob); err != nil {
+			log.Printf("Failed to delete the file of export %s. %v", j.ID, err)
+		}
+	}
+	return nil
+}
+
+// Write writes the kind of data of the domain in the format, and returns the
+// number of rows, or one pagers, written. People who haven't consented to
+// analytics aren't exported.
+func (r Runner) Write(w io.Writer, domain string, kind string, format string) (int, error) {
+	rows, _, err := r.write(w, domain, kind, format)
+	return rows, err
+}
+
+// write writes the export, and returns the number of rows and the profiles
+// written.
+func (r Runner) write(w io.Writer, domain string, kind string, format string) (int, []dataaccess.Profile, error) {
+	if !Supports(kind, format) {
+		return 0, nil, fmt.Errorf("%s can't be exported as %s", kind, format)
+	}
+	profiles, err := r.DataAccess.ListProfiles("@" + domain)
+	if err != nil {
+		return 0, nil, err
+	}
+	settings, err := r.DataAccess.GetSettings(domain)
+	if err != nil {
+		return 0, nil, err
+	}
+	profiles = dataaccess.Consenting(profiles, dataaccess.ConsentAnalytics, settings.Consent)
+
+	if kind == OnePagers {
+		n, err := r.writeOnePagers(w, domain, settings, profiles)
+		return n, profiles, err
+	}
+
+	columns, rows := profileColumns, [][]interface{}{}
+	if kind == Skills {
+		columns = historyColumns
+	}
+	for _, p := range profiles {
+		if kind == Profiles {
+			rows = append(rows, profileRow(p))
+			continue
+		}
+		rows = append(rows, historyRows(p, p.LastUpdated, p.Skills, true)...)
+		for _, h := range p.SkillsHistory {
+			rows = append(rows, historyRows(p, h.Date, h.Skills, false)...)
+		}
+	}
+	return len(rows), profiles, writeTable(w, format, columns, rows)
+}
+
+func writeTable(w io.Writer, format string, columns []parquet.Column, rows [][]interface{}) error {
+	if format == Parquet {
+		pw := parquet.NewWriter(w, columns)
+		for _, row := range rows {
+			if err := pw.Write(row...); err != nil {
+				return err
+			}
+		}
+		return pw.Close()
+	}
+
+	header, numeric := make([]string, len(columns)), make([]bool, len(columns))
+	for i, c := range columns {
+		header[i], numeric[i] = c.Name, c.Type == parquet.Int32
+	}
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = make([]string, len(row))
+		for j, v := range row {
+			cells[i][j] = cell(v)
+		}
+	}
+
+	if format == XLSX {
+		return writeXLSX(w, header, cells, numeric)
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(cells)
+	return cw.Error()
+}
+
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+// writeOnePagers writes a zip file of the one pager of each person, named by
+// their email address.
+func (r Runner) writeOnePagers(w io.Writer, domain string, settings dataaccess.Settings, profiles []dataaccess.Profile) (int, error) {
+	completions, err := r.DataAccess.ListCourseCompletions(domain)
+	if err != nil {
+		return 0, err
+	}
+	projects, err := r.DataAccess.ListProjects(domain)
+	if err != nil {
+		return 0, err
+	}
+	var logo image.Image
+	if settings.Branding.LogoURL != "" {
+		if logo, err = onepager.LoadLogo(settings.Branding.LogoURL); err != nil {
+			log.Printf("Failed to load the logo of %s, one pagers are exported without it. %v", domain, err)
+		}
+	}
+	branding := onepager.NewBranding(settings.Branding, logo)
+
+	z := zip.NewWriter(w)
+	now := r.now()
+	for i := range profiles {
+		data, err := onepager.Render(settings.OnePagerTemplate, onepager.NewData(&profiles[i], completions, projects, now), branding)
+		if err != nil {
+			return 0, fmt.Errorf("the one pager of %s: %v", profiles[i].EmailAddress, err)
+		}
+		f, err := z.Create(profiles[i].EmailAddress + ".pdf")
+		if err != nil {
+			return 0, err
+		}
+		if _, err := f.Write(data); err != nil {
+			return 0, err
+		}
+	}
+	return len(profiles), z.Close()
+}
@@ -0,0 +1,181 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type stubJobDataAccess struct {
+	stubDataAccess
+	pending []*dataaccess.ExportJob
+	saved   []dataaccess.ExportJob
+	expired []dataaccess.ExportJob
+}
+
+func (da *stubJobDataAccess) ClaimExportJob(now time.Time) (*dataaccess.ExportJob, bool, error) {
+	if len(da.pending) == 0 {
+		return nil, false, nil
+	}
+	j := da.pending[0]
+	da.pending = da.pending[1:]
+	j.Status, j.Started = dataaccess.ExportRunning, now
+	return j, true, nil
+}
+
+func (da *stubJobDataAccess) SaveExportJob(j *dataaccess.ExportJob) error {
+	da.saved = append(da.saved, *j)
+	return nil
+}
+
+func (da *stubJobDataAccess) PurgeExportJobs(before time.Time) ([]dataaccess.ExportJob, error) {
+	return da.expired, nil
+}
+
+type stubStore struct {
+	files   map[string][]byte
+	deleted []string
+	err     error
+}
+
+func (s *stubStore) Put(name string, data []byte) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.files[name] = data
+	return nil
+}
+
+func (s *stubStore) Get(name string) ([]byte, error) {
+	return s.files[name], nil
+}
+
+func (s *stubStore) Delete(name string) error {
+	s.deleted = append(s.deleted, name)
+	return nil
+}
+
+var july = time.Date(2017, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestRunner(da dataaccess.DataAccess, store *stubStore) *Runner {
+	r := NewRunner(da, store, DefaultRetention)
+	r.now = func() time.Time { return july }
+	return r
+}
+
+func TestThatExportJobsAreWrittenToTheStore(t *testing.T) {
+	da := &stubJobDataAccess{
+		stubDataAccess: stubDataAccess{profiles: []dataaccess.Profile{
+			{EmailAddress: "a@github.com", Domain: "github.com", Name: "Alice, A", Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}, LastUpdated: july},
+		}},
+		pending: []*dataaccess.ExportJob{
+			dataaccess.NewExportJob("github.com", "admin@github.com", Profiles, CSV, july),
+			dataaccess.NewExportJob("github.com", "admin@github.com", Skills, XLSX, july),
+		},
+	}
+	store := &stubStore{files: map[string][]byte{}}
+
+	if err := newTestRunner(da, store).Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+
+	if len(da.saved) != 2 {
+		t.Fatalf("Expected both jobs to be saved, but got %v.", da.saved)
+	}
+	for _, j := range da.saved {
+		if j.Status != dataaccess.ExportSucceeded || j.Rows != 1 || j.Size != len(store.files[j.Blob]) {
+			t.Errorf("Expected the job to succeed with 1 row in its file, but got %+v.", j)
+		}
+		if !reflect.DeepEqual(j.Exported, []string{"a@github.com"}) {
+			t.Errorf("Expected the people exported to be kept for the access log, but got %v.", j.Exported)
+		}
+		if !j.Expires.Equal(july.Add(DefaultRetention)) {
+			t.Errorf("Expected the job to expire after the retention period, but got %v.", j.Expires)
+		}
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(store.files[da.saved[0].Blob])).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"a@github.com", "github.com", "Alice, A", "", "0", "", "0", "2017-07-01T00:00:00Z", "1"}
+	if len(records) != 2 || !reflect.DeepEqual(records[1], expected) {
+		t.Errorf("Expected the header and %v, but got %v.", expected, records)
+	}
+}
+
+func TestThatFailedExportJobsAreMarkedAsFailed(t *testing.T) {
+	da := &stubJobDataAccess{pending: []*dataaccess.ExportJob{
+		dataaccess.NewExportJob("github.com", "admin@github.com", Profiles, Parquet, july),
+	}}
+	store := &stubStore{files: map[string][]byte{}, err: errors.New("store unavailable")}
+
+	if err := newTestRunner(da, store).Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+	if len(da.saved) != 1 || da.saved[0].Status != dataaccess.ExportFailed || da.saved[0].Error != "store unavailable" {
+		t.Errorf("Expected the job to fail, but got %+v.", da.saved)
+	}
+}
+
+func TestThatTheFilesOfExpiredExportJobsAreDeleted(t *testing.T) {
+	da := &stubJobDataAccess{expired: []dataaccess.ExportJob{{ID: "a", Blob: "exports/a"}, {ID: "b"}}}
+	store := &stubStore{files: map[string][]byte{}}
+
+	if err := newTestRunner(da, store).Purge(context.Background()); err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+	if !reflect.DeepEqual(store.deleted, []string{"exports/a"}) {
+		t.Errorf("Expected the file of the expired job to be deleted, but got %v.", store.deleted)
+	}
+}
+
+func TestThatUnsupportedFormatsAreNotExported(t *testing.T) {
+	r := newTestRunner(&stubJobDataAccess{}, &stubStore{})
+	if _, err := r.Write(ioutil.Discard, "github.com", OnePagers, CSV); err == nil {
+		t.Error("Expected one pagers not to be exported as CSV.")
+	}
+}
+
+func TestThatSpreadsheetsAreWrittenAsWorkbooks(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeXLSX(&buf, []string{"name", "level"}, [][]string{{"<Alice & Bob>", "3"}}, []bool{false, true})
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Expected a zip file, but got %v.", err)
+	}
+	var sheet string
+	for _, f := range z.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, _ := f.Open()
+			b, _ := ioutil.ReadAll(rc)
+			sheet = string(b)
+		}
+	}
+	for _, expected := range []string{`<c r="A2" t="inlineStr"><is><t xml:space="preserve">&lt;Alice &amp; Bob&gt;</t></is></c>`, `<c r="B2"><v>3</v></c>`} {
+		if !strings.Contains(sheet, expected) {
+			t.Errorf("Expected the sheet to contain %s, but got %s.", expected, sheet)
+		}
+	}
+}
+
+func TestThatSpreadsheetColumnsAreLettered(t *testing.T) {
+	for i, expected := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		if actual := xlsxColumn(i); actual != expected {
+			t.Errorf("Expected column %d to be %s, but got %s.", i, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// The parts of a workbook which don't depend on its contents.
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// writeXLSX writes the rows as the only sheet of an Excel workbook, with the
+// header as its first row. Numbers are written as numbers, and everything
+// else as inline strings, so no shared strings table is needed.
+func writeXLSX(w io.Writer, header []string, rows [][]string, numeric []bool) error {
+	z := zip.NewWriter(w)
+	for _, p := range xlsxParts {
+		f, err := z.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return err
+		}
+	}
+
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	io.WriteString(f, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`+"\n"+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err := writeXLSXRow(f, 1, header, nil); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		if err := writeXLSXRow(f, i+2, row, numeric); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(f, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return z.Close()
+}
+
+func writeXLSXRow(w io.Writer, n int, cells []string, numeric []bool) error {
+	if _, err := fmt.Fprintf(w, `<row r="%d">`, n); err != nil {
+		return err
+	}
+	for i, c := range cells {
+		if c == "" {
+			continue
+		}
+		ref := xlsxColumn(i) + strconv.Itoa(n)
+		if i < len(numeric) && numeric[i] {
+			fmt.Fprintf(w, `<c r="%s"><v>%s</v></c>`, ref, c)
+			continue
+		}
+		fmt.Fprintf(w, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+		if err := xml.EscapeText(w, []byte(c)); err != nil {
+			return err
+		}
+		io.WriteString(w, `</t></is></c>`)
+	}
+	_, err := io.WriteString(w, `</row>`)
+	return err
+}
+
+// xlsxColumn returns the letters of the zero based column, e.g. A, Z or AA.
+func xlsxColumn(i int) string {
+	var s string
+	for i++; i > 0; i = (i - 1) / 26 {
+		s = string(rune('A'+(i-1)%26)) + s
+	}
+	return s
+}
@@ -0,0 +1,115 @@
+// Package goals tracks the progress people make towards their learning
+// goals, reminds them of goals which are nearly due, and rolls goals up for
+// managers.
+package goals
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+// The Tracker marks goals as achieved when people reach their target levels.
+// It is an events.Publisher, so it receives the changes made through a
+// NotifyingDataAccess.
+type Tracker struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewTracker creates a Tracker which saves goals through the DataAccess.
+func NewTracker(da dataaccess.DataAccess) *Tracker {
+	return &Tracker{da, time.Now}
+}
+
+// Publish marks the goals achieved by the change.
+func (t *Tracker) Publish(e events.Event) {
+	if e.Type != events.ProfileUpdated {
+		return
+	}
+	p, ok := e.Data.(*dataaccess.Profile)
+	if !ok || p == nil || !p.AchieveLearningGoals(t.now()) {
+		return
+	}
+	if err := t.DataAccess.UpdateLearningGoals(p.EmailAddress, p.Goals); err != nil {
+		log.Printf("Failed to update the learning goals of %s. %v", p.EmailAddress, err)
+		return
+	}
+	log.Printf("Updated the achieved learning goals of %s.", p.EmailAddress)
+}
+
+// A Progress is a goal with the person's current level of the skill.
+type Progress struct {
+	dataaccess.LearningGoal
+	Level   dataaccess.DreyfusLevel `json:"level"`
+	Overdue bool                    `json:"overdue"`
+}
+
+// A Person is someone in a team and their goals, soonest due first.
+type Person struct {
+	EmailAddress string     `json:"emailAddress"`
+	Name         string     `json:"name,omitempty"`
+	Goals        []Progress `json:"goals"`
+}
+
+// A RollUp summarises the learning goals of a team.
+type RollUp struct {
+	// Statuses counts the goals in each state.
+	Statuses map[dataaccess.GoalStatus]int `json:"statuses"`
+	// Overdue is the number of open goals past their due dates.
+	Overdue int `json:"overdue"`
+	// People lists the people with goals, most overdue first.
+	People []Person `json:"people"`
+	// Skills counts the open goals for each skill.
+	Skills map[string]int `json:"skills"`
+}
+
+// Compile rolls up the goals of the profiles at the time.
+func Compile(profiles []dataaccess.Profile, at time.Time) RollUp {
+	r := RollUp{
+		Statuses: map[dataaccess.GoalStatus]int{},
+		People:   []Person{},
+		Skills:   map[string]int{},
+	}
+
+	overdue := make(map[string]int)
+	for _, p := range profiles {
+		if len(p.Goals) == 0 {
+			continue
+		}
+		person := Person{EmailAddress: strings.ToLower(p.EmailAddress), Name: p.Name, Goals: []Progress{}}
+		for _, g := range p.Goals {
+			gp := Progress{LearningGoal: g, Overdue: g.Overdue(at)}
+			for _, s := range p.Skills {
+				if s.Skill == g.Skill {
+					gp.Level = s.Level
+				}
+			}
+			person.Goals = append(person.Goals, gp)
+
+			r.Statuses[g.Status]++
+			if g.Open() {
+				r.Skills[g.Skill]++
+			}
+			if gp.Overdue {
+				r.Overdue++
+				overdue[person.EmailAddress]++
+			}
+		}
+		sort.Slice(person.Goals, func(i, j int) bool { return person.Goals[i].Due.Before(person.Goals[j].Due) })
+		r.People = append(r.People, person)
+	}
+
+	sort.Slice(r.People, func(i, j int) bool {
+		a, b := r.People[i], r.People[j]
+		if overdue[a.EmailAddress] != overdue[b.EmailAddress] {
+			return overdue[a.EmailAddress] > overdue[b.EmailAddress]
+		}
+		return a.EmailAddress < b.EmailAddress
+	})
+	return r
+}
@@ -0,0 +1,85 @@
+package goals
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+var august = time.Date(2017, time.August, 14, 0, 0, 0, 0, time.UTC)
+
+func TestThatGoalsAreRolledUp(t *testing.T) {
+	overdue := dataaccess.NewLearningGoal("terraform", 3, august.AddDate(0, -1, 0), august.AddDate(0, -6, 0))
+	inProgress := dataaccess.NewLearningGoal("go", 4, august.AddDate(0, 1, 0), august)
+	inProgress.Status = dataaccess.GoalInProgress
+	achieved := dataaccess.NewLearningGoal("go", 2, august.AddDate(0, -1, 0), august.AddDate(0, -6, 0))
+	achieved.Status = dataaccess.GoalAchieved
+	profiles := []dataaccess.Profile{
+		{EmailAddress: "a@github.com", Goals: []dataaccess.LearningGoal{inProgress, achieved}, Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}},
+		{EmailAddress: "B@github.com", Goals: []dataaccess.LearningGoal{overdue}},
+		{EmailAddress: "nogoals@github.com"},
+	}
+
+	r := Compile(profiles, august)
+
+	if r.Overdue != 1 || r.Statuses[dataaccess.GoalPlanned] != 1 || r.Statuses[dataaccess.GoalInProgress] != 1 || r.Statuses[dataaccess.GoalAchieved] != 1 {
+		t.Errorf("Unexpected counts %v with %d overdue.", r.Statuses, r.Overdue)
+	}
+	if r.Skills["go"] != 1 || r.Skills["terraform"] != 1 {
+		t.Errorf("Expected one open goal for go and terraform, but received %v", r.Skills)
+	}
+	if len(r.People) != 2 || r.People[0].EmailAddress != "b@github.com" {
+		t.Fatalf("Expected the person with an overdue goal first, but received %v", r.People)
+	}
+	if a := r.People[1]; a.Goals[0].Skill != "go" || a.Goals[0].Status != dataaccess.GoalAchieved || a.Goals[1].Level != 3 {
+		t.Errorf("Expected a@github.com's goals soonest first with their levels, but received %v", a.Goals)
+	}
+}
+
+func TestThatRemindersAreSentBeforeGoalsAreDue(t *testing.T) {
+	soon := dataaccess.NewLearningGoal("terraform", 3, august.Add(ReminderPeriod-time.Hour), august)
+	later := dataaccess.NewLearningGoal("go", 3, august.Add(ReminderPeriod+time.Hour), august)
+	reminded := dataaccess.NewLearningGoal("sql", 3, august.Add(time.Hour), august)
+	reminded.Reminded = august.Add(-time.Hour)
+	achieved := dataaccess.NewLearningGoal("rust", 3, august.Add(-time.Hour), august)
+	achieved.Status = dataaccess.GoalAchieved
+	p := dataaccess.Profile{EmailAddress: "a@github.com", Goals: []dataaccess.LearningGoal{soon, later, reminded, achieved}}
+
+	due := Due(p, august)
+
+	if len(due) != 1 || due[0].ID != soon.ID {
+		t.Fatalf("Expected a reminder about terraform, but received %v", due)
+	}
+	n := Notification(p, due, "https://pill.example.com/", august)
+	if n.Category != dataaccess.ReminderCategory || n.To != "a@github.com" {
+		t.Errorf("Unexpected notification %+v", n)
+	}
+}
+
+type goalStore struct {
+	dataaccess.DataAccess
+	updated []dataaccess.LearningGoal
+}
+
+func (s *goalStore) UpdateLearningGoals(emailAddress string, goals []dataaccess.LearningGoal) error {
+	s.updated = goals
+	return nil
+}
+
+func TestThatTheTrackerSavesAchievedGoals(t *testing.T) {
+	store := &goalStore{}
+	tracker := NewTracker(store)
+	p := &dataaccess.Profile{
+		EmailAddress: "a@github.com",
+		Skills:       []dataaccess.Skill{{Skill: "go", Level: 4}},
+		Goals:        []dataaccess.LearningGoal{dataaccess.NewLearningGoal("go", 4, august, august)},
+	}
+
+	tracker.Publish(events.NewEvent(events.ProfileUpdated, "github.com", p.EmailAddress, p))
+
+	if len(store.updated) != 1 || store.updated[0].Status != dataaccess.GoalAchieved {
+		t.Errorf("Expected the achieved goal to be saved, but received %v", store.updated)
+	}
+}
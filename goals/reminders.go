@@ -0,0 +1,127 @@
+package goals
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/i18n"
+	"github.com/a-h/pill/notifications"
+)
+
+// ReminderPeriod is how long before a goal is due people are reminded of it.
+const ReminderPeriod = 30 * 24 * time.Hour
+
+// ReminderInterval is the least time between reminders about a goal.
+const ReminderInterval = 7 * 24 * time.Hour
+
+// A ReminderJob reminds people of their open goals which are nearly due, or
+// overdue, in every domain which has email notifications enabled. It should
+// run once a day.
+type ReminderJob struct {
+	DataAccess dataaccess.DataAccess
+	Notifier   *notifications.Notifier
+	// BaseURL is the address of the pill website.
+	BaseURL string
+	now     func() time.Time
+}
+
+// NewReminderJob creates an instance of the ReminderJob.
+func NewReminderJob(da dataaccess.DataAccess, notifier *notifications.Notifier, baseURL string) *ReminderJob {
+	return &ReminderJob{da, notifier, baseURL, time.Now}
+}
+
+// Due returns the goals the person should be reminded about at the time.
+func Due(p dataaccess.Profile, at time.Time) []dataaccess.LearningGoal {
+	var op []dataaccess.LearningGoal
+	for _, g := range p.Goals {
+		if g.Open() && g.Due.Sub(at) <= ReminderPeriod && at.Sub(g.Reminded) >= ReminderInterval {
+			op = append(op, g)
+		}
+	}
+	return op
+}
+
+// Notification reminds the person about the goals.
+func Notification(p dataaccess.Profile, due []dataaccess.LearningGoal, baseURL string, at time.Time) notifications.Notification {
+	t := i18n.For(p.Language)
+	lines := []string{t("goals.reminder.intro")}
+	for _, g := range due {
+		key := "goals.reminder.due"
+		if g.Overdue(at) {
+			key = "goals.reminder.overdue"
+		}
+		lines = append(lines, "* "+t(key, g.Target, g.Skill, g.Due.In(p.Location()).Format(i18n.Message(p.Language, "date.long"))))
+	}
+	lines = append(lines, "", t("goals.reminder.update", strings.TrimSuffix(baseURL, "/")+"/profile/"))
+
+	return notifications.Notification{
+		Category: dataaccess.ReminderCategory,
+		To:       p.EmailAddress,
+		Subject:  t("goals.reminder.subject"),
+		Text:     strings.Join(lines, "\n"),
+	}
+}
+
+// Run sends the reminders. Failures to notify individual people are logged,
+// so that one bad address doesn't prevent the rest of the reminders being
+// sent, and the last error is returned.
+func (j *ReminderJob) Run(ctx context.Context) error {
+	now := j.now()
+
+	domains, err := j.DataAccess.ListDomains()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		settings, err := j.DataAccess.GetSettings(domain)
+		if err != nil {
+			return err
+		}
+		if !settings.Notifications.EmailEnabled {
+			log.Printf("Not sending goal reminders for %s because notifications are disabled.", domain)
+			continue
+		}
+
+		profiles, err := j.DataAccess.ListProfiles("@" + domain)
+		if err != nil {
+			return err
+		}
+
+		sent := 0
+		for _, p := range profiles {
+			due := Due(p, now)
+			if len(due) == 0 {
+				continue
+			}
+			if err := j.Notifier.Notify(p.Notifications, Notification(p, due, j.BaseURL, now)); err != nil {
+				log.Printf("Failed to remind %s of their learning goals. %v", p.EmailAddress, err)
+				lastErr = err
+				continue
+			}
+			for i, g := range p.Goals {
+				for _, d := range due {
+					if g.ID == d.ID {
+						p.Goals[i].Reminded = now
+					}
+				}
+			}
+			if err := j.DataAccess.UpdateLearningGoals(p.EmailAddress, p.Goals); err != nil {
+				log.Printf("Failed to record the goal reminders sent to %s. %v", p.EmailAddress, err)
+				lastErr = err
+			}
+			sent++
+		}
+		log.Printf("Sent %d goal reminders for %s.", sent, domain)
+	}
+
+	return lastErr
+}
@@ -0,0 +1,246 @@
+// Package holidays reads public holiday calendars, and marks people as
+// unavailable on the public holidays of the country they work in, so that
+// bank holidays aren't counted as billable days.
+package holidays
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// A Holiday is a public holiday.
+type Holiday struct {
+	// Date is the day of the holiday, at midnight UTC.
+	Date time.Time
+	Name string
+}
+
+// A Source lists the public holidays of countries.
+type Source interface {
+	// Name identifies the calendar, e.g. "nager".
+	Name() string
+	// Holidays returns the country's public holidays in the year. The
+	// country is an ISO 3166-1 alpha-2 code, e.g. "GB".
+	Holidays(ctx context.Context, country string, year int) ([]Holiday, error)
+}
+
+// NagerURL is the address of the public Nager.Date API.
+const NagerURL = "https://date.nager.at"
+
+// OpenSource returns the Source for the URL. "nager" is the public
+// Nager.Date API, and the https address of a self-hosted copy can be used
+// instead. Addresses which contain {country}, e.g.
+// https://example.com/holidays/{country}.ics, are iCalendar feeds with the
+// country's code in place of {country}.
+func OpenSource(rawurl string) (Source, error) {
+	if rawurl == "nager" {
+		return NewNagerSource(NagerURL), nil
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "webcal":
+		rawurl = "https" + strings.TrimPrefix(rawurl, "webcal")
+	case "https", "http":
+	default:
+		return nil, fmt.Errorf("holidays: unsupported holiday source '%s', use nager or an https address", rawurl)
+	}
+	if strings.Contains(rawurl, "{country}") {
+		return NewICalendarSource(rawurl), nil
+	}
+	return NewNagerSource(strings.TrimRight(rawurl, "/")), nil
+}
+
+// NagerSource reads public holidays from the Nager.Date API.
+type NagerSource struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewNagerSource creates an instance of the NagerSource.
+func NewNagerSource(baseURL string) *NagerSource {
+	return &NagerSource{baseURL, httpClient}
+}
+
+// Name returns "nager".
+func (s NagerSource) Name() string {
+	return "nager"
+}
+
+// Holidays returns the holidays observed across the whole country. Regional
+// holidays, e.g. those only observed in Scotland, are left out, because
+// people's work locations don't include their region.
+func (s NagerSource) Holidays(ctx context.Context, country string, year int) ([]Holiday, error) {
+	u := s.BaseURL + "/api/v3/PublicHolidays/" + strconv.Itoa(year) + "/" + url.PathEscape(country)
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("nager: the holidays of %s in %d returned status %d", country, year, resp.StatusCode)
+	}
+	var holidays []struct {
+		Date   string `json:"date"`
+		Name   string `json:"name"`
+		Global bool   `json:"global"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&holidays); err != nil {
+		return nil, err
+	}
+
+	var op []Holiday
+	for _, h := range holidays {
+		if !h.Global {
+			continue
+		}
+		d, err := time.Parse("2006-01-02", h.Date)
+		if err != nil {
+			return nil, fmt.Errorf("nager: the holiday '%s' of %s is on '%s'", h.Name, country, h.Date)
+		}
+		op = append(op, Holiday{Date: d, Name: h.Name})
+	}
+	return op, nil
+}
+
+// ICalendarSource reads public holidays from an iCalendar feed for each
+// country.
+type ICalendarSource struct {
+	// URL is the address of the feeds, with {country} in place of the
+	// country's code.
+	URL    string
+	Client *http.Client
+}
+
+// NewICalendarSource creates an instance of the ICalendarSource.
+func NewICalendarSource(url string) *ICalendarSource {
+	return &ICalendarSource{url, httpClient}
+}
+
+// Name returns "ical".
+func (s ICalendarSource) Name() string {
+	return "ical"
+}
+
+// Holidays returns the days of the events in the country's feed which fall
+// in the year.
+func (s ICalendarSource) Holidays(ctx context.Context, country string, year int) ([]Holiday, error) {
+	req, err := http.NewRequest("GET", strings.Replace(s.URL, "{country}", url.PathEscape(country), -1), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/calendar")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("ical: the holidays of %s returned status %d", country, resp.StatusCode)
+	}
+
+	holidays, err := ParseICalendar(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var op []Holiday
+	for _, h := range holidays {
+		if h.Date.Year() == year {
+			op = append(op, h)
+		}
+	}
+	return op, nil
+}
+
+// ParseICalendar reads the days of the events in an iCalendar document.
+// Events which last several days are a holiday on each of them.
+func ParseICalendar(r io.Reader) ([]Holiday, error) {
+	var op []Holiday
+	var event map[string]string
+	var last string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// Long lines are folded onto lines starting with white space.
+		if event != nil && last != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			event[last] += line[1:]
+			continue
+		}
+		switch line {
+		case "BEGIN:VEVENT":
+			event = make(map[string]string)
+			continue
+		case "END:VEVENT":
+			holidays, err := eventHolidays(event)
+			if err != nil {
+				return nil, err
+			}
+			op = append(op, holidays...)
+			event, last = nil, ""
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if event == nil || len(parts) != 2 {
+			continue
+		}
+		// Parameters, e.g. DTSTART;VALUE=DATE, are left out.
+		last = strings.ToUpper(strings.SplitN(parts[0], ";", 2)[0])
+		if _, ok := event[last]; !ok {
+			event[last] = parts[1]
+		}
+	}
+	return op, scanner.Err()
+}
+
+// eventHolidays returns a holiday for each day of the event.
+func eventHolidays(event map[string]string) ([]Holiday, error) {
+	if strings.EqualFold(event["STATUS"], "CANCELLED") {
+		return nil, nil
+	}
+	name := strings.TrimSpace(event["SUMMARY"])
+	start, err := icalDate(event["DTSTART"])
+	if err != nil {
+		return nil, fmt.Errorf("ical: the holiday '%s' starts on '%s'", name, event["DTSTART"])
+	}
+	end := start.AddDate(0, 0, 1)
+	if dtend, ok := event["DTEND"]; ok {
+		if end, err = icalDate(dtend); err != nil {
+			return nil, fmt.Errorf("ical: the holiday '%s' ends on '%s'", name, dtend)
+		}
+	}
+	var op []Holiday
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		op = append(op, Holiday{Date: d, Name: name})
+	}
+	return op, nil
+}
+
+// icalDate reads the date of a date or time, e.g. 20181225 or
+// 20181225T000000Z.
+func icalDate(v string) (time.Time, error) {
+	if len(v) < 8 {
+		return time.Time{}, fmt.Errorf("ical: '%s' isn't a date", v)
+	}
+	return time.Parse("20060102", v[:8])
+}
@@ -0,0 +1,187 @@
+package holidays
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type profileStore struct {
+	dataaccess.DataAccess
+	profiles map[string]dataaccess.Profile
+	settings dataaccess.Settings
+}
+
+func (s *profileStore) ListDomains() ([]string, error) {
+	return []string{"github.com"}, nil
+}
+
+func (s *profileStore) GetSettings(domain string) (dataaccess.Settings, error) {
+	return s.settings, nil
+}
+
+func (s *profileStore) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	var op []dataaccess.Profile
+	for _, p := range s.profiles {
+		if strings.HasSuffix(p.EmailAddress, emailAddress) {
+			op = append(op, p)
+		}
+	}
+	return op, nil
+}
+
+func (s *profileStore) UpdateAvailabilityWindows(emailAddress string, windows []dataaccess.AvailabilityWindow) error {
+	p := s.profiles[emailAddress]
+	p.AvailabilityWindows = windows
+	s.profiles[emailAddress] = p
+	return nil
+}
+
+type memoryCalendar map[string][]Holiday
+
+func (s memoryCalendar) Name() string {
+	return "memory"
+}
+
+func (s memoryCalendar) Holidays(ctx context.Context, country string, year int) ([]Holiday, error) {
+	holidays, ok := s[country]
+	if !ok {
+		return nil, errors.New("unknown country")
+	}
+	var op []Holiday
+	for _, h := range holidays {
+		if h.Date.Year() == year {
+			op = append(op, h)
+		}
+	}
+	return op, nil
+}
+
+func TestThatSourcesAreOpenedFromTheirURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected Source
+	}{
+		{"nager", NewNagerSource(NagerURL)},
+		{"https://holidays.example.com/", NewNagerSource("https://holidays.example.com")},
+		{"webcal://example.com/{country}.ics", NewICalendarSource("https://example.com/{country}.ics")},
+		{"ftp://example.com", nil},
+	}
+
+	for _, test := range tests {
+		actual, err := OpenSource(test.url)
+		if test.expected == nil && err == nil || test.expected != nil && !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("For '%s', expected %v, but got %v, %v", test.url, test.expected, actual, err)
+		}
+	}
+}
+
+func TestThatNationalNagerHolidaysAreRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/PublicHolidays/2018/GB" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`[{"date":"2018-11-30","localName":"Saint Andrew's Day","name":"Saint Andrew's Day","countryCode":"GB","global":false,"counties":["GB-SCT"]},
+			{"date":"2018-12-25","localName":"Christmas Day","name":"Christmas Day","countryCode":"GB","global":true,"counties":null}]`))
+	}))
+	defer server.Close()
+
+	holidays, err := NewNagerSource(server.URL).Holidays(context.Background(), "GB", 2018)
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	expected := []Holiday{{Date: time.Date(2018, time.December, 25, 0, 0, 0, 0, time.UTC), Name: "Christmas Day"}}
+	if !reflect.DeepEqual(holidays, expected) {
+		t.Errorf("Expected only Christmas Day, but got %v", holidays)
+	}
+
+	if _, err := NewNagerSource(server.URL).Holidays(context.Background(), "XX", 2018); err == nil {
+		t.Error("Expected an error for an unknown country.")
+	}
+}
+
+func TestThatICalendarEventsAreReadAsHolidays(t *testing.T) {
+	ics := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20181225\r\nDTEND;VALUE=DATE:20181227\r\nSUMMARY:Christmas\r\n  holidays\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20190101\r\nSUMMARY:New Year's Day\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20181226\r\nSTATUS:CANCELLED\r\nSUMMARY:Cancelled\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	holidays, err := ParseICalendar(strings.NewReader(ics))
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	expected := []Holiday{
+		{Date: time.Date(2018, time.December, 25, 0, 0, 0, 0, time.UTC), Name: "Christmas holidays"},
+		{Date: time.Date(2018, time.December, 26, 0, 0, 0, 0, time.UTC), Name: "Christmas holidays"},
+		{Date: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC), Name: "New Year's Day"},
+	}
+	if !reflect.DeepEqual(holidays, expected) {
+		t.Errorf("Expected %v, but got %v", expected, holidays)
+	}
+}
+
+func TestThatHolidaysAreImportedForEachPersonsCountry(t *testing.T) {
+	now := time.Date(2018, time.December, 1, 12, 0, 0, 0, time.UTC)
+	christmas := time.Date(2018, time.December, 25, 0, 0, 0, 0, time.UTC)
+	manual := dataaccess.AvailabilityWindow{Start: now.AddDate(0, 0, 1), End: now.AddDate(0, 0, 2), Availability: dataaccess.Amber, Note: "Conference"}
+	moved := dataaccess.AvailabilityWindow{Start: christmas, End: christmas.AddDate(0, 0, 1), Availability: dataaccess.Red, Note: "Christmas Day", Source: dataaccess.HolidaySource}
+	store := &profileStore{
+		profiles: map[string]dataaccess.Profile{
+			"london@github.com": {EmailAddress: "london@github.com", TimeZone: "Europe/London", AvailabilityWindows: []dataaccess.AvailabilityWindow{manual}},
+			"berlin@github.com": {EmailAddress: "berlin@github.com", TimeZone: "Europe/Berlin",
+				WorkLocation: &dataaccess.WorkLocation{City: "Berlin", Country: "DE"}},
+			"moved@github.com": {EmailAddress: "moved@github.com", WorkLocation: &dataaccess.WorkLocation{Country: "US"},
+				AvailabilityWindows: []dataaccess.AvailabilityWindow{moved}},
+		},
+		settings: dataaccess.Settings{Holidays: dataaccess.HolidaySettings{Country: "GB"}},
+	}
+	calendar := memoryCalendar{
+		"GB": {{Date: christmas, Name: "Christmas Day"}, {Date: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC), Name: "New Year's Day"}},
+		"DE": {{Date: time.Date(2018, time.December, 26, 0, 0, 0, 0, time.UTC), Name: "Zweiter Weihnachtsfeiertag"}},
+	}
+
+	im := NewImporter(store, calendar)
+	im.now = func() time.Time { return now }
+	r, err := im.Import(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	if !reflect.DeepEqual(r.Updated, []string{"berlin@github.com", "london@github.com"}) || !reflect.DeepEqual(r.Failed, []string{"US"}) || r.Windows != 3 {
+		t.Errorf("Expected London and Berlin to be updated, and the US calendar to fail, but got %+v", r)
+	}
+	london := store.profiles["london@github.com"].AvailabilityWindows
+	if len(london) != 3 || !reflect.DeepEqual(london[0], manual) || london[1].Note != "Christmas Day" || london[2].Note != "New Year's Day" {
+		t.Errorf("Expected the manual window to be kept and the GB holidays added, but got %v", london)
+	}
+	// Holidays are from midnight in the person's time zone, which is 11pm
+	// UTC in Berlin in winter.
+	berlin := store.profiles["berlin@github.com"].AvailabilityWindows
+	expected := dataaccess.AvailabilityWindow{
+		Start:        time.Date(2018, time.December, 25, 23, 0, 0, 0, time.UTC),
+		End:          time.Date(2018, time.December, 26, 23, 0, 0, 0, time.UTC),
+		Availability: dataaccess.Red,
+		Note:         "Zweiter Weihnachtsfeiertag",
+		Source:       dataaccess.HolidaySource,
+	}
+	if !reflect.DeepEqual(berlin, []dataaccess.AvailabilityWindow{expected}) {
+		t.Errorf("Expected %v, but got %v", expected, berlin)
+	}
+	if moved := store.profiles["moved@github.com"].AvailabilityWindows; len(moved) != 1 {
+		t.Errorf("Expected the holidays to be kept when the calendar can't be read, but got %v", moved)
+	}
+
+	r, _ = im.Import(context.Background())
+	if len(r.Updated) != 0 {
+		t.Errorf("Expected nothing to change the second time, but %v were updated", r.Updated)
+	}
+}
@@ -0,0 +1,149 @@
+package holidays
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// DefaultDays is how far ahead public holidays are imported.
+const DefaultDays = 365
+
+// An Importer adds the public holidays of the country each person works in
+// to the availability windows of their profile. The country comes from
+// their work location, or their tenant's holiday settings.
+type Importer struct {
+	DataAccess dataaccess.DataAccess
+	Source     Source
+	// Days is how far ahead holidays are imported.
+	Days int
+	now  func() time.Time
+}
+
+// NewImporter creates an instance of the Importer.
+func NewImporter(da dataaccess.DataAccess, source Source) *Importer {
+	return &Importer{da, source, DefaultDays, time.Now}
+}
+
+// A Report sums up an import.
+type Report struct {
+	// Updated is the profiles whose holidays have changed.
+	Updated []string `json:"updated"`
+	// Windows is the number of holidays imported.
+	Windows int `json:"windows"`
+	// Failed is the countries whose holidays couldn't be read. The holidays
+	// of the people who work in them are left as they were.
+	Failed []string `json:"failed"`
+}
+
+// Import replaces the holidays in the availability windows which haven't
+// ended with those in the calendar, marking them as red. People whose
+// country isn't known have their holidays removed.
+func (im Importer) Import(ctx context.Context) (Report, error) {
+	r := Report{Updated: []string{}, Failed: []string{}}
+	da := dataaccess.WithContext(im.DataAccess, ctx)
+	from := im.now().UTC()
+	to := from.AddDate(0, 0, im.Days)
+
+	domains, err := da.ListDomains()
+	if err != nil {
+		return r, err
+	}
+
+	calendars := make(map[string][]Holiday)
+	failed := make(map[string]bool)
+	for _, domain := range domains {
+		settings, err := da.GetSettings(domain)
+		if err != nil {
+			return r, err
+		}
+		profiles, err := da.ListProfiles("@" + domain)
+		if err != nil {
+			return r, err
+		}
+		for _, p := range profiles {
+			if ctx.Err() != nil {
+				return r, ctx.Err()
+			}
+			country := p.HolidayCountry(settings.Holidays)
+			if country != "" {
+				if _, ok := calendars[country]; !ok && !failed[country] {
+					if calendars[country], err = im.holidays(ctx, country, from, to); err != nil {
+						log.Printf("Failed to read the public holidays of %s, so they haven't been imported. %v", country, err)
+						delete(calendars, country)
+						failed[country] = true
+					}
+				}
+				if failed[country] {
+					continue
+				}
+			}
+
+			var next []dataaccess.AvailabilityWindow
+			for _, h := range calendars[country] {
+				if w := window(p, h); w.End.After(from) {
+					next = append(next, w)
+				}
+			}
+			r.Windows += len(next)
+			windows, changed := dataaccess.ReplaceImportedWindows(p.AvailabilityWindows, dataaccess.HolidaySource, from, next)
+			if !changed {
+				continue
+			}
+			if err := da.UpdateAvailabilityWindows(p.EmailAddress, windows); err != nil {
+				return r, err
+			}
+			r.Updated = append(r.Updated, p.EmailAddress)
+		}
+	}
+
+	for country := range failed {
+		r.Failed = append(r.Failed, country)
+	}
+	sort.Strings(r.Updated)
+	sort.Strings(r.Failed)
+	return r, nil
+}
+
+// holidays returns the country's holidays in each year the period covers.
+func (im Importer) holidays(ctx context.Context, country string, from, to time.Time) ([]Holiday, error) {
+	var op []Holiday
+	for year := from.Year(); year <= to.Year(); year++ {
+		holidays, err := im.Source.Holidays(ctx, country, year)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range holidays {
+			if h.Date.Before(to) {
+				op = append(op, h)
+			}
+		}
+	}
+	return op, nil
+}
+
+// window returns the availability window of the holiday, from midnight to
+// midnight in the person's time zone.
+func window(p dataaccess.Profile, h Holiday) dataaccess.AvailabilityWindow {
+	loc := p.Location()
+	return dataaccess.AvailabilityWindow{
+		Start:        time.Date(h.Date.Year(), h.Date.Month(), h.Date.Day(), 0, 0, 0, 0, loc).UTC(),
+		End:          time.Date(h.Date.Year(), h.Date.Month(), h.Date.Day()+1, 0, 0, 0, 0, loc).UTC(),
+		Availability: dataaccess.Red,
+		Note:         h.Name,
+		Source:       dataaccess.HolidaySource,
+	}
+}
+
+// Run imports the holidays, for use as a scheduled job.
+func (im Importer) Run(ctx context.Context) error {
+	r, err := im.Import(ctx)
+	if err == nil {
+		log.Printf("Imported %d public holidays from %s: %d profiles updated, and %d countries failed.",
+			r.Windows, im.Source.Name(), len(r.Updated), len(r.Failed))
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package hr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// BambooHRSource reads employees through a BambooHR custom report.
+type BambooHRSource struct {
+	// BaseURL is the address of the company's API, e.g.
+	// https://api.bamboohr.com/api/gateway.php/example.
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+}
+
+// NewBambooHRSource creates an instance of the BambooHRSource.
+func NewBambooHRSource(baseURL string, apiKey string) *BambooHRSource {
+	return &BambooHRSource{baseURL, apiKey, httpClient}
+}
+
+// Name returns "bamboohr".
+func (s BambooHRSource) Name() string {
+	return "bamboohr"
+}
+
+// DefaultMapping returns BambooHR's standard fields. Managers are identified
+// by their employee ID.
+func (s BambooHRSource) DefaultMapping() Mapping {
+	return Mapping{
+		IDField:         "id",
+		EmailField:      "workEmail",
+		NameField:       "displayName",
+		ManagerField:    "supervisorEId",
+		DepartmentField: "department",
+		StatusField:     "status",
+		TerminatedField: "terminationDate",
+	}
+}
+
+// Records runs a custom report of the mapped fields, including people who
+// have left.
+func (s BambooHRSource) Records(ctx context.Context, m Mapping) ([]Record, error) {
+	body, _ := json.Marshal(map[string]interface{}{"title": "pill", "fields": m.Fields()})
+	req, err := http.NewRequest("POST", s.BaseURL+"/v1/reports/custom?format=JSON&onlyCurrent=false", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.SetBasicAuth(s.APIKey, "x")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("bamboohr: the report returned status %d", resp.StatusCode)
+	}
+	var report struct {
+		Employees []map[string]interface{} `json:"employees"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return records(report.Employees), nil
+}
+
+// records converts the JSON values of each record to strings. Nulls are
+// empty.
+func records(values []map[string]interface{}) []Record {
+	op := make([]Record, len(values))
+	for i, v := range values {
+		r := Record{}
+		for k, value := range v {
+			if value != nil {
+				r[k] = fmt.Sprint(value)
+			}
+		}
+		op[i] = r
+	}
+	return op
+}
@@ -0,0 +1,206 @@
+// Package hr syncs employee records from an HR system into profiles, so that
+// joiners get a profile, and movers' and leavers' profiles stay up to date
+// without anyone having to edit them.
+package hr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// A Record is an employee's fields, as named by the HR system.
+type Record map[string]string
+
+// A Source lists the employee records in an HR system.
+type Source interface {
+	// Name identifies the HR system, e.g. "bamboohr".
+	Name() string
+	// DefaultMapping returns the mapping for the HR system's standard
+	// fields.
+	DefaultMapping() Mapping
+	// Records returns the employees, including those who have left. The
+	// fields are those named in the mapping.
+	Records(ctx context.Context, m Mapping) ([]Record, error)
+}
+
+// OpenSource returns the Source for the URL, e.g. bamboohr://example or
+// workday://wd5-services1.myworkday.com/ccx/service/customreport2/example/isu/pill_employees.
+// The BambooHR API key is read from the BAMBOOHR_API_KEY environment
+// variable, and the Workday credentials from WORKDAY_USER and
+// WORKDAY_PASSWORD.
+func OpenSource(rawurl string) (Source, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "bamboohr":
+		if u.Host == "" {
+			return nil, fmt.Errorf("hr: the BambooHR URL must include the company's subdomain, e.g. bamboohr://example")
+		}
+		return NewBambooHRSource("https://api.bamboohr.com/api/gateway.php/"+u.Host, os.Getenv("BAMBOOHR_API_KEY")), nil
+	case "workday":
+		if u.Host == "" || u.Path == "" {
+			return nil, fmt.Errorf("hr: the Workday URL must be the address of a custom report, e.g. workday://wd5-services1.myworkday.com/ccx/service/customreport2/example/isu/pill_employees")
+		}
+		return NewWorkdaySource("https://"+u.Host+u.Path, os.Getenv("WORKDAY_USER"), os.Getenv("WORKDAY_PASSWORD")), nil
+	}
+	return nil, fmt.Errorf("hr: unsupported source '%s', use bamboohr or workday", u.Scheme)
+}
+
+// The fields of an employee which are synced.
+const (
+	IDField         = "id"
+	EmailField      = "email"
+	NameField       = "name"
+	ManagerField    = "manager"
+	DepartmentField = "department"
+	CostCenterField = "costCenter"
+	// StatusField says whether the person still works for the tenant, e.g.
+	// "Active" or "Inactive".
+	StatusField = "status"
+	// TerminatedField is the date the person leaves.
+	TerminatedField = "terminated"
+)
+
+var fields = []string{IDField, EmailField, NameField, ManagerField, DepartmentField, CostCenterField, StatusField, TerminatedField}
+
+// A Mapping names the HR system's field for each of the fields which are
+// synced. Fields which aren't mapped aren't synced.
+type Mapping map[string]string
+
+// ParseMapping reads a comma separated list of overrides, e.g.
+// "department=division,manager=supervisorEmail", and applies them to the
+// defaults.
+func ParseMapping(s string, defaults Mapping) (Mapping, error) {
+	m := Mapping{}
+	for k, v := range defaults {
+		m[k] = v
+	}
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "=", 2)
+		field := strings.TrimSpace(parts[0])
+		known := false
+		for _, f := range fields {
+			known = known || f == field
+		}
+		if !known || len(parts) != 2 {
+			return nil, fmt.Errorf("hr: field mappings must be one of %s, followed by = and the HR system's field, but was '%s'", strings.Join(fields, ", "), item)
+		}
+		if v := strings.TrimSpace(parts[1]); v != "" {
+			m[field] = v
+		} else {
+			delete(m, field)
+		}
+	}
+	if m[EmailField] == "" {
+		return nil, fmt.Errorf("hr: the email field must be mapped")
+	}
+	return m, nil
+}
+
+// Fields returns the HR system's names of the mapped fields, in order.
+func (m Mapping) Fields() []string {
+	var op []string
+	for _, f := range m {
+		op = append(op, f)
+	}
+	sort.Strings(op)
+	return op
+}
+
+// An Employee is a person in the HR system.
+type Employee struct {
+	ID           string
+	EmailAddress string
+	Name         string
+	// Manager is the email address of the person's manager.
+	Manager    string
+	Department string
+	CostCenter string
+	// Terminated is when the person left, or zero if they haven't.
+	Terminated time.Time
+}
+
+// Employees reads the records with the mapping. Managers which are
+// identified by their employee ID are replaced with their email address.
+// People who have left, or whose leaving date has passed, are terminated;
+// those without a leaving date are treated as leaving at the time.
+func (m Mapping) Employees(records []Record, at time.Time) ([]Employee, error) {
+	var op []Employee
+	emails := make(map[string]string)
+	for _, r := range records {
+		e := Employee{
+			ID:           r[m[IDField]],
+			EmailAddress: strings.ToLower(strings.TrimSpace(r[m[EmailField]])),
+			Name:         strings.TrimSpace(r[m[NameField]]),
+			Manager:      strings.TrimSpace(r[m[ManagerField]]),
+			Department:   strings.TrimSpace(r[m[DepartmentField]]),
+			CostCenter:   strings.TrimSpace(r[m[CostCenterField]]),
+		}
+		terminated, err := parseDate(r[m[TerminatedField]])
+		if err != nil {
+			return nil, fmt.Errorf("hr: employee %s: the leaving date %v", e.ID, err)
+		}
+		if !active(r[m[StatusField]]) && terminated.IsZero() {
+			terminated = at
+		}
+		if !terminated.After(at) {
+			e.Terminated = terminated
+		}
+		if e.ID != "" {
+			emails[e.ID] = e.EmailAddress
+		}
+		op = append(op, e)
+	}
+	for i, e := range op {
+		if e.Manager != "" && !strings.Contains(e.Manager, "@") {
+			op[i].Manager = emails[e.Manager]
+		}
+		op[i].Manager = strings.ToLower(op[i].Manager)
+	}
+	return op, nil
+}
+
+// active returns true unless the status says that the person has left.
+// Systems which don't have a status are treated as listing active people.
+func active(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "", "active", "1", "true", "yes", "y":
+		return true
+	}
+	return false
+}
+
+// parseDate reads a date which may or may not include a time. Empty dates,
+// and BambooHR's 0000-00-00, are zero.
+func parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0000-00-00" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if len(s) > 10 {
+		// Workday dates include the offset, e.g. 2017-03-01-08:00.
+		s = s[:10]
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return t, fmt.Errorf("must be a date, such as 2017-03-01, but was '%s'", s)
+	}
+	return t, nil
+}
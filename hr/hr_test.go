@@ -0,0 +1,311 @@
+package hr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type profileStore struct {
+	dataaccess.DataAccess
+	profiles map[string]dataaccess.Profile
+	tenants  map[string]bool
+}
+
+func (s *profileStore) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	var op []dataaccess.Profile
+	for _, p := range s.profiles {
+		if strings.HasSuffix(p.EmailAddress, emailAddress) {
+			op = append(op, p)
+		}
+	}
+	return op, nil
+}
+
+func (s *profileStore) SyncEmployee(e dataaccess.EmployeeUpdate) error {
+	p := s.profiles[e.EmailAddress]
+	p.EmailAddress, p.Name, p.Manager = e.EmailAddress, e.Name, e.Manager
+	if e.Department != nil {
+		p.Department = *e.Department
+	}
+	if e.CostCenter != nil {
+		p.CostCenter = *e.CostCenter
+	}
+	employment := e.Employment
+	p.Employment = &employment
+	s.profiles[e.EmailAddress] = p
+	return nil
+}
+
+func (s *profileStore) MergeProfiles(primary string, duplicate string) (*dataaccess.Profile, bool, error) {
+	c := dataaccess.CombineProfiles(s.profiles[primary], s.profiles[duplicate])
+	s.profiles[primary] = c
+	delete(s.profiles, duplicate)
+	return &c, true, nil
+}
+
+func (s *profileStore) GetTenantConfiguration(domain string) (*dataaccess.TenantConfiguration, bool, error) {
+	return nil, s.tenants[domain], nil
+}
+
+func (s *profileStore) UpdateTenantConfiguration(tc *dataaccess.TenantConfiguration) error {
+	s.tenants[tc.Domain] = true
+	return nil
+}
+
+type memorySource []Record
+
+func (s memorySource) Name() string {
+	return "memory"
+}
+
+func (s memorySource) DefaultMapping() Mapping {
+	return BambooHRSource{}.DefaultMapping()
+}
+
+func (s memorySource) Records(ctx context.Context, m Mapping) ([]Record, error) {
+	return s, nil
+}
+
+func TestThatMappingsOverrideTheDefaults(t *testing.T) {
+	m, err := ParseMapping("department=division, status=", BambooHRSource{}.DefaultMapping())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if m[DepartmentField] != "division" || m[EmailField] != "workEmail" {
+		t.Errorf("Expected the department to be overridden, but got %v", m)
+	}
+	if _, ok := m[StatusField]; ok {
+		t.Errorf("Expected an empty mapping to remove the field, but got %v", m)
+	}
+
+	for _, s := range []string{"office=location", "department", "email="} {
+		if _, err := ParseMapping(s, BambooHRSource{}.DefaultMapping()); err == nil {
+			t.Errorf("Expected %q to be rejected.", s)
+		}
+	}
+}
+
+func TestThatRecordsAreReadAsEmployees(t *testing.T) {
+	at := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	m := BambooHRSource{}.DefaultMapping()
+	employees, err := m.Employees([]Record{
+		{"id": "1", "workEmail": "Boss@GitHub.com", "displayName": "Boss", "status": "Active", "terminationDate": "0000-00-00"},
+		{"id": "2", "workEmail": "dev@github.com", "supervisorEId": "1", "department": "Engineering", "status": "Active", "terminationDate": "2018-04-01"},
+		{"id": "3", "workEmail": "gone@github.com", "status": "Inactive", "terminationDate": "2018-02-01"},
+		{"id": "4", "workEmail": "quit@github.com", "status": "Inactive"},
+	}, at)
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	expected := []Employee{
+		{ID: "1", EmailAddress: "boss@github.com", Name: "Boss"},
+		{ID: "2", EmailAddress: "dev@github.com", Manager: "boss@github.com", Department: "Engineering"},
+		{ID: "3", EmailAddress: "gone@github.com", Terminated: time.Date(2018, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "4", EmailAddress: "quit@github.com", Terminated: at},
+	}
+	if !reflect.DeepEqual(employees, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, employees)
+	}
+}
+
+func TestThatJoinersMoversAndLeaversAreSynced(t *testing.T) {
+	store := &profileStore{
+		profiles: map[string]dataaccess.Profile{
+			"dev@github.com":        {EmailAddress: "dev@github.com", Name: "Dev", Manager: "old@github.com", Skills: []dataaccess.Skill{{Skill: "go"}}},
+			"gone@github.com":       {EmailAddress: "gone@github.com"},
+			"contractor@github.com": {EmailAddress: "contractor@github.com"},
+			"same@github.com":       {EmailAddress: "same@github.com", Name: "Same", Employment: &dataaccess.Employment{EmployeeID: "5"}},
+		},
+		tenants: map[string]bool{"github.com": true},
+	}
+	source := memorySource{
+		{"id": "1", "workEmail": "new@github.com", "displayName": "New"},
+		{"id": "2", "workEmail": "dev@github.com", "displayName": "Dev", "supervisorEId": "1"},
+		{"id": "3", "workEmail": "gone@github.com", "status": "Inactive"},
+		{"id": "4", "workEmail": "elsewhere@example.com"},
+		{"id": "5", "workEmail": "same@github.com", "displayName": "Same"},
+		{"id": "6", "workEmail": "new@github.io"},
+	}
+	s := NewSyncer(store, source, source.DefaultMapping(), []string{"github.com", "GitHub.io"})
+	s.now = func() time.Time { return time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC) }
+
+	r, err := s.Sync(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	if !reflect.DeepEqual(r.Joined, []string{"new@github.com", "new@github.io"}) {
+		t.Errorf("Expected the new starters to join, but got %v", r.Joined)
+	}
+	if !reflect.DeepEqual(r.Moved, []Change{
+		{EmailAddress: "dev@github.com", Field: ManagerField, From: "old@github.com", To: "new@github.com"},
+		{EmailAddress: "dev@github.com", Field: IDField, From: "", To: "2"},
+	}) {
+		t.Errorf("Expected the dev's manager to change, but got %+v", r.Moved)
+	}
+	if !reflect.DeepEqual(r.Left, []string{"gone@github.com"}) || r.Unchanged != 1 {
+		t.Errorf("Expected one leaver and one unchanged, but got %v and %d", r.Left, r.Unchanged)
+	}
+	if len(r.Skipped) != 1 || r.Skipped[0].EmailAddress != "elsewhere@example.com" {
+		t.Errorf("Expected the employee in another domain to be skipped, but got %+v", r.Skipped)
+	}
+	if !reflect.DeepEqual(r.Unmatched, []string{"contractor@github.com"}) {
+		t.Errorf("Expected the contractor not to be matched, but got %v", r.Unmatched)
+	}
+	if !reflect.DeepEqual(r.Tenants, []string{"github.io"}) {
+		t.Errorf("Expected the github.io tenant to be created, but got %v", r.Tenants)
+	}
+
+	if dev := store.profiles["dev@github.com"]; dev.Manager != "new@github.com" || len(dev.Skills) != 1 {
+		t.Errorf("Expected the dev's manager to change and skills to be kept, but got %+v", dev)
+	}
+	if gone := store.profiles["gone@github.com"]; gone.Employment == nil || gone.Employment.Active() {
+		t.Errorf("Expected the leaver to be terminated, but got %+v", gone.Employment)
+	}
+
+	// Nothing changes the second time.
+	r, err = s.Sync(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if len(r.Joined) != 0 || len(r.Moved) != 0 || len(r.Left) != 0 || r.Unchanged != 5 {
+		t.Errorf("Expected the second sync to change nothing, but got %+v", r)
+	}
+}
+
+func TestThatEmployeesWhoseEmailAddressesChangeAreMatchedByTheirHRISID(t *testing.T) {
+	store := &profileStore{
+		profiles: map[string]dataaccess.Profile{
+			"a.hesketh@github.com": {
+				EmailAddress: "a.hesketh@github.com",
+				Name:         "Adrian",
+				Skills:       []dataaccess.Skill{{Skill: "go", Level: 3}},
+				ExternalIDs:  map[string]string{dataaccess.HRISSystem: "7"},
+			},
+		},
+		tenants: map[string]bool{"github.com": true},
+	}
+	source := memorySource{{"id": "7", "workEmail": "adrian@github.com", "displayName": "Adrian"}}
+	s := NewSyncer(store, source, source.DefaultMapping(), []string{"github.com"})
+
+	r, err := s.Sync(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	expected := []Change{
+		{EmailAddress: "adrian@github.com", Field: EmailField, From: "a.hesketh@github.com", To: "adrian@github.com"},
+		{EmailAddress: "adrian@github.com", Field: IDField, From: "", To: "7"},
+	}
+	if len(r.Joined) != 0 || len(r.Unmatched) != 0 || !reflect.DeepEqual(r.Moved, expected) {
+		t.Errorf("Expected the change of email address to be a move, but got %+v", r)
+	}
+	if p, ok := store.profiles["adrian@github.com"]; !ok || len(store.profiles) != 1 || len(p.Skills) != 1 {
+		t.Errorf("Expected the old profile to be merged into the new one, but got %+v", store.profiles)
+	}
+}
+
+func TestThatFieldsWhichArentMappedAreLeftAlone(t *testing.T) {
+	store := &profileStore{
+		profiles: map[string]dataaccess.Profile{
+			"dev@github.com": {EmailAddress: "dev@github.com", Department: "Old", CostCenter: "100"},
+		},
+		tenants: map[string]bool{"github.com": true},
+	}
+	source := memorySource{{"id": "1", "workEmail": "dev@github.com", "department": "Engineering"}}
+	// BambooHR doesn't have a standard cost center field.
+	s := NewSyncer(store, source, source.DefaultMapping(), []string{"github.com"})
+
+	r, err := s.Sync(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if len(r.Moved) != 2 || r.Moved[0].Field != DepartmentField {
+		t.Errorf("Expected the department and ID to change, but got %+v", r.Moved)
+	}
+	if dev := store.profiles["dev@github.com"]; dev.Department != "Engineering" || dev.CostCenter != "100" {
+		t.Errorf("Expected the department to be synced and the cost center kept, but got %+v", dev)
+	}
+}
+
+func TestThatDryRunsDontChangeProfiles(t *testing.T) {
+	store := &profileStore{profiles: map[string]dataaccess.Profile{}, tenants: map[string]bool{}}
+	source := memorySource{{"id": "1", "workEmail": "new@github.com"}}
+	s := NewSyncer(store, source, source.DefaultMapping(), []string{"github.com"})
+	s.DryRun = true
+
+	r, err := s.Sync(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if len(r.Joined) != 1 || len(store.profiles) != 0 || len(store.tenants) != 0 {
+		t.Errorf("Expected the joiner to be reported but not created, but got %+v and %v", r, store.profiles)
+	}
+}
+
+func TestThatBambooHRReportsAreRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, _, ok := r.BasicAuth(); !ok || key != "key" || r.URL.Query().Get("onlyCurrent") != "false" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Fields []string `json:"fields"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Fields) != 7 {
+			t.Errorf("Expected the mapped fields to be requested, but got %v", req.Fields)
+		}
+		w.Write([]byte(`{"employees":[{"id":"1","workEmail":"dev@github.com","supervisorEId":null}]}`))
+	}))
+	defer server.Close()
+
+	s := NewBambooHRSource(server.URL, "key")
+	records, err := s.Records(context.Background(), s.DefaultMapping())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if !reflect.DeepEqual(records, []Record{{"id": "1", "workEmail": "dev@github.com"}}) {
+		t.Errorf("Unexpected records %v", records)
+	}
+}
+
+func TestThatWorkdayReportsAreRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "isu" || password != "secret" || r.URL.Query().Get("format") != "json" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"Report_Entry":[{"Employee_ID":"21001","Email_Address":"dev@github.com","Termination_Date":"2018-02-01-08:00"}]}`))
+	}))
+	defer server.Close()
+
+	s := NewWorkdaySource(server.URL+"/ccx/service/customreport2/github/isu/pill", "isu", "secret")
+	records, err := s.Records(context.Background(), s.DefaultMapping())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	employees, err := s.DefaultMapping().Employees(records, time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if len(employees) != 1 || employees[0].ID != "21001" || employees[0].Terminated.Format("2006-01-02") != "2018-02-01" {
+		t.Errorf("Unexpected employees %+v", employees)
+	}
+}
+
+func TestThatUnsupportedSourcesAreRejected(t *testing.T) {
+	for _, u := range []string{"personio://example", "bamboohr://", "workday://wd5.myworkday.com"} {
+		if _, err := OpenSource(u); err == nil {
+			t.Errorf("Expected %s to be rejected.", u)
+		}
+	}
+}
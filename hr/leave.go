@@ -0,0 +1,298 @@
+package hr
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Leave is a period of approved time off.
+type Leave struct {
+	// EmployeeID identifies the person in the HR system. Sources which don't
+	// have employee IDs give the EmailAddress instead.
+	EmployeeID   string
+	EmailAddress string
+	// Start and End are when the leave starts and ends. End is exclusive.
+	Start time.Time
+	End   time.Time
+	// AllDay leave starts and ends at midnight in the person's time zone,
+	// and Start and End are dates.
+	AllDay bool
+	// Type is the kind of leave, e.g. "Vacation".
+	Type string
+}
+
+// A LeaveSource lists approved leave from a PTO system or calendar.
+type LeaveSource interface {
+	// Name identifies the PTO system, e.g. "bamboohr".
+	Name() string
+	// Leave returns the approved leave which overlaps the period.
+	Leave(ctx context.Context, from, to time.Time) ([]Leave, error)
+}
+
+// OpenLeaveSource returns the LeaveSource for the URL, e.g. bamboohr://example
+// or the https address of an iCalendar feed of approved leave, such as
+// Timetastic's or a shared Outlook calendar's. The BambooHR API key is read
+// from the BAMBOOHR_API_KEY environment variable.
+func OpenLeaveSource(rawurl string) (LeaveSource, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "bamboohr":
+		if u.Host == "" {
+			return nil, fmt.Errorf("hr: the BambooHR URL must include the company's subdomain, e.g. bamboohr://example")
+		}
+		return NewBambooHRSource("https://api.bamboohr.com/api/gateway.php/"+u.Host, os.Getenv("BAMBOOHR_API_KEY")), nil
+	case "https", "webcal":
+		u.Scheme = "https"
+		return NewICalendarSource(u.String()), nil
+	}
+	return nil, fmt.Errorf("hr: unsupported leave source '%s', use bamboohr or the https address of an iCalendar feed", u.Scheme)
+}
+
+// Leave returns the approved time off requests which overlap the period.
+func (s BambooHRSource) Leave(ctx context.Context, from, to time.Time) ([]Leave, error) {
+	q := url.Values{}
+	q.Set("start", from.Format("2006-01-02"))
+	q.Set("end", to.Format("2006-01-02"))
+	q.Set("status", "approved")
+	req, err := http.NewRequest("GET", s.BaseURL+"/v1/time_off/requests/?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.SetBasicAuth(s.APIKey, "x")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("bamboohr: the time off requests returned status %d", resp.StatusCode)
+	}
+	var requests []struct {
+		EmployeeID string `json:"employeeId"`
+		Start      string `json:"start"`
+		End        string `json:"end"`
+		Status     struct {
+			Status string `json:"status"`
+		} `json:"status"`
+		Type struct {
+			Name string `json:"name"`
+		} `json:"type"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&requests); err != nil {
+		return nil, err
+	}
+
+	var op []Leave
+	for _, r := range requests {
+		if r.Status.Status != "approved" {
+			continue
+		}
+		start, err := time.Parse("2006-01-02", r.Start)
+		if err != nil {
+			return nil, fmt.Errorf("bamboohr: the time off of employee %s starts on '%s'", r.EmployeeID, r.Start)
+		}
+		end, err := time.Parse("2006-01-02", r.End)
+		if err != nil {
+			return nil, fmt.Errorf("bamboohr: the time off of employee %s ends on '%s'", r.EmployeeID, r.End)
+		}
+		// BambooHR's end dates are the last day of leave.
+		op = append(op, Leave{EmployeeID: r.EmployeeID, Start: start, End: end.AddDate(0, 0, 1), AllDay: true, Type: r.Type.Name})
+	}
+	return op, nil
+}
+
+// ICalendarSource reads leave from an iCalendar feed. Each event is leave
+// for its organizer, or its attendees if it doesn't have an organizer.
+type ICalendarSource struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewICalendarSource creates an instance of the ICalendarSource.
+func NewICalendarSource(url string) *ICalendarSource {
+	return &ICalendarSource{url, httpClient}
+}
+
+// Name returns "ical".
+func (s ICalendarSource) Name() string {
+	return "ical"
+}
+
+// Leave returns the events in the feed which overlap the period. Events
+// which have been cancelled are left out.
+func (s ICalendarSource) Leave(ctx context.Context, from, to time.Time) ([]Leave, error) {
+	req, err := http.NewRequest("GET", s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "text/calendar")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("ical: the feed returned status %d", resp.StatusCode)
+	}
+
+	leave, err := ParseICalendar(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var op []Leave
+	for _, l := range leave {
+		if l.End.After(from) && l.Start.Before(to) {
+			op = append(op, l)
+		}
+	}
+	return op, nil
+}
+
+// ParseICalendar reads the leave in the events of an iCalendar document.
+func ParseICalendar(r io.Reader) ([]Leave, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// Long lines are folded onto lines starting with white space.
+		if n := len(lines); n > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[n-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var op []Leave
+	var event map[string][]string
+	for _, line := range lines {
+		switch line {
+		case "BEGIN:VEVENT":
+			event = make(map[string][]string)
+			continue
+		case "END:VEVENT":
+			leave, err := eventLeave(event)
+			if err != nil {
+				return nil, err
+			}
+			op = append(op, leave...)
+			event = nil
+			continue
+		}
+		if event == nil {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		// Parameters, e.g. DTSTART;VALUE=DATE, are kept with the value, so
+		// that dates can be told apart from times.
+		name := parts[0]
+		params := ""
+		if i := strings.Index(name, ";"); i >= 0 {
+			name, params = name[:i], name[i:]
+		}
+		event[strings.ToUpper(name)] = append(event[strings.ToUpper(name)], params+":"+parts[1])
+	}
+	return op, nil
+}
+
+// eventLeave returns the leave of each person the event is for.
+func eventLeave(event map[string][]string) ([]Leave, error) {
+	if status := value(event, "STATUS"); strings.EqualFold(status, "CANCELLED") {
+		return nil, nil
+	}
+	start, allDay, err := icalTime(first(event, "DTSTART"))
+	if err != nil {
+		return nil, fmt.Errorf("ical: the event '%s' starts %v", value(event, "SUMMARY"), err)
+	}
+	end := start.AddDate(0, 0, 1)
+	if dtend := first(event, "DTEND"); dtend != "" {
+		if end, _, err = icalTime(dtend); err != nil {
+			return nil, fmt.Errorf("ical: the event '%s' ends %v", value(event, "SUMMARY"), err)
+		}
+	}
+
+	people := event["ORGANIZER"]
+	if len(people) == 0 {
+		people = event["ATTENDEE"]
+	}
+	var op []Leave
+	for _, p := range people {
+		v := p[strings.Index(p, ":")+1:]
+		if !strings.HasPrefix(strings.ToLower(v), "mailto:") {
+			continue
+		}
+		op = append(op, Leave{
+			EmailAddress: strings.ToLower(strings.TrimSpace(v[len("mailto:"):])),
+			Start:        start,
+			End:          end,
+			AllDay:       allDay,
+			Type:         value(event, "SUMMARY"),
+		})
+	}
+	return op, nil
+}
+
+func first(event map[string][]string, name string) string {
+	if len(event[name]) == 0 {
+		return ""
+	}
+	return event[name][0]
+}
+
+// value returns the value of the property, without its parameters.
+func value(event map[string][]string, name string) string {
+	v := first(event, name)
+	return strings.TrimSpace(v[strings.Index(v, ":")+1:])
+}
+
+// icalTime reads a date, e.g. ;VALUE=DATE:20180903, or a time, e.g.
+// :20180903T090000Z. Times with a TZID parameter are read in that time zone.
+func icalTime(property string) (t time.Time, allDay bool, err error) {
+	i := strings.LastIndex(property, ":")
+	if i < 0 {
+		return t, false, fmt.Errorf("without a time")
+	}
+	params, v := strings.ToUpper(property[:i]), property[i+1:]
+	if strings.Contains(params, "VALUE=DATE") && !strings.Contains(params, "VALUE=DATE-TIME") || len(v) == 8 {
+		t, err = time.Parse("20060102", v)
+		return t, true, err
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err = time.Parse("20060102T150405Z", v)
+		return t, false, err
+	}
+	loc := time.UTC
+	if j := strings.Index(params, "TZID="); j >= 0 {
+		name := property[j+len("TZID=") : i]
+		if k := strings.Index(name, ";"); k >= 0 {
+			name = name[:k]
+		}
+		if loc, err = time.LoadLocation(strings.Trim(name, `"`)); err != nil {
+			return t, false, fmt.Errorf("in the unknown time zone '%s'", name)
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", v, loc)
+	return t.UTC(), false, err
+}
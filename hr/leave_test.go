@@ -0,0 +1,131 @@
+package hr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func (s *profileStore) UpdateAvailabilityWindows(emailAddress string, windows []dataaccess.AvailabilityWindow) error {
+	p := s.profiles[emailAddress]
+	p.AvailabilityWindows = windows
+	s.profiles[emailAddress] = p
+	return nil
+}
+
+type memoryLeave []Leave
+
+func (s memoryLeave) Name() string {
+	return "memory"
+}
+
+func (s memoryLeave) Leave(ctx context.Context, from, to time.Time) ([]Leave, error) {
+	return s, nil
+}
+
+func TestThatICalendarEventsAreReadAsLeave(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"SUMMARY:Annual leave",
+		"DTSTART;VALUE=DATE:20180903",
+		"DTEND;VALUE=DATE:20180908",
+		"ORGANIZER;CN=Dev:mailto:Dev@github.com",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Dentist",
+		"DTSTART;TZID=Europe/London:20180910T090000",
+		"DTEND;TZID=Europe/London:20180910T1",
+		" 20000",
+		"ATTENDEE:mailto:ops@github.com",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Cancelled",
+		"STATUS:CANCELLED",
+		"DTSTART:20180911T090000Z",
+		"ORGANIZER:mailto:dev@github.com",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	leave, err := ParseICalendar(strings.NewReader(ics))
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	expected := []Leave{
+		{EmailAddress: "dev@github.com", Start: time.Date(2018, time.September, 3, 0, 0, 0, 0, time.UTC), End: time.Date(2018, time.September, 8, 0, 0, 0, 0, time.UTC), AllDay: true, Type: "Annual leave"},
+		{EmailAddress: "ops@github.com", Start: time.Date(2018, time.September, 10, 8, 0, 0, 0, time.UTC), End: time.Date(2018, time.September, 10, 11, 0, 0, 0, time.UTC), Type: "Dentist"},
+	}
+	if !reflect.DeepEqual(leave, expected) {
+		t.Errorf("Expected %v, but got %v", expected, leave)
+	}
+}
+
+func TestThatApprovedBambooHRTimeOffIsRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key, _, ok := r.BasicAuth(); !ok || key != "key" || r.URL.Query().Get("status") != "approved" || r.URL.Query().Get("start") != "2018-09-01" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[{"id":"1","employeeId":"4","status":{"status":"approved"},"start":"2018-09-03","end":"2018-09-07","type":{"name":"Vacation"}}]`))
+	}))
+	defer server.Close()
+
+	from := time.Date(2018, time.September, 1, 0, 0, 0, 0, time.UTC)
+	leave, err := NewBambooHRSource(server.URL, "key").Leave(context.Background(), from, from.AddDate(0, 0, 90))
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if len(leave) != 1 || leave[0].EmployeeID != "4" || !leave[0].AllDay || leave[0].End.Day() != 8 || leave[0].Type != "Vacation" {
+		t.Errorf("Expected the vacation to end at the start of the 8th, but got %v", leave)
+	}
+}
+
+func TestThatImportedLeaveReplacesThePreviousImport(t *testing.T) {
+	now := time.Date(2018, time.September, 1, 12, 0, 0, 0, time.UTC)
+	manual := dataaccess.AvailabilityWindow{Start: now.AddDate(0, 0, 20), End: now.AddDate(0, 0, 21), Availability: dataaccess.Amber, Note: "Conference"}
+	cancelled := dataaccess.AvailabilityWindow{Start: now.AddDate(0, 0, 30), End: now.AddDate(0, 0, 31), Availability: dataaccess.Red, Source: "memory"}
+	store := &profileStore{profiles: map[string]dataaccess.Profile{
+		"dev@github.com": {EmailAddress: "dev@github.com", TimeZone: "Europe/London", Employment: &dataaccess.Employment{EmployeeID: "4"},
+			AvailabilityWindows: []dataaccess.AvailabilityWindow{manual, cancelled}},
+		"ops@github.com": {EmailAddress: "ops@github.com"},
+	}}
+	source := memoryLeave{
+		{EmployeeID: "4", Start: time.Date(2018, time.September, 3, 0, 0, 0, 0, time.UTC), End: time.Date(2018, time.September, 8, 0, 0, 0, 0, time.UTC), AllDay: true, Type: "Vacation"},
+		{EmailAddress: "leaver@github.com", Start: now, End: now.Add(time.Hour)},
+	}
+
+	li := NewLeaveImporter(store, source, []string{"github.com"})
+	li.now = func() time.Time { return now }
+	r, err := li.Import(context.Background())
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	if !reflect.DeepEqual(r.Updated, []string{"dev@github.com"}) || !reflect.DeepEqual(r.Unmatched, []string{"leaver@github.com"}) || r.Windows != 1 {
+		t.Errorf("Expected dev@github.com to be updated and leaver@github.com to be unmatched, but got %+v", r)
+	}
+	windows := store.profiles["dev@github.com"].AvailabilityWindows
+	// Leave starts at midnight in London, which is 11pm UTC during BST.
+	vacation := dataaccess.AvailabilityWindow{
+		Start:        time.Date(2018, time.September, 2, 23, 0, 0, 0, time.UTC),
+		End:          time.Date(2018, time.September, 7, 23, 0, 0, 0, time.UTC),
+		Availability: dataaccess.Red,
+		Note:         "Vacation",
+		Source:       "memory",
+	}
+	if !reflect.DeepEqual(windows, []dataaccess.AvailabilityWindow{manual, vacation}) {
+		t.Errorf("Expected the manual window to be kept and the cancelled leave replaced, but got %v", windows)
+	}
+
+	r, _ = li.Import(context.Background())
+	if len(r.Updated) != 0 {
+		t.Errorf("Expected nothing to change the second time, but %v were updated", r.Updated)
+	}
+}
@@ -0,0 +1,152 @@
+package hr
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// DefaultLeaveDays is how far ahead leave is imported.
+const DefaultLeaveDays = 90
+
+// A LeaveImporter copies approved leave from a PTO system to the
+// availability windows of the profiles in a set of tenants, so that people
+// are shown as unavailable while they're away.
+type LeaveImporter struct {
+	DataAccess dataaccess.DataAccess
+	Source     LeaveSource
+	// Domains are the tenants whose leave is imported.
+	Domains []string
+	// Days is how far ahead leave is imported.
+	Days int
+	now  func() time.Time
+}
+
+// NewLeaveImporter creates an instance of the LeaveImporter.
+func NewLeaveImporter(da dataaccess.DataAccess, source LeaveSource, domains []string) *LeaveImporter {
+	lower := make([]string, len(domains))
+	for i, d := range domains {
+		lower[i] = strings.ToLower(strings.TrimSpace(d))
+	}
+	return &LeaveImporter{da, source, lower, DefaultLeaveDays, time.Now}
+}
+
+// A LeaveReport sums up an import.
+type LeaveReport struct {
+	// Updated is the profiles whose leave has changed.
+	Updated []string `json:"updated"`
+	// Windows is the number of periods of leave imported.
+	Windows int `json:"windows"`
+	// Unmatched is the employee IDs or email addresses of leave which
+	// doesn't belong to a profile in the tenants.
+	Unmatched []string `json:"unmatched"`
+}
+
+// Import replaces the imported availability windows which haven't ended
+// with the leave in the PTO system, marking the leave as red. Windows
+// entered by hand, and those imported from other sources, are kept. Leave
+// which has been cancelled is removed.
+func (li LeaveImporter) Import(ctx context.Context) (LeaveReport, error) {
+	r := LeaveReport{Updated: []string{}, Unmatched: []string{}}
+	da := dataaccess.WithContext(li.DataAccess, ctx)
+	from := li.now().UTC()
+	to := from.AddDate(0, 0, li.Days)
+
+	leave, err := li.Source.Leave(ctx, from, to)
+	if err != nil {
+		return r, err
+	}
+
+	profiles := make(map[string]dataaccess.Profile)
+	ids := make(map[string]string)
+	for _, domain := range li.Domains {
+		ps, err := da.ListProfiles("@" + domain)
+		if err != nil {
+			return r, err
+		}
+		for _, p := range ps {
+			email := strings.ToLower(p.EmailAddress)
+			profiles[email] = p
+			if p.Employment != nil && p.Employment.EmployeeID != "" {
+				ids[p.Employment.EmployeeID] = email
+			}
+		}
+	}
+
+	imported := make(map[string][]dataaccess.AvailabilityWindow)
+	unmatched := make(map[string]bool)
+	for _, l := range leave {
+		email := strings.ToLower(l.EmailAddress)
+		if email == "" {
+			email = ids[l.EmployeeID]
+		}
+		p, ok := profiles[email]
+		if !ok {
+			who := l.EmailAddress
+			if who == "" {
+				who = l.EmployeeID
+			}
+			unmatched[who] = true
+			continue
+		}
+		w := li.window(p, l)
+		if w.End.After(from) && w.End.After(w.Start) {
+			imported[email] = append(imported[email], w)
+		}
+	}
+
+	for email, p := range profiles {
+		if ctx.Err() != nil {
+			return r, ctx.Err()
+		}
+		next := imported[email]
+		r.Windows += len(next)
+		windows, changed := dataaccess.ReplaceImportedWindows(p.AvailabilityWindows, li.Source.Name(), from, next)
+		if !changed {
+			continue
+		}
+		if err := da.UpdateAvailabilityWindows(p.EmailAddress, windows); err != nil {
+			return r, err
+		}
+		r.Updated = append(r.Updated, p.EmailAddress)
+	}
+
+	for who := range unmatched {
+		r.Unmatched = append(r.Unmatched, who)
+	}
+	sort.Strings(r.Updated)
+	sort.Strings(r.Unmatched)
+	return r, nil
+}
+
+// window returns the availability window of the leave. All day leave is
+// from midnight to midnight in the person's time zone.
+func (li LeaveImporter) window(p dataaccess.Profile, l Leave) dataaccess.AvailabilityWindow {
+	start, end := l.Start.UTC(), l.End.UTC()
+	if l.AllDay {
+		loc := p.Location()
+		start = time.Date(l.Start.Year(), l.Start.Month(), l.Start.Day(), 0, 0, 0, 0, loc).UTC()
+		end = time.Date(l.End.Year(), l.End.Month(), l.End.Day(), 0, 0, 0, 0, loc).UTC()
+	}
+	return dataaccess.AvailabilityWindow{
+		Start:        start,
+		End:          end,
+		Availability: dataaccess.Red,
+		Note:         l.Type,
+		Source:       li.Source.Name(),
+	}
+}
+
+// Run imports the leave, for use as a scheduled job.
+func (li LeaveImporter) Run(ctx context.Context) error {
+	r, err := li.Import(ctx)
+	if err == nil {
+		log.Printf("Imported %d periods of leave from %s: %d profiles updated, and %d people not found.",
+			r.Windows, li.Source.Name(), len(r.Updated), len(r.Unmatched))
+	}
+	return err
+}
@@ -0,0 +1,277 @@
+package hr
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Syncer keeps the profiles in a set of tenants up to date with the
+// employees in an HR system.
+type Syncer struct {
+	DataAccess dataaccess.DataAccess
+	Source     Source
+	Mapping    Mapping
+	// Domains are the tenants which are synced. Employees whose email
+	// addresses are in other domains are skipped.
+	Domains []string
+	// DryRun reports the changes without making them.
+	DryRun bool
+	now    func() time.Time
+}
+
+// NewSyncer creates an instance of the Syncer.
+func NewSyncer(da dataaccess.DataAccess, source Source, m Mapping, domains []string) *Syncer {
+	lower := make([]string, len(domains))
+	for i, d := range domains {
+		lower[i] = strings.ToLower(strings.TrimSpace(d))
+	}
+	return &Syncer{da, source, m, lower, false, time.Now}
+}
+
+// A Change is a field of a mover's profile which has changed.
+type Change struct {
+	EmailAddress string `json:"emailAddress"`
+	Field        string `json:"field"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+}
+
+// A Skip is an employee record which wasn't synced.
+type Skip struct {
+	EmployeeID   string `json:"employeeId"`
+	EmailAddress string `json:"emailAddress"`
+	Reason       string `json:"reason"`
+}
+
+// A Report reconciles the HR system with the profiles.
+type Report struct {
+	// Joined is the new starters who have been given profiles, and people
+	// who have come back after leaving.
+	Joined []string `json:"joined"`
+	// Moved is the changes to the profiles of people whose names, managers,
+	// departments or cost centers have changed.
+	Moved []Change `json:"moved"`
+	// Left is the people who have been marked as having left.
+	Left      []string `json:"left"`
+	Unchanged int      `json:"unchanged"`
+	Skipped   []Skip   `json:"skipped"`
+	// Unmatched is the profiles in the tenants which aren't in the HR
+	// system, such as contractors, or people whose email addresses differ.
+	Unmatched []string `json:"unmatched"`
+	// Tenants is the tenants which have been created, because an employee
+	// was the first in their domain.
+	Tenants []string `json:"tenants"`
+}
+
+// Sync reads the employees from the HR system and updates their profiles.
+// People who have left keep their profiles, marked as terminated. Skills
+// are never changed.
+func (s Syncer) Sync(ctx context.Context) (Report, error) {
+	r := Report{Joined: []string{}, Moved: []Change{}, Left: []string{}, Skipped: []Skip{}, Unmatched: []string{}, Tenants: []string{}}
+	da := dataaccess.WithContext(s.DataAccess, ctx)
+	now := s.now().UTC().Truncate(time.Millisecond)
+
+	records, err := s.Source.Records(ctx, s.Mapping)
+	if err != nil {
+		return r, err
+	}
+	employees, err := s.Mapping.Employees(records, now)
+	if err != nil {
+		return r, err
+	}
+
+	profiles := make(map[string]dataaccess.Profile)
+	// byID finds the profiles of people whose email addresses have changed in
+	// the HR system, by their employee IDs.
+	byID := make(map[string]string)
+	for _, domain := range s.Domains {
+		ps, err := da.ListProfiles("@" + domain)
+		if err != nil {
+			return r, err
+		}
+		for _, p := range ps {
+			profiles[strings.ToLower(p.EmailAddress)] = p
+			if id := employeeID(p); id != "" {
+				byID[id] = strings.ToLower(p.EmailAddress)
+			}
+		}
+	}
+
+	synced := make(map[string]bool)
+	tenants := make(map[string]bool)
+	for _, e := range employees {
+		if ctx.Err() != nil {
+			return r, ctx.Err()
+		}
+		skip := Skip{EmployeeID: e.ID, EmailAddress: e.EmailAddress}
+		domain := dataaccess.GetDomain(e.EmailAddress)
+		switch {
+		case !strings.Contains(e.EmailAddress, "@"):
+			skip.Reason = "no email address"
+		case !s.syncs(domain):
+			skip.Reason = "not in a synced domain"
+		case synced[e.EmailAddress]:
+			skip.Reason = "duplicate email address"
+		}
+		p, found := profiles[e.EmailAddress]
+		var previous string
+		if skip.Reason == "" && !found && e.ID != "" && byID[e.ID] != "" {
+			// The person's email address has changed, so their old profile is
+			// merged into a new one.
+			previous = byID[e.ID]
+			p, found = profiles[previous], true
+			synced[previous] = true
+		}
+		if skip.Reason == "" && !found && !e.Terminated.IsZero() {
+			skip.Reason = "left before joining pill"
+		}
+		if skip.Reason != "" {
+			r.Skipped = append(r.Skipped, skip)
+			continue
+		}
+		synced[e.EmailAddress] = true
+
+		changes := changes(p, e, s.Mapping)
+		if previous != "" {
+			changes = append([]Change{{EmailAddress: e.EmailAddress, Field: EmailField, From: previous, To: e.EmailAddress}}, changes...)
+		}
+		left := !e.Terminated.IsZero() && (p.Employment == nil || p.Employment.Active())
+		rejoined := found && e.Terminated.IsZero() && p.Employment != nil && !p.Employment.Active()
+		switch {
+		case !found, rejoined:
+			r.Joined = append(r.Joined, e.EmailAddress)
+		case left:
+			r.Left = append(r.Left, e.EmailAddress)
+		case len(changes) > 0:
+			r.Moved = append(r.Moved, changes...)
+		}
+		if found && !left && !rejoined && len(changes) == 0 {
+			r.Unchanged++
+			continue
+		}
+		if s.DryRun {
+			continue
+		}
+
+		if !tenants[domain] {
+			created, err := s.ensureTenant(da, domain)
+			if err != nil {
+				return r, err
+			}
+			if created {
+				r.Tenants = append(r.Tenants, domain)
+			}
+			tenants[domain] = true
+		}
+		update := dataaccess.EmployeeUpdate{
+			EmailAddress: e.EmailAddress,
+			Name:         e.Name,
+			Manager:      e.Manager,
+			Employment: dataaccess.Employment{
+				EmployeeID: e.ID,
+				Terminated: e.Terminated,
+				Synced:     now,
+			},
+		}
+		// Fields which aren't in the HR system can be entered by hand.
+		if s.Mapping[DepartmentField] != "" {
+			update.Department = &e.Department
+		}
+		if s.Mapping[CostCenterField] != "" {
+			update.CostCenter = &e.CostCenter
+		}
+		err := da.SyncEmployee(update)
+		if err != nil {
+			return r, err
+		}
+		if previous == "" {
+			continue
+		}
+		_, _, err = da.MergeProfiles(e.EmailAddress, previous)
+		if err == dataaccess.ErrCrossShardMerge {
+			log.Printf("hr: %s has moved to %s, whose tenant is on another shard, so their profiles must be merged by hand.", previous, e.EmailAddress)
+			continue
+		}
+		if err != nil {
+			return r, err
+		}
+	}
+
+	for id := range profiles {
+		if !synced[id] {
+			r.Unmatched = append(r.Unmatched, id)
+		}
+	}
+	sort.Strings(r.Unmatched)
+	return r, nil
+}
+
+func (s Syncer) syncs(domain string) bool {
+	for _, d := range s.Domains {
+		if d == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureTenant creates the configuration of a tenant which doesn't have one,
+// so that it can be found and configured, returning true if it was created.
+func (s Syncer) ensureTenant(da dataaccess.DataAccess, domain string) (bool, error) {
+	_, found, err := da.GetTenantConfiguration(domain)
+	if err != nil || found {
+		return false, err
+	}
+	return true, da.UpdateTenantConfiguration(dataaccess.NewTenantConfiguration(domain))
+}
+
+// employeeID returns the person's ID in the HR system, if it's known.
+func employeeID(p dataaccess.Profile) string {
+	if id := p.ExternalIDs[dataaccess.HRISSystem]; id != "" {
+		return id
+	}
+	if p.Employment != nil {
+		return p.Employment.EmployeeID
+	}
+	return ""
+}
+
+// changes returns the mapped fields of the profile which differ from the HR
+// system.
+func changes(p dataaccess.Profile, e Employee, m Mapping) []Change {
+	var id string
+	if p.Employment != nil {
+		id = p.Employment.EmployeeID
+	}
+	var op []Change
+	add := func(field, from, to string) {
+		if from != to {
+			op = append(op, Change{EmailAddress: e.EmailAddress, Field: field, From: from, To: to})
+		}
+	}
+	add(NameField, p.Name, e.Name)
+	add(ManagerField, strings.ToLower(p.Manager), e.Manager)
+	if m[DepartmentField] != "" {
+		add(DepartmentField, p.Department, e.Department)
+	}
+	if m[CostCenterField] != "" {
+		add(CostCenterField, p.CostCenter, e.CostCenter)
+	}
+	add(IDField, id, e.ID)
+	return op
+}
+
+// Run syncs the profiles, for use as a scheduled job.
+func (s Syncer) Run(ctx context.Context) error {
+	r, err := s.Sync(ctx)
+	if err == nil {
+		log.Printf("Synced %s: %d joined, %d changes to movers, %d left, %d unchanged, %d skipped and %d profiles not in %s.",
+			s.Source.Name(), len(r.Joined), len(r.Moved), len(r.Left), r.Unchanged, len(r.Skipped), len(r.Unmatched), s.Source.Name())
+	}
+	return err
+}
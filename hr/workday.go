@@ -0,0 +1,74 @@
+package hr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// WorkdaySource reads employees from a Workday custom report, shared as a web
+// service (Report as a Service).
+type WorkdaySource struct {
+	// ReportURL is the address of the report, e.g.
+	// https://wd5-services1.myworkday.com/ccx/service/customreport2/example/isu/pill_employees.
+	ReportURL string
+	// User and Password are the credentials of an integration system user
+	// who can run the report.
+	User     string
+	Password string
+	Client   *http.Client
+}
+
+// NewWorkdaySource creates an instance of the WorkdaySource.
+func NewWorkdaySource(reportURL string, user string, password string) *WorkdaySource {
+	return &WorkdaySource{reportURL, user, password, httpClient}
+}
+
+// Name returns "workday".
+func (s WorkdaySource) Name() string {
+	return "workday"
+}
+
+// DefaultMapping returns the column aliases suggested for the report.
+// Managers are identified by their employee ID.
+func (s WorkdaySource) DefaultMapping() Mapping {
+	return Mapping{
+		IDField:         "Employee_ID",
+		EmailField:      "Email_Address",
+		NameField:       "Worker",
+		ManagerField:    "Manager_Employee_ID",
+		DepartmentField: "Supervisory_Organization",
+		CostCenterField: "Cost_Center",
+		StatusField:     "Active_Status",
+		TerminatedField: "Termination_Date",
+	}
+}
+
+// Records runs the report. The report decides which fields are included, so
+// it must include the mapped fields, and the people who have left.
+func (s WorkdaySource) Records(ctx context.Context, m Mapping) ([]Record, error) {
+	req, err := http.NewRequest("GET", s.ReportURL+"?format=json", nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.SetBasicAuth(s.User, s.Password)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("workday: the report returned status %d", resp.StatusCode)
+	}
+	var report struct {
+		Entries []map[string]interface{} `json:"Report_Entry"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return records(report.Entries), nil
+}
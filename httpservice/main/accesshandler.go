@@ -0,0 +1,59 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+)
+
+// DefaultAccessReportDays is how far back the access report looks, unless
+// the days parameter is given.
+const DefaultAccessReportDays = 30
+
+// The AccessReportHandler lets people see who has viewed or exported their
+// profile, e.g. /profile/access/?days=30, from the access log.
+type AccessReportHandler struct {
+	Log audit.Log
+	now func() time.Time
+}
+
+// NewAccessReportHandler creates an instance of the AccessReportHandler.
+func NewAccessReportHandler(l audit.Log) *AccessReportHandler {
+	return &AccessReportHandler{l, time.Now}
+}
+
+func (handler AccessReportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling access report request.")
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, "error.sessionRequired")
+		return
+	}
+
+	days := DefaultAccessReportDays
+	if v := r.FormValue("days"); v != "" {
+		var err error
+		if days, err = strconv.Atoi(v); err != nil || days < 1 || days > 366 {
+			writeError(w, r, http.StatusBadRequest, "error.invalidAccessDays")
+			return
+		}
+	}
+
+	report, err := audit.GetAccessReport(handler.Log, c.EmailAddress, handler.now().UTC().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("Failed to read the access log of %s. %v", c.EmailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.accessReportFailed")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+)
+
+func TestThatPeopleSeeWhoAccessedTheirProfile(t *testing.T) {
+	now := time.Date(2018, time.September, 3, 9, 0, 0, 0, time.UTC)
+	l := audit.NewMemoryLog()
+	l.Record(audit.Entry{Time: now.AddDate(0, 0, -10), Actor: "boss@github.com", Action: audit.ProfileViewed, Target: "dev@github.com"})
+	l.Record(audit.Entry{Time: now.AddDate(0, 0, -1), Actor: "sales@github.com", Action: audit.ProfileExported, Target: "dev@github.com"})
+	l.Record(audit.Entry{Time: now, Actor: "dev@github.com", Action: audit.ProfileViewed, Target: "other@github.com"})
+	handler := NewAccessReportHandler(l)
+	handler.now = func() time.Time { return now }
+	dev := caller.Caller{EmailAddress: "dev@github.com"}
+
+	tests := []struct {
+		url              string
+		expectedStatus   int
+		expectedAccesses int
+	}{
+		{"http://example.com/profile/access/", http.StatusOK, 2},
+		{"http://example.com/profile/access/?days=7", http.StatusOK, 1},
+		{"http://example.com/profile/access/?days=0", http.StatusBadRequest, 0},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequestWithCaller("GET", test.url, "", dev))
+		if w.Code != test.expectedStatus {
+			t.Errorf("For %s, expected status %d, but received %d: %s", test.url, test.expectedStatus, w.Code, w.Body.String())
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var report audit.AccessReport
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatal("Unexpected error.", err)
+		}
+		if report.EmailAddress != "dev@github.com" || len(report.Accesses) != test.expectedAccesses {
+			t.Errorf("For %s, expected %d accesses to the caller's profile, but received %+v", test.url, test.expectedAccesses, report)
+		}
+	}
+}
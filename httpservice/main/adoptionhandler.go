@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/adoption"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The AdoptionHandler returns how widely pill is used in the user's domain,
+// as JSON.
+type AdoptionHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewAdoptionHandler creates an instance of the AdoptionHandler.
+func NewAdoptionHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *AdoptionHandler {
+	return &AdoptionHandler{da, sessionFactory}
+}
+
+func (handler AdoptionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling adoption request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(emailAddress)
+
+	profiles, err := da.ListProfiles(emailAddress)
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	profiles = dataaccess.Consenting(profiles, dataaccess.ConsentAnalytics, settings.Consent)
+
+	report := adoption.Compile(domain, profiles, settings.Headcount, time.Now())
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		log.Printf("Failed to marshall the adoption report, with error %s", err)
+	}
+}
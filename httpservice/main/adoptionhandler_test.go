@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/adoption"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatAdoptionIsReportedForTheUsersDomain(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	var settingsDomain string
+	mda := &mockDataAccess{
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "a-h@github.com", LastUpdated: time.Now()},
+				// People who haven't consented to analytics aren't counted.
+				{EmailAddress: "private@github.com", LastUpdated: time.Now(),
+					Consents: []dataaccess.Consent{{Purpose: dataaccess.ConsentAnalytics, Given: false}}},
+			}, nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			settingsDomain = domain
+			return dataaccess.Settings{Headcount: 4}, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/adoption/", nil)
+
+	NewAdoptionHandler(mda, sf).ServeHTTP(w, r)
+
+	var report adoption.Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal("Failed to decode the report.", err)
+	}
+
+	if settingsDomain != "github.com" || report.Domain != "github.com" {
+		t.Errorf("Expected the report to be for github.com, but got the settings of %s and a report for %s.", settingsDomain, report.Domain)
+	}
+	if report.Coverage != 25 || report.UpToDate != 1 {
+		t.Errorf("Expected 25%% coverage with 1 up to date profile, but got %d%% and %d.", report.Coverage, report.UpToDate)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A Task runs in the background until its context is cancelled.
+type Task func(ctx context.Context)
+
+// The Application manages the lifecycle of the HTTP server and the
+// background tasks which run alongside it.
+type Application struct {
+	Server *http.Server
+	// Listener is used to accept connections if set, otherwise the server
+	// listens on Server.Addr.
+	Listener net.Listener
+	Tasks    []Task
+	// ShutdownTimeout is how long in-flight requests have to complete once
+	// shutdown has started.
+	ShutdownTimeout time.Duration
+	// OnShutdown functions are called after the server has stopped and the
+	// background tasks have completed.
+	OnShutdown []func()
+}
+
+// NewApplication creates an Application which serves the handler on the
+// address.
+func NewApplication(address string, handler http.Handler) *Application {
+	return &Application{
+		Server: &http.Server{
+			Addr:    address,
+			Handler: handler,
+		},
+		ShutdownTimeout: 30 * time.Second,
+	}
+}
+
+// Run starts the server and background tasks, and blocks until the context
+// is cancelled or the server fails. On cancellation, the server stops
+// accepting connections and waits for in-flight requests to complete.
+func (app *Application) Run(ctx context.Context) error {
+	taskContext, cancelTasks := context.WithCancel(ctx)
+	defer cancelTasks()
+
+	var wg sync.WaitGroup
+	for _, task := range app.Tasks {
+		wg.Add(1)
+		go func(task Task) {
+			defer wg.Done()
+			task(taskContext)
+		}(task)
+	}
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		if app.Listener != nil {
+			serverErrors <- app.Server.Serve(app.Listener)
+		} else {
+			serverErrors <- app.Server.ListenAndServe()
+		}
+	}()
+
+	var err error
+	select {
+	case err = <-serverErrors:
+		log.Print("The server stopped unexpectedly. ", err)
+	case <-ctx.Done():
+		log.Print("Shutting down, waiting for in-flight requests to complete.")
+
+		shutdownContext, cancel := context.WithTimeout(context.Background(), app.ShutdownTimeout)
+		defer cancel()
+
+		err = app.Server.Shutdown(shutdownContext)
+		if err != nil {
+			log.Print("Failed to shut down the server cleanly. ", err)
+		}
+	}
+
+	cancelTasks()
+	wg.Wait()
+
+	for _, f := range app.OnShutdown {
+		f()
+	}
+
+	log.Print("Shutdown complete.")
+	return err
+}
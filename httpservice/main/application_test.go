@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestThatInFlightRequestsCompleteDuringShutdown(t *testing.T) {
+	requestStarted := make(chan bool)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestStarted <- true
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Failed to listen. ", err)
+	}
+
+	taskStopped := make(chan bool, 1)
+	shutdownCalled := false
+
+	app := NewApplication("", handler)
+	app.Listener = listener
+	app.Tasks = []Task{func(ctx context.Context) {
+		<-ctx.Done()
+		taskStopped <- true
+	}}
+	app.OnShutdown = []func(){func() { shutdownCalled = true }}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runResult := make(chan error, 1)
+	go func() { runResult <- app.Run(ctx) }()
+
+	responseStatus := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			responseStatus <- 0
+			return
+		}
+		resp.Body.Close()
+		responseStatus <- resp.StatusCode
+	}()
+
+	<-requestStarted
+	cancel()
+
+	if status := <-responseStatus; status != http.StatusOK {
+		t.Errorf("The in-flight request should have completed with status 200, but was %d.", status)
+	}
+
+	if err := <-runResult; err != nil {
+		t.Error("Run should return without error after a clean shutdown. ", err)
+	}
+
+	select {
+	case <-taskStopped:
+	default:
+		t.Error("Background tasks should be stopped before Run returns.")
+	}
+
+	if !shutdownCalled {
+		t.Error("The OnShutdown functions should be called before Run returns.")
+	}
+}
+
+func TestThatClosingTheHubDisconnectsClients(t *testing.T) {
+	hub := NewHub()
+	c := newHubClient("github.com")
+	hub.register(c)
+
+	hub.Close()
+
+	if _, open := <-c.send; open {
+		t.Error("The client's send channel should be closed.")
+	}
+
+	if len(hub.clients) != 0 {
+		t.Error("No clients should remain registered after the hub is closed.")
+	}
+}
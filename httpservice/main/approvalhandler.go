@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The ApprovalHandler allows administrators to request destructive actions,
+// and to list the requests waiting for a second administrator's approval.
+type ApprovalHandler struct {
+	DataAccess dataaccess.DataAccess
+	Log        audit.Log
+	// Window is how long a request waits for approval before it expires.
+	Window time.Duration
+	now    func() time.Time
+}
+
+// NewApprovalHandler creates an instance of the ApprovalHandler.
+func NewApprovalHandler(da dataaccess.DataAccess, l audit.Log, window time.Duration) *ApprovalHandler {
+	return &ApprovalHandler{da, l, window, time.Now}
+}
+
+// The ApprovalDecisionHandler allows an administrator to approve or deny a
+// request made by another administrator. Approved actions are carried out
+// immediately.
+type ApprovalDecisionHandler struct {
+	DataAccess dataaccess.DataAccess
+	Log        audit.Log
+	now        func() time.Time
+}
+
+// NewApprovalDecisionHandler creates an instance of the
+// ApprovalDecisionHandler.
+func NewApprovalDecisionHandler(da dataaccess.DataAccess, l audit.Log) *ApprovalDecisionHandler {
+	return &ApprovalDecisionHandler{da, l, time.Now}
+}
+
+type approvalDecision struct {
+	ID string `json:"id"`
+	// Decision is "approve" or "deny".
+	Decision string `json:"decision"`
+}
+
+// administrator returns the caller, if they're an administrator acting as
+// themselves.
+func administrator(r *http.Request) (caller.Caller, bool) {
+	c, ok := caller.FromContext(r.Context())
+	return c, ok && c.HasRole(dataaccess.AdministratorRole) && c.Impersonator == ""
+}
+
+func (handler ApprovalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling approval request.")
+
+	c, ok := administrator(r)
+	if !ok {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyApprovals")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		requests, err := da.ListApprovalRequests()
+		if err != nil {
+			log.Print("Failed to list the approval requests. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.approvalReadFailed")
+			return
+		}
+		writeJSON(w, http.StatusOK, requests)
+	case http.MethodPost:
+		var a dataaccess.ApprovalRequest
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidApprovalRequest")
+			return
+		}
+		if err := a.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		now := handler.now().UTC()
+		a.ID = bson.NewObjectId().Hex()
+		a.Tenant = strings.ToLower(a.Tenant)
+		a.RequestedBy = c.EmailAddress
+		a.Requested = now
+		a.Expires = now.Add(handler.Window)
+
+		if err := da.RequestApproval(&a); err != nil {
+			log.Print("Failed to store the approval request. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.approvalRequestFailed")
+			return
+		}
+
+		e := audit.NewEntry(r.Context(), audit.ApprovalRequested, a.Tenant, strings.Join(a.Targets, ","))
+		e.Details = string(a.Action) + " " + a.ID
+		if err := handler.Log.Record(e); err != nil {
+			log.Printf("Failed to record approval request %s in the audit log. %v", a.ID, err)
+		}
+
+		log.Printf("User %s has requested approval to %s.", c.EmailAddress, a.Action)
+		writeJSON(w, http.StatusAccepted, a)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func (handler ApprovalDecisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling approval decision.")
+
+	c, ok := administrator(r)
+	if !ok {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyApprovals")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	var d approvalDecision
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil || (d.Decision != "approve" && d.Decision != "deny") {
+		writeError(w, r, http.StatusBadRequest, "error.invalidApprovalDecision")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	a, found, err := da.GetApprovalRequest(d.ID)
+	if err != nil {
+		log.Printf("Failed to get approval request %s. %v", d.ID, err)
+		writeError(w, r, http.StatusInternalServerError, "error.approvalReadFailed")
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "error.approvalNotFound")
+		return
+	}
+
+	if d.Decision == "approve" && strings.EqualFold(a.RequestedBy, c.EmailAddress) {
+		writeError(w, r, http.StatusForbidden, "error.approvalBySameAdministrator")
+		return
+	}
+
+	if !handler.now().Before(a.Expires) {
+		da.DeleteApprovalRequest(a.ID)
+		writeError(w, r, http.StatusGone, "error.approvalExpired")
+		return
+	}
+
+	// Remove the request first, so that it can't be approved twice.
+	if err := da.DeleteApprovalRequest(a.ID); err != nil {
+		log.Printf("Failed to remove approval request %s. %v", a.ID, err)
+		writeError(w, r, http.StatusInternalServerError, "error.approvalExecuteFailed")
+		return
+	}
+
+	action := audit.ApprovalDenied
+	if d.Decision == "approve" {
+		action = audit.ApprovalGranted
+		// Approved actions are expected to delete a lot, so they also bypass
+		// the anomaly quarantine.
+		ctx := dataaccess.Release(dataaccess.Approve(r.Context()))
+		if err := a.Execute(ctx, handler.DataAccess); err != nil {
+			log.Printf("Failed to carry out approval request %s. %v", a.ID, err)
+			writeError(w, r, http.StatusInternalServerError, "error.approvalExecuteFailed")
+			return
+		}
+	}
+
+	e := audit.NewEntry(r.Context(), action, a.Tenant, strings.Join(a.Targets, ","))
+	e.Details = string(a.Action) + " " + a.ID + " requested by " + a.RequestedBy
+	if err := handler.Log.Record(e); err != nil {
+		log.Printf("Failed to record the decision on approval request %s in the audit log. %v", a.ID, err)
+	}
+
+	log.Printf("User %s has decided to %s %s, requested by %s.", c.EmailAddress, d.Decision, a.Action, a.RequestedBy)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to marshall the response, with error %s", err)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+var secondAdministrator = caller.Caller{
+	EmailAddress: "second@github.com",
+	Roles:        []string{dataaccess.UserRole, dataaccess.AdministratorRole},
+	Tenant:       "github.com",
+}
+
+var approvalNow = time.Date(2017, time.March, 6, 12, 0, 0, 0, time.UTC)
+
+func TestThatAdministratorsCanRequestDestructiveActions(t *testing.T) {
+	var stored *dataaccess.ApprovalRequest
+	mda := &mockDataAccess{
+		requestApprovalResponse: func(a *dataaccess.ApprovalRequest) error {
+			stored = a
+			return nil
+		},
+	}
+	l := audit.NewMemoryLog()
+	h := NewApprovalHandler(mda, l, time.Hour)
+	h.now = func() time.Time { return approvalNow }
+
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("POST", "http://example.com/admin/approvals/", `{"action":"deleteTenant","tenant":"Example.com"}`, testAdministrator)
+
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusAccepted || stored == nil {
+		t.Fatalf("Expected the request to be stored, but received %d.", w.Code)
+	}
+
+	if stored.RequestedBy != testAdministrator.EmailAddress || stored.Tenant != "example.com" || !stored.Expires.Equal(approvalNow.Add(time.Hour)) {
+		t.Errorf("Expected a request by the administrator for example.com, expiring in an hour, but received %v", stored)
+	}
+
+	var response dataaccess.ApprovalRequest
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil || response.ID != stored.ID {
+		t.Errorf("Expected the stored request to be returned, but received %v, %v", response, err)
+	}
+
+	if entries, _ := l.List(audit.Query{}); len(entries) != 1 || entries[0].Action != audit.ApprovalRequested {
+		t.Errorf("Expected the request to be audited, but received %v", entries)
+	}
+}
+
+func TestThatApprovalsAreRestrictedToAdministratorsActingAsThemselves(t *testing.T) {
+	impersonating := testAdministrator
+	impersonating.Impersonator = "other@github.com"
+
+	for _, c := range []caller.Caller{{EmailAddress: "a-h@github.com"}, impersonating} {
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "http://example.com/admin/approvals/", `{"action":"deleteConfiguration"}`, c)
+
+		NewApprovalHandler(&mockDataAccess{}, audit.NewMemoryLog(), time.Hour).ServeHTTP(w, r)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("For %v, expected status 403, but was %d.", c, w.Code)
+		}
+	}
+}
+
+func TestThatApprovalRequiresASecondAdministrator(t *testing.T) {
+	tests := []struct {
+		name            string
+		approver        caller.Caller
+		body            string
+		expires         time.Time
+		expectedStatus  int
+		expectedDeletes int
+	}{
+		{"second administrator approves", secondAdministrator, `{"id":"123","decision":"approve"}`, approvalNow.Add(time.Minute), http.StatusNoContent, 2},
+		{"requester approves", testAdministrator, `{"id":"123","decision":"approve"}`, approvalNow.Add(time.Minute), http.StatusForbidden, 0},
+		{"requester cancels", testAdministrator, `{"id":"123","decision":"deny"}`, approvalNow.Add(time.Minute), http.StatusNoContent, 0},
+		{"request has expired", secondAdministrator, `{"id":"123","decision":"approve"}`, approvalNow, http.StatusGone, 0},
+		{"request doesn't exist", secondAdministrator, `{"id":"456","decision":"approve"}`, approvalNow.Add(time.Minute), http.StatusNotFound, 0},
+	}
+
+	for _, test := range tests {
+		expires := test.expires
+		mda := &mockDataAccess{
+			getApprovalRequestResponse: func(id string) (*dataaccess.ApprovalRequest, bool, error) {
+				if id != "123" {
+					return nil, false, nil
+				}
+				return &dataaccess.ApprovalRequest{
+					ID:          id,
+					Action:      dataaccess.EraseProfilesAction,
+					Targets:     []string{"a@github.com", "b@github.com"},
+					RequestedBy: testAdministrator.EmailAddress,
+					Expires:     expires,
+				}, true, nil
+			},
+			deleteApprovalRequestResponse: func(id string) error { return nil },
+			deleteProfileResponse:         func(string) (bool, error) { return true, nil },
+		}
+		h := NewApprovalDecisionHandler(mda, audit.NewMemoryLog())
+		h.now = func() time.Time { return approvalNow }
+
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "http://example.com/admin/approvals/decisions/", test.body, test.approver)
+
+		h.ServeHTTP(w, r)
+
+		if w.Code != test.expectedStatus || mda.deleteProfileCallCount != test.expectedDeletes {
+			t.Errorf("When the %s, expected status %d and %d deletes, but received %d and %d.", test.name, test.expectedStatus, test.expectedDeletes, w.Code, mda.deleteProfileCallCount)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The ArchiveHandler allows administrators to list the archived and deleted
+// profiles in their domain, e.g. those of people who have left, and restore
+// them before they're purged.
+type ArchiveHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewArchiveHandler creates an instance of the ArchiveHandler.
+func NewArchiveHandler(da dataaccess.DataAccess) *ArchiveHandler {
+	return &ArchiveHandler{da}
+}
+
+type profileRestore struct {
+	EmailAddress string `json:"emailAddress"`
+}
+
+func (handler ArchiveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling profile archive request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyArchive")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(c.EmailAddress)
+
+	switch r.Method {
+	case http.MethodGet:
+		archived, err := da.ListArchivedProfiles(domain)
+		if err != nil {
+			log.Print("Failed to list the archived profiles. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.archiveReadFailed")
+			return
+		}
+		if archived == nil {
+			archived = []dataaccess.ArchivedProfile{}
+		}
+		writeJSON(w, http.StatusOK, archived)
+	case http.MethodPost:
+		var restore profileRestore
+		if err := json.NewDecoder(r.Body).Decode(&restore); err != nil || !strings.Contains(restore.EmailAddress, "@") {
+			writeError(w, r, http.StatusBadRequest, "error.invalidProfileRestore")
+			return
+		}
+		if dataaccess.GetDomain(restore.EmailAddress) != domain {
+			writeError(w, r, http.StatusNotFound, "error.archivedProfileNotFound")
+			return
+		}
+
+		restored, err := da.RestoreProfile(restore.EmailAddress)
+		if err != nil {
+			log.Print("Failed to restore the profile. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.profileRestoreFailed")
+			return
+		}
+		if !restored {
+			writeError(w, r, http.StatusNotFound, "error.archivedProfileNotFound")
+			return
+		}
+
+		log.Printf("User %s has restored the profile of %s.", c.EmailAddress, restore.EmailAddress)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
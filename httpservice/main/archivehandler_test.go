@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatAdministratorsListTheArchivedProfilesInTheirDomain(t *testing.T) {
+	var domain string
+	mda := &mockDataAccess{
+		listArchivedProfilesResponse: func(d string) ([]dataaccess.ArchivedProfile, error) {
+			domain = d
+			return nil, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("GET", "http://example.com/admin/archive/", "", testAdministrator)
+
+	NewArchiveHandler(mda).ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || w.Body.String() != "[]\n" {
+		t.Errorf("Expected an empty list, but received %d '%s'", w.Code, w.Body.String())
+	}
+	if domain != "github.com" {
+		t.Errorf("Expected the archive of the administrator's domain to be listed, but was '%s'", domain)
+	}
+}
+
+func TestThatAdministratorsCanRestoreArchivedProfiles(t *testing.T) {
+	tests := []struct {
+		c              caller.Caller
+		body           string
+		restored       bool
+		expectedStatus int
+	}{
+		{testAdministrator, `{"emailAddress":"dev@github.com"}`, true, http.StatusNoContent},
+		{testAdministrator, `{"emailAddress":"dev@github.com"}`, false, http.StatusNotFound},
+		{testAdministrator, `{"emailAddress":"dev@example.org"}`, true, http.StatusNotFound},
+		{testAdministrator, `{"emailAddress":"dev"}`, true, http.StatusBadRequest},
+		{caller.Caller{EmailAddress: "a-h@github.com"}, `{"emailAddress":"dev@github.com"}`, true, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		restored := test.restored
+		mda := &mockDataAccess{
+			restoreProfileResponse: func(emailAddress string) (bool, error) {
+				return restored, nil
+			},
+		}
+
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "http://example.com/admin/archive/", test.body, test.c)
+
+		NewArchiveHandler(mda).ServeHTTP(w, r)
+
+		if w.Code != test.expectedStatus {
+			t.Errorf("For %s posting '%s', expected %d, but received %d '%s'.", test.c.EmailAddress, test.body, test.expectedStatus, w.Code, w.Body.String())
+		}
+	}
+}
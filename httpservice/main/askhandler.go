@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/nlquery"
+)
+
+// maxQuestionLength is the longest question which is sent to the language
+// model, in bytes.
+const maxQuestionLength = 1000
+
+// The AskHandler answers questions about the people in the user's domain
+// written in plain language, e.g. a POST to /search/ask/ of
+// {"question": "who can build a React Native app and is free in June"}. The
+// answer includes how the question was interpreted.
+type AskHandler struct {
+	Interpreter *nlquery.Interpreter
+	getSession  func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewAskHandler creates an instance of the AskHandler.
+func NewAskHandler(interpreter *nlquery.Interpreter, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *AskHandler {
+	return &AskHandler{interpreter, sessionFactory}
+}
+
+type askRequest struct {
+	Question string `json:"question"`
+}
+
+func (handler AskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling question request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	var ar askRequest
+	if err := json.NewDecoder(r.Body).Decode(&ar); err != nil || strings.TrimSpace(ar.Question) == "" || len(ar.Question) > maxQuestionLength {
+		writeError(w, r, http.StatusBadRequest, "error.invalidQuestion", maxQuestionLength)
+		return
+	}
+
+	domain := dataaccess.GetDomain(emailAddress)
+	answer, err := handler.Interpreter.Ask(r.Context(), domain, strings.TrimSpace(ar.Question))
+	if _, ok := err.(dataaccess.ValidationError); ok {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to answer the question %q in %s. %v", ar.Question, domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.questionFailed")
+		return
+	}
+	writeJSON(w, http.StatusOK, answer)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/nlquery"
+)
+
+type cannedProvider string
+
+func (p cannedProvider) Complete(ctx context.Context, system string, prompt string) (string, error) {
+	return string(p), nil
+}
+
+func TestThatQuestionsAreAnsweredWithTheirInterpretation(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	mda := &mockDataAccess{
+		listSkillTagsResponse: func() ([]dataaccess.SkillTag, error) {
+			return []dataaccess.SkillTag{{Name: "go"}}, nil
+		},
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "a@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}},
+				{EmailAddress: "b@github.com"},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		body         string
+		completion   string
+		expectedCode int
+	}{
+		{`{"question": "who knows go?"}`, `{"skills": [{"skill": "go", "level": 2}]}`, http.StatusOK},
+		{`{"question": "who knows go?"}`, `{"skills": [{"skill": "go", "level": 0}]}`, http.StatusBadRequest},
+		{`{"question": " "}`, `{}`, http.StatusBadRequest},
+		{`{"question": "` + strings.Repeat("a", maxQuestionLength+1) + `"}`, `{}`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "http://example.com/search/ask/", strings.NewReader(test.body))
+
+		NewAskHandler(nlquery.NewInterpreter(mda, cannedProvider(test.completion)), sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.completion, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var a nlquery.Answer
+		if err := json.NewDecoder(w.Body).Decode(&a); err != nil {
+			t.Fatal("Failed to decode the answer.", err)
+		}
+		if a.Question != "who knows go?" || len(a.Query.Skills) != 1 || len(a.Results) != 1 || a.Results[0].EmailAddress != "a@github.com" {
+			t.Errorf("Unexpected answer %+v", a)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The AuditHandler allows administrators to read and export the audit log,
+// or the access log.
+type AuditHandler struct {
+	Log audit.Log
+	// Name is used in the file name of exports, e.g. "auditlog".
+	Name string
+}
+
+// NewAuditHandler creates an instance of the AuditHandler.
+func NewAuditHandler(l audit.Log, name string) *AuditHandler {
+	return &AuditHandler{l, name}
+}
+
+func (handler AuditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling audit log request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyAuditLog")
+		return
+	}
+
+	q := audit.Query{
+		Tenant: r.FormValue("tenant"),
+		Actor:  r.FormValue("actor"),
+	}
+
+	if since := r.FormValue("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidSince")
+			return
+		}
+		q.Since = t
+	}
+
+	if limit := r.FormValue("limit"); limit != "" {
+		q.Limit, _ = strconv.Atoi(limit)
+	}
+
+	entries, err := handler.Log.List(q)
+	if err != nil {
+		log.Print("Failed to read the audit log. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.auditLogReadFailed")
+		return
+	}
+
+	if r.FormValue("format") == "csv" {
+		handler.writeCSV(w, entries)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		log.Printf("Failed to marshall the audit log, with error %s", err)
+	}
+}
+
+// writeCSV writes a row for each entry, e.g. for handing to a works council.
+func (handler AuditHandler) writeCSV(w http.ResponseWriter, entries []audit.Entry) {
+	w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+handler.Name+`.csv"`)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "actor", "impersonator", "tenant", "action", "target", "details"})
+	for _, e := range entries {
+		cw.Write([]string{e.Time.UTC().Format(time.RFC3339), e.Actor, e.Impersonator, e.Tenant, e.Action, e.Target, e.Details})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("Failed to write the %s, with error %s", handler.Name, err)
+	}
+}
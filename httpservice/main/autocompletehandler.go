@@ -0,0 +1,72 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/a-h/pill/autocomplete"
+	"github.com/a-h/pill/dataaccess"
+)
+
+const (
+	defaultAutocompleteLimit = 10
+	maxAutocompleteLimit     = 50
+)
+
+// The AutocompleteHandler completes skill tags and the names of people in
+// the user's domain as they're typed, e.g. /autocomplete/?q=kube. Only
+// skills or people are completed with ?type=skills or ?type=people, and
+// ?limit= (10) sets how many of each are returned.
+type AutocompleteHandler struct {
+	Index      *autocomplete.Index
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewAutocompleteHandler creates an instance of the AutocompleteHandler.
+func NewAutocompleteHandler(index *autocomplete.Index, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *AutocompleteHandler {
+	return &AutocompleteHandler{index, sessionFactory}
+}
+
+type autocompleteResponse struct {
+	Skills []autocomplete.Suggestion `json:"skills,omitempty"`
+	People []autocomplete.Suggestion `json:"people,omitempty"`
+}
+
+func (handler AutocompleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling autocomplete request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	t := r.FormValue("type")
+	limit := defaultAutocompleteLimit
+	if v := r.FormValue("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxAutocompleteLimit {
+			writeError(w, r, http.StatusBadRequest, "error.invalidAutocomplete", maxAutocompleteLimit)
+			return
+		}
+	}
+	if t != "" && t != "skills" && t != "people" {
+		writeError(w, r, http.StatusBadRequest, "error.invalidAutocomplete", maxAutocompleteLimit)
+		return
+	}
+
+	q := r.FormValue("q")
+	var resp autocompleteResponse
+	if t == "" || t == "skills" {
+		resp.Skills = handler.Index.Skills(q, limit)
+	}
+	if t == "" || t == "people" {
+		resp.People = handler.Index.People(dataaccess.GetDomain(emailAddress), q, limit)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/autocomplete"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+func TestThatSkillsAndPeopleInTheUsersDomainAreCompleted(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	index := autocomplete.NewIndex(&mockDataAccess{})
+	index.Publish(events.NewEvent(events.SkillTagsAdded, "", "", []string{"kubernetes", "kotlin"}))
+	for _, p := range []*dataaccess.Profile{
+		{EmailAddress: "kate@github.com", Name: "Kate Bush"},
+		{EmailAddress: "kim@example.com", Name: "Kim Deal"},
+	} {
+		index.Publish(events.NewEvent(events.ProfileUpdated, dataaccess.GetDomain(p.EmailAddress), p.EmailAddress, p))
+	}
+
+	tests := []struct {
+		query          string
+		expectedCode   int
+		expectedSkills int
+		expectedPeople int
+	}{
+		{"?q=k", http.StatusOK, 2, 1},
+		{"?q=kubrenetes&type=skills", http.StatusOK, 1, 0},
+		{"?q=k&type=people", http.StatusOK, 0, 1},
+		{"?q=k&limit=1", http.StatusOK, 1, 1},
+		{"?q=k&type=teams", http.StatusBadRequest, 0, 0},
+		{"?q=k&limit=100", http.StatusBadRequest, 0, 0},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "http://example.com/autocomplete/"+test.query, nil)
+
+		NewAutocompleteHandler(index, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.query, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var resp autocompleteResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal("Failed to decode the suggestions.", err)
+		}
+		if len(resp.Skills) != test.expectedSkills || len(resp.People) != test.expectedPeople {
+			t.Errorf("For %s, expected %d skills and %d people, but received %+v", test.query, test.expectedSkills, test.expectedPeople, resp)
+		}
+	}
+}
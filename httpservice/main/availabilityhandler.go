@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/contentfilter"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The AvailabilityHandler reads and updates availability windows. Windows are
+// returned in the viewer's time zone, so that a window starting at 09:00 in
+// London is shown to a viewer in New York as starting at 04:00.
+type AvailabilityHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewAvailabilityHandler creates an instance of the AvailabilityHandler.
+func NewAvailabilityHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *AvailabilityHandler {
+	return &AvailabilityHandler{da, sessionFactory, time.Now}
+}
+
+type availabilityResponse struct {
+	EmailAddress string `json:"emailAddress"`
+	// TimeZone is the viewer's time zone, which the windows are shown in.
+	TimeZone string `json:"timeZone"`
+	// Availability is the person's availability now.
+	Availability dataaccess.RagStatus            `json:"availability"`
+	Windows      []dataaccess.AvailabilityWindow `json:"windows"`
+}
+
+func (handler AvailabilityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling availability request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	viewer, _, err := da.GetProfile(emailAddress)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", emailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.availabilityReadFailed")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		profile := viewer
+		if other := r.FormValue("emailAddress"); other != "" && other != emailAddress {
+			var found bool
+			profile, found, err = da.GetProfile(other)
+			if err != nil {
+				log.Printf("Failed to get the profile of %s. %v", other, err)
+				writeError(w, r, http.StatusInternalServerError, "error.availabilityReadFailed")
+				return
+			}
+			if !found || profile.Domain != dataaccess.GetDomain(emailAddress) {
+				writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+				return
+			}
+		}
+		handler.writeAvailability(w, profile, viewer)
+	case http.MethodPost, http.MethodPut:
+		var windows []dataaccess.AvailabilityWindow
+		if err := json.NewDecoder(r.Body).Decode(&windows); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidAvailabilityWindows")
+			return
+		}
+
+		if err := dataaccess.ValidateAvailabilityWindows(windows); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var notes []contentfilter.Field
+		for _, window := range windows {
+			notes = append(notes, contentfilter.Field{Name: "note", Text: window.Note})
+		}
+		windows = withImportedWindows(windows, viewer)
+		if !checkContent(w, r, da, dataaccess.GetDomain(emailAddress), notes...) {
+			return
+		}
+
+		if err := da.UpdateAvailabilityWindows(emailAddress, windows); err != nil {
+			log.Printf("Failed to update the availability windows of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.availabilitySaveFailed")
+			return
+		}
+
+		log.Printf("User %s has updated their availability windows.", emailAddress)
+
+		viewer.AvailabilityWindows = windows
+		handler.writeAvailability(w, viewer, viewer)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func (handler AvailabilityHandler) writeAvailability(w http.ResponseWriter, profile *dataaccess.Profile, viewer *dataaccess.Profile) {
+	now := handler.now()
+	loc := viewer.Location()
+
+	response := availabilityResponse{
+		EmailAddress: profile.EmailAddress,
+		TimeZone:     loc.String(),
+		Availability: profile.AvailabilityAt(now),
+		Windows:      profile.UpcomingAvailabilityWindows(now, loc),
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Failed to marshall the availability, with error %s", err)
+	}
+}
+
+// withImportedWindows replaces any imported windows in the ones entered by
+// hand with those already on the profile, so that leave imported from the
+// PTO system can only be changed there.
+func withImportedWindows(windows []dataaccess.AvailabilityWindow, p *dataaccess.Profile) []dataaccess.AvailabilityWindow {
+	op := []dataaccess.AvailabilityWindow{}
+	for _, w := range windows {
+		if !w.Imported() {
+			op = append(op, w)
+		}
+	}
+	if p == nil {
+		return op
+	}
+	for _, w := range p.AvailabilityWindows {
+		if w.Imported() {
+			op = append(op, w)
+		}
+	}
+	return op
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+var availabilityNow = time.Date(2017, time.March, 6, 12, 0, 0, 0, time.UTC)
+
+func newAvailabilityTestHandler(received *[]dataaccess.AvailabilityWindow) *AvailabilityHandler {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "viewer@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	profiles := map[string]*dataaccess.Profile{
+		"viewer@github.com": {EmailAddress: "viewer@github.com", Domain: "github.com", TimeZone: "America/New_York"},
+		"dev@github.com": {
+			EmailAddress: "dev@github.com",
+			Domain:       "github.com",
+			TimeZone:     "Europe/London",
+			Availability: dataaccess.Green,
+			AvailabilityWindows: []dataaccess.AvailabilityWindow{
+				{Start: availabilityNow.Add(-48 * time.Hour), End: availabilityNow.Add(-24 * time.Hour), Availability: dataaccess.Amber},
+				{Start: availabilityNow.Add(-time.Hour), End: availabilityNow.Add(23 * time.Hour), Availability: dataaccess.Red, Note: "Holiday"},
+			},
+		},
+		"other@example.com": {EmailAddress: "other@example.com", Domain: "example.com"},
+	}
+
+	mda := &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			p, ok := profiles[emailAddress]
+			if !ok {
+				return dataaccess.NewProfile(), false, nil
+			}
+			return p, true, nil
+		},
+		updateAvailabilityWindowsResponse: func(emailAddress string, windows []dataaccess.AvailabilityWindow) error {
+			*received = windows
+			return nil
+		},
+	}
+
+	h := NewAvailabilityHandler(mda, sf)
+	h.now = func() time.Time { return availabilityNow }
+	return h
+}
+
+func TestThatAvailabilityWindowsAreShownInTheViewersTimeZone(t *testing.T) {
+	h := newAvailabilityTestHandler(nil)
+
+	r, _ := http.NewRequest("GET", "http://example.com/profile/availability/?emailAddress=dev@github.com", nil)
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	var response availabilityResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal("Failed to decode the availability.", err)
+	}
+
+	if response.TimeZone != "America/New_York" {
+		t.Errorf("Expected the windows to be shown in the viewer's time zone, but was %s.", response.TimeZone)
+	}
+
+	if response.Availability != dataaccess.Red {
+		t.Errorf("Expected the current window to set the availability to red, but was %v.", response.Availability)
+	}
+
+	if len(response.Windows) != 1 {
+		t.Fatalf("Expected only the upcoming window to be returned, but received %v", response.Windows)
+	}
+
+	if _, offset := response.Windows[0].Start.Zone(); offset != -5*60*60 {
+		t.Errorf("Expected the window to start at an offset of -05:00, but was %d seconds.", offset)
+	}
+
+	if !response.Windows[0].Start.Equal(availabilityNow.Add(-time.Hour)) {
+		t.Errorf("Converting the time zone should not change the time, but the window started at %v.", response.Windows[0].Start)
+	}
+}
+
+func TestThatAvailabilityInOtherDomainsIsNotShown(t *testing.T) {
+	h := newAvailabilityTestHandler(nil)
+
+	r, _ := http.NewRequest("GET", "http://example.com/profile/availability/?emailAddress=other@example.com", nil)
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected profiles in other domains not to be found, but received %d.", w.Code)
+	}
+}
+
+func TestThatAvailabilityWindowsCanBeReplaced(t *testing.T) {
+	tests := []struct {
+		body           string
+		expectedStatus int
+		expectedSaved  int
+	}{
+		{`[{"start":"2017-03-07T09:00:00+01:00","end":"2017-03-07T17:00:00+01:00","availability":1}]`, http.StatusOK, 1},
+		{`[{"start":"2017-03-07T17:00:00Z","end":"2017-03-07T09:00:00Z","availability":1}]`, http.StatusBadRequest, 0},
+		{`not json`, http.StatusBadRequest, 0},
+	}
+
+	for _, test := range tests {
+		var received []dataaccess.AvailabilityWindow
+		h := newAvailabilityTestHandler(&received)
+
+		r, _ := http.NewRequest("PUT", "http://example.com/profile/availability/", strings.NewReader(test.body))
+		w := httptest.NewRecorder()
+
+		h.ServeHTTP(w, r)
+
+		if w.Code != test.expectedStatus || len(received) != test.expectedSaved {
+			t.Errorf("For '%s', expected status %d and %d saved windows, but received %d and %v", test.body, test.expectedStatus, test.expectedSaved, w.Code, received)
+		}
+	}
+}
+
+func TestThatImportedLeaveCantBeChangedByHand(t *testing.T) {
+	imported := dataaccess.AvailabilityWindow{Start: availabilityNow, End: availabilityNow.Add(24 * time.Hour), Availability: dataaccess.Red, Source: "bamboohr"}
+	manual := dataaccess.AvailabilityWindow{Start: availabilityNow, End: availabilityNow.Add(time.Hour), Availability: dataaccess.Amber}
+	p := &dataaccess.Profile{AvailabilityWindows: []dataaccess.AvailabilityWindow{imported}}
+
+	spoofed := dataaccess.AvailabilityWindow{Start: availabilityNow, End: availabilityNow.Add(time.Hour), Availability: dataaccess.Green, Source: "bamboohr"}
+	windows := withImportedWindows([]dataaccess.AvailabilityWindow{manual, spoofed}, p)
+
+	if len(windows) != 2 || windows[0] != manual || windows[1] != imported {
+		t.Errorf("Expected the manual window and the imported leave, but got %v", windows)
+	}
+}
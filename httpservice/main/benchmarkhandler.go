@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The BenchmarkHandler compares the skill coverage of the user's domain with
+// the anonymous average of tenants of a similar size, e.g.
+// /report/benchmark/. Only tenants which have opted in to benchmarking can
+// see the averages.
+type BenchmarkHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewBenchmarkHandler creates an instance of the BenchmarkHandler.
+func NewBenchmarkHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *BenchmarkHandler {
+	return &BenchmarkHandler{da, sessionFactory}
+}
+
+func (handler BenchmarkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling benchmark request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	domain := dataaccess.GetDomain(emailAddress)
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	if !settings.Benchmarking.OptIn {
+		writeError(w, r, http.StatusForbidden, "error.benchmarkingNotEnabled")
+		return
+	}
+
+	profiles, err := da.ListProfiles(emailAddress)
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+
+	coverage := dataaccess.SkillCoverage(profiles, settings.Headcount)
+	band := dataaccess.BenchmarkBand(coverage.People)
+	benchmark, found, err := da.GetBenchmark(band)
+	if err != nil {
+		log.Printf("Unable to retrieve the %s benchmark. %v", band, err)
+		writeError(w, r, http.StatusInternalServerError, "error.benchmarkReadFailed")
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "error.benchmarkNotFound", dataaccess.MinBenchmarkTenants)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(dataaccess.CompareToBenchmark(coverage, *benchmark)); err != nil {
+		log.Printf("Failed to marshall the benchmark comparison, with error %s", err)
+	}
+}
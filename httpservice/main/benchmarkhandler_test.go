@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatOptedInTenantsAreComparedToTheirBenchmark(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	tests := []struct {
+		optIn        bool
+		benchmark    bool
+		expectedCode int
+	}{
+		{true, true, http.StatusOK},
+		{true, false, http.StatusNotFound},
+		{false, true, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		var band string
+		mda := &mockDataAccess{
+			getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+				s := dataaccess.DefaultSettings()
+				s.Benchmarking.OptIn = test.optIn
+				return s, nil
+			},
+			listProfilesResponse: func() ([]dataaccess.Profile, error) {
+				return []dataaccess.Profile{
+					{EmailAddress: "a-h@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 4}}},
+					{EmailAddress: "dev@github.com"},
+				}, nil
+			},
+			getBenchmarkResponse: func(b string) (*dataaccess.Benchmark, bool, error) {
+				band = b
+				return &dataaccess.Benchmark{Band: b, Tenants: 5, Coverage: map[string]float64{"go": 0.25}}, test.benchmark, nil
+			},
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "http://example.com/report/benchmark/", nil)
+
+		NewBenchmarkHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For opt in %t and benchmark %t, expected status %d, but was %d.", test.optIn, test.benchmark, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var c dataaccess.BenchmarkComparison
+		if err := json.NewDecoder(w.Body).Decode(&c); err != nil {
+			t.Fatal("Failed to decode the comparison.", err)
+		}
+		if band != "1-49" || len(c.Skills) != 1 || c.Skills[0].Coverage != 0.5 || c.Skills[0].Delta != 0.25 {
+			t.Errorf("Unexpected comparison %+v with the %s benchmark", c, band)
+		}
+	}
+}
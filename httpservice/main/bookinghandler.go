@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/staffing"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The BookingHandler lists, adds and removes the bookings which allocate
+// people in the user's domain to projects.
+type BookingHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewBookingHandler creates an instance of the BookingHandler.
+func NewBookingHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *BookingHandler {
+	return &BookingHandler{da, sessionFactory, time.Now}
+}
+
+// The AllocationHandler reports who is booked during a period, e.g.
+// /report/allocations/?from=2017-03-01&to=2017-06-01
+type AllocationHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewAllocationHandler creates an instance of the AllocationHandler.
+func NewAllocationHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *AllocationHandler {
+	return &AllocationHandler{da, sessionFactory, time.Now}
+}
+
+// DefaultAllocationPeriod is how far ahead the allocations report looks if
+// no end date is given.
+const DefaultAllocationPeriod = 90 * 24 * time.Hour
+
+// bookingRequest is posted to book someone onto a project. If the
+// ProjectID is set, the project's name and dates are used unless they're
+// given.
+type bookingRequest struct {
+	EmailAddress string    `json:"emailAddress"`
+	Project      string    `json:"project"`
+	ProjectID    string    `json:"projectId"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	Percentage   int       `json:"percentage"`
+}
+
+type bookingsResponse struct {
+	EmailAddress string `json:"emailAddress"`
+	// Allocation is the percentage of the person's time booked now.
+	Allocation   int                  `json:"allocation"`
+	Availability dataaccess.RagStatus `json:"availability"`
+	Bookings     []dataaccess.Booking `json:"bookings"`
+}
+
+func (handler BookingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling booking request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		person := r.FormValue("emailAddress")
+		if person == "" {
+			person = emailAddress
+		}
+		profile, ok := colleague(w, r, da, emailAddress, person)
+		if !ok {
+			return
+		}
+		now := handler.now()
+		response := bookingsResponse{
+			EmailAddress: profile.EmailAddress,
+			Allocation:   dataaccess.Allocation(profile.Bookings, now),
+			Availability: profile.AvailabilityAt(now),
+			Bookings:     profile.Bookings,
+		}
+		if response.Bookings == nil {
+			response.Bookings = []dataaccess.Booking{}
+		}
+		writeJSON(w, http.StatusOK, response)
+	case http.MethodPost:
+		var br bookingRequest
+		if err := json.NewDecoder(r.Body).Decode(&br); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidBooking")
+			return
+		}
+		profile, ok := colleague(w, r, da, emailAddress, br.EmailAddress)
+		if !ok {
+			return
+		}
+
+		b := dataaccess.Booking{
+			ID:         bson.NewObjectId().Hex(),
+			Project:    strings.TrimSpace(br.Project),
+			Start:      br.Start,
+			End:        br.End,
+			Percentage: br.Percentage,
+			BookedBy:   strings.ToLower(emailAddress),
+			Created:    handler.now(),
+		}
+		if br.ProjectID != "" {
+			project, ok := domainProject(w, r, da, emailAddress, br.ProjectID)
+			if !ok {
+				return
+			}
+			b.ProjectID = project.ID
+			if b.Project == "" {
+				b.Project = project.Name
+			}
+			if b.Start.IsZero() && b.End.IsZero() {
+				b.Start, b.End = project.Start, project.End
+			}
+		}
+		err := da.AddBooking(profile.EmailAddress, b)
+		if _, ok := err.(dataaccess.ValidationError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err == dataaccess.ErrOverbooked {
+			writeError(w, r, http.StatusConflict, "error.overbooked", profile.EmailAddress)
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to book %s onto %s. %v", profile.EmailAddress, b.Project, err)
+			writeError(w, r, http.StatusInternalServerError, "error.bookingSaveFailed")
+			return
+		}
+
+		log.Printf("User %s has booked %s onto %s.", emailAddress, profile.EmailAddress, b.Project)
+		writeJSON(w, http.StatusCreated, b)
+	case http.MethodDelete:
+		profile, ok := colleague(w, r, da, emailAddress, r.FormValue("emailAddress"))
+		if !ok {
+			return
+		}
+		id := r.FormValue("id")
+		err := da.RemoveBooking(profile.EmailAddress, id)
+		if err == dataaccess.ErrBookingNotFound {
+			writeError(w, r, http.StatusNotFound, "error.bookingNotFound")
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to remove booking %s of %s. %v", id, profile.EmailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.bookingSaveFailed")
+			return
+		}
+
+		log.Printf("User %s has removed booking %s of %s.", emailAddress, id, profile.EmailAddress)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+// colleague returns the profile of someone in the user's domain, writing a
+// 404 if there isn't one.
+func colleague(w http.ResponseWriter, r *http.Request, da dataaccess.DataAccess, emailAddress string, other string) (*dataaccess.Profile, bool) {
+	other = strings.ToLower(strings.TrimSpace(other))
+	if !strings.Contains(other, "@") || dataaccess.GetDomain(other) != dataaccess.GetDomain(emailAddress) {
+		writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+		return nil, false
+	}
+	profile, found, err := da.GetProfile(other)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", other, err)
+		writeError(w, r, http.StatusInternalServerError, "error.profileReadFailed", other)
+		return nil, false
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+		return nil, false
+	}
+	return profile, true
+}
+
+func (handler AllocationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling allocations request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	start := handler.now()
+	var err error
+	if from := r.FormValue("from"); from != "" {
+		if start, err = time.Parse("2006-01-02", from); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidAllocationPeriod")
+			return
+		}
+	}
+	end := start.Add(DefaultAllocationPeriod)
+	if to := r.FormValue("to"); to != "" {
+		if end, err = time.Parse("2006-01-02", to); err != nil || !end.After(start) {
+			writeError(w, r, http.StatusBadRequest, "error.invalidAllocationPeriod")
+			return
+		}
+	}
+
+	profiles, err := dataaccess.WithContext(handler.DataAccess, r.Context()).ListProfiles(emailAddress)
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(staffing.Allocations(profiles, start, end)); err != nil {
+		log.Printf("Failed to marshall the allocations, with error %s", err)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/staffing"
+)
+
+func TestThatColleaguesCanBeBookedOntoProjects(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "boss@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	body := `{"emailAddress":"dev@github.com","project":"Website","start":"2017-03-01T09:00:00Z","end":"2017-06-01T17:00:00Z","percentage":60}`
+	tests := []struct {
+		body         string
+		err          error
+		expectedCode int
+	}{
+		{body, nil, http.StatusCreated},
+		{body, dataaccess.ErrOverbooked, http.StatusConflict},
+		{strings.Replace(body, "dev@github.com", "dev@example.com", 1), nil, http.StatusNotFound},
+		{strings.Replace(body, `"percentage":60`, `"percentage":0`, 1), dataaccess.Booking{}.Validate(), http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		var booked string
+		var booking dataaccess.Booking
+		mda := &mockDataAccess{
+			getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+				return &dataaccess.Profile{EmailAddress: emailAddress}, true, nil
+			},
+			addBookingResponse: func(emailAddress string, b dataaccess.Booking) error {
+				booked, booking = emailAddress, b
+				return test.err
+			},
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "http://example.com/profile/bookings/", strings.NewReader(test.body))
+		NewBookingHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.body, test.expectedCode, w.Code)
+		}
+		if test.expectedCode == http.StatusCreated && (booked != "dev@github.com" || booking.BookedBy != "boss@github.com" || booking.ID == "") {
+			t.Errorf("Expected dev@github.com to be booked by boss@github.com, but %s was booked with %v.", booked, booking)
+		}
+	}
+}
+
+func TestThatTheAllocationsReportListsBookingsInThePeriod(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "boss@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	march := time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC)
+	mda := &mockDataAccess{
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "dev@github.com", Bookings: []dataaccess.Booking{
+					{Project: "website", Start: march, End: march.AddDate(0, 1, 0), Percentage: 50},
+					{Project: "app", Start: march.AddDate(0, 0, 7), End: march.AddDate(0, 2, 0), Percentage: 50},
+				}},
+				{EmailAddress: "later@github.com", Bookings: []dataaccess.Booking{
+					{Project: "website", Start: march.AddDate(1, 0, 0), End: march.AddDate(1, 1, 0), Percentage: 100},
+				}},
+				{EmailAddress: "free@github.com"},
+			}, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/allocations/?from=2017-03-01&to=2017-04-01", nil)
+	NewAllocationHandler(mda, sf).ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but was %d.", w.Code)
+	}
+
+	var allocations []staffing.Allocation
+	if err := json.NewDecoder(w.Body).Decode(&allocations); err != nil {
+		t.Fatal("Failed to decode the allocations.", err)
+	}
+	if len(allocations) != 1 || allocations[0].EmailAddress != "dev@github.com" || allocations[0].Peak != 100 || len(allocations[0].Bookings) != 2 {
+		t.Errorf("Expected dev@github.com to be fully allocated with 2 bookings, but received %v", allocations)
+	}
+}
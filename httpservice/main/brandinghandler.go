@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The BrandingHandler returns the branding of a tenant, which the user
+// interface and PDF profiles are drawn with. The tenant defaults to the
+// caller's, and can be given as ?tenant=example.com before logging in, so
+// that the login page is branded too. Administrators put the branding to
+// white-label a tenant.
+type BrandingHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewBrandingHandler creates an instance of the BrandingHandler.
+func NewBrandingHandler(da dataaccess.DataAccess) *BrandingHandler {
+	return &BrandingHandler{da}
+}
+
+func (handler BrandingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling branding request.")
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	tenant := strings.ToLower(r.FormValue("tenant"))
+	if c, ok := caller.FromContext(r.Context()); ok && tenant == "" {
+		tenant = c.Tenant
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		settings, err := da.GetSettings(tenant)
+		if err != nil {
+			log.Printf("Unable to retrieve the settings of %s. %v", tenant, err)
+			writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		writeJSON(w, http.StatusOK, settings.Branding)
+	case http.MethodPut:
+		c, ok := administrator(r)
+		if !ok {
+			writeError(w, r, http.StatusForbidden, "error.adminOnlyBranding")
+			return
+		}
+		if tenant == "" {
+			tenant = c.Tenant
+		}
+		var b dataaccess.BrandingSettings
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidBranding")
+			return
+		}
+
+		tc, _, err := da.GetTenantConfiguration(tenant)
+		if err != nil {
+			log.Printf("Unable to retrieve the configuration of %s. %v", tenant, err)
+			writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+			return
+		}
+		if tc == nil {
+			tc = dataaccess.NewTenantConfiguration(tenant)
+		}
+		tc.Overrides.Branding = &b
+		if err := da.UpdateTenantConfiguration(tc); err != nil {
+			if _, ok := err.(dataaccess.ValidationError); ok {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Failed to update the branding of %s. %v", tenant, err)
+			writeError(w, r, http.StatusInternalServerError, "error.brandingSaveFailed")
+			return
+		}
+		log.Printf("User %s has updated the branding of %s.", c.EmailAddress, tenant)
+		writeJSON(w, http.StatusOK, b)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatTheBrandingOfTheCallersTenantIsReturned(t *testing.T) {
+	tests := []struct {
+		url            string
+		caller         *caller.Caller
+		expectedTenant string
+	}{
+		{"http://example.com/branding/", &testReport, testReport.Tenant},
+		{"http://example.com/branding/?tenant=Example.com", nil, "example.com"},
+		{"http://example.com/branding/", nil, ""},
+	}
+
+	for _, test := range tests {
+		var tenant string
+		mda := &mockDataAccess{
+			getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+				tenant = domain
+				return dataaccess.Settings{Branding: dataaccess.BrandingSettings{PrimaryColor: "#1f6feb"}}, nil
+			},
+		}
+
+		r, _ := http.NewRequest(http.MethodGet, test.url, nil)
+		if test.caller != nil {
+			r = newRequestWithCaller(http.MethodGet, test.url, "", *test.caller)
+		}
+		w := httptest.NewRecorder()
+		NewBrandingHandler(mda).ServeHTTP(w, r)
+
+		var b dataaccess.BrandingSettings
+		json.NewDecoder(w.Body).Decode(&b)
+		if w.Code != http.StatusOK || b.PrimaryColor != "#1f6feb" || tenant != test.expectedTenant {
+			t.Errorf("For %s, expected the branding of '%s', but got %d %+v for '%s'.", test.url, test.expectedTenant, w.Code, b, tenant)
+		}
+	}
+}
+
+func TestThatOnlyAdministratorsCanChangeTheBranding(t *testing.T) {
+	body := `{"logoUrl":"https://example.com/logo.png","primaryColor":"#1f6feb","footerText":"Example Consulting"}`
+
+	tests := []struct {
+		caller         caller.Caller
+		body           string
+		expectedStatus int
+	}{
+		{testAdministrator, body, http.StatusOK},
+		{testReport, body, http.StatusForbidden},
+		{testAdministrator, `{"primaryColor":"blue"}`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		var updated *dataaccess.TenantConfiguration
+		mda := &mockDataAccess{
+			getTenantConfigurationResponse: func(domain string) (*dataaccess.TenantConfiguration, bool, error) {
+				return dataaccess.NewTenantConfiguration(domain), false, nil
+			},
+			updateTenantConfigurationResponse: func(tc *dataaccess.TenantConfiguration) error {
+				if err := tc.Overrides.Validate(); err != nil {
+					return err
+				}
+				updated = tc
+				return nil
+			},
+		}
+
+		w := httptest.NewRecorder()
+		NewBrandingHandler(mda).ServeHTTP(w, newRequestWithCaller(http.MethodPut, "http://example.com/branding/", test.body, test.caller))
+
+		if w.Code != test.expectedStatus {
+			t.Errorf("For %s, expected status %d, but was %d.", test.body, test.expectedStatus, w.Code)
+			continue
+		}
+		if test.expectedStatus == http.StatusOK && (updated == nil || updated.Domain != testAdministrator.Tenant || updated.Overrides.Branding.FooterText != "Example Consulting") {
+			t.Errorf("Expected the tenant's branding to be updated, but got %+v.", updated)
+		}
+		if test.expectedStatus == http.StatusBadRequest && !strings.Contains(w.Body.String(), "hex colour") {
+			t.Errorf("Expected the validation problem to be returned, but got %s.", w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/contentfilter"
+	"github.com/a-h/pill/dataaccess"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The CalibrationHandler allows managers to propose changes to the levels
+// of the people they manage, and lists the calibrations proposed for, or by,
+// the user.
+type CalibrationHandler struct {
+	DataAccess    dataaccess.DataAccess
+	Configuration *ConfigurationCache
+	now           func() time.Time
+}
+
+// NewCalibrationHandler creates an instance of the CalibrationHandler.
+func NewCalibrationHandler(da dataaccess.DataAccess, cc *ConfigurationCache) *CalibrationHandler {
+	return &CalibrationHandler{da, cc, time.Now}
+}
+
+// The CalibrationDecisionHandler allows people to accept or decline the
+// calibrations proposed for them.
+type CalibrationDecisionHandler struct {
+	DataAccess    dataaccess.DataAccess
+	Configuration *ConfigurationCache
+	now           func() time.Time
+}
+
+// NewCalibrationDecisionHandler creates an instance of the
+// CalibrationDecisionHandler.
+func NewCalibrationDecisionHandler(da dataaccess.DataAccess, cc *ConfigurationCache) *CalibrationDecisionHandler {
+	return &CalibrationDecisionHandler{da, cc, time.Now}
+}
+
+// calibrationProposal is posted by a manager to propose a new level.
+type calibrationProposal struct {
+	EmailAddress string                  `json:"emailAddress"`
+	Skill        string                  `json:"skill"`
+	Level        dataaccess.DreyfusLevel `json:"level"`
+	Note         string                  `json:"note"`
+}
+
+// calibrationDecision is posted by the person to accept or decline a
+// calibration.
+type calibrationDecision struct {
+	ID       string `json:"id"`
+	Decision string `json:"decision"`
+}
+
+// calibrationCaller returns the caller, if calibration is switched on.
+func calibrationCaller(w http.ResponseWriter, r *http.Request, cc *ConfigurationCache) (caller.Caller, bool) {
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, "error.sessionRequired")
+		return c, false
+	}
+	if !cc.Get().IsEnabled(dataaccess.CalibrationFeatureFlag) {
+		writeError(w, r, http.StatusNotFound, "error.calibrationDisabled")
+		return c, false
+	}
+	return c, true
+}
+
+// manages returns true if the person is in the manager's team.
+func manages(da dataaccess.DataAccess, manager string, emailAddress string) (bool, error) {
+	domain := dataaccess.GetDomain(manager)
+	if dataaccess.GetDomain(emailAddress) != domain || strings.EqualFold(manager, emailAddress) {
+		return false, nil
+	}
+
+	profiles, err := da.ListProfiles(manager)
+	if err != nil {
+		return false, err
+	}
+	node, ok := dataaccess.NewOrgTree(domain, profiles).Find(manager)
+	if !ok {
+		return false, nil
+	}
+	for _, id := range node.Members() {
+		if id == strings.ToLower(emailAddress) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func skillLevel(p *dataaccess.Profile, skill string) (dataaccess.DreyfusLevel, bool) {
+	for _, s := range p.Skills {
+		if s.Skill == skill {
+			return s.Level, true
+		}
+	}
+	return 0, false
+}
+
+func (handler CalibrationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling calibration request.")
+
+	c, ok := calibrationCaller(w, r, handler.Configuration)
+	if !ok {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		calibrations, err := da.ListCalibrations(c.EmailAddress)
+		if err != nil {
+			log.Printf("Failed to list the calibrations of %s. %v", c.EmailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.calibrationReadFailed")
+			return
+		}
+		if calibrations == nil {
+			calibrations = []dataaccess.Calibration{}
+		}
+		writeJSON(w, http.StatusOK, calibrations)
+	case http.MethodPost:
+		handleCalibrationPost(w, r, handler, da, c)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func handleCalibrationPost(w http.ResponseWriter, r *http.Request, handler CalibrationHandler, da dataaccess.DataAccess, c caller.Caller) {
+	var proposal calibrationProposal
+	if err := json.NewDecoder(r.Body).Decode(&proposal); err != nil {
+		writeError(w, r, http.StatusBadRequest, "error.invalidCalibration")
+		return
+	}
+	proposal.EmailAddress = strings.ToLower(strings.TrimSpace(proposal.EmailAddress))
+	proposal.Skill = dataaccess.CleanTag(proposal.Skill)
+
+	ok, err := manages(da, c.EmailAddress, proposal.EmailAddress)
+	if err != nil {
+		log.Printf("Failed to check whether %s manages %s. %v", c.EmailAddress, proposal.EmailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.calibrationSaveFailed")
+		return
+	}
+	if !ok {
+		writeError(w, r, http.StatusForbidden, "error.managerOnlyCalibration", proposal.EmailAddress)
+		return
+	}
+
+	profile, found, err := da.GetProfile(proposal.EmailAddress)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", proposal.EmailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.calibrationSaveFailed")
+		return
+	}
+	var level dataaccess.DreyfusLevel
+	if found {
+		level, ok = skillLevel(profile, proposal.Skill)
+	}
+	if !found || !ok {
+		writeError(w, r, http.StatusBadRequest, "error.skillNotOnProfile", proposal.Skill)
+		return
+	}
+
+	calibration := dataaccess.Calibration{
+		ID:           bson.NewObjectId().Hex(),
+		EmailAddress: proposal.EmailAddress,
+		Domain:       dataaccess.GetDomain(proposal.EmailAddress),
+		ProposedBy:   strings.ToLower(c.EmailAddress),
+		Skill:        proposal.Skill,
+		SelfAssessed: level,
+		Proposed:     proposal.Level,
+		Note:         proposal.Note,
+		Status:       dataaccess.CalibrationPending,
+		Created:      handler.now().UTC().Truncate(time.Millisecond),
+	}
+	if err := calibration.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !checkContent(w, r, da, calibration.Domain, contentfilter.Field{Name: "note", Text: calibration.Note}) {
+		return
+	}
+
+	if err := da.SaveCalibration(&calibration); err != nil {
+		log.Print("Failed to save the calibration. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.calibrationSaveFailed")
+		return
+	}
+
+	log.Printf("User %s has proposed changing the %s level of %s to %d.", c.EmailAddress, calibration.Skill, calibration.EmailAddress, calibration.Proposed)
+	writeJSON(w, http.StatusCreated, calibration)
+}
+
+func (handler CalibrationDecisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling calibration decision.")
+
+	c, ok := calibrationCaller(w, r, handler.Configuration)
+	if !ok {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	var d calibrationDecision
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil || (d.Decision != "accept" && d.Decision != "decline") {
+		writeError(w, r, http.StatusBadRequest, "error.invalidCalibrationDecision")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	calibration, found, err := da.GetCalibration(d.ID)
+	if err != nil {
+		log.Printf("Failed to get calibration %s. %v", d.ID, err)
+		writeError(w, r, http.StatusInternalServerError, "error.calibrationReadFailed")
+		return
+	}
+	// Only the person being calibrated can decide, and other people can't
+	// find out which calibrations exist.
+	if !found || !strings.EqualFold(calibration.EmailAddress, c.EmailAddress) {
+		writeError(w, r, http.StatusNotFound, "error.calibrationNotFound")
+		return
+	}
+
+	accept := d.Decision == "accept"
+	if err := calibration.Decide(accept, handler.now()); err != nil {
+		writeError(w, r, http.StatusConflict, "error.calibrationDecided")
+		return
+	}
+
+	if accept {
+		if status, key := applyCalibration(da, calibration); key != "" {
+			writeError(w, r, status, key, calibration.Skill)
+			return
+		}
+	}
+
+	if err := da.SaveCalibration(calibration); err != nil {
+		log.Print("Failed to save the calibration. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.calibrationSaveFailed")
+		return
+	}
+
+	log.Printf("User %s has %s the calibration of %s.", c.EmailAddress, calibration.Status, calibration.Skill)
+	writeJSON(w, http.StatusOK, calibration)
+}
+
+// applyCalibration changes the level of the skill on the person's profile,
+// returning the status and error key if it can't.
+func applyCalibration(da dataaccess.DataAccess, c *dataaccess.Calibration) (int, string) {
+	profile, found, err := da.GetProfile(c.EmailAddress)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", c.EmailAddress, err)
+		return http.StatusInternalServerError, "error.calibrationSaveFailed"
+	}
+	if !found {
+		return http.StatusConflict, "error.skillNotOnProfile"
+	}
+	if _, ok := skillLevel(profile, c.Skill); !ok {
+		return http.StatusConflict, "error.skillNotOnProfile"
+	}
+
+	pu := dataaccess.NewProfileUpdate()
+	pu.EmailAddress = profile.EmailAddress
+	pu.Availability = profile.Availability
+	for _, s := range profile.Skills {
+		if s.Skill == c.Skill {
+			s.Level = c.Proposed
+		}
+		pu.Skills = append(pu.Skills, s)
+	}
+
+	if _, err := da.UpdateProfile(pu); err != nil {
+		log.Printf("Failed to apply the calibration to the profile of %s. %v", c.EmailAddress, err)
+		return http.StatusInternalServerError, "error.calibrationSaveFailed"
+	}
+	return 0, ""
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+var testManager = caller.Caller{
+	EmailAddress: "boss@github.com",
+	Roles:        []string{dataaccess.UserRole},
+	Tenant:       "github.com",
+}
+
+var testReport = caller.Caller{
+	EmailAddress: "dev@github.com",
+	Roles:        []string{dataaccess.UserRole},
+	Tenant:       "github.com",
+}
+
+func newCalibrationDataAccess() *mockDataAccess {
+	return &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "boss@github.com", Domain: "github.com"},
+				{EmailAddress: "dev@github.com", Domain: "github.com", Manager: "boss@github.com"},
+				{EmailAddress: "peer@github.com", Domain: "github.com"},
+			}, nil
+		},
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{
+				EmailAddress: emailAddress,
+				Domain:       "github.com",
+				Skills: []dataaccess.Skill{
+					{Skill: "go", Level: dataaccess.ExpertLevel},
+					{Skill: "sql", Level: dataaccess.NoviceLevel},
+				},
+			}, true, nil
+		},
+		saveCalibrationResponse: func(c *dataaccess.Calibration) error { return nil },
+	}
+}
+
+func TestThatOnlyManagersCanProposeCalibrations(t *testing.T) {
+	tests := []struct {
+		caller       caller.Caller
+		body         string
+		expectedCode int
+	}{
+		{
+			caller:       testManager,
+			body:         `{"emailAddress":"dev@github.com","skill":"go","level":3,"note":"Needs more production experience."}`,
+			expectedCode: http.StatusCreated,
+		},
+		{
+			caller:       caller.Caller{EmailAddress: "peer@github.com", Tenant: "github.com"},
+			body:         `{"emailAddress":"dev@github.com","skill":"go","level":3}`,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caller:       testReport,
+			body:         `{"emailAddress":"dev@github.com","skill":"go","level":5}`,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			caller:       testManager,
+			body:         `{"emailAddress":"dev@github.com","skill":"cobol","level":3}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			caller:       testManager,
+			body:         `{"emailAddress":"dev@github.com","skill":"go","level":4}`,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		mda := newCalibrationDataAccess()
+		cc := newTestConfigurationCache(dataaccess.Configuration{FeatureFlags: map[string]bool{dataaccess.CalibrationFeatureFlag: true}})
+
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "http://example.com/profile/calibrations/", test.body, test.caller)
+		NewCalibrationHandler(mda, cc).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s posting %s, expected status %d, but was %d.", test.caller.EmailAddress, test.body, test.expectedCode, w.Code)
+		}
+		saved := test.expectedCode == http.StatusCreated
+		if saved != (mda.saveCalibrationCallCount == 1) {
+			t.Errorf("For %s posting %s, expected the calibration to be saved: %v, but it was saved %d times.", test.caller.EmailAddress, test.body, saved, mda.saveCalibrationCallCount)
+		}
+	}
+}
+
+func TestThatCalibrationsAreNotAvailableUnlessSwitchedOn(t *testing.T) {
+	mda := newCalibrationDataAccess()
+	cc := newTestConfigurationCache(dataaccess.Configuration{})
+
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("POST", "http://example.com/profile/calibrations/", `{"emailAddress":"dev@github.com","skill":"go","level":3}`, testManager)
+	NewCalibrationHandler(mda, cc).ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, but was %d.", w.Code)
+	}
+	if mda.saveCalibrationCallCount != 0 {
+		t.Errorf("Expected no calibrations to be saved, but %d were.", mda.saveCalibrationCallCount)
+	}
+}
+
+func TestThatAcceptingACalibrationChangesTheLevel(t *testing.T) {
+	mda := newCalibrationDataAccess()
+	pending := &dataaccess.Calibration{
+		ID:           "c1",
+		EmailAddress: "dev@github.com",
+		ProposedBy:   "boss@github.com",
+		Skill:        "go",
+		SelfAssessed: dataaccess.ExpertLevel,
+		Proposed:     dataaccess.CompetentLevel,
+		Status:       dataaccess.CalibrationPending,
+	}
+	mda.getCalibrationResponse = func(id string) (*dataaccess.Calibration, bool, error) {
+		return pending, id == pending.ID, nil
+	}
+	var update *dataaccess.ProfileUpdate
+	mda.updateProfileResponse = func(pu *dataaccess.ProfileUpdate) (*dataaccess.Profile, error) {
+		update = pu
+		return &dataaccess.Profile{}, nil
+	}
+	var saved dataaccess.Calibration
+	mda.saveCalibrationResponse = func(c *dataaccess.Calibration) error {
+		saved = *c
+		return nil
+	}
+	cc := newTestConfigurationCache(dataaccess.Configuration{FeatureFlags: map[string]bool{dataaccess.CalibrationFeatureFlag: true}})
+	now := time.Date(2017, time.March, 1, 9, 0, 0, 0, time.UTC)
+	handler := NewCalibrationDecisionHandler(mda, cc)
+	handler.now = func() time.Time { return now }
+
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("POST", "http://example.com/profile/calibrations/decisions/", `{"id":"c1","decision":"accept"}`, testReport)
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but was %d.", w.Code)
+	}
+	if update == nil {
+		t.Fatal("Expected the profile to be updated, but it wasn't.")
+	}
+	for _, s := range update.Skills {
+		if s.Skill == "go" && s.Level != dataaccess.CompetentLevel {
+			t.Errorf("Expected the go level to be changed to %d, but was %d.", dataaccess.CompetentLevel, s.Level)
+		}
+		if s.Skill == "sql" && s.Level != dataaccess.NoviceLevel {
+			t.Errorf("Expected the sql level to be unchanged, but was %d.", s.Level)
+		}
+	}
+	if saved.Status != dataaccess.CalibrationAccepted || !saved.Decided.Equal(now) {
+		t.Errorf("Expected the calibration to be saved as accepted at %v, but was %s at %v.", now, saved.Status, saved.Decided)
+	}
+
+	var result dataaccess.Calibration
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatal("Failed to decode the calibration.", err)
+	}
+	if result.Status != dataaccess.CalibrationAccepted {
+		t.Errorf("Expected the accepted calibration to be returned, but the status was %s.", result.Status)
+	}
+
+	w = httptest.NewRecorder()
+	r = newRequestWithCaller("POST", "http://example.com/profile/calibrations/decisions/", `{"id":"c1","decision":"decline"}`, testReport)
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected deciding the calibration twice to return status 409, but was %d.", w.Code)
+	}
+}
+
+func TestThatOnlyThePersonBeingCalibratedCanDecide(t *testing.T) {
+	for _, c := range []caller.Caller{testManager, testAdministrator} {
+		mda := newCalibrationDataAccess()
+		mda.getCalibrationResponse = func(id string) (*dataaccess.Calibration, bool, error) {
+			return &dataaccess.Calibration{
+				ID:           id,
+				EmailAddress: "dev@github.com",
+				Skill:        "go",
+				Status:       dataaccess.CalibrationPending,
+			}, true, nil
+		}
+		cc := newTestConfigurationCache(dataaccess.Configuration{FeatureFlags: map[string]bool{dataaccess.CalibrationFeatureFlag: true}})
+
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "http://example.com/profile/calibrations/decisions/", `{"id":"c1","decision":"accept"}`, c)
+		NewCalibrationDecisionHandler(mda, cc).ServeHTTP(w, r)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("For %s, expected status 404, but was %d.", c.EmailAddress, w.Code)
+		}
+		if mda.updateProfileCallCount != 0 || mda.saveCalibrationCallCount != 0 {
+			t.Errorf("For %s, expected nothing to be saved, but the profile was updated %d times and the calibration saved %d times.", c.EmailAddress, mda.updateProfileCallCount, mda.saveCalibrationCallCount)
+		}
+	}
+}
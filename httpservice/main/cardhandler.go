@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/i18n"
+)
+
+// The number of skills shown on a profile card, unless ?skills= says
+// otherwise.
+const (
+	defaultCardSkills = 5
+	maxCardSkills     = 10
+)
+
+// The size of the frame profile cards are embedded in, unless the consumer
+// asks for a smaller one.
+const (
+	cardWidth  = 320
+	cardHeight = 200
+)
+
+// The CardHandler returns a compact profile card of someone in the user's
+// tenant, for embedding in wiki pages, e.g.
+// /profile/card/?emailAddress=dev@example.com. Browsers get an HTML card,
+// and everything else gets JSON.
+type CardHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewCardHandler creates an instance of the CardHandler.
+func NewCardHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *CardHandler {
+	return &CardHandler{da, sessionFactory, time.Now}
+}
+
+// The OEmbedHandler implements the oEmbed protocol (https://oembed.com) for
+// profile cards, so that wikis which support it can embed a card from its
+// URL, e.g. /oembed/?url=https://pill.example.com/profile/card/?emailAddress=dev@example.com.
+// Wikis call it from their servers, without the user's session, so the
+// response only contains a frame which loads the card in the user's
+// browser.
+type OEmbedHandler struct {
+	baseURL string
+}
+
+// NewOEmbedHandler creates an instance of the OEmbedHandler for cards under
+// the base URL.
+func NewOEmbedHandler(baseURL string) *OEmbedHandler {
+	return &OEmbedHandler{strings.TrimSuffix(baseURL, "/")}
+}
+
+type profileCard struct {
+	Name         string               `json:"name"`
+	EmailAddress string               `json:"emailAddress"`
+	Skills       []dataaccess.Skill   `json:"skills"`
+	Availability dataaccess.RagStatus `json:"availability"`
+	// AvailabilityName is the availability in the user's language.
+	AvailabilityName string `json:"availabilityName"`
+}
+
+// oEmbedResponse is an oEmbed "rich" response.
+type oEmbedResponse struct {
+	Version      string `json:"version"`
+	Type         string `json:"type"`
+	Title        string `json:"title"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+func (handler CardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling profile card request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	owner := r.FormValue("emailAddress")
+	if owner == "" {
+		owner = emailAddress
+	}
+	if dataaccess.GetDomain(owner) != dataaccess.GetDomain(emailAddress) {
+		writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+		return
+	}
+	limit := defaultCardSkills
+	if s := r.FormValue("skills"); s != "" {
+		var err error
+		if limit, err = strconv.Atoi(s); err != nil || limit < 1 || limit > maxCardSkills {
+			writeError(w, r, http.StatusBadRequest, "error.invalidCardSkills", maxCardSkills)
+			return
+		}
+	}
+
+	profile, found, err := dataaccess.WithContext(handler.DataAccess, r.Context()).GetProfile(owner)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", owner, err)
+		writeError(w, r, http.StatusInternalServerError, "error.profileReadFailed", owner)
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+		return
+	}
+
+	card := newProfileCard(profile, limit, handler.now())
+	card.AvailabilityName = i18n.Translate(i18n.Negotiate(r.Header.Get("Accept-Language")), availabilityKey(card.Availability))
+
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		renderTemplate(w, "card.html", card)
+		return
+	}
+	writeJSON(w, http.StatusOK, card)
+}
+
+// newProfileCard returns the person's highest level skills, up to the
+// limit, and their availability at the time.
+func newProfileCard(p *dataaccess.Profile, limit int, at time.Time) profileCard {
+	skills := append([]dataaccess.Skill{}, p.Skills...)
+	sort.SliceStable(skills, func(i, j int) bool {
+		if skills[i].Level != skills[j].Level {
+			return skills[i].Level > skills[j].Level
+		}
+		return skills[i].Skill < skills[j].Skill
+	})
+	if len(skills) > limit {
+		skills = skills[:limit]
+	}
+	name := p.Name
+	if name == "" {
+		name = p.EmailAddress
+	}
+	return profileCard{
+		Name:         name,
+		EmailAddress: p.EmailAddress,
+		Skills:       skills,
+		Availability: p.AvailabilityAt(at),
+	}
+}
+
+func availabilityKey(s dataaccess.RagStatus) string {
+	switch s {
+	case dataaccess.Red:
+		return "availability.red"
+	case dataaccess.Amber:
+		return "availability.amber"
+	case dataaccess.Green:
+		return "availability.green"
+	}
+	return "availability.unknown"
+}
+
+func (handler OEmbedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling oEmbed request.")
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+	// The specification requires 501 for formats which aren't supported.
+	if f := r.FormValue("format"); f != "" && f != "json" {
+		writeError(w, r, http.StatusNotImplemented, "error.oEmbedFormat")
+		return
+	}
+
+	card, err := url.Parse(r.FormValue("url"))
+	if err != nil || !strings.HasPrefix(r.FormValue("url"), handler.baseURL+"/profile/card/") || card.Query().Get("emailAddress") == "" {
+		writeError(w, r, http.StatusNotFound, "error.oEmbedURL")
+		return
+	}
+
+	width, height := cardWidth, cardHeight
+	if mw, err := strconv.Atoi(r.FormValue("maxwidth")); err == nil && mw > 0 && mw < width {
+		width = mw
+	}
+	if mh, err := strconv.Atoi(r.FormValue("maxheight")); err == nil && mh > 0 && mh < height {
+		height = mh
+	}
+
+	writeJSON(w, http.StatusOK, oEmbedResponse{
+		Version:      "1.0",
+		Type:         "rich",
+		Title:        "pill profile",
+		ProviderName: "pill",
+		ProviderURL:  handler.baseURL,
+		HTML: fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" frameborder="0" scrolling="no"></iframe>`,
+			template.HTMLEscapeString(card.String()), width, height),
+		Width:  width,
+		Height: height,
+	})
+}
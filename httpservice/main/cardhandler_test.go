@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatProfileCardsShowTheHighestLevelSkillsAndAvailability(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{
+				EmailAddress: emailAddress,
+				Name:         "Lead",
+				Availability: dataaccess.Green,
+				Skills: []dataaccess.Skill{
+					{Skill: "java", Level: dataaccess.CompetentLevel},
+					{Skill: "go", Level: dataaccess.ExpertLevel},
+					{Skill: "rust", Level: dataaccess.NoviceLevel},
+					{Skill: "c", Level: dataaccess.ExpertLevel},
+				},
+			}, true, nil
+		},
+	}
+
+	tests := []struct {
+		url            string
+		expectedStatus int
+		expectedSkills []string
+	}{
+		{"/profile/card/?emailAddress=lead@github.com&skills=3", http.StatusOK, []string{"c", "go", "java"}},
+		{"/profile/card/?emailAddress=lead@github.com", http.StatusOK, []string{"c", "go", "java", "rust"}},
+		{"/profile/card/?emailAddress=lead@github.com&skills=11", http.StatusBadRequest, nil},
+		{"/profile/card/?emailAddress=dev@example.com", http.StatusNotFound, nil},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, test.url, nil)
+		NewCardHandler(mda, sf).ServeHTTP(w, r)
+		if w.Code != test.expectedStatus {
+			t.Errorf("For %s, expected status %d, but got %d.", test.url, test.expectedStatus, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var card profileCard
+		json.NewDecoder(w.Body).Decode(&card)
+		var skills []string
+		for _, s := range card.Skills {
+			skills = append(skills, s.Skill)
+		}
+		if strings.Join(skills, ",") != strings.Join(test.expectedSkills, ",") {
+			t.Errorf("For %s, expected skills %v, but got %v.", test.url, test.expectedSkills, skills)
+		}
+		if card.Name != "Lead" || card.Availability != dataaccess.Green || card.AvailabilityName != "green" {
+			t.Errorf("For %s, expected the name and availability, but got %+v.", test.url, card)
+		}
+	}
+}
+
+func TestThatBookingsReduceTheAvailabilityOnProfileCards(t *testing.T) {
+	now := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	p := &dataaccess.Profile{
+		Availability: dataaccess.Green,
+		Bookings:     []dataaccess.Booking{{Start: now.AddDate(0, 0, -1), End: now.AddDate(0, 0, 1), Percentage: 100}},
+	}
+	if card := newProfileCard(p, defaultCardSkills, now); card.Availability != dataaccess.Red {
+		t.Errorf("Expected a fully booked person to be red, but was %v.", card.Availability)
+	}
+}
+
+func TestThatOEmbedReturnsAFrameForProfileCards(t *testing.T) {
+	card := "https://pill.example.com/profile/card/?emailAddress=dev@github.com"
+	tests := []struct {
+		query          url.Values
+		expectedStatus int
+		expectedWidth  int
+	}{
+		{url.Values{"url": {card}}, http.StatusOK, cardWidth},
+		{url.Values{"url": {card}, "maxwidth": {"200"}}, http.StatusOK, 200},
+		{url.Values{"url": {card}, "format": {"xml"}}, http.StatusNotImplemented, 0},
+		{url.Values{"url": {"https://evil.example.com/profile/card/?emailAddress=dev@github.com"}}, http.StatusNotFound, 0},
+		{url.Values{"url": {"https://pill.example.com/profile/card/"}}, http.StatusNotFound, 0},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, "/oembed/?"+test.query.Encode(), nil)
+		NewOEmbedHandler("https://pill.example.com/").ServeHTTP(w, r)
+		if w.Code != test.expectedStatus {
+			t.Errorf("For %v, expected status %d, but got %d.", test.query, test.expectedStatus, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var resp oEmbedResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		if resp.Type != "rich" || resp.Width != test.expectedWidth || !strings.Contains(resp.HTML, `src="https://pill.example.com/profile/card/?emailAddress=dev@github.com"`) {
+			t.Errorf("For %v, expected a frame %d wide, but got %+v.", test.query, test.expectedWidth, resp)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The ClearanceHandler reads and records security clearances, e.g.
+// /profile/clearance/?emailAddress=dev@example.com. The profile defaults to
+// the user's. People can read their own clearance, but only the roles in the
+// tenant's clearance settings can read or change anyone else's.
+type ClearanceHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewClearanceHandler creates an instance of the ClearanceHandler.
+func NewClearanceHandler(da dataaccess.DataAccess) *ClearanceHandler {
+	return &ClearanceHandler{da}
+}
+
+// clearanceRequest is put to record a clearance. Expires is a date, e.g.
+// "2020-09-30", and can be left out.
+type clearanceRequest struct {
+	Level   string `json:"level"`
+	Expires string `json:"expires"`
+}
+
+func (handler ClearanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling clearance request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, "error.sessionRequired")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	owner := c.EmailAddress
+	if other := r.FormValue("emailAddress"); other != "" {
+		owner = other
+	}
+	profile, ok := colleague(w, r, da, c.EmailAddress, owner)
+	if !ok {
+		return
+	}
+
+	settings, err := da.GetSettings(dataaccess.GetDomain(profile.EmailAddress))
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", profile.EmailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if !settings.Clearance.CanView(c, profile.EmailAddress) {
+			writeError(w, r, http.StatusForbidden, "error.clearanceRestricted")
+			return
+		}
+		if profile.Clearance == nil {
+			writeError(w, r, http.StatusNotFound, "error.clearanceNotFound")
+			return
+		}
+		writeJSON(w, http.StatusOK, profile.Clearance)
+	case http.MethodPut:
+		if !settings.Clearance.CanChange(c) {
+			writeError(w, r, http.StatusForbidden, "error.clearanceRestricted")
+			return
+		}
+		var req clearanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidClearance")
+			return
+		}
+		cl := dataaccess.Clearance{Level: strings.TrimSpace(req.Level), VerifiedBy: c.EmailAddress}
+		if req.Expires != "" {
+			if cl.Expires, err = time.Parse("2006-01-02", req.Expires); err != nil {
+				writeError(w, r, http.StatusBadRequest, "error.invalidClearance")
+				return
+			}
+		}
+		if err := settings.Clearance.Check(cl); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := da.UpdateClearance(profile.EmailAddress, &cl); err != nil {
+			log.Printf("Failed to update the clearance of %s. %v", profile.EmailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.clearanceSaveFailed")
+			return
+		}
+		log.Printf("User %s has updated the clearance of %s.", c.EmailAddress, profile.EmailAddress)
+		writeJSON(w, http.StatusOK, cl)
+	case http.MethodDelete:
+		if !settings.Clearance.CanChange(c) {
+			writeError(w, r, http.StatusForbidden, "error.clearanceRestricted")
+			return
+		}
+		if err := da.UpdateClearance(profile.EmailAddress, nil); err != nil {
+			log.Printf("Failed to remove the clearance of %s. %v", profile.EmailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.clearanceSaveFailed")
+			return
+		}
+		log.Printf("User %s has removed the clearance of %s.", c.EmailAddress, profile.EmailAddress)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatOnlySecurityRolesCanChangeClearances(t *testing.T) {
+	var saved *dataaccess.Clearance
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{EmailAddress: emailAddress}, true, nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			s := dataaccess.DefaultSettings()
+			s.Clearance.Levels = []string{"SC", "DV"}
+			return s, nil
+		},
+		updateClearanceResponse: func(emailAddress string, cl *dataaccess.Clearance) error {
+			saved = cl
+			return nil
+		},
+	}
+	officer := caller.Caller{EmailAddress: "officer@github.com", Roles: []string{dataaccess.UserRole, dataaccess.SecurityOfficerRole}, Tenant: "github.com"}
+
+	tests := []struct {
+		c            caller.Caller
+		body         string
+		expectedCode int
+	}{
+		{officer, `{"level":"SC","expires":"2020-09-30"}`, http.StatusOK},
+		{testAdministrator, `{"level":"DV"}`, http.StatusOK},
+		{officer, `{"level":"TS"}`, http.StatusBadRequest},
+		{officer, `{"level":"SC","expires":"soon"}`, http.StatusBadRequest},
+		{caller.Caller{EmailAddress: "dev@github.com", Roles: []string{dataaccess.UserRole}}, `{"level":"DV"}`, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		saved = nil
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("PUT", "http://example.com/profile/clearance/?emailAddress=dev@github.com", test.body, test.c)
+		NewClearanceHandler(mda).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s putting %s, expected status %d, but was %d.", test.c.EmailAddress, test.body, test.expectedCode, w.Code)
+			continue
+		}
+		if test.expectedCode == http.StatusOK && (saved == nil || saved.VerifiedBy != test.c.EmailAddress) {
+			t.Errorf("For %s putting %s, expected the clearance to be verified by them, but saved %v", test.c.EmailAddress, test.body, saved)
+		}
+		if test.expectedCode != http.StatusOK && saved != nil {
+			t.Errorf("For %s putting %s, expected nothing to be saved, but saved %v", test.c.EmailAddress, test.body, saved)
+		}
+	}
+}
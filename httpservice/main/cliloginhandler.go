@@ -0,0 +1,157 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/middleware"
+	"github.com/a-h/pill/tokenverifier"
+)
+
+// The CLILoginHandler logs people in to pillctl from their browser. pillctl
+// listens on a loopback port and opens /login/cli/?port=...&state=..., which
+// shows the logon screen if the person isn't logged in, and then asks them to
+// confirm. Confirming posts the form, with its CSRF token, and redirects back
+// to pillctl with a new session token, so that no other page can send a
+// session to the port by linking to it. pillctl keeps the token and exchanges
+// it for access tokens from the TokenHandler. Like a browser's, the session
+// is ended when the person's sessions are revoked.
+type CLILoginHandler struct {
+	getSession    func(w http.ResponseWriter, r *http.Request) Session
+	TokenVerifier tokenverifier.TokenVerifier
+	Configuration *ConfigurationCache
+}
+
+// NewCLILoginHandler creates an instance of the CLILoginHandler.
+func NewCLILoginHandler(sessionFactory func(w http.ResponseWriter, r *http.Request) Session, verifier tokenverifier.TokenVerifier, cc *ConfigurationCache) *CLILoginHandler {
+	return &CLILoginHandler{sessionFactory, verifier, cc}
+}
+
+// maxCLILoginStateLength limits the state pillctl uses to match the redirect
+// to its login.
+const maxCLILoginStateLength = 128
+
+// cliLoginRedirect returns the loopback address pillctl is listening on, or
+// false if the port or state are invalid.
+func cliLoginRedirect(r *http.Request) (*url.URL, bool) {
+	port, err := strconv.Atoi(r.URL.Query().Get("port"))
+	if err != nil || port < 1024 || port > 65535 {
+		return nil, false
+	}
+	state := r.URL.Query().Get("state")
+	if state == "" || len(state) > maxCLILoginStateLength {
+		return nil, false
+	}
+	return &url.URL{
+		Scheme: "http",
+		Host:   "127.0.0.1:" + strconv.Itoa(port),
+		Path:   "/callback",
+	}, true
+}
+
+func (handler CLILoginHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling CLI login.")
+
+	redirect, ok := cliLoginRedirect(r)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "error.invalidCLILogin")
+		return
+	}
+
+	if strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		log.Print("Refusing to log in to pillctl with a bearer token.")
+		writeError(w, r, http.StatusForbidden, "error.tokenFromBearer")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet:
+		handler.get(w, r)
+	case r.Method == http.MethodPost && r.PostFormValue("confirm") == "true":
+		handler.confirm(w, r, redirect)
+	case r.Method == http.MethodPost:
+		handler.login(w, r)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+// get asks the person to confirm that pillctl can act as them, or shows the
+// logon screen, which posts back to the same address.
+func (handler CLILoginHandler) get(w http.ResponseWriter, r *http.Request) {
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		log.Print("Rendering the login template for pillctl.")
+		renderTemplate(w, "login.html", &loginModel{CSRFToken: middleware.CSRFToken(r)})
+		return
+	}
+
+	if c.Impersonator != "" {
+		log.Printf("Refusing to log %s in to pillctl while impersonating %s.", c.Impersonator, c.EmailAddress)
+		writeError(w, r, http.StatusForbidden, "error.tokenWhileImpersonating")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	renderTemplate(w, "clilogin.html", &cliLoginModel{EmailAddress: c.EmailAddress, CSRFToken: middleware.CSRFToken(r)})
+}
+
+// confirm redirects to pillctl with a new session token, once the person has
+// confirmed the login.
+func (handler CLILoginHandler) confirm(w http.ResponseWriter, r *http.Request, redirect *url.URL) {
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		http.Redirect(w, r, r.URL.RequestURI(), http.StatusSeeOther)
+		return
+	}
+
+	if c.Impersonator != "" {
+		log.Printf("Refusing to log %s in to pillctl while impersonating %s.", c.Impersonator, c.EmailAddress)
+		writeError(w, r, http.StatusForbidden, "error.tokenWhileImpersonating")
+		return
+	}
+
+	manager, err := newSessionManager(handler.Configuration.Get())
+	if err != nil {
+		log.Print("Failed to create the session manager. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.tokenIssueFailed")
+		return
+	}
+	token, err := manager.Issue(c.EmailAddress)
+	if err != nil {
+		log.Print("Failed to issue a session token. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.tokenIssueFailed")
+		return
+	}
+
+	log.Printf("Logging user %s in to pillctl.", c.EmailAddress)
+
+	redirect.RawQuery = url.Values{
+		"state":   {r.URL.Query().Get("state")},
+		"session": {token},
+	}.Encode()
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, redirect.String(), http.StatusSeeOther)
+}
+
+// login starts the browser's session from the logon screen, and returns to
+// the CLI login to confirm it.
+func (handler CLILoginHandler) login(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	idToken := r.FormValue("id_token")
+
+	claim, err := handler.TokenVerifier.ValidateToken(idToken)
+	if err != nil {
+		log.Printf("The claim %s is invalid. With error message %s", idToken, err.Error())
+		writeError(w, r, http.StatusInternalServerError, "error.invalidClaim")
+		return
+	}
+
+	handler.getSession(w, r).StartSession(claim.Email)
+
+	http.Redirect(w, r, r.URL.RequestURI(), http.StatusSeeOther)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/tokenverifier"
+)
+
+func newTestCLILoginHandler(ms *mockSession) *CLILoginHandler {
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	c := *dataaccess.NewConfiguration([]byte("0123456789abcdef0123456789abcdef"))
+	return NewCLILoginHandler(sf, tokenverifier.NewTestTokenVerifier(&tokenverifier.Claim{Email: "a-h@github.com"}, nil), newTestConfigurationCache(c))
+}
+
+func TestThatLoggedInUsersAreAskedToConfirmThePillctlLogin(t *testing.T) {
+	handler := newTestCLILoginHandler(&mockSession{})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/login/cli/?port=51234&state=abc", nil)
+	r = r.WithContext(caller.NewContext(r.Context(), caller.Caller{EmailAddress: "a-h@github.com"}))
+
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the status to be 200, but was %d.", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "a-h@github.com") || !strings.Contains(w.Body.String(), `name="confirm"`) {
+		t.Errorf("Expected the confirmation to be shown, but was:\n%s", w.Body.String())
+	}
+	if w.Header().Get("Location") != "" {
+		t.Error("A session should not be sent to pillctl before the login is confirmed.")
+	}
+}
+
+func TestThatConfirmingThePillctlLoginRedirectsToPillctlWithASession(t *testing.T) {
+	handler := newTestCLILoginHandler(&mockSession{})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "http://example.com/login/cli/?port=51234&state=abc", strings.NewReader("confirm=true"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(caller.NewContext(r.Context(), caller.Caller{EmailAddress: "a-h@github.com"}))
+
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("Expected the status to be 303, but was %d.", w.Code)
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if location.Host != "127.0.0.1:51234" || location.Path != "/callback" {
+		t.Errorf("Expected a redirect to pillctl on 127.0.0.1:51234, but was %s", location)
+	}
+	if state := location.Query().Get("state"); state != "abc" {
+		t.Errorf("Expected the state to be returned to pillctl, but was '%s'", state)
+	}
+
+	manager, _ := newSessionManager(handler.Configuration.Get())
+	token, err := manager.Verify(location.Query().Get("session"))
+	if err != nil {
+		t.Fatal("The session token should be valid.", err)
+	}
+	if token.EmailAddress != "a-h@github.com" {
+		t.Errorf("Expected the session to be for a-h@github.com, but was %s", token.EmailAddress)
+	}
+}
+
+func TestThatTheLogonScreenIsShownToPillctlUsersWhoArentLoggedIn(t *testing.T) {
+	handler := newTestCLILoginHandler(&mockSession{})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/login/cli/?port=51234&state=abc", nil)
+
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected the status to be 200, but was %d.", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Login with your Google Account") {
+		t.Error("The login view was not rendered.")
+	}
+	if strings.Contains(w.Header().Get("Location"), "127.0.0.1") {
+		t.Error("A session should not be sent to pillctl before logging in.")
+	}
+}
+
+func TestThatLoggingInFromTheLogonScreenReturnsToConfirmThePillctlLogin(t *testing.T) {
+	ms := &mockSession{}
+	handler := newTestCLILoginHandler(ms)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "http://example.com/login/cli/?port=51234&state=abc", strings.NewReader("id_token=token"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	handler.ServeHTTP(w, r)
+
+	if !ms.startSessionWasCalled {
+		t.Error("Expected the browser's session to be started.")
+	}
+	if location := w.Header().Get("Location"); location != "/login/cli/?port=51234&state=abc" {
+		t.Errorf("Expected a redirect back to the pillctl login, but was '%s'", location)
+	}
+}
+
+func TestThatPillctlSessionsArentIssuedToInvalidRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		caller   caller.Caller
+		bearer   bool
+		expected int
+	}{
+		{"no port", "http://example.com/login/cli/?state=abc", caller.Caller{EmailAddress: "a-h@github.com"}, false, http.StatusBadRequest},
+		{"privileged port", "http://example.com/login/cli/?port=80&state=abc", caller.Caller{EmailAddress: "a-h@github.com"}, false, http.StatusBadRequest},
+		{"no state", "http://example.com/login/cli/?port=51234", caller.Caller{EmailAddress: "a-h@github.com"}, false, http.StatusBadRequest},
+		{"impersonating", "http://example.com/login/cli/?port=51234&state=abc", caller.Caller{EmailAddress: "a-h@github.com", Impersonator: "admin@github.com"}, false, http.StatusForbidden},
+		{"bearer token", "http://example.com/login/cli/?port=51234&state=abc", caller.Caller{EmailAddress: "a-h@github.com"}, true, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		handler := newTestCLILoginHandler(&mockSession{})
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", test.url, strings.NewReader("confirm=true"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if test.bearer {
+			r.Header.Set("Authorization", "Bearer token")
+		}
+		r = r.WithContext(caller.NewContext(r.Context(), test.caller))
+
+		handler.ServeHTTP(w, r)
+
+		if w.Code != test.expected {
+			t.Errorf("%s: expected the status to be %d, but was %d.", test.name, test.expected, w.Code)
+		}
+		if strings.Contains(w.Header().Get("Location"), "session=") {
+			t.Errorf("%s: expected no session to be issued.", test.name)
+		}
+	}
+}
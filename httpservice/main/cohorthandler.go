@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The CohortHandler compares the skills of two groups of people in the
+// user's domain, e.g. /report/cohorts/?a=department:Engineering&b=department:Product
+// or ?a=city:London&b=city:Berlin
+type CohortHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewCohortHandler creates an instance of the CohortHandler.
+func NewCohortHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *CohortHandler {
+	return &CohortHandler{da, sessionFactory}
+}
+
+func (handler CohortHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling cohort comparison request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	var cohorts []dataaccess.Cohort
+	for _, s := range []string{r.URL.Query().Get("a"), r.URL.Query().Get("b")} {
+		if s == "" {
+			writeError(w, r, http.StatusBadRequest, "error.comparisonRequiresTwoCohorts")
+			return
+		}
+		c, err := dataaccess.ParseCohort(s)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		cohorts = append(cohorts, c)
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	profiles, err := da.ListProfiles(emailAddress)
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+	domain := dataaccess.GetDomain(emailAddress)
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	profiles = dataaccess.Consenting(profiles, dataaccess.ConsentAnalytics, settings.Consent)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(dataaccess.CompareCohorts(profiles, cohorts[0], cohorts[1])); err != nil {
+		log.Printf("Failed to marshall the cohort comparison, with error %s", err)
+	}
+}
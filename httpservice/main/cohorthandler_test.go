@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatCohortsInTheUsersDomainCanBeCompared(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	mda := &mockDataAccess{
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "a@github.com", WorkLocation: &dataaccess.WorkLocation{City: "London"}, Skills: []dataaccess.Skill{{Skill: "go", Level: 4}}},
+				{EmailAddress: "b@github.com", WorkLocation: &dataaccess.WorkLocation{City: "Berlin"}, Skills: []dataaccess.Skill{{Skill: "go", Level: 2}}},
+				{EmailAddress: "c@github.com"},
+				{EmailAddress: "private@github.com", WorkLocation: &dataaccess.WorkLocation{City: "London"}, Skills: []dataaccess.Skill{{Skill: "cobol", Level: 5}},
+					Consents: []dataaccess.Consent{{Purpose: dataaccess.ConsentAnalytics, Given: false}}},
+			}, nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+	}
+
+	tests := []struct {
+		query        string
+		expectedCode int
+	}{
+		{"?a=city:London&b=city:Berlin", http.StatusOK},
+		{"?a=city:London", http.StatusBadRequest},
+		{"?a=city:London&b=salary:100", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "http://example.com/report/cohorts/"+test.query, nil)
+
+		NewCohortHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.query, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var c dataaccess.CohortComparison
+		if err := json.NewDecoder(w.Body).Decode(&c); err != nil {
+			t.Fatal("Failed to decode the comparison.", err)
+		}
+		if c.A.People != 1 || c.B.People != 1 || len(c.Skills) != 1 || c.Skills[0].LevelDelta != 2 {
+			t.Errorf("Unexpected comparison %+v", c)
+		}
+	}
+}
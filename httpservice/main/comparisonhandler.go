@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The ComparisonHandler compares the skills of two people in the user's
+// domain, e.g. /report/compare/?a=someone@example.com&b=someone.else@example.com
+type ComparisonHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewComparisonHandler creates an instance of the ComparisonHandler.
+func NewComparisonHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *ComparisonHandler {
+	return &ComparisonHandler{da, sessionFactory}
+}
+
+func (handler ComparisonHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling profile comparison request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	domain := dataaccess.GetDomain(emailAddress)
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	var profiles []dataaccess.Profile
+	for _, id := range []string{r.URL.Query().Get("a"), r.URL.Query().Get("b")} {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" {
+			writeError(w, r, http.StatusBadRequest, "error.comparisonRequiresTwoProfiles")
+			return
+		}
+		// People can only compare colleagues in their own domain.
+		if dataaccess.GetDomain(id) != domain {
+			writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+			return
+		}
+		p, found, err := da.GetProfile(id)
+		if err != nil {
+			log.Printf("Unable to retrieve the profile for user %s. %v", id, err)
+			writeError(w, r, http.StatusInternalServerError, "error.profileReadFailed", id)
+			return
+		}
+		if !found {
+			writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+			return
+		}
+		profiles = append(profiles, *p)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(dataaccess.CompareProfiles(profiles[0], profiles[1])); err != nil {
+		log.Printf("Failed to marshall the comparison, with error %s", err)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatProfilesInTheUsersDomainCanBeCompared(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			skills := map[string][]dataaccess.Skill{
+				"a@github.com": {{Skill: "go", Level: 4}},
+				"b@github.com": {{Skill: "go", Level: 2}, {Skill: "sql", Level: 3}},
+			}
+			s, ok := skills[emailAddress]
+			return &dataaccess.Profile{EmailAddress: emailAddress, Skills: s}, ok, nil
+		},
+	}
+
+	tests := []struct {
+		query        string
+		expectedCode int
+	}{
+		{"?a=a@github.com&b=B@github.com", http.StatusOK},
+		{"?a=a@github.com", http.StatusBadRequest},
+		{"?a=a@github.com&b=missing@github.com", http.StatusNotFound},
+		{"?a=a@github.com&b=b@example.com", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "http://example.com/report/compare/"+test.query, nil)
+
+		NewComparisonHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.query, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var c dataaccess.ProfileComparison
+		if err := json.NewDecoder(w.Body).Decode(&c); err != nil {
+			t.Fatal("Failed to decode the comparison.", err)
+		}
+		if len(c.Shared) != 1 || c.Shared[0].Delta != 2 || len(c.B.Unique) != 1 {
+			t.Errorf("For %s, expected go to be shared with a delta of 2 and sql to be unique to b, but received %v", test.query, c)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The ConfigurationCache holds the configuration in memory, so that it
+// doesn't need to be retrieved for each request.
+type ConfigurationCache struct {
+	DataAccess dataaccess.DataAccess
+	// RefreshInterval determines how often Watch reloads the configuration,
+	// so that changes made by other replicas are picked up.
+	RefreshInterval time.Duration
+	mutex           sync.RWMutex
+	current         dataaccess.Configuration
+}
+
+// NewConfigurationCache creates an instance of the ConfigurationCache.
+func NewConfigurationCache(da dataaccess.DataAccess) *ConfigurationCache {
+	return &ConfigurationCache{
+		DataAccess:      da,
+		RefreshInterval: time.Minute,
+	}
+}
+
+// Get returns the cached configuration.
+func (cc *ConfigurationCache) Get() dataaccess.Configuration {
+	cc.mutex.RLock()
+	defer cc.mutex.RUnlock()
+
+	return cc.current
+}
+
+// Refresh reloads the configuration from the database.
+func (cc *ConfigurationCache) Refresh() error {
+	c, err := cc.DataAccess.GetOrCreateConfiguration()
+	if err != nil {
+		return err
+	}
+
+	cc.mutex.Lock()
+	defer cc.mutex.Unlock()
+
+	cc.current = c
+	return nil
+}
+
+// Watch refreshes the configuration periodically until the context is
+// cancelled.
+func (cc *ConfigurationCache) Watch(ctx context.Context) {
+	ticker := time.NewTicker(cc.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := cc.Refresh(); err != nil {
+				log.Print("Failed to refresh the configuration. ", err)
+			}
+		}
+	}
+}
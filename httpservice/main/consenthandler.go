@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The ConsentHandler serves the consent banner. GET returns the tenant's
+// privacy notice, and whether the user has consented to each purpose. PUT
+// records the user's choices, e.g.
+// {"noticeVersion":"2","consents":{"analytics":true}}.
+type ConsentHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewConsentHandler creates an instance of the ConsentHandler.
+func NewConsentHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *ConsentHandler {
+	return &ConsentHandler{da, sessionFactory, time.Now}
+}
+
+type consentRequest struct {
+	NoticeVersion string          `json:"noticeVersion"`
+	Consents      map[string]bool `json:"consents"`
+}
+
+type purposeConsent struct {
+	Purpose   string `json:"purpose"`
+	Consented bool   `json:"consented"`
+	// Given is the user's latest choice, if they've made one.
+	Given *dataaccess.Consent `json:"given,omitempty"`
+}
+
+type consentResponse struct {
+	NoticeVersion string `json:"noticeVersion,omitempty"`
+	NoticeURL     string `json:"noticeURL,omitempty"`
+	Required      bool   `json:"required"`
+	// Pending is true if the banner should be shown, because the user
+	// hasn't made a choice for each purpose under the current notice.
+	Pending  bool             `json:"pending"`
+	Purposes []purposeConsent `json:"purposes"`
+}
+
+func newConsentResponse(p dataaccess.Profile, s dataaccess.ConsentSettings) consentResponse {
+	cr := consentResponse{NoticeVersion: s.NoticeVersion, NoticeURL: s.NoticeURL, Required: s.Required, Purposes: []purposeConsent{}}
+	for _, purpose := range s.Purposes {
+		pc := purposeConsent{Purpose: purpose, Consented: p.Consented(purpose, s)}
+		c, ok := p.LatestConsent(purpose)
+		if ok {
+			pc.Given = &c
+		}
+		if !ok || c.NoticeVersion != s.NoticeVersion {
+			cr.Pending = true
+		}
+		cr.Purposes = append(cr.Purposes, pc)
+	}
+	return cr
+}
+
+func (handler ConsentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling consent request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	domain := dataaccess.GetDomain(emailAddress)
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+
+	profile, found, err := da.GetProfile(emailAddress)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", emailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.profileReadFailed", emailAddress)
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, newConsentResponse(*profile, settings.Consent))
+	case http.MethodPut:
+		var req consentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Consents) == 0 {
+			writeError(w, r, http.StatusBadRequest, "error.invalidConsent")
+			return
+		}
+		if req.NoticeVersion != settings.Consent.NoticeVersion {
+			writeError(w, r, http.StatusConflict, "error.staleNotice", settings.Consent.NoticeVersion)
+			return
+		}
+		now := handler.now().UTC()
+		var consents []dataaccess.Consent
+		// The purposes are recorded in the order of the settings.
+		for _, purpose := range settings.Consent.Purposes {
+			given, ok := req.Consents[purpose]
+			if !ok {
+				continue
+			}
+			delete(req.Consents, purpose)
+			consents = append(consents, dataaccess.Consent{Purpose: purpose, Given: given, Time: now, NoticeVersion: req.NoticeVersion})
+		}
+		for purpose := range req.Consents {
+			writeError(w, r, http.StatusBadRequest, "error.unknownConsentPurpose", purpose)
+			return
+		}
+		if err := da.RecordConsents(emailAddress, consents); err != nil {
+			log.Printf("Failed to record the consents of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.consentSaveFailed")
+			return
+		}
+		profile.Consents = append(profile.Consents, consents...)
+		writeJSON(w, http.StatusOK, newConsentResponse(*profile, settings.Consent))
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
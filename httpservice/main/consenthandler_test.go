@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func newConsentTestHandler(recorded *[]dataaccess.Consent) *ConsentHandler {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	mda := &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			s := dataaccess.DefaultSettings()
+			s.Consent = dataaccess.ConsentSettings{Required: true, NoticeVersion: "2", NoticeURL: "https://github.com/privacy", Purposes: []string{dataaccess.ConsentAnalytics, "marketing"}}
+			return s, nil
+		},
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{EmailAddress: emailAddress, Consents: []dataaccess.Consent{
+				{Purpose: dataaccess.ConsentAnalytics, Given: true, NoticeVersion: "1"},
+			}}, true, nil
+		},
+		recordConsentsResponse: func(emailAddress string, consents []dataaccess.Consent) error {
+			*recorded = append(*recorded, consents...)
+			return nil
+		},
+	}
+	h := NewConsentHandler(mda, sf)
+	h.now = func() time.Time { return time.Date(2018, time.September, 3, 9, 0, 0, 0, time.UTC) }
+	return h
+}
+
+func TestThatTheConsentBannerIsShownWhenTheNoticeChanges(t *testing.T) {
+	var recorded []dataaccess.Consent
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/profile/consent/", nil)
+
+	newConsentTestHandler(&recorded).ServeHTTP(w, r)
+
+	var cr consentResponse
+	if err := json.NewDecoder(w.Body).Decode(&cr); err != nil {
+		t.Fatal("Failed to decode the consents.", err)
+	}
+	// Consent to version 1 of the notice doesn't count towards version 2.
+	if !cr.Pending || len(cr.Purposes) != 2 || cr.Purposes[0].Consented || cr.Purposes[0].Given == nil || cr.Purposes[1].Given != nil {
+		t.Errorf("Expected consent to be pending for both purposes, but received %+v", cr)
+	}
+}
+
+func TestThatConsentsAreRecordedForTheCurrentNotice(t *testing.T) {
+	tests := []struct {
+		body          string
+		expectedCode  int
+		expectedCount int
+	}{
+		{`{"noticeVersion":"2","consents":{"analytics":true,"marketing":false}}`, http.StatusOK, 2},
+		{`{"noticeVersion":"1","consents":{"analytics":true}}`, http.StatusConflict, 0},
+		{`{"noticeVersion":"2","consents":{"selling":true}}`, http.StatusBadRequest, 0},
+		{`{"noticeVersion":"2"}`, http.StatusBadRequest, 0},
+	}
+
+	for _, test := range tests {
+		var recorded []dataaccess.Consent
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PUT", "http://example.com/profile/consent/", strings.NewReader(test.body))
+
+		newConsentTestHandler(&recorded).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode || len(recorded) != test.expectedCount {
+			t.Errorf("For %s, expected status %d and %d consents, but received %d and %v", test.body, test.expectedCode, test.expectedCount, w.Code, recorded)
+			continue
+		}
+		if test.expectedCode != http.StatusOK {
+			continue
+		}
+		var cr consentResponse
+		if err := json.NewDecoder(w.Body).Decode(&cr); err != nil {
+			t.Fatal("Failed to decode the consents.", err)
+		}
+		if cr.Pending || !cr.Purposes[0].Consented || cr.Purposes[1].Consented || recorded[0].NoticeVersion != "2" {
+			t.Errorf("For %s, expected analytics to be consented to and marketing declined, but received %+v", test.body, cr)
+		}
+	}
+}
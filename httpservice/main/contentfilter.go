@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/a-h/pill/contentfilter"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// checkContent returns false, having written the response, if any of the
+// fields contain content blocked by the domain's content filter.
+func checkContent(w http.ResponseWriter, r *http.Request, da dataaccess.DataAccess, domain string, fields ...contentfilter.Field) bool {
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return false
+	}
+	if err := contentfilter.New(settings.ContentFilter).Check(fields...); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// skillFields returns the names of the skills, to check with checkContent.
+func skillFields(skills []dataaccess.Skill) []contentfilter.Field {
+	fields := make([]contentfilter.Field, len(skills))
+	for i, s := range skills {
+		fields[i] = contentfilter.Field{Name: "skill name", Text: s.Skill}
+	}
+	return fields
+}
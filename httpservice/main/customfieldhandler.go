@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/contentfilter"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The CustomFieldSchemaHandler allows administrators to define the custom
+// fields on the profiles of their tenant.
+type CustomFieldSchemaHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewCustomFieldSchemaHandler creates an instance of the
+// CustomFieldSchemaHandler.
+func NewCustomFieldSchemaHandler(da dataaccess.DataAccess) *CustomFieldSchemaHandler {
+	return &CustomFieldSchemaHandler{da}
+}
+
+// The CustomFieldHandler returns the custom fields of a profile which the
+// user can see, e.g. /profile/fields/?emailAddress=dev@example.com. The
+// profile defaults to the user's. People put the values of their own
+// fields, and administrators can put anyone's.
+type CustomFieldHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewCustomFieldHandler creates an instance of the CustomFieldHandler.
+func NewCustomFieldHandler(da dataaccess.DataAccess) *CustomFieldHandler {
+	return &CustomFieldHandler{da}
+}
+
+// customFields is the fields of a profile which the user can see, with
+// their values.
+type customFields struct {
+	Fields []dataaccess.CustomField               `json:"fields"`
+	Values map[string]dataaccess.CustomFieldValue `json:"values"`
+}
+
+func (handler CustomFieldSchemaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling custom field schema request.")
+
+	c, ok := administrator(r)
+	if !ok {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyCustomFields")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		settings, err := da.GetSettings(c.Tenant)
+		if err != nil {
+			log.Printf("Unable to retrieve the settings of %s. %v", c.Tenant, err)
+			writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+			return
+		}
+		writeSchema(w, settings.CustomFields)
+	case http.MethodPut:
+		var schema dataaccess.CustomFieldSchema
+		if err := json.NewDecoder(r.Body).Decode(&schema); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidCustomFields")
+			return
+		}
+
+		tc, _, err := da.GetTenantConfiguration(c.Tenant)
+		if err != nil {
+			log.Printf("Unable to retrieve the configuration of %s. %v", c.Tenant, err)
+			writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+			return
+		}
+		if tc == nil {
+			tc = dataaccess.NewTenantConfiguration(c.Tenant)
+		}
+		tc.Overrides.CustomFields = &schema
+		if err := da.UpdateTenantConfiguration(tc); err != nil {
+			if _, ok := err.(dataaccess.ValidationError); ok {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("Failed to update the custom fields of %s. %v", c.Tenant, err)
+			writeError(w, r, http.StatusInternalServerError, "error.customFieldsSaveFailed")
+			return
+		}
+		log.Printf("User %s has updated the custom fields of %s.", c.EmailAddress, c.Tenant)
+		writeSchema(w, schema)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func writeSchema(w http.ResponseWriter, schema dataaccess.CustomFieldSchema) {
+	if schema == nil {
+		schema = dataaccess.CustomFieldSchema{}
+	}
+	writeJSON(w, http.StatusOK, schema)
+}
+
+func (handler CustomFieldHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling custom field request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, "error.sessionRequired")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	owner := c.EmailAddress
+	if other := r.FormValue("emailAddress"); other != "" {
+		owner = other
+	}
+	profile, ok := colleague(w, r, da, c.EmailAddress, owner)
+	if !ok {
+		return
+	}
+
+	settings, err := da.GetSettings(dataaccess.GetDomain(profile.EmailAddress))
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", profile.EmailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	schema := settings.CustomFields
+	admin := c.HasRole(dataaccess.AdministratorRole)
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if !admin && !strings.EqualFold(profile.EmailAddress, c.EmailAddress) {
+			writeError(w, r, http.StatusForbidden, "error.customFieldsNotYours")
+			return
+		}
+		var raw map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidCustomFieldValues")
+			return
+		}
+		values, ok := updatedCustomFields(w, r, schema, profile.CustomFields, raw, admin)
+		if !ok {
+			return
+		}
+		if !checkContent(w, r, da, dataaccess.GetDomain(profile.EmailAddress), customFieldText(schema, values)...) {
+			return
+		}
+		if err := da.UpdateCustomFields(profile.EmailAddress, values); err != nil {
+			log.Printf("Failed to update the custom fields of %s. %v", profile.EmailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.customFieldValuesSaveFailed")
+			return
+		}
+		log.Printf("User %s has updated the custom fields of %s.", c.EmailAddress, profile.EmailAddress)
+		profile.CustomFields = values
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	access, err := customFieldAccess(da, c, profile.EmailAddress)
+	if err != nil {
+		log.Printf("Failed to find out whether %s manages %s. %v", c.EmailAddress, profile.EmailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+	response := customFields{Fields: []dataaccess.CustomField{}, Values: schema.Visible(profile.CustomFields, access)}
+	for _, f := range schema {
+		if f.VisibleTo(access) {
+			response.Fields = append(response.Fields, f)
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// customFieldAccess returns the custom fields the caller can see on the
+// person's profile. People see all of their own fields, as do
+// administrators.
+func customFieldAccess(da dataaccess.DataAccess, c caller.Caller, emailAddress string) (dataaccess.CustomFieldVisibility, error) {
+	if strings.EqualFold(c.EmailAddress, emailAddress) || c.HasRole(dataaccess.AdministratorRole) {
+		return dataaccess.VisibleToAdministrators, nil
+	}
+	ok, err := manages(da, c.EmailAddress, emailAddress)
+	if ok {
+		return dataaccess.VisibleToManagers, err
+	}
+	return dataaccess.VisibleToEveryone, err
+}
+
+// updatedCustomFields applies the posted values to the current ones, writing
+// an error if they're invalid. Null values clear the field.
+func updatedCustomFields(w http.ResponseWriter, r *http.Request, schema dataaccess.CustomFieldSchema, current map[string]dataaccess.CustomFieldValue, raw map[string]json.RawMessage, admin bool) (map[string]dataaccess.CustomFieldValue, bool) {
+	values := make(map[string]dataaccess.CustomFieldValue)
+	for name, v := range current {
+		if _, ok := schema.Field(name); ok {
+			values[name] = v
+		}
+	}
+	for name, value := range raw {
+		f, ok := schema.Field(name)
+		if !ok {
+			writeError(w, r, http.StatusBadRequest, "error.customFieldNotFound", name)
+			return nil, false
+		}
+		if f.AdministratorsOnly && !admin {
+			writeError(w, r, http.StatusForbidden, "error.customFieldAdminOnly", name)
+			return nil, false
+		}
+		if string(value) == "null" {
+			delete(values, name)
+			continue
+		}
+		v, err := f.Parse(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+		values[name] = v
+	}
+	if err := schema.Check(values); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return values, true
+}
+
+// customFieldText returns the text of the values, to be checked by the
+// content filter.
+func customFieldText(schema dataaccess.CustomFieldSchema, values map[string]dataaccess.CustomFieldValue) []contentfilter.Field {
+	var op []contentfilter.Field
+	for _, f := range schema {
+		v := values[f.Name]
+		if f.Type == dataaccess.TextField && v.Text != "" {
+			op = append(op, contentfilter.Field{Name: f.Label, Text: v.Text})
+		}
+		if f.Type == dataaccess.ListField && len(f.Choices) == 0 && len(v.List) > 0 {
+			op = append(op, contentfilter.Field{Name: f.Label, Text: strings.Join(v.List, ", ")})
+		}
+	}
+	return op
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func newCustomFieldTestData() *mockDataAccess {
+	schema := dataaccess.CustomFieldSchema{
+		{Name: "languages", Type: dataaccess.ListField, Visibility: dataaccess.VisibleToEveryone},
+		{Name: "clearance", Type: dataaccess.ChoiceField, Choices: []string{"SC", "DV"}, Visibility: dataaccess.VisibleToManagers, AdministratorsOnly: true},
+	}
+	profiles := map[string]*dataaccess.Profile{
+		"boss@github.com": {EmailAddress: "boss@github.com"},
+		"dev@github.com": {EmailAddress: "dev@github.com", Manager: "boss@github.com", CustomFields: map[string]dataaccess.CustomFieldValue{
+			"languages": {Type: dataaccess.ListField, List: []string{"de"}},
+			"clearance": {Type: dataaccess.ChoiceField, Text: "SC"},
+		}},
+		"other@github.com": {EmailAddress: "other@github.com"},
+	}
+	return &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			p, ok := profiles[emailAddress]
+			return p, ok, nil
+		},
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			var op []dataaccess.Profile
+			for _, p := range profiles {
+				op = append(op, *p)
+			}
+			return op, nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			s := dataaccess.DefaultSettings()
+			s.CustomFields = schema
+			return s, nil
+		},
+		updateCustomFieldsResponse: func(emailAddress string, values map[string]dataaccess.CustomFieldValue) error {
+			return nil
+		},
+	}
+}
+
+func TestThatCustomFieldsAreOnlyShownToThoseAllowedToSeeThem(t *testing.T) {
+	tests := []struct {
+		viewer   string
+		expected []string
+	}{
+		{"dev@github.com", []string{"languages", "clearance"}},
+		{"boss@github.com", []string{"languages", "clearance"}},
+		{"other@github.com", []string{"languages"}},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("GET", "http://example.com/profile/fields/?emailAddress=dev@github.com", "", caller.Caller{EmailAddress: test.viewer})
+
+		NewCustomFieldHandler(newCustomFieldTestData()).ServeHTTP(w, r)
+
+		var response customFields
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("For %s, failed to decode the fields. %v", test.viewer, err)
+		}
+		if len(response.Fields) != len(test.expected) || len(response.Values) != len(test.expected) {
+			t.Errorf("For %s, expected %v, but got %+v", test.viewer, test.expected, response)
+			continue
+		}
+		for _, name := range test.expected {
+			if _, ok := response.Values[name]; !ok {
+				t.Errorf("For %s, expected %s to be visible, but got %+v", test.viewer, name, response.Values)
+			}
+		}
+	}
+}
+
+func TestThatCustomFieldValuesAreValidatedWhenTheyArePut(t *testing.T) {
+	tests := []struct {
+		c              caller.Caller
+		target         string
+		body           string
+		expectedStatus int
+	}{
+		{caller.Caller{EmailAddress: "dev@github.com"}, "dev@github.com", `{"languages":["de","fr"]}`, http.StatusOK},
+		{caller.Caller{EmailAddress: "dev@github.com"}, "dev@github.com", `{"languages":null}`, http.StatusOK},
+		{caller.Caller{EmailAddress: "dev@github.com"}, "dev@github.com", `{"languages":"de"}`, http.StatusBadRequest},
+		{caller.Caller{EmailAddress: "dev@github.com"}, "dev@github.com", `{"shoeSize":9}`, http.StatusBadRequest},
+		{caller.Caller{EmailAddress: "dev@github.com"}, "dev@github.com", `{"clearance":"DV"}`, http.StatusForbidden},
+		{caller.Caller{EmailAddress: "boss@github.com"}, "dev@github.com", `{"languages":["fr"]}`, http.StatusForbidden},
+		{testAdministrator, "dev@github.com", `{"clearance":"DV"}`, http.StatusOK},
+		{testAdministrator, "dev@github.com", `{"clearance":"TS"}`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		mda := newCustomFieldTestData()
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("PUT", "http://example.com/profile/fields/?emailAddress="+test.target, test.body, test.c)
+
+		NewCustomFieldHandler(mda).ServeHTTP(w, r)
+
+		if w.Code != test.expectedStatus {
+			t.Errorf("For %s putting '%s', expected %d, but received %d '%s'.", test.c.EmailAddress, test.body, test.expectedStatus, w.Code, w.Body.String())
+		}
+		if saved := mda.updateCustomFieldsCallCount == 1; saved != (test.expectedStatus == http.StatusOK) {
+			t.Errorf("For %s putting '%s', expected saved to be %v, but was %v", test.c.EmailAddress, test.body, !saved, saved)
+		}
+	}
+}
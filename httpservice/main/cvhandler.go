@@ -0,0 +1,262 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/a-h/pill/attachments"
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/resume"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// downloadExpiry is how long signed download URLs can be used for.
+const downloadExpiry = 15 * time.Minute
+
+// The CVHandler uploads and removes the user's CV, and returns signed URLs
+// which download the CVs of people in the user's tenant, e.g.
+// /profile/cv/?emailAddress=dev@example.com. If there's a resume parser,
+// the skills found in uploaded CVs are queued as suggestions.
+type CVHandler struct {
+	DataAccess    dataaccess.DataAccess
+	Store         attachments.Store
+	Parser        resume.Parser
+	Configuration *ConfigurationCache
+	getSession    func(w http.ResponseWriter, r *http.Request) Session
+	now           func() time.Time
+}
+
+// NewCVHandler creates an instance of the CVHandler.
+func NewCVHandler(da dataaccess.DataAccess, store attachments.Store, parser resume.Parser, cc *ConfigurationCache, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *CVHandler {
+	return &CVHandler{da, store, parser, cc, sessionFactory, time.Now}
+}
+
+// The AttachmentHandler downloads attachments with the signed URLs issued by
+// the CVHandler, so that they don't need a session.
+type AttachmentHandler struct {
+	Store         attachments.Store
+	Configuration *ConfigurationCache
+	now           func() time.Time
+}
+
+// NewAttachmentHandler creates an instance of the AttachmentHandler.
+func NewAttachmentHandler(store attachments.Store, cc *ConfigurationCache) *AttachmentHandler {
+	return &AttachmentHandler{store, cc, time.Now}
+}
+
+type cvResponse struct {
+	CV *dataaccess.Attachment `json:"cv"`
+	// URL downloads the CV until it Expires.
+	URL     string    `json:"url,omitempty"`
+	Expires time.Time `json:"expires,omitempty"`
+	// Suggestions are the skills found in an uploaded CV.
+	Suggestions *dataaccess.SkillSuggestions `json:"suggestions,omitempty"`
+}
+
+func newSigner(c dataaccess.Configuration) attachments.Signer {
+	return attachments.NewSigner(c.SessionEncryptionKey)
+}
+
+func (handler CVHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling CV request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	owner := emailAddress
+	if r.Method == http.MethodGet && r.FormValue("emailAddress") != "" {
+		owner = r.FormValue("emailAddress")
+		if dataaccess.GetDomain(owner) != dataaccess.GetDomain(emailAddress) {
+			writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+			return
+		}
+	}
+
+	profile, found, err := da.GetProfile(owner)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", owner, err)
+		writeError(w, r, http.StatusInternalServerError, "error.cvReadFailed")
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if profile.CV == nil {
+			writeError(w, r, http.StatusNotFound, "error.cvNotFound")
+			return
+		}
+		dataaccess.RecordAccess(da, audit.ProfileExported, profile.EmailAddress)
+		handler.writeCV(w, profile.CV, nil)
+	case http.MethodPost:
+		cv, data, ok := handler.upload(w, r, emailAddress)
+		if !ok {
+			return
+		}
+		if err := da.UpdateCV(emailAddress, cv); err != nil {
+			log.Printf("Failed to update the CV of %s. %v", emailAddress, err)
+			handler.Store.Delete(cv.Name)
+			writeError(w, r, http.StatusInternalServerError, "error.cvSaveFailed")
+			return
+		}
+		handler.remove(profile.CV)
+		log.Printf("User %s has uploaded their CV.", emailAddress)
+		handler.writeCV(w, cv, handler.suggest(da, profile, cv, data))
+	case http.MethodDelete:
+		if err := da.UpdateCV(emailAddress, nil); err != nil {
+			log.Printf("Failed to remove the CV of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.cvSaveFailed")
+			return
+		}
+		handler.remove(profile.CV)
+		log.Printf("User %s has removed their CV.", emailAddress)
+		writeJSON(w, http.StatusOK, cvResponse{})
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+// upload validates the file posted in the "cv" field of the multipart form,
+// and writes it to the store.
+func (handler CVHandler) upload(w http.ResponseWriter, r *http.Request, emailAddress string) (*dataaccess.Attachment, []byte, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, attachments.MaxSize+1<<20)
+	f, header, err := r.FormFile("cv")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "error.invalidCV")
+		return nil, nil, false
+	}
+	defer f.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(f, attachments.MaxSize+1))
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "error.invalidCV")
+		return nil, nil, false
+	}
+	fileName := filepath.Base(header.Filename)
+	contentType, err := attachments.ContentType(fileName, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	cv := &dataaccess.Attachment{
+		Name:        "cv/" + dataaccess.GetDomain(emailAddress) + "/" + bson.NewObjectId().Hex(),
+		FileName:    fileName,
+		ContentType: contentType,
+		Size:        len(data),
+		Uploaded:    handler.now().UTC().Truncate(time.Millisecond),
+	}
+	if err := handler.Store.Put(cv.Name, data); err != nil {
+		log.Printf("Failed to store the CV of %s. %v", emailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.cvSaveFailed")
+		return nil, nil, false
+	}
+	return cv, data, true
+}
+
+// remove deletes a CV which has been replaced or removed from the store.
+// Failures are only logged, since the CV can no longer be downloaded.
+func (handler CVHandler) remove(cv *dataaccess.Attachment) {
+	if cv == nil {
+		return
+	}
+	if err := handler.Store.Delete(cv.Name); err != nil {
+		log.Printf("Failed to delete attachment %s. %v", cv.Name, err)
+	}
+}
+
+// suggest queues the skills the parser finds in the CV which would raise
+// the levels on the profile. Parsing is best effort, so failures are only
+// logged.
+func (handler CVHandler) suggest(da dataaccess.DataAccess, profile *dataaccess.Profile, cv *dataaccess.Attachment, data []byte) *dataaccess.SkillSuggestions {
+	if handler.Parser == nil {
+		return nil
+	}
+	domain := dataaccess.GetDomain(profile.EmailAddress)
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		return nil
+	}
+	tags, err := da.ListSkillTags()
+	if err != nil {
+		log.Print("Unable to retrieve the list of skill tags. ", err)
+		return nil
+	}
+	candidates, err := handler.Parser.Parse(cv.ContentType, data, tags)
+	if err != nil {
+		log.Printf("Failed to parse the CV of %s. %v", profile.EmailAddress, err)
+		return nil
+	}
+	if settings.StrictVocabulary {
+		vocabulary := dataaccess.NewVocabulary(tags)
+		var known []resume.Candidate
+		for _, c := range candidates {
+			if _, ok := vocabulary[c.Skill]; ok {
+				known = append(known, c)
+			}
+		}
+		candidates = known
+	}
+
+	suggestions := resume.Suggest(profile, candidates)
+	if len(suggestions) == 0 {
+		return nil
+	}
+	ss := dataaccess.NewSkillSuggestions(profile.EmailAddress, cv.FileName, suggestions, handler.now())
+	if err := da.SaveSkillSuggestions(&ss); err != nil {
+		log.Printf("Failed to save the skill suggestions of %s. %v", profile.EmailAddress, err)
+		return nil
+	}
+	log.Printf("Suggested %d skills from the CV of %s.", len(suggestions), profile.EmailAddress)
+	return &ss
+}
+
+func (handler CVHandler) writeCV(w http.ResponseWriter, cv *dataaccess.Attachment, suggestions *dataaccess.SkillSuggestions) {
+	expires := handler.now().Add(downloadExpiry).UTC().Truncate(time.Second)
+	q := newSigner(handler.Configuration.Get()).URL(*cv, expires)
+	writeJSON(w, http.StatusOK, cvResponse{CV: cv, URL: "/attachments/?" + q.Encode(), Expires: expires, Suggestions: suggestions})
+}
+
+func (handler AttachmentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling attachment request.")
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	a, ok := newSigner(handler.Configuration.Get()).Verify(r.URL.Query(), handler.now())
+	if !ok {
+		writeError(w, r, http.StatusForbidden, "error.invalidDownloadURL")
+		return
+	}
+
+	data, err := handler.Store.Get(a.Name)
+	if err != nil {
+		log.Printf("Failed to read attachment %s. %v", a.Name, err)
+		writeError(w, r, http.StatusNotFound, "error.attachmentNotFound")
+		return
+	}
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(a.FileName))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
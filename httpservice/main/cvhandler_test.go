@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type memoryStore map[string][]byte
+
+func (s memoryStore) Put(name string, data []byte) error {
+	s[name] = data
+	return nil
+}
+
+func (s memoryStore) Get(name string) ([]byte, error) {
+	data, ok := s[name]
+	if !ok {
+		return nil, http.ErrMissingFile
+	}
+	return data, nil
+}
+
+func (s memoryStore) Delete(name string) error {
+	delete(s, name)
+	return nil
+}
+
+func newCVRequest(fileName string, data []byte) *http.Request {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, _ := mw.CreateFormFile("cv", fileName)
+	fw.Write(data)
+	mw.Close()
+
+	r, _ := http.NewRequest(http.MethodPost, "http://example.com/profile/cv/", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestThatCVsCanBeUploadedAndDownloadedWithSignedURLs(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	previous := &dataaccess.Attachment{Name: "cv/github.com/old", FileName: "old.pdf", ContentType: "application/pdf"}
+	store := memoryStore{previous.Name: []byte("%PDF-old")}
+	var saved *dataaccess.Attachment
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{EmailAddress: emailAddress, CV: previous}, true, nil
+		},
+		updateCVResponse: func(emailAddress string, cv *dataaccess.Attachment) error {
+			saved = cv
+			return nil
+		},
+	}
+	cc := newTestConfigurationCache(dataaccess.Configuration{SessionEncryptionKey: []byte("key")})
+
+	handler := NewCVHandler(mda, store, nil, cc, sf)
+	handler.now = func() time.Time { return march }
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newCVRequest("../cv.pdf", []byte("%PDF-1.4 new")))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, but was %d: %s", w.Code, w.Body.String())
+	}
+	if saved == nil || saved.FileName != "cv.pdf" || saved.ContentType != "application/pdf" || saved.Size != 12 {
+		t.Fatalf("Expected the CV to be saved, but got %+v.", saved)
+	}
+	if _, ok := store[previous.Name]; ok || string(store[saved.Name]) != "%PDF-1.4 new" {
+		t.Errorf("Expected the new CV to replace the previous one in the store, but got %v.", store)
+	}
+
+	var resp cvResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+
+	ah := NewAttachmentHandler(store, cc)
+	ah.now = func() time.Time { return march.Add(time.Minute) }
+	w = httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "http://example.com"+resp.URL, nil)
+	ah.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || w.Body.String() != "%PDF-1.4 new" || w.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("Expected the signed URL to download the CV, but got %d %s %q.", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	ah.now = func() time.Time { return march.Add(time.Hour) }
+	w = httptest.NewRecorder()
+	ah.ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected the expired URL to be forbidden, but was %d.", w.Code)
+	}
+}
+
+func TestThatFilesWhichAreNotDocumentsAreRejected(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{EmailAddress: emailAddress}, true, nil
+		},
+	}
+	store := memoryStore{}
+
+	w := httptest.NewRecorder()
+	NewCVHandler(mda, store, nil, newTestConfigurationCache(dataaccess.Configuration{}), sf).ServeHTTP(w, newCVRequest("cv.pdf", []byte("<script>")))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status bad request, but was %d.", w.Code)
+	}
+	if len(store) != 0 || mda.updateCVCallCount != 0 {
+		t.Error("Expected the file not to be stored.")
+	}
+}
+
+func TestThatCVsOfOtherTenantsCannotBeDownloaded(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	mda := &mockDataAccess{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodGet, "http://example.com/profile/cv/?emailAddress=dev@example.com", nil)
+	NewCVHandler(mda, memoryStore{}, nil, newTestConfigurationCache(dataaccess.Configuration{}), sf).ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound || mda.getProfileCallCount != 0 {
+		t.Errorf("Expected status not found without reading the profile, but was %d.", w.Code)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The DepartmentHandler sums up the headcount, availability and skills of
+// each department in the user's domain, e.g. /report/departments/, which
+// can be limited to a cost center with ?costCenter=.
+type DepartmentHandler struct {
+	DataAccess dataaccess.DataAccess
+	// SnapshotMaxAge is the oldest snapshot which is served for the whole
+	// domain. If it's zero, the report is always computed live.
+	SnapshotMaxAge time.Duration
+	getSession     func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewDepartmentHandler creates an instance of the DepartmentHandler.
+func NewDepartmentHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *DepartmentHandler {
+	return &DepartmentHandler{DataAccess: da, getSession: sessionFactory}
+}
+
+// profileFilter reads the department, cost center and spoken language to
+// limit a listing to from the query string, e.g. ?language=de&languageLevel=B2.
+func profileFilter(r *http.Request) dataaccess.ProfileFilter {
+	q := r.URL.Query()
+	return dataaccess.ProfileFilter{
+		Department:    q.Get("department"),
+		CostCenter:    q.Get("costCenter"),
+		Language:      strings.ToLower(q.Get("language")),
+		LanguageLevel: dataaccess.CEFRLevel(strings.ToUpper(q.Get("languageLevel"))),
+	}
+}
+
+// filteredProfiles lists the profiles in the user's domain, limited to the
+// filter in the request, if there is one.
+func filteredProfiles(da dataaccess.DataAccess, r *http.Request, emailAddress string) ([]dataaccess.Profile, error) {
+	f := profileFilter(r)
+	if f.Empty() {
+		return da.ListProfiles(emailAddress)
+	}
+	return da.FindProfiles(dataaccess.GetDomain(emailAddress), f)
+}
+
+func (handler DepartmentHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling department report request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	var departments []dataaccess.DepartmentSummary
+	if profileFilter(r).Empty() && readSnapshot(w, da, dataaccess.GetDomain(emailAddress), dataaccess.DepartmentsSnapshot, handler.SnapshotMaxAge, &departments) {
+		writeJSON(w, http.StatusOK, departments)
+		return
+	}
+
+	profiles, err := filteredProfiles(da, r, emailAddress)
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+	domain := dataaccess.GetDomain(emailAddress)
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dataaccess.Departments(dataaccess.Consenting(profiles, dataaccess.ConsentAnalytics, settings.Consent)))
+}
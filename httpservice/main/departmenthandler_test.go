@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatDepartmentsCanBeLimitedToACostCenter(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	var filter dataaccess.ProfileFilter
+	var domain string
+	mda := &mockDataAccess{
+		findProfilesResponse: func(d string, f dataaccess.ProfileFilter) ([]dataaccess.Profile, error) {
+			domain, filter = d, f
+			return []dataaccess.Profile{
+				{Department: "Engineering", CostCenter: "100"},
+				{Department: "Engineering", CostCenter: "100", Consents: []dataaccess.Consent{{Purpose: dataaccess.ConsentAnalytics, Given: false}}},
+			}, nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/departments/?costCenter=100", nil)
+
+	NewDepartmentHandler(mda, sf).ServeHTTP(w, r)
+
+	if domain != "github.com" || filter != (dataaccess.ProfileFilter{CostCenter: "100"}) {
+		t.Errorf("Expected the profiles in cost center 100 of github.com to be found, but got %s %+v", domain, filter)
+	}
+	var departments []dataaccess.DepartmentSummary
+	if err := json.NewDecoder(w.Body).Decode(&departments); err != nil {
+		t.Fatal("Failed to decode the departments.", err)
+	}
+	if len(departments) != 1 || departments[0].Department != "Engineering" || departments[0].People != 1 {
+		t.Errorf("Expected one person in Engineering, but got %+v", departments)
+	}
+}
+
+func TestThatTheHeatmapCanBeLimitedToADepartment(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	mda := &mockDataAccess{
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "boss@github.com", Department: "Management"},
+				{EmailAddress: "a-h@github.com", Manager: "boss@github.com", Department: "Engineering"},
+				{EmailAddress: "other@github.com", Department: "Engineering"},
+			}, nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/heatmap/?manager=boss@github.com&department=Engineering", nil)
+
+	NewHeatmapHandler(mda, sf).ServeHTTP(w, r)
+
+	var h heatmap
+	if err := json.NewDecoder(w.Body).Decode(&h); err != nil {
+		t.Fatal("Failed to decode the heatmap.", err)
+	}
+	if len(h.People) != 1 || h.People[0].EmailAddress != "a-h@github.com" {
+		t.Errorf("Expected only the boss's reports in Engineering, but got %+v", h.People)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The DiagnosticsHandler shows the runtime statistics of the process, and
+// serves pprof's profiles under /admin/diagnostics/pprof/, e.g.
+// /admin/diagnostics/pprof/goroutine?debug=2 for a goroutine dump. The
+// process is shared by every tenant, so only the administrators who are
+// listed can use it.
+type DiagnosticsHandler struct {
+	Administrators []string
+	Started        time.Time
+}
+
+// NewDiagnosticsHandler creates an instance of the DiagnosticsHandler.
+func NewDiagnosticsHandler(administrators []string) *DiagnosticsHandler {
+	return &DiagnosticsHandler{administrators, time.Now()}
+}
+
+type diagnostics struct {
+	GoVersion   string               `json:"goVersion"`
+	Uptime      string               `json:"uptime"`
+	Goroutines  int                  `json:"goroutines"`
+	CPUs        int                  `json:"cpus"`
+	HeapAlloc   uint64               `json:"heapAlloc"`
+	HeapObjects uint64               `json:"heapObjects"`
+	Sys         uint64               `json:"sys"`
+	NumGC       uint32               `json:"numGC"`
+	LastGCPause string               `json:"lastGCPause"`
+	PoolStats   dataaccess.PoolStats `json:"poolStats"`
+	Profiles    string               `json:"profiles"`
+}
+
+func (handler DiagnosticsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling diagnostics request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) || !containsFold(handler.Administrators, c.EmailAddress) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyDiagnostics")
+		return
+	}
+
+	const prefix = "/admin/diagnostics/pprof/"
+	if strings.HasPrefix(r.URL.Path, prefix) {
+		log.Printf("%s is reading the %s profile.", c.EmailAddress, r.URL.Path[len(prefix):])
+		servePprof(w, r, r.URL.Path[len(prefix):])
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	writeJSON(w, http.StatusOK, diagnostics{
+		GoVersion:   runtime.Version(),
+		Uptime:      time.Since(handler.Started).Round(time.Second).String(),
+		Goroutines:  runtime.NumGoroutine(),
+		CPUs:        runtime.NumCPU(),
+		HeapAlloc:   m.HeapAlloc,
+		HeapObjects: m.HeapObjects,
+		Sys:         m.Sys,
+		NumGC:       m.NumGC,
+		LastGCPause: time.Duration(m.PauseNs[(m.NumGC+255)%256]).String(),
+		PoolStats:   dataaccess.GetPoolStats(),
+		Profiles:    prefix,
+	})
+}
+
+// servePprof serves the named profile. pprof's handlers expect to be under
+// /debug/pprof/, so the name is given to them directly. The command line
+// isn't served, since flags such as -slackToken hold secrets.
+func servePprof(w http.ResponseWriter, r *http.Request, name string) {
+	switch name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		http.NotFound(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
+
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+)
+
+func TestThatOnlyListedAdministratorsCanReadDiagnostics(t *testing.T) {
+	tests := []struct {
+		c              caller.Caller
+		expectedStatus int
+	}{
+		{testAdministrator, http.StatusOK},
+		{caller.Caller{EmailAddress: "admin@github.com"}, http.StatusForbidden},
+		{caller.Caller{EmailAddress: "other@github.com", Roles: testAdministrator.Roles}, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("GET", "http://example.com/admin/diagnostics/", "", test.c)
+
+		NewDiagnosticsHandler([]string{"Admin@github.com"}).ServeHTTP(w, r)
+
+		if w.Code != test.expectedStatus {
+			t.Errorf("For %s, expected status %d, but got %d", test.c.EmailAddress, test.expectedStatus, w.Code)
+		}
+	}
+}
+
+func TestThatDiagnosticsIncludeRuntimeStatistics(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("GET", "http://example.com/admin/diagnostics/", "", testAdministrator)
+
+	NewDiagnosticsHandler([]string{testAdministrator.EmailAddress}).ServeHTTP(w, r)
+
+	var d diagnostics
+	if err := json.NewDecoder(w.Body).Decode(&d); err != nil {
+		t.Fatalf("Failed to decode the diagnostics: %v", err)
+	}
+	if d.Goroutines == 0 || d.GoVersion == "" || d.HeapAlloc == 0 {
+		t.Errorf("Expected the runtime statistics, but got %+v", d)
+	}
+}
+
+func TestThatTheCommandLineIsNotServed(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("GET", "http://example.com/admin/diagnostics/pprof/cmdline", "", testAdministrator)
+
+	NewDiagnosticsHandler([]string{testAdministrator.EmailAddress}).ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected the command line, which holds secrets, not to be served, but got %d '%s'", w.Code, w.Body.String())
+	}
+}
+
+func TestThatGoroutineDumpsAreServed(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("GET", "http://example.com/admin/diagnostics/pprof/goroutine?debug=2", "", testAdministrator)
+
+	NewDiagnosticsHandler([]string{testAdministrator.EmailAddress}).ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine ") {
+		t.Errorf("Expected a goroutine dump, but got %d '%s'", w.Code, w.Body.String())
+	}
+}
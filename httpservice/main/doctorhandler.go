@@ -0,0 +1,46 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/doctor"
+)
+
+// The DoctorHandler runs the doctor's checks of the deployment, e.g. that
+// its databases can be reached and its configuration decrypted, and lists
+// what to do about each problem. Like the diagnostics, it's only for the
+// administrators who operate the service.
+type DoctorHandler struct {
+	Check          func() []doctor.Result
+	Administrators []string
+}
+
+// NewDoctorHandler creates an instance of the DoctorHandler.
+func NewDoctorHandler(check func() []doctor.Result, administrators []string) *DoctorHandler {
+	return &DoctorHandler{check, administrators}
+}
+
+type doctorResponse struct {
+	Healthy bool            `json:"healthy"`
+	Results []doctor.Result `json:"results"`
+}
+
+func (handler DoctorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling doctor request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) || !containsFold(handler.Administrators, c.EmailAddress) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyDiagnostics")
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	results := handler.Check()
+	writeJSON(w, http.StatusOK, doctorResponse{doctor.Healthy(results), results})
+}
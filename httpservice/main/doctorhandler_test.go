@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/doctor"
+)
+
+func TestThatTheDoctorListsProblemsWithTheirRemedies(t *testing.T) {
+	check := func() []doctor.Result {
+		return []doctor.Result{
+			doctor.Pass("database connection"),
+			doctor.Fail("configuration", "The configuration is encrypted, but there's no master key to decrypt it.", "Pass the master key file."),
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("GET", "http://example.com/admin/doctor/", "", testAdministrator)
+
+	NewDoctorHandler(check, []string{testAdministrator.EmailAddress}).ServeHTTP(w, r)
+
+	var resp doctorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode the response: %v", err)
+	}
+	if resp.Healthy || len(resp.Results) != 2 || resp.Results[1].Remedy != "Pass the master key file." {
+		t.Errorf("Expected an unhealthy result with the remedy, but got %+v", resp)
+	}
+}
+
+func TestThatOnlyListedAdministratorsCanRunTheDoctor(t *testing.T) {
+	checked := false
+	check := func() []doctor.Result {
+		checked = true
+		return nil
+	}
+
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("GET", "http://example.com/admin/doctor/", "", caller.Caller{EmailAddress: "other@github.com", Roles: testAdministrator.Roles})
+
+	NewDoctorHandler(check, []string{testAdministrator.EmailAddress}).ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden || checked {
+		t.Errorf("Expected the request to be refused without running the checks, but got %d", w.Code)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The DuplicateProfileHandler reports the profiles which are likely to
+// belong to the same person, and merges them. Profiles are compared in the
+// administrator's tenant and its alias domains, or another tenant with
+// ?tenant=.
+type DuplicateProfileHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewDuplicateProfileHandler creates an instance of the
+// DuplicateProfileHandler.
+func NewDuplicateProfileHandler(da dataaccess.DataAccess) *DuplicateProfileHandler {
+	return &DuplicateProfileHandler{da}
+}
+
+// profileMerge is posted to merge the duplicate profile into the primary.
+type profileMerge struct {
+	Primary   string `json:"primary"`
+	Duplicate string `json:"duplicate"`
+}
+
+func (handler DuplicateProfileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling duplicate profile request.")
+
+	c, ok := administrator(r)
+	if !ok {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyDuplicateProfiles")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	tenant := r.FormValue("tenant")
+	if tenant == "" {
+		tenant = c.Tenant
+	}
+	settings, err := da.GetSettings(tenant)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", tenant, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	domains := append([]string{tenant}, settings.AliasDomains...)
+
+	switch r.Method {
+	case http.MethodGet:
+		var profiles []dataaccess.Profile
+		for _, d := range domains {
+			p, err := da.ListProfiles("@" + strings.ToLower(d))
+			if err != nil {
+				log.Print("Unable to retrieve the list of profiles.", err)
+				writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+				return
+			}
+			profiles = append(profiles, p...)
+		}
+		writeJSON(w, http.StatusOK, dataaccess.FindDuplicates(profiles, tenant))
+	case http.MethodPost:
+		var m profileMerge
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil || m.Primary == "" || m.Duplicate == "" || strings.EqualFold(m.Primary, m.Duplicate) {
+			writeError(w, r, http.StatusBadRequest, "error.invalidProfileMerge")
+			return
+		}
+		if !inDomains(m.Primary, domains) || !inDomains(m.Duplicate, domains) {
+			writeError(w, r, http.StatusForbidden, "error.profileMergeOutsideTenant", tenant)
+			return
+		}
+		p, found, err := da.MergeProfiles(m.Primary, m.Duplicate)
+		if err != nil {
+			log.Printf("Failed to merge %s into %s. %v", m.Duplicate, m.Primary, err)
+			writeError(w, r, http.StatusInternalServerError, "error.profileMergeFailed")
+			return
+		}
+		if !found {
+			writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+			return
+		}
+		log.Printf("User %s has merged the profile %s into %s.", c.EmailAddress, m.Duplicate, m.Primary)
+		writeJSON(w, http.StatusOK, p)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func inDomains(emailAddress string, domains []string) bool {
+	for _, d := range domains {
+		if strings.EqualFold(dataaccess.GetDomain(emailAddress), d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatDuplicateProfilesAreFoundInAliasDomains(t *testing.T) {
+	// The tenant's profiles are listed first, then the alias domain's.
+	domains := []string{"github.com", "octocat.com"}
+	mda := &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.Settings{AliasDomains: []string{"octocat.com"}}, nil
+		},
+	}
+	mda.listProfilesResponse = func() ([]dataaccess.Profile, error) {
+		domain := domains[mda.listProfilesCallCount-1]
+		return []dataaccess.Profile{{EmailAddress: "adrian@" + domain, Domain: domain}}, nil
+	}
+
+	w := httptest.NewRecorder()
+	NewDuplicateProfileHandler(mda).ServeHTTP(w, newRequestWithCaller("GET", "/admin/profiles/duplicates/", "", testAdministrator))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	var candidates []dataaccess.DuplicateCandidate
+	if err := json.NewDecoder(w.Body).Decode(&candidates); err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 || candidates[0].Primary != "adrian@github.com" || candidates[0].Duplicate != "adrian@octocat.com" {
+		t.Errorf("Expected the profile in the alias domain to be a duplicate, but got %+v", candidates)
+	}
+}
+
+func TestThatOnlyProfilesInTheTenantCanBeMerged(t *testing.T) {
+	var merged []string
+	mda := &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.Settings{AliasDomains: []string{"octocat.com"}}, nil
+		},
+		mergeProfilesResponse: func(primary string, duplicate string) (*dataaccess.Profile, bool, error) {
+			merged = append(merged, duplicate)
+			return &dataaccess.Profile{EmailAddress: primary}, true, nil
+		},
+	}
+
+	tests := []struct {
+		body         string
+		caller       caller.Caller
+		expectedCode int
+	}{
+		{`{"primary":"adrian@github.com","duplicate":"adrian@octocat.com"}`, testAdministrator, http.StatusOK},
+		{`{"primary":"adrian@github.com","duplicate":"adrian@example.com"}`, testAdministrator, http.StatusForbidden},
+		{`{"primary":"adrian@github.com","duplicate":"Adrian@github.com"}`, testAdministrator, http.StatusBadRequest},
+		{`{"primary":"adrian@github.com","duplicate":"adrian@octocat.com"}`, caller.Caller{EmailAddress: "dev@github.com"}, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		merged = nil
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "/admin/profiles/duplicates/", test.body, test.caller)
+
+		NewDuplicateProfileHandler(mda).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but got %d", test.body, test.expectedCode, w.Code)
+		}
+		if (len(merged) == 1) != (w.Code == http.StatusOK) {
+			t.Errorf("For %s, expected profiles only to be merged on success, but got %v", test.body, merged)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The DuplicateTagHandler reports the skill tags which are likely to be
+// duplicates, and merges them. Tags are compared by how they're used in the
+// administrator's tenant, or another tenant with ?tenant=.
+type DuplicateTagHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewDuplicateTagHandler creates an instance of the DuplicateTagHandler.
+func NewDuplicateTagHandler(da dataaccess.DataAccess) *DuplicateTagHandler {
+	return &DuplicateTagHandler{da}
+}
+
+// tagMerge is posted to merge the tags into another.
+type tagMerge struct {
+	Into string   `json:"into"`
+	Tags []string `json:"tags"`
+}
+
+type tagMergeResponse struct {
+	Into string `json:"into"`
+	// Profiles is the number of profiles which were changed.
+	Profiles int `json:"profiles"`
+}
+
+func (handler DuplicateTagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling duplicate tag request.")
+
+	c, ok := administrator(r)
+	if !ok {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyDuplicateTags")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		tenant := r.FormValue("tenant")
+		if tenant == "" {
+			tenant = c.Tenant
+		}
+		tags, err := da.ListSkillTags()
+		if err != nil {
+			log.Print("Failed to list the skill tags. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.skillTagsListFailed")
+			return
+		}
+		profiles, err := da.ListProfiles("@" + tenant)
+		if err != nil {
+			log.Print("Unable to retrieve the list of profiles.", err)
+			writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+			return
+		}
+		writeJSON(w, http.StatusOK, dataaccess.DuplicateTags(tags, profiles))
+	case http.MethodPost:
+		var m tagMerge
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil || dataaccess.CleanTag(m.Into) == "" || len(m.Tags) == 0 {
+			writeError(w, r, http.StatusBadRequest, "error.invalidTagMerge")
+			return
+		}
+		merged, err := da.MergeSkillTags(m.Into, m.Tags)
+		if err != nil {
+			log.Printf("Failed to merge %v into %s. %v", m.Tags, m.Into, err)
+			writeError(w, r, http.StatusInternalServerError, "error.tagMergeFailed")
+			return
+		}
+		log.Printf("User %s has merged %v into %s, changing %d profiles.", c.EmailAddress, m.Tags, m.Into, merged)
+		writeJSON(w, http.StatusOK, tagMergeResponse{dataaccess.CleanTag(m.Into), merged})
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatDuplicateTagsCanBeMerged(t *testing.T) {
+	var into string
+	var merged []string
+	mda := &mockDataAccess{
+		listSkillTagsResponse: func() ([]dataaccess.SkillTag, error) {
+			return []dataaccess.SkillTag{{Name: "nodejs"}, {Name: "node.js"}, {Name: "go"}}, nil
+		},
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{{EmailAddress: "dev@github.com", Skills: []dataaccess.Skill{{Skill: "nodejs"}}}}, nil
+		},
+		mergeSkillTagsResponse: func(i string, tags []string) (int, error) {
+			into, merged = i, tags
+			return 3, nil
+		},
+	}
+	handler := NewDuplicateTagHandler(mda)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequestWithCaller("GET", "http://example.com/admin/skills/duplicates/", "", testAdministrator))
+	var clusters []dataaccess.DuplicateCluster
+	if err := json.NewDecoder(w.Body).Decode(&clusters); err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 || clusters[0].Into != "nodejs" {
+		t.Fatalf("expected node.js to be suggested to merge into nodejs, but got %+v", clusters)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequestWithCaller("POST", "http://example.com/admin/skills/duplicates/", `{"into":"NodeJS","tags":["node.js"]}`, testAdministrator))
+	if w.Code != http.StatusOK || into != "NodeJS" || len(merged) != 1 {
+		t.Errorf("expected the tags to be merged, but got status %d, into %s, %v", w.Code, into, merged)
+	}
+	var response tagMergeResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil || response.Into != "nodejs" || response.Profiles != 3 {
+		t.Errorf("unexpected response %+v, %v", response, err)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequestWithCaller("POST", "http://example.com/admin/skills/duplicates/", `{"into":"nodejs","tags":["node.js"]}`, testReport))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected users who aren't administrators to be forbidden, but got %d", w.Code)
+	}
+}
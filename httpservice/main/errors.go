@@ -0,0 +1,13 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/a-h/pill/i18n"
+)
+
+// writeError writes the message for the key as an HTTP error, in the language
+// which best matches the request's Accept-Language header.
+func writeError(w http.ResponseWriter, r *http.Request, status int, key string, args ...interface{}) {
+	http.Error(w, i18n.Translate(i18n.Negotiate(r.Header.Get("Accept-Language")), key, args...), status)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-h/pill/attachments"
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/export"
+)
+
+// The ExportHandler allows administrators to export the data of their domain
+// in the background, e.g. POST /admin/exports/ with {"kind": "profiles",
+// "format": "xlsx"}. The job is returned at once, and its status is polled
+// with GET /admin/exports/?id=5a1f until it has succeeded, when the file can
+// be downloaded with the ExportDownloadHandler.
+type ExportHandler struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewExportHandler creates an instance of the ExportHandler.
+func NewExportHandler(da dataaccess.DataAccess) *ExportHandler {
+	return &ExportHandler{
+		DataAccess: da,
+		now:        time.Now,
+	}
+}
+
+// An exportRequest is the export an administrator asks for.
+type exportRequest struct {
+	Kind   string `json:"kind"`
+	Format string `json:"format"`
+}
+
+// An exportStatus is an export job and, once it has succeeded, where its file
+// is downloaded from.
+type exportStatus struct {
+	dataaccess.ExportJob
+	DownloadURL string `json:"downloadURL,omitempty"`
+}
+
+func newExportStatus(j dataaccess.ExportJob) exportStatus {
+	s := exportStatus{ExportJob: j}
+	if j.Status == dataaccess.ExportSucceeded {
+		s.DownloadURL = "/admin/exports/download/?id=" + j.ID
+	}
+	return s
+}
+
+func (handler ExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling export request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyExports")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(c.EmailAddress)
+
+	switch r.Method {
+	case http.MethodGet:
+		j, found, err := domainExportJob(da, domain, r.FormValue("id"))
+		if err != nil || !found {
+			writeExportJobError(w, r, err)
+			return
+		}
+		if !j.Done() {
+			// Clients poll until the job is done.
+			w.Header().Set("Retry-After", "5")
+		}
+		writeJSON(w, http.StatusOK, newExportStatus(*j))
+	case http.MethodPost:
+		var req exportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidExportRequest")
+			return
+		}
+		if !export.Supports(req.Kind, req.Format) {
+			writeError(w, r, http.StatusBadRequest, "error.exportNotSupported", req.Kind, req.Format)
+			return
+		}
+		j := dataaccess.NewExportJob(domain, c.EmailAddress, req.Kind, req.Format, handler.now())
+		if err := da.SaveExportJob(j); err != nil {
+			log.Print("Failed to save the export job. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.exportJobSaveFailed")
+			return
+		}
+		log.Printf("User %s has requested an export of the %s of %s as %s.", c.EmailAddress, req.Kind, domain, req.Format)
+		w.Header().Set("Location", "/admin/exports/?id="+j.ID)
+		writeJSON(w, http.StatusAccepted, newExportStatus(*j))
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+// The ExportDownloadHandler returns the file of an export job which has
+// succeeded, e.g. /admin/exports/download/?id=5a1f.
+type ExportDownloadHandler struct {
+	DataAccess dataaccess.DataAccess
+	Store      attachments.Store
+}
+
+// NewExportDownloadHandler creates an instance of the ExportDownloadHandler.
+func NewExportDownloadHandler(da dataaccess.DataAccess, store attachments.Store) *ExportDownloadHandler {
+	return &ExportDownloadHandler{da, store}
+}
+
+func (handler ExportDownloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling export download request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyExports")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	j, found, err := domainExportJob(da, dataaccess.GetDomain(c.EmailAddress), r.FormValue("id"))
+	if err != nil || !found {
+		writeExportJobError(w, r, err)
+		return
+	}
+	if j.Status != dataaccess.ExportSucceeded {
+		writeError(w, r, http.StatusConflict, "error.exportNotReady", string(j.Status))
+		return
+	}
+
+	data, err := handler.Store.Get(j.Blob)
+	if err != nil {
+		log.Printf("Failed to read the file of export %s. %v", j.ID, err)
+		writeError(w, r, http.StatusInternalServerError, "error.exportReadFailed")
+		return
+	}
+	// Each download is recorded in the access log of everyone in the file.
+	dataaccess.RecordAccesses(da, audit.ProfileExported, j.Exported)
+	w.Header().Set("Content-Type", export.ContentType(j.Format))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+export.FileName(*j)+`"`)
+	w.Write(data)
+}
+
+// domainExportJob finds the export job, if it was requested in the domain.
+func domainExportJob(da dataaccess.DataAccess, domain string, id string) (*dataaccess.ExportJob, bool, error) {
+	j, found, err := da.GetExportJob(id)
+	if err != nil || !found || j.Domain != domain {
+		return nil, false, err
+	}
+	return j, true, nil
+}
+
+func writeExportJobError(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		log.Print("Failed to retrieve the export job. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.exportJobReadFailed")
+		return
+	}
+	writeError(w, r, http.StatusNotFound, "error.exportJobNotFound")
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatAdministratorsCanStartExports(t *testing.T) {
+	var saved *dataaccess.ExportJob
+	mda := &mockDataAccess{
+		saveExportJobResponse: func(j *dataaccess.ExportJob) error {
+			saved = j
+			return nil
+		},
+	}
+
+	tests := []struct {
+		body         string
+		caller       caller.Caller
+		expectedCode int
+	}{
+		{`{"kind":"profiles","format":"xlsx"}`, testAdministrator, http.StatusAccepted},
+		{`{"kind":"onepagers","format":"pdf"}`, testAdministrator, http.StatusAccepted},
+		{`{"kind":"onepagers","format":"csv"}`, testAdministrator, http.StatusBadRequest},
+		{`{"kind":"salaries","format":"csv"}`, testAdministrator, http.StatusBadRequest},
+		{`{"kind":"profiles","format":"xlsx"}`, caller.Caller{EmailAddress: "dev@github.com"}, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		saved = nil
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "/admin/exports/", test.body, test.caller)
+
+		NewExportHandler(mda).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but got %d", test.body, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusAccepted {
+			continue
+		}
+		if saved == nil || saved.Domain != "github.com" || saved.RequestedBy != testAdministrator.EmailAddress || saved.Status != dataaccess.ExportPending {
+			t.Errorf("For %s, expected a pending job in the administrator's domain, but got %+v", test.body, saved)
+			continue
+		}
+		if location := w.Header().Get("Location"); location != "/admin/exports/?id="+saved.ID {
+			t.Errorf("For %s, expected the job's location, but got %s", test.body, location)
+		}
+	}
+}
+
+func TestThatExportStatusIsOnlyReturnedToTheDomain(t *testing.T) {
+	mda := &mockDataAccess{
+		getExportJobResponse: func(id string) (*dataaccess.ExportJob, bool, error) {
+			return &dataaccess.ExportJob{ID: id, Domain: "github.com", Status: dataaccess.ExportSucceeded}, id == "5a1f", nil
+		},
+	}
+
+	tests := []struct {
+		url          string
+		caller       caller.Caller
+		expectedCode int
+	}{
+		{"/admin/exports/?id=5a1f", testAdministrator, http.StatusOK},
+		{"/admin/exports/?id=missing", testAdministrator, http.StatusNotFound},
+		{"/admin/exports/?id=5a1f", caller.Caller{EmailAddress: "admin@example.com", Roles: []string{dataaccess.AdministratorRole}}, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("GET", test.url, "", test.caller)
+
+		NewExportHandler(mda).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s by %s, expected status %d, but got %d", test.url, test.caller.EmailAddress, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var status exportStatus
+		if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+			t.Fatal(err)
+		}
+		if status.DownloadURL != "/admin/exports/download/?id=5a1f" {
+			t.Errorf("Expected the download URL of the succeeded job, but got %q", status.DownloadURL)
+		}
+	}
+}
+
+type mockExportStore struct {
+	files map[string][]byte
+}
+
+func (s mockExportStore) Put(name string, data []byte) error { return nil }
+func (s mockExportStore) Get(name string) ([]byte, error)    { return s.files[name], nil }
+func (s mockExportStore) Delete(name string) error           { return nil }
+
+func TestThatExportsCanBeDownloadedOnceTheyHaveSucceeded(t *testing.T) {
+	created := time.Date(2017, time.July, 1, 0, 0, 0, 0, time.UTC)
+	jobs := map[string]dataaccess.ExportJob{
+		"done":    {ID: "done", Domain: "github.com", Kind: "profiles", Format: "csv", Status: dataaccess.ExportSucceeded, Blob: "exports/done", Created: created},
+		"running": {ID: "running", Domain: "github.com", Kind: "profiles", Format: "csv", Status: dataaccess.ExportRunning, Created: created},
+	}
+	mda := &mockDataAccess{
+		getExportJobResponse: func(id string) (*dataaccess.ExportJob, bool, error) {
+			j, ok := jobs[id]
+			return &j, ok, nil
+		},
+	}
+	store := mockExportStore{files: map[string][]byte{"exports/done": []byte("emailAddress\n")}}
+
+	w := httptest.NewRecorder()
+	NewExportDownloadHandler(mda, store).ServeHTTP(w, newRequestWithCaller("GET", "/admin/exports/download/?id=done", "", testAdministrator))
+
+	if w.Code != http.StatusOK || w.Body.String() != "emailAddress\n" {
+		t.Errorf("Expected the file to be downloaded, but got %d %q", w.Code, w.Body.String())
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != `attachment; filename="github.com-profiles-2017-07-01.csv"` {
+		t.Errorf("Expected the file to be named, but got %s", disposition)
+	}
+
+	w = httptest.NewRecorder()
+	NewExportDownloadHandler(mda, store).ServeHTTP(w, newRequestWithCaller("GET", "/admin/exports/download/?id=running", "", testAdministrator))
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected a running export not to be downloaded, but got %d", w.Code)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The ExternalIDHandler looks up profiles by their IDs in other systems,
+// e.g. /admin/profiles/external/?system=slack&id=U024BE7LH, and maps
+// profiles to their IDs, e.g. a PUT to
+// /admin/profiles/external/?emailAddress=dev@example.com. Connectors use it
+// to find people without relying on their email addresses matching.
+type ExternalIDHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewExternalIDHandler creates an instance of the ExternalIDHandler.
+func NewExternalIDHandler(da dataaccess.DataAccess) *ExternalIDHandler {
+	return &ExternalIDHandler{da}
+}
+
+func (handler ExternalIDHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling external ID request.")
+
+	c, ok := administrator(r)
+	if !ok {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyExternalIds")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	tenant := r.FormValue("tenant")
+	if tenant == "" {
+		tenant = c.Tenant
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		system, id := strings.ToLower(r.FormValue("system")), r.FormValue("id")
+		if system == "" || id == "" {
+			writeError(w, r, http.StatusBadRequest, "error.invalidExternalId")
+			return
+		}
+		p, found, err := da.FindProfileByExternalID(tenant, system, id)
+		if err != nil {
+			log.Printf("Failed to find the profile with the %s ID %s. %v", system, id, err)
+			writeError(w, r, http.StatusInternalServerError, "error.profileReadFailed", id)
+			return
+		}
+		if !found {
+			writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+			return
+		}
+		writeJSON(w, http.StatusOK, p)
+	case http.MethodPut:
+		emailAddress := strings.ToLower(r.FormValue("emailAddress"))
+		if !inDomains(emailAddress, []string{tenant}) {
+			writeError(w, r, http.StatusForbidden, "error.externalIdsOutsideTenant", tenant)
+			return
+		}
+		var ids map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&ids); err != nil || len(ids) == 0 {
+			writeError(w, r, http.StatusBadRequest, "error.invalidExternalId")
+			return
+		}
+		p, found, err := da.GetProfile(emailAddress)
+		if err != nil {
+			log.Printf("Failed to read the profile of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.profileReadFailed", emailAddress)
+			return
+		}
+		if !found {
+			writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+			return
+		}
+		err = da.UpdateExternalIDs(emailAddress, ids)
+		if _, ok := err.(dataaccess.ValidationError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to update the external IDs of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.externalIdsSaveFailed")
+			return
+		}
+
+		updated := make(map[string]string)
+		for system, id := range p.ExternalIDs {
+			updated[system] = id
+		}
+		for system, id := range ids {
+			if id == "" {
+				delete(updated, system)
+				continue
+			}
+			updated[system] = id
+		}
+		log.Printf("User %s has updated the external IDs of %s.", c.EmailAddress, emailAddress)
+		writeJSON(w, http.StatusOK, updated)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
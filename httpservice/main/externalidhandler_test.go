@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatProfilesAreFoundByTheirExternalIDsInTheTenant(t *testing.T) {
+	var searched string
+	mda := &mockDataAccess{
+		findProfileByExternalIDResponse: func(domain string, system string, id string) (*dataaccess.Profile, bool, error) {
+			searched = domain
+			return &dataaccess.Profile{EmailAddress: "adrian@" + domain}, system == dataaccess.SlackSystem && id == "U024BE7LH", nil
+		},
+	}
+
+	tests := []struct {
+		url          string
+		caller       caller.Caller
+		expectedCode int
+	}{
+		{"/admin/profiles/external/?system=Slack&id=U024BE7LH", testAdministrator, http.StatusOK},
+		{"/admin/profiles/external/?system=slack&id=U000", testAdministrator, http.StatusNotFound},
+		{"/admin/profiles/external/?system=slack", testAdministrator, http.StatusBadRequest},
+		{"/admin/profiles/external/?system=slack&id=U024BE7LH", caller.Caller{EmailAddress: "dev@github.com"}, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		searched = ""
+		w := httptest.NewRecorder()
+		NewExternalIDHandler(mda).ServeHTTP(w, newRequestWithCaller("GET", test.url, "", test.caller))
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but got %d", test.url, test.expectedCode, w.Code)
+		}
+		if searched != "" && searched != testAdministrator.Tenant {
+			t.Errorf("For %s, expected only the tenant to be searched, but got %s", test.url, searched)
+		}
+	}
+}
+
+func TestThatExternalIDsCanBeMapped(t *testing.T) {
+	var updated map[string]string
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{EmailAddress: emailAddress, ExternalIDs: map[string]string{dataaccess.GitHubSystem: "a-h"}}, true, nil
+		},
+		updateExternalIDsResponse: func(emailAddress string, ids map[string]string) error {
+			updated = ids
+			return dataaccess.CheckExternalIDs(ids)
+		},
+	}
+
+	tests := []struct {
+		url          string
+		body         string
+		expectedCode int
+	}{
+		{"/admin/profiles/external/?emailAddress=adrian@github.com", `{"slack":"U024BE7LH","github":""}`, http.StatusOK},
+		{"/admin/profiles/external/?emailAddress=adrian@github.com", `{"git.hub":"a-h"}`, http.StatusBadRequest},
+		{"/admin/profiles/external/?emailAddress=adrian@example.com", `{"slack":"U024BE7LH"}`, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		NewExternalIDHandler(mda).ServeHTTP(w, newRequestWithCaller("PUT", test.url, test.body, testAdministrator))
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but got %d", test.body, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var ids map[string]string
+		if err := json.NewDecoder(w.Body).Decode(&ids); err != nil {
+			t.Fatal(err)
+		}
+		if expected := map[string]string{dataaccess.SlackSystem: "U024BE7LH"}; !reflect.DeepEqual(ids, expected) {
+			t.Errorf("Expected the GitHub handle to be removed and the Slack ID added, but got %v", ids)
+		}
+		if updated[dataaccess.GitHubSystem] != "" || len(updated) != 2 {
+			t.Errorf("Expected the removal to be passed on as an empty ID, but got %v", updated)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The FeatureFlagHandler allows administrators to list and toggle feature
+// flags.
+type FeatureFlagHandler struct {
+	DataAccess    dataaccess.DataAccess
+	getSession    func(w http.ResponseWriter, r *http.Request) Session
+	Configuration *ConfigurationCache
+}
+
+// NewFeatureFlagHandler creates an instance of the FeatureFlagHandler.
+func NewFeatureFlagHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session, cc *ConfigurationCache) *FeatureFlagHandler {
+	return &FeatureFlagHandler{da, sessionFactory, cc}
+}
+
+// featureFlagUpdate is posted to the handler to switch a feature on or off.
+type featureFlagUpdate struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+func (handler FeatureFlagHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling feature flag request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	if !handler.Configuration.Get().IsAdministrator(emailAddress) {
+		log.Printf("User %s attempted to access feature flags, but is not an administrator.", emailAddress)
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyFeatureFlags")
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		handleFeatureFlagPost(w, r, handler, emailAddress)
+		return
+	}
+
+	writeFeatureFlags(w, handler.Configuration.Get().FeatureFlags)
+}
+
+func handleFeatureFlagPost(w http.ResponseWriter, r *http.Request, handler FeatureFlagHandler, emailAddress string) {
+	var update featureFlagUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, r, http.StatusBadRequest, "error.invalidFeatureFlagUpdate")
+		return
+	}
+
+	log.Printf("User %s is setting feature flag %s to %t.", emailAddress, update.Name, update.Enabled)
+
+	err := dataaccess.WithContext(handler.DataAccess, r.Context()).SetFeatureFlag(update.Name, update.Enabled)
+	if err == dataaccess.ErrInvalidFeatureFlagName {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		log.Print("Failed to set the feature flag. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.featureFlagSetFailed")
+		return
+	}
+
+	if err = handler.Configuration.Refresh(); err != nil {
+		log.Print("Failed to refresh the configuration. ", err)
+	}
+
+	writeFeatureFlags(w, handler.Configuration.Get().FeatureFlags)
+}
+
+func writeFeatureFlags(w http.ResponseWriter, flags map[string]bool) {
+	if flags == nil {
+		flags = map[string]bool{}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(flags); err != nil {
+		log.Printf("Failed to marshall the feature flags, with error %s", err)
+	}
+}
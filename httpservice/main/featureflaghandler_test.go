@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func newTestConfigurationCache(c dataaccess.Configuration) *ConfigurationCache {
+	mda := &mockDataAccess{
+		getOrCreateConfigurationResponse: func() (dataaccess.Configuration, error) {
+			return c, nil
+		},
+	}
+	cc := NewConfigurationCache(mda)
+	cc.Refresh()
+	return cc
+}
+
+func TestThatOnlyAdministratorsCanAccessFeatureFlags(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "someone@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	cc := newTestConfigurationCache(dataaccess.Configuration{Administrators: []string{"a-h@github.com"}})
+	fh := NewFeatureFlagHandler(&mockDataAccess{}, sf, cc)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/admin/features/", nil)
+
+	fh.ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Users who are not administrators should be forbidden, but the status was %d.", w.Code)
+	}
+}
+
+func TestThatAdministratorsCanToggleFeatureFlags(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	flags := map[string]bool{}
+	mda := &mockDataAccess{
+		setFeatureFlagResponse: func(name string, enabled bool) error {
+			flags[name] = enabled
+			return nil
+		},
+		getOrCreateConfigurationResponse: func() (dataaccess.Configuration, error) {
+			return dataaccess.Configuration{Administrators: []string{"a-h@github.com"}, FeatureFlags: flags}, nil
+		},
+	}
+	cc := NewConfigurationCache(mda)
+	cc.Refresh()
+
+	fh := NewFeatureFlagHandler(mda, sf, cc)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "http://example.com/admin/features/", strings.NewReader(`{"name":"endorsements","enabled":true}`))
+
+	fh.ServeHTTP(w, r)
+
+	if mda.setFeatureFlagCallCount != 1 {
+		t.Fatal("The feature flag should have been set.")
+	}
+
+	if !cc.Get().IsEnabled("endorsements") {
+		t.Error("The configuration should be refreshed after the flag is set.")
+	}
+
+	expected := `{"endorsements":true}`
+	if actual := strings.TrimSpace(w.Body.String()); actual != expected {
+		t.Errorf("Expected JSON to be %s, was %s", expected, actual)
+	}
+}
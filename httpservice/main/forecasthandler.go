@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/staffing"
+)
+
+// The ForecastHandler compares the demand for skills from the projects in
+// the user's domain with the capacity of the people who have them, for each
+// of the next two quarters, as JSON or CSV.
+type ForecastHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewForecastHandler creates an instance of the ForecastHandler.
+func NewForecastHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *ForecastHandler {
+	return &ForecastHandler{da, sessionFactory, time.Now}
+}
+
+func (handler ForecastHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling forecast request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(emailAddress)
+
+	projects, err := da.ListProjects(domain)
+	if err != nil {
+		log.Printf("Failed to list the projects of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.projectReadFailed")
+		return
+	}
+
+	profiles, err := da.ListProfiles(emailAddress)
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	profiles = dataaccess.Consenting(profiles, dataaccess.ConsentAnalytics, settings.Consent)
+
+	f := staffing.NewForecast(projects, profiles, handler.now(), staffing.ForecastMonths)
+
+	if r.FormValue("format") == "csv" {
+		writeForecastCSV(w, f)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(f); err != nil {
+		log.Printf("Failed to marshall the forecast, with error %s", err)
+	}
+}
+
+// writeForecastCSV writes a row for each skill needed in each month, for use
+// in a spreadsheet.
+func writeForecastCSV(w http.ResponseWriter, f staffing.Forecast) {
+	w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="forecast.csv"`)
+
+	format := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"month", "skill", "demand", "draftDemand", "capacity", "shortfall"})
+
+	for _, m := range f.Months {
+		for _, s := range m.Skills {
+			cw.Write([]string{m.Start.Format("2006-01"), s.Skill, format(s.Demand), format(s.DraftDemand), format(s.Capacity), format(s.Shortfall)})
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("Failed to write the forecast CSV, with error %s", err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/staffing"
+)
+
+func newForecastTestHandler() *ForecastHandler {
+	mda := newProjectDataAccess()
+	mda.listProjectsResponse = func(domain string) ([]dataaccess.Project, error) {
+		p, _, _ := mda.GetProject("website")
+		return []dataaccess.Project{*p}, nil
+	}
+	handler := NewForecastHandler(mda, newProjectSession())
+	handler.now = func() time.Time { return march.AddDate(0, 0, 14) }
+	return handler
+}
+
+func TestThatTheForecastIncludesTheNextTwoQuarters(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/forecast/", nil)
+
+	newForecastTestHandler().ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but was %d.", w.Code)
+	}
+
+	var f staffing.Forecast
+	if err := json.NewDecoder(w.Body).Decode(&f); err != nil {
+		t.Fatal("Failed to decode the forecast.", err)
+	}
+	if len(f.Months) != staffing.ForecastMonths || !f.Months[0].Start.Equal(march) {
+		t.Fatalf("Expected %d months from March, but received %v", staffing.ForecastMonths, f.Months)
+	}
+	if len(f.Months[0].Skills) != 1 || f.Months[0].Skills[0].Skill != "sql" || f.Months[0].Skills[0].Capacity != 1 {
+		t.Errorf("Expected the unbooked dba@github.com to be able to cover sql, but received %v", f.Months[0].Skills)
+	}
+}
+
+func TestThatPeopleWhoHaventConsentedArentForecast(t *testing.T) {
+	handler := newForecastTestHandler()
+	mda := handler.DataAccess.(*mockDataAccess)
+	listProfiles := mda.listProfilesResponse
+	mda.listProfilesResponse = func() ([]dataaccess.Profile, error) {
+		profiles, err := listProfiles()
+		for i := range profiles {
+			if profiles[i].EmailAddress == "dba@github.com" {
+				profiles[i].Consents = []dataaccess.Consent{{Purpose: dataaccess.ConsentAnalytics, Given: false}}
+			}
+		}
+		return profiles, err
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/forecast/", nil)
+
+	handler.ServeHTTP(w, r)
+
+	var f staffing.Forecast
+	if err := json.NewDecoder(w.Body).Decode(&f); err != nil {
+		t.Fatal("Failed to decode the forecast.", err)
+	}
+	if len(f.Months) == 0 || len(f.Months[0].Skills) != 1 || f.Months[0].Skills[0].Capacity != 0 {
+		t.Errorf("Expected dba@github.com not to be counted as capacity for sql, but received %v", f.Months)
+	}
+}
+
+func TestThatTheForecastCanBeDownloadedAsCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/forecast/?format=csv", nil)
+
+	newForecastTestHandler().ServeHTTP(w, r)
+
+	expected := "month,skill,demand,draftDemand,capacity,shortfall\n" +
+		"2017-03,sql,1,0,1,0\n" +
+		"2017-04,sql,1,0,1,0\n" +
+		"2017-05,sql,1,0,1,0\n"
+
+	if w.Body.String() != expected {
+		t.Errorf("Expected CSV:\n%s\nbut received:\n%s", expected, w.Body.String())
+	}
+}
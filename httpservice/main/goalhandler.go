@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/contentfilter"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/goals"
+)
+
+// The GoalHandler lists, adds, updates and removes the user's learning
+// goals.
+type GoalHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewGoalHandler creates an instance of the GoalHandler.
+func NewGoalHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *GoalHandler {
+	return &GoalHandler{da, sessionFactory, time.Now}
+}
+
+// The GoalReportHandler rolls up the learning goals of a manager's team,
+// e.g. /report/goals/?manager=boss@example.com. The team defaults to the
+// user's.
+type GoalReportHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewGoalReportHandler creates an instance of the GoalReportHandler.
+func NewGoalReportHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *GoalReportHandler {
+	return &GoalReportHandler{da, sessionFactory, time.Now}
+}
+
+// goalRequest is posted to add a goal, or put to update one. Due is a
+// quarter, e.g. "Q3", or a date, e.g. "2018-09-30".
+type goalRequest struct {
+	ID     string                  `json:"id"`
+	Skill  string                  `json:"skill"`
+	Target dataaccess.DreyfusLevel `json:"target"`
+	Due    string                  `json:"due"`
+	Status dataaccess.GoalStatus   `json:"status"`
+	Note   string                  `json:"note"`
+}
+
+type goalsResponse struct {
+	Goals []dataaccess.LearningGoal `json:"goals"`
+	// WantToLearn is the skills the user wants to learn, with their goals.
+	WantToLearn []dataaccess.WantToLearn `json:"wantToLearn"`
+}
+
+func (handler GoalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling learning goal request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	profile, found, err := da.GetProfile(emailAddress)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", emailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.learningGoalReadFailed")
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+		return
+	}
+
+	now := handler.now()
+	switch r.Method {
+	case http.MethodGet:
+		writeGoals(w, http.StatusOK, profile)
+		return
+	case http.MethodPost, http.MethodPut:
+		var req goalRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidLearningGoal")
+			return
+		}
+		fields := []contentfilter.Field{{Name: "skill name", Text: req.Skill}, {Name: "note", Text: req.Note}}
+		if !checkContent(w, r, da, dataaccess.GetDomain(emailAddress), fields...) {
+			return
+		}
+		var due time.Time
+		if req.Due != "" {
+			if due, err = dataaccess.ParseDue(req.Due, now); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if r.Method == http.MethodPost {
+			g := dataaccess.NewLearningGoal(req.Skill, req.Target, due, now)
+			g.Note = req.Note
+			profile.Goals = append(profile.Goals, g)
+		} else if !updateGoal(profile, req, due, now) {
+			writeError(w, r, http.StatusNotFound, "error.learningGoalNotFound")
+			return
+		}
+		// Goals for levels which have already been reached are achieved
+		// straight away.
+		profile.AchieveLearningGoals(now)
+	case http.MethodDelete:
+		id := r.FormValue("id")
+		var kept []dataaccess.LearningGoal
+		for _, g := range profile.Goals {
+			if g.ID != id {
+				kept = append(kept, g)
+			}
+		}
+		if len(kept) == len(profile.Goals) {
+			writeError(w, r, http.StatusNotFound, "error.learningGoalNotFound")
+			return
+		}
+		profile.Goals = kept
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	if err := dataaccess.ValidateLearningGoals(profile.Goals); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := da.UpdateLearningGoals(emailAddress, profile.Goals); err != nil {
+		log.Printf("Failed to update the learning goals of %s. %v", emailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.learningGoalSaveFailed")
+		return
+	}
+
+	log.Printf("User %s has updated their learning goals.", emailAddress)
+	writeGoals(w, http.StatusOK, profile)
+}
+
+// updateGoal changes the fields of the goal which are set in the request,
+// returning false if the profile doesn't have the goal.
+func updateGoal(profile *dataaccess.Profile, req goalRequest, due time.Time, now time.Time) bool {
+	for i := range profile.Goals {
+		g := &profile.Goals[i]
+		if g.ID != req.ID {
+			continue
+		}
+		if req.Skill != "" {
+			g.Skill = dataaccess.CleanTag(strings.TrimSpace(req.Skill))
+		}
+		if req.Target != 0 {
+			g.Target = req.Target
+		}
+		if !due.IsZero() {
+			g.Due = due
+		}
+		if req.Status != "" {
+			g.Status = req.Status
+		}
+		if req.Note != "" {
+			g.Note = req.Note
+		}
+		g.Updated = now.UTC().Truncate(time.Millisecond)
+		return true
+	}
+	return false
+}
+
+func writeGoals(w http.ResponseWriter, status int, profile *dataaccess.Profile) {
+	response := goalsResponse{Goals: profile.Goals, WantToLearn: profile.WantToLearn()}
+	if response.Goals == nil {
+		response.Goals = []dataaccess.LearningGoal{}
+	}
+	writeJSON(w, status, response)
+}
+
+func (handler GoalReportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling learning goal report request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	profiles, err := dataaccess.WithContext(handler.DataAccess, r.Context()).ListProfiles(emailAddress)
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+
+	manager := r.FormValue("manager")
+	if manager == "" {
+		manager = emailAddress
+	}
+	team, ok := teamProfiles(dataaccess.GetDomain(emailAddress), profiles, manager)
+	if !ok {
+		writeError(w, r, http.StatusNotFound, "error.managerNotFound")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, goals.Compile(team, handler.now()))
+}
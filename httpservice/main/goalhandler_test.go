@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/goals"
+)
+
+func TestThatLearningGoalsCanBeAdded(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	var saved []dataaccess.LearningGoal
+	mda := &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{
+				EmailAddress: emailAddress,
+				Skills:       []dataaccess.Skill{{Skill: "terraform", Level: dataaccess.NoviceLevel, Interest: dataaccess.StronglyAgree}, {Skill: "go", Level: dataaccess.ExpertLevel}},
+			}, true, nil
+		},
+		updateLearningGoalsResponse: func(emailAddress string, goals []dataaccess.LearningGoal) error {
+			saved = goals
+			return nil
+		},
+	}
+
+	tests := []struct {
+		body           string
+		expectedCode   int
+		expectedStatus dataaccess.GoalStatus
+	}{
+		{`{"skill":"Terraform","target":3,"due":"Q3"}`, http.StatusOK, dataaccess.GoalPlanned},
+		{`{"skill":"go","target":3,"due":"Q3"}`, http.StatusOK, dataaccess.GoalAchieved},
+		{`{"skill":"terraform","target":3,"due":"next year"}`, http.StatusBadRequest, ""},
+		{`{"skill":"terraform","target":9,"due":"Q3"}`, http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		saved = nil
+		handler := NewGoalHandler(mda, sf)
+		handler.now = func() time.Time { return time.Date(2017, time.August, 14, 0, 0, 0, 0, time.UTC) }
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "http://example.com/profile/goals/", strings.NewReader(test.body))
+		handler.ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.body, test.expectedCode, w.Code)
+			continue
+		}
+		if test.expectedCode != http.StatusOK {
+			continue
+		}
+		if len(saved) != 1 || saved[0].Status != test.expectedStatus || saved[0].Due.Month() != time.September {
+			t.Errorf("For %s, expected a %s goal due in September, but saved %v", test.body, test.expectedStatus, saved)
+		}
+		var response goalsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatal("Failed to decode the goals.", err)
+		}
+		if test.expectedStatus == dataaccess.GoalPlanned && (len(response.WantToLearn) != 1 || response.WantToLearn[0].Goal == nil) {
+			t.Errorf("Expected the goal to be linked to terraform in the want-to-learn list, but received %v", response.WantToLearn)
+		}
+	}
+}
+
+func TestThatManagersCanSeeTheirTeamsGoals(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "boss@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	now := time.Date(2017, time.August, 14, 0, 0, 0, 0, time.UTC)
+	goal := dataaccess.NewLearningGoal("terraform", 3, now.AddDate(0, -1, 0), now.AddDate(0, -6, 0))
+	mda := &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "boss@github.com"},
+				{EmailAddress: "dev@github.com", Manager: "boss@github.com", Goals: []dataaccess.LearningGoal{goal}},
+				{EmailAddress: "other@github.com", Goals: []dataaccess.LearningGoal{goal}},
+			}, nil
+		},
+	}
+	handler := NewGoalReportHandler(mda, sf)
+	handler.now = func() time.Time { return now }
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/goals/", nil)
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but was %d.", w.Code)
+	}
+	var rollUp goals.RollUp
+	if err := json.NewDecoder(w.Body).Decode(&rollUp); err != nil {
+		t.Fatal("Failed to decode the roll-up.", err)
+	}
+	if len(rollUp.People) != 1 || rollUp.People[0].EmailAddress != "dev@github.com" || rollUp.Overdue != 1 {
+		t.Errorf("Expected dev@github.com's overdue goal, but received %+v", rollUp)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The HeatmapHandler returns a matrix of people against skills, with the
+// level of each person's skill in the cells, as JSON or CSV. The matrix can
+// be limited to a manager's team, a department or a cost center.
+type HeatmapHandler struct {
+	DataAccess dataaccess.DataAccess
+	// SnapshotMaxAge is the oldest snapshot which is served for the whole
+	// domain. If it's zero, the heatmap is always computed live.
+	SnapshotMaxAge time.Duration
+	getSession     func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewHeatmapHandler creates an instance of the HeatmapHandler.
+func NewHeatmapHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *HeatmapHandler {
+	return &HeatmapHandler{DataAccess: da, getSession: sessionFactory}
+}
+
+// heatmap is the JSON representation of the matrix.
+type heatmap struct {
+	Skills []string     `json:"skills"`
+	People []heatmapRow `json:"people"`
+}
+
+type heatmapRow struct {
+	EmailAddress string               `json:"emailAddress"`
+	Name         string               `json:"name,omitempty"`
+	Availability dataaccess.RagStatus `json:"availability"`
+	// Levels has an entry for each skill, 0 if the person doesn't have it.
+	Levels []dataaccess.DreyfusLevel `json:"levels"`
+}
+
+func newHeatmap(model ReportModel) heatmap {
+	h := heatmap{Skills: model.SkillNames, People: []heatmapRow{}}
+
+	for _, p := range model.Profiles {
+		row := heatmapRow{
+			EmailAddress: p.EmailAddress,
+			Name:         p.Name,
+			Availability: p.Availability,
+			Levels:       make([]dataaccess.DreyfusLevel, len(p.Skills)),
+		}
+		for i, s := range p.Skills {
+			row.Levels[i] = s.Level
+		}
+		h.People = append(h.People, row)
+	}
+
+	return h
+}
+
+// teamProfiles returns the profiles of the manager and everyone who reports
+// to them.
+func teamProfiles(domain string, profiles []dataaccess.Profile, manager string) ([]dataaccess.Profile, bool) {
+	node, ok := dataaccess.NewOrgTree(domain, profiles).Find(manager)
+	if !ok {
+		return nil, false
+	}
+
+	members := make(map[string]bool)
+	for _, id := range node.Members() {
+		members[id] = true
+	}
+
+	var team []dataaccess.Profile
+	for _, p := range profiles {
+		if members[strings.ToLower(p.EmailAddress)] {
+			team = append(team, p)
+		}
+	}
+	return team, true
+}
+
+func (handler HeatmapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling heatmap request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(emailAddress)
+	var snapshot heatmap
+	if r.FormValue("manager") == "" && profileFilter(r).Empty() && readSnapshot(w, da, domain, dataaccess.HeatmapSnapshot, handler.SnapshotMaxAge, &snapshot) {
+		writeHeatmap(w, r, snapshot)
+		return
+	}
+
+	profiles, err := da.ListProfiles(emailAddress)
+
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+
+	if manager := r.FormValue("manager"); manager != "" {
+		var ok bool
+		if profiles, ok = teamProfiles(domain, profiles, manager); !ok {
+			writeError(w, r, http.StatusNotFound, "error.managerNotFound")
+			return
+		}
+	}
+
+	// The team is found before filtering, so that managers in other
+	// departments, and those who haven't consented to analytics, are still
+	// followed.
+	profiles = dataaccess.Consenting(profiles, dataaccess.ConsentAnalytics, settings.Consent)
+	writeHeatmap(w, r, newHeatmap(newReportModel(profileFilter(r).Apply(profiles))))
+}
+
+// writeHeatmap writes the heatmap as JSON, or as CSV if it's the requested
+// format.
+func writeHeatmap(w http.ResponseWriter, r *http.Request, h heatmap) {
+	if r.FormValue("format") == "csv" {
+		writeHeatmapCSV(w, h)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(h); err != nil {
+		log.Printf("Failed to marshall the heatmap, with error %s", err)
+	}
+}
+
+func writeHeatmapCSV(w http.ResponseWriter, h heatmap) {
+	w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="heatmap.csv"`)
+
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"emailAddress", "name", "availability"}, h.Skills...))
+
+	for _, p := range h.People {
+		record := []string{p.EmailAddress, p.Name, strconv.Itoa(int(p.Availability))}
+		for _, level := range p.Levels {
+			if level == 0 {
+				record = append(record, "")
+			} else {
+				record = append(record, strconv.Itoa(int(level)))
+			}
+		}
+		cw.Write(record)
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		log.Printf("Failed to write the heatmap CSV, with error %s", err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func newHeatmapTestHandler() *HeatmapHandler {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	mda := &mockDataAccess{
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "boss@github.com", Skills: []dataaccess.Skill{{Skill: "management", Level: 4}}},
+				{EmailAddress: "a-h@github.com", Name: "Adrian", Manager: "boss@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}},
+				{EmailAddress: "other@github.com", Skills: []dataaccess.Skill{{Skill: "java", Level: 5}}},
+				{EmailAddress: "private@github.com", Skills: []dataaccess.Skill{{Skill: "cobol", Level: 5}},
+					Consents: []dataaccess.Consent{{Purpose: dataaccess.ConsentAnalytics, Given: false}}},
+			}, nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+	}
+
+	return NewHeatmapHandler(mda, sf)
+}
+
+func TestThatTheHeatmapCanBeLimitedToATeam(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/heatmap/?manager=boss@github.com", nil)
+
+	newHeatmapTestHandler().ServeHTTP(w, r)
+
+	var h heatmap
+	if err := json.NewDecoder(w.Body).Decode(&h); err != nil {
+		t.Fatal("Failed to decode the heatmap.", err)
+	}
+
+	if len(h.People) != 2 || len(h.Skills) != 2 {
+		t.Fatalf("Expected the boss's team of 2 people with 2 skills, but received %v", h)
+	}
+
+	for _, p := range h.People {
+		if p.EmailAddress == "other@github.com" {
+			t.Error("People outside the team should not be included.")
+		}
+	}
+}
+
+func TestThatTheHeatmapCanBeDownloadedAsCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/heatmap/?format=csv", nil)
+
+	newHeatmapTestHandler().ServeHTTP(w, r)
+
+	// private@github.com has withdrawn their consent to analytics.
+	expected := "emailAddress,name,availability,go,java,management\n" +
+		"boss@github.com,,0,,,4\n" +
+		"a-h@github.com,Adrian,0,3,,\n" +
+		"other@github.com,,0,,5,\n"
+
+	if w.Body.String() != expected {
+		t.Errorf("Expected CSV:\n%s\nbut received:\n%s", expected, w.Body.String())
+	}
+}
+
+func TestThatAnUnknownManagerIsNotFound(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/heatmap/?manager=nobody@github.com", nil)
+
+	newHeatmapTestHandler().ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, but was %d.", w.Code)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"log"
+	"sync"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+// The Hub broadcasts change events to connected WebSocket clients. Clients
+// only receive events for their own domain, or events which apply to all
+// domains.
+type Hub struct {
+	clients map[*hubClient]bool
+	mutex   sync.RWMutex
+}
+
+// NewHub creates an instance of the Hub.
+func NewHub() *Hub {
+	return &Hub{
+		clients: make(map[*hubClient]bool),
+	}
+}
+
+// A subscription filters the events a client receives.
+type subscription struct {
+	// Types lists the event types the client wants to receive. If empty, all
+	// types are sent.
+	Types []events.EventType `json:"types"`
+}
+
+type hubClient struct {
+	domain string
+	send   chan events.Event
+	mutex  sync.RWMutex
+	types  map[events.EventType]bool
+}
+
+func newHubClient(domain string) *hubClient {
+	return &hubClient{
+		domain: domain,
+		send:   make(chan events.Event, 16),
+	}
+}
+
+func (c *hubClient) subscribe(s subscription) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(s.Types) == 0 {
+		c.types = nil
+		return
+	}
+
+	c.types = make(map[events.EventType]bool)
+	for _, t := range s.Types {
+		c.types[t] = true
+	}
+}
+
+func (c *hubClient) wants(e events.Event) bool {
+	if e.Domain != "" && e.Domain != c.domain {
+		return false
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.types == nil || c.types[e.Type]
+}
+
+func (h *Hub) register(c *hubClient) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.clients[c] = true
+}
+
+func (h *Hub) unregister(c *hubClient) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// Close disconnects all clients. Events which have already been published
+// are sent before each connection is closed.
+func (h *Hub) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for c := range h.clients {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// A profileChange is what clients are sent about a changed profile. The
+// profile itself isn't sent, since it hasn't been redacted for each client,
+// so clients read it again through the API, which redacts and audits it.
+type profileChange struct {
+	Version int `json:"version"`
+}
+
+// forClients returns the event with only the data which every client in the
+// domain may see: the version of a changed profile, or the skill tags which
+// were added or deleted.
+func forClients(e events.Event) events.Event {
+	switch d := e.Data.(type) {
+	case *dataaccess.Profile:
+		e.Data = nil
+		if d != nil {
+			e.Data = profileChange{Version: d.Version}
+		}
+	case []string:
+	default:
+		e.Data = nil
+	}
+	return e
+}
+
+// Publish sends the event to every interested client. Clients which are not
+// keeping up with the event stream are disconnected, rather than blocking the
+// publisher.
+func (h *Hub) Publish(e events.Event) {
+	e = forClients(e)
+	h.mutex.RLock()
+	var slow []*hubClient
+	for c := range h.clients {
+		if !c.wants(e) {
+			continue
+		}
+
+		select {
+		case c.send <- e:
+		default:
+			slow = append(slow, c)
+		}
+	}
+	h.mutex.RUnlock()
+
+	for _, c := range slow {
+		log.Printf("Disconnecting a slow WebSocket client in domain %s.", c.domain)
+		h.unregister(c)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+	"github.com/gorilla/websocket"
+)
+
+func TestThatHubClientsFilterEventsByDomainAndType(t *testing.T) {
+	tests := []struct {
+		types    []events.EventType
+		event    events.Event
+		expected bool
+	}{
+		{nil, events.Event{Type: events.ProfileUpdated, Domain: "github.com"}, true},
+		{nil, events.Event{Type: events.ProfileUpdated, Domain: "example.com"}, false},
+		{nil, events.Event{Type: events.SkillTagsAdded, Domain: ""}, true},
+		{[]events.EventType{events.ProfileDeleted}, events.Event{Type: events.ProfileUpdated, Domain: "github.com"}, false},
+		{[]events.EventType{events.ProfileDeleted}, events.Event{Type: events.ProfileDeleted, Domain: "github.com"}, true},
+	}
+
+	for _, test := range tests {
+		c := newHubClient("github.com")
+		c.subscribe(subscription{Types: test.types})
+
+		actual := c.wants(test.event)
+
+		if actual != test.expected {
+			t.Errorf("For subscription %v and event %v, expected %t, but was %t.", test.types, test.event, test.expected, actual)
+		}
+	}
+}
+
+func TestThatTheWebSocketHandlerSendsEventsForTheUsersDomain(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	hub := NewHub()
+	server := httptest.NewServer(NewWebSocketHandler(hub, sf))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal("Failed to connect to the WebSocket. ", err)
+	}
+	defer conn.Close()
+
+	waitForClients(hub, 1)
+
+	hub.Publish(events.NewEvent(events.ProfileUpdated, "example.com", "someone@example.com", nil))
+	hub.Publish(events.NewEvent(events.ProfileUpdated, "github.com", "a-h@github.com", nil))
+
+	var received events.Event
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := conn.ReadJSON(&received); err != nil {
+		t.Fatal("Failed to read an event from the WebSocket. ", err)
+	}
+
+	if received.EmailAddress != "a-h@github.com" {
+		t.Errorf("Only events for the github.com domain should be received, but received an event for %s.", received.EmailAddress)
+	}
+}
+
+func TestThatClientsAreOnlySentTheVersionOfAChangedProfile(t *testing.T) {
+	p := &dataaccess.Profile{
+		EmailAddress: "a-h@github.com",
+		Version:      3,
+		Clearance:    &dataaccess.Clearance{Level: "SC"},
+		CustomFields: map[string]dataaccess.CustomFieldValue{"salary": {}},
+	}
+
+	e := forClients(events.NewEvent(events.ProfileUpdated, "github.com", p.EmailAddress, p))
+
+	if change, ok := e.Data.(profileChange); !ok || change.Version != 3 {
+		t.Errorf("Expected only the profile's version to be sent, but received %#v", e.Data)
+	}
+	if p.Clearance == nil {
+		t.Error("The published profile should not be changed.")
+	}
+	if tags := forClients(events.NewEvent(events.SkillTagsAdded, "", "", []string{"go"})); len(tags.Data.([]string)) != 1 {
+		t.Errorf("Expected the added skill tags to be sent, but received %#v", tags.Data)
+	}
+}
+
+func TestThatTheWebSocketHandlerRejectsInvalidSessions(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse: false,
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	hub := NewHub()
+	server := httptest.NewServer(NewWebSocketHandler(hub, sf))
+	defer server.Close()
+
+	_, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+
+	if err == nil {
+		t.Error("The WebSocket connection should not be upgraded without a valid session.")
+	}
+
+	if len(hub.clients) != 0 {
+		t.Error("No clients should have been registered with the hub.")
+	}
+}
+
+func waitForClients(hub *Hub, count int) {
+	for i := 0; i < 100; i++ {
+		hub.mutex.RLock()
+		n := len(hub.clients)
+		hub.mutex.RUnlock()
+
+		if n >= count {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
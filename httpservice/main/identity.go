@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// identifyCaller adds the caller to the request's context, from either a
+// bearer token issued by the TokenHandler, or the session cookie. Requests
+// aren't rejected if the caller can't be identified, that's left to each
+// handler.
+func identifyCaller(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := identify(configuration.Get(), r); ok {
+			r = r.WithContext(caller.NewContext(r.Context(), c))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func identify(c dataaccess.Configuration, r *http.Request) (caller.Caller, bool) {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return identifyBearer(c, r)
+	}
+
+	cookie, err := r.Cookie(sessionName)
+	if err != nil {
+		return caller.Caller{}, false
+	}
+
+	manager, err := newSessionManager(c)
+	if err != nil {
+		return caller.Caller{}, false
+	}
+
+	t, err := manager.Verify(cookie.Value)
+	if err != nil {
+		return caller.Caller{}, false
+	}
+
+	if it, ok := impersonation(manager, r, t.EmailAddress); ok {
+		impersonated := c.Caller(it.EmailAddress)
+		impersonated.Impersonator = t.EmailAddress
+		return impersonated, true
+	}
+
+	return c.Caller(t.EmailAddress), true
+}
+
+// identifyBearer identifies the caller from the request's bearer token.
+// Browsers don't send the header by themselves, so requests identified by it
+// don't need CSRF protection. Tokens issued before the person's sessions
+// were revoked, e.g. because they have left, are refused like their
+// session cookies.
+func identifyBearer(c dataaccess.Configuration, r *http.Request) (caller.Caller, bool) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return caller.Caller{}, false
+	}
+	issuer := newIssuer(c)
+	claims, err := issuer.Verify(strings.TrimPrefix(auth, "Bearer "))
+	// Tokens issued for other services aren't accepted by pill.
+	if err != nil || (claims.Audience != "" && claims.Audience != issuer.Name) {
+		return caller.Caller{}, false
+	}
+	if revoked, ok := c.RevocationTimes()[strings.ToLower(claims.Email)]; ok && time.Unix(claims.IssuedAt, 0).Before(revoked) {
+		return caller.Caller{}, false
+	}
+	return caller.Caller{
+		EmailAddress: claims.Email,
+		Roles:        claims.Roles,
+		Tenant:       claims.Domain,
+	}, true
+}
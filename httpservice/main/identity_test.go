@@ -0,0 +1,143 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatCallersAreIdentifiedBySessionCookieOrBearerToken(t *testing.T) {
+	c := *dataaccess.NewConfiguration([]byte("0123456789abcdef0123456789abcdef"))
+	c.Administrators = []string{"admin@github.com"}
+
+	manager, _ := newSessionManager(c)
+	sessionToken, _ := manager.Issue("a-h@github.com")
+	bearerToken, _, _ := newIssuer(c).Issue("admin@github.com", "github.com", c.Roles("admin@github.com"), "")
+	otherAudience, _, _ := newIssuer(c).Issue("admin@github.com", "github.com", c.Roles("admin@github.com"), "reports")
+
+	tests := []struct {
+		name     string
+		cookie   string
+		bearer   string
+		expected string
+		admin    bool
+	}{
+		{"anonymous", "", "", "", false},
+		{"session cookie", sessionToken, "", "a-h@github.com", false},
+		{"invalid session cookie", "nonsense", "", "", false},
+		{"bearer token", "", bearerToken, "admin@github.com", true},
+		{"bearer token for another service", "", otherAudience, "", false},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", "http://example.com/profile/", nil)
+		if test.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: sessionName, Value: test.cookie})
+		}
+		if test.bearer != "" {
+			r.Header.Set("Authorization", "Bearer "+test.bearer)
+		}
+
+		actual, ok := identify(c, r)
+
+		if ok != (test.expected != "") || actual.EmailAddress != test.expected {
+			t.Errorf("For the '%s' test, expected caller '%s', but received '%s'.", test.name, test.expected, actual.EmailAddress)
+		}
+
+		if actual.HasRole(dataaccess.AdministratorRole) != test.admin {
+			t.Errorf("For the '%s' test, expected the administrator role to be %t.", test.name, test.admin)
+		}
+	}
+}
+
+func TestThatBearerTokensIssuedBeforeTheSessionsWereRevokedAreRefused(t *testing.T) {
+	c := *dataaccess.NewConfiguration([]byte("0123456789abcdef0123456789abcdef"))
+
+	bearerToken, _, _ := newIssuer(c).Issue("a-h@github.com", "github.com", nil, "")
+	c.RevokedSessions = []dataaccess.RevokedSession{{EmailAddress: "a-h@github.com", Revoked: time.Now().Add(time.Second)}}
+
+	r, _ := http.NewRequest("GET", "http://example.com/profile/", nil)
+	r.Header.Set("Authorization", "Bearer "+bearerToken)
+
+	if actual, ok := identify(c, r); ok {
+		t.Errorf("Expected the revoked token to be refused, but the caller was %v", actual)
+	}
+}
+
+func TestThatImpersonatedCallersRecordTheImpersonator(t *testing.T) {
+	c := *dataaccess.NewConfiguration([]byte("0123456789abcdef0123456789abcdef"))
+	c.Administrators = []string{"admin@github.com"}
+
+	manager, _ := newSessionManager(c)
+	sessionToken, _ := manager.Issue("admin@github.com")
+	impersonationToken, _ := manager.Impersonate("a-h@github.com", "admin@github.com", time.Hour)
+	otherToken, _ := manager.Impersonate("a-h@github.com", "someone@github.com", time.Hour)
+
+	r, _ := http.NewRequest("GET", "http://example.com/profile/", nil)
+	r.AddCookie(&http.Cookie{Name: sessionName, Value: sessionToken})
+	r.AddCookie(&http.Cookie{Name: impersonationName, Value: impersonationToken})
+
+	actual, _ := identify(c, r)
+
+	if actual.EmailAddress != "a-h@github.com" || actual.Impersonator != "admin@github.com" {
+		t.Errorf("Expected admin@github.com to be impersonating a-h@github.com, but received %v", actual)
+	}
+
+	if actual.HasRole(dataaccess.AdministratorRole) {
+		t.Error("An impersonated user should have their own roles.")
+	}
+
+	r, _ = http.NewRequest("GET", "http://example.com/profile/", nil)
+	r.AddCookie(&http.Cookie{Name: sessionName, Value: sessionToken})
+	r.AddCookie(&http.Cookie{Name: impersonationName, Value: otherToken})
+
+	if actual, _ := identify(c, r); actual.EmailAddress != "admin@github.com" {
+		t.Errorf("An impersonation token issued to another user should be ignored, but the caller was %s", actual.EmailAddress)
+	}
+}
+
+func TestThatRequestsWithABearerTokenDontNeedACSRFToken(t *testing.T) {
+	c := dataaccess.Configuration{SessionEncryptionKey: []byte("0123456789abcdef0123456789abcdef")}
+	previous := configuration
+	configuration = newTestConfigurationCache(c)
+	defer func() { configuration = previous }()
+
+	bearerToken, _, _ := newIssuer(c).Issue("a-h@github.com", "github.com", nil, "")
+	otherAudience, _, _ := newIssuer(c).Issue("a-h@github.com", "github.com", nil, "reports")
+
+	tests := []struct {
+		name     string
+		bearer   string
+		expected int
+	}{
+		{"without a bearer token", "", http.StatusForbidden},
+		{"with a bearer token", bearerToken, http.StatusOK},
+		{"with an invalid bearer token", "nonsense", http.StatusForbidden},
+		{"with a bearer token for another service", otherAudience, http.StatusForbidden},
+	}
+
+	h := csrfProtection(identifyCaller(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := caller.FromContext(r.Context()); !ok || c.EmailAddress != "a-h@github.com" {
+			t.Errorf("Expected the caller to be identified by the bearer token, but was %v.", c)
+		}
+	})))
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/profile/", strings.NewReader("availability=1"))
+		if test.bearer != "" {
+			r.Header.Set("Authorization", "Bearer "+test.bearer)
+		}
+
+		h.ServeHTTP(w, r)
+
+		if w.Code != test.expected {
+			t.Errorf("For a POST %s, expected status %d, but was %d.", test.name, test.expected, w.Code)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+const (
+	// DefaultImpersonationDuration is how long impersonation lasts if no
+	// duration is requested.
+	DefaultImpersonationDuration = 30 * time.Minute
+	// MaximumImpersonationDuration is the longest an administrator can
+	// impersonate a user for.
+	MaximumImpersonationDuration = time.Hour
+)
+
+// The ImpersonationHandler allows administrators to act as another user, to
+// see what they see. Starting and ending impersonation is recorded in the
+// audit log, as are any changes made while impersonating.
+type ImpersonationHandler struct {
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	Log        audit.Log
+}
+
+// NewImpersonationHandler creates an instance of the ImpersonationHandler.
+func NewImpersonationHandler(sessionFactory func(w http.ResponseWriter, r *http.Request) Session, l audit.Log) *ImpersonationHandler {
+	return &ImpersonationHandler{sessionFactory, l}
+}
+
+type impersonationRequest struct {
+	EmailAddress string `json:"emailAddress"`
+	Minutes      int    `json:"minutes"`
+}
+
+type impersonationStatus struct {
+	EmailAddress string `json:"emailAddress"`
+	Impersonator string `json:"impersonator,omitempty"`
+}
+
+func (handler ImpersonationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling impersonation request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, "error.sessionRequired")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		json.NewEncoder(w).Encode(impersonationStatus{c.EmailAddress, c.Impersonator})
+	case http.MethodPost:
+		handleImpersonationPost(w, r, handler, c)
+	case http.MethodDelete:
+		handleImpersonationDelete(w, r, handler, c)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func handleImpersonationPost(w http.ResponseWriter, r *http.Request, handler ImpersonationHandler, c caller.Caller) {
+	if !c.HasRole(dataaccess.AdministratorRole) || c.Impersonator != "" {
+		log.Printf("User %s attempted to impersonate another user, but is not an administrator.", c.EmailAddress)
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyImpersonation")
+		return
+	}
+
+	var ir impersonationRequest
+	if err := json.NewDecoder(r.Body).Decode(&ir); err != nil || !strings.Contains(ir.EmailAddress, "@") {
+		writeError(w, r, http.StatusBadRequest, "error.invalidImpersonationRequest")
+		return
+	}
+
+	d := DefaultImpersonationDuration
+	if ir.Minutes > 0 {
+		d = time.Duration(ir.Minutes) * time.Minute
+	}
+	if d > MaximumImpersonationDuration {
+		d = MaximumImpersonationDuration
+	}
+
+	impersonator, ok := handler.getSession(w, r).(Impersonator)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, "error.impersonationNotSupported")
+		return
+	}
+
+	e := audit.NewEntry(r.Context(), audit.ImpersonationStarted, dataaccess.GetDomain(ir.EmailAddress), ir.EmailAddress)
+	e.Details = fmt.Sprintf("for %v", d)
+	if err := handler.Log.Record(e); err != nil {
+		// Impersonation must not take place without being audited.
+		log.Print("Failed to record impersonation in the audit log. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.impersonationAuditFailed")
+		return
+	}
+
+	if err := impersonator.Impersonate(c.EmailAddress, ir.EmailAddress, d); err != nil {
+		log.Print("Failed to start impersonation. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.impersonationStartFailed")
+		return
+	}
+
+	log.Printf("User %s is impersonating %s for %v.", c.EmailAddress, ir.EmailAddress, d)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleImpersonationDelete(w http.ResponseWriter, r *http.Request, handler ImpersonationHandler, c caller.Caller) {
+	if c.Impersonator == "" {
+		writeError(w, r, http.StatusBadRequest, "error.notImpersonated")
+		return
+	}
+
+	impersonator, ok := handler.getSession(w, r).(Impersonator)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, "error.impersonationNotSupported")
+		return
+	}
+
+	impersonator.EndImpersonation()
+
+	e := audit.NewEntry(r.Context(), audit.ImpersonationEnded, c.Tenant, c.EmailAddress)
+	if err := handler.Log.Record(e); err != nil {
+		log.Print("Failed to record the end of impersonation in the audit log. ", err)
+	}
+
+	log.Printf("User %s has stopped impersonating %s.", c.Impersonator, c.EmailAddress)
+	w.WriteHeader(http.StatusNoContent)
+}
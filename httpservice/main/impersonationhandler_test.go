@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func newRequestWithCaller(method string, url string, body string, c caller.Caller) *http.Request {
+	r, _ := http.NewRequest(method, url, strings.NewReader(body))
+	return r.WithContext(caller.NewContext(context.Background(), c))
+}
+
+var testAdministrator = caller.Caller{
+	EmailAddress: "admin@github.com",
+	Roles:        []string{dataaccess.UserRole, dataaccess.AdministratorRole},
+	Tenant:       "github.com",
+}
+
+func TestThatAdministratorsCanImpersonateUsersForALimitedTime(t *testing.T) {
+	ms := &mockSession{}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	l := audit.NewMemoryLog()
+
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("POST", "http://example.com/admin/impersonate/", `{"emailAddress":"a-h@github.com","minutes":600}`, testAdministrator)
+
+	NewImpersonationHandler(sf, l).ServeHTTP(w, r)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, but was %d.", w.Code)
+	}
+
+	if ms.impersonating != "a-h@github.com" || ms.impersonationDuration != MaximumImpersonationDuration {
+		t.Errorf("Expected a-h@github.com to be impersonated for an hour, but was %s for %v.", ms.impersonating, ms.impersonationDuration)
+	}
+
+	entries, _ := l.List(audit.Query{})
+	if len(entries) != 1 || entries[0].Action != audit.ImpersonationStarted || entries[0].Actor != "admin@github.com" || entries[0].Target != "a-h@github.com" {
+		t.Errorf("Expected the start of impersonation to be audited, but received %v", entries)
+	}
+}
+
+func TestThatOnlyAdministratorsCanImpersonateUsers(t *testing.T) {
+	tests := []struct {
+		name   string
+		caller caller.Caller
+	}{
+		{"user", caller.Caller{EmailAddress: "someone@github.com", Roles: []string{dataaccess.UserRole}}},
+		{"administrator who is already impersonating", caller.Caller{EmailAddress: "someone@github.com", Roles: testAdministrator.Roles, Impersonator: "admin@github.com"}},
+	}
+
+	for _, test := range tests {
+		ms := &mockSession{}
+		sf := func(w http.ResponseWriter, r *http.Request) Session {
+			return ms
+		}
+
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "http://example.com/admin/impersonate/", `{"emailAddress":"a-h@github.com"}`, test.caller)
+
+		NewImpersonationHandler(sf, audit.NewMemoryLog()).ServeHTTP(w, r)
+
+		if w.Code != http.StatusForbidden || ms.impersonating != "" {
+			t.Errorf("For the '%s' test, impersonation should be forbidden, but the status was %d.", test.name, w.Code)
+		}
+	}
+}
+
+func TestThatEndingImpersonationIsAudited(t *testing.T) {
+	ms := &mockSession{}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	l := audit.NewMemoryLog()
+
+	impersonated := caller.Caller{EmailAddress: "a-h@github.com", Tenant: "github.com", Impersonator: "admin@github.com"}
+
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("DELETE", "http://example.com/admin/impersonate/", "", impersonated)
+
+	NewImpersonationHandler(sf, l).ServeHTTP(w, r)
+
+	if !ms.endImpersonationWasCalled {
+		t.Error("Impersonation should have been ended.")
+	}
+
+	entries, _ := l.List(audit.Query{Actor: "admin@github.com", Since: time.Now().Add(-time.Minute)})
+	if len(entries) != 1 || entries[0].Action != audit.ImpersonationEnded {
+		t.Errorf("Expected the end of impersonation to be audited, but received %v", entries)
+	}
+}
+
+func TestThatTheAuditLogIsOnlyAvailableToAdministrators(t *testing.T) {
+	l := audit.NewMemoryLog()
+	l.Record(audit.Entry{Time: time.Now(), Actor: "a-h@github.com", Action: audit.ProfileUpdated})
+
+	w := httptest.NewRecorder()
+	NewAuditHandler(l, "auditlog").ServeHTTP(w, newRequestWithCaller("GET", "http://example.com/admin/audit/", "", caller.Caller{EmailAddress: "a-h@github.com"}))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected users to be forbidden from reading the audit log, but the status was %d.", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	NewAuditHandler(l, "auditlog").ServeHTTP(w, newRequestWithCaller("GET", "http://example.com/admin/audit/?actor=a-h@github.com", "", testAdministrator))
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), audit.ProfileUpdated) {
+		t.Errorf("Expected administrators to be able to read the audit log, but received %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestThatTheAccessLogCanBeExportedAsCSV(t *testing.T) {
+	l := audit.NewMemoryLog()
+	l.Record(audit.Entry{Time: time.Date(2018, time.September, 3, 9, 0, 0, 0, time.UTC), Actor: "a-h@github.com", Tenant: "github.com", Action: audit.ProfileViewed, Target: "dev@github.com"})
+
+	w := httptest.NewRecorder()
+	NewAuditHandler(l, "accesslog").ServeHTTP(w, newRequestWithCaller("GET", "http://example.com/admin/accesslog/?format=csv", "", testAdministrator))
+
+	expected := "time,actor,impersonator,tenant,action,target,details\n2018-09-03T09:00:00Z,a-h@github.com,,github.com,profile.viewed,dev@github.com,\n"
+	if w.Code != http.StatusOK || w.Body.String() != expected || !strings.Contains(w.Header().Get("Content-Disposition"), "accesslog.csv") {
+		t.Errorf("Expected the access log as CSV, but received %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/importer"
+)
+
+// MaxImportSize is the largest file which can be imported over HTTP.
+const MaxImportSize = 32 << 20
+
+// The ImportPreviewHandler checks a skills export from another tool before it
+// is imported, e.g. POST /admin/import/preview/?format=skillsbase with the
+// CSV file as the body. It returns the problems with each row, and what
+// importing the file would create and update. If there are no problems, it
+// also returns the token which commits the import with the
+// ImportCommitHandler.
+type ImportPreviewHandler struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewImportPreviewHandler creates an instance of the ImportPreviewHandler.
+func NewImportPreviewHandler(da dataaccess.DataAccess) *ImportPreviewHandler {
+	return &ImportPreviewHandler{
+		DataAccess: da,
+		now:        time.Now,
+	}
+}
+
+// An importPreviewResponse is the result of checking an import.
+type importPreviewResponse struct {
+	importer.Diff
+	Errors []importer.RowError `json:"errors"`
+	// Token commits the import until it expires. It's empty if any of the
+	// rows have problems.
+	Token   string    `json:"token,omitempty"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+func (handler ImportPreviewHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling import preview request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyImports")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+	format := r.FormValue("format")
+	if _, ok := importer.Adapters[format]; !ok {
+		writeError(w, r, http.StatusBadRequest, "error.unknownImportFormat", format)
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(c.EmailAddress)
+
+	updates, problems, err := importer.ParseRows(format, http.MaxBytesReader(w, r.Body, MaxImportSize), domain)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := importPreviewResponse{Errors: problems}
+	if resp.Errors == nil {
+		resp.Errors = []importer.RowError{}
+	}
+	if resp.Diff, err = importer.NewImporter(da).Diff(updates); err != nil {
+		log.Print("Failed to compare the import with the existing profiles. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.importPreviewFailed")
+		return
+	}
+
+	if len(problems) == 0 {
+		p, token, err := dataaccess.NewImportPreview(domain, format, c.EmailAddress, updates, handler.now())
+		if err == nil {
+			err = da.SaveImportPreview(&p)
+		}
+		if err != nil {
+			log.Print("Failed to save the import preview. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.importPreviewFailed")
+			return
+		}
+		resp.Token, resp.Expires = token, p.Expires
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// The ImportCommitHandler imports a file which has been checked by the
+// ImportPreviewHandler, e.g. POST /admin/import/?token=abc. Each preview can
+// only be committed once.
+type ImportCommitHandler struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewImportCommitHandler creates an instance of the ImportCommitHandler.
+func NewImportCommitHandler(da dataaccess.DataAccess) *ImportCommitHandler {
+	return &ImportCommitHandler{
+		DataAccess: da,
+		now:        time.Now,
+	}
+}
+
+func (handler ImportCommitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling import commit request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyImports")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(c.EmailAddress)
+
+	p, found, err := da.TakeImportPreview(domain, dataaccess.ShareLinkID(r.FormValue("token")))
+	if err != nil {
+		log.Print("Failed to retrieve the import preview. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.importFailed")
+		return
+	}
+	if !found || p.Expired(handler.now()) {
+		writeError(w, r, http.StatusNotFound, "error.importPreviewNotFound")
+		return
+	}
+
+	result, err := importer.NewImporter(da).ImportUpdates(p.Updates)
+	if err != nil {
+		log.Print("Failed to import the previewed updates. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.importFailed")
+		return
+	}
+	log.Printf("User %s has imported a %s export into %s: %v.", c.EmailAddress, p.Format, domain, result)
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatImportsArePreviewedBeforeTheyAreCommitted(t *testing.T) {
+	var saved *dataaccess.ImportPreview
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{EmailAddress: emailAddress, Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}}, emailAddress == "a@github.com", nil
+		},
+		saveImportPreviewResponse: func(p *dataaccess.ImportPreview) error {
+			saved = p
+			return nil
+		},
+	}
+
+	tests := []struct {
+		body          string
+		caller        caller.Caller
+		expectedCode  int
+		expectedToken bool
+	}{
+		{"Email,Skill,Level\na@github.com,Go,3\nb@github.com,Go,2\n", testAdministrator, http.StatusOK, true},
+		{"Email,Skill,Level\na@github.com,Go,3\nb@example.com,Go,2\n", testAdministrator, http.StatusOK, false},
+		{"Name\nA\n", testAdministrator, http.StatusBadRequest, false},
+		{"Email,Skill,Level\na@github.com,Go,3\n", caller.Caller{EmailAddress: "dev@github.com"}, http.StatusForbidden, false},
+	}
+
+	for _, test := range tests {
+		saved = nil
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "/admin/import/preview/?format=skillsbase", test.body, test.caller)
+
+		NewImportPreviewHandler(mda).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %q, expected status %d, but got %d", test.body, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var resp importPreviewResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if (resp.Token != "") != test.expectedToken || (saved != nil) != test.expectedToken {
+			t.Errorf("For %q, expected a token %v, but got %q", test.body, test.expectedToken, resp.Token)
+		}
+		if test.expectedToken && (resp.NoOps != 1 || resp.Creates != 1 || saved.ID != dataaccess.ShareLinkID(resp.Token) || len(saved.Updates) != 2) {
+			t.Errorf("For %q, expected a no-op and a create to be saved, but got %+v", test.body, resp)
+		}
+		if !test.expectedToken && (len(resp.Errors) != 1 || resp.Errors[0].Line != 3) {
+			t.Errorf("For %q, expected a problem on line 3, but got %v", test.body, resp.Errors)
+		}
+	}
+}
+
+func TestThatPreviewedImportsCanBeCommitted(t *testing.T) {
+	now := time.Date(2017, time.July, 1, 0, 0, 0, 0, time.UTC)
+	var updated []string
+	mda := &mockDataAccess{
+		takeImportPreviewResponse: func(domain string, id string) (*dataaccess.ImportPreview, bool, error) {
+			p := &dataaccess.ImportPreview{
+				Domain:  domain,
+				Updates: []dataaccess.ProfileUpdate{{EmailAddress: "a@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}}},
+				Expires: now.Add(time.Minute),
+			}
+			if id == dataaccess.ShareLinkID("expired") {
+				p.Expires = now
+			}
+			return p, id != dataaccess.ShareLinkID("missing"), nil
+		},
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return nil, false, nil
+		},
+		updateProfileResponse: func(u *dataaccess.ProfileUpdate) (*dataaccess.Profile, error) {
+			updated = append(updated, u.EmailAddress)
+			return &dataaccess.Profile{}, nil
+		},
+		addSkillTagsResponse: func(tags []string) error {
+			return nil
+		},
+	}
+
+	tests := []struct {
+		token        string
+		expectedCode int
+	}{
+		{"valid", http.StatusOK},
+		{"expired", http.StatusNotFound},
+		{"missing", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		updated = nil
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "/admin/import/?token="+test.token, "", testAdministrator)
+
+		h := NewImportCommitHandler(mda)
+		h.now = func() time.Time { return now }
+		h.ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but got %d", test.token, test.expectedCode, w.Code)
+			continue
+		}
+		if imported := len(updated) == 1; imported != (w.Code == http.StatusOK) {
+			t.Errorf("For %s, expected the import only to be committed when it succeeds, but got %v", test.token, updated)
+		}
+	}
+}
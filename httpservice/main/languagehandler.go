@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The LanguageHandler reads and replaces the spoken languages on the user's
+// profile, e.g. [{"code":"de","level":"B2"}].
+type LanguageHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewLanguageHandler creates an instance of the LanguageHandler.
+func NewLanguageHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *LanguageHandler {
+	return &LanguageHandler{da, sessionFactory}
+}
+
+func (handler LanguageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling spoken language request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		profile, found, err := da.GetProfile(emailAddress)
+		if err != nil {
+			log.Printf("Failed to get the profile of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.languagesReadFailed")
+			return
+		}
+		if !found {
+			writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+			return
+		}
+		writeLanguages(w, profile.Languages)
+	case http.MethodPut:
+		var languages []dataaccess.Language
+		if err := json.NewDecoder(r.Body).Decode(&languages); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidLanguages")
+			return
+		}
+		languages = dataaccess.NormaliseLanguages(languages)
+		if err := dataaccess.ValidateLanguages(languages); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := da.UpdateLanguages(emailAddress, languages); err != nil {
+			log.Printf("Failed to update the languages of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.languagesSaveFailed")
+			return
+		}
+		writeLanguages(w, languages)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func writeLanguages(w http.ResponseWriter, languages []dataaccess.Language) {
+	if languages == nil {
+		languages = []dataaccess.Language{}
+	}
+	writeJSON(w, http.StatusOK, languages)
+}
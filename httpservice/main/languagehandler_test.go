@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatSpokenLanguagesCanBeUpdated(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	var saved []dataaccess.Language
+	mda := &mockDataAccess{
+		updateLanguagesResponse: func(emailAddress string, languages []dataaccess.Language) error {
+			saved = languages
+			return nil
+		},
+	}
+
+	tests := []struct {
+		body         string
+		expectedCode int
+		expected     []dataaccess.Language
+	}{
+		{`[{"code":"DE","level":"b2"},{"code":"en","level":"C2"}]`, http.StatusOK, []dataaccess.Language{{Code: "de", Level: dataaccess.CEFRB2}, {Code: "en", Level: dataaccess.CEFRC2}}},
+		{`[]`, http.StatusOK, []dataaccess.Language{}},
+		{`[{"code":"german","level":"B2"}]`, http.StatusBadRequest, nil},
+		{`[{"code":"de","level":"fluent"}]`, http.StatusBadRequest, nil},
+		{`{"code":"de"}`, http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		saved = nil
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("PUT", "http://example.com/profile/languages/", strings.NewReader(test.body))
+		NewLanguageHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.body, test.expectedCode, w.Code)
+			continue
+		}
+		if !reflect.DeepEqual(saved, test.expected) {
+			t.Errorf("For %s, expected %v to be saved, but was %v", test.body, test.expected, saved)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The WorkLocationHandler reads, replaces and removes where the user is
+// based, e.g. {"city":"Leeds","country":"GB","coordinates":{"latitude":53.8,"longitude":-1.55},"remote":"hybrid"}.
+type WorkLocationHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewWorkLocationHandler creates an instance of the WorkLocationHandler.
+func NewWorkLocationHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *WorkLocationHandler {
+	return &WorkLocationHandler{da, sessionFactory}
+}
+
+// The NearbyHandler lists the people in the user's domain who are based
+// within a radius of a point, nearest first, for staffing on-site
+// engagements, e.g. /report/nearby/?latitude=51.5&longitude=-0.12&radius=50.
+// The radius is in kilometres, and defaults to DefaultNearbyRadiusKm. Add
+// ?onSite=true to leave out people who would rather work remotely. The usual
+// department, cost center and language filters also apply.
+type NearbyHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewNearbyHandler creates an instance of the NearbyHandler.
+func NewNearbyHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *NearbyHandler {
+	return &NearbyHandler{da, sessionFactory}
+}
+
+// DefaultNearbyRadiusKm is the radius searched when a request doesn't have
+// one.
+const DefaultNearbyRadiusKm = 50
+
+// maxNearbyRadiusKm is half of the Earth's circumference.
+const maxNearbyRadiusKm = 20000
+
+type nearbyPerson struct {
+	EmailAddress string                  `json:"emailAddress"`
+	Name         string                  `json:"name,omitempty"`
+	Availability dataaccess.RagStatus    `json:"availability"`
+	WorkLocation dataaccess.WorkLocation `json:"workLocation"`
+	DistanceKm   float64                 `json:"distanceKm"`
+}
+
+func (handler WorkLocationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling work location request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		profile, found, err := da.GetProfile(emailAddress)
+		if err != nil {
+			log.Printf("Failed to get the profile of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.workLocationReadFailed")
+			return
+		}
+		if !found {
+			writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+			return
+		}
+		if profile.WorkLocation == nil {
+			writeError(w, r, http.StatusNotFound, "error.workLocationNotFound")
+			return
+		}
+		writeJSON(w, http.StatusOK, profile.WorkLocation)
+	case http.MethodPut:
+		var l dataaccess.WorkLocation
+		if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidWorkLocation")
+			return
+		}
+		l.City = strings.TrimSpace(l.City)
+		l.Country = strings.ToUpper(strings.TrimSpace(l.Country))
+		if err := l.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := da.UpdateWorkLocation(emailAddress, &l); err != nil {
+			log.Printf("Failed to update the work location of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.workLocationSaveFailed")
+			return
+		}
+		writeJSON(w, http.StatusOK, l)
+	case http.MethodDelete:
+		if err := da.UpdateWorkLocation(emailAddress, nil); err != nil {
+			log.Printf("Failed to remove the work location of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.workLocationSaveFailed")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func (handler NearbyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling nearby people request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	near, radius, ok := nearbyQuery(r)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "error.invalidNearbyQuery", maxNearbyRadiusKm)
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	profiles, err := da.FindProfilesNear(dataaccess.GetDomain(emailAddress), near, radius)
+	if err != nil {
+		log.Print("Unable to retrieve the list of nearby profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+
+	onSite := r.URL.Query().Get("onSite") == "true"
+	people := []nearbyPerson{}
+	for _, p := range profileFilter(r).Apply(profiles) {
+		if p.WorkLocation == nil || p.WorkLocation.Coordinates == nil {
+			continue
+		}
+		if onSite && p.WorkLocation.Remote == dataaccess.Remote {
+			continue
+		}
+		people = append(people, nearbyPerson{
+			EmailAddress: p.EmailAddress,
+			Name:         p.Name,
+			Availability: p.Availability,
+			WorkLocation: *p.WorkLocation,
+			DistanceKm:   near.DistanceKm(*p.WorkLocation.Coordinates),
+		})
+	}
+	sort.SliceStable(people, func(i, j int) bool { return people[i].DistanceKm < people[j].DistanceKm })
+
+	writeJSON(w, http.StatusOK, people)
+}
+
+// nearbyQuery reads the point and radius to search from the query string.
+func nearbyQuery(r *http.Request) (near dataaccess.Coordinates, radiusKm float64, ok bool) {
+	q := r.URL.Query()
+	lat, err := strconv.ParseFloat(q.Get("latitude"), 64)
+	if err != nil {
+		return near, 0, false
+	}
+	lng, err := strconv.ParseFloat(q.Get("longitude"), 64)
+	if err != nil {
+		return near, 0, false
+	}
+	near = dataaccess.Coordinates{Latitude: lat, Longitude: lng}
+	if err := (dataaccess.WorkLocation{Coordinates: &near}).Validate(); err != nil {
+		return near, 0, false
+	}
+	radiusKm = DefaultNearbyRadiusKm
+	if s := q.Get("radius"); s != "" {
+		if radiusKm, err = strconv.ParseFloat(s, 64); err != nil || radiusKm <= 0 || radiusKm > maxNearbyRadiusKm {
+			return near, 0, false
+		}
+	}
+	return near, radiusKm, true
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatNearbyPeopleAreListedNearestFirst(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	var radius float64
+	mda := &mockDataAccess{
+		findProfilesNearResponse: func(domain string, near dataaccess.Coordinates, radiusKm float64) ([]dataaccess.Profile, error) {
+			radius = radiusKm
+			return []dataaccess.Profile{
+				{EmailAddress: "reading@github.com", WorkLocation: &dataaccess.WorkLocation{City: "Reading", Coordinates: &dataaccess.Coordinates{Latitude: 51.45, Longitude: -0.97}, Remote: dataaccess.Hybrid}},
+				{EmailAddress: "london@github.com", WorkLocation: &dataaccess.WorkLocation{City: "London", Coordinates: &dataaccess.Coordinates{Latitude: 51.51, Longitude: -0.13}, Remote: dataaccess.OnSite}},
+				{EmailAddress: "remote@github.com", WorkLocation: &dataaccess.WorkLocation{City: "Watford", Coordinates: &dataaccess.Coordinates{Latitude: 51.66, Longitude: -0.4}, Remote: dataaccess.Remote}},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		url            string
+		expectedCode   int
+		expectedRadius float64
+		expected       []string
+	}{
+		{"/report/nearby/?latitude=51.5&longitude=-0.12", http.StatusOK, DefaultNearbyRadiusKm, []string{"london@github.com", "remote@github.com", "reading@github.com"}},
+		{"/report/nearby/?latitude=51.5&longitude=-0.12&radius=100&onSite=true", http.StatusOK, 100, []string{"london@github.com", "reading@github.com"}},
+		{"/report/nearby/?latitude=51.5", http.StatusBadRequest, 0, nil},
+		{"/report/nearby/?latitude=51.5&longitude=-0.12&radius=-1", http.StatusBadRequest, 0, nil},
+	}
+
+	for _, test := range tests {
+		radius = 0
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "http://example.com"+test.url, nil)
+		NewNearbyHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.url, test.expectedCode, w.Code)
+			continue
+		}
+		if test.expectedCode != http.StatusOK {
+			continue
+		}
+		var people []nearbyPerson
+		if err := json.NewDecoder(w.Body).Decode(&people); err != nil {
+			t.Fatal("Failed to decode the people.", err)
+		}
+		var actual []string
+		for _, p := range people {
+			actual = append(actual, p.EmailAddress)
+		}
+		if !reflect.DeepEqual(actual, test.expected) || radius != test.expectedRadius {
+			t.Errorf("For %s, expected %v within %vkm, but got %v within %vkm", test.url, test.expected, test.expectedRadius, actual, radius)
+		}
+	}
+}
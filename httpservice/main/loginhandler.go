@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/a-h/pill/middleware"
 	"github.com/a-h/pill/tokenverifier"
 )
 
@@ -38,7 +39,7 @@ func handleLoginGet(w http.ResponseWriter, r *http.Request, handler LoginHandler
 	}
 
 	log.Print("Rendering the login template.")
-	renderTemplate(w, "login.html", nil)
+	renderTemplate(w, "login.html", &loginModel{CSRFToken: middleware.CSRFToken(r)})
 }
 
 func handleLoginPost(w http.ResponseWriter, r *http.Request, handler LoginHandler) {
@@ -49,7 +50,7 @@ func handleLoginPost(w http.ResponseWriter, r *http.Request, handler LoginHandle
 
 	if err != nil {
 		log.Printf("The claim %s is invalid. With error message %s", idToken, err.Error())
-		http.Error(w, "The presented claim is invalid.", http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, "error.invalidClaim")
 		return
 	}
 
@@ -1,12 +1,56 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	// Profile time zones are loaded from the embedded database, so that they
+	// work in containers without tzdata.
+	_ "time/tzdata"
 
+	"github.com/a-h/pill/archival"
+	"github.com/a-h/pill/attachments"
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/autocomplete"
+	"github.com/a-h/pill/backup"
+	"github.com/a-h/pill/badges"
+	"github.com/a-h/pill/benchmark"
+	"github.com/a-h/pill/confluence"
+	"github.com/a-h/pill/crm"
 	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/decay"
+	"github.com/a-h/pill/digest"
+	"github.com/a-h/pill/doctor"
+	"github.com/a-h/pill/elasticsearch"
+	"github.com/a-h/pill/email"
+	"github.com/a-h/pill/encryption"
+	"github.com/a-h/pill/events"
+	"github.com/a-h/pill/export"
+	"github.com/a-h/pill/goals"
+	"github.com/a-h/pill/holidays"
+	"github.com/a-h/pill/hr"
+	"github.com/a-h/pill/jobs"
+	"github.com/a-h/pill/llm"
+	"github.com/a-h/pill/logredaction"
+	"github.com/a-h/pill/middleware"
+	"github.com/a-h/pill/nlquery"
+	"github.com/a-h/pill/notifications"
+	"github.com/a-h/pill/offboarding"
+	"github.com/a-h/pill/plugins"
+	"github.com/a-h/pill/profilerules"
+	"github.com/a-h/pill/readmodel"
+	"github.com/a-h/pill/reports"
+	"github.com/a-h/pill/resume"
+	"github.com/a-h/pill/semantic"
+	"github.com/a-h/pill/sessions"
+	"github.com/a-h/pill/summaries"
 	"github.com/a-h/pill/tokenverifier"
 	"github.com/gorilla/mux"
 )
@@ -14,15 +58,212 @@ import (
 var connectionString = flag.String("connectionString", "mongodb://mongo:27017",
 	"The MongoDB connection string used to store data.")
 
+var masterKeyFile = flag.String("masterKeyFile", "",
+	"The path to a file containing a base64 encoded 32 byte master key used to encrypt sensitive configuration values.")
+
+const databaseName = "pill"
+
+var logRedaction = flag.String("logRedaction", "none",
+	"How email addresses and other identifiers are redacted from the logs: none, mask (a***@example.com) or hash, where each is replaced by a hash keyed with the LOG_REDACTION_KEY environment variable, so that lines about the same person can still be found.")
+
+var logRedactionPattern = flag.String("logRedactionPattern", "",
+	"A regular expression of other identifiers to redact from the logs, e.g. \\bEMP-\\d+\\b for employee IDs.")
+
+var replicaConnectionString = flag.String("replicaConnectionString", "",
+	"The MongoDB connection string of the replica set, listing the members in this region first. If set, reads are served by the nearest member, and writes are sent to the primary.")
+
+var shards = flag.String("shards", "",
+	"A comma separated list of name=connectionString pairs of the MongoDB clusters tenants' profiles, and the other data about their people, such as their audit log entries, can be stored in. Tenants which haven't been moved with pillctl move-tenant are stored in the main database.")
+
+var shardRegions = flag.String("shardRegions", "",
+	"A comma separated list of name=region pairs of the regions the shards' clusters are in, e.g. default=US,eu=EU. Tenants whose data must stay in a region, set with pillctl residency, can only be served by shards in it.")
+
+var shardReplicas = flag.String("shardReplicas", "",
+	"A comma separated list of name=connectionString pairs of the replica sets of the shards, like -replicaConnectionString. Reads of the tenants on a shard with a replica set are served by its nearest member.")
+
+var profileCacheSize = flag.Int("profileCacheSize", 0,
+	"The number of recently read profiles to keep in memory, or 0 to disable the cache.")
+
+var eventSourcing = flag.Bool("eventSourcing", false,
+	"Record every change to people's skills, availability, bookings and details in an append-only event log, from which their profiles and history are derived.")
+
+var snapshotInterval = flag.Int("snapshotInterval", dataaccess.DefaultSnapshotInterval,
+	"The number of profile events between snapshots, when -eventSourcing is set.")
+
+var slowOperationThreshold = flag.Duration("slowOperationThreshold", dataaccess.DefaultSlowOperationThreshold,
+	"Database operations which take longer than this are logged, or 0 to disable logging.")
+
+var readOnly = flag.Bool("readOnly", false,
+	"Reject changes, e.g. during database maintenance. Administrators can also switch on the readOnly feature flag.")
+
+var breakerFailures = flag.Int("breakerFailures", dataaccess.DefaultBreakerFailures,
+	"The number of consecutive database connection failures after which requests fail fast, or 0 to disable the circuit breaker.")
+
+var breakerProbeInterval = flag.Duration("breakerProbeInterval", dataaccess.DefaultBreakerProbeInterval,
+	"How often to check whether the database has recovered while the circuit breaker is open.")
+
+var replicaLag = flag.Duration("replicaLag", dataaccess.DefaultReplicaLag,
+	"How long a user's reads are served by the primary after they make a change, so that they see their own changes.")
+
+var requestsPerSecond = flag.Float64("requestsPerSecond", 0,
+	"The number of requests per second each client IP address may make, or 0 to disable rate limiting. "+
+		"Clients are identified by the address they connect from, so don't set it behind a load balancer, where every request comes from the load balancer.")
+
+var requestBurst = flag.Int("requestBurst", 20,
+	"The number of requests each client IP address may make in a burst.")
+
+var diagnosticsAdministrators = flag.String("diagnosticsAdministrators", "",
+	"A comma separated list of the email addresses of the administrators who can read the runtime statistics and pprof profiles of the service at /admin/diagnostics/, and run its checks at /admin/doctor/. The endpoints are disabled if it's empty.")
+
+var apiSunset = flag.String("apiSunset", "",
+	"The date, e.g. 2018-06-30, after which the API will only be served under versioned paths such as /v1/profile/. It's sent in the Sunset header of requests to unversioned paths.")
+
+var sentryDSN = flag.String("sentryDSN", "",
+	"The Sentry DSN panics are reported to, e.g. https://key@o123.ingest.sentry.io/456. Panics are only logged if it's empty.")
+
+var sentryEnvironment = flag.String("sentryEnvironment", "production",
+	"The name of the environment panics are reported to Sentry under.")
+
+var smtpAddress = flag.String("smtpAddress", "",
+	"The host:port of the SMTP server used to send email. If empty, emails are logged instead of sent.")
+
+var emailFrom = flag.String("emailFrom", "pill@localhost",
+	"The address emails are sent from.")
+
+var slackToken = flag.String("slackToken", "",
+	"The OAuth token of the Slack bot used to send notifications. If empty, notifications are not sent to Slack.")
+
+var teamsWebhookURL = flag.String("teamsWebhookURL", "",
+	"The incoming webhook of the Microsoft Teams channel notifications are sent to. If empty, notifications are not sent to Teams.")
+
+var baseURL = flag.String("baseURL", "http://localhost:8080",
+	"The address of the website, used to create links in emails.")
+
+var anomalyWindow = flag.Duration("anomalyWindow", dataaccess.DefaultAnomalyThresholds().Window,
+	"The period each user's changes are counted over to detect anomalies.")
+
+var anomalyProfileEdits = flag.Int("anomalyProfileEdits", dataaccess.DefaultAnomalyThresholds().ProfileEdits,
+	"The number of other people's profiles a user may change within the anomaly window.")
+
+var anomalyDeletes = flag.Int("anomalyDeletes", dataaccess.DefaultAnomalyThresholds().Deletes,
+	"The number of profiles a user may delete within the anomaly window.")
+
+var approvalWindow = flag.Duration("approvalWindow", dataaccess.DefaultApprovalWindow,
+	"How long a destructive action waits for a second administrator's approval before it expires.")
+
+var quarantineAnomalies = flag.Bool("quarantineAnomalies", false,
+	"Hold changes which exceed the anomaly thresholds for review by an administrator, instead of only recording them in the audit log.")
+
+var accessLog = flag.Bool("accessLog", false,
+	"Record who views whose profile in the access log, which administrators can export from /admin/accesslog/.")
+
+var reportSnapshotMaxAge = flag.Duration("reportSnapshotMaxAge", 2*time.Hour,
+	"The oldest snapshot of a domain's department summary or heatmap which is served. Snapshots are computed hourly; 0 computes reports on each request.")
+
+var accessLogRetention = flag.Duration("accessLogRetention", 90*24*time.Hour,
+	"How long entries are kept in the access log.")
+
+var backupStore = flag.String("backupStore", "",
+	"The URL of the object store backups are written to, e.g. s3://bucket/pill. If empty, backups are not taken. Backups are encrypted with the master key.")
+
+var backupSchedule = flag.String("backupSchedule", "0 2 * * *",
+	"When backups are taken, as a cron expression or e.g. @every 6h.")
+
+var backupRetention = flag.Duration("backupRetention", backup.DefaultRetention,
+	"How long backups are kept for. The latest backup is always kept.")
+
+var attachmentStore = flag.String("attachmentStore", "gridfs:///"+attachments.DefaultPrefix,
+	"The URL of the store CVs uploaded to profiles are held in, e.g. gridfs:///attachments or s3://bucket/attachments. When profiles are sharded, GridFS files are held in each tenant's shard, and {shard} in an object store's URL is replaced by the name of the tenant's shard, e.g. s3://pill-{shard}/attachments.")
+
+var exportStore = flag.String("exportStore", "gridfs:///exports",
+	"The URL of the store files exported in the background are written to, e.g. gridfs:///exports or s3://bucket/exports. It is sharded in the same way as the -attachmentStore.")
+
+var exportRetention = flag.Duration("exportRetention", export.DefaultRetention,
+	"How long files exported in the background are kept for after they're written.")
+
+var embeddingURL = flag.String("embeddingURL", "",
+	"The OpenAI compatible embeddings API used to search for skills by meaning, e.g. https://api.openai.com/v1/embeddings. If empty, semantic search is disabled.")
+
+var embeddingModel = flag.String("embeddingModel", "text-embedding-3-small",
+	"The model the embeddings API embeds skills with.")
+
+var llmURL = flag.String("llmURL", "",
+	"The OpenAI compatible chat completions API used to answer questions about people, e.g. https://api.openai.com/v1/chat/completions. If empty, questions can't be asked.")
+
+var llmModel = flag.String("llmModel", "gpt-4o-mini",
+	"The model the chat completions API answers with.")
+
+var resumeParser = flag.String("resumeParser", "",
+	"How skills are found in uploaded CVs to suggest for profiles: local, or the URL of a resume parsing API. If empty, skills aren't suggested.")
+
+var crmSource = flag.String("crmSource", "",
+	"The CRM to draft projects from open opportunities in, e.g. salesforce://example.my.salesforce.com or hubspot://api.hubapi.com. If empty, projects are not drafted.")
+
+var crmDomain = flag.String("crmDomain", "",
+	"The tenant whose projects are drafted from the CRM's opportunities.")
+
+var crmSchedule = flag.String("crmSchedule", "0 * * * *",
+	"When projects are drafted from the CRM, as a cron expression or e.g. @every 6h.")
+
+var hrSource = flag.String("hrSource", "",
+	"The HR system to sync employees from, e.g. bamboohr://example or workday://wd5-services1.myworkday.com/ccx/service/customreport2/example/isu/pill_employees. If empty, employees are not synced.")
+
+var hrDomains = flag.String("hrDomains", "",
+	"The comma separated tenants whose profiles are synced from the HR system, e.g. example.com,example.co.uk.")
+
+var hrFields = flag.String("hrFields", "",
+	"Overrides of the HR system's field names, e.g. department=division,manager=supervisorEmail.")
+
+var hrSchedule = flag.String("hrSchedule", "0 5 * * *",
+	"When employees are synced from the HR system, as a cron expression or e.g. @every 6h.")
+
+var ptoSource = flag.String("ptoSource", "",
+	"The PTO system to import approved leave from, e.g. bamboohr://example or the https address of an iCalendar feed. If empty, leave is not imported.")
+
+var ptoDomains = flag.String("ptoDomains", "",
+	"The comma separated tenants whose leave is imported, e.g. example.com,example.co.uk.")
+
+var ptoSchedule = flag.String("ptoSchedule", "30 * * * *",
+	"When leave is imported from the PTO system, as a cron expression or e.g. @every 6h.")
+
+var holidaySource = flag.String("holidaySource", "",
+	"The public holiday calendar to import, nager for the Nager.Date API, or an https address of iCalendar feeds containing {country}. If empty, holidays are not imported.")
+
+var holidaySchedule = flag.String("holidaySchedule", "0 4 * * *",
+	"When public holidays are imported, as a cron expression or e.g. @every 24h.")
+
+var elasticsearchURL = flag.String("elasticsearchURL", "",
+	"The Elasticsearch or OpenSearch cluster to index search documents in, e.g. http://elasticsearch:9200. If empty, searches read MongoDB.")
+
+var elasticsearchIndex = flag.String("elasticsearchIndex", "pill",
+	"The name of the Elasticsearch index search documents are kept in.")
+
+var confluenceURL = flag.String("confluenceURL", "",
+	"The Confluence wiki to publish team skills pages to, e.g. https://example.atlassian.net/wiki. If empty, pages are not published.")
+
+var confluenceSpace = flag.String("confluenceSpace", "",
+	"The key of the Confluence space team skills pages are published to.")
+
+var confluenceParent = flag.String("confluenceParent", "",
+	"The ID of the Confluence page new team skills pages are created under. If empty, they're created at the top of the space.")
+
+var confluenceDomain = flag.String("confluenceDomain", "",
+	"The tenant whose team skills are published to Confluence.")
+
+var confluenceSchedule = flag.String("confluenceSchedule", "0 6 * * *",
+	"When team skills pages are published to Confluence, as a cron expression or e.g. @every 6h.")
+
 func main() {
-	log.Print("Starting up...")
 	flag.Parse()
+	redactLogs()
+	log.Print("Starting up...")
+	checkDeployment()
 
 	log.Print("Connecting to MongoDB to retrieve configuration.")
-	da := dataaccess.NewMongoDataAccess(*connectionString, "pill")
+	da, breakers := createDataAccess()
 
-	var err error
-	configuration, err = da.GetOrCreateConfiguration()
+	configuration = NewConfigurationCache(da)
+	err := configuration.Refresh()
 
 	if err != nil {
 		log.Fatal("Failed to retrieve configuration, the application cannot start. ", err)
@@ -30,27 +271,810 @@ func main() {
 
 	log.Print("Configuration retrieved.")
 
+	// Indexes are created in the background by MongoDB, so this doesn't hold
+	// up the start for long, and a failure only slows down queries.
+	if err := da.EnsureIndexes(); err != nil {
+		log.Print("Failed to create the database indexes. ", err)
+	}
+
+	da = dataaccess.NewReadOnlyDataAccess(da, func() bool {
+		return *readOnly || configuration.Get().IsEnabled(dataaccess.ReadOnlyFeatureFlag) || breakers.Open()
+	})
+
+	if *elasticsearchURL != "" {
+		da = createIndexingDataAccess(da)
+	}
+
+	da = attachments.NewDeletingDataAccess(da, openStore(*attachmentStore, "attachment"))
+
+	// Tenants' own rules are applied like any other plugin.
+	plugins.Register("profilerules", profilerules.NewHook(da))
+	log.Printf("Using the plugins: %s.", strings.Join(plugins.DefaultRegistry.Names(), ", "))
+	da = plugins.NewHookingDataAccess(da, plugins.DefaultRegistry)
+
+	hub := NewHub()
+	completions := autocomplete.NewIndex(da)
+	da = dataaccess.NewNotifyingDataAccess(da, events.Publishers{badges.NewAwarder(da), goals.NewTracker(da), readmodel.NewProjector(da), completions, hub, plugins.DefaultRegistry.Publishers()})
+
+	auditLog := openLog(audit.NewMongoLog)
+	da = dataaccess.NewAuditingDataAccess(da, auditLog)
+
+	anomalies := dataaccess.NewAnomalyDetector(dataaccess.AnomalyThresholds{
+		Window:       *anomalyWindow,
+		ProfileEdits: *anomalyProfileEdits,
+		Deletes:      *anomalyDeletes,
+	})
+	da = dataaccess.NewQuarantiningDataAccess(da, anomalies, auditLog, *quarantineAnomalies)
+	da = dataaccess.NewApprovingDataAccess(da)
+	da = dataaccess.NewRedactingDataAccess(da, auditLog)
+
+	var accessLogger prunableLog
+	if *accessLog {
+		accessLogger = openLog(audit.NewMongoAccessLog)
+		da = dataaccess.NewAccessLoggingDataAccess(da, accessLogger)
+	}
+
 	log.Print("Creating routes...")
-	r := createRoutes(da)
+	metrics := middleware.NewMetrics()
+	skillIndex := createSemanticIndex(da)
+	provider := createLLMProvider()
+	r := createRoutes(da, hub, completions, skillIndex, provider, auditLog, accessLogger, metrics)
+
+	scheduler := jobs.NewScheduler(jobs.NewDataAccessLocker(da),
+		jobs.NewMongoHistory(*connectionString, databaseName))
+	scheduler.AddJob(&jobs.Job{
+		Name: "digest",
+		// Every day at 8am, weekly digests are sent on Mondays.
+		Schedule: jobs.MustParseSchedule("0 8 * * *"),
+		Run:      digest.NewJob(da, createNotifier(), *baseURL).Run,
+	})
+	scheduler.AddJob(&jobs.Job{
+		Name:     "goalreminders",
+		Schedule: jobs.MustParseSchedule("0 9 * * *"),
+		Run:      goals.NewReminderJob(da, createNotifier(), *baseURL).Run,
+	})
+	scheduler.AddJob(&jobs.Job{
+		Name:     "stalereminders",
+		Schedule: jobs.MustParseSchedule("30 9 * * *"),
+		Run:      decay.NewJob(da, createNotifier(), *baseURL).Run,
+	})
+	scheduler.AddJob(&jobs.Job{
+		Name:     "offboarding",
+		Schedule: jobs.MustParseSchedule("0 4 * * *"),
+		Run:      offboarding.NewJob(da, createNotifier(), *baseURL).Run,
+	})
+	scheduler.AddJob(&jobs.Job{
+		Name:     "archival",
+		Schedule: jobs.MustParseSchedule("30 4 * * *"),
+		Run:      archival.NewJob(da).Run,
+	})
+	scheduler.AddJob(&jobs.Job{
+		Name: "readmodels",
+		// Picks up the changes which don't raise events, e.g. bulk imports.
+		Schedule: jobs.MustParseSchedule("30 3 * * *"),
+		Run:      readmodel.NewJob(da).Run,
+	})
+	scheduler.AddJob(&jobs.Job{
+		Name:     "benchmarks",
+		Schedule: jobs.MustParseSchedule("0 5 * * *"),
+		Run:      benchmark.NewJob(da).Run,
+	})
+	scheduler.AddJob(&jobs.Job{
+		Name: "reports",
+		// Every day at 7am, weekly reports are sent on Mondays.
+		Schedule: jobs.MustParseSchedule("0 7 * * *"),
+		Run:      reports.NewJob(da, createNotifier(), *baseURL).Run,
+	})
+	scheduler.AddJob(&jobs.Job{
+		Name:     "purgeskilltrash",
+		Schedule: jobs.MustParseSchedule("0 3 * * *"),
+		Run:      purgeSkillTrash(da),
+	})
+	exports := export.NewRunner(da, openStore(*exportStore, "export"), *exportRetention)
+	scheduler.AddJob(&jobs.Job{
+		Name:     "exports",
+		Schedule: jobs.MustParseSchedule("@every 10s"),
+		Run:      exports.Run,
+	})
+	scheduler.AddJob(&jobs.Job{
+		Name:     "purgeexports",
+		Schedule: jobs.MustParseSchedule("45 * * * *"),
+		Run:      exports.Purge,
+	})
+	if *reportSnapshotMaxAge > 0 {
+		scheduler.AddJob(&jobs.Job{
+			Name:     "materializereports",
+			Schedule: jobs.MustParseSchedule("15 * * * *"),
+			Run:      materializeReports(da),
+		})
+	}
+
+	if provider != nil {
+		scheduler.AddJob(&jobs.Job{
+			Name: "profilesummaries",
+			// Only tenants which enable summaries are sent to the model.
+			Schedule: jobs.MustParseSchedule("0 * * * *"),
+			Run:      summaries.NewJob(da, provider).Run,
+		})
+	}
+
+	if accessLogger != nil {
+		scheduler.AddJob(&jobs.Job{
+			Name:     "pruneaccesslog",
+			Schedule: jobs.MustParseSchedule("30 3 * * *"),
+			Run:      pruneLog(accessLogger, *accessLogRetention),
+		})
+	}
+
+	if *backupStore != "" {
+		scheduler.AddJob(createBackupJob())
+	}
+
+	if *crmSource != "" {
+		scheduler.AddJob(createCRMJob(da))
+	}
+
+	if *hrSource != "" {
+		scheduler.AddJob(createHRJob(da))
+	}
+
+	if *ptoSource != "" {
+		scheduler.AddJob(createPTOJob(da))
+	}
+
+	if *holidaySource != "" {
+		scheduler.AddJob(createHolidayJob(da))
+	}
+
+	if *confluenceURL != "" {
+		scheduler.AddJob(createConfluenceJob(da))
+	}
+
+	metrics.Route = middleware.RouteTemplate(r)
+	app := NewApplication(":8080", createMiddleware(r, metrics))
+	app.Tasks = append(app.Tasks, scheduler.Run, configuration.Watch, completions.Watch)
+	if skillIndex != nil {
+		app.Tasks = append(app.Tasks, skillIndex.Watch)
+	}
+	// Hijacked WebSocket connections are not closed by the server's shutdown.
+	app.Server.RegisterOnShutdown(hub.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
 
 	log.Print("Serving...")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	if err := app.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// deployment is the databases and master key the service is configured with.
+func deployment() dataaccess.Deployment {
+	return dataaccess.Deployment{
+		ConnectionString:        *connectionString,
+		ReplicaConnectionString: *replicaConnectionString,
+		DatabaseName:            databaseName,
+		Shards:                  *shards,
+		MasterKeyFile:           *masterKeyFile,
+	}
+}
+
+// checkDeployment logs the problems the doctor finds, with their remedies,
+// so that a service which fails to start says why.
+func checkDeployment() {
+	for _, r := range deployment().Check() {
+		if r.Status != doctor.OK {
+			log.Printf("Startup check of the %s: %s %s To fix: %s", r.Check, r.Status, r.Problem, r.Remedy)
+		}
+	}
+}
+
+// redactLogs redacts identifiers from everything written to the log.
+func redactLogs() {
+	mode, err := logredaction.ParseMode(*logRedaction)
+	if err != nil {
+		log.Fatal("The log redaction mode is invalid, the application cannot start. ", err)
+	}
+	if mode == logredaction.None {
+		return
+	}
+	r, err := logredaction.New(mode, []byte(os.Getenv("LOG_REDACTION_KEY")), *logRedactionPattern)
+	if err != nil {
+		log.Fatal("Failed to configure log redaction, the application cannot start. ", err)
+	}
+	log.SetOutput(logredaction.NewWriter(os.Stderr, r))
+}
+
+// breakers are the circuit breakers of the main cluster and the shards.
+type breakers []*dataaccess.CircuitBreakingDataAccess
+
+// Open returns true if any of the clusters is unavailable.
+func (b breakers) Open() bool {
+	for _, breaker := range b {
+		if breaker.Open() {
+			return true
+		}
+	}
+	return false
+}
+
+// createDataAccess connects to MongoDB. There are no breakers if the circuit
+// breaker is disabled.
+func createDataAccess() (dataaccess.DataAccess, breakers) {
+	var kp encryption.KeyProvider
+	var da dataaccess.DataAccess
+	if *masterKeyFile == "" {
+		log.Print("No master key file has been provided, configuration values will not be encrypted.")
+		da = dataaccess.NewMongoDataAccess(*connectionString, databaseName)
+	} else {
+		var err error
+		if kp, err = encryption.LoadKeyFile(*masterKeyFile); err != nil {
+			log.Fatal("Failed to load the master key, the application cannot start. ", err)
+		}
+		da = dataaccess.NewEncryptedMongoDataAccess(*connectionString, databaseName, kp)
+	}
+
+	if *replicaConnectionString != "" {
+		log.Print("Reads will be served by the nearest member of the replica set.")
+	}
+	da, breaker := createCluster(da, *replicaConnectionString, kp)
+	var b breakers
+	if breaker != nil {
+		b = append(b, breaker)
+	}
+
+	if *shards != "" {
+		var shardBreakers breakers
+		da, shardBreakers = createShardedDataAccess(da, kp)
+		b = append(b, shardBreakers...)
+	}
+
+	if *slowOperationThreshold > 0 {
+		da = dataaccess.NewSlowLoggingDataAccess(da, *slowOperationThreshold)
+	}
+
+	if *eventSourcing {
+		log.Print("Changes to profiles are recorded in the event log.")
+		da = dataaccess.NewEventSourcingDataAccess(da, *snapshotInterval)
+	}
+
+	if *profileCacheSize > 0 {
+		da = dataaccess.NewCachingDataAccess(da, *profileCacheSize, dataaccess.DefaultProfileCacheTTL)
+	}
+	return da, b
+}
+
+// createCluster protects the cluster with a circuit breaker, and serves its
+// reads from the replica set, if there is one. The breaker is nil if it is
+// disabled.
+func createCluster(da dataaccess.DataAccess, replicaConnectionString string, kp encryption.KeyProvider) (dataaccess.DataAccess, *dataaccess.CircuitBreakingDataAccess) {
+	// The breaker only protects the primary, so that reads continue from the
+	// replica while the primary is unavailable.
+	var breaker *dataaccess.CircuitBreakingDataAccess
+	if *breakerFailures > 0 {
+		breaker = dataaccess.NewCircuitBreakingDataAccess(da, *breakerFailures, *breakerProbeInterval)
+		da = breaker
+	}
+
+	if replicaConnectionString != "" {
+		replica := dataaccess.NewReplicaMongoDataAccess(replicaConnectionString, databaseName, kp)
+		da = dataaccess.NewRoutingDataAccess(da, replica, *replicaLag)
+	}
+	return da, breaker
+}
+
+// tenantShards routes tenants' data to their shards, or is nil if profiles
+// aren't sharded.
+var tenantShards *dataaccess.ShardedDataAccess
+
+// parseShards returns the connection strings of the shards, keyed by their
+// names.
+func parseShards() map[string]string {
+	spec, err := dataaccess.ParseShards(*shards)
+	if err != nil {
+		log.Fatal("The shards are invalid, the application cannot start. ", err)
+	}
+	return spec
+}
+
+// createShardedDataAccess routes tenants' data to their shards, each of
+// which is protected by a circuit breaker and served by its replica set, like
+// the main cluster.
+func createShardedDataAccess(da dataaccess.DataAccess, kp encryption.KeyProvider) (dataaccess.DataAccess, breakers) {
+	replicas, err := dataaccess.ParseShards(*shardReplicas)
+	if err != nil {
+		log.Fatal("The shard replicas are invalid, the application cannot start. ", err)
+	}
+	spec := parseShards()
+	for name := range replicas {
+		if _, ok := spec[name]; !ok {
+			log.Fatalf("The shard replicas are invalid, the application cannot start. %v: %s", dataaccess.ErrUnknownShard, name)
+		}
+	}
+	clusters := map[string]dataaccess.DataAccess{}
+	var b breakers
+	for name, cs := range spec {
+		var breaker *dataaccess.CircuitBreakingDataAccess
+		clusters[name], breaker = createCluster(dataaccess.NewEncryptedMongoDataAccess(cs, databaseName, kp), replicas[name], kp)
+		if breaker != nil {
+			b = append(b, breaker)
+		}
+	}
+	regions, err := dataaccess.ParseShardRegions(*shardRegions)
+	if err != nil {
+		log.Fatal("The shard regions are invalid, the application cannot start. ", err)
+	}
+	log.Printf("Tenants' profiles are sharded across %d clusters.", len(clusters)+1)
+	directory := dataaccess.NewMongoShardDirectory(*connectionString, databaseName)
+	sharded := dataaccess.NewShardedDataAccess(da, clusters, directory, dataaccess.DefaultShardDirectoryTTL)
+	sharded.Regions = regions
+	tenantShards = sharded
+	return sharded, b
+}
+
+func createNotifier() *notifications.Notifier {
+	var sender email.Sender = email.LogSender{}
+	if *smtpAddress == "" {
+		log.Print("No SMTP server has been provided, emails will be logged instead of sent.")
+	} else {
+		sender = email.NewSMTPSender(*smtpAddress, *emailFrom)
+	}
+
+	channels := map[dataaccess.NotificationChannel]notifications.Channel{
+		dataaccess.EmailChannel: notifications.EmailChannel{Sender: sender},
+	}
+	if *slackToken != "" {
+		channels[dataaccess.SlackChannel] = notifications.NewSlackChannel(*slackToken)
+	}
+	if *teamsWebhookURL != "" {
+		channels[dataaccess.TeamsChannel] = notifications.NewTeamsChannel(*teamsWebhookURL)
+	}
+
+	return notifications.NewNotifier(channels)
+}
+
+func createBackupJob() *jobs.Job {
+	if *masterKeyFile == "" {
+		log.Fatal("Backups are encrypted with the master key, but no master key file has been provided.")
+	}
+	kp, err := encryption.LoadKeyFile(*masterKeyFile)
+	if err != nil {
+		log.Fatal("Failed to load the master key, the application cannot start. ", err)
+	}
+
+	store, err := backup.OpenStore(*backupStore)
+	if err != nil {
+		log.Fatal("Failed to open the backup store. ", err)
+	}
+
+	schedule, err := jobs.ParseSchedule(*backupSchedule)
+	if err != nil {
+		log.Fatal("The backup schedule is invalid. ", err)
+	}
+
+	// Every shard is backed up with the main database, so that restoring a
+	// backup restores every tenant's profiles.
+	b := backup.NewBackuper(backup.NewShardedMongoDatabase(*connectionString, databaseName, parseShards()), store, kp, *backupRetention)
+	return &jobs.Job{
+		Name:     "backup",
+		Schedule: schedule,
+		Run: func(ctx context.Context) error {
+			_, err := b.Backup()
+			return err
+		},
+	}
+}
+
+func createCRMJob(da dataaccess.DataAccess) *jobs.Job {
+	if *crmDomain == "" {
+		log.Fatal("Projects are drafted from the CRM for a tenant, but no CRM domain has been provided.")
+	}
+
+	source, err := crm.OpenSource(*crmSource)
+	if err != nil {
+		log.Fatal("Failed to open the CRM. ", err)
+	}
+
+	schedule, err := jobs.ParseSchedule(*crmSchedule)
+	if err != nil {
+		log.Fatal("The CRM schedule is invalid. ", err)
+	}
+
+	return &jobs.Job{
+		Name:     "crm",
+		Schedule: schedule,
+		Run:      crm.NewSyncer(da, source, *crmDomain).Run,
+	}
+}
+
+func createHRJob(da dataaccess.DataAccess) *jobs.Job {
+	if *hrDomains == "" {
+		log.Fatal("Employees are synced from the HR system, but no HR domains have been provided.")
+	}
+
+	source, err := hr.OpenSource(*hrSource)
+	if err != nil {
+		log.Fatal("Failed to open the HR system. ", err)
+	}
+	mapping, err := hr.ParseMapping(*hrFields, source.DefaultMapping())
+	if err != nil {
+		log.Fatal("The HR field mapping is invalid. ", err)
+	}
+
+	schedule, err := jobs.ParseSchedule(*hrSchedule)
+	if err != nil {
+		log.Fatal("The HR schedule is invalid. ", err)
+	}
+
+	return &jobs.Job{
+		Name:     "hr",
+		Schedule: schedule,
+		Run:      hr.NewSyncer(da, source, mapping, strings.Split(*hrDomains, ",")).Run,
+	}
+}
+
+func createPTOJob(da dataaccess.DataAccess) *jobs.Job {
+	if *ptoDomains == "" {
+		log.Fatal("Leave is imported from the PTO system, but no PTO domains have been provided.")
+	}
+
+	source, err := hr.OpenLeaveSource(*ptoSource)
+	if err != nil {
+		log.Fatal("Failed to open the PTO system. ", err)
+	}
+
+	schedule, err := jobs.ParseSchedule(*ptoSchedule)
+	if err != nil {
+		log.Fatal("The PTO schedule is invalid. ", err)
+	}
+
+	return &jobs.Job{
+		Name:     "pto",
+		Schedule: schedule,
+		Run:      hr.NewLeaveImporter(da, source, strings.Split(*ptoDomains, ",")).Run,
+	}
+}
+
+// createHolidayJob marks people as unavailable on the public holidays of the
+// countries they work in.
+func createHolidayJob(da dataaccess.DataAccess) *jobs.Job {
+	source, err := holidays.OpenSource(*holidaySource)
+	if err != nil {
+		log.Fatal("Failed to open the holiday calendar. ", err)
+	}
+
+	schedule, err := jobs.ParseSchedule(*holidaySchedule)
+	if err != nil {
+		log.Fatal("The holiday schedule is invalid. ", err)
+	}
+
+	return &jobs.Job{
+		Name:     "holidays",
+		Schedule: schedule,
+		Run:      holidays.NewImporter(da, source).Run,
+	}
+}
+
+// createIndexingDataAccess mirrors search documents into Elasticsearch. If
+// the index doesn't exist, it's created and filled from the read models. The
+// credentials are read from the ELASTICSEARCH_USER and ELASTICSEARCH_PASSWORD
+// environment variables.
+func createIndexingDataAccess(da dataaccess.DataAccess) dataaccess.DataAccess {
+	client := elasticsearch.NewClient(*elasticsearchURL, *elasticsearchIndex, os.Getenv("ELASTICSEARCH_USER"), os.Getenv("ELASTICSEARCH_PASSWORD"))
+	ida := elasticsearch.NewIndexingDataAccess(da, client)
+	created, err := client.EnsureIndex(context.Background())
+	if err != nil {
+		// Searches read MongoDB until the index is available.
+		log.Print("Failed to create the Elasticsearch index. ", err)
+	}
+	if created {
+		log.Printf("Created the Elasticsearch index %s, filling it from the read models.", *elasticsearchIndex)
+		go func() {
+			if err := readmodel.NewJob(ida).Run(context.Background()); err != nil {
+				log.Print("Failed to fill the Elasticsearch index. ", err)
+			}
+		}()
+	}
+	return ida
+}
+
+// createConfluenceJob publishes team skills pages. The credentials are read
+// from the CONFLUENCE_USER and CONFLUENCE_API_TOKEN environment variables.
+func createConfluenceJob(da dataaccess.DataAccess) *jobs.Job {
+	if *confluenceDomain == "" || *confluenceSpace == "" {
+		log.Fatal("Team skills are published to Confluence, but no Confluence domain or space has been provided.")
+	}
+
+	schedule, err := jobs.ParseSchedule(*confluenceSchedule)
+	if err != nil {
+		log.Fatal("The Confluence schedule is invalid. ", err)
+	}
+
+	client := confluence.NewClient(strings.TrimSuffix(*confluenceURL, "/"), os.Getenv("CONFLUENCE_USER"), os.Getenv("CONFLUENCE_API_TOKEN"))
+	return &jobs.Job{
+		Name:     "confluence",
+		Schedule: schedule,
+		Run:      confluence.NewPublisher(da, client, *confluenceSpace, *confluenceParent, *confluenceDomain).Run,
+	}
+}
+
+func createSemanticIndex(da dataaccess.DataAccess) *semantic.Index {
+	if *embeddingURL == "" {
+		return nil
+	}
+	e, err := semantic.OpenEmbedder(*embeddingURL, *embeddingModel)
+	if err != nil {
+		log.Fatal("Failed to create the embedder. ", err)
+	}
+	return semantic.NewIndex(da, e)
+}
+
+func createLLMProvider() llm.Provider {
+	if *llmURL == "" {
+		return nil
+	}
+	p, err := llm.Open(*llmURL, *llmModel)
+	if err != nil {
+		log.Fatal("Failed to create the language model provider. ", err)
+	}
+	return p
+}
+
+func createResumeParser() resume.Parser {
+	if *resumeParser == "" {
+		return nil
+	}
+	p, err := resume.OpenParser(*resumeParser)
+	if err != nil {
+		log.Fatal("Failed to create the resume parser. ", err)
+	}
+	return p
+}
+
+// purgeSkillTrash permanently removes skill tags which have been in the
+// trash for longer than the retention period.
+func purgeSkillTrash(da dataaccess.DataAccess) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		n, err := dataaccess.WithContext(da, ctx).PurgeDeletedSkillTags(time.Now().Add(-dataaccess.SkillTagRetention))
+		if err == nil {
+			log.Printf("Purged %d skill tags from the trash.", n)
+		}
+		return err
+	}
+}
+
+// pruneLog removes the entries older than the retention period from the log.
+func pruneLog(l audit.Pruner, retention time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		n, err := l.Prune(time.Now().Add(-retention))
+		if err == nil {
+			log.Printf("Pruned %d entries from the access log.", n)
+		}
+		return err
+	}
+}
+
+// createVersioning serves the API under /v1/. The pages, static files and
+// endpoints used by other services keep their unversioned paths.
+func createVersioning() middleware.Versioning {
+	v := middleware.Versioning{
+		Supported:   []int{1},
+		Default:     1,
+		Unversioned: []string{"/", "/static/", "/.well-known/", "/metrics/", "/ws/", "/shared/", "/oembed/"},
+	}
+	if *apiSunset != "" {
+		sunset, err := time.Parse("2006-01-02", *apiSunset)
+		if err != nil {
+			log.Fatal("The API sunset date is invalid, the application cannot start. ", err)
+		}
+		v.Sunset = sunset
+	}
+	return v
+}
+
+func createMiddleware(h http.Handler, metrics *middleware.Metrics) http.Handler {
+	recovery := middleware.Recover
+	if *sentryDSN != "" {
+		reporter, err := middleware.NewSentryReporter(*sentryDSN, *sentryEnvironment)
+		if err != nil {
+			log.Fatal("The Sentry DSN is invalid, the application cannot start. ", err)
+		}
+		recovery = middleware.RecoverAndReport(reporter)
+	}
+	m := []middleware.Middleware{recovery, middleware.Log, metrics.Handler}
+
+	if *requestsPerSecond > 0 {
+		m = append(m, middleware.NewRateLimiter(*requestsPerSecond, *requestBurst).Handler)
+	}
+
+	m = append(m, createVersioning().Handler, csrfProtection, identifyCaller)
+
+	return middleware.Chain(h, m...)
+}
+
+// csrfProtection signs CSRF tokens with the session encryption keys, so that
+// tokens are replaced when the keys are rotated. Requests with a valid bearer
+// token are from API clients rather than a browser, so they don't need a CSRF
+// token, and couldn't get one.
+func csrfProtection(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := configuration.Get()
+		if _, ok := identifyBearer(c, r); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		csrf := middleware.NewCSRF(c.SessionEncryptionKey, c.PreviousSessionEncryptionKeys...)
+		csrf.SetSecureFlag = c.SetSecureFlag
+		csrf.Handler(next).ServeHTTP(w, r)
+	})
+}
+
+// openStore opens the store at the URL, e.g. the store export jobs write
+// their files to. When profiles are sharded, each tenant's files are kept in
+// the store of its shard.
+func openStore(rawurl string, name string) attachments.Store {
+	if tenantShards == nil {
+		store, err := attachments.OpenStore(rawurl, *connectionString, databaseName)
+		if err != nil {
+			log.Fatalf("Failed to open the %s store. %v", name, err)
+		}
+		return store
+	}
+	clusters := parseShards()
+	clusters[dataaccess.DefaultShard] = *connectionString
+	stores, err := attachments.OpenShardStores(rawurl, clusters, databaseName)
+	if err != nil {
+		log.Fatalf("Failed to open the %s store. %v", name, err)
+	}
+	if !strings.HasPrefix(rawurl, "gridfs:") && !strings.Contains(rawurl, attachments.ShardPlaceholder) {
+		log.Printf("The %s store doesn't contain %s, so every shard's files are kept in the same store.", name, attachments.ShardPlaceholder)
+	}
+	return attachments.NewShardedStore(stores, tenantShards.ShardOf)
 }
 
-func createRoutes(da dataaccess.DataAccess) *mux.Router {
+// A prunableLog is an audit log whose old entries can be removed.
+type prunableLog interface {
+	audit.Log
+	audit.Pruner
+}
+
+// openLog opens a log in the main database. When profiles are sharded, each
+// tenant's entries are kept in a log in the cluster of its shard.
+func openLog(open func(connectionString string, databaseName string) *audit.MongoLog) prunableLog {
+	main := open(*connectionString, databaseName)
+	if tenantShards == nil {
+		return main
+	}
+	logs := map[string]audit.Log{}
+	for name, cs := range parseShards() {
+		logs[name] = open(cs, databaseName)
+	}
+	return audit.NewShardedLog(main, logs, tenantShards.ShardOf)
+}
+
+// createRoutes creates the routes. The access log is nil if it is
+// disabled.
+func createRoutes(da dataaccess.DataAccess, hub *Hub, completions *autocomplete.Index, skillIndex *semantic.Index, provider llm.Provider, auditLog audit.Log, accessLog prunableLog, metrics *middleware.Metrics) *mux.Router {
 	r := mux.NewRouter()
 
+	store := openStore(*attachmentStore, "attachment")
+
 	lh := NewLoginHandler(createSession, tokenverifier.GoogleTokenVerifier{})
 	r.Handle("/", lh)
 
 	ph := NewProfileHandler(da, createSession)
 	r.Handle("/profile/", ph)
+	r.Handle("/profile/notifications/", NewNotificationsHandler(da, createSession))
+	r.Handle("/profile/availability/", NewAvailabilityHandler(da, createSession))
+	r.Handle("/profile/bookings/", NewBookingHandler(da, createSession))
+	r.Handle("/profile/stale/", NewStaleSkillHandler(da, createSession))
+	r.Handle("/profile/goals/", NewGoalHandler(da, createSession))
+	r.Handle("/profile/cv/", NewCVHandler(da, store, createResumeParser(), configuration, createSession))
+	r.Handle("/profile/suggestions/", NewSkillSuggestionHandler(da, createSession))
+	r.Handle("/profile/onepager/", NewOnePagerHandler(da, createSession))
+	r.Handle("/profile/card/", NewCardHandler(da, createSession))
+	r.Handle("/profile/summary/", NewProfileSummaryHandler(da, createSession))
+	r.Handle("/profile/fields/", NewCustomFieldHandler(da))
+	r.Handle("/profile/languages/", NewLanguageHandler(da, createSession))
+	r.Handle("/profile/location/", NewWorkLocationHandler(da, createSession))
+	r.Handle("/profile/consent/", NewConsentHandler(da, createSession))
+	r.Handle("/profile/hours/", NewWorkingHoursHandler(da, createSession))
+	r.Handle("/profile/clearance/", NewClearanceHandler(da))
+	if *eventSourcing {
+		r.Handle("/profile/events/", NewProfileEventHandler(da))
+	}
+	r.Handle("/profile/shares/", NewShareLinkHandler(da, createSession, *baseURL))
+	r.Handle("/shared/", NewSharedProfileHandler(da))
+	r.Handle("/oembed/", NewOEmbedHandler(*baseURL))
+	r.Handle("/attachments/", NewAttachmentHandler(store, configuration))
+	r.Handle("/profile/calibrations/", NewCalibrationHandler(da, configuration))
+	r.Handle("/profile/calibrations/decisions/", NewCalibrationDecisionHandler(da, configuration))
 
 	sh := NewSkillHandler(da, createSession)
 	r.Handle("/skills/", sh)
 
 	rh := NewReportHandler(da, createSession)
 	r.Handle("/report/", rh)
+	r.Handle("/report/org/", NewOrgTreeHandler(da, createSession))
+	hh := NewHeatmapHandler(da, createSession)
+	hh.SnapshotMaxAge = *reportSnapshotMaxAge
+	r.Handle("/report/heatmap/", hh)
+	dh := NewDepartmentHandler(da, createSession)
+	dh.SnapshotMaxAge = *reportSnapshotMaxAge
+	r.Handle("/report/departments/", dh)
+	r.Handle("/report/nearby/", NewNearbyHandler(da, createSession))
+	r.Handle("/report/overlap/", NewOverlapHandler(da, createSession))
+	r.Handle("/report/adoption/", NewAdoptionHandler(da, createSession))
+	r.Handle("/report/compare/", NewComparisonHandler(da, createSession))
+	r.Handle("/report/team/", NewTeamHandler(da, createSession))
+	r.Handle("/report/teams/", NewTeamSummaryHandler(da, createSession))
+	r.Handle("/search/", NewSearchHandler(da, createSession))
+	r.Handle("/autocomplete/", NewAutocompleteHandler(completions, createSession))
+	if skillIndex != nil {
+		r.Handle("/search/semantic/", NewSemanticSearchHandler(da, skillIndex, createSession))
+	}
+	if provider != nil {
+		r.Handle("/search/ask/", NewAskHandler(nlquery.NewInterpreter(da, provider), createSession))
+	}
+	r.Handle("/report/allocations/", NewAllocationHandler(da, createSession))
+	r.Handle("/report/forecast/", NewForecastHandler(da, createSession))
+	r.Handle("/report/goals/", NewGoalReportHandler(da, createSession))
+	r.Handle("/report/trends/", NewTrendHandler(da, createSession))
+	r.Handle("/report/cohorts/", NewCohortHandler(da, createSession))
+	r.Handle("/report/benchmark/", NewBenchmarkHandler(da, createSession))
+
+	r.Handle("/projects/", NewProjectHandler(da, createSession))
+	r.Handle("/projects/team/", NewProjectTeamHandler(da, createSession))
+
+	r.Handle("/training/courses/", NewCourseHandler(da))
+	r.Handle("/training/paths/", NewLearningPathHandler(da))
+	r.Handle("/training/completions/", NewCourseCompletionHandler(da))
+	r.Handle("/training/completions/decisions/", NewCourseCompletionDecisionHandler(da))
+
+	wh := NewWebSocketHandler(hub, createSession)
+	r.Handle("/ws/", wh)
+
+	r.Handle("/branding/", NewBrandingHandler(da))
+
+	fh := NewFeatureFlagHandler(da, createSession, configuration)
+	r.Handle("/admin/features/", fh)
+
+	r.Handle("/admin/impersonate/", NewImpersonationHandler(createSession, auditLog))
+	r.Handle("/admin/audit/", NewAuditHandler(auditLog, "auditlog"))
+	if accessLog != nil {
+		r.Handle("/admin/accesslog/", NewAuditHandler(accessLog, "accesslog"))
+		r.Handle("/profile/access/", NewAccessReportHandler(accessLog))
+	}
+	r.Handle("/admin/skills/trash/", NewSkillTrashHandler(da))
+	r.Handle("/admin/archive/", NewArchiveHandler(da))
+	r.Handle("/admin/reports/", NewReportDefinitionHandler(da))
+	r.Handle("/admin/reports/run/", NewReportRunHandler(da))
+	r.Handle("/admin/exports/", NewExportHandler(da))
+	r.Handle("/admin/import/", NewImportCommitHandler(da))
+	r.Handle("/admin/import/preview/", NewImportPreviewHandler(da))
+	r.Handle("/admin/exports/download/", NewExportDownloadHandler(da, openStore(*exportStore, "export")))
+	r.Handle("/admin/fields/", NewCustomFieldSchemaHandler(da))
+	r.Handle("/admin/skills/descriptors/", NewSkillDescriptorHandler(da))
+	r.Handle("/admin/skills/proposals/", NewTagProposalHandler(da))
+	r.Handle("/admin/skills/duplicates/", NewDuplicateTagHandler(da))
+	r.Handle("/admin/profiles/duplicates/", NewDuplicateProfileHandler(da))
+	r.Handle("/admin/profiles/external/", NewExternalIDHandler(da))
+	r.Handle("/admin/quarantine/", NewQuarantineHandler(da, auditLog))
+	r.Handle("/admin/approvals/", NewApprovalHandler(da, auditLog, *approvalWindow))
+	r.Handle("/admin/approvals/decisions/", NewApprovalDecisionHandler(da, auditLog))
+
+	if *diagnosticsAdministrators != "" {
+		dataaccess.EnablePoolStats()
+		operators := strings.Split(*diagnosticsAdministrators, ",")
+		r.PathPrefix("/admin/diagnostics/").Handler(NewDiagnosticsHandler(operators))
+		r.Handle("/admin/doctor/", NewDoctorHandler(deployment().Check, operators))
+	}
+
+	r.Handle("/api/token/", NewTokenHandler(createSession, configuration))
+	r.Handle("/login/cli/", NewCLILoginHandler(createSession, tokenverifier.GoogleTokenVerifier{}, configuration))
+	r.Handle("/.well-known/jwks.json", NewJWKSHandler(configuration))
+
+	r.Handle("/metrics/", middleware.RequireAuthentication(middleware.AuthenticatorFunc(authenticateSession))(metrics))
 
 	// Serve static content.
 	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./static/"))))
@@ -58,9 +1082,30 @@ func createRoutes(da dataaccess.DataAccess) *mux.Router {
 	return r
 }
 
-var configuration dataaccess.Configuration
+var configuration *ConfigurationCache
 
 func createSession(w http.ResponseWriter, r *http.Request) Session {
 	loginURL, _ := url.Parse("/")
-	return NewGorillaSession(w, r, configuration.SessionEncryptionKey, configuration.SetSecureFlag, *loginURL)
+	c := configuration.Get()
+	if strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		bearer, ok := identifyBearer(c, r)
+		return NewBearerSession(w, r, bearer.EmailAddress, ok)
+	}
+	// The configuration is validated when it's read, so it always contains a
+	// session encryption key.
+	manager, _ := newSessionManager(c)
+	return NewCookieSession(w, r, manager, c.SetSecureFlag, *loginURL)
+}
+
+func newSessionManager(c dataaccess.Configuration) (*sessions.Manager, error) {
+	m, err := sessions.NewManager(c.SessionEncryptionKey, c.PreviousSessionEncryptionKeys...)
+	if err != nil {
+		return nil, err
+	}
+	m.Revoked = c.RevocationTimes()
+	return m, nil
+}
+
+func authenticateSession(w http.ResponseWriter, r *http.Request) (bool, string) {
+	return createSession(w, r).ValidateSession()
 }
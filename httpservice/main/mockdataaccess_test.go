@@ -2,29 +2,238 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/a-h/pill/dataaccess"
 )
 
 type mockDataAccess struct {
-	getProfileResponse                func(string) (*dataaccess.Profile, bool, error)
-	getProfileCallCount               int
-	updateProfileResponse             func(update *dataaccess.ProfileUpdate) (*dataaccess.Profile, error)
-	updateProfileCallCount            int
-	listSkillTagsResponse             func() ([]string, error)
-	listSkillTagsCallCount            int
-	addSkillTagsResponse              func(tags []string) error
-	addSkillTagsCallCount             int
-	deleteProfileResponse             func(emailAddress string) (bool, error)
-	deleteProfileCallCount            int
-	listProfilesResponse              func() ([]dataaccess.Profile, error)
-	listProfilesCallCount             int
-	deleteSkillTagsResponse           func(tags []string) error
-	deleteSkillTagsCallCount          int
-	getOrCreateConfigurationResponse  func() (dataaccess.Configuration, error)
-	getOrCreateConfigurationCallCount int
-	deleteConfigurationResponse       func() error
-	deleteConfigurationCallCount      int
+	getProfileResponse                     func(string) (*dataaccess.Profile, bool, error)
+	getProfileCallCount                    int
+	updateProfileResponse                  func(update *dataaccess.ProfileUpdate) (*dataaccess.Profile, error)
+	updateProfileCallCount                 int
+	listSkillTagsResponse                  func() ([]dataaccess.SkillTag, error)
+	listSkillTagsCallCount                 int
+	addSkillTagsResponse                   func(tags []string) error
+	addSkillTagsCallCount                  int
+	deleteProfileResponse                  func(emailAddress string) (bool, error)
+	deleteProfileCallCount                 int
+	listProfilesResponse                   func() ([]dataaccess.Profile, error)
+	listProfilesCallCount                  int
+	getOrgTreeResponse                     func(domain string) (*dataaccess.OrgNode, error)
+	getOrgTreeCallCount                    int
+	deleteSkillTagsResponse                func(tags []string) error
+	deleteSkillTagsCallCount               int
+	getOrCreateConfigurationResponse       func() (dataaccess.Configuration, error)
+	getOrCreateConfigurationCallCount      int
+	deleteConfigurationResponse            func() error
+	deleteConfigurationCallCount           int
+	rotateSessionEncryptionKeyResponse     func() (dataaccess.Configuration, error)
+	rotateSessionEncryptionKeyCallCount    int
+	setFeatureFlagResponse                 func(name string, enabled bool) error
+	setFeatureFlagCallCount                int
+	getTenantConfigurationResponse         func(domain string) (*dataaccess.TenantConfiguration, bool, error)
+	getTenantConfigurationCallCount        int
+	updateTenantConfigurationResponse      func(tc *dataaccess.TenantConfiguration) error
+	updateTenantConfigurationCallCount     int
+	deleteTenantConfigurationResponse      func(domain string) error
+	deleteTenantConfigurationCallCount     int
+	getSettingsResponse                    func(domain string) (dataaccess.Settings, error)
+	getSettingsCallCount                   int
+	acquireLockResponse                    func(name string, ttl time.Duration) (*dataaccess.Lock, bool, error)
+	acquireLockCallCount                   int
+	releaseLockResponse                    func(lock *dataaccess.Lock) error
+	releaseLockCallCount                   int
+	listDomainsResponse                    func() ([]string, error)
+	listDomainsCallCount                   int
+	updateNotificationPreferencesResponse  func(emailAddress string, p dataaccess.NotificationPreferences) error
+	updateNotificationPreferencesCallCount int
+	updateAvailabilityWindowsResponse      func(emailAddress string, windows []dataaccess.AvailabilityWindow) error
+	updateAvailabilityWindowsCallCount     int
+	quarantineChangeResponse               func(c *dataaccess.QuarantinedChange) error
+	quarantineChangeCallCount              int
+	listQuarantinedChangesResponse         func(domain string) ([]dataaccess.QuarantinedChange, error)
+	listQuarantinedChangesCallCount        int
+	getQuarantinedChangeResponse           func(id string) (*dataaccess.QuarantinedChange, bool, error)
+	getQuarantinedChangeCallCount          int
+	deleteQuarantinedChangeResponse        func(id string) error
+	deleteQuarantinedChangeCallCount       int
+	requestApprovalResponse                func(a *dataaccess.ApprovalRequest) error
+	requestApprovalCallCount               int
+	listApprovalRequestsResponse           func() ([]dataaccess.ApprovalRequest, error)
+	listApprovalRequestsCallCount          int
+	getApprovalRequestResponse             func(id string) (*dataaccess.ApprovalRequest, bool, error)
+	getApprovalRequestCallCount            int
+	deleteApprovalRequestResponse          func(id string) error
+	deleteApprovalRequestCallCount         int
+	listDeletedSkillTagsResponse           func() ([]dataaccess.DeletedSkillTag, error)
+	listDeletedSkillTagsCallCount          int
+	restoreSkillTagsResponse               func(tags []string) ([]string, error)
+	restoreSkillTagsCallCount              int
+	purgeDeletedSkillTagsResponse          func(before time.Time) (int, error)
+	purgeDeletedSkillTagsCallCount         int
+	importProfileResponse                  func(p *dataaccess.Profile) error
+	importProfileCallCount                 int
+	awardBadgesResponse                    func(emailAddress string, badges []dataaccess.Badge) error
+	awardBadgesCallCount                   int
+	setSkillTagDescriptorsResponse         func(name string, descriptors []dataaccess.LevelDescriptor) error
+	setSkillTagDescriptorsCallCount        int
+	saveCalibrationResponse                func(c *dataaccess.Calibration) error
+	saveCalibrationCallCount               int
+	listCalibrationsResponse               func(emailAddress string) ([]dataaccess.Calibration, error)
+	listCalibrationsCallCount              int
+	getCalibrationResponse                 func(id string) (*dataaccess.Calibration, bool, error)
+	getCalibrationCallCount                int
+	addBookingResponse                     func(emailAddress string, b dataaccess.Booking) error
+	addBookingCallCount                    int
+	removeBookingResponse                  func(emailAddress string, id string) error
+	removeBookingCallCount                 int
+	saveProjectResponse                    func(p *dataaccess.Project) error
+	saveProjectCallCount                   int
+	listProjectsResponse                   func(domain string) ([]dataaccess.Project, error)
+	listProjectsCallCount                  int
+	getProjectResponse                     func(id string) (*dataaccess.Project, bool, error)
+	getProjectCallCount                    int
+	deleteProjectResponse                  func(id string) error
+	deleteProjectCallCount                 int
+	saveCourseResponse                     func(c *dataaccess.Course) error
+	saveCourseCallCount                    int
+	listCoursesResponse                    func(domain string) ([]dataaccess.Course, error)
+	listCoursesCallCount                   int
+	getCourseResponse                      func(id string) (*dataaccess.Course, bool, error)
+	getCourseCallCount                     int
+	deleteCourseResponse                   func(id string) error
+	deleteCourseCallCount                  int
+	saveCourseCompletionResponse           func(cc *dataaccess.CourseCompletion) error
+	saveCourseCompletionCallCount          int
+	listCourseCompletionsResponse          func(domain string) ([]dataaccess.CourseCompletion, error)
+	listCourseCompletionsCallCount         int
+	getCourseCompletionResponse            func(id string) (*dataaccess.CourseCompletion, bool, error)
+	getCourseCompletionCallCount           int
+	updateLearningGoalsResponse            func(emailAddress string, goals []dataaccess.LearningGoal) error
+	updateLearningGoalsCallCount           int
+	confirmSkillsResponse                  func(emailAddress string, skills []string) error
+	confirmSkillsCallCount                 int
+	importSkillTagsResponse                func(tags []dataaccess.SkillTag) (int, error)
+	importSkillTagsCallCount               int
+	saveTagProposalResponse                func(p *dataaccess.TagProposal) error
+	saveTagProposalCallCount               int
+	listTagProposalsResponse               func(domain string) ([]dataaccess.TagProposal, error)
+	listTagProposalsCallCount              int
+	getTagProposalResponse                 func(id string) (*dataaccess.TagProposal, bool, error)
+	getTagProposalCallCount                int
+	mergeSkillTagsResponse                 func(into string, tags []string) (int, error)
+	mergeSkillTagsCallCount                int
+	updateCVResponse                       func(emailAddress string, cv *dataaccess.Attachment) error
+	updateCVCallCount                      int
+	saveSkillSuggestionsResponse           func(ss *dataaccess.SkillSuggestions) error
+	saveSkillSuggestionsCallCount          int
+	listSkillSuggestionsResponse           func(emailAddress string) ([]dataaccess.SkillSuggestions, error)
+	listSkillSuggestionsCallCount          int
+	getSkillSuggestionsResponse            func(id string) (*dataaccess.SkillSuggestions, bool, error)
+	getSkillSuggestionsCallCount           int
+	saveShareLinkResponse                  func(l *dataaccess.ShareLink) error
+	saveShareLinkCallCount                 int
+	listShareLinksResponse                 func(emailAddress string) ([]dataaccess.ShareLink, error)
+	listShareLinksCallCount                int
+	getShareLinkResponse                   func(id string) (*dataaccess.ShareLink, bool, error)
+	getShareLinkCallCount                  int
+	deleteShareLinkResponse                func(id string) error
+	deleteShareLinkCallCount               int
+	syncEmployeeResponse                   func(e dataaccess.EmployeeUpdate) error
+	syncEmployeeCallCount                  int
+	archiveProfileResponse                 func(emailAddress string, reason string, purgeAfter time.Time) (bool, error)
+	archiveProfileCallCount                int
+	restoreProfileResponse                 func(emailAddress string) (bool, error)
+	restoreProfileCallCount                int
+	listArchivedProfilesResponse           func(domain string) ([]dataaccess.ArchivedProfile, error)
+	listArchivedProfilesCallCount          int
+	purgeArchivedProfilesResponse          func(before time.Time) (int, error)
+	purgeArchivedProfilesCallCount         int
+	revokeSessionsResponse                 func(emailAddress string, at time.Time) error
+	revokeSessionsCallCount                int
+	findProfilesResponse                   func(domain string, f dataaccess.ProfileFilter) ([]dataaccess.Profile, error)
+	findProfilesCallCount                  int
+	ensureIndexesResponse                  func() error
+	ensureIndexesCallCount                 int
+	updateCustomFieldsResponse             func(emailAddress string, values map[string]dataaccess.CustomFieldValue) error
+	updateCustomFieldsCallCount            int
+	updateLanguagesResponse                func(emailAddress string, languages []dataaccess.Language) error
+	updateLanguagesCallCount               int
+	updateClearanceResponse                func(emailAddress string, cl *dataaccess.Clearance) error
+	updateClearanceCallCount               int
+	updateWorkLocationResponse             func(emailAddress string, l *dataaccess.WorkLocation) error
+	updateWorkLocationCallCount            int
+	findProfilesNearResponse               func(domain string, near dataaccess.Coordinates, radiusKm float64) ([]dataaccess.Profile, error)
+	findProfilesNearCallCount              int
+	updateWorkingHoursResponse             func(emailAddress string, h *dataaccess.WorkingHours) error
+	updateWorkingHoursCallCount            int
+	recordConsentsResponse                 func(emailAddress string, consents []dataaccess.Consent) error
+	recordConsentsCallCount                int
+	saveBenchmarksResponse                 func(benchmarks []dataaccess.Benchmark) error
+	saveBenchmarksCallCount                int
+	getBenchmarkResponse                   func(band string) (*dataaccess.Benchmark, bool, error)
+	getBenchmarkCallCount                  int
+	saveReportDefinitionResponse           func(d *dataaccess.ReportDefinition) error
+	saveReportDefinitionCallCount          int
+	listReportDefinitionsResponse          func(domain string) ([]dataaccess.ReportDefinition, error)
+	listReportDefinitionsCallCount         int
+	deleteReportDefinitionResponse         func(domain string, id string) (bool, error)
+	deleteReportDefinitionCallCount        int
+	runReportResponse                      func(d dataaccess.ReportDefinition, consent dataaccess.ConsentSettings) (*dataaccess.ReportResult, error)
+	runReportCallCount                     int
+	saveReportSnapshotResponse             func(s *dataaccess.ReportSnapshot) error
+	saveReportSnapshotCallCount            int
+	getReportSnapshotResponse              func(domain string, report string) (*dataaccess.ReportSnapshot, bool, error)
+	getReportSnapshotCallCount             int
+	saveExportJobResponse                  func(j *dataaccess.ExportJob) error
+	saveExportJobCallCount                 int
+	getExportJobResponse                   func(id string) (*dataaccess.ExportJob, bool, error)
+	getExportJobCallCount                  int
+	claimExportJobResponse                 func(now time.Time) (*dataaccess.ExportJob, bool, error)
+	claimExportJobCallCount                int
+	purgeExportJobsResponse                func(before time.Time) ([]dataaccess.ExportJob, error)
+	purgeExportJobsCallCount               int
+	saveImportPreviewResponse              func(p *dataaccess.ImportPreview) error
+	saveImportPreviewCallCount             int
+	takeImportPreviewResponse              func(domain string, id string) (*dataaccess.ImportPreview, bool, error)
+	takeImportPreviewCallCount             int
+	mergeProfilesResponse                  func(primary string, duplicate string) (*dataaccess.Profile, bool, error)
+	mergeProfilesCallCount                 int
+	findProfileByExternalIDResponse        func(domain string, system string, id string) (*dataaccess.Profile, bool, error)
+	findProfileByExternalIDCallCount       int
+	updateExternalIDsResponse              func(emailAddress string, ids map[string]string) error
+	updateExternalIDsCallCount             int
+	appendProfileEventsResponse            func(events []dataaccess.ProfileEvent) error
+	appendProfileEventsCallCount           int
+	listProfileEventsResponse              func(emailAddress string, after int) ([]dataaccess.ProfileEvent, error)
+	listProfileEventsCallCount             int
+	saveProfileSnapshotResponse            func(s *dataaccess.ProfileSnapshot) error
+	saveProfileSnapshotCallCount           int
+	getProfileSnapshotResponse             func(emailAddress string, at time.Time) (*dataaccess.ProfileSnapshot, bool, error)
+	getProfileSnapshotCallCount            int
+	projectProfileResponse                 func(s *dataaccess.ProfileSnapshot) error
+	projectProfileCallCount                int
+	saveSearchDocumentResponse             func(d *dataaccess.SearchDocument) error
+	saveSearchDocumentCallCount            int
+	getSearchDocumentResponse              func(emailAddress string) (*dataaccess.SearchDocument, bool, error)
+	getSearchDocumentCallCount             int
+	removeSearchDocumentResponse           func(emailAddress string) error
+	removeSearchDocumentCallCount          int
+	searchDocumentsResponse                func(domain string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error)
+	searchDocumentsCallCount               int
+	saveTeamSummaryResponse                func(s *dataaccess.TeamSummary) error
+	saveTeamSummaryCallCount               int
+	getTeamSummaryResponse                 func(manager string) (*dataaccess.TeamSummary, bool, error)
+	getTeamSummaryCallCount                int
+	saveProfileSummaryResponse             func(s *dataaccess.ProfileSummary) error
+	saveProfileSummaryCallCount            int
+	getProfileSummaryResponse              func(emailAddress string) (*dataaccess.ProfileSummary, bool, error)
+	getProfileSummaryCallCount             int
+	listProfileSummariesResponse           func(domain string) ([]dataaccess.ProfileSummary, error)
+	listProfileSummariesCallCount          int
+	removeProfileSummaryResponse           func(emailAddress string) error
+	removeProfileSummaryCallCount          int
 }
 
 func (da *mockDataAccess) GetProfile(emailAddress string) (*dataaccess.Profile, bool, error) {
@@ -37,7 +246,7 @@ func (da *mockDataAccess) UpdateProfile(update *dataaccess.ProfileUpdate) (*data
 	return da.updateProfileResponse(update)
 }
 
-func (da *mockDataAccess) ListSkillTags() ([]string, error) {
+func (da *mockDataAccess) ListSkillTags() ([]dataaccess.SkillTag, error) {
 	da.listSkillTagsCallCount++
 	return da.listSkillTagsResponse()
 }
@@ -57,6 +266,11 @@ func (da *mockDataAccess) ListProfiles(domain string) ([]dataaccess.Profile, err
 	return da.listProfilesResponse()
 }
 
+func (da *mockDataAccess) GetOrgTree(domain string) (*dataaccess.OrgNode, error) {
+	da.getOrgTreeCallCount++
+	return da.getOrgTreeResponse(domain)
+}
+
 func (da *mockDataAccess) DeleteSkillTags(tags []string) error {
 	da.deleteSkillTagsCallCount++
 	return da.deleteSkillTagsResponse(tags)
@@ -72,6 +286,46 @@ func (da *mockDataAccess) DeleteConfiguration() error {
 	return da.deleteConfigurationResponse()
 }
 
+func (da *mockDataAccess) RotateSessionEncryptionKey() (dataaccess.Configuration, error) {
+	da.rotateSessionEncryptionKeyCallCount++
+	return da.rotateSessionEncryptionKeyResponse()
+}
+
+func (da *mockDataAccess) SetFeatureFlag(name string, enabled bool) error {
+	da.setFeatureFlagCallCount++
+	return da.setFeatureFlagResponse(name, enabled)
+}
+
+func (da *mockDataAccess) GetTenantConfiguration(domain string) (*dataaccess.TenantConfiguration, bool, error) {
+	da.getTenantConfigurationCallCount++
+	return da.getTenantConfigurationResponse(domain)
+}
+
+func (da *mockDataAccess) UpdateTenantConfiguration(tc *dataaccess.TenantConfiguration) error {
+	da.updateTenantConfigurationCallCount++
+	return da.updateTenantConfigurationResponse(tc)
+}
+
+func (da *mockDataAccess) DeleteTenantConfiguration(domain string) error {
+	da.deleteTenantConfigurationCallCount++
+	return da.deleteTenantConfigurationResponse(domain)
+}
+
+func (da *mockDataAccess) GetSettings(domain string) (dataaccess.Settings, error) {
+	da.getSettingsCallCount++
+	return da.getSettingsResponse(domain)
+}
+
+func (da *mockDataAccess) AcquireLock(name string, ttl time.Duration) (*dataaccess.Lock, bool, error) {
+	da.acquireLockCallCount++
+	return da.acquireLockResponse(name, ttl)
+}
+
+func (da *mockDataAccess) ReleaseLock(lock *dataaccess.Lock) error {
+	da.releaseLockCallCount++
+	return da.releaseLockResponse(lock)
+}
+
 func TestMockRecordsIncrementsAndExecutesFunctions(t *testing.T) {
 	mda := &mockDataAccess{
 		getProfileResponse: func(string) (*dataaccess.Profile, bool, error) { return nil, false, nil },
@@ -83,3 +337,478 @@ func TestMockRecordsIncrementsAndExecutesFunctions(t *testing.T) {
 		t.Error("The profile call count was not incremented.")
 	}
 }
+
+func (da *mockDataAccess) ListDomains() ([]string, error) {
+	da.listDomainsCallCount++
+	return da.listDomainsResponse()
+}
+
+func (da *mockDataAccess) UpdateNotificationPreferences(emailAddress string, p dataaccess.NotificationPreferences) error {
+	da.updateNotificationPreferencesCallCount++
+	return da.updateNotificationPreferencesResponse(emailAddress, p)
+}
+
+func (da *mockDataAccess) UpdateAvailabilityWindows(emailAddress string, windows []dataaccess.AvailabilityWindow) error {
+	da.updateAvailabilityWindowsCallCount++
+	return da.updateAvailabilityWindowsResponse(emailAddress, windows)
+}
+
+func (da *mockDataAccess) QuarantineChange(c *dataaccess.QuarantinedChange) error {
+	da.quarantineChangeCallCount++
+	return da.quarantineChangeResponse(c)
+}
+
+func (da *mockDataAccess) ListQuarantinedChanges(domain string) ([]dataaccess.QuarantinedChange, error) {
+	da.listQuarantinedChangesCallCount++
+	return da.listQuarantinedChangesResponse(domain)
+}
+
+func (da *mockDataAccess) GetQuarantinedChange(id string) (*dataaccess.QuarantinedChange, bool, error) {
+	da.getQuarantinedChangeCallCount++
+	return da.getQuarantinedChangeResponse(id)
+}
+
+func (da *mockDataAccess) DeleteQuarantinedChange(id string) error {
+	da.deleteQuarantinedChangeCallCount++
+	return da.deleteQuarantinedChangeResponse(id)
+}
+
+func (da *mockDataAccess) RequestApproval(a *dataaccess.ApprovalRequest) error {
+	da.requestApprovalCallCount++
+	return da.requestApprovalResponse(a)
+}
+
+func (da *mockDataAccess) ListApprovalRequests() ([]dataaccess.ApprovalRequest, error) {
+	da.listApprovalRequestsCallCount++
+	return da.listApprovalRequestsResponse()
+}
+
+func (da *mockDataAccess) GetApprovalRequest(id string) (*dataaccess.ApprovalRequest, bool, error) {
+	da.getApprovalRequestCallCount++
+	return da.getApprovalRequestResponse(id)
+}
+
+func (da *mockDataAccess) DeleteApprovalRequest(id string) error {
+	da.deleteApprovalRequestCallCount++
+	return da.deleteApprovalRequestResponse(id)
+}
+
+func (da *mockDataAccess) ListDeletedSkillTags() ([]dataaccess.DeletedSkillTag, error) {
+	da.listDeletedSkillTagsCallCount++
+	return da.listDeletedSkillTagsResponse()
+}
+
+func (da *mockDataAccess) RestoreSkillTags(tags []string) ([]string, error) {
+	da.restoreSkillTagsCallCount++
+	return da.restoreSkillTagsResponse(tags)
+}
+
+func (da *mockDataAccess) PurgeDeletedSkillTags(before time.Time) (int, error) {
+	da.purgeDeletedSkillTagsCallCount++
+	return da.purgeDeletedSkillTagsResponse(before)
+}
+
+func (da *mockDataAccess) ImportProfile(p *dataaccess.Profile) error {
+	da.importProfileCallCount++
+	return da.importProfileResponse(p)
+}
+
+func (da *mockDataAccess) AwardBadges(emailAddress string, badges []dataaccess.Badge) error {
+	da.awardBadgesCallCount++
+	return da.awardBadgesResponse(emailAddress, badges)
+}
+
+func (da *mockDataAccess) SetSkillTagDescriptors(name string, descriptors []dataaccess.LevelDescriptor) error {
+	da.setSkillTagDescriptorsCallCount++
+	return da.setSkillTagDescriptorsResponse(name, descriptors)
+}
+
+func (da *mockDataAccess) SaveCalibration(c *dataaccess.Calibration) error {
+	da.saveCalibrationCallCount++
+	return da.saveCalibrationResponse(c)
+}
+
+func (da *mockDataAccess) ListCalibrations(emailAddress string) ([]dataaccess.Calibration, error) {
+	da.listCalibrationsCallCount++
+	return da.listCalibrationsResponse(emailAddress)
+}
+
+func (da *mockDataAccess) GetCalibration(id string) (*dataaccess.Calibration, bool, error) {
+	da.getCalibrationCallCount++
+	return da.getCalibrationResponse(id)
+}
+
+func (da *mockDataAccess) AddBooking(emailAddress string, b dataaccess.Booking) error {
+	da.addBookingCallCount++
+	return da.addBookingResponse(emailAddress, b)
+}
+
+func (da *mockDataAccess) RemoveBooking(emailAddress string, id string) error {
+	da.removeBookingCallCount++
+	return da.removeBookingResponse(emailAddress, id)
+}
+
+func (da *mockDataAccess) SaveProject(p *dataaccess.Project) error {
+	da.saveProjectCallCount++
+	return da.saveProjectResponse(p)
+}
+
+func (da *mockDataAccess) ListProjects(domain string) ([]dataaccess.Project, error) {
+	da.listProjectsCallCount++
+	return da.listProjectsResponse(domain)
+}
+
+func (da *mockDataAccess) GetProject(id string) (*dataaccess.Project, bool, error) {
+	da.getProjectCallCount++
+	return da.getProjectResponse(id)
+}
+
+func (da *mockDataAccess) DeleteProject(id string) error {
+	da.deleteProjectCallCount++
+	return da.deleteProjectResponse(id)
+}
+
+func (da *mockDataAccess) SaveCourse(c *dataaccess.Course) error {
+	da.saveCourseCallCount++
+	return da.saveCourseResponse(c)
+}
+
+func (da *mockDataAccess) ListCourses(domain string) ([]dataaccess.Course, error) {
+	da.listCoursesCallCount++
+	return da.listCoursesResponse(domain)
+}
+
+func (da *mockDataAccess) GetCourse(id string) (*dataaccess.Course, bool, error) {
+	da.getCourseCallCount++
+	return da.getCourseResponse(id)
+}
+
+func (da *mockDataAccess) DeleteCourse(id string) error {
+	da.deleteCourseCallCount++
+	return da.deleteCourseResponse(id)
+}
+
+func (da *mockDataAccess) SaveCourseCompletion(cc *dataaccess.CourseCompletion) error {
+	da.saveCourseCompletionCallCount++
+	return da.saveCourseCompletionResponse(cc)
+}
+
+func (da *mockDataAccess) ListCourseCompletions(domain string) ([]dataaccess.CourseCompletion, error) {
+	da.listCourseCompletionsCallCount++
+	return da.listCourseCompletionsResponse(domain)
+}
+
+func (da *mockDataAccess) GetCourseCompletion(id string) (*dataaccess.CourseCompletion, bool, error) {
+	da.getCourseCompletionCallCount++
+	return da.getCourseCompletionResponse(id)
+}
+
+func (da *mockDataAccess) UpdateLearningGoals(emailAddress string, goals []dataaccess.LearningGoal) error {
+	da.updateLearningGoalsCallCount++
+	return da.updateLearningGoalsResponse(emailAddress, goals)
+}
+
+func (da *mockDataAccess) ConfirmSkills(emailAddress string, skills []string) error {
+	da.confirmSkillsCallCount++
+	return da.confirmSkillsResponse(emailAddress, skills)
+}
+
+func (da *mockDataAccess) ImportSkillTags(tags []dataaccess.SkillTag) (int, error) {
+	da.importSkillTagsCallCount++
+	return da.importSkillTagsResponse(tags)
+}
+
+func (da *mockDataAccess) SaveTagProposal(p *dataaccess.TagProposal) error {
+	da.saveTagProposalCallCount++
+	return da.saveTagProposalResponse(p)
+}
+
+func (da *mockDataAccess) ListTagProposals(domain string) ([]dataaccess.TagProposal, error) {
+	da.listTagProposalsCallCount++
+	return da.listTagProposalsResponse(domain)
+}
+
+func (da *mockDataAccess) GetTagProposal(id string) (*dataaccess.TagProposal, bool, error) {
+	da.getTagProposalCallCount++
+	return da.getTagProposalResponse(id)
+}
+
+func (da *mockDataAccess) MergeSkillTags(into string, tags []string) (int, error) {
+	da.mergeSkillTagsCallCount++
+	return da.mergeSkillTagsResponse(into, tags)
+}
+
+func (da *mockDataAccess) UpdateCV(emailAddress string, cv *dataaccess.Attachment) error {
+	da.updateCVCallCount++
+	return da.updateCVResponse(emailAddress, cv)
+}
+
+func (da *mockDataAccess) SaveSkillSuggestions(ss *dataaccess.SkillSuggestions) error {
+	da.saveSkillSuggestionsCallCount++
+	return da.saveSkillSuggestionsResponse(ss)
+}
+
+func (da *mockDataAccess) ListSkillSuggestions(emailAddress string) ([]dataaccess.SkillSuggestions, error) {
+	da.listSkillSuggestionsCallCount++
+	return da.listSkillSuggestionsResponse(emailAddress)
+}
+
+func (da *mockDataAccess) GetSkillSuggestions(id string) (*dataaccess.SkillSuggestions, bool, error) {
+	da.getSkillSuggestionsCallCount++
+	return da.getSkillSuggestionsResponse(id)
+}
+
+func (da *mockDataAccess) SaveShareLink(l *dataaccess.ShareLink) error {
+	da.saveShareLinkCallCount++
+	return da.saveShareLinkResponse(l)
+}
+
+func (da *mockDataAccess) ListShareLinks(emailAddress string) ([]dataaccess.ShareLink, error) {
+	da.listShareLinksCallCount++
+	return da.listShareLinksResponse(emailAddress)
+}
+
+func (da *mockDataAccess) GetShareLink(id string) (*dataaccess.ShareLink, bool, error) {
+	da.getShareLinkCallCount++
+	return da.getShareLinkResponse(id)
+}
+
+func (da *mockDataAccess) DeleteShareLink(id string) error {
+	da.deleteShareLinkCallCount++
+	return da.deleteShareLinkResponse(id)
+}
+
+func (da *mockDataAccess) SyncEmployee(e dataaccess.EmployeeUpdate) error {
+	da.syncEmployeeCallCount++
+	return da.syncEmployeeResponse(e)
+}
+
+func (da *mockDataAccess) ArchiveProfile(emailAddress string, reason string, purgeAfter time.Time) (bool, error) {
+	da.archiveProfileCallCount++
+	return da.archiveProfileResponse(emailAddress, reason, purgeAfter)
+}
+
+func (da *mockDataAccess) RestoreProfile(emailAddress string) (bool, error) {
+	da.restoreProfileCallCount++
+	return da.restoreProfileResponse(emailAddress)
+}
+
+func (da *mockDataAccess) ListArchivedProfiles(domain string) ([]dataaccess.ArchivedProfile, error) {
+	da.listArchivedProfilesCallCount++
+	return da.listArchivedProfilesResponse(domain)
+}
+
+func (da *mockDataAccess) PurgeArchivedProfiles(before time.Time) (int, error) {
+	da.purgeArchivedProfilesCallCount++
+	return da.purgeArchivedProfilesResponse(before)
+}
+
+func (da *mockDataAccess) RevokeSessions(emailAddress string, at time.Time) error {
+	da.revokeSessionsCallCount++
+	return da.revokeSessionsResponse(emailAddress, at)
+}
+
+func (da *mockDataAccess) FindProfiles(domain string, f dataaccess.ProfileFilter) ([]dataaccess.Profile, error) {
+	da.findProfilesCallCount++
+	return da.findProfilesResponse(domain, f)
+}
+
+func (da *mockDataAccess) EnsureIndexes() error {
+	da.ensureIndexesCallCount++
+	return da.ensureIndexesResponse()
+}
+
+func (da *mockDataAccess) UpdateCustomFields(emailAddress string, values map[string]dataaccess.CustomFieldValue) error {
+	da.updateCustomFieldsCallCount++
+	return da.updateCustomFieldsResponse(emailAddress, values)
+}
+
+func (da *mockDataAccess) UpdateLanguages(emailAddress string, languages []dataaccess.Language) error {
+	da.updateLanguagesCallCount++
+	return da.updateLanguagesResponse(emailAddress, languages)
+}
+
+func (da *mockDataAccess) UpdateClearance(emailAddress string, cl *dataaccess.Clearance) error {
+	da.updateClearanceCallCount++
+	return da.updateClearanceResponse(emailAddress, cl)
+}
+
+func (da *mockDataAccess) UpdateWorkLocation(emailAddress string, l *dataaccess.WorkLocation) error {
+	da.updateWorkLocationCallCount++
+	return da.updateWorkLocationResponse(emailAddress, l)
+}
+
+func (da *mockDataAccess) FindProfilesNear(domain string, near dataaccess.Coordinates, radiusKm float64) ([]dataaccess.Profile, error) {
+	da.findProfilesNearCallCount++
+	return da.findProfilesNearResponse(domain, near, radiusKm)
+}
+
+func (da *mockDataAccess) UpdateWorkingHours(emailAddress string, h *dataaccess.WorkingHours) error {
+	da.updateWorkingHoursCallCount++
+	return da.updateWorkingHoursResponse(emailAddress, h)
+}
+
+func (da *mockDataAccess) RecordConsents(emailAddress string, consents []dataaccess.Consent) error {
+	da.recordConsentsCallCount++
+	return da.recordConsentsResponse(emailAddress, consents)
+}
+
+func (da *mockDataAccess) SaveBenchmarks(benchmarks []dataaccess.Benchmark) error {
+	da.saveBenchmarksCallCount++
+	return da.saveBenchmarksResponse(benchmarks)
+}
+
+func (da *mockDataAccess) GetBenchmark(band string) (*dataaccess.Benchmark, bool, error) {
+	da.getBenchmarkCallCount++
+	return da.getBenchmarkResponse(band)
+}
+
+func (da *mockDataAccess) SaveReportDefinition(d *dataaccess.ReportDefinition) error {
+	da.saveReportDefinitionCallCount++
+	return da.saveReportDefinitionResponse(d)
+}
+
+func (da *mockDataAccess) ListReportDefinitions(domain string) ([]dataaccess.ReportDefinition, error) {
+	da.listReportDefinitionsCallCount++
+	return da.listReportDefinitionsResponse(domain)
+}
+
+func (da *mockDataAccess) DeleteReportDefinition(domain string, id string) (bool, error) {
+	da.deleteReportDefinitionCallCount++
+	return da.deleteReportDefinitionResponse(domain, id)
+}
+
+func (da *mockDataAccess) RunReport(d dataaccess.ReportDefinition, consent dataaccess.ConsentSettings) (*dataaccess.ReportResult, error) {
+	da.runReportCallCount++
+	return da.runReportResponse(d, consent)
+}
+
+func (da *mockDataAccess) SaveReportSnapshot(s *dataaccess.ReportSnapshot) error {
+	da.saveReportSnapshotCallCount++
+	return da.saveReportSnapshotResponse(s)
+}
+
+func (da *mockDataAccess) GetReportSnapshot(domain string, report string) (*dataaccess.ReportSnapshot, bool, error) {
+	da.getReportSnapshotCallCount++
+	return da.getReportSnapshotResponse(domain, report)
+}
+
+func (da *mockDataAccess) SaveExportJob(j *dataaccess.ExportJob) error {
+	da.saveExportJobCallCount++
+	return da.saveExportJobResponse(j)
+}
+
+func (da *mockDataAccess) GetExportJob(id string) (*dataaccess.ExportJob, bool, error) {
+	da.getExportJobCallCount++
+	return da.getExportJobResponse(id)
+}
+
+func (da *mockDataAccess) ClaimExportJob(now time.Time) (*dataaccess.ExportJob, bool, error) {
+	da.claimExportJobCallCount++
+	return da.claimExportJobResponse(now)
+}
+
+func (da *mockDataAccess) PurgeExportJobs(before time.Time) ([]dataaccess.ExportJob, error) {
+	da.purgeExportJobsCallCount++
+	return da.purgeExportJobsResponse(before)
+}
+
+func (da *mockDataAccess) SaveImportPreview(p *dataaccess.ImportPreview) error {
+	da.saveImportPreviewCallCount++
+	return da.saveImportPreviewResponse(p)
+}
+
+func (da *mockDataAccess) TakeImportPreview(domain string, id string) (*dataaccess.ImportPreview, bool, error) {
+	da.takeImportPreviewCallCount++
+	return da.takeImportPreviewResponse(domain, id)
+}
+
+func (da *mockDataAccess) MergeProfiles(primary string, duplicate string) (*dataaccess.Profile, bool, error) {
+	da.mergeProfilesCallCount++
+	return da.mergeProfilesResponse(primary, duplicate)
+}
+
+func (da *mockDataAccess) FindProfileByExternalID(domain string, system string, id string) (*dataaccess.Profile, bool, error) {
+	da.findProfileByExternalIDCallCount++
+	return da.findProfileByExternalIDResponse(domain, system, id)
+}
+
+func (da *mockDataAccess) UpdateExternalIDs(emailAddress string, ids map[string]string) error {
+	da.updateExternalIDsCallCount++
+	return da.updateExternalIDsResponse(emailAddress, ids)
+}
+
+func (da *mockDataAccess) AppendProfileEvents(events []dataaccess.ProfileEvent) error {
+	da.appendProfileEventsCallCount++
+	return da.appendProfileEventsResponse(events)
+}
+
+func (da *mockDataAccess) ListProfileEvents(emailAddress string, after int) ([]dataaccess.ProfileEvent, error) {
+	da.listProfileEventsCallCount++
+	return da.listProfileEventsResponse(emailAddress, after)
+}
+
+func (da *mockDataAccess) SaveProfileSnapshot(s *dataaccess.ProfileSnapshot) error {
+	da.saveProfileSnapshotCallCount++
+	return da.saveProfileSnapshotResponse(s)
+}
+
+func (da *mockDataAccess) GetProfileSnapshot(emailAddress string, at time.Time) (*dataaccess.ProfileSnapshot, bool, error) {
+	da.getProfileSnapshotCallCount++
+	return da.getProfileSnapshotResponse(emailAddress, at)
+}
+
+func (da *mockDataAccess) ProjectProfile(s *dataaccess.ProfileSnapshot) error {
+	da.projectProfileCallCount++
+	return da.projectProfileResponse(s)
+}
+
+func (da *mockDataAccess) SaveSearchDocument(d *dataaccess.SearchDocument) error {
+	da.saveSearchDocumentCallCount++
+	return da.saveSearchDocumentResponse(d)
+}
+
+func (da *mockDataAccess) GetSearchDocument(emailAddress string) (*dataaccess.SearchDocument, bool, error) {
+	da.getSearchDocumentCallCount++
+	return da.getSearchDocumentResponse(emailAddress)
+}
+
+func (da *mockDataAccess) RemoveSearchDocument(emailAddress string) error {
+	da.removeSearchDocumentCallCount++
+	return da.removeSearchDocumentResponse(emailAddress)
+}
+
+func (da *mockDataAccess) SearchDocuments(domain string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error) {
+	da.searchDocumentsCallCount++
+	return da.searchDocumentsResponse(domain, q)
+}
+
+func (da *mockDataAccess) SaveTeamSummary(s *dataaccess.TeamSummary) error {
+	da.saveTeamSummaryCallCount++
+	return da.saveTeamSummaryResponse(s)
+}
+
+func (da *mockDataAccess) GetTeamSummary(manager string) (*dataaccess.TeamSummary, bool, error) {
+	da.getTeamSummaryCallCount++
+	return da.getTeamSummaryResponse(manager)
+}
+
+func (da *mockDataAccess) SaveProfileSummary(s *dataaccess.ProfileSummary) error {
+	da.saveProfileSummaryCallCount++
+	return da.saveProfileSummaryResponse(s)
+}
+
+func (da *mockDataAccess) GetProfileSummary(emailAddress string) (*dataaccess.ProfileSummary, bool, error) {
+	da.getProfileSummaryCallCount++
+	return da.getProfileSummaryResponse(emailAddress)
+}
+
+func (da *mockDataAccess) ListProfileSummaries(domain string) ([]dataaccess.ProfileSummary, error) {
+	da.listProfileSummariesCallCount++
+	return da.listProfileSummariesResponse(domain)
+}
+
+func (da *mockDataAccess) RemoveProfileSummary(emailAddress string) error {
+	da.removeProfileSummaryCallCount++
+	return da.removeProfileSummaryResponse(emailAddress)
+}
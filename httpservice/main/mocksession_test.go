@@ -1,12 +1,18 @@
 package main
 
-import "log"
+import (
+	"log"
+	"time"
+)
 
 type mockSession struct {
 	validateSessionValidResponse        bool
 	validateSessionEmailAddressResponse string
 	validateSessionWasCalled            bool
 	startSessionWasCalled               bool
+	impersonating                       string
+	impersonationDuration               time.Duration
+	endImpersonationWasCalled           bool
 }
 
 func (ms *mockSession) ValidateSession() (isValid bool, emailAddress string) {
@@ -19,3 +25,15 @@ func (ms *mockSession) StartSession(emailAddress string) {
 	log.Print("The session was started.")
 	ms.startSessionWasCalled = true
 }
+
+func (ms *mockSession) Impersonate(impersonator string, emailAddress string, d time.Duration) error {
+	log.Print("Impersonation was started.")
+	ms.impersonating = emailAddress
+	ms.impersonationDuration = d
+	return nil
+}
+
+func (ms *mockSession) EndImpersonation() {
+	log.Print("Impersonation was ended.")
+	ms.endImpersonationWasCalled = true
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The NotificationsHandler reads and updates the user's notification
+// preferences. JSON requests replace the preferences, while form posts mute or
+// unmute a single category, for use by the profile page.
+type NotificationsHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewNotificationsHandler creates an instance of the NotificationsHandler.
+func NewNotificationsHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *NotificationsHandler {
+	return &NotificationsHandler{da, sessionFactory}
+}
+
+func (handler NotificationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling notification preferences request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	profile, _, err := da.GetProfile(emailAddress)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", emailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.notificationPreferencesReadFailed")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		writeNotificationPreferences(w, profile.Notifications)
+		return
+	}
+
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	isJSON := strings.HasPrefix(r.Header.Get("Content-Type"), "application/json")
+
+	p := profile.Notifications
+	if isJSON {
+		p = dataaccess.NotificationPreferences{}
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidNotificationPreferences")
+			return
+		}
+	} else {
+		muted, err := strconv.ParseBool(r.FormValue("muted"))
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidMuted")
+			return
+		}
+		p.Mute(dataaccess.NotificationCategory(r.FormValue("category")), muted)
+	}
+
+	if err := p.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := da.UpdateNotificationPreferences(emailAddress, p); err != nil {
+		log.Printf("Failed to update the notification preferences of %s. %v", emailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.notificationPreferencesSaveFailed")
+		return
+	}
+
+	log.Printf("User %s has updated their notification preferences.", emailAddress)
+
+	if !isJSON {
+		http.Redirect(w, r, "/profile/", http.StatusFound)
+		return
+	}
+	writeNotificationPreferences(w, p)
+}
+
+func writeNotificationPreferences(w http.ResponseWriter, p dataaccess.NotificationPreferences) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		log.Printf("Failed to marshall the notification preferences, with error %s", err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func newNotificationsTestHandler(received *dataaccess.NotificationPreferences) (*NotificationsHandler, *mockDataAccess) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			p := dataaccess.NewProfile()
+			p.Notifications.Channels = []dataaccess.NotificationChannel{dataaccess.SlackChannel}
+			return p, true, nil
+		},
+		updateNotificationPreferencesResponse: func(emailAddress string, p dataaccess.NotificationPreferences) error {
+			*received = p
+			return nil
+		},
+	}
+
+	return NewNotificationsHandler(mda, sf), mda
+}
+
+func TestThatJSONRequestsReplaceTheNotificationPreferences(t *testing.T) {
+	var received dataaccess.NotificationPreferences
+	h, _ := newNotificationsTestHandler(&received)
+
+	r, _ := http.NewRequest("PUT", "http://example.com/profile/notifications/", strings.NewReader(`{"channels":["email","teams"],"frequency":"daily"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || len(received.Channels) != 2 || received.Frequency != dataaccess.DailyFrequency {
+		t.Errorf("Expected the preferences to be replaced, but received %d: %v", w.Code, received)
+	}
+}
+
+func TestThatFormPostsMuteACategory(t *testing.T) {
+	var received dataaccess.NotificationPreferences
+	h, _ := newNotificationsTestHandler(&received)
+
+	form := url.Values{"category": {"digest"}, "muted": {"true"}}
+	r, _ := http.NewRequest("POST", "http://example.com/profile/notifications/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	if received.Allows(dataaccess.DigestCategory) {
+		t.Error("Expected the digest to be muted.")
+	}
+
+	if len(received.Channels) != 1 || received.Channels[0] != dataaccess.SlackChannel {
+		t.Errorf("The other preferences should be unchanged, but the channels were %v", received.Channels)
+	}
+
+	if w.Code != http.StatusFound {
+		t.Errorf("Expected a redirect to the profile, but the status was %d.", w.Code)
+	}
+}
+
+func TestThatInvalidNotificationPreferencesAreRejected(t *testing.T) {
+	var received dataaccess.NotificationPreferences
+	h, mda := newNotificationsTestHandler(&received)
+
+	r, _ := http.NewRequest("PUT", "http://example.com/profile/notifications/", strings.NewReader(`{"channels":["pigeon"]}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest || mda.updateNotificationPreferencesCallCount != 0 {
+		t.Errorf("Expected the preferences to be rejected, but the status was %d.", w.Code)
+	}
+}
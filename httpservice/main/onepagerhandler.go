@@ -0,0 +1,105 @@
+package main
+
+import (
+	"image"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/onepager"
+)
+
+// The OnePagerHandler renders the PDF profile of someone in the user's
+// tenant, for including in client proposals, e.g.
+// /profile/onepager/?emailAddress=dev@example.com. The profile defaults to
+// the user's, and is laid out with the tenant's template and branding.
+type OnePagerHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewOnePagerHandler creates an instance of the OnePagerHandler.
+func NewOnePagerHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *OnePagerHandler {
+	return &OnePagerHandler{da, sessionFactory, time.Now}
+}
+
+func (handler OnePagerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling one pager request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	owner := r.FormValue("emailAddress")
+	if owner == "" {
+		owner = emailAddress
+	}
+	domain := dataaccess.GetDomain(emailAddress)
+	if dataaccess.GetDomain(owner) != domain {
+		writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	profile, found, err := da.GetProfile(owner)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", owner, err)
+		writeError(w, r, http.StatusInternalServerError, "error.onePagerFailed")
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+		return
+	}
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	completions, err := da.ListCourseCompletions(domain)
+	if err != nil {
+		log.Printf("Failed to list the course completions of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.onePagerFailed")
+		return
+	}
+	projects, err := da.ListProjects(domain)
+	if err != nil {
+		log.Printf("Failed to list the projects of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.onePagerFailed")
+		return
+	}
+
+	var logo image.Image
+	if settings.Branding.LogoURL != "" {
+		if logo, err = onepager.LoadLogo(settings.Branding.LogoURL); err != nil {
+			log.Printf("Failed to load the logo of %s, the one pager is rendered without it. %v", domain, err)
+		}
+	}
+
+	data, err := onepager.Render(settings.OnePagerTemplate, onepager.NewData(profile, completions, projects, handler.now()),
+		onepager.NewBranding(settings.Branding, logo))
+	if err != nil {
+		log.Printf("Failed to render the one pager of %s. %v", owner, err)
+		writeError(w, r, http.StatusInternalServerError, "error.onePagerFailed")
+		return
+	}
+
+	dataaccess.RecordAccess(da, audit.ProfileExported, profile.EmailAddress)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Content-Disposition", "inline; filename="+strconv.Quote(dataaccess.CleanTag(profile.EmailAddress)+".pdf"))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatOnePagersAreRenderedForPeopleInTheSameTenant(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	tests := []struct {
+		url            string
+		expectedStatus int
+	}{
+		{"http://example.com/profile/onepager/", http.StatusOK},
+		{"http://example.com/profile/onepager/?emailAddress=lead@github.com", http.StatusOK},
+		{"http://example.com/profile/onepager/?emailAddress=dev@example.com", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		mda := &mockDataAccess{
+			getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+				return &dataaccess.Profile{EmailAddress: emailAddress, Skills: []dataaccess.Skill{{Skill: "go", Level: dataaccess.ExpertLevel}}}, true, nil
+			},
+			getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+				return dataaccess.DefaultSettings(), nil
+			},
+			listCourseCompletionsResponse: func(domain string) ([]dataaccess.CourseCompletion, error) {
+				return nil, nil
+			},
+			listProjectsResponse: func(domain string) ([]dataaccess.Project, error) {
+				return nil, nil
+			},
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, test.url, nil)
+		NewOnePagerHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedStatus {
+			t.Errorf("For %s, expected status %d, but was %d.", test.url, test.expectedStatus, w.Code)
+			continue
+		}
+		if test.expectedStatus == http.StatusOK && (w.Header().Get("Content-Type") != "application/pdf" || !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-"))) {
+			t.Errorf("For %s, expected a PDF, but got %s.", test.url, w.Header().Get("Content-Type"))
+		}
+	}
+}
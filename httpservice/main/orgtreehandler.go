@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The OrgTreeHandler returns the management hierarchy of the user's domain,
+// for display in an org chart.
+type OrgTreeHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewOrgTreeHandler creates an instance of the OrgTreeHandler.
+func NewOrgTreeHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *OrgTreeHandler {
+	return &OrgTreeHandler{da, sessionFactory}
+}
+
+func (handler OrgTreeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling org tree request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	domain := dataaccess.GetDomain(emailAddress)
+	tree, err := dataaccess.WithContext(handler.DataAccess, r.Context()).GetOrgTree(domain)
+
+	if err != nil {
+		log.Print("Unable to retrieve the org tree.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.orgTreeReadFailed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(tree); err != nil {
+		log.Printf("Failed to marshall the org tree, with error %s", err)
+	}
+}
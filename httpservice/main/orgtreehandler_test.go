@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatTheOrgTreeIsReturnedForTheUsersDomain(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@GitHub.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	var requestedDomain string
+	mda := &mockDataAccess{
+		getOrgTreeResponse: func(domain string) (*dataaccess.OrgNode, error) {
+			requestedDomain = domain
+			return dataaccess.NewOrgTree(domain, []dataaccess.Profile{{EmailAddress: "a-h@github.com"}}), nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/report/org/", nil)
+
+	NewOrgTreeHandler(mda, sf).ServeHTTP(w, r)
+
+	if requestedDomain != "github.com" {
+		t.Errorf("Expected the org tree for github.com to be requested, but was %s.", requestedDomain)
+	}
+
+	var tree dataaccess.OrgNode
+	if err := json.NewDecoder(w.Body).Decode(&tree); err != nil {
+		t.Fatal("Failed to decode the org tree.", err)
+	}
+
+	if len(tree.Children) != 1 || tree.Children[0].ID != "a-h@github.com" {
+		t.Errorf("Expected the tree to contain a-h@github.com, but received %v", tree.Children)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The WorkingHoursHandler reads, replaces and resets the user's working
+// hours, e.g. {"start":"08:00","end":"16:00","days":[1,2,3,4,5]}.
+type WorkingHoursHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewWorkingHoursHandler creates an instance of the WorkingHoursHandler.
+func NewWorkingHoursHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *WorkingHoursHandler {
+	return &WorkingHoursHandler{da, sessionFactory}
+}
+
+// The OverlapHandler lists the people in the user's domain who work for at
+// least a number of hours during a time window, for assembling follow the
+// sun rosters, e.g.
+// /report/overlap/?start=2018-09-03T22:00:00Z&end=2018-09-04T06:00:00Z&hours=4.
+// People are listed with the most overlap first. The usual department, cost
+// center and language filters also apply.
+type OverlapHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewOverlapHandler creates an instance of the OverlapHandler.
+func NewOverlapHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *OverlapHandler {
+	return &OverlapHandler{da, sessionFactory}
+}
+
+// maxOverlapWindow is the longest time window which can be searched.
+const maxOverlapWindow = 7 * 24 * time.Hour
+
+type overlapPerson struct {
+	EmailAddress string                     `json:"emailAddress"`
+	Name         string                     `json:"name,omitempty"`
+	TimeZone     string                     `json:"timeZone"`
+	OverlapHours float64                    `json:"overlapHours"`
+	Periods      []dataaccess.WorkingPeriod `json:"periods"`
+}
+
+func (handler WorkingHoursHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling working hours request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		profile, found, err := da.GetProfile(emailAddress)
+		if err != nil {
+			log.Printf("Failed to get the profile of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.workingHoursReadFailed")
+			return
+		}
+		if !found {
+			writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+			return
+		}
+		writeJSON(w, http.StatusOK, profile.Hours())
+	case http.MethodPut:
+		var h dataaccess.WorkingHours
+		if err := json.NewDecoder(r.Body).Decode(&h); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidWorkingHours")
+			return
+		}
+		if err := h.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := da.UpdateWorkingHours(emailAddress, &h); err != nil {
+			log.Printf("Failed to update the working hours of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.workingHoursSaveFailed")
+			return
+		}
+		writeJSON(w, http.StatusOK, h)
+	case http.MethodDelete:
+		if err := da.UpdateWorkingHours(emailAddress, nil); err != nil {
+			log.Printf("Failed to reset the working hours of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.workingHoursSaveFailed")
+			return
+		}
+		writeJSON(w, http.StatusOK, dataaccess.DefaultWorkingHours)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func (handler OverlapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling working hours overlap request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	from, to, minimum, ok := overlapQuery(r)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "error.invalidOverlapQuery")
+		return
+	}
+
+	profiles, err := filteredProfiles(dataaccess.WithContext(handler.DataAccess, r.Context()), r, emailAddress)
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+
+	people := []overlapPerson{}
+	for _, p := range profiles {
+		overlap := p.WorkingOverlap(from, to)
+		if overlap == 0 || overlap < minimum {
+			continue
+		}
+		people = append(people, overlapPerson{
+			EmailAddress: p.EmailAddress,
+			Name:         p.Name,
+			TimeZone:     p.Location().String(),
+			OverlapHours: overlap.Hours(),
+			Periods:      p.WorkingPeriods(from, to),
+		})
+	}
+	sort.Slice(people, func(i, j int) bool {
+		if people[i].OverlapHours != people[j].OverlapHours {
+			return people[i].OverlapHours > people[j].OverlapHours
+		}
+		return people[i].EmailAddress < people[j].EmailAddress
+	})
+
+	writeJSON(w, http.StatusOK, people)
+}
+
+// overlapQuery reads the time window, and the minimum overlap with it, from
+// the query string.
+func overlapQuery(r *http.Request) (from, to time.Time, minimum time.Duration, ok bool) {
+	q := r.URL.Query()
+	from, err := time.Parse(time.RFC3339, q.Get("start"))
+	if err != nil {
+		return
+	}
+	to, err = time.Parse(time.RFC3339, q.Get("end"))
+	if err != nil || !to.After(from) || to.Sub(from) > maxOverlapWindow {
+		return
+	}
+	hours, err := strconv.ParseFloat(q.Get("hours"), 64)
+	if err != nil || hours < 0 {
+		return
+	}
+	return from, to, time.Duration(hours * float64(time.Hour)), true
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatPeopleWithEnoughOverlapAreListed(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	mda := &mockDataAccess{
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "ny@github.com", TimeZone: "America/New_York"},
+				{EmailAddress: "london@github.com", TimeZone: "Europe/London"},
+				{EmailAddress: "sydney@github.com", TimeZone: "Australia/Sydney"},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		url          string
+		expectedCode int
+		expected     []string
+	}{
+		{"/report/overlap/?start=2018-09-03T06:00:00Z&end=2018-09-03T14:00:00Z&hours=1", http.StatusOK, []string{"london@github.com", "ny@github.com", "sydney@github.com"}},
+		{"/report/overlap/?start=2018-09-03T06:00:00Z&end=2018-09-03T14:00:00Z&hours=4", http.StatusOK, []string{"london@github.com"}},
+		{"/report/overlap/?start=2018-09-03T06:00:00Z&end=2018-09-03T14:00:00Z&hours=9", http.StatusOK, nil},
+		{"/report/overlap/?start=2018-09-03T14:00:00Z&end=2018-09-03T06:00:00Z&hours=1", http.StatusBadRequest, nil},
+		{"/report/overlap/?start=2018-09-03T06:00:00Z&end=2018-09-30T06:00:00Z&hours=1", http.StatusBadRequest, nil},
+		{"/report/overlap/?start=monday&end=2018-09-03T06:00:00Z&hours=1", http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "http://example.com"+test.url, nil)
+		NewOverlapHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.url, test.expectedCode, w.Code)
+			continue
+		}
+		if test.expectedCode != http.StatusOK {
+			continue
+		}
+		var people []overlapPerson
+		if err := json.NewDecoder(w.Body).Decode(&people); err != nil {
+			t.Fatal("Failed to decode the people.", err)
+		}
+		var actual []string
+		for _, p := range people {
+			actual = append(actual, p.EmailAddress)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("For %s, expected %v, but got %v", test.url, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The ProfileEventHandler reads the event log of a profile, e.g.
+// /profile/events/?emailAddress=dev@example.com&after=10, which lists the
+// events after the 10th, so that clients can follow the changes. With
+// &at=2018-03-01T00:00:00Z, it returns the person's skills and availability
+// at that time instead. The profile defaults to the user's, and can be
+// anyone's in the same tenant.
+type ProfileEventHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewProfileEventHandler creates an instance of the ProfileEventHandler.
+func NewProfileEventHandler(da dataaccess.DataAccess) *ProfileEventHandler {
+	return &ProfileEventHandler{da}
+}
+
+func (handler ProfileEventHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling profile event request.")
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, "error.sessionRequired")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	owner := c.EmailAddress
+	if other := r.FormValue("emailAddress"); other != "" {
+		owner = other
+	}
+	profile, ok := colleague(w, r, da, c.EmailAddress, owner)
+	if !ok {
+		return
+	}
+
+	if v := r.FormValue("at"); v != "" {
+		at, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidProfileEventRequest")
+			return
+		}
+		state, err := dataaccess.ProfileAt(da, profile.EmailAddress, at)
+		if err != nil {
+			log.Printf("Failed to read the events of %s. %v", profile.EmailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.profileEventsReadFailed")
+			return
+		}
+		writeJSON(w, http.StatusOK, state)
+		return
+	}
+
+	var after int
+	if v := r.FormValue("after"); v != "" {
+		var err error
+		if after, err = strconv.Atoi(v); err != nil || after < 0 {
+			writeError(w, r, http.StatusBadRequest, "error.invalidProfileEventRequest")
+			return
+		}
+	}
+	events, err := da.ListProfileEvents(profile.EmailAddress, after)
+	if err != nil {
+		log.Printf("Failed to read the events of %s. %v", profile.EmailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.profileEventsReadFailed")
+		return
+	}
+	writeJSON(w, http.StatusOK, events)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatProfileEventsCanBeFollowed(t *testing.T) {
+	march := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	events := []dataaccess.ProfileEvent{
+		{EmailAddress: "adrian@github.com", Sequence: 1, Type: dataaccess.SkillAdded, Skill: &dataaccess.Skill{Skill: "go", Level: 3}, Time: march},
+		{EmailAddress: "adrian@github.com", Sequence: 2, Type: dataaccess.LevelChanged, Skill: &dataaccess.Skill{Skill: "go", Level: 4}, Time: march.AddDate(0, 1, 0)},
+	}
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{EmailAddress: emailAddress}, true, nil
+		},
+		listProfileEventsResponse: func(emailAddress string, after int) ([]dataaccess.ProfileEvent, error) {
+			return events[after:], nil
+		},
+		getProfileSnapshotResponse: func(emailAddress string, at time.Time) (*dataaccess.ProfileSnapshot, bool, error) {
+			return nil, false, nil
+		},
+	}
+	dev := caller.Caller{EmailAddress: "dev@github.com"}
+
+	tests := []struct {
+		url            string
+		expectedCode   int
+		expectedEvents int
+		expectedLevel  dataaccess.DreyfusLevel
+	}{
+		{"/profile/events/?emailAddress=adrian@github.com", http.StatusOK, 2, 0},
+		{"/profile/events/?emailAddress=adrian@github.com&after=1", http.StatusOK, 1, 0},
+		{"/profile/events/?emailAddress=adrian@github.com&at=2018-03-15T00:00:00Z", http.StatusOK, 0, 3},
+		{"/profile/events/?emailAddress=adrian@github.com&after=first", http.StatusBadRequest, 0, 0},
+		{"/profile/events/?emailAddress=adrian@example.com", http.StatusNotFound, 0, 0},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		NewProfileEventHandler(mda).ServeHTTP(w, newRequestWithCaller("GET", test.url, "", dev))
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but got %d", test.url, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		if test.expectedLevel > 0 {
+			var state dataaccess.ProfileSnapshot
+			if err := json.NewDecoder(w.Body).Decode(&state); err != nil {
+				t.Fatal(err)
+			}
+			if len(state.Skills) != 1 || state.Skills[0].Level != test.expectedLevel {
+				t.Errorf("For %s, expected level %d, but got %+v", test.url, test.expectedLevel, state)
+			}
+			continue
+		}
+		var actual []dataaccess.ProfileEvent
+		if err := json.NewDecoder(w.Body).Decode(&actual); err != nil {
+			t.Fatal(err)
+		}
+		if len(actual) != test.expectedEvents {
+			t.Errorf("For %s, expected %d events, but got %d", test.url, test.expectedEvents, len(actual))
+		}
+	}
+}
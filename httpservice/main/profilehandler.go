@@ -1,14 +1,18 @@
 package main
 
 import (
-	"fmt"
 	"log"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/a-h/pill/badges"
+	"github.com/a-h/pill/contentfilter"
 	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/i18n"
+	"github.com/a-h/pill/middleware"
 )
 
 // The ProfileHandler handles updating profile information.
@@ -42,18 +46,40 @@ func handleProfileGet(w http.ResponseWriter, r *http.Request, handler ProfileHan
 
 	log.Print("The session is valid, rendering the profile.")
 
-	profile, _, err := handler.DataAccess.GetProfile(emailAddress)
+	profile, _, err := dataaccess.WithContext(handler.DataAccess, r.Context()).GetProfile(emailAddress)
+
+	if err == dataaccess.ErrUnavailable {
+		log.Printf("Unable to retrieve the profile for user %s while the database is unavailable.", emailAddress)
+		writeError(w, r, http.StatusServiceUnavailable, "error.databaseUnavailable")
+		return
+	}
 
 	if err != nil {
-		msg := fmt.Sprintf("Unable to retrieve the profile for user %s.", emailAddress)
-		log.Print(msg, err)
-		http.Error(w, msg, http.StatusInternalServerError)
+		log.Printf("Unable to retrieve the profile for user %s. %v", emailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.profileReadFailed", emailAddress)
+		return
+	}
+
+	// Clients using a token, such as pillctl, read the profile so that they
+	// can post it back with their changes.
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		writeJSON(w, http.StatusOK, profile)
 		return
 	}
 
+	language := profile.Language
+	if language == "" {
+		language = i18n.DefaultLanguage
+	}
+
 	p := &profileModel{
-		Profile: profile,
-		Skills:  strings.Join(flattenSkill(profile.Skills), ","),
+		Profile:   profile,
+		Skills:    strings.Join(flattenSkill(profile.Skills), ","),
+		CSRFToken: middleware.CSRFToken(r),
+		Language:  language,
+		Languages: languageOptions(),
+		Badges:    badgeModels(profile.Badges),
+		Streak:    badges.CurrentStreak(*profile),
 	}
 
 	renderTemplate(w, "profile.html", p)
@@ -84,7 +110,7 @@ func handleProfilePost(w http.ResponseWriter, r *http.Request, handler ProfileHa
 
 	if err != nil {
 		log.Print("Failed to parse the form post.")
-		http.Error(w, "Invalid form post.", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, "error.invalidForm")
 		return
 	}
 
@@ -119,13 +145,92 @@ func handleProfilePost(w http.ResponseWriter, r *http.Request, handler ProfileHa
 	pu.Availability = dataaccess.RagStatus(availability)
 	pu.EmailAddress = emailAddress
 	pu.Skills = getSkillsFromMap(skills)
+	if _, ok := r.Form["name"]; ok {
+		name := strings.TrimSpace(r.Form.Get("name"))
+		pu.Name = &name
+	}
+	if _, ok := r.Form["manager"]; ok {
+		manager := strings.TrimSpace(r.Form.Get("manager"))
+		pu.Manager = &manager
+	}
+	if _, ok := r.Form["department"]; ok {
+		department := strings.TrimSpace(r.Form.Get("department"))
+		pu.Department = &department
+	}
+	if _, ok := r.Form["costCenter"]; ok {
+		costCenter := strings.TrimSpace(r.Form.Get("costCenter"))
+		pu.CostCenter = &costCenter
+	}
+	if _, ok := r.Form["timeZone"]; ok {
+		timeZone := strings.TrimSpace(r.Form.Get("timeZone"))
+		pu.TimeZone = &timeZone
+	}
+	if _, ok := r.Form["bio"]; ok {
+		bio := strings.TrimSpace(r.Form.Get("bio"))
+		pu.Bio = &bio
+	}
+	if _, ok := r.Form["language"]; ok {
+		language := r.Form.Get("language")
+		if language == "" || i18n.IsSupported(language) {
+			pu.Language = &language
+		}
+	}
 
-	_, err = handler.DataAccess.UpdateProfile(pu)
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	settings, err := da.GetSettings(dataaccess.GetDomain(emailAddress))
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", emailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	fields := skillFields(pu.Skills)
+	if pu.Bio != nil {
+		fields = append(fields, contentfilter.Field{Name: "bio", Text: *pu.Bio})
+	}
+	fields = append(fields, contentfilter.Field{Name: "justification", Text: r.Form.Get("justification")})
+	if !checkContent(w, r, da, dataaccess.GetDomain(emailAddress), fields...) {
+		return
+	}
+	if settings.StrictVocabulary && !checkVocabulary(w, r, da, pu, time.Now()) {
+		return
+	}
+
+	_, err = da.UpdateProfile(pu)
+
+	if err == dataaccess.ErrQuarantined {
+		log.Printf("The change to the profile of %s has been quarantined.", emailAddress)
+		writeError(w, r, http.StatusAccepted, "error.changeQuarantined")
+		return
+	}
+
+	if err == dataaccess.ErrTenantMoving {
+		log.Printf("Unable to save profile for user %s while the tenant is moving between shards.", emailAddress)
+		writeError(w, r, http.StatusServiceUnavailable, "error.tenantMoving")
+		return
+	}
+
+	if err == dataaccess.ErrReadOnly {
+		log.Printf("Unable to save profile for user %s while pill is read only.", emailAddress)
+		writeError(w, r, http.StatusServiceUnavailable, "error.readOnly")
+		return
+	}
+
+	if err == dataaccess.ErrUnavailable {
+		log.Printf("Unable to save profile for user %s while the database is unavailable.", emailAddress)
+		writeError(w, r, http.StatusServiceUnavailable, "error.databaseUnavailable")
+		return
+	}
+
+	if _, ok := err.(dataaccess.ValidationError); ok {
+		log.Printf("The change to the profile of %s was rejected. %v", emailAddress, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	if err != nil {
-		msg := fmt.Sprintf("Unable to save profile for user %s.", emailAddress)
-		log.Print(msg)
-		http.Error(w, msg, http.StatusBadRequest)
+		log.Printf("Unable to save profile for user %s.", emailAddress)
+		writeError(w, r, http.StatusBadRequest, "error.profileSaveFailed", emailAddress)
 		return
 	}
 
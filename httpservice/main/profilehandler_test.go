@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -10,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/middleware"
 )
 
 func TestThatItIsNotPossibleToAccessTheProfileWithAnInvalidSession(t *testing.T) {
@@ -70,6 +72,36 @@ func TestThatTheProfileHandlerRendersTheProfileView(t *testing.T) {
 	}
 }
 
+func TestThatClientsCanReadTheProfileAsJSON(t *testing.T) {
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return &mockSession{validateSessionValidResponse: true, validateSessionEmailAddressResponse: "a-h@github.com"}
+	}
+
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (profile *dataaccess.Profile, ok bool, err error) {
+			p := dataaccess.NewProfile()
+			p.EmailAddress = emailAddress
+			p.Skills = []dataaccess.Skill{{Skill: "go", Level: dataaccess.ExpertLevel}}
+			return p, true, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/profile", nil)
+	r.Header.Set("Accept", "application/json")
+
+	NewProfileHandler(mda, sf).ServeHTTP(w, r)
+
+	var actual dataaccess.Profile
+	if err := json.NewDecoder(w.Body).Decode(&actual); err != nil {
+		t.Fatalf("Expected the profile as JSON, but received %v", err)
+	}
+
+	if actual.EmailAddress != "a-h@github.com" || len(actual.Skills) != 1 || actual.Skills[0].Skill != "go" {
+		t.Errorf("Expected the profile of a-h@github.com, but was %v", actual)
+	}
+}
+
 func TestThatAfterAProfileUpdateTheUserIsRedirectedToTheReport(t *testing.T) {
 	ms := &mockSession{
 		validateSessionValidResponse:        true,
@@ -87,6 +119,9 @@ func TestThatAfterAProfileUpdateTheUserIsRedirectedToTheReport(t *testing.T) {
 	var receivedSkills []dataaccess.Skill
 
 	mda := &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
 		updateProfileResponse: func(update *dataaccess.ProfileUpdate) (*dataaccess.Profile, error) {
 			receivedAvailability = update.Availability
 			receivedEmailAddress = update.EmailAddress
@@ -178,3 +213,124 @@ func containsAll(receivedSkills []dataaccess.Skill, expectedSkills ...dataaccess
 
 	return found == len(expectedSkills)
 }
+
+func TestThatTheProfileFormIncludesTheCSRFToken(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (profile *dataaccess.Profile, ok bool, err error) {
+			return dataaccess.NewProfile(), true, nil
+		},
+	}
+
+	csrf := middleware.NewCSRF([]byte("0123456789abcdef0123456789abcdef"))
+	ph := csrf.Handler(NewProfileHandler(mda, sf))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/profile", nil)
+
+	ph.ServeHTTP(w, r)
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected the CSRF cookie to be set, but received %v", cookies)
+	}
+
+	if !strings.Contains(w.Body.String(), `value="`+cookies[0].Value+`"`) {
+		t.Error("The profile form should submit the CSRF token.")
+	}
+}
+
+func TestThatTheNameAndManagerAreOnlyUpdatedWhenPosted(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	tests := []struct {
+		form            url.Values
+		expectedManager string
+	}{
+		{url.Values{"availability": {"1"}}, ""},
+		{url.Values{"availability": {"1"}, "manager": {" boss@github.com "}}, "boss@github.com"},
+	}
+
+	for _, test := range tests {
+		var received *dataaccess.ProfileUpdate
+		mda := &mockDataAccess{
+			getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+				return dataaccess.DefaultSettings(), nil
+			},
+			updateProfileResponse: func(update *dataaccess.ProfileUpdate) (*dataaccess.Profile, error) {
+				received = update
+				return dataaccess.NewProfile(), nil
+			},
+		}
+
+		r, _ := http.NewRequest("POST", "http://example.com/profile", strings.NewReader(test.form.Encode()))
+		r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+		NewProfileHandler(mda, sf).ServeHTTP(httptest.NewRecorder(), r)
+
+		if test.expectedManager == "" && received.Manager != nil {
+			t.Errorf("For form %v, the manager should not be updated, but was set to %s.", test.form, *received.Manager)
+		}
+
+		if test.expectedManager != "" && (received.Manager == nil || *received.Manager != test.expectedManager) {
+			t.Errorf("For form %v, expected the manager to be %s.", test.form, test.expectedManager)
+		}
+	}
+}
+
+func TestThatBiosWithPersonalInformationAreRejected(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	mda := &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+		updateProfileResponse: func(update *dataaccess.ProfileUpdate) (*dataaccess.Profile, error) {
+			return dataaccess.NewProfile(), nil
+		},
+	}
+
+	tests := []struct {
+		bio          string
+		expectedCode int
+	}{
+		{"Backend developer, mostly Go.", http.StatusFound},
+		{"Backend developer, call 07700 900123.", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		form := url.Values{"availability": {"1"}, "bio": {test.bio}}
+		r, _ := http.NewRequest("POST", "http://example.com/profile", strings.NewReader(form.Encode()))
+		r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+
+		NewProfileHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For bio '%s', expected status %d, but was %d.", test.bio, test.expectedCode, w.Code)
+		}
+	}
+	if mda.updateProfileCallCount != 1 {
+		t.Errorf("Expected only the acceptable bio to be saved, but the profile was updated %d times.", mda.updateProfileCallCount)
+	}
+}
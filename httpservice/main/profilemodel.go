@@ -1,8 +1,60 @@
 package main
 
-import "github.com/a-h/pill/dataaccess"
+import (
+	"time"
+
+	"github.com/a-h/pill/badges"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/i18n"
+)
 
 type profileModel struct {
-	Profile *dataaccess.Profile
-	Skills  string
+	Profile   *dataaccess.Profile
+	Skills    string
+	CSRFToken string
+	// Language is the profile's language, or the default language.
+	Language  string
+	Languages []languageOption
+	Badges    []badgeModel
+	// Streak is how many quarters in a row the profile has been updated.
+	Streak int
+}
+
+type badgeModel struct {
+	Title       string
+	Description string
+	Awarded     time.Time
+}
+
+func badgeModels(awarded []dataaccess.Badge) []badgeModel {
+	var models []badgeModel
+	for _, b := range awarded {
+		if r, ok := badges.Find(b.Name); ok {
+			models = append(models, badgeModel{r.Title, r.Description, b.Awarded})
+		}
+	}
+	return models
+}
+
+type languageOption struct {
+	Code string
+	Name string
+}
+
+func languageOptions() []languageOption {
+	var options []languageOption
+	for _, l := range i18n.Supported() {
+		options = append(options, languageOption{l, i18n.Translate(l, "language.name")})
+	}
+	return options
+}
+
+type loginModel struct {
+	CSRFToken string
+}
+
+// cliLoginModel asks the person to confirm that pillctl can act as them.
+type cliLoginModel struct {
+	EmailAddress string
+	CSRFToken    string
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The ProfileSummaryHandler returns the summary a language model has written
+// of what someone in the user's tenant can do, e.g.
+// /profile/summary/?emailAddress=dev@example.com. The profile defaults to the
+// user's. Posting asks for the user's own summary to be written again the
+// next time summaries are updated.
+type ProfileSummaryHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewProfileSummaryHandler creates an instance of the ProfileSummaryHandler.
+func NewProfileSummaryHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *ProfileSummaryHandler {
+	return &ProfileSummaryHandler{da, sessionFactory}
+}
+
+func (handler ProfileSummaryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling profile summary request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	owner := strings.ToLower(r.FormValue("emailAddress"))
+	if owner == "" || r.Method == http.MethodPost {
+		owner = strings.ToLower(emailAddress)
+	}
+	domain := dataaccess.GetDomain(emailAddress)
+	if !strings.EqualFold(dataaccess.GetDomain(owner), domain) {
+		writeError(w, r, http.StatusNotFound, "error.profileSummaryNotFound")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	if !settings.Summaries.Enabled {
+		writeError(w, r, http.StatusNotFound, "error.profileSummaryNotFound")
+		return
+	}
+
+	s, found, err := da.GetProfileSummary(owner)
+	if err != nil {
+		log.Printf("Failed to read the profile summary of %s. %v", owner, err)
+		writeError(w, r, http.StatusInternalServerError, "error.profileSummaryFailed")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		if !found {
+			writeError(w, r, http.StatusNotFound, "error.profileSummaryNotFound")
+			return
+		}
+		writeJSON(w, http.StatusOK, s)
+		return
+	}
+
+	if !found {
+		s = &dataaccess.ProfileSummary{EmailAddress: owner, Domain: domain}
+	}
+	s.Regenerate = true
+	if err := da.SaveProfileSummary(s); err != nil {
+		log.Printf("Failed to flag the profile summary of %s to be written again. %v", owner, err)
+		writeError(w, r, http.StatusInternalServerError, "error.profileSummaryFailed")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, s)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestProfileSummaryHandler(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		url          string
+		enabled      bool
+		expectedCode int
+		expectedSave string
+	}{
+		{"disabled", "GET", "/profile/summary/", false, http.StatusNotFound, ""},
+		{"own", "GET", "/profile/summary/", true, http.StatusOK, ""},
+		{"colleague", "GET", "/profile/summary/?emailAddress=dev@github.com", true, http.StatusOK, ""},
+		{"missing", "GET", "/profile/summary/?emailAddress=new@github.com", true, http.StatusNotFound, ""},
+		{"other tenant", "GET", "/profile/summary/?emailAddress=dev@example.com", true, http.StatusNotFound, ""},
+		{"regenerate own", "POST", "/profile/summary/?emailAddress=dev@github.com", true, http.StatusAccepted, "a-h@github.com"},
+		{"delete", "DELETE", "/profile/summary/", true, http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, test := range tests {
+		var saved *dataaccess.ProfileSummary
+		mda := &mockDataAccess{
+			getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+				return dataaccess.Settings{Summaries: dataaccess.SummarySettings{Enabled: test.enabled}}, nil
+			},
+			getProfileSummaryResponse: func(emailAddress string) (*dataaccess.ProfileSummary, bool, error) {
+				if emailAddress == "new@github.com" {
+					return nil, false, nil
+				}
+				return &dataaccess.ProfileSummary{EmailAddress: emailAddress, Summary: "Writes Go."}, true, nil
+			},
+			saveProfileSummaryResponse: func(s *dataaccess.ProfileSummary) error {
+				saved = s
+				return nil
+			},
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(test.method, "http://example.com"+test.url, nil)
+		NewProfileSummaryHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("%s: expected status %d, but was %d.", test.name, test.expectedCode, w.Code)
+		}
+		if test.expectedSave == "" && saved != nil {
+			t.Errorf("%s: expected nothing to be saved, but %+v was.", test.name, saved)
+		}
+		if test.expectedSave != "" && (saved == nil || saved.EmailAddress != test.expectedSave || !saved.Regenerate) {
+			t.Errorf("%s: expected the summary of %s to be flagged, but received %+v", test.name, test.expectedSave, saved)
+		}
+	}
+}
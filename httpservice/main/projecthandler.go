@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/staffing"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The ProjectHandler lists, creates, updates and deletes the projects of the
+// user's domain.
+type ProjectHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewProjectHandler creates an instance of the ProjectHandler.
+func NewProjectHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *ProjectHandler {
+	return &ProjectHandler{da, sessionFactory, time.Now}
+}
+
+// The ProjectTeamHandler suggests people to book onto a project to cover the
+// requirements its current allocations don't.
+type ProjectTeamHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewProjectTeamHandler creates an instance of the ProjectTeamHandler.
+func NewProjectTeamHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *ProjectTeamHandler {
+	return &ProjectTeamHandler{da, sessionFactory}
+}
+
+// projectModel is a project with the people booked onto it.
+type projectModel struct {
+	dataaccess.Project
+	Allocations []projectAllocation `json:"allocations"`
+	// Uncovered lists the requirements which none of the allocated people
+	// meet.
+	Uncovered []dataaccess.SkillRequirement `json:"uncovered"`
+}
+
+type projectAllocation struct {
+	EmailAddress string             `json:"emailAddress"`
+	Name         string             `json:"name,omitempty"`
+	Booking      dataaccess.Booking `json:"booking"`
+}
+
+func newProjectModel(p dataaccess.Project, profiles []dataaccess.Profile) projectModel {
+	allocations := []projectAllocation{}
+	for _, profile := range profiles {
+		for _, b := range profile.Bookings {
+			if b.ProjectID == p.ID {
+				allocations = append(allocations, projectAllocation{profile.EmailAddress, profile.Name, b})
+			}
+		}
+	}
+	return projectModel{p, allocations, staffing.Uncovered(p, profiles)}
+}
+
+// domainProject returns the project if it's in the user's domain, writing a
+// 404 if it isn't.
+func domainProject(w http.ResponseWriter, r *http.Request, da dataaccess.DataAccess, emailAddress string, id string) (*dataaccess.Project, bool) {
+	p, found, err := da.GetProject(id)
+	if err != nil {
+		log.Printf("Failed to get project %s. %v", id, err)
+		writeError(w, r, http.StatusInternalServerError, "error.projectReadFailed")
+		return nil, false
+	}
+	if !found || p.Domain != dataaccess.GetDomain(emailAddress) {
+		writeError(w, r, http.StatusNotFound, "error.projectNotFound")
+		return nil, false
+	}
+	return p, true
+}
+
+func (handler ProjectHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling project request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(emailAddress)
+	id := r.FormValue("id")
+
+	switch r.Method {
+	case http.MethodGet:
+		if id == "" {
+			projects, err := da.ListProjects(domain)
+			if err != nil {
+				log.Printf("Failed to list the projects of %s. %v", domain, err)
+				writeError(w, r, http.StatusInternalServerError, "error.projectReadFailed")
+				return
+			}
+			if projects == nil {
+				projects = []dataaccess.Project{}
+			}
+			writeJSON(w, http.StatusOK, projects)
+			return
+		}
+		p, ok := domainProject(w, r, da, emailAddress, id)
+		if !ok {
+			return
+		}
+		profiles, err := da.ListProfiles(emailAddress)
+		if err != nil {
+			log.Print("Unable to retrieve the list of profiles.", err)
+			writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+			return
+		}
+		writeJSON(w, http.StatusOK, newProjectModel(*p, profiles))
+	case http.MethodPost, http.MethodPut:
+		var p dataaccess.Project
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidProject")
+			return
+		}
+
+		now := handler.now()
+		status := http.StatusCreated
+		// Projects created in pill are going ahead. Draft projects from a CRM
+		// are confirmed by posting them with the confirmed status.
+		existingStatus := dataaccess.ProjectConfirmed
+		if p.ID == "" {
+			p.ID = bson.NewObjectId().Hex()
+			p.Created = now
+		} else {
+			existing, ok := domainProject(w, r, da, emailAddress, p.ID)
+			if !ok {
+				return
+			}
+			p.Created, p.Opportunity = existing.Created, existing.Opportunity
+			existingStatus = existing.Status
+			status = http.StatusOK
+		}
+		p.Domain = domain
+		p.Name = strings.TrimSpace(p.Name)
+		if p.Status == "" {
+			p.Status = existingStatus
+		}
+		p.Updated = now
+		for i := range p.Requirements {
+			p.Requirements[i].Skill = dataaccess.CleanTag(strings.TrimSpace(p.Requirements[i].Skill))
+		}
+
+		err := da.SaveProject(&p)
+		if _, ok := err.(dataaccess.ValidationError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Failed to save project %s. %v", p.ID, err)
+			writeError(w, r, http.StatusInternalServerError, "error.projectSaveFailed")
+			return
+		}
+
+		log.Printf("User %s has saved project %s.", emailAddress, p.ID)
+		writeJSON(w, status, p)
+	case http.MethodDelete:
+		if _, ok := domainProject(w, r, da, emailAddress, id); !ok {
+			return
+		}
+		if err := da.DeleteProject(id); err != nil {
+			log.Printf("Failed to delete project %s. %v", id, err)
+			writeError(w, r, http.StatusInternalServerError, "error.projectSaveFailed")
+			return
+		}
+
+		log.Printf("User %s has deleted project %s.", emailAddress, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func (handler ProjectTeamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling project team request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	p, ok := domainProject(w, r, da, emailAddress, r.FormValue("id"))
+	if !ok {
+		return
+	}
+
+	profiles, err := da.ListProfiles(emailAddress)
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+
+	model := newProjectModel(*p, profiles)
+	if profiles, err = decayed(da, p.Domain, profiles, p.Start); err != nil {
+		log.Printf("Failed to discount the stale skills of %s. %v", p.Domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+	booked := make(map[string]bool)
+	for _, a := range model.Allocations {
+		booked[a.EmailAddress] = true
+	}
+	var candidates []dataaccess.Profile
+	for _, profile := range profiles {
+		if !booked[profile.EmailAddress] {
+			candidates = append(candidates, profile)
+		}
+	}
+
+	team := staffing.Suggest(model.Uncovered, candidates, dataaccess.Amber, p.Start)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(team); err != nil {
+		log.Printf("Failed to marshall the team, with error %s", err)
+	}
+}
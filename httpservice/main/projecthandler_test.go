@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/staffing"
+)
+
+var march = time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+func newProjectDataAccess() *mockDataAccess {
+	website := &dataaccess.Project{
+		ID:     "website",
+		Domain: "github.com",
+		Name:   "Website",
+		Status: dataaccess.ProjectConfirmed,
+		Start:  march,
+		End:    march.AddDate(0, 3, 0),
+		Requirements: []dataaccess.SkillRequirement{
+			{Skill: "go", Level: 3},
+			{Skill: "sql", Level: 2},
+		},
+	}
+	return &mockDataAccess{
+		getProjectResponse: func(id string) (*dataaccess.Project, bool, error) {
+			if id == "other" {
+				return &dataaccess.Project{ID: id, Domain: "example.com"}, true, nil
+			}
+			return website, id == website.ID, nil
+		},
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "gopher@github.com", Availability: dataaccess.Green, Skills: []dataaccess.Skill{{Skill: "go", Level: 4}}, Bookings: []dataaccess.Booking{
+					{ID: "b1", Project: "Website", ProjectID: "website", Start: march, End: march.AddDate(0, 3, 0), Percentage: 100},
+				}},
+				{EmailAddress: "dba@github.com", Availability: dataaccess.Green, Skills: []dataaccess.Skill{{Skill: "sql", Level: 2}, {Skill: "go", Level: 4}}},
+			}, nil
+		},
+		saveProjectResponse: func(p *dataaccess.Project) error { return p.Validate() },
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.Settings{}, nil
+		},
+	}
+}
+
+func newProjectSession() func(w http.ResponseWriter, r *http.Request) Session {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "boss@github.com",
+	}
+	return func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+}
+
+func TestThatProjectsAreCreatedInTheUsersDomain(t *testing.T) {
+	tests := []struct {
+		body         string
+		expectedCode int
+	}{
+		{`{"name":"App","client":"Acme","domain":"example.com","start":"2017-03-01T00:00:00Z","end":"2017-06-01T00:00:00Z","requirements":[{"skill":"Go","level":3}]}`, http.StatusCreated},
+		{`{"id":"website","name":"Website","start":"2017-03-01T00:00:00Z","end":"2017-07-01T00:00:00Z"}`, http.StatusOK},
+		{`{"id":"other","name":"Website","start":"2017-03-01T00:00:00Z","end":"2017-07-01T00:00:00Z"}`, http.StatusNotFound},
+		{`{"name":"App","start":"2017-03-01T00:00:00Z","end":"2017-02-01T00:00:00Z"}`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		mda := newProjectDataAccess()
+		var saved dataaccess.Project
+		mda.saveProjectResponse = func(p *dataaccess.Project) error {
+			saved = *p
+			return p.Validate()
+		}
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "http://example.com/projects/", strings.NewReader(test.body))
+		NewProjectHandler(mda, newProjectSession()).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.body, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code == http.StatusCreated && (saved.Domain != "github.com" || saved.ID == "" || saved.Requirements[0].Skill != "go") {
+			t.Errorf("Expected the project to be saved in github.com with an ID and clean skill names, but received %v", saved)
+		}
+	}
+}
+
+func TestThatProjectsIncludeTheirAllocations(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/projects/?id=website", nil)
+	NewProjectHandler(newProjectDataAccess(), newProjectSession()).ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but was %d.", w.Code)
+	}
+
+	var model projectModel
+	if err := json.NewDecoder(w.Body).Decode(&model); err != nil {
+		t.Fatal("Failed to decode the project.", err)
+	}
+	if len(model.Allocations) != 1 || model.Allocations[0].EmailAddress != "gopher@github.com" {
+		t.Errorf("Expected gopher@github.com to be allocated, but received %v", model.Allocations)
+	}
+	if len(model.Uncovered) != 1 || model.Uncovered[0].Skill != "sql" {
+		t.Errorf("Expected sql to be uncovered, but received %v", model.Uncovered)
+	}
+}
+
+func TestThatProjectTeamsCoverTheRemainingRequirements(t *testing.T) {
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/projects/team/?id=website", nil)
+	NewProjectTeamHandler(newProjectDataAccess(), newProjectSession()).ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but was %d.", w.Code)
+	}
+
+	var team staffing.Team
+	if err := json.NewDecoder(w.Body).Decode(&team); err != nil {
+		t.Fatal("Failed to decode the team.", err)
+	}
+	if len(team.Members) != 1 || team.Members[0].EmailAddress != "dba@github.com" || len(team.Members[0].Covers) != 1 {
+		t.Errorf("Expected dba@github.com to be suggested to cover sql, but received %v", team.Members)
+	}
+}
+
+func TestThatBookingsCanBeMadeAgainstProjects(t *testing.T) {
+	mda := newProjectDataAccess()
+	mda.getProfileResponse = func(emailAddress string) (*dataaccess.Profile, bool, error) {
+		return &dataaccess.Profile{EmailAddress: emailAddress}, true, nil
+	}
+	var booking dataaccess.Booking
+	mda.addBookingResponse = func(emailAddress string, b dataaccess.Booking) error {
+		booking = b
+		return b.Validate()
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "http://example.com/profile/bookings/", strings.NewReader(`{"emailAddress":"dba@github.com","projectId":"website","percentage":50}`))
+	NewBookingHandler(mda, newProjectSession()).ServeHTTP(w, r)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but was %d.", w.Code)
+	}
+	if booking.ProjectID != "website" || booking.Project != "Website" || !booking.Start.Equal(march) || !booking.End.Equal(march.AddDate(0, 3, 0)) {
+		t.Errorf("Expected the booking to use the project's name and dates, but received %v", booking)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The QuarantineHandler allows administrators to review changes which were
+// held because they were part of an anomaly. Released changes are made on
+// behalf of the administrator, rejected changes are discarded.
+type QuarantineHandler struct {
+	DataAccess dataaccess.DataAccess
+	Log        audit.Log
+}
+
+// NewQuarantineHandler creates an instance of the QuarantineHandler.
+func NewQuarantineHandler(da dataaccess.DataAccess, l audit.Log) *QuarantineHandler {
+	return &QuarantineHandler{da, l}
+}
+
+type quarantineReview struct {
+	ID string `json:"id"`
+	// Action is "release" or "reject".
+	Action string `json:"action"`
+}
+
+func (handler QuarantineHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling quarantine request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyQuarantine")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		changes, err := da.ListQuarantinedChanges(r.FormValue("tenant"))
+		if err != nil {
+			log.Print("Failed to list the quarantined changes. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.quarantineReadFailed")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		if err := json.NewEncoder(w).Encode(changes); err != nil {
+			log.Printf("Failed to marshall the quarantined changes, with error %s", err)
+		}
+	case http.MethodPost:
+		handleQuarantinePost(w, r, handler, da)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func handleQuarantinePost(w http.ResponseWriter, r *http.Request, handler QuarantineHandler, da dataaccess.DataAccess) {
+	var review quarantineReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil || (review.Action != "release" && review.Action != "reject") {
+		writeError(w, r, http.StatusBadRequest, "error.invalidQuarantineReview")
+		return
+	}
+
+	qc, found, err := da.GetQuarantinedChange(review.ID)
+	if err != nil {
+		log.Printf("Failed to get the quarantined change %s. %v", review.ID, err)
+		writeError(w, r, http.StatusInternalServerError, "error.quarantineReadFailed")
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "error.quarantinedChangeNotFound")
+		return
+	}
+
+	action := audit.QuarantineRejected
+	if review.Action == "release" {
+		action = audit.QuarantineReleased
+		if err := qc.Apply(dataaccess.WithContext(handler.DataAccess, dataaccess.Release(r.Context()))); err != nil {
+			log.Printf("Failed to release the quarantined change %s. %v", qc.ID, err)
+			writeError(w, r, http.StatusInternalServerError, "error.quarantineReleaseFailed")
+			return
+		}
+	}
+
+	if err := da.DeleteQuarantinedChange(qc.ID); err != nil {
+		log.Printf("Failed to remove the quarantined change %s. %v", qc.ID, err)
+		writeError(w, r, http.StatusInternalServerError, "error.quarantineReleaseFailed")
+		return
+	}
+
+	e := audit.NewEntry(r.Context(), action, qc.Tenant, qc.Target)
+	e.Details = string(qc.Kind) + " by " + qc.Actor + ": " + qc.Reason
+	if err := handler.Log.Record(e); err != nil {
+		log.Printf("Failed to record the review of quarantined change %s in the audit log. %v", qc.ID, err)
+	}
+
+	log.Printf("The quarantined change %s has been reviewed: %s.", qc.ID, review.Action)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func newQuarantineTestDataAccess() *mockDataAccess {
+	return &mockDataAccess{
+		getQuarantinedChangeResponse: func(id string) (*dataaccess.QuarantinedChange, bool, error) {
+			if id != "123" {
+				return nil, false, nil
+			}
+			return &dataaccess.QuarantinedChange{ID: id, Kind: dataaccess.DeleteProfileChange, Target: "a-h@github.com", Tenant: "github.com", Actor: "rogue@github.com"}, true, nil
+		},
+		deleteProfileResponse:           func(string) (bool, error) { return true, nil },
+		deleteQuarantinedChangeResponse: func(id string) error { return nil },
+	}
+}
+
+func TestThatReviewingQuarantinedChangesIsRestrictedToAdministrators(t *testing.T) {
+	mda := newQuarantineTestDataAccess()
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("POST", "http://example.com/admin/quarantine/", `{"id":"123","action":"release"}`, caller.Caller{EmailAddress: "a-h@github.com"})
+
+	NewQuarantineHandler(mda, audit.NewMemoryLog()).ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden || mda.deleteProfileCallCount != 0 {
+		t.Errorf("Expected users to be forbidden from releasing changes, but received %d.", w.Code)
+	}
+}
+
+func TestThatQuarantinedChangesCanBeReleasedOrRejected(t *testing.T) {
+	tests := []struct {
+		body            string
+		expectedStatus  int
+		expectedDeletes int
+		expectedAction  string
+	}{
+		{`{"id":"123","action":"release"}`, http.StatusNoContent, 1, audit.QuarantineReleased},
+		{`{"id":"123","action":"reject"}`, http.StatusNoContent, 0, audit.QuarantineRejected},
+		{`{"id":"456","action":"release"}`, http.StatusNotFound, 0, ""},
+		{`{"id":"123","action":"ignore"}`, http.StatusBadRequest, 0, ""},
+	}
+
+	for _, test := range tests {
+		mda := newQuarantineTestDataAccess()
+		l := audit.NewMemoryLog()
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "http://example.com/admin/quarantine/", test.body, testAdministrator)
+
+		NewQuarantineHandler(mda, l).ServeHTTP(w, r)
+
+		if w.Code != test.expectedStatus || mda.deleteProfileCallCount != test.expectedDeletes {
+			t.Errorf("For '%s', expected status %d and %d deletes, but received %d and %d.", test.body, test.expectedStatus, test.expectedDeletes, w.Code, mda.deleteProfileCallCount)
+		}
+
+		entries, _ := l.List(audit.Query{})
+		if test.expectedAction != "" && (len(entries) != 1 || entries[0].Action != test.expectedAction || entries[0].Actor != testAdministrator.EmailAddress) {
+			t.Errorf("For '%s', expected the review to be audited as %s, but received %v", test.body, test.expectedAction, entries)
+		}
+	}
+}
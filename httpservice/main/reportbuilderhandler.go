@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The ReportDefinitionHandler allows administrators to list, save and delete
+// the custom reports of their domain, e.g. /admin/reports/. Reports are run
+// by the ReportRunHandler.
+type ReportDefinitionHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewReportDefinitionHandler creates an instance of the
+// ReportDefinitionHandler.
+func NewReportDefinitionHandler(da dataaccess.DataAccess) *ReportDefinitionHandler {
+	return &ReportDefinitionHandler{da}
+}
+
+func (handler ReportDefinitionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling report definition request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyReports")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(c.EmailAddress)
+
+	switch r.Method {
+	case http.MethodGet:
+		definitions, err := da.ListReportDefinitions(domain)
+		if err != nil {
+			log.Print("Failed to list the report definitions. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.reportDefinitionsReadFailed")
+			return
+		}
+		if definitions == nil {
+			definitions = []dataaccess.ReportDefinition{}
+		}
+		writeJSON(w, http.StatusOK, definitions)
+	case http.MethodPost:
+		var d dataaccess.ReportDefinition
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidReportDefinition")
+			return
+		}
+		// Existing reports can only be replaced by their own domain.
+		if d.ID != "" {
+			if _, found, err := domainReportDefinition(da, domain, d.ID); err != nil || !found {
+				writeReportDefinitionError(w, r, found, err)
+				return
+			}
+		}
+		d.Domain = domain
+		d.CreatedBy = c.EmailAddress
+		d.Updated = time.Now().UTC()
+		if err := d.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := da.SaveReportDefinition(&d); err != nil {
+			log.Print("Failed to save the report definition. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.reportDefinitionSaveFailed")
+			return
+		}
+		writeJSON(w, http.StatusOK, d)
+	case http.MethodDelete:
+		deleted, err := da.DeleteReportDefinition(domain, r.FormValue("id"))
+		if err != nil || !deleted {
+			writeReportDefinitionError(w, r, deleted, err)
+			return
+		}
+		log.Printf("User %s has deleted the report %s.", c.EmailAddress, r.FormValue("id"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+// domainReportDefinition finds the report definition in the domain.
+func domainReportDefinition(da dataaccess.DataAccess, domain string, id string) (dataaccess.ReportDefinition, bool, error) {
+	definitions, err := da.ListReportDefinitions(domain)
+	if err != nil {
+		return dataaccess.ReportDefinition{}, false, err
+	}
+	for _, d := range definitions {
+		if d.ID == id {
+			return d, true, nil
+		}
+	}
+	return dataaccess.ReportDefinition{}, false, nil
+}
+
+func writeReportDefinitionError(w http.ResponseWriter, r *http.Request, found bool, err error) {
+	if err != nil {
+		log.Print("Failed to retrieve the report definition. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.reportDefinitionsReadFailed")
+		return
+	}
+	writeError(w, r, http.StatusNotFound, "error.reportDefinitionNotFound")
+}
+
+// The ReportRunHandler runs one of the custom reports of the administrator's
+// domain, e.g. /admin/reports/run/?id=5a1f&format=csv. Without a format, the
+// results are returned as JSON.
+type ReportRunHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewReportRunHandler creates an instance of the ReportRunHandler.
+func NewReportRunHandler(da dataaccess.DataAccess) *ReportRunHandler {
+	return &ReportRunHandler{da}
+}
+
+func (handler ReportRunHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling report run request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyReports")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(c.EmailAddress)
+
+	d, found, err := domainReportDefinition(da, domain, r.FormValue("id"))
+	if err != nil || !found {
+		writeReportDefinitionError(w, r, found, err)
+		return
+	}
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	result, err := da.RunReport(d, settings.Consent)
+	if err != nil {
+		log.Printf("Failed to run the report %s. %v", d.ID, err)
+		writeError(w, r, http.StatusInternalServerError, "error.reportRunFailed")
+		return
+	}
+
+	if r.FormValue("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="report.csv"`)
+		cw := csv.NewWriter(w)
+		cw.Write(result.Columns)
+		cw.WriteAll(result.Table())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
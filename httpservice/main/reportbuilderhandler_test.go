@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatAdministratorsCanSaveReportDefinitions(t *testing.T) {
+	var saved *dataaccess.ReportDefinition
+	mda := &mockDataAccess{
+		listReportDefinitionsResponse: func(domain string) ([]dataaccess.ReportDefinition, error) {
+			return []dataaccess.ReportDefinition{{ID: "existing", Domain: domain}}, nil
+		},
+		saveReportDefinitionResponse: func(d *dataaccess.ReportDefinition) error {
+			saved = d
+			return nil
+		},
+	}
+
+	tests := []struct {
+		body         string
+		caller       caller.Caller
+		expectedCode int
+	}{
+		{`{"name":"Skills","dimensions":["skill"],"measures":["people"],"domain":"example.com"}`, testAdministrator, http.StatusOK},
+		{`{"id":"existing","name":"Skills","dimensions":["skill"],"measures":["people"]}`, testAdministrator, http.StatusOK},
+		{`{"id":"missing","name":"Skills","dimensions":["skill"],"measures":["people"]}`, testAdministrator, http.StatusNotFound},
+		{`{"name":"Skills","dimensions":["salary"],"measures":["people"]}`, testAdministrator, http.StatusBadRequest},
+		{`{"name":"Skills","dimensions":["skill"],"measures":["people"]}`, caller.Caller{EmailAddress: "dev@github.com"}, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		saved = nil
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "/admin/reports/", test.body, test.caller)
+
+		NewReportDefinitionHandler(mda).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but got %d", test.body, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code == http.StatusOK && (saved == nil || saved.Domain != "github.com" || saved.CreatedBy != testAdministrator.EmailAddress) {
+			t.Errorf("For %s, expected the report to be saved in the administrator's domain, but got %+v", test.body, saved)
+		}
+	}
+}
+
+func TestThatReportsCanBeRunAsCSV(t *testing.T) {
+	var consent dataaccess.ConsentSettings
+	mda := &mockDataAccess{
+		listReportDefinitionsResponse: func(domain string) ([]dataaccess.ReportDefinition, error) {
+			return []dataaccess.ReportDefinition{{ID: "skills", Domain: domain, Name: "Skills", Dimensions: []string{"skill"}, Measures: []string{"people"}}}, nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			s := dataaccess.DefaultSettings()
+			s.Consent.Required = true
+			return s, nil
+		},
+		runReportResponse: func(d dataaccess.ReportDefinition, c dataaccess.ConsentSettings) (*dataaccess.ReportResult, error) {
+			consent = c
+			return &dataaccess.ReportResult{Definition: d, Columns: d.Columns(), Rows: []map[string]interface{}{{"skill": "go", "people": float64(2)}}}, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	NewReportRunHandler(mda).ServeHTTP(w, newRequestWithCaller("GET", "/admin/reports/run/?id=skills&format=csv", "", testAdministrator))
+
+	if expected := "skill,people\ngo,2\n"; w.Body.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, w.Body.String())
+	}
+	if !consent.Required {
+		t.Errorf("Expected the report to be run with the tenant's consent settings")
+	}
+
+	w = httptest.NewRecorder()
+	NewReportRunHandler(mda).ServeHTTP(w, newRequestWithCaller("GET", "/admin/reports/run/?id=skills", "", testAdministrator))
+	var result dataaccess.ReportResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil || len(result.Rows) != 1 {
+		t.Errorf("Expected the result as JSON, but got %v %s", err, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	NewReportRunHandler(mda).ServeHTTP(w, newRequestWithCaller("GET", "/admin/reports/run/?id=other", "", testAdministrator))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected reports which aren't in the domain not to be found, but got %d", w.Code)
+	}
+}
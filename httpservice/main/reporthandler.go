@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 	"sort"
+	"time"
 
 	"github.com/a-h/pill/dataaccess"
 )
@@ -33,17 +34,32 @@ func handleReportGet(w http.ResponseWriter, r *http.Request, handler ReportHandl
 
 	log.Printf("The session is valid, rendering the report for user %s.", emailAddress)
 
-	profiles, err := handler.DataAccess.ListProfiles(emailAddress)
+	profiles, err := dataaccess.WithContext(handler.DataAccess, r.Context()).ListProfiles(emailAddress)
 
 	if err != nil {
-		msg := "Unable to retrieve the list of profiles."
-		log.Print(msg, err)
-		http.Error(w, msg, http.StatusInternalServerError)
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
 		return
 	}
 
 	log.Printf("Found %d profiles.", len(profiles))
 
+	// The viewer may not be in the department, but their time zone is still
+	// used.
+	loc := viewerLocation(profiles, emailAddress)
+	profiles = profileFilter(r).Apply(profiles)
+	model := newReportModel(profiles)
+	model.showAvailabilityWindows(profiles, time.Now(), loc)
+
+	log.Printf("Listing %d skills.", len(model.SkillNames))
+	log.Printf("Listing %d profiles.", len(model.Profiles))
+
+	renderTemplate(w, "report.html", model)
+}
+
+// newReportModel creates a matrix of people against the skills used by any
+// of them.
+func newReportModel(profiles []dataaccess.Profile) ReportModel {
 	// List all the skills.
 	skillNames := getSkillNames(profiles)
 	sort.Strings(skillNames)
@@ -56,6 +72,7 @@ func handleReportGet(w http.ResponseWriter, r *http.Request, handler ReportHandl
 	for idx, profile := range profiles {
 		m := &ProfileSkills{
 			EmailAddress: profile.EmailAddress,
+			Name:         profile.Name,
 			Availability: profile.Availability,
 			Skills:       make([]dataaccess.Skill, len(skillNames)),
 		}
@@ -78,21 +95,40 @@ func handleReportGet(w http.ResponseWriter, r *http.Request, handler ReportHandl
 		profileSkills[idx] = *m
 	}
 
-	model := ReportModel{
+	return ReportModel{
 		SkillNames: skillNames,
 		Profiles:   profileSkills,
 	}
+}
 
-	log.Printf("Listing %d skills.", len(model.SkillNames))
-	log.Printf("Listing %d profiles.", len(model.Profiles))
+// viewerLocation returns the time zone of the person with the email address.
+func viewerLocation(profiles []dataaccess.Profile, emailAddress string) *time.Location {
+	for _, p := range profiles {
+		if p.EmailAddress == emailAddress {
+			return p.Location()
+		}
+	}
+	return time.UTC
+}
 
-	renderTemplate(w, "report.html", model)
+// showAvailabilityWindows adds each person's upcoming availability windows
+// to the model, in the viewer's time zone. The profiles must be in the order
+// they were passed to newReportModel.
+func (m *ReportModel) showAvailabilityWindows(profiles []dataaccess.Profile, now time.Time, loc *time.Location) {
+	m.TimeZone = loc.String()
+	for idx, p := range profiles {
+		m.Profiles[idx].Availability = p.AvailabilityAt(now)
+		m.Profiles[idx].AvailabilityWindows = p.UpcomingAvailabilityWindows(now, loc)
+	}
 }
 
 // ReportModel provides data to the Report View.
 type ReportModel struct {
 	SkillNames []string
 	Profiles   []ProfileSkills
+	// TimeZone is the viewer's time zone, which availability windows are
+	// shown in.
+	TimeZone string
 }
 
 // ProfileSkills provides information about a person's skills in a matrix
@@ -102,8 +138,11 @@ type ReportModel struct {
 // SkillNames properties, with some of the values being empty.
 type ProfileSkills struct {
 	EmailAddress string
+	Name         string
 	Availability dataaccess.RagStatus
 	Skills       []dataaccess.Skill
+	// AvailabilityWindows are the person's upcoming availability windows.
+	AvailabilityWindows []dataaccess.AvailabilityWindow
 }
 
 func getSkillNames(profiles []dataaccess.Profile) []string {
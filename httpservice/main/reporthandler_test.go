@@ -0,0 +1,21 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/loadtest"
+)
+
+func BenchmarkReport(b *testing.B) {
+	profiles := loadtest.DefaultDataset.Generate()[:loadtest.DefaultDataset.Profiles]
+	now := time.Date(2017, time.June, 1, 9, 0, 0, 0, time.UTC)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		model := newReportModel(profiles)
+		model.showAvailabilityWindows(profiles, now, time.UTC)
+		renderTemplate(httptest.NewRecorder(), "report.html", model)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The SearchHandler finds people in the user's domain from the search read
+// model, e.g. /search/?skill=go&level=3&availability=2, or by the words in
+// their name, skills or department, e.g. /search/?q=kubernetes. Results can
+// be limited to a ?department= or a ?manager='s direct reports.
+type SearchHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewSearchHandler creates an instance of the SearchHandler.
+func NewSearchHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *SearchHandler {
+	return &SearchHandler{da, sessionFactory}
+}
+
+func (handler SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling search request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	q := dataaccess.SearchQuery{
+		Skill:      r.FormValue("skill"),
+		Text:       r.FormValue("q"),
+		Manager:    r.FormValue("manager"),
+		Department: r.FormValue("department"),
+	}
+	if v := r.FormValue("level"); v != "" {
+		level, err := strconv.Atoi(v)
+		if err != nil || level < dataaccess.NoviceLevel || level > dataaccess.MasterLevel || q.Skill == "" {
+			writeError(w, r, http.StatusBadRequest, "error.invalidSearch")
+			return
+		}
+		q.Level = dataaccess.DreyfusLevel(level)
+	}
+	if v := r.FormValue("availability"); v != "" {
+		availability, err := strconv.Atoi(v)
+		if err != nil || availability < dataaccess.Red || availability > dataaccess.Green {
+			writeError(w, r, http.StatusBadRequest, "error.invalidSearch")
+			return
+		}
+		q.Availability = dataaccess.RagStatus(availability)
+	}
+
+	domain := dataaccess.GetDomain(emailAddress)
+	documents, err := dataaccess.WithContext(handler.DataAccess, r.Context()).SearchDocuments(domain, q)
+	if err != nil {
+		log.Printf("Failed to search the profiles in %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.searchFailed")
+		return
+	}
+	writeJSON(w, http.StatusOK, documents)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatSearchesReadTheSearchDocumentsInTheUsersDomain(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	var domain string
+	var query dataaccess.SearchQuery
+	mda := &mockDataAccess{
+		searchDocumentsResponse: func(d string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error) {
+			domain, query = d, q
+			return []dataaccess.SearchDocument{{EmailAddress: "a@github.com", Skills: []dataaccess.SearchSkill{{Skill: "go", Level: 4}}}}, nil
+		},
+	}
+
+	tests := []struct {
+		query        string
+		expectedCode int
+	}{
+		{"?skill=go&level=3&availability=2&q=Platform", http.StatusOK},
+		{"?level=3", http.StatusBadRequest},
+		{"?skill=go&level=9", http.StatusBadRequest},
+		{"?availability=green", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "http://example.com/search/"+test.query, nil)
+
+		NewSearchHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.query, test.expectedCode, w.Code)
+		}
+	}
+
+	if domain != "github.com" || query.Skill != "go" || query.Level != 3 || query.Availability != dataaccess.Amber || query.Text != "Platform" {
+		t.Errorf("Unexpected search of %s with %+v", domain, query)
+	}
+}
+
+func TestThatTeamSummariesAreLimitedToTheUsersDomain(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "boss@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	mda := &mockDataAccess{
+		getTeamSummaryResponse: func(manager string) (*dataaccess.TeamSummary, bool, error) {
+			if manager != "boss@github.com" {
+				return nil, false, nil
+			}
+			return &dataaccess.TeamSummary{Manager: manager, Members: []string{"a@github.com"}, Available: 1}, true, nil
+		},
+	}
+
+	tests := []struct {
+		query           string
+		expectedCode    int
+		expectedMembers int
+	}{
+		{"", http.StatusOK, 1},
+		{"?manager=nobody@github.com", http.StatusOK, 0},
+		{"?manager=boss@example.com", http.StatusForbidden, 0},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "http://example.com/report/teams/"+test.query, nil)
+
+		NewTeamSummaryHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %q, expected status %d, but was %d.", test.query, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var s dataaccess.TeamSummary
+		if err := json.NewDecoder(w.Body).Decode(&s); err != nil {
+			t.Fatal("Failed to decode the team summary.", err)
+		}
+		if len(s.Members) != test.expectedMembers {
+			t.Errorf("For %q, expected %d members, but received %v", test.query, test.expectedMembers, s.Members)
+		}
+	}
+}
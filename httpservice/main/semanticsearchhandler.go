@@ -0,0 +1,71 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/semantic"
+)
+
+// The SemanticSearchHandler finds people in the user's domain with skills
+// like what's searched for, even if they're called something else, e.g.
+// /search/semantic/?q=container+orchestration finds people who know
+// kubernetes. ?level= limits the results to people at that level or above.
+type SemanticSearchHandler struct {
+	DataAccess dataaccess.DataAccess
+	Index      *semantic.Index
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewSemanticSearchHandler creates an instance of the SemanticSearchHandler.
+// The people found are recorded in their access logs through the data
+// access.
+func NewSemanticSearchHandler(da dataaccess.DataAccess, index *semantic.Index, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *SemanticSearchHandler {
+	return &SemanticSearchHandler{da, index, sessionFactory}
+}
+
+func (handler SemanticSearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling semantic search request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	q := strings.TrimSpace(r.FormValue("q"))
+	level := dataaccess.NoviceLevel
+	if v := r.FormValue("level"); v != "" {
+		var err error
+		if level, err = strconv.Atoi(v); err != nil || level < dataaccess.NoviceLevel || level > dataaccess.MasterLevel {
+			writeError(w, r, http.StatusBadRequest, "error.invalidSemanticSearch")
+			return
+		}
+	}
+	if q == "" {
+		writeError(w, r, http.StatusBadRequest, "error.invalidSemanticSearch")
+		return
+	}
+
+	domain := dataaccess.GetDomain(emailAddress)
+	matches, err := handler.Index.Search(r.Context(), domain, q, dataaccess.DreyfusLevel(level))
+	if err != nil {
+		log.Printf("Failed to search the skills in %s for %q. %v", domain, q, err)
+		writeError(w, r, http.StatusInternalServerError, "error.searchFailed")
+		return
+	}
+	found := make([]string, len(matches))
+	for i, m := range matches {
+		found[i] = m.EmailAddress
+	}
+	dataaccess.RecordAccesses(dataaccess.WithContext(handler.DataAccess, r.Context()), audit.ProfileListed, found)
+	writeJSON(w, http.StatusOK, matches)
+}
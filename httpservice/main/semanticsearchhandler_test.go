@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/semantic"
+)
+
+type constantEmbedder struct{}
+
+func (constantEmbedder) Embed(ctx context.Context, texts []string) ([]semantic.Vector, error) {
+	op := make([]semantic.Vector, len(texts))
+	for i := range texts {
+		op[i] = semantic.Vector{1, 1}
+	}
+	return op, nil
+}
+
+func TestThatSemanticSearchesFindPeopleInTheUsersDomain(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	var domains []string
+	mda := &mockDataAccess{
+		listSkillTagsResponse: func() ([]dataaccess.SkillTag, error) {
+			return []dataaccess.SkillTag{{Name: "kubernetes"}}, nil
+		},
+		searchDocumentsResponse: func(domain string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error) {
+			domains = append(domains, domain)
+			return []dataaccess.SearchDocument{{EmailAddress: "a@github.com", Skills: []dataaccess.SearchSkill{{Skill: q.Skill, Level: 3}}}}, nil
+		},
+	}
+	index := semantic.NewIndex(mda, constantEmbedder{})
+	if err := index.Refresh(context.Background()); err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	tests := []struct {
+		query        string
+		expectedCode int
+	}{
+		{"?q=container+orchestration&level=3", http.StatusOK},
+		{"?q=", http.StatusBadRequest},
+		{"?q=devops&level=6", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "http://example.com/search/semantic/"+test.query, nil)
+
+		NewSemanticSearchHandler(mda, index, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.query, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var matches []semantic.Match
+		if err := json.NewDecoder(w.Body).Decode(&matches); err != nil {
+			t.Fatal("Failed to decode the matches.", err)
+		}
+		if len(matches) != 1 || matches[0].Matched[0].Skill != "kubernetes" {
+			t.Errorf("Expected a@github.com to match by kubernetes, but received %+v", matches)
+		}
+	}
+	if len(domains) != 1 || domains[0] != "github.com" {
+		t.Errorf("Expected only the user's domain to be searched, but searched %v", domains)
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"time"
 
-	"github.com/gorilla/sessions"
+	"github.com/a-h/pill/sessions"
 )
 
 // Session determines how a user is logged in to the system.
@@ -16,71 +17,190 @@ type Session interface {
 	StartSession(emailAddress string)
 }
 
-// A GorillaSession uses the Gorilla framework to manage the session.
-type GorillaSession struct {
-	store    sessions.CookieStore
-	w        http.ResponseWriter
-	r        *http.Request
-	loginURL url.URL
+// A CookieSession stores a session token issued by the sessions package in a
+// cookie.
+type CookieSession struct {
+	manager       *sessions.Manager
+	setSecureFlag bool
+	w             http.ResponseWriter
+	r             *http.Request
+	loginURL      url.URL
+	// startedToken is the token issued by StartSession during this request.
+	startedToken string
 }
 
 const sessionName string = "pill-session-cookie"
 
-// NewGorillaSession creates a Session which uses Gorilla.
-func NewGorillaSession(w http.ResponseWriter, r *http.Request, encryptionKey []byte, setSecureFlag bool, loginURL url.URL) *GorillaSession {
-	store := sessions.NewCookieStore(encryptionKey)
-	store.Options = &sessions.Options{
-		HttpOnly: true,
-		Secure:   setSecureFlag,
+// impersonationName is the cookie which holds an administrator's
+// impersonation token, alongside their own session.
+const impersonationName string = "pill-impersonation-cookie"
+
+// An Impersonator is a Session which allows administrators to act as another
+// user.
+type Impersonator interface {
+	Impersonate(impersonator string, emailAddress string, d time.Duration) error
+	EndImpersonation()
+}
+
+// A BearerSession is the session of a request authenticated by a bearer token
+// from the TokenHandler, for clients such as pillctl which don't keep
+// cookies. The token expires by itself, so there's nothing to start or renew.
+type BearerSession struct {
+	w            http.ResponseWriter
+	r            *http.Request
+	emailAddress string
+	valid        bool
+}
+
+// NewBearerSession creates a Session for the user the bearer token was
+// issued to, or an invalid Session if the token couldn't be verified.
+func NewBearerSession(w http.ResponseWriter, r *http.Request, emailAddress string, valid bool) *BearerSession {
+	return &BearerSession{w: w, r: r, emailAddress: emailAddress, valid: valid}
+}
+
+// ValidateSession returns the user the token was issued to. Clients can't
+// log in from the logon screen, so invalid tokens are refused rather than
+// redirected.
+func (bs *BearerSession) ValidateSession() (isValid bool, emailAddress string) {
+	if !bs.valid {
+		log.Print("Failed to validate the bearer token.")
+		writeError(bs.w, bs.r, http.StatusUnauthorized, "error.invalidToken")
+		return false, ""
 	}
+	log.Printf("The bearer token is valid for user %s", bs.emailAddress)
+	return true, bs.emailAddress
+}
+
+// StartSession does nothing, since sessions are started by logging in from
+// a browser.
+func (bs *BearerSession) StartSession(emailAddress string) {}
 
-	return &GorillaSession{
-		store:    *store,
-		w:        w,
-		r:        r,
-		loginURL: loginURL,
+// NewCookieSession creates a Session which stores tokens issued by the
+// manager in a cookie.
+func NewCookieSession(w http.ResponseWriter, r *http.Request, manager *sessions.Manager, setSecureFlag bool, loginURL url.URL) *CookieSession {
+	return &CookieSession{
+		manager:       manager,
+		setSecureFlag: setSecureFlag,
+		w:             w,
+		r:             r,
+		loginURL:      loginURL,
 	}
 }
 
-// StartSession starts off a session by adding the emailAddress value to an
-// encrypted cookie.
-func (gs GorillaSession) StartSession(emailAdress string) {
-	session, err := gs.store.Get(gs.r, sessionName)
+func (cs *CookieSession) setCookie(token string) {
+	http.SetCookie(cs.w, &http.Cookie{
+		Name:     sessionName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(cs.manager.MaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   cs.setSecureFlag,
+	})
+}
+
+// StartSession starts off a session by adding a token containing the
+// emailAddress value to an encrypted cookie.
+func (cs *CookieSession) StartSession(emailAddress string) {
+	token, err := cs.manager.Issue(emailAddress)
 
 	if err != nil {
-		http.Error(gs.w, err.Error(), http.StatusInternalServerError)
+		http.Error(cs.w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	session.Values["emailAddress"] = emailAdress
-	session.Save(gs.r, gs.w)
+
+	cs.startedToken = token
+	cs.setCookie(token)
 }
 
-// ValidateSession checks whether the session is valid. If it isn't, it will
-// redirect the user to the logon screen.
-func (gs GorillaSession) ValidateSession() (isValid bool, emailAddress string) {
-	log.Print("Validating the session.")
-	session, err := gs.store.Get(gs.r, sessionName)
+func (cs *CookieSession) token() string {
+	if cs.startedToken != "" {
+		return cs.startedToken
+	}
+
+	cookie, err := cs.r.Cookie(sessionName)
 	if err != nil {
-		print("Failed to get the cookie from the store.")
-		http.Error(gs.w, err.Error(), http.StatusInternalServerError)
-		return false, ""
+		return ""
 	}
+	return cookie.Value
+}
+
+// ValidateSession checks whether the session is valid. If it isn't, it will
+// redirect the user to the logon screen. Tokens which are due to be renewed
+// are replaced.
+func (cs *CookieSession) ValidateSession() (isValid bool, emailAddress string) {
+	log.Print("Validating the session.")
 
-	ea, ok := session.Values["emailAddress"].(string)
+	token := cs.token()
+	t, err := cs.manager.Verify(token)
 
-	if !ok || ea == "" {
-		log.Printf("Failed to recover the email address {ea: %s, ok: %t}. Considering redirecting to %s", ea, ok, gs.loginURL.String())
+	if token == "" || err != nil {
+		log.Printf("Failed to validate the session token {present: %t, err: %v}. Considering redirecting to %s", token != "", err, cs.loginURL.String())
 
-		log.Printf("The incoming URL was %s.", gs.r.URL.Path)
+		log.Printf("The incoming URL was %s.", cs.r.URL.Path)
 
-		if gs.r.URL.Path == gs.loginURL.String() {
+		if cs.r.URL.Path == cs.loginURL.String() {
 			log.Print("Not redirecting because the user is at the logon screen.")
 		} else {
-			http.Redirect(gs.w, gs.r, gs.loginURL.String(), http.StatusFound)
+			http.Redirect(cs.w, cs.r, cs.loginURL.String(), http.StatusFound)
 		}
-		return false, ea
+		return false, ""
+	}
+
+	if renewed, ok, err := cs.manager.Renew(token); ok && err == nil {
+		log.Printf("Renewing the session token for user %s", t.EmailAddress)
+		cs.setCookie(renewed)
+	}
+
+	if it, ok := impersonation(cs.manager, cs.r, t.EmailAddress); ok {
+		log.Printf("The session is valid for user %s, who is impersonating %s", t.EmailAddress, it.EmailAddress)
+		return true, it.EmailAddress
+	}
+
+	log.Printf("The session is valid for user %s", t.EmailAddress)
+	return true, t.EmailAddress
+}
+
+// impersonation returns the impersonation token, if the user with the email
+// address is impersonating another user.
+func impersonation(manager *sessions.Manager, r *http.Request, emailAddress string) (sessions.Token, bool) {
+	cookie, err := r.Cookie(impersonationName)
+	if err != nil {
+		return sessions.Token{}, false
+	}
+
+	t, err := manager.Verify(cookie.Value)
+	if err != nil || t.Impersonator != emailAddress {
+		return sessions.Token{}, false
 	}
+	return t, true
+}
 
-	log.Printf("The session is valid for user %s", ea)
-	return true, ea
+// Impersonate allows the impersonator to act as the user with the email
+// address for the duration.
+func (cs *CookieSession) Impersonate(impersonator string, emailAddress string, d time.Duration) error {
+	token, err := cs.manager.Impersonate(emailAddress, impersonator, d)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(cs.w, &http.Cookie{
+		Name:     impersonationName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(d.Seconds()),
+		HttpOnly: true,
+		Secure:   cs.setSecureFlag,
+	})
+	return nil
+}
+
+// EndImpersonation returns the administrator to their own session.
+func (cs *CookieSession) EndImpersonation() {
+	http.SetCookie(cs.w, &http.Cookie{
+		Name:     impersonationName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   cs.setSecureFlag,
+	})
 }
@@ -5,13 +5,21 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/sessions"
 )
 
+func newTestSessionManager() *sessions.Manager {
+	m, _ := sessions.NewManager([]byte("0123456789abcdef0123456789abcdef"))
+	return m
+}
+
 func TestThatInvalidSessionsRedirectToTheHomePage(t *testing.T) {
 	w := httptest.NewRecorder()
 	redirectURL, _ := url.Parse("http://example.com/login")
 	r, _ := http.NewRequest("GET", "http://example.com/secret_area", nil)
-	s := NewGorillaSession(w, r, []byte("random_data"), false, *redirectURL)
+	s := NewCookieSession(w, r, newTestSessionManager(), false, *redirectURL)
 
 	result, _ := s.ValidateSession()
 
@@ -32,7 +40,7 @@ func TestThatLoginsDoNotLoop(t *testing.T) {
 	w := httptest.NewRecorder()
 	redirectURL, _ := url.Parse("/")
 	r, _ := http.NewRequest("GET", "http://example.com/", nil)
-	s := NewGorillaSession(w, r, []byte("random_data"), false, *redirectURL)
+	s := NewCookieSession(w, r, newTestSessionManager(), false, *redirectURL)
 
 	result, _ := s.ValidateSession()
 
@@ -50,7 +58,7 @@ func TestThatValidSessionsPassThrough(t *testing.T) {
 	redirectURL, _ := url.Parse("http://example.com/")
 	r, _ := http.NewRequest("GET", "http://example.com/secret_area", nil)
 
-	s := NewGorillaSession(w, r, []byte("random_data"), false, *redirectURL)
+	s := NewCookieSession(w, r, newTestSessionManager(), false, *redirectURL)
 	s.StartSession("a-h@github.com")
 
 	result, emailAddress := s.ValidateSession()
@@ -67,3 +75,80 @@ func TestThatValidSessionsPassThrough(t *testing.T) {
 		t.Error("The session should store the user's email address.")
 	}
 }
+
+func TestThatSessionCookiesAreAcceptedOnLaterRequests(t *testing.T) {
+	redirectURL, _ := url.Parse("http://example.com/")
+
+	w1 := httptest.NewRecorder()
+	r1, _ := http.NewRequest("POST", "http://example.com/", nil)
+	NewCookieSession(w1, r1, newTestSessionManager(), true, *redirectURL).StartSession("a-h@github.com")
+
+	cookies := w1.Result().Cookies()
+
+	if len(cookies) != 1 || !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Fatalf("Expected a single HttpOnly, Secure session cookie, but received %v", cookies)
+	}
+
+	w2 := httptest.NewRecorder()
+	r2, _ := http.NewRequest("GET", "http://example.com/secret_area", nil)
+	r2.AddCookie(cookies[0])
+
+	result, emailAddress := NewCookieSession(w2, r2, newTestSessionManager(), true, *redirectURL).ValidateSession()
+
+	if !result || emailAddress != "a-h@github.com" {
+		t.Errorf("The session cookie should be valid for a-h@github.com, but was valid: %t, email: %s", result, emailAddress)
+	}
+}
+
+func TestThatTamperedSessionCookiesAreRejected(t *testing.T) {
+	redirectURL, _ := url.Parse("http://example.com/")
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/secret_area", nil)
+	r.AddCookie(&http.Cookie{Name: sessionName, Value: "a-h@github.com"})
+
+	result, _ := NewCookieSession(w, r, newTestSessionManager(), false, *redirectURL).ValidateSession()
+
+	if result {
+		t.Error("A session cookie which wasn't issued by the manager should be rejected.")
+	}
+
+	if w.Code != http.StatusFound {
+		t.Error("The user should have been redirected to the login URL.")
+	}
+}
+
+func TestThatBearerTokensAreAcceptedInsteadOfASession(t *testing.T) {
+	c := dataaccess.Configuration{SessionEncryptionKey: []byte("0123456789abcdef0123456789abcdef")}
+	previous := configuration
+	configuration = newTestConfigurationCache(c)
+	defer func() { configuration = previous }()
+
+	bearerToken, _, _ := newIssuer(c).Issue("a-h@github.com", "github.com", nil, "")
+
+	tests := []struct {
+		name     string
+		bearer   string
+		expected string
+		status   int
+	}{
+		{"a bearer token", bearerToken, "a-h@github.com", http.StatusOK},
+		{"an invalid bearer token", "nonsense", "", http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/profile/", nil)
+		r.Header.Set("Authorization", "Bearer "+test.bearer)
+
+		valid, emailAddress := createSession(w, r).ValidateSession()
+
+		if valid != (test.expected != "") || emailAddress != test.expected {
+			t.Errorf("For %s, expected the session of '%s', but received '%s'.", test.name, test.expected, emailAddress)
+		}
+
+		if w.Code != test.status {
+			t.Errorf("For %s, expected status %d, but was %d.", test.name, test.status, w.Code)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/onepager"
+)
+
+// defaultShareLinkDays is how long share links last if the user doesn't
+// say.
+const defaultShareLinkDays = 30
+
+// The ShareLinkHandler creates, lists and revokes links which show the
+// user's skills and certifications to people without an account.
+type ShareLinkHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	baseURL    string
+	now        func() time.Time
+}
+
+// NewShareLinkHandler creates an instance of the ShareLinkHandler. Links are
+// created under the base URL.
+func NewShareLinkHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session, baseURL string) *ShareLinkHandler {
+	return &ShareLinkHandler{da, sessionFactory, strings.TrimSuffix(baseURL, "/"), time.Now}
+}
+
+// The SharedProfileHandler shows the skills and certifications on a profile
+// to anyone with a share link which hasn't expired, e.g.
+// /shared/?token=... Nothing else on the profile is shown.
+type SharedProfileHandler struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewSharedProfileHandler creates an instance of the SharedProfileHandler.
+func NewSharedProfileHandler(da dataaccess.DataAccess) *SharedProfileHandler {
+	return &SharedProfileHandler{da, time.Now}
+}
+
+// shareLinkRequest is posted to create a share link.
+type shareLinkRequest struct {
+	Days int `json:"days"`
+}
+
+type shareLinkResponse struct {
+	dataaccess.ShareLink
+	// URL is only returned when the link is created, since the token it
+	// contains isn't stored.
+	URL string `json:"url"`
+}
+
+// sharedProfile is the redacted view of a profile shown by share links.
+type sharedProfile struct {
+	Name           string                   `json:"name,omitempty"`
+	Skills         []onepager.Skill         `json:"skills"`
+	Certifications []onepager.Certification `json:"certifications"`
+	Expires        time.Time                `json:"expires"`
+}
+
+func (handler ShareLinkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling share link request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		links, err := da.ListShareLinks(emailAddress)
+		if err != nil {
+			log.Printf("Failed to list the share links of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.shareLinkReadFailed")
+			return
+		}
+		if links == nil {
+			links = []dataaccess.ShareLink{}
+		}
+		writeJSON(w, http.StatusOK, links)
+	case http.MethodPost:
+		req := shareLinkRequest{Days: defaultShareLinkDays}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Days < 1 || req.Days > dataaccess.MaxShareLinkDays {
+			writeError(w, r, http.StatusBadRequest, "error.invalidShareLink", dataaccess.MaxShareLinkDays)
+			return
+		}
+		link, token, err := dataaccess.NewShareLink(emailAddress, req.Days, handler.now())
+		if err == nil {
+			err = da.SaveShareLink(&link)
+		}
+		if err != nil {
+			log.Printf("Failed to create a share link for %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.shareLinkSaveFailed")
+			return
+		}
+		log.Printf("User %s has shared their profile until %v.", emailAddress, link.Expires)
+		writeJSON(w, http.StatusCreated, shareLinkResponse{link, handler.baseURL + "/shared/?" + url.Values{"token": {token}}.Encode()})
+	case http.MethodDelete:
+		id := r.FormValue("id")
+		link, found, err := da.GetShareLink(id)
+		if err != nil {
+			log.Printf("Failed to get share link %s. %v", id, err)
+			writeError(w, r, http.StatusInternalServerError, "error.shareLinkReadFailed")
+			return
+		}
+		if !found || link.EmailAddress != emailAddress {
+			writeError(w, r, http.StatusNotFound, "error.shareLinkNotFound")
+			return
+		}
+		if err := da.DeleteShareLink(id); err != nil {
+			log.Printf("Failed to revoke share link %s. %v", id, err)
+			writeError(w, r, http.StatusInternalServerError, "error.shareLinkSaveFailed")
+			return
+		}
+		log.Printf("User %s has revoked a share link.", emailAddress)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func (handler SharedProfileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling shared profile request.")
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	now := handler.now()
+
+	link, found, err := da.GetShareLink(dataaccess.ShareLinkID(r.FormValue("token")))
+	if err != nil {
+		log.Print("Failed to get the share link. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.shareLinkReadFailed")
+		return
+	}
+	// Expired and unknown links can't be told apart.
+	if !found || link.Expired(now) {
+		writeError(w, r, http.StatusNotFound, "error.shareLinkNotFound")
+		return
+	}
+
+	profile, found, err := da.GetProfile(link.EmailAddress)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", link.EmailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.shareLinkReadFailed")
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "error.shareLinkNotFound")
+		return
+	}
+	domain := dataaccess.GetDomain(link.EmailAddress)
+	completions, err := da.ListCourseCompletions(domain)
+	if err != nil {
+		log.Printf("Failed to list the course completions of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.shareLinkReadFailed")
+		return
+	}
+
+	d := onepager.NewData(profile, completions, nil, now)
+	sp := sharedProfile{Name: profile.Name, Skills: d.Skills, Certifications: d.Certifications, Expires: link.Expires}
+	if sp.Skills == nil {
+		sp.Skills = []onepager.Skill{}
+	}
+	if sp.Certifications == nil {
+		sp.Certifications = []onepager.Certification{}
+	}
+
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		renderTemplate(w, "shared.html", sp)
+		return
+	}
+	writeJSON(w, http.StatusOK, sp)
+}
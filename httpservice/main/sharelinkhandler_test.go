@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatShareLinksAreCreatedWithATokenWhichOpensTheRedactedProfile(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	now := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	links := make(map[string]dataaccess.ShareLink)
+	mda := &mockDataAccess{
+		saveShareLinkResponse: func(l *dataaccess.ShareLink) error {
+			links[l.ID] = *l
+			return nil
+		},
+		getShareLinkResponse: func(id string) (*dataaccess.ShareLink, bool, error) {
+			l, ok := links[id]
+			return &l, ok, nil
+		},
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{
+				EmailAddress: emailAddress,
+				Name:         "Dev",
+				Bio:          "Private",
+				Skills:       []dataaccess.Skill{{Skill: "go", Level: dataaccess.ExpertLevel}},
+			}, true, nil
+		},
+		listCourseCompletionsResponse: func(domain string) ([]dataaccess.CourseCompletion, error) {
+			return []dataaccess.CourseCompletion{
+				{EmailAddress: "dev@github.com", Course: "Go", Status: dataaccess.CompletionApproved, Completed: now},
+				{EmailAddress: "dev@github.com", Course: "Rust", Status: dataaccess.CompletionPending, Completed: now},
+				{EmailAddress: "lead@github.com", Course: "Java", Status: dataaccess.CompletionApproved, Completed: now},
+			}, nil
+		},
+	}
+
+	h := NewShareLinkHandler(mda, sf, "https://pill.example.com/")
+	h.now = func() time.Time { return now }
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest(http.MethodPost, "/profile/shares/", strings.NewReader(`{"days":7}`))
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected the link to be created, but got %d: %s", w.Code, w.Body.String())
+	}
+	var created shareLinkResponse
+	json.NewDecoder(w.Body).Decode(&created)
+	if !created.Expires.Equal(now.AddDate(0, 0, 7)) {
+		t.Errorf("Expected the link to expire in 7 days, but it expires at %v.", created.Expires)
+	}
+	u, err := url.Parse(created.URL)
+	if err != nil || !strings.HasPrefix(created.URL, "https://pill.example.com/shared/?token=") {
+		t.Fatalf("Expected a share URL, but got %q.", created.URL)
+	}
+	if _, ok := links[u.Query().Get("token")]; ok {
+		t.Error("Expected the token not to be stored.")
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		at             time.Time
+		expectedStatus int
+	}{
+		{"valid", u.Query().Get("token"), now.AddDate(0, 0, 6), http.StatusOK},
+		{"expired", u.Query().Get("token"), now.AddDate(0, 0, 7), http.StatusNotFound},
+		{"unknown", "nope", now, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		sh := NewSharedProfileHandler(mda)
+		sh.now = func() time.Time { return test.at }
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, "/shared/?"+url.Values{"token": {test.token}}.Encode(), nil)
+		sh.ServeHTTP(w, r)
+		if w.Code != test.expectedStatus {
+			t.Errorf("%s: expected status %d, but got %d.", test.name, test.expectedStatus, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		body := w.Body.String()
+		if strings.Contains(body, "Private") || strings.Contains(body, "dev@github.com") {
+			t.Errorf("%s: expected the profile to be redacted, but got %s", test.name, body)
+		}
+		var sp sharedProfile
+		json.Unmarshal([]byte(body), &sp)
+		if sp.Name != "Dev" || len(sp.Skills) != 1 || sp.Skills[0].Skill != "go" {
+			t.Errorf("%s: expected the skills to be shown, but got %+v", test.name, sp)
+		}
+		if len(sp.Certifications) != 1 || sp.Certifications[0].Course != "Go" {
+			t.Errorf("%s: expected only the approved certification, but got %+v", test.name, sp.Certifications)
+		}
+	}
+}
+
+func TestThatOnlyTheOwnerCanRevokeAShareLink(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	tests := []struct {
+		owner          string
+		expectedStatus int
+	}{
+		{"dev@github.com", http.StatusNoContent},
+		{"lead@github.com", http.StatusNotFound},
+	}
+	for _, test := range tests {
+		mda := &mockDataAccess{
+			getShareLinkResponse: func(id string) (*dataaccess.ShareLink, bool, error) {
+				return &dataaccess.ShareLink{ID: id, EmailAddress: test.owner}, true, nil
+			},
+			deleteShareLinkResponse: func(id string) error {
+				return nil
+			},
+		}
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodDelete, "/profile/shares/?id=abc", nil)
+		NewShareLinkHandler(mda, sf, "").ServeHTTP(w, r)
+		if w.Code != test.expectedStatus {
+			t.Errorf("For a link owned by %s, expected status %d, but got %d.", test.owner, test.expectedStatus, w.Code)
+		}
+		if test.expectedStatus == http.StatusNoContent && mda.deleteShareLinkCallCount != 1 {
+			t.Errorf("Expected the link to be deleted once, but was deleted %d times.", mda.deleteShareLinkCallCount)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The SkillDescriptorHandler allows administrators to describe what each
+// level of a skill means.
+type SkillDescriptorHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewSkillDescriptorHandler creates an instance of the SkillDescriptorHandler.
+func NewSkillDescriptorHandler(da dataaccess.DataAccess) *SkillDescriptorHandler {
+	return &SkillDescriptorHandler{da}
+}
+
+// skillDescriptorUpdate is posted to the handler to replace the descriptions
+// of a skill's levels.
+type skillDescriptorUpdate struct {
+	Name        string                       `json:"name"`
+	Descriptors []dataaccess.LevelDescriptor `json:"descriptors"`
+}
+
+func (handler SkillDescriptorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling skill descriptor request.")
+
+	c, ok := administrator(r)
+	if !ok {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlySkillDescriptors")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	var update skillDescriptorUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil || update.Name == "" {
+		writeError(w, r, http.StatusBadRequest, "error.invalidSkillDescriptors")
+		return
+	}
+
+	err := dataaccess.WithContext(handler.DataAccess, r.Context()).SetSkillTagDescriptors(dataaccess.CleanTag(update.Name), update.Descriptors)
+	if _, ok := err.(dataaccess.ValidationError); ok {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err == dataaccess.ErrSkillTagNotFound {
+		writeError(w, r, http.StatusNotFound, "error.skillTagNotFound", update.Name)
+		return
+	}
+	if err != nil {
+		log.Print("Failed to set the skill descriptors. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.skillDescriptorsSaveFailed")
+		return
+	}
+
+	log.Printf("User %s has described %d levels of %s.", c.EmailAddress, len(update.Descriptors), update.Name)
+	writeJSON(w, http.StatusOK, update)
+}
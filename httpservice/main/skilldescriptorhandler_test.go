@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatAdministratorsCanDescribeSkillLevels(t *testing.T) {
+	tests := []struct {
+		c              caller.Caller
+		body           string
+		expectedStatus int
+		expectedSet    bool
+	}{
+		{caller.Caller{EmailAddress: "a-h@github.com"}, `{"name":"kubernetes","descriptors":[]}`, http.StatusForbidden, false},
+		{testAdministrator, `{"descriptors":[]}`, http.StatusBadRequest, false},
+		{testAdministrator, `{"name":"Kubernetes","descriptors":[{"level":3,"description":"Has run a production cluster."}]}`, http.StatusOK, true},
+	}
+
+	for _, test := range tests {
+		var name string
+		mda := &mockDataAccess{
+			setSkillTagDescriptorsResponse: func(n string, descriptors []dataaccess.LevelDescriptor) error {
+				name = n
+				return nil
+			},
+		}
+
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "http://example.com/admin/skills/descriptors/", test.body, test.c)
+
+		NewSkillDescriptorHandler(mda).ServeHTTP(w, r)
+
+		if w.Code != test.expectedStatus {
+			t.Errorf("For %s posting %s, expected status %d, but got %d.", test.c.EmailAddress, test.body, test.expectedStatus, w.Code)
+		}
+		if set := mda.setSkillTagDescriptorsCallCount > 0; set != test.expectedSet {
+			t.Errorf("For %s posting %s, expected the descriptors to be set: %t, but were set: %t.", test.c.EmailAddress, test.body, test.expectedSet, set)
+		}
+		if test.expectedSet && name != "kubernetes" {
+			t.Errorf("Expected the tag name to be cleaned, but was %s.", name)
+		}
+	}
+}
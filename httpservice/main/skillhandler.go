@@ -8,7 +8,9 @@ import (
 	"github.com/a-h/pill/dataaccess"
 )
 
-// SkillHandler lists all of the skills previously mentioned.
+// SkillHandler lists all of the skills previously mentioned. The names of the
+// skills are returned, unless ?descriptors=true is passed, in which case the
+// descriptions of each skill's levels, its category and aliases are included.
 type SkillHandler struct {
 	DataAccess dataaccess.DataAccess
 	getSession func(w http.ResponseWriter, r *http.Request) Session
@@ -22,18 +24,23 @@ func NewSkillHandler(da dataaccess.DataAccess, sessionFactory func(w http.Respon
 func (sh SkillHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Handling skill request.")
 
-	skillTags, err := sh.DataAccess.ListSkillTags()
+	skillTags, err := dataaccess.WithContext(sh.DataAccess, r.Context()).ListSkillTags()
 
 	if err != nil {
 		log.Printf("Failed to list skill tags, with error %s", err)
-		http.Error(w, "Failed to list skill tags", http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, "error.skillTagsListFailed")
 		return
 	}
 
+	var response interface{} = dataaccess.SkillTagNames(skillTags)
+	if r.FormValue("descriptors") == "true" {
+		response = skillTags
+	}
+
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
-	if err = json.NewEncoder(w).Encode(skillTags); err != nil {
+	if err = json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("Failed to marshall the skill tags, with error %s", err)
-		http.Error(w, "Failed to marshall skill tags", http.StatusInternalServerError)
+		writeError(w, r, http.StatusInternalServerError, "error.skillTagsListFailed")
 	}
 }
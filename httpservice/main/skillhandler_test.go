@@ -5,12 +5,14 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/a-h/pill/dataaccess"
 )
 
 func TestThatTheSkillHandlerReturnsJSON(t *testing.T) {
 	mda := &mockDataAccess{
-		listSkillTagsResponse: func() ([]string, error) {
-			return []string{"a", "b"}, nil
+		listSkillTagsResponse: func() ([]dataaccess.SkillTag, error) {
+			return []dataaccess.SkillTag{{Name: "a"}, {Name: "b"}}, nil
 		},
 	}
 
@@ -39,3 +41,28 @@ func TestThatTheSkillHandlerReturnsJSON(t *testing.T) {
 		t.Errorf("Expected JSON to be %s, was %s", expected, actual)
 	}
 }
+
+func TestThatTheSkillHandlerCanIncludeLevelDescriptors(t *testing.T) {
+	mda := &mockDataAccess{
+		listSkillTagsResponse: func() ([]dataaccess.SkillTag, error) {
+			return []dataaccess.SkillTag{{Name: "kubernetes", Descriptors: []dataaccess.LevelDescriptor{{Level: 3, Description: "Has run a production cluster."}}}}, nil
+		},
+	}
+
+	sessionFactory := func(w http.ResponseWriter, r *http.Request) Session {
+		return &mockSession{
+			validateSessionValidResponse:        true,
+			validateSessionEmailAddressResponse: "a-h@github.com",
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/skill/?descriptors=true", nil)
+
+	NewSkillHandler(mda, sessionFactory).ServeHTTP(w, r)
+
+	expected := `[{"name":"kubernetes","descriptors":[{"level":3,"description":"Has run a production cluster."}]}]`
+	if actual := strings.TrimSpace(w.Body.String()); actual != expected {
+		t.Errorf("Expected JSON to be %s, was %s", expected, actual)
+	}
+}
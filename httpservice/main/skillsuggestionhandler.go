@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The SkillSuggestionHandler lists the skills found in the user's CV, and
+// adds the ones they accept to their profile.
+type SkillSuggestionHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewSkillSuggestionHandler creates an instance of the SkillSuggestionHandler.
+func NewSkillSuggestionHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *SkillSuggestionHandler {
+	return &SkillSuggestionHandler{da, sessionFactory, time.Now}
+}
+
+// skillSuggestionDecision is posted to accept or dismiss skill suggestions.
+// If Skills is empty, every suggestion is accepted.
+type skillSuggestionDecision struct {
+	ID       string   `json:"id"`
+	Decision string   `json:"decision"`
+	Skills   []string `json:"skills"`
+}
+
+func (handler SkillSuggestionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling skill suggestion request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		suggestions, err := da.ListSkillSuggestions(emailAddress)
+		if err != nil {
+			log.Printf("Failed to list the skill suggestions of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.skillSuggestionReadFailed")
+			return
+		}
+		if suggestions == nil {
+			suggestions = []dataaccess.SkillSuggestions{}
+		}
+		writeJSON(w, http.StatusOK, suggestions)
+	case http.MethodPost:
+		handler.decide(w, r, da, emailAddress)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func (handler SkillSuggestionHandler) decide(w http.ResponseWriter, r *http.Request, da dataaccess.DataAccess, emailAddress string) {
+	var d skillSuggestionDecision
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil || (d.Decision != "accept" && d.Decision != "dismiss") {
+		writeError(w, r, http.StatusBadRequest, "error.invalidSkillSuggestionDecision")
+		return
+	}
+
+	ss, found, err := da.GetSkillSuggestions(d.ID)
+	if err != nil {
+		log.Printf("Failed to get skill suggestions %s. %v", d.ID, err)
+		writeError(w, r, http.StatusInternalServerError, "error.skillSuggestionReadFailed")
+		return
+	}
+	// People can't find out which suggestions other people have.
+	if !found || ss.EmailAddress != emailAddress {
+		writeError(w, r, http.StatusNotFound, "error.skillSuggestionNotFound")
+		return
+	}
+
+	accept := d.Decision == "accept"
+	if err := ss.Decide(accept, d.Skills, handler.now()); err != nil {
+		writeError(w, r, http.StatusConflict, "error.skillSuggestionDecided")
+		return
+	}
+
+	if accept && len(ss.Suggestions) > 0 {
+		if status, key := applySuggestions(da, emailAddress, ss.Suggestions, "error.skillSuggestionSaveFailed"); key != "" {
+			writeError(w, r, status, key)
+			return
+		}
+	}
+
+	if err := da.SaveSkillSuggestions(ss); err != nil {
+		log.Print("Failed to save the skill suggestions. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.skillSuggestionSaveFailed")
+		return
+	}
+
+	log.Printf("User %s has %s the skill suggestions %s.", emailAddress, ss.Status, ss.ID)
+	writeJSON(w, http.StatusOK, ss)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/resume"
+)
+
+func TestThatSkillsFoundInUploadedCVsAreSuggested(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+	var saved *dataaccess.SkillSuggestions
+	mda := &mockDataAccess{
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{EmailAddress: emailAddress, Skills: []dataaccess.Skill{{Skill: "go", Level: dataaccess.ExpertLevel}}}, true, nil
+		},
+		updateCVResponse: func(emailAddress string, cv *dataaccess.Attachment) error {
+			return nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.Settings{}, nil
+		},
+		listSkillTagsResponse: func() ([]dataaccess.SkillTag, error) {
+			return []dataaccess.SkillTag{{Name: "go"}, {Name: "kubernetes", Aliases: []string{"k8s"}}}, nil
+		},
+		saveSkillSuggestionsResponse: func(ss *dataaccess.SkillSuggestions) error {
+			saved = ss
+			return nil
+		},
+	}
+	cc := newTestConfigurationCache(dataaccess.Configuration{SessionEncryptionKey: []byte("key")})
+
+	handler := NewCVHandler(mda, memoryStore{}, resume.LocalParser{}, cc, sf)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newCVRequest("cv.rtf", []byte(`{\rtf1 Go and K8S for 4 years.}`)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status OK, but was %d: %s", w.Code, w.Body.String())
+	}
+	expected := []dataaccess.LevelSuggestion{{Skill: "kubernetes", Suggested: dataaccess.ProficientLevel}}
+	if saved == nil || saved.Source != "cv.rtf" || len(saved.Suggestions) != 1 || saved.Suggestions[0] != expected[0] {
+		t.Fatalf("Expected %+v to be suggested, but got %+v.", expected, saved)
+	}
+
+	var resp cvResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.Suggestions == nil || resp.Suggestions.ID != saved.ID {
+		t.Errorf("Expected the response to include the suggestions, but got %+v.", resp.Suggestions)
+	}
+}
+
+func TestThatAcceptedSkillSuggestionsAreAddedToTheProfile(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	tests := []struct {
+		emailAddress   string
+		body           string
+		expectedStatus int
+		expectedSkills []dataaccess.Skill
+	}{
+		{"dev@github.com", `{"id":"1","decision":"accept","skills":["Kubernetes"]}`, http.StatusOK, []dataaccess.Skill{
+			{Skill: "go", Level: dataaccess.ExpertLevel},
+			{Skill: "kubernetes", Level: dataaccess.ProficientLevel},
+		}},
+		{"dev@github.com", `{"id":"1","decision":"accept"}`, http.StatusOK, []dataaccess.Skill{
+			{Skill: "go", Level: dataaccess.ExpertLevel},
+			{Skill: "kubernetes", Level: dataaccess.ProficientLevel},
+			{Skill: "terraform", Level: dataaccess.CompetentLevel},
+		}},
+		{"dev@github.com", `{"id":"1","decision":"dismiss"}`, http.StatusOK, nil},
+		{"other@github.com", `{"id":"1","decision":"accept"}`, http.StatusNotFound, nil},
+		{"dev@github.com", `{"id":"1","decision":"maybe"}`, http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		var updated *dataaccess.ProfileUpdate
+		mda := &mockDataAccess{
+			getSkillSuggestionsResponse: func(id string) (*dataaccess.SkillSuggestions, bool, error) {
+				ss := dataaccess.NewSkillSuggestions(test.emailAddress, "cv.pdf", []dataaccess.LevelSuggestion{
+					{Skill: "kubernetes", Suggested: dataaccess.ProficientLevel},
+					{Skill: "terraform", Suggested: dataaccess.CompetentLevel},
+				}, march)
+				return &ss, true, nil
+			},
+			getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+				return &dataaccess.Profile{EmailAddress: emailAddress, Skills: []dataaccess.Skill{{Skill: "go", Level: dataaccess.ExpertLevel}}}, true, nil
+			},
+			updateProfileResponse: func(pu *dataaccess.ProfileUpdate) (*dataaccess.Profile, error) {
+				updated = pu
+				return &dataaccess.Profile{}, nil
+			},
+			saveSkillSuggestionsResponse: func(ss *dataaccess.SkillSuggestions) error {
+				return nil
+			},
+		}
+
+		handler := NewSkillSuggestionHandler(mda, sf)
+		handler.now = func() time.Time { return march }
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodPost, "http://example.com/profile/suggestions/", strings.NewReader(test.body))
+		handler.ServeHTTP(w, r)
+
+		if w.Code != test.expectedStatus {
+			t.Errorf("For %s, expected status %d, but was %d.", test.body, test.expectedStatus, w.Code)
+			continue
+		}
+		if test.expectedSkills == nil {
+			if updated != nil {
+				t.Errorf("For %s, expected the profile not to be updated.", test.body)
+			}
+			continue
+		}
+		if updated == nil || len(updated.Skills) != len(test.expectedSkills) {
+			t.Errorf("For %s, expected skills %v, but got %+v.", test.body, test.expectedSkills, updated)
+			continue
+		}
+		for i, s := range test.expectedSkills {
+			if updated.Skills[i].Skill != s.Skill || updated.Skills[i].Level != s.Level {
+				t.Errorf("For %s, expected skill %d to be %v, but got %v.", test.body, i, s, updated.Skills[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The SkillTrashHandler allows administrators to list deleted skill tags,
+// and restore them before they're purged.
+type SkillTrashHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewSkillTrashHandler creates an instance of the SkillTrashHandler.
+func NewSkillTrashHandler(da dataaccess.DataAccess) *SkillTrashHandler {
+	return &SkillTrashHandler{da}
+}
+
+type deletedSkillTag struct {
+	Name    string    `json:"name"`
+	Deleted time.Time `json:"deleted"`
+	Expires time.Time `json:"expires"`
+}
+
+type skillTagRestore struct {
+	Tags []string `json:"tags"`
+}
+
+type skillTagRestoreResult struct {
+	Restored []string `json:"restored"`
+}
+
+func (handler SkillTrashHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling skill trash request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok || !c.HasRole(dataaccess.AdministratorRole) {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlySkillTrash")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		tags, err := da.ListDeletedSkillTags()
+		if err != nil {
+			log.Print("Failed to list the deleted skill tags. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.skillTrashReadFailed")
+			return
+		}
+
+		response := make([]deletedSkillTag, len(tags))
+		for i, t := range tags {
+			response[i] = deletedSkillTag{t.Name, t.Deleted, t.Expires()}
+		}
+		writeJSON(w, http.StatusOK, response)
+	case http.MethodPost:
+		var restore skillTagRestore
+		if err := json.NewDecoder(r.Body).Decode(&restore); err != nil || len(restore.Tags) == 0 {
+			writeError(w, r, http.StatusBadRequest, "error.invalidSkillTagRestore")
+			return
+		}
+
+		restored, err := da.RestoreSkillTags(restore.Tags)
+		if err != nil {
+			log.Print("Failed to restore the skill tags. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.skillTagRestoreFailed")
+			return
+		}
+
+		log.Printf("User %s has restored the skill tags %v.", c.EmailAddress, restored)
+		writeJSON(w, http.StatusOK, skillTagRestoreResult{restored})
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
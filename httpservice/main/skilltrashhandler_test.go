@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatDeletedSkillTagsAreListedWithTheirExpiry(t *testing.T) {
+	deleted := time.Date(2017, time.March, 6, 12, 0, 0, 0, time.UTC)
+	mda := &mockDataAccess{
+		listDeletedSkillTagsResponse: func() ([]dataaccess.DeletedSkillTag, error) {
+			return []dataaccess.DeletedSkillTag{{Name: "go", Tag: dataaccess.SkillTag{Name: "go"}, Deleted: deleted}}, nil
+		},
+	}
+
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("GET", "http://example.com/admin/skills/trash/", "", testAdministrator)
+
+	NewSkillTrashHandler(mda).ServeHTTP(w, r)
+
+	var response []deletedSkillTag
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal("Failed to decode the trash.", err)
+	}
+
+	if len(response) != 1 || response[0].Name != "go" || !response[0].Expires.Equal(deleted.Add(30*24*time.Hour)) {
+		t.Errorf("Expected go to expire 30 days after it was deleted, but received %v", response)
+	}
+}
+
+func TestThatAdministratorsCanRestoreDeletedSkillTags(t *testing.T) {
+	tests := []struct {
+		c              caller.Caller
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{testAdministrator, `{"tags":["go","cobol"]}`, http.StatusOK, `{"restored":["go"]}` + "\n"},
+		{testAdministrator, `{"tags":[]}`, http.StatusBadRequest, ""},
+		{caller.Caller{EmailAddress: "a-h@github.com"}, `{"tags":["go"]}`, http.StatusForbidden, ""},
+	}
+
+	for _, test := range tests {
+		mda := &mockDataAccess{
+			restoreSkillTagsResponse: func(tags []string) ([]string, error) {
+				return tags[:1], nil
+			},
+		}
+
+		w := httptest.NewRecorder()
+		r := newRequestWithCaller("POST", "http://example.com/admin/skills/trash/", test.body, test.c)
+
+		NewSkillTrashHandler(mda).ServeHTTP(w, r)
+
+		if w.Code != test.expectedStatus || (test.expectedBody != "" && w.Body.String() != test.expectedBody) {
+			t.Errorf("For %s posting '%s', expected %d '%s', but received %d '%s'.", test.c.EmailAddress, test.body, test.expectedStatus, test.expectedBody, w.Code, w.Body.String())
+		}
+	}
+}
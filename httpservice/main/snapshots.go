@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// materializeReports computes the department summary and heatmap of every
+// domain, and saves them as snapshots, so that the reports of whole domains
+// can be served without reading every profile on each request. Failures are
+// logged, and the last error is returned.
+func materializeReports(da dataaccess.DataAccess) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		da := dataaccess.WithContext(da, ctx)
+		domains, err := da.ListDomains()
+		if err != nil {
+			return err
+		}
+
+		var lastErr error
+		for _, domain := range domains {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := materializeDomainReports(da, domain, time.Now()); err != nil {
+				log.Printf("Failed to materialise the reports of %s. %v", domain, err)
+				lastErr = err
+			}
+		}
+		log.Printf("Materialised the reports of %d domains.", len(domains))
+		return lastErr
+	}
+}
+
+func materializeDomainReports(da dataaccess.DataAccess, domain string, now time.Time) error {
+	profiles, err := da.ListProfiles("@" + domain)
+	if err != nil {
+		return err
+	}
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		return err
+	}
+
+	profiles = dataaccess.Consenting(profiles, dataaccess.ConsentAnalytics, settings.Consent)
+
+	reports := map[string]interface{}{
+		dataaccess.DepartmentsSnapshot: dataaccess.Departments(profiles),
+		dataaccess.HeatmapSnapshot:     newHeatmap(newReportModel(profiles)),
+	}
+	for name, report := range reports {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		if err := da.SaveReportSnapshot(dataaccess.NewReportSnapshot(domain, name, data, len(profiles), now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSnapshot reads the domain's snapshot of the report into v, if there's
+// one which is less than maxAge old, and sets the Last-Modified header to when
+// it was computed. Reports are computed live if it returns false.
+func readSnapshot(w http.ResponseWriter, da dataaccess.DataAccess, domain string, report string, maxAge time.Duration, v interface{}) bool {
+	if maxAge <= 0 {
+		return false
+	}
+	s, found, err := da.GetReportSnapshot(domain, report)
+	if err != nil {
+		log.Printf("Unable to retrieve the %s snapshot of %s, so computing it. %v", report, domain, err)
+		return false
+	}
+	if !found || !s.Fresh(maxAge, time.Now()) {
+		return false
+	}
+	if err := json.Unmarshal(s.Data, v); err != nil {
+		log.Printf("Unable to read the %s snapshot of %s, so computing it. %v", report, domain, err)
+		return false
+	}
+	w.Header().Set("Last-Modified", s.Computed.Format(http.TimeFormat))
+	return true
+}
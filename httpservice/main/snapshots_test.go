@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatReportsAreMaterialised(t *testing.T) {
+	saved := map[string]*dataaccess.ReportSnapshot{}
+	mda := &mockDataAccess{
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "a-h@github.com", Department: "Engineering", Skills: []dataaccess.Skill{{Skill: "go", Level: 4}}},
+				{EmailAddress: "withdrawn@github.com", Department: "Engineering", Skills: []dataaccess.Skill{{Skill: "sql", Level: 2}},
+					Consents: []dataaccess.Consent{{Purpose: dataaccess.ConsentAnalytics, Given: false}}},
+			}, nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+		saveReportSnapshotResponse: func(s *dataaccess.ReportSnapshot) error {
+			saved[s.ID] = s
+			return nil
+		},
+	}
+
+	now := time.Date(2018, time.March, 1, 9, 15, 0, 0, time.UTC)
+	if err := materializeDomainReports(mda, "GitHub.com", now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	h, ok := saved["github.com/heatmap"]
+	if !ok || h.Profiles != 1 || !h.Computed.Equal(now) {
+		t.Fatalf("Expected a heatmap snapshot of the profile which consented, but got %+v", h)
+	}
+	var hm heatmap
+	if err := json.Unmarshal(h.Data, &hm); err != nil || len(hm.People) != 1 {
+		t.Errorf("Expected the heatmap to leave out people who haven't consented, but got %+v, %v", hm, err)
+	}
+	d, ok := saved["github.com/departments"]
+	if !ok {
+		t.Fatalf("Expected a departments snapshot, but got %v", saved)
+	}
+	var departments []dataaccess.DepartmentSummary
+	if err := json.Unmarshal(d.Data, &departments); err != nil || len(departments) != 1 || departments[0].People != 1 {
+		t.Errorf("Expected the departments to leave out people who haven't consented, but got %+v, %v", departments, err)
+	}
+}
+
+func TestThatHeatmapsAreServedFromFreshSnapshots(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session { return ms }
+
+	computed := time.Now().Add(-30 * time.Minute).UTC().Truncate(time.Second)
+	data, _ := json.Marshal(heatmap{Skills: []string{"go"}, People: []heatmapRow{{EmailAddress: "snapshot@github.com", Levels: []dataaccess.DreyfusLevel{3}}}})
+	mda := &mockDataAccess{
+		getReportSnapshotResponse: func(domain string, report string) (*dataaccess.ReportSnapshot, bool, error) {
+			return dataaccess.NewReportSnapshot(domain, report, data, 1, computed), report == dataaccess.HeatmapSnapshot, nil
+		},
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{{EmailAddress: "live@github.com"}}, nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+	}
+
+	tests := []struct {
+		url      string
+		maxAge   time.Duration
+		expected string
+	}{
+		{"/report/heatmap/", time.Hour, "snapshot@github.com"},
+		{"/report/heatmap/", 10 * time.Minute, "live@github.com"},
+		{"/report/heatmap/", 0, "live@github.com"},
+		{"/report/heatmap/?department=Engineering", time.Hour, ""},
+	}
+	for _, test := range tests {
+		h := NewHeatmapHandler(mda, sf)
+		h.SnapshotMaxAge = test.maxAge
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.url, nil)
+		h.ServeHTTP(w, r)
+
+		var hm heatmap
+		if err := json.NewDecoder(w.Body).Decode(&hm); err != nil {
+			t.Fatalf("Failed to decode the heatmap: %v", err)
+		}
+		var served string
+		if len(hm.People) > 0 {
+			served = hm.People[0].EmailAddress
+		}
+		if served != test.expected {
+			t.Errorf("For %s with a maximum age of %v, expected %q, but got %q", test.url, test.maxAge, test.expected, served)
+		}
+		if fromSnapshot := w.Header().Get("Last-Modified") != ""; fromSnapshot != (test.expected == "snapshot@github.com") {
+			t.Errorf("For %s with a maximum age of %v, expected Last-Modified only for snapshots, but got '%s'", test.url, test.maxAge, w.Header().Get("Last-Modified"))
+		}
+	}
+}
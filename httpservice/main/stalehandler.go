@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The StaleSkillHandler lists the user's skills which haven't been confirmed
+// or exercised recently, and re-confirms them.
+type StaleSkillHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewStaleSkillHandler creates an instance of the StaleSkillHandler.
+func NewStaleSkillHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *StaleSkillHandler {
+	return &StaleSkillHandler{da, sessionFactory, time.Now}
+}
+
+// skillConfirmation is posted to re-confirm skills. If Skills is empty,
+// every stale skill is confirmed.
+type skillConfirmation struct {
+	Skills []string `json:"skills"`
+}
+
+type staleSkillsResponse struct {
+	dataaccess.DecaySettings
+	Skills []dataaccess.StaleSkill `json:"skills"`
+}
+
+func (handler StaleSkillHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling stale skills request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(emailAddress)
+
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	profile, found, err := da.GetProfile(emailAddress)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", emailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.staleSkillsReadFailed")
+		return
+	}
+	if !found {
+		writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+		return
+	}
+	projects, err := da.ListProjects(domain)
+	if err != nil {
+		log.Printf("Failed to list the projects of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.staleSkillsReadFailed")
+		return
+	}
+	stale := settings.Decay.StaleSkills(*profile, projects, handler.now())
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, staleSkillsResponse{settings.Decay, stale})
+	case http.MethodPost:
+		var c skillConfirmation
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidSkillConfirmation")
+			return
+		}
+		if len(c.Skills) == 0 {
+			for _, s := range stale {
+				c.Skills = append(c.Skills, s.Skill)
+			}
+		}
+		for i := range c.Skills {
+			c.Skills[i] = dataaccess.CleanTag(c.Skills[i])
+		}
+		if err := da.ConfirmSkills(emailAddress, c.Skills); err != nil {
+			log.Printf("Failed to confirm the skills of %s. %v", emailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.skillConfirmationFailed")
+			return
+		}
+		log.Printf("User %s has re-confirmed %d skills.", emailAddress, len(c.Skills))
+
+		confirmed := make(map[string]bool)
+		for _, s := range c.Skills {
+			confirmed[s] = true
+		}
+		remaining := []dataaccess.StaleSkill{}
+		for _, s := range stale {
+			if !confirmed[s.Skill] {
+				remaining = append(remaining, s)
+			}
+		}
+		writeJSON(w, http.StatusOK, staleSkillsResponse{settings.Decay, remaining})
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+// decayed discounts the levels of stale skills in the profiles, if the
+// domain's settings ask for it, so that people aren't matched to work on the
+// strength of skills they haven't used for a long time.
+func decayed(da dataaccess.DataAccess, domain string, profiles []dataaccess.Profile, at time.Time) ([]dataaccess.Profile, error) {
+	settings, err := da.GetSettings(domain)
+	if err != nil || !settings.Decay.Enabled() || settings.Decay.Discount == 0 {
+		return profiles, err
+	}
+	projects, err := da.ListProjects(domain)
+	if err != nil {
+		return profiles, err
+	}
+	return settings.Decay.Discounted(profiles, projects, at), nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func newStaleDataAccess(confirmed *[]string) *mockDataAccess {
+	return &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.Settings{Decay: dataaccess.DecaySettings{StaleMonths: 12, Discount: 1}}, nil
+		},
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{
+				EmailAddress: emailAddress,
+				LastUpdated:  march.AddDate(-2, 0, 0),
+				Skills: []dataaccess.Skill{
+					{Skill: "go", Level: dataaccess.ExpertLevel, Confirmed: march},
+					{Skill: "sql", Level: dataaccess.ExpertLevel},
+					{Skill: "java", Level: dataaccess.ProficientLevel},
+				},
+			}, true, nil
+		},
+		listProjectsResponse: func(domain string) ([]dataaccess.Project, error) {
+			return nil, nil
+		},
+		confirmSkillsResponse: func(emailAddress string, skills []string) error {
+			*confirmed = skills
+			return nil
+		},
+	}
+}
+
+func TestThatStaleSkillsAreListedAndConfirmed(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	tests := []struct {
+		method            string
+		body              string
+		expectedConfirmed []string
+		expectedStale     []string
+	}{
+		{"GET", "", nil, []string{"sql", "java"}},
+		{"POST", `{"skills":["SQL"]}`, []string{"sql"}, []string{"java"}},
+		{"POST", `{}`, []string{"sql", "java"}, []string{}},
+	}
+
+	for _, test := range tests {
+		var confirmed []string
+		handler := NewStaleSkillHandler(newStaleDataAccess(&confirmed), sf)
+		handler.now = func() time.Time { return march.AddDate(0, 1, 0) }
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(test.method, "http://example.com/profile/stale/", strings.NewReader(test.body))
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("For %s %s, expected status OK, but was %d.", test.method, test.body, w.Code)
+		}
+		if strings.Join(confirmed, ",") != strings.Join(test.expectedConfirmed, ",") {
+			t.Errorf("For %s %s, expected %v to be confirmed, but was %v.", test.method, test.body, test.expectedConfirmed, confirmed)
+		}
+		var response staleSkillsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		var stale []string
+		for _, s := range response.Skills {
+			stale = append(stale, s.Skill)
+		}
+		if strings.Join(stale, ",") != strings.Join(test.expectedStale, ",") {
+			t.Errorf("For %s %s, expected %v to be stale, but was %v.", test.method, test.body, test.expectedStale, stale)
+		}
+	}
+}
+
+func TestThatStaleSkillsAreDiscountedWhenMatching(t *testing.T) {
+	var confirmed []string
+	mda := newStaleDataAccess(&confirmed)
+	profiles := []dataaccess.Profile{
+		{EmailAddress: "dev@github.com", LastUpdated: march.AddDate(-2, 0, 0), Skills: []dataaccess.Skill{{Skill: "go", Level: dataaccess.ExpertLevel}}},
+	}
+
+	discounted, err := decayed(mda, "github.com", profiles, march)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if discounted[0].Skills[0].Level != dataaccess.ProficientLevel {
+		t.Errorf("expected the stale skill to be discounted to proficient, but was %v", discounted[0].Skills[0].Level)
+	}
+}
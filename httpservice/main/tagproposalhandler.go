@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The TagProposalHandler is the queue of skill tags proposed by users of
+// tenants with a strict vocabulary. Administrators approve or reject them,
+// and once every tag proposed by a profile update has been decided, the
+// update is applied without the rejected tags.
+type TagProposalHandler struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewTagProposalHandler creates an instance of the TagProposalHandler.
+func NewTagProposalHandler(da dataaccess.DataAccess) *TagProposalHandler {
+	return &TagProposalHandler{da, time.Now}
+}
+
+type tagProposalDecision struct {
+	ID string `json:"id"`
+	// Action is "approve" or "reject".
+	Action string `json:"action"`
+}
+
+// checkVocabulary replaces aliases in the update with the names of their
+// tags, and returns false if the update uses tags which aren't in the
+// vocabulary. If the user has explained why the tags are needed, they're
+// proposed to an administrator, and the update is held until they've been
+// decided.
+func checkVocabulary(w http.ResponseWriter, r *http.Request, da dataaccess.DataAccess, pu *dataaccess.ProfileUpdate, now time.Time) bool {
+	tags, err := da.ListSkillTags()
+	if err != nil {
+		log.Print("Failed to list the skill tags. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.skillTagsListFailed")
+		return false
+	}
+
+	var unknown []string
+	pu.Skills, unknown = dataaccess.NewVocabulary(tags).Resolve(pu.Skills)
+	if len(unknown) == 0 {
+		return true
+	}
+
+	justification := strings.TrimSpace(r.Form.Get("justification"))
+	if justification == "" {
+		writeError(w, r, http.StatusBadRequest, "error.unknownSkillTags", strings.Join(unknown, ", "))
+		return false
+	}
+
+	profile, _, err := da.GetProfile(pu.EmailAddress)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", pu.EmailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.tagProposalSaveFailed")
+		return false
+	}
+	var version int
+	if profile != nil {
+		version = profile.Version
+	}
+
+	proposals := dataaccess.NewTagProposals(pu, version, unknown, justification, now)
+	for i := range proposals {
+		if err := da.SaveTagProposal(&proposals[i]); err != nil {
+			log.Printf("Failed to save the proposal of %s by %s. %v", proposals[i].Tag, pu.EmailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.tagProposalSaveFailed")
+			return false
+		}
+	}
+
+	log.Printf("User %s has proposed the skill tags %v.", pu.EmailAddress, unknown)
+	writeError(w, r, http.StatusAccepted, "error.skillTagsProposed", strings.Join(unknown, ", "))
+	return false
+}
+
+func (handler TagProposalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling tag proposal request.")
+
+	c, ok := administrator(r)
+	if !ok {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyTagProposals")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	switch r.Method {
+	case http.MethodGet:
+		proposals, err := da.ListTagProposals(r.FormValue("tenant"))
+		if err != nil {
+			log.Print("Failed to list the tag proposals. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.tagProposalReadFailed")
+			return
+		}
+		if proposals == nil {
+			proposals = []dataaccess.TagProposal{}
+		}
+		writeJSON(w, http.StatusOK, proposals)
+	case http.MethodPost:
+		var d tagProposalDecision
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil || (d.Action != "approve" && d.Action != "reject") {
+			writeError(w, r, http.StatusBadRequest, "error.invalidTagProposalDecision")
+			return
+		}
+		p, status, key := handler.decide(da, d, c.EmailAddress)
+		if key != "" {
+			writeError(w, r, status, key)
+			return
+		}
+		log.Printf("User %s has decided to %s the skill tag %s.", c.EmailAddress, d.Action, p.Tag)
+		writeJSON(w, http.StatusOK, p)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+// decide records the decision, and applies the held update once the other
+// tags it proposed have been decided. It returns the status and error key
+// to respond with if the decision couldn't be made.
+func (handler TagProposalHandler) decide(da dataaccess.DataAccess, d tagProposalDecision, administrator string) (*dataaccess.TagProposal, int, string) {
+	p, found, err := da.GetTagProposal(d.ID)
+	if err != nil {
+		log.Printf("Failed to get the tag proposal %s. %v", d.ID, err)
+		return nil, http.StatusInternalServerError, "error.tagProposalReadFailed"
+	}
+	if !found {
+		return nil, http.StatusNotFound, "error.tagProposalNotFound"
+	}
+	if err := p.Decide(d.Action == "approve", administrator, handler.now()); err == dataaccess.ErrProposalDecided {
+		return nil, http.StatusConflict, "error.tagProposalDecided"
+	}
+
+	if p.Status == dataaccess.ProposalApproved {
+		if err := da.AddSkillTags([]string{p.Tag}); err != nil {
+			log.Printf("Failed to add the skill tag %s. %v", p.Tag, err)
+			return nil, http.StatusInternalServerError, "error.tagProposalSaveFailed"
+		}
+	} else {
+		p.WithoutSkill(p.Tag)
+	}
+	if err := da.SaveTagProposal(p); err != nil {
+		log.Printf("Failed to save the tag proposal %s. %v", p.ID, err)
+		return nil, http.StatusInternalServerError, "error.tagProposalSaveFailed"
+	}
+
+	pending, err := da.ListTagProposals(p.Domain)
+	if err != nil {
+		log.Printf("Failed to list the tag proposals of %s. %v", p.Domain, err)
+		return nil, http.StatusInternalServerError, "error.tagProposalReadFailed"
+	}
+	waiting := false
+	for i := range pending {
+		other := &pending[i]
+		if other.UpdateID != p.UpdateID || other.ID == p.ID {
+			continue
+		}
+		waiting = true
+		// The update is applied by the last decision, so it must not
+		// include the tags which have been rejected.
+		other.Update = p.Update
+		if err := da.SaveTagProposal(other); err != nil {
+			log.Printf("Failed to save the tag proposal %s. %v", other.ID, err)
+			return nil, http.StatusInternalServerError, "error.tagProposalSaveFailed"
+		}
+	}
+	if waiting {
+		return p, 0, ""
+	}
+
+	profile, _, err := da.GetProfile(p.ProposedBy)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", p.ProposedBy, err)
+		return nil, http.StatusInternalServerError, "error.tagProposalSaveFailed"
+	}
+	if profile != nil && profile.Version != p.Version {
+		log.Printf("The profile of %s has changed since the tag %s was proposed, discarding the held update.", p.ProposedBy, p.Tag)
+		return p, 0, ""
+	}
+	if _, err := da.UpdateProfile(p.Update); err != nil {
+		log.Printf("Failed to apply the held update to the profile of %s. %v", p.ProposedBy, err)
+		return nil, http.StatusInternalServerError, "error.tagProposalSaveFailed"
+	}
+	return p, 0, ""
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// newStrictDataAccess stores tag proposals in memory, in a tenant with a
+// strict vocabulary.
+func newStrictDataAccess(proposals map[string]*dataaccess.TagProposal, updates *[]*dataaccess.ProfileUpdate) *mockDataAccess {
+	return &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			s := dataaccess.DefaultSettings()
+			s.StrictVocabulary = true
+			return s, nil
+		},
+		listSkillTagsResponse: func() ([]dataaccess.SkillTag, error) {
+			return []dataaccess.SkillTag{{Name: "go", Aliases: []string{"golang"}}}, nil
+		},
+		getProfileResponse: func(emailAddress string) (*dataaccess.Profile, bool, error) {
+			return &dataaccess.Profile{EmailAddress: emailAddress, Version: 2}, true, nil
+		},
+		updateProfileResponse: func(update *dataaccess.ProfileUpdate) (*dataaccess.Profile, error) {
+			*updates = append(*updates, update)
+			return dataaccess.NewProfile(), nil
+		},
+		saveTagProposalResponse: func(p *dataaccess.TagProposal) error {
+			copied := *p
+			proposals[p.ID] = &copied
+			return nil
+		},
+		getTagProposalResponse: func(id string) (*dataaccess.TagProposal, bool, error) {
+			p, ok := proposals[id]
+			if !ok {
+				return nil, false, nil
+			}
+			copied := *p
+			return &copied, true, nil
+		},
+		listTagProposalsResponse: func(domain string) ([]dataaccess.TagProposal, error) {
+			var op []dataaccess.TagProposal
+			for _, p := range proposals {
+				if p.Status == dataaccess.ProposalPending {
+					op = append(op, *p)
+				}
+			}
+			return op, nil
+		},
+		addSkillTagsResponse: func(tags []string) error { return nil },
+	}
+}
+
+func TestThatUnknownTagsAreProposedInStrictVocabularies(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "dev@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	tests := []struct {
+		form              url.Values
+		expectedCode      int
+		expectedProposals int
+		expectedUpdates   int
+	}{
+		{url.Values{"availability": {"1"}, "name_1": {"golang"}, "level_1": {"4"}}, http.StatusFound, 0, 1},
+		{url.Values{"availability": {"1"}, "name_1": {"rust"}, "level_1": {"2"}}, http.StatusBadRequest, 0, 0},
+		{url.Values{"availability": {"1"}, "name_1": {"rust"}, "level_1": {"2"}, "name_2": {"zig"}, "level_2": {"1"}, "justification": {"We're starting a Rust project."}}, http.StatusAccepted, 2, 0},
+	}
+
+	for _, test := range tests {
+		proposals := map[string]*dataaccess.TagProposal{}
+		var updates []*dataaccess.ProfileUpdate
+
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "http://example.com/profile", strings.NewReader(test.form.Encode()))
+		r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		NewProfileHandler(newStrictDataAccess(proposals, &updates), sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %v, expected status %d, but was %d.", test.form, test.expectedCode, w.Code)
+		}
+		if len(proposals) != test.expectedProposals {
+			t.Errorf("For %v, expected %d proposals, but got %d.", test.form, test.expectedProposals, len(proposals))
+		}
+		if len(updates) != test.expectedUpdates {
+			t.Errorf("For %v, expected %d updates, but got %d.", test.form, test.expectedUpdates, len(updates))
+		}
+		if len(updates) == 1 && updates[0].Skills[0].Skill != "go" {
+			t.Errorf("For %v, expected the alias to be replaced with go, but was %s.", test.form, updates[0].Skills[0].Skill)
+		}
+	}
+}
+
+func TestThatTheHeldUpdateIsAppliedOnceEveryProposedTagIsDecided(t *testing.T) {
+	proposals := map[string]*dataaccess.TagProposal{}
+	var updates []*dataaccess.ProfileUpdate
+	mda := newStrictDataAccess(proposals, &updates)
+
+	u := &dataaccess.ProfileUpdate{EmailAddress: "dev@github.com", Skills: []dataaccess.Skill{{Skill: "go"}, {Skill: "rust"}, {Skill: "zig"}}}
+	for _, p := range dataaccess.NewTagProposals(u, 2, []string{"rust", "zig"}, "New project", march) {
+		p := p
+		proposals[p.ID] = &p
+	}
+	ids := map[string]string{}
+	for id, p := range proposals {
+		ids[p.Tag] = id
+	}
+
+	handler := NewTagProposalHandler(mda)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequestWithCaller("POST", "http://example.com/admin/skills/proposals/", `{"id":"`+ids["rust"]+`","action":"approve"}`, testAdministrator))
+	if w.Code != http.StatusOK || len(updates) != 0 || mda.addSkillTagsCallCount != 1 {
+		t.Fatalf("expected the tag to be added without applying the update, but got status %d, %d updates", w.Code, len(updates))
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequestWithCaller("POST", "http://example.com/admin/skills/proposals/", `{"id":"`+ids["zig"]+`","action":"reject"}`, testAdministrator))
+	if w.Code != http.StatusOK || len(updates) != 1 {
+		t.Fatalf("expected the update to be applied, but got status %d, %d updates", w.Code, len(updates))
+	}
+	var skills []string
+	for _, s := range updates[0].Skills {
+		skills = append(skills, s.Skill)
+	}
+	if strings.Join(skills, ",") != "go,rust" {
+		t.Errorf("expected the update to be applied without the rejected tag, but was %v", skills)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequestWithCaller("POST", "http://example.com/admin/skills/proposals/", `{"id":"`+ids["zig"]+`","action":"approve"}`, testAdministrator))
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected a decided proposal to be rejected with a conflict, but got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequestWithCaller("GET", "http://example.com/admin/skills/proposals/", "", testReport))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected users who aren't administrators to be forbidden, but got %d", w.Code)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/staffing"
+)
+
+// The TeamHandler suggests a team from the user's domain which covers the
+// posted skill requirements. Candidates can be limited to a department or
+// cost center with ?department= or ?costCenter=, or to the people who speak
+// a language, e.g. ?language=de&languageLevel=B2.
+type TeamHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewTeamHandler creates an instance of the TeamHandler.
+func NewTeamHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *TeamHandler {
+	return &TeamHandler{da, sessionFactory, time.Now}
+}
+
+// teamRequest is posted to suggest a team.
+type teamRequest struct {
+	Requirements []dataaccess.SkillRequirement `json:"requirements"`
+	// Candidates limits the team to these email addresses. If empty, anyone
+	// in the domain can be suggested.
+	Candidates []string `json:"candidates"`
+	// MinimumAvailability excludes people who are less available. If zero,
+	// people who are at least amber are included.
+	MinimumAvailability dataaccess.RagStatus `json:"minimumAvailability"`
+}
+
+func (handler TeamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling team suggestion request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	var tr teamRequest
+	if err := json.NewDecoder(r.Body).Decode(&tr); err != nil || len(tr.Requirements) == 0 {
+		writeError(w, r, http.StatusBadRequest, "error.invalidTeamRequest")
+		return
+	}
+	if tr.MinimumAvailability == 0 {
+		tr.MinimumAvailability = dataaccess.Amber
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	now := handler.now()
+
+	profiles, err := filteredProfiles(da, r, emailAddress)
+	if err == nil {
+		profiles, err = decayed(da, dataaccess.GetDomain(emailAddress), profiles, now)
+	}
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+
+	if len(tr.Candidates) > 0 {
+		pool := make(map[string]bool)
+		for _, c := range tr.Candidates {
+			pool[strings.ToLower(strings.TrimSpace(c))] = true
+		}
+		var candidates []dataaccess.Profile
+		for _, p := range profiles {
+			if pool[strings.ToLower(p.EmailAddress)] {
+				candidates = append(candidates, p)
+			}
+		}
+		profiles = candidates
+	}
+
+	team := staffing.Suggest(tr.Requirements, profiles, tr.MinimumAvailability, now)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(team); err != nil {
+		log.Printf("Failed to marshall the team, with error %s", err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/staffing"
+)
+
+func TestThatTeamsAreSuggestedFromTheCandidates(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	mda := &mockDataAccess{
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.Settings{}, nil
+		},
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "expert@github.com", Availability: dataaccess.Green, Skills: []dataaccess.Skill{{Skill: "go", Level: 5}}},
+				{EmailAddress: "novice@github.com", Availability: dataaccess.Green, Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}},
+			}, nil
+		},
+	}
+
+	tests := []struct {
+		body           string
+		expectedCode   int
+		expectedMember string
+	}{
+		{`{"requirements":[{"skill":"go","level":3}]}`, http.StatusOK, "expert@github.com"},
+		{`{"requirements":[{"skill":"go","level":3}],"candidates":["Novice@github.com"]}`, http.StatusOK, "novice@github.com"},
+		{`{"requirements":[{"skill":"go","level":3}],"candidates":["nobody@github.com"]}`, http.StatusOK, ""},
+		{`{"requirements":[]}`, http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "http://example.com/report/team/", strings.NewReader(test.body))
+
+		NewTeamHandler(mda, sf).ServeHTTP(w, r)
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s, expected status %d, but was %d.", test.body, test.expectedCode, w.Code)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var team staffing.Team
+		if err := json.NewDecoder(w.Body).Decode(&team); err != nil {
+			t.Fatal("Failed to decode the team.", err)
+		}
+		var members []string
+		for _, m := range team.Members {
+			members = append(members, m.EmailAddress)
+		}
+		if strings.Join(members, ",") != test.expectedMember {
+			t.Errorf("For %s, expected the team to be %q, but was %v.", test.body, test.expectedMember, members)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The TeamSummaryHandler returns the headcount, availability and skills of
+// a manager's direct reports from the team read model, e.g.
+// /report/teams/?manager=boss@example.com. Without a manager, the user's own
+// team is returned.
+type TeamSummaryHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+}
+
+// NewTeamSummaryHandler creates an instance of the TeamSummaryHandler.
+func NewTeamSummaryHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *TeamSummaryHandler {
+	return &TeamSummaryHandler{da, sessionFactory}
+}
+
+func (handler TeamSummaryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling team summary request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	manager := strings.ToLower(r.FormValue("manager"))
+	if manager == "" {
+		manager = strings.ToLower(emailAddress)
+	}
+	if !strings.EqualFold(dataaccess.GetDomain(manager), dataaccess.GetDomain(emailAddress)) {
+		writeError(w, r, http.StatusForbidden, "error.teamOutsideDomain")
+		return
+	}
+
+	s, found, err := dataaccess.WithContext(handler.DataAccess, r.Context()).GetTeamSummary(manager)
+	if err != nil {
+		log.Printf("Failed to read the team summary of %s. %v", manager, err)
+		writeError(w, r, http.StatusInternalServerError, "error.teamSummaryReadFailed")
+		return
+	}
+	if !found {
+		// A manager without reports has an empty team, rather than none.
+		empty := dataaccess.SummariseTeam(manager, nil, time.Now())
+		s = &empty
+	}
+	writeJSON(w, http.StatusOK, s)
+}
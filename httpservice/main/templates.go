@@ -11,8 +11,11 @@ import (
 var templates = template.Must(template.New("").Funcs(templateFunctions).ParseFiles("templates/header.html",
 	"./templates/navigation.html",
 	"./templates/login.html",
+	"./templates/clilogin.html",
 	"./templates/profile.html",
+	"./templates/card.html",
 	"./templates/report.html",
+	"./templates/shared.html",
 	"./templates/footer.html"))
 
 // Register the functions required to render the templates.
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/jwt"
+)
+
+// The TokenHandler issues short-lived access tokens to logged in users, so
+// that they can call other services which trust pill. Tokens are only issued
+// to session cookies, which can be revoked, and not to other access tokens,
+// since a token could otherwise be swapped for a new one before it expired,
+// forever. Tokens aren't issued while an administrator is impersonating
+// someone, since the token would outlive the impersonation, and other
+// services wouldn't know who was acting.
+type TokenHandler struct {
+	getSession    func(w http.ResponseWriter, r *http.Request) Session
+	Configuration *ConfigurationCache
+}
+
+// NewTokenHandler creates an instance of the TokenHandler.
+func NewTokenHandler(sessionFactory func(w http.ResponseWriter, r *http.Request) Session, cc *ConfigurationCache) *TokenHandler {
+	return &TokenHandler{sessionFactory, cc}
+}
+
+// tokenResponse follows the format of an OAuth 2.0 access token response.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func newIssuer(c dataaccess.Configuration) *jwt.Issuer {
+	return jwt.NewIssuer("pill", c.SessionEncryptionKey, c.PreviousSessionEncryptionKeys...)
+}
+
+func (handler TokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling token request.")
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.tokenMethod")
+		return
+	}
+
+	if strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		log.Print("Refusing to issue a token in exchange for a bearer token.")
+		writeError(w, r, http.StatusForbidden, "error.tokenFromBearer")
+		return
+	}
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	if c, ok := caller.FromContext(r.Context()); ok && c.Impersonator != "" {
+		log.Printf("Refusing to issue a token to %s, who is impersonating %s.", c.Impersonator, emailAddress)
+		writeError(w, r, http.StatusForbidden, "error.tokenWhileImpersonating")
+		return
+	}
+
+	c := handler.Configuration.Get()
+	token, claims, err := newIssuer(c).Issue(emailAddress, dataaccess.GetDomain(emailAddress), c.Roles(emailAddress), r.FormValue("audience"))
+
+	if err != nil {
+		log.Print("Failed to issue a token. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.tokenIssueFailed")
+		return
+	}
+
+	log.Printf("Issued token %s to user %s.", claims.ID, emailAddress)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(tokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   claims.Expires - claims.IssuedAt,
+	})
+}
+
+// The JWKSHandler publishes the keys which verify tokens issued by the
+// TokenHandler.
+type JWKSHandler struct {
+	Configuration *ConfigurationCache
+}
+
+// NewJWKSHandler creates an instance of the JWKSHandler.
+func NewJWKSHandler(cc *ConfigurationCache) *JWKSHandler {
+	return &JWKSHandler{cc}
+}
+
+func (handler JWKSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "max-age=300")
+	if err := json.NewEncoder(w).Encode(newIssuer(handler.Configuration.Get()).JWKS()); err != nil {
+		log.Printf("Failed to marshall the JWKS, with error %s", err)
+	}
+}
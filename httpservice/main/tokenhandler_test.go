@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/jwt"
+)
+
+func TestThatTokensAreIssuedWithRolesAndDomain(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	c := *dataaccess.NewConfiguration([]byte("0123456789abcdef0123456789abcdef"))
+	c.Administrators = []string{"a-h@github.com"}
+	cc := newTestConfigurationCache(c)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "http://example.com/api/token/", nil)
+
+	NewTokenHandler(sf, cc).ServeHTTP(w, r)
+
+	var response tokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal("Failed to decode the token response.", err)
+	}
+
+	claims, err := newIssuer(c).Verify(response.AccessToken)
+	if err != nil {
+		t.Fatal("The issued token should be valid.", err)
+	}
+
+	if claims.Domain != "github.com" {
+		t.Errorf("Expected the domain claim to be github.com, but was %s", claims.Domain)
+	}
+
+	if len(claims.Roles) != 2 || claims.Roles[1] != dataaccess.AdministratorRole {
+		t.Errorf("Expected the user and administrator roles, but was %v", claims.Roles)
+	}
+}
+
+func TestThatTokensArentIssuedWhileImpersonating(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	c := *dataaccess.NewConfiguration([]byte("0123456789abcdef0123456789abcdef"))
+	c.Administrators = []string{"admin@github.com"}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "http://example.com/api/token/", nil)
+	r = r.WithContext(caller.NewContext(r.Context(), caller.Caller{EmailAddress: "a-h@github.com", Impersonator: "admin@github.com"}))
+
+	NewTokenHandler(sf, newTestConfigurationCache(c)).ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected the status to be 403, but was %d.", w.Code)
+	}
+
+	if strings.Contains(w.Body.String(), "access_token") {
+		t.Error("A token should not be issued while impersonating.")
+	}
+}
+
+func TestThatTokensArentIssuedInExchangeForABearerToken(t *testing.T) {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	c := *dataaccess.NewConfiguration([]byte("0123456789abcdef0123456789abcdef"))
+	bearerToken, _, _ := newIssuer(c).Issue("a-h@github.com", "github.com", nil, "")
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "http://example.com/api/token/", nil)
+	r.Header.Set("Authorization", "Bearer "+bearerToken)
+
+	NewTokenHandler(sf, newTestConfigurationCache(c)).ServeHTTP(w, r)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected the status to be 403, but was %d.", w.Code)
+	}
+
+	if strings.Contains(w.Body.String(), "access_token") {
+		t.Error("A token should not be issued in exchange for another token.")
+	}
+}
+
+func TestThatTokensCanOnlyBeRequestedWithAPost(t *testing.T) {
+	ms := &mockSession{}
+
+	sf := func(w http.ResponseWriter, r *http.Request) Session {
+		return ms
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/api/token/", nil)
+
+	NewTokenHandler(sf, newTestConfigurationCache(dataaccess.Configuration{})).ServeHTTP(w, r)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected the status to be 405, but was %d.", w.Code)
+	}
+
+	if ms.validateSessionWasCalled {
+		t.Error("The session should not be validated for a GET.")
+	}
+}
+
+func TestThatTheJWKSHandlerPublishesTheSigningKeys(t *testing.T) {
+	c := *dataaccess.NewConfiguration([]byte("0123456789abcdef0123456789abcdef"))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://example.com/.well-known/jwks.json", nil)
+
+	NewJWKSHandler(newTestConfigurationCache(c)).ServeHTTP(w, r)
+
+	var jwks jwt.JSONWebKeySet
+	if err := json.NewDecoder(w.Body).Decode(&jwks); err != nil {
+		t.Fatal("Failed to decode the JWKS.", err)
+	}
+
+	if len(jwks.Keys) != 1 || jwks.Keys[0].Algorithm != "EdDSA" {
+		t.Errorf("Expected a single EdDSA key, but received %v", jwks.Keys)
+	}
+}
+
+func TestThatErrorsAreWrittenInTheRequestedLanguage(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		expected       string
+	}{
+		{"", "Tokens must be requested with a POST.\n"},
+		{"de-DE,de;q=0.9", "Tokens müssen mit einem POST angefordert werden.\n"},
+		{"fr-FR", "Tokens must be requested with a POST.\n"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "http://example.com/api/token/", nil)
+		r.Header.Set("Accept-Language", test.acceptLanguage)
+
+		NewTokenHandler(nil, nil).ServeHTTP(w, r)
+
+		if w.Code != http.StatusMethodNotAllowed || w.Body.String() != test.expected {
+			t.Errorf("For Accept-Language '%s', expected '%s', but received %d '%s'.", test.acceptLanguage, test.expected, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The CourseHandler lists the training courses in the user's domain, and
+// allows administrators to add, update and remove them.
+type CourseHandler struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewCourseHandler creates an instance of the CourseHandler.
+func NewCourseHandler(da dataaccess.DataAccess) *CourseHandler {
+	return &CourseHandler{da, time.Now}
+}
+
+// The LearningPathHandler returns the courses which take the user from their
+// current level of a skill to a target level, e.g.
+// /training/paths/?skill=terraform&target=3
+type LearningPathHandler struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewLearningPathHandler creates an instance of the LearningPathHandler.
+func NewLearningPathHandler(da dataaccess.DataAccess) *LearningPathHandler {
+	return &LearningPathHandler{da}
+}
+
+// The CourseCompletionHandler records the courses the user has completed,
+// and lists the completions of the user and the people they manage.
+type CourseCompletionHandler struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewCourseCompletionHandler creates an instance of the
+// CourseCompletionHandler.
+func NewCourseCompletionHandler(da dataaccess.DataAccess) *CourseCompletionHandler {
+	return &CourseCompletionHandler{da, time.Now}
+}
+
+// The CourseCompletionDecisionHandler allows managers to approve or decline
+// the level changes suggested by the courses completed by their team.
+type CourseCompletionDecisionHandler struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewCourseCompletionDecisionHandler creates an instance of the
+// CourseCompletionDecisionHandler.
+func NewCourseCompletionDecisionHandler(da dataaccess.DataAccess) *CourseCompletionDecisionHandler {
+	return &CourseCompletionDecisionHandler{da, time.Now}
+}
+
+// courseCompletionRequest is posted by the user when they complete a course.
+type courseCompletionRequest struct {
+	CourseID string `json:"courseId"`
+}
+
+// courseCompletionDecision is posted by a manager to approve or decline the
+// suggestions of a course completion.
+type courseCompletionDecision struct {
+	ID       string `json:"id"`
+	Decision string `json:"decision"`
+}
+
+func (handler CourseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling course request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, "error.sessionRequired")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(c.EmailAddress)
+
+	if r.Method == http.MethodGet {
+		courses, err := da.ListCourses(domain)
+		if err != nil {
+			log.Printf("Failed to list the courses of %s. %v", domain, err)
+			writeError(w, r, http.StatusInternalServerError, "error.courseReadFailed")
+			return
+		}
+		op := []dataaccess.Course{}
+		skill := dataaccess.CleanTag(strings.TrimSpace(r.FormValue("skill")))
+		for _, course := range courses {
+			if _, teaches := course.Teaches(skill); skill == "" || teaches {
+				op = append(op, course)
+			}
+		}
+		writeJSON(w, http.StatusOK, op)
+		return
+	}
+
+	if _, ok := administrator(r); !ok {
+		writeError(w, r, http.StatusForbidden, "error.adminOnlyCourses")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost, http.MethodPut:
+		var course dataaccess.Course
+		if err := json.NewDecoder(r.Body).Decode(&course); err != nil {
+			writeError(w, r, http.StatusBadRequest, "error.invalidCourse")
+			return
+		}
+		if course.ID == "" {
+			course.ID = bson.NewObjectId().Hex()
+			course.Created = handler.now()
+		} else {
+			existing, found, err := da.GetCourse(course.ID)
+			if err != nil {
+				log.Printf("Failed to get course %s. %v", course.ID, err)
+				writeError(w, r, http.StatusInternalServerError, "error.courseReadFailed")
+				return
+			}
+			if !found || existing.Domain != domain {
+				writeError(w, r, http.StatusNotFound, "error.courseNotFound")
+				return
+			}
+			course.Created = existing.Created
+		}
+		course.Domain = domain
+		course.Provider = dataaccess.CourseProvider(course.URL)
+		for i := range course.Skills {
+			course.Skills[i].Skill = dataaccess.CleanTag(strings.TrimSpace(course.Skills[i].Skill))
+		}
+
+		if err := da.SaveCourse(&course); err != nil {
+			if _, ok := err.(dataaccess.ValidationError); ok {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Print("Failed to save the course. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.courseSaveFailed")
+			return
+		}
+		log.Printf("User %s has saved the course %s.", c.EmailAddress, course.ID)
+		writeJSON(w, http.StatusOK, course)
+	case http.MethodDelete:
+		id := r.FormValue("id")
+		existing, found, err := da.GetCourse(id)
+		if err != nil {
+			log.Printf("Failed to get course %s. %v", id, err)
+			writeError(w, r, http.StatusInternalServerError, "error.courseReadFailed")
+			return
+		}
+		if !found || existing.Domain != domain {
+			writeError(w, r, http.StatusNotFound, "error.courseNotFound")
+			return
+		}
+		if err := da.DeleteCourse(id); err != nil {
+			log.Printf("Failed to delete course %s. %v", id, err)
+			writeError(w, r, http.StatusInternalServerError, "error.courseSaveFailed")
+			return
+		}
+		log.Printf("User %s has deleted the course %s.", c.EmailAddress, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func (handler LearningPathHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling learning path request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, "error.sessionRequired")
+		return
+	}
+
+	skill := dataaccess.CleanTag(strings.TrimSpace(r.FormValue("skill")))
+	target := dataaccess.DreyfusLevel(dataaccess.MasterLevel)
+	if t := r.FormValue("target"); t != "" {
+		n, err := strconv.Atoi(t)
+		if err != nil || n < dataaccess.NoviceLevel || n > dataaccess.MasterLevel {
+			writeError(w, r, http.StatusBadRequest, "error.invalidLearningPath")
+			return
+		}
+		target = dataaccess.DreyfusLevel(n)
+	}
+	if skill == "" {
+		writeError(w, r, http.StatusBadRequest, "error.invalidLearningPath")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	var current dataaccess.DreyfusLevel
+	profile, found, err := da.GetProfile(c.EmailAddress)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", c.EmailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.courseReadFailed")
+		return
+	}
+	if found {
+		current, _ = skillLevel(profile, skill)
+	}
+
+	courses, err := da.ListCourses(dataaccess.GetDomain(c.EmailAddress))
+	if err != nil {
+		log.Printf("Failed to list the courses of %s. %v", c.EmailAddress, err)
+		writeError(w, r, http.StatusInternalServerError, "error.courseReadFailed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dataaccess.NewLearningPath(skill, current, target, courses))
+}
+
+func (handler CourseCompletionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling course completion request.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, "error.sessionRequired")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	domain := dataaccess.GetDomain(c.EmailAddress)
+
+	switch r.Method {
+	case http.MethodGet:
+		completions, err := da.ListCourseCompletions(domain)
+		if err != nil {
+			log.Printf("Failed to list the course completions of %s. %v", domain, err)
+			writeError(w, r, http.StatusInternalServerError, "error.courseReadFailed")
+			return
+		}
+		profiles, err := da.ListProfiles(c.EmailAddress)
+		if err != nil {
+			log.Print("Unable to retrieve the list of profiles.", err)
+			writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+			return
+		}
+		visible := map[string]bool{strings.ToLower(c.EmailAddress): true}
+		if node, ok := dataaccess.NewOrgTree(domain, profiles).Find(c.EmailAddress); ok {
+			for _, id := range node.Members() {
+				visible[id] = true
+			}
+		}
+		op := []dataaccess.CourseCompletion{}
+		for _, cc := range completions {
+			if visible[cc.EmailAddress] {
+				op = append(op, cc)
+			}
+		}
+		writeJSON(w, http.StatusOK, op)
+	case http.MethodPost:
+		var req courseCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.CourseID == "" {
+			writeError(w, r, http.StatusBadRequest, "error.invalidCourseCompletion")
+			return
+		}
+		course, found, err := da.GetCourse(req.CourseID)
+		if err != nil {
+			log.Printf("Failed to get course %s. %v", req.CourseID, err)
+			writeError(w, r, http.StatusInternalServerError, "error.courseReadFailed")
+			return
+		}
+		if !found || course.Domain != domain {
+			writeError(w, r, http.StatusNotFound, "error.courseNotFound")
+			return
+		}
+		profile, found, err := da.GetProfile(c.EmailAddress)
+		if err != nil {
+			log.Printf("Failed to get the profile of %s. %v", c.EmailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.courseSaveFailed")
+			return
+		}
+		if !found {
+			writeError(w, r, http.StatusNotFound, "error.profileNotFound")
+			return
+		}
+
+		cc := dataaccess.NewCourseCompletion(profile, *course, handler.now())
+		if err := da.SaveCourseCompletion(&cc); err != nil {
+			log.Print("Failed to save the course completion. ", err)
+			writeError(w, r, http.StatusInternalServerError, "error.courseSaveFailed")
+			return
+		}
+		log.Printf("User %s has completed the course %s, suggesting %d level changes.", c.EmailAddress, course.ID, len(cc.Suggestions))
+		writeJSON(w, http.StatusCreated, cc)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+	}
+}
+
+func (handler CourseCompletionDecisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling course completion decision.")
+
+	c, ok := caller.FromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, "error.sessionRequired")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "error.methodNotAllowed")
+		return
+	}
+
+	var d courseCompletionDecision
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil || (d.Decision != "approve" && d.Decision != "decline") {
+		writeError(w, r, http.StatusBadRequest, "error.invalidCourseCompletionDecision")
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+
+	cc, found, err := da.GetCourseCompletion(d.ID)
+	if err != nil {
+		log.Printf("Failed to get course completion %s. %v", d.ID, err)
+		writeError(w, r, http.StatusInternalServerError, "error.courseReadFailed")
+		return
+	}
+	var manager bool
+	if found {
+		if manager, err = manages(da, c.EmailAddress, cc.EmailAddress); err != nil {
+			log.Printf("Failed to check whether %s manages %s. %v", c.EmailAddress, cc.EmailAddress, err)
+			writeError(w, r, http.StatusInternalServerError, "error.courseReadFailed")
+			return
+		}
+	}
+	// Only managers can decide, and other people can't find out which
+	// completions exist.
+	if !found || !manager {
+		writeError(w, r, http.StatusNotFound, "error.courseCompletionNotFound")
+		return
+	}
+
+	approve := d.Decision == "approve"
+	if err := cc.Decide(approve, c.EmailAddress, handler.now()); err != nil {
+		writeError(w, r, http.StatusConflict, "error.courseCompletionDecided")
+		return
+	}
+
+	if approve {
+		if status, key := applySuggestions(da, cc.EmailAddress, cc.Suggestions, "error.courseSaveFailed"); key != "" {
+			writeError(w, r, status, key)
+			return
+		}
+	}
+
+	if err := da.SaveCourseCompletion(cc); err != nil {
+		log.Print("Failed to save the course completion. ", err)
+		writeError(w, r, http.StatusInternalServerError, "error.courseSaveFailed")
+		return
+	}
+
+	log.Printf("User %s has %s the course completion %s of %s.", c.EmailAddress, cc.Status, cc.ID, cc.EmailAddress)
+	writeJSON(w, http.StatusOK, cc)
+}
+
+// applySuggestions raises the levels of the skills on the person's profile
+// to the suggested levels, adding skills they don't have yet. It returns the
+// status and error key if it can't, using the failed key for errors saving
+// the profile.
+func applySuggestions(da dataaccess.DataAccess, emailAddress string, suggestions []dataaccess.LevelSuggestion, failedKey string) (int, string) {
+	profile, found, err := da.GetProfile(emailAddress)
+	if err != nil {
+		log.Printf("Failed to get the profile of %s. %v", emailAddress, err)
+		return http.StatusInternalServerError, failedKey
+	}
+	if !found {
+		return http.StatusNotFound, "error.profileNotFound"
+	}
+
+	pu := dataaccess.NewProfileUpdate()
+	pu.EmailAddress = profile.EmailAddress
+	pu.Availability = profile.Availability
+	applied := make(map[string]bool)
+	for _, s := range profile.Skills {
+		for _, suggestion := range suggestions {
+			if suggestion.Skill == s.Skill && suggestion.Suggested > s.Level {
+				s.Level = suggestion.Suggested
+			}
+		}
+		applied[s.Skill] = true
+		pu.Skills = append(pu.Skills, s)
+	}
+	for _, suggestion := range suggestions {
+		if !applied[suggestion.Skill] {
+			pu.Skills = append(pu.Skills, dataaccess.Skill{Skill: suggestion.Skill, Level: suggestion.Suggested})
+		}
+	}
+
+	if _, err := da.UpdateProfile(pu); err != nil {
+		log.Printf("Failed to apply the suggested levels to the profile of %s. %v", emailAddress, err)
+		return http.StatusInternalServerError, failedKey
+	}
+	return 0, ""
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func newTrainingDataAccess() *mockDataAccess {
+	mda := newCalibrationDataAccess()
+	course := &dataaccess.Course{
+		ID:     "terraform",
+		Domain: "github.com",
+		Title:  "Terraform Modules",
+		URL:    "https://www.udemy.com/course/terraform/",
+		Skills: []dataaccess.SkillRequirement{{Skill: "terraform", Level: dataaccess.ProficientLevel}, {Skill: "sql", Level: dataaccess.CompetentLevel}},
+	}
+	mda.getCourseResponse = func(id string) (*dataaccess.Course, bool, error) {
+		return course, id == course.ID, nil
+	}
+	mda.listCoursesResponse = func(domain string) ([]dataaccess.Course, error) {
+		return []dataaccess.Course{*course}, nil
+	}
+	mda.saveCourseResponse = func(c *dataaccess.Course) error { return c.Validate() }
+	return mda
+}
+
+func TestThatOnlyAdministratorsCanChangeTheCatalog(t *testing.T) {
+	body := `{"title":"Go","url":"https://www.coursera.org/learn/golang","skills":[{"skill":" Go ","level":3}]}`
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		expectedCode int
+	}{
+		{"users", "POST", body, http.StatusForbidden},
+		{"administrators", "POST", body, http.StatusOK},
+		{"administrators", "POST", `{"title":"Go","url":"golang","skills":[]}`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		c := testReport
+		if test.name == "administrators" {
+			c = testAdministrator
+		}
+		var saved dataaccess.Course
+		mda := newTrainingDataAccess()
+		mda.saveCourseResponse = func(course *dataaccess.Course) error {
+			saved = *course
+			return course.Validate()
+		}
+
+		w := httptest.NewRecorder()
+		NewCourseHandler(mda).ServeHTTP(w, newRequestWithCaller(test.method, "http://example.com/training/courses/", test.body, c))
+
+		if w.Code != test.expectedCode {
+			t.Errorf("For %s posting %s, expected status %d, but was %d.", test.name, test.body, test.expectedCode, w.Code)
+		}
+		if w.Code == http.StatusOK && (saved.Provider != dataaccess.CourseraProvider || saved.Domain != "github.com" || saved.Skills[0].Skill != "go") {
+			t.Errorf("Expected a Coursera course in github.com teaching go, but saved %+v", saved)
+		}
+	}
+}
+
+func TestThatLearningPathsStartFromTheUsersLevel(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := newRequestWithCaller("GET", "http://example.com/training/paths/?skill=sql&target=2", "", testReport)
+	NewLearningPathHandler(newTrainingDataAccess()).ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but was %d.", w.Code)
+	}
+	var lp dataaccess.LearningPath
+	if err := json.NewDecoder(w.Body).Decode(&lp); err != nil {
+		t.Fatal("Failed to decode the learning path.", err)
+	}
+	if lp.Current != dataaccess.NoviceLevel || len(lp.Steps) != 1 || lp.Steps[0].Courses[0].ID != "terraform" {
+		t.Errorf("Expected a path from level 1 through the terraform course, but received %+v", lp)
+	}
+}
+
+func TestThatManagersApproveTheLevelsSuggestedByCompletions(t *testing.T) {
+	mda := newTrainingDataAccess()
+	var saved *dataaccess.CourseCompletion
+	mda.saveCourseCompletionResponse = func(cc *dataaccess.CourseCompletion) error {
+		saved = cc
+		return nil
+	}
+	mda.getCourseCompletionResponse = func(id string) (*dataaccess.CourseCompletion, bool, error) {
+		return saved, saved != nil && id == saved.ID, nil
+	}
+	var update *dataaccess.ProfileUpdate
+	mda.updateProfileResponse = func(pu *dataaccess.ProfileUpdate) (*dataaccess.Profile, error) {
+		update = pu
+		return &dataaccess.Profile{}, nil
+	}
+	now := time.Date(2017, time.March, 1, 9, 0, 0, 0, time.UTC)
+
+	w := httptest.NewRecorder()
+	completions := NewCourseCompletionHandler(mda)
+	completions.now = func() time.Time { return now }
+	completions.ServeHTTP(w, newRequestWithCaller("POST", "http://example.com/training/completions/", `{"courseId":"terraform"}`, testReport))
+
+	if w.Code != http.StatusCreated || saved == nil || saved.Status != dataaccess.CompletionPending || len(saved.Suggestions) != 2 {
+		t.Fatalf("Expected a pending completion suggesting 2 levels, but received status %d and %+v", w.Code, saved)
+	}
+
+	decisions := NewCourseCompletionDecisionHandler(mda)
+	body := `{"id":"` + saved.ID + `","decision":"approve"}`
+
+	w = httptest.NewRecorder()
+	decisions.ServeHTTP(w, newRequestWithCaller("POST", "http://example.com/training/completions/decisions/", body, testReport))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected people not to be able to approve their own completions, but the status was %d.", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	decisions.ServeHTTP(w, newRequestWithCaller("POST", "http://example.com/training/completions/decisions/", body, testManager))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but was %d.", w.Code)
+	}
+	if saved.Status != dataaccess.CompletionApproved || saved.DecidedBy != "boss@github.com" {
+		t.Errorf("Expected the completion to be approved by boss@github.com, but was %s by %s.", saved.Status, saved.DecidedBy)
+	}
+	levels := map[string]dataaccess.DreyfusLevel{}
+	for _, s := range update.Skills {
+		levels[s.Skill] = s.Level
+	}
+	if levels["go"] != dataaccess.ExpertLevel || levels["sql"] != dataaccess.CompetentLevel || levels["terraform"] != dataaccess.ProficientLevel {
+		t.Errorf("Expected sql to be raised and terraform to be added, but received %v", levels)
+	}
+}
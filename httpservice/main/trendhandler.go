@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The TrendHandler returns how many people in the user's domain have had a
+// skill tag at the start of each month, e.g. /report/trends/?tag=go&months=12,
+// or, without a tag, the trends of the tags which the most people have
+// added, for "trending skills" panels.
+type TrendHandler struct {
+	DataAccess dataaccess.DataAccess
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	now        func() time.Time
+}
+
+// NewTrendHandler creates an instance of the TrendHandler.
+func NewTrendHandler(da dataaccess.DataAccess, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *TrendHandler {
+	return &TrendHandler{da, sessionFactory, time.Now}
+}
+
+// The defaults and limits of the trend parameters.
+const (
+	defaultTrendMonths = 12
+	maxTrendMonths     = 36
+	defaultTrendLimit  = 10
+	maxTrendLimit      = 50
+)
+
+func (handler TrendHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling skill trend request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	months, ok := intParameter(r, "months", defaultTrendMonths, 1, maxTrendMonths)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "error.invalidTrendMonths", maxTrendMonths)
+		return
+	}
+	limit, ok := intParameter(r, "limit", defaultTrendLimit, 1, maxTrendLimit)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "error.invalidTrendLimit", maxTrendLimit)
+		return
+	}
+
+	da := dataaccess.WithContext(handler.DataAccess, r.Context())
+	profiles, err := da.ListProfiles(emailAddress)
+	if err != nil {
+		log.Print("Unable to retrieve the list of profiles.", err)
+		writeError(w, r, http.StatusInternalServerError, "error.profilesListFailed")
+		return
+	}
+	domain := dataaccess.GetDomain(emailAddress)
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		log.Printf("Unable to retrieve the settings of %s. %v", domain, err)
+		writeError(w, r, http.StatusInternalServerError, "error.settingsReadFailed")
+		return
+	}
+	profiles = dataaccess.Consenting(profiles, dataaccess.ConsentAnalytics, settings.Consent)
+
+	if tag := r.FormValue("tag"); tag != "" {
+		writeJSON(w, http.StatusOK, dataaccess.GetTagTrends(profiles, tag, months, handler.now()))
+		return
+	}
+	writeJSON(w, http.StatusOK, dataaccess.TrendingTags(profiles, months, handler.now(), limit))
+}
+
+// intParameter reads the integer form value, or returns the default if it's
+// missing. It returns false if it isn't a number between min and max.
+func intParameter(r *http.Request, name string, def, min, max int) (int, bool) {
+	v := r.FormValue(name)
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min || n > max {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func newTrendTestHandler(now time.Time) *TrendHandler {
+	ms := &mockSession{
+		validateSessionValidResponse:        true,
+		validateSessionEmailAddressResponse: "a-h@github.com",
+	}
+	mda := &mockDataAccess{
+		listProfilesResponse: func() ([]dataaccess.Profile, error) {
+			return []dataaccess.Profile{
+				{EmailAddress: "a-h@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}, LastUpdated: now.AddDate(0, -1, 0)},
+				{EmailAddress: "dev@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 5}}, LastUpdated: now.AddDate(-2, 0, 0)},
+				// People who haven't consented to analytics aren't counted.
+				{EmailAddress: "private@github.com", Skills: []dataaccess.Skill{{Skill: "rust", Level: 4}}, LastUpdated: now.AddDate(0, -1, 0),
+					Consents: []dataaccess.Consent{{Purpose: dataaccess.ConsentAnalytics, Given: false}}},
+			}, nil
+		},
+		getSettingsResponse: func(domain string) (dataaccess.Settings, error) {
+			return dataaccess.DefaultSettings(), nil
+		},
+	}
+	h := NewTrendHandler(mda, func(w http.ResponseWriter, r *http.Request) Session { return ms })
+	h.now = func() time.Time { return now }
+	return h
+}
+
+func TestThatTagTrendsAreReturned(t *testing.T) {
+	now := time.Date(2018, time.June, 10, 0, 0, 0, 0, time.UTC)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/report/trends/?tag=Go&months=6", nil)
+	newTrendTestHandler(now).ServeHTTP(w, r)
+
+	var trend dataaccess.TagTrend
+	if err := json.NewDecoder(w.Body).Decode(&trend); err != nil {
+		t.Fatalf("Failed to decode the trend: %v", err)
+	}
+	if trend.Tag != "go" || len(trend.Points) != 7 || trend.Points[0].People != 1 || trend.Change != 1 {
+		t.Errorf("Expected go to go from 1 to 2 people over 6 months, but got %+v", trend)
+	}
+}
+
+func TestThatTrendingTagsAreReturnedWithoutATag(t *testing.T) {
+	now := time.Date(2018, time.June, 10, 0, 0, 0, 0, time.UTC)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/report/trends/", nil)
+	newTrendTestHandler(now).ServeHTTP(w, r)
+
+	var trends []dataaccess.TagTrend
+	if err := json.NewDecoder(w.Body).Decode(&trends); err != nil {
+		t.Fatalf("Failed to decode the trends: %v", err)
+	}
+	if len(trends) != 1 || trends[0].Tag != "go" {
+		t.Errorf("Expected go to be trending, but got %+v", trends)
+	}
+}
+
+func TestThatInvalidTrendMonthsAreRejected(t *testing.T) {
+	for _, months := range []string{"0", "37", "a"} {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/report/trends/?months="+months, nil)
+		newTrendTestHandler(time.Now()).ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("For %s months, expected status 400, but got %d", months, w.Code)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/gorilla/websocket"
+)
+
+// The WebSocketHandler upgrades authenticated requests to WebSocket
+// connections which receive change events from the Hub.
+type WebSocketHandler struct {
+	Hub        *Hub
+	getSession func(w http.ResponseWriter, r *http.Request) Session
+	upgrader   websocket.Upgrader
+}
+
+// NewWebSocketHandler creates an instance of the WebSocketHandler.
+func NewWebSocketHandler(hub *Hub, sessionFactory func(w http.ResponseWriter, r *http.Request) Session) *WebSocketHandler {
+	return &WebSocketHandler{
+		Hub:        hub,
+		getSession: sessionFactory,
+	}
+}
+
+const webSocketWriteTimeout = 10 * time.Second
+
+func (handler WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Print("Handling WebSocket request.")
+
+	valid, emailAddress := handler.getSession(w, r).ValidateSession()
+	if !valid {
+		return
+	}
+
+	conn, err := handler.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Print("Failed to upgrade the WebSocket connection. ", err)
+		return
+	}
+
+	log.Printf("WebSocket connected for user %s.", emailAddress)
+
+	c := newHubClient(dataaccess.GetDomain(emailAddress))
+	handler.Hub.register(c)
+
+	go writeEvents(conn, c)
+	readSubscriptions(conn, c)
+
+	handler.Hub.unregister(c)
+	log.Printf("WebSocket disconnected for user %s.", emailAddress)
+}
+
+// readSubscriptions updates the client's subscription whenever a message is
+// received, until the connection is closed.
+func readSubscriptions(conn *websocket.Conn, c *hubClient) {
+	for {
+		var s subscription
+		if err := conn.ReadJSON(&s); err != nil {
+			return
+		}
+		c.subscribe(s)
+	}
+}
+
+// writeEvents writes events to the connection until the client's send channel
+// is closed.
+func writeEvents(conn *websocket.Conn, c *hubClient) {
+	defer conn.Close()
+
+	for e := range c.send {
+		conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+		if err := conn.WriteJSON(e); err != nil {
+			log.Print("Failed to write to the WebSocket. ", err)
+			return
+		}
+	}
+
+	conn.WriteMessage(websocket.CloseMessage, []byte{})
+}
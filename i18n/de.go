@@ -0,0 +1,263 @@
+package i18n
+
+var german = Catalog{
+	"language.name": "Deutsch",
+
+	"availability.red":     "rot",
+	"availability.amber":   "gelb",
+	"availability.green":   "grün",
+	"availability.unknown": "unbekannt",
+
+	"date.short": "2.1.",
+	"date.long":  "2.1.2006",
+
+	"digest.subject.daily":                 "Der Tag deines Teams in pill",
+	"digest.subject.weekly":                "Die Woche deines Teams in pill",
+	"digest.intro":                         "Das hat sich in deinem Team zwischen dem %s und dem %s geändert.",
+	"digest.updated":                       "%s hat das Profil aktualisiert.",
+	"digest.availabilityChanged":           "%s ist jetzt %s.",
+	"digest.updatedAndAvailabilityChanged": "%s hat das Profil aktualisiert und ist jetzt %s.",
+	"digest.personNewSkills":               "Neue Fähigkeiten: %s.",
+	"digest.teamNewSkills":                 "Neue Fähigkeiten in deinem Team: %s.",
+	"digest.preferences":                   "Unter %s kannst du einstellen, wie oft du diese Zusammenfassung erhältst, oder sie abbestellen.",
+	"digest.preferencesLink":               "Einstellen, wie oft du diese Zusammenfassung erhältst, oder sie abbestellen",
+	"digest.adoption":                      "%v%% der Mitarbeitenden bei %s haben ein pill-Profil, und %v%% der Profile wurden in den letzten 90 Tagen aktualisiert.",
+	"digest.adoptionProfiles":              "%v Mitarbeitende bei %s haben ein pill-Profil, und %v%% der Profile wurden in den letzten 90 Tagen aktualisiert.",
+
+	"error.methodNotAllowed":                  "Methode nicht erlaubt.",
+	"error.sessionRequired":                   "Eine Sitzung ist erforderlich.",
+	"error.invalidForm":                       "Ungültige Formulardaten.",
+	"error.invalidClaim":                      "Der vorgelegte Nachweis ist ungültig.",
+	"error.profileReadFailed":                 "Das Profil von %s konnte nicht abgerufen werden.",
+	"error.profileSaveFailed":                 "Das Profil von %s konnte nicht gespeichert werden.",
+	"error.profilesListFailed":                "Die Liste der Profile konnte nicht abgerufen werden.",
+	"error.skillTagsListFailed":               "Die Fähigkeiten konnten nicht aufgelistet werden.",
+	"error.orgTreeReadFailed":                 "Das Organigramm konnte nicht abgerufen werden.",
+	"error.managerNotFound":                   "Die Führungskraft wurde nicht gefunden.",
+	"error.tokenMethod":                       "Tokens müssen mit einem POST angefordert werden.",
+	"error.tokenIssueFailed":                  "Das Token konnte nicht ausgestellt werden.",
+	"error.adminOnlyAuditLog":                 "Nur Administratoren können das Audit-Protokoll lesen.",
+	"error.invalidSince":                      "Der Parameter since muss eine Zeit nach RFC 3339 sein.",
+	"error.auditLogReadFailed":                "Das Audit-Protokoll konnte nicht gelesen werden.",
+	"error.adminOnlyFeatureFlags":             "Nur Administratoren können Feature-Flags verwalten.",
+	"error.invalidFeatureFlagUpdate":          "Ungültige Änderung des Feature-Flags.",
+	"error.featureFlagSetFailed":              "Das Feature-Flag konnte nicht gesetzt werden.",
+	"error.adminOnlyImpersonation":            "Nur Administratoren können als andere Benutzer handeln.",
+	"error.invalidImpersonationRequest":       "Ungültige Anfrage zum Handeln als anderer Benutzer.",
+	"error.impersonationNotSupported":         "Die Sitzung unterstützt das Handeln als anderer Benutzer nicht.",
+	"error.impersonationAuditFailed":          "Das Handeln als anderer Benutzer konnte nicht im Audit-Protokoll erfasst werden.",
+	"error.impersonationStartFailed":          "Das Handeln als anderer Benutzer konnte nicht gestartet werden.",
+	"error.notImpersonated":                   "Es wird nicht als dieser Benutzer gehandelt.",
+	"error.notificationPreferencesReadFailed": "Die Benachrichtigungseinstellungen konnten nicht abgerufen werden.",
+	"error.invalidNotificationPreferences":    "Ungültige Benachrichtigungseinstellungen.",
+	"error.invalidMuted":                      "Der Wert für muted muss true oder false sein.",
+	"error.notificationPreferencesSaveFailed": "Die Benachrichtigungseinstellungen konnten nicht gespeichert werden.",
+	"error.profileNotFound":                   "Das Profil wurde nicht gefunden.",
+	"error.availabilityReadFailed":            "Die Verfügbarkeit konnte nicht abgerufen werden.",
+	"error.invalidAvailabilityWindows":        "Ungültige Verfügbarkeitszeiträume.",
+	"error.availabilitySaveFailed":            "Die Verfügbarkeitszeiträume konnten nicht gespeichert werden.",
+	"error.changeQuarantined":                 "Die Änderung wird zurückgehalten, bis ein Administrator sie geprüft hat.",
+	"error.adminOnlyQuarantine":               "Nur Administratoren können zurückgehaltene Änderungen prüfen.",
+	"error.quarantineReadFailed":              "Die zurückgehaltenen Änderungen konnten nicht abgerufen werden.",
+	"error.invalidQuarantineReview":           "Die Prüfung benötigt eine id und die Aktion release oder reject.",
+	"error.quarantinedChangeNotFound":         "Die zurückgehaltene Änderung wurde nicht gefunden.",
+	"error.quarantineReleaseFailed":           "Die Prüfung der zurückgehaltenen Änderung konnte nicht abgeschlossen werden.",
+	"error.adminOnlyApprovals":                "Nur Administratoren können destruktive Aktionen beantragen und genehmigen.",
+	"error.invalidApprovalRequest":            "Ungültiger Genehmigungsantrag.",
+	"error.approvalRequestFailed":             "Der Genehmigungsantrag konnte nicht gespeichert werden.",
+	"error.approvalReadFailed":                "Die Genehmigungsanträge konnten nicht abgerufen werden.",
+	"error.approvalNotFound":                  "Der Genehmigungsantrag wurde nicht gefunden.",
+	"error.invalidApprovalDecision":           "Die Entscheidung benötigt eine id und die Entscheidung approve oder deny.",
+	"error.approvalBySameAdministrator":       "Die Aktion muss von einem anderen Administrator genehmigt werden als dem, der sie beantragt hat.",
+	"error.approvalExpired":                   "Der Genehmigungsantrag ist abgelaufen.",
+	"error.approvalExecuteFailed":             "Die genehmigte Aktion konnte nicht ausgeführt werden.",
+	"error.adminOnlySkillTrash":               "Nur Administratoren können gelöschte Fähigkeiten wiederherstellen.",
+	"error.skillTrashReadFailed":              "Die gelöschten Fähigkeiten konnten nicht aufgelistet werden.",
+	"error.invalidSkillTagRestore":            "Die wiederherzustellenden Fähigkeiten müssen im Feld tags aufgeführt sein.",
+	"error.skillTagRestoreFailed":             "Die Fähigkeiten konnten nicht wiederhergestellt werden.",
+	"error.tenantMoving":                      "Die Profile Ihrer Organisation werden gerade verschoben, bitte versuchen Sie es in einigen Minuten erneut.",
+	"error.databaseUnavailable":               "Die Datenbank ist nicht erreichbar, bitte versuchen Sie es in einigen Minuten erneut.",
+	"error.readOnly":                          "Pill ist während der Wartung schreibgeschützt, Ihre Änderungen wurden nicht gespeichert. Bitte versuchen Sie es später erneut.",
+	"error.settingsReadFailed":                "Die Einstellungen Ihrer Organisation konnten nicht abgerufen werden.",
+	"error.adminOnlySkillDescriptors":         "Nur Administratoren können Kompetenzstufen beschreiben.",
+	"error.invalidSkillDescriptors":           "Die Stufenbeschreibungen müssen JSON sein, z. B. {\"name\":\"kubernetes\",\"descriptors\":[{\"level\":3,\"description\":\"...\"}]}.",
+	"error.skillTagNotFound":                  "Die Kompetenz %s wurde nicht gefunden.",
+	"error.skillDescriptorsSaveFailed":        "Die Stufenbeschreibungen konnten nicht gespeichert werden.",
+	"error.calibrationDisabled":               "Die Kalibrierung ist nicht aktiviert.",
+	"error.calibrationReadFailed":             "Die Kalibrierungen konnten nicht abgerufen werden.",
+	"error.calibrationSaveFailed":             "Die Kalibrierung konnte nicht gespeichert werden.",
+	"error.invalidCalibration":                "Die Kalibrierung muss JSON sein, z. B. {\"emailAddress\":\"someone@example.com\",\"skill\":\"go\",\"level\":3,\"note\":\"...\"}.",
+	"error.managerOnlyCalibration":            "Nur die Vorgesetzten von %s können Änderungen an den Stufen vorschlagen.",
+	"error.skillNotOnProfile":                 "Die Kompetenz %s ist nicht im Profil enthalten.",
+	"error.invalidCalibrationDecision":        "Die Entscheidung muss JSON sein, z. B. {\"id\":\"...\",\"decision\":\"accept\"} oder {\"id\":\"...\",\"decision\":\"decline\"}.",
+	"error.calibrationNotFound":               "Die Kalibrierung wurde nicht gefunden.",
+	"error.calibrationDecided":                "Die Kalibrierung wurde bereits angenommen oder abgelehnt.",
+	"error.comparisonRequiresTwoProfiles":     "Es werden zwei Personen benötigt, z. B. ?a=someone@example.com&b=someone.else@example.com.",
+	"error.invalidTeamRequest":                "Die Anfrage muss JSON sein, z. B. {\"requirements\":[{\"skill\":\"go\",\"level\":3}],\"candidates\":[\"someone@example.com\"]}.",
+	"error.invalidBooking":                    "Die Buchung muss JSON sein, z. B. {\"emailAddress\":\"someone@example.com\",\"project\":\"Website\",\"start\":\"2017-03-01T09:00:00Z\",\"end\":\"2017-06-01T17:00:00Z\",\"percentage\":50}.",
+	"error.overbooked":                        "Durch die Buchung wäre %s zu mehr als 100%% der Zeit verplant.",
+	"error.bookingSaveFailed":                 "Die Buchung konnte nicht gespeichert werden.",
+	"error.bookingNotFound":                   "Die Buchung wurde nicht gefunden.",
+	"error.invalidAllocationPeriod":           "Der Zeitraum muss aus Daten wie ?from=2017-03-01&to=2017-06-01 bestehen und nach dem Beginn enden.",
+	"error.invalidProject":                    "Das Projekt muss JSON sein, z. B. {\"name\":\"Website\",\"client\":\"Example\",\"start\":\"2017-03-01T00:00:00Z\",\"end\":\"2017-06-01T00:00:00Z\",\"requirements\":[{\"skill\":\"go\",\"level\":3}]}.",
+	"error.projectReadFailed":                 "Die Projekte konnten nicht abgerufen werden.",
+	"error.projectSaveFailed":                 "Das Projekt konnte nicht gespeichert werden.",
+	"error.projectNotFound":                   "Das Projekt wurde nicht gefunden.",
+	"error.courseReadFailed":                  "Die Schulungen konnten nicht abgerufen werden.",
+	"error.courseSaveFailed":                  "Die Schulung konnte nicht gespeichert werden.",
+	"error.adminOnlyCourses":                  "Nur Administratoren können den Schulungskatalog ändern.",
+	"error.invalidCourse":                     "Die Schulung muss JSON sein, z. B. {\"title\":\"...\",\"url\":\"https://www.udemy.com/course/...\",\"skills\":[{\"skill\":\"terraform\",\"level\":3}]}.",
+	"error.courseNotFound":                    "Die Schulung wurde nicht gefunden.",
+	"error.invalidLearningPath":               "Eine Fähigkeit ist erforderlich, und die Zielstufe muss zwischen 1 und 5 liegen.",
+	"error.invalidCourseCompletion":           "Der Abschluss muss JSON sein, z. B. {\"courseId\":\"...\"}.",
+	"error.invalidCourseCompletionDecision":   "Die Entscheidung muss JSON sein, z. B. {\"id\":\"...\",\"decision\":\"approve\"} oder {\"id\":\"...\",\"decision\":\"decline\"}.",
+	"error.courseCompletionNotFound":          "Der Schulungsabschluss wurde nicht gefunden.",
+	"error.courseCompletionDecided":           "Der Schulungsabschluss wurde bereits genehmigt oder abgelehnt.",
+	"goals.reminder.subject":                  "Deine Lernziele in pill",
+	"goals.reminder.intro":                    "Diese Lernziele sind bald fällig:",
+	"goals.reminder.due":                      "Stufe %d in %s bis %s erreichen.",
+	"goals.reminder.overdue":                  "Stufe %d in %s erreichen, fällig seit %s.",
+	"goals.reminder.update":                   "Wenn du ein Ziel erreicht hast, aktualisiere dein Profil oder ändere das Ziel unter %s",
+	"error.invalidLearningGoal":               "Das Ziel muss JSON sein, z. B. {\"skill\":\"terraform\",\"target\":3,\"due\":\"Q3\"}.",
+	"error.learningGoalNotFound":              "Das Lernziel wurde nicht gefunden.",
+	"error.learningGoalSaveFailed":            "Die Lernziele konnten nicht gespeichert werden.",
+	"error.learningGoalReadFailed":            "Die Lernziele konnten nicht abgerufen werden.",
+	"decay.subject":                           "Sind deine Fähigkeiten in pill noch aktuell?",
+	"decay.text":                              "Du hast diese Fähigkeiten schon länger nicht bestätigt oder eingesetzt: %s. Bestätige oder aktualisiere deine Stufen unter %s",
+	"error.staleSkillsReadFailed":             "Die veralteten Fähigkeiten konnten nicht abgerufen werden.",
+	"error.invalidSkillConfirmation":          "Die Bestätigung muss JSON sein, z. B. {\"skills\":[\"go\",\"sql\"]}.",
+	"error.skillConfirmationFailed":           "Die Fähigkeiten konnten nicht bestätigt werden.",
+	"error.unknownSkillTags":                  "Nur die Fähigkeiten deiner Organisation können verwendet werden, deshalb müssen %s einem Administrator vorgeschlagen werden. Begründe, warum die Fähigkeiten gebraucht werden, um sie vorzuschlagen.",
+	"error.skillTagsProposed":                 "%s wurden einem Administrator vorgeschlagen. Dein Profil wird aktualisiert, sobald sie geprüft wurden.",
+	"error.adminOnlyTagProposals":             "Nur Administratoren können vorgeschlagene Fähigkeiten prüfen.",
+	"error.invalidTagProposalDecision":        "Die Entscheidung muss JSON sein, z. B. {\"id\":\"...\",\"action\":\"approve\"}, mit der Aktion approve oder reject.",
+	"error.tagProposalNotFound":               "Die vorgeschlagene Fähigkeit wurde nicht gefunden.",
+	"error.tagProposalDecided":                "Die vorgeschlagene Fähigkeit wurde bereits geprüft.",
+	"error.tagProposalReadFailed":             "Die vorgeschlagenen Fähigkeiten konnten nicht abgerufen werden.",
+	"error.tagProposalSaveFailed":             "Die vorgeschlagenen Fähigkeiten konnten nicht gespeichert werden.",
+	"error.adminOnlyDuplicateTags":            "Nur Administratoren können doppelte Fähigkeiten zusammenführen.",
+	"error.invalidTagMerge":                   "Die Zusammenführung muss JSON sein, z. B. {\"into\":\"javascript\",\"tags\":[\"js\"]}.",
+	"error.tagMergeFailed":                    "Die Fähigkeiten konnten nicht zusammengeführt werden.",
+	"error.cvReadFailed":                      "Der Lebenslauf konnte nicht abgerufen werden.",
+	"error.cvNotFound":                        "Es wurde kein Lebenslauf hochgeladen.",
+	"error.cvSaveFailed":                      "Der Lebenslauf konnte nicht gespeichert werden.",
+	"error.invalidCV":                         "Der Lebenslauf muss als Feld cv eines Multipart-Formulars hochgeladen werden.",
+	"error.invalidDownloadURL":                "Der Download-Link ist ungültig oder abgelaufen.",
+	"error.attachmentNotFound":                "Die Datei wurde nicht gefunden.",
+	"error.skillSuggestionReadFailed":         "Die vorgeschlagenen Fähigkeiten konnten nicht abgerufen werden.",
+	"error.skillSuggestionSaveFailed":         "Die vorgeschlagenen Fähigkeiten konnten nicht gespeichert werden.",
+	"error.invalidSkillSuggestionDecision":    "Die Entscheidung muss accept oder dismiss sein.",
+	"error.skillSuggestionNotFound":           "Die vorgeschlagenen Fähigkeiten wurden nicht gefunden.",
+	"error.skillSuggestionDecided":            "Die vorgeschlagenen Fähigkeiten wurden bereits angenommen oder verworfen.",
+	"level.1":                                 "Neuling",
+	"level.2":                                 "Kompetent",
+	"level.3":                                 "Gewandt",
+	"level.4":                                 "Experte",
+	"level.5":                                 "Meister",
+	"onepager.skills":                         "Fähigkeiten",
+	"onepager.certifications":                 "Zertifizierungen",
+	"onepager.projects":                       "Projekte",
+	"onepager.present":                        "heute",
+	"onepager.month":                          "01.2006",
+	"error.onePagerFailed":                    "Das Profil-PDF konnte nicht erstellt werden.",
+	"error.adminOnlyBranding":                 "Nur Administratoren können das Branding ändern.",
+	"error.invalidBranding":                   "Das Branding muss JSON sein, z. B. {\"logoUrl\":\"https://example.com/logo.png\",\"primaryColor\":\"#1f6feb\",\"accentColor\":\"#f78166\",\"footerText\":\"...\"}.",
+	"error.brandingSaveFailed":                "Das Branding konnte nicht gespeichert werden.",
+	"error.shareLinkReadFailed":               "Die Freigabelinks konnten nicht abgerufen werden.",
+	"error.shareLinkSaveFailed":               "Der Freigabelink konnte nicht gespeichert werden.",
+	"error.invalidShareLink":                  "Der Freigabelink muss JSON sein, z. B. {\"days\":30}, und zwischen 1 und %d Tagen gültig sein.",
+	"error.shareLinkNotFound":                 "Der Link existiert nicht oder ist abgelaufen.",
+	"error.invalidCardSkills":                 "Die Anzahl der Skills muss zwischen 1 und %d liegen.",
+	"error.oEmbedFormat":                      "Nur das Format json wird unterstützt.",
+	"error.oEmbedURL":                         "Die URL ist keine pill-Profilkarte.",
+	"error.adminOnlyArchive":                  "Nur Administratoren können archivierte Profile auflisten und wiederherstellen.",
+	"error.archiveReadFailed":                 "Die archivierten Profile konnten nicht geladen werden.",
+	"error.invalidProfileRestore":             "Die E-Mail-Adresse des wiederherzustellenden Profils ist erforderlich.",
+	"error.archivedProfileNotFound":           "Das archivierte Profil wurde nicht gefunden.",
+	"error.profileRestoreFailed":              "Das Profil konnte nicht wiederhergestellt werden.",
+	"offboarding.subject":                     "%s hat das Unternehmen verlassen",
+	"offboarding.archived":                    "%s hat das Unternehmen verlassen, deshalb wurde das Profil archiviert. Administratoren können es unter %s wiederherstellen.",
+	"offboarding.deleted":                     "%s hat das Unternehmen verlassen, deshalb wurde das Profil gelöscht. Administratoren können es innerhalb von %d Tagen unter %s wiederherstellen.",
+	"error.adminOnlyCustomFields":             "Nur Administratoren können eigene Felder festlegen.",
+	"error.invalidCustomFields":               "Die eigenen Felder müssen eine Liste von Feldern sein.",
+	"error.customFieldsSaveFailed":            "Die eigenen Felder konnten nicht gespeichert werden.",
+	"error.customFieldsNotYours":              "Nur Administratoren können die eigenen Felder anderer Personen ändern.",
+	"error.invalidCustomFieldValues":          "Die Werte der eigenen Felder müssen ein Objekt sein.",
+	"error.customFieldValuesSaveFailed":       "Die Werte der eigenen Felder konnten nicht gespeichert werden.",
+	"error.customFieldNotFound":               "Es gibt kein eigenes Feld namens %s.",
+	"error.customFieldAdminOnly":              "Nur Administratoren können %s ändern.",
+	"error.languagesReadFailed":               "Deine Sprachen konnten nicht gelesen werden.",
+	"error.invalidLanguages":                  "Die Sprachen müssen eine Liste von Codes und Stufen sein, z. B. [{\"code\":\"de\",\"level\":\"B2\"}].",
+	"error.languagesSaveFailed":               "Deine Sprachen konnten nicht gespeichert werden.",
+	"error.clearanceRestricted":               "Du kannst diese Sicherheitsüberprüfung nicht sehen oder ändern.",
+	"error.clearanceNotFound":                 "Es wurde keine Sicherheitsüberprüfung erfasst.",
+	"error.invalidClearance":                  "Die Sicherheitsüberprüfung braucht eine Stufe und ein Ablaufdatum wie 2020-09-30.",
+	"error.clearanceSaveFailed":               "Die Sicherheitsüberprüfung konnte nicht gespeichert werden.",
+	"error.workLocationReadFailed":            "Dein Arbeitsort konnte nicht gelesen werden.",
+	"error.workLocationNotFound":              "Du hast noch nicht angegeben, wo du arbeitest.",
+	"error.invalidWorkLocation":               "Der Arbeitsort braucht eine Stadt, ein Land, Koordinaten und eine Remote-Präferenz.",
+	"error.workLocationSaveFailed":            "Dein Arbeitsort konnte nicht gespeichert werden.",
+	"error.invalidNearbyQuery":                "Die Suche braucht einen Breiten- und Längengrad und einen Radius von bis zu %d km.",
+	"error.workingHoursReadFailed":            "Deine Arbeitszeiten konnten nicht gelesen werden.",
+	"error.invalidWorkingHours":               "Die Arbeitszeiten brauchen einen Beginn, ein Ende und Tage, z. B. {\"start\":\"09:00\",\"end\":\"17:00\",\"days\":[1,2,3,4,5]}.",
+	"error.workingHoursSaveFailed":            "Deine Arbeitszeiten konnten nicht gespeichert werden.",
+	"error.invalidOverlapQuery":               "Die Suche braucht eine Start- und Endzeit wie 2018-09-03T22:00:00Z, höchstens eine Woche auseinander, und eine Anzahl von Stunden.",
+	"error.invalidAccessDays":                 "Der Parameter days muss eine Zahl von 1 bis 366 sein.",
+	"error.accessReportFailed":                "Es konnte nicht gelesen werden, wer auf dein Profil zugegriffen hat.",
+	"error.invalidConsent":                    "Die Einwilligungen müssen ein Objekt mit Zwecken sein, z. B. {\"analytics\":true}.",
+	"error.staleNotice":                       "Die Datenschutzerklärung hat sich geändert, bitte lies Version %s.",
+	"error.unknownConsentPurpose":             "Für den Zweck '%s' wird keine Einwilligung erfragt.",
+	"error.consentSaveFailed":                 "Deine Einwilligung konnte nicht gespeichert werden.",
+	"error.adminOnlyDiagnostics":              "Nur die Betreiber des Dienstes können seine Diagnosedaten lesen.",
+	"error.invalidTrendMonths":                "Der Parameter months muss eine Zahl von 1 bis %d sein.",
+	"error.invalidTrendLimit":                 "Der Parameter limit muss eine Zahl von 1 bis %d sein.",
+	"error.comparisonRequiresTwoCohorts":      "Es werden zwei Gruppen benötigt, z. B. ?a=department:Engineering&b=department:Product.",
+	"error.benchmarkingNotEnabled":            "Deine Organisation nimmt nicht am Benchmarking teil.",
+	"error.benchmarkReadFailed":               "Der Vergleichswert konnte nicht abgerufen werden.",
+	"error.benchmarkNotFound":                 "Für Organisationen deiner Größe gibt es noch keinen Vergleichswert. Mindestens %d müssen teilnehmen.",
+	"reports.subject":                         "%s, von pill",
+	"reports.download":                        "Als CSV herunterladen",
+	"error.adminOnlyReports":                  "Nur Administratoren können eigene Berichte verwalten.",
+	"error.reportDefinitionsReadFailed":       "Die eigenen Berichte konnten nicht abgerufen werden.",
+	"error.invalidReportDefinition":           "Die Berichtsdefinition muss JSON sein.",
+	"error.reportDefinitionSaveFailed":        "Der eigene Bericht konnte nicht gespeichert werden.",
+	"error.reportDefinitionNotFound":          "Der eigene Bericht wurde nicht gefunden.",
+	"error.reportRunFailed":                   "Der eigene Bericht konnte nicht ausgeführt werden.",
+	"error.adminOnlyExports":                  "Nur Administratoren können die Daten ihrer Domain exportieren.",
+	"error.invalidExportRequest":              "Die Exportanfrage konnte nicht gelesen werden.",
+	"error.exportNotSupported":                "%s kann nicht als %s exportiert werden.",
+	"error.exportJobSaveFailed":               "Der Export konnte nicht gestartet werden.",
+	"error.exportJobReadFailed":               "Der Export konnte nicht abgerufen werden.",
+	"error.exportJobNotFound":                 "Der Export wurde nicht gefunden.",
+	"error.exportNotReady":                    "Der Export kann nicht heruntergeladen werden, weil er den Status %s hat.",
+	"error.exportReadFailed":                  "Die exportierte Datei konnte nicht gelesen werden.",
+	"error.adminOnlyImports":                  "Nur Administratoren können Skills in ihre Domain importieren.",
+	"error.unknownImportFormat":               "'%s' ist kein Format, das importiert werden kann.",
+	"error.importPreviewFailed":               "Der Import konnte nicht geprüft werden.",
+	"error.importPreviewNotFound":             "Der Import wurde nicht gefunden. Er ist vielleicht abgelaufen oder wurde schon übernommen, prüfe die Datei also erneut.",
+	"error.importFailed":                      "Die Datei konnte nicht importiert werden.",
+	"error.adminOnlyDuplicateProfiles":        "Nur Administratoren können doppelte Profile finden und zusammenführen.",
+	"error.invalidProfileMerge":               "Die Zusammenführung muss ein Hauptprofil und ein anderes, doppeltes Profil angeben.",
+	"error.profileMergeOutsideTenant":         "Nur Profile in %s und seinen Alias-Domains können zusammengeführt werden.",
+	"error.profileMergeFailed":                "Die Profile konnten nicht zusammengeführt werden.",
+	"error.adminOnlyExternalIds":              "Nur Administratoren können externe IDs nachschlagen und ändern.",
+	"error.invalidExternalId":                 "Die Anfrage muss ein System und eine ID angeben.",
+	"error.externalIdsOutsideTenant":          "Nur die externen IDs von Profilen in %s können geändert werden.",
+	"error.externalIdsSaveFailed":             "Die externen IDs konnten nicht gespeichert werden.",
+	"error.invalidProfileEventRequest":        "Der Parameter after muss die Nummer eines Ereignisses sein und der Parameter at eine Zeit wie 2018-03-01T00:00:00Z.",
+	"error.profileEventsReadFailed":           "Die Ereignisse des Profils konnten nicht gelesen werden.",
+	"error.invalidSearch":                     "Die Suche ist ungültig. Die Stufe muss zwischen 1 und 5 liegen und eine Fähigkeit angegeben sein, die Verfügbarkeit zwischen 1 (rot) und 3 (grün).",
+	"error.searchFailed":                      "Die Profile konnten nicht durchsucht werden.",
+	"error.teamOutsideDomain":                 "Du kannst nur die Teams in deiner Domain ansehen.",
+	"error.teamSummaryReadFailed":             "Die Teamübersicht konnte nicht gelesen werden.",
+	"error.invalidAutocomplete":               "Der Typ muss skills oder people sein und das Limit zwischen 1 und %d liegen.",
+	"error.invalidSemanticSearch":             "Suche mit ?q=, z. B. ?q=container orchestration, und einer Stufe zwischen 1 und 5.",
+	"error.invalidQuestion":                   "Die Anfrage muss JSON mit einer Frage von bis zu %d Zeichen sein, z. B. {\"question\":\"wer kann go und hat im Juni Zeit?\"}.",
+	"error.questionFailed":                    "Die Frage konnte nicht beantwortet werden.",
+	"error.profileSummaryNotFound":            "Für dieses Profil wurde keine Zusammenfassung geschrieben.",
+	"error.profileSummaryFailed":              "Die Profilzusammenfassung konnte nicht gelesen oder aktualisiert werden.",
+	"error.tokenWhileImpersonating":           "Während du jemanden imitierst, können keine Tokens ausgestellt werden.",
+	"error.invalidToken":                      "Das Zugriffstoken ist ungültig oder abgelaufen.",
+	"error.tokenFromBearer":                   "Tokens werden nur für eine angemeldete Sitzung ausgestellt, nicht im Austausch gegen ein anderes Token.",
+	"error.invalidCLILogin":                   "Der Link zur Anmeldung bei pillctl ist ungültig. Führe pillctl login erneut aus.",
+}
@@ -0,0 +1,263 @@
+package i18n
+
+var english = Catalog{
+	"language.name": "English",
+
+	"availability.red":     "red",
+	"availability.amber":   "amber",
+	"availability.green":   "green",
+	"availability.unknown": "unknown",
+
+	"date.short": "2 January",
+	"date.long":  "2 January 2006",
+
+	"digest.subject.daily":                 "Your team's day in pill",
+	"digest.subject.weekly":                "Your team's week in pill",
+	"digest.intro":                         "Here's what changed in your team between %s and %s.",
+	"digest.updated":                       "%s updated their profile.",
+	"digest.availabilityChanged":           "%s is now %s.",
+	"digest.updatedAndAvailabilityChanged": "%s updated their profile and is now %s.",
+	"digest.personNewSkills":               "New skills: %s.",
+	"digest.teamNewSkills":                 "New skills in your team: %s.",
+	"digest.preferences":                   "To change how often you receive this digest, or stop receiving it, visit %s",
+	"digest.preferencesLink":               "Change how often you receive this digest, or stop receiving it",
+	"digest.adoption":                      "%v%% of people at %s have a pill profile, and %v%% of profiles were updated in the last 90 days.",
+	"digest.adoptionProfiles":              "%v people at %s have a pill profile, and %v%% of profiles were updated in the last 90 days.",
+
+	"error.methodNotAllowed":                  "Method not allowed.",
+	"error.sessionRequired":                   "A session is required.",
+	"error.invalidForm":                       "Invalid form post.",
+	"error.invalidClaim":                      "The presented claim is invalid.",
+	"error.profileReadFailed":                 "Unable to retrieve the profile for user %s.",
+	"error.profileSaveFailed":                 "Unable to save profile for user %s.",
+	"error.profilesListFailed":                "Unable to retrieve the list of profiles.",
+	"error.skillTagsListFailed":               "Failed to list skill tags.",
+	"error.orgTreeReadFailed":                 "Unable to retrieve the org tree.",
+	"error.managerNotFound":                   "The manager was not found.",
+	"error.tokenMethod":                       "Tokens must be requested with a POST.",
+	"error.tokenIssueFailed":                  "Failed to issue a token.",
+	"error.adminOnlyAuditLog":                 "Only administrators can read the audit log.",
+	"error.invalidSince":                      "The since parameter must be an RFC 3339 time.",
+	"error.auditLogReadFailed":                "Failed to read the audit log.",
+	"error.adminOnlyFeatureFlags":             "Only administrators can manage feature flags.",
+	"error.invalidFeatureFlagUpdate":          "Invalid feature flag update.",
+	"error.featureFlagSetFailed":              "Failed to set the feature flag.",
+	"error.adminOnlyImpersonation":            "Only administrators can impersonate users.",
+	"error.invalidImpersonationRequest":       "Invalid impersonation request.",
+	"error.impersonationNotSupported":         "Impersonation is not supported by the session.",
+	"error.impersonationAuditFailed":          "Failed to record impersonation in the audit log.",
+	"error.impersonationStartFailed":          "Failed to start impersonation.",
+	"error.notImpersonated":                   "The user is not being impersonated.",
+	"error.notificationPreferencesReadFailed": "Failed to retrieve the notification preferences.",
+	"error.invalidNotificationPreferences":    "Invalid notification preferences.",
+	"error.invalidMuted":                      "The muted value must be true or false.",
+	"error.notificationPreferencesSaveFailed": "Failed to save the notification preferences.",
+	"error.profileNotFound":                   "The profile was not found.",
+	"error.availabilityReadFailed":            "Failed to retrieve the availability.",
+	"error.invalidAvailabilityWindows":        "Invalid availability windows.",
+	"error.availabilitySaveFailed":            "Failed to save the availability windows.",
+	"error.changeQuarantined":                 "The change has been held for review by an administrator.",
+	"error.adminOnlyQuarantine":               "Only administrators can review quarantined changes.",
+	"error.quarantineReadFailed":              "Failed to retrieve the quarantined changes.",
+	"error.invalidQuarantineReview":           "The review must have an id, and an action of release or reject.",
+	"error.quarantinedChangeNotFound":         "The quarantined change was not found.",
+	"error.quarantineReleaseFailed":           "Failed to complete the review of the quarantined change.",
+	"error.adminOnlyApprovals":                "Only administrators can request and approve destructive actions.",
+	"error.invalidApprovalRequest":            "Invalid approval request.",
+	"error.approvalRequestFailed":             "Failed to store the approval request.",
+	"error.approvalReadFailed":                "Failed to retrieve the approval requests.",
+	"error.approvalNotFound":                  "The approval request was not found.",
+	"error.invalidApprovalDecision":           "The decision must have an id, and a decision of approve or deny.",
+	"error.approvalBySameAdministrator":       "The action must be approved by a different administrator to the one who requested it.",
+	"error.approvalExpired":                   "The approval request has expired.",
+	"error.approvalExecuteFailed":             "Failed to carry out the approved action.",
+	"error.adminOnlySkillTrash":               "Only administrators can restore deleted skill tags.",
+	"error.skillTrashReadFailed":              "Failed to list the deleted skill tags.",
+	"error.invalidSkillTagRestore":            "The tags to restore must be listed in the tags field.",
+	"error.skillTagRestoreFailed":             "Failed to restore the skill tags.",
+	"error.tenantMoving":                      "Your organisation's profiles are being moved, try again in a few minutes.",
+	"error.databaseUnavailable":               "The database is unavailable, try again in a few minutes.",
+	"error.readOnly":                          "Pill is read only during maintenance, so your changes haven't been saved. Try again later.",
+	"error.settingsReadFailed":                "Unable to retrieve your organisation's settings.",
+	"error.adminOnlySkillDescriptors":         "Only administrators can describe skill levels.",
+	"error.invalidSkillDescriptors":           "The skill descriptors must be JSON, such as {\"name\":\"kubernetes\",\"descriptors\":[{\"level\":3,\"description\":\"...\"}]}.",
+	"error.skillTagNotFound":                  "The skill %s was not found.",
+	"error.skillDescriptorsSaveFailed":        "Unable to save the skill descriptors.",
+	"error.calibrationDisabled":               "Calibration is not switched on.",
+	"error.calibrationReadFailed":             "Unable to retrieve the calibrations.",
+	"error.calibrationSaveFailed":             "Unable to save the calibration.",
+	"error.invalidCalibration":                "The calibration must be JSON, such as {\"emailAddress\":\"someone@example.com\",\"skill\":\"go\",\"level\":3,\"note\":\"...\"}.",
+	"error.managerOnlyCalibration":            "Only the managers of %s can propose changes to their levels.",
+	"error.skillNotOnProfile":                 "The skill %s is not on the profile.",
+	"error.invalidCalibrationDecision":        "The decision must be JSON, such as {\"id\":\"...\",\"decision\":\"accept\"} or {\"id\":\"...\",\"decision\":\"decline\"}.",
+	"error.calibrationNotFound":               "The calibration was not found.",
+	"error.calibrationDecided":                "The calibration has already been accepted or declined.",
+	"error.comparisonRequiresTwoProfiles":     "Two people are required, such as ?a=someone@example.com&b=someone.else@example.com.",
+	"error.invalidTeamRequest":                "The request must be JSON, such as {\"requirements\":[{\"skill\":\"go\",\"level\":3}],\"candidates\":[\"someone@example.com\"]}.",
+	"error.invalidBooking":                    "The booking must be JSON, such as {\"emailAddress\":\"someone@example.com\",\"project\":\"Website\",\"start\":\"2017-03-01T09:00:00Z\",\"end\":\"2017-06-01T17:00:00Z\",\"percentage\":50}.",
+	"error.overbooked":                        "The booking would allocate %s to more than 100%% of their time.",
+	"error.bookingSaveFailed":                 "Unable to save the booking.",
+	"error.bookingNotFound":                   "The booking was not found.",
+	"error.invalidAllocationPeriod":           "The period must be dates such as ?from=2017-03-01&to=2017-06-01, and end after it starts.",
+	"error.invalidProject":                    "The project must be JSON, such as {\"name\":\"Website\",\"client\":\"Example\",\"start\":\"2017-03-01T00:00:00Z\",\"end\":\"2017-06-01T00:00:00Z\",\"requirements\":[{\"skill\":\"go\",\"level\":3}]}.",
+	"error.projectReadFailed":                 "Unable to retrieve the projects.",
+	"error.projectSaveFailed":                 "Unable to save the project.",
+	"error.projectNotFound":                   "The project was not found.",
+	"error.courseReadFailed":                  "Unable to retrieve the training courses.",
+	"error.courseSaveFailed":                  "Unable to save the training course.",
+	"error.adminOnlyCourses":                  "Only administrators can change the training catalog.",
+	"error.invalidCourse":                     "The course must be JSON, such as {\"title\":\"...\",\"url\":\"https://www.udemy.com/course/...\",\"skills\":[{\"skill\":\"terraform\",\"level\":3}]}.",
+	"error.courseNotFound":                    "The training course was not found.",
+	"error.invalidLearningPath":               "A skill is required, and the target level must be between 1 and 5.",
+	"error.invalidCourseCompletion":           "The completion must be JSON, such as {\"courseId\":\"...\"}.",
+	"error.invalidCourseCompletionDecision":   "The decision must be JSON, such as {\"id\":\"...\",\"decision\":\"approve\"} or {\"id\":\"...\",\"decision\":\"decline\"}.",
+	"error.courseCompletionNotFound":          "The course completion was not found.",
+	"error.courseCompletionDecided":           "The course completion has already been approved or declined.",
+	"goals.reminder.subject":                  "Your learning goals in pill",
+	"goals.reminder.intro":                    "These learning goals are nearly due:",
+	"goals.reminder.due":                      "Reach level %d in %s by %s.",
+	"goals.reminder.overdue":                  "Reach level %d in %s, which was due on %s.",
+	"goals.reminder.update":                   "When you've reached a goal, update your profile, or change the goal, at %s",
+	"error.invalidLearningGoal":               "The goal must be JSON, such as {\"skill\":\"terraform\",\"target\":3,\"due\":\"Q3\"}.",
+	"error.learningGoalNotFound":              "The learning goal was not found.",
+	"error.learningGoalSaveFailed":            "Unable to save the learning goals.",
+	"error.learningGoalReadFailed":            "Unable to retrieve the learning goals.",
+	"decay.subject":                           "Are your skills in pill still up to date?",
+	"decay.text":                              "You haven't confirmed or used these skills for a while: %s. Re-confirm your levels, or update them, at %s",
+	"error.staleSkillsReadFailed":             "Unable to retrieve the stale skills.",
+	"error.invalidSkillConfirmation":          "The confirmation must be JSON, such as {\"skills\":[\"go\",\"sql\"]}.",
+	"error.skillConfirmationFailed":           "Unable to confirm the skills.",
+	"error.unknownSkillTags":                  "Only the organisation's skills can be used, so %s must be proposed to an administrator. Explain why the skills are needed to propose them.",
+	"error.skillTagsProposed":                 "%s have been proposed to an administrator. Your profile will be updated once they've been reviewed.",
+	"error.adminOnlyTagProposals":             "Only administrators can review proposed skills.",
+	"error.invalidTagProposalDecision":        "The decision must be JSON, e.g. {\"id\":\"...\",\"action\":\"approve\"}, with an action of approve or reject.",
+	"error.tagProposalNotFound":               "The proposed skill was not found.",
+	"error.tagProposalDecided":                "The proposed skill has already been reviewed.",
+	"error.tagProposalReadFailed":             "Unable to retrieve the proposed skills.",
+	"error.tagProposalSaveFailed":             "Unable to save the proposed skills.",
+	"error.adminOnlyDuplicateTags":            "Only administrators can merge duplicate skills.",
+	"error.invalidTagMerge":                   "The merge must be JSON, e.g. {\"into\":\"javascript\",\"tags\":[\"js\"]}.",
+	"error.tagMergeFailed":                    "Unable to merge the skills.",
+	"error.cvReadFailed":                      "Unable to retrieve the CV.",
+	"error.cvNotFound":                        "No CV has been uploaded.",
+	"error.cvSaveFailed":                      "Unable to save the CV.",
+	"error.invalidCV":                         "The CV must be uploaded as the cv field of a multipart form.",
+	"error.invalidDownloadURL":                "The download link is invalid or has expired.",
+	"error.attachmentNotFound":                "The file could not be found.",
+	"error.skillSuggestionReadFailed":         "Unable to retrieve the suggested skills.",
+	"error.skillSuggestionSaveFailed":         "Unable to save the suggested skills.",
+	"error.invalidSkillSuggestionDecision":    "The decision must be accept or dismiss.",
+	"error.skillSuggestionNotFound":           "The suggested skills could not be found.",
+	"error.skillSuggestionDecided":            "The suggested skills have already been accepted or dismissed.",
+	"level.1":                                 "Novice",
+	"level.2":                                 "Competent",
+	"level.3":                                 "Proficient",
+	"level.4":                                 "Expert",
+	"level.5":                                 "Master",
+	"onepager.skills":                         "Skills",
+	"onepager.certifications":                 "Certifications",
+	"onepager.projects":                       "Project history",
+	"onepager.present":                        "present",
+	"onepager.month":                          "Jan 2006",
+	"error.onePagerFailed":                    "Unable to create the profile PDF.",
+	"error.adminOnlyBranding":                 "Only administrators can change the branding.",
+	"error.invalidBranding":                   "The branding must be JSON, such as {\"logoUrl\":\"https://example.com/logo.png\",\"primaryColor\":\"#1f6feb\",\"accentColor\":\"#f78166\",\"footerText\":\"...\"}.",
+	"error.brandingSaveFailed":                "Unable to save the branding.",
+	"error.shareLinkReadFailed":               "Unable to retrieve the share links.",
+	"error.shareLinkSaveFailed":               "Unable to save the share link.",
+	"error.invalidShareLink":                  "The share link must be JSON, such as {\"days\":30}, and last between 1 and %d days.",
+	"error.shareLinkNotFound":                 "The link doesn't exist or has expired.",
+	"error.invalidCardSkills":                 "The number of skills must be between 1 and %d.",
+	"error.oEmbedFormat":                      "Only the json format is supported.",
+	"error.oEmbedURL":                         "The URL isn't a pill profile card.",
+	"error.adminOnlyArchive":                  "Only administrators can list and restore archived profiles.",
+	"error.archiveReadFailed":                 "Failed to list the archived profiles.",
+	"error.invalidProfileRestore":             "The email address of the profile to restore is required.",
+	"error.archivedProfileNotFound":           "The archived profile was not found.",
+	"error.profileRestoreFailed":              "Failed to restore the profile.",
+	"offboarding.subject":                     "%s has left",
+	"offboarding.archived":                    "%s has left, so their profile has been archived. Administrators can restore it at %s",
+	"offboarding.deleted":                     "%s has left, so their profile has been deleted. Administrators can restore it within %d days at %s",
+	"error.adminOnlyCustomFields":             "Only administrators can define custom fields.",
+	"error.invalidCustomFields":               "The custom fields must be a list of fields.",
+	"error.customFieldsSaveFailed":            "Failed to save the custom fields.",
+	"error.customFieldsNotYours":              "Only administrators can change the custom fields of other people.",
+	"error.invalidCustomFieldValues":          "The custom field values must be an object.",
+	"error.customFieldValuesSaveFailed":       "Failed to save the values of the custom fields.",
+	"error.customFieldNotFound":               "There isn't a custom field called %s.",
+	"error.customFieldAdminOnly":              "Only administrators can change %s.",
+	"error.languagesReadFailed":               "Failed to read your languages.",
+	"error.invalidLanguages":                  "The languages must be a list of codes and levels, e.g. [{\"code\":\"de\",\"level\":\"B2\"}].",
+	"error.languagesSaveFailed":               "Failed to save your languages.",
+	"error.clearanceRestricted":               "You can't see or change this clearance.",
+	"error.clearanceNotFound":                 "No clearance has been recorded.",
+	"error.invalidClearance":                  "The clearance must have a level, and an expiry date such as 2020-09-30.",
+	"error.clearanceSaveFailed":               "Failed to save the clearance.",
+	"error.workLocationReadFailed":            "Failed to read your work location.",
+	"error.workLocationNotFound":              "You haven't said where you're based.",
+	"error.invalidWorkLocation":               "The work location must have a city, a country, coordinates and a remote preference.",
+	"error.workLocationSaveFailed":            "Failed to save your work location.",
+	"error.invalidNearbyQuery":                "The search must have a latitude and longitude, and a radius of up to %d km.",
+	"error.workingHoursReadFailed":            "Failed to read your working hours.",
+	"error.invalidWorkingHours":               "The working hours must have a start, an end and days, e.g. {\"start\":\"09:00\",\"end\":\"17:00\",\"days\":[1,2,3,4,5]}.",
+	"error.workingHoursSaveFailed":            "Failed to save your working hours.",
+	"error.invalidOverlapQuery":               "The search must have a start and end time, such as 2018-09-03T22:00:00Z, no more than a week apart, and a number of hours.",
+	"error.invalidAccessDays":                 "The days parameter must be a number from 1 to 366.",
+	"error.accessReportFailed":                "Failed to read who has accessed your profile.",
+	"error.invalidConsent":                    "The consents must be an object of purposes, such as {\"analytics\":true}.",
+	"error.staleNotice":                       "The privacy notice has changed, please read version %s.",
+	"error.unknownConsentPurpose":             "Consent isn't asked for the purpose '%s'.",
+	"error.consentSaveFailed":                 "Failed to save your consent.",
+	"error.adminOnlyDiagnostics":              "Only the service's operators can read its diagnostics.",
+	"error.invalidTrendMonths":                "The months parameter must be a number from 1 to %d.",
+	"error.invalidTrendLimit":                 "The limit parameter must be a number from 1 to %d.",
+	"error.comparisonRequiresTwoCohorts":      "Two cohorts are required, such as ?a=department:Engineering&b=department:Product.",
+	"error.benchmarkingNotEnabled":            "Your organisation hasn't opted in to benchmarking.",
+	"error.benchmarkReadFailed":               "Unable to retrieve the benchmark.",
+	"error.benchmarkNotFound":                 "There's no benchmark for organisations of your size yet. At least %d must opt in.",
+	"reports.subject":                         "%s, from pill",
+	"reports.download":                        "Download it as CSV",
+	"error.adminOnlyReports":                  "Only administrators can manage custom reports.",
+	"error.reportDefinitionsReadFailed":       "Unable to retrieve the custom reports.",
+	"error.invalidReportDefinition":           "The report definition must be JSON.",
+	"error.reportDefinitionSaveFailed":        "Unable to save the custom report.",
+	"error.reportDefinitionNotFound":          "The custom report was not found.",
+	"error.reportRunFailed":                   "Unable to run the custom report.",
+	"error.adminOnlyExports":                  "Only administrators can export their domain's data.",
+	"error.invalidExportRequest":              "The export request could not be read.",
+	"error.exportNotSupported":                "%s can't be exported as %s.",
+	"error.exportJobSaveFailed":               "Failed to start the export.",
+	"error.exportJobReadFailed":               "Failed to retrieve the export.",
+	"error.exportJobNotFound":                 "The export was not found.",
+	"error.exportNotReady":                    "The export can't be downloaded because it is %s.",
+	"error.exportReadFailed":                  "Failed to read the exported file.",
+	"error.adminOnlyImports":                  "Only administrators can import skills into their domain.",
+	"error.unknownImportFormat":               "'%s' is not a format which can be imported.",
+	"error.importPreviewFailed":               "Failed to check the import.",
+	"error.importPreviewNotFound":             "The import was not found. It may have expired or already been committed, so check the file again.",
+	"error.importFailed":                      "Failed to import the file.",
+	"error.adminOnlyDuplicateProfiles":        "Only administrators can find and merge duplicate profiles.",
+	"error.invalidProfileMerge":               "The merge must name a primary profile and a different duplicate profile.",
+	"error.profileMergeOutsideTenant":         "Only profiles in %s and its alias domains can be merged.",
+	"error.profileMergeFailed":                "Failed to merge the profiles.",
+	"error.adminOnlyExternalIds":              "Only administrators can look up and change external IDs.",
+	"error.invalidExternalId":                 "The request must name a system and an ID.",
+	"error.externalIdsOutsideTenant":          "Only the external IDs of profiles in %s can be changed.",
+	"error.externalIdsSaveFailed":             "Failed to save the external IDs.",
+	"error.invalidProfileEventRequest":        "The after parameter must be an event's sequence, and the at parameter a time such as 2018-03-01T00:00:00Z.",
+	"error.profileEventsReadFailed":           "Failed to read the profile's events.",
+	"error.invalidSearch":                     "The search is invalid. The level must be between 1 and 5, with a skill, and the availability between 1 (red) and 3 (green).",
+	"error.searchFailed":                      "Unable to search the profiles.",
+	"error.teamOutsideDomain":                 "Only the teams in your domain can be viewed.",
+	"error.teamSummaryReadFailed":             "Unable to read the team summary.",
+	"error.invalidAutocomplete":               "The type must be skills or people, and the limit between 1 and %d.",
+	"error.invalidSemanticSearch":             "Search with ?q=, e.g. ?q=container orchestration, and a level between 1 and 5.",
+	"error.invalidQuestion":                   "The request must be JSON with a question of up to %d characters, such as {\"question\":\"who knows go and is free in June?\"}.",
+	"error.questionFailed":                    "Unable to answer the question.",
+	"error.profileSummaryNotFound":            "No summary has been written of this profile.",
+	"error.profileSummaryFailed":              "Failed to read or update the profile summary.",
+	"error.tokenWhileImpersonating":           "Tokens can't be issued while impersonating someone.",
+	"error.invalidToken":                      "The access token is invalid or has expired.",
+	"error.tokenFromBearer":                   "Tokens can only be issued to a logged in session, not in exchange for another token.",
+	"error.invalidCLILogin":                   "The link to log in to pillctl is invalid. Run pillctl login again.",
+}
@@ -0,0 +1,102 @@
+// Package i18n translates generated content, such as emails and error
+// messages, using message catalogs.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when no language is requested, or the requested
+// language isn't supported. Its catalog contains every message.
+const DefaultLanguage = "en"
+
+// A Catalog maps message keys to translations. Translations are fmt format
+// strings, and can use explicit argument indexes (e.g. %[2]s) when the word
+// order differs between languages.
+type Catalog map[string]string
+
+var catalogs = map[string]Catalog{
+	"en": english,
+	"de": german,
+}
+
+// Supported lists the supported languages.
+func Supported() []string {
+	var languages []string
+	for l := range catalogs {
+		languages = append(languages, l)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// IsSupported returns true if there's a catalog for the language, or the
+// language's base language, e.g. "de" for "de-CH".
+func IsSupported(language string) bool {
+	_, ok := catalogs[base(language)]
+	return ok
+}
+
+func base(language string) string {
+	return strings.ToLower(strings.SplitN(strings.Replace(language, "_", "-", -1), "-", 2)[0])
+}
+
+// Message returns the untranslated format string for the key in the
+// language, falling back to the default language, then to the key itself.
+func Message(language string, key string) string {
+	if message, ok := catalogs[base(language)][key]; ok {
+		return message
+	}
+	if message, ok := catalogs[DefaultLanguage][key]; ok {
+		return message
+	}
+	return key
+}
+
+// Translate returns the message in the language, formatted with the args.
+func Translate(language string, key string, args ...interface{}) string {
+	message := Message(language, key)
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// A Translator translates messages into a single language.
+type Translator func(key string, args ...interface{}) string
+
+// For returns a Translator for the language.
+func For(language string) Translator {
+	return func(key string, args ...interface{}) string {
+		return Translate(language, key, args...)
+	}
+}
+
+// Negotiate returns the supported language which best matches an HTTP
+// Accept-Language header, or the default language.
+func Negotiate(acceptLanguage string) string {
+	best, bestQuality := DefaultLanguage, 0.0
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		language := strings.TrimSpace(fields[0])
+
+		quality := 1.0
+		for _, f := range fields[1:] {
+			if q := strings.TrimSpace(f); strings.HasPrefix(q, "q=") {
+				if v, err := strconv.ParseFloat(q[2:], 64); err == nil {
+					quality = v
+				}
+			}
+		}
+
+		if quality > bestQuality && IsSupported(language) {
+			best, bestQuality = base(language), quality
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,75 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThatEveryCatalogTranslatesEveryMessage(t *testing.T) {
+	for language, catalog := range catalogs {
+		for key, message := range catalogs[DefaultLanguage] {
+			translation, ok := catalog[key]
+			if !ok {
+				t.Errorf("The %s catalog is missing the message %s.", language, key)
+				continue
+			}
+
+			if strings.Count(translation, "%") != strings.Count(message, "%") {
+				t.Errorf("The %s translation of %s has different arguments to the %s message.", language, key, DefaultLanguage)
+			}
+		}
+
+		for key := range catalog {
+			if _, ok := catalogs[DefaultLanguage][key]; !ok {
+				t.Errorf("The %s catalog contains the message %s, which isn't in the %s catalog.", language, key, DefaultLanguage)
+			}
+		}
+	}
+}
+
+func TestThatMessagesFallBackToTheDefaultLanguage(t *testing.T) {
+	tests := []struct {
+		language string
+		expected string
+	}{
+		{"en", "red"},
+		{"de", "rot"},
+		{"de-CH", "rot"},
+		{"de_AT", "rot"},
+		{"ja", "red"},
+		{"", "red"},
+	}
+
+	for _, test := range tests {
+		if actual := Translate(test.language, "availability.red"); actual != test.expected {
+			t.Errorf("For language '%s', expected '%s', but was '%s'.", test.language, test.expected, actual)
+		}
+	}
+
+	if actual := Translate("de", "missing.key"); actual != "missing.key" {
+		t.Errorf("Expected unknown keys to be returned unchanged, but was '%s'.", actual)
+	}
+
+	if actual := For("en")("error.profileReadFailed", "a-h@github.com"); actual != "Unable to retrieve the profile for user a-h@github.com." {
+		t.Errorf("Expected the arguments to be formatted into the message, but was '%s'.", actual)
+	}
+}
+
+func TestThatTheAcceptLanguageHeaderIsNegotiated(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", "en"},
+		{"de-DE,de;q=0.9,en;q=0.8", "de"},
+		{"fr-FR, en;q=0.5, de;q=0.7", "de"},
+		{"fr-FR", "en"},
+		{"en;q=0.9, de;q=nonsense", "de"},
+	}
+
+	for _, test := range tests {
+		if actual := Negotiate(test.header); actual != test.expected {
+			t.Errorf("For Accept-Language '%s', expected '%s', but was '%s'.", test.header, test.expected, actual)
+		}
+	}
+}
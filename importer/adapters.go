@@ -0,0 +1,255 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// An Adapter reads the export of another skills management tool.
+type Adapter func(r io.Reader) ([]dataaccess.ProfileUpdate, error)
+
+// Adapters are the supported export formats, by name.
+var Adapters = map[string]Adapter{
+	"skillsbase": ParseSkillsBase,
+	"kahuna":     ParseKahuna,
+	"imocha":     ParseIMocha,
+}
+
+// The formats of the Adapters, by name.
+var formats = map[string]longFormat{
+	"skillsbase": skillsBaseFormat,
+	"kahuna":     kahunaFormat,
+	"imocha":     iMochaFormat,
+}
+
+var skillsBaseFormat = longFormat{
+	email:    []string{"email", "person email", "email address"},
+	name:     []string{"name", "person", "person name"},
+	skill:    []string{"skill", "skill name"},
+	level:    []string{"skill level", "level", "rating"},
+	interest: []string{"interest level", "interest"},
+	parseLevel: func(v string) (dataaccess.DreyfusLevel, bool, error) {
+		n, err := scale(v, 0, 4)
+		// 1 (beginner) to 4 (expert) map onto novice to expert.
+		return dataaccess.DreyfusLevel(n), n > 0, err
+	},
+	parseInterest: func(v string) (dataaccess.LikertScale, error) {
+		if strings.TrimSpace(v) == "" {
+			return 0, nil
+		}
+		n, err := scale(v, 0, 4)
+		return dataaccess.LikertScale(n + 1), err
+	},
+}
+
+// ParseSkillsBase reads a Skills Base "Skills Data" CSV export, which has a
+// row per person and skill. Skills Base rates skills from 0 (none) to 4
+// (expert), and interest from 0 to 4. Skills rated 0 are skipped.
+func ParseSkillsBase(r io.Reader) ([]dataaccess.ProfileUpdate, error) {
+	return skillsBaseFormat.parse(r)
+}
+
+var kahunaLevels = map[string]dataaccess.DreyfusLevel{
+	"beginner":     dataaccess.NoviceLevel,
+	"novice":       dataaccess.NoviceLevel,
+	"intermediate": dataaccess.CompetentLevel,
+	"competent":    dataaccess.CompetentLevel,
+	"advanced":     dataaccess.ProficientLevel,
+	"expert":       dataaccess.ExpertLevel,
+	"master":       dataaccess.MasterLevel,
+}
+
+var kahunaFormat = longFormat{
+	email: []string{"employee email", "email"},
+	name:  []string{"employee name", "employee", "name"},
+	skill: []string{"skill", "skill name"},
+	level: []string{"proficiency", "proficiency level", "level"},
+	parseLevel: func(v string) (dataaccess.DreyfusLevel, bool, error) {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v == "" || v == "none" {
+			return 0, false, nil
+		}
+		l, ok := kahunaLevels[v]
+		if !ok {
+			return 0, false, fmt.Errorf("'%s' is not a Kahuna proficiency level", v)
+		}
+		return l, true, nil
+	},
+}
+
+// ParseKahuna reads a Kahuna "Employee Skills" CSV export, which has a row
+// per employee and skill. Kahuna names its proficiency levels, e.g.
+// "Intermediate", and has no interest rating.
+func ParseKahuna(r io.Reader) ([]dataaccess.ProfileUpdate, error) {
+	return kahunaFormat.parse(r)
+}
+
+var iMochaFormat = longFormat{
+	email: []string{"candidate email", "email"},
+	name:  []string{"candidate name", "name"},
+	skill: []string{"skill", "skill name", "test name"},
+	level: []string{"score (%)", "score", "percentage"},
+	parseLevel: func(v string) (dataaccess.DreyfusLevel, bool, error) {
+		score, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "%"), 64)
+		if err != nil || score < 0 || score > 100 {
+			return 0, false, fmt.Errorf("'%s' is not a percentage", v)
+		}
+		if score == 0 {
+			return 0, false, nil
+		}
+		level := int(score/20) + 1
+		if level > dataaccess.MasterLevel {
+			level = dataaccess.MasterLevel
+		}
+		return dataaccess.DreyfusLevel(level), true, nil
+	},
+}
+
+// ParseIMocha reads an iMocha "Skills Assessment Report" CSV export, which
+// has a row per candidate and skill assessed, with a percentage score.
+// Scores are converted to levels in bands of 20%, and people who scored 0
+// are skipped.
+func ParseIMocha(r io.Reader) ([]dataaccess.ProfileUpdate, error) {
+	return iMochaFormat.parse(r)
+}
+
+func scale(v string, min, max int) (int, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return min, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("'%s' is not a rating from %d to %d", v, min, max)
+	}
+	return n, nil
+}
+
+// A longFormat is a CSV export with a row per person and skill. Each column
+// is found by any of its names, ignoring case.
+type longFormat struct {
+	email, name, skill, level, interest []string
+	// parseLevel returns false if the person doesn't have the skill.
+	parseLevel    func(v string) (level dataaccess.DreyfusLevel, ok bool, err error)
+	parseInterest func(v string) (dataaccess.LikertScale, error)
+}
+
+func (f longFormat) parse(r io.Reader) ([]dataaccess.ProfileUpdate, error) {
+	updates, problems, err := f.parseRows(r, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("importer: %v", problems[0])
+	}
+	return updates, nil
+}
+
+// parseRows reads the rows, skipping those with problems and returning them.
+// If the domain isn't empty, rows for people outside it are problems. An
+// error is only returned if the file can't be read at all.
+func (f longFormat) parseRows(r io.Reader, domain string) ([]dataaccess.ProfileUpdate, []RowError, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("importer: failed to read the header: %v", err)
+	}
+
+	columns := map[string]int{}
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	find := func(names []string) int {
+		for _, n := range names {
+			if i, ok := columns[n]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	emailColumn, nameColumn, skillColumn, levelColumn, interestColumn := find(f.email), find(f.name), find(f.skill), find(f.level), find(f.interest)
+	if emailColumn < 0 || skillColumn < 0 || levelColumn < 0 {
+		return nil, nil, fmt.Errorf("importer: the header must include %s, %s and %s columns", f.email[0], f.skill[0], f.level[0])
+	}
+
+	cell := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var problems []RowError
+	problem := func(line int, format string, args ...interface{}) {
+		problems = append(problems, RowError{Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+	updates := map[string]*dataaccess.ProfileUpdate{}
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); !ok {
+				return nil, nil, fmt.Errorf("importer: line %d: %v", line, err)
+			}
+			problem(line, "%v", err)
+			continue
+		}
+
+		email := strings.ToLower(cell(record, emailColumn))
+		if !strings.Contains(email, "@") {
+			problem(line, "'%s' is not an email address", email)
+			continue
+		}
+		if domain != "" && !strings.EqualFold(dataaccess.GetDomain(email), domain) {
+			problem(line, "%s is not in %s", email, domain)
+			continue
+		}
+
+		var skill dataaccess.Skill
+		tag := dataaccess.CleanTag(cell(record, skillColumn))
+		level, ok, err := f.parseLevel(cell(record, levelColumn))
+		if tag != "" && err != nil {
+			problem(line, "%v", err)
+			continue
+		}
+		if tag != "" && ok {
+			skill = dataaccess.Skill{Skill: tag, Level: level}
+			if f.parseInterest != nil && interestColumn >= 0 {
+				if skill.Interest, err = f.parseInterest(cell(record, interestColumn)); err != nil {
+					problem(line, "%v", err)
+					continue
+				}
+			}
+		}
+
+		u, found := updates[email]
+		if !found {
+			u = &dataaccess.ProfileUpdate{EmailAddress: email, Skills: []dataaccess.Skill{}}
+			updates[email] = u
+		}
+		if name := cell(record, nameColumn); name != "" {
+			u.Name = &name
+		}
+		if skill.Skill != "" {
+			u.Skills = append(u.Skills, skill)
+		}
+	}
+
+	op := make([]dataaccess.ProfileUpdate, 0, len(updates))
+	for _, u := range updates {
+		op = append(op, *u)
+	}
+	sort.Slice(op, func(i, j int) bool { return op[i].EmailAddress < op[j].EmailAddress })
+	return op, problems, nil
+}
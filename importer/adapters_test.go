@@ -0,0 +1,91 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatCompetitorExportsAreConvertedToProfileUpdates(t *testing.T) {
+	a, b := "Adrian", "Bea"
+
+	tests := []struct {
+		format   string
+		input    string
+		expected []dataaccess.ProfileUpdate
+	}{
+		{
+			format: "skillsbase",
+			input: `Person,Person Email,Skill,Category,Skill Level,Interest Level
+Adrian,A@github.com,Go,Languages,4,3
+Adrian,a@github.com,Java,Languages,0,0
+Bea,b@github.com,Rust,Languages,1,
+`,
+			expected: []dataaccess.ProfileUpdate{
+				{EmailAddress: "a@github.com", Name: &a, Skills: []dataaccess.Skill{{Skill: "go", Level: dataaccess.ExpertLevel, Interest: dataaccess.Agree}}},
+				{EmailAddress: "b@github.com", Name: &b, Skills: []dataaccess.Skill{{Skill: "rust", Level: dataaccess.NoviceLevel}}},
+			},
+		},
+		{
+			format: "kahuna",
+			input: `Employee Name,Employee Email,Skill,Proficiency
+Adrian,a@github.com,Go,Advanced
+Adrian,a@github.com,C#,Intermediate
+Bea,b@github.com,Rust,None
+`,
+			expected: []dataaccess.ProfileUpdate{
+				{EmailAddress: "a@github.com", Name: &a, Skills: []dataaccess.Skill{
+					{Skill: "go", Level: dataaccess.ProficientLevel},
+					{Skill: "c#", Level: dataaccess.CompetentLevel},
+				}},
+				{EmailAddress: "b@github.com", Name: &b, Skills: []dataaccess.Skill{}},
+			},
+		},
+		{
+			format: "imocha",
+			input: `Candidate Name,Candidate Email,Test Name,Score (%)
+Adrian,a@github.com,Go,100%
+Bea,b@github.com,Go,39.5
+Bea,b@github.com,Java,0
+`,
+			expected: []dataaccess.ProfileUpdate{
+				{EmailAddress: "a@github.com", Name: &a, Skills: []dataaccess.Skill{{Skill: "go", Level: dataaccess.MasterLevel}}},
+				{EmailAddress: "b@github.com", Name: &b, Skills: []dataaccess.Skill{{Skill: "go", Level: dataaccess.CompetentLevel}}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		actual, err := Adapters[test.format](strings.NewReader(test.input))
+		if err != nil {
+			t.Errorf("%s: expected no error, but got %v.", test.format, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s: expected %+v, but got %+v.", test.format, test.expected, actual)
+		}
+	}
+}
+
+func TestThatInvalidCompetitorExportsAreRejected(t *testing.T) {
+	tests := []struct {
+		format   string
+		input    string
+		expected string
+	}{
+		{"skillsbase", "Person Email,Skill\na@github.com,Go\n", "the header must include email, skill and skill level columns"},
+		{"skillsbase", "Email,Skill,Level\na@github.com,Go,5\n", "line 2: '5' is not a rating from 0 to 4"},
+		{"kahuna", "Email,Skill,Proficiency\na@github.com,Go,Guru\n", "line 2: 'guru' is not a Kahuna proficiency level"},
+		{"imocha", "Email,Skill,Score\na@github.com,Go,101\n", "line 2: '101' is not a percentage"},
+		{"imocha", "Email,Skill,Score\nadrian,Go,50\n", "line 2: 'adrian' is not an email address"},
+	}
+
+	for _, test := range tests {
+		_, err := Adapters[test.format](strings.NewReader(test.input))
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected an error containing '%s', but got %v.", test.format, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// ReconstructProfiles combines snapshots into profiles. Each person's skills
+// in the latest snapshot they appear in become their current skills, and
+// their skills in earlier snapshots become their history. Snapshots where a
+// person's skills didn't change don't add to their history.
+func ReconstructProfiles(snapshots []Snapshot) []dataaccess.Profile {
+	sorted := append([]Snapshot(nil), snapshots...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	profiles := map[string]*dataaccess.Profile{}
+	for _, s := range sorted {
+		date := s.Date.UTC()
+		for _, row := range s.Rows {
+			p, ok := profiles[row.EmailAddress]
+			if !ok {
+				p = &dataaccess.Profile{
+					EmailAddress:        row.EmailAddress,
+					Domain:              dataaccess.GetDomain(row.EmailAddress),
+					AvailabilityChanged: date,
+				}
+				profiles[row.EmailAddress] = p
+			}
+
+			skills := sortedSkills(row.Skills)
+			if ok && !reflect.DeepEqual(p.Skills, skills) {
+				p.SkillsHistory = append(p.SkillsHistory, dataaccess.SkillLevel{Date: p.LastUpdated, Skills: p.Skills})
+			}
+			if ok && p.Availability != row.Availability {
+				p.AvailabilityChanged = date
+			}
+
+			p.Skills = skills
+			p.Availability = row.Availability
+			if row.Name != "" {
+				p.Name = row.Name
+			}
+			p.LastUpdated = date
+			p.Version++
+		}
+	}
+
+	op := make([]dataaccess.Profile, 0, len(profiles))
+	for _, p := range profiles {
+		op = append(op, *p)
+	}
+	sort.Slice(op, func(i, j int) bool { return op[i].EmailAddress < op[j].EmailAddress })
+	return op
+}
+
+func sortedSkills(skills []dataaccess.Skill) []dataaccess.Skill {
+	op := append([]dataaccess.Skill{}, skills...)
+	sort.Slice(op, func(i, j int) bool { return op[i].Skill < op[j].Skill })
+	return op
+}
+
+// Merge adds an imported profile to the history of a profile which already
+// exists in pill, so that importing doesn't overwrite changes made since.
+// History entries on dates the existing profile already has are skipped, so
+// that importing the same files twice doesn't duplicate them.
+func Merge(existing dataaccess.Profile, imported dataaccess.Profile) dataaccess.Profile {
+	dates := map[time.Time]bool{}
+	for _, h := range existing.SkillsHistory {
+		dates[h.Date.UTC()] = true
+	}
+
+	var history []dataaccess.SkillLevel
+	for _, h := range append(imported.SkillsHistory, dataaccess.SkillLevel{Date: imported.LastUpdated, Skills: imported.Skills}) {
+		if !dates[h.Date.UTC()] && h.Date.Before(existing.LastUpdated) {
+			history = append(history, h)
+		}
+	}
+
+	merged := existing
+	merged.SkillsHistory = append(history, existing.SkillsHistory...)
+	sort.SliceStable(merged.SkillsHistory, func(i, j int) bool {
+		return merged.SkillsHistory[i].Date.Before(merged.SkillsHistory[j].Date)
+	})
+	if merged.Name == "" {
+		merged.Name = imported.Name
+	}
+	merged.Version++
+	return merged
+}
@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+var (
+	january  = time.Date(2016, time.January, 4, 0, 0, 0, 0, time.UTC)
+	february = time.Date(2016, time.February, 1, 0, 0, 0, 0, time.UTC)
+	march    = time.Date(2016, time.March, 7, 0, 0, 0, 0, time.UTC)
+)
+
+func TestThatHistoryIsReconstructedFromDatedSnapshots(t *testing.T) {
+	goNovice := []dataaccess.Skill{{Skill: "go", Level: dataaccess.NoviceLevel}}
+	goCompetent := []dataaccess.Skill{{Skill: "go", Level: dataaccess.CompetentLevel}}
+
+	// The snapshots are out of order, to check that they're sorted by date.
+	snapshots := []Snapshot{
+		{Date: march, Rows: []Row{{EmailAddress: "a@github.com", Availability: dataaccess.Red, Skills: goCompetent}}},
+		{Date: january, Rows: []Row{
+			{EmailAddress: "a@github.com", Name: "A", Availability: dataaccess.Green, Skills: goNovice},
+			{EmailAddress: "b@github.com", Availability: dataaccess.Amber, Skills: goCompetent},
+		}},
+		{Date: february, Rows: []Row{{EmailAddress: "a@github.com", Availability: dataaccess.Green, Skills: goNovice}}},
+	}
+
+	profiles := ReconstructProfiles(snapshots)
+
+	if len(profiles) != 2 {
+		t.Fatalf("Expected 2 profiles, but got %d.", len(profiles))
+	}
+
+	a := profiles[0]
+	if a.EmailAddress != "a@github.com" || a.Domain != "github.com" || a.Name != "A" {
+		t.Errorf("Expected a@github.com, named A, in the github.com domain, but got %+v.", a)
+	}
+	if !reflect.DeepEqual(a.Skills, goCompetent) || a.Availability != dataaccess.Red {
+		t.Errorf("Expected the skills and availability from the latest snapshot, but got %v and %v.", a.Skills, a.Availability)
+	}
+	if !a.LastUpdated.Equal(march) || !a.AvailabilityChanged.Equal(march) || a.Version != 3 {
+		t.Errorf("Expected the profile to be updated in March at version 3, but got %v, %v and %d.", a.LastUpdated, a.AvailabilityChanged, a.Version)
+	}
+	// February didn't change the skills, so isn't in the history.
+	expectedHistory := []dataaccess.SkillLevel{{Date: february, Skills: goNovice}}
+	if !reflect.DeepEqual(a.SkillsHistory, expectedHistory) {
+		t.Errorf("Expected history %v, but got %v.", expectedHistory, a.SkillsHistory)
+	}
+
+	b := profiles[1]
+	if b.EmailAddress != "b@github.com" || len(b.SkillsHistory) != 0 || !b.LastUpdated.Equal(january) {
+		t.Errorf("Expected b@github.com to be last updated in January with no history, but got %+v.", b)
+	}
+}
+
+func TestThatImportedHistoryIsMergedIntoExistingProfiles(t *testing.T) {
+	june := time.Date(2017, time.June, 1, 0, 0, 0, 0, time.UTC)
+	existing := dataaccess.Profile{
+		EmailAddress:  "a@github.com",
+		Skills:        []dataaccess.Skill{{Skill: "rust", Level: dataaccess.ExpertLevel}},
+		SkillsHistory: []dataaccess.SkillLevel{{Date: february, Skills: []dataaccess.Skill{{Skill: "c"}}}},
+		LastUpdated:   june,
+		Version:       5,
+	}
+	imported := dataaccess.Profile{
+		EmailAddress: "a@github.com",
+		Name:         "A",
+		Skills:       []dataaccess.Skill{{Skill: "go"}},
+		SkillsHistory: []dataaccess.SkillLevel{
+			{Date: january, Skills: []dataaccess.Skill{{Skill: "java"}}},
+			{Date: february, Skills: []dataaccess.Skill{{Skill: "java"}}},
+		},
+		LastUpdated: march,
+	}
+
+	merged := Merge(existing, imported)
+
+	if !reflect.DeepEqual(merged.Skills, existing.Skills) {
+		t.Errorf("Expected the existing skills to be kept, but got %v.", merged.Skills)
+	}
+	expectedHistory := []dataaccess.SkillLevel{
+		{Date: january, Skills: []dataaccess.Skill{{Skill: "java"}}},
+		{Date: february, Skills: []dataaccess.Skill{{Skill: "c"}}},
+		{Date: march, Skills: []dataaccess.Skill{{Skill: "go"}}},
+	}
+	if !reflect.DeepEqual(merged.SkillsHistory, expectedHistory) {
+		t.Errorf("Expected history %v, but got %v.", expectedHistory, merged.SkillsHistory)
+	}
+	if merged.Name != "A" || merged.Version != 6 || !merged.LastUpdated.Equal(june) {
+		t.Errorf("Expected the name to be filled in and the version to be 6, but got %+v.", merged)
+	}
+}
@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// The Importer stores imported profiles, and adds their skills to the list
+// of skill tags.
+type Importer struct {
+	DataAccess dataaccess.DataAccess
+}
+
+// NewImporter creates an instance of the Importer.
+func NewImporter(da dataaccess.DataAccess) *Importer {
+	return &Importer{da}
+}
+
+// A Result summarises an import.
+type Result struct {
+	// Created is the number of profiles which didn't exist before.
+	Created int `json:"created"`
+	// Merged is the number of existing profiles which the import was merged
+	// into.
+	Merged int `json:"merged"`
+	// Tags is the number of distinct skill tags in the import.
+	Tags int `json:"tags"`
+}
+
+// Import stores the profiles. Profiles which already exist are merged, see
+// Merge.
+func (i Importer) Import(profiles []dataaccess.Profile) (Result, error) {
+	var r Result
+
+	tags := map[string]bool{}
+	for _, p := range profiles {
+		for _, s := range p.Skills {
+			tags[s.Skill] = true
+		}
+		for _, h := range p.SkillsHistory {
+			for _, s := range h.Skills {
+				tags[s.Skill] = true
+			}
+		}
+
+		existing, found, err := i.DataAccess.GetProfile(p.EmailAddress)
+		if err != nil {
+			return r, err
+		}
+
+		if found {
+			p = Merge(*existing, p)
+			r.Merged++
+		} else {
+			r.Created++
+		}
+
+		if err := i.DataAccess.ImportProfile(&p); err != nil {
+			return r, err
+		}
+	}
+
+	r.Tags = len(tags)
+	return r, i.addSkillTags(tags)
+}
+
+// ImportUpdates applies updates read by an Adapter. Profiles which already
+// exist have their skills replaced, keeping their previous skills in their
+// history, and keep their availability, since other tools don't record it.
+func (i Importer) ImportUpdates(updates []dataaccess.ProfileUpdate) (Result, error) {
+	var r Result
+
+	tags := map[string]bool{}
+	for _, u := range updates {
+		for _, s := range u.Skills {
+			tags[s.Skill] = true
+		}
+
+		existing, found, err := i.DataAccess.GetProfile(u.EmailAddress)
+		if err != nil {
+			return r, err
+		}
+
+		if found {
+			u.Availability = existing.Availability
+			r.Merged++
+		} else {
+			r.Created++
+		}
+
+		if _, err := i.DataAccess.UpdateProfile(&u); err != nil {
+			return r, fmt.Errorf("failed to import %s: %v", u.EmailAddress, err)
+		}
+	}
+
+	r.Tags = len(tags)
+	return r, i.addSkillTags(tags)
+}
+
+func (i Importer) addSkillTags(tags map[string]bool) error {
+	var list []string
+	for t := range tags {
+		list = append(list, t)
+	}
+	sort.Strings(list)
+
+	if len(list) > 0 {
+		return i.DataAccess.AddSkillTags(list)
+	}
+	return nil
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%d profiles created, %d profiles merged, %d skill tags", r.Created, r.Merged, r.Tags)
+}
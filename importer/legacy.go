@@ -0,0 +1,158 @@
+// Package importer converts data exported from other systems, including
+// earlier versions of pill, into pill profiles.
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Snapshot is the skills of a set of people on a date.
+type Snapshot struct {
+	Date time.Time
+	Rows []Row
+}
+
+// A Row is a person's entry in a snapshot.
+type Row struct {
+	EmailAddress string
+	Name         string
+	Availability dataaccess.RagStatus
+	Skills       []dataaccess.Skill
+}
+
+var legacyFileDate = regexp.MustCompile(`(\d{4}-\d{2}-\d{2})`)
+
+// DateFromFileName returns the date in a file name, e.g. 2016-03-01 for
+// "skills-2016-03-01.csv". Legacy exports were named by the date they were
+// taken.
+func DateFromFileName(name string) (time.Time, bool) {
+	m := legacyFileDate.FindString(filepath.Base(name))
+	if m == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006-01-02", m)
+	return t, err == nil
+}
+
+// ParseLegacyCSV reads a skill matrix exported by earlier versions of pill.
+// The header row names the columns. The "email" column is required, the
+// "name" and "availability" columns are optional, and every other column is
+// a skill:
+//
+//	email,availability,go,java
+//	a-h@github.com,green,4/5,2/1
+//
+// Availability is red, amber or green, or 1 to 3. Skill cells are the level,
+// optionally followed by a slash and the interest, and are empty if the
+// person doesn't have the skill.
+func ParseLegacyCSV(r io.Reader, date time.Time) (Snapshot, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("importer: failed to read the header: %v", err)
+	}
+
+	emailColumn, nameColumn, availabilityColumn := -1, -1, -1
+	skillColumns := map[int]string{}
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "email", "emailaddress", "email address":
+			emailColumn = i
+		case "name":
+			nameColumn = i
+		case "availability":
+			availabilityColumn = i
+		default:
+			if tag := dataaccess.CleanTag(strings.TrimSpace(h)); tag != "" {
+				skillColumns[i] = tag
+			}
+		}
+	}
+	if emailColumn < 0 {
+		return Snapshot{}, fmt.Errorf("importer: the header must include an email column")
+	}
+
+	s := Snapshot{Date: date}
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("importer: line %d: %v", line, err)
+		}
+
+		row := Row{EmailAddress: strings.ToLower(strings.TrimSpace(record[emailColumn]))}
+		if !strings.Contains(row.EmailAddress, "@") {
+			return Snapshot{}, fmt.Errorf("importer: line %d: '%s' is not an email address", line, row.EmailAddress)
+		}
+		if nameColumn >= 0 {
+			row.Name = strings.TrimSpace(record[nameColumn])
+		}
+		if availabilityColumn >= 0 {
+			if row.Availability, err = parseAvailability(record[availabilityColumn]); err != nil {
+				return Snapshot{}, fmt.Errorf("importer: line %d: %v", line, err)
+			}
+		}
+
+		for i := range record {
+			tag, ok := skillColumns[i]
+			if !ok || strings.TrimSpace(record[i]) == "" {
+				continue
+			}
+			skill, err := parseSkill(tag, record[i])
+			if err != nil {
+				return Snapshot{}, fmt.Errorf("importer: line %d: %v", line, err)
+			}
+			row.Skills = append(row.Skills, skill)
+		}
+
+		s.Rows = append(s.Rows, row)
+	}
+
+	return s, nil
+}
+
+func parseAvailability(v string) (dataaccess.RagStatus, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "":
+		return 0, nil
+	case "red", "1":
+		return dataaccess.Red, nil
+	case "amber", "2":
+		return dataaccess.Amber, nil
+	case "green", "3":
+		return dataaccess.Green, nil
+	}
+	return 0, fmt.Errorf("'%s' is not an availability, use red, amber or green", v)
+}
+
+func parseSkill(tag string, v string) (dataaccess.Skill, error) {
+	parts := strings.SplitN(strings.TrimSpace(v), "/", 2)
+
+	level, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || level < dataaccess.NoviceLevel || level > dataaccess.MasterLevel {
+		return dataaccess.Skill{}, fmt.Errorf("'%s' is not a level from 1 to 5 for %s", v, tag)
+	}
+
+	skill := dataaccess.Skill{Skill: tag, Level: dataaccess.DreyfusLevel(level)}
+	if len(parts) == 2 {
+		interest, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || interest < dataaccess.StronglyDisagree || interest > dataaccess.StronglyAgree {
+			return dataaccess.Skill{}, fmt.Errorf("'%s' is not an interest from 1 to 5 for %s", v, tag)
+		}
+		skill.Interest = dataaccess.LikertScale(interest)
+	}
+	return skill, nil
+}
@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatLegacyCSVFilesCanBeParsed(t *testing.T) {
+	input := `Email,Name,Availability,Go,Java,C#
+A-H@github.com,Adrian,green,4/5,,2
+b@github.com,,2,1/1,3/4,
+`
+	date := time.Date(2016, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	s, err := ParseLegacyCSV(strings.NewReader(input), date)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+
+	expected := Snapshot{
+		Date: date,
+		Rows: []Row{
+			{
+				EmailAddress: "a-h@github.com",
+				Name:         "Adrian",
+				Availability: dataaccess.Green,
+				Skills: []dataaccess.Skill{
+					{Skill: "go", Level: dataaccess.ExpertLevel, Interest: dataaccess.StronglyAgree},
+					{Skill: "c#", Level: dataaccess.CompetentLevel},
+				},
+			},
+			{
+				EmailAddress: "b@github.com",
+				Availability: dataaccess.Amber,
+				Skills: []dataaccess.Skill{
+					{Skill: "go", Level: dataaccess.NoviceLevel, Interest: dataaccess.StronglyDisagree},
+					{Skill: "java", Level: dataaccess.ProficientLevel, Interest: dataaccess.Agree},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("Expected %+v, but got %+v.", expected, s)
+	}
+}
+
+func TestThatInvalidLegacyCSVFilesAreRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"missing email column", "name,go\nAdrian,1\n", "the header must include an email column"},
+		{"invalid email address", "email,go\nadrian,1\n", "line 2: 'adrian' is not an email address"},
+		{"invalid availability", "email,availability\na@github.com,blue\n", "line 2: 'blue' is not an availability"},
+		{"level out of range", "email,go\na@github.com,6\n", "line 2: '6' is not a level from 1 to 5 for go"},
+		{"interest out of range", "email,go\na@github.com,\nb@github.com,1/0\n", "line 3: '1/0' is not an interest from 1 to 5 for go"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseLegacyCSV(strings.NewReader(test.input), time.Now())
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected an error containing '%s', but got %v.", test.name, test.expected, err)
+		}
+	}
+}
+
+func TestThatTheDateIsReadFromLegacyFileNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected time.Time
+		ok       bool
+	}{
+		{"skills-2016-03-01.csv", time.Date(2016, time.March, 1, 0, 0, 0, 0, time.UTC), true},
+		{"/exports/2015-12-31/matrix.csv", time.Time{}, false},
+		{"/exports/2015-12-31-matrix.csv", time.Date(2015, time.December, 31, 0, 0, 0, 0, time.UTC), true},
+		{"skills.csv", time.Time{}, false},
+		{"skills-2016-13-01.csv", time.Time{}, false},
+	}
+
+	for _, test := range tests {
+		actual, ok := DateFromFileName(test.name)
+		if ok != test.ok || !actual.Equal(test.expected) {
+			t.Errorf("For '%s', expected %v (%t), but got %v (%t).", test.name, test.expected, test.ok, actual, ok)
+		}
+	}
+}
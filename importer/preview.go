@@ -0,0 +1,136 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A RowError is a problem with a row of an imported file.
+type RowError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ParseRows reads the export of the named Adapter, like the Adapter, but
+// skips the rows with problems and returns them all, rather than stopping at
+// the first. Rows for people outside the domain are problems. An error is
+// returned if the format is unknown or the file can't be read at all.
+func ParseRows(format string, r io.Reader, domain string) ([]dataaccess.ProfileUpdate, []RowError, error) {
+	f, ok := formats[format]
+	if !ok {
+		return nil, nil, fmt.Errorf("importer: unknown format '%s'", format)
+	}
+	return f.parseRows(r, domain)
+}
+
+// The ways importing changes a profile.
+const (
+	Create = "create"
+	Update = "update"
+	NoOp   = "none"
+)
+
+// A Change is what importing an update would do to a person's profile.
+type Change struct {
+	EmailAddress string `json:"emailAddress"`
+	// Action is create, update or none.
+	Action string `json:"action"`
+	// Name is the new name, if it changes.
+	Name    string        `json:"name,omitempty"`
+	Added   []string      `json:"added,omitempty"`
+	Changed []SkillChange `json:"changed,omitempty"`
+	Removed []string      `json:"removed,omitempty"`
+}
+
+// A SkillChange is a skill which is in a profile and the import, with a
+// different level or interest.
+type SkillChange struct {
+	Skill string           `json:"skill"`
+	From  dataaccess.Skill `json:"from"`
+	To    dataaccess.Skill `json:"to"`
+}
+
+// A Diff is what importing the updates would change, without changing
+// anything.
+type Diff struct {
+	Creates int      `json:"creates"`
+	Updates int      `json:"updates"`
+	NoOps   int      `json:"noOps"`
+	Changes []Change `json:"changes"`
+}
+
+// Diff compares the updates with the existing profiles, in the same way as
+// ImportUpdates applies them: the skills of existing profiles are replaced,
+// and names are only changed if the import has one.
+func (i Importer) Diff(updates []dataaccess.ProfileUpdate) (Diff, error) {
+	d := Diff{Changes: []Change{}}
+	for _, u := range updates {
+		existing, found, err := i.DataAccess.GetProfile(u.EmailAddress)
+		if err != nil {
+			return d, err
+		}
+		c := diffProfile(existing, found, u)
+		switch c.Action {
+		case Create:
+			d.Creates++
+		case Update:
+			d.Updates++
+		default:
+			d.NoOps++
+		}
+		d.Changes = append(d.Changes, c)
+	}
+	return d, nil
+}
+
+func diffProfile(existing *dataaccess.Profile, found bool, u dataaccess.ProfileUpdate) Change {
+	c := Change{EmailAddress: u.EmailAddress, Action: Create}
+	if !found {
+		for _, s := range u.Skills {
+			c.Added = append(c.Added, s.Skill)
+		}
+		if u.Name != nil {
+			c.Name = *u.Name
+		}
+		return c
+	}
+
+	if u.Name != nil && *u.Name != existing.Name {
+		c.Name = *u.Name
+	}
+	current := make(map[string]dataaccess.Skill)
+	for _, s := range existing.Skills {
+		current[s.Skill] = s
+	}
+	imported := make(map[string]bool)
+	for _, s := range u.Skills {
+		imported[s.Skill] = true
+		from, ok := current[s.Skill]
+		if !ok {
+			c.Added = append(c.Added, s.Skill)
+			continue
+		}
+		if from.Level != s.Level || from.Interest != s.Interest {
+			c.Changed = append(c.Changed, SkillChange{Skill: s.Skill, From: from, To: s})
+		}
+	}
+	for _, s := range existing.Skills {
+		if !imported[s.Skill] {
+			c.Removed = append(c.Removed, s.Skill)
+		}
+	}
+	sort.Strings(c.Removed)
+
+	c.Action = NoOp
+	if c.Name != "" || len(c.Added) > 0 || len(c.Changed) > 0 || len(c.Removed) > 0 {
+		c.Action = Update
+	}
+	return c
+}
@@ -0,0 +1,84 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatEveryProblemRowIsReported(t *testing.T) {
+	csv := "Email,Skill,Level\n" +
+		"a@github.com,Go,3\n" +
+		"adrian,Go,3\n" +
+		"b@github.com,Go,5\n" +
+		"c@example.com,Go,3\n" +
+		"b@github.com,Rust,2\n"
+
+	updates, problems, err := ParseRows("skillsbase", strings.NewReader(csv), "github.com")
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+
+	expectedProblems := []RowError{
+		{3, "'adrian' is not an email address"},
+		{4, "'5' is not a rating from 0 to 4"},
+		{5, "c@example.com is not in github.com"},
+	}
+	if !reflect.DeepEqual(problems, expectedProblems) {
+		t.Errorf("Expected problems %v, but got %v.", expectedProblems, problems)
+	}
+	if len(updates) != 2 || updates[0].EmailAddress != "a@github.com" || len(updates[1].Skills) != 1 || updates[1].Skills[0].Skill != "rust" {
+		t.Errorf("Expected the valid rows to be read, but got %+v.", updates)
+	}
+}
+
+func TestThatUnknownFormatsCantBeParsed(t *testing.T) {
+	if _, _, err := ParseRows("spreadsheet", strings.NewReader(""), "github.com"); err == nil {
+		t.Error("Expected an error for an unknown format.")
+	}
+}
+
+type stubDataAccess struct {
+	dataaccess.DataAccess
+	profiles map[string]dataaccess.Profile
+}
+
+func (da stubDataAccess) GetProfile(emailAddress string) (*dataaccess.Profile, bool, error) {
+	p, ok := da.profiles[emailAddress]
+	return &p, ok, nil
+}
+
+func TestThatImportsAreComparedWithExistingProfiles(t *testing.T) {
+	da := stubDataAccess{profiles: map[string]dataaccess.Profile{
+		"a@github.com": {EmailAddress: "a@github.com", Name: "A", Skills: []dataaccess.Skill{{Skill: "go", Level: 3}, {Skill: "java", Level: 2}}},
+		"b@github.com": {EmailAddress: "b@github.com", Name: "B", Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}},
+	}}
+	name := "C"
+	updates := []dataaccess.ProfileUpdate{
+		{EmailAddress: "a@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 4}, {Skill: "rust", Level: 1}}},
+		{EmailAddress: "b@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 3}}},
+		{EmailAddress: "c@github.com", Name: &name, Skills: []dataaccess.Skill{{Skill: "go", Level: 1}}},
+	}
+
+	d, err := NewImporter(da).Diff(updates)
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v.", err)
+	}
+
+	expected := Diff{Creates: 1, Updates: 1, NoOps: 1, Changes: []Change{
+		{
+			EmailAddress: "a@github.com",
+			Action:       Update,
+			Added:        []string{"rust"},
+			Changed:      []SkillChange{{Skill: "go", From: dataaccess.Skill{Skill: "go", Level: 3}, To: dataaccess.Skill{Skill: "go", Level: 4}}},
+			Removed:      []string{"java"},
+		},
+		{EmailAddress: "b@github.com", Action: NoOp},
+		{EmailAddress: "c@github.com", Action: Create, Name: "C", Added: []string{"go"}},
+	}}
+	if !reflect.DeepEqual(d, expected) {
+		t.Errorf("Expected %+v, but got %+v.", expected, d)
+	}
+}
@@ -0,0 +1,146 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A TaxonomyAdapter reads a taxonomy of skills, so that new tenants can
+// start with a categorised list of skill tags.
+type TaxonomyAdapter func(r io.Reader) ([]dataaccess.SkillTag, error)
+
+// Taxonomies are the supported taxonomy formats, by name.
+var Taxonomies = map[string]TaxonomyAdapter{
+	"esco": ParseESCO,
+	"onet": ParseONET,
+	"csv":  ParseTaxonomyCSV,
+}
+
+// ParseESCO reads the skills_en.csv file of the European Commission's ESCO
+// classification. Each skill is categorised by its type, e.g. "knowledge",
+// and its alternative labels, which ESCO separates with new lines, become
+// aliases. Obsolete skills are skipped.
+func ParseESCO(r io.Reader) ([]dataaccess.SkillTag, error) {
+	return taxonomyFormat{
+		name:     []string{"preferredlabel"},
+		category: []string{"skilltype"},
+		aliases:  []string{"altlabels"},
+		status:   []string{"status"},
+		split:    func(v string) []string { return strings.Split(v, "\n") },
+	}.parse(csv.NewReader(r))
+}
+
+// ParseONET reads the tab separated "Technology Skills" file of the O*NET
+// database, which has a row per occupation and technology. The technologies
+// are categorised by their commodity title, e.g. "Web platform development
+// software".
+func ParseONET(r io.Reader) ([]dataaccess.SkillTag, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = '\t'
+	cr.LazyQuotes = true
+	return taxonomyFormat{
+		name:     []string{"example"},
+		category: []string{"commodity title"},
+	}.parse(cr)
+}
+
+// ParseTaxonomyCSV reads a custom taxonomy, with name, category and aliases
+// columns. Aliases are separated by semicolons, e.g. "golang;go-lang".
+func ParseTaxonomyCSV(r io.Reader) ([]dataaccess.SkillTag, error) {
+	return taxonomyFormat{
+		name:     []string{"name", "skill", "tag"},
+		category: []string{"category"},
+		aliases:  []string{"aliases", "alias"},
+		split:    func(v string) []string { return strings.Split(v, ";") },
+	}.parse(csv.NewReader(r))
+}
+
+// A taxonomyFormat is a file with a row per skill. Each column is found by
+// any of its names, ignoring case. Rows which name the same skill are
+// combined.
+type taxonomyFormat struct {
+	name, category, aliases, status []string
+	split                           func(v string) []string
+}
+
+func (f taxonomyFormat) parse(cr *csv.Reader) ([]dataaccess.SkillTag, error) {
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("importer: failed to read the header: %v", err)
+	}
+
+	columns := map[string]int{}
+	for i, h := range header {
+		// Exports from Excel start with a byte order mark.
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	find := func(names []string) int {
+		for _, n := range names {
+			if i, ok := columns[n]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	nameColumn, categoryColumn, aliasesColumn, statusColumn := find(f.name), find(f.category), find(f.aliases), find(f.status)
+	if nameColumn < 0 {
+		return nil, fmt.Errorf("importer: the header must include a %s column", f.name[0])
+	}
+
+	cell := func(record []string, i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	tags := map[string]*dataaccess.SkillTag{}
+	aliases := map[string]map[string]bool{}
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("importer: line %d: %v", line, err)
+		}
+
+		name := dataaccess.CleanTag(cell(record, nameColumn))
+		if name == "" || strings.EqualFold(cell(record, statusColumn), "obsolete") {
+			continue
+		}
+		t, ok := tags[name]
+		if !ok {
+			t = &dataaccess.SkillTag{Name: name}
+			tags[name] = t
+			aliases[name] = map[string]bool{}
+		}
+		if category := strings.ToLower(cell(record, categoryColumn)); category != "" {
+			t.Category = category
+		}
+		if f.split == nil {
+			continue
+		}
+		for _, a := range f.split(cell(record, aliasesColumn)) {
+			if a = dataaccess.CleanTag(strings.TrimSpace(a)); a != "" && a != name && !aliases[name][a] {
+				aliases[name][a] = true
+				t.Aliases = append(t.Aliases, a)
+			}
+		}
+	}
+
+	op := make([]dataaccess.SkillTag, 0, len(tags))
+	for _, t := range tags {
+		op = append(op, *t)
+	}
+	sort.Slice(op, func(i, j int) bool { return op[i].Name < op[j].Name })
+	return op, nil
+}
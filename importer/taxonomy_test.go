@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatTaxonomiesAreConvertedToSkillTags(t *testing.T) {
+	tests := []struct {
+		format   string
+		input    string
+		expected []dataaccess.SkillTag
+	}{
+		{
+			format: "esco",
+			input: "conceptType,conceptUri,skillType,reuseLevel,preferredLabel,altLabels,status\n" +
+				"KnowledgeSkillCompetence,http://data.europa.eu/esco/skill/1,knowledge,cross-sector,JavaScript,\"JS\nECMAScript\",released\n" +
+				"KnowledgeSkillCompetence,http://data.europa.eu/esco/skill/2,skill/competence,sector-specific,Perl,,obsolete\n",
+			expected: []dataaccess.SkillTag{
+				{Name: "javascript", Category: "knowledge", Aliases: []string{"js", "ecmascript"}},
+			},
+		},
+		{
+			format: "onet",
+			input: "O*NET-SOC Code\tTitle\tExample\tCommodity Code\tCommodity Title\tHot Technology\n" +
+				"15-1252.00\tSoftware Developers\tReact\t43232408\tWeb platform development software\tY\n" +
+				"15-1254.00\tWeb Developers\tReact\t43232408\tWeb platform development software\tY\n" +
+				"15-1252.00\tSoftware Developers\tOracle Java\t43232405\tObject or component oriented development software\tY\n",
+			expected: []dataaccess.SkillTag{
+				{Name: "oracle-java", Category: "object or component oriented development software"},
+				{Name: "react", Category: "web platform development software"},
+			},
+		},
+		{
+			format: "csv",
+			input: "Name,Category,Aliases\n" +
+				"Go,Programming languages,golang; Go Lang\n" +
+				"Kubernetes,Platforms,k8s;kubernetes\n" +
+				",Platforms,\n",
+			expected: []dataaccess.SkillTag{
+				{Name: "go", Category: "programming languages", Aliases: []string{"golang", "go-lang"}},
+				{Name: "kubernetes", Category: "platforms", Aliases: []string{"k8s"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		actual, err := Taxonomies[test.format](strings.NewReader(test.input))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.format, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%s: expected %+v, but got %+v", test.format, test.expected, actual)
+		}
+	}
+}
+
+func TestThatTaxonomiesMustNameTheirSkills(t *testing.T) {
+	if _, err := ParseTaxonomyCSV(strings.NewReader("Category,Aliases\nPlatforms,k8s\n")); err == nil {
+		t.Error("expected an error when the taxonomy doesn't have a name column")
+	}
+}
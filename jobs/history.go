@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+)
+
+// A Run records a single execution of a job.
+type Run struct {
+	Job  string `json:"job"`
+	Host string `json:"host"`
+	// Slot is the time the run was scheduled for, or zero if it was started
+	// by Execute.
+	Slot     time.Time `json:"slot"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	// Error is the error message returned by the job, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Succeeded returns true if the run completed without error.
+func (r Run) Succeeded() bool {
+	return r.Error == ""
+}
+
+// History stores the record of job runs.
+type History interface {
+	Record(run Run) error
+	// List returns the most recent runs of the job, newest first.
+	List(job string, limit int) ([]Run, error)
+}
+
+// MemoryHistory is a History held in memory.
+type MemoryHistory struct {
+	mutex sync.Mutex
+	runs  []Run
+}
+
+// NewMemoryHistory creates an instance of the MemoryHistory.
+func NewMemoryHistory() *MemoryHistory {
+	return &MemoryHistory{}
+}
+
+// Record adds the run to the history.
+func (h *MemoryHistory) Record(run Run) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.runs = append(h.runs, run)
+	return nil
+}
+
+// List returns the most recent runs of the job, newest first.
+func (h *MemoryHistory) List(job string, limit int) ([]Run, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	var runs []Run
+	for i := len(h.runs) - 1; i >= 0 && len(runs) < limit; i-- {
+		if h.runs[i].Job == job {
+			runs = append(runs, h.runs[i])
+		}
+	}
+	return runs, nil
+}
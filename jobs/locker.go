@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Locker ensures that a job only runs on one replica at a time.
+type Locker interface {
+	// TryLock attempts to take the named lock for the duration of the ttl. It
+	// returns false if another owner holds the lock.
+	TryLock(name string, ttl time.Duration) (bool, error)
+	// Unlock releases the named lock.
+	Unlock(name string) error
+}
+
+// A MemoryLocker is a Locker for a single process.
+type MemoryLocker struct {
+	mutex sync.Mutex
+	locks map[string]time.Time
+}
+
+// NewMemoryLocker creates an instance of the MemoryLocker.
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{
+		locks: make(map[string]time.Time),
+	}
+}
+
+// TryLock takes the lock if it is not held, or has expired.
+func (l *MemoryLocker) TryLock(name string, ttl time.Duration) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if expires, ok := l.locks[name]; ok && time.Now().Before(expires) {
+		return false, nil
+	}
+
+	l.locks[name] = time.Now().Add(ttl)
+	return true, nil
+}
+
+// Unlock releases the lock.
+func (l *MemoryLocker) Unlock(name string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.locks, name)
+	return nil
+}
+
+// LockAccess is the part of the data access layer which manages locks.
+type LockAccess interface {
+	AcquireLock(name string, ttl time.Duration) (*dataaccess.Lock, bool, error)
+	ReleaseLock(lock *dataaccess.Lock) error
+}
+
+// A DataAccessLocker is a Locker which stores locks in the database, so that
+// they are shared between replicas.
+type DataAccessLocker struct {
+	da    LockAccess
+	mutex sync.Mutex
+	held  map[string]*dataaccess.Lock
+}
+
+// NewDataAccessLocker creates an instance of the DataAccessLocker.
+func NewDataAccessLocker(da LockAccess) *DataAccessLocker {
+	return &DataAccessLocker{
+		da:   da,
+		held: make(map[string]*dataaccess.Lock),
+	}
+}
+
+// TryLock takes the lock if it is not held by another owner.
+func (l *DataAccessLocker) TryLock(name string, ttl time.Duration) (bool, error) {
+	lock, acquired, err := l.da.AcquireLock(name, ttl)
+	if err != nil || !acquired {
+		return false, err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.held[name] = lock
+	return true, nil
+}
+
+// Unlock releases the lock, if it's held by this locker.
+func (l *DataAccessLocker) Unlock(name string) error {
+	l.mutex.Lock()
+	lock, ok := l.held[name]
+	delete(l.held, name)
+	l.mutex.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	err := l.da.ReleaseLock(lock)
+	if err == dataaccess.ErrLockNotHeld {
+		return nil
+	}
+	return err
+}
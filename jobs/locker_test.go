@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type fakeLockAccess struct {
+	locks    map[string]*dataaccess.Lock
+	released []*dataaccess.Lock
+}
+
+func (f *fakeLockAccess) AcquireLock(name string, ttl time.Duration) (*dataaccess.Lock, bool, error) {
+	if _, ok := f.locks[name]; ok {
+		return nil, false, nil
+	}
+	l := &dataaccess.Lock{Name: name, Owner: "test", Expires: time.Now().Add(ttl)}
+	f.locks[name] = l
+	return l, true, nil
+}
+
+func (f *fakeLockAccess) ReleaseLock(lock *dataaccess.Lock) error {
+	if f.locks[lock.Name] != lock {
+		return dataaccess.ErrLockNotHeld
+	}
+	delete(f.locks, lock.Name)
+	f.released = append(f.released, lock)
+	return nil
+}
+
+func TestThatTheDataAccessLockerReleasesTheLockItAcquired(t *testing.T) {
+	f := &fakeLockAccess{locks: make(map[string]*dataaccess.Lock)}
+	l := NewDataAccessLocker(f)
+
+	if ok, err := l.TryLock("job", time.Minute); !ok || err != nil {
+		t.Fatal("Expected to take the lock.", err)
+	}
+
+	if ok, _ := l.TryLock("job", time.Minute); ok {
+		t.Error("The lock should not be taken twice.")
+	}
+
+	if err := l.Unlock("job"); err != nil {
+		t.Error("Failed to unlock.", err)
+	}
+
+	if len(f.released) != 1 || f.released[0].Name != "job" {
+		t.Errorf("Expected the lock to be released in the data access layer, but released %v.", f.released)
+	}
+
+	if err := l.Unlock("job"); err != nil {
+		t.Error("Unlocking a lock which isn't held should be a no-op.", err)
+	}
+}
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"log"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MongoHistory is a History which stores runs in the "jobruns" collection,
+// where they expire after 30 days, see dataaccess.EnsureIndexes.
+type MongoHistory struct {
+	connectionString string
+	databaseName     string
+}
+
+// NewMongoHistory creates an instance of the MongoHistory.
+func NewMongoHistory(connectionString string, databaseName string) *MongoHistory {
+	return &MongoHistory{connectionString, databaseName}
+}
+
+// Record adds the run to the history.
+func (h MongoHistory) Record(run Run) error {
+	session, err := mgo.Dial(h.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return err
+	}
+	defer session.Close()
+
+	return session.DB(h.databaseName).C("jobruns").Insert(run)
+}
+
+// List returns the most recent runs of the job, newest first.
+func (h MongoHistory) List(job string, limit int) ([]Run, error) {
+	session, err := mgo.Dial(h.connectionString)
+	if err != nil {
+		log.Print("Failed to connect to MongoDB.", err)
+		return nil, err
+	}
+	defer session.Close()
+
+	var runs []Run
+	err = session.DB(h.databaseName).C("jobruns").Find(bson.M{"job": job}).Sort("-started").Limit(limit).All(&runs)
+	return runs, err
+}
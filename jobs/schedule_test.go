@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThatSchedulesCalculateTheNextRun(t *testing.T) {
+	// 2016-06-15 was a Wednesday.
+	after := time.Date(2016, 6, 15, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2016, 6, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2016, 6, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2016, 6, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2016, 6, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1", time.Date(2016, 6, 20, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2016, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"30 10,12 * * *", time.Date(2016, 6, 15, 12, 30, 0, 0, time.UTC)},
+		{"0 0 1 1 *", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2016, 6, 19, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2016, 6, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2016, 6, 15, 11, 0, 0, 0, time.UTC)},
+		{"@every 1h", time.Date(2016, 6, 15, 11, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		s, err := ParseSchedule(test.spec)
+		if err != nil {
+			t.Errorf("Failed to parse '%s'. %s", test.spec, err)
+			continue
+		}
+
+		actual := s.Next(after)
+
+		if !actual.Equal(test.expected) {
+			t.Errorf("For '%s', expected the next run at %s, but was %s.", test.spec, test.expected, actual)
+		}
+	}
+}
+
+func TestThatInvalidSchedulesAreRejected(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every 1ms",
+		"@every tuesday",
+	}
+
+	for _, spec := range specs {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("Expected '%s' to be rejected.", spec)
+		}
+	}
+}
+
+func TestThatImpossibleSchedulesHaveNoNextRun(t *testing.T) {
+	s := MustParseSchedule("0 0 30 2 *")
+
+	if next := s.Next(time.Now()); !next.IsZero() {
+		t.Errorf("The 30th of February should never occur, but the next run was %s.", next)
+	}
+}
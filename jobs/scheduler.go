@@ -0,0 +1,194 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultLockTTL is how long a job's lock is held if the job does not set
+// its own LockTTL. It should be longer than the job takes to run.
+const DefaultLockTTL = time.Hour
+
+// A Job is a named function which runs on a schedule.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+	LockTTL  time.Duration
+}
+
+// The Scheduler runs jobs on their schedules. Before a job runs, the
+// Scheduler takes a lock named after the job and the time the run was
+// scheduled for, so that when several replicas are running, only one of them
+// executes each run.
+type Scheduler struct {
+	Locker  Locker
+	History History
+	jobs    []*Job
+	host    string
+	now     func() time.Time
+}
+
+// NewScheduler creates an instance of the Scheduler.
+func NewScheduler(locker Locker, history History) *Scheduler {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return &Scheduler{
+		Locker:  locker,
+		History: history,
+		host:    host,
+		now:     time.Now,
+	}
+}
+
+// Add registers a job which runs according to the spec, see ParseSchedule.
+func (s *Scheduler) Add(name string, spec string, run func(ctx context.Context) error) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+
+	s.AddJob(&Job{Name: name, Schedule: schedule, Run: run})
+	return nil
+}
+
+// AddJob registers a job.
+func (s *Scheduler) AddJob(job *Job) {
+	if job.LockTTL == 0 {
+		job.LockTTL = DefaultLockTTL
+	}
+	s.jobs = append(s.jobs, job)
+}
+
+// Jobs returns the registered jobs.
+func (s *Scheduler) Jobs() []*Job {
+	return s.jobs
+}
+
+// Run executes the jobs on their schedules until the context is cancelled,
+// then waits for running jobs to complete.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func(job *Job) {
+			defer wg.Done()
+			s.schedule(ctx, job)
+		}(job)
+	}
+
+	wg.Wait()
+}
+
+func (s *Scheduler) schedule(ctx context.Context, job *Job) {
+	for {
+		next := job.Schedule.Next(s.now())
+		if next.IsZero() {
+			log.Printf("The job %s has no future runs scheduled.", job.Name)
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.executeSlot(ctx, job, next)
+		}
+	}
+}
+
+// executeSlot runs the job for the time it was scheduled for. The lock for
+// the slot isn't released when the run finishes, it expires after the job's
+// LockTTL, so that a replica whose clock is behind, and fires after the run
+// has finished, doesn't run it again.
+func (s *Scheduler) executeSlot(ctx context.Context, job *Job, slot time.Time) (ran bool, err error) {
+	name := slotLockName(job.Name, slot)
+	locked, err := s.Locker.TryLock(name, job.LockTTL)
+	if err != nil {
+		log.Printf("Failed to take the lock for the %s run of job %s. %s", slot.Format(time.RFC3339), job.Name, err)
+		return false, err
+	}
+
+	if !locked {
+		log.Printf("Skipping the %s run of job %s, because another replica has run it.", slot.Format(time.RFC3339), job.Name)
+		return false, nil
+	}
+
+	return s.execute(ctx, job, slot)
+}
+
+func slotLockName(job string, slot time.Time) string {
+	return job + "@" + slot.UTC().Format(time.RFC3339)
+}
+
+// Execute runs the job immediately, if the job's lock can be taken, and
+// records the run in the history. It returns false if the job was skipped
+// because another replica holds the lock.
+func (s *Scheduler) Execute(ctx context.Context, job *Job) (ran bool, err error) {
+	return s.execute(ctx, job, time.Time{})
+}
+
+// execute runs the job while holding the job's lock, so that runs of the
+// same job never overlap, even when a run takes longer than the interval.
+func (s *Scheduler) execute(ctx context.Context, job *Job, slot time.Time) (ran bool, err error) {
+	locked, err := s.Locker.TryLock(job.Name, job.LockTTL)
+	if err != nil {
+		log.Printf("Failed to take the lock for job %s. %s", job.Name, err)
+		return false, err
+	}
+
+	if !locked {
+		log.Printf("Skipping job %s, because it is locked by another replica.", job.Name)
+		return false, nil
+	}
+
+	defer func() {
+		if err := s.Locker.Unlock(job.Name); err != nil {
+			log.Printf("Failed to release the lock for job %s. %s", job.Name, err)
+		}
+	}()
+
+	run := Run{
+		Job:     job.Name,
+		Host:    s.host,
+		Slot:    slot,
+		Started: s.now(),
+	}
+
+	log.Printf("Running job %s.", job.Name)
+	err = runSafely(ctx, job)
+	run.Finished = s.now()
+
+	if err != nil {
+		log.Printf("The job %s failed. %s", job.Name, err)
+		run.Error = err.Error()
+	}
+
+	if recordErr := s.History.Record(run); recordErr != nil {
+		log.Printf("Failed to record the run of job %s. %s", job.Name, recordErr)
+	}
+
+	return true, err
+}
+
+// runSafely converts a panic in a job into an error, so that one job can't
+// stop the scheduler.
+func runSafely(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("jobs: the job %s panicked: %v", job.Name, r)
+		}
+	}()
+
+	return job.Run(ctx)
+}
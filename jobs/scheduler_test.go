@@ -0,0 +1,165 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestThatJobsAreSkippedWhenLockedByAnotherReplica(t *testing.T) {
+	locker := NewMemoryLocker()
+	history := NewMemoryHistory()
+	s := NewScheduler(locker, history)
+
+	runs := 0
+	job := &Job{Name: "test", LockTTL: time.Minute, Run: func(ctx context.Context) error {
+		runs++
+		return nil
+	}}
+
+	locker.TryLock("test", time.Minute)
+
+	ran, err := s.Execute(context.Background(), job)
+
+	if ran || runs != 0 || err != nil {
+		t.Errorf("The job should have been skipped, ran: %t, runs: %d, err: %v", ran, runs, err)
+	}
+
+	locker.Unlock("test")
+
+	ran, err = s.Execute(context.Background(), job)
+
+	if !ran || runs != 1 || err != nil {
+		t.Errorf("The job should have run once the lock was released, ran: %t, runs: %d, err: %v", ran, runs, err)
+	}
+
+	if ok, _ := locker.TryLock("test", time.Minute); !ok {
+		t.Error("The lock should be released after the job has run.")
+	}
+}
+
+func TestThatJobRunsAreRecorded(t *testing.T) {
+	history := NewMemoryHistory()
+	s := NewScheduler(NewMemoryLocker(), history)
+
+	s.Execute(context.Background(), &Job{Name: "ok", Run: func(ctx context.Context) error { return nil }})
+	s.Execute(context.Background(), &Job{Name: "fails", Run: func(ctx context.Context) error { return errors.New("failed") }})
+	s.Execute(context.Background(), &Job{Name: "panics", Run: func(ctx context.Context) error { panic("oops") }})
+
+	tests := []struct {
+		job       string
+		succeeded bool
+	}{
+		{"ok", true},
+		{"fails", false},
+		{"panics", false},
+	}
+
+	for _, test := range tests {
+		runs, _ := history.List(test.job, 10)
+
+		if len(runs) != 1 {
+			t.Errorf("Expected one run of %s to be recorded, but found %d.", test.job, len(runs))
+			continue
+		}
+
+		if runs[0].Succeeded() != test.succeeded {
+			t.Errorf("Expected the run of %s to have succeeded: %t, but the error was '%s'.", test.job, test.succeeded, runs[0].Error)
+		}
+	}
+}
+
+func TestThatTheSchedulerStopsWhenTheContextIsCancelled(t *testing.T) {
+	s := NewScheduler(NewMemoryLocker(), NewMemoryHistory())
+
+	ran := make(chan bool, 10)
+	s.Add("frequent", "@every 1s", func(ctx context.Context) error {
+		ran <- true
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan bool)
+	go func() {
+		s.Run(ctx)
+		stopped <- true
+	}()
+
+	select {
+	case <-ran:
+	case <-time.After(3 * time.Second):
+		t.Fatal("The job should have run within 3 seconds.")
+	}
+
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("The scheduler should stop when the context is cancelled.")
+	}
+}
+
+func TestThatEachScheduledRunOnlyHappensOnce(t *testing.T) {
+	locker := NewMemoryLocker()
+	history := NewMemoryHistory()
+	// The replicas share the lock and history, but one's clock is behind, so
+	// their timers fire at different times for the same slot.
+	replicas := []*Scheduler{NewScheduler(locker, history), NewScheduler(locker, history)}
+
+	runs := 0
+	job := &Job{Name: "test", LockTTL: time.Minute, Run: func(ctx context.Context) error {
+		runs++
+		return nil
+	}}
+
+	slot := time.Date(2016, 1, 1, 2, 0, 0, 0, time.UTC)
+	for i, s := range replicas {
+		ran, err := s.executeSlot(context.Background(), job, slot)
+		if ran != (i == 0) || err != nil {
+			t.Errorf("Expected only the first replica to run the slot, but replica %d ran: %t, err: %v", i, ran, err)
+		}
+	}
+
+	if ran, _ := replicas[1].executeSlot(context.Background(), job, slot.Add(time.Hour)); !ran {
+		t.Error("Expected the next slot to run.")
+	}
+
+	if runs != 2 {
+		t.Errorf("Expected a run for each slot, but there were %d.", runs)
+	}
+
+	recorded, _ := history.List("test", 10)
+	if len(recorded) != 2 || !recorded[0].Slot.Equal(slot.Add(time.Hour)) || !recorded[1].Slot.Equal(slot) {
+		t.Errorf("Expected the runs to record their slots, but were %v.", recorded)
+	}
+}
+
+func TestThatReplicasStartedAtDifferentTimesShareTheirSlots(t *testing.T) {
+	locker := NewMemoryLocker()
+	history := NewMemoryHistory()
+
+	runs := 0
+	job := &Job{Name: "test", Schedule: MustParseSchedule("@every 15m"), LockTTL: time.Minute, Run: func(ctx context.Context) error {
+		runs++
+		return nil
+	}}
+
+	// The replicas were started minutes apart, and their clocks differ.
+	started := []time.Time{
+		time.Date(2016, 1, 1, 2, 1, 10, 0, time.UTC),
+		time.Date(2016, 1, 1, 2, 7, 45, 500, time.UTC),
+	}
+	for _, now := range started {
+		now := now
+		s := NewScheduler(locker, history)
+		s.now = func() time.Time { return now }
+
+		s.executeSlot(context.Background(), job, job.Schedule.Next(s.now()))
+	}
+
+	if runs != 1 {
+		t.Errorf("Expected the slot to run once, but there were %d runs.", runs)
+	}
+}
@@ -0,0 +1,191 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned when a token is malformed, or its signature
+	// can't be verified.
+	ErrInvalidToken = errors.New("jwt: the token is invalid")
+	// ErrExpiredToken is returned when the token's expiry has passed.
+	ErrExpiredToken = errors.New("jwt: the token has expired")
+)
+
+// Claims are the contents of an access token issued by pill.
+type Claims struct {
+	Issuer   string   `json:"iss"`
+	Subject  string   `json:"sub"`
+	Audience string   `json:"aud,omitempty"`
+	IssuedAt int64    `json:"iat"`
+	Expires  int64    `json:"exp"`
+	ID       string   `json:"jti"`
+	Email    string   `json:"email"`
+	Domain   string   `json:"domain"`
+	Roles    []string `json:"roles"`
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+type signingKey struct {
+	id         string
+	privateKey ed25519.PrivateKey
+}
+
+// deriveSigningKey creates an Ed25519 key from the configuration key, so
+// that every replica signs with the same key without storing another
+// secret, and rotating the configuration key rotates the signing key.
+func deriveSigningKey(key []byte) signingKey {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("pill jwt signing key"))
+	privateKey := ed25519.NewKeyFromSeed(mac.Sum(nil))
+
+	fingerprint := sha256.Sum256(privateKey.Public().(ed25519.PublicKey))
+
+	return signingKey{
+		id:         hex.EncodeToString(fingerprint[:8]),
+		privateKey: privateKey,
+	}
+}
+
+// An Issuer creates short-lived access tokens signed with EdDSA.
+type Issuer struct {
+	// Name is used as the "iss" claim.
+	Name string
+	// TTL is how long tokens are valid for.
+	TTL  time.Duration
+	keys []signingKey
+	now  func() time.Time
+}
+
+// NewIssuer creates an Issuer which signs tokens with a key derived from the
+// current key, and verifies tokens signed with keys derived from the current
+// or previous keys.
+func NewIssuer(name string, currentKey []byte, previousKeys ...[]byte) *Issuer {
+	i := &Issuer{
+		Name: name,
+		TTL:  15 * time.Minute,
+		now:  time.Now,
+	}
+
+	for _, key := range append([][]byte{currentKey}, previousKeys...) {
+		i.keys = append(i.keys, deriveSigningKey(key))
+	}
+
+	return i
+}
+
+// Issue creates a token for the user.
+func (i *Issuer) Issue(email string, domain string, roles []string, audience string) (token string, claims Claims, err error) {
+	id := make([]byte, 16)
+	if _, err = rand.Read(id); err != nil {
+		return "", claims, err
+	}
+
+	now := i.now()
+	claims = Claims{
+		Issuer:   i.Name,
+		Subject:  email,
+		Audience: audience,
+		IssuedAt: now.Unix(),
+		Expires:  now.Add(i.TTL).Unix(),
+		ID:       hex.EncodeToString(id),
+		Email:    email,
+		Domain:   domain,
+		Roles:    roles,
+	}
+
+	key := i.keys[0]
+	h, err := encodeSegment(header{Algorithm: "EdDSA", Type: "JWT", KeyID: key.id})
+	if err != nil {
+		return "", claims, err
+	}
+
+	c, err := encodeSegment(claims)
+	if err != nil {
+		return "", claims, err
+	}
+
+	signingInput := h + "." + c
+	signature := ed25519.Sign(key.privateKey, []byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), claims, nil
+}
+
+func encodeSegment(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// Verify checks the token's signature and expiry, and returns its claims.
+func (i *Issuer) Verify(token string) (Claims, error) {
+	var claims Claims
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return claims, err
+	}
+
+	if h.Algorithm != "EdDSA" {
+		return claims, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+
+	verified := false
+	for _, key := range i.keys {
+		if key.id == h.KeyID && ed25519.Verify(key.privateKey.Public().(ed25519.PublicKey), []byte(parts[0]+"."+parts[1]), signature) {
+			verified = true
+			break
+		}
+	}
+
+	if !verified {
+		return claims, ErrInvalidToken
+	}
+
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return claims, err
+	}
+
+	if i.now().Unix() >= claims.Expires {
+		return claims, ErrExpiredToken
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,98 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	key1 = []byte("0123456789abcdef0123456789abcdef")
+	key2 = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func TestThatIssuedTokensCanBeVerified(t *testing.T) {
+	i := NewIssuer("pill", key1)
+
+	token, _, err := i.Issue("a-h@github.com", "github.com", []string{"user"}, "")
+	if err != nil {
+		t.Fatal("Failed to issue the token.", err)
+	}
+
+	claims, err := i.Verify(token)
+	if err != nil {
+		t.Fatal("Failed to verify the token.", err)
+	}
+
+	if claims.Email != "a-h@github.com" || claims.Domain != "github.com" || len(claims.Roles) != 1 {
+		t.Errorf("The claims were not as expected: %v", claims)
+	}
+}
+
+func TestThatTokensSignedWithPreviousKeysAreVerified(t *testing.T) {
+	token, _, _ := NewIssuer("pill", key1).Issue("a-h@github.com", "github.com", nil, "")
+
+	if _, err := NewIssuer("pill", key2, key1).Verify(token); err != nil {
+		t.Error("A token signed with a previous key should be valid.", err)
+	}
+
+	if _, err := NewIssuer("pill", key2).Verify(token); err != ErrInvalidToken {
+		t.Errorf("A token signed with an unknown key should be invalid, but the error was %v", err)
+	}
+}
+
+func TestThatExpiredTokensAreRejected(t *testing.T) {
+	now := time.Now()
+	i := NewIssuer("pill", key1)
+	i.now = func() time.Time { return now }
+
+	token, _, _ := i.Issue("a-h@github.com", "github.com", nil, "")
+	now = now.Add(i.TTL)
+
+	if _, err := i.Verify(token); err != ErrExpiredToken {
+		t.Errorf("Expected the token to have expired, but the error was %v", err)
+	}
+}
+
+func TestThatModifiedTokensAreRejected(t *testing.T) {
+	i := NewIssuer("pill", key1)
+	token, _, _ := i.Issue("a-h@github.com", "github.com", []string{"user"}, "")
+	parts := strings.Split(token, ".")
+
+	elevated, _ := encodeSegment(Claims{Email: "a-h@github.com", Roles: []string{"administrator"}, Expires: time.Now().Add(time.Hour).Unix()})
+	none, _ := encodeSegment(header{Algorithm: "none", Type: "JWT"})
+
+	tests := []string{
+		"",
+		"a.b",
+		parts[0] + "." + elevated + "." + parts[2],
+		none + "." + parts[1] + ".",
+	}
+
+	for _, test := range tests {
+		if _, err := i.Verify(test); err != ErrInvalidToken {
+			t.Errorf("Expected the token '%s' to be invalid, but the error was %v", test, err)
+		}
+	}
+}
+
+func TestThatTheJWKSCanVerifyIssuedTokens(t *testing.T) {
+	i := NewIssuer("pill", key2, key1)
+	token, _, _ := i.Issue("a-h@github.com", "github.com", nil, "")
+	parts := strings.Split(token, ".")
+
+	jwks := i.JWKS()
+
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("Expected the current and previous keys to be published, but found %d keys.", len(jwks.Keys))
+	}
+
+	publicKey, _ := base64.RawURLEncoding.DecodeString(jwks.Keys[0].X)
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+
+	if !ed25519.Verify(ed25519.PublicKey(publicKey), []byte(parts[0]+"."+parts[1]), signature) {
+		t.Error("The published key should verify the token's signature.")
+	}
+}
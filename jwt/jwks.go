@@ -0,0 +1,40 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+)
+
+// A JSONWebKey is the public part of a signing key, see RFC 8037.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+}
+
+// A JSONWebKeySet is served so that other services can verify tokens issued
+// by pill.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys which can verify tokens issued by the Issuer.
+func (i *Issuer) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+
+	for _, key := range i.keys {
+		set.Keys = append(set.Keys, JSONWebKey{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key.privateKey.Public().(ed25519.PublicKey)),
+			KeyID:     key.id,
+			Use:       "sig",
+			Algorithm: "EdDSA",
+		})
+	}
+
+	return set
+}
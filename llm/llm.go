@@ -0,0 +1,122 @@
+// Package llm sends prompts to a large language model, through a pluggable
+// Provider, so that features such as natural language search don't depend
+// on a particular vendor.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// A Provider completes a prompt, following the system instructions.
+type Provider interface {
+	Complete(ctx context.Context, system string, prompt string) (string, error)
+}
+
+// Open returns the provider described by the value, the http or https URL of
+// an OpenAI compatible chat completions API, e.g.
+// https://api.openai.com/v1/chat/completions or
+// http://localhost:11434/v1/chat/completions for Ollama, authenticated with
+// the key in the LLM_API_KEY environment variable.
+func Open(value string, model string) (Provider, error) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("llm: unsupported provider '%s', use the URL of a chat completions API", value)
+	}
+	return NewAPIProvider(value, model, os.Getenv("LLM_API_KEY")), nil
+}
+
+// An APIProvider completes prompts with an OpenAI compatible chat
+// completions API.
+type APIProvider struct {
+	url    string
+	model  string
+	key    string
+	Client *http.Client
+}
+
+// NewAPIProvider creates an APIProvider which posts to the URL. If the key
+// isn't empty, it's sent as a bearer token.
+func NewAPIProvider(url string, model string, key string) *APIProvider {
+	return &APIProvider{url, model, key, &http.Client{Timeout: time.Minute}}
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string    `json:"model,omitempty"`
+	Messages []message `json:"messages"`
+	// Temperature is zero, so that the same prompt gets the same answer as
+	// far as the model allows.
+	Temperature float64 `json:"temperature"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message message `json:"message"`
+	} `json:"choices"`
+}
+
+// Complete posts the prompt to the API, and returns the first choice.
+func (p APIProvider) Complete(ctx context.Context, system string, prompt string) (string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model:    p.model,
+		Messages: []message{{"system", system}, {"user", prompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("Accept", "application/json")
+	if p.key != "" {
+		req.Header.Set("Authorization", "Bearer "+p.key)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return "", fmt.Errorf("llm: the API returned %s: %s", resp.Status, body)
+	}
+
+	var cr chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cr); err != nil {
+		return "", err
+	}
+	if len(cr.Choices) == 0 {
+		return "", errors.New("llm: the API didn't return a completion")
+	}
+	return strings.TrimSpace(cr.Choices[0].Message.Content), nil
+}
+
+// JSON decodes the JSON object in a completion into v. Models often wrap
+// JSON in a Markdown code block, or explain it, so only the text from the
+// first { to the last } is decoded.
+func JSON(completion string, v interface{}) error {
+	start, end := strings.Index(completion, "{"), strings.LastIndex(completion, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("llm: the completion doesn't contain a JSON object: %q", completion)
+	}
+	return json.Unmarshal([]byte(completion[start:end+1]), v)
+}
@@ -0,0 +1,36 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestThatPromptsAreCompletedByTheAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.Header.Get("Authorization") != "Bearer key" || req.Model != "small" || len(req.Messages) != 2 || req.Messages[0].Role != "system" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("{\"choices\": [{\"message\": {\"role\": \"assistant\", \"content\": \"Here you go:\\n```json\\n{\\\"skill\\\": \\\"go\\\"}\\n```\"}}]}"))
+	}))
+	defer server.Close()
+
+	completion, err := NewAPIProvider(server.URL, "small", "key").Complete(context.Background(), "Answer in JSON.", "Which skill?")
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	var v struct {
+		Skill string `json:"skill"`
+	}
+	if err := JSON(completion, &v); err != nil || v.Skill != "go" {
+		t.Errorf("Expected the JSON in the completion to be decoded, but received %+v, %v from %q", v, err, completion)
+	}
+	if err := JSON("I don't know.", &v); err == nil {
+		t.Error("Expected an error for a completion without JSON.")
+	}
+}
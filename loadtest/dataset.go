@@ -0,0 +1,144 @@
+// Package loadtest generates reproducible datasets and load against pill's
+// data access, so that performance can be compared between releases.
+package loadtest
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Dataset describes a set of generated profiles. The same Dataset always
+// generates the same profiles.
+type Dataset struct {
+	Seed    int64
+	Tenants int
+	// Profiles is the number of profiles in each tenant.
+	Profiles int
+	// Skills is the number of distinct skill tags.
+	Skills int
+	// SkillsPerProfile is the number of skills each person has.
+	SkillsPerProfile int
+	// History is the number of history entries each profile has.
+	History int
+}
+
+// DefaultDataset is a mid sized installation.
+var DefaultDataset = Dataset{
+	Seed:             1,
+	Tenants:          5,
+	Profiles:         500,
+	Skills:           300,
+	SkillsPerProfile: 15,
+	History:          10,
+}
+
+// Tenant returns the domain of the tenant.
+func (d Dataset) Tenant(i int) string {
+	return fmt.Sprintf("tenant%d.example.com", i)
+}
+
+// EmailAddress returns the email address of a profile.
+func (d Dataset) EmailAddress(tenant, i int) string {
+	return fmt.Sprintf("person%d@%s", i, d.Tenant(tenant))
+}
+
+// Skill returns the name of a skill tag.
+func (d Dataset) Skill(i int) string {
+	return fmt.Sprintf("skill%d", i)
+}
+
+// SkillTags returns the names of the skill tags.
+func (d Dataset) SkillTags() []string {
+	tags := make([]string, d.Skills)
+	for i := range tags {
+		tags[i] = d.Skill(i)
+	}
+	return tags
+}
+
+// Generate returns the profiles. Skills are chosen from a Zipf distribution,
+// so that a few skills are common and most are rare, as they are in real
+// tenants.
+func (d Dataset) Generate() []dataaccess.Profile {
+	r := rand.New(rand.NewSource(d.Seed))
+	zipf := rand.NewZipf(r, 1.2, 1, uint64(d.Skills-1))
+	start := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	var profiles []dataaccess.Profile
+	for t := 0; t < d.Tenants; t++ {
+		for i := 0; i < d.Profiles; i++ {
+			p := dataaccess.Profile{
+				EmailAddress: d.EmailAddress(t, i),
+				Domain:       d.Tenant(t),
+				Name:         fmt.Sprintf("Person %d", i),
+				Availability: dataaccess.RagStatus(r.Intn(3) + 1),
+				Version:      d.History + 1,
+			}
+			if i > 0 {
+				p.Manager = d.EmailAddress(t, r.Intn(i))
+			}
+
+			date := start
+			for h := 0; h <= d.History; h++ {
+				date = date.Add(time.Duration(r.Intn(30*24)+1) * time.Hour)
+				skills := d.skills(r, zipf)
+				if h == d.History {
+					p.Skills = skills
+					p.LastUpdated = date
+					break
+				}
+				p.SkillsHistory = append(p.SkillsHistory, dataaccess.SkillLevel{Date: date, Skills: skills})
+			}
+			p.AvailabilityChanged = p.LastUpdated
+
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles
+}
+
+func (d Dataset) skills(r *rand.Rand, zipf *rand.Zipf) []dataaccess.Skill {
+	n := d.SkillsPerProfile
+	if n > d.Skills {
+		n = d.Skills
+	}
+
+	seen := map[uint64]bool{}
+	skills := make([]dataaccess.Skill, 0, n)
+	for len(skills) < n {
+		s := zipf.Uint64()
+		if seen[s] {
+			// Fall back to a uniform choice, so that profiles with many skills
+			// don't spin on the common ones.
+			s = uint64(r.Intn(d.Skills))
+			if seen[s] {
+				continue
+			}
+		}
+		seen[s] = true
+		skills = append(skills, dataaccess.Skill{
+			Skill:    d.Skill(int(s)),
+			Level:    dataaccess.DreyfusLevel(r.Intn(dataaccess.MasterLevel) + 1),
+			Interest: dataaccess.LikertScale(r.Intn(dataaccess.StronglyAgree) + 1),
+		})
+	}
+	return skills
+}
+
+// Write stores the dataset in the DataAccess.
+func (d Dataset) Write(da dataaccess.DataAccess) (int, error) {
+	if err := da.AddSkillTags(d.SkillTags()); err != nil {
+		return 0, err
+	}
+
+	profiles := d.Generate()
+	for i := range profiles {
+		if err := da.ImportProfile(&profiles[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(profiles), nil
+}
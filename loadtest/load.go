@@ -0,0 +1,191 @@
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// An Operation is a call made by the load generator.
+type Operation string
+
+const (
+	// GetProfile reads a random profile.
+	GetProfile Operation = "GetProfile"
+	// UpdateProfile replaces the skills of a random profile.
+	UpdateProfile Operation = "UpdateProfile"
+	// ListProfiles lists the profiles of a random tenant.
+	ListProfiles Operation = "ListProfiles"
+	// Search lists a random tenant's profiles and finds the people with a
+	// random skill, as the report does when browsing for who knows a skill.
+	Search Operation = "Search"
+)
+
+// DefaultMix is the proportion of each operation, which is mostly reads.
+var DefaultMix = map[Operation]int{
+	GetProfile:    50,
+	ListProfiles:  15,
+	Search:        30,
+	UpdateProfile: 5,
+}
+
+// Options configure a load test.
+type Options struct {
+	Dataset     Dataset
+	Concurrency int
+	Duration    time.Duration
+	// Mix is the relative frequency of each operation.
+	Mix map[Operation]int
+}
+
+// A Report summarises the latency of each operation.
+type Report struct {
+	Duration time.Duration
+	Stats    map[Operation]*Stats
+}
+
+// Stats are the latencies of an operation.
+type Stats struct {
+	Errors    int
+	latencies []time.Duration
+}
+
+// Count is the number of calls made.
+func (s *Stats) Count() int {
+	return len(s.latencies)
+}
+
+// Percentile returns the latency which p percent of calls were faster than.
+func (s *Stats) Percentile(p float64) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(s.latencies)-1) * p / 100)
+	return s.latencies[i]
+}
+
+func (r Report) String() string {
+	var ops []string
+	for op := range r.Stats {
+		ops = append(ops, string(op))
+	}
+	sort.Strings(ops)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-14s %8s %8s %10s %10s %10s %8s\n", "operation", "calls", "per sec", "p50", "p95", "p99", "errors")
+	for _, op := range ops {
+		s := r.Stats[Operation(op)]
+		fmt.Fprintf(&sb, "%-14s %8d %8.1f %10v %10v %10v %8d\n", op, s.Count(), float64(s.Count())/r.Duration.Seconds(),
+			s.Percentile(50).Round(time.Microsecond), s.Percentile(95).Round(time.Microsecond), s.Percentile(99).Round(time.Microsecond), s.Errors)
+	}
+	return sb.String()
+}
+
+// Run calls the DataAccess from Concurrency goroutines until the Duration has
+// passed or the context is cancelled. The dataset must already have been
+// written with Dataset.Write.
+func Run(ctx context.Context, da dataaccess.DataAccess, o Options) Report {
+	ctx, cancel := context.WithTimeout(ctx, o.Duration)
+	defer cancel()
+
+	mix := o.Mix
+	if len(mix) == 0 {
+		mix = DefaultMix
+	}
+	var choices []Operation
+	for op, n := range mix {
+		for i := 0; i < n; i++ {
+			choices = append(choices, op)
+		}
+	}
+	sort.Slice(choices, func(i, j int) bool { return choices[i] < choices[j] })
+
+	var m sync.Mutex
+	r := Report{Stats: map[Operation]*Stats{}}
+	for op := range mix {
+		r.Stats[op] = &Stats{}
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < o.Concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(o.Dataset.Seed + int64(w)))
+			for ctx.Err() == nil {
+				op := choices[rnd.Intn(len(choices))]
+				began := time.Now()
+				err := call(da, o.Dataset, op, rnd)
+				elapsed := time.Since(began)
+
+				m.Lock()
+				s := r.Stats[op]
+				s.latencies = append(s.latencies, elapsed)
+				if err != nil {
+					s.Errors++
+				}
+				m.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+	r.Duration = time.Since(start)
+
+	for _, s := range r.Stats {
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	}
+	return r
+}
+
+func call(da dataaccess.DataAccess, d Dataset, op Operation, r *rand.Rand) error {
+	tenant := r.Intn(d.Tenants)
+	emailAddress := d.EmailAddress(tenant, r.Intn(d.Profiles))
+
+	switch op {
+	case GetProfile:
+		_, _, err := da.GetProfile(emailAddress)
+		return err
+	case ListProfiles:
+		_, err := da.ListProfiles(emailAddress)
+		return err
+	case Search:
+		profiles, err := da.ListProfiles(emailAddress)
+		if err != nil {
+			return err
+		}
+		FindSkill(profiles, d.Skill(r.Intn(d.Skills)))
+		return nil
+	case UpdateProfile:
+		skills := make([]dataaccess.Skill, d.SkillsPerProfile)
+		for i := range skills {
+			skills[i] = dataaccess.Skill{Skill: d.Skill(r.Intn(d.Skills)), Level: dataaccess.DreyfusLevel(r.Intn(5) + 1)}
+		}
+		_, err := da.UpdateProfile(&dataaccess.ProfileUpdate{EmailAddress: emailAddress, Skills: skills, Availability: dataaccess.Green})
+		return err
+	}
+	return fmt.Errorf("loadtest: unknown operation %s", op)
+}
+
+// FindSkill returns the profiles with the skill, most skilled first.
+func FindSkill(profiles []dataaccess.Profile, skill string) []dataaccess.Profile {
+	var found []dataaccess.Profile
+	levels := map[string]dataaccess.DreyfusLevel{}
+	for _, p := range profiles {
+		for _, s := range p.Skills {
+			if s.Skill == skill {
+				found = append(found, p)
+				levels[p.EmailAddress] = s.Level
+				break
+			}
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return levels[found[i].EmailAddress] > levels[found[j].EmailAddress] })
+	return found
+}
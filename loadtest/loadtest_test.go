@@ -0,0 +1,134 @@
+package loadtest
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+var smallDataset = Dataset{Seed: 42, Tenants: 2, Profiles: 20, Skills: 30, SkillsPerProfile: 5, History: 3}
+
+func TestThatDatasetsAreReproducible(t *testing.T) {
+	a, b := smallDataset.Generate(), smallDataset.Generate()
+
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Expected the same dataset to generate the same profiles.")
+	}
+	if len(a) != 40 {
+		t.Fatalf("Expected 40 profiles, but got %d.", len(a))
+	}
+	for _, p := range a {
+		if len(p.Skills) != 5 || len(p.SkillsHistory) != 3 {
+			t.Errorf("Expected %s to have 5 skills and 3 history entries, but got %d and %d.", p.EmailAddress, len(p.Skills), len(p.SkillsHistory))
+		}
+		if p.Domain != dataaccess.GetDomain(p.EmailAddress) {
+			t.Errorf("Expected %s to be in its domain, but was in %s.", p.EmailAddress, p.Domain)
+		}
+	}
+
+	other := smallDataset
+	other.Seed = 43
+	if reflect.DeepEqual(a, other.Generate()) {
+		t.Errorf("Expected a different seed to generate different profiles.")
+	}
+}
+
+type memoryDataAccess struct {
+	dataaccess.DataAccess
+	m        sync.Mutex
+	profiles map[string]dataaccess.Profile
+}
+
+func (da *memoryDataAccess) AddSkillTags(tags []string) error {
+	return nil
+}
+
+func (da *memoryDataAccess) ImportProfile(p *dataaccess.Profile) error {
+	da.m.Lock()
+	defer da.m.Unlock()
+	da.profiles[p.EmailAddress] = *p
+	return nil
+}
+
+func (da *memoryDataAccess) GetProfile(emailAddress string) (*dataaccess.Profile, bool, error) {
+	da.m.Lock()
+	defer da.m.Unlock()
+	p, ok := da.profiles[emailAddress]
+	return &p, ok, nil
+}
+
+func (da *memoryDataAccess) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	da.m.Lock()
+	defer da.m.Unlock()
+	var op []dataaccess.Profile
+	for _, p := range da.profiles {
+		if p.Domain == dataaccess.GetDomain(emailAddress) {
+			op = append(op, p)
+		}
+	}
+	return op, nil
+}
+
+func (da *memoryDataAccess) UpdateProfile(u *dataaccess.ProfileUpdate) (*dataaccess.Profile, error) {
+	da.m.Lock()
+	defer da.m.Unlock()
+	p := da.profiles[u.EmailAddress]
+	p.Skills = u.Skills
+	da.profiles[u.EmailAddress] = p
+	return &p, nil
+}
+
+func TestThatLoadIsGeneratedForEachOperation(t *testing.T) {
+	da := &memoryDataAccess{profiles: map[string]dataaccess.Profile{}}
+	if n, err := smallDataset.Write(da); err != nil || n != 40 {
+		t.Fatalf("Expected 40 profiles to be written, but got %d (%v).", n, err)
+	}
+
+	r := Run(context.Background(), da, Options{Dataset: smallDataset, Concurrency: 4, Duration: 50 * time.Millisecond})
+
+	for op := range DefaultMix {
+		s := r.Stats[op]
+		if s == nil || s.Count() == 0 {
+			t.Errorf("Expected %s to be called.", op)
+			continue
+		}
+		if s.Errors > 0 {
+			t.Errorf("Expected %s not to fail, but it failed %d times.", op, s.Errors)
+		}
+		if s.Percentile(50) > s.Percentile(99) {
+			t.Errorf("Expected the p50 latency of %s to be no more than the p99.", op)
+		}
+	}
+}
+
+func TestThatSearchFindsTheMostSkilledPeopleFirst(t *testing.T) {
+	profiles := []dataaccess.Profile{
+		{EmailAddress: "a@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 2}}},
+		{EmailAddress: "b@github.com", Skills: []dataaccess.Skill{{Skill: "java", Level: 5}}},
+		{EmailAddress: "c@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 4}}},
+	}
+
+	found := FindSkill(profiles, "go")
+
+	if len(found) != 2 || found[0].EmailAddress != "c@github.com" || found[1].EmailAddress != "a@github.com" {
+		t.Errorf("Expected c@github.com then a@github.com, but got %v.", found)
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		smallDataset.Generate()
+	}
+}
+
+func BenchmarkFindSkill(b *testing.B) {
+	profiles := DefaultDataset.Generate()[:DefaultDataset.Profiles]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FindSkill(profiles, DefaultDataset.Skill(i%DefaultDataset.Skills))
+	}
+}
@@ -0,0 +1,128 @@
+// Package logredaction removes email addresses, and other identifiers, from
+// log lines before they're written, so that logs shipped to other systems
+// don't contain personal information.
+package logredaction
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// A Mode is how identifiers are redacted.
+type Mode string
+
+// The redaction modes.
+const (
+	// None leaves log lines as they are.
+	None Mode = "none"
+	// Mask keeps the first character of each identifier, e.g.
+	// a***@example.com, which is readable but ambiguous.
+	Mask Mode = "mask"
+	// Hash replaces each identifier with a keyed hash, e.g.
+	// #3f2a9c1b7e4d@example.com, so that the lines about the same person can
+	// be found without saying who they are.
+	Hash Mode = "hash"
+)
+
+// ParseMode returns the Mode with the name.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "", None:
+		return None, nil
+	case Mask, Hash:
+		return m, nil
+	}
+	return None, fmt.Errorf("logredaction: unknown mode '%s', use none, mask or hash", s)
+}
+
+// emailPattern matches email addresses. The domain is kept when they're
+// redacted, since it identifies the tenant rather than the person.
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+'-]+@([A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)+)`)
+
+// hashLength is the number of hex characters of the hash which are kept,
+// enough to tell a tenant's people apart.
+const hashLength = 12
+
+// A Redactor rewrites the identifiers in text.
+type Redactor struct {
+	mode     Mode
+	key      []byte
+	patterns []*regexp.Regexp
+}
+
+// New creates a Redactor. Email addresses are always redacted; the patterns
+// are regular expressions of other identifiers to redact, such as employee
+// IDs. The key is used by the Hash mode, where the same key must be given to
+// each instance of the service for their hashes to match.
+func New(mode Mode, key []byte, patterns ...string) (*Redactor, error) {
+	if mode == Hash && len(key) == 0 {
+		return nil, fmt.Errorf("logredaction: a key is required to hash identifiers")
+	}
+	r := &Redactor{mode: mode, key: key}
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("logredaction: the pattern '%s' is invalid: %v", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns the text with its identifiers redacted.
+func (r *Redactor) Redact(s string) string {
+	if r.mode == None {
+		return s
+	}
+	s = emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+		i := strings.LastIndex(email, "@")
+		// Addresses are compared case insensitively, so their hashes must
+		// be too.
+		return r.replace(strings.ToLower(email[:i])) + email[i:]
+	})
+	for _, p := range r.patterns {
+		s = p.ReplaceAllStringFunc(s, r.replace)
+	}
+	return s
+}
+
+func (r *Redactor) replace(id string) string {
+	if r.mode == Mask {
+		if id == "" {
+			return "***"
+		}
+		return id[:1] + "***"
+	}
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(id))
+	return "#" + hex.EncodeToString(mac.Sum(nil))[:hashLength]
+}
+
+// Writer redacts each write to the underlying writer. The log package writes
+// each line in one call, so it can be used with log.SetOutput.
+type Writer struct {
+	w io.Writer
+	r *Redactor
+}
+
+// NewWriter creates a Writer which redacts what's written to w.
+func NewWriter(w io.Writer, r *Redactor) *Writer {
+	return &Writer{w, r}
+}
+
+// Write writes the redacted bytes. The length of p is returned, not the
+// length written, because redaction changes it.
+func (w *Writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.r.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,95 @@
+package logredaction
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestThatEmailAddressesAreMasked(t *testing.T) {
+	r, err := New(Mask, nil, `\bEMP-\d+\b`)
+	if err != nil {
+		t.Fatalf("Failed to create the redactor: %v", err)
+	}
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"Failed to get the profile of adrian@example.com. timeout", "Failed to get the profile of a***@example.com. timeout"},
+		{"Moved a.b+c@example.co.uk to eu", "Moved a***@example.co.uk to eu"},
+		{"Imported EMP-1234 from bamboohr", "Imported E*** from bamboohr"},
+		{"GET /profile/ 200 3ms", "GET /profile/ 200 3ms"},
+	}
+
+	for _, test := range tests {
+		if actual := r.Redact(test.text); actual != test.expected {
+			t.Errorf("For '%s', expected '%s', but got '%s'", test.text, test.expected, actual)
+		}
+	}
+}
+
+func TestThatHashedEmailAddressesCanBeCorrelated(t *testing.T) {
+	r, err := New(Hash, []byte("key"))
+	if err != nil {
+		t.Fatalf("Failed to create the redactor: %v", err)
+	}
+
+	a := r.Redact("Updated adrian@example.com")
+	b := r.Redact("Deleted Adrian@example.com")
+	c := r.Redact("Updated jo@example.com")
+
+	if strings.Contains(a, "adrian") {
+		t.Errorf("Expected the email address to be hashed, but got '%s'", a)
+	}
+	if !strings.HasSuffix(a, "@example.com") {
+		t.Errorf("Expected the domain to be kept, but got '%s'", a)
+	}
+	if strings.TrimPrefix(a, "Updated ") != strings.TrimPrefix(b, "Deleted ") {
+		t.Errorf("Expected the same address to have the same hash, but got '%s' and '%s'", a, b)
+	}
+	if a == c {
+		t.Errorf("Expected different addresses to have different hashes, but both were '%s'", a)
+	}
+
+	other, _ := New(Hash, []byte("other key"))
+	if other.Redact("Updated adrian@example.com") == a {
+		t.Error("Expected the hash to depend on the key")
+	}
+}
+
+func TestThatTheHashModeRequiresAKey(t *testing.T) {
+	if _, err := New(Hash, nil); err == nil {
+		t.Error("Expected an error without a key")
+	}
+}
+
+func TestThatInvalidPatternsAreRejected(t *testing.T) {
+	if _, err := New(Mask, nil, "("); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func TestThatModesCanBeParsed(t *testing.T) {
+	for s, expected := range map[string]Mode{"": None, "none": None, "Mask": Mask, "hash": Hash} {
+		if actual, err := ParseMode(s); err != nil || actual != expected {
+			t.Errorf("For '%s', expected %s, but got %s, %v", s, expected, actual, err)
+		}
+	}
+	if _, err := ParseMode("encrypt"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
+
+func TestThatTheWriterRedactsLogLines(t *testing.T) {
+	r, _ := New(Mask, nil)
+	buf := new(bytes.Buffer)
+	l := log.New(NewWriter(buf, r), "", 0)
+
+	l.Printf("Failed to email %s.", "adrian@example.com")
+
+	if expected := "Failed to email a***@example.com.\n"; buf.String() != expected {
+		t.Errorf("Expected '%s', but got '%s'", expected, buf.String())
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+// An Authenticator determines who made a request. If the request is not
+// authenticated, the Authenticator is responsible for writing the response,
+// e.g. a redirect to the login page.
+type Authenticator interface {
+	Authenticate(w http.ResponseWriter, r *http.Request) (ok bool, emailAddress string)
+}
+
+// AuthenticatorFunc adapts a function to the Authenticator interface.
+type AuthenticatorFunc func(w http.ResponseWriter, r *http.Request) (ok bool, emailAddress string)
+
+// Authenticate calls f(w, r).
+func (f AuthenticatorFunc) Authenticate(w http.ResponseWriter, r *http.Request) (ok bool, emailAddress string) {
+	return f(w, r)
+}
+
+type contextKey int
+
+const emailAddressKey contextKey = iota
+
+// RequireAuthentication only passes authenticated requests to the next
+// handler, and makes the email address of the user available via
+// EmailAddress.
+func RequireAuthentication(a Authenticator) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, emailAddress := a.Authenticate(w, r)
+			if !ok {
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), emailAddressKey, emailAddress)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// EmailAddress returns the email address of the authenticated user, if
+// RequireAuthentication has been applied to the request.
+func EmailAddress(r *http.Request) (emailAddress string, ok bool) {
+	emailAddress, ok = r.Context().Value(emailAddressKey).(string)
+	return
+}
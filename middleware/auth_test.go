@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestThatAuthenticatedRequestsCarryTheEmailAddress(t *testing.T) {
+	a := AuthenticatorFunc(func(w http.ResponseWriter, r *http.Request) (bool, string) {
+		return true, "a-h@github.com"
+	})
+
+	var received string
+	h := RequireAuthentication(a)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = EmailAddress(r)
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if received != "a-h@github.com" {
+		t.Errorf("Expected the email address to be available to the handler, but was '%s'.", received)
+	}
+}
+
+func TestThatUnauthenticatedRequestsDoNotReachTheHandler(t *testing.T) {
+	a := AuthenticatorFunc(func(w http.ResponseWriter, r *http.Request) (bool, string) {
+		http.Error(w, "Unauthorized.", http.StatusUnauthorized)
+		return false, ""
+	})
+
+	called := false
+	h := RequireAuthentication(a)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if called {
+		t.Error("The handler should not be called for unauthenticated requests.")
+	}
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("The authenticator's response should be returned, but the status was %d.", w.Code)
+	}
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const (
+	// CSRFCookieName is the name of the cookie which holds the CSRF token.
+	CSRFCookieName = "pill-csrf"
+	// CSRFHeaderName is the header scripts use to submit the CSRF token.
+	CSRFHeaderName = "X-CSRF-Token"
+	// CSRFFieldName is the form field forms use to submit the CSRF token.
+	CSRFFieldName = "csrf_token"
+)
+
+const csrfTokenKey contextKey = emailAddressKey + 1
+
+// CSRF protects against cross-site request forgery using double-submit
+// cookies. A token is set in a cookie, and requests which change state must
+// submit the same token in a header or form field. Tokens are signed, so
+// that a cookie planted by another site isn't accepted.
+type CSRF struct {
+	// SetSecureFlag marks the cookie as only being sent over HTTPS.
+	SetSecureFlag bool
+	keys          [][]byte
+}
+
+// NewCSRF creates CSRF protection which signs tokens with the current key,
+// and accepts tokens signed by any of the previous keys.
+func NewCSRF(currentKey []byte, previousKeys ...[]byte) *CSRF {
+	c := &CSRF{}
+	for _, key := range append([][]byte{currentKey}, previousKeys...) {
+		c.keys = append(c.keys, deriveCSRFKey(key))
+	}
+	return c
+}
+
+// deriveCSRFKey derives a signing key, so that the session key isn't used
+// directly by more than one algorithm.
+func deriveCSRFKey(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("pill csrf token"))
+	return mac.Sum(nil)
+}
+
+func sign(key []byte, nonce string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(nonce))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NewToken creates a token signed with the current key.
+func (c *CSRF) NewToken() (string, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	n := base64.RawURLEncoding.EncodeToString(nonce)
+	return n + "." + sign(c.keys[0], n), nil
+}
+
+// Valid returns true if the token was created by NewToken with any of the
+// keys.
+func (c *CSRF) Valid(token string) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || parts[0] == "" {
+		return false
+	}
+
+	for _, key := range c.keys {
+		if hmac.Equal([]byte(sign(key, parts[0])), []byte(parts[1])) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func submittedCSRFToken(r *http.Request) string {
+	if token := r.Header.Get(CSRFHeaderName); token != "" {
+		return token
+	}
+	return r.FormValue(CSRFFieldName)
+}
+
+// Handler rejects requests which change state with a 403 status unless they
+// submit the token from the CSRF cookie. The token is made available to
+// templates via CSRFToken.
+func (c *CSRF) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		if cookie, err := r.Cookie(CSRFCookieName); err == nil && c.Valid(cookie.Value) {
+			token = cookie.Value
+		}
+
+		if !isSafeMethod(r.Method) {
+			submitted := submittedCSRFToken(r)
+			if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(submitted)) != 1 {
+				log.Printf("Rejecting a %s to %s with a missing or invalid CSRF token.", r.Method, r.URL.Path)
+				http.Error(w, "Invalid CSRF token.", http.StatusForbidden)
+				return
+			}
+		}
+
+		if token == "" {
+			var err error
+			if token, err = c.NewToken(); err != nil {
+				log.Print("Failed to create a CSRF token. ", err)
+				http.Error(w, "Failed to create a CSRF token.", http.StatusInternalServerError)
+				return
+			}
+
+			// The cookie isn't HttpOnly, so that scripts can submit it in the
+			// header.
+			http.SetCookie(w, &http.Cookie{
+				Name:     CSRFCookieName,
+				Value:    token,
+				Path:     "/",
+				Secure:   c.SetSecureFlag,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+
+		ctx := context.WithValue(r.Context(), csrfTokenKey, token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// CSRFToken returns the token which must be submitted with forms, if the CSRF
+// Handler has been applied to the request.
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfTokenKey).(string)
+	return token
+}
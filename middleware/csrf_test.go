@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+var csrfTestKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestThatSafeRequestsReceiveACSRFToken(t *testing.T) {
+	c := NewCSRF(csrfTestKey)
+
+	var token string
+	h := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = CSRFToken(r)
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/profile/", nil))
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CSRFCookieName {
+		t.Fatalf("Expected the CSRF cookie to be set, but received %v", cookies)
+	}
+
+	if token == "" || token != cookies[0].Value {
+		t.Errorf("Expected the token available to the handler to match the cookie, but was '%s'.", token)
+	}
+}
+
+func TestThatStateChangingRequestsMustSubmitTheCSRFToken(t *testing.T) {
+	c := NewCSRF(csrfTestKey)
+	token, _ := c.NewToken()
+	other, _ := NewCSRF([]byte("another key which is 32 bytes...")).NewToken()
+
+	tests := []struct {
+		name     string
+		cookie   string
+		header   string
+		field    string
+		expected int
+	}{
+		{"no token", "", "", "", http.StatusForbidden},
+		{"cookie only", token, "", "", http.StatusForbidden},
+		{"header matches", token, token, "", http.StatusOK},
+		{"field matches", token, "", token, http.StatusOK},
+		{"mismatched", token, "", token + "x", http.StatusForbidden},
+		{"signed by another key", other, other, "", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		h := c.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		form := url.Values{}
+		if test.field != "" {
+			form.Set(CSRFFieldName, test.field)
+		}
+		r := httptest.NewRequest("POST", "/profile/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if test.cookie != "" {
+			r.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: test.cookie})
+		}
+		if test.header != "" {
+			r.Header.Set(CSRFHeaderName, test.header)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != test.expected {
+			t.Errorf("For the '%s' test, expected status %d, but was %d.", test.name, test.expected, w.Code)
+		}
+	}
+}
+
+func TestThatTokensSignedWithPreviousKeysAreAccepted(t *testing.T) {
+	previous := []byte("the previous key, also 32 bytes.")
+	token, _ := NewCSRF(previous).NewToken()
+
+	if !NewCSRF(csrfTestKey, previous).Valid(token) {
+		t.Error("Tokens signed with a previous key should be accepted after rotation.")
+	}
+}
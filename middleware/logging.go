@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// Log logs the method, path, status code and duration of each request.
+func Log(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sr := newStatusRecorder(w)
+
+		next.ServeHTTP(sr, r)
+
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, sr.status, time.Since(start))
+	})
+}
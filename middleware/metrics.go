@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Metrics counts requests and their durations by route and status code. It is
+// also a http.Handler which serves the current counts as JSON.
+type Metrics struct {
+	// Route names the route a request was made to. The metrics are kept by
+	// route rather than by path, so that requests for paths containing email
+	// addresses, ids, or made up by a scanner don't each add a counter.
+	// Defaults to the path.
+	Route func(r *http.Request) string
+
+	mutex    sync.Mutex
+	counters map[string]*RequestMetric
+}
+
+// RequestMetric holds the totals for a route and status code.
+type RequestMetric struct {
+	Path        string        `json:"path"`
+	Status      int           `json:"status"`
+	Count       int64         `json:"count"`
+	TotalTime   time.Duration `json:"totalTime"`
+	MaximumTime time.Duration `json:"maximumTime"`
+}
+
+// NewMetrics creates an instance of Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		Route:    func(r *http.Request) string { return r.URL.Path },
+		counters: make(map[string]*RequestMetric),
+	}
+}
+
+// UnmatchedRoute is the route of requests which don't match a route.
+const UnmatchedRoute = "unmatched"
+
+// RouteTemplate names requests by the template of the route they match on the
+// router, e.g. /profile/{email}/, or UnmatchedRoute. Versioned requests are
+// matched without the version, which is kept on the name, e.g.
+// /v1/profile/{email}/.
+func RouteTemplate(router *mux.Router) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		if m := versionPath.FindStringSubmatch(r.URL.Path); m != nil {
+			u := *r.URL
+			u.Path, u.RawPath = m[2], ""
+			if u.Path == "" {
+				u.Path = "/"
+			}
+			unversioned := *r
+			unversioned.URL = &u
+			if route := RouteTemplate(router)(&unversioned); route != UnmatchedRoute {
+				return "/v" + m[1] + route
+			}
+			return UnmatchedRoute
+		}
+		var match mux.RouteMatch
+		if !router.Match(r, &match) || match.Route == nil {
+			return UnmatchedRoute
+		}
+		template, err := match.Route.GetPathTemplate()
+		if err != nil {
+			return UnmatchedRoute
+		}
+		return template
+	}
+}
+
+// Handler records metrics for each request handled by next.
+func (m *Metrics) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sr := newStatusRecorder(w)
+
+		next.ServeHTTP(sr, r)
+
+		m.record(m.Route(r), sr.status, time.Since(start))
+	})
+}
+
+func (m *Metrics) record(path string, status int, duration time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := path + " " + strconv.Itoa(status)
+	rm, ok := m.counters[key]
+	if !ok {
+		rm = &RequestMetric{Path: path, Status: status}
+		m.counters[key] = rm
+	}
+
+	rm.Count++
+	rm.TotalTime += duration
+	if duration > rm.MaximumTime {
+		rm.MaximumTime = duration
+	}
+}
+
+// Snapshot returns a copy of the current metrics.
+func (m *Metrics) Snapshot() []RequestMetric {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	snapshot := make([]RequestMetric, 0, len(m.counters))
+	for _, rm := range m.counters {
+		snapshot = append(snapshot, *rm)
+	}
+	return snapshot
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(m.Snapshot()); err != nil {
+		log.Printf("Failed to marshall the metrics, with error %s", err)
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestThatMetricsAreRecordedByPathAndStatus(t *testing.T) {
+	m := NewMetrics()
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ok", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ok", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+
+	counts := make(map[string]int64)
+	for _, rm := range m.Snapshot() {
+		counts[rm.Path+" "+http.StatusText(rm.Status)] = rm.Count
+	}
+
+	if counts["/ok OK"] != 2 {
+		t.Errorf("Expected 2 successful requests to /ok, but was %d.", counts["/ok OK"])
+	}
+
+	if counts["/missing Not Found"] != 1 {
+		t.Errorf("Expected 1 not found request to /missing, but was %d.", counts["/missing Not Found"])
+	}
+}
+
+func TestThatMetricsAreRecordedByRouteTemplate(t *testing.T) {
+	router := mux.NewRouter()
+	router.Handle("/profile/{email}/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	m := NewMetrics()
+	m.Route = RouteTemplate(router)
+	h := m.Handler(router)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/profile/a@example.com/", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/profile/b@example.com/", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/profile/c@example.com/", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/wp-login.php", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/.env", nil))
+
+	counts := make(map[string]int64)
+	for _, rm := range m.Snapshot() {
+		counts[rm.Path+" "+http.StatusText(rm.Status)] = rm.Count
+	}
+
+	if len(counts) != 3 {
+		t.Errorf("Expected a counter for each version of the route and one for unmatched requests, but got %v.", counts)
+	}
+
+	if counts["/profile/{email}/ OK"] != 2 {
+		t.Errorf("Expected 2 requests to the profile route, but was %d.", counts["/profile/{email}/ OK"])
+	}
+
+	if counts["/v1/profile/{email}/ Not Found"] != 1 {
+		t.Errorf("Expected 1 request to version 1 of the profile route, but was %d.", counts["/v1/profile/{email}/ Not Found"])
+	}
+
+	if counts[UnmatchedRoute+" Not Found"] != 2 {
+		t.Errorf("Expected 2 unmatched requests, but was %d.", counts[UnmatchedRoute+" Not Found"])
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A RateLimiter limits the number of requests each client can make, using a
+// token bucket per client.
+type RateLimiter struct {
+	// RequestsPerSecond is the rate at which tokens are added to each bucket.
+	RequestsPerSecond float64
+	// Burst is the maximum number of tokens a bucket can hold.
+	Burst int
+	// Key identifies the client making the request. Defaults to the remote IP
+	// address.
+	Key func(r *http.Request) string
+
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter creates a RateLimiter which allows requestsPerSecond requests,
+// with bursts of up to burst requests.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		RequestsPerSecond: requestsPerSecond,
+		Burst:             burst,
+		Key:               RemoteIP,
+		buckets:           make(map[string]*bucket),
+		now:               time.Now,
+	}
+}
+
+// RemoteIP returns the IP address of the client which made the request.
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Allow returns true if the client identified by key may make a request.
+func (rl *RateLimiter) Allow(key string) bool {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	now := rl.now()
+	rl.sweep(now)
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rl.Burst), updated: now}
+		rl.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.updated).Seconds() * rl.RequestsPerSecond
+	if b.tokens > float64(rl.Burst) {
+		b.tokens = float64(rl.Burst)
+	}
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// sweep removes the buckets of clients which have been idle for long enough
+// for their bucket to refill, since they would be given a full bucket anyway.
+// Without it, every address which ever made a request would be kept. It runs
+// at most once per refill, so that it isn't a cost on every request.
+func (rl *RateLimiter) sweep(now time.Time) {
+	refill := time.Duration(float64(rl.Burst) / rl.RequestsPerSecond * float64(time.Second))
+	if now.Sub(rl.swept) < refill {
+		return
+	}
+	for key, b := range rl.buckets {
+		if now.Sub(b.updated) >= refill {
+			delete(rl.buckets, key)
+		}
+	}
+	rl.swept = now
+}
+
+// Handler rejects requests with a 429 status when the client has exceeded
+// its rate limit.
+func (rl *RateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !rl.Allow(rl.Key(r)) {
+			http.Error(w, "Too many requests.", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
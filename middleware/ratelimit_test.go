@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestThatTheRateLimiterAllowsBurstsThenRefills(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	rl := NewRateLimiter(1, 2)
+	rl.now = func() time.Time { return now }
+
+	tests := []struct {
+		advance  time.Duration
+		key      string
+		expected bool
+	}{
+		{0, "a", true},
+		{0, "a", true},
+		{0, "a", false},
+		{0, "b", true},
+		{time.Second, "a", true},
+		{0, "a", false},
+	}
+
+	for i, test := range tests {
+		now = now.Add(test.advance)
+		actual := rl.Allow(test.key)
+
+		if actual != test.expected {
+			t.Errorf("Request %d for key %s: expected %t, but was %t.", i, test.key, test.expected, actual)
+		}
+	}
+}
+
+func TestThatRateLimitedRequestsReceiveTooManyRequests(t *testing.T) {
+	rl := NewRateLimiter(1, 1)
+	h := rl.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+
+	w1 := httptest.NewRecorder()
+	h.ServeHTTP(w1, r)
+
+	w2 := httptest.NewRecorder()
+	h.ServeHTTP(w2, r)
+
+	if w1.Code != http.StatusOK {
+		t.Errorf("The first request should be allowed, but received status %d.", w1.Code)
+	}
+
+	if w2.Code != http.StatusTooManyRequests {
+		t.Errorf("The second request should be rate limited, but received status %d.", w2.Code)
+	}
+}
+
+func TestThatIdleBucketsAreRemoved(t *testing.T) {
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	rl := NewRateLimiter(1, 2)
+	rl.now = func() time.Time { return now }
+
+	rl.Allow("a")
+	rl.Allow("a")
+	now = now.Add(time.Second)
+	rl.Allow("b")
+	if len(rl.buckets) != 2 {
+		t.Fatalf("Expected a bucket for each client, but there were %d.", len(rl.buckets))
+	}
+
+	now = now.Add(time.Second)
+	if !rl.Allow("b") {
+		t.Error("Expected b to be allowed a second request.")
+	}
+	if _, ok := rl.buckets["a"]; ok {
+		t.Error("Expected the bucket of the idle client to have been removed.")
+	}
+	if len(rl.buckets) != 1 {
+		t.Errorf("Expected only the bucket of the active client to be kept, but there were %d.", len(rl.buckets))
+	}
+
+	if !rl.Allow("a") || !rl.Allow("a") || rl.Allow("a") {
+		t.Error("Expected a client whose bucket was removed to be given a full bucket.")
+	}
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// A Panic is a panic recovered while handling a request.
+type Panic struct {
+	// Value is the value passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the goroutine which panicked.
+	Stack []byte
+	Time  time.Time
+}
+
+// A Reporter sends recovered panics to an error tracking service.
+type Reporter interface {
+	// Report sends the panic, and returns a reference to it which the client
+	// can quote, or an empty string if it doesn't have one. Reporters must
+	// not block the response for long.
+	Report(r *http.Request, p Panic) string
+}
+
+// Recover catches panics raised by the handler, logs them with a stack trace
+// and returns a 500 error to the client.
+func Recover(next http.Handler) http.Handler {
+	return RecoverAndReport(nil)(next)
+}
+
+// RecoverAndReport returns middleware which recovers panics like Recover, and
+// also sends them to the reporter, if it isn't nil.
+func RecoverAndReport(reporter Reporter) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &writeRecorder{ResponseWriter: w}
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+				// The server uses ErrAbortHandler to stop a response
+				// quietly, so it isn't an error.
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				p := Panic{Value: err, Stack: debug.Stack(), Time: time.Now().UTC()}
+				log.Printf("Recovered from a panic while handling %s %s: %v\n%s", r.Method, r.URL.Path, p.Value, p.Stack)
+
+				var reference string
+				if reporter != nil {
+					reference = reporter.Report(r, p)
+				}
+				// If the handler started the response, the status can't be
+				// changed, and writing the error would corrupt the body.
+				if rw.written {
+					return
+				}
+				msg := "An internal error occurred."
+				if reference != "" {
+					msg = fmt.Sprintf("An internal error occurred, reference %s.", reference)
+				}
+				http.Error(w, msg, http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// writeRecorder records whether the response has been started.
+type writeRecorder struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (wr *writeRecorder) WriteHeader(status int) {
+	wr.written = true
+	wr.ResponseWriter.WriteHeader(status)
+}
+
+func (wr *writeRecorder) Write(b []byte) (int, error) {
+	wr.written = true
+	return wr.ResponseWriter.Write(b)
+}
+
+// Hijack allows WebSocket connections to be upgraded through the middleware.
+func (wr *writeRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := wr.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("middleware: the response writer does not implement http.Hijacker")
+	}
+	wr.written = true
+	return h.Hijack()
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestThatPanicsAreRecoveredAsInternalServerErrors(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("oops")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected a panic to result in a 500 status, but was %d.", w.Code)
+	}
+}
+
+type testReporter struct {
+	reported []Panic
+}
+
+func (tr *testReporter) Report(r *http.Request, p Panic) string {
+	tr.reported = append(tr.reported, p)
+	return "abc123"
+}
+
+func TestThatPanicsAreReported(t *testing.T) {
+	reporter := &testReporter{}
+	h := RecoverAndReport(reporter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("oops")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if len(reporter.reported) != 1 {
+		t.Fatalf("Expected the panic to be reported once, but it was reported %d times.", len(reporter.reported))
+	}
+	if p := reporter.reported[0]; p.Value != "oops" || !strings.Contains(string(p.Stack), "recovery_test.go") {
+		t.Errorf("Expected the panic's value and stack trace to be reported, but got %v.", p.Value)
+	}
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "reference abc123") {
+		t.Errorf("Expected a 500 status quoting the reference, but got %d '%s'.", w.Code, w.Body.String())
+	}
+}
+
+func TestThatStartedResponsesAreNotOverwrittenAfterAPanic(t *testing.T) {
+	h := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("oops")
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Body.String() != "partial" {
+		t.Errorf("Expected the body to be left as it was, but got '%s'.", w.Body.String())
+	}
+}
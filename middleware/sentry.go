@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SentryReporter reports panics to Sentry, using its store API, so that the
+// service doesn't need the Sentry SDK.
+type SentryReporter struct {
+	// StoreURL is the address events are posted to, derived from the DSN.
+	StoreURL string
+	// Key is the public key from the DSN.
+	Key string
+	// Environment is the name Sentry groups the events by, e.g. "production".
+	Environment string
+	Client      *http.Client
+}
+
+// NewSentryReporter creates a SentryReporter from a DSN, e.g.
+// https://key@o123.ingest.sentry.io/456.
+func NewSentryReporter(dsn, environment string) (*SentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sentry: the DSN is invalid: %v", err)
+	}
+	project := strings.Trim(u.Path, "/")
+	if u.User == nil || u.User.Username() == "" || project == "" || u.Host == "" {
+		return nil, fmt.Errorf("sentry: the DSN must include the key and project, e.g. https://key@o123.ingest.sentry.io/456")
+	}
+	// Self-hosted Sentry can be under a path, e.g. https://key@example.com/sentry/456.
+	prefix := ""
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	return &SentryReporter{
+		StoreURL:    fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, prefix, project),
+		Key:         u.User.Username(),
+		Environment: environment,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	Environment string            `json:"environment,omitempty"`
+	Message     string            `json:"message"`
+	Request     sentryRequest     `json:"request"`
+	Extra       map[string]string `json:"extra"`
+}
+
+type sentryRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// Report sends the panic to Sentry in the background, and returns the ID of
+// the Sentry event. Query strings aren't sent, since they can contain
+// personal information.
+func (s *SentryReporter) Report(r *http.Request, p Panic) string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		log.Print("Failed to create the ID of the Sentry event. ", err)
+		return ""
+	}
+	e := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   p.Time.Format(time.RFC3339),
+		Level:       "error",
+		Platform:    "go",
+		Environment: s.Environment,
+		Message:     fmt.Sprintf("panic: %v", p.Value),
+		Request:     sentryRequest{Method: r.Method, URL: r.URL.Path},
+		Extra:       map[string]string{"stack": string(p.Stack)},
+	}
+	go func() {
+		if err := s.send(e); err != nil {
+			log.Printf("Failed to report event %s to Sentry. %v", e.EventID, err)
+		}
+	}()
+	return e.EventID
+}
+
+func (s *SentryReporter) send(e sentryEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", s.StoreURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=pill/1.0, sentry_timestamp=%d, sentry_key=%s", time.Now().Unix(), s.Key))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThatSentryDSNsAreParsed(t *testing.T) {
+	tests := []struct {
+		dsn      string
+		expected string
+	}{
+		{"https://key@o123.ingest.sentry.io/456", "https://o123.ingest.sentry.io/api/456/store/"},
+		{"https://key@example.com/sentry/456", "https://example.com/sentry/api/456/store/"},
+	}
+	for _, test := range tests {
+		s, err := NewSentryReporter(test.dsn, "test")
+		if err != nil {
+			t.Errorf("For '%s', unexpected error %v", test.dsn, err)
+			continue
+		}
+		if s.StoreURL != test.expected || s.Key != "key" {
+			t.Errorf("For '%s', expected '%s' with the key 'key', but got '%s' with '%s'", test.dsn, test.expected, s.StoreURL, s.Key)
+		}
+	}
+	if _, err := NewSentryReporter("https://o123.ingest.sentry.io/456", ""); err == nil {
+		t.Error("Expected an error for a DSN without a key")
+	}
+}
+
+func TestThatPanicsAreSentToSentry(t *testing.T) {
+	events := make(chan sentryEvent, 1)
+	auth := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e sentryEvent
+		json.NewDecoder(r.Body).Decode(&e)
+		auth <- r.Header.Get("X-Sentry-Auth")
+		events <- e
+	}))
+	defer server.Close()
+
+	s, err := NewSentryReporter(strings.Replace(server.URL, "://", "://key@", 1)+"/1", "test")
+	if err != nil {
+		t.Fatalf("Failed to create the reporter: %v", err)
+	}
+	id := s.Report(httptest.NewRequest("GET", "/profile/?email=a@example.com", nil), Panic{Value: "oops", Stack: []byte("stack"), Time: time.Now()})
+
+	select {
+	case e := <-events:
+		if e.EventID != id || e.Message != "panic: oops" || e.Extra["stack"] != "stack" || e.Request.URL != "/profile/" {
+			t.Errorf("Unexpected event %+v", e)
+		}
+		if a := <-auth; !strings.Contains(a, "sentry_key=key") {
+			t.Errorf("Expected the key to be sent, but got '%s'", a)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Timed out waiting for the event to be sent")
+	}
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionHeader is the response header which says which version of the API
+// served the request.
+const VersionHeader = "Pill-API-Version"
+
+// Versioning serves the API under versioned paths, e.g. /v1/profile/, and
+// passes the version to handlers in the request's context, so that a later
+// version can change the shape of responses without breaking clients of the
+// earlier one. Clients can also ask for a version of an unversioned path with
+// the Accept header, e.g. application/vnd.pill.v1+json.
+//
+// Requests to unversioned paths without a version in the Accept header are
+// served by the Default version, and are marked as deprecated with the
+// Deprecation, Sunset and Link headers.
+type Versioning struct {
+	// Supported are the versions which can be requested.
+	Supported []int
+	// Default is the version unversioned requests are served by.
+	Default int
+	// Unversioned are the paths which aren't part of the API, such as pages
+	// and static files. Paths ending in a slash match everything under them,
+	// apart from "/", which only matches itself.
+	Unversioned []string
+	// Deprecated is when unversioned requests were deprecated. If it's zero,
+	// the Deprecation header is "true".
+	Deprecated time.Time
+	// Sunset is when unversioned requests will stop being served. If it's
+	// zero, the Sunset header isn't sent.
+	Sunset time.Time
+}
+
+type versionKey struct{}
+
+// APIVersion returns the version of the API the request is being served by.
+func APIVersion(ctx context.Context) (int, bool) {
+	v, ok := ctx.Value(versionKey{}).(int)
+	return v, ok
+}
+
+var (
+	versionPath   = regexp.MustCompile(`^/v(\d+)(/.*)?$`)
+	versionAccept = regexp.MustCompile(`application/vnd\.pill\.v(\d+)\+json`)
+)
+
+// Handler serves versioned requests by next, with the version removed from
+// the path.
+func (v Versioning) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m := versionPath.FindStringSubmatch(r.URL.Path); m != nil {
+			version, _ := strconv.Atoi(m[1])
+			if !v.supports(version) {
+				http.Error(w, fmt.Sprintf("Version %d of the API isn't supported.", version), http.StatusNotFound)
+				return
+			}
+			path := m[2]
+			if path == "" {
+				path = "/"
+			}
+			u := *r.URL
+			u.Path, u.RawPath = path, ""
+			r = r.WithContext(context.WithValue(r.Context(), versionKey{}, version))
+			r.URL = &u
+			w.Header().Set(VersionHeader, strconv.Itoa(version))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if v.unversioned(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version := v.Default
+		if m := versionAccept.FindStringSubmatch(r.Header.Get("Accept")); m != nil {
+			version, _ = strconv.Atoi(m[1])
+			if !v.supports(version) {
+				http.Error(w, fmt.Sprintf("Version %d of the API isn't supported.", version), http.StatusNotAcceptable)
+				return
+			}
+		} else {
+			v.deprecate(w, r)
+		}
+		w.Header().Set(VersionHeader, strconv.Itoa(version))
+		w.Header().Add("Vary", "Accept")
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, version)))
+	})
+}
+
+func (v Versioning) supports(version int) bool {
+	for _, s := range v.Supported {
+		if s == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (v Versioning) unversioned(path string) bool {
+	for _, u := range v.Unversioned {
+		if path == u || (u != "/" && strings.HasSuffix(u, "/") && strings.HasPrefix(path, u)) {
+			return true
+		}
+	}
+	return false
+}
+
+// deprecate adds the headers of RFC 8594 and the Deprecation header draft,
+// which point clients at the versioned path.
+func (v Versioning) deprecate(w http.ResponseWriter, r *http.Request) {
+	if v.Deprecated.IsZero() {
+		w.Header().Set("Deprecation", "true")
+	} else {
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(v.Deprecated.Unix(), 10))
+	}
+	if !v.Sunset.IsZero() {
+		w.Header().Set("Sunset", v.Sunset.UTC().Format(http.TimeFormat))
+	}
+	w.Header().Add("Link", fmt.Sprintf(`</v%d%s>; rel="successor-version"`, v.Default, r.URL.EscapedPath()))
+}
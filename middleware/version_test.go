@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestThatRequestsAreServedByTheirVersion(t *testing.T) {
+	v := Versioning{
+		Supported:   []int{1, 2},
+		Default:     1,
+		Unversioned: []string{"/", "/static/"},
+		Sunset:      time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		path            string
+		accept          string
+		expectedStatus  int
+		expectedPath    string
+		expectedVersion int
+		deprecated      bool
+	}{
+		{"/v1/profile/", "", http.StatusOK, "/profile/", 1, false},
+		{"/v2/profile/", "", http.StatusOK, "/profile/", 2, false},
+		{"/v3/profile/", "", http.StatusNotFound, "", 0, false},
+		{"/profile/", "", http.StatusOK, "/profile/", 1, true},
+		{"/profile/", "application/vnd.pill.v2+json", http.StatusOK, "/profile/", 2, false},
+		{"/profile/", "application/vnd.pill.v9+json", http.StatusNotAcceptable, "", 0, false},
+		{"/", "", http.StatusOK, "/", 0, false},
+		{"/static/js/typeahead.js", "", http.StatusOK, "/static/js/typeahead.js", 0, false},
+	}
+
+	for _, test := range tests {
+		var path string
+		var version int
+		h := v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			version, _ = APIVersion(r.Context())
+		}))
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", test.path, nil)
+		r.Header.Set("Accept", test.accept)
+		h.ServeHTTP(w, r)
+
+		if w.Code != test.expectedStatus || path != test.expectedPath || version != test.expectedVersion {
+			t.Errorf("For %s '%s', expected %d %s version %d, but got %d %s version %d", test.path, test.accept, test.expectedStatus, test.expectedPath, test.expectedVersion, w.Code, path, version)
+		}
+		if deprecated := w.Header().Get("Deprecation") != ""; deprecated != test.deprecated {
+			t.Errorf("For %s '%s', expected deprecated to be %t", test.path, test.accept, test.deprecated)
+		}
+	}
+}
+
+func TestThatUnversionedRequestsPointToTheirSuccessor(t *testing.T) {
+	v := Versioning{
+		Supported: []int{1},
+		Default:   1,
+		Sunset:    time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	h := v.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/report/?skill=go", nil))
+
+	if expected := "Mon, 01 Jan 2018 00:00:00 GMT"; w.Header().Get("Sunset") != expected {
+		t.Errorf("Expected the Sunset header to be '%s', but got '%s'", expected, w.Header().Get("Sunset"))
+	}
+	if expected := `</v1/report/>; rel="successor-version"`; w.Header().Get("Link") != expected {
+		t.Errorf("Expected the Link header to be '%s', but got '%s'", expected, w.Header().Get("Link"))
+	}
+	if w.Header().Get(VersionHeader) != "1" {
+		t.Errorf("Expected version 1, but got '%s'", w.Header().Get(VersionHeader))
+	}
+}
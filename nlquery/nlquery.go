@@ -0,0 +1,247 @@
+// Package nlquery answers questions about people written in plain language,
+// e.g. "who can build a React Native app and is free in June", by having a
+// large language model interpret them as a Query, which is then run against
+// the profiles. The interpreted query is returned with the results, so that
+// people can see what was searched for.
+package nlquery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/llm"
+)
+
+// MaxResults is the number of people an answer lists.
+const MaxResults = 50
+
+// dateFormat is the format of the dates in a Query.
+const dateFormat = "2006-01-02"
+
+// A Query is the interpretation of a question, which selects people in a
+// domain.
+type Query struct {
+	// Skills must all be held at their levels or above.
+	Skills []dataaccess.SkillRequirement `json:"skills,omitempty"`
+	dataaccess.ProfileFilter
+	// MinimumAvailability excludes people who are less available now.
+	MinimumAvailability dataaccess.RagStatus `json:"minimumAvailability,omitempty"`
+	// FreeFrom and FreeUntil are the dates, e.g. 2018-06-01, between which
+	// people must not be fully booked or on leave.
+	FreeFrom  string `json:"freeFrom,omitempty"`
+	FreeUntil string `json:"freeUntil,omitempty"`
+}
+
+// window returns the period people must be free in, which ends at the end
+// of FreeUntil.
+func (q Query) window() (from time.Time, to time.Time, ok bool, err error) {
+	if q.FreeFrom == "" && q.FreeUntil == "" {
+		return from, to, false, nil
+	}
+	var problems []string
+	if from, err = time.Parse(dateFormat, q.FreeFrom); err != nil {
+		problems = append(problems, "the start of the period people must be free in is invalid")
+	}
+	if to, err = time.Parse(dateFormat, q.FreeUntil); err != nil {
+		problems = append(problems, "the end of the period people must be free in is invalid")
+	}
+	if len(problems) == 0 && to.Before(from) {
+		problems = append(problems, "the period people must be free in ends before it starts")
+	}
+	if len(problems) > 0 {
+		return from, to, false, dataaccess.ValidationError{Problems: problems}
+	}
+	return from, to.AddDate(0, 0, 1), true, nil
+}
+
+func (q Query) validate() error {
+	var problems []string
+	for _, s := range q.Skills {
+		if s.Skill == "" || s.Level < dataaccess.NoviceLevel || s.Level > dataaccess.MasterLevel {
+			problems = append(problems, fmt.Sprintf("the skill '%s' must have a level between 1 and 5", s.Skill))
+		}
+	}
+	if q.MinimumAvailability < 0 || q.MinimumAvailability > dataaccess.Green {
+		problems = append(problems, "the minimum availability must be between 1 (red) and 3 (green)")
+	}
+	if len(problems) > 0 {
+		return dataaccess.ValidationError{Problems: problems}
+	}
+	_, _, _, err := q.window()
+	return err
+}
+
+// A Result is someone who matches a query.
+type Result struct {
+	EmailAddress string `json:"emailAddress"`
+	Name         string `json:"name,omitempty"`
+	Department   string `json:"department,omitempty"`
+	// Skills are the person's skills which the query asked for.
+	Skills []dataaccess.Skill `json:"skills"`
+	// Allocation is the highest percentage of their time which is booked
+	// during the period they must be free in.
+	Allocation int `json:"allocation"`
+}
+
+// An Answer is the interpretation of a question and the people who match it.
+type Answer struct {
+	Question string   `json:"question"`
+	Query    Query    `json:"query"`
+	Results  []Result `json:"results"`
+}
+
+// The Interpreter turns questions into queries with a language model, and
+// runs them.
+type Interpreter struct {
+	DataAccess dataaccess.DataAccess
+	Provider   llm.Provider
+	now        func() time.Time
+}
+
+// NewInterpreter creates an instance of the Interpreter.
+func NewInterpreter(da dataaccess.DataAccess, provider llm.Provider) *Interpreter {
+	return &Interpreter{da, provider, time.Now}
+}
+
+const instructions = `You turn questions about the people in an organisation into search queries. Today is %s.
+Reply with only a JSON object, leaving out the fields the question doesn't mention:
+{
+  "skills": [{"skill": "react native", "level": 2}],
+  "department": "Engineering",
+  "costCenter": "CC-100",
+  "language": "de",
+  "languageLevel": "B2",
+  "minimumAvailability": 3,
+  "freeFrom": "2018-06-01",
+  "freeUntil": "2018-06-30"
+}
+skills are the skills the people need, in lower case, without versions. Levels are 1 novice, 2 competent, 3 proficient, 4 expert and 5 master; use 2 unless the question says how good people must be.
+language is the ISO 639-1 code of a language the people speak, at the CEFR languageLevel (A1 to C2) or above.
+minimumAvailability is how available the people must be now: 1 red, 2 amber or 3 green.
+freeFrom and freeUntil are the first and last days the people must be free, as YYYY-MM-DD. A month means its first and last days, in the next year if it has already passed this year.`
+
+// Interpret has the language model turn the question into a query. Skills
+// are resolved to the skill tags they're aliases of.
+func (i Interpreter) Interpret(ctx context.Context, question string) (Query, error) {
+	var q Query
+	completion, err := i.Provider.Complete(ctx, fmt.Sprintf(instructions, i.now().Format(dateFormat)), question)
+	if err != nil {
+		return q, err
+	}
+	if err := llm.JSON(completion, &q); err != nil {
+		return q, err
+	}
+
+	tags, err := dataaccess.WithContext(i.DataAccess, ctx).ListSkillTags()
+	if err != nil {
+		return q, err
+	}
+	skills := make([]dataaccess.Skill, len(q.Skills))
+	for j, s := range q.Skills {
+		skills[j] = dataaccess.Skill{Skill: dataaccess.CleanTag(s.Skill)}
+	}
+	resolved, _ := dataaccess.NewVocabulary(tags).Resolve(skills)
+	for j := range q.Skills {
+		q.Skills[j].Skill = resolved[j].Skill
+	}
+	return q, q.validate()
+}
+
+// Ask interprets the question and finds the people in the domain who match
+// it.
+func (i Interpreter) Ask(ctx context.Context, domain string, question string) (Answer, error) {
+	a := Answer{Question: question, Results: []Result{}}
+	q, err := i.Interpret(ctx, question)
+	a.Query = q
+	if err != nil {
+		return a, err
+	}
+	a.Results, err = i.Run(ctx, domain, q)
+	return a, err
+}
+
+// Run finds the people in the domain who match the query, the least booked
+// first, then the most skilled.
+func (i Interpreter) Run(ctx context.Context, domain string, q Query) ([]Result, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+	from, to, free, _ := q.window()
+	now := i.now()
+
+	da := dataaccess.WithContext(i.DataAccess, ctx)
+	var profiles []dataaccess.Profile
+	var err error
+	if q.ProfileFilter.Empty() {
+		profiles, err = da.ListProfiles("@" + domain)
+	} else {
+		profiles, err = da.FindProfiles(domain, q.ProfileFilter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := []Result{}
+	total := make(map[string]int)
+	for _, p := range profiles {
+		r, ok := match(p, q)
+		if !ok {
+			continue
+		}
+		if q.MinimumAvailability > 0 && p.AvailabilityAt(now) < q.MinimumAvailability {
+			continue
+		}
+		if free {
+			r.Allocation = dataaccess.PeakAllocation(p.Bookings, from, to)
+			if r.Allocation >= dataaccess.FullAllocation {
+				continue
+			}
+			if working, available := p.AvailableDays(from, to); working > 0 && available == 0 {
+				continue
+			}
+		}
+		for _, s := range r.Skills {
+			total[r.EmailAddress] += int(s.Level)
+		}
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(x, y int) bool {
+		a, b := results[x], results[y]
+		if a.Allocation != b.Allocation {
+			return a.Allocation < b.Allocation
+		}
+		if total[a.EmailAddress] != total[b.EmailAddress] {
+			return total[a.EmailAddress] > total[b.EmailAddress]
+		}
+		return a.EmailAddress < b.EmailAddress
+	})
+	if len(results) > MaxResults {
+		results = results[:MaxResults]
+	}
+	return results, nil
+}
+
+// match returns the result for the profile if it has every skill in the
+// query at its level.
+func match(p dataaccess.Profile, q Query) (Result, bool) {
+	r := Result{EmailAddress: strings.ToLower(p.EmailAddress), Name: p.Name, Department: p.Department, Skills: []dataaccess.Skill{}}
+	for _, req := range q.Skills {
+		found := false
+		for _, s := range p.Skills {
+			if s.Skill == req.Skill && s.Level >= req.Level {
+				r.Skills = append(r.Skills, s)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return r, false
+		}
+	}
+	return r, true
+}
@@ -0,0 +1,78 @@
+package nlquery
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+var may = time.Date(2018, time.May, 14, 0, 0, 0, 0, time.UTC)
+
+type cannedProvider struct {
+	completion string
+	system     string
+}
+
+func (p *cannedProvider) Complete(ctx context.Context, system string, prompt string) (string, error) {
+	p.system = system
+	return p.completion, nil
+}
+
+type profileStore struct {
+	dataaccess.DataAccess
+}
+
+func (s profileStore) ListSkillTags() ([]dataaccess.SkillTag, error) {
+	return []dataaccess.SkillTag{{Name: "react native", Aliases: []string{"rn"}}}, nil
+}
+
+func (s profileStore) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	june := time.Date(2018, time.June, 1, 0, 0, 0, 0, time.UTC)
+	return []dataaccess.Profile{
+		{EmailAddress: "booked@github.com", Skills: []dataaccess.Skill{{Skill: "react native", Level: 4}},
+			Bookings: []dataaccess.Booking{{Start: june, End: june.AddDate(0, 1, 0), Percentage: 100}}},
+		{EmailAddress: "half@github.com", Skills: []dataaccess.Skill{{Skill: "react native", Level: 4}},
+			Bookings: []dataaccess.Booking{{Start: june, End: june.AddDate(0, 0, 7), Percentage: 50}}},
+		{EmailAddress: "free@github.com", Skills: []dataaccess.Skill{{Skill: "react native", Level: 2}}},
+		{EmailAddress: "novice@github.com", Skills: []dataaccess.Skill{{Skill: "react native", Level: 1}}},
+	}, nil
+}
+
+func TestThatQuestionsAreInterpretedAndAnswered(t *testing.T) {
+	provider := &cannedProvider{completion: "```json\n" +
+		`{"skills": [{"skill": "RN", "level": 2}], "freeFrom": "2018-06-01", "freeUntil": "2018-06-30"}` + "\n```"}
+	i := NewInterpreter(profileStore{}, provider)
+	i.now = func() time.Time { return may }
+
+	a, err := i.Ask(context.Background(), "github.com", "who can build a React Native app and is free in June")
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	if !strings.Contains(provider.system, "Today is 2018-05-14.") {
+		t.Errorf("Expected the model to be told the date, but the instructions were %s", provider.system)
+	}
+	if len(a.Query.Skills) != 1 || a.Query.Skills[0].Skill != "react native" {
+		t.Errorf("Expected the alias to be resolved, but received %+v", a.Query)
+	}
+	if len(a.Results) != 2 || a.Results[0].EmailAddress != "free@github.com" || a.Results[1].EmailAddress != "half@github.com" || a.Results[1].Allocation != 50 {
+		t.Errorf("Expected the free person, then the half booked one, but received %+v", a.Results)
+	}
+}
+
+func TestThatInvalidInterpretationsAreRejected(t *testing.T) {
+	for _, completion := range []string{
+		`{"skills": [{"skill": "go", "level": 9}]}`,
+		`{"freeFrom": "June"}`,
+		`{"freeFrom": "2018-06-30", "freeUntil": "2018-06-01"}`,
+	} {
+		i := NewInterpreter(profileStore{}, &cannedProvider{completion: completion})
+		_, err := i.Ask(context.Background(), "github.com", "who?")
+		if _, ok := err.(dataaccess.ValidationError); !ok {
+			t.Errorf("For %s, expected a validation error, but received %v", completion, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/a-h/pill/email"
+)
+
+// EmailChannel delivers notifications by email.
+type EmailChannel struct {
+	Sender email.Sender
+}
+
+// Deliver sends the notification as an email.
+func (c EmailChannel) Deliver(n Notification) error {
+	return c.Sender.Send(email.Message{
+		To:      []string{n.To},
+		Subject: n.Subject,
+		Text:    n.Text,
+		HTML:    n.HTML,
+	})
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// SlackChannel delivers notifications as direct messages from a Slack bot.
+// The recipient is found by their email address.
+type SlackChannel struct {
+	// Token is the bot's OAuth token, which needs the users:read.email and
+	// chat:write scopes.
+	Token string
+	// BaseURL is the address of the Slack API.
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewSlackChannel creates an instance of the SlackChannel.
+func NewSlackChannel(token string) *SlackChannel {
+	return &SlackChannel{token, "https://slack.com/api", httpClient}
+}
+
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+	User  struct {
+		ID string `json:"id"`
+	} `json:"user"`
+}
+
+func (c SlackChannel) call(req *http.Request) (slackResponse, error) {
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return slackResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	var sr slackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&sr); err != nil {
+		return sr, err
+	}
+	if !sr.OK {
+		return sr, errors.New("slack: " + sr.Error)
+	}
+	return sr, nil
+}
+
+// Deliver sends the notification as a direct message.
+func (c SlackChannel) Deliver(n Notification) error {
+	req, err := http.NewRequest("GET", c.BaseURL+"/users.lookupByEmail?email="+url.QueryEscape(n.To), nil)
+	if err != nil {
+		return err
+	}
+
+	user, err := c.call(req)
+	if err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"channel": user.User.ID,
+		"text":    fmt.Sprintf("*%s*\n\n%s", n.Subject, n.Text),
+	})
+
+	req, err = http.NewRequest("POST", c.BaseURL+"/chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+
+	_, err = c.call(req)
+	return err
+}
+
+// TeamsChannel delivers notifications to a Microsoft Teams channel through
+// an incoming webhook. Teams webhooks can't send direct messages, so the
+// notification is addressed to the recipient in the text.
+type TeamsChannel struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+// NewTeamsChannel creates an instance of the TeamsChannel.
+func NewTeamsChannel(webhookURL string) *TeamsChannel {
+	return &TeamsChannel{webhookURL, httpClient}
+}
+
+// Deliver posts the notification to the webhook.
+func (c TeamsChannel) Deliver(n Notification) error {
+	body, _ := json.Marshal(map[string]string{
+		"title": n.Subject,
+		"text":  fmt.Sprintf("For %s\n\n%s", n.To, n.Text),
+	})
+
+	resp, err := c.Client.Post(c.WebhookURL, "application/json; charset=UTF-8", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("teams: the webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
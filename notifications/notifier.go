@@ -0,0 +1,69 @@
+// Package notifications delivers notifications to people through the
+// channels they've chosen.
+package notifications
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Notification is a message to a single person.
+type Notification struct {
+	Category dataaccess.NotificationCategory
+	// To is the email address of the recipient.
+	To      string
+	Subject string
+	Text    string
+	// HTML is an optional HTML version of the Text, used by channels which
+	// support it.
+	HTML string
+}
+
+// A Channel delivers notifications.
+type Channel interface {
+	Deliver(n Notification) error
+}
+
+// The Notifier delivers notifications according to each recipient's
+// preferences. Every sender of notifications should use a Notifier, so that
+// preferences are always respected.
+type Notifier struct {
+	Channels map[dataaccess.NotificationChannel]Channel
+}
+
+// NewNotifier creates a Notifier which delivers to the channels.
+func NewNotifier(channels map[dataaccess.NotificationChannel]Channel) *Notifier {
+	return &Notifier{channels}
+}
+
+// Notify delivers the notification to each of the recipient's channels,
+// unless they've muted its category. Channels which aren't configured are
+// skipped.
+func (n *Notifier) Notify(p dataaccess.NotificationPreferences, notification Notification) error {
+	if !p.Allows(notification.Category) {
+		log.Printf("Not notifying %s, because they have muted %s notifications.", notification.To, notification.Category)
+		return nil
+	}
+
+	var failed []string
+	for _, name := range p.EffectiveChannels() {
+		c, ok := n.Channels[name]
+		if !ok {
+			log.Printf("Not notifying %s by %s, because the channel is not configured.", notification.To, name)
+			continue
+		}
+
+		if err := c.Deliver(notification); err != nil {
+			log.Printf("Failed to notify %s by %s. %v", notification.To, name, err)
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("notifications: failed to notify %s (%s)", notification.To, strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+type recordingChannel struct {
+	delivered []Notification
+	err       error
+}
+
+func (c *recordingChannel) Deliver(n Notification) error {
+	c.delivered = append(c.delivered, n)
+	return c.err
+}
+
+func TestThatNotificationsRespectPreferences(t *testing.T) {
+	tests := []struct {
+		name          string
+		preferences   dataaccess.NotificationPreferences
+		expectedEmail int
+		expectedSlack int
+	}{
+		{"defaults", dataaccess.NotificationPreferences{}, 1, 0},
+		{"slack only", dataaccess.NotificationPreferences{Channels: []dataaccess.NotificationChannel{dataaccess.SlackChannel}}, 0, 1},
+		{"muted", dataaccess.NotificationPreferences{MutedCategories: []dataaccess.NotificationCategory{dataaccess.DigestCategory}}, 0, 0},
+		{"unconfigured channel", dataaccess.NotificationPreferences{Channels: []dataaccess.NotificationChannel{dataaccess.TeamsChannel}}, 0, 0},
+	}
+
+	for _, test := range tests {
+		emailChannel, slackChannel := &recordingChannel{}, &recordingChannel{}
+		n := NewNotifier(map[dataaccess.NotificationChannel]Channel{
+			dataaccess.EmailChannel: emailChannel,
+			dataaccess.SlackChannel: slackChannel,
+		})
+
+		if err := n.Notify(test.preferences, Notification{Category: dataaccess.DigestCategory, To: "a-h@github.com"}); err != nil {
+			t.Errorf("For the '%s' test, unexpected error %v", test.name, err)
+		}
+
+		if len(emailChannel.delivered) != test.expectedEmail || len(slackChannel.delivered) != test.expectedSlack {
+			t.Errorf("For the '%s' test, expected %d emails and %d Slack messages, but %d and %d were delivered.",
+				test.name, test.expectedEmail, test.expectedSlack, len(emailChannel.delivered), len(slackChannel.delivered))
+		}
+	}
+}
+
+func TestThatDeliveryFailuresAreReturned(t *testing.T) {
+	failing := &recordingChannel{err: errors.New("failed")}
+	n := NewNotifier(map[dataaccess.NotificationChannel]Channel{dataaccess.EmailChannel: failing})
+
+	if err := n.Notify(dataaccess.NotificationPreferences{}, Notification{To: "a-h@github.com"}); err == nil {
+		t.Error("Expected the delivery failure to be returned.")
+	}
+}
+
+func TestThatSlackMessagesAreSentToTheUserWithTheEmailAddress(t *testing.T) {
+	var posted map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Error("Expected the bot token to be sent.")
+		}
+		switch r.URL.Path {
+		case "/users.lookupByEmail":
+			if r.URL.Query().Get("email") != "a-h@github.com" {
+				t.Errorf("Unexpected email lookup %s", r.URL.Query().Get("email"))
+			}
+			w.Write([]byte(`{"ok":true,"user":{"id":"U123"}}`))
+		case "/chat.postMessage":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer server.Close()
+
+	c := NewSlackChannel("token")
+	c.BaseURL = server.URL
+
+	if err := c.Deliver(Notification{To: "a-h@github.com", Subject: "Hello", Text: "World"}); err != nil {
+		t.Fatal("Failed to deliver the Slack message.", err)
+	}
+
+	if posted["channel"] != "U123" || !strings.Contains(posted["text"], "World") {
+		t.Errorf("Expected a direct message to U123, but posted %v", posted)
+	}
+}
+
+func TestThatTeamsWebhookErrorsAreReturned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	if err := NewTeamsChannel(server.URL).Deliver(Notification{To: "a-h@github.com"}); err == nil {
+		t.Error("Expected the webhook's error status to be returned.")
+	}
+}
@@ -0,0 +1,167 @@
+// Package offboarding takes care of the profiles of people who the HR sync
+// has marked as having left, in tenants which have switched offboarding on.
+package offboarding
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/i18n"
+	"github.com/a-h/pill/notifications"
+)
+
+// Reason is recorded on the profiles which are archived by the Job.
+const Reason = "left"
+
+// A Job revokes the sessions of people who have left, and archives or
+// deletes their profiles once the tenant's grace period is over.
+type Job struct {
+	DataAccess dataaccess.DataAccess
+	Notifier   *notifications.Notifier
+	// BaseURL is the address of the pill website.
+	BaseURL string
+	now     func() time.Time
+}
+
+// NewJob creates an instance of the Job.
+func NewJob(da dataaccess.DataAccess, notifier *notifications.Notifier, baseURL string) *Job {
+	return &Job{da, notifier, baseURL, time.Now}
+}
+
+// Leaving returns true if the HR system says that the person has left.
+func Leaving(p dataaccess.Profile) bool {
+	return p.Employment != nil && !p.Employment.Terminated.IsZero()
+}
+
+// Due returns true if the grace period after the person left is over at the
+// time.
+func Due(o dataaccess.OffboardingSettings, p dataaccess.Profile, at time.Time) bool {
+	return Leaving(p) && !at.Before(p.Employment.Terminated.AddDate(0, 0, o.GraceDays))
+}
+
+// PurgeAfter returns when a profile offboarded at the time is permanently
+// deleted, or zero if it's archived until it's restored.
+func PurgeAfter(o dataaccess.OffboardingSettings, at time.Time) time.Time {
+	if o.Action != dataaccess.DeleteLeavers {
+		return time.Time{}
+	}
+	return at.AddDate(0, 0, o.PurgeDays)
+}
+
+// Notification tells the manager that the leaver's profile has been archived
+// or deleted.
+func Notification(manager dataaccess.Profile, leaver dataaccess.Profile, o dataaccess.OffboardingSettings, baseURL string) notifications.Notification {
+	t := i18n.For(manager.Language)
+	name := leaver.Name
+	if name == "" {
+		name = leaver.EmailAddress
+	}
+	text := t("offboarding.archived", name, strings.TrimSuffix(baseURL, "/")+"/admin/archive/")
+	if o.Action == dataaccess.DeleteLeavers {
+		text = t("offboarding.deleted", name, o.PurgeDays, strings.TrimSuffix(baseURL, "/")+"/admin/archive/")
+	}
+	return notifications.Notification{
+		Category: dataaccess.AnnouncementCategory,
+		To:       manager.EmailAddress,
+		Subject:  t("offboarding.subject", name),
+		Text:     text,
+	}
+}
+
+// Run offboards the leavers in each domain which has offboarding enabled,
+// then purges the deleted profiles which can no longer be restored.
+// Failures to offboard individual people are logged, so that one bad
+// profile doesn't prevent the rest being offboarded, and the last error is
+// returned.
+func (j *Job) Run(ctx context.Context) error {
+	now := j.now()
+
+	domains, err := j.DataAccess.ListDomains()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		settings, err := j.DataAccess.GetSettings(domain)
+		if err != nil {
+			return err
+		}
+		if !settings.Offboarding.Enabled {
+			continue
+		}
+
+		profiles, err := j.DataAccess.ListProfiles("@" + domain)
+		if err != nil {
+			return err
+		}
+
+		revoked, offboarded := 0, 0
+		for _, p := range profiles {
+			if !Leaving(p) {
+				continue
+			}
+			// Sessions are revoked on every run until the profile is
+			// offboarded, so that the revocation outlives the grace period.
+			if err := j.DataAccess.RevokeSessions(p.EmailAddress, now); err != nil {
+				log.Printf("Failed to revoke the sessions of %s. %v", p.EmailAddress, err)
+				lastErr = err
+				continue
+			}
+			revoked++
+			if !Due(settings.Offboarding, p, now) {
+				continue
+			}
+			if err := j.offboard(p, settings, now); err != nil {
+				log.Printf("Failed to offboard %s. %v", p.EmailAddress, err)
+				lastErr = err
+				continue
+			}
+			offboarded++
+		}
+		log.Printf("Revoked the sessions of %d leavers in %s, and offboarded %d.", revoked, domain, offboarded)
+	}
+
+	purged, err := j.DataAccess.PurgeArchivedProfiles(now)
+	if err != nil {
+		return err
+	}
+	log.Printf("Purged %d deleted profiles.", purged)
+
+	return lastErr
+}
+
+// offboard removes the leaver's share links, archives their profile and
+// tells their manager.
+func (j *Job) offboard(p dataaccess.Profile, settings dataaccess.Settings, now time.Time) error {
+	links, err := j.DataAccess.ListShareLinks(p.EmailAddress)
+	if err != nil {
+		return err
+	}
+	for _, l := range links {
+		if err := j.DataAccess.DeleteShareLink(l.ID); err != nil {
+			return err
+		}
+	}
+
+	archived, err := j.DataAccess.ArchiveProfile(p.EmailAddress, Reason, PurgeAfter(settings.Offboarding, now))
+	if err != nil || !archived {
+		return err
+	}
+
+	if !settings.Offboarding.NotifyManager || !settings.Notifications.EmailEnabled || p.Manager == "" {
+		return nil
+	}
+	manager, found, err := j.DataAccess.GetProfile(p.Manager)
+	if err != nil || !found {
+		return err
+	}
+	return j.Notifier.Notify(manager.Notifications, Notification(*manager, p, settings.Offboarding, j.BaseURL))
+}
@@ -0,0 +1,61 @@
+package offboarding
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatLeaversAreOffboardedAfterTheGracePeriod(t *testing.T) {
+	left := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+	o := dataaccess.OffboardingSettings{Enabled: true, GraceDays: 30, Action: dataaccess.ArchiveLeavers}
+	leaver := dataaccess.Profile{Employment: &dataaccess.Employment{Terminated: left}}
+
+	tests := []struct {
+		name     string
+		p        dataaccess.Profile
+		at       time.Time
+		expected bool
+	}{
+		{"without employment", dataaccess.Profile{}, left.AddDate(1, 0, 0), false},
+		{"still employed", dataaccess.Profile{Employment: &dataaccess.Employment{EmployeeID: "1"}}, left.AddDate(1, 0, 0), false},
+		{"within the grace period", leaver, left.AddDate(0, 0, 29), false},
+		{"at the end of the grace period", leaver, left.AddDate(0, 0, 30), true},
+	}
+
+	for _, test := range tests {
+		if actual := Due(o, test.p, test.at); actual != test.expected {
+			t.Errorf("%s: expected %v, but got %v", test.name, test.expected, actual)
+		}
+	}
+}
+
+func TestThatOnlyDeletedProfilesArePurged(t *testing.T) {
+	at := time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	if p := PurgeAfter(dataaccess.OffboardingSettings{Action: dataaccess.ArchiveLeavers, PurgeDays: 30}, at); !p.IsZero() {
+		t.Errorf("expected archived profiles to be kept, but they're purged after %v", p)
+	}
+	if p := PurgeAfter(dataaccess.OffboardingSettings{Action: dataaccess.DeleteLeavers, PurgeDays: 30}, at); !p.Equal(at.AddDate(0, 0, 30)) {
+		t.Errorf("expected deleted profiles to be purged after 30 days, but they're purged after %v", p)
+	}
+}
+
+func TestThatTheManagerIsToldHowToRestoreTheProfile(t *testing.T) {
+	manager := dataaccess.Profile{EmailAddress: "boss@github.com"}
+	leaver := dataaccess.Profile{EmailAddress: "dev@github.com", Name: "Dev"}
+	o := dataaccess.OffboardingSettings{Action: dataaccess.DeleteLeavers, PurgeDays: 14}
+
+	n := Notification(manager, leaver, o, "https://pill.example.com/")
+
+	if n.To != manager.EmailAddress {
+		t.Errorf("expected the notification to be sent to %s, but was %s", manager.EmailAddress, n.To)
+	}
+	for _, s := range []string{"Dev", "14 days", "https://pill.example.com/admin/archive/"} {
+		if !strings.Contains(n.Text, s) {
+			t.Errorf("expected the notification to contain '%s', but was '%s'", s, n.Text)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package onepager
+
+import (
+	"fmt"
+	"image"
+	// The logo formats which can be decoded.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/pdf"
+)
+
+// maxLogoSize is the largest logo which is downloaded, in bytes.
+const maxLogoSize = 1 << 20
+
+// Branding is how the tenant's PDF profiles look.
+type Branding struct {
+	// Logo is drawn at the top right of the first page, if it isn't nil.
+	Logo    image.Image
+	Primary pdf.Color
+	Accent  pdf.Color
+	Footer  string
+}
+
+// DefaultBranding is pill's own.
+var DefaultBranding = Branding{
+	Primary: pdf.Black,
+	Accent:  pdf.Color{R: 0.2, G: 0.2, B: 0.2},
+}
+
+// NewBranding applies the tenant's branding settings to the
+// DefaultBranding. The logo is downloaded separately, with LoadLogo.
+func NewBranding(s dataaccess.BrandingSettings, logo image.Image) Branding {
+	b := DefaultBranding
+	b.Logo = logo
+	if c, ok := pdf.HexColor(s.PrimaryColor); ok {
+		b.Primary = c
+	}
+	if c, ok := pdf.HexColor(s.AccentColor); ok {
+		b.Accent = c
+	}
+	b.Footer = s.FooterText
+	return b
+}
+
+var logoClient = &http.Client{Timeout: 5 * time.Second}
+
+// LoadLogo downloads and decodes the logo at the URL.
+func LoadLogo(url string) (image.Image, error) {
+	resp, err := logoClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("onepager: the logo returned %s", resp.Status)
+	}
+	img, _, err := image.Decode(io.LimitReader(resp.Body, maxLogoSize))
+	return img, err
+}
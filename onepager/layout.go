@@ -0,0 +1,141 @@
+package onepager
+
+import (
+	"image"
+	"math"
+	"strings"
+
+	"github.com/a-h/pill/pdf"
+)
+
+const margin = 56
+
+// The largest size the logo is drawn at, in points.
+const (
+	logoWidth  = 120
+	logoHeight = 48
+)
+
+// A style is how a kind of markup line is drawn.
+type style struct {
+	font    pdf.Font
+	size    float64
+	indent  float64
+	before  float64
+	heading bool
+}
+
+var (
+	titleStyle     = style{pdf.HelveticaBold, 22, 0, 0, true}
+	headingStyle   = style{pdf.HelveticaBold, 13, 0, 14, true}
+	bulletStyle    = style{pdf.Helvetica, 10, 12, 2, false}
+	paragraphStyle = style{pdf.Helvetica, 10, 0, 2, false}
+	footerStyle    = style{pdf.Helvetica, 8, 0, 0, false}
+)
+
+// layout draws each line of the markup in its style, wrapping lines which
+// are too wide, and starting new pages when the page is full. Titles and
+// headings are drawn in the primary colour, and rules in the accent colour.
+func layout(title string, markup string, b Branding) *pdf.Document {
+	doc := pdf.NewDocument(title)
+	width := float64(pdf.A4Width - 2*margin)
+	bottom := float64(margin)
+	if b.Footer != "" {
+		bottom += footerStyle.size * 2
+	}
+
+	var page *pdf.Page
+	var y float64
+	addPage := func() {
+		page = doc.AddPage()
+		y = float64(pdf.A4Height - margin)
+		if b.Footer != "" {
+			page.Line(margin, margin+footerStyle.size*1.5, margin+width, margin+footerStyle.size*1.5, 0.5, b.Accent)
+			page.Text(margin, margin, footerStyle.font, footerStyle.size, b.Accent, b.Footer)
+		}
+	}
+	addPage()
+	if b.Logo != nil {
+		w, h := logoSize(b.Logo)
+		page.Image(margin+width-w, float64(pdf.A4Height-margin)-h, w, h, b.Logo)
+	}
+
+	gap := false
+	for _, line := range strings.Split(markup, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			gap = true
+			continue
+		}
+
+		s, text, bullet := paragraphStyle, line, false
+		switch {
+		case strings.HasPrefix(line, "## "):
+			s, text = headingStyle, strings.TrimPrefix(line, "## ")
+		case strings.HasPrefix(line, "# "):
+			s, text = titleStyle, strings.TrimPrefix(line, "# ")
+		case strings.HasPrefix(line, "- "):
+			s, text, bullet = bulletStyle, strings.TrimPrefix(line, "- "), true
+		}
+
+		y -= s.before
+		if gap {
+			y -= paragraphStyle.size
+			gap = false
+		}
+		for i, l := range wrap(text, s.font, s.size, width-s.indent) {
+			leading := s.size * 1.3
+			if y-leading < bottom {
+				addPage()
+			}
+			y -= leading
+			c := pdf.Black
+			if s.heading {
+				c = b.Primary
+			}
+			if bullet && i == 0 {
+				page.Text(margin, y, s.font, s.size, b.Accent, "•")
+			}
+			page.Text(margin+s.indent, y, s.font, s.size, c, l)
+		}
+		if s == headingStyle {
+			page.Line(margin, y-4, margin+width, y-4, 0.5, b.Accent)
+			y -= 4
+		}
+	}
+	return doc
+}
+
+// logoSize scales the logo to fit within the largest size, keeping its
+// aspect ratio.
+func logoSize(img image.Image) (float64, float64) {
+	w, h := float64(img.Bounds().Dx()), float64(img.Bounds().Dy())
+	if w == 0 || h == 0 {
+		return 0, 0
+	}
+	scale := math.Min(logoWidth/w, logoHeight/h)
+	return w * scale, h * scale
+}
+
+// wrap splits the text into lines no wider than the width, breaking between
+// words. Words wider than the width are left on lines of their own.
+func wrap(text string, font pdf.Font, size float64, width float64) []string {
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if current != "" && pdf.Width(candidate, font, size) > width {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+		current = candidate
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
@@ -0,0 +1,152 @@
+// Package onepager renders a person's skills, certifications and project
+// history as a PDF consultant profile, for including in client proposals.
+package onepager
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/i18n"
+)
+
+// DefaultTemplate is used when the tenant hasn't set a template of its own.
+// Templates are text templates which produce lines of simple markup: "# " is
+// the title, "## " starts a section, "- " is a bullet point, blank lines are
+// gaps, and other lines are paragraphs.
+const DefaultTemplate = `# {{.Name}}
+{{.EmailAddress}}
+{{if .Bio}}
+{{.Bio}}
+{{end}}
+{{- if .Skills}}
+## {{.Labels.skills}}
+{{range .Skills}}- {{.Skill}}: {{.LevelName}}
+{{end}}{{end}}
+{{- if .Certifications}}
+## {{.Labels.certifications}}
+{{range .Certifications}}- {{.Course}}, {{.Completed}}
+{{end}}{{end}}
+{{- if .Projects}}
+## {{.Labels.projects}}
+{{range .Projects}}- {{.Name}}{{if .Client}} ({{.Client}}){{end}}, {{.Dates}}
+{{end}}{{end}}`
+
+// Data is what templates are rendered with. Text is already translated into
+// the person's language, so that tenants' templates don't need functions.
+type Data struct {
+	Name           string
+	EmailAddress   string
+	Bio            string
+	Skills         []Skill
+	Certifications []Certification
+	Projects       []Project
+	// Labels are the translated section headings, by name: skills,
+	// certifications and projects.
+	Labels map[string]string
+}
+
+// A Skill is a skill on the profile, with the name of its level.
+type Skill struct {
+	Skill     string                  `json:"skill"`
+	Level     dataaccess.DreyfusLevel `json:"level"`
+	LevelName string                  `json:"levelName"`
+}
+
+// A Certification is a course the person has completed.
+type Certification struct {
+	Course    string `json:"course"`
+	Completed string `json:"completed"`
+}
+
+// A Project is one the person has been booked on.
+type Project struct {
+	Name   string
+	Client string
+	Dates  string
+}
+
+// NewData collects the profile's skills, in order of level, the approved
+// course completions, and the projects the person has been booked on
+// before the time, most recent first.
+func NewData(p *dataaccess.Profile, completions []dataaccess.CourseCompletion, projects []dataaccess.Project, at time.Time) Data {
+	language := p.Language
+	month := func(t time.Time) string { return t.Format(i18n.Message(language, "onepager.month")) }
+
+	d := Data{
+		Name:         p.Name,
+		EmailAddress: p.EmailAddress,
+		Bio:          p.Bio,
+		Labels: map[string]string{
+			"skills":         i18n.Translate(language, "onepager.skills"),
+			"certifications": i18n.Translate(language, "onepager.certifications"),
+			"projects":       i18n.Translate(language, "onepager.projects"),
+		},
+	}
+	if d.Name == "" {
+		d.Name = p.EmailAddress
+	}
+
+	skills := append([]dataaccess.Skill{}, p.Skills...)
+	sort.SliceStable(skills, func(i, j int) bool {
+		if skills[i].Level != skills[j].Level {
+			return skills[i].Level > skills[j].Level
+		}
+		return skills[i].Skill < skills[j].Skill
+	})
+	for _, s := range skills {
+		d.Skills = append(d.Skills, Skill{s.Skill, s.Level, i18n.Translate(language, "level."+strconv.Itoa(int(s.Level)))})
+	}
+
+	for _, cc := range completions {
+		if strings.EqualFold(cc.EmailAddress, p.EmailAddress) && cc.Status == dataaccess.CompletionApproved {
+			d.Certifications = append(d.Certifications, Certification{cc.Course, month(cc.Completed)})
+		}
+	}
+
+	clients := make(map[string]string)
+	for _, project := range projects {
+		clients[project.ID] = project.Client
+	}
+	bookings := append([]dataaccess.Booking{}, p.Bookings...)
+	sort.SliceStable(bookings, func(i, j int) bool { return bookings[i].Start.After(bookings[j].Start) })
+	for _, b := range bookings {
+		if !b.Start.Before(at) {
+			continue
+		}
+		dates := month(b.Start) + " - " + month(b.End)
+		if b.End.After(at) {
+			dates = month(b.Start) + " - " + i18n.Translate(language, "onepager.present")
+		}
+		d.Projects = append(d.Projects, Project{b.Project, clients[b.ProjectID], dates})
+	}
+	return d
+}
+
+// Render executes the template with the data, and lays the markup out as an
+// A4 PDF in the branding. If the template is empty, the DefaultTemplate is
+// used.
+func Render(tmpl string, d Data, b Branding) ([]byte, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = DefaultTemplate
+	}
+	t, err := template.New("onepager").Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	var markup bytes.Buffer
+	if err := t.Execute(&markup, d); err != nil {
+		return nil, err
+	}
+
+	doc := layout(d.Name, markup.String(), b)
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
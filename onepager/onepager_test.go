@@ -0,0 +1,121 @@
+package onepager
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/pdf"
+	"github.com/a-h/pill/resume"
+)
+
+var march = time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestProfile() *dataaccess.Profile {
+	return &dataaccess.Profile{
+		EmailAddress: "dev@github.com",
+		Name:         "Ada Developer",
+		Bio:          "Builds distributed systems.",
+		Skills: []dataaccess.Skill{
+			{Skill: "sql", Level: dataaccess.CompetentLevel},
+			{Skill: "go", Level: dataaccess.ExpertLevel},
+			{Skill: "aws", Level: dataaccess.ExpertLevel},
+		},
+		Bookings: []dataaccess.Booking{
+			{Project: "Website", ProjectID: "1", Start: march.AddDate(0, -6, 0), End: march.AddDate(0, -2, 0)},
+			{Project: "Payments", Start: march.AddDate(0, -1, 0), End: march.AddDate(0, 2, 0)},
+			{Project: "Future", Start: march.AddDate(0, 1, 0), End: march.AddDate(0, 2, 0)},
+		},
+	}
+}
+
+func TestThatDataIsCollectedFromTheProfile(t *testing.T) {
+	completions := []dataaccess.CourseCompletion{
+		{EmailAddress: "dev@github.com", Course: "Terraform", Status: dataaccess.CompletionApproved, Completed: march},
+		{EmailAddress: "dev@github.com", Course: "Pending", Status: dataaccess.CompletionPending, Completed: march},
+		{EmailAddress: "other@github.com", Course: "Other", Status: dataaccess.CompletionApproved, Completed: march},
+	}
+	projects := []dataaccess.Project{{ID: "1", Name: "Website", Client: "Initech"}}
+
+	d := NewData(newTestProfile(), completions, projects, march)
+
+	var skills []string
+	for _, s := range d.Skills {
+		skills = append(skills, s.Skill+":"+s.LevelName)
+	}
+	if actual := strings.Join(skills, ","); actual != "aws:Expert,go:Expert,sql:Competent" {
+		t.Errorf("Expected the skills in order of level, but got %s.", actual)
+	}
+	if len(d.Certifications) != 1 || d.Certifications[0].Course != "Terraform" || d.Certifications[0].Completed != "Mar 2018" {
+		t.Errorf("Expected only the approved completion, but got %+v.", d.Certifications)
+	}
+	expected := []Project{
+		{Name: "Payments", Dates: "Feb 2018 - present"},
+		{Name: "Website", Client: "Initech", Dates: "Sep 2017 - Jan 2018"},
+	}
+	if len(d.Projects) != 2 || d.Projects[0] != expected[0] || d.Projects[1] != expected[1] {
+		t.Errorf("Expected %+v, but got %+v.", expected, d.Projects)
+	}
+}
+
+func TestThatTheTemplateIsRenderedAsAPDF(t *testing.T) {
+	d := NewData(newTestProfile(), nil, nil, march)
+
+	tests := []struct {
+		template string
+		expected []string
+	}{
+		{"", []string{"Ada Developer", "Builds distributed systems.", "Skills", "aws", "Payments"}},
+		{"# {{.Name}}\n## Summary\n{{range .Skills}}{{.Skill}} {{end}}", []string{"Ada Developer", "Summary", "aws go sql"}},
+	}
+
+	for _, test := range tests {
+		data, err := Render(test.template, d, DefaultBranding)
+		if err != nil {
+			t.Fatalf("Unexpected error %v.", err)
+		}
+		text, _ := resume.Text("application/pdf", data)
+		for _, e := range test.expected {
+			if !strings.Contains(text, e) {
+				t.Errorf("Expected the PDF to contain %q, but got %q.", e, text)
+			}
+		}
+	}
+}
+
+func TestThatLongTextIsWrapped(t *testing.T) {
+	lines := wrap(strings.Repeat("word ", 40), 0, 10, 200)
+	if len(lines) < 2 {
+		t.Fatalf("Expected the text to be wrapped, but got %v.", lines)
+	}
+	for _, l := range lines {
+		if len(l) > 60 {
+			t.Errorf("Expected lines to fit within the width, but got %q.", l)
+		}
+	}
+}
+
+func TestThatTheBrandingIsApplied(t *testing.T) {
+	logo := image.NewRGBA(image.Rect(0, 0, 200, 50))
+	b := NewBranding(dataaccess.BrandingSettings{PrimaryColor: "#ff0000", FooterText: "Example Consulting"}, logo)
+	if b.Primary != (pdf.Color{R: 1}) || b.Accent != DefaultBranding.Accent {
+		t.Errorf("Expected the primary colour to be overridden, but got %+v.", b)
+	}
+
+	data, err := Render("", NewData(newTestProfile(), nil, nil, march), b)
+	if err != nil {
+		t.Fatalf("Unexpected error %v.", err)
+	}
+	if !bytes.Contains(data, []byte("/Subtype /Image /Width 200 /Height 50")) {
+		t.Error("Expected the logo to be drawn.")
+	}
+	if text, _ := resume.Text("application/pdf", data); !strings.Contains(text, "Example Consulting") {
+		t.Errorf("Expected the footer to be drawn, but got %q.", text)
+	}
+	if w, h := logoSize(logo); w != 120 || h != 30 {
+		t.Errorf("Expected the logo to be scaled to 120x30, but got %vx%v.", w, h)
+	}
+}
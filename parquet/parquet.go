@@ -0,0 +1,312 @@
+// Package parquet writes flat tables as Apache Parquet files, which can be
+// loaded by analytics tools such as Spark, BigQuery and Athena.
+//
+// Each file is written as a single row group, with a single uncompressed
+// page per column, so the writer is suited to the thousands of rows in a
+// partition of pill's data rather than to large datasets.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// A Type is the type of a column.
+type Type int
+
+const (
+	// String columns hold UTF-8 strings.
+	String Type = iota
+	// Int32 columns hold int values.
+	Int32
+	// Int64 columns hold int64 values.
+	Int64
+	// Bool columns hold bool values.
+	Bool
+	// Timestamp columns hold time.Time values, stored to the millisecond in
+	// UTC.
+	Timestamp
+)
+
+// A Column describes a column of a table.
+type Column struct {
+	Name string
+	Type Type
+	// Optional columns can hold nil values.
+	Optional bool
+}
+
+// Parquet physical types, converted types and encodings. See
+// https://github.com/apache/parquet-format/blob/master/src/main/thrift/parquet.thrift
+const (
+	physicalBoolean   = 0
+	physicalInt32     = 1
+	physicalInt64     = 2
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+	pageTypeData      = 0
+)
+
+var magic = []byte("PAR1")
+
+func (t Type) physical() int32 {
+	switch t {
+	case Int32:
+		return physicalInt32
+	case Int64, Timestamp:
+		return physicalInt64
+	case Bool:
+		return physicalBoolean
+	}
+	return physicalByteArray
+}
+
+// A Writer buffers the rows of a table, and writes them as a Parquet file
+// when it's closed.
+type Writer struct {
+	w       io.Writer
+	columns []Column
+	values  [][]interface{}
+	rows    int
+}
+
+// NewWriter creates a Writer of a table with the columns.
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	return &Writer{
+		w:       w,
+		columns: columns,
+		values:  make([][]interface{}, len(columns)),
+	}
+}
+
+// Write adds a row. The values must be in the order of the columns, and be
+// a string, int, int64, bool or time.Time to match the column's type, or nil
+// if the column is optional.
+func (pw *Writer) Write(row ...interface{}) error {
+	if len(row) != len(pw.columns) {
+		return fmt.Errorf("parquet: expected %d values, but got %d", len(pw.columns), len(row))
+	}
+	for i, c := range pw.columns {
+		if err := c.check(row[i]); err != nil {
+			return err
+		}
+	}
+	for i := range row {
+		pw.values[i] = append(pw.values[i], row[i])
+	}
+	pw.rows++
+	return nil
+}
+
+func (c Column) check(v interface{}) error {
+	if v == nil {
+		if c.Optional {
+			return nil
+		}
+		return fmt.Errorf("parquet: %s is required", c.Name)
+	}
+
+	ok := false
+	switch v.(type) {
+	case string:
+		ok = c.Type == String
+	case int:
+		ok = c.Type == Int32
+	case int64:
+		ok = c.Type == Int64
+	case bool:
+		ok = c.Type == Bool
+	case time.Time:
+		ok = c.Type == Timestamp
+	}
+	if !ok {
+		return fmt.Errorf("parquet: %v is the wrong type for %s", v, c.Name)
+	}
+	return nil
+}
+
+// Rows returns the number of rows written.
+func (pw *Writer) Rows() int {
+	return pw.rows
+}
+
+type chunk struct {
+	offset int64
+	size   int64
+}
+
+// Close writes the file. It doesn't close the underlying io.Writer.
+func (pw *Writer) Close() error {
+	var body bytes.Buffer
+	body.Write(magic)
+
+	chunks := make([]chunk, len(pw.columns))
+	for i, c := range pw.columns {
+		page := encodePage(c, pw.values[i])
+
+		var header thriftWriter
+		header.beginStruct()
+		header.i32(1, pageTypeData)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.structField(5)
+		header.i32(1, int32(len(pw.values[i])))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.endStruct()
+		header.endStruct()
+
+		chunks[i] = chunk{offset: int64(body.Len()), size: int64(header.buf.Len() + len(page))}
+		body.Write(header.buf.Bytes())
+		body.Write(page)
+	}
+
+	footer := pw.footer(chunks)
+	body.Write(footer)
+	binary.Write(&body, binary.LittleEndian, uint32(len(footer)))
+	body.Write(magic)
+
+	_, err := pw.w.Write(body.Bytes())
+	return err
+}
+
+func (pw *Writer) footer(chunks []chunk) []byte {
+	var t thriftWriter
+	var total int64
+	for _, c := range chunks {
+		total += c.size
+	}
+
+	t.beginStruct()
+	t.i32(1, 1)
+
+	t.list(2, thriftStruct, len(pw.columns)+1)
+	t.beginStruct()
+	t.string(4, "schema")
+	t.i32(5, int32(len(pw.columns)))
+	t.endStruct()
+	for _, c := range pw.columns {
+		t.beginStruct()
+		t.i32(1, c.Type.physical())
+		repetition := int32(repetitionRequired)
+		if c.Optional {
+			repetition = repetitionOptional
+		}
+		t.i32(3, repetition)
+		t.string(4, c.Name)
+		switch c.Type {
+		case String:
+			t.i32(6, convertedUTF8)
+		case Timestamp:
+			t.i32(6, convertedTimestampMillis)
+		}
+		t.endStruct()
+	}
+
+	t.i64(3, int64(pw.rows))
+
+	t.list(4, thriftStruct, 1)
+	t.beginStruct()
+	t.list(1, thriftStruct, len(pw.columns))
+	for i, c := range pw.columns {
+		t.beginStruct()
+		t.i64(2, chunks[i].offset)
+		t.structField(3)
+		t.i32(1, c.Type.physical())
+		t.list(2, thriftI32, 2)
+		t.zigzag(encodingPlain)
+		t.zigzag(encodingRLE)
+		t.list(3, thriftBinary, 1)
+		t.binary(c.Name)
+		t.i32(4, codecUncompressed)
+		t.i64(5, int64(pw.rows))
+		t.i64(6, chunks[i].size)
+		t.i64(7, chunks[i].size)
+		t.i64(9, chunks[i].offset)
+		t.endStruct()
+		t.endStruct()
+	}
+	t.i64(2, total)
+	t.i64(3, int64(pw.rows))
+	t.endStruct()
+
+	t.string(6, "pill")
+	t.endStruct()
+	return t.buf.Bytes()
+}
+
+// encodePage returns the definition levels, for optional columns, followed by
+// the PLAIN encoded values which aren't nil.
+func encodePage(c Column, values []interface{}) []byte {
+	var page bytes.Buffer
+
+	if c.Optional {
+		levels := definitionLevels(values)
+		binary.Write(&page, binary.LittleEndian, uint32(len(levels)))
+		page.Write(levels)
+	}
+
+	var bits []bool
+	for _, v := range values {
+		switch v := v.(type) {
+		case string:
+			binary.Write(&page, binary.LittleEndian, uint32(len(v)))
+			page.WriteString(v)
+		case int:
+			binary.Write(&page, binary.LittleEndian, int32(v))
+		case int64:
+			binary.Write(&page, binary.LittleEndian, v)
+		case time.Time:
+			binary.Write(&page, binary.LittleEndian, v.UnixNano()/int64(time.Millisecond))
+		case bool:
+			bits = append(bits, v)
+		}
+	}
+
+	// Booleans are bit packed, least significant bit first.
+	if len(bits) > 0 {
+		packed := make([]byte, (len(bits)+7)/8)
+		for i, b := range bits {
+			if b {
+				packed[i/8] |= 1 << uint(i%8)
+			}
+		}
+		page.Write(packed)
+	}
+	return page.Bytes()
+}
+
+// definitionLevels encodes whether each value is present, 1, or nil, 0, as
+// runs of the RLE/bit-packing hybrid encoding with a bit width of 1.
+func definitionLevels(values []interface{}) []byte {
+	var levels thriftWriter
+	for i := 0; i < len(values); {
+		present := values[i] != nil
+		n := 1
+		for i+n < len(values) && (values[i+n] != nil) == present {
+			n++
+		}
+		levels.varint(uint64(n) << 1)
+		if present {
+			levels.buf.WriteByte(1)
+		} else {
+			levels.buf.WriteByte(0)
+		}
+		i += n
+	}
+	return levels.buf.Bytes()
+}
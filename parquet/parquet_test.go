@@ -0,0 +1,223 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// thriftReader decodes the Thrift compact protocol, so that tests can check
+// the files which are written.
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	fields := map[int16]interface{}{}
+	var last int16
+	for {
+		b := r.b[r.pos]
+		r.pos++
+		if b == 0 {
+			return fields
+		}
+		if delta := int16(b >> 4); delta == 0 {
+			last = int16(r.zigzag())
+		} else {
+			last += delta
+		}
+		fields[last] = r.value(b & 0x0f)
+	}
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.varint())
+		s := string(r.b[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		h := r.b[r.pos]
+		r.pos++
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(r.varint())
+		}
+		l := make([]interface{}, n)
+		for i := range l {
+			l[i] = r.value(h & 0x0f)
+		}
+		return l
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("unsupported thrift type")
+}
+
+// readColumn decodes the values of a column chunk written by the Writer.
+func readColumn(file []byte, offset int64, c Column) []interface{} {
+	r := &thriftReader{b: file, pos: int(offset)}
+	header := r.readStruct()
+	n := int(header[5].(map[int16]interface{})[1].(int64))
+	page := bytes.NewReader(file[r.pos : r.pos+int(header[2].(int64))])
+
+	present := make([]bool, n)
+	if c.Optional {
+		var size uint32
+		binary.Read(page, binary.LittleEndian, &size)
+		levels := make([]byte, size)
+		page.Read(levels)
+		lr := &thriftReader{b: levels}
+		for i := 0; lr.pos < len(levels); {
+			run := int(lr.varint() >> 1)
+			v := levels[lr.pos] == 1
+			lr.pos++
+			for j := 0; j < run; j++ {
+				present[i] = v
+				i++
+			}
+		}
+	} else {
+		for i := range present {
+			present[i] = true
+		}
+	}
+
+	values := make([]interface{}, n)
+	var bits []bool
+	if c.Type == Bool {
+		packed := make([]byte, page.Len())
+		page.Read(packed)
+		for _, b := range packed {
+			for i := uint(0); i < 8; i++ {
+				bits = append(bits, b&(1<<i) != 0)
+			}
+		}
+	}
+	for i := range values {
+		if !present[i] {
+			continue
+		}
+		switch c.Type {
+		case String:
+			var size uint32
+			binary.Read(page, binary.LittleEndian, &size)
+			s := make([]byte, size)
+			page.Read(s)
+			values[i] = string(s)
+		case Int32:
+			var v int32
+			binary.Read(page, binary.LittleEndian, &v)
+			values[i] = int(v)
+		case Int64:
+			var v int64
+			binary.Read(page, binary.LittleEndian, &v)
+			values[i] = v
+		case Timestamp:
+			var v int64
+			binary.Read(page, binary.LittleEndian, &v)
+			values[i] = time.Unix(0, v*int64(time.Millisecond)).UTC()
+		case Bool:
+			values[i], bits = bits[0], bits[1:]
+		}
+	}
+	return values
+}
+
+func TestThatWrittenFilesCanBeReadBack(t *testing.T) {
+	columns := []Column{
+		{Name: "emailAddress", Type: String},
+		{Name: "level", Type: Int32, Optional: true},
+		{Name: "version", Type: Int64},
+		{Name: "current", Type: Bool},
+		{Name: "lastUpdated", Type: Timestamp, Optional: true},
+	}
+	date := time.Date(2017, time.June, 1, 9, 30, 0, 0, time.UTC)
+	rows := [][]interface{}{
+		{"a@github.com", 3, int64(1), true, date},
+		{"b@github.com", nil, int64(2), false, nil},
+		{"c@github.com", nil, int64(3), true, date.Add(time.Hour)},
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, columns)
+	for _, row := range rows {
+		if err := w.Write(row...); err != nil {
+			t.Fatalf("Failed to write %v: %v", row, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close the writer: %v", err)
+	}
+
+	file := buf.Bytes()
+	if !bytes.HasPrefix(file, magic) || !bytes.HasSuffix(file, magic) {
+		t.Fatalf("Expected the file to start and end with PAR1.")
+	}
+	footerLength := binary.LittleEndian.Uint32(file[len(file)-8:])
+	footer := &thriftReader{b: file[len(file)-8-int(footerLength) : len(file)-8]}
+	metadata := footer.readStruct()
+
+	if metadata[3].(int64) != 3 {
+		t.Errorf("Expected 3 rows, but the metadata has %v.", metadata[3])
+	}
+	schema := metadata[2].([]interface{})
+	if len(schema) != len(columns)+1 {
+		t.Fatalf("Expected the schema to have a root and %d columns, but got %d elements.", len(columns), len(schema))
+	}
+
+	chunks := metadata[4].([]interface{})[0].(map[int16]interface{})[1].([]interface{})
+	for i, c := range columns {
+		if name := schema[i+1].(map[int16]interface{})[4]; name != c.Name {
+			t.Errorf("Expected column %d to be %s, but was %v.", i, c.Name, name)
+		}
+
+		actual := readColumn(file, chunks[i].(map[int16]interface{})[2].(int64), c)
+		var expected []interface{}
+		for _, row := range rows {
+			expected = append(expected, row[i])
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("For column %s, expected %v, but got %v.", c.Name, expected, actual)
+		}
+	}
+}
+
+func TestThatValuesAreCheckedAgainstTheColumns(t *testing.T) {
+	w := NewWriter(&bytes.Buffer{}, []Column{{Name: "name", Type: String}, {Name: "level", Type: Int32, Optional: true}})
+
+	tests := []struct {
+		row      []interface{}
+		expected string
+	}{
+		{[]interface{}{"a"}, "parquet: expected 2 values, but got 1"},
+		{[]interface{}{nil, 1}, "parquet: name is required"},
+		{[]interface{}{"a", "1"}, "parquet: 1 is the wrong type for level"},
+	}
+
+	for _, test := range tests {
+		if err := w.Write(test.row...); err == nil || err.Error() != test.expected {
+			t.Errorf("For %v, expected error '%s', but got %v.", test.row, test.expected, err)
+		}
+	}
+	if w.Rows() != 0 {
+		t.Errorf("Expected invalid rows not to be written, but %d were.", w.Rows())
+	}
+}
@@ -0,0 +1,229 @@
+// Package pdf writes simple PDF documents of text, lines and rectangles in
+// the standard Helvetica fonts, which every PDF reader has, so that no fonts
+// need to be embedded.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The page sizes, in points.
+const (
+	A4Width  = 595
+	A4Height = 842
+)
+
+// A Font is one of the standard fonts.
+type Font int
+
+// The fonts which can be used.
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+var fontNames = []string{"Helvetica", "Helvetica-Bold"}
+
+// A Color is an RGB colour, with each component between 0 and 1.
+type Color struct {
+	R, G, B float64
+}
+
+// Black is the default colour of text.
+var Black = Color{0, 0, 0}
+
+// HexColor parses a colour written as #rrggbb.
+func HexColor(s string) (Color, bool) {
+	if len(s) != 7 || s[0] != '#' {
+		return Color{}, false
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return Color{}, false
+	}
+	return Color{float64(v>>16&0xff) / 255, float64(v>>8&0xff) / 255, float64(v&0xff) / 255}, true
+}
+
+// A Document is a sequence of pages.
+type Document struct {
+	Title  string
+	pages  []*Page
+	images []image.Image
+}
+
+// NewDocument creates an empty document.
+func NewDocument(title string) *Document {
+	return &Document{Title: title}
+}
+
+// AddPage adds an A4 page to the end of the document.
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// A Page is drawn on from the bottom left corner, in points.
+type Page struct {
+	content bytes.Buffer
+	doc     *Document
+}
+
+// Text draws the text with its baseline starting at the position.
+func (p *Page) Text(x, y float64, font Font, size float64, c Color, s string) {
+	fmt.Fprintf(&p.content, "BT %s rg /F%d %s Tf %s %s Td (%s) Tj ET\n",
+		c.operands(), font+1, num(size), num(x), num(y), escape(s))
+}
+
+// Rect fills a rectangle.
+func (p *Page) Rect(x, y, width, height float64, c Color) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n", c.operands(), num(x), num(y), num(width), num(height))
+}
+
+// Line draws a line.
+func (p *Page) Line(x1, y1, x2, y2, width float64, c Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s m %s %s l S\n", c.operands(), num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Image draws the image scaled to the rectangle.
+func (p *Page) Image(x, y, width, height float64, img image.Image) {
+	p.doc.images = append(p.doc.images, img)
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(width), num(height), num(x), num(y), len(p.doc.images))
+}
+
+func (c Color) operands() string {
+	return num(c.R) + " " + num(c.G) + " " + num(c.B)
+}
+
+func num(f float64) string {
+	s := fmt.Sprintf("%.3f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "" || s == "-" {
+		return "0"
+	}
+	return s
+}
+
+// winAnsi are the characters WinAnsiEncoding adds to Latin-1.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// escape encodes the text in WinAnsiEncoding, which matches Latin-1 for the
+// characters most names need. Other characters are replaced with '?'.
+func escape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		b, ok := winAnsi[r]
+		switch {
+		case ok:
+			fmt.Fprintf(&sb, "\\%03o", b)
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r >= 32 && r < 127:
+			sb.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&sb, "\\%03o", r)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return sb.String()
+}
+
+// Width returns the width of the text in the font, in points.
+func Width(s string, font Font, size float64) float64 {
+	widths := helveticaWidths
+	if font == HelveticaBold {
+		widths = helveticaBoldWidths
+	}
+	var w int
+	for _, r := range s {
+		if r >= 32 && r < 127 {
+			w += widths[r-32]
+		} else {
+			w += 556
+		}
+	}
+	return float64(w) * size / 1000
+}
+
+// Write writes the document. Page content is compressed.
+func (d *Document) Write(w io.Writer) error {
+	var buf bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// The catalog, page tree, fonts and information dictionary are objects 1
+	// to 5, followed by each page and its content, then the images.
+	const info = 5
+	pageObj := func(i int) int { return info + 1 + i*2 }
+	imageObj := func(i int) int { return pageObj(len(d.pages)) + i }
+	var xobjects []string
+	for i := range d.images {
+		xobjects = append(xobjects, fmt.Sprintf("/Im%d %d 0 R", i+1, imageObj(i)))
+	}
+	var kids []string
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObj(i)))
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for _, name := range fontNames {
+		obj("<< /Type /Font /Subtype /Type1 /BaseFont /" + name + " /Encoding /WinAnsiEncoding >>")
+	}
+	obj("<< /Title (" + escape(d.Title) + ") /Producer (pill) >>")
+
+	for i, p := range d.pages {
+		compressed := deflate(p.content.Bytes())
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> /XObject << %s >> >> /Contents %d 0 R >>",
+			A4Width, A4Height, strings.Join(xobjects, " "), pageObj(i)+1))
+		obj(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", len(compressed), compressed))
+	}
+	for _, img := range d.images {
+		b := img.Bounds()
+		rgb := make([]byte, 0, b.Dx()*b.Dy()*3)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				// Transparent pixels are blended with the white page.
+				a := int(c.A)
+				rgb = append(rgb, byte((int(c.R)*a+255*(255-a))/255), byte((int(c.G)*a+255*(255-a))/255), byte((int(c.B)*a+255*(255-a))/255))
+			}
+		}
+		compressed := deflate(rgb)
+		obj(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream",
+			b.Dx(), b.Dy(), len(compressed), compressed))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, info, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func deflate(data []byte) []byte {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+	return compressed.Bytes()
+}
@@ -0,0 +1,72 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestThatTheCrossReferenceTablePointsAtEachObject(t *testing.T) {
+	doc := NewDocument("Profile (draft)")
+	doc.AddPage().Text(56, 700, Helvetica, 10, Black, "Größe (1) \\ 2 • €")
+	doc.AddPage().Rect(0, 0, 10, 10, Color{1, 0, 0})
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("Expected the document to end with startxref.")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	if len(entries) != 9 {
+		t.Fatalf("Expected 9 objects, but got %d.", len(entries))
+	}
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		if expected := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(data[offset:], []byte(expected)) {
+			t.Errorf("Expected object %d at offset %d.", i+1, offset)
+		}
+	}
+}
+
+func TestThatTextIsEscaped(t *testing.T) {
+	expected := `Gr\366\337e \(1\) \\ 2 \225 \200 ?`
+	if actual := escape("Größe (1) \\ 2 • € 漢"); actual != expected {
+		t.Errorf("Expected %s, but got %s.", expected, actual)
+	}
+}
+
+func TestThatTextIsMeasured(t *testing.T) {
+	tests := []struct {
+		text     string
+		font     Font
+		expected float64
+	}{
+		{"Hello", Helvetica, 22.78},
+		{"Hello", HelveticaBold, 24.45},
+		{"", Helvetica, 0},
+	}
+	for _, test := range tests {
+		if actual := Width(test.text, test.font, 10); fmt.Sprintf("%.2f", actual) != fmt.Sprintf("%.2f", test.expected) {
+			t.Errorf("For %s, expected %.2f, but got %.2f.", test.text, test.expected, actual)
+		}
+	}
+}
+
+func TestThatHexColorsAreParsed(t *testing.T) {
+	if c, ok := HexColor("#ff8000"); !ok || c != (Color{1, 128.0 / 255, 0}) {
+		t.Errorf("Expected orange, but got %v, %v.", c, ok)
+	}
+	for _, s := range []string{"", "ff8000", "#ff80", "#gg8000"} {
+		if _, ok := HexColor(s); ok {
+			t.Errorf("Expected %q to be rejected.", s)
+		}
+	}
+}
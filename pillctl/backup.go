@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/a-h/pill/backup"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/encryption"
+)
+
+// backupFlags holds the flags used to open the backup store.
+type backupFlags struct {
+	store         *string
+	masterKeyFile *string
+}
+
+func newBackupFlags(fs *flag.FlagSet) backupFlags {
+	return backupFlags{
+		store:         fs.String("store", "", "The URL of the backup store, e.g. s3://bucket/pill, gs://bucket/pill, azure://account/container or file:///var/backups/pill."),
+		masterKeyFile: fs.String("masterKeyFile", "", "The path to the file containing the master key backups are encrypted with."),
+	}
+}
+
+func (f backupFlags) backuper(db backup.Database) (*backup.Backuper, error) {
+	if *f.store == "" || *f.masterKeyFile == "" {
+		return nil, usageErrorf("the -store and -masterKeyFile flags are required")
+	}
+	kp, err := encryption.LoadKeyFile(*f.masterKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	store, err := backup.OpenStore(*f.store)
+	if err != nil {
+		return nil, err
+	}
+	return backup.NewBackuper(db, store, kp, backup.DefaultRetention), nil
+}
+
+// database returns the main database and the shards, which are backed up and
+// restored together.
+func (spec shardSpec) database(db database) (backup.Database, error) {
+	shards, err := dataaccess.ParseShards(*spec.shards)
+	if err != nil {
+		return nil, err
+	}
+	return backup.NewShardedMongoDatabase(*db.connectionString, *db.databaseName, shards), nil
+}
+
+func takeBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	db := databaseFlags(fs)
+	spec := shardFlags(fs)
+	bf := newBackupFlags(fs)
+	retention := fs.Duration("retention", backup.DefaultRetention, "How long backups are kept for. The latest backup is always kept.")
+	out := outputFlag(fs)
+	fs.Parse(args)
+
+	d, err := spec.database(db)
+	if err != nil {
+		return err
+	}
+	b, err := bf.backuper(d)
+	if err != nil {
+		return err
+	}
+	b.Retention = *retention
+
+	name, err := b.Backup()
+	if err != nil {
+		return err
+	}
+
+	t := newTable("backup")
+	t.add(name)
+	return out.write(restoredBackup{Backup: name}, t)
+}
+
+// A restoredBackup is the backup taken or restored, and the database it was
+// restored to.
+type restoredBackup struct {
+	Backup   string `json:"backup"`
+	Database string `json:"database,omitempty"`
+}
+
+func restoreBackup(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	db := databaseFlags(fs)
+	spec := shardFlags(fs)
+	bf := newBackupFlags(fs)
+	name := fs.String("name", "", "The backup to restore, e.g. pill-20170601T020000Z.backup. If empty, the latest backup is restored.")
+	list := fs.Bool("list", false, "List the backups in the store instead of restoring one.")
+	out := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl restore -store s3://bucket/pill -masterKeyFile key [-name pill-20170601T020000Z.backup]")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Replaces the collections in the database, and in each of the -shards, with")
+		fmt.Fprintln(os.Stderr, "those in the backup.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	d, err := spec.database(db)
+	if err != nil {
+		return err
+	}
+	b, err := bf.backuper(d)
+	if err != nil {
+		return err
+	}
+
+	if *list {
+		backups, err := b.List()
+		if err != nil {
+			return err
+		}
+		t := newTable("backup")
+		for _, n := range backups {
+			t.add(n)
+		}
+		if backups == nil {
+			backups = []string{}
+		}
+		return out.write(backups, t)
+	}
+
+	restored, err := b.Restore(*name)
+	if err != nil {
+		return err
+	}
+
+	t := newTable("backup", "database")
+	t.add(restored, *db.databaseName)
+	return out.write(restoredBackup{restored, *db.databaseName}, t)
+}
@@ -0,0 +1,780 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func browse(args []string) error {
+	fs := flag.NewFlagSet("browse", flag.ExitOnError)
+	baseURL := fs.String("url", "", "The address of the pill service. Defaults to the one you logged in to.")
+	token := fs.String("token", os.Getenv("PILL_TOKEN"), "An access token from POST /api/token/, to use instead of logging in with pillctl login. Defaults to $PILL_TOKEN.")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl browse")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Searches and reads the profiles of the people in your tenant, and changes")
+		fmt.Fprintln(os.Stderr, "your own skills, from the terminal. Log in with pillctl login first.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	u, tokens, err := apiTokens(*baseURL, *token)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	b, err := newBrowser(newAPIClient(u, tokens))
+	if err != nil {
+		return err
+	}
+	_, err = tea.NewProgram(b, tea.WithAltScreen()).Run()
+	return err
+}
+
+// An apiClient calls pill's HTTP API with an access token, so that the
+// service decides who the person is, what they can see, and records their
+// changes in the audit log as theirs.
+type apiClient struct {
+	baseURL string
+	tokens  tokenSource
+	client  *http.Client
+}
+
+func newAPIClient(baseURL string, tokens tokenSource) *apiClient {
+	return &apiClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		tokens:  tokens,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			// Saving a profile redirects browsers to the report, which
+			// isn't needed here.
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// do sends the request, and returns the service's error message if it
+// fails. A refused access token is replaced and the request sent again, if
+// there's another token to try.
+func (c *apiClient) do(method string, path string, query url.Values, form url.Values) (*http.Response, error) {
+	for retried := false; ; retried = true {
+		resp, err := c.send(method, path, query, form)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && !retried && c.tokens.refused() {
+			resp.Body.Close()
+			continue
+		}
+		if resp.StatusCode >= http.StatusBadRequest {
+			defer resp.Body.Close()
+			msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+			if resp.StatusCode == http.StatusUnauthorized {
+				return nil, fmt.Errorf("%s Run pillctl login, or get a new token from /api/token/.", strings.TrimSpace(string(msg)))
+			}
+			return nil, fmt.Errorf("%s (%s)", strings.TrimSpace(string(msg)), resp.Status)
+		}
+		return resp, nil
+	}
+}
+
+func (c *apiClient) send(method string, path string, query url.Values, form url.Values) (*http.Response, error) {
+	token, err := c.tokens.token()
+	if err != nil {
+		return nil, err
+	}
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	return c.client.Do(req)
+}
+
+func (c *apiClient) get(path string, query url.Values, v interface{}) error {
+	resp, err := c.do(http.MethodGet, path, query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// profile returns the profile of the person the token was issued to.
+func (c *apiClient) profile() (p dataaccess.Profile, err error) {
+	err = c.get("/v1/profile/", nil, &p)
+	return
+}
+
+func (c *apiClient) search(q dataaccess.SearchQuery) (results []dataaccess.SearchDocument, err error) {
+	query := url.Values{}
+	if q.Text != "" {
+		query.Set("q", q.Text)
+	}
+	if q.Skill != "" {
+		query.Set("skill", q.Skill)
+	}
+	if q.Level > 0 {
+		query.Set("level", strconv.Itoa(int(q.Level)))
+	}
+	err = c.get("/v1/search/", query, &results)
+	return
+}
+
+// A card is someone's profile card, with their highest level skills.
+type card struct {
+	Name         string               `json:"name"`
+	EmailAddress string               `json:"emailAddress"`
+	Skills       []dataaccess.Skill   `json:"skills"`
+	Availability dataaccess.RagStatus `json:"availability"`
+}
+
+// maxCardSkills is the most skills the service puts on a card.
+const maxCardSkills = 10
+
+func (c *apiClient) card(emailAddress string) (pc card, err error) {
+	query := url.Values{"emailAddress": {emailAddress}, "skills": {strconv.Itoa(maxCardSkills)}}
+	err = c.get("/v1/profile/card/", query, &pc)
+	return
+}
+
+// updateProfile posts the availability and skills, as the profile page
+// does. It returns false if the change is waiting to be approved.
+func (c *apiClient) updateProfile(availability dataaccess.RagStatus, skills []dataaccess.Skill) (saved bool, err error) {
+	form := url.Values{"availability": {strconv.Itoa(int(availability))}}
+	for i, s := range skills {
+		form.Set("name_"+strconv.Itoa(i), s.Skill)
+		form.Set("level_"+strconv.Itoa(i), strconv.Itoa(int(s.Level)))
+		form.Set("interest_"+strconv.Itoa(i), strconv.Itoa(int(s.Interest)))
+	}
+	resp, err := c.do(http.MethodPost, "/v1/profile/", nil, form)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode != http.StatusAccepted, nil
+}
+
+// A screen is one of the browser's screens.
+type screen int
+
+const (
+	searchScreen screen = iota
+	cardScreen
+	profileScreen
+	skillScreen
+)
+
+// The fields of the skill form.
+const (
+	skillField = iota
+	levelField
+	interestField
+)
+
+// A browser is the full screen terminal UI of pillctl browse. People search
+// for others and read their cards, and change the skills on their own
+// profile.
+type browser struct {
+	api    *apiClient
+	me     dataaccess.Profile
+	domain string
+	screen screen
+
+	query textinput.Model
+	// bySkill is true if the query is a skill, and optionally a level, rather
+	// than words.
+	bySkill  bool
+	searched dataaccess.SearchQuery
+	results  []dataaccess.SearchDocument
+	result   int
+	card     card
+
+	// skill is the selected skill on the person's profile.
+	skill int
+	// fields are the skill, level and interest of the skill being added or
+	// changed, and editing is the name of the skill being changed.
+	fields  [3]textinput.Model
+	field   int
+	editing string
+
+	// status is the outcome of the last change, or an error.
+	status string
+	height int
+}
+
+// newBrowser reads the person's own profile, to find out who the token was
+// issued to, so that a token which doesn't work fails straight away.
+func newBrowser(api *apiClient) (browser, error) {
+	me, err := api.profile()
+	if err != nil {
+		return browser{}, err
+	}
+	query := textinput.New()
+	query.Prompt = "Search: "
+	query.Placeholder = "name, skill or department"
+	query.Focus()
+
+	var fields [3]textinput.Model
+	for i, name := range []string{"Skill", "Level", "Interest"} {
+		fields[i] = textinput.New()
+		fields[i].Prompt = fmt.Sprintf("%-10s", name+":")
+	}
+	fields[levelField].Placeholder = "1 to 5"
+	fields[interestField].Placeholder = "1 to 5"
+
+	return browser{
+		api:    api,
+		me:     me,
+		domain: dataaccess.GetDomain(me.EmailAddress),
+		query:  query,
+		fields: fields,
+	}, nil
+}
+
+// Messages carry the service's responses back to the browser.
+type (
+	searchedMsg struct {
+		query   dataaccess.SearchQuery
+		results []dataaccess.SearchDocument
+	}
+	cardMsg    card
+	profileMsg dataaccess.Profile
+	savedMsg   struct {
+		profile dataaccess.Profile
+		saved   bool
+	}
+	errMsg struct{ err error }
+)
+
+func (b browser) Init() tea.Cmd {
+	return nil
+}
+
+func (b browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.height = msg.Height
+		return b, nil
+	case errMsg:
+		b.status = msg.err.Error()
+		return b, nil
+	case searchedMsg:
+		b.searched, b.results, b.result = msg.query, msg.results, 0
+		b.status = ""
+		if len(msg.results) == 0 {
+			b.status = "Nobody matches."
+		}
+		return b, nil
+	case cardMsg:
+		b.card = card(msg)
+		b.screen = cardScreen
+		b.status = ""
+		return b, nil
+	case profileMsg:
+		b.me = dataaccess.Profile(msg)
+		b.skill = clamp(b.skill, len(b.me.Skills))
+		return b, nil
+	case savedMsg:
+		b.me = msg.profile
+		b.skill = clamp(b.skill, len(b.me.Skills))
+		b.screen = profileScreen
+		b.status = "Saved."
+		if !msg.saved {
+			b.status = "Your change is waiting to be approved."
+		}
+		return b, nil
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return b, tea.Quit
+		}
+		switch b.screen {
+		case searchScreen:
+			return b.searchKey(msg)
+		case cardScreen:
+			return b.cardKey(msg)
+		case profileScreen:
+			return b.profileKey(msg)
+		case skillScreen:
+			return b.skillKey(msg)
+		}
+	}
+	return b, nil
+}
+
+func (b browser) searchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		return b, tea.Quit
+	case "tab":
+		b.screen = profileScreen
+		b.status = ""
+		return b, b.refreshProfile()
+	case "ctrl+t":
+		b.bySkill = !b.bySkill
+		b.query.Placeholder = "name, skill or department"
+		if b.bySkill {
+			b.query.Placeholder = "skill, and optionally a level, e.g. go 3"
+		}
+		return b, nil
+	case "up", "ctrl+p":
+		b.result = clamp(b.result-1, len(b.results))
+		return b, nil
+	case "down", "ctrl+n":
+		b.result = clamp(b.result+1, len(b.results))
+		return b, nil
+	case "enter":
+		q, err := b.parseQuery()
+		if err != nil {
+			b.status = err.Error()
+			return b, nil
+		}
+		// Enter searches again when the query changes, and otherwise shows
+		// the selected person.
+		if q != b.searched || len(b.results) == 0 {
+			if q == (dataaccess.SearchQuery{}) {
+				return b, nil
+			}
+			return b, b.search(q)
+		}
+		return b, b.showCard(b.results[b.result].EmailAddress)
+	}
+	// The cursor is left steady rather than blinking, so the input's
+	// commands aren't run.
+	b.query, _ = b.query.Update(msg)
+	return b, nil
+}
+
+// parseQuery reads the search from the query input.
+func (b browser) parseQuery() (dataaccess.SearchQuery, error) {
+	value := strings.TrimSpace(b.query.Value())
+	if !b.bySkill {
+		return dataaccess.SearchQuery{Text: value}, nil
+	}
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return dataaccess.SearchQuery{}, nil
+	}
+	if len(fields) > 2 {
+		return dataaccess.SearchQuery{}, fmt.Errorf("expected a skill, and optionally a level, e.g. go 3")
+	}
+	q := dataaccess.SearchQuery{Skill: fields[0]}
+	if len(fields) == 2 {
+		level, err := parseScale(fields[1], "level")
+		if err != nil {
+			return q, err
+		}
+		q.Level = dataaccess.DreyfusLevel(level)
+	}
+	return q, nil
+}
+
+func (b browser) cardKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "backspace", "left", "q":
+		b.screen = searchScreen
+		b.status = ""
+	case "tab":
+		b.screen = profileScreen
+		b.status = ""
+		return b, b.refreshProfile()
+	}
+	return b, nil
+}
+
+func (b browser) profileKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "tab":
+		b.screen = searchScreen
+		b.status = ""
+	case "q":
+		return b, tea.Quit
+	case "up", "k":
+		b.skill = clamp(b.skill-1, len(b.me.Skills))
+	case "down", "j":
+		b.skill = clamp(b.skill+1, len(b.me.Skills))
+	case "a":
+		return b.editSkill(dataaccess.Skill{}), nil
+	case "enter", "e":
+		if len(b.me.Skills) > 0 {
+			return b.editSkill(b.me.Skills[b.skill]), nil
+		}
+	case "d", "delete":
+		if len(b.me.Skills) > 0 {
+			return b, b.updateSkills(removeSkill(b.me.Skills, b.me.Skills[b.skill].Skill))
+		}
+	}
+	return b, nil
+}
+
+// editSkill shows the form to add a skill, or to change the skill if it has
+// a name.
+func (b browser) editSkill(s dataaccess.Skill) browser {
+	b.screen = skillScreen
+	b.status = ""
+	b.editing = s.Skill
+	b.fields[skillField].SetValue(s.Skill)
+	b.fields[levelField].SetValue("")
+	b.fields[interestField].SetValue("")
+	if s.Skill != "" {
+		b.fields[levelField].SetValue(strconv.Itoa(int(s.Level)))
+		b.fields[interestField].SetValue(strconv.Itoa(int(s.Interest)))
+	}
+	return b.focus(skillField)
+}
+
+func (b browser) focus(field int) browser {
+	b.field = (field + len(b.fields)) % len(b.fields)
+	for i := range b.fields {
+		b.fields[i].Blur()
+	}
+	b.fields[b.field].Focus()
+	return b
+}
+
+func (b browser) skillKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		b.screen = profileScreen
+		b.status = ""
+		return b, nil
+	case "tab", "down":
+		return b.focus(b.field + 1), nil
+	case "shift+tab", "up":
+		return b.focus(b.field - 1), nil
+	case "enter":
+		s, err := b.parseSkill()
+		if err != nil {
+			b.status = err.Error()
+			return b, nil
+		}
+		return b, b.updateSkills(setSkill(b.me.Skills, b.editing, s))
+	}
+	// As in the search, the cursor is left steady.
+	b.fields[b.field], _ = b.fields[b.field].Update(msg)
+	return b, nil
+}
+
+// parseSkill reads the skill from the form. The interest is optional.
+func (b browser) parseSkill() (s dataaccess.Skill, err error) {
+	s.Skill = strings.ToLower(strings.TrimSpace(b.fields[skillField].Value()))
+	if s.Skill == "" {
+		return s, fmt.Errorf("enter the name of the skill")
+	}
+	level, err := parseScale(strings.TrimSpace(b.fields[levelField].Value()), "level")
+	if err != nil {
+		return s, err
+	}
+	s.Level = dataaccess.DreyfusLevel(level)
+	s.Interest = dataaccess.NeitherAgreeNorDisagree
+	if v := strings.TrimSpace(b.fields[interestField].Value()); v != "" {
+		interest, err := parseScale(v, "interest")
+		if err != nil {
+			return s, err
+		}
+		s.Interest = dataaccess.LikertScale(interest)
+	}
+	return s, nil
+}
+
+// setSkill returns the skills with the skill added, or in place of the
+// skill being changed, or of another with the same name.
+func setSkill(skills []dataaccess.Skill, editing string, s dataaccess.Skill) []dataaccess.Skill {
+	var updated []dataaccess.Skill
+	set := false
+	for _, existing := range skills {
+		if existing.Skill == s.Skill || (editing != "" && existing.Skill == editing) {
+			if !set {
+				updated = append(updated, s)
+				set = true
+			}
+			continue
+		}
+		updated = append(updated, existing)
+	}
+	if !set {
+		updated = append(updated, s)
+	}
+	return updated
+}
+
+func removeSkill(skills []dataaccess.Skill, skill string) []dataaccess.Skill {
+	var updated []dataaccess.Skill
+	for _, s := range skills {
+		if s.Skill != skill {
+			updated = append(updated, s)
+		}
+	}
+	return updated
+}
+
+func (b browser) search(q dataaccess.SearchQuery) tea.Cmd {
+	api := b.api
+	return func() tea.Msg {
+		results, err := api.search(q)
+		if err != nil {
+			return errMsg{err}
+		}
+		return searchedMsg{q, results}
+	}
+}
+
+// showCard shows the card of the person. The service only shows the people
+// in the same tenant.
+func (b browser) showCard(emailAddress string) tea.Cmd {
+	api := b.api
+	return func() tea.Msg {
+		c, err := api.card(emailAddress)
+		if err != nil {
+			return errMsg{err}
+		}
+		return cardMsg(c)
+	}
+}
+
+func (b browser) refreshProfile() tea.Cmd {
+	api := b.api
+	return func() tea.Msg {
+		p, err := api.profile()
+		if err != nil {
+			return errMsg{err}
+		}
+		return profileMsg(p)
+	}
+}
+
+// updateSkills saves the skills on the person's own profile, keeping their
+// availability. The profile page posts every skill, so the current ones are
+// read first, and the skills are changed by the caller.
+func (b browser) updateSkills(skills []dataaccess.Skill) tea.Cmd {
+	api := b.api
+	return func() tea.Msg {
+		current, err := api.profile()
+		if err != nil {
+			return errMsg{err}
+		}
+		availability := current.Availability
+		if availability == 0 {
+			availability = dataaccess.Green
+		}
+		saved, err := api.updateProfile(availability, skills)
+		if err != nil {
+			return errMsg{err}
+		}
+		p, err := api.profile()
+		if err != nil {
+			return errMsg{err}
+		}
+		return savedMsg{p, saved}
+	}
+}
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+)
+
+const (
+	searchHelp  = "enter search, or show the selected person • ↑/↓ select • ctrl+t search by skill • tab your profile • esc quit"
+	cardHelp    = "esc back • tab your profile • ctrl+c quit"
+	profileHelp = "↑/↓ select • a add a skill • enter change it • d remove it • tab search • q quit"
+	skillHelp   = "tab next field • enter save • esc cancel"
+)
+
+func (b browser) View() string {
+	var s strings.Builder
+	fmt.Fprintln(&s, titleStyle.Render(fmt.Sprintf("pill · %s · %s", b.domain, b.me.EmailAddress)))
+	fmt.Fprintln(&s)
+
+	var help string
+	switch b.screen {
+	case searchScreen:
+		b.viewSearch(&s)
+		help = searchHelp
+	case cardScreen:
+		b.viewCard(&s)
+		help = cardHelp
+	case profileScreen:
+		b.viewProfile(&s)
+		help = profileHelp
+	case skillScreen:
+		b.viewSkill(&s)
+		help = skillHelp
+	}
+
+	fmt.Fprintln(&s)
+	if b.status != "" {
+		fmt.Fprintln(&s, b.status)
+	}
+	fmt.Fprint(&s, helpStyle.Render(help))
+	return s.String()
+}
+
+func (b browser) viewSearch(w io.Writer) {
+	fmt.Fprintln(w, b.query.View())
+	fmt.Fprintln(w)
+	if len(b.results) == 0 {
+		return
+	}
+
+	var rows []string
+	for _, d := range b.results {
+		level := ""
+		if b.searched.Skill != "" {
+			level = strconv.Itoa(int(d.Level(strings.ToLower(b.searched.Skill))))
+		}
+		rows = append(rows, fmt.Sprintf("%s\t%s\t%s\t%s\t%s", d.Name, d.EmailAddress, d.Department, availabilityName(d.Availability), level))
+	}
+	writeRows(w, "Name\tEmail address\tDepartment\tAvailability\tLevel", rows, b.result, b.listHeight())
+}
+
+func (b browser) viewCard(w io.Writer) {
+	fmt.Fprintln(w, titleStyle.Render(b.card.Name))
+	fmt.Fprintln(w, b.card.EmailAddress)
+	fmt.Fprintf(w, "Availability: %s\n", availabilityName(b.card.Availability))
+	fmt.Fprintln(w)
+	writeRows(w, "Skill\tLevel\tInterest", skillRows(b.card.Skills), -1, 0)
+}
+
+func (b browser) viewProfile(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Name\t%s\n", b.me.Name)
+	fmt.Fprintf(tw, "Manager\t%s\n", b.me.Manager)
+	fmt.Fprintf(tw, "Department\t%s\n", b.me.Department)
+	fmt.Fprintf(tw, "Availability\t%s\n", availabilityName(b.me.Availability))
+	if b.me.Bio != "" {
+		fmt.Fprintf(tw, "Bio\t%s\n", b.me.Bio)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	if len(b.me.Skills) == 0 {
+		fmt.Fprintln(w, "You haven't added any skills.")
+		return
+	}
+	writeRows(w, "Skill\tLevel\tInterest", skillRows(b.me.Skills), b.skill, b.listHeight())
+}
+
+func (b browser) viewSkill(w io.Writer) {
+	title := "Add a skill"
+	if b.editing != "" {
+		title = "Change " + b.editing
+	}
+	fmt.Fprintln(w, titleStyle.Render(title))
+	fmt.Fprintln(w)
+	for _, f := range b.fields {
+		fmt.Fprintln(w, f.View())
+	}
+}
+
+// listHeight is how many rows of a list fit on the screen, or 0 if the
+// size of the screen isn't known.
+func (b browser) listHeight() int {
+	if b.height == 0 {
+		return 0
+	}
+	// The title, the input or profile, and the status and help take up to
+	// 12 lines.
+	if h := b.height - 12; h > 1 {
+		return h
+	}
+	return 1
+}
+
+func skillRows(skills []dataaccess.Skill) (rows []string) {
+	for _, s := range skills {
+		rows = append(rows, fmt.Sprintf("%s\t%d\t%d", s.Skill, s.Level, s.Interest))
+	}
+	return
+}
+
+// writeRows writes the tab separated rows as a table, highlighting the
+// selected row, if there is one. If height is more than 0, only that many
+// rows around the selected one are written.
+func writeRows(w io.Writer, header string, rows []string, selected int, height int) {
+	var table strings.Builder
+	tw := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "  "+header)
+	for _, row := range rows {
+		fmt.Fprintln(tw, "  "+row)
+	}
+	tw.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	fmt.Fprintln(w, titleStyle.Render(lines[0]))
+	lines = lines[1:]
+	from, to := 0, len(lines)
+	if height > 0 && len(lines) > height {
+		from = clamp(selected-height/2, len(lines)-height+1)
+		to = from + height
+	}
+	for i := from; i < to; i++ {
+		if i == selected {
+			fmt.Fprintln(w, selectedStyle.Render(">"+lines[i][1:]))
+			continue
+		}
+		fmt.Fprintln(w, lines[i])
+	}
+}
+
+// clamp returns i, or the nearest index from 0 to n-1.
+func clamp(i int, n int) int {
+	if i >= n {
+		i = n - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// parseScale parses a level or interest from 1 to 5.
+func parseScale(value string, name string) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < dataaccess.NoviceLevel || v > dataaccess.MasterLevel {
+		return 0, fmt.Errorf("the %s must be a number from %d to %d", name, dataaccess.NoviceLevel, dataaccess.MasterLevel)
+	}
+	return v, nil
+}
+
+func availabilityName(s dataaccess.RagStatus) string {
+	switch s {
+	case dataaccess.Red:
+		return "red"
+	case dataaccess.Amber:
+		return "amber"
+	case dataaccess.Green:
+		return "green"
+	}
+	return ""
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakeService serves the parts of pill's API used by the browser, for the
+// person with the token.
+type fakeService struct {
+	token   string
+	profile dataaccess.Profile
+	// posted is the last form posted to the profile.
+	posted map[string][]string
+}
+
+func (s *fakeService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.token {
+		http.Error(w, "The access token is invalid or has expired.", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.URL.Path == "/v1/profile/" && r.Method == http.MethodPost:
+		r.ParseForm()
+		s.posted = r.PostForm
+		http.Redirect(w, r, "/report/", http.StatusFound)
+	case r.URL.Path == "/v1/profile/":
+		json.NewEncoder(w).Encode(s.profile)
+	case r.URL.Path == "/v1/search/" && r.FormValue("skill") == "go":
+		json.NewEncoder(w).Encode([]dataaccess.SearchDocument{
+			{EmailAddress: "dev@example.com", Name: "Dev", Skills: []dataaccess.SearchSkill{{Skill: "go", Level: dataaccess.ExpertLevel}}},
+		})
+	case r.URL.Path == "/v1/search/":
+		json.NewEncoder(w).Encode([]dataaccess.SearchDocument{})
+	case r.URL.Path == "/v1/profile/card/" && r.FormValue("emailAddress") == "dev@example.com":
+		json.NewEncoder(w).Encode(card{Name: "Dev", EmailAddress: "dev@example.com", Skills: []dataaccess.Skill{{Skill: "go", Level: dataaccess.ExpertLevel}}})
+	default:
+		http.Error(w, "The profile was not found.", http.StatusNotFound)
+	}
+}
+
+func newFakeService() *fakeService {
+	return &fakeService{
+		token: "token",
+		profile: dataaccess.Profile{
+			EmailAddress: "me@example.com",
+			Availability: dataaccess.Amber,
+			Skills:       []dataaccess.Skill{{Skill: "go", Level: dataaccess.CompetentLevel, Interest: dataaccess.Agree}},
+		},
+	}
+}
+
+// press sends the keys to the browser, and runs the commands they start,
+// such as calls to the service, as the terminal would. Keys are named as
+// bubbletea names them, and anything else is typed.
+func press(m tea.Model, keys ...string) browser {
+	named := map[string]tea.KeyType{
+		"enter":     tea.KeyEnter,
+		"esc":       tea.KeyEsc,
+		"tab":       tea.KeyTab,
+		"up":        tea.KeyUp,
+		"down":      tea.KeyDown,
+		"backspace": tea.KeyBackspace,
+		"ctrl+t":    tea.KeyCtrlT,
+	}
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if kt, ok := named[k]; ok {
+			msg = tea.KeyMsg{Type: kt}
+		}
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		for cmd != nil {
+			m, cmd = m.Update(cmd())
+		}
+	}
+	return m.(browser)
+}
+
+func TestThatTheBrowserSearchesAndShowsProfilesThroughTheAPI(t *testing.T) {
+	s := newFakeService()
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	b, err := newBrowser(newAPIClient(server.URL, staticToken("token")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b = press(b, "ctrl+t", "go 4", "enter")
+	if view := b.View(); !strings.Contains(view, "> Dev") || !strings.Contains(view, "dev@example.com") {
+		t.Errorf("Expected the results to be shown, with Dev selected, but was:\n%s", view)
+	}
+	if b.searched.Skill != "go" || b.searched.Level != dataaccess.ExpertLevel {
+		t.Errorf("Expected a search for go at level 4, but was %+v", b.searched)
+	}
+
+	b = press(b, "enter")
+	if b.screen != cardScreen || !strings.Contains(b.View(), "Availability") {
+		t.Errorf("Expected Dev's card to be shown, but was:\n%s", b.View())
+	}
+
+	b = press(b, "esc", "ctrl+t", "backspace", "backspace", "backspace", "backspace", "nobody", "enter")
+	if !strings.Contains(b.View(), "Nobody matches.") {
+		t.Errorf("Expected nobody to match, but was:\n%s", b.View())
+	}
+
+	m, _ := b.Update(b.showCard("nobody@example.com")())
+	if view := m.View(); !strings.Contains(view, "The profile was not found. (404 Not Found)") {
+		t.Errorf("Expected the service's error to be shown, but was:\n%s", view)
+	}
+}
+
+func TestThatTheBrowserPostsEverySkillWhenOneChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		expected map[string]string
+	}{
+		{"add", []string{"tab", "a", "Rust", "tab", "3", "tab", "5", "enter"}, map[string]string{"availability": "2", "name_0": "go", "level_0": "2", "interest_0": "4", "name_1": "rust", "level_1": "3", "interest_1": "5"}},
+		{"change", []string{"tab", "enter", "tab", "backspace", "5", "enter"}, map[string]string{"availability": "2", "name_0": "go", "level_0": "5", "interest_0": "4"}},
+		{"remove", []string{"tab", "d"}, map[string]string{"availability": "2"}},
+	}
+
+	for _, test := range tests {
+		s := newFakeService()
+		server := httptest.NewServer(s)
+
+		b, err := newBrowser(newAPIClient(server.URL, staticToken("token")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b = press(b, test.keys...)
+		server.Close()
+
+		if !strings.Contains(b.View(), "Saved.") {
+			t.Errorf("For %s, expected the change to be saved, but was:\n%s", test.name, b.View())
+		}
+		if len(s.posted) != len(test.expected) {
+			t.Errorf("For %s, expected %v to be posted, but was %v", test.name, test.expected, s.posted)
+		}
+		for k, v := range test.expected {
+			if actual := strings.Join(s.posted[k], ","); actual != v {
+				t.Errorf("For %s, expected %s to be '%s', but was '%s'", test.name, k, v, actual)
+			}
+		}
+	}
+}
+
+func TestThatInvalidSkillsArentPosted(t *testing.T) {
+	s := newFakeService()
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	b, err := newBrowser(newAPIClient(server.URL, staticToken("token")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = press(b, "tab", "a", "rust", "tab", "9", "enter")
+
+	if s.posted != nil {
+		t.Errorf("Expected nothing to be posted, but was %v", s.posted)
+	}
+	if b.screen != skillScreen || !strings.Contains(b.View(), "the level must be a number from 1 to 5") {
+		t.Errorf("Expected the form to say what's wrong, but was:\n%s", b.View())
+	}
+}
+
+func TestThatTheBrowserDoesntStartWithAnInvalidToken(t *testing.T) {
+	server := httptest.NewServer(newFakeService())
+	defer server.Close()
+
+	_, err := newBrowser(newAPIClient(server.URL, staticToken("expired")))
+
+	if err == nil || !strings.Contains(err.Error(), "pillctl login") {
+		t.Errorf("Expected an error saying how to log in again, but was %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/dataaccess"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func deleteProfiles(args []string) error {
+	fs := flag.NewFlagSet("delete-profiles", flag.ExitOnError)
+	svc := serviceFlags(fs)
+	domain := fs.String("domain", "", "The tenant to delete profiles from, e.g. example.org.")
+	email := fs.String("email", "", "A pattern the email addresses must match, e.g. 'test-*@example.org'.")
+	department := fs.String("department", "", "Only delete profiles in the department.")
+	costCenter := fs.String("costCenter", "", "Only delete profiles in the cost center.")
+	limit := fs.Int("limit", dataaccess.DefaultBulkDeleteLimit, "The most profiles to delete. Nothing is deleted if more match.")
+	confirm := fs.String("confirm", "", "The confirmation printed by a dry run of the same query. Without it, the matching profiles are listed but not deleted.")
+	requestedBy := fs.String("requestedBy", "", "The email address of the administrator asking to delete the profiles. Required with -confirm.")
+	out := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl delete-profiles -domain example.org [-email 'test-*@example.org'] [-confirm token -requestedBy admin@example.org]")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Lists the profiles which match, and the confirmation to delete them. Run again with -confirm")
+		fmt.Fprintln(os.Stderr, "to ask for them to be deleted. A second administrator approves the deletion at")
+		fmt.Fprintln(os.Stderr, "/admin/approvals/decisions/, and the service deletes them if they still match.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *domain == "" {
+		return usageErrorf("the -domain flag is required")
+	}
+	if *confirm != "" && *requestedBy == "" {
+		return usageErrorf("the -requestedBy flag is required with -confirm")
+	}
+	q := dataaccess.ProfileQuery{
+		Domain:       *domain,
+		Filter:       dataaccess.ProfileFilter{Department: *department, CostCenter: *costCenter},
+		EmailPattern: *email,
+		Limit:        *limit,
+	}
+
+	da, err := svc.dataAccess()
+	if err != nil {
+		return err
+	}
+	plan, err := dataaccess.PlanProfileDeletion(da, q)
+	if err != nil {
+		return err
+	}
+
+	if *confirm == "" {
+		t := newTable("emailAddress")
+		for _, e := range plan.EmailAddresses {
+			t.add(e)
+		}
+		if err := out.write(plan, t); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Nothing has been deleted. To ask for the %d profiles to be deleted, run again with -confirm %s -requestedBy your email address\n", len(plan.EmailAddresses), plan.Confirmation)
+		return nil
+	}
+
+	a, err := requestDeletion(da, audit.NewMongoLog(*svc.connectionString, *svc.databaseName), plan, *confirm, *requestedBy, time.Now())
+	if err != nil {
+		return err
+	}
+	t := newTable("id", "action", "tenant", "requestedBy", "expires")
+	t.add(a.ID, a.Action, a.Tenant, a.RequestedBy, a.Expires.Format(time.RFC3339))
+	if err := out.write(a, t); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Nothing has been deleted yet. Another administrator must approve request %s at /admin/approvals/decisions/ before %s.\n", a.ID, a.Expires.Format(time.RFC3339))
+	return nil
+}
+
+// requestDeletion asks a second administrator to approve the deletion of the
+// profiles in the plan, if the confirmation is the plan's, so that a query
+// can't delete more than its dry run found.
+func requestDeletion(da dataaccess.DataAccess, l audit.Log, plan dataaccess.BulkDeletion, confirmation string, requestedBy string, now time.Time) (*dataaccess.ApprovalRequest, error) {
+	if plan.Confirmation != confirmation {
+		return nil, dataaccess.ErrBulkDeleteNotConfirmed
+	}
+	c, err := da.GetOrCreateConfiguration()
+	if err != nil {
+		return nil, err
+	}
+	if !c.Caller(requestedBy).HasRole(dataaccess.AdministratorRole) {
+		return nil, usageErrorf("%s isn't an administrator", requestedBy)
+	}
+	q := plan.Query
+	a := &dataaccess.ApprovalRequest{
+		ID:           bson.NewObjectId().Hex(),
+		Action:       dataaccess.DeleteProfilesWhereAction,
+		Tenant:       strings.ToLower(q.Domain),
+		Targets:      plan.EmailAddresses,
+		Query:        &q,
+		Confirmation: confirmation,
+		RequestedBy:  strings.ToLower(requestedBy),
+		Requested:    now.UTC(),
+		Expires:      now.UTC().Add(dataaccess.DefaultApprovalWindow),
+	}
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	if err := da.RequestApproval(a); err != nil {
+		return nil, err
+	}
+
+	e := audit.NewEntry(context.Background(), audit.ApprovalRequested, a.Tenant, strings.Join(a.Targets, ","))
+	e.Details = string(a.Action) + " " + a.ID + " requested by " + a.RequestedBy + " with pillctl"
+	if err := l.Record(e); err != nil {
+		log.Printf("Failed to record approval request %s in the audit log. %v", a.ID, err)
+	}
+	return a, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/dataaccess"
+)
+
+type approvalDataAccess struct {
+	dataaccess.DataAccess
+	requested []dataaccess.ApprovalRequest
+}
+
+func (da *approvalDataAccess) GetOrCreateConfiguration() (dataaccess.Configuration, error) {
+	return dataaccess.Configuration{Administrators: []string{"admin@example.org"}}, nil
+}
+
+func (da *approvalDataAccess) RequestApproval(a *dataaccess.ApprovalRequest) error {
+	da.requested = append(da.requested, *a)
+	return nil
+}
+
+func TestThatBulkDeletionsAreRequestedForApproval(t *testing.T) {
+	now := time.Date(2017, time.June, 1, 0, 0, 0, 0, time.UTC)
+	plan := dataaccess.BulkDeletion{
+		Query:          dataaccess.ProfileQuery{Domain: "example.org", EmailPattern: "test-*@example.org"},
+		EmailAddresses: []string{"test-1@example.org"},
+		Confirmation:   "abc",
+	}
+
+	tests := []struct {
+		name         string
+		confirmation string
+		requestedBy  string
+		expected     bool
+	}{
+		{"an administrator confirms the dry run", "abc", "admin@example.org", true},
+		{"the confirmation is from another dry run", "def", "admin@example.org", false},
+		{"someone who isn't an administrator confirms", "abc", "user@example.org", false},
+	}
+
+	for _, test := range tests {
+		da := &approvalDataAccess{}
+		l := audit.NewMemoryLog()
+
+		a, err := requestDeletion(da, l, plan, test.confirmation, test.requestedBy, now)
+		entries, _ := l.List(audit.Query{})
+
+		if (err == nil) != test.expected || len(da.requested) != len(entries) {
+			t.Errorf("When %s, expected a request: %t, but received %v with %d requests.", test.name, test.expected, err, len(da.requested))
+			continue
+		}
+		if !test.expected {
+			continue
+		}
+		if a.Action != dataaccess.DeleteProfilesWhereAction || a.Query.EmailPattern != "test-*@example.org" || !a.Expires.Equal(now.Add(dataaccess.DefaultApprovalWindow)) {
+			t.Errorf("When %s, expected the query to wait for approval, but received %+v.", test.name, a)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/doctor"
+)
+
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	db := databaseFlags(fs)
+	spec := shardFlags(fs)
+	replica := fs.String("replicaConnectionString", "", "The replica set connection string, as configured with the service's -replicaConnectionString flag.")
+	masterKeyFile := fs.String("masterKeyFile", "", "The path to the master key file the service's configuration is encrypted with.")
+	out := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl doctor [-connectionString mongodb://localhost:27017] [-masterKeyFile key] [-shards eu=mongodb://mongo-eu:27017]")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Checks the databases, indexes, permissions, master key and configuration the service is given, and says how to fix each problem.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	results := dataaccess.Deployment{
+		ConnectionString:        *db.connectionString,
+		ReplicaConnectionString: *replica,
+		DatabaseName:            *db.databaseName,
+		Shards:                  *spec.shards,
+		MasterKeyFile:           *masterKeyFile,
+	}.Check()
+	t := newTable("check", "status", "problem", "remedy")
+	for _, r := range results {
+		t.add(r.Check, r.Status, r.Problem, r.Remedy)
+	}
+	t.print = func(w io.Writer) { doctor.Print(w, results) }
+	if err := out.write(results, t); err != nil {
+		return err
+	}
+
+	if !doctor.Healthy(results) {
+		return problemsErrorf("problems were found which stop the service from working")
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/caller"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/export"
+)
+
+func exportParquet(args []string) error {
+	fs := flag.NewFlagSet("export-parquet", flag.ExitOnError)
+	svc := serviceFlags(fs)
+	dir := fs.String("dir", "export", "The directory to write the files to.")
+	exportedBy := fs.String("exportedBy", audit.SystemActor, "The email address of the person the export is for, which is recorded in the access log of everyone exported when -accessLog is set.")
+	out := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl export-parquet -dir export")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Writes profiles and skills history as Parquet files, partitioned by domain")
+		fmt.Fprintln(os.Stderr, "and month, e.g. export/history/domain=github.com/month=2017-06/part-0.parquet.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	da, err := svc.dataAccess()
+	if err != nil {
+		return err
+	}
+	da = dataaccess.WithContext(da, caller.NewContext(context.Background(), caller.Caller{EmailAddress: *exportedBy}))
+	r, err := export.NewParquetExporter(da).Export(*dir)
+	if err != nil {
+		return err
+	}
+
+	t := newTable("files", "rows", "domains")
+	t.add(r.Files, r.Rows, r.Domains)
+	return out.write(r, t)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/a-h/pill/importer"
+)
+
+func importExport(args []string) error {
+	var formats []string
+	for name := range importer.Adapters {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	svc := serviceFlags(fs)
+	format := fs.String("format", "", "The tool which exported the file, one of "+strings.Join(formats, ", ")+".")
+	dryRun := fs.Bool("dryRun", false, "Parse the file and report what would be imported, without changing the database.")
+	out := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl import -format skillsbase export.csv")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Imports skills exported from another skills management tool.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	adapter, ok := importer.Adapters[*format]
+	if !ok {
+		fs.Usage()
+		return usageErrorf("unknown format '%s'", *format)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return usageErrorf("expected a single file to import")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	updates, err := adapter(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+
+	if *dryRun {
+		t := newTable("emailAddress", "skills")
+		for _, u := range updates {
+			t.add(u.EmailAddress, len(u.Skills))
+		}
+		return out.write(updates, t)
+	}
+
+	da, err := svc.dataAccess()
+	if err != nil {
+		return err
+	}
+	r, err := importer.NewImporter(da).ImportUpdates(updates)
+	if err != nil {
+		return err
+	}
+
+	return writeImportResult(out, r)
+}
+
+func writeImportResult(out *outputFormat, r importer.Result) error {
+	t := newTable("created", "merged", "tags")
+	t.add(r.Created, r.Merged, r.Tags)
+	return out.write(r, t)
+}
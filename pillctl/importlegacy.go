@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/a-h/pill/importer"
+)
+
+func importLegacy(args []string) error {
+	fs := flag.NewFlagSet("import-legacy", flag.ExitOnError)
+	svc := serviceFlags(fs)
+	date := fs.String("date", "", "The date of the export, as YYYY-MM-DD, if it isn't in the file name.")
+	dryRun := fs.Bool("dryRun", false, "Parse the files and report what would be imported, without changing the database.")
+	out := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl import-legacy [flags] skills-2016-01-04.csv [skills-2016-02-01.csv ...]")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Each file is a snapshot of the skill matrix on the date in its name. The")
+		fmt.Fprintln(os.Stderr, "latest snapshot becomes each person's skills, earlier ones their history.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return usageErrorf("no files to import")
+	}
+
+	var snapshots []importer.Snapshot
+	for _, name := range fs.Args() {
+		d, ok := importer.DateFromFileName(name)
+		if !ok {
+			if *date == "" || fs.NArg() > 1 {
+				return usageErrorf("%s: the file name must contain the date of the export, e.g. skills-2016-01-04.csv", name)
+			}
+			var err error
+			if d, err = time.Parse("2006-01-02", *date); err != nil {
+				return usageErrorf("the date must be YYYY-MM-DD: %v", err)
+			}
+		}
+
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		s, err := importer.ParseLegacyCSV(f, d)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+
+		fmt.Fprintf(os.Stderr, "%s: %d people on %s\n", name, len(s.Rows), d.Format("2006-01-02"))
+		snapshots = append(snapshots, s)
+	}
+
+	profiles := importer.ReconstructProfiles(snapshots)
+
+	if *dryRun {
+		t := newTable("emailAddress", "skills", "history", "lastUpdated")
+		for _, p := range profiles {
+			t.add(p.EmailAddress, len(p.Skills), len(p.SkillsHistory), p.LastUpdated.Format("2006-01-02"))
+		}
+		return out.write(profiles, t)
+	}
+
+	da, err := svc.dataAccess()
+	if err != nil {
+		return err
+	}
+	r, err := importer.NewImporter(da).Import(profiles)
+	if err != nil {
+		return err
+	}
+
+	return writeImportResult(out, r)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/a-h/pill/importer"
+)
+
+func importTaxonomy(args []string) error {
+	var formats []string
+	for name := range importer.Taxonomies {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+
+	fs := flag.NewFlagSet("import-taxonomy", flag.ExitOnError)
+	db := databaseFlags(fs)
+	format := fs.String("format", "csv", "The format of the taxonomy, one of "+strings.Join(formats, ", ")+".")
+	dryRun := fs.Bool("dryRun", false, "Parse the file and list the tags which would be imported, without changing the database.")
+	out := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl import-taxonomy -format esco skills_en.csv")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Adds categorised skill tags, and their aliases, from a taxonomy.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	adapter, ok := importer.Taxonomies[*format]
+	if !ok {
+		fs.Usage()
+		return usageErrorf("unknown format '%s'", *format)
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return usageErrorf("expected a single file to import")
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tags, err := adapter(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+
+	if *dryRun {
+		t := newTable("name", "category", "aliases")
+		for _, tag := range tags {
+			t.add(tag.Name, tag.Category, strings.Join(tag.Aliases, ", "))
+		}
+		return out.write(tags, t)
+	}
+
+	added, err := db.dataAccess().ImportSkillTags(tags)
+	if err != nil {
+		return err
+	}
+
+	t := newTable("tags", "new")
+	t.add(len(tags), added)
+	return out.write(struct {
+		Tags int `json:"tags"`
+		New  int `json:"new"`
+	}{len(tags), added}, t)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/loadtest"
+)
+
+func datasetFlags(fs *flag.FlagSet) *loadtest.Dataset {
+	d := loadtest.DefaultDataset
+	fs.Int64Var(&d.Seed, "seed", d.Seed, "The seed of the generated dataset. The same seed always generates the same profiles.")
+	fs.IntVar(&d.Tenants, "tenants", d.Tenants, "The number of tenants.")
+	fs.IntVar(&d.Profiles, "profiles", d.Profiles, "The number of profiles in each tenant.")
+	fs.IntVar(&d.Skills, "skills", d.Skills, "The number of distinct skill tags.")
+	fs.IntVar(&d.SkillsPerProfile, "skillsPerProfile", d.SkillsPerProfile, "The number of skills each person has.")
+	fs.IntVar(&d.History, "history", d.History, "The number of history entries each profile has.")
+	return &d
+}
+
+func seed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	db := databaseFlags(fs)
+	d := datasetFlags(fs)
+	out := outputFlag(fs)
+	fs.Parse(args)
+
+	n, err := d.Write(dataaccess.NewMongoDataAccess(*db.connectionString, *db.databaseName))
+	if err != nil {
+		return err
+	}
+
+	t := newTable("database", "profiles")
+	t.add(*db.databaseName, n)
+	return out.write(struct {
+		Database string `json:"database"`
+		Profiles int    `json:"profiles"`
+	}{*db.databaseName, n}, t)
+}
+
+func load(args []string) error {
+	fs := flag.NewFlagSet("load", flag.ExitOnError)
+	db := databaseFlags(fs)
+	d := datasetFlags(fs)
+	concurrency := fs.Int("concurrency", 10, "The number of concurrent callers.")
+	duration := fs.Duration("duration", 30*time.Second, "How long to generate load for.")
+	out := outputFlag(fs)
+	fs.Parse(args)
+
+	fmt.Fprintf(os.Stderr, "Calling %s from %d goroutines for %v. The dataset must have been written with pillctl seed and the same flags.\n", *db.databaseName, *concurrency, *duration)
+	r := loadtest.Run(context.Background(), dataaccess.NewMongoDataAccess(*db.connectionString, *db.databaseName), loadtest.Options{
+		Dataset:     *d,
+		Concurrency: *concurrency,
+		Duration:    *duration,
+	})
+
+	var ops []string
+	for op := range r.Stats {
+		ops = append(ops, string(op))
+	}
+	sort.Strings(ops)
+	stats := []operationStats{}
+	t := newTable("operation", "calls", "perSecond", "p50Ms", "p95Ms", "p99Ms", "errors")
+	for _, op := range ops {
+		s := r.Stats[loadtest.Operation(op)]
+		stat := operationStats{op, s.Count(), float64(s.Count()) / r.Duration.Seconds(),
+			milliseconds(s.Percentile(50)), milliseconds(s.Percentile(95)), milliseconds(s.Percentile(99)), s.Errors}
+		stats = append(stats, stat)
+		t.add(stat.Operation, stat.Calls, stat.PerSecond, stat.P50, stat.P95, stat.P99, stat.Errors)
+	}
+	t.print = func(w io.Writer) { fmt.Fprint(w, r) }
+	return out.write(stats, t)
+}
+
+// operationStats are the calls made of an operation, and their latencies in
+// milliseconds.
+type operationStats struct {
+	Operation string  `json:"operation"`
+	Calls     int     `json:"calls"`
+	PerSecond float64 `json:"perSecond"`
+	P50       float64 `json:"p50Ms"`
+	P95       float64 `json:"p95Ms"`
+	P99       float64 `json:"p99Ms"`
+	Errors    int     `json:"errors"`
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
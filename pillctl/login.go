@@ -0,0 +1,365 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/middleware"
+)
+
+// defaultURL is the address of a pill service running locally.
+const defaultURL = "http://localhost:8080"
+
+// sessionCookieName is the cookie the service keeps sessions in.
+const sessionCookieName = "pill-session-cookie"
+
+func login(args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	baseURL := fs.String("url", defaultURL, "The address of the pill service.")
+	timeout := fs.Duration("timeout", 5*time.Minute, "How long to wait for you to log in from the browser.")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl login -url https://pill.example.com")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Logs in to pill from your browser, and saves the session for commands which")
+		fmt.Fprintln(os.Stderr, "use the API as you, such as browse. Run pillctl logout to forget it.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	path, err := credentialsPath()
+	if err != nil {
+		return err
+	}
+
+	state, err := newLoginState()
+	if err != nil {
+		return err
+	}
+	// The service sends the session to pillctl by redirecting the browser to
+	// this port, which only accepts connections from the same machine.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	sessions := make(chan string, 1)
+	server := &http.Server{Handler: loginCallback(state, sessions)}
+	go server.Serve(l)
+	defer server.Close()
+
+	u := strings.TrimSuffix(*baseURL, "/") + "/login/cli/?" + url.Values{
+		"port":  {strconv.Itoa(l.Addr().(*net.TCPAddr).Port)},
+		"state": {state},
+	}.Encode()
+	fmt.Fprintln(os.Stderr, "Log in to pill in your browser, at:")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "  "+u)
+	fmt.Fprintln(os.Stderr)
+	if err := openBrowser(u); err != nil {
+		fmt.Fprintln(os.Stderr, "Open the link if your browser didn't open by itself.")
+	}
+
+	var session string
+	select {
+	case session = <-sessions:
+	case <-time.After(*timeout):
+		return fmt.Errorf("gave up waiting for you to log in after %v", *timeout)
+	}
+
+	c := credentials{URL: strings.TrimSuffix(*baseURL, "/"), Session: session}
+	p, err := newAPIClient(c.URL, newSessionTokens(c, "")).profile()
+	if err != nil {
+		return err
+	}
+	if err := saveCredentials(path, c); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Logged in to %s as %s.\n", c.URL, p.EmailAddress)
+	return nil
+}
+
+func logout(args []string) error {
+	fs := flag.NewFlagSet("logout", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl logout")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Forgets the session saved by pillctl login.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	path, err := credentialsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "Logged out.")
+	return nil
+}
+
+// newLoginState returns a random value, which the service returns with the
+// session, so that pillctl only accepts the login it started.
+func newLoginState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// loginCallback receives the session from the browser's redirect, and sends
+// it to the channel.
+func loginCallback(state string, sessions chan<- string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session := r.URL.Query().Get("session")
+		if r.URL.Path != "/callback" || session == "" ||
+			subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("state")), []byte(state)) != 1 {
+			http.Error(w, "This isn't the login pillctl is waiting for. Run pillctl login again.", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, "You're logged in to pillctl. You can close this tab.")
+		select {
+		case sessions <- session:
+		default:
+		}
+	})
+}
+
+// openBrowser opens the link in the person's browser.
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
+
+// credentials are saved by pillctl login, so that later commands can call
+// the service as the person who logged in.
+type credentials struct {
+	URL     string `json:"url"`
+	Session string `json:"session"`
+}
+
+// credentialsPath returns where the credentials are saved, in the person's
+// configuration directory.
+func credentialsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pill", "credentials.json"), nil
+}
+
+// loadCredentials reads the credentials, returning false if nobody has
+// logged in.
+func loadCredentials(path string) (c credentials, ok bool, err error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, false, nil
+	}
+	if err != nil {
+		return c, false, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err == nil, err
+}
+
+// saveCredentials writes the credentials so that only the person can read
+// them, since the session can be used to act as them.
+func saveCredentials(path string, c credentials) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// A tokenSource provides the access tokens sent to the service.
+type tokenSource interface {
+	// token returns the access token to send.
+	token() (string, error)
+	// refused discards the token after the service refused it, and returns
+	// false if there isn't another to try.
+	refused() bool
+}
+
+// A staticToken is an access token given on the command line, which can't
+// be renewed.
+type staticToken string
+
+func (t staticToken) token() (string, error) {
+	return string(t), nil
+}
+
+func (t staticToken) refused() bool {
+	return false
+}
+
+// errSessionEnded is returned when the service no longer accepts the saved
+// session, because it expired or the person's sessions were revoked.
+var errSessionEnded = errors.New("your pillctl session has expired or was ended, run pillctl login again")
+
+// sessionTokens exchanges the session saved by pillctl login for access
+// tokens from /api/token/, as the web UI's scripts do, and exchanges it
+// again when they expire. The service renews sessions while they're used,
+// and a renewed session is saved for next time.
+type sessionTokens struct {
+	credentials credentials
+	// path is where renewed sessions are saved, or empty if they aren't.
+	path    string
+	access  string
+	expires time.Time
+}
+
+func newSessionTokens(c credentials, path string) *sessionTokens {
+	return &sessionTokens{credentials: c, path: path}
+}
+
+func (s *sessionTokens) token() (string, error) {
+	if s.access != "" && time.Now().Before(s.expires) {
+		return s.access, nil
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(s.credentials.URL, "/") + "/api/token/")
+	if err != nil {
+		return "", err
+	}
+	jar, _ := cookiejar.New(nil)
+	// The service keeps the session at the root, where it also renews it.
+	jar.SetCookies(u, []*http.Cookie{{Name: sessionCookieName, Value: s.credentials.Session, Path: "/"}})
+	client := &http.Client{
+		Jar:     jar,
+		Timeout: 30 * time.Second,
+		// The service redirects sessions which have ended to the logon screen.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// Tokens are only issued to requests which submit the token from the
+	// service's CSRF cookie, which it sets on any request.
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	csrf := cookieValue(jar.Cookies(u), middleware.CSRFCookieName)
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(middleware.CSRFHeaderName, csrf)
+	resp, err = client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusFound || resp.StatusCode == http.StatusUnauthorized {
+		return "", errSessionEnded
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("%s (%s)", strings.TrimSpace(string(msg)), resp.Status)
+	}
+
+	var tr struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", err
+	}
+	s.access = tr.AccessToken
+	// The token is replaced a little early, so that it doesn't expire on its
+	// way to the service.
+	s.expires = time.Now().Add(time.Duration(tr.ExpiresIn)*time.Second - 30*time.Second)
+
+	if renewed := cookieValue(resp.Cookies(), sessionCookieName); renewed != "" && renewed != s.credentials.Session {
+		s.credentials.Session = renewed
+		if s.path != "" {
+			if err := saveCredentials(s.path, s.credentials); err != nil {
+				log.Print("Failed to save the renewed session. ", err)
+			}
+		}
+	}
+	return s.access, nil
+}
+
+func (s *sessionTokens) refused() bool {
+	if s.access == "" {
+		return false
+	}
+	s.access = ""
+	return true
+}
+
+func cookieValue(cookies []*http.Cookie, name string) string {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+// apiTokens returns the service's address, and the access tokens to call
+// it with: the token given with -token, or those exchanged for the session
+// saved by pillctl login.
+func apiTokens(baseURL string, token string) (string, tokenSource, error) {
+	if token != "" {
+		if baseURL == "" {
+			baseURL = defaultURL
+		}
+		return baseURL, staticToken(token), nil
+	}
+
+	path, err := credentialsPath()
+	if err != nil {
+		return "", nil, err
+	}
+	c, ok, err := loadCredentials(path)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		return "", nil, usageErrorf("run pillctl login first, or give an access token with -token")
+	}
+	if baseURL != "" && strings.TrimSuffix(baseURL, "/") != c.URL {
+		return "", nil, usageErrorf("you're logged in to %s, run pillctl login -url %s to use %s", c.URL, baseURL, baseURL)
+	}
+	return c.URL, newSessionTokens(c, path), nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/middleware"
+)
+
+// tokenService issues access tokens to the session, as /api/token/ does,
+// and serves the profile to the last token it issued.
+type tokenService struct {
+	session string
+	// renewed is the session the service renews the session to.
+	renewed string
+	issued  int
+}
+
+func (s *tokenService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/token/":
+		if r.Method == http.MethodGet {
+			http.SetCookie(w, &http.Cookie{Name: middleware.CSRFCookieName, Value: "csrf", Path: "/"})
+			http.Error(w, "Tokens must be requested with a POST.", http.StatusMethodNotAllowed)
+			return
+		}
+		csrf, err := r.Cookie(middleware.CSRFCookieName)
+		if err != nil || csrf.Value != r.Header.Get(middleware.CSRFHeaderName) {
+			http.Error(w, "Invalid CSRF token.", http.StatusForbidden)
+			return
+		}
+		session, err := r.Cookie(sessionCookieName)
+		if err != nil || session.Value != s.session {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		if s.renewed != "" {
+			http.SetCookie(w, &http.Cookie{Name: sessionCookieName, Value: s.renewed, Path: "/"})
+			s.session = s.renewed
+		}
+		s.issued++
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token" + strconv.Itoa(s.issued), "token_type": "Bearer", "expires_in": 900})
+	case "/v1/profile/":
+		if r.Header.Get("Authorization") != "Bearer token"+strconv.Itoa(s.issued) {
+			http.Error(w, "The access token is invalid or has expired.", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(dataaccess.Profile{EmailAddress: "me@example.com"})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestThatTheSessionIsExchangedForAccessTokens(t *testing.T) {
+	s := &tokenService{session: "session", renewed: "renewed"}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "pillctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "credentials.json")
+
+	api := newAPIClient(server.URL, newSessionTokens(credentials{URL: server.URL, Session: "session"}, path))
+	if _, err := api.profile(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, ok, err := loadCredentials(path)
+	if err != nil || !ok {
+		t.Fatal("Expected the renewed session to be saved.", err)
+	}
+	if saved.Session != "renewed" {
+		t.Errorf("Expected the renewed session to be saved, but was '%s'", saved.Session)
+	}
+
+	// The service refuses the token, e.g. because the keys were rotated.
+	s.issued++
+	if _, err := api.profile(); err != nil {
+		t.Fatal("Expected a refused token to be replaced.", err)
+	}
+	if s.issued != 3 {
+		t.Errorf("Expected another token to be issued, but %d were", s.issued)
+	}
+}
+
+func TestThatAnEndedSessionAsksToLogInAgain(t *testing.T) {
+	server := httptest.NewServer(&tokenService{session: "session"})
+	defer server.Close()
+
+	api := newAPIClient(server.URL, newSessionTokens(credentials{URL: server.URL, Session: "revoked"}, ""))
+	_, err := api.profile()
+
+	if err != errSessionEnded {
+		t.Errorf("Expected the session to have ended, but was %v", err)
+	}
+}
+
+func TestThatTheLoginCallbackOnlyAcceptsItsOwnLogin(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected int
+	}{
+		{"/callback?state=state&session=session", http.StatusOK},
+		{"/callback?state=other&session=session", http.StatusBadRequest},
+		{"/callback?state=state", http.StatusBadRequest},
+		{"/other?state=state&session=session", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		sessions := make(chan string, 1)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, test.url, nil)
+
+		loginCallback("state", sessions).ServeHTTP(w, r)
+
+		if w.Code != test.expected {
+			t.Errorf("For %s, expected the status to be %d, but was %d", test.url, test.expected, w.Code)
+		}
+		if received := len(sessions) == 1; received != (test.expected == http.StatusOK) {
+			t.Errorf("For %s, expected the session to be received: %t, but was: %t", test.url, test.expected == http.StatusOK, received)
+		}
+	}
+}
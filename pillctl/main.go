@@ -0,0 +1,236 @@
+// Command pillctl administers a pill deployment from the command line.
+//
+// Usage:
+//
+//	pillctl <command> [flags] [arguments]
+//
+// Run pillctl without arguments to list the commands. Every command writes
+// its results to stdout in the format given by -output: table, json or csv.
+// Progress and errors are written to stderr. pillctl exits with 0 on
+// success, 1 if the command failed, 2 if it was used incorrectly, and 3 if
+// a check, such as doctor, found problems.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/a-h/pill/attachments"
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/badges"
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/elasticsearch"
+	"github.com/a-h/pill/encryption"
+	"github.com/a-h/pill/events"
+	"github.com/a-h/pill/goals"
+	"github.com/a-h/pill/plugins"
+	"github.com/a-h/pill/profilerules"
+	"github.com/a-h/pill/readmodel"
+)
+
+// A command is a pillctl subcommand.
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+var commands = map[string]command{
+	"backup":          {"Write an encrypted backup of the database to object storage.", takeBackup},
+	"browse":          {"Search and read profiles, and change your own skills, from the terminal.", browse},
+	"delete-profiles": {"Delete the profiles matching a query, e.g. test accounts, after a dry run.", deleteProfiles},
+	"doctor":          {"Check the deployment's databases, master key and configuration, and say how to fix problems.", runDoctor},
+	"export-parquet":  {"Export profiles and skills history as Parquet files for analytics tools.", exportParquet},
+	"import":          {"Import skills exported from another skills management tool.", importExport},
+	"import-legacy":   {"Import skill matrix CSV files exported by earlier versions of pill.", importLegacy},
+	"import-taxonomy": {"Add categorised skill tags from ESCO, O*NET or a CSV taxonomy.", importTaxonomy},
+	"load":            {"Generate load against a database seeded with pillctl seed, and report latencies.", load},
+	"login":           {"Log in to pill from your browser, for commands which use the API as you, such as browse.", login},
+	"logout":          {"Forget the session saved by login.", logout},
+	"move-tenant":     {"Move a tenant's profiles to another shard.", moveTenant},
+	"residency":       {"Require a tenant's profiles to be stored in a region.", setResidency},
+	"restore":         {"Restore the database from a backup.", restoreBackup},
+	"seed":            {"Write a generated, reproducible dataset to a database for load testing.", seed},
+	"shards":          {"List the shards and the tenants assigned to them.", listShards},
+	"sync-hr":         {"Sync employees from an HR system, and print the reconciliation report.", syncHR},
+	"verify-backup":   {"Check that a backup can be restored, using a temporary database.", verifyBackup},
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "pillctl: unknown command %s\n\n", os.Args[1])
+		usage()
+		os.Exit(exitUsage)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		log.Printf("pillctl %s: %v", os.Args[1], err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: pillctl <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-17s %s\n", name, commands[name].description)
+	}
+}
+
+// database holds the flags used to connect to pill's database.
+type database struct {
+	connectionString *string
+	databaseName     *string
+}
+
+func databaseFlags(fs *flag.FlagSet) database {
+	return database{
+		connectionString: fs.String("connectionString", "mongodb://localhost:27017", "The MongoDB connection string used to store data."),
+		databaseName:     fs.String("databaseName", "pill", "The name of the database."),
+	}
+}
+
+// dataAccess connects to the database. Changes are recorded in the audit
+// log as being made by the system.
+func (d database) dataAccess() dataaccess.DataAccess {
+	da := dataaccess.NewMongoDataAccess(*d.connectionString, *d.databaseName)
+	return dataaccess.NewAuditingDataAccess(da, audit.NewMongoLog(*d.connectionString, *d.databaseName))
+}
+
+// A service holds the flags of the commands which change profiles, which
+// must match the service's own.
+type service struct {
+	database
+	shards             shardSpec
+	masterKeyFile      *string
+	accessLog          *bool
+	eventSourcing      *bool
+	attachmentStore    *string
+	elasticsearchURL   *string
+	elasticsearchIndex *string
+}
+
+func serviceFlags(fs *flag.FlagSet) service {
+	return service{
+		database:           databaseFlags(fs),
+		shards:             shardFlags(fs),
+		masterKeyFile:      fs.String("masterKeyFile", "", "The path to the master key file, as configured with the service's -masterKeyFile flag."),
+		accessLog:          fs.Bool("accessLog", false, "Record access to profiles in the access log, as configured with the service's -accessLog flag."),
+		eventSourcing:      fs.Bool("eventSourcing", false, "Record changes to profiles in the event log, as configured with the service's -eventSourcing flag."),
+		attachmentStore:    fs.String("attachmentStore", "gridfs:///"+attachments.DefaultPrefix, "The URL of the store CVs are held in, as configured with the service's -attachmentStore flag."),
+		elasticsearchURL:   fs.String("elasticsearchURL", "", "The Elasticsearch cluster, as configured with the service's -elasticsearchURL flag."),
+		elasticsearchIndex: fs.String("elasticsearchIndex", "pill", "The Elasticsearch index, as configured with the service's -elasticsearchIndex flag."),
+	}
+}
+
+// dataAccess connects to the database with the same layers as the service,
+// so that changes reach the shard the tenant is on and the event log, keep
+// the read models, search index and badges up to date, delete people's
+// files with their profiles, and follow the tenants' rules, as well as being
+// audited as made by the system. Access to profiles is only recorded by
+// commands which give the data access a caller.
+func (s service) dataAccess() (dataaccess.DataAccess, error) {
+	var kp encryption.KeyProvider
+	var da dataaccess.DataAccess = dataaccess.NewMongoDataAccess(*s.connectionString, *s.databaseName)
+	if *s.masterKeyFile != "" {
+		var err error
+		if kp, err = encryption.LoadKeyFile(*s.masterKeyFile); err != nil {
+			return nil, err
+		}
+		da = dataaccess.NewEncryptedMongoDataAccess(*s.connectionString, *s.databaseName, kp)
+	}
+
+	var sharded *dataaccess.ShardedDataAccess
+	if *s.shards.shards != "" {
+		shards, err := dataaccess.ParseShards(*s.shards.shards)
+		if err != nil {
+			return nil, err
+		}
+		regions, err := dataaccess.ParseShardRegions(*s.shards.regions)
+		if err != nil {
+			return nil, err
+		}
+		clusters := map[string]dataaccess.DataAccess{}
+		for name, cs := range shards {
+			clusters[name] = dataaccess.NewEncryptedMongoDataAccess(cs, *s.databaseName, kp)
+		}
+		sharded = dataaccess.NewShardedDataAccess(da, clusters, dataaccess.NewMongoShardDirectory(*s.connectionString, *s.databaseName), 0)
+		sharded.Regions = regions
+		da = sharded
+	}
+
+	if *s.eventSourcing {
+		da = dataaccess.NewEventSourcingDataAccess(da, dataaccess.DefaultSnapshotInterval)
+	}
+
+	if *s.elasticsearchURL != "" {
+		client := elasticsearch.NewClient(*s.elasticsearchURL, *s.elasticsearchIndex, os.Getenv("ELASTICSEARCH_USER"), os.Getenv("ELASTICSEARCH_PASSWORD"))
+		da = elasticsearch.NewIndexingDataAccess(da, client)
+	}
+
+	store, err := s.openStore(sharded)
+	if err != nil {
+		return nil, err
+	}
+	da = attachments.NewDeletingDataAccess(da, store)
+
+	plugins.Register("profilerules", profilerules.NewHook(da))
+	da = plugins.NewHookingDataAccess(da, plugins.DefaultRegistry)
+	da = dataaccess.NewNotifyingDataAccess(da, events.Publishers{badges.NewAwarder(da), goals.NewTracker(da), readmodel.NewProjector(da), plugins.DefaultRegistry.Publishers()})
+	da = dataaccess.NewAuditingDataAccess(da, s.openLog(audit.NewMongoLog, sharded))
+	da = dataaccess.NewApprovingDataAccess(da)
+	if *s.accessLog {
+		da = dataaccess.NewAccessLoggingDataAccess(da, s.openLog(audit.NewMongoAccessLog, sharded))
+	}
+	return da, nil
+}
+
+// openStore opens the attachment store. When profiles are sharded, each
+// tenant's files are kept in the store of its shard, as the service does.
+func (s service) openStore(sharded *dataaccess.ShardedDataAccess) (attachments.Store, error) {
+	if sharded == nil {
+		return attachments.OpenStore(*s.attachmentStore, *s.connectionString, *s.databaseName)
+	}
+	// The shards have already been parsed by dataAccess.
+	clusters, _ := dataaccess.ParseShards(*s.shards.shards)
+	clusters[dataaccess.DefaultShard] = *s.connectionString
+	stores, err := attachments.OpenShardStores(*s.attachmentStore, clusters, *s.databaseName)
+	if err != nil {
+		return nil, err
+	}
+	return attachments.NewShardedStore(stores, sharded.ShardOf), nil
+}
+
+// openLog opens a log in the main database. When profiles are sharded, each
+// tenant's entries are kept in a log in the cluster of its shard, as the
+// service does.
+func (s service) openLog(open func(connectionString string, databaseName string) *audit.MongoLog, sharded *dataaccess.ShardedDataAccess) audit.Log {
+	main := open(*s.connectionString, *s.databaseName)
+	if sharded == nil {
+		return main
+	}
+	// The shards have already been parsed by dataAccess.
+	shards, _ := dataaccess.ParseShards(*s.shards.shards)
+	logs := map[string]audit.Log{}
+	for name, cs := range shards {
+		logs[name] = open(cs, *s.databaseName)
+	}
+	return audit.NewShardedLog(main, logs, sharded.ShardOf)
+}
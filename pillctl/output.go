@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// The exit codes of pillctl, so that scripts can tell why it failed.
+const (
+	// exitFailed is returned when a command fails, e.g. because the database
+	// can't be reached.
+	exitFailed = 1
+	// exitUsage is returned when the command, its flags or its arguments
+	// are invalid.
+	exitUsage = 2
+	// exitProblems is returned when a command which checks something, e.g.
+	// doctor, ran but found problems.
+	exitProblems = 3
+)
+
+// A usageError is returned when a command is given invalid flags or
+// arguments.
+type usageError struct {
+	error
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return usageError{fmt.Errorf(format, args...)}
+}
+
+// A problemsError is returned when a check ran, but found problems.
+type problemsError struct {
+	error
+}
+
+func problemsErrorf(format string, args ...interface{}) error {
+	return problemsError{fmt.Errorf(format, args...)}
+}
+
+// exitCode returns the exit code for the error a command returned.
+func exitCode(err error) int {
+	switch err.(type) {
+	case usageError:
+		return exitUsage
+	case problemsError:
+		return exitProblems
+	}
+	return exitFailed
+}
+
+// The formats commands write their results in.
+const (
+	tableOutput = "table"
+	jsonOutput  = "json"
+	csvOutput   = "csv"
+)
+
+// An outputFormat is the value of the -output flag. It's checked when the
+// flags are parsed, so that a command doesn't do its work and then fail to
+// print the result.
+type outputFormat string
+
+func (f *outputFormat) String() string {
+	return string(*f)
+}
+
+func (f *outputFormat) Set(value string) error {
+	switch value {
+	case tableOutput, jsonOutput, csvOutput:
+		*f = outputFormat(value)
+		return nil
+	}
+	return fmt.Errorf("unknown output format '%s', use table, json or csv", value)
+}
+
+func outputFlag(fs *flag.FlagSet) *outputFormat {
+	f := outputFormat(tableOutput)
+	fs.Var(&f, "output", "The format to write the results to stdout in: table, json or csv.")
+	return &f
+}
+
+// A table is the result of a command as rows, for the table and csv
+// formats.
+type table struct {
+	header []string
+	rows   [][]string
+	// print, if set, writes the table format instead of aligned columns,
+	// for results which read better as text, e.g. those of doctor.
+	print func(w io.Writer)
+}
+
+func newTable(header ...string) *table {
+	return &table{header: header}
+}
+
+// add adds a row of the values, formatted with fmt.Sprint.
+func (t *table) add(values ...interface{}) {
+	row := make([]string, len(values))
+	for i, v := range values {
+		row[i] = fmt.Sprint(v)
+	}
+	t.rows = append(t.rows, row)
+}
+
+// write writes the result to stdout in the format: v as JSON, or the table.
+func (f outputFormat) write(v interface{}, t *table) error {
+	return f.writeTo(os.Stdout, v, t)
+}
+
+func (f outputFormat) writeTo(w io.Writer, v interface{}, t *table) error {
+	switch f {
+	case jsonOutput:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case csvOutput:
+		cw := csv.NewWriter(w)
+		cw.Write(t.header)
+		cw.WriteAll(t.rows)
+		return cw.Error()
+	}
+	if t.print != nil {
+		t.print(w)
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, row := range append([][]string{t.header}, t.rows...) {
+		for i, cell := range row {
+			if i > 0 {
+				fmt.Fprint(tw, "\t")
+			}
+			fmt.Fprint(tw, cell)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestThatResultsAreWrittenInTheOutputFormat(t *testing.T) {
+	result := struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}{"go, kubernetes", 2}
+
+	tests := []struct {
+		format   outputFormat
+		print    func(w io.Writer)
+		expected string
+	}{
+		{jsonOutput, nil, "{\n  \"name\": \"go, kubernetes\",\n  \"count\": 2\n}\n"},
+		{csvOutput, nil, "name,count\n\"go, kubernetes\",2\n"},
+		{tableOutput, nil, "name            count\ngo, kubernetes  2\n"},
+		{tableOutput, func(w io.Writer) { fmt.Fprintln(w, "2 skills") }, "2 skills\n"},
+		// The text of the table is only for people, so scripts get the same
+		// results either way.
+		{csvOutput, func(w io.Writer) { fmt.Fprintln(w, "2 skills") }, "name,count\n\"go, kubernetes\",2\n"},
+	}
+
+	for _, test := range tests {
+		tbl := newTable("name", "count")
+		tbl.add(result.Name, result.Count)
+		tbl.print = test.print
+
+		var buf bytes.Buffer
+		if err := test.format.writeTo(&buf, result, tbl); err != nil {
+			t.Errorf("For %s, unexpected error %v", test.format, err)
+			continue
+		}
+
+		if buf.String() != test.expected {
+			t.Errorf("For %s, expected:\n%q\nbut received:\n%q", test.format, test.expected, buf.String())
+		}
+	}
+}
+
+func TestThatTheOutputFormatIsCheckedWhenTheFlagsAreParsed(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected outputFormat
+		valid    bool
+	}{
+		{nil, tableOutput, true},
+		{[]string{"-output", "json"}, jsonOutput, true},
+		{[]string{"-output", "csv"}, csvOutput, true},
+		{[]string{"-output", "table"}, tableOutput, true},
+		{[]string{"-output", "xml"}, "", false},
+		{[]string{"-output", "JSON"}, "", false},
+	}
+
+	for _, test := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		out := outputFlag(fs)
+
+		err := fs.Parse(test.args)
+
+		if (err == nil) != test.valid {
+			t.Errorf("For %v, expected valid %t, but received %v", test.args, test.valid, err)
+			continue
+		}
+		if test.valid && *out != test.expected {
+			t.Errorf("For %v, expected the %s format, but was %s", test.args, test.expected, *out)
+		}
+	}
+}
+
+func TestThatErrorsHaveTheirExitCodes(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{usageErrorf("the -domain flag is required"), exitUsage},
+		{problemsErrorf("%s is not restorable", "pill-20170601T020000Z.backup"), exitProblems},
+		{errors.New("no reachable servers"), exitFailed},
+	}
+
+	for _, test := range tests {
+		if actual := exitCode(test.err); actual != test.expected {
+			t.Errorf("For '%v', expected exit code %d, but was %d", test.err, test.expected, actual)
+		}
+	}
+}
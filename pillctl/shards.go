@@ -0,0 +1,239 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/attachments"
+	"github.com/a-h/pill/audit"
+	"github.com/a-h/pill/dataaccess"
+)
+
+type shardSpec struct {
+	shards  *string
+	regions *string
+}
+
+func shardFlags(fs *flag.FlagSet) shardSpec {
+	return shardSpec{
+		shards:  fs.String("shards", "", "The shards, as configured with the service's -shards flag, e.g. apac=mongodb://mongo-apac:27017."),
+		regions: fs.String("shardRegions", "", "The regions of the shards, as configured with the service's -shardRegions flag, e.g. default=US,eu=EU."),
+	}
+}
+
+func shardedDataAccess(db database, spec shardSpec) (*dataaccess.ShardedDataAccess, dataaccess.ShardDirectory, error) {
+	shards, err := dataaccess.ParseShards(*spec.shards)
+	if err != nil {
+		return nil, nil, err
+	}
+	regions, err := dataaccess.ParseShardRegions(*spec.regions)
+	if err != nil {
+		return nil, nil, err
+	}
+	clusters := map[string]dataaccess.DataAccess{}
+	for name, cs := range shards {
+		clusters[name] = dataaccess.NewMongoDataAccess(cs, *db.databaseName)
+	}
+	directory := dataaccess.NewMongoShardDirectory(*db.connectionString, *db.databaseName)
+	da := dataaccess.NewShardedDataAccess(db.dataAccess(), clusters, directory, 0)
+	da.Regions = regions
+	return da, directory, nil
+}
+
+func listShards(args []string) error {
+	fs := flag.NewFlagSet("shards", flag.ExitOnError)
+	db := databaseFlags(fs)
+	spec := shardFlags(fs)
+	out := outputFlag(fs)
+	fs.Parse(args)
+
+	da, directory, err := shardedDataAccess(db, spec)
+	if err != nil {
+		return err
+	}
+
+	assignments, err := directory.ListShardAssignments()
+	if err != nil {
+		return err
+	}
+	t := newTable("tenant", "shard", "region", "moving")
+	for _, a := range assignments {
+		t.add(a.Tenant, a.Shard, a.Region, a.Moving)
+	}
+	t.print = func(w io.Writer) {
+		fmt.Fprintln(w, "Shards:", da.Shards())
+		for _, a := range assignments {
+			status := ""
+			if a.Region != "" {
+				status = " (must stay in " + a.Region + ")"
+			}
+			if a.Moving {
+				status += " (moving)"
+			}
+			fmt.Fprintf(w, "%s: %s%s\n", a.Tenant, a.Shard, status)
+		}
+	}
+	return out.write(struct {
+		Shards      []string                     `json:"shards"`
+		Assignments []dataaccess.ShardAssignment `json:"assignments"`
+	}{da.Shards(), assignments}, t)
+}
+
+func moveTenant(args []string) error {
+	fs := flag.NewFlagSet("move-tenant", flag.ExitOnError)
+	db := databaseFlags(fs)
+	spec := shardFlags(fs)
+	tenant := fs.String("tenant", "", "The domain of the tenant to move, e.g. github.com.")
+	to := fs.String("to", "", "The name of the shard to move the tenant to, or "+dataaccess.DefaultShard+" for the main database.")
+	settle := fs.Duration("settle", dataaccess.DefaultShardDirectoryTTL, "How long to wait for running instances to stop writing to the old shard. It must be at least the directory cache duration.")
+	attachmentStore := fs.String("attachmentStore", "gridfs:///"+attachments.DefaultPrefix, "The URL of the store CVs are held in, as configured with the service's -attachmentStore flag.")
+	out := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl move-tenant -shards apac=mongodb://mongo-apac:27017 -tenant github.com -to apac")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Moves a tenant's profiles, and the other data about its people, such as their")
+		fmt.Fprintln(os.Stderr, "CVs and audit log entries, to another shard. The tenant's profiles can't be")
+		fmt.Fprintln(os.Stderr, "changed while it is moving. If the move fails, run it again.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *tenant == "" || *to == "" {
+		fs.Usage()
+		return usageErrorf("the -tenant and -to flags are required")
+	}
+
+	da, _, err := shardedDataAccess(db, spec)
+	if err != nil {
+		return err
+	}
+
+	from, err := da.ShardOf(*tenant)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	moved, err := da.MoveTenant(*tenant, *to, *settle)
+	if err != nil {
+		return err
+	}
+	if from != *to {
+		if err := moveTenantFiles(db, spec, da, *tenant, from, *to, *attachmentStore); err != nil {
+			return err
+		}
+	}
+
+	duration := time.Since(start).Round(time.Second)
+	t := newTable("tenant", "shard", "profiles", "duration")
+	t.add(*tenant, *to, moved, duration)
+	t.print = func(w io.Writer) {
+		fmt.Fprintf(w, "Moved %d profiles of %s to %s in %v.\n", moved, *tenant, *to, duration)
+	}
+	return out.write(struct {
+		Tenant   string `json:"tenant"`
+		Shard    string `json:"shard"`
+		Profiles int    `json:"profiles"`
+		Duration string `json:"duration"`
+	}{*tenant, *to, moved, duration.String()}, t)
+}
+
+// moveTenantFiles moves the tenant's audit and access log entries, and its
+// people's CVs, from the cluster and store of the shard it has left to those
+// of the shard it has moved to.
+func moveTenantFiles(db database, spec shardSpec, da *dataaccess.ShardedDataAccess, tenant, from, to, attachmentStore string) error {
+	clusters, err := dataaccess.ParseShards(*spec.shards)
+	if err != nil {
+		return err
+	}
+	clusters[dataaccess.DefaultShard] = *db.connectionString
+
+	for _, open := range []func(string, string) *audit.MongoLog{audit.NewMongoLog, audit.NewMongoAccessLog} {
+		if _, err := open(clusters[from], *db.databaseName).MoveTenant(tenant, open(clusters[to], *db.databaseName)); err != nil {
+			return fmt.Errorf("failed to move the log entries of %s: %v", tenant, err)
+		}
+	}
+
+	// An object store without a placeholder is shared by every shard, so
+	// its files are already where they need to be.
+	if !strings.HasPrefix(attachmentStore, "gridfs:") && !strings.Contains(attachmentStore, attachments.ShardPlaceholder) {
+		return nil
+	}
+	stores, err := attachments.OpenShardStores(attachmentStore, clusters, *db.databaseName)
+	if err != nil {
+		return err
+	}
+	src, dst := stores[from], stores[to]
+	profiles, err := da.ListProfiles("@" + tenant)
+	if err != nil {
+		return err
+	}
+	for _, p := range profiles {
+		if p.CV == nil {
+			continue
+		}
+		data, err := src.Get(p.CV.Name)
+		if err != nil {
+			// The CV was moved by an earlier attempt.
+			if _, dstErr := dst.Get(p.CV.Name); dstErr == nil {
+				continue
+			}
+			return fmt.Errorf("failed to read the CV of %s: %v", p.EmailAddress, err)
+		}
+		if err := dst.Put(p.CV.Name, data); err != nil {
+			return fmt.Errorf("failed to copy the CV of %s: %v", p.EmailAddress, err)
+		}
+		if err := src.Delete(p.CV.Name); err != nil {
+			return fmt.Errorf("failed to remove the CV of %s from shard %s: %v", p.EmailAddress, from, err)
+		}
+	}
+	return nil
+}
+
+func setResidency(args []string) error {
+	fs := flag.NewFlagSet("residency", flag.ExitOnError)
+	db := databaseFlags(fs)
+	spec := shardFlags(fs)
+	tenant := fs.String("tenant", "", "The domain of the tenant, e.g. github.com.")
+	region := fs.String("region", "", "The region the tenant's data must be stored in, e.g. EU, or empty to store it anywhere.")
+	out := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl residency -shards eu=mongodb://mongo-eu:27017 -shardRegions default=US,eu=EU -tenant github.com -region EU")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Requires a tenant's profiles to be stored in a region. The tenant must already")
+		fmt.Fprintln(os.Stderr, "be in a shard in the region, so move it there first with move-tenant.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *tenant == "" {
+		fs.Usage()
+		return usageErrorf("the -tenant flag is required")
+	}
+
+	da, _, err := shardedDataAccess(db, spec)
+	if err != nil {
+		return err
+	}
+	if err := da.SetResidency(*tenant, *region); err != nil {
+		return err
+	}
+
+	r := strings.ToUpper(*region)
+	t := newTable("tenant", "region")
+	t.add(*tenant, r)
+	t.print = func(w io.Writer) {
+		if r == "" {
+			fmt.Fprintf(w, "%s can be stored in any region.\n", *tenant)
+			return
+		}
+		fmt.Fprintf(w, "%s must be stored in %s.\n", *tenant, r)
+	}
+	return out.write(struct {
+		Tenant string `json:"tenant"`
+		Region string `json:"region"`
+	}{*tenant, r}, t)
+}
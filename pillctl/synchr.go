@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/a-h/pill/hr"
+)
+
+func syncHR(args []string) error {
+	fs := flag.NewFlagSet("sync-hr", flag.ExitOnError)
+	svc := serviceFlags(fs)
+	source := fs.String("source", "", "The HR system, as configured with the service's -hrSource flag, e.g. bamboohr://example.")
+	domains := fs.String("domains", "", "The comma separated tenants to sync, e.g. example.com,example.co.uk.")
+	fields := fs.String("fields", "", "Overrides of the HR system's field names, e.g. department=division.")
+	dryRun := fs.Bool("dryRun", false, "Report the changes without making them.")
+	out := outputFlag(fs)
+	fs.Parse(args)
+
+	if *source == "" || *domains == "" {
+		return usageErrorf("the -source and -domains flags are required")
+	}
+	s, err := hr.OpenSource(*source)
+	if err != nil {
+		return err
+	}
+	mapping, err := hr.ParseMapping(*fields, s.DefaultMapping())
+	if err != nil {
+		return err
+	}
+
+	da, err := svc.dataAccess()
+	if err != nil {
+		return err
+	}
+	syncer := hr.NewSyncer(da, s, mapping, strings.Split(*domains, ","))
+	syncer.DryRun = *dryRun
+	report, err := syncer.Sync(context.Background())
+	if err != nil {
+		return err
+	}
+
+	t := newTable("change", "emailAddress", "detail")
+	for _, e := range report.Joined {
+		t.add("joined", e, "")
+	}
+	for _, c := range report.Moved {
+		t.add("moved", c.EmailAddress, fmt.Sprintf("%s: %q to %q", c.Field, c.From, c.To))
+	}
+	for _, e := range report.Left {
+		t.add("left", e, "")
+	}
+	for _, s := range report.Skipped {
+		t.add("skipped", s.EmailAddress, s.EmployeeID+": "+s.Reason)
+	}
+	for _, e := range report.Unmatched {
+		t.add("unmatched", e, "")
+	}
+	for _, d := range report.Tenants {
+		t.add("tenant", "", d)
+	}
+	return out.write(report, t)
+}
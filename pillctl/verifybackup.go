@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func verifyBackup(args []string) error {
+	fs := flag.NewFlagSet("verify-backup", flag.ExitOnError)
+	db := databaseFlags(fs)
+	spec := shardFlags(fs)
+	bf := newBackupFlags(fs)
+	name := fs.String("name", "", "The backup to verify. If empty, the latest backup is verified.")
+	out := outputFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pillctl verify-backup -store s3://bucket/pill -masterKeyFile key [-name pill-20170601T020000Z.backup]")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Restores the backup into a temporary database on the MongoDB server, and on")
+		fmt.Fprintln(os.Stderr, "each of the -shards, checks that pill can read it, and then drops the")
+		fmt.Fprintln(os.Stderr, "temporary databases. Exits with status 3 if the backup can't be restored.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	shards, err := dataaccess.ParseShards(*spec.shards)
+	if err != nil {
+		return err
+	}
+	b, err := bf.backuper(nil)
+	if err != nil {
+		return err
+	}
+
+	r, err := b.Verify(*db.connectionString, shards, *name)
+	if err != nil {
+		return err
+	}
+
+	checks := []checkResult{}
+	t := newTable("check", "error")
+	for _, c := range r.Results {
+		cr := checkResult{Name: c.Name}
+		if c.Err != nil {
+			cr.Error = c.Err.Error()
+		}
+		checks = append(checks, cr)
+		t.add(cr.Name, cr.Error)
+	}
+	t.print = func(w io.Writer) { fmt.Fprint(w, r) }
+	err = out.write(struct {
+		Backup   string        `json:"backup"`
+		Restored string        `json:"restored"`
+		Checks   []checkResult `json:"checks"`
+	}{r.Backup, r.Restored.String(), checks}, t)
+	if err != nil {
+		return err
+	}
+	if !r.OK() {
+		return problemsErrorf("%s is not restorable", r.Backup)
+	}
+	return nil
+}
+
+// A checkResult is a backup.CheckResult with its error as text, so that it
+// can be written as JSON.
+type checkResult struct {
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
@@ -0,0 +1,95 @@
+package plugins
+
+import (
+	"context"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// HookingDataAccess wraps a DataAccess and calls the registered hooks before
+// profiles are updated and skill tags change, and to rank searches. Profiles
+// written in bulk, e.g. by imports and the HR sync, don't call the hooks.
+type HookingDataAccess struct {
+	dataaccess.DataAccess
+	Registry *Registry
+	ctx      context.Context
+}
+
+// NewHookingDataAccess creates a DataAccess which calls the hooks of the
+// plugins in the registry.
+func NewHookingDataAccess(da dataaccess.DataAccess, r *Registry) *HookingDataAccess {
+	return &HookingDataAccess{da, r, context.Background()}
+}
+
+// WithContext passes the context to the wrapped DataAccess and the hooks.
+func (da HookingDataAccess) WithContext(ctx context.Context) dataaccess.DataAccess {
+	return &HookingDataAccess{dataaccess.WithContext(da.DataAccess, ctx), da.Registry, ctx}
+}
+
+// UpdateProfile calls the ProfileHooks, in order of name, and updates the
+// profile unless one of them returns an error.
+func (da HookingDataAccess) UpdateProfile(update *dataaccess.ProfileUpdate) (*dataaccess.Profile, error) {
+	var hooks []ProfileHook
+	da.Registry.each(func(plugin interface{}) {
+		if h, ok := plugin.(ProfileHook); ok {
+			hooks = append(hooks, h)
+		}
+	})
+	if len(hooks) > 0 {
+		current, found, err := da.DataAccess.GetProfile(update.EmailAddress)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			current = nil
+		}
+		for _, h := range hooks {
+			if err := h.BeforeProfileUpdate(da.ctx, current, update); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return da.DataAccess.UpdateProfile(update)
+}
+
+// AddSkillTags calls the SkillTagHooks, and adds the tags unless one of them
+// returns an error.
+func (da HookingDataAccess) AddSkillTags(tags []string) error {
+	if err := da.skillTagHooks(func(h SkillTagHook) error { return h.BeforeSkillTagsAdded(da.ctx, tags) }); err != nil {
+		return err
+	}
+	return da.DataAccess.AddSkillTags(tags)
+}
+
+// DeleteSkillTags calls the SkillTagHooks, and deletes the tags unless one
+// of them returns an error.
+func (da HookingDataAccess) DeleteSkillTags(tags []string) error {
+	if err := da.skillTagHooks(func(h SkillTagHook) error { return h.BeforeSkillTagsDeleted(da.ctx, tags) }); err != nil {
+		return err
+	}
+	return da.DataAccess.DeleteSkillTags(tags)
+}
+
+func (da HookingDataAccess) skillTagHooks(f func(h SkillTagHook) error) (err error) {
+	da.Registry.each(func(plugin interface{}) {
+		if h, ok := plugin.(SkillTagHook); ok && err == nil {
+			err = f(h)
+		}
+	})
+	return err
+}
+
+// SearchDocuments finds the documents, and has the SearchRankers order the
+// results of searches for a skill or text, in order of name.
+func (da HookingDataAccess) SearchDocuments(domain string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error) {
+	results, err := da.DataAccess.SearchDocuments(domain, q)
+	if err != nil || (q.Skill == "" && q.Text == "") {
+		return results, err
+	}
+	da.Registry.each(func(plugin interface{}) {
+		if r, ok := plugin.(SearchRanker); ok {
+			results = r.Rank(da.ctx, domain, q, results)
+		}
+	})
+	return results, nil
+}
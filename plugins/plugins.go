@@ -0,0 +1,120 @@
+// Package plugins lets organisations compile their own business rules into
+// pill without changing it. A plugin is a value implementing one or more of
+// the hook interfaces, registered from the init function of a package which
+// is imported for its side effects, in the same way as database/sql
+// drivers:
+//
+//	func init() {
+//		plugins.Register("levelcaps", LevelCaps{})
+//	}
+//
+// Plugins which implement events.Publisher also receive the events raised
+// after each change.
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+// A ProfileHook is called before a person's profile is updated. It can
+// change the update, or reject it by returning an error. A
+// dataaccess.ValidationError is shown to the person, other errors fail the
+// request. Current is nil if the person doesn't have a profile yet.
+type ProfileHook interface {
+	BeforeProfileUpdate(ctx context.Context, current *dataaccess.Profile, update *dataaccess.ProfileUpdate) error
+}
+
+// A SkillTagHook is called before skill tags are added to or deleted from
+// the list, and can reject the change by returning an error.
+type SkillTagHook interface {
+	BeforeSkillTagsAdded(ctx context.Context, tags []string) error
+	BeforeSkillTagsDeleted(ctx context.Context, tags []string) error
+}
+
+// A SearchRanker orders the results of searches which rank people, i.e.
+// those for a skill or text, e.g. to put people on the bench first. It must
+// return the documents it's given, in any order, since listings such as
+// team summaries depend on them.
+type SearchRanker interface {
+	Rank(ctx context.Context, domain string, q dataaccess.SearchQuery, results []dataaccess.SearchDocument) []dataaccess.SearchDocument
+}
+
+// A Registry holds the plugins which are compiled in.
+type Registry struct {
+	mutex   sync.RWMutex
+	plugins map[string]interface{}
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{plugins: make(map[string]interface{})}
+}
+
+// DefaultRegistry is the Registry that Register adds to, and which the
+// service uses.
+var DefaultRegistry = NewRegistry()
+
+// Register adds the plugin to the DefaultRegistry. It panics if the name is
+// already registered, or the plugin implements none of the hooks.
+func Register(name string, plugin interface{}) {
+	DefaultRegistry.Register(name, plugin)
+}
+
+// Register adds the plugin to the registry. It panics if the name is already
+// registered, or the plugin implements none of the hooks.
+func (r *Registry) Register(name string, plugin interface{}) {
+	switch plugin.(type) {
+	case ProfileHook, SkillTagHook, SearchRanker, events.Publisher:
+	default:
+		panic(fmt.Sprintf("plugins: %s implements none of the hooks", name))
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.plugins[name]; ok {
+		panic("plugins: Register called twice for " + name)
+	}
+	r.plugins[name] = plugin
+}
+
+// Names returns the names of the registered plugins, in order.
+func (r *Registry) Names() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.names()
+}
+
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.plugins))
+	for name := range r.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// each calls f with the registered plugins in order of name, so that hooks
+// run in the same order every time.
+func (r *Registry) each(f func(plugin interface{})) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, name := range r.names() {
+		f(r.plugins[name])
+	}
+}
+
+// Publishers returns the plugins which receive events.
+func (r *Registry) Publishers() events.Publishers {
+	var op events.Publishers
+	r.each(func(plugin interface{}) {
+		if p, ok := plugin.(events.Publisher); ok {
+			op = append(op, p)
+		}
+	})
+	return op
+}
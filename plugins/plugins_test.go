@@ -0,0 +1,144 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+type hookStore struct {
+	dataaccess.DataAccess
+	updated []*dataaccess.ProfileUpdate
+	tags    []string
+}
+
+func (s *hookStore) GetProfile(emailAddress string) (*dataaccess.Profile, bool, error) {
+	if emailAddress == "new@github.com" {
+		return nil, false, nil
+	}
+	return &dataaccess.Profile{EmailAddress: emailAddress, Skills: []dataaccess.Skill{{Skill: "go", Level: 4}}}, true, nil
+}
+
+func (s *hookStore) UpdateProfile(update *dataaccess.ProfileUpdate) (*dataaccess.Profile, error) {
+	s.updated = append(s.updated, update)
+	return &dataaccess.Profile{EmailAddress: update.EmailAddress, Skills: update.Skills}, nil
+}
+
+func (s *hookStore) AddSkillTags(tags []string) error {
+	s.tags = append(s.tags, tags...)
+	return nil
+}
+
+func (s *hookStore) SearchDocuments(domain string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error) {
+	return []dataaccess.SearchDocument{{EmailAddress: "a@github.com"}, {EmailAddress: "b@github.com"}}, nil
+}
+
+// noDemotions rejects updates which lower a skill, and caps levels at 4.
+type noDemotions struct{}
+
+func (noDemotions) BeforeProfileUpdate(ctx context.Context, current *dataaccess.Profile, update *dataaccess.ProfileUpdate) error {
+	for i, s := range update.Skills {
+		if s.Level > 4 {
+			update.Skills[i].Level = 4
+		}
+		if current == nil {
+			continue
+		}
+		for _, c := range current.Skills {
+			if c.Skill == s.Skill && c.Level > s.Level {
+				return dataaccess.ValidationError{Problems: []string{s.Skill + " can't be lowered"}}
+			}
+		}
+	}
+	return nil
+}
+
+type noTags struct{}
+
+func (noTags) BeforeSkillTagsAdded(ctx context.Context, tags []string) error {
+	return dataaccess.ValidationError{Problems: []string{"tags are managed centrally"}}
+}
+
+func (noTags) BeforeSkillTagsDeleted(ctx context.Context, tags []string) error {
+	return nil
+}
+
+type reverse struct{}
+
+func (reverse) Rank(ctx context.Context, domain string, q dataaccess.SearchQuery, results []dataaccess.SearchDocument) []dataaccess.SearchDocument {
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
+	return results
+}
+
+type recorder struct {
+	events []events.Event
+}
+
+func (r *recorder) Publish(e events.Event) {
+	r.events = append(r.events, e)
+}
+
+func TestThatHooksChangeAndRejectWrites(t *testing.T) {
+	r := NewRegistry()
+	r.Register("nodemotions", noDemotions{})
+	r.Register("notags", noTags{})
+	store := &hookStore{}
+	da := NewHookingDataAccess(store, r).WithContext(context.Background())
+
+	if _, err := da.UpdateProfile(&dataaccess.ProfileUpdate{EmailAddress: "a@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 2}}}); err == nil {
+		t.Error("Expected lowering a skill to be rejected.")
+	}
+	if _, err := da.UpdateProfile(&dataaccess.ProfileUpdate{EmailAddress: "new@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 5}}}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if len(store.updated) != 1 || store.updated[0].Skills[0].Level != 4 {
+		t.Errorf("Expected only the capped update to be saved, but received %+v", store.updated)
+	}
+
+	if err := da.AddSkillTags([]string{"go"}); err == nil || len(store.tags) != 0 {
+		t.Errorf("Expected the tags to be rejected, but %v were added.", store.tags)
+	}
+}
+
+func TestThatRankersOnlyOrderRankedSearches(t *testing.T) {
+	r := NewRegistry()
+	r.Register("reverse", reverse{})
+	da := NewHookingDataAccess(&hookStore{}, r)
+
+	ranked, _ := da.SearchDocuments("github.com", dataaccess.SearchQuery{Skill: "go"})
+	if ranked[0].EmailAddress != "b@github.com" {
+		t.Errorf("Expected the ranker to order the skill search, but received %v", ranked)
+	}
+	listed, _ := da.SearchDocuments("github.com", dataaccess.SearchQuery{Manager: "boss@github.com"})
+	if listed[0].EmailAddress != "a@github.com" {
+		t.Errorf("Expected the team listing to be left in order, but received %v", listed)
+	}
+}
+
+func TestThatPluginsMustImplementAHook(t *testing.T) {
+	r := NewRegistry()
+	rec := &recorder{}
+	r.Register("recorder", rec)
+	r.Publishers().Publish(events.NewEvent(events.ProfileDeleted, "github.com", "a@github.com", nil))
+	if len(rec.events) != 1 {
+		t.Errorf("Expected the plugin to receive the event, but received %v", rec.events)
+	}
+
+	for name, plugin := range map[string]interface{}{"recorder": &recorder{}, "none": struct{}{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected registering %s to panic.", name)
+				}
+			}()
+			r.Register(name, plugin)
+		}()
+	}
+	if names := r.Names(); len(names) != 1 || names[0] != "recorder" {
+		t.Errorf("Expected only the recorder to be registered, but received %v", names)
+	}
+}
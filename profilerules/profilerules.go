@@ -0,0 +1,236 @@
+// Package profilerules applies the tenants' own ProfileRules, written as
+// script expressions, to the profiles people save. Rules are applied by a
+// plugin, so they run before the profile is updated.
+package profilerules
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/script"
+)
+
+// The Hook is a plugins.ProfileHook which applies the rules in the settings
+// of the person's tenant.
+type Hook struct {
+	DataAccess dataaccess.DataAccess
+	Limits     script.Limits
+	m          sync.Mutex
+	// parsed are the rules of each tenant, by domain, as they were when the
+	// tenant's settings were last read, so that they're only parsed again
+	// when they change.
+	parsed map[string]Rules
+}
+
+// NewHook creates a Hook which reads the rules through the DataAccess, and
+// evaluates them within the script.DefaultLimits.
+func NewHook(da dataaccess.DataAccess) *Hook {
+	return &Hook{DataAccess: da, Limits: script.DefaultLimits, parsed: make(map[string]Rules)}
+}
+
+// BeforeProfileUpdate applies the tenant's rules to the update.
+func (h *Hook) BeforeProfileUpdate(ctx context.Context, current *dataaccess.Profile, update *dataaccess.ProfileUpdate) error {
+	domain := dataaccess.GetDomain(update.EmailAddress)
+	settings, err := dataaccess.WithContext(h.DataAccess, ctx).GetSettings(domain)
+	if err != nil {
+		return err
+	}
+	return h.rules(domain, settings.ProfileRules).Apply(ctx, current, update, h.Limits)
+}
+
+// rules returns the tenant's rules, parsing them if they've changed since
+// they were last read.
+func (h *Hook) rules(domain string, rules []dataaccess.ProfileRule) Rules {
+	h.m.Lock()
+	defer h.m.Unlock()
+	if r, ok := h.parsed[domain]; ok && reflect.DeepEqual(r.source, rules) {
+		return r
+	}
+	r := Parse(rules)
+	h.parsed[domain] = r
+	return r
+}
+
+// A rule is a ProfileRule with its expressions parsed.
+type rule struct {
+	dataaccess.ProfileRule
+	when    *script.Expression
+	require *script.Expression
+	set     map[string]*script.Expression
+	// err is why the rule couldn't be parsed. The settings are validated
+	// when they're saved, so it's only set for rules saved before a change
+	// to the language.
+	err error
+}
+
+// Rules are a tenant's ProfileRules, parsed so that they can be applied to
+// every update.
+type Rules struct {
+	source []dataaccess.ProfileRule
+	rules  []rule
+}
+
+// Parse parses the expressions of the rules.
+func Parse(rules []dataaccess.ProfileRule) Rules {
+	parsed := Rules{source: rules}
+	for _, pr := range rules {
+		r := rule{ProfileRule: pr, set: make(map[string]*script.Expression)}
+		parse := func(expression string) *script.Expression {
+			if r.err != nil {
+				return nil
+			}
+			e, err := script.Parse(expression)
+			r.err = err
+			return e
+		}
+		if pr.When != "" {
+			r.when = parse(pr.When)
+		}
+		for _, field := range dataaccess.ProfileRuleFields {
+			if expression, ok := pr.Set[field]; ok {
+				r.set[field] = parse(expression)
+			}
+		}
+		if pr.Require != "" {
+			r.require = parse(pr.Require)
+		}
+		parsed.rules = append(parsed.rules, r)
+	}
+	return parsed
+}
+
+// Apply parses the rules and applies them to the update.
+func Apply(ctx context.Context, rules []dataaccess.ProfileRule, current *dataaccess.Profile, update *dataaccess.ProfileUpdate, limits script.Limits) error {
+	return Parse(rules).Apply(ctx, current, update, limits)
+}
+
+// Apply applies the rules, in order, to the update. Fields set by a rule are
+// seen by the rules after it. All of the rules share the limits, so that
+// many rules can't take longer than one. It returns a
+// dataaccess.ValidationError with the messages of the rules which aren't
+// met, and of those which can't be evaluated, e.g. because they exceed the
+// limits.
+func (rs Rules) Apply(ctx context.Context, current *dataaccess.Profile, update *dataaccess.ProfileUpdate, limits script.Limits) error {
+	if len(rs.rules) == 0 {
+		return nil
+	}
+	vars := variables(current, update)
+	budget := script.NewBudget(ctx, limits)
+	var problems []string
+	failed := func(r rule, err error) {
+		log.Printf("Failed to evaluate the profile rule '%s' for %s. %v", r.Name, update.EmailAddress, err)
+		problems = append(problems, fmt.Sprintf("the rule '%s' couldn't be checked: %v", r.Name, err))
+	}
+
+	for _, r := range rs.rules {
+		if r.err != nil {
+			failed(r, r.err)
+			continue
+		}
+		if r.when != nil {
+			applies, err := r.when.EvalBoolWithin(ctx, vars, budget)
+			if err != nil {
+				failed(r, err)
+				continue
+			}
+			if !applies {
+				continue
+			}
+		}
+		for _, field := range dataaccess.ProfileRuleFields {
+			e, ok := r.set[field]
+			if !ok {
+				continue
+			}
+			value, err := e.EvalStringWithin(ctx, vars, budget)
+			if err != nil {
+				failed(r, err)
+				continue
+			}
+			*fieldOf(update, field) = &value
+			vars[field] = value
+		}
+		if r.require != nil {
+			met, err := r.require.EvalBoolWithin(ctx, vars, budget)
+			if err != nil {
+				failed(r, err)
+				continue
+			}
+			if !met {
+				problems = append(problems, r.Message)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return dataaccess.ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// variables are what rules can see of the profile, as it will be once it's
+// updated:
+//
+//   - emailAddress and domain
+//   - the fields in dataaccess.ProfileRuleFields, as strings
+//   - availability, 1 red, 2 amber or 3 green
+//   - skills, the list of skill names
+//   - levels and interests, maps of skill names to numbers
+func variables(current *dataaccess.Profile, update *dataaccess.ProfileUpdate) map[string]interface{} {
+	vars := map[string]interface{}{
+		"emailAddress": update.EmailAddress,
+		"domain":       dataaccess.GetDomain(update.EmailAddress),
+		"availability": int(update.Availability),
+	}
+	if current == nil {
+		current = &dataaccess.Profile{}
+	}
+	existing := map[string]string{
+		"name":       current.Name,
+		"manager":    current.Manager,
+		"department": current.Department,
+		"costCenter": current.CostCenter,
+		"bio":        current.Bio,
+		"language":   current.Language,
+		"timeZone":   current.TimeZone,
+	}
+	for _, field := range dataaccess.ProfileRuleFields {
+		vars[field] = existing[field]
+		if v := *fieldOf(update, field); v != nil {
+			vars[field] = *v
+		}
+	}
+
+	skills := []string{}
+	levels := map[string]float64{}
+	interests := map[string]float64{}
+	for _, s := range update.Skills {
+		skills = append(skills, s.Skill)
+		levels[s.Skill] = float64(s.Level)
+		interests[s.Skill] = float64(s.Interest)
+	}
+	vars["skills"], vars["levels"], vars["interests"] = skills, levels, interests
+	return vars
+}
+
+// fieldOf returns the field of the update a rule sets.
+func fieldOf(update *dataaccess.ProfileUpdate, field string) **string {
+	switch field {
+	case "name":
+		return &update.Name
+	case "manager":
+		return &update.Manager
+	case "department":
+		return &update.Department
+	case "costCenter":
+		return &update.CostCenter
+	case "bio":
+		return &update.Bio
+	case "language":
+		return &update.Language
+	}
+	return &update.TimeZone
+}
@@ -0,0 +1,117 @@
+package profilerules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/script"
+)
+
+func TestThatRulesValidateAndFillInProfiles(t *testing.T) {
+	rules := []dataaccess.ProfileRule{
+		{
+			Name: "engineering cost center",
+			When: `costCenter == "CC-100"`,
+			Set:  map[string]string{"department": `"Engineering"`},
+		},
+		{
+			Name:    "engineers list a language",
+			When:    `department == "Engineering"`,
+			Require: `contains(skills, "go") || contains(skills, "java")`,
+			Message: "Engineers must list Go or Java.",
+		},
+		{
+			Name:    "experts are interested",
+			Require: `levels["go"] < 4 || interests["go"] > 0`,
+			Message: "Go experts must say how interested they are in Go.",
+		},
+	}
+	costCenter := "CC-100"
+	current := &dataaccess.Profile{EmailAddress: "a@github.com", Name: "A"}
+
+	update := &dataaccess.ProfileUpdate{EmailAddress: "a@github.com", CostCenter: &costCenter, Skills: []dataaccess.Skill{{Skill: "go", Level: 4, Interest: 1}}}
+	if err := Apply(context.Background(), rules, current, update, script.DefaultLimits); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if update.Department == nil || *update.Department != "Engineering" {
+		t.Errorf("Expected the department to be filled in from the cost center, but received %v", update.Department)
+	}
+	if update.Name != nil {
+		t.Errorf("Expected the name to be left unchanged, but received %v", *update.Name)
+	}
+
+	update = &dataaccess.ProfileUpdate{EmailAddress: "a@github.com", CostCenter: &costCenter, Skills: []dataaccess.Skill{{Skill: "sql", Level: 4}}}
+	err := Apply(context.Background(), rules, current, update, script.DefaultLimits)
+	verr, ok := err.(dataaccess.ValidationError)
+	if !ok || len(verr.Problems) != 1 || verr.Problems[0] != "Engineers must list Go or Java." {
+		t.Errorf("Expected the update to be rejected by one rule, but received %v", err)
+	}
+}
+
+func TestThatRulesWhichCantBeEvaluatedRejectTheUpdate(t *testing.T) {
+	rules := []dataaccess.ProfileRule{
+		{Name: "typo", Require: `department == 1`, Message: "Never shown."},
+		{Name: "slow", Require: `len(bio + bio + bio + bio) > 0`, Message: "Never shown."},
+	}
+	bio := "A long bio."
+	update := &dataaccess.ProfileUpdate{EmailAddress: "a@github.com", Bio: &bio}
+	err := Apply(context.Background(), rules, nil, update, script.Limits{MaxSteps: 5, MaxBytes: 1024, Timeout: script.DefaultLimits.Timeout})
+	verr, ok := err.(dataaccess.ValidationError)
+	if !ok || len(verr.Problems) != 2 {
+		t.Errorf("Expected both rules to fail, but received %v", err)
+	}
+}
+
+func TestThatAllOfTheRulesShareTheLimits(t *testing.T) {
+	var rules []dataaccess.ProfileRule
+	for i := 0; i < 20; i++ {
+		rules = append(rules, dataaccess.ProfileRule{Name: fmt.Sprintf("rule %d", i), Require: `department == ""`, Message: "Never shown."})
+	}
+	limits := script.Limits{MaxSteps: 10, MaxBytes: 1024, Timeout: script.DefaultLimits.Timeout}
+	update := &dataaccess.ProfileUpdate{EmailAddress: "a@github.com"}
+
+	if err := Apply(context.Background(), rules[:1], nil, update, limits); err != nil {
+		t.Fatalf("Expected one rule to be within the limits, but received %v", err)
+	}
+
+	err := Apply(context.Background(), rules, nil, update, limits)
+	verr, ok := err.(dataaccess.ValidationError)
+	if !ok || !strings.Contains(strings.Join(verr.Problems, " "), "steps") {
+		t.Errorf("Expected the rules to run out of steps together, but received %v", err)
+	}
+}
+
+type settingsDataAccess struct {
+	dataaccess.DataAccess
+	settings dataaccess.Settings
+}
+
+func (da *settingsDataAccess) GetSettings(domain string) (dataaccess.Settings, error) {
+	return da.settings, nil
+}
+
+func TestThatRulesAreOnlyParsedAgainWhenTheyChange(t *testing.T) {
+	da := &settingsDataAccess{}
+	da.settings.ProfileRules = []dataaccess.ProfileRule{{Name: "department", Require: `department != ""`, Message: "Enter your department."}}
+	h := NewHook(da)
+	update := &dataaccess.ProfileUpdate{EmailAddress: "a@github.com"}
+
+	if err := h.BeforeProfileUpdate(context.Background(), nil, update); err == nil {
+		t.Fatal("Expected the update to be rejected by the rule.")
+	}
+	parsed := h.parsed["github.com"].rules[0].require
+
+	da.settings.ProfileRules = []dataaccess.ProfileRule{{Name: "department", Require: `department != ""`, Message: "Enter your department."}}
+	h.BeforeProfileUpdate(context.Background(), nil, update)
+	if h.parsed["github.com"].rules[0].require != parsed {
+		t.Error("Expected the rules to be parsed once, while they're unchanged.")
+	}
+
+	da.settings.ProfileRules = []dataaccess.ProfileRule{{Name: "department", Require: `true`, Message: "Enter your department."}}
+	if err := h.BeforeProfileUpdate(context.Background(), nil, update); err != nil {
+		t.Errorf("Expected the changed rule to be applied, but received %v", err)
+	}
+}
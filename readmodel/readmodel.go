@@ -0,0 +1,171 @@
+// Package readmodel keeps the read models used by searches and team
+// listings up to date with the changes to profiles, so that those queries
+// never read the profiles themselves.
+package readmodel
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+// The Projector updates a person's search document, and the summaries of
+// the teams they're in, when their profile changes. It is an
+// events.Publisher, so it receives the changes made through a
+// NotifyingDataAccess.
+type Projector struct {
+	DataAccess dataaccess.DataAccess
+	now        func() time.Time
+}
+
+// NewProjector creates a Projector which saves the read models through the
+// DataAccess.
+func NewProjector(da dataaccess.DataAccess) *Projector {
+	return &Projector{da, time.Now}
+}
+
+// Publish updates the read models affected by the change.
+func (p *Projector) Publish(e events.Event) {
+	switch e.Type {
+	case events.ProfileUpdated:
+		profile, ok := e.Data.(*dataaccess.Profile)
+		if !ok || profile == nil {
+			return
+		}
+		if err := p.Project(*profile); err != nil {
+			log.Printf("Failed to update the search document of %s. %v", profile.EmailAddress, err)
+		}
+	case events.ProfileDeleted:
+		if err := p.Remove(e.EmailAddress); err != nil {
+			log.Printf("Failed to remove the search document of %s. %v", e.EmailAddress, err)
+		}
+	}
+}
+
+// Project saves the profile's search document, and summarises the team of
+// its manager, and of its previous manager if it has moved.
+func (p *Projector) Project(profile dataaccess.Profile) error {
+	previous, found, err := p.DataAccess.GetSearchDocument(profile.EmailAddress)
+	if err != nil {
+		return err
+	}
+	d := dataaccess.NewSearchDocument(profile, p.now())
+	if err := p.DataAccess.SaveSearchDocument(&d); err != nil {
+		return err
+	}
+	if found && previous.Manager != d.Manager {
+		if err := p.summarise(d.Domain, previous.Manager); err != nil {
+			return err
+		}
+	}
+	return p.summarise(d.Domain, d.Manager)
+}
+
+// Remove removes the person's search document, and summarises their
+// manager's team without them.
+func (p *Projector) Remove(emailAddress string) error {
+	previous, found, err := p.DataAccess.GetSearchDocument(emailAddress)
+	if err != nil || !found {
+		return err
+	}
+	if err := p.DataAccess.RemoveSearchDocument(emailAddress); err != nil {
+		return err
+	}
+	return p.summarise(previous.Domain, previous.Manager)
+}
+
+// summarise saves the summary of the manager's team from the search
+// documents of their reports. Managers in other tenants don't have a
+// summary, since their teams can't be read from this tenant.
+func (p *Projector) summarise(domain string, manager string) error {
+	if manager == "" || !strings.EqualFold(dataaccess.GetDomain(manager), domain) {
+		return nil
+	}
+	reports, err := p.DataAccess.SearchDocuments(domain, dataaccess.SearchQuery{Manager: manager})
+	if err != nil {
+		return err
+	}
+	s := dataaccess.SummariseTeam(manager, reports, p.now())
+	return p.DataAccess.SaveTeamSummary(&s)
+}
+
+// Rebuild projects every profile in the domain again, and removes the
+// search documents of people who no longer have profiles. It returns the
+// number of profiles projected.
+func (p *Projector) Rebuild(domain string) (int, error) {
+	profiles, err := p.DataAccess.ListProfiles("@" + domain)
+	if err != nil {
+		return 0, err
+	}
+	existing, err := p.DataAccess.SearchDocuments(domain, dataaccess.SearchQuery{})
+	if err != nil {
+		return 0, err
+	}
+
+	managers := make(map[string]bool)
+	current := make(map[string]bool)
+	for _, profile := range profiles {
+		d := dataaccess.NewSearchDocument(profile, p.now())
+		if err := p.DataAccess.SaveSearchDocument(&d); err != nil {
+			return 0, err
+		}
+		current[d.EmailAddress] = true
+		managers[d.Manager] = true
+	}
+	for _, d := range existing {
+		managers[d.Manager] = true
+		if current[d.EmailAddress] {
+			continue
+		}
+		if err := p.DataAccess.RemoveSearchDocument(d.EmailAddress); err != nil {
+			return 0, err
+		}
+	}
+	for manager := range managers {
+		if err := p.summarise(domain, manager); err != nil {
+			return 0, err
+		}
+	}
+	return len(profiles), nil
+}
+
+// A Job rebuilds the read models of every tenant. It should run once a
+// day, to pick up changes which don't raise events, e.g. profiles which are
+// imported in bulk, or the teams of people who were archived.
+type Job struct {
+	Projector *Projector
+}
+
+// NewJob creates an instance of the Job.
+func NewJob(da dataaccess.DataAccess) *Job {
+	return &Job{NewProjector(da)}
+}
+
+// Run rebuilds the read models of each tenant. Failures are logged, so that
+// one tenant doesn't prevent the rest being rebuilt, and the last error is
+// returned.
+func (j *Job) Run(ctx context.Context) error {
+	domains, err := j.Projector.DataAccess.ListDomains()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		n, err := j.Projector.Rebuild(domain)
+		if err != nil {
+			log.Printf("Failed to rebuild the read models of %s. %v", domain, err)
+			lastErr = err
+			continue
+		}
+		log.Printf("Rebuilt the read models of %d profiles in %s.", n, domain)
+	}
+	return lastErr
+}
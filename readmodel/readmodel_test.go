@@ -0,0 +1,125 @@
+package readmodel
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/events"
+)
+
+var august = time.Date(2017, time.August, 14, 0, 0, 0, 0, time.UTC)
+
+type readStore struct {
+	dataaccess.DataAccess
+	profiles  []dataaccess.Profile
+	documents map[string]dataaccess.SearchDocument
+	teams     map[string]dataaccess.TeamSummary
+}
+
+func newReadStore(profiles ...dataaccess.Profile) *readStore {
+	return &readStore{
+		profiles:  profiles,
+		documents: map[string]dataaccess.SearchDocument{},
+		teams:     map[string]dataaccess.TeamSummary{},
+	}
+}
+
+func (s *readStore) ListDomains() ([]string, error) {
+	return []string{"github.com"}, nil
+}
+
+func (s *readStore) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	return s.profiles, nil
+}
+
+func (s *readStore) SaveSearchDocument(d *dataaccess.SearchDocument) error {
+	s.documents[d.EmailAddress] = *d
+	return nil
+}
+
+func (s *readStore) GetSearchDocument(emailAddress string) (*dataaccess.SearchDocument, bool, error) {
+	d, ok := s.documents[strings.ToLower(emailAddress)]
+	return &d, ok, nil
+}
+
+func (s *readStore) RemoveSearchDocument(emailAddress string) error {
+	delete(s.documents, strings.ToLower(emailAddress))
+	return nil
+}
+
+func (s *readStore) SearchDocuments(domain string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error) {
+	var op []dataaccess.SearchDocument
+	for _, d := range s.documents {
+		if d.Domain == domain && (q.Manager == "" || d.Manager == q.Manager) {
+			op = append(op, d)
+		}
+	}
+	return op, nil
+}
+
+func (s *readStore) SaveTeamSummary(summary *dataaccess.TeamSummary) error {
+	if len(summary.Members) == 0 {
+		delete(s.teams, summary.Manager)
+		return nil
+	}
+	s.teams[summary.Manager] = *summary
+	return nil
+}
+
+func TestThatChangesToProfilesUpdateTheTeamsOfTheOldAndNewManagers(t *testing.T) {
+	store := newReadStore()
+	p := NewProjector(store)
+	p.now = func() time.Time { return august }
+	a := &dataaccess.Profile{EmailAddress: "A@github.com", Manager: "boss@github.com", Availability: dataaccess.Green,
+		Skills: []dataaccess.Skill{{Skill: "go", Level: 4}}}
+	b := &dataaccess.Profile{EmailAddress: "b@github.com", Manager: "boss@github.com",
+		Skills: []dataaccess.Skill{{Skill: "go", Level: 2}}}
+
+	p.Publish(events.NewEvent(events.ProfileUpdated, "github.com", a.EmailAddress, a))
+	p.Publish(events.NewEvent(events.ProfileUpdated, "github.com", b.EmailAddress, b))
+
+	team := store.teams["boss@github.com"]
+	if len(team.Members) != 2 || team.Available != 1 || len(team.Skills) != 1 || team.Skills[0].AverageLevel != 3 {
+		t.Fatalf("Expected a team of two with an average go level of 3, but received %+v", team)
+	}
+
+	b.Manager = "other@github.com"
+	p.Publish(events.NewEvent(events.ProfileUpdated, "github.com", b.EmailAddress, b))
+	if team := store.teams["boss@github.com"]; len(team.Members) != 1 || team.Members[0] != "a@github.com" {
+		t.Errorf("Expected b@github.com to leave the team, but received %+v", team)
+	}
+	if team := store.teams["other@github.com"]; len(team.Members) != 1 {
+		t.Errorf("Expected b@github.com to join the new team, but received %+v", team)
+	}
+
+	p.Publish(events.NewEvent(events.ProfileDeleted, "github.com", "a@github.com", nil))
+	if _, ok := store.documents["a@github.com"]; ok {
+		t.Error("Expected the search document of the deleted profile to be removed.")
+	}
+	if _, ok := store.teams["boss@github.com"]; ok {
+		t.Error("Expected the empty team to be removed.")
+	}
+}
+
+func TestThatTheJobRebuildsTheReadModels(t *testing.T) {
+	store := newReadStore(dataaccess.Profile{EmailAddress: "a@github.com", Manager: "boss@github.com"})
+	store.documents["gone@github.com"] = dataaccess.SearchDocument{EmailAddress: "gone@github.com", Domain: "github.com", Manager: "old@github.com"}
+	store.teams["old@github.com"] = dataaccess.TeamSummary{Manager: "old@github.com", Members: []string{"gone@github.com"}}
+
+	if err := NewJob(store).Run(context.Background()); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if _, ok := store.documents["gone@github.com"]; ok || len(store.documents) != 1 {
+		t.Errorf("Expected only a@github.com to have a search document, but received %v", store.documents)
+	}
+	if _, ok := store.teams["old@github.com"]; ok {
+		t.Error("Expected the team of the removed profile to be removed.")
+	}
+	if team := store.teams["boss@github.com"]; len(team.Members) != 1 {
+		t.Errorf("Expected a@github.com to be in their manager's team, but received %+v", team)
+	}
+}
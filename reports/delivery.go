@@ -0,0 +1,167 @@
+// Package reports emails custom reports to the people they're delivered to.
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/i18n"
+	"github.com/a-h/pill/notifications"
+)
+
+// A Job delivers the custom reports which are due. It should run once a day.
+// Weekly reports are delivered on Mondays.
+type Job struct {
+	DataAccess dataaccess.DataAccess
+	Notifier   *notifications.Notifier
+	// BaseURL is the address of the pill website.
+	BaseURL string
+	now     func() time.Time
+}
+
+// NewJob creates an instance of the Job.
+func NewJob(da dataaccess.DataAccess, notifier *notifications.Notifier, baseURL string) *Job {
+	return &Job{da, notifier, baseURL, time.Now}
+}
+
+// Run runs each report which is due, and sends it to its recipients.
+// Failures to run or deliver individual reports are logged, so that one bad
+// report doesn't prevent the rest being delivered, and the last error is
+// returned.
+func (j *Job) Run(ctx context.Context) error {
+	now := j.now()
+
+	domains, err := j.DataAccess.ListDomains()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		definitions, err := j.DataAccess.ListReportDefinitions(domain)
+		if err != nil {
+			return err
+		}
+		var due []dataaccess.ReportDefinition
+		for _, d := range definitions {
+			if isDue(d, now) {
+				due = append(due, d)
+			}
+		}
+		if len(due) == 0 {
+			continue
+		}
+
+		settings, err := j.DataAccess.GetSettings(domain)
+		if err != nil {
+			return err
+		}
+		for _, d := range due {
+			if err := j.deliver(d, settings.Consent); err != nil {
+				log.Printf("Failed to deliver the report %s in %s. %v", d.ID, domain, err)
+				lastErr = err
+			}
+		}
+	}
+
+	return lastErr
+}
+
+func isDue(d dataaccess.ReportDefinition, now time.Time) bool {
+	if d.Delivery == nil {
+		return false
+	}
+	return d.Delivery.Frequency == dataaccess.DailyFrequency ||
+		(d.Delivery.Frequency == dataaccess.WeeklyFrequency && now.Weekday() == time.Monday)
+}
+
+func (j *Job) deliver(d dataaccess.ReportDefinition, consent dataaccess.ConsentSettings) error {
+	result, err := j.DataAccess.RunReport(d, consent)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, to := range d.Delivery.Recipients {
+		// Recipients are notified with their own preferences, so people who
+		// have left or muted reports aren't sent them.
+		p, found, err := j.DataAccess.GetProfile(to)
+		if err != nil {
+			return err
+		}
+		if !found {
+			log.Printf("Not delivering the report %s to %s, because they don't have a profile.", d.ID, to)
+			continue
+		}
+		n, err := Notification(*result, p.Language, j.BaseURL)
+		if err != nil {
+			return err
+		}
+		n.To = p.EmailAddress
+		if err := j.Notifier.Notify(p.Notifications, n); err != nil {
+			failed = append(failed, to)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("reports: failed to deliver the report to %s", strings.Join(failed, ", "))
+	}
+	log.Printf("Delivered the report %s in %s to %d people.", d.ID, d.Domain, len(d.Delivery.Recipients))
+	return nil
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<h1>{{.Name}}</h1>
+<table>
+<tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+<p><a href="{{.Download}}">{{.DownloadText}}</a></p>
+`))
+
+// Notification renders the result of a report as a notification in the
+// language, without a recipient.
+func Notification(result dataaccess.ReportResult, language string, baseURL string) (notifications.Notification, error) {
+	download := strings.TrimSuffix(baseURL, "/") + "/admin/reports/run/?format=csv&id=" + url.QueryEscape(result.Definition.ID)
+	table := result.Table()
+
+	var text bytes.Buffer
+	text.WriteString(result.Definition.Name + "\n\n")
+	cw := csv.NewWriter(&text)
+	cw.Write(result.Columns)
+	cw.WriteAll(table)
+	if err := cw.Error(); err != nil {
+		return notifications.Notification{}, err
+	}
+	downloadText := i18n.Translate(language, "reports.download")
+	text.WriteString("\n" + downloadText + ": " + download + "\n")
+
+	var html bytes.Buffer
+	err := htmlTemplate.Execute(&html, struct {
+		Name         string
+		Columns      []string
+		Rows         [][]string
+		Download     string
+		DownloadText string
+	}{result.Definition.Name, result.Columns, table, download, downloadText})
+	if err != nil {
+		return notifications.Notification{}, err
+	}
+
+	return notifications.Notification{
+		Category: dataaccess.ReportCategory,
+		Subject:  i18n.Translate(language, "reports.subject", result.Definition.Name),
+		Text:     text.String(),
+		HTML:     html.String(),
+	}, nil
+}
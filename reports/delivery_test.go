@@ -0,0 +1,93 @@
+package reports
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/notifications"
+)
+
+type stubDataAccess struct {
+	dataaccess.DataAccess
+	definitions []dataaccess.ReportDefinition
+	ran         []string
+}
+
+func (da *stubDataAccess) ListDomains() ([]string, error) {
+	return []string{"github.com"}, nil
+}
+
+func (da *stubDataAccess) ListReportDefinitions(domain string) ([]dataaccess.ReportDefinition, error) {
+	return da.definitions, nil
+}
+
+func (da *stubDataAccess) GetSettings(domain string) (dataaccess.Settings, error) {
+	return dataaccess.DefaultSettings(), nil
+}
+
+func (da *stubDataAccess) GetProfile(emailAddress string) (*dataaccess.Profile, bool, error) {
+	if emailAddress == "leaver@github.com" {
+		return nil, false, nil
+	}
+	return &dataaccess.Profile{EmailAddress: emailAddress, Language: "de"}, true, nil
+}
+
+func (da *stubDataAccess) RunReport(d dataaccess.ReportDefinition, consent dataaccess.ConsentSettings) (*dataaccess.ReportResult, error) {
+	da.ran = append(da.ran, d.ID)
+	return &dataaccess.ReportResult{
+		Definition: d,
+		Columns:    []string{"skill", "people"},
+		Rows:       []map[string]interface{}{{"skill": "go", "people": float64(2)}},
+	}, nil
+}
+
+type recordingChannel struct {
+	delivered []notifications.Notification
+}
+
+func (c *recordingChannel) Deliver(n notifications.Notification) error {
+	c.delivered = append(c.delivered, n)
+	return nil
+}
+
+func TestThatReportsAreDeliveredWhenTheyreDue(t *testing.T) {
+	da := &stubDataAccess{definitions: []dataaccess.ReportDefinition{
+		{ID: "daily", Name: "Daily", Delivery: &dataaccess.ReportDelivery{Frequency: dataaccess.DailyFrequency, Recipients: []string{"boss@github.com", "leaver@github.com"}}},
+		{ID: "weekly", Name: "Weekly", Delivery: &dataaccess.ReportDelivery{Frequency: dataaccess.WeeklyFrequency, Recipients: []string{"boss@github.com"}}},
+		{ID: "undelivered", Name: "Undelivered"},
+	}}
+	c := &recordingChannel{}
+	j := NewJob(da, notifications.NewNotifier(map[dataaccess.NotificationChannel]notifications.Channel{dataaccess.EmailChannel: c}), "https://pill.example.com/")
+
+	// A Tuesday.
+	j.now = func() time.Time { return time.Date(2018, time.March, 6, 7, 0, 0, 0, time.UTC) }
+	if err := j.Run(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(da.ran) != 1 || da.ran[0] != "daily" || len(c.delivered) != 1 {
+		t.Fatalf("Expected only the daily report to be delivered to the boss, but ran %v and delivered %d", da.ran, len(c.delivered))
+	}
+	n := c.delivered[0]
+	if n.To != "boss@github.com" || n.Category != dataaccess.ReportCategory || n.Subject != "Daily, von pill" {
+		t.Errorf("Unexpected notification %+v", n)
+	}
+	if !strings.Contains(n.Text, "skill,people\ngo,2\n") || !strings.Contains(n.Text, "https://pill.example.com/admin/reports/run/?format=csv&id=daily") {
+		t.Errorf("Expected the text to contain the CSV and a link to download it, but got %s", n.Text)
+	}
+	if !strings.Contains(n.HTML, "<td>go</td><td>2</td>") {
+		t.Errorf("Expected the HTML to contain the table, but got %s", n.HTML)
+	}
+
+	// A Monday.
+	da.ran = nil
+	j.now = func() time.Time { return time.Date(2018, time.March, 5, 7, 0, 0, 0, time.UTC) }
+	if err := j.Run(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(da.ran) != 2 {
+		t.Errorf("Expected the daily and weekly reports to be delivered on Mondays, but ran %v", da.ran)
+	}
+}
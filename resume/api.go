@@ -0,0 +1,91 @@
+package resume
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// An APIParser posts CVs to an external resume parsing service. The service
+// receives the file as the body of the request, with its content type, and
+// responds with the skills it found:
+//
+//	{"skills": [{"name": "Go", "years": 5}, {"name": "Kubernetes"}]}
+type APIParser struct {
+	url    string
+	key    string
+	Client *http.Client
+}
+
+// NewAPIParser creates an APIParser which posts to the URL. If the key isn't
+// empty, it's sent as a bearer token.
+func NewAPIParser(url string, key string) *APIParser {
+	return &APIParser{url, key, &http.Client{Timeout: 30 * time.Second}}
+}
+
+type apiResponse struct {
+	Skills []struct {
+		Name  string `json:"name"`
+		Years int    `json:"years"`
+	} `json:"skills"`
+}
+
+// Parse posts the CV to the service. Skills are matched to the tags by name
+// or alias, and skills which don't match a tag are returned as new tags.
+func (p APIParser) Parse(contentType string, data []byte, tags []dataaccess.SkillTag) ([]Candidate, error) {
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	if p.key != "" {
+		req.Header.Set("Authorization", "Bearer "+p.key)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("resume: the parser returned %s: %s", resp.Status, body)
+	}
+
+	var ar apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ar); err != nil {
+		return nil, err
+	}
+
+	skills := make([]dataaccess.Skill, len(ar.Skills))
+	for i, s := range ar.Skills {
+		skills[i] = dataaccess.Skill{Skill: s.Name}
+	}
+	resolved, _ := dataaccess.NewVocabulary(tags).Resolve(skills)
+
+	var op []Candidate
+	seen := make(map[string]int)
+	for i, s := range resolved {
+		name := dataaccess.CleanTag(s.Skill)
+		if name == "" {
+			continue
+		}
+		if j, ok := seen[name]; ok {
+			op[j].Mentions++
+			if ar.Skills[i].Years > op[j].Years {
+				op[j].Years = ar.Skills[i].Years
+			}
+			continue
+		}
+		seen[name] = len(op)
+		op = append(op, Candidate{Skill: name, Years: ar.Skills[i].Years, Mentions: 1})
+	}
+	return op, nil
+}
@@ -0,0 +1,87 @@
+package resume
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A LocalParser extracts the text of the CV and looks for the names and
+// aliases of the skill tags in it.
+type LocalParser struct{}
+
+// Parse finds the skill tags in the CV.
+func (p LocalParser) Parse(contentType string, data []byte, tags []dataaccess.SkillTag) ([]Candidate, error) {
+	text, err := Text(contentType, data)
+	if err != nil {
+		return nil, err
+	}
+	return Find(text, tags), nil
+}
+
+var yearsPattern = regexp.MustCompile(`(?i)\b(\d{1,2})\+?\s*(?:years|yrs)\b`)
+
+var sentenceSeparators = regexp.MustCompile(`[.;\n]\s`)
+
+// Find returns the skill tags which are mentioned in the text, by name or by
+// alias, in order of skill. The years of experience are taken from the
+// sentences which mention the skill, e.g. "5 years of Go".
+func Find(text string, tags []dataaccess.SkillTag) []Candidate {
+	sentences := sentenceSeparators.Split(text+"\n ", -1)
+
+	var op []Candidate
+	for _, t := range tags {
+		names := append([]string{t.Name}, t.Aliases...)
+		var patterns []*regexp.Regexp
+		for _, n := range names {
+			if p := tagPattern(n); p != nil {
+				patterns = append(patterns, p)
+			}
+		}
+
+		c := Candidate{Skill: t.Name}
+		for _, s := range sentences {
+			mentions := 0
+			for _, p := range patterns {
+				for _, m := range p.FindAllStringSubmatch(s, -1) {
+					if len(strings.TrimSpace(m[1])) <= 2 && strings.ToLower(m[1]) == m[1] {
+						// Short tags, such as "go", are only recognised when
+						// capitalised, so that ordinary words aren't matched.
+						continue
+					}
+					mentions++
+				}
+			}
+			if mentions == 0 {
+				continue
+			}
+			c.Mentions += mentions
+			for _, y := range yearsPattern.FindAllStringSubmatch(s, -1) {
+				if years, _ := strconv.Atoi(y[1]); years > c.Years {
+					c.Years = years
+				}
+			}
+		}
+		if c.Mentions > 0 {
+			op = append(op, c)
+		}
+	}
+	return op
+}
+
+// tagPattern matches the tag as a whole word, ignoring case. Hyphens in the
+// tag match spaces, since tags can't contain them. Tags of a single
+// character are too ambiguous to find.
+func tagPattern(tag string) *regexp.Regexp {
+	tag = dataaccess.CleanTag(strings.TrimSpace(tag))
+	if len([]rune(tag)) < 2 {
+		return nil
+	}
+	words := strings.Split(tag, "-")
+	for i, w := range words {
+		words[i] = regexp.QuoteMeta(w)
+	}
+	return regexp.MustCompile(`(?i)(?:^|[^\pL\pN+#])(` + strings.Join(words, `[\s-]+`) + `)(?:$|[^\pL\pN+#])`)
+}
@@ -0,0 +1,78 @@
+// Package resume finds the skills people have listed in their CVs, so that
+// they can be suggested for their profiles.
+package resume
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Candidate is a skill found in a CV.
+type Candidate struct {
+	Skill string `json:"skill"`
+	// Years is the most years of experience claimed alongside the skill.
+	Years int `json:"years"`
+	// Mentions is the number of times the skill appears.
+	Mentions int `json:"mentions"`
+}
+
+// A Parser finds the skills in a CV. The tags are the skill tags in use, so
+// that skills can be recognised by their aliases.
+type Parser interface {
+	Parse(contentType string, data []byte, tags []dataaccess.SkillTag) ([]Candidate, error)
+}
+
+// OpenParser returns the parser described by the value: "local" finds skill
+// tags in the text of the CV, and an http or https URL posts the CV to an
+// external resume parsing API, authenticated with the key in the
+// RESUME_PARSER_API_KEY environment variable.
+func OpenParser(value string) (Parser, error) {
+	if value == "local" {
+		return LocalParser{}, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("resume: unsupported parser '%s', use local or the URL of an API", value)
+	}
+	return NewAPIParser(value, os.Getenv("RESUME_PARSER_API_KEY")), nil
+}
+
+// Level returns the level a candidate skill suggests. Skills which are
+// mentioned suggest the competent level, and skills with three or more years
+// of experience, or which are mentioned three or more times, suggest the
+// proficient level. Higher levels are never suggested, since they need more
+// evidence than a CV.
+func Level(c Candidate) dataaccess.DreyfusLevel {
+	if c.Years >= 3 || c.Mentions >= 3 {
+		return dataaccess.ProficientLevel
+	}
+	return dataaccess.CompetentLevel
+}
+
+// Suggest returns the skills which the candidates suggest a higher level of
+// than the profile has, in order of skill.
+func Suggest(p *dataaccess.Profile, candidates []Candidate) []dataaccess.LevelSuggestion {
+	current := make(map[string]dataaccess.DreyfusLevel)
+	for _, s := range p.Skills {
+		current[s.Skill] = s.Level
+	}
+
+	suggested := make(map[string]dataaccess.DreyfusLevel)
+	for _, c := range candidates {
+		level := Level(c)
+		if level > current[c.Skill] && level > suggested[c.Skill] {
+			suggested[c.Skill] = level
+		}
+	}
+
+	op := []dataaccess.LevelSuggestion{}
+	for skill, level := range suggested {
+		op = append(op, dataaccess.LevelSuggestion{Skill: skill, Current: current[skill], Suggested: level})
+	}
+	sort.Slice(op, func(i, j int) bool { return op[i].Skill < op[j].Skill })
+	return op
+}
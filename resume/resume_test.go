@@ -0,0 +1,92 @@
+package resume
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+var tags = []dataaccess.SkillTag{
+	{Name: "go", Aliases: []string{"golang"}},
+	{Name: "kubernetes", Aliases: []string{"k8s"}},
+	{Name: "machine-learning"},
+	{Name: "c#"},
+	{Name: "c"},
+	{Name: "java"},
+}
+
+func TestThatSkillTagsAreFoundInText(t *testing.T) {
+	text := `Senior engineer with 6 years of Golang and Kubernetes experience.
+I'd go anywhere to learn more about machine learning; I've also written some C# and JavaScript.
+Shipped Go services to production. Wrote Go tooling for K8S clusters.`
+
+	expected := []Candidate{
+		{Skill: "go", Years: 6, Mentions: 3},
+		{Skill: "kubernetes", Years: 6, Mentions: 2},
+		{Skill: "machine-learning", Mentions: 1},
+		{Skill: "c#", Mentions: 1},
+	}
+	if actual := Find(text, tags); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %+v, but got %+v.", expected, actual)
+	}
+}
+
+func TestThatOnlyLevelsAboveTheProfileAreSuggested(t *testing.T) {
+	p := &dataaccess.Profile{Skills: []dataaccess.Skill{
+		{Skill: "go", Level: dataaccess.ExpertLevel},
+		{Skill: "kubernetes", Level: dataaccess.CompetentLevel},
+	}}
+	candidates := []Candidate{
+		{Skill: "go", Years: 6, Mentions: 3},
+		{Skill: "kubernetes", Years: 6, Mentions: 2},
+		{Skill: "c#", Mentions: 1},
+	}
+
+	expected := []dataaccess.LevelSuggestion{
+		{Skill: "c#", Current: 0, Suggested: dataaccess.CompetentLevel},
+		{Skill: "kubernetes", Current: dataaccess.CompetentLevel, Suggested: dataaccess.ProficientLevel},
+	}
+	if actual := Suggest(p, candidates); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %+v, but got %+v.", expected, actual)
+	}
+}
+
+func TestThatTextIsExtractedFromDocuments(t *testing.T) {
+	var docx bytes.Buffer
+	zw := zip.NewWriter(&docx)
+	f, _ := zw.Create("word/document.xml")
+	f.Write([]byte(`<w:document xmlns:w="w"><w:body><w:p><w:r><w:t>Java</w:t></w:r><w:r><w:tab/><w:t>developer</w:t></w:r></w:p><w:p><w:r><w:t>Go</w:t></w:r></w:p></w:body></w:document>`))
+	zw.Close()
+
+	var content bytes.Buffer
+	fw := zlib.NewWriter(&content)
+	fw.Write([]byte(`BT /F1 12 Tf 72 712 Td (Java) Tj (developer) Tj T* [(G) -20 (o)] TJ ET`))
+	fw.Close()
+	pdf := append([]byte("%PDF-1.4\n1 0 obj << /Filter /FlateDecode >>\nstream\n"), content.Bytes()...)
+	pdf = append(pdf, []byte("\nendstream\nendobj\n%%EOF")...)
+
+	tests := []struct {
+		contentType string
+		data        []byte
+	}{
+		{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", docx.Bytes()},
+		{"application/pdf", pdf},
+		{"application/rtf", []byte(`{\rtf1\ansi{\fonttbl\f0\fswiss Helvetica;}\f0\pard Java \b developer\b0\par Go}`)},
+		{"application/msword", []byte("\xd0\xcf\x11\xe0J\x00a\x00v\x00a\x00 \x00d\x00e\x00v\x00e\x00l\x00o\x00p\x00e\x00r\x00\x01\x02Go is fun")},
+	}
+
+	for _, test := range tests {
+		text, err := Text(test.contentType, test.data)
+		if err != nil {
+			t.Fatalf("For %s, unexpected error %v.", test.contentType, err)
+		}
+		if !strings.Contains(text, "Java") || !strings.Contains(text, "developer") || !strings.Contains(text, "Go") {
+			t.Errorf("For %s, expected the text to be extracted, but got %q.", test.contentType, text)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package resume
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Text extracts the text of a CV with one of the content types accepted by
+// the attachments package. The text is only good enough to find skills in:
+// layout is lost, and text in PDFs which use embedded font encodings can't
+// be read.
+func Text(contentType string, data []byte) (string, error) {
+	switch contentType {
+	case "application/pdf":
+		return pdfText(data), nil
+	case "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
+		return zipXMLText(data, "word/document.xml")
+	case "application/vnd.oasis.opendocument.text":
+		return zipXMLText(data, "content.xml")
+	case "application/rtf":
+		return rtfText(data), nil
+	case "application/msword":
+		return printableText(data), nil
+	}
+	return "", fmt.Errorf("resume: unable to read text from %s files", contentType)
+}
+
+// zipXMLText reads the text of the XML document in the zip archive, with a
+// line for each paragraph.
+func zipXMLText(data []byte, name string) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+
+		var sb strings.Builder
+		d := xml.NewDecoder(rc)
+		for {
+			t, err := d.Token()
+			if err == io.EOF {
+				return sb.String(), nil
+			}
+			if err != nil {
+				return "", err
+			}
+			switch e := t.(type) {
+			case xml.CharData:
+				sb.Write(e)
+			case xml.StartElement:
+				if e.Name.Local == "tab" || e.Name.Local == "s" {
+					sb.WriteString(" ")
+				}
+			case xml.EndElement:
+				if e.Name.Local == "p" || e.Name.Local == "h" {
+					sb.WriteString("\n")
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("resume: the document doesn't contain %s", name)
+}
+
+var (
+	rtfHex     = regexp.MustCompile(`\\'[0-9a-fA-F]{2}`)
+	rtfControl = regexp.MustCompile(`\\(par|line)\b ?|\\[a-zA-Z]+-?\d* ?|\\[^a-zA-Z]|[{}]`)
+)
+
+// rtfText removes the control words and groups from an RTF document.
+func rtfText(data []byte) string {
+	s := rtfHex.ReplaceAllStringFunc(string(data), func(h string) string {
+		b, _ := strconv.ParseUint(h[2:], 16, 8)
+		return string(rune(b))
+	})
+	return rtfControl.ReplaceAllStringFunc(s, func(c string) string {
+		if strings.HasPrefix(c, `\par`) || strings.HasPrefix(c, `\line`) {
+			return "\n"
+		}
+		return ""
+	})
+}
+
+var (
+	pdfStream    = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+	pdfOperators = regexp.MustCompile(`(?s)\((?:\\.|[^\\)])*\)|\bT[dDJj*]\b|\bET\b|'`)
+)
+
+// pdfText reads the strings shown by the text operators in the PDF's
+// content streams, decompressing them if they're compressed with Flate.
+func pdfText(data []byte) string {
+	var sb strings.Builder
+	for _, m := range pdfStream.FindAllSubmatch(data, -1) {
+		content := m[1]
+		if zr, err := zlib.NewReader(bytes.NewReader(content)); err == nil {
+			if inflated, err := ioutil.ReadAll(zr); err == nil {
+				content = inflated
+			}
+		}
+		for _, tok := range pdfOperators.FindAll(content, -1) {
+			switch {
+			case tok[0] == '(':
+				sb.WriteString(unescapePDF(tok[1 : len(tok)-1]))
+			case string(tok) == "Tj":
+				sb.WriteString(" ")
+			case string(tok) != "TJ":
+				sb.WriteString("\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+func unescapePDF(s []byte) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'r':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte(' ')
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// printableText returns the runs of printable characters in a binary
+// document, such as a Word 97 file, which holds its text as either single
+// byte or UTF-16 characters.
+func printableText(data []byte) string {
+	var sb, run strings.Builder
+	flush := func() {
+		if run.Len() >= 4 {
+			sb.WriteString(run.String())
+			sb.WriteString("\n")
+		}
+		run.Reset()
+	}
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		if b >= 0x20 && b < 0x7F {
+			run.WriteByte(b)
+			// Skip the high byte of UTF-16 characters.
+			if i+1 < len(data) && data[i+1] == 0 {
+				i++
+			}
+			continue
+		}
+		flush()
+	}
+	flush()
+	return sb.String()
+}
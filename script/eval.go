@@ -0,0 +1,465 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits restrict the work evaluating an expression can do.
+type Limits struct {
+	// MaxSteps is the number of operations, counting each element of the
+	// lists and strings functions look through.
+	MaxSteps int
+	// MaxBytes is the total size of the strings the expression creates.
+	MaxBytes int
+	// Timeout is how long the expression can run for.
+	Timeout time.Duration
+}
+
+// DefaultLimits are generous for the rules about a profile, which take a few
+// hundred steps.
+var DefaultLimits = Limits{
+	MaxSteps: 10000,
+	MaxBytes: 64 * 1024,
+	Timeout:  10 * time.Millisecond,
+}
+
+// An EvalError is returned when an expression can't be evaluated, e.g.
+// because it compares a number with a string, or exceeds its limits.
+type EvalError struct {
+	Problem string
+}
+
+func (e EvalError) Error() string {
+	return "script: " + e.Problem
+}
+
+// checkEvery is how many steps are taken between checks of the time.
+const checkEvery = 64
+
+// A Budget is the Limits of several evaluations together, e.g. of all the
+// rules applied to one profile, so that they share one timeout and count of
+// steps and bytes, rather than each having its own. It isn't safe for
+// concurrent use.
+type Budget struct {
+	Limits   Limits
+	deadline time.Time
+	steps    int
+	bytes    int
+}
+
+// NewBudget creates a Budget which starts now, and ends at the timeout or
+// the context's deadline, whichever is sooner.
+func NewBudget(ctx context.Context, limits Limits) *Budget {
+	deadline := time.Now().Add(limits.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	return &Budget{Limits: limits, deadline: deadline}
+}
+
+type env struct {
+	ctx    context.Context
+	vars   map[string]interface{}
+	budget *Budget
+}
+
+// step counts n steps, and fails once the limits are exceeded.
+func (e *env) step(n int) error {
+	b := e.budget
+	before := b.steps
+	b.steps += n
+	if b.steps > b.Limits.MaxSteps {
+		return EvalError{fmt.Sprintf("the expression took more than %d steps", b.Limits.MaxSteps)}
+	}
+	if b.steps/checkEvery != before/checkEvery {
+		if e.ctx.Err() != nil || time.Now().After(b.deadline) {
+			return EvalError{fmt.Sprintf("the expression ran for more than %v", b.Limits.Timeout)}
+		}
+	}
+	return nil
+}
+
+// allocate counts the bytes of a string the expression has created.
+func (e *env) allocate(s string) (string, error) {
+	b := e.budget
+	b.bytes += len(s)
+	if b.bytes > b.Limits.MaxBytes {
+		return "", EvalError{fmt.Sprintf("the expression used more than %d bytes", b.Limits.MaxBytes)}
+	}
+	return s, nil
+}
+
+// Eval evaluates the expression with the variables, which must be bools,
+// ints, float64s, strings, []strings or map[string]float64s.
+func (e *Expression) Eval(ctx context.Context, vars map[string]interface{}, limits Limits) (interface{}, error) {
+	return e.EvalWithin(ctx, vars, NewBudget(ctx, limits))
+}
+
+// EvalWithin evaluates the expression, counting its work against the
+// budget. It fails straight away if the budget has already run out.
+func (e *Expression) EvalWithin(ctx context.Context, vars map[string]interface{}, b *Budget) (interface{}, error) {
+	if !time.Now().Before(b.deadline) {
+		return nil, EvalError{fmt.Sprintf("the expression ran for more than %v", b.Limits.Timeout)}
+	}
+	return e.root.eval(&env{ctx: ctx, vars: vars, budget: b})
+}
+
+// EvalBool evaluates the expression, which must be true or false.
+func (e *Expression) EvalBool(ctx context.Context, vars map[string]interface{}, limits Limits) (bool, error) {
+	return e.EvalBoolWithin(ctx, vars, NewBudget(ctx, limits))
+}
+
+// EvalBoolWithin evaluates the expression within the budget. It must be true
+// or false.
+func (e *Expression) EvalBoolWithin(ctx context.Context, vars map[string]interface{}, b *Budget) (bool, error) {
+	v, err := e.EvalWithin(ctx, vars, b)
+	if err != nil {
+		return false, err
+	}
+	result, ok := v.(bool)
+	if !ok {
+		return false, EvalError{fmt.Sprintf("the expression is %s, not true or false", typeName(v))}
+	}
+	return result, nil
+}
+
+// EvalString evaluates the expression, which must be a string.
+func (e *Expression) EvalString(ctx context.Context, vars map[string]interface{}, limits Limits) (string, error) {
+	return e.EvalStringWithin(ctx, vars, NewBudget(ctx, limits))
+}
+
+// EvalStringWithin evaluates the expression within the budget. It must be a
+// string.
+func (e *Expression) EvalStringWithin(ctx context.Context, vars map[string]interface{}, b *Budget) (string, error) {
+	v, err := e.EvalWithin(ctx, vars, b)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", EvalError{fmt.Sprintf("the expression is %s, not a string", typeName(v))}
+	}
+	return s, nil
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "a bool"
+	case float64:
+		return "a number"
+	case string:
+		return "a string"
+	case []string:
+		return "a list"
+	case map[string]float64:
+		return "a map"
+	}
+	return fmt.Sprintf("an unsupported %T", v)
+}
+
+type node interface {
+	eval(e *env) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(e *env) (interface{}, error) {
+	return n.value, e.step(1)
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n variableNode) eval(e *env) (interface{}, error) {
+	if err := e.step(1); err != nil {
+		return nil, err
+	}
+	v, ok := e.vars[n.name]
+	if !ok {
+		return nil, EvalError{fmt.Sprintf("unknown variable '%s'", n.name)}
+	}
+	if i, ok := v.(int); ok {
+		return float64(i), nil
+	}
+	return v, nil
+}
+
+type indexNode struct {
+	target node
+	key    node
+}
+
+// eval looks the key up in the map. Missing keys are zero, so that
+// levels["go"] is 0 for people without the skill.
+func (n indexNode) eval(e *env) (interface{}, error) {
+	target, err := n.target.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	key, err := n.key.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := target.(map[string]float64)
+	if !ok {
+		return nil, EvalError{fmt.Sprintf("only maps can be indexed, not %s", typeName(target))}
+	}
+	k, ok := key.(string)
+	if !ok {
+		return nil, EvalError{fmt.Sprintf("maps are indexed by strings, not %s", typeName(key))}
+	}
+	return m[k], e.step(1)
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n unaryNode) eval(e *env) (interface{}, error) {
+	v, err := n.operand.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.step(1); err != nil {
+		return nil, err
+	}
+	switch x := v.(type) {
+	case bool:
+		if n.op == "!" {
+			return !x, nil
+		}
+	case float64:
+		if n.op == "-" {
+			return -x, nil
+		}
+	}
+	return nil, EvalError{fmt.Sprintf("'%s' can't be applied to %s", n.op, typeName(v))}
+}
+
+type conditionalNode struct {
+	condition node
+	then      node
+	otherwise node
+}
+
+func (n conditionalNode) eval(e *env) (interface{}, error) {
+	c, err := evalBool(e, n.condition, "?")
+	if err != nil {
+		return nil, err
+	}
+	if c {
+		return n.then.eval(e)
+	}
+	return n.otherwise.eval(e)
+}
+
+func evalBool(e *env, n node, op string) (bool, error) {
+	v, err := n.eval(e)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, EvalError{fmt.Sprintf("'%s' needs true or false, not %s", op, typeName(v))}
+	}
+	return b, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) eval(e *env) (interface{}, error) {
+	if err := e.step(1); err != nil {
+		return nil, err
+	}
+	// && and || don't evaluate their right hand side if they don't need to.
+	if n.op == "&&" || n.op == "||" {
+		l, err := evalBool(e, n.left, n.op)
+		if err != nil || l == (n.op == "||") {
+			return l, err
+		}
+		return evalBool(e, n.right, n.op)
+	}
+
+	l, err := n.left.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.right.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	mismatch := EvalError{fmt.Sprintf("'%s' can't be applied to %s and %s", n.op, typeName(l), typeName(r))}
+
+	switch x := l.(type) {
+	case float64:
+		y, ok := r.(float64)
+		if !ok {
+			return nil, mismatch
+		}
+		switch n.op {
+		case "+":
+			return x + y, nil
+		case "-":
+			return x - y, nil
+		case "*":
+			return x * y, nil
+		case "/":
+			if y == 0 {
+				return nil, EvalError{"division by zero"}
+			}
+			return x / y, nil
+		case "==":
+			return x == y, nil
+		case "!=":
+			return x != y, nil
+		case "<":
+			return x < y, nil
+		case "<=":
+			return x <= y, nil
+		case ">":
+			return x > y, nil
+		case ">=":
+			return x >= y, nil
+		}
+	case string:
+		y, ok := r.(string)
+		if !ok {
+			return nil, mismatch
+		}
+		switch n.op {
+		case "+":
+			if err := e.step(len(x) + len(y)); err != nil {
+				return nil, err
+			}
+			return e.allocate(x + y)
+		case "==":
+			return x == y, nil
+		case "!=":
+			return x != y, nil
+		case "<":
+			return x < y, nil
+		case "<=":
+			return x <= y, nil
+		case ">":
+			return x > y, nil
+		case ">=":
+			return x >= y, nil
+		}
+	case bool:
+		y, ok := r.(bool)
+		if !ok {
+			return nil, mismatch
+		}
+		switch n.op {
+		case "==":
+			return x == y, nil
+		case "!=":
+			return x != y, nil
+		}
+	}
+	return nil, mismatch
+}
+
+type function struct {
+	arity int
+	call  func(e *env, args []interface{}) (interface{}, error)
+}
+
+// functions are the functions expressions can call.
+var functions = map[string]function{
+	"len": {1, func(e *env, args []interface{}) (interface{}, error) {
+		switch x := args[0].(type) {
+		case string:
+			return float64(utf8.RuneCountInString(x)), e.step(len(x))
+		case []string:
+			return float64(len(x)), nil
+		case map[string]float64:
+			return float64(len(x)), nil
+		}
+		return nil, EvalError{fmt.Sprintf("len can't be applied to %s", typeName(args[0]))}
+	}},
+	"lower":      stringFunction(strings.ToLower),
+	"upper":      stringFunction(strings.ToUpper),
+	"trim":       stringFunction(strings.TrimSpace),
+	"startsWith": predicate(strings.HasPrefix),
+	"endsWith":   predicate(strings.HasSuffix),
+	// contains finds a substring in a string, or a string in a list.
+	"contains": {2, func(e *env, args []interface{}) (interface{}, error) {
+		item, ok := args[1].(string)
+		if !ok {
+			return nil, EvalError{fmt.Sprintf("contains looks for a string, not %s", typeName(args[1]))}
+		}
+		switch x := args[0].(type) {
+		case string:
+			return strings.Contains(x, item), e.step(len(x))
+		case []string:
+			if err := e.step(len(x)); err != nil {
+				return nil, err
+			}
+			for _, s := range x {
+				if s == item {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return nil, EvalError{fmt.Sprintf("contains can't look in %s", typeName(args[0]))}
+	}},
+}
+
+func stringFunction(f func(string) string) function {
+	return function{1, func(e *env, args []interface{}) (interface{}, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, EvalError{fmt.Sprintf("expected a string, not %s", typeName(args[0]))}
+		}
+		if err := e.step(len(s)); err != nil {
+			return nil, err
+		}
+		return e.allocate(f(s))
+	}}
+}
+
+func predicate(f func(s string, part string) bool) function {
+	return function{2, func(e *env, args []interface{}) (interface{}, error) {
+		s, ok1 := args[0].(string)
+		part, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return nil, EvalError{fmt.Sprintf("expected two strings, not %s and %s", typeName(args[0]), typeName(args[1]))}
+		}
+		return f(s, part), e.step(len(part))
+	}}
+}
+
+type callNode struct {
+	name string
+	f    function
+	args []node
+}
+
+func (n callNode) eval(e *env) (interface{}, error) {
+	if err := e.step(1); err != nil {
+		return nil, err
+	}
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return n.f.call(e, args)
+}
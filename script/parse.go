@@ -0,0 +1,334 @@
+// Package script is a small expression language for the rules tenants write
+// about their own data, e.g.
+//
+//	has("go") && level("go") >= 3 || department != "Engineering"
+//
+// Expressions can't loop, call out of the package or change anything, and
+// are evaluated with limits on the steps they take, the memory their strings
+// use and how long they run, so that one tenant's rules can't slow the
+// service down for others.
+//
+// Values are booleans, numbers, strings, lists of strings and maps of
+// strings to numbers. The operators are, in order of precedence, ?: || &&
+// == != < <= > >= + - * / ! and unary -, and maps are indexed with [].
+// + joins strings as well as adding numbers.
+package script
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxLength is the length of the longest expression, in bytes.
+const MaxLength = 2000
+
+// maxDepth limits the nesting of expressions, so that parsing can't exhaust
+// the stack.
+const maxDepth = 50
+
+// An Expression is a parsed expression, which can be evaluated many times.
+type Expression struct {
+	source string
+	root   node
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// A SyntaxError is returned when an expression can't be parsed.
+type SyntaxError struct {
+	// Offset is the position of the problem, in bytes from the start of the
+	// expression.
+	Offset  int
+	Problem string
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("script: %s at offset %d", e.Problem, e.Offset)
+}
+
+// Parse parses the expression.
+func Parse(source string) (*Expression, error) {
+	if len(source) > MaxLength {
+		return nil, SyntaxError{MaxLength, fmt.Sprintf("the expression is longer than %d bytes", MaxLength)}
+	}
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.ternary(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != endToken {
+		return nil, SyntaxError{t.offset, fmt.Sprintf("unexpected '%s'", t.text)}
+	}
+	return &Expression{source, root}, nil
+}
+
+type tokenKind int
+
+const (
+	endToken tokenKind = iota
+	numberToken
+	stringToken
+	identToken
+	operatorToken
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+	number float64
+}
+
+// operators are longest first, so that <= is matched before <.
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "!", "(", ")", "[", "]", ",", "?", ":"}
+
+func lex(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			start := i
+			for i < len(source) && (source[i] >= '0' && source[i] <= '9' || source[i] == '.') {
+				i++
+			}
+			n, err := strconv.ParseFloat(source[start:i], 64)
+			if err != nil {
+				return nil, SyntaxError{start, fmt.Sprintf("invalid number '%s'", source[start:i])}
+			}
+			tokens = append(tokens, token{numberToken, source[start:i], start, n})
+		case c == '"' || c == '\'':
+			start := i
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(source) {
+					return nil, SyntaxError{start, "unterminated string"}
+				}
+				if rune(source[i]) == c {
+					i++
+					break
+				}
+				if source[i] == '\\' && i+1 < len(source) {
+					i++
+				}
+				b.WriteByte(source[i])
+			}
+			tokens = append(tokens, token{stringToken, b.String(), start, 0})
+		case isLetter(source[i]):
+			start := i
+			for i < len(source) && (isLetter(source[i]) || source[i] >= '0' && source[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, token{identToken, source[start:i], start, 0})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, token{operatorToken, op, i, 0})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, SyntaxError{i, fmt.Sprintf("unexpected '%c'", c)}
+			}
+		}
+	}
+	return append(tokens, token{kind: endToken, text: "end of expression", offset: len(source)}), nil
+}
+
+// isLetter returns true if the byte can start a name. Names are ASCII.
+func isLetter(b byte) bool {
+	return b == '_' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != endToken {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it's one of the operators.
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != operatorToken {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		t := p.peek()
+		return SyntaxError{t.offset, fmt.Sprintf("expected '%s' but found '%s'", op, t.text)}
+	}
+	return nil
+}
+
+func (p *parser) ternary(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, SyntaxError{p.peek().offset, "the expression is nested too deeply"}
+	}
+	condition, err := p.binary(0, depth)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return condition, nil
+	}
+	then, err := p.ternary(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.ternary(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	return conditionalNode{condition, then, otherwise}, nil
+}
+
+// precedence lists the binary operators, loosest first.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+func (p *parser) binary(level int, depth int) (node, error) {
+	if level == len(precedence) {
+		return p.unary(depth)
+	}
+	left, err := p.binary(level+1, depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level+1, depth)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op, left, right}
+	}
+}
+
+func (p *parser) unary(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, SyntaxError{p.peek().offset, "the expression is nested too deeply"}
+	}
+	if op, ok := p.accept("!", "-"); ok {
+		operand, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op, operand}, nil
+	}
+	n, err := p.primary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("["); !ok {
+			return n, nil
+		}
+		key, err := p.ternary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		n = indexNode{n, key}
+	}
+}
+
+func (p *parser) primary(depth int) (node, error) {
+	t := p.next()
+	switch t.kind {
+	case numberToken:
+		return literalNode{t.number}, nil
+	case stringToken:
+		return literalNode{t.text}, nil
+	case identToken:
+		switch t.text {
+		case "true":
+			return literalNode{true}, nil
+		case "false":
+			return literalNode{false}, nil
+		}
+		if _, ok := p.accept("("); !ok {
+			return variableNode{t.text}, nil
+		}
+		f, ok := functions[t.text]
+		if !ok {
+			return nil, SyntaxError{t.offset, fmt.Sprintf("unknown function '%s'", t.text)}
+		}
+		var args []node
+		if _, ok := p.accept(")"); !ok {
+			for {
+				arg, err := p.ternary(depth + 1)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if _, ok := p.accept(","); ok {
+					continue
+				}
+				if err := p.expect(")"); err != nil {
+					return nil, err
+				}
+				break
+			}
+		}
+		if len(args) != f.arity {
+			return nil, SyntaxError{t.offset, fmt.Sprintf("%s takes %d arguments, not %d", t.text, f.arity, len(args))}
+		}
+		return callNode{t.text, f, args}, nil
+	case operatorToken:
+		if t.text == "(" {
+			n, err := p.ternary(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		}
+	}
+	return nil, SyntaxError{t.offset, fmt.Sprintf("unexpected '%s'", t.text)}
+}
@@ -0,0 +1,122 @@
+package script
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+var vars = map[string]interface{}{
+	"department":   "Engineering",
+	"availability": 3,
+	"skills":       []string{"go", "sql"},
+	"levels":       map[string]float64{"go": 4, "sql": 2},
+}
+
+func TestExpressions(t *testing.T) {
+	tests := []struct {
+		expression string
+		expected   interface{}
+	}{
+		{`1 + 2 * 3`, 7.0},
+		{`(1 + 2) * 3`, 9.0},
+		{`-availability + 1`, -2.0},
+		{`levels["go"] >= 3 && contains(skills, "go")`, true},
+		{`levels["rust"] == 0`, true},
+		{`!contains(skills, "rust") || levels["rust"] / 0 > 1`, true},
+		{`department == "Engineering" ? "ENG-" + upper('go') : "other"`, "ENG-GO"},
+		{`startsWith(lower(department), "eng") && !endsWith(department, "x")`, true},
+		{`len(skills) == 2 && len("héllo") == 5 && trim("  a ") == "a"`, true},
+		{`"it's" == 'it\'s'`, true},
+		{`availability > 1 ? availability < 2 ? "amber" : "green" : "red"`, "green"},
+	}
+	for _, test := range tests {
+		e, err := Parse(test.expression)
+		if err != nil {
+			t.Errorf("%s: unexpected error parsing. %v", test.expression, err)
+			continue
+		}
+		actual, err := e.Eval(context.Background(), vars, DefaultLimits)
+		if err != nil {
+			t.Errorf("%s: unexpected error evaluating. %v", test.expression, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("%s: expected %v, but got %v", test.expression, test.expected, actual)
+		}
+	}
+}
+
+func TestThatInvalidExpressionsAreRejected(t *testing.T) {
+	syntax := []string{
+		`1 +`,
+		`(1`,
+		`"unterminated`,
+		`1 # 2`,
+		`exec("rm")`,
+		`len("a", "b")`,
+		`a ? b`,
+		strings.Repeat("(", maxDepth+1) + "1" + strings.Repeat(")", maxDepth+1),
+		strings.Repeat("1+", MaxLength),
+	}
+	for _, s := range syntax {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%.40s: expected a syntax error.", s)
+		}
+	}
+
+	runtime := []string{
+		`department + 1`,
+		`unknown == 1`,
+		`availability && true`,
+		`levels[1]`,
+		`skills["go"]`,
+		`1 / 0`,
+		`len(availability)`,
+	}
+	for _, s := range runtime {
+		e, err := Parse(s)
+		if err != nil {
+			t.Errorf("%s: unexpected syntax error. %v", s, err)
+			continue
+		}
+		if _, err := e.Eval(context.Background(), vars, DefaultLimits); err == nil {
+			t.Errorf("%s: expected an error evaluating.", s)
+		}
+	}
+	if _, err := mustParse(t, `department`).EvalBool(context.Background(), vars, DefaultLimits); err == nil {
+		t.Error("Expected a string not to be accepted as a bool.")
+	}
+}
+
+func TestThatLimitsAreEnforced(t *testing.T) {
+	// Each + doubles the length of the string.
+	doubling := `department` + strings.Repeat(` + department`, 1)
+	for i := 0; i < 12; i++ {
+		doubling = "(" + doubling + ") + (" + doubling + ")"
+		if len(doubling) > MaxLength/2 {
+			break
+		}
+	}
+	e := mustParse(t, doubling)
+	if _, err := e.Eval(context.Background(), vars, Limits{MaxSteps: 1000000, MaxBytes: 1024, Timeout: time.Second}); err == nil || !strings.Contains(err.Error(), "bytes") {
+		t.Errorf("Expected the memory limit to be exceeded, but got %v", err)
+	}
+	if _, err := e.Eval(context.Background(), vars, Limits{MaxSteps: 10, MaxBytes: 1 << 20, Timeout: time.Second}); err == nil || !strings.Contains(err.Error(), "steps") {
+		t.Errorf("Expected the step limit to be exceeded, but got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := e.Eval(ctx, vars, Limits{MaxSteps: 1000000, MaxBytes: 1 << 20, Timeout: time.Second}); err == nil || !strings.Contains(err.Error(), "ran for") {
+		t.Errorf("Expected the time limit to be exceeded, but got %v", err)
+	}
+}
+
+func mustParse(t *testing.T, s string) *Expression {
+	e, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Failed to parse %s. %v", s, err)
+	}
+	return e
+}
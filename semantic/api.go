@@ -0,0 +1,88 @@
+package semantic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// An APIEmbedder embeds texts with an OpenAI compatible embeddings API,
+// which OpenAI, Azure OpenAI, Ollama and LocalAI all provide:
+//
+//	POST {"model": "text-embedding-3-small", "input": ["kubernetes"]}
+//	{"data": [{"index": 0, "embedding": [0.01, -0.02, ...]}]}
+type APIEmbedder struct {
+	url    string
+	model  string
+	key    string
+	Client *http.Client
+}
+
+// NewAPIEmbedder creates an APIEmbedder which posts to the URL. If the key
+// isn't empty, it's sent as a bearer token.
+func NewAPIEmbedder(url string, model string, key string) *APIEmbedder {
+	return &APIEmbedder{url, model, key, &http.Client{Timeout: 30 * time.Second}}
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed posts the texts to the API.
+func (e APIEmbedder) Embed(ctx context.Context, texts []string) ([]Vector, error) {
+	body, err := json.Marshal(embeddingRequest{e.model, texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("Accept", "application/json")
+	if e.key != "" {
+		req.Header.Set("Authorization", "Bearer "+e.key)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("semantic: the embeddings API returned %s: %s", resp.Status, body)
+	}
+
+	var er embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&er); err != nil {
+		return nil, err
+	}
+	op := make([]Vector, len(texts))
+	for _, d := range er.Data {
+		if d.Index < 0 || d.Index >= len(op) {
+			return nil, fmt.Errorf("semantic: the embeddings API returned an embedding for input %d of %d", d.Index, len(op))
+		}
+		op[d.Index] = Vector(d.Embedding)
+	}
+	for i, v := range op {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("semantic: the embeddings API didn't return an embedding for input %d", i)
+		}
+	}
+	return op, nil
+}
@@ -0,0 +1,278 @@
+// Package semantic finds people by what their skills mean rather than what
+// they're called, so that a search for "container orchestration" finds the
+// people tagged "kubernetes" or "nomad". Skill tags are embedded as vectors
+// by a pluggable Embedder, and held in an index in memory.
+package semantic
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Vector is the embedding of a text.
+type Vector []float32
+
+// Similarity returns the cosine similarity of the vectors, from -1 to 1, or
+// 0 if they have different dimensions.
+func (v Vector) Similarity(w Vector) float64 {
+	if len(v) != len(w) {
+		return 0
+	}
+	var dot, vv, ww float64
+	for i := range v {
+		dot += float64(v[i]) * float64(w[i])
+		vv += float64(v[i]) * float64(v[i])
+		ww += float64(w[i]) * float64(w[i])
+	}
+	if vv == 0 || ww == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(vv*ww)
+}
+
+// An Embedder turns texts into vectors, so that texts with similar meanings
+// have similar vectors.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([]Vector, error)
+}
+
+// OpenEmbedder returns the embedder described by the value, the http or
+// https URL of an OpenAI compatible embeddings API, e.g.
+// https://api.openai.com/v1/embeddings or
+// http://localhost:11434/v1/embeddings for Ollama, authenticated with the
+// key in the EMBEDDING_API_KEY environment variable.
+func OpenEmbedder(value string, model string) (Embedder, error) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("semantic: unsupported embedder '%s', use the URL of an embeddings API", value)
+	}
+	return NewAPIEmbedder(value, model, os.Getenv("EMBEDDING_API_KEY")), nil
+}
+
+// DefaultMinSimilarity is how similar a skill must be to a search to match
+// it, unless configured.
+const DefaultMinSimilarity = 0.4
+
+// embeddingBatchSize is the number of tags embedded in each request.
+const embeddingBatchSize = 100
+
+// The Index holds the embeddings of the skill tags. Watch reloads it
+// periodically, only embedding the tags which are new or have changed.
+type Index struct {
+	DataAccess dataaccess.DataAccess
+	Embedder   Embedder
+	// MinSimilarity is how similar a skill must be to a search to match it.
+	MinSimilarity float64
+	// RefreshInterval determines how often Watch reloads the index.
+	RefreshInterval time.Duration
+	mutex           sync.RWMutex
+	skills          map[string]embeddedSkill
+}
+
+type embeddedSkill struct {
+	text   string
+	vector Vector
+}
+
+// NewIndex creates an empty Index, which is filled by Refresh.
+func NewIndex(da dataaccess.DataAccess, embedder Embedder) *Index {
+	return &Index{
+		DataAccess:      da,
+		Embedder:        embedder,
+		MinSimilarity:   DefaultMinSimilarity,
+		RefreshInterval: time.Hour,
+		skills:          map[string]embeddedSkill{},
+	}
+}
+
+// text is what's embedded for the tag: its name, aliases, category and the
+// descriptions of its levels, which say most about what it means.
+func text(tag dataaccess.SkillTag) string {
+	parts := append([]string{tag.Name}, tag.Aliases...)
+	if tag.Category != "" {
+		parts = append(parts, tag.Category)
+	}
+	for _, d := range tag.Descriptors {
+		parts = append(parts, d.Description)
+	}
+	return strings.Join(parts, ". ")
+}
+
+// Refresh embeds the skill tags which are new or have changed, and removes
+// the ones which have been deleted.
+func (ix *Index) Refresh(ctx context.Context) error {
+	tags, err := ix.DataAccess.ListSkillTags()
+	if err != nil {
+		return err
+	}
+
+	ix.mutex.RLock()
+	skills := make(map[string]embeddedSkill, len(tags))
+	var pending []dataaccess.SkillTag
+	for _, tag := range tags {
+		if s, ok := ix.skills[tag.Name]; ok && s.text == text(tag) {
+			skills[tag.Name] = s
+			continue
+		}
+		pending = append(pending, tag)
+	}
+	ix.mutex.RUnlock()
+
+	for start := 0; start < len(pending); start += embeddingBatchSize {
+		batch := pending[start:]
+		if len(batch) > embeddingBatchSize {
+			batch = batch[:embeddingBatchSize]
+		}
+		texts := make([]string, len(batch))
+		for i, tag := range batch {
+			texts[i] = text(tag)
+		}
+		vectors, err := ix.Embedder.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		for i, tag := range batch {
+			skills[tag.Name] = embeddedSkill{texts[i], vectors[i]}
+		}
+	}
+
+	ix.mutex.Lock()
+	defer ix.mutex.Unlock()
+
+	ix.skills = skills
+	return nil
+}
+
+// Watch fills the index, then refreshes it periodically until the context
+// is cancelled.
+func (ix *Index) Watch(ctx context.Context) {
+	if err := ix.Refresh(ctx); err != nil {
+		log.Print("Failed to fill the semantic skill index. ", err)
+	}
+
+	ticker := time.NewTicker(ix.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ix.Refresh(ctx); err != nil {
+				log.Print("Failed to refresh the semantic skill index. ", err)
+			}
+		}
+	}
+}
+
+// A SimilarSkill is a skill tag whose meaning is like a search.
+type SimilarSkill struct {
+	Skill      string  `json:"skill"`
+	Similarity float64 `json:"similarity"`
+}
+
+// Similar returns up to limit skill tags at least MinSimilarity like the
+// query, most similar first.
+func (ix *Index) Similar(ctx context.Context, query string, limit int) ([]SimilarSkill, error) {
+	vectors, err := ix.Embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+
+	ix.mutex.RLock()
+	defer ix.mutex.RUnlock()
+
+	op := []SimilarSkill{}
+	for name, s := range ix.skills {
+		if similarity := vectors[0].Similarity(s.vector); similarity >= ix.MinSimilarity {
+			op = append(op, SimilarSkill{name, similarity})
+		}
+	}
+	sort.Slice(op, func(i, j int) bool {
+		if op[i].Similarity != op[j].Similarity {
+			return op[i].Similarity > op[j].Similarity
+		}
+		return op[i].Skill < op[j].Skill
+	})
+	if limit > 0 && len(op) > limit {
+		op = op[:limit]
+	}
+	return op, nil
+}
+
+// A Match is a person with skills like a search.
+type Match struct {
+	dataaccess.SearchDocument
+	// Matched are the person's skills which are like the search, best
+	// first.
+	Matched []SkillMatch `json:"matched"`
+	// Score is the similarity of the best skill, weighted by the person's
+	// level of it, from 0 to 1.
+	Score float64 `json:"score"`
+}
+
+// A SkillMatch is one of a person's skills which is like a search.
+type SkillMatch struct {
+	SimilarSkill
+	Level dataaccess.DreyfusLevel `json:"level"`
+}
+
+// MaxSimilarSkills is the number of skill tags a search looks for people
+// with.
+const MaxSimilarSkills = 10
+
+// Search finds the people in the domain with skills like the query, at the
+// level or above, from the search read model. People with a skill more like
+// the query, or at a higher level, come first.
+func (ix *Index) Search(ctx context.Context, domain string, query string, level dataaccess.DreyfusLevel) ([]Match, error) {
+	similar, err := ix.Similar(ctx, query, MaxSimilarSkills)
+	if err != nil {
+		return nil, err
+	}
+
+	da := dataaccess.WithContext(ix.DataAccess, ctx)
+	matches := make(map[string]*Match)
+	for _, s := range similar {
+		documents, err := da.SearchDocuments(domain, dataaccess.SearchQuery{Skill: s.Skill, Level: level})
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range documents {
+			l := d.Level(s.Skill)
+			if l == 0 || l < level {
+				continue
+			}
+			m, ok := matches[d.EmailAddress]
+			if !ok {
+				m = &Match{SearchDocument: d}
+				matches[d.EmailAddress] = m
+			}
+			m.Matched = append(m.Matched, SkillMatch{s, l})
+			if score := s.Similarity * float64(l) / float64(dataaccess.MasterLevel); score > m.Score {
+				m.Score = score
+			}
+		}
+	}
+
+	op := []Match{}
+	for _, m := range matches {
+		op = append(op, *m)
+	}
+	sort.Slice(op, func(i, j int) bool {
+		if op[i].Score != op[j].Score {
+			return op[i].Score > op[j].Score
+		}
+		return op[i].EmailAddress < op[j].EmailAddress
+	})
+	return op, nil
+}
@@ -0,0 +1,117 @@
+package semantic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// topicEmbedder embeds texts by which topics they mention, so that texts
+// about the same topic are similar.
+type topicEmbedder struct {
+	calls int
+}
+
+var topics = [][]string{{"container", "kubernetes", "nomad"}, {"database", "sql"}}
+
+func (e *topicEmbedder) Embed(ctx context.Context, texts []string) ([]Vector, error) {
+	e.calls++
+	op := make([]Vector, len(texts))
+	for i, t := range texts {
+		op[i] = make(Vector, len(topics))
+		for j, words := range topics {
+			for _, w := range words {
+				if strings.Contains(strings.ToLower(t), w) {
+					op[i][j]++
+				}
+			}
+		}
+	}
+	return op, nil
+}
+
+type skillStore struct {
+	dataaccess.DataAccess
+	tags []dataaccess.SkillTag
+}
+
+func (s *skillStore) ListSkillTags() ([]dataaccess.SkillTag, error) {
+	return s.tags, nil
+}
+
+func (s *skillStore) SearchDocuments(domain string, q dataaccess.SearchQuery) ([]dataaccess.SearchDocument, error) {
+	people := []dataaccess.SearchDocument{
+		{EmailAddress: "a@github.com", Skills: []dataaccess.SearchSkill{{Skill: "kubernetes", Level: 2}, {Skill: "nomad", Level: 4}}},
+		{EmailAddress: "b@github.com", Skills: []dataaccess.SearchSkill{{Skill: "kubernetes", Level: 5}}},
+		{EmailAddress: "c@github.com", Skills: []dataaccess.SearchSkill{{Skill: "postgres", Level: 5}}},
+	}
+	var op []dataaccess.SearchDocument
+	for _, d := range people {
+		if d.Level(q.Skill) >= q.Level && d.Level(q.Skill) > 0 {
+			op = append(op, d)
+		}
+	}
+	return op, nil
+}
+
+func TestThatPeopleAreFoundBySkillsWithSimilarMeanings(t *testing.T) {
+	store := &skillStore{tags: []dataaccess.SkillTag{
+		{Name: "kubernetes"},
+		{Name: "nomad"},
+		{Name: "postgres", Category: "database"},
+	}}
+	embedder := &topicEmbedder{}
+	ix := NewIndex(store, embedder)
+	if err := ix.Refresh(context.Background()); err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+
+	matches, err := ix.Search(context.Background(), "github.com", "container orchestration", dataaccess.ProficientLevel)
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if len(matches) != 2 || matches[0].EmailAddress != "b@github.com" || matches[1].EmailAddress != "a@github.com" {
+		t.Fatalf("Expected the kubernetes master, then the nomad expert, but received %+v", matches)
+	}
+	if m := matches[1].Matched; len(m) != 1 || m[0].Skill != "nomad" {
+		t.Errorf("Expected only a@github.com's nomad to be at the level, but received %+v", m)
+	}
+
+	calls := embedder.calls
+	store.tags = append(store.tags[:2], dataaccess.SkillTag{Name: "mysql"})
+	if err := ix.Refresh(context.Background()); err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if embedder.calls != calls+1 || len(ix.skills) != 3 {
+		t.Errorf("Expected only the new tag to be embedded, in %d calls, but received %d calls and %d skills", 1, embedder.calls-calls, len(ix.skills))
+	}
+	if similar, _ := ix.Similar(context.Background(), "sql database", 10); len(similar) != 1 || similar[0].Skill != "mysql" {
+		t.Errorf("Expected the deleted tag to be removed, but received %v", similar)
+	}
+}
+
+func TestThatTheAPIEmbedderReturnsEmbeddingsInOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.Header.Get("Authorization") != "Bearer key" || req.Model != "small" || len(req.Input) != 2 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data": [{"index": 1, "embedding": [0, 1]}, {"index": 0, "embedding": [1, 0]}]}`))
+	}))
+	defer server.Close()
+
+	vectors, err := NewAPIEmbedder(server.URL, "small", "key").Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal("Unexpected error.", err)
+	}
+	if vectors[0].Similarity(Vector{1, 0}) != 1 || vectors[1].Similarity(Vector{0, 1}) != 1 {
+		t.Errorf("Expected the embeddings in the order of the inputs, but received %v", vectors)
+	}
+}
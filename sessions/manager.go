@@ -0,0 +1,192 @@
+package sessions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned when a token has been modified, or was not
+	// issued with any of the Manager's keys.
+	ErrInvalidToken = errors.New("sessions: the token is invalid")
+	// ErrExpiredToken is returned when the token's expiry has passed.
+	ErrExpiredToken = errors.New("sessions: the token has expired")
+	// ErrNoKey is returned when the Manager is created without a key.
+	ErrNoKey = errors.New("sessions: a key is required")
+	// ErrRevokedToken is returned when the token was issued before the
+	// user's sessions were revoked.
+	ErrRevokedToken = errors.New("sessions: the token has been revoked")
+)
+
+// A Token is the content of a session token.
+type Token struct {
+	EmailAddress string    `json:"emailAddress"`
+	Issued       time.Time `json:"issued"`
+	Expires      time.Time `json:"expires"`
+	// Impersonator is the email address of the administrator acting as the
+	// user, if the token was issued by Impersonate.
+	Impersonator string `json:"impersonator,omitempty"`
+}
+
+// The Manager issues and verifies session tokens. Tokens are encrypted and
+// authenticated with the current key. Tokens issued with previous keys are
+// still accepted, so that rotating the key doesn't end every session.
+type Manager struct {
+	// MaxAge is how long a token is valid for after it's issued.
+	MaxAge time.Duration
+	// RenewAfter is how long after issue Renew replaces a token.
+	RenewAfter time.Duration
+	// Revoked maps email addresses to when their sessions were revoked.
+	// Tokens issued before then are rejected.
+	Revoked map[string]time.Time
+	ciphers []cipher.AEAD
+	now     func() time.Time
+}
+
+// NewManager creates a Manager which issues tokens with the current key, and
+// accepts tokens issued with the current key or any of the previous keys.
+func NewManager(currentKey []byte, previousKeys ...[]byte) (*Manager, error) {
+	if len(currentKey) == 0 {
+		return nil, ErrNoKey
+	}
+
+	m := &Manager{
+		MaxAge:     30 * 24 * time.Hour,
+		RenewAfter: 24 * time.Hour,
+		now:        time.Now,
+	}
+
+	for _, key := range append([][]byte{currentKey}, previousKeys...) {
+		aead, err := newCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		m.ciphers = append(m.ciphers, aead)
+	}
+
+	return m, nil
+}
+
+// newCipher derives a token encryption key from the configuration key, so
+// that the configuration key isn't used directly by more than one algorithm.
+func newCipher(key []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("pill session token"))
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Issue creates a token for the email address.
+func (m *Manager) Issue(emailAddress string) (string, error) {
+	now := m.now()
+
+	return m.seal(Token{
+		EmailAddress: emailAddress,
+		Issued:       now,
+		Expires:      now.Add(m.MaxAge),
+	})
+}
+
+// Impersonate creates a token which allows the impersonator to act as the
+// user with the email address for the duration, up to MaxAge.
+func (m *Manager) Impersonate(emailAddress string, impersonator string, d time.Duration) (string, error) {
+	if d > m.MaxAge {
+		d = m.MaxAge
+	}
+
+	now := m.now()
+
+	return m.seal(Token{
+		EmailAddress: emailAddress,
+		Issued:       now,
+		Expires:      now.Add(d),
+		Impersonator: impersonator,
+	})
+}
+
+func (m *Manager) seal(t Token) (string, error) {
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+
+	aead := m.ciphers[0]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, payload, nil)), nil
+}
+
+// Verify checks the token and returns its content.
+func (m *Manager) Verify(token string) (Token, error) {
+	t, _, err := m.verify(token)
+	return t, err
+}
+
+// verify also returns the index of the key which verified the token.
+func (m *Manager) verify(token string) (Token, int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Token{}, -1, ErrInvalidToken
+	}
+
+	for idx, aead := range m.ciphers {
+		if len(data) < aead.NonceSize() {
+			return Token{}, -1, ErrInvalidToken
+		}
+
+		payload, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+		if err != nil {
+			continue
+		}
+
+		var t Token
+		if err := json.Unmarshal(payload, &t); err != nil {
+			return Token{}, -1, ErrInvalidToken
+		}
+
+		if !m.now().Before(t.Expires) {
+			return t, idx, ErrExpiredToken
+		}
+
+		if revoked, ok := m.Revoked[strings.ToLower(t.EmailAddress)]; ok && t.Issued.Before(revoked) {
+			return t, idx, ErrRevokedToken
+		}
+
+		return t, idx, nil
+	}
+
+	return Token{}, -1, ErrInvalidToken
+}
+
+// Renew verifies the token and issues a replacement if it was issued with a
+// previous key, or more than RenewAfter ago. If the token doesn't need to be
+// replaced, it is returned unchanged and renewed is false. Impersonation
+// tokens are never renewed, so that they expire when intended.
+func (m *Manager) Renew(token string) (renewedToken string, renewed bool, err error) {
+	t, keyIndex, err := m.verify(token)
+	if err != nil {
+		return "", false, err
+	}
+
+	if t.Impersonator != "" || (keyIndex == 0 && m.now().Sub(t.Issued) < m.RenewAfter) {
+		return token, false, nil
+	}
+
+	renewedToken, err = m.Issue(t.EmailAddress)
+	return renewedToken, err == nil, err
+}
@@ -0,0 +1,164 @@
+package sessions
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	key1 = []byte("0123456789abcdef0123456789abcdef")
+	key2 = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func TestThatIssuedTokensCanBeVerified(t *testing.T) {
+	m, _ := NewManager(key1)
+
+	token, err := m.Issue("a-h@github.com")
+	if err != nil {
+		t.Fatal("Failed to issue a token.", err)
+	}
+
+	actual, err := m.Verify(token)
+	if err != nil {
+		t.Fatal("Failed to verify the token.", err)
+	}
+
+	if actual.EmailAddress != "a-h@github.com" {
+		t.Errorf("Expected the token to be for a-h@github.com, but was %s", actual.EmailAddress)
+	}
+}
+
+func TestThatTokensAreVerifiedWithPreviousKeys(t *testing.T) {
+	old, _ := NewManager(key1)
+	token, _ := old.Issue("a-h@github.com")
+
+	rotated, _ := NewManager(key2, key1)
+
+	if _, err := rotated.Verify(token); err != nil {
+		t.Error("A token issued with a previous key should be valid.", err)
+	}
+
+	withoutPrevious, _ := NewManager(key2)
+
+	if _, err := withoutPrevious.Verify(token); err != ErrInvalidToken {
+		t.Errorf("A token issued with an unknown key should be invalid, but the error was %v", err)
+	}
+}
+
+func TestThatExpiredTokensAreRejected(t *testing.T) {
+	now := time.Now()
+	m, _ := NewManager(key1)
+	m.now = func() time.Time { return now }
+
+	token, _ := m.Issue("a-h@github.com")
+	now = now.Add(m.MaxAge)
+
+	if _, err := m.Verify(token); err != ErrExpiredToken {
+		t.Errorf("Expected the token to have expired, but the error was %v", err)
+	}
+}
+
+func TestThatTokensIssuedBeforeRevocationAreRejected(t *testing.T) {
+	now := time.Now()
+	m, _ := NewManager(key1)
+	m.now = func() time.Time { return now }
+
+	before, _ := m.Issue("a-h@github.com")
+	other, _ := m.Issue("dev@github.com")
+	now = now.Add(time.Hour)
+	m.Revoked = map[string]time.Time{"a-h@github.com": now}
+	now = now.Add(time.Minute)
+	after, _ := m.Issue("A-H@github.com")
+
+	if _, err := m.Verify(before); err != ErrRevokedToken {
+		t.Errorf("Expected the token to have been revoked, but the error was %v", err)
+	}
+	if _, err := m.Verify(other); err != nil {
+		t.Errorf("Expected other users' tokens to be valid, but the error was %v", err)
+	}
+	if _, err := m.Verify(after); err != nil {
+		t.Errorf("Expected tokens issued after the revocation to be valid, but the error was %v", err)
+	}
+}
+
+func TestThatModifiedTokensAreRejected(t *testing.T) {
+	m, _ := NewManager(key1)
+	token, _ := m.Issue("a-h@github.com")
+
+	replacement := "A"
+	if token[10] == 'A' {
+		replacement = "B"
+	}
+
+	tests := []string{
+		"",
+		"not base64!",
+		token[:len(token)-2],
+		token[:10] + replacement + token[11:],
+	}
+
+	for _, test := range tests {
+		if _, err := m.Verify(test); err != ErrInvalidToken {
+			t.Errorf("Expected the token '%s' to be invalid, but the error was %v", test, err)
+		}
+	}
+}
+
+func TestThatTokensAreRenewedWhenOldOrIssuedWithAPreviousKey(t *testing.T) {
+	now := time.Now()
+	old, _ := NewManager(key1)
+	old.now = func() time.Time { return now }
+	oldToken, _ := old.Issue("a-h@github.com")
+
+	m, _ := NewManager(key2, key1)
+	m.now = func() time.Time { return now }
+	freshToken, _ := m.Issue("a-h@github.com")
+
+	if _, renewed, _ := m.Renew(freshToken); renewed {
+		t.Error("A fresh token issued with the current key should not be renewed.")
+	}
+
+	renewedToken, renewed, err := m.Renew(oldToken)
+	if !renewed || err != nil {
+		t.Error("A token issued with a previous key should be renewed.", err)
+	}
+
+	if _, keyIndex, _ := m.verify(renewedToken); keyIndex != 0 {
+		t.Error("The renewed token should be issued with the current key.")
+	}
+
+	now = now.Add(m.RenewAfter)
+
+	if _, renewed, _ := m.Renew(freshToken); !renewed {
+		t.Error("A token issued more than RenewAfter ago should be renewed.")
+	}
+}
+
+func TestThatAKeyIsRequired(t *testing.T) {
+	if _, err := NewManager(nil); err != ErrNoKey {
+		t.Errorf("Expected ErrNoKey, but was %v", err)
+	}
+}
+
+func TestThatImpersonationTokensExpireAndAreNotRenewed(t *testing.T) {
+	m, _ := NewManager(key1)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	token, _ := m.Impersonate("a-h@github.com", "admin@github.com", time.Hour)
+
+	actual, err := m.Verify(token)
+	if err != nil || actual.Impersonator != "admin@github.com" {
+		t.Fatalf("Expected an impersonation token for admin@github.com, but received %v, %v", actual, err)
+	}
+
+	m.now = func() time.Time { return now.Add(59 * time.Minute) }
+	if _, renewed, _ := m.Renew(token); renewed {
+		t.Error("Impersonation tokens should not be renewed.")
+	}
+
+	m.now = func() time.Time { return now.Add(61 * time.Minute) }
+	if _, err := m.Verify(token); err != ErrExpiredToken {
+		t.Errorf("Expected the impersonation token to have expired, but the error was %v", err)
+	}
+}
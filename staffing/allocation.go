@@ -0,0 +1,49 @@
+package staffing
+
+import (
+	"sort"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// An Allocation is the bookings of a person during a period.
+type Allocation struct {
+	EmailAddress string `json:"emailAddress"`
+	Name         string `json:"name,omitempty"`
+	// Peak is the highest percentage of the person's time which is booked
+	// during the period.
+	Peak     int                  `json:"peak"`
+	Bookings []dataaccess.Booking `json:"bookings"`
+}
+
+// Allocations lists the people with bookings during the period, most
+// allocated first.
+func Allocations(profiles []dataaccess.Profile, start, end time.Time) []Allocation {
+	op := []Allocation{}
+	for _, p := range profiles {
+		var bookings []dataaccess.Booking
+		for _, b := range p.Bookings {
+			if b.Overlaps(start, end) {
+				bookings = append(bookings, b)
+			}
+		}
+		if len(bookings) == 0 {
+			continue
+		}
+		sort.Slice(bookings, func(i, j int) bool { return bookings[i].Start.Before(bookings[j].Start) })
+		op = append(op, Allocation{
+			EmailAddress: p.EmailAddress,
+			Name:         p.Name,
+			Peak:         dataaccess.PeakAllocation(bookings, start, end),
+			Bookings:     bookings,
+		})
+	}
+	sort.SliceStable(op, func(i, j int) bool {
+		if op[i].Peak != op[j].Peak {
+			return op[i].Peak > op[j].Peak
+		}
+		return op[i].EmailAddress < op[j].EmailAddress
+	})
+	return op
+}
@@ -0,0 +1,136 @@
+package staffing
+
+import (
+	"sort"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// ForecastMonths is the number of months forecast, two quarters.
+const ForecastMonths = 6
+
+// A Forecast compares the demand for skills from projects with the capacity
+// of the people who have them, month by month.
+type Forecast struct {
+	Months []MonthForecast `json:"months"`
+}
+
+// A MonthForecast is the demand and capacity for each skill needed during a
+// month.
+type MonthForecast struct {
+	Start  time.Time       `json:"start"`
+	Skills []SkillForecast `json:"skills"`
+}
+
+// A SkillForecast compares the demand for a skill with the capacity to meet
+// it, in full time people.
+type SkillForecast struct {
+	Skill string `json:"skill"`
+	// Demand is the number of people needed by confirmed projects which
+	// haven't been booked yet.
+	Demand float64 `json:"demand"`
+	// DraftDemand is the number of people needed by draft projects, e.g.
+	// opportunities in the sales pipeline.
+	DraftDemand float64 `json:"draftDemand"`
+	// Capacity is the unbooked time of the available people with the skill,
+	// at the lowest level the projects need, less their days off.
+	Capacity float64 `json:"capacity"`
+	// Shortfall is how far the demand, including drafts, exceeds the
+	// capacity.
+	Shortfall float64 `json:"shortfall"`
+}
+
+// NewForecast forecasts the months from the one containing the time. Each
+// of a project's requirements needs one person, unless someone booked onto
+// the project meets it.
+func NewForecast(projects []dataaccess.Project, profiles []dataaccess.Profile, at time.Time, months int) Forecast {
+	f := Forecast{Months: []MonthForecast{}}
+
+	start := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < months; i++ {
+		end := start.AddDate(0, 1, 0)
+		f.Months = append(f.Months, forecastMonth(projects, profiles, start, end))
+		start = end
+	}
+	return f
+}
+
+func forecastMonth(projects []dataaccess.Project, profiles []dataaccess.Profile, start, end time.Time) MonthForecast {
+	demand := make(map[string]*SkillForecast)
+	lowest := make(map[string]dataaccess.DreyfusLevel)
+
+	for _, p := range projects {
+		if !p.Start.Before(end) || !start.Before(p.End) {
+			continue
+		}
+		for _, r := range Uncovered(p, profiles) {
+			sf, ok := demand[r.Skill]
+			if !ok {
+				sf = &SkillForecast{Skill: r.Skill}
+				demand[r.Skill] = sf
+				lowest[r.Skill] = r.Level
+			}
+			if p.Status == dataaccess.ProjectDraft {
+				sf.DraftDemand++
+			} else {
+				sf.Demand++
+			}
+			if r.Level < lowest[r.Skill] {
+				lowest[r.Skill] = r.Level
+			}
+		}
+	}
+
+	middle := start.Add(end.Sub(start) / 2)
+	for _, p := range profiles {
+		if p.AvailabilityAt(middle) == dataaccess.Red {
+			continue
+		}
+		free := float64(dataaccess.FullAllocation-dataaccess.PeakAllocation(p.Bookings, start, end)) / dataaccess.FullAllocation
+		// Days off, such as leave and public holidays, aren't billable.
+		if working, available := p.AvailableDays(start, end); working > 0 {
+			free *= float64(available) / float64(working)
+		}
+		if free <= 0 {
+			continue
+		}
+		for _, s := range p.Skills {
+			if sf, ok := demand[s.Skill]; ok && s.Level >= lowest[s.Skill] {
+				sf.Capacity += free
+			}
+		}
+	}
+
+	m := MonthForecast{Start: start, Skills: []SkillForecast{}}
+	for _, sf := range demand {
+		if shortfall := sf.Demand + sf.DraftDemand - sf.Capacity; shortfall > 0 {
+			sf.Shortfall = shortfall
+		}
+		m.Skills = append(m.Skills, *sf)
+	}
+	sort.Slice(m.Skills, func(i, j int) bool {
+		if m.Skills[i].Shortfall != m.Skills[j].Shortfall {
+			return m.Skills[i].Shortfall > m.Skills[j].Shortfall
+		}
+		return m.Skills[i].Skill < m.Skills[j].Skill
+	})
+	return m
+}
+
+// Uncovered returns the project's requirements which none of the people
+// booked onto it meet.
+func Uncovered(p dataaccess.Project, profiles []dataaccess.Profile) []dataaccess.SkillRequirement {
+	var people []dataaccess.Profile
+	for _, profile := range profiles {
+		for _, b := range profile.Bookings {
+			if b.ProjectID == p.ID {
+				people = append(people, profile)
+				break
+			}
+		}
+	}
+	// Booked people are busy with the project, so their availability isn't
+	// taken into account.
+	return Suggest(p.Requirements, people, 0, p.Start).Uncovered
+}
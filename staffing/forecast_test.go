@@ -0,0 +1,80 @@
+package staffing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func TestThatShortfallsAreForecastEachMonth(t *testing.T) {
+	march := time.Date(2017, time.March, 1, 0, 0, 0, 0, time.UTC)
+	projects := []dataaccess.Project{
+		{ID: "website", Status: dataaccess.ProjectConfirmed, Start: march, End: march.AddDate(0, 2, 0),
+			Requirements: []dataaccess.SkillRequirement{{Skill: "go", Level: 3}, {Skill: "sql", Level: 2}}},
+		{ID: "app", Status: dataaccess.ProjectDraft, Start: march.AddDate(0, 1, 0), End: march.AddDate(0, 3, 0),
+			Requirements: []dataaccess.SkillRequirement{{Skill: "go", Level: 2}}},
+	}
+	dba := profile("dba@github.com", dataaccess.Green, skill("sql", 4))
+	dba.Bookings = []dataaccess.Booking{{ProjectID: "website", Start: march, End: march.AddDate(0, 2, 0), Percentage: 100}}
+	half := profile("half@github.com", dataaccess.Green, skill("go", 2))
+	half.Bookings = []dataaccess.Booking{{ProjectID: "other", Start: march, End: march.AddDate(1, 0, 0), Percentage: 50}}
+	profiles := []dataaccess.Profile{
+		dba,
+		half,
+		profile("busy@github.com", dataaccess.Red, skill("go", 5)),
+	}
+
+	f := NewForecast(projects, profiles, march.AddDate(0, 0, 14), 4)
+
+	if len(f.Months) != 4 || !f.Months[0].Start.Equal(march) {
+		t.Fatalf("Expected 4 months from March, but received %v", f.Months)
+	}
+
+	tests := []struct {
+		month    int
+		expected []SkillForecast
+	}{
+		// The sql requirement is met by the person booked onto the website,
+		// and half@github.com's go isn't good enough for it.
+		{0, []SkillForecast{{Skill: "go", Demand: 1, Shortfall: 1}}},
+		// The lower level needed by the app lets half@github.com help.
+		{1, []SkillForecast{{Skill: "go", Demand: 1, DraftDemand: 1, Capacity: 0.5, Shortfall: 1.5}}},
+		{2, []SkillForecast{{Skill: "go", DraftDemand: 1, Capacity: 0.5, Shortfall: 0.5}}},
+		{3, []SkillForecast{}},
+	}
+
+	for _, test := range tests {
+		actual := f.Months[test.month].Skills
+		if len(actual) != len(test.expected) {
+			t.Errorf("For month %d, expected %v, but received %v", test.month, test.expected, actual)
+			continue
+		}
+		for i := range actual {
+			if actual[i] != test.expected[i] {
+				t.Errorf("For month %d, expected %v, but received %v", test.month, test.expected, actual)
+			}
+		}
+	}
+}
+
+func TestThatDaysOffReduceTheCapacity(t *testing.T) {
+	april := time.Date(2017, time.April, 1, 0, 0, 0, 0, time.UTC)
+	projects := []dataaccess.Project{
+		{ID: "website", Status: dataaccess.ProjectConfirmed, Start: april, End: april.AddDate(0, 1, 0),
+			Requirements: []dataaccess.SkillRequirement{{Skill: "go", Level: 3}}},
+	}
+	dev := profile("dev@github.com", dataaccess.Green, skill("go", 4))
+	// Good Friday and Easter Monday are 2 of April's 20 working days.
+	dev.AvailabilityWindows = []dataaccess.AvailabilityWindow{
+		{Start: april.AddDate(0, 0, 13), End: april.AddDate(0, 0, 14), Availability: dataaccess.Red, Source: dataaccess.HolidaySource},
+		{Start: april.AddDate(0, 0, 16), End: april.AddDate(0, 0, 17), Availability: dataaccess.Red, Source: dataaccess.HolidaySource},
+	}
+
+	f := NewForecast(projects, []dataaccess.Profile{dev}, april, 1)
+
+	expected := SkillForecast{Skill: "go", Demand: 1, Capacity: 0.9, Shortfall: 0.1}
+	if s := f.Months[0].Skills; len(s) != 1 || s[0].Capacity != expected.Capacity || s[0].Shortfall < 0.099 || s[0].Shortfall > 0.101 {
+		t.Errorf("Expected %v, but received %v", expected, s)
+	}
+}
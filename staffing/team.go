@@ -0,0 +1,169 @@
+// Package staffing suggests who to put on a piece of work, based on the
+// skills it needs and who is available.
+package staffing
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+// A Member is a person suggested for the team.
+type Member struct {
+	EmailAddress string               `json:"emailAddress"`
+	Name         string               `json:"name,omitempty"`
+	Availability dataaccess.RagStatus `json:"availability"`
+	// Covers lists the skills the person was chosen for.
+	Covers []string `json:"covers"`
+}
+
+// A Team is the suggested team, and any requirements nobody available
+// could meet.
+type Team struct {
+	Members   []Member                      `json:"members"`
+	Uncovered []dataaccess.SkillRequirement `json:"uncovered"`
+}
+
+// Suggest picks a small team from the candidates which covers the
+// requirements. Finding the smallest team is a set cover problem, so it's
+// approximated: the person who meets the most outstanding requirements is
+// picked until they're all met, then anyone made redundant by later picks is
+// dropped. Ties go to the more available person. Candidates whose
+// availability at the time is below the minimum are ignored.
+func Suggest(requirements []dataaccess.SkillRequirement, candidates []dataaccess.Profile, minimum dataaccess.RagStatus, at time.Time) Team {
+	requirements = merge(requirements)
+
+	type candidate struct {
+		profile      dataaccess.Profile
+		availability dataaccess.RagStatus
+		meets        map[int]bool
+	}
+	var pool []candidate
+	for _, p := range candidates {
+		c := candidate{profile: p, availability: p.AvailabilityAt(at), meets: make(map[int]bool)}
+		if c.availability < minimum {
+			continue
+		}
+		for i, r := range requirements {
+			if meets(p, r) {
+				c.meets[i] = true
+			}
+		}
+		if len(c.meets) > 0 {
+			pool = append(pool, c)
+		}
+	}
+	sort.SliceStable(pool, func(i, j int) bool {
+		if pool[i].availability != pool[j].availability {
+			return pool[i].availability > pool[j].availability
+		}
+		return strings.ToLower(pool[i].profile.EmailAddress) < strings.ToLower(pool[j].profile.EmailAddress)
+	})
+
+	outstanding := make(map[int]bool)
+	for i := range requirements {
+		outstanding[i] = true
+	}
+	var picked []candidate
+	for len(outstanding) > 0 {
+		best, bestCount := -1, 0
+		for i, c := range pool {
+			count := 0
+			for r := range c.meets {
+				if outstanding[r] {
+					count++
+				}
+			}
+			if count > bestCount {
+				best, bestCount = i, count
+			}
+		}
+		if best < 0 {
+			break
+		}
+		for r := range pool[best].meets {
+			delete(outstanding, r)
+		}
+		picked = append(picked, pool[best])
+		pool = append(pool[:best], pool[best+1:]...)
+	}
+
+	// Drop anyone whose requirements are all met by the rest of the team,
+	// starting with the last picked, who tend to contribute least.
+	for i := len(picked) - 1; i >= 0; i-- {
+		redundant := true
+		for r := range picked[i].meets {
+			met := false
+			for j, other := range picked {
+				if j != i && other.meets[r] {
+					met = true
+					break
+				}
+			}
+			if !met {
+				redundant = false
+				break
+			}
+		}
+		if redundant {
+			picked = append(picked[:i], picked[i+1:]...)
+		}
+	}
+
+	team := Team{Members: []Member{}, Uncovered: []dataaccess.SkillRequirement{}}
+	covered := make(map[int]bool)
+	for _, c := range picked {
+		m := Member{
+			EmailAddress: c.profile.EmailAddress,
+			Name:         c.profile.Name,
+			Availability: c.availability,
+			Covers:       []string{},
+		}
+		for i, r := range requirements {
+			if c.meets[i] && !covered[i] {
+				covered[i] = true
+				m.Covers = append(m.Covers, r.Skill)
+			}
+		}
+		team.Members = append(team.Members, m)
+	}
+	for i := range outstanding {
+		team.Uncovered = append(team.Uncovered, requirements[i])
+	}
+	sort.Slice(team.Uncovered, func(i, j int) bool { return team.Uncovered[i].Skill < team.Uncovered[j].Skill })
+	return team
+}
+
+// merge combines requirements for the same skill, keeping the highest level.
+func merge(requirements []dataaccess.SkillRequirement) []dataaccess.SkillRequirement {
+	levels := make(map[string]dataaccess.DreyfusLevel)
+	var skills []string
+	for _, r := range requirements {
+		skill := dataaccess.CleanTag(r.Skill)
+		if skill == "" {
+			continue
+		}
+		if l, ok := levels[skill]; !ok || r.Level > l {
+			if !ok {
+				skills = append(skills, skill)
+			}
+			levels[skill] = r.Level
+		}
+	}
+	op := make([]dataaccess.SkillRequirement, len(skills))
+	for i, s := range skills {
+		op[i] = dataaccess.SkillRequirement{Skill: s, Level: levels[s]}
+	}
+	return op
+}
+
+func meets(p dataaccess.Profile, r dataaccess.SkillRequirement) bool {
+	for _, s := range p.Skills {
+		if s.Skill == r.Skill && s.Level >= r.Level {
+			return true
+		}
+	}
+	return false
+}
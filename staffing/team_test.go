@@ -0,0 +1,60 @@
+package staffing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+func profile(emailAddress string, availability dataaccess.RagStatus, skills ...dataaccess.Skill) dataaccess.Profile {
+	return dataaccess.Profile{EmailAddress: emailAddress, Availability: availability, Skills: skills}
+}
+
+func skill(name string, level dataaccess.DreyfusLevel) dataaccess.Skill {
+	return dataaccess.Skill{Skill: name, Level: level}
+}
+
+func TestThatTheSmallestCoveringTeamIsSuggested(t *testing.T) {
+	candidates := []dataaccess.Profile{
+		profile("go@github.com", dataaccess.Green, skill("go", 4)),
+		profile("sql@github.com", dataaccess.Green, skill("sql", 3)),
+		profile("both@github.com", dataaccess.Amber, skill("go", 3), skill("sql", 4)),
+		profile("docker@github.com", dataaccess.Green, skill("docker", 2), skill("go", 1)),
+	}
+	requirements := []dataaccess.SkillRequirement{{Skill: "go", Level: 3}, {Skill: "sql", Level: 3}, {Skill: "docker", Level: 2}}
+
+	team := Suggest(requirements, candidates, dataaccess.Amber, time.Now())
+
+	if len(team.Members) != 2 || team.Members[0].EmailAddress != "both@github.com" || team.Members[1].EmailAddress != "docker@github.com" {
+		t.Fatalf("Expected both@github.com and docker@github.com to be suggested, but received %v", team.Members)
+	}
+	if len(team.Members[1].Covers) != 1 || team.Members[1].Covers[0] != "docker" {
+		t.Errorf("Expected docker@github.com to cover docker, but covered %v", team.Members[1].Covers)
+	}
+	if len(team.Uncovered) != 0 {
+		t.Errorf("Expected every requirement to be covered, but %v weren't.", team.Uncovered)
+	}
+}
+
+func TestThatUnavailablePeopleAreNotSuggested(t *testing.T) {
+	now := time.Date(2017, time.March, 8, 12, 0, 0, 0, time.UTC)
+	onHoliday := profile("holiday@github.com", dataaccess.Green, skill("go", 5), skill("sql", 5))
+	onHoliday.AvailabilityWindows = []dataaccess.AvailabilityWindow{
+		{Start: now.Add(-time.Hour), End: now.Add(time.Hour), Availability: dataaccess.Red},
+	}
+	candidates := []dataaccess.Profile{
+		onHoliday,
+		profile("busy@github.com", dataaccess.Red, skill("sql", 5)),
+		profile("go@github.com", dataaccess.Amber, skill("go", 3)),
+	}
+
+	team := Suggest([]dataaccess.SkillRequirement{{Skill: "go", Level: 3}, {Skill: "sql", Level: 2}, {Skill: "go", Level: 2}}, candidates, dataaccess.Amber, now)
+
+	if len(team.Members) != 1 || team.Members[0].EmailAddress != "go@github.com" {
+		t.Errorf("Expected only go@github.com to be suggested, but received %v", team.Members)
+	}
+	if len(team.Uncovered) != 1 || team.Uncovered[0].Skill != "sql" {
+		t.Errorf("Expected sql to be uncovered, but received %v", team.Uncovered)
+	}
+}
@@ -0,0 +1,237 @@
+// Package summaries has a large language model write a two sentence summary
+// of what each person can do, from the skills, projects and certifications
+// on their profile. Summaries are only written for tenants which enable
+// them, and people who consent, since their details are sent to the model.
+package summaries
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+	"github.com/a-h/pill/i18n"
+	"github.com/a-h/pill/llm"
+)
+
+// ErrEmptySummary is returned when the language model doesn't write a
+// summary.
+var ErrEmptySummary = errors.New("summaries: the language model returned an empty summary")
+
+// monthFormat is the format of the dates of projects and certifications.
+const monthFormat = "2006-01"
+
+// Details lists the skills, projects and certifications on the profile, as
+// the text the summary is written from. It's empty if there are none.
+// Projects are the ones the person has been booked on before the time.
+func Details(p dataaccess.Profile, completions []dataaccess.CourseCompletion, projects []dataaccess.Project, at time.Time) string {
+	var lines []string
+
+	skills := append([]dataaccess.Skill{}, p.Skills...)
+	sort.SliceStable(skills, func(i, j int) bool {
+		if skills[i].Level != skills[j].Level {
+			return skills[i].Level > skills[j].Level
+		}
+		return skills[i].Skill < skills[j].Skill
+	})
+	for _, s := range skills {
+		level := strings.ToLower(i18n.Translate("en", "level."+strconv.Itoa(int(s.Level))))
+		lines = append(lines, fmt.Sprintf("Skill: %s (%s)", s.Skill, level))
+	}
+
+	clients := make(map[string]string)
+	for _, project := range projects {
+		clients[project.ID] = project.Client
+	}
+	bookings := append([]dataaccess.Booking{}, p.Bookings...)
+	sort.SliceStable(bookings, func(i, j int) bool { return bookings[i].Start.Before(bookings[j].Start) })
+	for _, b := range bookings {
+		if !b.Start.Before(at) {
+			continue
+		}
+		line := "Project: " + b.Project
+		if client := clients[b.ProjectID]; client != "" {
+			line += " for " + client
+		}
+		lines = append(lines, line+fmt.Sprintf(" (%s to %s)", b.Start.Format(monthFormat), b.End.Format(monthFormat)))
+	}
+
+	for _, cc := range completions {
+		if strings.EqualFold(cc.EmailAddress, p.EmailAddress) && cc.Status == dataaccess.CompletionApproved {
+			lines = append(lines, fmt.Sprintf("Certification: %s (%s)", cc.Course, cc.Completed.Format(monthFormat)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// source identifies the details, and the language the summary is written
+// in, so that a summary is only written again when they change.
+func source(details string, language string) string {
+	h := sha256.Sum256([]byte(language + "\n" + details))
+	return hex.EncodeToString(h[:])
+}
+
+// languageOf returns the language the person's summary is written in.
+func languageOf(p dataaccess.Profile) string {
+	if p.Language == "" {
+		return "en"
+	}
+	return p.Language
+}
+
+const instructions = `You write the summary shown at the top of a person's skills profile, so that colleagues can see what they can do at a glance.
+Write exactly two sentences, in the third person, from the skills, projects and certifications you're given. Don't use their name, and don't mention anything you aren't given.
+Write in the language with the ISO 639-1 code %s. Reply with only the summary.`
+
+// A Summarizer writes the summaries of profiles with a language model.
+type Summarizer struct {
+	DataAccess dataaccess.DataAccess
+	Provider   llm.Provider
+	now        func() time.Time
+}
+
+// NewSummarizer creates a Summarizer which writes summaries with the
+// provider.
+func NewSummarizer(da dataaccess.DataAccess, provider llm.Provider) *Summarizer {
+	return &Summarizer{da, provider, time.Now}
+}
+
+// Summarize has the language model write the summary of the details, in the
+// person's language.
+func (s Summarizer) Summarize(ctx context.Context, p dataaccess.Profile, details string) (dataaccess.ProfileSummary, error) {
+	language := languageOf(p)
+	completion, err := s.Provider.Complete(ctx, fmt.Sprintf(instructions, language), details)
+	if err != nil {
+		return dataaccess.ProfileSummary{}, err
+	}
+	summary := strings.Trim(strings.TrimSpace(completion), `"`)
+	if summary == "" {
+		return dataaccess.ProfileSummary{}, ErrEmptySummary
+	}
+	return dataaccess.ProfileSummary{
+		EmailAddress: strings.ToLower(p.EmailAddress),
+		Domain:       dataaccess.GetDomain(p.EmailAddress),
+		Summary:      summary,
+		Source:       source(details, language),
+		Generated:    s.now().UTC(),
+	}, nil
+}
+
+// Update writes the summaries in the domain which are missing, out of date,
+// or have been asked to be written again, and removes those of people who
+// no longer have a profile, details or consent. It returns the number of
+// summaries written.
+func (s Summarizer) Update(ctx context.Context, domain string) (int, error) {
+	da := dataaccess.WithContext(s.DataAccess, ctx)
+	settings, err := da.GetSettings(domain)
+	if err != nil {
+		return 0, err
+	}
+	if !settings.Summaries.Enabled {
+		return 0, nil
+	}
+	profiles, err := da.ListProfiles("@" + domain)
+	if err != nil {
+		return 0, err
+	}
+	completions, err := da.ListCourseCompletions(domain)
+	if err != nil {
+		return 0, err
+	}
+	projects, err := da.ListProjects(domain)
+	if err != nil {
+		return 0, err
+	}
+	summaries, err := da.ListProfileSummaries(domain)
+	if err != nil {
+		return 0, err
+	}
+	existing := make(map[string]dataaccess.ProfileSummary)
+	for _, summary := range summaries {
+		existing[summary.EmailAddress] = summary
+	}
+
+	var written int
+	var lastErr error
+	for _, p := range profiles {
+		if ctx.Err() != nil {
+			return written, ctx.Err()
+		}
+		emailAddress := strings.ToLower(p.EmailAddress)
+		previous, found := existing[emailAddress]
+		delete(existing, emailAddress)
+
+		details := Details(p, completions, projects, s.now())
+		if details == "" || !p.Consented(dataaccess.ConsentSummaries, settings.Consent) {
+			if found {
+				if err := da.RemoveProfileSummary(emailAddress); err != nil {
+					return written, err
+				}
+			}
+			continue
+		}
+		if found && !previous.Regenerate && previous.Source == source(details, languageOf(p)) {
+			continue
+		}
+
+		summary, err := s.Summarize(ctx, p, details)
+		if err != nil {
+			log.Printf("Failed to summarise the profile of %s. %v", emailAddress, err)
+			lastErr = err
+			continue
+		}
+		if err := da.SaveProfileSummary(&summary); err != nil {
+			return written, err
+		}
+		written++
+	}
+	for emailAddress := range existing {
+		if err := da.RemoveProfileSummary(emailAddress); err != nil {
+			return written, err
+		}
+	}
+	return written, lastErr
+}
+
+// A Job keeps the summaries of every tenant which enables them up to date.
+type Job struct {
+	Summarizer *Summarizer
+}
+
+// NewJob creates an instance of the Job.
+func NewJob(da dataaccess.DataAccess, provider llm.Provider) *Job {
+	return &Job{NewSummarizer(da, provider)}
+}
+
+// Run updates the summaries of each tenant. Failures are logged, so that one
+// tenant doesn't prevent the rest being updated, and the last error is
+// returned.
+func (j *Job) Run(ctx context.Context) error {
+	domains, err := j.Summarizer.DataAccess.ListDomains()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		n, err := j.Summarizer.Update(ctx, domain)
+		if err != nil {
+			log.Printf("Failed to update the profile summaries of %s. %v", domain, err)
+			lastErr = err
+		}
+		if n > 0 {
+			log.Printf("Wrote %d profile summaries in %s.", n, domain)
+		}
+	}
+	return lastErr
+}
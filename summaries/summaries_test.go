@@ -0,0 +1,135 @@
+package summaries
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a-h/pill/dataaccess"
+)
+
+var august = time.Date(2017, time.August, 14, 0, 0, 0, 0, time.UTC)
+
+type summaryStore struct {
+	dataaccess.DataAccess
+	settings  dataaccess.Settings
+	profiles  []dataaccess.Profile
+	summaries map[string]dataaccess.ProfileSummary
+}
+
+func (s *summaryStore) GetSettings(domain string) (dataaccess.Settings, error) {
+	return s.settings, nil
+}
+
+func (s *summaryStore) ListProfiles(emailAddress string) ([]dataaccess.Profile, error) {
+	return s.profiles, nil
+}
+
+func (s *summaryStore) ListCourseCompletions(domain string) ([]dataaccess.CourseCompletion, error) {
+	return []dataaccess.CourseCompletion{
+		{EmailAddress: "a@github.com", Course: "Go in Action", Status: dataaccess.CompletionApproved, Completed: august},
+		{EmailAddress: "a@github.com", Course: "Declined", Status: dataaccess.CompletionDeclined, Completed: august},
+	}, nil
+}
+
+func (s *summaryStore) ListProjects(domain string) ([]dataaccess.Project, error) {
+	return []dataaccess.Project{{ID: "p1", Client: "Acme"}}, nil
+}
+
+func (s *summaryStore) ListProfileSummaries(domain string) ([]dataaccess.ProfileSummary, error) {
+	var op []dataaccess.ProfileSummary
+	for _, summary := range s.summaries {
+		op = append(op, summary)
+	}
+	return op, nil
+}
+
+func (s *summaryStore) SaveProfileSummary(summary *dataaccess.ProfileSummary) error {
+	s.summaries[summary.EmailAddress] = *summary
+	return nil
+}
+
+func (s *summaryStore) RemoveProfileSummary(emailAddress string) error {
+	delete(s.summaries, emailAddress)
+	return nil
+}
+
+type countingProvider struct {
+	prompts []string
+}
+
+func (p *countingProvider) Complete(ctx context.Context, system string, prompt string) (string, error) {
+	p.prompts = append(p.prompts, prompt)
+	return ` "Builds services in Go. Recently delivered the payments platform for Acme." `, nil
+}
+
+func TestThatTheDetailsIncludeSkillsProjectsAndCertifications(t *testing.T) {
+	p := dataaccess.Profile{
+		EmailAddress: "a@github.com",
+		Skills:       []dataaccess.Skill{{Skill: "sql", Level: 2}, {Skill: "go", Level: 4}},
+		Bookings: []dataaccess.Booking{
+			{Project: "Payments", ProjectID: "p1", Start: august.AddDate(0, -3, 0), End: august.AddDate(0, 1, 0)},
+			{Project: "Future", Start: august.AddDate(0, 1, 0), End: august.AddDate(0, 2, 0)},
+		},
+	}
+	s := &summaryStore{}
+	completions, _ := s.ListCourseCompletions("github.com")
+	projects, _ := s.ListProjects("github.com")
+
+	actual := Details(p, completions, projects, august)
+	expected := "Skill: go (expert)\nSkill: sql (competent)\nProject: Payments for Acme (2017-05 to 2017-09)\nCertification: Go in Action (2017-08)"
+	if actual != expected {
+		t.Errorf("Expected details\n%s\nbut received\n%s", expected, actual)
+	}
+}
+
+func TestThatSummariesAreOnlyWrittenWhenEnabledAndOutOfDate(t *testing.T) {
+	s := &summaryStore{
+		profiles: []dataaccess.Profile{
+			{EmailAddress: "a@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 4}}},
+			{EmailAddress: "withdrawn@github.com", Skills: []dataaccess.Skill{{Skill: "go", Level: 4}},
+				Consents: []dataaccess.Consent{{Purpose: dataaccess.ConsentSummaries, Given: false}}},
+			{EmailAddress: "empty@github.com"},
+		},
+		summaries: map[string]dataaccess.ProfileSummary{
+			"withdrawn@github.com": {EmailAddress: "withdrawn@github.com", Summary: "Old."},
+			"gone@github.com":      {EmailAddress: "gone@github.com", Summary: "Old."},
+		},
+	}
+	provider := &countingProvider{}
+	summarizer := NewSummarizer(s, provider)
+	summarizer.now = func() time.Time { return august }
+
+	if n, err := summarizer.Update(context.Background(), "github.com"); err != nil || n != 0 || len(provider.prompts) != 0 {
+		t.Fatalf("Expected nothing to be sent to the model while summaries are disabled, but %d were written. %v", n, err)
+	}
+
+	s.settings.Summaries.Enabled = true
+	if n, err := summarizer.Update(context.Background(), "github.com"); err != nil || n != 1 {
+		t.Fatalf("Expected one summary to be written, but %d were. %v", n, err)
+	}
+	if len(s.summaries) != 1 {
+		t.Errorf("Expected the summaries of the withdrawn and removed profiles to be removed, but received %v", s.summaries)
+	}
+	summary := s.summaries["a@github.com"]
+	if summary.Summary != "Builds services in Go. Recently delivered the payments platform for Acme." || !summary.Generated.Equal(august) {
+		t.Errorf("Unexpected summary %+v", summary)
+	}
+	if strings.Contains(strings.Join(provider.prompts, "\n"), "withdrawn") {
+		t.Error("Expected the details of the withdrawn profile not to be sent to the model.")
+	}
+
+	if n, _ := summarizer.Update(context.Background(), "github.com"); n != 0 {
+		t.Errorf("Expected the unchanged summary not to be written again, but %d were.", n)
+	}
+	summary.Regenerate = true
+	s.summaries["a@github.com"] = summary
+	if n, _ := summarizer.Update(context.Background(), "github.com"); n != 1 || s.summaries["a@github.com"].Regenerate {
+		t.Errorf("Expected the flagged summary to be written again, but %d were.", n)
+	}
+	s.profiles[0].Skills[0].Level = 5
+	if n, _ := summarizer.Update(context.Background(), "github.com"); n != 1 {
+		t.Errorf("Expected the summary to be written again when the skills change, but %d were.", n)
+	}
+}